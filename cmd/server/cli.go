@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// newRootCmd arma la CLI: sin comando levanta el servidor (como `serve`)
+func newRootCmd() *cobra.Command {
+	serve := conServicio("Server", func(logger *zap.Logger, cfg *config.Config) error {
+		runServe(logger, cfg)
+		return nil
+	})
+
+	root := &cobra.Command{
+		Use:          "stock-service",
+		Short:        "Servicio de stock, POS y bodegas",
+		Version:      fmt.Sprintf("%s (commit %s, compilado %s)", version, commit, buildDate),
+		Args:         cobra.NoArgs,
+		Run:          serve,
+		SilenceUsage: true,
+	}
+	root.SetVersionTemplate("stock-service {{.Version}}\n")
+	root.CompletionOptions.DisableDefaultCmd = true
+
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "serve",
+			Short: "Levanta el servidor HTTP (por defecto si no se indica comando)",
+			Args:  cobra.NoArgs,
+			Run:   serve,
+		},
+		&cobra.Command{
+			Use:   "edge",
+			Short: "Levanta el modo edge: réplica local del local EDGE_LOCAL_ID sincronizada desde EDGE_CENTRAL_URL",
+			Args:  cobra.NoArgs,
+			Run:   conServicio("Edge mode", runEdge),
+		},
+		newMigrateCmd(),
+		newWarmCacheCmd(),
+		newAuditStockCmd(),
+		newSeedCmd(),
+		newCreateUserCmd(),
+		newCheckConfigCmd(),
+		&cobra.Command{
+			Use:   "version",
+			Short: "Muestra la versión del binario",
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				fmt.Printf("stock-service %s (commit %s, compilado %s)\n", version, commit, buildDate)
+			},
+		},
+	)
+
+	return root
+}
+
+// newMigrateCmd migrate up|down|status
+func newMigrateCmd() *cobra.Command {
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "Aplica, revierte o lista las migraciones del esquema",
+	}
+
+	var steps int
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Revierte las últimas migraciones aplicadas (--steps N)",
+		Args:  cobra.NoArgs,
+		Run: conServicio("Migration", func(logger *zap.Logger, cfg *config.Config) error {
+			return runMigrate(logger, cfg, "down", steps)
+		}),
+	}
+	down.Flags().IntVar(&steps, "steps", 1, "cantidad de migraciones a revertir")
+
+	migrate.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "Aplica las migraciones pendientes",
+			Args:  cobra.NoArgs,
+			Run: conServicio("Migration", func(logger *zap.Logger, cfg *config.Config) error {
+				return runMigrate(logger, cfg, "up", 0)
+			}),
+		},
+		down,
+		&cobra.Command{
+			Use:   "status",
+			Short: "Lista las migraciones y si están aplicadas",
+			Args:  cobra.NoArgs,
+			Run: conServicio("Migration", func(logger *zap.Logger, cfg *config.Config) error {
+				return runMigrate(logger, cfg, "status", 0)
+			}),
+		},
+	)
+
+	return migrate
+}
+
+// newWarmCacheCmd warm-cache [--limit N]
+func newWarmCacheCmd() *cobra.Command {
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "warm-cache",
+		Short: "Pre-carga en la caché del POS los productos frecuentes",
+		Args:  cobra.NoArgs,
+		Run: conServicio("Cache warm-up", func(logger *zap.Logger, cfg *config.Config) error {
+			return runWarmCache(logger, cfg, limit)
+		}),
+	}
+	cmd.Flags().IntVar(&limit, "limit", 500, "cantidad máxima de productos a pre-cargar")
+	return cmd
+}
+
+// newAuditStockCmd audit-stock [--local N] [--codigo X] [--corregir --usuario N]
+// Sale con 0 si no quedan descuadres sin corregir, 1 si los hay y 2 si falló
+func newAuditStockCmd() *cobra.Command {
+	var opts auditStockOptions
+	cmd := &cobra.Command{
+		Use:   "audit-stock",
+		Short: "Compara el stock con los movimientos y opcionalmente corrige los descuadres",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			logger := configureLogger()
+			code := runAuditStock(logger, loadConfig(logger), opts)
+			logger.Sync()
+			os.Exit(code)
+		},
+	}
+	cmd.Flags().IntVar(&opts.local, "local", 0, "local a auditar (0: todos)")
+	cmd.Flags().StringVar(&opts.codigo, "codigo", "", "producto a auditar (vacío: todos)")
+	cmd.Flags().BoolVar(&opts.corregir, "corregir", false, "registrar un movimiento de ajuste por cada descuadre")
+	cmd.Flags().IntVar(&opts.usuario, "usuario", 0, "usuario que registra los ajustes (requerido con --corregir)")
+	return cmd
+}
+
+// newSeedCmd seed --demo [--dias N] [--reset] | seed --clean
+func newSeedCmd() *cobra.Command {
+	var opts seedOptions
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Genera o borra los datos de demostración",
+		Args:  cobra.NoArgs,
+		Run: conServicio("Seed", func(logger *zap.Logger, cfg *config.Config) error {
+			return runSeed(logger, cfg, opts)
+		}),
+	}
+	cmd.Flags().BoolVar(&opts.demo, "demo", false, "generar locales, productos, stock e historial de demostración")
+	cmd.Flags().BoolVar(&opts.clean, "clean", false, "borrar los datos de demostración")
+	cmd.Flags().BoolVar(&opts.reset, "reset", false, "regenerar aunque ya existan datos de demostración")
+	cmd.Flags().IntVar(&opts.dias, "dias", 90, "días de historial de movimientos a simular")
+	cmd.MarkFlagsOneRequired("demo", "clean")
+	cmd.MarkFlagsMutuallyExclusive("demo", "clean")
+	return cmd
+}
+
+// newCreateUserCmd create-user --username X [--rol R] [--email E] [--password P]
+func newCreateUserCmd() *cobra.Command {
+	var opts createUserOptions
+	cmd := &cobra.Command{
+		Use:   "create-user",
+		Short: "Crea un usuario (la contraseña se lee de stdin si no se indica)",
+		Args:  cobra.NoArgs,
+		Run: conServicio("Create user", func(logger *zap.Logger, cfg *config.Config) error {
+			return runCreateUser(logger, cfg, opts)
+		}),
+	}
+	cmd.Flags().StringVar(&opts.username, "username", "", "username del usuario")
+	cmd.Flags().StringVar(&opts.email, "email", "", "email del usuario (opcional)")
+	cmd.Flags().StringVar(&opts.rol, "rol", models.RolAdmin, "rol: admin, bodeguero o vendedor")
+	cmd.Flags().StringVar(&opts.password, "password", "", "contraseña (vacío: se lee de la entrada estándar)")
+	return cmd
+}
+
+// newCheckConfigCmd check-config [--connect]
+// Sale con 0 si la configuración es válida (y accesible con --connect) y 1 si no
+func newCheckConfigCmd() *cobra.Command {
+	var connect bool
+	cmd := &cobra.Command{
+		Use:   "check-config",
+		Short: "Valida la configuración",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Exit(runCheckConfig(connect))
+		},
+	}
+	cmd.Flags().BoolVar(&connect, "connect", false, "probar también la conexión a PostgreSQL y Redis")
+	return cmd
+}
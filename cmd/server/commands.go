@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"stock-service/internal/cache"
+	"stock-service/internal/config"
+	"stock-service/internal/database"
+	"stock-service/internal/migrations"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+	"stock-service/internal/seed"
+	"stock-service/internal/services"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// runMigrate aplica o revierte las migraciones del esquema
+// migrate up | migrate down [--steps N] | migrate status
+func runMigrate(logger *zap.Logger, cfg *config.Config, action string, steps int) error {
+	postgresDB := connectPostgres(logger, cfg)
+	defer postgresDB.Close()
+
+	migrator := migrations.NewMigrator(postgresDB.DB, logger)
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("El esquema está al día")
+		}
+		for _, m := range applied {
+			fmt.Printf("aplicada  %04d_%s\n", m.Version, m.Name)
+		}
+	case "down":
+		if steps < 1 {
+			return fmt.Errorf("--steps debe ser al menos 1")
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No hay migraciones aplicadas para revertir")
+		}
+		for _, m := range reverted {
+			fmt.Printf("revertida %04d_%s\n", m.Version, m.Name)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			estado := "pendiente"
+			if s.AppliedAt != nil {
+				estado = "aplicada " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, estado)
+		}
+	default:
+		return fmt.Errorf("acción desconocida %q: use migrate up|down|status", action)
+	}
+
+	return nil
+}
+
+// runWarmCache pre-carga en L1/L2 los productos disponibles para venta
+func runWarmCache(logger *zap.Logger, cfg *config.Config, limit int) error {
+	postgresDB := connectPostgres(logger, cfg)
+	defer postgresDB.Close()
+	redisDB := connectRedis(logger, cfg)
+	defer redisDB.Close()
+
+	productRepo, err := repository.NewProductRepository(postgresDB.DB, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create product repository: %w", err)
+	}
+	productCache := cache.NewProductCache(redisDB.Client, cfg.Cache.L1MaxSize, cfg.Cache.TTL, logger)
+	productCache.SetL2Format(cfg.Cache.L2Format, cfg.Cache.L2CompressMinBytes)
+
+	ctx := context.Background()
+	productos, err := productRepo.GetProductosFrecuentes(ctx, limit)
+	if err != nil {
+		return err
+	}
+
+	// La caché se indexa por código de barras: un producto puede tener interno y externo
+	cargados := 0
+	for _, producto := range productos {
+		for _, barcode := range []*string{producto.CodigoBarraExterno, producto.CodigoBarraInterno} {
+			if barcode == nil || *barcode == "" {
+				continue
+			}
+			if err := productCache.SetProduct(ctx, *barcode, producto); err != nil {
+				return fmt.Errorf("error cacheando %s: %w", *barcode, err)
+			}
+			cargados++
+		}
+	}
+
+	fmt.Printf("Productos pre-cargados: %d (%d códigos de barras)\n", len(productos), cargados)
+	return nil
+}
+
+// auditStockOptions opciones de audit-stock
+type auditStockOptions struct {
+	local    int
+	codigo   string
+	corregir bool
+	usuario  int
+}
+
+// runAuditStock audita el stock contra los movimientos (pensado para ejecutarse como job)
+// audit-stock [--local N] [--codigo X] [--corregir --usuario N]
+// Retorna el código de salida: 0 si no quedan descuadres sin corregir, 1 si los hay, 2 si falló
+func runAuditStock(logger *zap.Logger, cfg *config.Config, opts auditStockOptions) int {
+	if opts.corregir && opts.usuario < 1 {
+		fmt.Fprintln(os.Stderr, "--usuario es requerido con --corregir")
+		return 2
+	}
+
+	postgresDB := connectPostgres(logger, cfg)
+	defer postgresDB.Close()
+	redisDB := connectRedis(logger, cfg)
+	defer redisDB.Close()
+
+	stockRepo, err := repository.NewStockRepository(postgresDB.DB)
+	if err != nil {
+		logger.Error("Failed to create stock repository", zap.Error(err))
+		return 2
+	}
+	productRepo, err := repository.NewProductRepository(postgresDB.DB, logger)
+	if err != nil {
+		logger.Error("Failed to create product repository", zap.Error(err))
+		return 2
+	}
+	invalidationQueue := cache.NewInvalidationQueue(redisDB.Client, cfg.Cache.InvalidationRetryInterval, cfg.Cache.InvalidationMaxAttempts, logger)
+	stockService := services.NewStockService(stockRepo, productRepo, redisDB.Client, invalidationQueue, nil, cfg.Webhooks, logger)
+
+	req := &models.AuditoriaStockRequest{Corregir: opts.corregir, IDUsuario: opts.usuario}
+	if opts.local > 0 {
+		req.IDLocal = &opts.local
+	}
+	if opts.codigo != "" {
+		req.CodigoProducto = &opts.codigo
+	}
+
+	auditoria, err := stockService.AuditarStock(context.Background(), req)
+	if err != nil {
+		logger.Error("Stock audit failed", zap.Error(err))
+		return 2
+	}
+
+	for _, d := range auditoria.Descuadres {
+		estado := ""
+		switch {
+		case d.IDMovimientoAjuste != nil:
+			estado = fmt.Sprintf("ajustado (movimiento %d)", *d.IDMovimientoAjuste)
+		case d.Omitido != "":
+			estado = "omitido: " + d.Omitido
+		}
+		cuadre := "ninguno"
+		if d.UltimoMovimientoCuadre != nil {
+			cuadre = fmt.Sprintf("%d (%s)", d.UltimoMovimientoCuadre.ID, d.UltimoMovimientoCuadre.CreatedAt.Format(time.RFC3339))
+		}
+		fmt.Printf("local %-4d %-20s actual %6d  teórico %6d  diferencia %+6d  último cuadre %s %s\n",
+			d.IDLocal, d.CodigoProducto, d.CantidadActual, d.CantidadTeorica, d.Diferencia, cuadre, estado)
+	}
+	fmt.Printf("Descuadres: %d, corregidos: %d\n", auditoria.TotalDescuadres, auditoria.Corregidos)
+
+	if auditoria.TotalDescuadres > auditoria.Corregidos {
+		return 1
+	}
+	return 0
+}
+
+// seedOptions opciones de seed (--demo y --clean son excluyentes)
+type seedOptions struct {
+	demo  bool
+	clean bool
+	reset bool
+	dias  int
+}
+
+// runSeed genera o borra los datos de demostración
+// seed --demo [--dias N] [--reset] | seed --clean
+func runSeed(logger *zap.Logger, cfg *config.Config, opts seedOptions) error {
+	if opts.dias < 1 {
+		return fmt.Errorf("--dias debe ser al menos 1")
+	}
+
+	postgresDB := connectPostgres(logger, cfg)
+	defer postgresDB.Close()
+
+	seeder := seed.NewSeeder(postgresDB.DB, logger)
+	ctx := context.Background()
+
+	if opts.clean {
+		resumen, err := seeder.Clean(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Datos demo borrados: %d locales, %d productos, %d packs, %d movimientos\n",
+			resumen.Locales, resumen.Productos, resumen.Packs, resumen.Movimientos)
+		return nil
+	}
+
+	resumen, err := seeder.Seed(ctx, seed.Options{Dias: opts.dias, Reset: opts.reset})
+	if err != nil {
+		return err
+	}
+	if resumen.Omitido {
+		fmt.Println("Ya existen datos demo; use --reset para regenerarlos")
+		return nil
+	}
+	fmt.Printf("Datos demo generados: %d locales, %d productos, %d packs, %d movimientos (%d ventas)\n",
+		resumen.Locales, resumen.Productos, resumen.Packs, resumen.Movimientos, resumen.Ventas)
+	return nil
+}
+
+// createUserOptions opciones de create-user
+type createUserOptions struct {
+	username string
+	email    string
+	rol      string
+	password string
+}
+
+// runCreateUser crea un usuario (ej. el primer admin, antes de que exista alguien que pueda crearlo por la API)
+// Sin --password la contraseña se lee de la entrada estándar, para que no quede en el historial
+func runCreateUser(logger *zap.Logger, cfg *config.Config, opts createUserOptions) error {
+	if opts.password == "" {
+		fmt.Fprint(os.Stderr, "Contraseña: ")
+		linea, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("error leyendo contraseña: %w", err)
+		}
+		opts.password = strings.TrimRight(linea, "\r\n")
+	}
+
+	req := &models.CrearUsuarioRequest{
+		Username: opts.username,
+		Email:    opts.email,
+		Password: opts.password,
+		Rol:      opts.rol,
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return fmt.Errorf("datos inválidos: %w", err)
+	}
+
+	postgresDB := connectPostgres(logger, cfg)
+	defer postgresDB.Close()
+
+	userRepo, err := repository.NewUserRepository(postgresDB.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create user repository: %w", err)
+	}
+	usuario, err := services.NewUsuarioService(userRepo, logger).CrearUsuario(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Usuario creado: %s (id %d, rol %s)\n", usuario.Username, usuario.ID, usuario.Rol)
+	return nil
+}
+
+// runCheckConfig valida la configuración y, con --connect, la conectividad a PostgreSQL y Redis
+// Retorna el código de salida: 0 si todo está bien, 1 si hay problemas
+func runCheckConfig(connect bool) int {
+	cfg, err := config.Load()
+	if err != nil {
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			fmt.Fprintln(os.Stderr, "❌ Configuración inválida:")
+			for _, problem := range validationErr.Problems {
+				fmt.Fprintln(os.Stderr, "  - "+problem)
+			}
+			return 1
+		}
+		fmt.Fprintln(os.Stderr, "❌ "+err.Error())
+		return 1
+	}
+	fmt.Println("✅ Configuración válida")
+
+	if !connect {
+		return 0
+	}
+
+	logger := zap.NewNop()
+	ok := true
+
+	postgresDB, err := database.NewPostgresDB(cfg.Database.URL, 1, 1, time.Minute, logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "❌ PostgreSQL: "+err.Error())
+		ok = false
+	} else {
+		postgresDB.Close()
+		fmt.Println("✅ PostgreSQL accesible")
+	}
+
+	redisDB, err := database.NewRedisDB(cfg.Redis.URL, cfg.Redis.Password, cfg.Redis.DB, logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "❌ Redis: "+err.Error())
+		ok = false
+	} else {
+		redisDB.Close()
+		fmt.Println("✅ Redis accesible")
+	}
+
+	if !ok {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/edge"
+	"stock-service/internal/handlers"
+	"stock-service/internal/middleware"
+	"stock-service/internal/routes"
+	"stock-service/internal/server"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// runEdge levanta el servidor en modo edge: réplica local del catálogo y stock del local,
+// sin PostgreSQL ni Redis (todo lo que no se resuelve localmente se reenvía al central)
+func runEdge(logger *zap.Logger, cfg *config.Config) error {
+	logger.Info("Initializing Stock Service in edge mode...",
+		zap.String("central", cfg.Edge.CentralURL),
+		zap.Int("id_local", cfg.Edge.LocalID))
+
+	gin.SetMode(cfg.Server.GinMode)
+
+	node, err := edge.New(cfg.Edge, logger)
+	if err != nil {
+		return err
+	}
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	node.Start(workersCtx)
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(middleware.CORSMiddleware())
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.LoggerMiddleware(logger))
+	router.Use(middleware.LegacyResponseMiddleware())
+
+	routes.SetupEdgeRoutes(router, handlers.NewEdgeHandler(node, logger))
+
+	srv := server.New(cfg.Server, router, logger)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		logger.Info("Starting edge server", zap.String("port", cfg.Server.Port), zap.Bool("tls", srv.TLSEnabled()))
+		if err := srv.ListenAndServe(); err != nil {
+			logger.Fatal("Failed to start edge server", zap.Error(err))
+		}
+	}()
+
+	<-quit
+	logger.Info("Shutting down edge server...")
+	stopWorkers()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	logger.Info("Edge server exited")
+	return nil
+}
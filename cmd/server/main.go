@@ -2,157 +2,95 @@ package main
 
 import (
 	"context"
-	"net/http"
+	"errors"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
-	"stock-service/internal/cache"
 	"stock-service/internal/config"
 	"stock-service/internal/database"
-	"stock-service/internal/handlers"
-	"stock-service/internal/middleware"
-	"stock-service/internal/repository"
-	"stock-service/internal/routes"
-	"stock-service/internal/services"
 
-	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// Datos de compilación, inyectados con -ldflags "-X main.version=..."
+var (
+	version   = "dev"
+	commit    = "none"
+	buildDate = "unknown"
+)
+
 func main() {
-	// Configurar logger según ambiente
-	logger := configureLogger()
-	defer logger.Sync()
+	if err := newRootCmd().Execute(); err != nil {
+		// cobra ya mostró el error y el uso
+		os.Exit(2)
+	}
+}
 
-	logger.Info("Initializing Stock Service...")
+// conServicio configura el logger y carga la configuración antes de ejecutar el comando
+// Si el comando falla lo registra y termina el proceso
+func conServicio(operacion string, run func(logger *zap.Logger, cfg *config.Config) error) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		logger := configureLogger()
+		defer logger.Sync()
+
+		if err := run(logger, loadConfig(logger)); err != nil {
+			logger.Fatal(operacion+" failed", zap.Error(err))
+		}
+	}
+}
 
-	// Cargar configuración
+// loadConfig carga la configuración o termina el proceso con el listado de problemas
+func loadConfig(logger *zap.Logger) *config.Config {
 	cfg, err := config.Load()
 	if err != nil {
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			logger.Fatal("Invalid configuration, fix the following settings and restart",
+				zap.Strings("problems", validationErr.Problems))
+		}
 		logger.Fatal("Failed to load configuration", zap.Error(err))
 	}
+	return cfg
+}
 
-	// Configurar modo de Gin
-	gin.SetMode(cfg.Server.GinMode)
-
-	// Conectar a PostgreSQL
-	postgresDB, err := database.NewPostgresDB(
-		cfg.Database.URL,
-		cfg.Database.MaxOpenConns,
-		cfg.Database.MaxIdleConns,
-		cfg.Database.ConnMaxLifetime,
-		logger,
-	)
+// connectPostgres abre el pool de PostgreSQL (con reintentos) o termina el proceso
+func connectPostgres(logger *zap.Logger, cfg *config.Config) *database.PostgresDB {
+	var postgresDB *database.PostgresDB
+	err := database.ConnectWithRetry(context.Background(), cfg.ConnectRetry, "postgres", logger, func() error {
+		var err error
+		postgresDB, err = database.NewPostgresDB(
+			cfg.Database.URL,
+			cfg.Database.MaxOpenConns,
+			cfg.Database.MaxIdleConns,
+			cfg.Database.ConnMaxLifetime,
+			logger,
+		)
+		return err
+	})
 	if err != nil {
 		logger.Fatal("Failed to connect to PostgreSQL", zap.Error(err))
 	}
-	defer postgresDB.Close()
-
-	// Conectar a Redis
-	redisDB, err := database.NewRedisDB(
-		cfg.Redis.URL,
-		cfg.Redis.Password,
-		cfg.Redis.DB,
-		logger,
-	)
-	if err != nil {
-		logger.Fatal("Failed to connect to Redis", zap.Error(err))
-	}
-	defer redisDB.Close()
-
-	// Crear ProductCache para POS
-	productCache := cache.NewProductCache(
-		redisDB.Client,
-		1000,           // Max 1000 productos en L1 cache
-		30*time.Minute, // TTL de 30 minutos
-		logger,
-	)
-
-	// Crear repositories
-	stockRepo, err := repository.NewStockRepository(postgresDB.DB)
-	if err != nil {
-		logger.Fatal("Failed to create stock repository", zap.Error(err))
-	}
+	return postgresDB
+}
 
-	productRepo, err := repository.NewProductRepository(postgresDB.DB, logger)
+// connectRedis abre la conexión a Redis (con reintentos) o termina el proceso
+func connectRedis(logger *zap.Logger, cfg *config.Config) *database.RedisDB {
+	var redisDB *database.RedisDB
+	err := database.ConnectWithRetry(context.Background(), cfg.ConnectRetry, "redis", logger, func() error {
+		var err error
+		redisDB, err = database.NewRedisDB(
+			cfg.Redis.URL,
+			cfg.Redis.Password,
+			cfg.Redis.DB,
+			logger,
+		)
+		return err
+	})
 	if err != nil {
-		logger.Fatal("Failed to create product repository", zap.Error(err))
-	}
-
-	// Crear service
-	stockService := services.NewStockService(stockRepo, productRepo, redisDB.Client, logger)
-
-	// Crear monitoring service
-	monitoringService := services.NewMonitoringService(
-		logger,
-		cfg,
-		redisDB.Client,
-		postgresDB.DB,
-		productCache,
-	)
-
-	// Crear handlers
-	stockHandler := handlers.NewStockHandler(stockService, logger)
-	posHandler := handlers.NewPOSHandler(productCache, stockService, productRepo, logger)
-	monitoringHandler := handlers.NewMonitoringHandler(monitoringService, logger)
-
-	// Crear health checker
-	healthChecker := middleware.NewHealthChecker(postgresDB, redisDB, logger)
-
-	// Configurar router
-	router := gin.New()
-
-	// Middleware global
-	router.Use(gin.Recovery())
-	router.Use(middleware.CORSMiddleware())
-	router.Use(middleware.RequestIDMiddleware())
-	router.Use(middleware.LoggerMiddleware(logger))
-	router.Use(monitoringHandler.RecordRequestMiddleware()) // Middleware de monitoring
-
-	// Configurar rutas
-	routes.SetupRoutes(router, stockHandler, posHandler, monitoringHandler, healthChecker)
-
-	// Configurar servidor
-	srv := &http.Server{
-		Addr:         ":" + cfg.Server.Port,
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
-
-	// Canal para señales de terminación
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-	// Mostrar información del servidor
-	middleware.ServerInfo(cfg.Server.Port, logger)
-
-	// Iniciar servidor en goroutine
-	go func() {
-		logger.Info("Starting server", zap.String("port", cfg.Server.Port))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatal("Failed to start server", zap.Error(err))
-		}
-	}()
-
-	// Esperar señal de terminación
-	<-quit
-	logger.Info("Shutting down server...")
-
-	// Configurar contexto para shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Cerrar servidor gracefulmente
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown", zap.Error(err))
+		logger.Fatal("Failed to connect to Redis", zap.Error(err))
 	}
-
-	logger.Info("Server exited")
+	return redisDB
 }
 
 // configureLogger configura el logger según el ambiente
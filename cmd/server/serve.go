@@ -0,0 +1,461 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"stock-service/internal/auth"
+	"stock-service/internal/cache"
+	"stock-service/internal/config"
+	"stock-service/internal/database"
+	"stock-service/internal/degraded"
+	"stock-service/internal/features"
+	"stock-service/internal/handlers"
+	"stock-service/internal/maintenance"
+	"stock-service/internal/middleware"
+	"stock-service/internal/outbox"
+	"stock-service/internal/quota"
+	"stock-service/internal/repository"
+	"stock-service/internal/routes"
+	"stock-service/internal/server"
+	"stock-service/internal/services"
+	"stock-service/internal/signing"
+	"stock-service/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// runServe levanta el servidor HTTP (comando por defecto)
+func runServe(logger *zap.Logger, cfg *config.Config) {
+	logger.Info("Initializing Stock Service...")
+
+	// Configurar modo de Gin
+	gin.SetMode(cfg.Server.GinMode)
+
+	// Conectar a PostgreSQL
+	postgresDB := connectPostgres(logger, cfg)
+	defer postgresDB.Close()
+
+	// Conectar a Redis
+	redisDB := connectRedis(logger, cfg)
+	defer redisDB.Close()
+
+	// Crear ProductCache para POS
+	productCache := cache.NewProductCache(
+		redisDB.Client,
+		cfg.Cache.L1MaxSize,
+		cfg.Cache.TTL,
+		logger,
+	)
+	productCache.SetCheckInterval(cfg.Cache.VersionCheckInterval)
+	productCache.SetL2Format(cfg.Cache.L2Format, cfg.Cache.L2CompressMinBytes)
+
+	// Invalidaciones de stock con reintentos en background si Redis falla
+	invalidationQueue := cache.NewInvalidationQueue(
+		redisDB.Client,
+		cfg.Cache.InvalidationRetryInterval,
+		cfg.Cache.InvalidationMaxAttempts,
+		logger,
+	)
+
+	// Locks por producto+local entre réplicas (nil: sin serialización)
+	var stockLocker *cache.KeyLocker
+	if cfg.StockLock.Enabled {
+		stockLocker = cache.NewKeyLocker(redisDB.Client, cfg.StockLock.TTL, cfg.StockLock.Wait, logger)
+	}
+
+	// Configuración recargable en caliente (SIGHUP o POST /api/v1/admin/config/reload)
+	configManager := config.NewManager(cfg)
+	featureFlags := features.New(cfg.Features)
+	configManager.OnReload(func(c *config.Config) {
+		productCache.SetCheckInterval(c.Cache.VersionCheckInterval)
+		featureFlags.Replace(c.Features)
+	})
+
+	// Modo mantenimiento (estado compartido entre réplicas vía Redis)
+	maintenanceMode := maintenance.New(redisDB.Client, cfg.Maintenance, logger)
+
+	// Modo lectura de emergencia si PostgreSQL cae (búsquedas solo-cache y ventas encoladas)
+	degradedMonitor := degraded.New(postgresDB.DB, cfg.Degraded, logger)
+
+	// Cuotas por API key (contadores compartidos vía Redis)
+	quotaLimiter := quota.NewLimiter(redisDB.Client, cfg.Quotas)
+
+	// Crear repositories
+	stockRepo, err := repository.NewStockRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create stock repository", zap.Error(err))
+	}
+
+	productRepo, err := repository.NewProductRepository(postgresDB.DB, logger)
+	if err != nil {
+		logger.Fatal("Failed to create product repository", zap.Error(err))
+	}
+
+	// Filtro de existencia de códigos de barras (se carga en segundo plano al iniciar los workers)
+	barcodeFilter := cache.NewBarcodeFilter(productRepo.ListCodigosBarras, cfg.Cache.BarcodeFilterRebuildInterval, logger)
+	productCache.SetBarcodeFilter(barcodeFilter)
+
+	ventaSospechosaRepo, err := repository.NewVentaSospechosaRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create venta sospechosa repository", zap.Error(err))
+	}
+
+	pickingRepo, err := repository.NewPickingRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create picking repository", zap.Error(err))
+	}
+
+	guiaRepo, err := repository.NewGuiaDespachoRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create guia despacho repository", zap.Error(err))
+	}
+
+	folioRepo, err := repository.NewFolioRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create folio repository", zap.Error(err))
+	}
+
+	aprobacionRepo, err := repository.NewAprobacionRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create aprobacion repository", zap.Error(err))
+	}
+
+	precioRepo, err := repository.NewPrecioRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create precio repository", zap.Error(err))
+	}
+
+	reporteRepo, err := repository.NewReporteRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create reporte repository", zap.Error(err))
+	}
+
+	avisoVencimientoRepo, err := repository.NewAvisoVencimientoRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create aviso vencimiento repository", zap.Error(err))
+	}
+
+	bajaVencidosRepo, err := repository.NewBajaVencidosRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create baja vencidos repository", zap.Error(err))
+	}
+
+	reglaOperacionRepo, err := repository.NewReglaOperacionRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create regla operacion repository", zap.Error(err))
+	}
+
+	exportacionERPRepo, err := repository.NewExportacionERPRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create exportacion erp repository", zap.Error(err))
+	}
+
+	claveFirmaRepo, err := repository.NewClaveFirmaRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create clave firma repository", zap.Error(err))
+	}
+
+	ubicacionRepo, err := repository.NewUbicacionRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create ubicacion repository", zap.Error(err))
+	}
+
+	productoCriticoRepo, err := repository.NewProductoCriticoRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create producto critico repository", zap.Error(err))
+	}
+
+	conteoCiclicoRepo, err := repository.NewConteoCiclicoRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create conteo ciclico repository", zap.Error(err))
+	}
+
+	tomaInventarioRepo, err := repository.NewTomaInventarioRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create toma inventario repository", zap.Error(err))
+	}
+
+	notaCreditoRepo, err := repository.NewNotaCreditoRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create nota credito repository", zap.Error(err))
+	}
+
+	escaneoRepo, err := repository.NewEscaneoNoEncontradoRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create escaneo no encontrado repository", zap.Error(err))
+	}
+
+	busquedaRepo, err := repository.NewBusquedaRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create busqueda repository", zap.Error(err))
+	}
+
+	imagenRepo, err := repository.NewImagenRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create imagen repository", zap.Error(err))
+	}
+
+	unidadRepo, err := repository.NewUnidadRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create unidad repository", zap.Error(err))
+	}
+
+	packRepo, err := repository.NewPackRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create pack repository", zap.Error(err))
+	}
+
+	plantillaRepo, err := repository.NewPlantillaRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create plantilla repository", zap.Error(err))
+	}
+
+	botonRepo, err := repository.NewBotonRapidoRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create boton rapido repository", zap.Error(err))
+	}
+
+	ventaRepo, err := repository.NewVentaRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create venta repository", zap.Error(err))
+	}
+
+	canalRepo, err := repository.NewCanalRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create canal repository", zap.Error(err))
+	}
+
+	bloqueoProductoRepo, err := repository.NewBloqueoProductoRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create bloqueo producto repository", zap.Error(err))
+	}
+
+	minimoEstacionalRepo, err := repository.NewMinimoEstacionalRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create minimo estacional repository", zap.Error(err))
+	}
+
+	turnoRepo, err := repository.NewTurnoRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create turno repository", zap.Error(err))
+	}
+
+	userRepo, err := repository.NewUserRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create user repository", zap.Error(err))
+	}
+
+	ecommerceRepo, err := repository.NewEcommerceRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create ecommerce repository", zap.Error(err))
+	}
+
+	outboxRepo, err := repository.NewOutboxRepository(postgresDB.DB)
+	if err != nil {
+		logger.Fatal("Failed to create outbox repository", zap.Error(err))
+	}
+
+	imageStorage, err := storage.New(cfg.Images)
+	if err != nil {
+		logger.Fatal("Failed to create image storage", zap.Error(err))
+	}
+
+	jobStorage, err := storage.NewJobs(cfg.Jobs)
+	if err != nil {
+		logger.Fatal("Failed to create job storage", zap.Error(err))
+	}
+
+	// Crear service
+	stockService := services.NewStockService(stockRepo, productRepo, redisDB.Client, invalidationQueue, stockLocker, cfg.Webhooks, logger)
+	duplicateSaleService := services.NewDuplicateSaleService(ventaSospechosaRepo, redisDB.Client, cfg.Sales, logger)
+	reglaOperacionService := services.NewReglaOperacionService(reglaOperacionRepo, stockRepo, logger)
+	approvalService := services.NewApprovalService(aprobacionRepo, stockRepo, stockService, reglaOperacionService, redisDB.Client, cfg.Approval, logger)
+	precioService := services.NewPrecioService(precioRepo, productCache, logger)
+	ventaService := services.NewVentaService(ventaRepo, cfg.Sales, logger)
+	ventaEncoladaService := services.NewVentaEncoladaService(redisDB.Client, stockService, precioService, ventaService, degradedMonitor, cfg.Degraded, logger)
+	reporteService := services.NewReporteService(reporteRepo, cfg.ReportAggregates, logger)
+	avisoVencimientoService := services.NewAvisoVencimientoService(avisoVencimientoRepo, cfg.ExpiryAlerts, cfg.Webhooks, logger)
+	bajaVencidosService := services.NewBajaVencidosService(bajaVencidosRepo, stockService, cfg.ExpiredLots, cfg.Webhooks, logger)
+	busquedaService := services.NewBusquedaService(busquedaRepo, logger)
+	syncService := services.NewSyncService(productRepo, stockRepo, logger)
+	escaneoService := services.NewEscaneoNoEncontradoService(escaneoRepo, logger)
+	folioService := services.NewFolioService(folioRepo, logger)
+	responseSigner := signing.New(claveFirmaRepo, cfg.ResponseSigning, logger)
+	unidadService := services.NewUnidadService(unidadRepo, stockRepo, logger)
+	packService := services.NewPackService(packRepo, logger)
+	imagenService := services.NewImagenService(imagenRepo, imageStorage, productCache, cfg.Images, logger)
+	pickingService := services.NewPickingService(pickingRepo, stockRepo, ubicacionRepo, stockService, cfg.Picking, logger)
+	ubicacionService := services.NewUbicacionService(ubicacionRepo, stockRepo, logger)
+	guiaService := services.NewGuiaDespachoService(guiaRepo, stockRepo, stockService, cfg.Reception, logger)
+	exportacionERPService := services.NewExportacionERPService(exportacionERPRepo, logger)
+	productoCriticoService := services.NewProductoCriticoService(productoCriticoRepo, stockRepo, cfg.CriticalProducts, logger)
+	conteoCiclicoService := services.NewConteoCiclicoService(conteoCiclicoRepo, cfg.CycleCounts, cfg.PDT, logger)
+	tomaInventarioService := services.NewTomaInventarioService(tomaInventarioRepo, stockRepo, stockService, logger)
+	notaCreditoService := services.NewNotaCreditoService(notaCreditoRepo, stockService, cfg.CreditNotes, logger)
+	botonService := services.NewBotonRapidoService(botonRepo, stockRepo, redisDB.Client, invalidationQueue, cfg.Cache.TTL, logger)
+	plantillaService := services.NewPlantillaService(plantillaRepo, stockRepo, stockService, approvalService, logger)
+	canalService := services.NewCanalService(canalRepo, stockRepo, productCache, logger)
+	bloqueoProductoService := services.NewBloqueoProductoService(bloqueoProductoRepo, stockRepo, productCache, logger)
+	minimoEstacionalService := services.NewMinimoEstacionalService(minimoEstacionalRepo, stockRepo, logger)
+	turnoService := services.NewTurnoService(turnoRepo, userRepo, stockRepo, cfg.Shifts, logger)
+	ecommerceService := services.NewEcommerceService(ecommerceRepo, stockRepo, cfg.Ecommerce, logger)
+	// Notificaciones de actualización masiva: encoladas y procesadas en orden por un único worker
+	notificacionService := services.NewNotificacionMasivaService(redisDB.Client, productCache, productRepo, botonService, barcodeFilter, cfg.Webhooks, logger)
+	// Exportaciones e importaciones en background, con resultado por polling o callback
+	jobService := services.NewJobService(redisDB.Client, stockService, conteoCiclicoService, jobStorage, cfg.Jobs, cfg.Webhooks, logger)
+
+	// Workers en background (se detienen al apagar el servidor)
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	pickingService.StartExpirationWorker(workersCtx)
+	invalidationQueue.Start(workersCtx)
+	barcodeFilter.Start(workersCtx)
+	degradedMonitor.Start(workersCtx)
+	ventaEncoladaService.StartReconciliationWorker(workersCtx)
+	notificacionService.StartWorker(workersCtx)
+	jobService.StartWorker(workersCtx)
+	reporteService.StartAggregationWorker(workersCtx)
+	avisoVencimientoService.StartDailyWorker(workersCtx)
+	bajaVencidosService.StartDailyWorker(workersCtx)
+	conteoCiclicoService.StartWeeklyWorker(workersCtx)
+	escaneoService.StartFlushWorker(workersCtx)
+
+	// Vigilancia de la espera por conexiones del pool (alerta por log) y ajuste en caliente
+	dbPool := database.NewPoolMonitor(
+		postgresDB.DB,
+		cfg.Database.MaxOpenConns,
+		cfg.Database.MaxIdleConns,
+		cfg.Database.PoolWaitAlertThreshold,
+		cfg.Database.PoolCheckInterval,
+		logger,
+	)
+	dbPool.Start(workersCtx)
+
+	// Cupo del pool para reportes y exportaciones (el resto queda para el POS)
+	// Tokens JWT: los grupos de JWT_PROTECTED_GROUPS exigen Authorization: Bearer
+	tokens := auth.NewTokens(cfg.JWT)
+	authenticator := middleware.NewAuthenticator(tokens, cfg.JWT, logger)
+	authService := services.NewAuthService(userRepo, tokens, cfg.JWT, logger)
+	usuarioService := services.NewUsuarioService(userRepo, logger)
+	heavyLimiter := middleware.NewHeavyLimiter(cfg.Database.HeavyMaxConcurrent, cfg.Database.HeavyMaxWait, logger)
+
+	// Entrega de los eventos de la outbox a los webhooks (sin webhooks no se inicia)
+	outboxDispatcher := outbox.NewDispatcher(outboxRepo, cfg.Webhooks, logger)
+	outboxDispatcher.Start(workersCtx)
+
+	// Crear monitoring service
+	monitoringService := services.NewMonitoringService(
+		logger,
+		cfg,
+		redisDB.Client,
+		dbPool,
+		productCache,
+		invalidationQueue,
+	)
+
+	// Crear handlers
+	stockHandler := handlers.NewStockHandler(stockService, approvalService, logger)
+	posHandler := handlers.NewPOSHandler(productCache, stockService, duplicateSaleService, botonService, precioService, ventaService, ventaEncoladaService, reglaOperacionService, authService, escaneoService, notificacionService, productRepo, barcodeFilter, degradedMonitor, logger)
+	botonHandler := handlers.NewBotonRapidoHandler(botonService, logger)
+	pickingHandler := handlers.NewPickingHandler(pickingService, logger)
+	ubicacionHandler := handlers.NewUbicacionHandler(ubicacionService, logger)
+	guiaHandler := handlers.NewGuiaDespachoHandler(guiaService, logger)
+	notaCreditoHandler := handlers.NewNotaCreditoHandler(notaCreditoService, logger)
+	conteoCiclicoHandler := handlers.NewConteoCiclicoHandler(conteoCiclicoService, cfg.PDT, logger)
+	jobHandler := handlers.NewJobHandler(jobService, cfg.PDT, logger)
+	tomaInventarioHandler := handlers.NewTomaInventarioHandler(tomaInventarioService, logger)
+	approvalHandler := handlers.NewApprovalHandler(approvalService, logger)
+	reglaHandler := handlers.NewReglaOperacionHandler(reglaOperacionService, logger)
+	productoHandler := handlers.NewProductoHandler(precioService, imagenService, canalService, bloqueoProductoService, cfg.Images, logger)
+	unidadHandler := handlers.NewUnidadHandler(unidadService, logger)
+	packHandler := handlers.NewPackHandler(packService, logger)
+	plantillaHandler := handlers.NewPlantillaHandler(plantillaService, logger)
+	ecommerceHandler := handlers.NewEcommerceHandler(ecommerceService, logger)
+	reporteHandler := handlers.NewReporteHandler(reporteService, logger)
+	vencimientoHandler := handlers.NewVencimientoHandler(avisoVencimientoService, bajaVencidosService, logger)
+	busquedaHandler := handlers.NewBusquedaHandler(busquedaService, logger)
+	syncHandler := handlers.NewSyncHandler(syncService, logger)
+	monitoringHandler := handlers.NewMonitoringHandler(monitoringService, cfg.Metrics, logger)
+	criticoHandler := handlers.NewProductoCriticoHandler(productoCriticoService, logger)
+	authHandler := handlers.NewAuthHandler(authService, logger)
+	usuarioHandler := handlers.NewUsuarioHandler(usuarioService, logger)
+	minimoHandler := handlers.NewMinimoEstacionalHandler(minimoEstacionalService, logger)
+	turnoHandler := handlers.NewTurnoHandler(turnoService, logger)
+	exportacionERPHandler := handlers.NewExportacionERPHandler(exportacionERPService, logger)
+	adminHandler := handlers.NewAdminHandler(configManager, featureFlags, maintenanceMode, quotaLimiter, outboxDispatcher, dbPool, folioService, responseSigner, logger)
+
+	// Crear health checker
+	healthChecker := middleware.NewHealthChecker(postgresDB, redisDB, degradedMonitor, ventaEncoladaService, logger)
+
+	// Configurar router
+	router := gin.New()
+
+	// Middleware global
+	router.Use(gin.Recovery())
+	router.Use(middleware.CORSMiddleware())
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.LoggerMiddleware(logger))
+	router.Use(monitoringHandler.RecordRequestMiddleware()) // Middleware de monitoring
+	router.Use(middleware.QuotaMiddleware(quotaLimiter, logger))
+	router.Use(middleware.MaintenanceMiddleware(maintenanceMode))
+	router.Use(middleware.ResponseSigningMiddleware(responseSigner))
+	router.Use(middleware.LegacyResponseMiddleware()) // Formato legado para cajas antiguas (X-Response-Format: legacy)
+
+	// Configurar rutas
+	routes.SetupRoutes(router, stockHandler, posHandler, botonHandler, pickingHandler, ubicacionHandler, guiaHandler, notaCreditoHandler, conteoCiclicoHandler, tomaInventarioHandler, approvalHandler, reglaHandler, productoHandler, unidadHandler, packHandler, plantillaHandler, ecommerceHandler, reporteHandler, exportacionERPHandler, vencimientoHandler, busquedaHandler, syncHandler, adminHandler, monitoringHandler, criticoHandler, authHandler, usuarioHandler, minimoHandler, turnoHandler, jobHandler, healthChecker, authenticator, middleware.RequireAPIKeyMiddleware(quotaLimiter, cfg.Ecommerce.APIKeys), middleware.TurnosMiddleware(turnoService, logger), heavyLimiter, cfg.Timeouts)
+
+	// Configurar servidor
+	srv := server.New(cfg.Server, router, logger)
+
+	// Canal para señales de terminación
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	// SIGHUP recarga la configuración sin reiniciar
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			result, err := configManager.Reload()
+			if err != nil {
+				logger.Error("Configuration reload failed, keeping current settings", zap.Error(err))
+				continue
+			}
+			logger.Info("Configuration reloaded",
+				zap.Strings("applied", result.Applied),
+				zap.Strings("requires_restart", result.RequiresRestart))
+		}
+	}()
+
+	// Mostrar información del servidor
+	middleware.ServerInfo(cfg.Server.Port, logger)
+
+	// Iniciar servidor en goroutine
+	go func() {
+		logger.Info("Starting server", zap.String("port", cfg.Server.Port), zap.Bool("tls", srv.TLSEnabled()))
+		if err := srv.ListenAndServe(); err != nil {
+			logger.Fatal("Failed to start server", zap.Error(err))
+		}
+	}()
+
+	// Esperar señal de terminación
+	<-quit
+	logger.Info("Shutting down server...")
+	stopWorkers()
+
+	// Configurar contexto para shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Cerrar servidor gracefulmente
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Fatal("Server forced to shutdown", zap.Error(err))
+	}
+
+	logger.Info("Server exited")
+}
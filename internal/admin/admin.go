@@ -0,0 +1,21 @@
+// Package admin sirve el dashboard administrativo (SPA estática embebida en el binario)
+package admin
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// FileSystem retorna los archivos del dashboard con static/ como raíz
+func FileSystem() http.FileSystem {
+	sub, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		// static está embebido en tiempo de compilación: no puede faltar
+		panic(err)
+	}
+	return http.FS(sub)
+}
@@ -0,0 +1,153 @@
+// Dashboard administrativo: consume la API del mismo servicio (/api/v1)
+(function () {
+  'use strict';
+
+  const API = '/api/v1';
+  const MAX_VENTAS = 50;
+  let socket = null;
+
+  const $ = (id) => document.getElementById(id);
+
+  function mostrarError(msg) {
+    const el = $('error');
+    el.textContent = msg;
+    el.hidden = !msg;
+  }
+
+  async function getJSON(path) {
+    const resp = await fetch(API + path);
+    const body = await resp.json();
+    if (!resp.ok || body.success === false) {
+      throw new Error(body.message || ('HTTP ' + resp.status));
+    }
+    return body.data !== undefined ? body.data : body;
+  }
+
+  function fila(celdas, clase) {
+    const tr = document.createElement('tr');
+    if (clase) tr.className = clase;
+    celdas.forEach((valor) => {
+      const td = document.createElement('td');
+      td.textContent = valor === undefined || valor === null ? '-' : valor;
+      tr.appendChild(td);
+    });
+    return tr;
+  }
+
+  function llenar(tbody, filas, vacio) {
+    tbody.replaceChildren(...filas);
+    if (filas.length === 0) {
+      const tr = fila([vacio]);
+      tr.firstChild.colSpan = 10;
+      tbody.appendChild(tr);
+    }
+  }
+
+  // --- Stock bajo ---
+  async function cargarStockBajo(local) {
+    const data = await getJSON('/stock/bajo/' + encodeURIComponent(local));
+    const filas = (data.productos_bajo_stock || []).map((s) =>
+      fila([s.codigo_producto, s.tipo_item, s.cantidad_actual, s.cantidad_minima],
+        s.cantidad_actual <= 0 ? 'critico' : ''));
+    llenar($('tabla-stock-bajo'), filas, 'Sin productos bajo el mínimo');
+  }
+
+  // --- Métricas en vivo (WebSocket de monitoring) ---
+  function conectarMetricas() {
+    if (socket) return;
+    const proto = location.protocol === 'https:' ? 'wss://' : 'ws://';
+    socket = new WebSocket(proto + location.host + API + '/monitoring/ws');
+
+    socket.onopen = () => {
+      $('ws-estado').textContent = 'conectado';
+      $('ws-estado').classList.add('conectado');
+    };
+    socket.onclose = () => {
+      $('ws-estado').textContent = 'desconectado';
+      $('ws-estado').classList.remove('conectado');
+      socket = null;
+      // Reintentar mientras la vista siga abierta
+      if (location.hash === '#metricas') setTimeout(conectarMetricas, 5000);
+    };
+    socket.onmessage = (event) => pintarMetricas(JSON.parse(event.data));
+
+    // El servidor envía cada 10s: mostrar algo de inmediato con el endpoint REST
+    getJSON('/monitoring/metrics').then(pintarMetricas).catch(() => {});
+  }
+
+  function pintarMetricas(m) {
+    if (!m || !m.requests) return;
+    $('m-requests').textContent = m.requests.total_requests;
+    $('m-promedio').textContent = m.performance.avg_response_time_ms;
+    $('m-errores').textContent = m.requests.errors_count;
+    $('m-hitrate').textContent = m.cache.hit_rate_percentage;
+    $('m-memoria').textContent = m.system.memoryUsage;
+    $('m-uptime').textContent = m.system.uptime_hours;
+    $('m-timestamp').textContent = m.timestamp;
+
+    const filas = (m.requests.top_endpoints || []).map((e) => fila([e.endpoint, e.count, e.avg_time_ms]));
+    llenar($('tabla-endpoints'), filas, 'Sin requests registrados');
+  }
+
+  // --- Estado de cache del POS ---
+  async function cargarCache() {
+    const stats = await getJSON('/pos/cache-stats');
+    const tarjetas = Object.keys(stats).map((clave) => {
+      const div = document.createElement('div');
+      div.className = 'tarjeta';
+      const label = document.createElement('span');
+      label.textContent = clave.replace(/_/g, ' ');
+      const valor = document.createElement('strong');
+      const v = stats[clave];
+      valor.textContent = clave.startsWith('hit_rate') ? (isFinite(v) ? (v * 100).toFixed(1) + '%' : '-') : v;
+      div.append(label, valor);
+      return div;
+    });
+    $('tarjetas-cache').replaceChildren(...tarjetas);
+  }
+
+  // --- Últimas ventas (salidas registradas por el POS) ---
+  async function cargarVentas() {
+    const data = await getJSON('/movimientos?tipo=salida');
+    const ventas = (data.movimientos || [])
+      .filter((m) => (m.observaciones || '').startsWith('[POS]'))
+      .sort((a, b) => new Date(b.created_at) - new Date(a.created_at))
+      .slice(0, MAX_VENTAS);
+    const filas = ventas.map((v) => fila([
+      new Date(v.created_at).toLocaleString(),
+      v.nombre_local || v.id_local,
+      v.nombre_producto || v.codigo_producto,
+      v.cantidad,
+      v.nombre_usuario || v.id_usuario,
+    ]));
+    llenar($('tabla-ventas'), filas, 'Sin ventas registradas');
+  }
+
+  // --- Navegación por hash ---
+  const cargadores = {
+    'stock-bajo': () => {},
+    metricas: conectarMetricas,
+    cache: cargarCache,
+    ventas: cargarVentas,
+  };
+
+  function navegar() {
+    const vista = location.hash.slice(1) || 'stock-bajo';
+    document.querySelectorAll('.vista').forEach((s) => s.classList.toggle('activa', s.id === vista));
+    document.querySelectorAll('nav a').forEach((a) => a.classList.toggle('activo', a.hash === '#' + vista));
+    mostrarError('');
+    if (vista !== 'metricas' && socket) socket.close();
+    Promise.resolve((cargadores[vista] || (() => {}))()).catch((e) => mostrarError(e.message));
+  }
+
+  $('form-stock-bajo').addEventListener('submit', (event) => {
+    event.preventDefault();
+    mostrarError('');
+    cargarStockBajo(event.target.local.value).catch((e) => mostrarError(e.message));
+  });
+  $('refrescar-cache').addEventListener('click', () => cargarCache().catch((e) => mostrarError(e.message)));
+  $('refrescar-ventas').addEventListener('click', () => cargarVentas().catch((e) => mostrarError(e.message)));
+
+  window.addEventListener('hashchange', navegar);
+  navegar();
+})();
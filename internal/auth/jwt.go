@@ -0,0 +1,126 @@
+// Package auth firma y valida los tokens JWT (HS256) de los usuarios del POS y del backoffice
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"stock-service/internal/config"
+)
+
+var (
+	// ErrTokenInvalido el token está mal formado, usa otro algoritmo o la firma no coincide
+	ErrTokenInvalido = errors.New("token inválido")
+	// ErrTokenExpirado el token ya venció
+	ErrTokenExpirado = errors.New("token expirado")
+)
+
+// header único que se emite y acepta: HS256 con el secreto de JWT_SECRET
+var headerHS256 = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims datos del usuario que viajan en el token
+type Claims struct {
+	Subject   string `json:"sub"` // id del usuario
+	Username  string `json:"username,omitempty"`
+	Rol       string `json:"rol,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// IDUsuario id del usuario del token (sub)
+func (c *Claims) IDUsuario() (int, error) {
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: sub no es un id de usuario", ErrTokenInvalido)
+	}
+	return id, nil
+}
+
+// Tokens firma y valida tokens con el secreto y la duración configurados
+type Tokens struct {
+	secret []byte
+	expiry time.Duration
+}
+
+// NewTokens crea el firmador/validador de tokens
+func NewTokens(cfg config.JWTConfig) *Tokens {
+	return &Tokens{
+		secret: []byte(cfg.Secret),
+		expiry: time.Duration(cfg.ExpiryHours) * time.Hour,
+	}
+}
+
+// Firmar emite un token para el usuario, vigente por JWT_EXPIRY_HOURS
+func (t *Tokens) Firmar(idUsuario int, username, rol string) (string, *Claims, error) {
+	now := time.Now()
+	claims := &Claims{
+		Subject:   strconv.Itoa(idUsuario),
+		Username:  username,
+		Rol:       rol,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(t.expiry).Unix(),
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	unsigned := headerHS256 + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + t.firma(unsigned), claims, nil
+}
+
+// Validar verifica la firma y el vencimiento del token y retorna sus claims
+func (t *Tokens) Validar(token string) (*Claims, error) {
+	partes := strings.Split(token, ".")
+	if len(partes) != 3 {
+		return nil, ErrTokenInvalido
+	}
+
+	// Solo HS256: un token con alg "none" u otro algoritmo se rechaza sin mirar la firma
+	header, err := base64.RawURLEncoding.DecodeString(partes[0])
+	if err != nil {
+		return nil, ErrTokenInvalido
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &h); err != nil || h.Alg != "HS256" {
+		return nil, ErrTokenInvalido
+	}
+
+	esperada := t.firma(partes[0] + "." + partes[1])
+	if !hmac.Equal([]byte(esperada), []byte(partes[2])) {
+		return nil, ErrTokenInvalido
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(partes[1])
+	if err != nil {
+		return nil, ErrTokenInvalido
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrTokenInvalido
+	}
+	if claims.ExpiresAt == 0 || time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpirado
+	}
+	if _, err := claims.IDUsuario(); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// firma HMAC-SHA256 de header.payload en base64url
+func (t *Tokens) firma(unsigned string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordCost costo de bcrypt de las contraseñas (~50-100 ms por login)
+const passwordCost = 11
+
+// hashDummy hash contra el que se compara cuando el usuario no existe, para que el login
+// tarde lo mismo y no revele qué usernames existen
+var hashDummy, _ = bcrypt.GenerateFromPassword([]byte("usuario-inexistente"), passwordCost)
+
+// HashPassword hash bcrypt de la contraseña
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// VerificarPassword compara la contraseña con su hash bcrypt
+// Con hash vacío (usuario inexistente) igual paga el costo de bcrypt y retorna false
+func VerificarPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(hashDummy, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NuevoRefreshToken genera un refresh token aleatorio (opaco, no es un JWT) y su hash
+// En la BD solo se guarda el hash: una fuga de la tabla no permite renovar sesiones
+func NuevoRefreshToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken hash SHA-256 (hex) con que se busca el refresh token en la BD
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"context"
+	"hash/maphash"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// bloomFalsePositiveRate tasa de falsos positivos con que se dimensiona el filtro
+// (un falso positivo responde "existe" para un código que no está en el catálogo)
+const bloomFalsePositiveRate = 0.001
+
+// bloomFilter filtro de Bloom de tamaño fijo (no admite borrar: se reconstruye)
+type bloomFilter struct {
+	bits []uint64
+	m    uint64 // cantidad de bits
+	k    uint64 // cantidad de funciones hash
+	seed maphash.Seed
+}
+
+// newBloomFilter dimensiona el filtro para n elementos con la tasa de falsos positivos p
+func newBloomFilter(n int, p float64) *bloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) / 64 * 64
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloomFilter{
+		bits: make([]uint64, m/64),
+		m:    m,
+		k:    k,
+		seed: maphash.MakeSeed(),
+	}
+}
+
+// positions calcula las k posiciones con doble hashing (Kirsch-Mitzenmacher)
+func (f *bloomFilter) positions(s string, fn func(pos uint64) bool) {
+	h := maphash.String(f.seed, s)
+	h1, h2 := h&0xffffffff, h>>32|1
+	for i := uint64(0); i < f.k; i++ {
+		if !fn((h1 + i*h2) % f.m) {
+			return
+		}
+	}
+}
+
+// add agrega el código; se puede llamar con lecturas concurrentes (las escrituras las serializa BarcodeFilter)
+func (f *bloomFilter) add(s string) {
+	f.positions(s, func(pos uint64) bool {
+		word, bit := &f.bits[pos/64], uint64(1)<<(pos%64)
+		for {
+			old := atomic.LoadUint64(word)
+			if old&bit != 0 || atomic.CompareAndSwapUint64(word, old, old|bit) {
+				return true
+			}
+		}
+	})
+}
+
+// mayContain retorna false si el código seguro no está
+func (f *bloomFilter) mayContain(s string) bool {
+	found := true
+	f.positions(s, func(pos uint64) bool {
+		if atomic.LoadUint64(&f.bits[pos/64])&(1<<(pos%64)) == 0 {
+			found = false
+		}
+		return found
+	})
+	return found
+}
+
+// BarcodeFilterStats estado del filtro de códigos de barras
+type BarcodeFilterStats struct {
+	Ready           bool       `json:"ready"`
+	Codigos         int        `json:"codigos"`
+	Agregados       int64      `json:"agregados"` // agregados desde la última reconstrucción
+	Bits            uint64     `json:"bits"`
+	Hashes          uint64     `json:"hashes"`
+	UltimaCarga     *time.Time `json:"ultima_carga,omitempty"`
+	DuracionCargaMs int64      `json:"duracion_carga_ms"`
+	Negativos       int64      `json:"negativos"` // consultas descartadas solo con el filtro
+	Positivos       int64      `json:"positivos"`
+}
+
+// BarcodeFilter filtro de Bloom con todos los códigos de barras del catálogo (productos y packs)
+// Responde "no existe" sin consultar Redis ni PostgreSQL; se reconstruye periódicamente
+// desde la BD para reflejar altas y bajas, y los productos que se cachean se agregan al vuelo
+type BarcodeFilter struct {
+	load     func(ctx context.Context) ([]string, error)
+	interval time.Duration
+	logger   *zap.Logger
+
+	current atomic.Pointer[bloomFilter]
+
+	mu sync.Mutex
+	// Mientras se reconstruye, lo agregado se guarda para aplicarlo al filtro nuevo
+	rebuilding bool
+	pendientes []string
+	codigos    int
+	agregados  int64
+	lastLoad   time.Time
+	loadTime   time.Duration
+	rebuild    chan struct{}
+
+	negatives atomic.Int64
+	positives atomic.Int64
+}
+
+// NewBarcodeFilter crea el filtro; load retorna todos los códigos de barras del catálogo
+func NewBarcodeFilter(load func(ctx context.Context) ([]string, error), interval time.Duration, logger *zap.Logger) *BarcodeFilter {
+	return &BarcodeFilter{
+		load:     load,
+		interval: interval,
+		logger:   logger,
+		rebuild:  make(chan struct{}, 1),
+	}
+}
+
+// Start carga el filtro y lo reconstruye cada intervalo (o al pedirlo con RequestRebuild)
+// hasta que se cancele ctx; mientras no se cargue por primera vez, MayContain no decide
+func (f *BarcodeFilter) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+
+		f.Rebuild(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-f.rebuild:
+			}
+			f.Rebuild(ctx)
+		}
+	}()
+}
+
+// RequestRebuild pide una reconstrucción en segundo plano (ej: tras una actualización masiva)
+func (f *BarcodeFilter) RequestRebuild() {
+	select {
+	case f.rebuild <- struct{}{}:
+	default:
+	}
+}
+
+// Rebuild recarga los códigos desde la BD y reemplaza el filtro
+func (f *BarcodeFilter) Rebuild(ctx context.Context) {
+	start := time.Now()
+
+	f.mu.Lock()
+	f.rebuilding = true
+	f.pendientes = nil
+	f.mu.Unlock()
+
+	codigos, err := f.load(ctx)
+	if err != nil {
+		f.mu.Lock()
+		f.rebuilding = false
+		f.pendientes = nil
+		f.mu.Unlock()
+		f.logger.Error("Error cargando códigos de barras para el filtro",
+			zap.String("operation", "barcode_filter_rebuild"),
+			zap.Error(err))
+		return
+	}
+
+	filter := newBloomFilter(len(codigos)+len(codigos)/10, bloomFalsePositiveRate)
+	for _, codigo := range codigos {
+		filter.add(codigo)
+	}
+
+	f.mu.Lock()
+	for _, codigo := range f.pendientes {
+		filter.add(codigo)
+	}
+	f.current.Store(filter)
+	f.rebuilding = false
+	f.pendientes = nil
+	f.codigos = len(codigos)
+	f.agregados = 0
+	f.lastLoad = time.Now()
+	f.loadTime = time.Since(start)
+	f.mu.Unlock()
+
+	f.logger.Info("Filtro de códigos de barras reconstruido",
+		zap.String("operation", "barcode_filter_rebuild"),
+		zap.Int("codigos", len(codigos)),
+		zap.Uint64("bits", filter.m),
+		zap.Duration("duracion", time.Since(start)))
+}
+
+// Add agrega un código encontrado en la BD después de la última carga
+func (f *BarcodeFilter) Add(codigo string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// El filtro se reemplaza con mu tomado: cargarlo acá evita agregar al que se descarta
+	filter := f.current.Load()
+	if f.rebuilding {
+		f.pendientes = append(f.pendientes, codigo)
+	}
+	if filter != nil && !filter.mayContain(codigo) {
+		filter.add(codigo)
+		f.agregados++
+	}
+}
+
+// MayContain retorna si el código puede existir (false: seguro no existe)
+// ready es false mientras el filtro no se cargó: en ese caso el resultado no sirve
+func (f *BarcodeFilter) MayContain(codigo string) (maybe, ready bool) {
+	filter := f.current.Load()
+	if filter == nil {
+		return false, false
+	}
+	if filter.mayContain(codigo) {
+		f.positives.Add(1)
+		return true, true
+	}
+	f.negatives.Add(1)
+	return false, true
+}
+
+// Stats retorna el estado del filtro
+func (f *BarcodeFilter) Stats() BarcodeFilterStats {
+	filter := f.current.Load()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stats := BarcodeFilterStats{
+		Ready:           filter != nil,
+		Codigos:         f.codigos,
+		Agregados:       f.agregados,
+		DuracionCargaMs: f.loadTime.Milliseconds(),
+		Negativos:       f.negatives.Load(),
+		Positivos:       f.positives.Load(),
+	}
+	if filter != nil {
+		stats.Bits = filter.m
+		stats.Hashes = filter.k
+		lastLoad := f.lastLoad
+		stats.UltimaCarga = &lastLoad
+	}
+	return stats
+}
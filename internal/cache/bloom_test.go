@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestBloomFilterSinFalsosNegativos(t *testing.T) {
+	const n = 20000
+	filter := newBloomFilter(n, bloomFalsePositiveRate)
+	for i := 0; i < n; i++ {
+		filter.add(fmt.Sprintf("780%010d", i))
+	}
+
+	for i := 0; i < n; i++ {
+		if codigo := fmt.Sprintf("780%010d", i); !filter.mayContain(codigo) {
+			t.Fatalf("mayContain(%q) = false para un código agregado", codigo)
+		}
+	}
+}
+
+func TestBloomFilterTasaDeFalsosPositivos(t *testing.T) {
+	tests := []struct {
+		n int
+		p float64
+	}{
+		{1000, 0.01},
+		{20000, bloomFalsePositiveRate},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("n=%d p=%g", tt.n, tt.p), func(t *testing.T) {
+			filter := newBloomFilter(tt.n, tt.p)
+			for i := 0; i < tt.n; i++ {
+				filter.add(fmt.Sprintf("780%010d", i))
+			}
+
+			// Códigos que no se agregaron; la tasa observada puede pasar un poco la teórica
+			const consultas = 200000
+			positivos := 0
+			for i := 0; i < consultas; i++ {
+				if filter.mayContain(fmt.Sprintf("990%010d", i)) {
+					positivos++
+				}
+			}
+			if tasa := float64(positivos) / consultas; tasa > 2*tt.p {
+				t.Errorf("tasa de falsos positivos = %.5f, want <= %.5f", tasa, 2*tt.p)
+			}
+		})
+	}
+}
+
+func TestBloomFilterDimensionado(t *testing.T) {
+	for _, n := range []int{0, 1, 100, 100000} {
+		filter := newBloomFilter(n, bloomFalsePositiveRate)
+		if filter.m == 0 || filter.m%64 != 0 || uint64(len(filter.bits))*64 != filter.m {
+			t.Errorf("n=%d: m = %d con %d palabras", n, filter.m, len(filter.bits))
+		}
+		if filter.k < 1 {
+			t.Errorf("n=%d: k = %d", n, filter.k)
+		}
+	}
+}
+
+func TestBarcodeFilter(t *testing.T) {
+	codigos := []string{"7801234567894", "12345670"}
+	errCarga := errors.New("bd caída")
+	fallar := false
+	filter := NewBarcodeFilter(func(ctx context.Context) ([]string, error) {
+		if fallar {
+			return nil, errCarga
+		}
+		return codigos, nil
+	}, time.Hour, zap.NewNop())
+
+	if _, ready := filter.MayContain("7801234567894"); ready {
+		t.Fatal("ready = true antes de la primera carga")
+	}
+
+	filter.Rebuild(context.Background())
+	for _, codigo := range codigos {
+		if maybe, ready := filter.MayContain(codigo); !maybe || !ready {
+			t.Errorf("MayContain(%q) = %v, %v, want true, true", codigo, maybe, ready)
+		}
+	}
+
+	// Un producto creado después de la carga se agrega al vuelo
+	filter.Add("0000000000017")
+	if maybe, _ := filter.MayContain("0000000000017"); !maybe {
+		t.Error("MayContain del código agregado = false")
+	}
+
+	// Una carga fallida mantiene el filtro anterior
+	fallar = true
+	filter.Rebuild(context.Background())
+	if maybe, ready := filter.MayContain("12345670"); !maybe || !ready {
+		t.Errorf("tras carga fallida MayContain = %v, %v, want true, true", maybe, ready)
+	}
+
+	stats := filter.Stats()
+	if !stats.Ready || stats.Codigos != len(codigos) || stats.Agregados != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
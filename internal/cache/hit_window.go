@@ -0,0 +1,64 @@
+package cache
+
+import "time"
+
+const (
+	// hitWindowSize duración de la ventana móvil del hit rate
+	hitWindowSize = time.Hour
+	// hitWindowSlots cantidad de tramos de la ventana (uno por minuto)
+	hitWindowSlots = 60
+)
+
+// hitSlot hits y misses de un minuto
+type hitSlot struct {
+	minuto int64 // minuto Unix al que corresponden los contadores
+	hits   int64
+	misses int64
+}
+
+// hitWindow contadores de hits y misses de la última hora, en tramos de un minuto
+// No es seguro para uso concurrente: el ProductCache lo protege con statsMutex
+type hitWindow struct {
+	slots [hitWindowSlots]hitSlot
+}
+
+// slot retorna el tramo del minuto de now, reiniciándolo si quedó de una vuelta anterior
+func (w *hitWindow) slot(now time.Time) *hitSlot {
+	minuto := now.Unix() / 60
+	s := &w.slots[minuto%hitWindowSlots]
+	if s.minuto != minuto {
+		*s = hitSlot{minuto: minuto}
+	}
+	return s
+}
+
+// recordHit registra un hit en el minuto actual
+func (w *hitWindow) recordHit(now time.Time) {
+	w.slot(now).hits++
+}
+
+// recordMiss registra un miss en el minuto actual
+func (w *hitWindow) recordMiss(now time.Time) {
+	w.slot(now).misses++
+}
+
+// totals suma los tramos que siguen dentro de la ventana
+func (w *hitWindow) totals(now time.Time) (hits, misses int64) {
+	desde := now.Add(-hitWindowSize).Unix() / 60
+	for _, s := range w.slots {
+		if s.minuto > desde {
+			hits += s.hits
+			misses += s.misses
+		}
+	}
+	return hits, misses
+}
+
+// HitRate proporción de hits sobre el total de búsquedas (0 sin búsquedas, nunca NaN)
+func HitRate(hits, misses int64) float64 {
+	total := hits + misses
+	if total == 0 {
+		return 0
+	}
+	return float64(hits) / float64(total)
+}
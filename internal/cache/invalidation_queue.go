@@ -0,0 +1,268 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// pendingSetKey set de Redis con las claves pendientes de invalidar (compartido entre réplicas
+	// y sobrevive a reinicios, siempre que Redis haya aceptado el registro)
+	pendingSetKey = "cache:invalidaciones:pendientes"
+	// deadLetterKey lista de Redis con las invalidaciones que agotaron los reintentos
+	deadLetterKey = "cache:invalidaciones:dead_letter"
+	// maxDeadLetter cantidad de invalidaciones descartadas que se conservan
+	maxDeadLetter = 100
+	// maxRetryBackoff espera máxima entre reintentos de una misma clave
+	maxRetryBackoff = 5 * time.Minute
+)
+
+// InvalidationStats estado de la cola de invalidaciones
+type InvalidationStats struct {
+	// Invalidaciones en mora (fallaron y esperan reintento)
+	Pending int `json:"pendientes"`
+	// Antigüedad de la invalidación en mora más vieja
+	OldestPendingSeconds int64 `json:"mas_antigua_segundos"`
+	Retried              int64 `json:"reintentadas_ok"`
+	DeadLetter           int64 `json:"dead_letter"`
+	// Últimas invalidaciones descartadas (más reciente primero)
+	RecentDeadLetter []DeadInvalidation `json:"dead_letter_recientes"`
+}
+
+// DeadInvalidation invalidación descartada tras agotar los reintentos
+type DeadInvalidation struct {
+	Key          string    `json:"key"`
+	Attempts     int       `json:"intentos"`
+	FirstFailure time.Time `json:"primer_fallo"`
+	LastError    string    `json:"ultimo_error"`
+}
+
+// pendingInvalidation invalidación en mora
+type pendingInvalidation struct {
+	attempts     int
+	firstFailure time.Time
+	nextAttempt  time.Time
+	lastError    string
+}
+
+// InvalidationQueue borra claves de la caché y, si Redis falla, las reintenta en background
+// con backoff exponencial; tras maxAttempts intentos pasan a la dead letter
+type InvalidationQueue struct {
+	redisClient   *redis.Client
+	retryInterval time.Duration
+	maxAttempts   int
+	logger        *zap.Logger
+
+	mu         sync.Mutex
+	pending    map[string]*pendingInvalidation
+	deadLetter []DeadInvalidation
+	retried    int64
+	discarded  int64
+}
+
+// NewInvalidationQueue crea la cola de invalidaciones
+func NewInvalidationQueue(redisClient *redis.Client, retryInterval time.Duration, maxAttempts int, logger *zap.Logger) *InvalidationQueue {
+	return &InvalidationQueue{
+		redisClient:   redisClient,
+		retryInterval: retryInterval,
+		maxAttempts:   maxAttempts,
+		logger:        logger,
+		pending:       make(map[string]*pendingInvalidation),
+	}
+}
+
+// Invalidate borra las claves; las que no se pudieron borrar quedan en mora para reintentar
+func (q *InvalidationQueue) Invalidate(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	err := q.redisClient.Del(ctx, keys...).Err()
+	if err == nil {
+		return
+	}
+
+	q.logger.Warn("Error invalidando cache, se reintentará en background",
+		zap.Strings("keys", keys),
+		zap.Error(err))
+
+	now := time.Now()
+	q.mu.Lock()
+	for _, key := range keys {
+		if _, exists := q.pending[key]; exists {
+			continue
+		}
+		q.pending[key] = &pendingInvalidation{
+			attempts:     1,
+			firstFailure: now,
+			nextAttempt:  now.Add(q.retryInterval),
+			lastError:    err.Error(),
+		}
+	}
+	q.mu.Unlock()
+
+	// Best effort: si Redis responde a medias, otras réplicas también podrán reintentarlas
+	members := make([]interface{}, len(keys))
+	for i, key := range keys {
+		members[i] = key
+	}
+	q.redisClient.SAdd(ctx, pendingSetKey, members...)
+}
+
+// Start inicia el worker de reintentos hasta que se cancele ctx
+func (q *InvalidationQueue) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(q.retryInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				q.retryPending(ctx)
+				q.drainShared(ctx)
+			}
+		}
+	}()
+}
+
+// retryPending reintenta las invalidaciones en mora cuyo backoff venció
+func (q *InvalidationQueue) retryPending(ctx context.Context) {
+	now := time.Now()
+
+	q.mu.Lock()
+	due := make([]string, 0, len(q.pending))
+	for key, p := range q.pending {
+		if !now.Before(p.nextAttempt) {
+			due = append(due, key)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, key := range due {
+		err := q.redisClient.Del(ctx, key).Err()
+
+		q.mu.Lock()
+		p, ok := q.pending[key]
+		if !ok {
+			q.mu.Unlock()
+			continue
+		}
+		if err == nil {
+			delete(q.pending, key)
+			q.retried++
+			q.mu.Unlock()
+			q.redisClient.SRem(ctx, pendingSetKey, key)
+			continue
+		}
+
+		p.attempts++
+		p.lastError = err.Error()
+		if p.attempts < q.maxAttempts {
+			p.nextAttempt = now.Add(retryBackoff(q.retryInterval, p.attempts))
+			q.mu.Unlock()
+			continue
+		}
+
+		dead := DeadInvalidation{Key: key, Attempts: p.attempts, FirstFailure: p.firstFailure, LastError: p.lastError}
+		delete(q.pending, key)
+		q.addDeadLetter(dead)
+		q.mu.Unlock()
+
+		q.logger.Error("Invalidación de cache descartada tras agotar los reintentos",
+			zap.String("key", key),
+			zap.Int("attempts", dead.Attempts),
+			zap.String("last_error", dead.LastError))
+
+		if data, err := json.Marshal(dead); err == nil {
+			pipe := q.redisClient.TxPipeline()
+			pipe.LPush(ctx, deadLetterKey, data)
+			pipe.LTrim(ctx, deadLetterKey, 0, maxDeadLetter-1)
+			pipe.SRem(ctx, pendingSetKey, key)
+			pipe.Exec(ctx)
+		}
+	}
+}
+
+// drainShared procesa las invalidaciones pendientes registradas en Redis
+// (de otras réplicas o de antes de un reinicio) que esta réplica no tiene en memoria
+func (q *InvalidationQueue) drainShared(ctx context.Context) {
+	keys, err := q.redisClient.SMembers(ctx, pendingSetKey).Result()
+	if err != nil || len(keys) == 0 {
+		return
+	}
+
+	for _, key := range keys {
+		q.mu.Lock()
+		_, local := q.pending[key]
+		q.mu.Unlock()
+		if local {
+			continue
+		}
+
+		if err := q.redisClient.Del(ctx, key).Err(); err != nil {
+			return
+		}
+		q.redisClient.SRem(ctx, pendingSetKey, key)
+
+		q.mu.Lock()
+		q.retried++
+		q.mu.Unlock()
+	}
+}
+
+// addDeadLetter agrega a la dead letter en memoria (debe llamarse con q.mu tomado)
+func (q *InvalidationQueue) addDeadLetter(dead DeadInvalidation) {
+	q.discarded++
+	q.deadLetter = append(q.deadLetter, dead)
+	if len(q.deadLetter) > maxDeadLetter {
+		q.deadLetter = q.deadLetter[1:]
+	}
+}
+
+// Stats retorna el estado de la cola
+func (q *InvalidationQueue) Stats() InvalidationStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := InvalidationStats{
+		Pending:          len(q.pending),
+		Retried:          q.retried,
+		DeadLetter:       q.discarded,
+		RecentDeadLetter: make([]DeadInvalidation, 0, len(q.deadLetter)),
+	}
+
+	var oldest time.Time
+	for _, p := range q.pending {
+		if oldest.IsZero() || p.firstFailure.Before(oldest) {
+			oldest = p.firstFailure
+		}
+	}
+	if !oldest.IsZero() {
+		stats.OldestPendingSeconds = int64(time.Since(oldest).Seconds())
+	}
+
+	for i := len(q.deadLetter) - 1; i >= 0 && len(stats.RecentDeadLetter) < 10; i-- {
+		stats.RecentDeadLetter = append(stats.RecentDeadLetter, q.deadLetter[i])
+	}
+
+	return stats
+}
+
+// retryBackoff espera antes del siguiente intento: se duplica por intento hasta maxRetryBackoff
+func retryBackoff(base time.Duration, attempts int) time.Duration {
+	backoff := base
+	for i := 1; i < attempts && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// keyLockPrefix prefijo de las claves de lock en Redis
+	keyLockPrefix = "lock:"
+	// keyLockRetry espera entre intentos de tomar un lock ocupado
+	keyLockRetry = 20 * time.Millisecond
+)
+
+// ErrLockTimeout no se pudo tomar el lock dentro de la espera máxima
+var ErrLockTimeout = errors.New("lock ocupado")
+
+// releaseScript borra el lock solo si sigue siendo del mismo dueño (no uno tomado tras expirar)
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// KeyLocker serializa operaciones por clave entre réplicas con locks en Redis (SET NX con TTL)
+// El TTL acota cuánto queda tomado un lock si la réplica que lo tiene muere
+// Es seguro llamarlo sobre un *KeyLocker nil (sin serialización)
+type KeyLocker struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+	wait        time.Duration
+	logger      *zap.Logger
+}
+
+// NewKeyLocker crea el locker; ttl es la vida máxima de un lock y wait cuánto se espera por uno ocupado
+func NewKeyLocker(redisClient *redis.Client, ttl, wait time.Duration, logger *zap.Logger) *KeyLocker {
+	return &KeyLocker{
+		redisClient: redisClient,
+		ttl:         ttl,
+		wait:        wait,
+		logger:      logger,
+	}
+}
+
+// Lock toma el lock de la clave, esperando hasta wait si está ocupado
+// Retorna la función que lo libera; ErrLockTimeout si no se obtuvo a tiempo
+// Si Redis falla no bloquea la operación: se continúa sin lock (fail-open) y se registra
+func (l *KeyLocker) Lock(ctx context.Context, key string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	token := lockToken()
+	redisKey := keyLockPrefix + key
+	deadline := time.Now().Add(l.wait)
+
+	for {
+		ok, err := l.redisClient.SetNX(ctx, redisKey, token, l.ttl).Result()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			l.logger.Warn("Error tomando lock en Redis, se continúa sin serializar",
+				zap.String("key", key),
+				zap.Error(err))
+			return func() {}, nil
+		}
+		if ok {
+			return func() { l.release(redisKey, token) }, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrLockTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(keyLockRetry):
+		}
+	}
+}
+
+// release libera el lock si todavía es propio; usa un contexto propio para liberar
+// aunque el de la operación ya haya vencido
+func (l *KeyLocker) release(redisKey, token string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := releaseScript.Run(ctx, l.redisClient, []string{redisKey}, token).Err(); err != nil && err != redis.Nil {
+		// Expira solo por TTL
+		l.logger.Warn("Error liberando lock en Redis",
+			zap.String("key", redisKey),
+			zap.Error(err))
+	}
+}
+
+// lockToken identifica al dueño de un lock
+func lockToken() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return time.Now().Format(time.RFC3339Nano)
+	}
+	return hex.EncodeToString(b[:])
+}
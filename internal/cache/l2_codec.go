@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"stock-service/internal/models"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Formatos de serialización de los productos en el L2 (CACHE_L2_FORMAT)
+const (
+	L2FormatJSON    = "json"
+	L2FormatGzip    = "gzip"
+	L2FormatMsgpack = "msgpack"
+)
+
+// Los formatos binarios se marcan con un byte inicial que no puede empezar un JSON;
+// el JSON se guarda sin marca, así las entradas del formato antiguo se siguen leyendo
+// y una réplica configurada en json convive con las demás durante un despliegue
+const (
+	l2TagGzip    byte = 0x01
+	l2TagMsgpack byte = 0x02
+)
+
+// msgpackHandle usa los tags json de los modelos (nombres de campo y omitempty)
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true
+	h.TypeInfos = codec.NewTypeInfos([]string{"json"})
+	return h
+}()
+
+// l2Codec serializa los productos del L2 en el formato configurado
+type l2Codec struct {
+	format string
+	// Con gzip, los JSON más chicos que esto se guardan sin comprimir
+	compressMinBytes int
+}
+
+// encode serializa el producto en el formato configurado
+func (c l2Codec) encode(producto *models.ProductoCompleto) ([]byte, error) {
+	switch c.format {
+	case L2FormatMsgpack:
+		var encoded []byte
+		if err := codec.NewEncoderBytes(&encoded, msgpackHandle).Encode(producto); err != nil {
+			return nil, err
+		}
+		return append([]byte{l2TagMsgpack}, encoded...), nil
+	case L2FormatGzip:
+		data, err := json.Marshal(producto)
+		if err != nil || len(data) < c.compressMinBytes {
+			return data, err
+		}
+		var buf bytes.Buffer
+		buf.WriteByte(l2TagGzip)
+		zw, _ := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return json.Marshal(producto)
+	}
+}
+
+// decode lee una entrada en cualquiera de los formatos (detectado por el byte inicial)
+// y retorna si está en el formato configurado
+func (c l2Codec) decode(data []byte) (*models.ProductoCompleto, bool, error) {
+	if len(data) == 0 {
+		return nil, false, fmt.Errorf("entrada vacía")
+	}
+
+	var producto models.ProductoCompleto
+	var format string
+	switch data[0] {
+	case l2TagMsgpack:
+		format = L2FormatMsgpack
+		if err := codec.NewDecoderBytes(data[1:], msgpackHandle).Decode(&producto); err != nil {
+			return nil, false, err
+		}
+	case l2TagGzip:
+		format = L2FormatGzip
+		zr, err := gzip.NewReader(bytes.NewReader(data[1:]))
+		if err != nil {
+			return nil, false, err
+		}
+		raw, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := json.Unmarshal(raw, &producto); err != nil {
+			return nil, false, err
+		}
+	default:
+		// JSON plano: formato antiguo, o gzip por debajo del umbral de compresión
+		format = L2FormatJSON
+		if err := json.Unmarshal(data, &producto); err != nil {
+			return nil, false, err
+		}
+		if c.format == L2FormatGzip && len(data) < c.compressMinBytes {
+			format = L2FormatGzip
+		}
+	}
+
+	return &producto, format == c.format, nil
+}
@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"stock-service/internal/models"
+)
+
+const (
+	// BarcodeSLA latencia máxima comprometida para el escaneo en el POS
+	BarcodeSLA = 50 * time.Millisecond
+	// latencyWindow cantidad de muestras recientes sobre las que se calculan los percentiles
+	latencyWindow = 1024
+)
+
+// latencyBuckets límites superiores de los buckets (el SLA es uno de ellos)
+var latencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	BarcodeSLA,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// LatencyHistogram histograma de latencias de una etapa: buckets acumulados y ventana
+// circular con las últimas muestras para los percentiles
+type LatencyHistogram struct {
+	mu     sync.Mutex
+	counts []int64 // un contador por bucket más el de +Inf
+	count  int64
+	sum    time.Duration
+	max    time.Duration
+	window []time.Duration
+	next   int
+}
+
+// Observe registra una muestra
+func (h *LatencyHistogram) Observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.counts == nil {
+		h.counts = make([]int64, len(latencyBuckets)+1)
+	}
+	h.counts[sort.Search(len(latencyBuckets), func(i int) bool { return d <= latencyBuckets[i] })]++
+	h.count++
+	h.sum += d
+	if d > h.max {
+		h.max = d
+	}
+
+	if len(h.window) < latencyWindow {
+		h.window = append(h.window, d)
+		return
+	}
+	h.window[h.next] = d
+	h.next = (h.next + 1) % latencyWindow
+}
+
+// Snapshot retorna el estado del histograma
+func (h *LatencyHistogram) Snapshot() models.LatencyHistogram {
+	h.mu.Lock()
+	recent := append([]time.Duration(nil), h.window...)
+	snapshot := models.LatencyHistogram{
+		Count:   h.count,
+		MaxMs:   durationMs(h.max),
+		Buckets: make([]models.LatencyBucket, 0, len(latencyBuckets)+1),
+	}
+	if h.count > 0 {
+		snapshot.AvgMs = durationMs(h.sum) / float64(h.count)
+	}
+	for i := 0; i <= len(latencyBuckets); i++ {
+		le := "+Inf"
+		if i < len(latencyBuckets) {
+			le = strconv.FormatFloat(durationMs(latencyBuckets[i]), 'f', -1, 64)
+		}
+		var count int64
+		if h.counts != nil {
+			count = h.counts[i]
+		}
+		snapshot.Buckets = append(snapshot.Buckets, models.LatencyBucket{Le: le, Count: count})
+	}
+	h.mu.Unlock()
+
+	sort.Slice(recent, func(i, j int) bool { return recent[i] < recent[j] })
+	snapshot.P50Ms = percentile(recent, 0.50)
+	snapshot.P95Ms = percentile(recent, 0.95)
+	snapshot.P99Ms = percentile(recent, 0.99)
+	return snapshot
+}
+
+// within retorna cuántas muestras acumuladas quedaron dentro de limit (límite de un bucket)
+func (h *LatencyHistogram) within(limit time.Duration) (within, total int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, le := range latencyBuckets {
+		if le > limit || h.counts == nil {
+			break
+		}
+		within += h.counts[i]
+	}
+	return within, h.count
+}
+
+// reset descarta todas las muestras
+func (h *LatencyHistogram) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counts = nil
+	h.count = 0
+	h.sum = 0
+	h.max = 0
+	h.window = nil
+	h.next = 0
+}
+
+// BarcodeLatency latencias del camino crítico de escaneo: L1 y L2 las registra la cache,
+// la BD y el total el handler de búsqueda por código de barras
+type BarcodeLatency struct {
+	L1       LatencyHistogram
+	L2       LatencyHistogram
+	Database LatencyHistogram
+	Total    LatencyHistogram
+
+	mu    sync.Mutex
+	since time.Time
+}
+
+// NewBarcodeLatency crea los histogramas vacíos
+func NewBarcodeLatency() *BarcodeLatency {
+	return &BarcodeLatency{since: time.Now()}
+}
+
+// Snapshot retorna las latencias por etapa y el cumplimiento del SLA
+func (b *BarcodeLatency) Snapshot() models.BarcodeLatencyMetrics {
+	b.mu.Lock()
+	since := b.since
+	b.mu.Unlock()
+
+	metrics := models.BarcodeLatencyMetrics{
+		SLAMs:    durationMs(BarcodeSLA),
+		L1:       b.L1.Snapshot(),
+		L2:       b.L2.Snapshot(),
+		Database: b.Database.Snapshot(),
+		Total:    b.Total.Snapshot(),
+		Since:    since,
+	}
+	if within, total := b.Total.within(BarcodeSLA); total > 0 {
+		metrics.WithinSLAPercentage = float64(within) / float64(total) * 100
+	}
+	return metrics
+}
+
+// Reset descarta las muestras (por ejemplo tras desplegar un cambio de queries,
+// para comparar contra una línea base limpia)
+func (b *BarcodeLatency) Reset() {
+	b.L1.reset()
+	b.L2.reset()
+	b.Database.reset()
+	b.Total.reset()
+
+	b.mu.Lock()
+	b.since = time.Now()
+	b.mu.Unlock()
+}
+
+// percentile retorna el percentil p (0-1) de las muestras ordenadas, en ms
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	// Nearest-rank
+	idx := int(math.Ceil(float64(len(sorted))*p)) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return durationMs(sorted[idx])
+}
+
+// durationMs convierte a milisegundos con decimales
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"stock-service/internal/models"
@@ -13,19 +15,58 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// productMetaPrefix hash por código de barras con cuándo se cacheó y cuándo se invalidó
+	// por última vez; sobrevive al TTL del producto para poder clasificar los misses
+	productMetaPrefix = "product_meta:"
+	// invalidatedAllKey momento de la última invalidación total de la cache de productos
+	invalidatedAllKey = "product_meta_invalidated_all"
+	// productMinimoPrefix productos en detalle mínimo (?detalle=minimo), cacheados aparte del completo
+	productMinimoPrefix = "product_min:"
+	// productMetaTTL retención de la metadata (pasado este plazo el miss cuenta como nunca cacheado)
+	productMetaTTL = 7 * 24 * time.Hour
+)
+
+// Causas de un cache miss
+const (
+	// MissNuncaCacheado el producto no se cacheó (o su metadata ya venció): producto nuevo o poco usado
+	MissNuncaCacheado = "nunca_cacheado"
+	// MissExpirado se cacheó y venció el TTL
+	MissExpirado = "expirado"
+	// MissInvalidado se invalidó (individual o masivamente) después de cachearse
+	MissInvalidado = "invalidado"
+	// MissDesalojado seguía vigente pero Redis lo desalojó (memoria llena)
+	MissDesalojado = "desalojado"
+	// MissDesconocido no se pudo consultar Redis
+	MissDesconocido = "desconocido"
+)
+
 // CacheStats estadísticas del caché
 type CacheStats struct {
 	Hits          int64
 	Misses        int64
 	TotalRequests int64
 	TotalKeys     int
+
+	// Resultado de las búsquedas en BD tras un miss
+	NotFound     int64
+	LookupErrors int64
+
+	// Misses por causa (MissExpirado, MissInvalidado, ...)
+	MissCauses map[string]int64
+
+	// Hits y misses de la última hora (ventana móvil por minuto)
+	HitsUltimaHora   int64
+	MissesUltimaHora int64
 }
 
 // ProductCache implementa caché multi-nivel para productos
 type ProductCache struct {
 	// L1 Cache: Memoria local (más rápido)
 	l1Cache map[string]*models.ProductoCompleto
-	l1Mutex sync.RWMutex
+	// Detalle mínimo: independiente del completo, comparte el mutex y el tamaño máximo
+	l1Minimo map[string]*models.ProductoPOSResponse
+	l1Mutex  sync.RWMutex
 
 	// L2 Cache: Redis (persistente)
 	redisClient *redis.Client
@@ -33,45 +74,85 @@ type ProductCache struct {
 	// Configuración
 	maxL1Size int
 	ttl       time.Duration
+	// Serialización del L2; las entradas en otro formato se reescriben al leerlas
+	codec      l2Codec
+	l2Rewrites atomic.Int64
 
 	logger *zap.Logger
 
 	// Estadísticas
-	statsMutex sync.RWMutex
-	hits       int64
-	misses     int64
+	statsMutex   sync.RWMutex
+	hits         int64
+	misses       int64
+	notFound     int64
+	lookupErrors int64
+	missCauses   map[string]int64
+	ventana      hitWindow
+
+	// Latencias del camino de escaneo (L1 y L2 acá; BD y total en el handler)
+	latency *BarcodeLatency
+
+	// Filtro de existencia de códigos de barras; los productos que se cachean se le agregan
+	barcodeFilter *BarcodeFilter
 
 	// Versión global de lista_precios_cantera (para invalidación masiva)
-	globalVersionKey        string
-	lastCheckTimestampKey   string
-	checkIntervalSeconds    int64 // Verificar BD solo cada N segundos
-	
+	globalVersionKey      string
+	lastCheckTimestampKey string
+	checkIntervalSeconds  atomic.Int64 // Verificar BD solo cada N segundos (recargable)
+
 	// Versión global de productos (para invalidación masiva)
-	productosVersionKey     string
-	productosLastCheckKey   string
+	productosVersionKey   string
+	productosLastCheckKey string
 }
 
 // NewProductCache crea una nueva instancia del caché
 func NewProductCache(redisClient *redis.Client, maxL1Size int, ttl time.Duration, logger *zap.Logger) *ProductCache {
 	pc := &ProductCache{
 		l1Cache:               make(map[string]*models.ProductoCompleto),
+		l1Minimo:              make(map[string]*models.ProductoPOSResponse),
 		redisClient:           redisClient,
 		maxL1Size:             maxL1Size,
 		ttl:                   ttl,
+		codec:                 l2Codec{format: L2FormatJSON},
 		logger:                logger,
 		globalVersionKey:      "lista_precios:global_version",
 		lastCheckTimestampKey: "lista_precios:last_check",
-		checkIntervalSeconds:  10, // Verificar BD solo cada 10 segundos
 		productosVersionKey:   "productos:global_version",
 		productosLastCheckKey: "productos:last_check",
+		latency:               NewBarcodeLatency(),
+		missCauses:            make(map[string]int64),
 	}
 
+	pc.checkIntervalSeconds.Store(10) // Verificar BD solo cada 10 segundos
+
 	// Iniciar limpieza periódica del L1 cache
 	go pc.cleanupL1Cache()
 
 	return pc
 }
 
+// SetCheckInterval cambia cada cuánto se consulta la BD para detectar cambios de versión
+// Se puede llamar en caliente (recarga de configuración)
+func (pc *ProductCache) SetCheckInterval(interval time.Duration) {
+	seconds := int64(interval / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	pc.checkIntervalSeconds.Store(seconds)
+}
+
+// SetBarcodeFilter asocia el filtro de existencia: los productos cacheados se agregan a él
+// para que un alta posterior a la última carga no se responda como inexistente
+func (pc *ProductCache) SetBarcodeFilter(filter *BarcodeFilter) {
+	pc.barcodeFilter = filter
+}
+
+// SetL2Format define la serialización de los productos en Redis (json, gzip o msgpack)
+// Las entradas se leen en cualquier formato; no es recargable: llamar antes de usar la cache
+func (pc *ProductCache) SetL2Format(format string, compressMinBytes int) {
+	pc.codec = l2Codec{format: format, compressMinBytes: compressMinBytes}
+}
+
 // GetStats retorna estadísticas del caché
 func (pc *ProductCache) GetStats() CacheStats {
 	pc.statsMutex.RLock()
@@ -81,11 +162,23 @@ func (pc *ProductCache) GetStats() CacheStats {
 	totalKeys := len(pc.l1Cache)
 	pc.l1Mutex.RUnlock()
 
+	missCauses := make(map[string]int64, len(pc.missCauses))
+	for causa, n := range pc.missCauses {
+		missCauses[causa] = n
+	}
+	hitsHora, missesHora := pc.ventana.totals(time.Now())
+
 	return CacheStats{
+		MissCauses:    missCauses,
 		Hits:          pc.hits,
 		Misses:        pc.misses,
 		TotalRequests: pc.hits + pc.misses,
 		TotalKeys:     totalKeys,
+		NotFound:      pc.notFound,
+		LookupErrors:  pc.lookupErrors,
+
+		HitsUltimaHora:   hitsHora,
+		MissesUltimaHora: missesHora,
 	}
 }
 
@@ -96,7 +189,9 @@ func (pc *ProductCache) GetProduct(ctx context.Context, codigoBarras string) (*m
 	start := time.Now()
 
 	// 1. L1 Cache (Memoria local) - Más rápido
-	if producto := pc.getFromL1(codigoBarras); producto != nil {
+	producto := pc.getFromL1(codigoBarras)
+	pc.latency.L1.Observe(time.Since(start))
+	if producto != nil {
 		pc.recordHit()
 		pc.logger.Debug("L1 cache hit",
 			zap.String("codigo_barras", codigoBarras),
@@ -105,7 +200,10 @@ func (pc *ProductCache) GetProduct(ctx context.Context, codigoBarras string) (*m
 	}
 
 	// 2. L2 Cache (Redis) - Medio
-	if producto, err := pc.getFromL2(ctx, codigoBarras); err == nil && producto != nil {
+	l2Start := time.Now()
+	producto, causa := pc.lookupL2(ctx, codigoBarras)
+	pc.latency.L2.Observe(time.Since(l2Start))
+	if producto != nil {
 		// Mover a L1 cache para futuras consultas
 		pc.setToL1(codigoBarras, producto)
 		pc.recordHit()
@@ -116,14 +214,90 @@ func (pc *ProductCache) GetProduct(ctx context.Context, codigoBarras string) (*m
 	}
 
 	// 3. Database - Más lento (se implementará en el service)
-	pc.recordMiss()
+	pc.recordMiss(causa)
 	pc.logger.Debug("Cache miss",
 		zap.String("codigo_barras", codigoBarras),
+		zap.String("causa", causa),
 		zap.Duration("latency", time.Since(start)))
 
 	return nil, fmt.Errorf("producto no encontrado en caché")
 }
 
+// GetProductoMinimo busca el producto en detalle mínimo (L1 y L2)
+// Solo un hit cuenta en las estadísticas: ante un miss el handler sigue con GetProduct,
+// que registra el miss con su causa
+func (pc *ProductCache) GetProductoMinimo(ctx context.Context, codigoBarras string) (*models.ProductoPOSResponse, error) {
+	start := time.Now()
+
+	pc.l1Mutex.RLock()
+	producto := pc.l1Minimo[codigoBarras]
+	pc.l1Mutex.RUnlock()
+	pc.latency.L1.Observe(time.Since(start))
+	if producto != nil {
+		pc.recordHit()
+		return producto, nil
+	}
+
+	l2Start := time.Now()
+	data, err := pc.redisClient.Get(ctx, productMinimoPrefix+codigoBarras).Bytes()
+	pc.latency.L2.Observe(time.Since(l2Start))
+	if err != nil {
+		return nil, fmt.Errorf("producto no encontrado en caché")
+	}
+	producto = &models.ProductoPOSResponse{}
+	if err := json.Unmarshal(data, producto); err != nil {
+		return nil, fmt.Errorf("failed to decode cached product: %w", err)
+	}
+
+	pc.setMinimoToL1(codigoBarras, producto)
+	pc.recordHit()
+	return producto, nil
+}
+
+// SetProductoMinimo almacena el producto en detalle mínimo en ambos niveles de caché
+func (pc *ProductCache) SetProductoMinimo(ctx context.Context, codigoBarras string, producto *models.ProductoPOSResponse) error {
+	pc.setMinimoToL1(codigoBarras, producto)
+
+	data, err := json.Marshal(producto)
+	if err != nil {
+		return err
+	}
+	return pc.redisClient.Set(ctx, productMinimoPrefix+codigoBarras, data, pc.ttl).Err()
+}
+
+// setMinimoToL1 almacena un producto en detalle mínimo en el L1 cache
+func (pc *ProductCache) setMinimoToL1(codigoBarras string, producto *models.ProductoPOSResponse) {
+	pc.l1Mutex.Lock()
+	defer pc.l1Mutex.Unlock()
+
+	if len(pc.l1Minimo) >= pc.maxL1Size {
+		for key := range pc.l1Minimo {
+			delete(pc.l1Minimo, key)
+			break
+		}
+	}
+
+	pc.l1Minimo[codigoBarras] = producto
+}
+
+// Exists indica si el producto está en la cache (L1 o L2) sin deserializarlo
+// No cuenta como hit/miss: las consultas de existencia no deben mover el hit rate del escaneo
+func (pc *ProductCache) Exists(ctx context.Context, codigoBarras string) (bool, error) {
+	if pc.getFromL1(codigoBarras) != nil {
+		return true, nil
+	}
+	n, err := pc.redisClient.Exists(ctx, fmt.Sprintf("product:%s", codigoBarras)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Latency retorna los histogramas de latencia del camino de escaneo
+func (pc *ProductCache) Latency() *BarcodeLatency {
+	return pc.latency
+}
+
 // GetGlobalVersion obtiene la versión global de lista_precios_cantera desde Redis
 func (pc *ProductCache) GetGlobalVersion(ctx context.Context) (string, error) {
 	version, err := pc.redisClient.Get(ctx, pc.globalVersionKey).Result()
@@ -168,7 +342,7 @@ func (pc *ProductCache) ShouldCheckDatabase(ctx context.Context) (bool, error) {
 	elapsed := now - lastCheck
 
 	// Solo verificar si pasó el intervalo
-	return elapsed >= pc.checkIntervalSeconds, nil
+	return elapsed >= pc.checkIntervalSeconds.Load(), nil
 }
 
 // UpdateLastCheck actualiza el timestamp de última verificación
@@ -221,7 +395,7 @@ func (pc *ProductCache) ShouldCheckProductosDatabase(ctx context.Context) (bool,
 	elapsed := now - lastCheck
 
 	// Solo verificar si pasó el intervalo
-	return elapsed >= pc.checkIntervalSeconds, nil
+	return elapsed >= pc.checkIntervalSeconds.Load(), nil
 }
 
 // UpdateProductosLastCheck actualiza el timestamp de última verificación de productos
@@ -230,6 +404,46 @@ func (pc *ProductCache) UpdateProductosLastCheck(ctx context.Context) error {
 	return pc.redisClient.Set(ctx, pc.productosLastCheckKey, now, 0).Err()
 }
 
+// VersionStatus versiones globales de lista_precios y productos guardadas en Redis, su último check
+// contra la BD y la última invalidación total, leídos en un solo pipeline
+func (pc *ProductCache) VersionStatus(ctx context.Context) (*models.CacheVersionStatus, error) {
+	pipe := pc.redisClient.Pipeline()
+	listaVersion := pipe.Get(ctx, pc.globalVersionKey)
+	listaCheck := pipe.Get(ctx, pc.lastCheckTimestampKey)
+	productosVersion := pipe.Get(ctx, pc.productosVersionKey)
+	productosCheck := pipe.Get(ctx, pc.productosLastCheckKey)
+	invalidatedAll := pipe.Get(ctx, invalidatedAllKey)
+	// Las claves inexistentes vienen como redis.Nil en cada comando: se revisan por separado
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	status := &models.CacheVersionStatus{
+		ListaPrecios: models.CacheVersionGlobal{
+			Version:       listaVersion.Val(),
+			UltimoCheckBD: timestampRedis(listaCheck, time.Second),
+		},
+		Productos: models.CacheVersionGlobal{
+			Version:       productosVersion.Val(),
+			UltimoCheckBD: timestampRedis(productosCheck, time.Second),
+		},
+		UltimaInvalidacionTotal: timestampRedis(invalidatedAll, time.Millisecond),
+		IntervaloCheckSegundos:  pc.checkIntervalSeconds.Load(),
+	}
+	return status, nil
+}
+
+// timestampRedis convierte un timestamp Unix guardado en Redis en la unidad indicada
+// (nil si la clave no existe o no es un número)
+func timestampRedis(cmd *redis.StringCmd, unidad time.Duration) *time.Time {
+	valor, err := cmd.Int64()
+	if err != nil {
+		return nil
+	}
+	t := time.Unix(0, valor*int64(unidad))
+	return &t
+}
+
 // InvalidateAllByProductosVersion invalida toda la cache si la versión de productos cambió
 func (pc *ProductCache) InvalidateAllByProductosVersion(ctx context.Context, newVersion string) (bool, error) {
 	currentVersion, err := pc.GetProductosVersion(ctx)
@@ -290,18 +504,39 @@ func (pc *ProductCache) InvalidateAllByVersion(ctx context.Context, newVersion s
 func (pc *ProductCache) recordHit() {
 	pc.statsMutex.Lock()
 	pc.hits++
+	pc.ventana.recordHit(time.Now())
 	pc.statsMutex.Unlock()
 }
 
-// recordMiss registra un miss en el caché
-func (pc *ProductCache) recordMiss() {
+// recordMiss registra un miss en el caché con su causa
+func (pc *ProductCache) recordMiss(causa string) {
 	pc.statsMutex.Lock()
 	pc.misses++
+	pc.missCauses[causa]++
+	pc.ventana.recordMiss(time.Now())
+	pc.statsMutex.Unlock()
+}
+
+// RecordNotFound registra una búsqueda en BD donde el producto no existe
+func (pc *ProductCache) RecordNotFound() {
+	pc.statsMutex.Lock()
+	pc.notFound++
+	pc.statsMutex.Unlock()
+}
+
+// RecordLookupError registra una búsqueda en BD fallida por error de infraestructura
+func (pc *ProductCache) RecordLookupError() {
+	pc.statsMutex.Lock()
+	pc.lookupErrors++
 	pc.statsMutex.Unlock()
 }
 
 // SetProduct almacena un producto en ambos niveles de caché
 func (pc *ProductCache) SetProduct(ctx context.Context, codigoBarras string, producto *models.ProductoCompleto) error {
+	if pc.barcodeFilter != nil {
+		pc.barcodeFilter.Add(codigoBarras)
+	}
+
 	// 1. L1 Cache (memoria local)
 	pc.setToL1(codigoBarras, producto)
 
@@ -314,10 +549,15 @@ func (pc *ProductCache) InvalidateProduct(ctx context.Context, codigoBarras stri
 	// 1. L1 Cache
 	pc.l1Mutex.Lock()
 	delete(pc.l1Cache, codigoBarras)
+	delete(pc.l1Minimo, codigoBarras)
 	pc.l1Mutex.Unlock()
 
-	// 2. L2 Cache
-	return pc.redisClient.Del(ctx, fmt.Sprintf("product:%s", codigoBarras)).Err()
+	// 2. L2 Cache (y marca de invalidación para clasificar el próximo miss)
+	pipe := pc.redisClient.Pipeline()
+	pipe.Del(ctx, fmt.Sprintf("product:%s", codigoBarras), productMinimoPrefix+codigoBarras)
+	markInvalidated(ctx, pipe, codigoBarras, time.Now())
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
 // InvalidateProducts invalida múltiples productos por códigos de barras
@@ -330,13 +570,16 @@ func (pc *ProductCache) InvalidateProducts(ctx context.Context, codigosBarras []
 	pc.l1Mutex.Lock()
 	for _, codigo := range codigosBarras {
 		delete(pc.l1Cache, codigo)
+		delete(pc.l1Minimo, codigo)
 	}
 	pc.l1Mutex.Unlock()
 
 	// 2. L2 Cache - Invalidar en Redis (usar pipeline para mejor rendimiento)
 	pipe := pc.redisClient.Pipeline()
+	now := time.Now()
 	for _, codigo := range codigosBarras {
-		pipe.Del(ctx, fmt.Sprintf("product:%s", codigo))
+		pipe.Del(ctx, fmt.Sprintf("product:%s", codigo), productMinimoPrefix+codigo)
+		markInvalidated(ctx, pipe, codigo, now)
 	}
 	_, err := pipe.Exec(ctx)
 	if err != nil && err != redis.Nil {
@@ -368,6 +611,12 @@ func (pc *ProductCache) InvalidateByCodigoTivendo(ctx context.Context, codigoTiv
 			codigosInvalidar = append(codigosInvalidar, codigoBarras)
 		}
 	}
+	// El detalle mínimo puede seguir cacheado aunque el completo ya no esté
+	for codigoBarras, producto := range pc.l1Minimo {
+		if producto != nil && producto.Codigo == codigoTivendo {
+			codigosInvalidar = append(codigosInvalidar, codigoBarras)
+		}
+	}
 	pc.l1Mutex.RUnlock()
 
 	// 2. Buscar en L2 Cache (Redis) - buscar por patrón
@@ -394,6 +643,24 @@ func (pc *ProductCache) InvalidateByCodigoTivendo(ctx context.Context, codigoTiv
 			zap.Error(err))
 	}
 
+	iter = pc.redisClient.Scan(ctx, 0, productMinimoPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		data, err := pc.redisClient.Get(ctx, key).Bytes()
+		if err != nil {
+			continue
+		}
+		var producto models.ProductoPOSResponse
+		if json.Unmarshal(data, &producto) == nil && producto.Codigo == codigoTivendo {
+			codigosInvalidar = append(codigosInvalidar, key[len(productMinimoPrefix):])
+		}
+	}
+	if err := iter.Err(); err != nil {
+		pc.logger.Error("Error escaneando Redis para invalidación (detalle mínimo)",
+			zap.String("codigo_tivendo", codigoTivendo),
+			zap.Error(err))
+	}
+
 	// 3. Invalidar todos los productos encontrados
 	if len(codigosInvalidar) > 0 {
 		pc.logger.Info("Invalidando productos por código_tivendo",
@@ -414,18 +681,25 @@ func (pc *ProductCache) InvalidateAll(ctx context.Context) error {
 	pc.l1Mutex.Lock()
 	cantidadL1 := len(pc.l1Cache)
 	pc.l1Cache = make(map[string]*models.ProductoCompleto)
+	pc.l1Minimo = make(map[string]*models.ProductoPOSResponse)
 	pc.l1Mutex.Unlock()
 
-	// 2. L2 Cache - Eliminar todas las claves de productos
-	pattern := "product:*"
-	iter := pc.redisClient.Scan(ctx, 0, pattern, 0).Iterator()
-	var keys []string
-	for iter.Next(ctx) {
-		keys = append(keys, iter.Val())
+	// Marca de invalidación total antes de borrar: un miss posterior se atribuye a la invalidación
+	if err := pc.redisClient.Set(ctx, invalidatedAllKey, time.Now().UnixMilli(), productMetaTTL).Err(); err != nil {
+		pc.logger.Warn("Error registrando invalidación total de la cache", zap.Error(err))
 	}
-	if err := iter.Err(); err != nil {
-		pc.logger.Error("Error escaneando Redis para invalidación total", zap.Error(err))
-		return err
+
+	// 2. L2 Cache - Eliminar todas las claves de productos (ambos niveles de detalle)
+	var keys []string
+	for _, pattern := range []string{"product:*", productMinimoPrefix + "*"} {
+		iter := pc.redisClient.Scan(ctx, 0, pattern, 0).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			pc.logger.Error("Error escaneando Redis para invalidación total", zap.Error(err))
+			return err
+		}
 	}
 
 	if len(keys) > 0 {
@@ -442,6 +716,40 @@ func (pc *ProductCache) InvalidateAll(ctx context.Context) error {
 	return nil
 }
 
+// Entradas obtiene los productos en cache por código de barras, para diagnóstico (limite <= 0: todos)
+// Primero los del L1 (lo que sirve esta instancia) y luego los del L2 que falten
+func (pc *ProductCache) Entradas(ctx context.Context, limite int) (map[string]*models.ProductoCompleto, error) {
+	entradas := make(map[string]*models.ProductoCompleto)
+	completo := func() bool { return limite > 0 && len(entradas) >= limite }
+
+	pc.l1Mutex.RLock()
+	for codigoBarras, producto := range pc.l1Cache {
+		if completo() {
+			break
+		}
+		if producto != nil {
+			entradas[codigoBarras] = producto
+		}
+	}
+	pc.l1Mutex.RUnlock()
+
+	iter := pc.redisClient.Scan(ctx, 0, "product:*", 0).Iterator()
+	for !completo() && iter.Next(ctx) {
+		codigoBarras := iter.Val()[8:] // Remover "product:" del inicio
+		if _, ok := entradas[codigoBarras]; ok {
+			continue
+		}
+		if producto, err := pc.getFromL2(ctx, codigoBarras); err == nil && producto != nil {
+			entradas[codigoBarras] = producto
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return entradas, nil
+}
+
 // PreloadProducts pre-carga productos frecuentes
 func (pc *ProductCache) PreloadProducts(ctx context.Context, codigosBarras []string) error {
 	for _, codigo := range codigosBarras {
@@ -485,28 +793,121 @@ func (pc *ProductCache) evictLRU() {
 // getFromL2 obtiene un producto del L2 cache (Redis)
 func (pc *ProductCache) getFromL2(ctx context.Context, codigoBarras string) (*models.ProductoCompleto, error) {
 	key := fmt.Sprintf("product:%s", codigoBarras)
-	data, err := pc.redisClient.Get(ctx, key).Result()
+	data, err := pc.redisClient.Get(ctx, key).Bytes()
 	if err != nil {
 		return nil, err
 	}
 
-	var producto models.ProductoCompleto
-	if err := json.Unmarshal([]byte(data), &producto); err != nil {
-		return nil, err
-	}
-
-	return &producto, nil
+	producto, _, err := pc.codec.decode(data)
+	return producto, err
 }
 
 // setToL2 almacena un producto en el L2 cache (Redis)
 func (pc *ProductCache) setToL2(ctx context.Context, codigoBarras string, producto *models.ProductoCompleto) error {
 	key := fmt.Sprintf("product:%s", codigoBarras)
-	data, err := json.Marshal(producto)
+	data, err := pc.codec.encode(producto)
 	if err != nil {
 		return err
 	}
 
-	return pc.redisClient.Set(ctx, key, data, pc.ttl).Err()
+	pipe := pc.redisClient.Pipeline()
+	pipe.Set(ctx, key, data, pc.ttl)
+	metaKey := productMetaPrefix + codigoBarras
+	pipe.HSet(ctx, metaKey, "cached_at", time.Now().UnixMilli())
+	pipe.Expire(ctx, metaKey, productMetaTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// lookupL2 busca el producto en Redis; si no está, retorna la causa del miss
+// La metadata se lee en el mismo pipeline para no agregar otra ida a Redis
+func (pc *ProductCache) lookupL2(ctx context.Context, codigoBarras string) (*models.ProductoCompleto, string) {
+	pipe := pc.redisClient.Pipeline()
+	get := pipe.Get(ctx, fmt.Sprintf("product:%s", codigoBarras))
+	meta := pipe.HMGet(ctx, productMetaPrefix+codigoBarras, "cached_at", "invalidated_at")
+	invalidatedAll := pipe.Get(ctx, invalidatedAllKey)
+	// Las claves inexistentes vienen como redis.Nil en cada comando: se revisan por separado
+	pipe.Exec(ctx)
+
+	data, err := get.Bytes()
+	if err == nil {
+		producto, current, err := pc.codec.decode(data)
+		if err != nil {
+			return nil, MissDesconocido
+		}
+		if !current {
+			go pc.rewriteL2(codigoBarras, data, producto)
+		}
+		return producto, ""
+	}
+	if err != redis.Nil || meta.Err() != nil {
+		return nil, MissDesconocido
+	}
+
+	fields := meta.Val()
+	cachedAt := parseMillis(fields[0])
+	invalidatedAt := parseMillis(fields[1])
+	allAt, _ := invalidatedAll.Int64()
+
+	switch {
+	case invalidatedAt > 0 && invalidatedAt >= cachedAt:
+		return nil, MissInvalidado
+	case cachedAt == 0:
+		return nil, MissNuncaCacheado
+	case allAt >= cachedAt:
+		return nil, MissInvalidado
+	case time.Since(time.UnixMilli(cachedAt)) >= pc.ttl:
+		return nil, MissExpirado
+	default:
+		return nil, MissDesalojado
+	}
+}
+
+// rewriteL2Script reemplaza la entrada solo si no cambió desde que se leyó, conservando el TTL
+var rewriteL2Script = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('SET', KEYS[1], ARGV[2], 'KEEPTTL')
+end
+return false
+`)
+
+// rewriteL2 reescribe en el formato configurado una entrada leída en otro formato
+// (migración transparente: las entradas se convierten a medida que se usan)
+func (pc *ProductCache) rewriteL2(codigoBarras string, old []byte, producto *models.ProductoCompleto) {
+	data, err := pc.codec.encode(producto)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err = rewriteL2Script.Run(ctx, pc.redisClient, []string{fmt.Sprintf("product:%s", codigoBarras)}, old, data).Err()
+	if err != nil && err != redis.Nil {
+		pc.logger.Debug("Error reescribiendo entrada del L2 al formato configurado",
+			zap.String("codigo_barras", codigoBarras),
+			zap.Error(err))
+		return
+	}
+	if err == nil {
+		pc.l2Rewrites.Add(1)
+	}
+}
+
+// markInvalidated agrega al pipeline la marca de invalidación del producto
+func markInvalidated(ctx context.Context, pipe redis.Pipeliner, codigoBarras string, at time.Time) {
+	metaKey := productMetaPrefix + codigoBarras
+	pipe.HSet(ctx, metaKey, "invalidated_at", at.UnixMilli())
+	pipe.Expire(ctx, metaKey, productMetaTTL)
+}
+
+// parseMillis interpreta un campo de la metadata (0 si no existe)
+func parseMillis(v interface{}) int64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
 }
 
 // cleanupL1Cache limpia el L1 cache periódicamente
@@ -525,11 +926,24 @@ func (pc *ProductCache) cleanupL1Cache() {
 // Stats retorna estadísticas del caché (método legacy)
 func (pc *ProductCache) Stats() map[string]interface{} {
 	stats := pc.GetStats()
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"hits":           stats.Hits,
 		"misses":         stats.Misses,
 		"total_requests": stats.TotalRequests,
 		"total_keys":     stats.TotalKeys,
-		"hit_rate":       float64(stats.Hits) / float64(stats.TotalRequests),
+		"not_found":      stats.NotFound,
+		"lookup_errors":  stats.LookupErrors,
+		"miss_causes":    stats.MissCauses,
+		"l2_format":      pc.codec.format,
+		"l2_rewrites":    pc.l2Rewrites.Load(),
+		"hit_rate":       HitRate(stats.Hits, stats.Misses),
+
+		"hits_ultima_hora":     stats.HitsUltimaHora,
+		"misses_ultima_hora":   stats.MissesUltimaHora,
+		"hit_rate_ultima_hora": HitRate(stats.HitsUltimaHora, stats.MissesUltimaHora),
+	}
+	if pc.barcodeFilter != nil {
+		result["barcode_filter"] = pc.barcodeFilter.Stats()
 	}
+	return result
 }
@@ -0,0 +1,287 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// defaultConfigFiles archivos que se buscan en el directorio de trabajo si no se define CONFIG_FILE
+var defaultConfigFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+// fileKeys mapea cada clave del archivo (sección.clave) a su variable de entorno
+// Precedencia: variable de entorno (o .env) > archivo > valor por defecto; la resuelve viper
+// con una variable ligada por clave. Al agregar una variable en Load hay que agregar su clave acá
+var fileKeys = map[string]string{
+	"database.url":                         "DATABASE_URL",
+	"database.max_open_conns":              "DB_MAX_OPEN_CONNS",
+	"database.max_idle_conns":              "DB_MAX_IDLE_CONNS",
+	"database.conn_max_lifetime_minutes":   "DB_CONN_MAX_LIFETIME",
+	"database.pool_wait_alert_ms":          "DB_POOL_WAIT_ALERT_MS",
+	"database.pool_check_interval_seconds": "DB_POOL_CHECK_INTERVAL_SECONDS",
+	"database.heavy_max_concurrent":        "DB_HEAVY_MAX_CONCURRENT",
+	"database.heavy_max_wait_ms":           "DB_HEAVY_MAX_WAIT_MS",
+
+	"redis.url":      "REDIS_URL",
+	"redis.password": "REDIS_PASSWORD",
+	"redis.db":       "REDIS_DB",
+
+	"connect_retry.max_attempts":        "CONNECT_RETRY_MAX_ATTEMPTS",
+	"connect_retry.initial_backoff_ms":  "CONNECT_RETRY_INITIAL_BACKOFF_MS",
+	"connect_retry.max_backoff_seconds": "CONNECT_RETRY_MAX_BACKOFF_SECONDS",
+	"connect_retry.jitter_percent":      "CONNECT_RETRY_JITTER_PERCENT",
+
+	"server.port":                   "PORT",
+	"server.gin_mode":               "GIN_MODE",
+	"server.read_timeout_seconds":   "SERVER_READ_TIMEOUT_SECONDS",
+	"server.write_timeout_seconds":  "SERVER_WRITE_TIMEOUT_SECONDS",
+	"server.idle_timeout_seconds":   "SERVER_IDLE_TIMEOUT_SECONDS",
+	"server.tls_cert_file":          "TLS_CERT_FILE",
+	"server.tls_key_file":           "TLS_KEY_FILE",
+	"server.tls_autocert_domains":   "TLS_AUTOCERT_DOMAINS",
+	"server.tls_autocert_cache_dir": "TLS_AUTOCERT_CACHE_DIR",
+	"server.tls_autocert_email":     "TLS_AUTOCERT_EMAIL",
+	"server.tls_redirect_http":      "TLS_REDIRECT_HTTP",
+	"server.tls_http_port":          "TLS_HTTP_PORT",
+
+	"auth.jwt_secret":               "JWT_SECRET",
+	"auth.jwt_expiry_hours":         "JWT_EXPIRY_HOURS",
+	"auth.jwt_refresh_expiry_hours": "JWT_REFRESH_EXPIRY_HOURS",
+	"auth.jwt_protected_groups":     "JWT_PROTECTED_GROUPS",
+
+	"logging.level": "LOG_LEVEL",
+
+	"timeouts.pos_search_ms":      "TIMEOUT_POS_SEARCH_MS",
+	"timeouts.stock_operation_ms": "TIMEOUT_STOCK_OPERATION_MS",
+	"timeouts.report_ms":          "TIMEOUT_REPORT_MS",
+
+	"sales.duplicate_window_seconds": "DUPLICATE_SALE_WINDOW_SECONDS",
+	"sales.block_duplicates":         "DUPLICATE_SALE_BLOCK",
+	"sales.rounding_rules":           "SALES_ROUNDING_RULES",
+	"sales.tip_percent":              "SALES_TIP_PERCENT",
+	"sales.service_charge_percent":   "SALES_SERVICE_CHARGE_PERCENT",
+	"sales.service_charge_taxable":   "SALES_SERVICE_CHARGE_TAXABLE",
+	"sales.iva_percent":              "SALES_IVA_PERCENT",
+
+	"picking.ttl_minutes":                 "PICKING_TTL_MINUTES",
+	"picking.expiration_interval_seconds": "PICKING_EXPIRATION_INTERVAL_SECONDS",
+
+	"approval.cantidad_threshold": "APPROVAL_CANTIDAD_THRESHOLD",
+	"approval.monto_threshold":    "APPROVAL_MONTO_THRESHOLD",
+
+	"reception.tolerance_percent": "RECEPTION_TOLERANCE_PERCENT",
+
+	"credit_notes.max_days":      "CREDIT_NOTES_MAX_DAYS",
+	"credit_notes.allowed_roles": "CREDIT_NOTES_ALLOWED_ROLES",
+
+	"ecommerce.locales":      "ECOMMERCE_LOCALES",
+	"ecommerce.stock_buffer": "ECOMMERCE_STOCK_BUFFER",
+	"ecommerce.api_keys":     "ECOMMERCE_API_KEYS",
+
+	"stock_lock.enabled":     "STOCK_LOCK_ENABLED",
+	"stock_lock.ttl_seconds": "STOCK_LOCK_TTL_SECONDS",
+	"stock_lock.wait_ms":     "STOCK_LOCK_WAIT_MS",
+
+	"webhooks.urls":                   "WEBHOOK_URLS",
+	"webhooks.secret":                 "WEBHOOK_SECRET",
+	"webhooks.dispatch_interval_ms":   "WEBHOOK_DISPATCH_INTERVAL_MS",
+	"webhooks.batch_size":             "WEBHOOK_BATCH_SIZE",
+	"webhooks.max_attempts":           "WEBHOOK_MAX_ATTEMPTS",
+	"webhooks.timeout_ms":             "WEBHOOK_TIMEOUT_MS",
+	"webhooks.retention_days":         "WEBHOOK_RETENTION_DAYS",
+	"webhooks.callback_allowed_hosts": "WEBHOOK_CALLBACK_ALLOWED_HOSTS",
+
+	"jobs.storage":         "JOBS_STORAGE",
+	"jobs.dir":             "JOBS_DIR",
+	"jobs.bucket_url":      "JOBS_BUCKET_URL",
+	"jobs.bucket_token":    "JOBS_BUCKET_TOKEN",
+	"jobs.timeout_minutes": "JOBS_TIMEOUT_MINUTES",
+	"jobs.retention_hours": "JOBS_RETENTION_HOURS",
+
+	"degraded.enabled":                    "DEGRADED_MODE_ENABLED",
+	"degraded.check_interval_seconds":     "DEGRADED_CHECK_INTERVAL_SECONDS",
+	"degraded.failure_threshold":          "DEGRADED_FAILURE_THRESHOLD",
+	"degraded.max_queued_sales":           "DEGRADED_MAX_QUEUED_SALES",
+	"degraded.reconcile_interval_seconds": "DEGRADED_RECONCILE_INTERVAL_SECONDS",
+
+	"report_aggregates.enabled":               "REPORT_AGGREGATES_ENABLED",
+	"report_aggregates.interval_minutes":      "REPORT_AGGREGATES_INTERVAL_MINUTES",
+	"report_aggregates.chunk_days":            "REPORT_AGGREGATES_CHUNK_DAYS",
+	"expiry_alerts.enabled":                   "EXPIRY_ALERTS_ENABLED",
+	"expiry_alerts.hour":                      "EXPIRY_ALERTS_HOUR",
+	"expiry_alerts.thresholds_days":           "EXPIRY_ALERTS_THRESHOLDS_DAYS",
+	"expired_lots.enabled":                    "EXPIRED_LOTS_ENABLED",
+	"expired_lots.hour":                       "EXPIRED_LOTS_HOUR",
+	"expired_lots.dry_run":                    "EXPIRED_LOTS_DRY_RUN",
+	"expired_lots.user_id":                    "EXPIRED_LOTS_USER_ID",
+	"cycle_counts.enabled":                    "CYCLE_COUNTS_ENABLED",
+	"cycle_counts.weekday":                    "CYCLE_COUNTS_WEEKDAY",
+	"cycle_counts.hour":                       "CYCLE_COUNTS_HOUR",
+	"cycle_counts.criteria":                   "CYCLE_COUNTS_CRITERIA",
+	"cycle_counts.items_per_session":          "CYCLE_COUNTS_ITEMS_PER_SESSION",
+	"cycle_counts.sessions_per_local":         "CYCLE_COUNTS_SESSIONS_PER_LOCAL",
+	"cycle_counts.due_days":                   "CYCLE_COUNTS_DUE_DAYS",
+	"cycle_counts.user_ids":                   "CYCLE_COUNTS_USER_IDS",
+	"pdt.delimiter":                           "PDT_DELIMITER",
+	"pdt.code_column":                         "PDT_CODE_COLUMN",
+	"pdt.quantity_column":                     "PDT_QUANTITY_COLUMN",
+	"pdt.timestamp_column":                    "PDT_TIMESTAMP_COLUMN",
+	"pdt.timestamp_format":                    "PDT_TIMESTAMP_FORMAT",
+	"pdt.skip_header":                         "PDT_SKIP_HEADER",
+	"pdt.max_size_kb":                         "PDT_MAX_SIZE_KB",
+	"shifts.enabled":                          "SHIFTS_ENABLED",
+	"shifts.roles":                            "SHIFTS_ROLES",
+	"shifts.tolerance_minutes":                "SHIFTS_TOLERANCE_MINUTES",
+	"shifts.max_exception_hours":              "SHIFTS_MAX_EXCEPTION_HOURS",
+	"critical_products.sla_target_percent":    "CRITICAL_PRODUCTS_SLA_TARGET_PERCENT",
+	"critical_products.window_days":           "CRITICAL_PRODUCTS_WINDOW_DAYS",
+	"response_signing.enabled":                "RESPONSE_SIGNING_ENABLED",
+	"response_signing.paths":                  "RESPONSE_SIGNING_PATHS",
+	"response_signing.check_interval_seconds": "RESPONSE_SIGNING_CHECK_INTERVAL_SECONDS",
+	"response_signing.retired_key_hours":      "RESPONSE_SIGNING_RETIRED_KEY_HOURS",
+	"metrics.token":                           "METRICS_TOKEN",
+	"edge.central_url":                        "EDGE_CENTRAL_URL",
+	"edge.api_key":                            "EDGE_API_KEY",
+	"edge.local_id":                           "EDGE_LOCAL_ID",
+	"edge.sync_interval_seconds":              "EDGE_SYNC_INTERVAL_SECONDS",
+	"edge.data_dir":                           "EDGE_DATA_DIR",
+	"edge.timeout_seconds":                    "EDGE_TIMEOUT_SECONDS",
+	"edge.max_queued_writes":                  "EDGE_MAX_QUEUED_WRITES",
+
+	"images.storage":             "IMAGES_STORAGE",
+	"images.dir":                 "IMAGES_DIR",
+	"images.bucket_url":          "IMAGES_BUCKET_URL",
+	"images.bucket_token":        "IMAGES_BUCKET_TOKEN",
+	"images.public_base_url":     "IMAGES_PUBLIC_BASE_URL",
+	"images.max_size_kb":         "IMAGES_MAX_SIZE_KB",
+	"images.thumbnail_size":      "IMAGES_THUMBNAIL_SIZE",
+	"images.cache_max_age_hours": "IMAGES_CACHE_MAX_AGE_HOURS",
+
+	"cache.l1_max_size":                    "CACHE_L1_MAX_SIZE",
+	"cache.ttl_minutes":                    "CACHE_TTL_MINUTES",
+	"cache.version_check_interval_seconds": "CACHE_VERSION_CHECK_INTERVAL_SECONDS",
+	"cache.invalidation_retry_seconds":     "CACHE_INVALIDATION_RETRY_SECONDS",
+	"cache.invalidation_max_attempts":      "CACHE_INVALIDATION_MAX_ATTEMPTS",
+	"cache.l2_format":                      "CACHE_L2_FORMAT",
+	"cache.l2_compress_min_bytes":          "CACHE_L2_COMPRESS_MIN_BYTES",
+	"cache.barcode_filter_rebuild_minutes": "CACHE_BARCODE_FILTER_REBUILD_MINUTES",
+
+	"quotas.api_keys":           "API_KEYS",
+	"quotas.default_per_minute": "API_KEY_DEFAULT_PER_MINUTE",
+	"quotas.default_per_day":    "API_KEY_DEFAULT_PER_DAY",
+
+	"maintenance.message":                "MAINTENANCE_MESSAGE",
+	"maintenance.retry_after_seconds":    "MAINTENANCE_RETRY_AFTER_SECONDS",
+	"maintenance.check_interval_seconds": "MAINTENANCE_CHECK_INTERVAL_SECONDS",
+}
+
+// featuresSection sección libre nombre -> bool del archivo que se traduce a FEATURE_FLAGS
+const featuresSection = "features"
+
+// envFileKeys índice inverso de fileKeys: variable de entorno -> clave del archivo
+var envFileKeys = func() map[string]string {
+	keys := make(map[string]string, len(fileKeys))
+	for fileKey, envKey := range fileKeys {
+		keys[envKey] = fileKey
+	}
+	return keys
+}()
+
+// settings configuración del archivo con las variables de entorno ligadas
+// Solo se reemplaza en Load (bajo loadMu)
+var settings = viper.New()
+
+// configFilePath retorna el archivo a usar: CONFIG_FILE o el primero de los por defecto que exista
+func configFilePath() (string, error) {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("CONFIG_FILE=%s: %w", path, err)
+		}
+		return path, nil
+	}
+
+	for _, name := range defaultConfigFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// loadSettings lee el archivo YAML/TOML (si hay) con viper y liga cada clave a su variable de entorno
+// Las claves desconocidas son un error para detectar typos en vez de ignorarlos
+func loadSettings(path string) (*viper.Viper, error) {
+	v := viper.New()
+
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			if _, ok := err.(viper.UnsupportedConfigError); ok {
+				return nil, fmt.Errorf("%s: formato no soportado (use .yaml, .yml o .toml)", path)
+			}
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		var unknown []string
+		for _, key := range v.AllKeys() {
+			if _, ok := fileKeys[key]; ok || strings.HasPrefix(key, featuresSection+".") {
+				continue
+			}
+			unknown = append(unknown, key)
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			return nil, fmt.Errorf("%s: claves desconocidas: %s", path, strings.Join(unknown, ", "))
+		}
+	}
+
+	for fileKey, envKey := range fileKeys {
+		if err := v.BindEnv(fileKey, envKey); err != nil {
+			return nil, fmt.Errorf("error ligando %s: %w", envKey, err)
+		}
+	}
+
+	return v, nil
+}
+
+// lookup valor de la variable: el entorno y, si no está, el archivo de configuración
+func lookup(key string) string {
+	if fileKey, ok := envFileKeys[key]; ok {
+		return fileValue(settings.Get(fileKey))
+	}
+	if value := os.Getenv(key); value != "" || key != "FEATURE_FLAGS" {
+		return value
+	}
+	return fileFeatureFlags()
+}
+
+// fileFeatureFlags traduce la sección features del archivo al formato de FEATURE_FLAGS
+func fileFeatureFlags() string {
+	entries := settings.GetStringMap(featuresSection)
+	flags := make([]string, 0, len(entries))
+	for name, value := range entries {
+		flags = append(flags, fmt.Sprintf("%s=%v", name, value))
+	}
+	sort.Strings(flags)
+	return strings.Join(flags, ",")
+}
+
+// fileValue convierte un valor del archivo al formato de variable de entorno
+// (las listas se unen por comas, ej: tls_autocert_domains)
+func fileValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
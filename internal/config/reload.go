@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+// processEnvKeys variables definidas en el entorno del proceso al arrancar
+// Al recargar, el .env nunca pisa estas variables (misma precedencia que en Load)
+var processEnvKeys = snapshotEnvKeys()
+
+// ReloadResult resume qué cambió en una recarga
+type ReloadResult struct {
+	// Secciones recargables que cambiaron y ya se aplicaron
+	Applied []string `json:"applied"`
+	// Secciones que cambiaron pero requieren reiniciar el servicio
+	RequiresRestart []string `json:"requires_restart"`
+}
+
+// Manager mantiene la configuración vigente y permite recargar en caliente
+// el subconjunto recargable: Cache.VersionCheckInterval y Features
+type Manager struct {
+	mu        sync.RWMutex
+	current   *Config
+	listeners []func(*Config)
+}
+
+// NewManager crea el manager a partir de la configuración cargada al arrancar
+func NewManager(cfg *Config) *Manager {
+	return &Manager{current: cfg}
+}
+
+// Current retorna una copia de la configuración vigente
+func (m *Manager) Current() Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return *m.current
+}
+
+// OnReload registra una función que se ejecuta tras cada recarga con la configuración nueva
+func (m *Manager) OnReload(fn func(*Config)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// Reload vuelve a leer .env y variables de entorno, valida y aplica el subconjunto recargable
+// Si la configuración nueva es inválida no se aplica nada
+func (m *Manager) Reload() (*ReloadResult, error) {
+	if err := reloadEnvFile(); err != nil {
+		return nil, err
+	}
+
+	next, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	result := &ReloadResult{
+		Applied:         []string{},
+		RequiresRestart: changedSections(m.current, next),
+	}
+
+	if m.current.Cache.VersionCheckInterval != next.Cache.VersionCheckInterval {
+		m.current.Cache.VersionCheckInterval = next.Cache.VersionCheckInterval
+		result.Applied = append(result.Applied, "cache.version_check_interval")
+	}
+	if !reflect.DeepEqual(m.current.Features, next.Features) {
+		m.current.Features = next.Features
+		result.Applied = append(result.Applied, "features")
+	}
+
+	applied := *m.current
+	listeners := append([]func(*Config){}, m.listeners...)
+	m.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(&applied)
+	}
+
+	return result, nil
+}
+
+// changedSections lista las secciones no recargables que difieren entre ambas configuraciones
+func changedSections(current, next *Config) []string {
+	sections := []struct {
+		name string
+		a, b interface{}
+	}{
+		{name: "database", a: current.Database, b: next.Database},
+		{name: "redis", a: current.Redis, b: next.Redis},
+		{name: "connect_retry", a: current.ConnectRetry, b: next.ConnectRetry},
+		{name: "server", a: current.Server, b: next.Server},
+		{name: "jwt", a: current.JWT, b: next.JWT},
+		{name: "logging", a: current.Logging, b: next.Logging},
+		{name: "timeouts", a: current.Timeouts, b: next.Timeouts},
+		{name: "sales", a: current.Sales, b: next.Sales},
+		{name: "picking", a: current.Picking, b: next.Picking},
+		{name: "approval", a: current.Approval, b: next.Approval},
+		{name: "reception", a: current.Reception, b: next.Reception},
+		{name: "credit_notes", a: current.CreditNotes, b: next.CreditNotes},
+		{name: "ecommerce", a: current.Ecommerce, b: next.Ecommerce},
+		{name: "stock_lock", a: current.StockLock, b: next.StockLock},
+		{name: "webhooks", a: current.Webhooks, b: next.Webhooks},
+		{name: "degraded", a: current.Degraded, b: next.Degraded},
+		{name: "report_aggregates", a: current.ReportAggregates, b: next.ReportAggregates},
+		{name: "expiry_alerts", a: current.ExpiryAlerts, b: next.ExpiryAlerts},
+		{name: "expired_lots", a: current.ExpiredLots, b: next.ExpiredLots},
+		{name: "cycle_counts", a: current.CycleCounts, b: next.CycleCounts},
+		{name: "pdt", a: current.PDT, b: next.PDT},
+		{name: "shifts", a: current.Shifts, b: next.Shifts},
+		{name: "critical_products", a: current.CriticalProducts, b: next.CriticalProducts},
+		{name: "response_signing", a: current.ResponseSigning, b: next.ResponseSigning},
+		{name: "metrics", a: current.Metrics, b: next.Metrics},
+		{name: "edge", a: current.Edge, b: next.Edge},
+		{name: "images", a: current.Images, b: next.Images},
+		{name: "quotas", a: current.Quotas, b: next.Quotas},
+		{name: "maintenance", a: current.Maintenance, b: next.Maintenance},
+		// De cache solo el intervalo de verificación es recargable
+		{name: "cache", a: withoutCheckInterval(current.Cache), b: withoutCheckInterval(next.Cache)},
+	}
+
+	changed := []string{}
+	for _, s := range sections {
+		if !reflect.DeepEqual(s.a, s.b) {
+			changed = append(changed, s.name)
+		}
+	}
+	return changed
+}
+
+// withoutCheckInterval copia de cache sin el campo recargable, para comparar el resto
+func withoutCheckInterval(c CacheConfig) CacheConfig {
+	c.VersionCheckInterval = 0
+	return c
+}
+
+// reloadEnvFile vuelve a aplicar el .env sobre las variables que no vienen del proceso
+func reloadEnvFile() error {
+	values, err := godotenv.Read()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("error leyendo .env: %w", err)
+	}
+
+	for key, value := range values {
+		if processEnvKeys[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("error aplicando %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// snapshotEnvKeys registra los nombres de las variables de entorno actuales
+func snapshotEnvKeys() map[string]bool {
+	keys := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		if key, _, ok := strings.Cut(kv, "="); ok {
+			keys[key] = true
+		}
+	}
+	return keys
+}
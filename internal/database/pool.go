@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"stock-service/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// PoolMonitor vigila la espera por conexiones del pool y permite ajustar sus límites en caliente
+// La alerta se activa cuando la espera promedio de una ventana supera el umbral
+type PoolMonitor struct {
+	db        *sql.DB
+	threshold time.Duration
+	interval  time.Duration
+	logger    *zap.Logger
+
+	mu            sync.Mutex
+	maxOpen       int
+	maxIdle       int
+	lastWaitCount int64
+	lastWaitDur   time.Duration
+	recentWaits   int64
+	recentAvgWait time.Duration
+	alertSince    *time.Time
+}
+
+// NewPoolMonitor crea el monitor con los límites con que se abrió el pool
+func NewPoolMonitor(db *sql.DB, maxOpen, maxIdle int, threshold, interval time.Duration, logger *zap.Logger) *PoolMonitor {
+	stats := db.Stats()
+	return &PoolMonitor{
+		db:            db,
+		threshold:     threshold,
+		interval:      interval,
+		logger:        logger,
+		maxOpen:       maxOpen,
+		maxIdle:       maxIdle,
+		lastWaitCount: stats.WaitCount,
+		lastWaitDur:   stats.WaitDuration,
+	}
+}
+
+// Start verifica la espera del pool cada intervalo hasta que se cancele ctx
+func (p *PoolMonitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.check()
+			}
+		}
+	}()
+}
+
+// check calcula la espera promedio de la última ventana y actualiza la alerta
+func (p *PoolMonitor) check() {
+	stats := p.db.Stats()
+
+	p.mu.Lock()
+	waits := stats.WaitCount - p.lastWaitCount
+	waited := stats.WaitDuration - p.lastWaitDur
+	p.lastWaitCount = stats.WaitCount
+	p.lastWaitDur = stats.WaitDuration
+
+	p.recentWaits = waits
+	p.recentAvgWait = 0
+	if waits > 0 {
+		p.recentAvgWait = waited / time.Duration(waits)
+	}
+	avg := p.recentAvgWait
+
+	entering := p.alertSince == nil && avg > p.threshold
+	recovered := p.alertSince != nil && avg <= p.threshold
+	var since time.Time
+	switch {
+	case entering:
+		now := time.Now()
+		p.alertSince = &now
+	case recovered:
+		since = *p.alertSince
+		p.alertSince = nil
+	}
+	maxOpen := p.maxOpen
+	p.mu.Unlock()
+
+	if entering {
+		p.logger.Warn("Espera por conexiones del pool de PostgreSQL sobre el umbral",
+			zap.String("operation", "db_pool_wait"),
+			zap.Duration("avg_wait", avg),
+			zap.Duration("threshold", p.threshold),
+			zap.Int64("waits", waits),
+			zap.Int("in_use", stats.InUse),
+			zap.Int("max_open_conns", maxOpen))
+	}
+	if recovered {
+		p.logger.Info("Espera por conexiones del pool de PostgreSQL normalizada",
+			zap.String("operation", "db_pool_wait"),
+			zap.Duration("duracion_alerta", time.Since(since)))
+	}
+}
+
+// Stats retorna el estado del pool y de la alerta de espera
+func (p *PoolMonitor) Stats() models.DatabasePoolMetrics {
+	stats := p.db.Stats()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return models.DatabasePoolMetrics{
+		MaxOpenConns:     p.maxOpen,
+		MaxIdleConns:     p.maxIdle,
+		Open:             stats.OpenConnections,
+		InUse:            stats.InUse,
+		Idle:             stats.Idle,
+		WaitCount:        stats.WaitCount,
+		WaitDurationMs:   durationMs(stats.WaitDuration),
+		RecentWaits:      p.recentWaits,
+		RecentAvgWaitMs:  durationMs(p.recentAvgWait),
+		WaitAlert:        p.alertSince != nil,
+		WaitAlertSince:   p.alertSince,
+		AlertThresholdMs: durationMs(p.threshold),
+	}
+}
+
+// Resize ajusta los límites del pool sin reiniciar (no persiste: al reiniciar rige la configuración)
+// Bajar MaxOpenConns no corta las conexiones en uso: se cierran al liberarse
+func (p *PoolMonitor) Resize(maxOpen, maxIdle int) error {
+	if maxOpen < 1 {
+		return fmt.Errorf("max_open_conns debe ser al menos 1 (actual: %d)", maxOpen)
+	}
+	if maxIdle < 0 || maxIdle > maxOpen {
+		return fmt.Errorf("max_idle_conns debe estar entre 0 y max_open_conns (actual: %d)", maxIdle)
+	}
+
+	p.mu.Lock()
+	prevOpen, prevIdle := p.maxOpen, p.maxIdle
+	p.maxOpen, p.maxIdle = maxOpen, maxIdle
+	p.db.SetMaxOpenConns(maxOpen)
+	p.db.SetMaxIdleConns(maxIdle)
+	p.mu.Unlock()
+
+	p.logger.Warn("Pool de conexiones de PostgreSQL ajustado en caliente",
+		zap.String("operation", "db_pool_resize"),
+		zap.Int("max_open_conns_anterior", prevOpen),
+		zap.Int("max_open_conns", maxOpen),
+		zap.Int("max_idle_conns_anterior", prevIdle),
+		zap.Int("max_idle_conns", maxIdle))
+	return nil
+}
+
+// durationMs convierte a milisegundos con decimales
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
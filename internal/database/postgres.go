@@ -26,6 +26,7 @@ func NewPostgresDB(dsn string, maxOpenConns, maxIdleConns int, connMaxLifetime t
 
 	// Verificar conexión
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
@@ -32,6 +32,7 @@ func NewRedisDB(url, password string, db int, logger *zap.Logger) (*RedisDB, err
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to ping Redis: %w", err)
 	}
 
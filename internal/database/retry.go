@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"stock-service/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// ConnectWithRetry ejecuta connect hasta que tenga éxito o se agoten los intentos
+// La espera entre intentos se duplica hasta MaxBackoff, con ±JitterPercent aleatorio
+// para que varias réplicas no reintenten al mismo tiempo
+func ConnectWithRetry(ctx context.Context, cfg config.ConnectRetryConfig, target string, logger *zap.Logger, connect func() error) error {
+	attempts := cfg.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := cfg.InitialBackoff
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = connect(); err == nil {
+			if attempt > 1 {
+				logger.Info("Connection established after retries",
+					zap.String("target", target),
+					zap.Int("attempt", attempt))
+			}
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		wait := withJitter(backoff, cfg.JitterPercent)
+		logger.Warn("Connection attempt failed, retrying",
+			zap.String("target", target),
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", attempts),
+			zap.Duration("retry_in", wait),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("connection to %s cancelled: %w", target, ctx.Err())
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+		if backoff > cfg.MaxBackoff {
+			backoff = cfg.MaxBackoff
+		}
+	}
+
+	return fmt.Errorf("failed to connect to %s after %d attempts: %w", target, attempts, err)
+}
+
+// withJitter aplica una variación aleatoria de ±percent% a d
+func withJitter(d time.Duration, percent int) time.Duration {
+	if percent <= 0 || d <= 0 {
+		return d
+	}
+	delta := int64(d) * int64(percent) / 100
+	if delta <= 0 {
+		return d
+	}
+	return d + time.Duration(rand.Int63n(2*delta+1)-delta)
+}
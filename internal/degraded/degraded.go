@@ -0,0 +1,144 @@
+// Package degraded detecta la caída de PostgreSQL y señaliza el modo lectura de emergencia
+// Cada réplica verifica la BD por su cuenta: el modo refleja lo que ve la réplica que responde
+package degraded
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"stock-service/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// State estado del modo degradado
+type State struct {
+	Activo             bool       `json:"activo"`
+	Desde              *time.Time `json:"desde,omitempty"`
+	FallosConsecutivos int        `json:"fallos_consecutivos"`
+	UltimoError        string     `json:"ultimo_error,omitempty"`
+}
+
+// Monitor verifica PostgreSQL periódicamente y entra en modo degradado tras FailureThreshold
+// verificaciones fallidas seguidas; sale con la primera exitosa
+// Es seguro llamarlo sobre un *Monitor nil (nunca degradado)
+type Monitor struct {
+	db     *sql.DB
+	config config.DegradedConfig
+	logger *zap.Logger
+
+	mu        sync.RWMutex
+	state     State
+	listeners []func()
+}
+
+// New crea el monitor (no degradado hasta que se detecten fallas)
+func New(db *sql.DB, cfg config.DegradedConfig, logger *zap.Logger) *Monitor {
+	return &Monitor{
+		db:     db,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// OnRecover registra una función a ejecutar cuando la BD vuelve (en su propia goroutine)
+func (m *Monitor) OnRecover(fn func()) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// Start inicia las verificaciones hasta que se cancele ctx (no hace nada si está deshabilitado)
+func (m *Monitor) Start(ctx context.Context) {
+	if m == nil || !m.config.Enabled {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(m.config.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.check(ctx)
+			}
+		}
+	}()
+}
+
+// check verifica la BD y actualiza el estado
+func (m *Monitor) check(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, m.config.CheckInterval)
+	err := m.db.PingContext(pingCtx)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.mu.Lock()
+	if err == nil {
+		recovered := m.state.Activo
+		desde := m.state.Desde
+		m.state = State{}
+		listeners := append([]func(){}, m.listeners...)
+		m.mu.Unlock()
+
+		if recovered {
+			m.logger.Warn("PostgreSQL disponible nuevamente, saliendo del modo degradado",
+				zap.Duration("duracion", time.Since(*desde)))
+			for _, fn := range listeners {
+				go fn()
+			}
+		}
+		return
+	}
+
+	m.state.FallosConsecutivos++
+	m.state.UltimoError = err.Error()
+	entering := !m.state.Activo && m.state.FallosConsecutivos >= m.config.FailureThreshold
+	if entering {
+		now := time.Now()
+		m.state.Activo = true
+		m.state.Desde = &now
+	}
+	fallos := m.state.FallosConsecutivos
+	m.mu.Unlock()
+
+	if entering {
+		m.logger.Error("PostgreSQL no responde, entrando en modo degradado (búsquedas solo-cache y ventas encoladas)",
+			zap.Int("fallos_consecutivos", fallos),
+			zap.Error(err))
+		return
+	}
+	m.logger.Warn("Verificación de PostgreSQL fallida",
+		zap.Int("fallos_consecutivos", fallos),
+		zap.Error(err))
+}
+
+// Active indica si el servicio está en modo degradado
+func (m *Monitor) Active() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.Activo
+}
+
+// Current retorna el estado vigente
+func (m *Monitor) Current() State {
+	if m == nil {
+		return State{}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
@@ -0,0 +1,448 @@
+// Package edge implementa el modo edge del binario para locales con enlace inestable
+// Mantiene una réplica local del catálogo y del stock del local, sincronizada desde el central
+// por /api/v1/sync/delta, para responder al POS sin depender del enlace. Las escrituras se
+// reenvían al central; las ventas rápidas sin enlace se encolan en disco y se envían en orden
+// cuando el enlace vuelve
+package edge
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// apiKeyHeader header con que el edge se identifica ante el central (ver middleware.APIKeyHeader)
+const apiKeyHeader = "X-API-Key"
+
+// Estado estado del enlace, de la réplica y de la cola
+type Estado struct {
+	IDLocal              int        `json:"id_local"`
+	Central              string     `json:"central"`
+	Enlace               bool       `json:"enlace"`
+	UltimoContacto       *time.Time `json:"ultimo_contacto,omitempty"`
+	UltimoError          string     `json:"ultimo_error,omitempty"`
+	FallosConsecutivos   int        `json:"fallos_consecutivos"`
+	Productos            int        `json:"productos"`
+	Stock                int        `json:"stock"`
+	Cursor               *time.Time `json:"cursor,omitempty"`
+	SincronizadaAt       *time.Time `json:"sincronizada_at,omitempty"`
+	EscriturasPendientes int        `json:"escrituras_pendientes"`
+}
+
+// Respuesta respuesta del central a una escritura reenviada
+type Respuesta struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Node nodo edge: réplica, cola de escrituras y estado del enlace con el central
+type Node struct {
+	config  config.EdgeConfig
+	central *url.URL
+	client  *http.Client
+	replica *Replica
+	cola    *Cola
+	proxy   *httputil.ReverseProxy
+	logger  *zap.Logger
+
+	mu             sync.RWMutex
+	enlace         bool
+	ultimoContacto *time.Time
+	ultimoError    string
+	fallos         int
+
+	sincronizar chan struct{}
+}
+
+// New crea el nodo cargando la réplica y la cola de DataDir
+// El enlace se considera caído hasta el primer contacto con el central
+func New(cfg config.EdgeConfig, logger *zap.Logger) (*Node, error) {
+	if cfg.CentralURL == "" || cfg.LocalID <= 0 {
+		return nil, fmt.Errorf("EDGE_CENTRAL_URL y EDGE_LOCAL_ID son requeridos para el modo edge")
+	}
+	central, err := url.Parse(cfg.CentralURL)
+	if err != nil {
+		return nil, fmt.Errorf("EDGE_CENTRAL_URL inválida: %w", err)
+	}
+
+	replica, err := LoadReplica(filepath.Join(cfg.DataDir, "replica.json"), cfg.LocalID)
+	if err != nil {
+		return nil, err
+	}
+	cola, err := LoadCola(filepath.Join(cfg.DataDir, "escrituras_pendientes.json"), cfg.MaxQueuedWrites)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Node{
+		config:      cfg,
+		central:     central,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		replica:     replica,
+		cola:        cola,
+		logger:      logger,
+		sincronizar: make(chan struct{}, 1),
+	}
+	n.proxy = n.newProxy()
+	return n, nil
+}
+
+// Replica réplica local del catálogo y del stock
+func (n *Node) Replica() *Replica {
+	return n.replica
+}
+
+// IDLocal local replicado
+func (n *Node) IDLocal() int {
+	return n.config.LocalID
+}
+
+// Enlace indica si el último contacto con el central fue exitoso
+func (n *Node) Enlace() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.enlace
+}
+
+// Estado estado actual del nodo
+func (n *Node) Estado() Estado {
+	productos, stock, cursor, sincronizadaAt := n.replica.Resumen()
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return Estado{
+		IDLocal:              n.config.LocalID,
+		Central:              n.central.String(),
+		Enlace:               n.enlace,
+		UltimoContacto:       n.ultimoContacto,
+		UltimoError:          n.ultimoError,
+		FallosConsecutivos:   n.fallos,
+		Productos:            productos,
+		Stock:                stock,
+		Cursor:               cursor,
+		SincronizadaAt:       sincronizadaAt,
+		EscriturasPendientes: n.cola.Len(),
+	}
+}
+
+// Pendientes escrituras en espera de enlace
+func (n *Node) Pendientes() []EscrituraPendiente {
+	return n.cola.Pendientes()
+}
+
+// Start sincroniza al arrancar y luego cada SyncInterval hasta que se cancele ctx
+// Antes de pedir cambios se envían las escrituras pendientes, para que el delta ya las refleje
+func (n *Node) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(n.config.SyncInterval)
+		defer ticker.Stop()
+
+		for {
+			n.enviarPendientes(ctx)
+			n.sincronizarReplica(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-n.sincronizar:
+			}
+		}
+	}()
+}
+
+// SincronizarAhora adelanta la próxima sincronización (tras una escritura que modifica stock)
+func (n *Node) SincronizarAhora() {
+	select {
+	case n.sincronizar <- struct{}{}:
+	default:
+	}
+}
+
+// Proxy reenvía el request al central tal cual; sin enlace responde 503 sin intentarlo
+// (el POS no espera el timeout; la sincronización periódica detecta cuándo vuelve)
+func (n *Node) Proxy(w http.ResponseWriter, r *http.Request) {
+	if !n.Enlace() {
+		escribirSinEnlace(w, "El central no responde; solo están disponibles las consultas de la réplica local")
+		return
+	}
+	n.proxy.ServeHTTP(w, r)
+}
+
+// Reenviar envía una escritura al central y retorna su respuesta
+// Un error significa que el central no respondió (el enlace queda marcado como caído)
+func (n *Node) Reenviar(ctx context.Context, method, path string, header http.Header, body []byte) (*Respuesta, error) {
+	req, err := http.NewRequestWithContext(ctx, method, n.central.String()+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	n.autenticar(req)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		n.registrarFallo(err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		n.registrarFallo(err)
+		return nil, fmt.Errorf("failed to read central response: %w", err)
+	}
+	n.registrarContacto()
+
+	return &Respuesta{Status: resp.StatusCode, Header: resp.Header, Body: respBody}, nil
+}
+
+// Encolar guarda la escritura para enviarla cuando vuelva el enlace
+func (n *Node) Encolar(method, path, contentType string, body []byte) (*EscrituraPendiente, error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	escritura := &EscrituraPendiente{
+		ID:          id,
+		Method:      method,
+		Path:        path,
+		ContentType: contentType,
+		Body:        body,
+		EncoladaAt:  time.Now(),
+	}
+	if err := n.cola.Encolar(escritura); err != nil {
+		return nil, err
+	}
+
+	n.logger.Warn("Escritura encolada sin enlace con el central",
+		zap.String("operation", "edge_encolar"),
+		zap.String("id", escritura.ID),
+		zap.String("method", method),
+		zap.String("path", path),
+		zap.Int("pendientes", n.cola.Len()))
+	return escritura, nil
+}
+
+// HayPendientes indica si hay escrituras esperando; las nuevas deben ir detrás para respetar el orden
+func (n *Node) HayPendientes() bool {
+	return n.cola.Len() > 0
+}
+
+// sincronizarReplica pide al central los cambios desde el cursor y los aplica
+func (n *Node) sincronizarReplica(ctx context.Context) {
+	query := url.Values{"local": {fmt.Sprint(n.config.LocalID)}}
+	if cursor := n.replica.Cursor(); cursor != nil {
+		query.Set("desde", cursor.Format(time.RFC3339Nano))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.central.String()+"/api/v1/sync/delta?"+query.Encode(), nil)
+	if err != nil {
+		n.logger.Error("Error armando la sincronización de la réplica", zap.Error(err))
+		return
+	}
+	n.autenticar(req)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			n.registrarFallo(err)
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// El central respondió: hay enlace, pero la réplica no avanza
+		n.registrarContacto()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		n.logger.Error("El central rechazó la sincronización de la réplica",
+			zap.Int("status", resp.StatusCode),
+			zap.String("body", string(body)))
+		return
+	}
+
+	var payload struct {
+		Data models.DeltaSync `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		n.registrarFallo(fmt.Errorf("failed to decode delta: %w", err))
+		return
+	}
+	n.registrarContacto()
+
+	n.replica.Aplicar(&payload.Data)
+	if err := n.replica.Guardar(); err != nil {
+		n.logger.Error("Error guardando la réplica local", zap.Error(err))
+	}
+
+	if len(payload.Data.Productos) > 0 || len(payload.Data.Stock) > 0 {
+		n.logger.Info("Réplica sincronizada",
+			zap.String("operation", "edge_sync"),
+			zap.Bool("completa", payload.Data.Completa),
+			zap.Int("productos", len(payload.Data.Productos)),
+			zap.Int("stock", len(payload.Data.Stock)),
+			zap.Time("cursor", payload.Data.Cursor))
+	}
+}
+
+// enviarPendientes envía la cola en orden; se detiene en la primera que no se pudo entregar
+// Un rechazo definitivo del central (4xx) se registra y se descarta para no bloquear la cola
+func (n *Node) enviarPendientes(ctx context.Context) {
+	for {
+		escritura := n.cola.Primera()
+		if escritura == nil || ctx.Err() != nil {
+			return
+		}
+
+		header := http.Header{}
+		if escritura.ContentType != "" {
+			header.Set("Content-Type", escritura.ContentType)
+		}
+		resp, err := n.Reenviar(ctx, escritura.Method, escritura.Path, header, escritura.Body)
+		if err == nil && reintentable(resp.Status) {
+			err = fmt.Errorf("el central respondió %d", resp.Status)
+		}
+		if err != nil {
+			if qErr := n.cola.Reintentar(escritura.ID, err); qErr != nil {
+				n.logger.Error("Error guardando la cola de escrituras", zap.Error(qErr))
+			}
+			return
+		}
+
+		if resp.Status >= http.StatusBadRequest {
+			n.logger.Error("Escritura encolada rechazada por el central, se descarta",
+				zap.String("operation", "edge_enviar_pendiente"),
+				zap.String("id", escritura.ID),
+				zap.String("path", escritura.Path),
+				zap.Int("status", resp.Status),
+				zap.ByteString("respuesta", resp.Body))
+		} else {
+			n.logger.Info("Escritura encolada enviada al central",
+				zap.String("operation", "edge_enviar_pendiente"),
+				zap.String("id", escritura.ID),
+				zap.String("path", escritura.Path),
+				zap.Duration("espera", time.Since(escritura.EncoladaAt)))
+		}
+		if err := n.cola.Quitar(escritura.ID); err != nil {
+			n.logger.Error("Error guardando la cola de escrituras", zap.Error(err))
+			return
+		}
+	}
+}
+
+// newProxy proxy inverso hacia el central para todo lo que el edge no resuelve localmente
+func (n *Node) newProxy() *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(n.central)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = n.central.Host
+		n.autenticar(req)
+	}
+	proxy.Transport = &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: n.config.Timeout,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	proxy.ModifyResponse = func(*http.Response) error {
+		n.registrarContacto()
+		return nil
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if r.Context().Err() == nil {
+			n.registrarFallo(err)
+		}
+		escribirSinEnlace(w, err.Error())
+	}
+	return proxy
+}
+
+// autenticar agrega la API key del edge si el request no trae una
+func (n *Node) autenticar(req *http.Request) {
+	if n.config.APIKey != "" && req.Header.Get(apiKeyHeader) == "" {
+		req.Header.Set(apiKeyHeader, n.config.APIKey)
+	}
+}
+
+// registrarContacto marca el enlace como activo
+func (n *Node) registrarContacto() {
+	now := time.Now()
+	n.mu.Lock()
+	recuperado := !n.enlace && n.ultimoContacto != nil
+	n.enlace = true
+	n.ultimoContacto = &now
+	n.ultimoError = ""
+	n.fallos = 0
+	n.mu.Unlock()
+
+	if recuperado {
+		n.logger.Warn("Enlace con el central recuperado", zap.String("central", n.central.String()))
+		n.SincronizarAhora()
+	}
+}
+
+// registrarFallo marca el enlace como caído
+func (n *Node) registrarFallo(err error) {
+	n.mu.Lock()
+	caido := n.enlace
+	n.enlace = false
+	n.ultimoError = err.Error()
+	n.fallos++
+	fallos := n.fallos
+	n.mu.Unlock()
+
+	if caido {
+		n.logger.Error("Sin enlace con el central, se atiende desde la réplica local",
+			zap.String("central", n.central.String()),
+			zap.Int("escrituras_pendientes", n.cola.Len()),
+			zap.Error(err))
+		return
+	}
+	n.logger.Debug("El central sigue sin responder", zap.Int("fallos_consecutivos", fallos), zap.Error(err))
+}
+
+// reintentable indica si una respuesta del central amerita reintentar más tarde
+func reintentable(status int) bool {
+	return status >= http.StatusInternalServerError ||
+		status == http.StatusRequestTimeout ||
+		status == http.StatusTooManyRequests
+}
+
+// escribirSinEnlace responde 503 con el formato de error del servicio
+func escribirSinEnlace(w http.ResponseWriter, causa string) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success": false,
+		"message": "❌ Sin enlace con el central",
+		"error":   causa,
+	})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = w.Write(body)
+}
+
+// randomID identificador de una escritura encolada
+func randomID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generando identificador: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
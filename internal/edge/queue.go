@@ -0,0 +1,127 @@
+package edge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrColaLlena la cola de escrituras pendientes alcanzó MaxQueuedWrites
+var ErrColaLlena = errors.New("cola de escrituras pendientes llena")
+
+// EscrituraPendiente request de escritura a reenviar al central cuando vuelva el enlace
+type EscrituraPendiente struct {
+	ID          string          `json:"id"`
+	Method      string          `json:"method"`
+	Path        string          `json:"path"` // incluye la query
+	ContentType string          `json:"content_type,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"` // JSON (las ventas se validan como JSON antes de encolar)
+	EncoladaAt  time.Time       `json:"encolada_at"`
+	Intentos    int             `json:"intentos"`
+	UltimoError string          `json:"ultimo_error,omitempty"`
+}
+
+// Cola escrituras pendientes en orden de llegada, persistidas en un archivo JSON
+type Cola struct {
+	path   string
+	maximo int
+
+	mu         sync.Mutex
+	pendientes []*EscrituraPendiente
+}
+
+// LoadCola carga las escrituras pendientes del archivo (vacía si no existe)
+func LoadCola(path string, maximo int) (*Cola, error) {
+	q := &Cola{path: path, maximo: maximo, pendientes: []*EscrituraPendiente{}}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue: %w", err)
+	}
+	if err := json.Unmarshal(raw, &q.pendientes); err != nil {
+		return nil, fmt.Errorf("failed to decode queue %s: %w", path, err)
+	}
+	return q, nil
+}
+
+// Encolar agrega la escritura al final y la persiste antes de retornar
+func (q *Cola) Encolar(escritura *EscrituraPendiente) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pendientes) >= q.maximo {
+		return fmt.Errorf("%w (%d)", ErrColaLlena, q.maximo)
+	}
+	q.pendientes = append(q.pendientes, escritura)
+	if err := q.guardar(); err != nil {
+		q.pendientes = q.pendientes[:len(q.pendientes)-1]
+		return err
+	}
+	return nil
+}
+
+// Primera retorna la escritura más antigua (nil si no hay)
+func (q *Cola) Primera() *EscrituraPendiente {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pendientes) == 0 {
+		return nil
+	}
+	return q.pendientes[0]
+}
+
+// Quitar saca la escritura más antigua (ya entregada o rechazada por el central)
+func (q *Cola) Quitar(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pendientes) == 0 || q.pendientes[0].ID != id {
+		return nil
+	}
+	q.pendientes = q.pendientes[1:]
+	return q.guardar()
+}
+
+// Reintentar registra un intento fallido de la escritura más antigua
+func (q *Cola) Reintentar(id string, causa error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pendientes) == 0 || q.pendientes[0].ID != id {
+		return nil
+	}
+	q.pendientes[0].Intentos++
+	q.pendientes[0].UltimoError = causa.Error()
+	return q.guardar()
+}
+
+// Pendientes copia de las escrituras en espera
+func (q *Cola) Pendientes() []EscrituraPendiente {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	copia := make([]EscrituraPendiente, len(q.pendientes))
+	for i, escritura := range q.pendientes {
+		copia[i] = *escritura
+	}
+	return copia
+}
+
+// Len cantidad de escrituras en espera
+func (q *Cola) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pendientes)
+}
+
+// guardar persiste la cola (con el lock tomado)
+func (q *Cola) guardar() error {
+	raw, err := json.Marshal(q.pendientes)
+	if err != nil {
+		return fmt.Errorf("failed to encode queue: %w", err)
+	}
+	return writeFileAtomic(q.path, raw)
+}
@@ -0,0 +1,172 @@
+package edge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"stock-service/internal/models"
+)
+
+// snapshot contenido persistido de la réplica
+type snapshot struct {
+	IDLocal        int                                 `json:"id_local"`
+	Cursor         *time.Time                          `json:"cursor,omitempty"`
+	SincronizadaAt *time.Time                          `json:"sincronizada_at,omitempty"`
+	Productos      map[string]*models.ProductoCompleto `json:"productos"` // por codigo_final
+	Stock          map[string]*models.Stock            `json:"stock"`     // por codigo_producto
+}
+
+// Replica copia local del catálogo y del stock de un local, persistida en un archivo JSON
+// para seguir respondiendo al POS después de reiniciar sin enlace
+type Replica struct {
+	path string
+
+	mu         sync.RWMutex
+	data       snapshot
+	porBarcode map[string]*models.ProductoCompleto
+}
+
+// LoadReplica carga la réplica del archivo; sin archivo (o de otro local) parte vacía
+func LoadReplica(path string, idLocal int) (*Replica, error) {
+	r := &Replica{path: path}
+	r.data = snapshot{
+		IDLocal:   idLocal,
+		Productos: make(map[string]*models.ProductoCompleto),
+		Stock:     make(map[string]*models.Stock),
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read replica: %w", err)
+	}
+	if err == nil {
+		var loaded snapshot
+		if err := json.Unmarshal(raw, &loaded); err != nil {
+			return nil, fmt.Errorf("failed to decode replica %s: %w", path, err)
+		}
+		if loaded.IDLocal == idLocal && loaded.Productos != nil && loaded.Stock != nil {
+			r.data = loaded
+		}
+	}
+
+	r.indexar()
+	return r, nil
+}
+
+// Cursor cursor de la última sincronización (nil: nunca sincronizada)
+func (r *Replica) Cursor() *time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.data.Cursor
+}
+
+// Aplicar incorpora un delta del central; uno completo reemplaza la réplica
+func (r *Replica) Aplicar(delta *models.DeltaSync) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if delta.Completa {
+		r.data.Productos = make(map[string]*models.ProductoCompleto, len(delta.Productos))
+		r.data.Stock = make(map[string]*models.Stock, len(delta.Stock))
+	}
+	for _, producto := range delta.Productos {
+		r.data.Productos[producto.CodigoFinal] = producto
+	}
+	for _, item := range delta.Stock {
+		r.data.Stock[item.CodigoProducto] = item
+	}
+
+	cursor := delta.Cursor
+	now := time.Now()
+	r.data.Cursor = &cursor
+	r.data.SincronizadaAt = &now
+	r.indexar()
+}
+
+// Producto busca por código de barras (interno o externo) o código de pack
+func (r *Replica) Producto(barcode string) *models.ProductoCompleto {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.porBarcode[barcode]
+}
+
+// Stock retorna el stock replicado del producto (nil si el local no lo tiene)
+func (r *Replica) Stock(codigoProducto string) *models.Stock {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.data.Stock[codigoProducto]
+}
+
+// Descontar resta localmente lo vendido mientras la venta espera en la cola
+// La próxima sincronización trae el valor real del central
+func (r *Replica) Descontar(items []models.ProductoStock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, item := range items {
+		if stock, ok := r.data.Stock[item.CodigoProducto]; ok {
+			actualizado := *stock
+			actualizado.CantidadActual -= item.Cantidad
+			r.data.Stock[item.CodigoProducto] = &actualizado
+		}
+	}
+}
+
+// Resumen datos de la réplica para el estado del edge
+func (r *Replica) Resumen() (productos, stock int, cursor, sincronizadaAt *time.Time) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.data.Productos), len(r.data.Stock), r.data.Cursor, r.data.SincronizadaAt
+}
+
+// Guardar escribe la réplica en un archivo temporal y lo renombra, para no dejarla a medias
+func (r *Replica) Guardar() error {
+	r.mu.RLock()
+	raw, err := json.Marshal(r.data)
+	r.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode replica: %w", err)
+	}
+	return writeFileAtomic(r.path, raw)
+}
+
+// indexar reconstruye el índice por código de barras (con el lock tomado)
+func (r *Replica) indexar() {
+	r.porBarcode = make(map[string]*models.ProductoCompleto, len(r.data.Productos)*2)
+	for _, producto := range r.data.Productos {
+		for _, codigo := range []*string{producto.CodigoBarraExterno, producto.CodigoBarraInterno, producto.CodigoPack} {
+			if codigo != nil && *codigo != "" {
+				r.porBarcode[*codigo] = producto
+			}
+		}
+	}
+}
+
+// writeFileAtomic escribe el archivo completo o no lo modifica
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create dir for %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".edge-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Package export escribe tablas descargables (CSV o XLSX) fila a fila, sin armar el archivo en memoria
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Formatos de exportación soportados
+const (
+	FormatoCSV  = "csv"
+	FormatoXLSX = "xlsx"
+)
+
+// formatoFechaHora formato de las fechas en las celdas (texto: se ve igual en CSV y en XLSX)
+const formatoFechaHora = "2006-01-02 15:04:05"
+
+// ErrFormatoInvalido el formato pedido no es csv ni xlsx
+var ErrFormatoInvalido = errors.New("formato de exportación inválido (use csv o xlsx)")
+
+// Writer escribe las filas de una tabla en el formato elegido
+// Los valores pueden ser string, int, int64, float64, bool, time.Time o punteros a ellos (nil: celda vacía)
+type Writer interface {
+	WriteRow(valores ...interface{}) error
+	// Close termina el archivo; sin Close el XLSX queda inválido
+	Close() error
+}
+
+// NewWriter crea el writer del formato sobre w; hoja es el nombre de la hoja en XLSX
+func NewWriter(formato string, w io.Writer, hoja string) (Writer, error) {
+	switch formato {
+	case FormatoCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatoXLSX:
+		return newXLSXWriter(w, hoja)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrFormatoInvalido, formato)
+	}
+}
+
+// ValidarFormato verifica el formato antes de comenzar la descarga
+func ValidarFormato(formato string) error {
+	if formato != FormatoCSV && formato != FormatoXLSX {
+		return fmt.Errorf("%w: %q", ErrFormatoInvalido, formato)
+	}
+	return nil
+}
+
+// ContentType content type de la descarga según el formato
+func ContentType(formato string) string {
+	if formato == FormatoXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// csvWriter escribe las filas como CSV separado por comas
+type csvWriter struct {
+	w    *csv.Writer
+	fila []string
+}
+
+func (c *csvWriter) WriteRow(valores ...interface{}) error {
+	c.fila = c.fila[:0]
+	for _, v := range valores {
+		texto, _ := celda(v)
+		c.fila = append(c.fila, texto)
+	}
+	return c.w.Write(c.fila)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// celda texto del valor e indicación de si es numérico (en XLSX va como número)
+func celda(v interface{}) (string, bool) {
+	switch x := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return x, false
+	case *string:
+		if x == nil {
+			return "", false
+		}
+		return *x, false
+	case int:
+		return strconv.Itoa(x), true
+	case *int:
+		if x == nil {
+			return "", false
+		}
+		return strconv.Itoa(*x), true
+	case int64:
+		return strconv.FormatInt(x, 10), true
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	case *float64:
+		if x == nil {
+			return "", false
+		}
+		return strconv.FormatFloat(*x, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(x), false
+	case *bool:
+		if x == nil {
+			return "", false
+		}
+		return strconv.FormatBool(*x), false
+	case time.Time:
+		return x.Format(formatoFechaHora), false
+	case *time.Time:
+		if x == nil {
+			return "", false
+		}
+		return x.Format(formatoFechaHora), false
+	default:
+		return fmt.Sprint(x), false
+	}
+}
@@ -0,0 +1,164 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxFilasXLSX límite de filas de una hoja de Excel
+const maxFilasXLSX = 1048576
+
+// ErrDemasiadasFilas la exportación no cabe en una hoja XLSX (usar CSV o acotar los filtros)
+var ErrDemasiadasFilas = errors.New("la exportación supera el máximo de filas de una hoja XLSX")
+
+// Partes fijas del paquete: un libro con una sola hoja y celdas de texto inline
+// (sin tabla de strings compartidos, que obligaría a tener todos los textos en memoria)
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbookInicio = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`
+	xlsxWorkbookFin = `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxSheetInicio = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetFin = `</sheetData></worksheet>`
+)
+
+// xlsxWriter escribe un XLSX mínimo en streaming: la hoja es la última entrada del zip
+// y se va comprimiendo a medida que llegan las filas
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	filas int
+	buf   []byte
+}
+
+func newXLSXWriter(w io.Writer, hoja string) (*xlsxWriter, error) {
+	z := zip.NewWriter(w)
+
+	partes := []struct{ nombre, contenido string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/workbook.xml", xlsxWorkbookInicio + escaparXML(nombreHoja(hoja)) + xlsxWorkbookFin},
+	}
+	for _, parte := range partes {
+		f, err := z.Create(parte.nombre)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, parte.contenido); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriterSize(f, 32*1024)
+	if _, err := sheet.WriteString(xlsxSheetInicio); err != nil {
+		return nil, err
+	}
+
+	return &xlsxWriter{zip: z, sheet: sheet}, nil
+}
+
+func (x *xlsxWriter) WriteRow(valores ...interface{}) error {
+	if x.filas >= maxFilasXLSX {
+		return ErrDemasiadasFilas
+	}
+	x.filas++
+	fila := strconv.Itoa(x.filas)
+
+	b := x.buf[:0]
+	b = append(b, `<row r="`...)
+	b = append(b, fila...)
+	b = append(b, `">`...)
+	for i, v := range valores {
+		texto, numero := celda(v)
+		if texto == "" {
+			continue
+		}
+		b = append(b, `<c r="`...)
+		b = append(b, columnaXLSX(i)...)
+		b = append(b, fila...)
+		if numero {
+			b = append(b, `"><v>`...)
+			b = append(b, texto...)
+			b = append(b, `</v></c>`...)
+			continue
+		}
+		b = append(b, `" t="inlineStr"><is><t xml:space="preserve">`...)
+		b = append(b, escaparXML(texto)...)
+		b = append(b, `</t></is></c>`...)
+	}
+	b = append(b, `</row>`...)
+	x.buf = b
+
+	_, err := x.sheet.Write(b)
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := x.sheet.WriteString(xlsxSheetFin); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// columnaXLSX letra de la columna (0 = A, 26 = AA)
+func columnaXLSX(i int) string {
+	col := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		col = string(rune('A'+(i-1)%26)) + col
+	}
+	return col
+}
+
+// nombreHoja nombre válido de hoja: sin []:*?/\ y hasta 31 caracteres
+func nombreHoja(nombre string) string {
+	limpio := make([]rune, 0, len(nombre))
+	for _, r := range nombre {
+		switch r {
+		case '[', ']', ':', '*', '?', '/', '\\':
+			continue
+		}
+		limpio = append(limpio, r)
+	}
+	if len(limpio) > 31 {
+		limpio = limpio[:31]
+	}
+	if len(limpio) == 0 {
+		return "Hoja1"
+	}
+	return string(limpio)
+}
+
+// escaparXML escapa el texto de una celda (los caracteres no válidos en XML se reemplazan)
+func escaparXML(texto string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(texto))
+	return b.String()
+}
@@ -0,0 +1,54 @@
+// Package features expone los feature flags configurados (FEATURE_FLAGS) a los services
+package features
+
+import (
+	"sync"
+)
+
+// Flags conjunto de feature flags, seguro para uso concurrente y recargable en caliente
+type Flags struct {
+	mu    sync.RWMutex
+	flags map[string]bool
+}
+
+// New crea el conjunto de flags con los valores iniciales
+func New(initial map[string]bool) *Flags {
+	f := &Flags{}
+	f.Replace(initial)
+	return f
+}
+
+// Enabled indica si el flag está activo; un flag no configurado está inactivo
+// Es seguro llamarlo sobre un *Flags nil (todo inactivo)
+func (f *Flags) Enabled(name string) bool {
+	if f == nil {
+		return false
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.flags[name]
+}
+
+// Replace reemplaza todos los flags (usado al recargar la configuración)
+func (f *Flags) Replace(flags map[string]bool) {
+	copia := make(map[string]bool, len(flags))
+	for name, enabled := range flags {
+		copia[name] = enabled
+	}
+
+	f.mu.Lock()
+	f.flags = copia
+	f.mu.Unlock()
+}
+
+// Snapshot retorna una copia de los flags actuales
+func (f *Flags) Snapshot() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	copia := make(map[string]bool, len(f.flags))
+	for name, enabled := range f.flags {
+		copia[name] = enabled
+	}
+	return copia
+}
@@ -0,0 +1,250 @@
+// Package gs1 decodifica los identificadores de aplicación (AI) de las etiquetas GS1-128 y
+// GS1 DataMatrix que los proveedores ponen en las cajas: GTIN, lote y fecha de vencimiento
+package gs1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrCodigoInvalido el texto escaneado no es una secuencia GS1 válida
+var ErrCodigoInvalido = errors.New("código GS1 inválido")
+
+// Identificadores de aplicación que se precargan en una recepción
+const (
+	AIGTIN        = "01"
+	AILote        = "10"
+	AIVencimiento = "17"
+)
+
+// fnc1 separador de campos de largo variable (ASCII GS) con que los lectores transmiten el FNC1
+const fnc1 = '\x1d'
+
+// largoFijo largo de los datos de los AI de largo fijo más comunes en logística
+// Los AI que no están aquí son de largo variable y terminan en FNC1 o al final del código
+var largoFijo = map[string]int{
+	"00": 18, // SSCC
+	"01": 14, // GTIN
+	"02": 14, // GTIN del contenido
+	"11": 6,  // fecha de producción
+	"12": 6,  // fecha de vencimiento del pago
+	"13": 6,  // fecha de empaque
+	"15": 6,  // consumir preferentemente antes de
+	"16": 6,  // fecha de venta hasta
+	"17": 6,  // fecha de vencimiento
+	"20": 2,  // variante
+}
+
+// largoAI cantidad de dígitos del AI según su prefijo (tabla de la especificación GS1)
+func largoAI(datos string) int {
+	if len(datos) < 2 {
+		return 0
+	}
+	switch datos[0] {
+	case '0', '1':
+		return 2
+	case '2':
+		if datos[1] == '0' || datos[1] == '1' || datos[1] == '2' {
+			return 2
+		}
+		return 3
+	case '3':
+		// 30 y 37 son de dos dígitos; el resto son medidas e importes con decimal (4 dígitos)
+		if datos[1] == '0' || datos[1] == '7' {
+			return 2
+		}
+		return 4
+	case '4':
+		return 3
+	case '7', '8':
+		return 4
+	case '9':
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Datos contenido de una etiqueta GS1
+type Datos struct {
+	GTIN        string
+	Lote        string
+	Vencimiento *time.Time
+	// AIs todos los identificadores leídos (AI -> valor), incluidos los que no se interpretan
+	AIs map[string]string
+}
+
+// Parse decodifica el texto escaneado de una etiqueta GS1-128 o GS1 DataMatrix
+// Acepta el identificador de simbología del lector (]C1, ]d2, ]Q3), FNC1 como separador y
+// también la forma legible con los AI entre paréntesis: (01)07891234567895(17)261231(10)L42
+func Parse(codigo string) (*Datos, error) {
+	codigo = strings.TrimSpace(codigo)
+	for _, prefijo := range []string{"]C1", "]e0", "]d2", "]Q3"} {
+		codigo = strings.TrimPrefix(codigo, prefijo)
+	}
+	codigo = strings.TrimLeft(codigo, string(fnc1))
+	if codigo == "" {
+		return nil, fmt.Errorf("%w: vacío", ErrCodigoInvalido)
+	}
+
+	var ais map[string]string
+	var err error
+	if codigo[0] == '(' {
+		ais, err = parseLegible(codigo)
+	} else {
+		ais, err = parseCrudo(codigo)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	datos := &Datos{AIs: ais}
+	if gtin, ok := ais[AIGTIN]; ok {
+		if !ValidarGTIN(gtin) {
+			return nil, fmt.Errorf("%w: dígito verificador del GTIN %s", ErrCodigoInvalido, gtin)
+		}
+		datos.GTIN = gtin
+	}
+	datos.Lote = ais[AILote]
+	if v, ok := ais[AIVencimiento]; ok {
+		fecha, err := parseFecha(v)
+		if err != nil {
+			return nil, err
+		}
+		datos.Vencimiento = &fecha
+	}
+	return datos, nil
+}
+
+// parseCrudo recorre la secuencia AI+datos tal como la transmite el lector
+func parseCrudo(codigo string) (map[string]string, error) {
+	ais := make(map[string]string)
+	for len(codigo) > 0 {
+		n := largoAI(codigo)
+		if n == 0 || len(codigo) < n {
+			return nil, fmt.Errorf("%w: identificador de aplicación desconocido en %q", ErrCodigoInvalido, codigo)
+		}
+		ai := codigo[:n]
+		codigo = codigo[n:]
+
+		var valor string
+		if largo, ok := largoFijo[ai]; ok {
+			if len(codigo) < largo {
+				return nil, fmt.Errorf("%w: AI %s incompleto", ErrCodigoInvalido, ai)
+			}
+			valor, codigo = codigo[:largo], codigo[largo:]
+		} else if i := strings.IndexByte(codigo, fnc1); i >= 0 {
+			valor, codigo = codigo[:i], codigo[i:]
+		} else {
+			valor, codigo = codigo, ""
+		}
+		codigo = strings.TrimLeft(codigo, string(fnc1))
+
+		if valor == "" {
+			return nil, fmt.Errorf("%w: AI %s sin datos", ErrCodigoInvalido, ai)
+		}
+		ais[ai] = valor
+	}
+	return ais, nil
+}
+
+// parseLegible recorre la forma (AI)datos(AI)datos impresa bajo el código de barras
+func parseLegible(codigo string) (map[string]string, error) {
+	ais := make(map[string]string)
+	for len(codigo) > 0 {
+		if codigo[0] != '(' {
+			return nil, fmt.Errorf("%w: se esperaba '(' en %q", ErrCodigoInvalido, codigo)
+		}
+		fin := strings.IndexByte(codigo, ')')
+		if fin < 3 {
+			return nil, fmt.Errorf("%w: identificador de aplicación mal formado en %q", ErrCodigoInvalido, codigo)
+		}
+		ai := codigo[1:fin]
+		codigo = codigo[fin+1:]
+
+		valor := codigo
+		if i := strings.IndexByte(codigo, '('); i >= 0 {
+			valor = codigo[:i]
+		}
+		codigo = codigo[len(valor):]
+
+		if largo, ok := largoFijo[ai]; ok && len(valor) != largo {
+			return nil, fmt.Errorf("%w: AI %s debe tener %d caracteres", ErrCodigoInvalido, ai, largo)
+		}
+		if valor == "" {
+			return nil, fmt.Errorf("%w: AI %s sin datos", ErrCodigoInvalido, ai)
+		}
+		ais[ai] = valor
+	}
+	return ais, nil
+}
+
+// parseFecha interpreta una fecha AAMMDD de GS1; día 00 es el último día del mes
+// El siglo se elige para que el año quede entre 49 años atrás y 50 adelante
+func parseFecha(v string) (time.Time, error) {
+	if len(v) != 6 || strings.Trim(v, "0123456789") != "" {
+		return time.Time{}, fmt.Errorf("%w: fecha %q", ErrCodigoInvalido, v)
+	}
+	yy := int(v[0]-'0')*10 + int(v[1]-'0')
+	mm := int(v[2]-'0')*10 + int(v[3]-'0')
+	dd := int(v[4]-'0')*10 + int(v[5]-'0')
+	if mm < 1 || mm > 12 || dd > 31 {
+		return time.Time{}, fmt.Errorf("%w: fecha %q", ErrCodigoInvalido, v)
+	}
+
+	actual := time.Now().Year()
+	anio := actual/100*100 + yy
+	switch diff := anio - actual; {
+	case diff > 50:
+		anio -= 100
+	case diff < -49:
+		anio += 100
+	}
+
+	if dd == 0 {
+		// Último día del mes
+		return time.Date(anio, time.Month(mm)+1, 0, 0, 0, 0, 0, time.UTC), nil
+	}
+	fecha := time.Date(anio, time.Month(mm), dd, 0, 0, 0, 0, time.UTC)
+	if fecha.Day() != dd {
+		return time.Time{}, fmt.Errorf("%w: fecha %q", ErrCodigoInvalido, v)
+	}
+	return fecha, nil
+}
+
+// ValidarGTIN verifica el dígito verificador (módulo 10) de un GTIN-8/12/13/14
+func ValidarGTIN(gtin string) bool {
+	switch len(gtin) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	suma := 0
+	for i := len(gtin) - 2; i >= 0; i-- {
+		d := gtin[i]
+		if d < '0' || d > '9' {
+			return false
+		}
+		peso := 1
+		if (len(gtin)-2-i)%2 == 0 {
+			peso = 3
+		}
+		suma += int(d-'0') * peso
+	}
+	ultimo := gtin[len(gtin)-1]
+	return ultimo >= '0' && ultimo <= '9' && int(ultimo-'0') == (10-suma%10)%10
+}
+
+// CodigosBarra formas en que el GTIN puede estar registrado como código de barras del producto:
+// el GTIN-14 tal cual y, si tiene ceros a la izquierda, como EAN-13, UPC-A y EAN-8
+func CodigosBarra(gtin string) []string {
+	codigos := []string{gtin}
+	for _, largo := range []int{13, 12, 8} {
+		if len(gtin) > largo && strings.Trim(gtin[:len(gtin)-largo], "0") == "" {
+			codigos = append(codigos, gtin[len(gtin)-largo:])
+		}
+	}
+	return codigos
+}
@@ -0,0 +1,124 @@
+package gs1
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	fecha := func(anio int, mes time.Month, dia int) *time.Time {
+		f := time.Date(anio, mes, dia, 0, 0, 0, 0, time.UTC)
+		return &f
+	}
+
+	tests := []struct {
+		name    string
+		codigo  string
+		want    *Datos
+		wantErr bool
+	}{
+		{
+			name:   "GS1-128 crudo con FNC1 tras el lote",
+			codigo: "]C10107891234567895" + "10L42\x1d" + "17300615",
+			want: &Datos{GTIN: "07891234567895", Lote: "L42", Vencimiento: fecha(2030, time.June, 15),
+				AIs: map[string]string{"01": "07891234567895", "10": "L42", "17": "300615"}},
+		},
+		{
+			name:   "DataMatrix con lote al final sin FNC1",
+			codigo: "]d2\x1d01078912345678951730061510LOTE-9",
+			want: &Datos{GTIN: "07891234567895", Lote: "LOTE-9", Vencimiento: fecha(2030, time.June, 15),
+				AIs: map[string]string{"01": "07891234567895", "17": "300615", "10": "LOTE-9"}},
+		},
+		{
+			name:   "forma legible con paréntesis",
+			codigo: "(01)07891234567895(17)300200(10)L42",
+			want: &Datos{GTIN: "07891234567895", Lote: "L42", Vencimiento: fecha(2030, time.February, 28),
+				AIs: map[string]string{"01": "07891234567895", "17": "300200", "10": "L42"}},
+		},
+		{
+			name:   "AI sin interpretar se conserva (SSCC y cantidad)",
+			codigo: "00376104250021234569" + "3710\x1d" + "0107891234567895",
+			want: &Datos{GTIN: "07891234567895",
+				AIs: map[string]string{"00": "376104250021234569", "37": "10", "01": "07891234567895"}},
+		},
+		{name: "vacío", codigo: "   ", wantErr: true},
+		{name: "solo identificador de simbología", codigo: "]C1", wantErr: true},
+		{name: "GTIN truncado", codigo: "01078912345678", wantErr: true},
+		{name: "fecha truncada", codigo: "010789123456789517300", wantErr: true},
+		{name: "AI de un dígito al final", codigo: "01078912345678951", wantErr: true},
+		{name: "AI variable sin datos", codigo: "10\x1d17300615", wantErr: true},
+		{name: "AI desconocido", codigo: "5012345", wantErr: true},
+		{name: "AI desconocido tras uno válido", codigo: "0107891234567895" + "6612", wantErr: true},
+		{name: "dígito verificador del GTIN incorrecto", codigo: "0107891234567896", wantErr: true},
+		{name: "dígito verificador incorrecto en forma legible", codigo: "(01)07891234567890", wantErr: true},
+		{name: "legible con largo fijo incorrecto", codigo: "(01)0789123456789", wantErr: true},
+		{name: "legible sin paréntesis de cierre", codigo: "(01", wantErr: true},
+		{name: "legible con texto suelto", codigo: "(10)L42x(17", wantErr: true},
+		{name: "mes inválido", codigo: "17301315", wantErr: true},
+		{name: "día inexistente", codigo: "17300231", wantErr: true},
+		{name: "fecha no numérica", codigo: "(17)30AB15", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.codigo)
+			if tt.wantErr {
+				if !errors.Is(err, ErrCodigoInvalido) {
+					t.Fatalf("Parse(%q) err = %v, want ErrCodigoInvalido", tt.codigo, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) err = %v", tt.codigo, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.codigo, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidarGTIN(t *testing.T) {
+	tests := []struct {
+		gtin string
+		want bool
+	}{
+		{"07891234567895", true}, // GTIN-14
+		{"7801234567894", true},  // EAN-13
+		{"000123456789", false},  // UPC-A con dígito incorrecto
+		{"001234567895", true},   // UPC-A
+		{"12345670", true},       // EAN-8
+		{"12345671", false},
+		{"07891234567896", false},
+		{"0789123456789X", false},
+		{"078912345678A5", false},
+		{"1234567", false}, // largo no GS1
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidarGTIN(tt.gtin); got != tt.want {
+			t.Errorf("ValidarGTIN(%q) = %v, want %v", tt.gtin, got, tt.want)
+		}
+	}
+}
+
+func TestCodigosBarra(t *testing.T) {
+	tests := []struct {
+		gtin string
+		want []string
+	}{
+		{"07891234567895", []string{"07891234567895", "7891234567895"}},
+		{"00012345678905", []string{"00012345678905", "0012345678905", "012345678905"}},
+		{"00000012345670", []string{"00000012345670", "0000012345670", "000012345670", "12345670"}},
+		{"17891234567892", []string{"17891234567892"}},
+	}
+
+	for _, tt := range tests {
+		if got := CodigosBarra(tt.gtin); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CodigosBarra(%q) = %v, want %v", tt.gtin, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/database"
+	"stock-service/internal/features"
+	"stock-service/internal/maintenance"
+	"stock-service/internal/models"
+	"stock-service/internal/outbox"
+	"stock-service/internal/quota"
+	"stock-service/internal/services"
+	"stock-service/internal/signing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// AdminHandler maneja la administración en caliente del servicio
+// (configuración, feature flags, modo mantenimiento, cuotas de API keys, outbox de eventos,
+// pool de conexiones a PostgreSQL, folios de documentos y claves de firma de respuestas)
+type AdminHandler struct {
+	configManager *config.Manager
+	flags         *features.Flags
+	maintenance   *maintenance.Mode
+	limiter       *quota.Limiter
+	outbox        *outbox.Dispatcher
+	dbPool        *database.PoolMonitor
+	folioService  services.FolioService
+	signer        *signing.Signer
+	validator     *validator.Validate
+	logger        *zap.Logger
+}
+
+// NewAdminHandler crea una nueva instancia del handler
+func NewAdminHandler(configManager *config.Manager, flags *features.Flags, maintenanceMode *maintenance.Mode, limiter *quota.Limiter, outboxDispatcher *outbox.Dispatcher, dbPool *database.PoolMonitor, folioService services.FolioService, signer *signing.Signer, logger *zap.Logger) *AdminHandler {
+	return &AdminHandler{
+		configManager: configManager,
+		flags:         flags,
+		maintenance:   maintenanceMode,
+		limiter:       limiter,
+		outbox:        outboxDispatcher,
+		dbPool:        dbPool,
+		folioService:  folioService,
+		signer:        signer,
+		validator:     validator.New(),
+		logger:        logger,
+	}
+}
+
+// ReloadConfig recarga la configuración (equivalente a enviar SIGHUP al proceso)
+// POST /admin/config/reload
+func (h *AdminHandler) ReloadConfig(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "reload_config"))
+
+	result, err := h.configManager.Reload()
+	if err != nil {
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			logger.Warn("Recarga rechazada, configuración inválida", zap.Strings("problems", validationErr.Problems))
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"success":    false,
+				"request_id": requestID(c),
+				"message":    "❌ Configuración inválida, no se aplicaron cambios",
+				"error":      "configuración inválida",
+				"data": gin.H{
+					"problemas": validationErr.Problems,
+				},
+			})
+			return
+		}
+		logger.Error("Error recargando configuración", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error recargando configuración", err.Error()))
+		return
+	}
+
+	logger.Info("Configuración recargada",
+		zap.Strings("applied", result.Applied),
+		zap.Strings("requires_restart", result.RequiresRestart))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Configuración recargada",
+		"data":    result,
+	})
+}
+
+// GetFeatures lista los feature flags vigentes
+// GET /admin/features
+func (h *AdminHandler) GetFeatures(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Feature flags obtenidos",
+		"data": gin.H{
+			"features": h.flags.Snapshot(),
+		},
+	})
+}
+
+// GetMantenimiento retorna el estado del modo mantenimiento
+// GET /admin/mantenimiento
+func (h *AdminHandler) GetMantenimiento(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Estado de mantenimiento obtenido",
+		"data":    h.maintenance.Current(c.Request.Context()),
+	})
+}
+
+// ActivarMantenimiento pone el servicio en modo mantenimiento (todas las réplicas)
+// Las escrituras pasan a responder 503 con Retry-After; las lecturas siguen funcionando
+// POST /admin/mantenimiento
+func (h *AdminHandler) ActivarMantenimiento(c *gin.Context) {
+	// El body es opcional: sin body se usan el mensaje y Retry-After configurados
+	var req models.ActivarMantenimientoRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	idUsuario := idUsuarioActual(c)
+
+	state, err := h.maintenance.Enable(c.Request.Context(), req.Mensaje, time.Duration(req.RetryAfterSeconds)*time.Second, idUsuario)
+	if err != nil {
+		h.logger.Error("Error activando modo mantenimiento", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error activando modo mantenimiento", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Modo mantenimiento activado",
+		"data":    state,
+	})
+}
+
+// DesactivarMantenimiento vuelve a aceptar escrituras
+// DELETE /admin/mantenimiento
+func (h *AdminHandler) DesactivarMantenimiento(c *gin.Context) {
+	idUsuario := idUsuarioActual(c)
+
+	if err := h.maintenance.Disable(c.Request.Context(), idUsuario); err != nil {
+		h.logger.Error("Error desactivando modo mantenimiento", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error desactivando modo mantenimiento", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Modo mantenimiento desactivado",
+		"data":    maintenance.State{},
+	})
+}
+
+// GetUsoAPIKeys retorna el consumo de cada API key (minuto actual, hoy y últimos días)
+// GET /admin/api-keys/uso?dias=7
+func (h *AdminHandler) GetUsoAPIKeys(c *gin.Context) {
+	dias := 7
+	if diasStr := c.Query("dias"); diasStr != "" {
+		if d, err := strconv.Atoi(diasStr); err == nil {
+			dias = d
+		}
+	}
+
+	uso, err := h.limiter.Usage(c.Request.Context(), dias)
+	if err != nil {
+		h.logger.Error("Error obteniendo consumo de API keys", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo consumo de API keys", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Consumo de API keys obtenido",
+		"data": gin.H{
+			"api_keys": uso,
+			"total":    len(uso),
+		},
+	})
+}
+
+// GetOutbox retorna el estado de la outbox de eventos (pendientes, en reintento y descartados)
+// GET /admin/outbox
+func (h *AdminHandler) GetOutbox(c *gin.Context) {
+	resumen, err := h.outbox.Resumen(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Error obteniendo estado de la outbox", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo estado de la outbox", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Estado de la outbox obtenido",
+		"data": gin.H{
+			"webhooks_habilitados": h.outbox.Enabled(),
+			"outbox":               resumen,
+		},
+	})
+}
+
+// ReintentarOutbox vuelve a poner en cola los eventos descartados tras agotar los reintentos
+// POST /admin/outbox/reintentar
+func (h *AdminHandler) ReintentarOutbox(c *gin.Context) {
+	n, err := h.outbox.ReintentarDescartados(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Error reintentando eventos descartados", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error reintentando eventos descartados", err.Error()))
+		return
+	}
+
+	h.logger.Info("Eventos descartados de la outbox reprogramados",
+		zap.String("operation", "reintentar_outbox"),
+		zap.Int("eventos", n))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("✅ %d eventos reprogramados", n),
+		"data": gin.H{
+			"reprogramados": n,
+		},
+	})
+}
+
+// GetDBPool retorna el estado del pool de conexiones a PostgreSQL y la alerta de espera
+// GET /admin/db-pool
+func (h *AdminHandler) GetDBPool(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Estado del pool de conexiones obtenido",
+		"data":    h.dbPool.Stats(),
+	})
+}
+
+// AjustarDBPool ajusta MaxOpenConns/MaxIdleConns sin reiniciar (solo esta réplica;
+// al reiniciar vuelven a regir DB_MAX_OPEN_CONNS y DB_MAX_IDLE_CONNS)
+// PUT /admin/db-pool
+func (h *AdminHandler) AjustarDBPool(c *gin.Context) {
+	var req models.AjustarPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	maxIdle := h.dbPool.Stats().MaxIdleConns
+	if req.MaxIdleConns != nil {
+		maxIdle = *req.MaxIdleConns
+	} else if maxIdle > req.MaxOpenConns {
+		maxIdle = req.MaxOpenConns
+	}
+
+	if err := h.dbPool.Resize(req.MaxOpenConns, maxIdle); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Límites del pool inválidos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Pool de conexiones ajustado",
+		"data":    h.dbPool.Stats(),
+	})
+}
+
+// GetFolios retorna el último folio emitido de cada tipo de documento por local
+// GET /admin/folios?local=
+func (h *AdminHandler) GetFolios(c *gin.Context) {
+	var idLocal *int
+	if idLocalStr := c.Query("local"); idLocalStr != "" {
+		id, err := strconv.Atoi(idLocalStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Local inválido", "local debe ser un número válido"))
+			return
+		}
+		idLocal = &id
+	}
+
+	folios, err := h.folioService.GetFolios(c.Request.Context(), idLocal)
+	if err != nil {
+		h.logger.Error("Error obteniendo folios", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo folios", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Folios obtenidos",
+		"data":    folios,
+	})
+}
+
+// AvanzarFolio fija el último folio emitido de un tipo de documento en un local
+// (ej: para continuar la numeración de otro sistema); solo puede avanzar
+// PUT /admin/folios
+func (h *AdminHandler) AvanzarFolio(c *gin.Context) {
+	var req models.AvanzarFolioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	if err := h.folioService.AvanzarFolio(c.Request.Context(), &req); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrTipoDocumentoInvalido):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrFolioRetroceso):
+			status = http.StatusConflict
+		}
+		c.JSON(errorStatus(c, err, status), errorResponse(c, "❌ Error avanzando folio", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("✅ Folio de %s del local %d avanzado a %d", req.TipoDocumento, req.IDLocal, req.UltimoFolio),
+		"data":    req,
+	})
+}
+
+// GetClavesFirma lista las claves de firma de respuestas no revocadas (sin secretos)
+// GET /admin/firmas
+func (h *AdminHandler) GetClavesFirma(c *gin.Context) {
+	claves, err := h.signer.Claves(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo claves de firma", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Claves de firma obtenidas",
+		"data": gin.H{
+			"habilitada": h.signer.Enabled(),
+			"rutas":      h.signer.PathPrefixes(),
+			"claves":     claves,
+		},
+	})
+}
+
+// RotarClaveFirma genera una nueva clave activa; la anterior queda retirada
+// El secreto de una clave HMAC solo se entrega en esta respuesta
+// POST /admin/firmas/rotar
+func (h *AdminHandler) RotarClaveFirma(c *gin.Context) {
+	var req models.RotarClaveFirmaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	clave, err := h.signer.Rotar(c.Request.Context(), req.Algoritmo)
+	if err != nil {
+		h.logger.Error("Error rotando clave de firma", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error rotando clave de firma", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("✅ Clave de firma %s activa", clave.KID),
+		"data":    clave,
+	})
+}
+
+// RevocarClaveFirma revoca una clave; si era la activa las respuestas salen sin firma hasta rotar
+// DELETE /admin/firmas/:kid
+func (h *AdminHandler) RevocarClaveFirma(c *gin.Context) {
+	if err := h.signer.Revocar(c.Request.Context(), c.Param("kid")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, signing.ErrClaveNoEncontrada) {
+			status = http.StatusNotFound
+		}
+		c.JSON(errorStatus(c, err, status), errorResponse(c, "❌ Error revocando clave de firma", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Clave de firma revocada",
+	})
+}
+
+// GetClavesPublicas publica las claves con que los integradores verifican las firmas:
+// la activa y las retiradas recientes (Ed25519 con su clave pública; HMAC solo el kid)
+// GET /firmas/claves
+func (h *AdminHandler) GetClavesPublicas(c *gin.Context) {
+	claves, err := h.signer.ClavesPublicas(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo claves de firma", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Claves de firma obtenidas",
+		"data":    claves,
+	})
+}
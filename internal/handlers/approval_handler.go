@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// ApprovalHandler maneja la aprobación de operaciones grandes por un supervisor
+type ApprovalHandler struct {
+	approvalService services.ApprovalService
+	validator       *validator.Validate
+	logger          *zap.Logger
+}
+
+// NewApprovalHandler crea una nueva instancia del handler
+func NewApprovalHandler(approvalService services.ApprovalService, logger *zap.Logger) *ApprovalHandler {
+	return &ApprovalHandler{
+		approvalService: approvalService,
+		validator:       validator.New(),
+		logger:          logger,
+	}
+}
+
+// GetSolicitudes lista las solicitudes de aprobación (por defecto las pendientes)
+func (h *ApprovalHandler) GetSolicitudes(c *gin.Context) {
+	filter := &models.SolicitudAprobacionFilter{
+		Estado: c.DefaultQuery("estado", models.AprobacionEstadoPendiente),
+	}
+	if filter.Estado == "todas" {
+		filter.Estado = ""
+	}
+
+	if idLocalStr := c.Query("local"); idLocalStr != "" {
+		if idLocal, err := strconv.Atoi(idLocalStr); err == nil {
+			filter.IDLocal = &idLocal
+		}
+	}
+
+	if idSolicitanteStr := c.Query("solicitante"); idSolicitanteStr != "" {
+		if idSolicitante, err := strconv.Atoi(idSolicitanteStr); err == nil {
+			filter.IDSolicitante = &idSolicitante
+		}
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			filter.Limit = limit
+		}
+	}
+
+	solicitudes, err := h.approvalService.GetSolicitudes(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Error obteniendo solicitudes de aprobación", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo solicitudes", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Solicitudes obtenidas",
+		"data": gin.H{
+			"solicitudes": solicitudes,
+			"total":       len(solicitudes),
+			"filtros":     filter,
+		},
+	})
+}
+
+// GetSolicitud obtiene una solicitud de aprobación
+func (h *ApprovalHandler) GetSolicitud(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	solicitud, err := h.approvalService.GetSolicitud(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, approvalErrorStatus(err)), errorResponse(c, "❌ Error obteniendo solicitud", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Solicitud obtenida",
+		"data":    solicitud,
+	})
+}
+
+// Aprobar aprueba la solicitud y aplica la operación al stock
+func (h *ApprovalHandler) Aprobar(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	idSupervisor := idUsuarioActual(c)
+
+	solicitud, err := h.approvalService.Aprobar(c.Request.Context(), id, idSupervisor)
+	if err != nil {
+		h.logger.Error("Error aprobando solicitud", zap.Int("id_solicitud", id), zap.Error(err))
+		status := errorStatus(c, err, approvalErrorStatus(err))
+		response := errorResponse(c, "❌ Error aprobando solicitud", err.Error())
+		if solicitud != nil {
+			// Aprobada pero la operación falló al aplicarse
+			response["data"] = solicitud
+		}
+		c.JSON(status, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Solicitud aprobada, operación aplicada",
+		"data":    solicitud,
+	})
+}
+
+// Rechazar rechaza la solicitud sin afectar el stock
+func (h *ApprovalHandler) Rechazar(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.RechazarAprobacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	idSupervisor := idUsuarioActual(c)
+
+	solicitud, err := h.approvalService.Rechazar(c.Request.Context(), id, idSupervisor, req.Motivo)
+	if err != nil {
+		h.logger.Error("Error rechazando solicitud", zap.Int("id_solicitud", id), zap.Error(err))
+		c.JSON(errorStatus(c, err, approvalErrorStatus(err)), errorResponse(c, "❌ Error rechazando solicitud", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Solicitud rechazada",
+		"data":    solicitud,
+	})
+}
+
+// parseID obtiene el ID de la solicitud de la URL
+func (h *ApprovalHandler) parseID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de solicitud inválido", "El ID debe ser un número válido"))
+		return 0, false
+	}
+	return id, true
+}
+
+// approvalErrorStatus mapea los errores de dominio de aprobaciones a códigos HTTP
+func approvalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrSolicitudNoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrSolicitudYaResuelta):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrAutoaprobacion):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// AuthHandler maneja el login y la renovación de tokens (rutas públicas)
+type AuthHandler struct {
+	authService services.AuthService
+	validator   *validator.Validate
+	logger      *zap.Logger
+}
+
+// NewAuthHandler crea una nueva instancia del handler
+func NewAuthHandler(authService services.AuthService, logger *zap.Logger) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+		validator:   validator.New(),
+		logger:      logger,
+	}
+}
+
+// Login inicia sesión con usuario y contraseña y entrega el access token y el refresh token
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	sesion, err := h.authService.Login(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, authErrorStatus(err)), errorResponse(c, "❌ Error iniciando sesión", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Sesión iniciada",
+		"data":    sesion,
+	})
+}
+
+// Refresh renueva el access token; el refresh token usado queda revocado y se entrega uno nuevo
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	sesion, err := h.authService.Refresh(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, authErrorStatus(err)), errorResponse(c, "❌ Error renovando sesión", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Sesión renovada",
+		"data":    sesion,
+	})
+}
+
+// authErrorStatus mapea los errores de autenticación a códigos HTTP
+func authErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrCredencialesInvalidas),
+		errors.Is(err, services.ErrRefreshTokenInvalido):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrUsuarioInactivo):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// BotonRapidoHandler maneja la grilla de botones rápidos del POS por local
+type BotonRapidoHandler struct {
+	botonService services.BotonRapidoService
+	validator    *validator.Validate
+	logger       *zap.Logger
+}
+
+// NewBotonRapidoHandler crea una nueva instancia del handler
+func NewBotonRapidoHandler(botonService services.BotonRapidoService, logger *zap.Logger) *BotonRapidoHandler {
+	return &BotonRapidoHandler{
+		botonService: botonService,
+		validator:    validator.New(),
+		logger:       logger,
+	}
+}
+
+// GetGrilla entrega la grilla del local para que el POS la renderice (cacheada)
+func (h *BotonRapidoHandler) GetGrilla(c *gin.Context) {
+	idLocal, ok := h.parseLocal(c)
+	if !ok {
+		return
+	}
+
+	grilla, cacheHit, err := h.botonService.GetGrilla(c.Request.Context(), idLocal)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo botones rápidos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Botones rápidos obtenidos",
+		"data": gin.H{
+			"id_local":  grilla.IDLocal,
+			"botones":   grilla.Botones,
+			"total":     len(grilla.Botones),
+			"cache_hit": cacheHit,
+		},
+	})
+}
+
+// ReemplazarGrilla guarda la grilla completa del local (las posiciones no enviadas quedan vacías)
+func (h *BotonRapidoHandler) ReemplazarGrilla(c *gin.Context) {
+	idLocal, ok := h.parseLocal(c)
+	if !ok {
+		return
+	}
+
+	var req models.GrillaBotonesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	grilla, err := h.botonService.ReemplazarGrilla(c.Request.Context(), idLocal, &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, botonRapidoErrorStatus(err)), errorResponse(c, "❌ Error guardando botones rápidos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Botones rápidos actualizados",
+		"data":    grilla,
+	})
+}
+
+// EliminarBoton quita el botón de una posición de la grilla
+func (h *BotonRapidoHandler) EliminarBoton(c *gin.Context) {
+	idLocal, ok := h.parseLocal(c)
+	if !ok {
+		return
+	}
+
+	posicion, err := strconv.Atoi(c.Param("posicion"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Posición inválida", "La posición debe ser un número válido"))
+		return
+	}
+
+	if err := h.botonService.EliminarBoton(c.Request.Context(), idLocal, posicion); err != nil {
+		c.JSON(errorStatus(c, err, botonRapidoErrorStatus(err)), errorResponse(c, "❌ Error eliminando botón rápido", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Botón rápido eliminado",
+	})
+}
+
+// parseLocal obtiene el ID del local de la URL
+func (h *BotonRapidoHandler) parseLocal(c *gin.Context) (int, bool) {
+	idLocal, err := strconv.Atoi(c.Param("local"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de local inválido", "El ID debe ser un número válido"))
+		return 0, false
+	}
+	return idLocal, true
+}
+
+// botonRapidoErrorStatus mapea los errores de dominio de los botones rápidos a códigos HTTP
+func botonRapidoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrBotonRapidoNoEncontrado),
+		errors.Is(err, services.ErrLocalNoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrGrillaBotonesInvalida),
+		errors.Is(err, services.ErrProductoNoEncontrado),
+		errors.Is(err, services.ErrProductoDescontinuado):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
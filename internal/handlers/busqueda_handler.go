@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// busquedaLargoMinimo largo mínimo del término para evitar barridos completos de las tablas
+const busquedaLargoMinimo = 2
+
+// BusquedaHandler maneja la búsqueda global del dashboard
+type BusquedaHandler struct {
+	busquedaService services.BusquedaService
+	logger          *zap.Logger
+}
+
+// NewBusquedaHandler crea una nueva instancia del handler
+func NewBusquedaHandler(busquedaService services.BusquedaService, logger *zap.Logger) *BusquedaHandler {
+	return &BusquedaHandler{
+		busquedaService: busquedaService,
+		logger:          logger,
+	}
+}
+
+// Buscar busca en productos, packs y movimientos recientes
+// GET /buscar?q=&limit=
+func (h *BusquedaHandler) Buscar(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if len([]rune(q)) < busquedaLargoMinimo {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Término de búsqueda inválido", "q debe tener al menos 2 caracteres"))
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 50 {
+			limit = l
+		}
+	}
+
+	resultados, err := h.busquedaService.Buscar(c.Request.Context(), q, limit)
+	if err != nil {
+		h.logger.Error("Error en búsqueda global", zap.String("q", q), zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error en la búsqueda", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Búsqueda completada",
+		"data": gin.H{
+			"q":          q,
+			"resultados": resultados,
+			"total":      len(resultados),
+		},
+	})
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// ConteoCiclicoHandler maneja las peticiones HTTP de los conteos cíclicos
+type ConteoCiclicoHandler struct {
+	conteoService services.ConteoCiclicoService
+	pdtConfig     config.PDTConfig
+	validator     *validator.Validate
+	logger        *zap.Logger
+}
+
+// NewConteoCiclicoHandler crea una nueva instancia del handler
+func NewConteoCiclicoHandler(conteoService services.ConteoCiclicoService, pdtConfig config.PDTConfig, logger *zap.Logger) *ConteoCiclicoHandler {
+	return &ConteoCiclicoHandler{
+		conteoService: conteoService,
+		pdtConfig:     pdtConfig,
+		validator:     validator.New(),
+		logger:        logger,
+	}
+}
+
+// GetConteos lista las sesiones de conteo (filtros: local, usuario, estado, semana)
+func (h *ConteoCiclicoHandler) GetConteos(c *gin.Context) {
+	filter := &models.ConteoCiclicoFilter{Estado: c.Query("estado")}
+
+	var ok bool
+	if filter.IDLocal, ok = queryIntOpcional(c, "local"); !ok {
+		return
+	}
+	if filter.IDUsuario, ok = queryIntOpcional(c, "usuario"); !ok {
+		return
+	}
+	switch filter.Estado {
+	case "", models.ConteoEstadoPendiente, models.ConteoEstadoEnCurso, models.ConteoEstadoCompletada, "vencida":
+	default:
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Estado inválido", "estado debe ser pendiente, en_curso, completada o vencida"))
+		return
+	}
+	if semanaStr := c.Query("semana"); semanaStr != "" {
+		semana, err := time.ParseInLocation("2006-01-02", semanaStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Semana inválida", "semana debe tener formato YYYY-MM-DD (lunes de la semana)"))
+			return
+		}
+		filter.Semana = &semana
+	}
+
+	conteos, err := h.conteoService.GetConteos(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo conteos cíclicos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Conteos cíclicos obtenidos",
+		"data":    conteos,
+	})
+}
+
+// GetConteo obtiene una sesión de conteo con sus productos
+func (h *ConteoCiclicoHandler) GetConteo(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	conteo, err := h.conteoService.GetConteo(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, conteoErrorStatus(err)), errorResponse(c, "❌ Error obteniendo conteo cíclico", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Conteo cíclico obtenido",
+		"data":    conteo,
+	})
+}
+
+// RegistrarConteo registra las cantidades contadas de la sesión
+func (h *ConteoCiclicoHandler) RegistrarConteo(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "registrar_conteo_ciclico"))
+
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.RegistrarConteoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	conteo, err := h.conteoService.RegistrarConteo(c.Request.Context(), id, &req)
+	if err != nil {
+		logger.Error("Error registrando conteo cíclico", zap.Int64("id_conteo", id), zap.Error(err))
+		c.JSON(errorStatus(c, err, conteoErrorStatus(err)), errorResponse(c, "❌ Error registrando conteo cíclico", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Conteo registrado",
+		"data":    conteo,
+	})
+}
+
+// GenerarSemana genera las sesiones de la semana en curso (forzar=true genera una muestra adicional)
+func (h *ConteoCiclicoHandler) GenerarSemana(c *gin.Context) {
+	forzar := c.Query("forzar") == "true"
+
+	generacion, err := h.conteoService.GenerarSemana(c.Request.Context(), forzar)
+	if err != nil {
+		h.logger.Error("Error generando conteos cíclicos", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error generando conteos cíclicos", err.Error()))
+		return
+	}
+
+	message := "✅ Conteos cíclicos de la semana generados"
+	if !generacion.Generada {
+		message = "✅ Los conteos cíclicos de la semana ya estaban generados"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    generacion,
+	})
+}
+
+// ImportarArchivoPDT carga en la sesión el archivo exportado por una terminal de inventario
+// POST /conteos-ciclicos/:id/archivo-pdt (multipart, campo "archivo")
+func (h *ConteoCiclicoHandler) ImportarArchivoPDT(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("archivo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Archivo requerido", "Envíe el archivo de la PDT en el campo multipart 'archivo'"))
+		return
+	}
+	defer file.Close()
+
+	// Leer un byte más del máximo para detectar archivos que lo exceden
+	data, err := io.ReadAll(io.LimitReader(file, h.pdtConfig.MaxBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error leyendo el archivo", err.Error()))
+		return
+	}
+
+	importacion, err := h.conteoService.ImportarArchivoPDT(c.Request.Context(), id, header.Filename, data, idUsuarioActual(c))
+	if err != nil {
+		c.JSON(errorStatus(c, err, conteoErrorStatus(err)), errorResponse(c, "❌ Error importando archivo de PDT", err.Error()))
+		return
+	}
+
+	message := "✅ Archivo de PDT importado"
+	if len(importacion.Rechazadas) > 0 {
+		message = "⚠️ Archivo de PDT importado con líneas rechazadas"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    importacion,
+	})
+}
+
+// GetReporteCumplimiento resume el cumplimiento de los conteos por local y usuario
+func (h *ConteoCiclicoHandler) GetReporteCumplimiento(c *gin.Context) {
+	filter, err := parseReporteFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", err.Error()))
+		return
+	}
+	var ok bool
+	if filter.IDUsuario, ok = queryIntOpcional(c, "usuario"); !ok {
+		return
+	}
+
+	reporte, err := h.conteoService.GetCumplimiento(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error generando reporte de conteos cíclicos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Reporte de cumplimiento de conteos cíclicos generado",
+		"data":    reporte,
+	})
+}
+
+func (h *ConteoCiclicoHandler) parseID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de conteo inválido", "El ID debe ser un número válido"))
+		return 0, false
+	}
+	return id, true
+}
+
+// queryIntOpcional lee un parámetro entero opcional; responde 400 si no es un número
+func queryIntOpcional(c *gin.Context, nombre string) (*int, bool) {
+	valor := c.Query(nombre)
+	if valor == "" {
+		return nil, true
+	}
+	n, err := strconv.Atoi(valor)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", nombre+" debe ser un número válido"))
+		return nil, false
+	}
+	return &n, true
+}
+
+// conteoErrorStatus mapea los errores de dominio de los conteos cíclicos a códigos HTTP
+func conteoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrConteoNoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConteoCompletado):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrProductoFueraDeConteo),
+		errors.Is(err, services.ErrArchivoPDTInvalido):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrArchivoPDTDemasiadoGrande):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// EcommerceHandler maneja el stock publicable de la tienda online y sus márgenes de seguridad
+type EcommerceHandler struct {
+	ecommerceService services.EcommerceService
+	validator        *validator.Validate
+	logger           *zap.Logger
+}
+
+// NewEcommerceHandler crea una nueva instancia del handler
+func NewEcommerceHandler(ecommerceService services.EcommerceService, logger *zap.Logger) *EcommerceHandler {
+	return &EcommerceHandler{
+		ecommerceService: ecommerceService,
+		validator:        validator.New(),
+		logger:           logger,
+	}
+}
+
+// GetStockPublicable expone el stock publicable a la tienda online (requiere API key)
+// GET /ecommerce/stock?codigos=A1,B2&canal=ecommerce (por defecto el canal ecommerce)
+// Responde ETag por hash del contenido: con If-None-Match igual responde 304 sin cuerpo
+func (h *EcommerceHandler) GetStockPublicable(c *gin.Context) {
+	canal := c.DefaultQuery("canal", models.CanalEcommerce)
+	if !models.EsCanalValido(canal) {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Canal inválido", "El canal debe ser uno de: "+strings.Join(models.CanalesVenta, ", ")))
+		return
+	}
+
+	var codigos []string
+	for _, codigo := range strings.Split(c.Query("codigos"), ",") {
+		if codigo = strings.TrimSpace(codigo); codigo != "" {
+			codigos = append(codigos, codigo)
+		}
+	}
+
+	productos, err := h.ecommerceService.GetStockPublicable(c.Request.Context(), canal, codigos)
+	if err != nil {
+		h.logger.Error("Error obteniendo stock publicable", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo stock publicable", err.Error()))
+		return
+	}
+
+	data := gin.H{
+		"canal":     canal,
+		"productos": productos,
+		"total":     len(productos),
+	}
+
+	// El cliente hace polling: siempre revalida, y si nada cambió recibe un 304
+	c.Header("Cache-Control", "no-cache")
+	if etag, err := etagJSON(data); err == nil {
+		c.Header("ETag", etag)
+		if etagCoincide(c.GetHeader("If-None-Match"), etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Stock publicable obtenido",
+		"data":    data,
+	})
+}
+
+// GetMargenes lista los márgenes de seguridad definidos por producto y categoría
+func (h *EcommerceHandler) GetMargenes(c *gin.Context) {
+	margenes, err := h.ecommerceService.GetMargenes(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo márgenes", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Márgenes obtenidos",
+		"data": gin.H{
+			"margenes": margenes,
+			"total":    len(margenes),
+		},
+	})
+}
+
+// SetMargenProducto define el margen de seguridad propio de un producto
+func (h *EcommerceHandler) SetMargenProducto(c *gin.Context) {
+	req, ok := h.bindMargen(c)
+	if !ok {
+		return
+	}
+
+	margen, err := h.ecommerceService.SetMargenProducto(c.Request.Context(), c.Param("codigo"), *req.Margen)
+	if err != nil {
+		c.JSON(errorStatus(c, err, ecommerceErrorStatus(err)), errorResponse(c, "❌ Error definiendo margen", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Margen definido",
+		"data":    margen,
+	})
+}
+
+// SetMargenCategoria define el margen de seguridad de una categoría
+func (h *EcommerceHandler) SetMargenCategoria(c *gin.Context) {
+	idCategoria, ok := h.parseCategoria(c)
+	if !ok {
+		return
+	}
+
+	req, ok := h.bindMargen(c)
+	if !ok {
+		return
+	}
+
+	margen, err := h.ecommerceService.SetMargenCategoria(c.Request.Context(), idCategoria, *req.Margen)
+	if err != nil {
+		c.JSON(errorStatus(c, err, ecommerceErrorStatus(err)), errorResponse(c, "❌ Error definiendo margen", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Margen definido",
+		"data":    margen,
+	})
+}
+
+// EliminarMargenProducto elimina el margen propio de un producto
+func (h *EcommerceHandler) EliminarMargenProducto(c *gin.Context) {
+	if err := h.ecommerceService.EliminarMargenProducto(c.Request.Context(), c.Param("codigo")); err != nil {
+		c.JSON(errorStatus(c, err, ecommerceErrorStatus(err)), errorResponse(c, "❌ Error eliminando margen", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Margen eliminado",
+	})
+}
+
+// EliminarMargenCategoria elimina el margen de una categoría
+func (h *EcommerceHandler) EliminarMargenCategoria(c *gin.Context) {
+	idCategoria, ok := h.parseCategoria(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ecommerceService.EliminarMargenCategoria(c.Request.Context(), idCategoria); err != nil {
+		c.JSON(errorStatus(c, err, ecommerceErrorStatus(err)), errorResponse(c, "❌ Error eliminando margen", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Margen eliminado",
+	})
+}
+
+// bindMargen lee y valida el cuerpo con el margen
+func (h *EcommerceHandler) bindMargen(c *gin.Context) (*models.MargenStockRequest, bool) {
+	var req models.MargenStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return nil, false
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// parseCategoria obtiene el ID de la categoría de la URL
+func (h *EcommerceHandler) parseCategoria(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de categoría inválido", "El ID debe ser un número válido"))
+		return 0, false
+	}
+	return id, true
+}
+
+// etagJSON calcula un ETag fuerte a partir del hash del contenido serializado
+func etagJSON(v interface{}) (string, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// etagCoincide indica si el header If-None-Match incluye el ETag (admite lista, W/ y *)
+func etagCoincide(ifNoneMatch, etag string) bool {
+	for _, candidato := range strings.Split(ifNoneMatch, ",") {
+		candidato = strings.TrimPrefix(strings.TrimSpace(candidato), "W/")
+		if candidato == "*" || candidato == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ecommerceErrorStatus mapea los errores de dominio del stock publicable a códigos HTTP
+func ecommerceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrProductoNoEncontrado),
+		errors.Is(err, services.ErrCategoriaNoEncontrada),
+		errors.Is(err, services.ErrMargenNoEncontrado):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
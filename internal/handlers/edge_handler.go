@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"stock-service/internal/edge"
+	"stock-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// edgeHeadersReenviados headers del POS que se conservan al reenviar una venta al central
+var edgeHeadersReenviados = []string{"Content-Type", "Authorization", "X-Request-ID", "X-User-Role", "X-API-Key"}
+
+// EdgeHandler atiende al POS en el modo edge: consultas desde la réplica local y
+// escrituras reenviadas al central (o encoladas sin enlace)
+type EdgeHandler struct {
+	node   *edge.Node
+	logger *zap.Logger
+}
+
+// NewEdgeHandler crea una nueva instancia del handler
+func NewEdgeHandler(node *edge.Node, logger *zap.Logger) *EdgeHandler {
+	return &EdgeHandler{
+		node:   node,
+		logger: logger,
+	}
+}
+
+// SearchProductByBarcode busca en la réplica; si no está, se consulta al central mientras haya enlace
+func (h *EdgeHandler) SearchProductByBarcode(c *gin.Context) {
+	start := time.Now()
+	codigoBarras := c.Param("codigo")
+
+	canal := c.DefaultQuery("canal", models.CanalPOS)
+	if !models.EsCanalValido(canal) {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Canal inválido", fmt.Sprintf("El canal debe ser uno de: %s", strings.Join(models.CanalesVenta, ", "))))
+		return
+	}
+
+	producto := h.node.Replica().Producto(codigoBarras)
+	if producto == nil {
+		if h.node.Enlace() {
+			h.node.Proxy(c.Writer, c.Request)
+			return
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success":    false,
+			"request_id": requestID(c),
+			"message":    "❌ Producto no disponible sin enlace",
+			"error":      "El producto no está en la réplica local y el central no responde",
+			"data": gin.H{
+				"codigo_barras": codigoBarras,
+				"cache_hit":     false,
+				"modo_edge":     true,
+				"latency_ms":    time.Since(start).Milliseconds(),
+			},
+		})
+		return
+	}
+
+	if !producto.HabilitadoEnCanal(canal) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success":    false,
+			"request_id": requestID(c),
+			"message":    "❌ Producto no disponible en el canal",
+			"error":      fmt.Sprintf("El producto %s no está habilitado para el canal %s", producto.Codigo, canal),
+			"data": gin.H{
+				"codigo_barras": codigoBarras,
+				"canal":         canal,
+				"canales":       producto.Canales,
+				"cache_hit":     true,
+				"latency_ms":    time.Since(start).Milliseconds(),
+			},
+		})
+		return
+	}
+
+	successJSON(c, http.StatusOK, "✅ Producto encontrado", &models.BusquedaBarcodeData{
+		Producto:      producto,
+		CacheHit:      true,
+		ModoDegradado: !h.node.Enlace(),
+		LatencyMs:     time.Since(start).Milliseconds(),
+	})
+}
+
+// ExisteProducto responde desde la réplica (HEAD o GET); un código que no está se consulta al central
+func (h *EdgeHandler) ExisteProducto(c *gin.Context) {
+	codigoBarras := c.Param("codigo")
+
+	existe := h.node.Replica().Producto(codigoBarras) != nil
+	if !existe && h.node.Enlace() {
+		h.node.Proxy(c.Writer, c.Request)
+		return
+	}
+
+	status := http.StatusOK
+	if !existe {
+		status = http.StatusNotFound
+	}
+	c.Header("X-Existe-Fuente", "replica")
+	if c.Request.Method == http.MethodHead {
+		c.Status(status)
+		return
+	}
+	c.JSON(status, gin.H{
+		"existe":        existe,
+		"codigo_barras": codigoBarras,
+		"fuente":        "replica",
+	})
+}
+
+// GetStockByProducto responde el stock del local replicado; otros locales se consultan al central
+func (h *EdgeHandler) GetStockByProducto(c *gin.Context) {
+	codigoProducto := c.Param("codigo")
+
+	idLocal := h.node.IDLocal()
+	if idLocalStr := c.Query("local"); idLocalStr != "" {
+		if parsed, err := strconv.Atoi(idLocalStr); err == nil && parsed != idLocal {
+			h.node.Proxy(c.Writer, c.Request)
+			return
+		}
+	}
+
+	stock := h.node.Replica().Stock(codigoProducto)
+	message := "✅ Stock obtenido correctamente"
+	if stock == nil {
+		message = "✅ Producto sin stock disponible"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data": gin.H{
+			"codigo_producto": codigoProducto,
+			"id_local":        idLocal,
+			"stock":           stock,
+			"modo_edge":       true,
+		},
+	})
+}
+
+// QuickSale reenvía la venta al central; sin enlace (o con ventas ya en espera) la encola
+// y descuenta el stock de la réplica hasta la próxima sincronización
+func (h *EdgeHandler) QuickSale(c *gin.Context) {
+	start := time.Now()
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	// Solo para descontar la réplica: la validación completa la hace el central
+	var req models.QuickSaleRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	path := c.Request.URL.RequestURI()
+	if h.node.Enlace() && !h.node.HayPendientes() {
+		header := http.Header{}
+		for _, name := range edgeHeadersReenviados {
+			if value := c.GetHeader(name); value != "" {
+				header.Set(name, value)
+			}
+		}
+
+		resp, err := h.node.Reenviar(c.Request.Context(), c.Request.Method, path, header, body)
+		if err == nil {
+			if resp.Status < http.StatusBadRequest {
+				h.node.SincronizarAhora()
+			}
+			c.Data(resp.Status, resp.Header.Get("Content-Type"), resp.Body)
+			return
+		}
+		h.logger.Warn("El central no respondió la venta, se encola", zap.Error(err))
+	}
+
+	escritura, err := h.node.Encolar(c.Request.Method, path, c.ContentType(), body)
+	if err != nil {
+		h.logger.Error("Error encolando venta en modo edge", zap.Error(err))
+		status := http.StatusServiceUnavailable
+		if errors.Is(err, edge.ErrColaLlena) {
+			status = http.StatusInsufficientStorage
+		}
+		c.JSON(status, errorResponse(c, "❌ Error encolando venta", err.Error()))
+		return
+	}
+	h.node.Replica().Descontar(req.Items)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "⚠️ Venta encolada: se enviará al central cuando vuelva el enlace",
+		"data": gin.H{
+			"id_venta_encolada": escritura.ID,
+			"id_operacion":      escritura.ID,
+			"total_items":       len(req.Items),
+			"modo_edge":         true,
+			"latency_ms":        time.Since(start).Milliseconds(),
+			"timestamp":         escritura.EncoladaAt.Format(time.RFC3339),
+		},
+	})
+}
+
+// GetEstado estado del enlace, de la réplica y de la cola de escrituras
+func (h *EdgeHandler) GetEstado(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Estado del edge obtenido",
+		"data":    h.node.Estado(),
+	})
+}
+
+// GetPendientes escrituras encoladas a la espera del enlace
+func (h *EdgeHandler) GetPendientes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Escrituras pendientes obtenidas",
+		"data":    h.node.Pendientes(),
+	})
+}
+
+// Health responde siempre 200 mientras el proceso esté vivo: sin enlace el edge sigue atendiendo
+func (h *EdgeHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"modo":   "edge",
+		"edge":   h.node.Estado(),
+	})
+}
+
+// Proxy reenvía al central todo lo que el edge no resuelve localmente
+func (h *EdgeHandler) Proxy(c *gin.Context) {
+	h.node.Proxy(c.Writer, c.Request)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"stock-service/internal/repository"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Códigos con que el monitoring clasifica los requests fallidos
+const (
+	errorCodeTimeout       = "timeout"
+	errorCodeDBUnavailable = "db_unavailable"
+	errorCodeUnavailable   = "unavailable"
+	errorCodeValidation    = "validation"
+	errorCodeUnauthorized  = "unauthorized"
+	errorCodeNotFound      = "not_found"
+	errorCodeConflict      = "conflict"
+	errorCodeRateLimited   = "rate_limited"
+	errorCodeClient        = "client_error"
+	errorCodeInternal      = "internal"
+)
+
+// errorStatusKey clave del contexto donde errorStatus deja el código HTTP elegido
+// (errorResponse lo usa para decidir si oculta el detalle del error)
+const errorStatusKey = "error_status"
+
+// errorStatus determina el código HTTP para un error de la capa de servicio
+// Un deadline vencido responde 504 y una BD inalcanzable 503; el resto usa fallback
+// El error queda registrado en el contexto para que el monitoring lo capture
+func errorStatus(c *gin.Context, err error, fallback int) int {
+	c.Error(err)
+
+	status := fallback
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(c.Request.Context().Err(), context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	case repository.IsUnavailable(err):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, services.ErrStockOcupado):
+		// Otra operación tiene tomado el producto: el cliente puede reintentar
+		status = http.StatusConflict
+	}
+	c.Set(errorStatusKey, status)
+	return status
+}
+
+// multipleStatus determina el código HTTP de una entrada o salida múltiple ya procesada
+// Si el deadline venció a mitad de la operación los ítems restantes fallaron por timeout: con
+// algún ítem aplicado se responde 207 con el detalle por ítem, y 504 solo si no se aplicó ninguno
+func multipleStatus(c *gin.Context, aplicados int) int {
+	if !errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		return http.StatusOK
+	}
+	if aplicados > 0 {
+		return http.StatusMultiStatus
+	}
+	return errorStatus(c, context.DeadlineExceeded, http.StatusGatewayTimeout)
+}
+
+// classifyError asigna el código de monitoring a partir del status y el error registrado
+// (nil si el rechazo vino de un middleware: cuota, mantenimiento, timeout)
+func classifyError(status int, err error) string {
+	switch {
+	case status == http.StatusGatewayTimeout || errors.Is(err, context.DeadlineExceeded):
+		return errorCodeTimeout
+	case repository.IsUnavailable(err):
+		return errorCodeDBUnavailable
+	case status == http.StatusServiceUnavailable:
+		return errorCodeUnavailable
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity || status == http.StatusRequestEntityTooLarge:
+		return errorCodeValidation
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return errorCodeUnauthorized
+	case status == http.StatusNotFound:
+		return errorCodeNotFound
+	case status == http.StatusConflict:
+		return errorCodeConflict
+	case status == http.StatusTooManyRequests:
+		return errorCodeRateLimited
+	case status >= 500:
+		return errorCodeInternal
+	default:
+		return errorCodeClient
+	}
+}
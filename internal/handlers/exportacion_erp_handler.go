@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// ExportacionERPHandler maneja la exportación de movimientos al layout del ERP y sus perfiles
+type ExportacionERPHandler struct {
+	exportacionService services.ExportacionERPService
+	validator          *validator.Validate
+	logger             *zap.Logger
+}
+
+// NewExportacionERPHandler crea una nueva instancia del handler
+func NewExportacionERPHandler(exportacionService services.ExportacionERPService, logger *zap.Logger) *ExportacionERPHandler {
+	return &ExportacionERPHandler{
+		exportacionService: exportacionService,
+		validator:          validator.New(),
+		logger:             logger,
+	}
+}
+
+// Exportar descarga los movimientos del período con el layout del perfil
+// GET /reportes/erp?perfil=&local=&desde=YYYY-MM-DD&hasta=YYYY-MM-DD
+func (h *ExportacionERPHandler) Exportar(c *gin.Context) {
+	filter, err := parseReporteFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", err.Error()))
+		return
+	}
+	if c.Query("perfil") == "" {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "perfil es requerido"))
+		return
+	}
+
+	// El perfil se resuelve antes de escribir: una vez iniciada la descarga ya no se puede responder un error
+	perfil, err := h.exportacionService.GetPerfil(c.Request.Context(), c.Query("perfil"))
+	if err != nil {
+		c.JSON(errorStatus(c, err, exportacionERPErrorStatus(err)), errorResponse(c, "❌ Error exportando movimientos", err.Error()))
+		return
+	}
+
+	nombreArchivo := fmt.Sprintf("%s_%s_%s.csv", perfil.Nombre,
+		filter.Desde.Format("20060102"), filter.Hasta.AddDate(0, 0, -1).Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", nombreArchivo))
+	c.Status(http.StatusOK)
+
+	filas, err := h.exportacionService.Exportar(c.Request.Context(), perfil, filter, c.Writer)
+	if err != nil {
+		// La respuesta ya comenzó: el archivo queda truncado y solo se registra el error
+		h.logger.Error("Error exportando movimientos al layout ERP",
+			zap.String("perfil", perfil.Nombre),
+			zap.Int("filas_escritas", filas),
+			zap.Error(err))
+		c.Error(err)
+	}
+}
+
+// CrearPerfil crea un perfil de exportación
+func (h *ExportacionERPHandler) CrearPerfil(c *gin.Context) {
+	var req models.PerfilExportacionERPRequest
+	if !h.bindPerfil(c, &req) {
+		return
+	}
+
+	perfil, err := h.exportacionService.CreatePerfil(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Error creando perfil de exportación ERP", zap.Error(err))
+		c.JSON(errorStatus(c, err, exportacionERPErrorStatus(err)), errorResponse(c, "❌ Error creando perfil de exportación", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Perfil de exportación creado",
+		"data":    perfil,
+	})
+}
+
+// ActualizarPerfil reemplaza un perfil de exportación
+func (h *ExportacionERPHandler) ActualizarPerfil(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.PerfilExportacionERPRequest
+	if !h.bindPerfil(c, &req) {
+		return
+	}
+
+	perfil, err := h.exportacionService.UpdatePerfil(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Error actualizando perfil de exportación ERP", zap.Int("id_perfil", id), zap.Error(err))
+		c.JSON(errorStatus(c, err, exportacionERPErrorStatus(err)), errorResponse(c, "❌ Error actualizando perfil de exportación", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Perfil de exportación actualizado",
+		"data":    perfil,
+	})
+}
+
+// EliminarPerfil elimina un perfil de exportación
+func (h *ExportacionERPHandler) EliminarPerfil(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.exportacionService.DeletePerfil(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(c, err, exportacionERPErrorStatus(err)), errorResponse(c, "❌ Error eliminando perfil de exportación", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Perfil de exportación eliminado",
+	})
+}
+
+// GetPerfiles lista los perfiles de exportación
+func (h *ExportacionERPHandler) GetPerfiles(c *gin.Context) {
+	perfiles, err := h.exportacionService.GetPerfiles(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo perfiles de exportación", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Perfiles de exportación obtenidos",
+		"data":    perfiles,
+	})
+}
+
+// bindPerfil lee y valida el cuerpo de un perfil; responde el error y retorna false si es inválido
+func (h *ExportacionERPHandler) bindPerfil(c *gin.Context, req *models.PerfilExportacionERPRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return false
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return false
+	}
+	return true
+}
+
+// parseID obtiene el ID del perfil de la URL
+func (h *ExportacionERPHandler) parseID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de perfil inválido", "El ID debe ser un número válido"))
+		return 0, false
+	}
+	return id, true
+}
+
+// exportacionERPErrorStatus mapea los errores de dominio de la exportación ERP a códigos HTTP
+func exportacionERPErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPerfilERPNoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPerfilERPDuplicado):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrPerfilERPInvalido):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
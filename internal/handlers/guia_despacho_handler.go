@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// guiaImprimibleTemplate formato de impresión de una guía de despacho
+var guiaImprimibleTemplate = template.Must(template.New("guia").Parse(`<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<title>Guía de despacho {{.Numero}}</title>
+<style>
+body { font-family: sans-serif; font-size: 12px; margin: 24px; }
+h1 { font-size: 18px; margin-bottom: 4px; }
+table { border-collapse: collapse; width: 100%; margin-top: 16px; }
+th, td { border: 1px solid #444; padding: 4px 6px; text-align: left; }
+td.num { text-align: right; }
+.firmas { display: flex; justify-content: space-between; margin-top: 64px; }
+.firmas div { border-top: 1px solid #444; width: 40%; text-align: center; padding-top: 4px; }
+</style>
+</head>
+<body>
+<h1>Guía de despacho N° {{.Numero}}</h1>
+<p>Emitida: {{.CreatedAt.Format "02-01-2006 15:04"}} · Estado: {{.Estado}}</p>
+<p><strong>Origen:</strong> {{with .LocalOrigen}}{{.Nombre}}{{with .Direccion}} — {{.}}{{end}}{{else}}Local {{.IDLocalOrigen}}{{end}}<br>
+<strong>Destino:</strong> {{with .LocalDestino}}{{.Nombre}}{{with .Direccion}} — {{.}}{{end}}{{else}}Local {{.IDLocalDestino}}{{end}}</p>
+{{if .Observaciones}}<p><strong>Observaciones:</strong> {{.Observaciones}}</p>{{end}}
+<table>
+<thead><tr><th>Código</th><th>Descripción</th><th>Tipo</th><th>Enviado</th><th>Recibido</th></tr></thead>
+<tbody>
+{{range .Items}}<tr><td>{{.CodigoProducto}}</td><td>{{.Descripcion}}</td><td>{{.TipoItem}}</td><td class="num">{{.CantidadEnviada}}</td><td class="num">{{with .CantidadRecibida}}{{.}}{{end}}</td></tr>
+{{end}}</tbody>
+</table>
+<div class="firmas"><div>Despacha</div><div>Recibe</div></div>
+</body>
+</html>
+`))
+
+// GuiaDespachoHandler maneja las peticiones HTTP de las guías de despacho entre locales
+type GuiaDespachoHandler struct {
+	guiaService services.GuiaDespachoService
+	validator   *validator.Validate
+	logger      *zap.Logger
+}
+
+// NewGuiaDespachoHandler crea una nueva instancia del handler
+func NewGuiaDespachoHandler(guiaService services.GuiaDespachoService, logger *zap.Logger) *GuiaDespachoHandler {
+	return &GuiaDespachoHandler{
+		guiaService: guiaService,
+		validator:   validator.New(),
+		logger:      logger,
+	}
+}
+
+// EmitirGuia emite la guía y descuenta el stock en el local origen
+func (h *GuiaDespachoHandler) EmitirGuia(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "emitir_guia"))
+
+	var req models.EmitirGuiaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	guia, err := h.guiaService.EmitirGuia(c.Request.Context(), &req)
+	if err != nil {
+		logger.Error("Error emitiendo guía de despacho", zap.Error(err))
+		c.JSON(errorStatus(c, err, guiaErrorStatus(err)), errorResponse(c, "❌ Error emitiendo guía de despacho", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Guía de despacho emitida, stock descontado en origen",
+		"data":    guia,
+	})
+}
+
+// GetGuia obtiene una guía con sus ítems
+func (h *GuiaDespachoHandler) GetGuia(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	guia, err := h.guiaService.GetGuia(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, guiaErrorStatus(err)), errorResponse(c, "❌ Error obteniendo guía de despacho", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Guía de despacho obtenida",
+		"data":    guia,
+	})
+}
+
+// ImprimirGuia entrega la guía en formato HTML listo para imprimir
+func (h *GuiaDespachoHandler) ImprimirGuia(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	guia, err := h.guiaService.GetGuiaImprimible(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, guiaErrorStatus(err)), errorResponse(c, "❌ Error obteniendo guía de despacho", err.Error()))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := guiaImprimibleTemplate.Execute(&buf, guia); err != nil {
+		h.logger.Error("Error generando guía imprimible", zap.Int("id_guia", id), zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error generando guía imprimible", err.Error()))
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// DespacharGuia marca la guía como en tránsito
+func (h *GuiaDespachoHandler) DespacharGuia(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	guia, err := h.guiaService.DespacharGuia(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, guiaErrorStatus(err)), errorResponse(c, "❌ Error despachando guía", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Guía de despacho en tránsito",
+		"data":    guia,
+	})
+}
+
+// RecibirGuia confirma la recepción e ingresa el stock en el local destino
+func (h *GuiaDespachoHandler) RecibirGuia(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "recibir_guia"))
+
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.RecibirGuiaRequest
+	// El body es opcional: sin ítems se recibe lo enviado
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+			return
+		}
+		if err := h.validator.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+			return
+		}
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	guia, err := h.guiaService.RecibirGuia(c.Request.Context(), id, &req)
+	if err != nil {
+		logger.Error("Error recibiendo guía de despacho", zap.Int("id_guia", id), zap.Error(err))
+		c.JSON(errorStatus(c, err, guiaErrorStatus(err)), errorResponse(c, "❌ Error recibiendo guía de despacho", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Guía recibida, stock ingresado en destino",
+		"data":    guia,
+	})
+}
+
+// GetGuiasEnTransito obtiene el reporte de guías despachadas pendientes de recepción
+func (h *GuiaDespachoHandler) GetGuiasEnTransito(c *gin.Context) {
+	filter := &models.GuiaEnTransitoFilter{}
+
+	if origenStr := c.Query("origen"); origenStr != "" {
+		if origen, err := strconv.Atoi(origenStr); err == nil {
+			filter.IDLocalOrigen = &origen
+		}
+	}
+
+	if destinoStr := c.Query("destino"); destinoStr != "" {
+		if destino, err := strconv.Atoi(destinoStr); err == nil {
+			filter.IDLocalDestino = &destino
+		}
+	}
+
+	guias, err := h.guiaService.GetGuiasEnTransito(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo guías en tránsito", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Guías en tránsito obtenidas",
+		"data": gin.H{
+			"guias": guias,
+			"total": len(guias),
+		},
+	})
+}
+
+// GetReporteMermas reporta las diferencias de recepción (mermas y sobrantes de transporte)
+// GET /guias/mermas?local=&desde=YYYY-MM-DD&hasta=YYYY-MM-DD (local: origen o destino)
+func (h *GuiaDespachoHandler) GetReporteMermas(c *gin.Context) {
+	filter, err := parseReporteFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", err.Error()))
+		return
+	}
+
+	reporte, err := h.guiaService.GetReporteMermas(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Error generando reporte de mermas de transporte", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error generando reporte de mermas", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Reporte de mermas de transporte generado",
+		"data":    reporte,
+	})
+}
+
+// parseID obtiene el ID de la guía de la URL
+func (h *GuiaDespachoHandler) parseID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de guía inválido", "El ID debe ser un número válido"))
+		return 0, false
+	}
+	return id, true
+}
+
+// guiaErrorStatus mapea los errores de dominio de las guías de despacho a códigos HTTP
+func guiaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrGuiaNoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrGuiaEstadoInvalido),
+		errors.Is(err, services.ErrDocumentoDuplicado),
+		errors.Is(err, services.ErrDiferenciaFueraTolerancia):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrCantidadRecibidaInvalida),
+		errors.Is(err, services.ErrUnidadSinConversion),
+		errors.Is(err, services.ErrLocalNoEncontrado),
+		errors.Is(err, services.ErrLocalInactivo),
+		errors.Is(err, services.ErrProductoNoEncontrado),
+		errors.Is(err, services.ErrProductoDescontinuado):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"stock-service/internal/config"
+	"stock-service/internal/middleware"
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// JobHandler maneja las peticiones HTTP de los jobs asíncronos (exportaciones e importaciones)
+type JobHandler struct {
+	jobService services.JobService
+	pdtConfig  config.PDTConfig
+	logger     *zap.Logger
+}
+
+// NewJobHandler crea una nueva instancia del handler
+func NewJobHandler(jobService services.JobService, pdtConfig config.PDTConfig, logger *zap.Logger) *JobHandler {
+	return &JobHandler{
+		jobService: jobService,
+		pdtConfig:  pdtConfig,
+		logger:     logger,
+	}
+}
+
+// EncolarExportacionStock encola la exportación del stock del local (la de /stock/local/:id/export)
+// POST /jobs/exportaciones/stock/:id?formato=csv|xlsx  body opcional {"callback_url": "..."}
+func (h *JobHandler) EncolarExportacionStock(c *gin.Context) {
+	idLocal, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de local inválido", "El ID debe ser un número válido"))
+		return
+	}
+	formato, ok := parseFormatoExportacion(c)
+	if !ok {
+		return
+	}
+	callbackURL, ok := parseCallbackURL(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobService.EncolarExportacionStock(c.Request.Context(), idLocal, formato, callbackURL, idUsuarioActual(c))
+	h.responderEncolado(c, job, err)
+}
+
+// EncolarExportacionMovimientos encola la exportación de movimientos con los filtros de
+// GET /movimientos/export
+// POST /jobs/exportaciones/movimientos?formato=csv|xlsx&local=&tipo=&fecha_desde=&fecha_hasta=...
+func (h *JobHandler) EncolarExportacionMovimientos(c *gin.Context) {
+	filter, ok := parseMovimientosQuery(c)
+	if !ok {
+		return
+	}
+	formato, ok := parseFormatoExportacion(c)
+	if !ok {
+		return
+	}
+	callbackURL, ok := parseCallbackURL(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobService.EncolarExportacionMovimientos(c.Request.Context(), filter, formato, callbackURL, idUsuarioActual(c))
+	h.responderEncolado(c, job, err)
+}
+
+// EncolarImportacionPDT encola la carga del archivo de la PDT en la sesión de conteo
+// POST /jobs/importaciones/conteos-ciclicos/:id/archivo-pdt (multipart: archivo y callback_url opcional)
+func (h *JobHandler) EncolarImportacionPDT(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de conteo inválido", "El ID debe ser un número válido"))
+		return
+	}
+
+	file, header, err := c.Request.FormFile("archivo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Archivo requerido", "Envíe el archivo de la PDT en el campo multipart 'archivo'"))
+		return
+	}
+	defer file.Close()
+
+	// Leer un byte más del máximo para detectar archivos que lo exceden
+	data, err := io.ReadAll(io.LimitReader(file, h.pdtConfig.MaxBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error leyendo el archivo", err.Error()))
+		return
+	}
+	if h.pdtConfig.MaxBytes > 0 && int64(len(data)) > h.pdtConfig.MaxBytes {
+		err := fmt.Errorf("%w: más de %d bytes", services.ErrArchivoPDTDemasiadoGrande, h.pdtConfig.MaxBytes)
+		c.JSON(errorStatus(c, err, conteoErrorStatus(err)), errorResponse(c, "❌ Error importando archivo de PDT", err.Error()))
+		return
+	}
+
+	callbackURL := strings.TrimSpace(c.PostForm("callback_url"))
+	job, err := h.jobService.EncolarImportacionPDT(c.Request.Context(), id, header.Filename, data, callbackURL, idUsuarioActual(c))
+	h.responderEncolado(c, job, err)
+}
+
+// GetJob obtiene el estado y resultado de un job (con los enlaces de descarga al completarse)
+func (h *JobHandler) GetJob(c *gin.Context) {
+	job, err := h.jobService.GetJob(c.Request.Context(), c.Param("id"))
+	if err == nil && !puedeVerJob(c, job) {
+		err = fmt.Errorf("%w: %s", services.ErrJobNoEncontrado, c.Param("id"))
+	}
+	if err != nil {
+		c.JSON(errorStatus(c, err, jobErrorStatus(err)), errorResponse(c, "❌ Error obteniendo job", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Job obtenido",
+		"data":    job,
+	})
+}
+
+// DescargarArchivo descarga un archivo generado por el job
+// GET /jobs/:id/archivos/:nombre
+func (h *JobHandler) DescargarArchivo(c *gin.Context) {
+	job, err := h.jobService.GetJob(c.Request.Context(), c.Param("id"))
+	if err == nil && !puedeVerJob(c, job) {
+		err = fmt.Errorf("%w: %s", services.ErrJobNoEncontrado, c.Param("id"))
+	}
+	if err != nil {
+		c.JSON(errorStatus(c, err, jobErrorStatus(err)), errorResponse(c, "❌ Error descargando archivo", err.Error()))
+		return
+	}
+
+	archivo, reader, err := h.jobService.AbrirArchivo(c.Request.Context(), job.ID, c.Param("nombre"))
+	if err != nil {
+		c.JSON(errorStatus(c, err, jobErrorStatus(err)), errorResponse(c, "❌ Error descargando archivo", err.Error()))
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, -1, archivo.ContentType, reader, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", archivo.Nombre),
+	})
+}
+
+// responderEncolado responde 202 con el job encolado o el error al encolarlo
+func (h *JobHandler) responderEncolado(c *gin.Context, job *models.Job, err error) {
+	if err != nil {
+		h.logger.Error("Error encolando job", zap.Error(err))
+		c.JSON(errorStatus(c, err, jobErrorStatus(err)), errorResponse(c, "❌ Error encolando job", err.Error()))
+		return
+	}
+
+	c.Header("Location", "/api/v1/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "✅ Job encolado, consulte su estado en /api/v1/jobs/" + job.ID,
+		"data":    job,
+	})
+}
+
+// parseCallbackURL lee el callback_url del body JSON opcional; responde 400 y retorna false si
+// el body no es válido
+func parseCallbackURL(c *gin.Context) (string, bool) {
+	var req models.JobRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+			return "", false
+		}
+	}
+	return strings.TrimSpace(req.CallbackURL), true
+}
+
+// puedeVerJob el job lo consulta quien lo encoló o un admin
+func puedeVerJob(c *gin.Context, job *models.Job) bool {
+	if claims, ok := middleware.UsuarioActual(c); ok && claims.Rol == models.RolAdmin {
+		return true
+	}
+	return job.IDUsuario == idUsuarioActual(c)
+}
+
+// jobErrorStatus mapea los errores de dominio de los jobs a códigos HTTP
+func jobErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrJobNoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrJobInvalido), errors.Is(err, services.ErrCallbackInvalido):
+		return http.StatusBadRequest
+	default:
+		return conteoErrorStatus(err)
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// MinimoEstacionalHandler maneja los mínimos de stock por temporada
+type MinimoEstacionalHandler struct {
+	minimoService services.MinimoEstacionalService
+	validator     *validator.Validate
+	logger        *zap.Logger
+}
+
+// NewMinimoEstacionalHandler crea una nueva instancia del handler
+func NewMinimoEstacionalHandler(minimoService services.MinimoEstacionalService, logger *zap.Logger) *MinimoEstacionalHandler {
+	return &MinimoEstacionalHandler{
+		minimoService: minimoService,
+		validator:     validator.New(),
+		logger:        logger,
+	}
+}
+
+// GetMinimos lista los mínimos estacionales (?codigo=&local=&vigentes=true)
+func (h *MinimoEstacionalHandler) GetMinimos(c *gin.Context) {
+	filter := &models.MinimoEstacionalFilter{
+		SoloVigentes: c.Query("vigentes") == "true",
+	}
+	if codigo := c.Query("codigo"); codigo != "" {
+		filter.CodigoProducto = &codigo
+	}
+	if idLocalStr := c.Query("local"); idLocalStr != "" {
+		if idLocal, err := strconv.Atoi(idLocalStr); err == nil {
+			filter.IDLocal = &idLocal
+		}
+	}
+
+	minimos, err := h.minimoService.GetMinimos(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo mínimos estacionales", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Mínimos estacionales obtenidos",
+		"data": gin.H{
+			"minimos": minimos,
+			"total":   len(minimos),
+		},
+	})
+}
+
+// CrearMinimo define el mínimo de un producto en un local para un rango de fechas
+func (h *MinimoEstacionalHandler) CrearMinimo(c *gin.Context) {
+	var req models.MinimoEstacionalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+	req.IDUsuario = idUsuarioActual(c)
+
+	minimo, err := h.minimoService.CrearMinimo(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, minimoEstacionalErrorStatus(err)), errorResponse(c, "❌ Error definiendo mínimo estacional", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Mínimo estacional definido",
+		"data":    minimo,
+	})
+}
+
+// EliminarMinimo elimina un mínimo estacional
+func (h *MinimoEstacionalHandler) EliminarMinimo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID inválido", "El ID debe ser un número válido"))
+		return
+	}
+
+	if err := h.minimoService.EliminarMinimo(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(c, err, minimoEstacionalErrorStatus(err)), errorResponse(c, "❌ Error eliminando mínimo estacional", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Mínimo estacional eliminado",
+	})
+}
+
+// minimoEstacionalErrorStatus mapea los errores de dominio de mínimos estacionales a códigos HTTP
+func minimoEstacionalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrMinimoEstacionalInvalido),
+		errors.Is(err, services.ErrLocalInactivo):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrProductoNoEncontrado),
+		errors.Is(err, services.ErrLocalNoEncontrado),
+		errors.Is(err, services.ErrMinimoEstacionalNoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrMinimoEstacionalSolapado):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
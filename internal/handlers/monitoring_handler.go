@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"context"
+	"crypto/subtle"
 	"net/http"
+	"strings"
 	"time"
 
+	"stock-service/internal/config"
+	"stock-service/internal/metrics"
 	"stock-service/internal/models"
 	"stock-service/internal/services"
 
@@ -15,12 +19,14 @@ import (
 
 type MonitoringHandler struct {
 	monitoringService services.MonitoringService
+	metricsConfig     config.MetricsConfig
 	logger            *zap.Logger
 }
 
-func NewMonitoringHandler(monitoringService services.MonitoringService, logger *zap.Logger) *MonitoringHandler {
+func NewMonitoringHandler(monitoringService services.MonitoringService, metricsConfig config.MetricsConfig, logger *zap.Logger) *MonitoringHandler {
 	return &MonitoringHandler{
 		monitoringService: monitoringService,
+		metricsConfig:     metricsConfig,
 		logger:            logger,
 	}
 }
@@ -40,6 +46,26 @@ func (h *MonitoringHandler) GetMetrics(c *gin.Context) {
 	c.JSON(http.StatusOK, metrics)
 }
 
+// PrometheusMetrics expone las métricas en el formato de texto de Prometheus (GET /metrics)
+// Con METRICS_TOKEN configurado exige "Authorization: Bearer <token>"
+func (h *MonitoringHandler) PrometheusMetrics(c *gin.Context) {
+	if token := h.metricsConfig.Token; token != "" {
+		enviado := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(enviado), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.JSON(http.StatusUnauthorized, errorResponse(c, "❌ Token de métricas inválido", "Envíe el token en el header Authorization: Bearer <token>"))
+			return
+		}
+	}
+
+	c.Header("Content-Type", metrics.ContentType)
+	c.Status(http.StatusOK)
+	if err := h.monitoringService.WritePrometheus(c.Writer); err != nil {
+		h.logger.Error("Error escribiendo métricas Prometheus", zap.Error(err))
+		c.Error(err)
+	}
+}
+
 // WebSocketUpgrader configuración para WebSocket
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
@@ -114,11 +140,19 @@ func (h *MonitoringHandler) RecordRequestMiddleware() gin.HandlerFunc {
 		// Registrar request
 		requestData := models.RequestData{
 			Endpoint:   path,
+			Route:      c.FullPath(),
 			Method:     c.Request.Method,
 			Duration:   duration,
 			StatusCode: c.Writer.Status(),
 			Timestamp:  time.Now(),
-			Error:      nil, // TODO: Capturar errores específicos
+		}
+
+		// Error de dominio registrado por el handler (errorStatus/errorResponse)
+		if last := c.Errors.Last(); last != nil {
+			requestData.Error = last.Err
+		}
+		if requestData.Error != nil || requestData.StatusCode >= 400 {
+			requestData.ErrorCode = classifyError(requestData.StatusCode, requestData.Error)
 		}
 
 		h.monitoringService.RecordRequest(requestData)
@@ -132,6 +166,7 @@ func (h *MonitoringHandler) shouldSkipMonitoring(path string) bool {
 		"/api/v1/monitoring/metrics",
 		"/api/v1/monitoring/metrics/summary",
 		"/api/v1/monitoring/ws",
+		"/metrics",
 		"/health/monitoring",
 		"/health",
 		"/",
@@ -189,10 +224,11 @@ func (h *MonitoringHandler) GetMetricsSummary(c *gin.Context) {
 	// Crear resumen
 	summary := gin.H{
 		"requests": gin.H{
-			"total":         metrics.Requests.TotalRequests,
-			"endpoints":     metrics.Requests.Total,
-			"errors":        metrics.Requests.ErrorsCount,
-			"slow_requests": metrics.Requests.SlowRequestsCount,
+			"total":          metrics.Requests.TotalRequests,
+			"endpoints":      metrics.Requests.Total,
+			"errors":         metrics.Requests.ErrorsCount,
+			"errors_by_code": metrics.Requests.ErrorsByCode,
+			"slow_requests":  metrics.Requests.SlowRequestsCount,
 		},
 		"performance": gin.H{
 			"avg_response_time": metrics.Performance.AvgResponseTimeMs,
@@ -200,14 +236,20 @@ func (h *MonitoringHandler) GetMetricsSummary(c *gin.Context) {
 			"min_response_time": metrics.Performance.MinResponseTimeMs,
 		},
 		"cache": gin.H{
-			"hit_rate":   metrics.Cache.HitRatePercentage,
-			"total_keys": metrics.Cache.TotalKeys,
-			"status":     metrics.Cache.Status,
+			"hit_rate":                  metrics.Cache.HitRatePercentage,
+			"hit_rate_ultima_hora":      metrics.Cache.HitRateUltimaHoraPercentage,
+			"total_keys":                metrics.Cache.TotalKeys,
+			"status":                    metrics.Cache.Status,
+			"pending_invalidations":     metrics.Cache.PendingInvalidations,
+			"dead_letter_invalidations": metrics.Cache.DeadLetterInvalidations,
 		},
 		"database": gin.H{
 			"active_connections": metrics.Database.ActiveConnections,
 			"total_queries":      metrics.Database.TotalQueries,
 			"status":             metrics.Database.Status,
+			"pool_in_use":        metrics.Database.Pool.InUse,
+			"pool_wait_count":    metrics.Database.Pool.WaitCount,
+			"pool_wait_alert":    metrics.Database.Pool.WaitAlert,
 		},
 		"system": gin.H{
 			"memory_usage": metrics.System.MemoryUsage,
@@ -220,6 +262,14 @@ func (h *MonitoringHandler) GetMetricsSummary(c *gin.Context) {
 			"memory":    metrics.Redis.MemoryMB,
 			"status":    metrics.Redis.Status,
 		},
+		"barcode": gin.H{
+			"count":                 metrics.Barcode.Total.Count,
+			"p50_ms":                metrics.Barcode.Total.P50Ms,
+			"p95_ms":                metrics.Barcode.Total.P95Ms,
+			"p99_ms":                metrics.Barcode.Total.P99Ms,
+			"sla_ms":                metrics.Barcode.SLAMs,
+			"within_sla_percentage": metrics.Barcode.WithinSLAPercentage,
+		},
 		"timestamp": metrics.Timestamp,
 	}
 
@@ -229,3 +279,22 @@ func (h *MonitoringHandler) GetMetricsSummary(c *gin.Context) {
 
 	c.JSON(http.StatusOK, summary)
 }
+
+// GetBarcodeLatency latencias del escaneo por código de barras por etapa, con percentiles
+func (h *MonitoringHandler) GetBarcodeLatency(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Latencias del escaneo obtenidas",
+		"data":    h.monitoringService.GetBarcodeLatency(),
+	})
+}
+
+// ResetBarcodeLatency descarta las muestras de latencia del escaneo
+// Útil tras desplegar un cambio de queries para medir contra una línea base limpia
+func (h *MonitoringHandler) ResetBarcodeLatency(c *gin.Context) {
+	h.monitoringService.ResetBarcodeLatency()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Latencias del escaneo reiniciadas",
+	})
+}
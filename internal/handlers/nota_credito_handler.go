@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// NotaCreditoHandler maneja las peticiones HTTP de las notas de crédito sobre ventas del POS
+type NotaCreditoHandler struct {
+	notaCreditoService services.NotaCreditoService
+	validator          *validator.Validate
+	logger             *zap.Logger
+}
+
+// NewNotaCreditoHandler crea una nueva instancia del handler
+func NewNotaCreditoHandler(notaCreditoService services.NotaCreditoService, logger *zap.Logger) *NotaCreditoHandler {
+	return &NotaCreditoHandler{
+		notaCreditoService: notaCreditoService,
+		validator:          validator.New(),
+		logger:             logger,
+	}
+}
+
+// EmitirNotaCredito anula una venta cerrada reingresando su stock
+func (h *NotaCreditoHandler) EmitirNotaCredito(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "emitir_nota_credito"))
+
+	var req models.EmitirNotaCreditoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+	// El rol del supervisor que autoriza lo informa el POS
+	req.RolAutorizador = c.GetHeader("X-User-Role")
+
+	nota, err := h.notaCreditoService.EmitirNotaCredito(c.Request.Context(), &req)
+	if err != nil {
+		logger.Error("Error emitiendo nota de crédito", zap.Error(err))
+		c.JSON(errorStatus(c, err, notaCreditoErrorStatus(err)), errorResponse(c, "❌ Error emitiendo nota de crédito", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Nota de crédito emitida, stock reingresado",
+		"data":    nota,
+	})
+}
+
+// GetNotaCredito obtiene una nota de crédito con sus ítems
+func (h *NotaCreditoHandler) GetNotaCredito(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de nota de crédito inválido", "El ID debe ser un número válido"))
+		return
+	}
+
+	nota, err := h.notaCreditoService.GetNotaCredito(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, notaCreditoErrorStatus(err)), errorResponse(c, "❌ Error obteniendo nota de crédito", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Nota de crédito obtenida",
+		"data":    nota,
+	})
+}
+
+// GetNotasCreditoVenta lista las notas de crédito emitidas sobre una venta
+func (h *NotaCreditoHandler) GetNotasCreditoVenta(c *gin.Context) {
+	idOperacion := c.Param("id_operacion")
+
+	notas, err := h.notaCreditoService.GetNotasCreditoVenta(c.Request.Context(), idOperacion)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo notas de crédito de la venta", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Notas de crédito de la venta obtenidas",
+		"data":    notas,
+	})
+}
+
+// GetReporte resume las notas de crédito aplicadas por local y medio de pago
+func (h *NotaCreditoHandler) GetReporte(c *gin.Context) {
+	filter, err := parseReporteFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", err.Error()))
+		return
+	}
+
+	reporte, err := h.notaCreditoService.GetReporte(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error generando reporte de notas de crédito", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Reporte de notas de crédito generado",
+		"data":    reporte,
+	})
+}
+
+// notaCreditoErrorStatus mapea los errores de dominio de las notas de crédito a códigos HTTP
+func notaCreditoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrRolNoAutorizado):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrVentaNoEncontrada),
+		errors.Is(err, services.ErrNotaCreditoNoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPlazoNotaCreditoVencido),
+		errors.Is(err, services.ErrVentaYaAcreditada),
+		errors.Is(err, services.ErrDocumentoDuplicado):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrNotaCreditoExcedeVenta),
+		errors.Is(err, services.ErrLocalInactivo),
+		errors.Is(err, services.ErrProductoNoEncontrado):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
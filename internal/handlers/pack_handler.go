@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PackHandler maneja las consultas del catálogo de packs
+type PackHandler struct {
+	packService services.PackService
+	logger      *zap.Logger
+}
+
+// NewPackHandler crea una nueva instancia del handler
+func NewPackHandler(packService services.PackService, logger *zap.Logger) *PackHandler {
+	return &PackHandler{
+		packService: packService,
+		logger:      logger,
+	}
+}
+
+// ListPacks lista el catálogo de packs con componentes y precios
+// GET /packs?limit=&offset=
+func (h *PackHandler) ListPacks(c *gin.Context) {
+	limit, ok := queryIntOpcional(c, "limit")
+	if !ok {
+		return
+	}
+	offset, ok := queryIntOpcional(c, "offset")
+	if !ok {
+		return
+	}
+
+	var l, o int
+	if limit != nil {
+		l = *limit
+	}
+	if offset != nil {
+		o = *offset
+	}
+
+	pagina, err := h.packService.ListPacks(c.Request.Context(), l, o)
+	if err != nil {
+		h.logger.Error("Error listando packs", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error listando packs", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Packs obtenidos",
+		"data":    pagina,
+	})
+}
+
+// GetPacksPorArticulo lista los packs que incluyen un producto (por código o código de barras)
+// GET /packs/por-articulo/:codigo
+func (h *PackHandler) GetPacksPorArticulo(c *gin.Context) {
+	codigo := c.Param("codigo")
+
+	packs, err := h.packService.GetPacksPorArticulo(c.Request.Context(), codigo)
+	if err != nil {
+		h.logger.Error("Error obteniendo packs por artículo", zap.String("codigo", codigo), zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo packs", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Packs obtenidos",
+		"data": gin.H{
+			"codigo_articulo": codigo,
+			"packs":           packs,
+			"total":           len(packs),
+		},
+	})
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// PickingHandler maneja las peticiones HTTP del picking en dos pasos
+type PickingHandler struct {
+	pickingService services.PickingService
+	validator      *validator.Validate
+	logger         *zap.Logger
+}
+
+// NewPickingHandler crea una nueva instancia del handler
+func NewPickingHandler(pickingService services.PickingService, logger *zap.Logger) *PickingHandler {
+	return &PickingHandler{
+		pickingService: pickingService,
+		validator:      validator.New(),
+		logger:         logger,
+	}
+}
+
+// PrepararPicking reserva los ítems y genera la lista de picking
+func (h *PickingHandler) PrepararPicking(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "preparar_picking"))
+
+	var req models.PrepararPickingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	picking, err := h.pickingService.PrepararPicking(c.Request.Context(), &req)
+	if err != nil {
+		logger.Error("Error preparando picking", zap.Error(err))
+		c.JSON(errorStatus(c, err, pickingErrorStatus(err)), errorResponse(c, "❌ Error preparando picking", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Picking preparado, ítems reservados",
+		"data":    picking,
+	})
+}
+
+// GetPicking obtiene el estado y la lista de picking de una preparación
+func (h *PickingHandler) GetPicking(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	picking, err := h.pickingService.GetPicking(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, pickingErrorStatus(err)), errorResponse(c, "❌ Error obteniendo picking", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Picking obtenido",
+		"data":    picking,
+	})
+}
+
+// ConfirmarPicking descuenta el stock con las cantidades realmente pickeadas
+func (h *PickingHandler) ConfirmarPicking(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "confirmar_picking"))
+
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ConfirmarPickingRequest
+	// El body es opcional: sin ítems se confirma lo solicitado
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+			return
+		}
+		if err := h.validator.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+			return
+		}
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	picking, err := h.pickingService.ConfirmarPicking(c.Request.Context(), id, &req)
+	if err != nil {
+		logger.Error("Error confirmando picking", zap.Int("id_picking", id), zap.Error(err))
+		c.JSON(errorStatus(c, err, pickingErrorStatus(err)), errorResponse(c, "❌ Error confirmando picking", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Picking confirmado, stock descontado",
+		"data":    picking,
+	})
+}
+
+// CancelarPicking libera la reserva de una preparación
+func (h *PickingHandler) CancelarPicking(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	picking, err := h.pickingService.CancelarPicking(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, pickingErrorStatus(err)), errorResponse(c, "❌ Error cancelando picking", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Picking cancelado, reserva liberada",
+		"data":    picking,
+	})
+}
+
+// parseID obtiene el ID del picking de la URL
+func (h *PickingHandler) parseID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de picking inválido", "El ID debe ser un número válido"))
+		return 0, false
+	}
+	return id, true
+}
+
+// pickingErrorStatus mapea los errores de dominio del picking a códigos HTTP
+func pickingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPickingNoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPickingEstadoInvalido), errors.Is(err, services.ErrPickingExpirado):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrCantidadPickeadaInvalida),
+		errors.Is(err, services.ErrUnidadSinConversion),
+		errors.Is(err, services.ErrLocalNoEncontrado),
+		errors.Is(err, services.ErrLocalInactivo):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// PlantillaHandler maneja las plantillas de recepción recurrente
+type PlantillaHandler struct {
+	plantillaService services.PlantillaService
+	validator        *validator.Validate
+	logger           *zap.Logger
+}
+
+// NewPlantillaHandler crea una nueva instancia del handler
+func NewPlantillaHandler(plantillaService services.PlantillaService, logger *zap.Logger) *PlantillaHandler {
+	return &PlantillaHandler{
+		plantillaService: plantillaService,
+		validator:        validator.New(),
+		logger:           logger,
+	}
+}
+
+// CrearPlantilla guarda una entrada múltiple recurrente
+func (h *PlantillaHandler) CrearPlantilla(c *gin.Context) {
+	req, ok := h.bindPlantilla(c)
+	if !ok {
+		return
+	}
+
+	plantilla, err := h.plantillaService.CrearPlantilla(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, plantillaErrorStatus(err)), errorResponse(c, "❌ Error creando plantilla", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Plantilla de entrada creada",
+		"data":    plantilla,
+	})
+}
+
+// GetPlantillas lista las plantillas (?local= filtra por local)
+func (h *PlantillaHandler) GetPlantillas(c *gin.Context) {
+	var idLocal *int
+	if localStr := c.Query("local"); localStr != "" {
+		if local, err := strconv.Atoi(localStr); err == nil {
+			idLocal = &local
+		}
+	}
+
+	plantillas, err := h.plantillaService.GetPlantillas(c.Request.Context(), idLocal)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo plantillas", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Plantillas obtenidas",
+		"data": gin.H{
+			"plantillas": plantillas,
+			"total":      len(plantillas),
+		},
+	})
+}
+
+// GetPlantilla obtiene una plantilla con sus productos
+func (h *PlantillaHandler) GetPlantilla(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	plantilla, err := h.plantillaService.GetPlantilla(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, plantillaErrorStatus(err)), errorResponse(c, "❌ Error obteniendo plantilla", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Plantilla obtenida",
+		"data":    plantilla,
+	})
+}
+
+// ActualizarPlantilla reemplaza los datos y los productos de la plantilla
+func (h *PlantillaHandler) ActualizarPlantilla(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := h.bindPlantilla(c)
+	if !ok {
+		return
+	}
+
+	plantilla, err := h.plantillaService.ActualizarPlantilla(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, plantillaErrorStatus(err)), errorResponse(c, "❌ Error actualizando plantilla", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Plantilla actualizada",
+		"data":    plantilla,
+	})
+}
+
+// EliminarPlantilla elimina la plantilla
+func (h *PlantillaHandler) EliminarPlantilla(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.plantillaService.EliminarPlantilla(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(c, err, plantillaErrorStatus(err)), errorResponse(c, "❌ Error eliminando plantilla", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Plantilla eliminada",
+	})
+}
+
+// EjecutarPlantilla ejecuta la plantilla como entrada múltiple, ajustando cantidades si se indica
+// ?dry_run=true calcula el resultado previsto sin escribir en la BD
+func (h *PlantillaHandler) EjecutarPlantilla(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "ejecutar_plantilla"))
+
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.EjecutarPlantillaRequest
+	// El body es opcional: sin ajustes se ingresan las cantidades típicas
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+			return
+		}
+		if err := h.validator.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+			return
+		}
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+	req.DryRun = c.Query("dry_run") == "true"
+
+	resultado, err := h.plantillaService.EjecutarPlantilla(c.Request.Context(), id, &req)
+	if err != nil {
+		logger.Error("Error ejecutando plantilla", zap.Int("id_plantilla", id), zap.Error(err))
+		c.JSON(errorStatus(c, err, plantillaErrorStatus(err)), errorResponse(c, "❌ Error ejecutando plantilla", err.Error()))
+		return
+	}
+
+	if resultado.Solicitud != nil {
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"message": "⏳ Operación pendiente de aprobación de un supervisor",
+			"data":    resultado.Solicitud,
+		})
+		return
+	}
+
+	c.JSON(multipleStatus(c, itemsAplicados(resultado.Entrada.Resultados, resultado.Entrada.DryRun)), resultado.Entrada)
+}
+
+// bindPlantilla lee y valida el body de creación o reemplazo de una plantilla
+func (h *PlantillaHandler) bindPlantilla(c *gin.Context) (*models.PlantillaEntradaRequest, bool) {
+	var req models.PlantillaEntradaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return nil, false
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return nil, false
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	return &req, true
+}
+
+// parseID obtiene el ID de la plantilla de la URL
+func (h *PlantillaHandler) parseID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de plantilla inválido", "El ID debe ser un número válido"))
+		return 0, false
+	}
+	return id, true
+}
+
+// plantillaErrorStatus mapea los errores de dominio de las plantillas a códigos HTTP
+func plantillaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPlantillaNoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrDocumentoDuplicado):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrAjustePlantillaInvalido),
+		errors.Is(err, services.ErrDocumentoInvalido),
+		errors.Is(err, services.ErrUnidadSinConversion),
+		errors.Is(err, services.ErrLocalNoEncontrado),
+		errors.Is(err, services.ErrProductoNoEncontrado):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrOperacionBloqueada):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"stock-service/internal/cache"
+	"stock-service/internal/degraded"
 	"stock-service/internal/models"
 	"stock-service/internal/repository"
 	"stock-service/internal/services"
@@ -17,65 +21,148 @@ import (
 
 // POSHandler maneja las operaciones específicas del POS
 type POSHandler struct {
-	productCache *cache.ProductCache
-	stockService services.StockService
-	productRepo  repository.ProductRepository
-	logger       *zap.Logger
+	productCache         *cache.ProductCache
+	stockService         services.StockService
+	duplicateSaleService services.DuplicateSaleService
+	botonService         services.BotonRapidoService
+	precioService        services.PrecioService
+	ventaService         services.VentaService
+	ventaEncoladaService services.VentaEncoladaService
+	reglaService         services.ReglaOperacionService
+	// Verifica la contraseña del supervisor que autoriza precios modificados y reglas de venta
+	authService         services.AuthService
+	escaneoService      services.EscaneoNoEncontradoService
+	notificacionService services.NotificacionMasivaService
+	productRepo         repository.ProductRepository
+	// Filtro de existencia de códigos de barras (consulta rápida de las pistolas de inventario)
+	barcodeFilter *cache.BarcodeFilter
+	// Modo degradado (PostgreSQL caído): búsquedas solo-cache y ventas encoladas
+	degraded *degraded.Monitor
+	logger   *zap.Logger
 }
 
 // NewPOSHandler crea una nueva instancia del handler POS
-func NewPOSHandler(productCache *cache.ProductCache, stockService services.StockService, productRepo repository.ProductRepository, logger *zap.Logger) *POSHandler {
+func NewPOSHandler(productCache *cache.ProductCache, stockService services.StockService, duplicateSaleService services.DuplicateSaleService, botonService services.BotonRapidoService, precioService services.PrecioService, ventaService services.VentaService, ventaEncoladaService services.VentaEncoladaService, reglaService services.ReglaOperacionService, authService services.AuthService, escaneoService services.EscaneoNoEncontradoService, notificacionService services.NotificacionMasivaService, productRepo repository.ProductRepository, barcodeFilter *cache.BarcodeFilter, degradedMonitor *degraded.Monitor, logger *zap.Logger) *POSHandler {
 	return &POSHandler{
-		productCache: productCache,
-		stockService: stockService,
-		productRepo:  productRepo,
-		logger:       logger,
+		productCache:         productCache,
+		stockService:         stockService,
+		duplicateSaleService: duplicateSaleService,
+		botonService:         botonService,
+		precioService:        precioService,
+		ventaService:         ventaService,
+		ventaEncoladaService: ventaEncoladaService,
+		reglaService:         reglaService,
+		authService:          authService,
+		escaneoService:       escaneoService,
+		notificacionService:  notificacionService,
+		productRepo:          productRepo,
+		barcodeFilter:        barcodeFilter,
+		degraded:             degradedMonitor,
+		logger:               logger,
 	}
 }
 
 // SearchProductByBarcode busca un producto por código de barras (ultra-rápido)
+// ?detalle=minimo responde solo lo necesario para cobrar (ProductoPOSResponse); completo
+// (por defecto) el ProductoCompleto con vencimientos. Cada nivel se cachea por separado
 func (h *POSHandler) SearchProductByBarcode(c *gin.Context) {
 	start := time.Now()
 	codigoBarras := c.Param("codigo")
 
 	if codigoBarras == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ Código de barras requerido",
-			"error":   "El código de barras no puede estar vacío",
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Código de barras requerido", "El código de barras no puede estar vacío"))
 		return
 	}
 
+	// Canal que consulta (por defecto la caja): un producto no habilitado en el canal no se ofrece
+	canal := c.DefaultQuery("canal", models.CanalPOS)
+	if !models.EsCanalValido(canal) {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Canal inválido", fmt.Sprintf("El canal debe ser uno de: %s", strings.Join(models.CanalesVenta, ", "))))
+		return
+	}
+
+	detalle := c.DefaultQuery("detalle", models.DetalleCompleto)
+	if detalle != models.DetalleMinimo && detalle != models.DetalleCompleto {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Detalle inválido", fmt.Sprintf("El detalle debe ser %s o %s", models.DetalleMinimo, models.DetalleCompleto)))
+		return
+	}
+
+	// Latencia total del escaneo (incluye no encontrados y errores)
+	defer func() { h.productCache.Latency().Total.Observe(time.Since(start)) }()
+
 	logger := h.logger.With(
 		zap.String("handler", "search_product_barcode"),
 		zap.String("codigo_barras", codigoBarras),
+		zap.String("canal", canal),
+		zap.String("detalle", detalle),
 	)
 
 	logger.Info("Buscando producto por código de barras")
 
+	// En modo degradado la búsqueda es solo-cache: no se consulta PostgreSQL
+	degradado := h.degraded.Active()
+
 	// 0. Validar versión global de lista_precios (solo consulta a Redis, ultra-rápida)
 	// Solo consulta PostgreSQL si detecta que la versión puede haber cambiado
-	if err := h.validateGlobalVersion(c.Request.Context()); err != nil {
-		logger.Warn("Error validando versión global de lista_precios, continuando con cache",
-			zap.Error(err))
+	if !degradado {
+		if err := h.validateGlobalVersion(c.Request.Context()); err != nil {
+			logger.Warn("Error validando versión global de lista_precios, continuando con cache",
+				zap.Error(err))
+		}
+	}
+
+	// 1a. Detalle mínimo: tiene su propia entrada en la caché
+	if detalle == models.DetalleMinimo {
+		minimo, err := h.productCache.GetProductoMinimo(c.Request.Context(), codigoBarras)
+		if err == nil && minimo != nil {
+			if !minimo.HabilitadoEnCanal(canal) {
+				h.respondNoHabilitadoEnCanal(c, minimo.Codigo, minimo.Canales, canal, codigoBarras, true, start)
+				return
+			}
+
+			logger.Info("Producto encontrado en caché (detalle mínimo)",
+				zap.String("nombre", minimo.Nombre),
+				zap.Duration("latency", time.Since(start)))
+
+			successJSON(c, http.StatusOK, "✅ Producto encontrado", &models.BusquedaBarcodeMinimaData{
+				Producto:      minimo,
+				CacheHit:      true,
+				ModoDegradado: degradado,
+				LatencyMs:     time.Since(start).Milliseconds(),
+			})
+			return
+		}
 	}
 
 	// 1. Buscar en caché multi-nivel (ultra-rápido)
 	producto, err := h.productCache.GetProduct(c.Request.Context(), codigoBarras)
 	if err == nil && producto != nil {
+		if !producto.HabilitadoEnCanal(canal) {
+			h.respondNoHabilitadoEnCanal(c, producto.Codigo, producto.Canales, canal, codigoBarras, true, start)
+			return
+		}
+
 		// Producto encontrado en caché
 		logger.Info("Producto encontrado en caché",
 			zap.String("nombre", producto.Nombre),
 			zap.Duration("latency", time.Since(start)))
 
-		c.JSON(http.StatusOK, gin.H{
-			"success": true,
-			"message": "✅ Producto encontrado",
+		h.respondProducto(c, producto, detalle, codigoBarras, true, degradado, start)
+		return
+	}
+
+	if degradado {
+		logger.Warn("Producto no encontrado en caché, base de datos no disponible (modo degradado)")
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success":    false,
+			"request_id": requestID(c),
+			"message":    "❌ Producto no disponible en modo degradado",
+			"error":      "La base de datos no está disponible y el producto no está en la cache",
 			"data": gin.H{
-				"producto":   producto,
-				"cache_hit":  true,
-				"latency_ms": time.Since(start).Milliseconds(),
+				"codigo_barras":  codigoBarras,
+				"cache_hit":      false,
+				"modo_degradado": true,
+				"latency_ms":     time.Since(start).Milliseconds(),
 			},
 		})
 		return
@@ -84,17 +171,41 @@ func (h *POSHandler) SearchProductByBarcode(c *gin.Context) {
 	// 2. Buscar en base de datos (más lento)
 	logger.Info("Producto no encontrado en caché, buscando en base de datos")
 
+	dbStart := time.Now()
 	producto, err = h.stockService.GetProductoByBarcode(c.Request.Context(), codigoBarras)
+	h.productCache.Latency().Database.Observe(time.Since(dbStart))
+	if err != nil && !errors.Is(err, services.ErrProductoNoEncontrado) {
+		// Falla real de infraestructura: no ocultarla como "no encontrado"
+		h.productCache.RecordLookupError()
+		logger.Error("Error buscando producto en base de datos",
+			zap.Duration("latency", time.Since(start)),
+			zap.Error(err))
+
+		status := errorStatus(c, err, http.StatusInternalServerError)
+		response := errorResponse(c, "❌ Error buscando producto", err.Error())
+		response["data"] = gin.H{
+			"codigo_barras": codigoBarras,
+			"cache_hit":     false,
+			"latency_ms":    time.Since(start).Milliseconds(),
+		}
+		c.JSON(status, response)
+		return
+	}
 	if err != nil {
+		h.productCache.RecordNotFound()
+		// Código sin catalogar: se cuenta para el top de códigos desconocidos (?local= opcional)
+		idLocal, _ := strconv.Atoi(c.Query("local"))
+		h.escaneoService.Registrar(codigoBarras, idLocal)
 		logger.Warn("Producto no encontrado en base de datos",
 			zap.String("codigo_barras", codigoBarras),
 			zap.Duration("latency", time.Since(start)),
 			zap.Error(err))
 
 		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "❌ Producto no encontrado",
-			"error":   "El producto no existe en el sistema",
+			"success":    false,
+			"request_id": requestID(c),
+			"message":    "❌ Producto no encontrado",
+			"error":      "El producto no existe en el sistema",
 			"data": gin.H{
 				"codigo_barras": codigoBarras,
 				"cache_hit":     false,
@@ -109,18 +220,117 @@ func (h *POSHandler) SearchProductByBarcode(c *gin.Context) {
 		logger.Error("Error cacheando producto", zap.Error(err))
 	}
 
+	if !producto.HabilitadoEnCanal(canal) {
+		h.respondNoHabilitadoEnCanal(c, producto.Codigo, producto.Canales, canal, codigoBarras, false, start)
+		return
+	}
+
 	logger.Info("Producto encontrado en base de datos",
 		zap.String("nombre", producto.Nombre),
 		zap.String("origen", producto.Origen),
 		zap.Duration("latency", time.Since(start)))
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "✅ Producto encontrado",
+	h.respondProducto(c, producto, detalle, codigoBarras, false, false, start)
+}
+
+// respondProducto responde el escaneo en el nivel de detalle pedido
+// El detalle mínimo se deriva del completo y se cachea para los próximos escaneos
+func (h *POSHandler) respondProducto(c *gin.Context, producto *models.ProductoCompleto, detalle, codigoBarras string, cacheHit, degradado bool, start time.Time) {
+	if detalle == models.DetalleMinimo {
+		minimo := producto.ToProductoPOSResponse()
+		if err := h.productCache.SetProductoMinimo(c.Request.Context(), codigoBarras, &minimo); err != nil {
+			h.logger.Error("Error cacheando producto en detalle mínimo",
+				zap.String("codigo_barras", codigoBarras),
+				zap.Error(err))
+		}
+
+		successJSON(c, http.StatusOK, "✅ Producto encontrado", &models.BusquedaBarcodeMinimaData{
+			Producto:      &minimo,
+			CacheHit:      cacheHit,
+			ModoDegradado: degradado,
+			LatencyMs:     time.Since(start).Milliseconds(),
+		})
+		return
+	}
+
+	successJSON(c, http.StatusOK, "✅ Producto encontrado", &models.BusquedaBarcodeData{
+		Producto:      producto,
+		CacheHit:      cacheHit,
+		ModoDegradado: degradado,
+		LatencyMs:     time.Since(start).Milliseconds(),
+	})
+}
+
+// ExisteProducto indica si un código de barras existe, sin serializar el producto
+// HEAD o GET /pos/producto/:codigo/existe: 200 si existe, 404 si no
+// Se resuelve con el filtro de existencia y la cache; la BD solo se consulta mientras el
+// filtro no terminó su primera carga. Un positivo del filtro sin el producto en cache puede
+// ser un falso positivo (~0,1%); el header X-Existe-Fuente indica de dónde salió la respuesta
+func (h *POSHandler) ExisteProducto(c *gin.Context) {
+	codigoBarras := c.Param("codigo")
+	ctx := c.Request.Context()
+
+	existe, fuente := false, "filtro"
+	maybe, ready := h.barcodeFilter.MayContain(codigoBarras)
+	if !ready || maybe {
+		enCache, err := h.productCache.Exists(ctx, codigoBarras)
+		if err != nil {
+			h.logger.Warn("Error consultando existencia en cache",
+				zap.String("codigo_barras", codigoBarras),
+				zap.Error(err))
+		}
+		switch {
+		case enCache:
+			existe, fuente = true, "cache"
+		case ready:
+			existe = true
+		default:
+			// Filtro aún sin cargar (arranque): se resuelve con la búsqueda normal
+			if h.degraded.Active() {
+				c.JSON(http.StatusServiceUnavailable, errorResponse(c, "❌ Existencia no disponible", "El filtro de códigos aún no está cargado y la base de datos no está disponible"))
+				return
+			}
+			_, err := h.stockService.GetProductoByBarcode(ctx, codigoBarras)
+			if err != nil && !errors.Is(err, services.ErrProductoNoEncontrado) {
+				h.logger.Error("Error verificando existencia en base de datos",
+					zap.String("codigo_barras", codigoBarras),
+					zap.Error(err))
+				c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error verificando existencia", err.Error()))
+				return
+			}
+			existe, fuente = err == nil, "bd"
+		}
+	}
+
+	status := http.StatusOK
+	if !existe {
+		status = http.StatusNotFound
+	}
+	c.Header("X-Existe-Fuente", fuente)
+	if c.Request.Method == http.MethodHead {
+		c.Status(status)
+		return
+	}
+	c.JSON(status, gin.H{
+		"existe":        existe,
+		"codigo_barras": codigoBarras,
+		"fuente":        fuente,
+	})
+}
+
+// respondNoHabilitadoEnCanal responde 404 para un producto que existe pero no se vende en el canal
+func (h *POSHandler) respondNoHabilitadoEnCanal(c *gin.Context, codigoProducto string, canales []string, canal, codigoBarras string, cacheHit bool, start time.Time) {
+	c.JSON(http.StatusNotFound, gin.H{
+		"success":    false,
+		"request_id": requestID(c),
+		"message":    "❌ Producto no disponible en el canal",
+		"error":      fmt.Sprintf("El producto %s no está habilitado para el canal %s", codigoProducto, canal),
 		"data": gin.H{
-			"producto":   producto,
-			"cache_hit":  false,
-			"latency_ms": time.Since(start).Milliseconds(),
+			"codigo_barras": codigoBarras,
+			"canal":         canal,
+			"canales":       canales,
+			"cache_hit":     cacheHit,
+			"latency_ms":    time.Since(start).Milliseconds(),
 		},
 	})
 }
@@ -131,11 +341,7 @@ func (h *POSHandler) QuickSale(c *gin.Context) {
 
 	var req models.QuickSaleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ Error en el formato de datos",
-			"error":   err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
 		return
 	}
 
@@ -147,9 +353,40 @@ func (h *POSHandler) QuickSale(c *gin.Context) {
 
 	logger.Info("Procesando venta rápida")
 
+	req.IDUsuario = idUsuarioActual(c)
+
+	// El autorizador se verifica antes de usarlo: desde acá id_autorizador es un supervisor real
+	if req.IDAutorizador != nil {
+		autorizador, err := h.authService.VerificarAutorizador(c.Request.Context(), *req.IDAutorizador, req.PasswordAutorizador)
+		if err != nil {
+			logger.Warn("Autorizador de la venta rechazado", zap.Int("id_autorizador", *req.IDAutorizador), zap.Error(err))
+			c.JSON(errorStatus(c, err, autorizadorErrorStatus(err)), errorResponse(c, "❌ Autorización de supervisor inválida", err.Error()))
+			return
+		}
+		logger = logger.With(zap.String("autorizador", autorizador.Username))
+	}
+	// La contraseña no viaja más allá de la verificación (ni a la cola de ventas del modo degradado)
+	req.PasswordAutorizador = ""
+
+	// En modo degradado no se puede verificar el stock: la venta se encola y se aplica al volver la BD
+	degradado := h.degraded.Active()
+
 	// Validar que todos los productos existan y tengan stock
 	var itemsValidos []models.ProductoStock
 	var errores []string
+	var monto float64
+	// Monto de cada producto de la venta, para totalizar solo lo efectivamente vendido
+	montoPorProducto := map[string]float64{}
+	exentos := map[string]bool{}
+	// Precios modificados por el cajero, por código de producto (se registran tras la venta)
+	overrides := map[string]*models.OverridePrecio{}
+	// Líneas con su precio final y costo, para las reglas de operación
+	var itemsEvaluados []models.ItemEvaluado
+	// Precio de cada línea y de dónde salió (se registra para las líneas vendidas)
+	var preciosLineas []*models.PrecioLineaVenta
+	// Productos con la venta bloqueada (ej. retiro de lote): la venta se rechaza con su código
+	var bloqueados []gin.H
+	ahora := time.Now()
 
 	for i, item := range req.Items {
 		// Buscar producto en caché
@@ -160,6 +397,73 @@ func (h *POSHandler) QuickSale(c *gin.Context) {
 			continue
 		}
 
+		// Productos solo online o solo mayorista no se venden en sala
+		if !producto.HabilitadoEnCanal(models.CanalPOS) {
+			errorMsg := fmt.Sprintf("Item %d: Producto %s no está habilitado para venta en sala", i+1, item.CodigoProducto)
+			errores = append(errores, errorMsg)
+			continue
+		}
+
+		if producto.BloqueadoParaVenta(ahora) {
+			errorMsg := fmt.Sprintf("Item %d: Producto %s tiene la venta bloqueada: %s", i+1, item.CodigoProducto, producto.Bloqueo.Motivo)
+			errores = append(errores, errorMsg)
+			bloqueados = append(bloqueados, gin.H{
+				"codigo_producto": item.CodigoProducto,
+				"motivo":          producto.Bloqueo.Motivo,
+				"hasta":           producto.Bloqueo.Hasta,
+			})
+			continue
+		}
+
+		if producto.EsExento != nil && *producto.EsExento {
+			exentos[item.CodigoProducto] = true
+		}
+
+		// Precio de la línea: el de lista, o el modificado por el cajero con autorización
+		precio := producto.PrecioVenta()
+		if item.PrecioAplicado != nil {
+			if errorMsg := validarPrecioModificado(i, &item, req.IDAutorizador); errorMsg != "" {
+				errores = append(errores, errorMsg)
+				continue
+			}
+			overrides[item.CodigoProducto] = &models.OverridePrecio{
+				IDLocal:        req.IDLocal,
+				CodigoProducto: item.CodigoProducto,
+				TipoItem:       item.TipoItem,
+				Cantidad:       item.Cantidad,
+				PrecioOriginal: precio,
+				PrecioAplicado: *item.PrecioAplicado,
+				Motivo:         strings.TrimSpace(item.MotivoPrecio),
+				IDUsuario:      req.IDUsuario,
+				IDAutorizador:  *req.IDAutorizador,
+			}
+			precio = *item.PrecioAplicado
+		}
+		precioLinea := precio
+		preciosLineas = append(preciosLineas, models.NuevoPrecioLineaVenta(producto, &item, precio))
+		itemsEvaluados = append(itemsEvaluados, models.ItemEvaluado{
+			CodigoProducto: item.CodigoProducto,
+			TipoItem:       item.TipoItem,
+			Cantidad:       item.Cantidad,
+			Precio:         &precioLinea,
+			Costo:          producto.CostoUnitario(),
+		})
+
+		// Los servicios (flete, garantía) se venden sin control de stock
+		if producto.EsServicio != nil && *producto.EsServicio {
+			itemsValidos = append(itemsValidos, item)
+			monto += precio * float64(item.Cantidad)
+			montoPorProducto[item.CodigoProducto] += precio * float64(item.Cantidad)
+			continue
+		}
+
+		if degradado {
+			itemsValidos = append(itemsValidos, item)
+			monto += precio * float64(item.Cantidad)
+			montoPorProducto[item.CodigoProducto] += precio * float64(item.Cantidad)
+			continue
+		}
+
 		// Verificar stock disponible
 		stock, err := h.stockService.GetStockByProducto(c.Request.Context(), item.CodigoProducto, req.IDLocal)
 		if err != nil || stock == nil {
@@ -176,15 +480,35 @@ func (h *POSHandler) QuickSale(c *gin.Context) {
 		}
 
 		itemsValidos = append(itemsValidos, item)
+		monto += precio * float64(item.Cantidad)
+		montoPorProducto[item.CodigoProducto] += precio * float64(item.Cantidad)
+	}
+
+	// Un producto bloqueado rechaza la venta con un código propio: la caja debe retirarlo
+	if len(bloqueados) > 0 {
+		logger.Warn("Venta rápida con productos bloqueados", zap.Strings("errores", errores))
+		c.JSON(http.StatusConflict, gin.H{
+			"success":    false,
+			"request_id": requestID(c),
+			"codigo":     models.CodigoProductoBloqueado,
+			"message":    "🚫 La venta incluye productos bloqueados",
+			"errors":     errores,
+			"data": gin.H{
+				"productos_bloqueados": bloqueados,
+				"latency_ms":           time.Since(start).Milliseconds(),
+			},
+		})
+		return
 	}
 
 	// Si hay errores, retornar lista de problemas
 	if len(errores) > 0 {
 		logger.Warn("Errores en venta rápida", zap.Strings("errores", errores))
 		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ Errores en la venta",
-			"errors":  errores,
+			"success":    false,
+			"request_id": requestID(c),
+			"message":    "❌ Errores en la venta",
+			"errors":     errores,
 			"data": gin.H{
 				"items_validos":   len(itemsValidos),
 				"items_invalidos": len(req.Items) - len(itemsValidos),
@@ -194,6 +518,39 @@ func (h *POSHandler) QuickSale(c *gin.Context) {
 		return
 	}
 
+	// Reglas de operación (venta bajo costo): en modo degradado no se pueden leer
+	if !degradado && !h.verificarReglasVenta(c, &req, itemsEvaluados, logger, start) {
+		return
+	}
+
+	// Verificar venta duplicada (misma venta en el mismo local dentro de la ventana)
+	duplicateCheck, err := h.duplicateSaleService.Check(c.Request.Context(), &req, monto)
+	if err != nil {
+		// No bloquear la venta si Redis falla
+		logger.Warn("Error verificando venta duplicada, continuando", zap.Error(err))
+	}
+	if duplicateCheck != nil && duplicateCheck.Bloqueada {
+		logger.Warn("Venta bloqueada por posible duplicado",
+			zap.String("fingerprint", duplicateCheck.Fingerprint))
+		c.JSON(http.StatusConflict, gin.H{
+			"success":    false,
+			"request_id": requestID(c),
+			"message":    "❌ Venta bloqueada por posible duplicado",
+			"error":      "Se registró una venta idéntica hace pocos segundos; requiere revisión del supervisor",
+			"data": gin.H{
+				"venta_sospechosa": true,
+				"fingerprint":      duplicateCheck.Fingerprint,
+				"latency_ms":       time.Since(start).Milliseconds(),
+			},
+		})
+		return
+	}
+
+	if degradado {
+		h.encolarVenta(c, &req, montoPorProducto, exentos, overrides, preciosLineas, duplicateCheck, start)
+		return
+	}
+
 	// Procesar venta con items válidos
 	// Convertir ProductoStock a ProductoSalida
 	var productosSalida []models.ProductoSalida
@@ -209,37 +566,593 @@ func (h *POSHandler) QuickSale(c *gin.Context) {
 		Productos:     productosSalida,
 		Motivo:        req.Motivo,
 		IDLocal:       req.IDLocal,
-		Observaciones: req.Observaciones,
+		Observaciones: strings.TrimSpace(models.ObservacionVentaPOS + " " + req.Observaciones),
+		IDUsuario:     req.IDUsuario,
+	}
+
+	// La salida es atómica: si se aplica, se vendieron todos los ítems válidos
+	var subtotal models.SubtotalVenta
+	var preciosModificados []*models.OverridePrecio
+	for _, item := range itemsValidos {
+		if monto, ok := montoPorProducto[item.CodigoProducto]; ok {
+			if exentos[item.CodigoProducto] {
+				subtotal.Exento += monto
+			} else {
+				subtotal.Afecto += monto
+			}
+			delete(montoPorProducto, item.CodigoProducto)
+		}
+		if override, ok := overrides[item.CodigoProducto]; ok {
+			preciosModificados = append(preciosModificados, override)
+			delete(overrides, item.CodigoProducto)
+		}
 	}
 
-	// TODO: Implementar autenticación cuando sea necesario
-	// Por ahora usar ID por defecto
-	salidaReq.IDUsuario = 1
+	// Total a cobrar con propina, cargo por servicio y redondeo del medio de pago;
+	// cada uno queda como línea contable aparte
+	totales := h.ventaService.CalcularTotales(subtotal, &req)
+	sospechosa := duplicateCheck != nil && duplicateCheck.Sospechosa
+
+	// La venta queda registrada con sus totales y líneas; su ID es el venta_ref de las líneas
+	// contables y de precios
+	venta := &models.Venta{
+		IDLocal:         req.IDLocal,
+		IDUsuario:       req.IDUsuario,
+		IDAutorizador:   req.IDAutorizador,
+		Motivo:          req.Motivo,
+		Observaciones:   req.Observaciones,
+		Totales:         *totales,
+		VentaSospechosa: sospechosa,
+	}
+
+	// La venta, sus precios modificados, los precios de sus líneas y sus líneas contables se registran
+	// en la transacción de la salida: si alguno falla no se descuenta stock
+	response, err := h.stockService.SalidaMultipleStockAtomica(c.Request.Context(), salidaReq, func(repo repository.StockRepository, idOperacion string) error {
+		ctx := c.Request.Context()
+		ventas := h.ventaService.ConTransaccion(repo)
 
-	response, err := h.stockService.SalidaMultipleStock(c.Request.Context(), salidaReq)
+		if err := h.precioService.ConTransaccion(repo).RegistrarOverrides(ctx, preciosModificados); err != nil {
+			return err
+		}
+		venta.IDOperacion = idOperacion
+		if err := ventas.RegistrarVenta(ctx, venta, preciosLineas, exentos); err != nil {
+			return err
+		}
+		if err := ventas.RegistrarPreciosLineas(ctx, venta.ID, idOperacion, &req, preciosLineas); err != nil {
+			return err
+		}
+		return ventas.RegistrarLineasContables(ctx, venta.ID, req.IDLocal, req.IDUsuario, totales)
+	})
 	if err != nil {
 		logger.Error("Error procesando venta rápida", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error procesando venta",
-			"error":   err.Error(),
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error procesando venta", err.Error()))
+		return
+	}
+
+	// Algún producto no se pudo descontar (ej. se vendió entre la validación y la salida): la venta
+	// se revirtió completa
+	if len(response.Errores) > 0 {
+		logger.Warn("Venta rápida revertida", zap.Int("productos_fallidos", len(response.Errores)))
+		c.JSON(http.StatusConflict, gin.H{
+			"success":    false,
+			"request_id": requestID(c),
+			"message":    "❌ Venta no procesada: ningún producto fue descontado",
+			"errors":     response.Errores,
+			"data": gin.H{
+				"items":      response.Items,
+				"latency_ms": time.Since(start).Milliseconds(),
+			},
 		})
 		return
 	}
+	h.registrarHuellaVenta(c, duplicateCheck, logger)
 
 	logger.Info("Venta rápida completada",
 		zap.Int("productos_procesados", response.TotalProductos),
+		zap.Int("precios_modificados", len(preciosModificados)),
 		zap.Duration("latency", time.Since(start)))
 
+	successJSON(c, http.StatusOK, "✅ Venta procesada correctamente", &models.VentaRapidaData{
+		VentaID:             venta.ID,
+		IDOperacion:         response.IDOperacion,
+		Totales:             *totales,
+		ProductosProcesados: response.TotalProductos,
+		TotalItems:          len(itemsValidos),
+		VentaSospechosa:     sospechosa,
+		PreciosModificados:  len(preciosModificados),
+		LatencyMs:           time.Since(start).Milliseconds(),
+		Timestamp:           time.Now().Format(time.RFC3339),
+	})
+}
+
+// verificarReglasVenta evalúa la venta contra las reglas de operación del local
+// Una regla que bloquea rechaza la venta; una que pide aprobación exige el autorizador de la venta,
+// ya verificado por QuickSale
+// Retorna false si ya respondió el rechazo
+func (h *POSHandler) verificarReglasVenta(c *gin.Context, req *models.QuickSaleRequest, items []models.ItemEvaluado, logger *zap.Logger, start time.Time) bool {
+	evaluacion, err := h.reglaService.Evaluar(c.Request.Context(), &models.OperacionEvaluada{
+		Operacion: models.OperacionReglaVenta,
+		IDLocal:   req.IDLocal,
+		Motivo:    req.Motivo,
+		Fecha:     time.Now(),
+		Items:     items,
+	})
+	if err != nil {
+		// No bloquear la venta si las reglas no se pueden leer
+		logger.Warn("Error evaluando reglas de operación, continuando", zap.Error(err))
+		return true
+	}
+
+	message := ""
+	switch {
+	case evaluacion.Bloqueada():
+		message = "❌ Venta bloqueada por reglas de operación"
+	case evaluacion.RequiereAprobacion() && (req.IDAutorizador == nil || *req.IDAutorizador <= 0):
+		message = "❌ La venta requiere autorización de un supervisor"
+	case evaluacion.RequiereAprobacion():
+		logger.Info("Venta autorizada pese a reglas de operación",
+			zap.Int("id_autorizador", *req.IDAutorizador),
+			zap.Int("reglas_incumplidas", len(evaluacion.Incumplidas)))
+		return true
+	default:
+		return true
+	}
+
+	logger.Warn(message, zap.Int("reglas_incumplidas", len(evaluacion.Incumplidas)))
+	c.JSON(http.StatusForbidden, gin.H{
+		"success":    false,
+		"request_id": requestID(c),
+		"message":    message,
+		"data": gin.H{
+			"reglas":     evaluacion.Incumplidas,
+			"latency_ms": time.Since(start).Milliseconds(),
+		},
+	})
+	return false
+}
+
+// registrarHuellaVenta guarda la huella de la venta ya aplicada para detectar duplicados
+// Si Redis falla la venta no se revierte: solo se pierde la detección de un duplicado posterior
+func (h *POSHandler) registrarHuellaVenta(c *gin.Context, duplicateCheck *models.DuplicateSaleCheck, logger *zap.Logger) {
+	if duplicateCheck == nil {
+		return
+	}
+	if err := h.duplicateSaleService.Registrar(c.Request.Context(), duplicateCheck.Fingerprint); err != nil {
+		logger.Warn("Error registrando huella de la venta", zap.Error(err))
+	}
+}
+
+// encolarVenta encola la venta validada contra la cache para aplicarla cuando la BD vuelva
+func (h *POSHandler) encolarVenta(c *gin.Context, req *models.QuickSaleRequest, montoPorProducto map[string]float64, exentos map[string]bool, overrides map[string]*models.OverridePrecio, preciosLineas []*models.PrecioLineaVenta, duplicateCheck *models.DuplicateSaleCheck, start time.Time) {
+	venta := &models.VentaEncolada{
+		Venta:            *req,
+		IDUsuario:        req.IDUsuario,
+		MontoPorProducto: montoPorProducto,
+		Exentos:          exentos,
+		PreciosLineas:    preciosLineas,
+	}
+	for _, override := range overrides {
+		venta.Overrides = append(venta.Overrides, override)
+	}
+
+	if err := h.ventaEncoladaService.Encolar(c.Request.Context(), venta); err != nil {
+		h.logger.Error("Error encolando venta en modo degradado", zap.Error(err))
+		fallback := http.StatusServiceUnavailable
+		if errors.Is(err, services.ErrColaVentasLlena) {
+			fallback = http.StatusInsufficientStorage
+		}
+		c.JSON(errorStatus(c, err, fallback), errorResponse(c, "❌ Error encolando venta", err.Error()))
+		return
+	}
+	h.registrarHuellaVenta(c, duplicateCheck, h.logger)
+
+	// El total es provisorio: se confirma al aplicar la venta, sobre lo que efectivamente se descuente
+	var subtotal models.SubtotalVenta
+	for codigo, monto := range montoPorProducto {
+		if exentos[codigo] {
+			subtotal.Exento += monto
+		} else {
+			subtotal.Afecto += monto
+		}
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "⚠️ Venta encolada: se aplicará cuando la base de datos vuelva a estar disponible",
+		"data": gin.H{
+			"id_venta_encolada": venta.ID,
+			"id_operacion":      venta.ID,
+			"totales":           h.ventaService.CalcularTotales(subtotal, req),
+			"total_items":       len(req.Items),
+			"venta_sospechosa":  duplicateCheck != nil && duplicateCheck.Sospechosa,
+			"modo_degradado":    true,
+			"latency_ms":        time.Since(start).Milliseconds(),
+			"timestamp":         venta.EncoladaAt.Format(time.RFC3339),
+		},
+	})
+}
+
+// GetVentasEncoladas retorna las ventas encoladas en modo degradado pendientes de aplicar
+// y las últimas que se aplicaron con errores
+func (h *POSHandler) GetVentasEncoladas(c *gin.Context) {
+	estado, err := h.ventaEncoladaService.GetEstado(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo ventas encoladas", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Ventas encoladas obtenidas",
+		"data": gin.H{
+			"modo_degradado":   h.degraded.Current(),
+			"ventas_encoladas": estado,
+		},
+	})
+}
+
+// ReconciliarVentasEncoladas aplica ahora las ventas encoladas (normalmente lo hace el worker)
+func (h *POSHandler) ReconciliarVentasEncoladas(c *gin.Context) {
+	resultado, err := h.ventaEncoladaService.Reconciliar(c.Request.Context())
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrReconciliacionEnCurso):
+			status = http.StatusConflict
+		case errors.Is(err, services.ErrBaseDatosNoDisponible):
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(errorStatus(c, err, status), errorResponse(c, "❌ Error reconciliando ventas encoladas", err.Error()))
+		return
+	}
+
+	message := "✅ Ventas encoladas reconciliadas"
+	if resultado.ConErrores > 0 {
+		message = fmt.Sprintf("⚠️ %d ventas encoladas aplicadas con errores", resultado.ConErrores)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    resultado,
+	})
+}
+
+// autorizadorErrorStatus mapea los errores de la verificación del supervisor a códigos HTTP
+func autorizadorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrAutorizadorInvalido), errors.Is(err, services.ErrUsuarioInactivo):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// validarPrecioModificado verifica que una línea con precio modificado tenga motivo y autorizador
+// (QuickSale ya verificó la contraseña y el rol del autorizador)
+// Retorna el mensaje de error de la línea, o vacío si es válida
+func validarPrecioModificado(i int, item *models.ProductoStock, idAutorizador *int) string {
+	switch {
+	case *item.PrecioAplicado < 0:
+		return fmt.Sprintf("Item %d: Precio modificado inválido para %s", i+1, item.CodigoProducto)
+	case strings.TrimSpace(item.MotivoPrecio) == "":
+		return fmt.Sprintf("Item %d: Falta el motivo del precio modificado de %s", i+1, item.CodigoProducto)
+	case idAutorizador == nil || *idAutorizador <= 0:
+		return fmt.Sprintf("Item %d: El precio modificado de %s requiere autorización de un supervisor", i+1, item.CodigoProducto)
+	default:
+		return ""
+	}
+}
+
+// GetReporteOverridesPrecio reporta los precios modificados en ventas del período
+// GET /pos/overrides-precio?local=&desde=YYYY-MM-DD&hasta=YYYY-MM-DD&usuario=
+func (h *POSHandler) GetReporteOverridesPrecio(c *gin.Context) {
+	filter, err := parseReporteFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", err.Error()))
+		return
+	}
+
+	if usuarioStr := c.Query("usuario"); usuarioStr != "" {
+		idUsuario, err := strconv.Atoi(usuarioStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "usuario debe ser un número válido"))
+			return
+		}
+		filter.IDUsuario = &idUsuario
+	}
+
+	reporte, err := h.precioService.GetReporteOverrides(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Error generando reporte de precios modificados", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error generando reporte de precios modificados", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Reporte de precios modificados generado",
+		"data":    reporte,
+	})
+}
+
+// GetVenta obtiene una venta rápida registrada con su detalle
+// GET /pos/ventas/:id
+func (h *POSHandler) GetVenta(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de venta inválido", "id debe ser un número mayor a 0"))
+		return
+	}
+
+	venta, err := h.ventaService.GetVenta(c.Request.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrVentaNoEncontrada) {
+			status = http.StatusNotFound
+		}
+		c.JSON(errorStatus(c, err, status), errorResponse(c, "❌ Error obteniendo venta", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Venta obtenida",
+		"data":    venta,
+	})
+}
+
+// GetVentas lista las ventas rápidas registradas, las más recientes primero
+// GET /pos/ventas?local=&desde=YYYY-MM-DD&hasta=YYYY-MM-DD&limit=&offset=
+func (h *POSHandler) GetVentas(c *gin.Context) {
+	filter := &models.VentaFilter{}
+
+	idLocal, ok := queryIntOpcional(c, "local")
+	if !ok {
+		return
+	}
+	filter.IDLocal = idLocal
+
+	for _, param := range []struct {
+		nombre string
+		fecha  **time.Time
+	}{
+		{nombre: "desde", fecha: &filter.FechaDesde},
+		{nombre: "hasta", fecha: &filter.FechaHasta},
+	} {
+		valor := c.Query(param.nombre)
+		if valor == "" {
+			continue
+		}
+		fecha, err := time.ParseInLocation("2006-01-02", valor, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", param.nombre+" debe tener formato YYYY-MM-DD"))
+			return
+		}
+		*param.fecha = &fecha
+	}
+
+	limit, ok := queryIntOpcional(c, "limit")
+	if !ok {
+		return
+	}
+	offset, ok := queryIntOpcional(c, "offset")
+	if !ok {
+		return
+	}
+	if limit != nil {
+		filter.Limit = *limit
+	}
+	if offset != nil {
+		filter.Offset = *offset
+	}
+
+	pagina, err := h.ventaService.GetVentas(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Error obteniendo ventas", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo ventas", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Ventas obtenidas",
+		"data":    pagina,
+	})
+}
+
+// GetPreciosVenta reconstruye por qué se cobró cada precio de una venta (id de operación de la venta)
+func (h *POSHandler) GetPreciosVenta(c *gin.Context) {
+	// La ruta comparte el comodín con /ventas/:id, pero aquí es el id de operación
+	idOperacion := c.Param("id")
+
+	explicacion, err := h.ventaService.ExplicarPrecios(c.Request.Context(), idOperacion)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrVentaNoEncontrada) {
+			status = http.StatusNotFound
+		}
+		c.JSON(errorStatus(c, err, status), errorResponse(c, "❌ Error obteniendo precios de la venta", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Precios de la venta obtenidos",
+		"data":    explicacion,
+	})
+}
+
+// GetVentasSospechosas lista las ventas sospechosas de duplicado para revisión del supervisor
+func (h *POSHandler) GetVentasSospechosas(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "get_ventas_sospechosas"))
+
+	filter := &models.VentaSospechosaFilter{
+		SoloPendientes: c.Query("pendientes") != "false",
+	}
+
+	if idLocalStr := c.Query("local"); idLocalStr != "" {
+		if idLocal, err := strconv.Atoi(idLocalStr); err == nil {
+			filter.IDLocal = &idLocal
+		}
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			filter.Limit = limit
+		}
+	}
+
+	ventas, err := h.duplicateSaleService.GetVentasSospechosas(c.Request.Context(), filter)
+	if err != nil {
+		logger.Error("Error obteniendo ventas sospechosas", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo ventas sospechosas", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Ventas sospechosas obtenidas",
+		"data": gin.H{
+			"ventas":  ventas,
+			"total":   len(ventas),
+			"filtros": filter,
+		},
+	})
+}
+
+// GetEscaneosNoEncontrados lista los códigos escaneados que no existen en el catálogo,
+// los más escaneados primero (?local=&desde=YYYY-MM-DD&limit=)
+func (h *POSHandler) GetEscaneosNoEncontrados(c *gin.Context) {
+	filter := &models.EscaneoNoEncontradoFilter{}
+
+	idLocal, ok := queryIntOpcional(c, "local")
+	if !ok {
+		return
+	}
+	filter.IDLocal = idLocal
+
+	if desdeStr := c.Query("desde"); desdeStr != "" {
+		desde, err := time.ParseInLocation("2006-01-02", desdeStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "desde debe tener formato YYYY-MM-DD"))
+			return
+		}
+		filter.Desde = &desde
+	}
+
+	limit, ok := queryIntOpcional(c, "limit")
+	if !ok {
+		return
+	}
+	if limit != nil {
+		filter.Limit = *limit
+	}
+
+	escaneos, err := h.escaneoService.GetTop(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Error obteniendo escaneos no encontrados", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo códigos no encontrados", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Códigos no encontrados obtenidos",
+		"data": gin.H{
+			"codigos": escaneos,
+			"total":   len(escaneos),
+		},
+	})
+}
+
+// MarcarVentaSospechosaRevisada marca una venta sospechosa como revisada por el supervisor
+func (h *POSHandler) MarcarVentaSospechosaRevisada(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "marcar_venta_sospechosa_revisada"))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de venta inválido", "El ID debe ser un número válido"))
+		return
+	}
+
+	idUsuario := idUsuarioActual(c)
+
+	if err := h.duplicateSaleService.MarcarRevisada(c.Request.Context(), id, idUsuario); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(c, "❌ Venta sospechosa no encontrada", err.Error()))
+			return
+		}
+		logger.Error("Error marcando venta sospechosa como revisada", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error marcando venta como revisada", err.Error()))
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "✅ Venta procesada correctamente",
+		"message": "✅ Venta marcada como revisada",
 		"data": gin.H{
-			"venta_id":             time.Now().Unix(), // ID temporal
-			"productos_procesados": response.TotalProductos,
-			"total_items":          len(itemsValidos),
-			"latency_ms":           time.Since(start).Milliseconds(),
-			"timestamp":            time.Now().Format(time.RFC3339),
+			"id": id,
+		},
+	})
+}
+
+// ConciliarPreciosCache compara los precios en cache contra lista_precios_cantera y reporta las divergencias
+// POST /pos/cache/conciliacion-precios?muestra=200 (sin muestra: todas las entradas en cache)
+func (h *POSHandler) ConciliarPreciosCache(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "conciliar_precios_cache"))
+
+	muestra := 0
+	if muestraStr := c.Query("muestra"); muestraStr != "" {
+		m, err := strconv.Atoi(muestraStr)
+		if err != nil || m < 0 {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Muestra inválida", "La muestra debe ser un número mayor o igual a 0"))
+			return
+		}
+		muestra = m
+	}
+
+	conciliacion, err := h.precioService.ConciliarCache(c.Request.Context(), muestra)
+	if err != nil {
+		logger.Error("Error conciliando cache contra lista de precios", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error conciliando precios", err.Error()))
+		return
+	}
+
+	message := "✅ Cache conciliada sin divergencias"
+	if conciliacion.TotalDivergencias > 0 {
+		message = fmt.Sprintf("⚠️ %d divergencias entre la cache y la lista de precios", conciliacion.TotalDivergencias)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    conciliacion,
+	})
+}
+
+// GetConciliacionesPrecios lista la bitácora de conciliaciones de la cache contra la lista de precios
+func (h *POSHandler) GetConciliacionesPrecios(c *gin.Context) {
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			limit = l
+		}
+	}
+
+	conciliaciones, err := h.precioService.GetConciliaciones(c.Request.Context(), limit)
+	if err != nil {
+		h.logger.Error("Error obteniendo bitácora de conciliaciones", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo conciliaciones", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Conciliaciones obtenidas",
+		"data": gin.H{
+			"conciliaciones": conciliaciones,
+			"total":          len(conciliaciones),
 		},
 	})
 }
@@ -251,11 +1164,7 @@ func (h *POSHandler) PreloadFrequentProducts(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ Error en el formato de datos",
-			"error":   err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
 		return
 	}
 
@@ -270,11 +1179,7 @@ func (h *POSHandler) PreloadFrequentProducts(c *gin.Context) {
 	err := h.productCache.PreloadProducts(c.Request.Context(), req.CodigosBarras)
 	if err != nil {
 		logger.Error("Error pre-cargando productos", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error pre-cargando productos",
-			"error":   err.Error(),
-		})
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error pre-cargando productos", err.Error()))
 		return
 	}
 
@@ -305,16 +1210,29 @@ func (h *POSHandler) GetCacheStats(c *gin.Context) {
 	})
 }
 
+// GetCacheVersionStatus versión global de lista_precios y productos que el servicio considera vigente,
+// su último check contra la BD y la última invalidación total (diagnóstico para soporte)
+func (h *POSHandler) GetCacheVersionStatus(c *gin.Context) {
+	status, err := h.productCache.VersionStatus(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Error obteniendo estado de versión de la cache", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo estado de versión de la cache", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Estado de versión de la cache",
+		"data":    status,
+	})
+}
+
 // InvalidateProductCache invalida la cache de un producto por código de barras
 func (h *POSHandler) InvalidateProductCache(c *gin.Context) {
 	codigoBarras := c.Param("codigo")
 
 	if codigoBarras == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ Código de barras requerido",
-			"error":   "El código de barras no puede estar vacío",
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Código de barras requerido", "El código de barras no puede estar vacío"))
 		return
 	}
 
@@ -327,11 +1245,7 @@ func (h *POSHandler) InvalidateProductCache(c *gin.Context) {
 
 	if err := h.productCache.InvalidateProduct(c.Request.Context(), codigoBarras); err != nil {
 		logger.Error("Error invalidando cache", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error invalidando cache",
-			"error":   err.Error(),
-		})
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error invalidando cache", err.Error()))
 		return
 	}
 
@@ -350,11 +1264,7 @@ func (h *POSHandler) InvalidateByCodigoTivendo(c *gin.Context) {
 	codigoTivendo := c.Param("codigo")
 
 	if codigoTivendo == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ Código Tivendo requerido",
-			"error":   "El código Tivendo no puede estar vacío",
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Código Tivendo requerido", "El código Tivendo no puede estar vacío"))
 		return
 	}
 
@@ -367,11 +1277,7 @@ func (h *POSHandler) InvalidateByCodigoTivendo(c *gin.Context) {
 
 	if err := h.productCache.InvalidateByCodigoTivendo(c.Request.Context(), codigoTivendo); err != nil {
 		logger.Error("Error invalidando cache", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error invalidando cache",
-			"error":   err.Error(),
-		})
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error invalidando cache", err.Error()))
 		return
 	}
 
@@ -395,11 +1301,7 @@ func (h *POSHandler) InvalidateAllCache(c *gin.Context) {
 
 	if err := h.productCache.InvalidateAll(c.Request.Context()); err != nil {
 		logger.Error("Error invalidando cache", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error invalidando cache",
-			"error":   err.Error(),
-		})
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error invalidando cache", err.Error()))
 		return
 	}
 
@@ -421,11 +1323,7 @@ func (h *POSHandler) InvalidateProductsCache(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ Error en el formato de datos",
-			"error":   err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
 		return
 	}
 
@@ -438,11 +1336,7 @@ func (h *POSHandler) InvalidateProductsCache(c *gin.Context) {
 
 	if err := h.productCache.InvalidateProducts(c.Request.Context(), req.CodigosBarras); err != nil {
 		logger.Error("Error invalidando cache", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error invalidando cache",
-			"error":   err.Error(),
-		})
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error invalidando cache", err.Error()))
 		return
 	}
 
@@ -457,103 +1351,70 @@ func (h *POSHandler) InvalidateProductsCache(c *gin.Context) {
 
 // NotifyProductosUpdate notifica que se actualizaron productos/packs masivamente
 // Este endpoint debe ser llamado desde el otro servidor después de actualizar productos
-// Invalida toda la cache de productos directamente, sin verificar timestamps
+// La invalidación total de la cache se encola y la procesa el worker en orden (202 + id)
 func (h *POSHandler) NotifyProductosUpdate(c *gin.Context) {
-	logger := h.logger.With(
-		zap.String("handler", "notify_productos_update"),
-	)
-
-	logger.Info("Notificación de actualización masiva de productos/packs - invalidando cache")
-
-	// Invalidar toda la cache de productos directamente
-	if err := h.productCache.InvalidateAll(c.Request.Context()); err != nil {
-		logger.Error("Error invalidando cache", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error invalidando cache",
-			"error":   err.Error(),
-		})
-		return
-	}
-
-	logger.Info("Cache de productos invalidada correctamente")
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "✅ Cache invalidada correctamente",
-		"data": gin.H{
-			"invalidated": true,
-		},
-	})
+	h.encolarNotificacion(c, models.NotificacionProductos)
 }
 
 // NotifyListaPreciosUpdate notifica que se actualizó lista_precios_cantera masivamente
 // Este endpoint debe ser llamado desde el otro servidor después de actualizar ~9900 filas
+// Varias notificaciones seguidas ya no se pisan: se encolan y un único worker las procesa
+// en orden; el resultado se consulta en GET /pos/cache/notificaciones/:id
 func (h *POSHandler) NotifyListaPreciosUpdate(c *gin.Context) {
+	h.encolarNotificacion(c, models.NotificacionListaPrecios)
+}
+
+// encolarNotificacion encola la notificación de actualización masiva y responde 202 con su id
+func (h *POSHandler) encolarNotificacion(c *gin.Context, tipo string) {
 	logger := h.logger.With(
-		zap.String("handler", "notify_lista_precios_update"),
+		zap.String("handler", "notify_"+tipo+"_update"),
 	)
 
-	logger.Info("Notificación de actualización masiva de lista_precios_cantera")
-
-	// Obtener el último timestamp de la BD
-	timestamp, err := h.productRepo.GetLastListaPreciosTimestamp(c.Request.Context())
-	if err != nil {
-		logger.Error("Error obteniendo timestamp de lista_precios", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error obteniendo timestamp",
-			"error":   err.Error(),
-		})
-		return
+	// El body es opcional: sin callback_url el resultado solo se consulta por id
+	var req models.NotificacionMasivaRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+			return
+		}
 	}
 
-	if timestamp == nil {
-		logger.Warn("No se encontró timestamp de lista_precios")
-		c.JSON(http.StatusOK, gin.H{
-			"success": true,
-			"message": "⚠️ No hay timestamp disponible",
-		})
+	notificacion, err := h.notificacionService.Encolar(c.Request.Context(), tipo, strings.TrimSpace(req.CallbackURL))
+	if err != nil {
+		logger.Error("Error encolando notificación de actualización masiva", zap.Error(err))
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrCallbackInvalido) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(errorStatus(c, err, status), errorResponse(c, "❌ Error encolando notificación", err.Error()))
 		return
 	}
 
-	// Convertir timestamp a string para usar como versión
-	version := timestamp.Format(time.RFC3339Nano)
+	logger.Info("Notificación de actualización masiva encolada", zap.String("id_notificacion", notificacion.ID))
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "✅ Notificación encolada, la cache se invalidará en orden de llegada",
+		"data":    notificacion,
+	})
+}
 
-	// Invalidar cache si la versión cambió
-	invalidated, err := h.productCache.InvalidateAllByVersion(c.Request.Context(), version)
+// GetNotificacion obtiene el estado y resultado de una notificación de actualización masiva
+func (h *POSHandler) GetNotificacion(c *gin.Context) {
+	notificacion, err := h.notificacionService.GetNotificacion(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		logger.Error("Error invalidando cache por versión", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error invalidando cache",
-			"error":   err.Error(),
-		})
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrNotificacionNoEncontrada) {
+			status = http.StatusNotFound
+		}
+		c.JSON(errorStatus(c, err, status), errorResponse(c, "❌ Error obteniendo notificación", err.Error()))
 		return
 	}
 
-	if invalidated {
-		logger.Info("Cache invalidada por actualización masiva",
-			zap.String("version", version))
-		c.JSON(http.StatusOK, gin.H{
-			"success": true,
-			"message": "✅ Cache invalidada correctamente",
-			"data": gin.H{
-				"version":     version,
-				"invalidated": true,
-			},
-		})
-	} else {
-		logger.Info("Cache ya estaba actualizada",
-			zap.String("version", version))
-		c.JSON(http.StatusOK, gin.H{
-			"success": true,
-			"message": "✅ Cache ya está actualizada",
-			"data": gin.H{
-				"version":     version,
-				"invalidated": false,
-			},
-		})
-	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Notificación obtenida",
+		"data":    notificacion,
+	})
 }
 
 // validateGlobalVersion valida la versión global de lista_precios_cantera
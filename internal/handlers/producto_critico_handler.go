@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// ProductoCriticoHandler maneja las peticiones HTTP de los productos críticos y su SLA
+type ProductoCriticoHandler struct {
+	criticoService services.ProductoCriticoService
+	validator      *validator.Validate
+	logger         *zap.Logger
+}
+
+// NewProductoCriticoHandler crea una nueva instancia del handler
+func NewProductoCriticoHandler(criticoService services.ProductoCriticoService, logger *zap.Logger) *ProductoCriticoHandler {
+	return &ProductoCriticoHandler{
+		criticoService: criticoService,
+		validator:      validator.New(),
+		logger:         logger,
+	}
+}
+
+// MarcarCritico marca un producto como crítico en un local
+func (h *ProductoCriticoHandler) MarcarCritico(c *gin.Context) {
+	var req models.MarcarCriticoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	critico, err := h.criticoService.MarcarCritico(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Error marcando producto crítico", zap.Error(err))
+		c.JSON(errorStatus(c, err, criticoErrorStatus(err)), errorResponse(c, "❌ Error marcando producto crítico", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Producto marcado como crítico",
+		"data":    critico,
+	})
+}
+
+// DesmarcarCritico quita la marca de crítico de un producto en un local (?local=)
+func (h *ProductoCriticoHandler) DesmarcarCritico(c *gin.Context) {
+	idLocal, err := strconv.Atoi(c.Query("local"))
+	if err != nil || idLocal <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Local inválido", "local debe ser un número válido"))
+		return
+	}
+
+	if err := h.criticoService.DesmarcarCritico(c.Request.Context(), c.Param("codigo"), idLocal); err != nil {
+		c.JSON(errorStatus(c, err, criticoErrorStatus(err)), errorResponse(c, "❌ Error desmarcando producto crítico", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Producto ya no es crítico en el local",
+	})
+}
+
+// GetCriticos lista los productos críticos (?local=)
+func (h *ProductoCriticoHandler) GetCriticos(c *gin.Context) {
+	idLocal, ok := queryIntOpcional(c, "local")
+	if !ok {
+		return
+	}
+
+	criticos, err := h.criticoService.GetCriticos(c.Request.Context(), idLocal)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo productos críticos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Productos críticos obtenidos",
+		"data":    criticos,
+	})
+}
+
+// GetDisponibilidad calcula la disponibilidad de los productos críticos contra su SLA
+// (?local=&desde=YYYY-MM-DD&hasta=YYYY-MM-DD; sin fechas: la ventana configurada)
+func (h *ProductoCriticoHandler) GetDisponibilidad(c *gin.Context) {
+	idLocal, ok := queryIntOpcional(c, "local")
+	if !ok {
+		return
+	}
+
+	var desde, hasta *time.Time
+	if desdeStr := c.Query("desde"); desdeStr != "" {
+		fecha, err := time.ParseInLocation("2006-01-02", desdeStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "desde debe tener formato YYYY-MM-DD"))
+			return
+		}
+		desde = &fecha
+	}
+	if hastaStr := c.Query("hasta"); hastaStr != "" {
+		fecha, err := time.ParseInLocation("2006-01-02", hastaStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "hasta debe tener formato YYYY-MM-DD"))
+			return
+		}
+		fecha = fecha.AddDate(0, 0, 1)
+		hasta = &fecha
+	}
+	if desde != nil && hasta != nil && !desde.Before(*hasta) {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "desde debe ser anterior a hasta"))
+		return
+	}
+
+	reporte, err := h.criticoService.GetDisponibilidad(c.Request.Context(), idLocal, desde, hasta)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error calculando disponibilidad de productos críticos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Disponibilidad de productos críticos calculada",
+		"data":    reporte,
+	})
+}
+
+// GetQuiebresActivos lista los productos críticos hoy sin stock (?local=)
+func (h *ProductoCriticoHandler) GetQuiebresActivos(c *gin.Context) {
+	idLocal, ok := queryIntOpcional(c, "local")
+	if !ok {
+		return
+	}
+
+	quiebres, err := h.criticoService.GetQuiebresActivos(c.Request.Context(), idLocal)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo quiebres de productos críticos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Quiebres de productos críticos obtenidos",
+		"data":    quiebres,
+	})
+}
+
+// criticoErrorStatus mapea los errores de dominio de los productos críticos a códigos HTTP
+func criticoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrProductoCriticoNoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrLocalNoEncontrado),
+		errors.Is(err, services.ErrProductoNoEncontrado):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// ProductoHandler maneja las peticiones HTTP sobre el maestro de productos
+type ProductoHandler struct {
+	precioService  services.PrecioService
+	imagenService  services.ImagenService
+	canalService   services.CanalService
+	bloqueoService services.BloqueoProductoService
+	imagesConfig   config.ImagesConfig
+	validator      *validator.Validate
+	logger         *zap.Logger
+}
+
+// NewProductoHandler crea una nueva instancia del handler
+func NewProductoHandler(precioService services.PrecioService, imagenService services.ImagenService, canalService services.CanalService, bloqueoService services.BloqueoProductoService, imagesConfig config.ImagesConfig, logger *zap.Logger) *ProductoHandler {
+	return &ProductoHandler{
+		precioService:  precioService,
+		imagenService:  imagenService,
+		canalService:   canalService,
+		bloqueoService: bloqueoService,
+		imagesConfig:   imagesConfig,
+		validator:      validator.New(),
+		logger:         logger,
+	}
+}
+
+// GetHistorialPrecios obtiene el historial de precios de un producto
+// Con ?fecha=YYYY-MM-DD (o RFC3339) responde el precio vigente ese día (o instante)
+func (h *ProductoHandler) GetHistorialPrecios(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "get_historial_precios"))
+
+	codigo := c.Param("codigo")
+
+	var (
+		historial []*models.HistorialPrecio
+		err       error
+	)
+
+	fechaStr := c.Query("fecha")
+	if fechaStr != "" {
+		desde, hasta, errFecha := parseFechaAuditoria(fechaStr)
+		if errFecha != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Fecha inválida", "Use el formato YYYY-MM-DD o RFC3339"))
+			return
+		}
+		historial, err = h.precioService.GetPreciosVigentes(c.Request.Context(), codigo, desde, hasta)
+	} else {
+		limit := 100
+		if limitStr := c.Query("limit"); limitStr != "" {
+			if l, errLimit := strconv.Atoi(limitStr); errLimit == nil {
+				limit = l
+			}
+		}
+		historial, err = h.precioService.GetHistorial(c.Request.Context(), codigo, limit)
+	}
+
+	if err != nil {
+		logger.Error("Error obteniendo historial de precios", zap.String("codigo", codigo), zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo historial de precios", err.Error()))
+		return
+	}
+
+	data := gin.H{
+		"codigo":    codigo,
+		"historial": historial,
+		"total":     len(historial),
+	}
+	if fechaStr != "" {
+		data["fecha"] = fechaStr
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Historial de precios obtenido",
+		"data":    data,
+	})
+}
+
+// SubirImagen sube (o reemplaza) la imagen del producto
+// POST /productos/:codigo/imagen (multipart, campo "imagen")
+func (h *ProductoHandler) SubirImagen(c *gin.Context) {
+	codigo := c.Param("codigo")
+	logger := h.logger.With(
+		zap.String("handler", "subir_imagen"),
+		zap.String("codigo", codigo),
+	)
+
+	file, _, err := c.Request.FormFile("imagen")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Imagen requerida", "Envíe la imagen en el campo multipart 'imagen'"))
+		return
+	}
+	defer file.Close()
+
+	// Leer un byte más del máximo para detectar archivos que lo exceden
+	data, err := io.ReadAll(io.LimitReader(file, h.imagesConfig.MaxBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error leyendo la imagen", err.Error()))
+		return
+	}
+
+	idUsuario := idUsuarioActual(c)
+
+	imagen, err := h.imagenService.Subir(c.Request.Context(), codigo, data, idUsuario)
+	if err != nil {
+		logger.Error("Error subiendo imagen", zap.Error(err))
+		c.JSON(errorStatus(c, err, imagenErrorStatus(err)), errorResponse(c, "❌ Error subiendo imagen", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Imagen subida correctamente",
+		"data":    imagen,
+	})
+}
+
+// AsociarImagen asocia al producto una imagen publicada en otra URL
+// PUT /productos/:codigo/imagen
+func (h *ProductoHandler) AsociarImagen(c *gin.Context) {
+	codigo := c.Param("codigo")
+	logger := h.logger.With(
+		zap.String("handler", "asociar_imagen"),
+		zap.String("codigo", codigo),
+	)
+
+	var req models.AsociarImagenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	imagen, err := h.imagenService.Asociar(c.Request.Context(), codigo, &req)
+	if err != nil {
+		logger.Error("Error asociando imagen", zap.Error(err))
+		c.JSON(errorStatus(c, err, imagenErrorStatus(err)), errorResponse(c, "❌ Error asociando imagen", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Imagen asociada correctamente",
+		"data":    imagen,
+	})
+}
+
+// EliminarImagen quita la imagen del producto
+// DELETE /productos/:codigo/imagen
+func (h *ProductoHandler) EliminarImagen(c *gin.Context) {
+	codigo := c.Param("codigo")
+
+	if err := h.imagenService.Eliminar(c.Request.Context(), codigo); err != nil {
+		h.logger.Error("Error eliminando imagen", zap.String("codigo", codigo), zap.Error(err))
+		c.JSON(errorStatus(c, err, imagenErrorStatus(err)), errorResponse(c, "❌ Error eliminando imagen", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Imagen eliminada correctamente",
+		"data": gin.H{
+			"codigo": codigo,
+		},
+	})
+}
+
+// GetImagen sirve la imagen original del producto
+// GET /productos/:codigo/imagen
+func (h *ProductoHandler) GetImagen(c *gin.Context) {
+	h.servirImagen(c, false)
+}
+
+// GetMiniatura sirve la miniatura del producto
+// GET /productos/:codigo/imagen/miniatura
+func (h *ProductoHandler) GetMiniatura(c *gin.Context) {
+	h.servirImagen(c, true)
+}
+
+// servirImagen responde el contenido con cache HTTP: ETag por hash del contenido y
+// Cache-Control público (immutable cuando la URL viene versionada con ?v=)
+func (h *ProductoHandler) servirImagen(c *gin.Context, miniatura bool) {
+	codigo := c.Param("codigo")
+
+	imagen, reader, contentType, err := h.imagenService.Abrir(c.Request.Context(), codigo, miniatura)
+	if err != nil {
+		c.JSON(errorStatus(c, err, imagenErrorStatus(err)), errorResponse(c, "❌ Error obteniendo imagen", err.Error()))
+		return
+	}
+
+	// Imagen externa: el cliente la obtiene directo de su URL
+	if reader == nil {
+		destino := imagen.URL
+		if miniatura {
+			destino = imagen.URLMiniatura
+		}
+		c.Redirect(http.StatusFound, destino)
+		return
+	}
+	defer reader.Close()
+
+	cacheControl := fmt.Sprintf("public, max-age=%d", int(h.imagesConfig.CacheMaxAge.Seconds()))
+	if c.Query("v") != "" {
+		cacheControl += ", immutable"
+	}
+	c.Header("Cache-Control", cacheControl)
+
+	if imagen.Hash != nil {
+		etag := `"` + *imagen.Hash
+		if miniatura {
+			etag += "-thumb"
+		}
+		etag += `"`
+		c.Header("ETag", etag)
+
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.DataFromReader(http.StatusOK, -1, contentType, reader, nil)
+}
+
+// imagenErrorStatus mapea los errores de dominio de imágenes a códigos HTTP
+func imagenErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrProductoNoEncontrado), errors.Is(err, services.ErrImagenNoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrImagenInvalida):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, services.ErrImagenDemasiadoGrande):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetCanales obtiene los canales en que se vende el producto
+// GET /productos/:codigo/canales
+func (h *ProductoHandler) GetCanales(c *gin.Context) {
+	canales, err := h.canalService.GetCanales(c.Request.Context(), c.Param("codigo"))
+	if err != nil {
+		c.JSON(errorStatus(c, err, canalErrorStatus(err)), errorResponse(c, "❌ Error obteniendo canales", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Canales obtenidos",
+		"data":    canales,
+	})
+}
+
+// SetCanales define los canales en que se vende el producto (pos, ecommerce, mayorista)
+// PUT /productos/:codigo/canales
+func (h *ProductoHandler) SetCanales(c *gin.Context) {
+	var req models.CanalesProductoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	canales, err := h.canalService.SetCanales(c.Request.Context(), c.Param("codigo"), req.Canales)
+	if err != nil {
+		c.JSON(errorStatus(c, err, canalErrorStatus(err)), errorResponse(c, "❌ Error definiendo canales", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Canales definidos",
+		"data":    canales,
+	})
+}
+
+// EliminarCanales elimina los canales definidos: el producto vuelve a venderse en todos
+// DELETE /productos/:codigo/canales
+func (h *ProductoHandler) EliminarCanales(c *gin.Context) {
+	if err := h.canalService.EliminarCanales(c.Request.Context(), c.Param("codigo")); err != nil {
+		c.JSON(errorStatus(c, err, canalErrorStatus(err)), errorResponse(c, "❌ Error eliminando canales", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Canales eliminados, el producto se vende en todos los canales",
+	})
+}
+
+// canalErrorStatus mapea los errores de dominio de canales a códigos HTTP
+func canalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrProductoNoEncontrado), errors.Is(err, services.ErrCanalesNoDefinidos):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// BloquearProducto bloquea la venta del producto en todos los locales (con motivo y vigencia opcional)
+// POST /productos/:codigo/bloqueos
+func (h *ProductoHandler) BloquearProducto(c *gin.Context) {
+	var req models.BloquearProductoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	bloqueo, err := h.bloqueoService.Bloquear(c.Request.Context(), c.Param("codigo"), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, bloqueoErrorStatus(err)), errorResponse(c, "❌ Error bloqueando producto", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "🚫 Venta del producto bloqueada",
+		"data":    bloqueo,
+	})
+}
+
+// LevantarBloqueo levanta los bloqueos vigentes del producto: vuelve a venderse
+// POST /productos/:codigo/bloqueos/levantar
+func (h *ProductoHandler) LevantarBloqueo(c *gin.Context) {
+	var req models.LevantarBloqueoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	if err := h.bloqueoService.Levantar(c.Request.Context(), c.Param("codigo"), &req); err != nil {
+		c.JSON(errorStatus(c, err, bloqueoErrorStatus(err)), errorResponse(c, "❌ Error levantando bloqueo", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Bloqueo levantado, el producto vuelve a venderse",
+	})
+}
+
+// GetBloqueos obtiene el historial de bloqueos del producto
+// GET /productos/:codigo/bloqueos
+func (h *ProductoHandler) GetBloqueos(c *gin.Context) {
+	bloqueos, err := h.bloqueoService.GetBloqueos(c.Request.Context(), c.Param("codigo"))
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo bloqueos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Bloqueos obtenidos",
+		"data":    bloqueos,
+	})
+}
+
+// GetBloqueosVigentes obtiene los productos con la venta bloqueada
+// GET /productos/bloqueos
+func (h *ProductoHandler) GetBloqueosVigentes(c *gin.Context) {
+	bloqueos, err := h.bloqueoService.GetBloqueosVigentes(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo bloqueos vigentes", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Bloqueos vigentes obtenidos",
+		"data":    bloqueos,
+	})
+}
+
+// bloqueoErrorStatus mapea los errores de dominio de bloqueos a códigos HTTP
+func bloqueoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrProductoNoEncontrado), errors.Is(err, services.ErrBloqueoNoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrBloqueoInvalido):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// parseFechaAuditoria convierte una fecha (día completo) o un instante RFC3339 en un rango
+func parseFechaAuditoria(fecha string) (time.Time, time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, fecha); err == nil {
+		return t, t, nil
+	}
+
+	dia, err := time.ParseInLocation("2006-01-02", fecha, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return dia, dia.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// ReglaOperacionHandler maneja la administración de las reglas de bloqueo de operaciones
+type ReglaOperacionHandler struct {
+	reglaService services.ReglaOperacionService
+	validator    *validator.Validate
+	logger       *zap.Logger
+}
+
+// NewReglaOperacionHandler crea una nueva instancia del handler
+func NewReglaOperacionHandler(reglaService services.ReglaOperacionService, logger *zap.Logger) *ReglaOperacionHandler {
+	return &ReglaOperacionHandler{
+		reglaService: reglaService,
+		validator:    validator.New(),
+		logger:       logger,
+	}
+}
+
+// CrearRegla crea una regla de operación
+func (h *ReglaOperacionHandler) CrearRegla(c *gin.Context) {
+	var req models.ReglaOperacionRequest
+	if !h.bindRegla(c, &req) {
+		return
+	}
+
+	regla, err := h.reglaService.CreateRegla(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Error creando regla de operación", zap.Error(err))
+		c.JSON(errorStatus(c, err, reglaErrorStatus(err)), errorResponse(c, "❌ Error creando regla de operación", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Regla de operación creada",
+		"data":    regla,
+	})
+}
+
+// ActualizarRegla reemplaza una regla de operación
+func (h *ReglaOperacionHandler) ActualizarRegla(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReglaOperacionRequest
+	if !h.bindRegla(c, &req) {
+		return
+	}
+
+	regla, err := h.reglaService.UpdateRegla(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Error actualizando regla de operación", zap.Int("id_regla", id), zap.Error(err))
+		c.JSON(errorStatus(c, err, reglaErrorStatus(err)), errorResponse(c, "❌ Error actualizando regla de operación", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Regla de operación actualizada",
+		"data":    regla,
+	})
+}
+
+// EliminarRegla elimina una regla de operación
+func (h *ReglaOperacionHandler) EliminarRegla(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.reglaService.DeleteRegla(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(c, err, reglaErrorStatus(err)), errorResponse(c, "❌ Error eliminando regla de operación", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Regla de operación eliminada",
+	})
+}
+
+// GetRegla obtiene una regla de operación
+func (h *ReglaOperacionHandler) GetRegla(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	regla, err := h.reglaService.GetRegla(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, reglaErrorStatus(err)), errorResponse(c, "❌ Error obteniendo regla de operación", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Regla de operación obtenida",
+		"data":    regla,
+	})
+}
+
+// GetReglas lista las reglas de operación (?local=: las del local y las globales)
+func (h *ReglaOperacionHandler) GetReglas(c *gin.Context) {
+	idLocal, ok := queryIntOpcional(c, "local")
+	if !ok {
+		return
+	}
+
+	reglas, err := h.reglaService.GetReglas(c.Request.Context(), idLocal)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo reglas de operación", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Reglas de operación obtenidas",
+		"data":    reglas,
+	})
+}
+
+// bindRegla lee y valida el cuerpo de una regla; responde el error y retorna false si es inválido
+func (h *ReglaOperacionHandler) bindRegla(c *gin.Context, req *models.ReglaOperacionRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return false
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return false
+	}
+	return true
+}
+
+// parseID obtiene el ID de la regla de la URL
+func (h *ReglaOperacionHandler) parseID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de regla inválido", "El ID debe ser un número válido"))
+		return 0, false
+	}
+	return id, true
+}
+
+// reglaErrorStatus mapea los errores de dominio de las reglas de operación a códigos HTTP
+func reglaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrReglaNoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrReglaInvalida):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
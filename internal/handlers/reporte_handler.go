@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// reportePeriodoDefault período de los reportes cuando no se indica ?desde=
+const reportePeriodoDefault = 30 * 24 * time.Hour
+
+// ReporteHandler maneja los reportes de gestión
+type ReporteHandler struct {
+	reporteService services.ReporteService
+	logger         *zap.Logger
+}
+
+// NewReporteHandler crea una nueva instancia del handler
+func NewReporteHandler(reporteService services.ReporteService, logger *zap.Logger) *ReporteHandler {
+	return &ReporteHandler{
+		reporteService: reporteService,
+		logger:         logger,
+	}
+}
+
+// GetReporteMargenes reporta margen bruto por producto y categoría
+// GET /reportes/margenes?local=&desde=YYYY-MM-DD&hasta=YYYY-MM-DD
+func (h *ReporteHandler) GetReporteMargenes(c *gin.Context) {
+	filter, err := parseReporteFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", err.Error()))
+		return
+	}
+
+	reporte, err := h.reporteService.GetReporteMargenes(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Error generando reporte de márgenes", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error generando reporte de márgenes", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Reporte de márgenes generado",
+		"data":    reporte,
+	})
+}
+
+// GetReporteActividad resume la actividad por usuario
+// GET /reportes/actividad-usuarios?local=&usuario=&desde=YYYY-MM-DD&hasta=YYYY-MM-DD
+func (h *ReporteHandler) GetReporteActividad(c *gin.Context) {
+	filter, err := parseReporteFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", err.Error()))
+		return
+	}
+
+	if idUsuarioStr := c.Query("usuario"); idUsuarioStr != "" {
+		idUsuario, err := strconv.Atoi(idUsuarioStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "usuario debe ser un número válido"))
+			return
+		}
+		filter.IDUsuario = &idUsuario
+	}
+
+	reporte, err := h.reporteService.GetReporteActividad(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Error generando reporte de actividad", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error generando reporte de actividad", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Reporte de actividad generado",
+		"data":    reporte,
+	})
+}
+
+// GetCierreDia consolida ventas, devoluciones, mermas, entradas, caja y quiebres del día de un local
+// GET /reportes/cierre-dia?local=&fecha=YYYY-MM-DD&declarado= (fecha por defecto: hoy)
+func (h *ReporteHandler) GetCierreDia(c *gin.Context) {
+	idLocal, err := strconv.Atoi(c.Query("local"))
+	if err != nil || idLocal <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "local es obligatorio y debe ser un número válido"))
+		return
+	}
+
+	fecha := time.Now()
+	if fechaStr := c.Query("fecha"); fechaStr != "" {
+		fecha, err = time.ParseInLocation("2006-01-02", fechaStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "fecha debe tener formato YYYY-MM-DD"))
+			return
+		}
+	}
+
+	var declarado *float64
+	if declaradoStr := c.Query("declarado"); declaradoStr != "" {
+		monto, err := strconv.ParseFloat(declaradoStr, 64)
+		if err != nil || monto < 0 {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "declarado debe ser un monto válido"))
+			return
+		}
+		declarado = &monto
+	}
+
+	cierre, err := h.reporteService.GetCierreDia(c.Request.Context(), idLocal, fecha, declarado)
+	if err != nil {
+		h.logger.Error("Error generando cierre diario", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error generando cierre diario", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Cierre diario generado",
+		"data":    cierre,
+	})
+}
+
+// GetEstadoAgregados informa hasta qué día están agregados los movimientos
+// GET /reportes/agregados
+func (h *ReporteHandler) GetEstadoAgregados(c *gin.Context) {
+	estado, err := h.reporteService.GetEstadoAgregados(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Error obteniendo estado de agregados", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo estado de agregados", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Estado de agregados obtenido",
+		"data":    estado,
+	})
+}
+
+// RecalcularAgregados descarta los agregados desde una fecha para que el job los rehaga
+// (por ejemplo tras corregir el historial de precios)
+// POST /reportes/agregados/recalcular?desde=YYYY-MM-DD
+func (h *ReporteHandler) RecalcularAgregados(c *gin.Context) {
+	desde, err := time.ParseInLocation("2006-01-02", c.Query("desde"), time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Fecha inválida", "desde es obligatorio con formato YYYY-MM-DD"))
+		return
+	}
+
+	if err := h.reporteService.RecalcularAgregados(c.Request.Context(), desde); err != nil {
+		h.logger.Error("Error descartando agregados", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error descartando agregados", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Agregados descartados, se recalcularán en la próxima ronda",
+		"data": gin.H{
+			"desde": desde.Format("2006-01-02"),
+		},
+	})
+}
+
+// parseReporteFilter lee local, desde y hasta del query (hasta incluye el día completo)
+func parseReporteFilter(c *gin.Context) (*models.ReporteFilter, error) {
+	filter := &models.ReporteFilter{}
+
+	if idLocalStr := c.Query("local"); idLocalStr != "" {
+		idLocal, err := strconv.Atoi(idLocalStr)
+		if err != nil {
+			return nil, fmt.Errorf("local debe ser un número válido")
+		}
+		filter.IDLocal = &idLocal
+	}
+
+	now := time.Now()
+	filter.Hasta = now
+	if hastaStr := c.Query("hasta"); hastaStr != "" {
+		hasta, err := time.ParseInLocation("2006-01-02", hastaStr, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("hasta debe tener formato YYYY-MM-DD")
+		}
+		filter.Hasta = hasta.AddDate(0, 0, 1)
+	}
+
+	filter.Desde = filter.Hasta.Add(-reportePeriodoDefault)
+	if desdeStr := c.Query("desde"); desdeStr != "" {
+		desde, err := time.ParseInLocation("2006-01-02", desdeStr, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("desde debe tener formato YYYY-MM-DD")
+		}
+		filter.Desde = desde
+	}
+
+	if !filter.Desde.Before(filter.Hasta) {
+		return nil, fmt.Errorf("desde debe ser anterior a hasta")
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"stock-service/internal/jsonenc"
+	"stock-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// internalErrorMessages mensaje genérico por código para los errores internos en producción
+var internalErrorMessages = map[string]string{
+	errorCodeTimeout:       "la operación excedió el tiempo máximo",
+	errorCodeDBUnavailable: "base de datos no disponible",
+	errorCodeUnavailable:   "servicio no disponible temporalmente",
+	errorCodeInternal:      "error interno del servidor",
+}
+
+// errorResponse construye la respuesta de error estándar
+// Incluye el request_id para poder cruzar el error con los logs
+// Si el handler no registró el error (errorStatus) se registra el mensaje para el monitoring
+// Con GIN_MODE=release los errores 5xx (status elegido con errorStatus antes de llamarla)
+// no exponen el detalle (SQL, tablas): se reemplaza por el código de dominio y el detalle
+// queda en el log del request
+func errorResponse(c *gin.Context, message, errMsg string) gin.H {
+	if len(c.Errors) == 0 {
+		c.Error(errors.New(errMsg))
+	}
+
+	response := gin.H{
+		"success":    false,
+		"message":    message,
+		"error":      errMsg,
+		"request_id": requestID(c),
+	}
+	if status := c.GetInt(errorStatusKey); status >= http.StatusInternalServerError && gin.Mode() == gin.ReleaseMode {
+		code := classifyError(status, c.Errors.Last().Err)
+		msg, ok := internalErrorMessages[code]
+		if !ok {
+			msg = internalErrorMessages[errorCodeInternal]
+		}
+		response["codigo"] = code
+		response["error"] = msg
+	}
+	return response
+}
+
+// requestID obtiene el ID del request asignado por RequestIDMiddleware
+func requestID(c *gin.Context) string {
+	return c.GetString(middleware.RequestIDKey)
+}
+
+// idUsuarioActual id del usuario autenticado por el JWT (0 si el request no trajo token)
+// Las rutas que registran operaciones exigen rol y por lo tanto token (routes.rolesPorGrupo y
+// rolesPorRuta): una operación nunca se atribuye a un usuario que no la hizo
+func idUsuarioActual(c *gin.Context) int {
+	return c.GetInt(middleware.IDUsuarioKey)
+}
+
+// successJSON responde {"success":true,"message":...,"data":...} serializando data sin
+// reflexión; para los endpoints más llamados del POS, donde gin.H aparece en los profiles
+func successJSON(c *gin.Context, status int, message string, data jsonenc.Appender) {
+	buf := jsonenc.GetBuffer()
+	defer jsonenc.PutBuffer(buf)
+
+	b := append(*buf, '{')
+	b = jsonenc.AppendKey(b, "success")
+	b = jsonenc.AppendBool(b, true)
+	b = jsonenc.AppendKey(b, "message")
+	b = jsonenc.AppendString(b, message)
+	b = jsonenc.AppendKey(b, "data")
+	b = data.AppendJSON(b)
+	b = append(b, '}')
+	*buf = b
+
+	c.Data(status, "application/json; charset=utf-8", b)
+}
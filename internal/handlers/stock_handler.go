@@ -2,12 +2,18 @@ package handlers
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"stock-service/internal/export"
+	"stock-service/internal/gs1"
 	"stock-service/internal/models"
+	"stock-service/internal/repository"
 	"stock-service/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -17,17 +23,19 @@ import (
 
 // StockHandler maneja las peticiones HTTP relacionadas con stock
 type StockHandler struct {
-	stockService services.StockService
-	validator    *validator.Validate
-	logger       *zap.Logger
+	stockService    services.StockService
+	approvalService services.ApprovalService
+	validator       *validator.Validate
+	logger          *zap.Logger
 }
 
 // NewStockHandler crea una nueva instancia del handler
-func NewStockHandler(stockService services.StockService, logger *zap.Logger) *StockHandler {
+func NewStockHandler(stockService services.StockService, approvalService services.ApprovalService, logger *zap.Logger) *StockHandler {
 	return &StockHandler{
-		stockService: stockService,
-		validator:    validator.New(),
-		logger:       logger,
+		stockService:    stockService,
+		approvalService: approvalService,
+		validator:       validator.New(),
+		logger:          logger,
 	}
 }
 
@@ -67,11 +75,7 @@ func (h *StockHandler) EntradaMultipleStock(c *gin.Context) {
 	var req models.EntradaMultipleStockRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logError("Error binding JSON", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ Error en el formato de datos",
-			"error":   err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
 		return
 	}
 
@@ -83,11 +87,7 @@ func (h *StockHandler) EntradaMultipleStock(c *gin.Context) {
 	// Validar request
 	if err := h.validator.Struct(req); err != nil {
 		h.logError("Validation error", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ Datos de entrada inválidos",
-			"error":   err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
 		return
 	}
 
@@ -103,22 +103,40 @@ func (h *StockHandler) EntradaMultipleStock(c *gin.Context) {
 			zap.Int("cantidad_minima", producto.CantidadMinima))
 	}
 
-	// TODO: Implementar autenticación cuando sea necesario
-	// Por ahora usar ID por defecto
-	req.IDUsuario = 1
+	req.IDUsuario = idUsuarioActual(c)
 	h.logDebug("ID Usuario asignado", zap.Int("id_usuario", req.IDUsuario))
 
+	// ?dry_run=true valida y calcula el resultado previsto sin escribir en la BD
+	req.DryRun = c.Query("dry_run") == "true"
+
+	// Operaciones grandes quedan pendientes de aprobación del supervisor (salvo simulaciones)
+	var solicitud *models.SolicitudAprobacion
+	var err error
+	if !req.DryRun {
+		solicitud, err = h.approvalService.EvaluarEntradaMultiple(c.Request.Context(), &req)
+	}
+	if err != nil {
+		h.logError("Error evaluando aprobación", zap.Error(err))
+		c.JSON(errorStatus(c, err, entradaErrorStatus(err)), errorResponse(c, "❌ Error procesando entrada múltiple de stock", err.Error()))
+		return
+	}
+	if solicitud != nil {
+		h.logInfo("Entrada múltiple pendiente de aprobación", zap.Int("id_solicitud", solicitud.ID))
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"message": "⏳ Operación pendiente de aprobación de un supervisor",
+			"data":    solicitud,
+		})
+		return
+	}
+
 	h.logDebug("Llamando a stockService.EntradaMultipleStock")
 
 	// Procesar entrada múltiple
 	response, err := h.stockService.EntradaMultipleStock(c.Request.Context(), &req)
 	if err != nil {
 		h.logError("Error procesando entrada múltiple", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error procesando entrada múltiple de stock",
-			"error":   err.Error(),
-		})
+		c.JSON(errorStatus(c, err, entradaErrorStatus(err)), errorResponse(c, "❌ Error procesando entrada múltiple de stock", err.Error()))
 		return
 	}
 
@@ -145,7 +163,13 @@ func (h *StockHandler) EntradaMultipleStock(c *gin.Context) {
 			zap.String("error", error.Error))
 	}
 
-	c.JSON(http.StatusOK, response)
+	// Si se agotó el deadline a mitad de la operación, los ítems restantes fallaron por timeout
+	status := multipleStatus(c, itemsAplicados(response.Resultados, response.DryRun))
+	if versionRespuestaMultiple(c) == models.RespuestaMultipleV2 {
+		c.JSON(status, response.V2())
+		return
+	}
+	c.JSON(status, response)
 }
 
 // SalidaMultipleStock maneja la salida múltiple de stock
@@ -164,11 +188,7 @@ func (h *StockHandler) SalidaMultipleStock(c *gin.Context) {
 	var req models.SalidaMultipleStockRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logError("Error binding JSON", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ Error en el formato de datos",
-			"error":   err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
 		return
 	}
 
@@ -180,11 +200,7 @@ func (h *StockHandler) SalidaMultipleStock(c *gin.Context) {
 	// Validar request
 	if err := h.validator.Struct(req); err != nil {
 		h.logError("Validation error", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ Datos de entrada inválidos",
-			"error":   err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
 		return
 	}
 
@@ -199,22 +215,40 @@ func (h *StockHandler) SalidaMultipleStock(c *gin.Context) {
 			zap.Int("cantidad", producto.Cantidad))
 	}
 
-	// TODO: Implementar autenticación cuando sea necesario
-	// Por ahora usar ID por defecto
-	req.IDUsuario = 1
+	req.IDUsuario = idUsuarioActual(c)
 	h.logDebug("ID Usuario asignado", zap.Int("id_usuario", req.IDUsuario))
 
+	// ?dry_run=true valida y calcula el resultado previsto sin escribir en la BD
+	req.DryRun = c.Query("dry_run") == "true"
+
+	// Operaciones grandes quedan pendientes de aprobación del supervisor (salvo simulaciones)
+	var solicitud *models.SolicitudAprobacion
+	var err error
+	if !req.DryRun {
+		solicitud, err = h.approvalService.EvaluarSalidaMultiple(c.Request.Context(), &req)
+	}
+	if err != nil {
+		h.logError("Error evaluando aprobación", zap.Error(err))
+		c.JSON(errorStatus(c, err, salidaErrorStatus(err)), errorResponse(c, "❌ Error procesando salida múltiple de stock", err.Error()))
+		return
+	}
+	if solicitud != nil {
+		h.logInfo("Salida múltiple pendiente de aprobación", zap.Int("id_solicitud", solicitud.ID))
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"message": "⏳ Operación pendiente de aprobación de un supervisor",
+			"data":    solicitud,
+		})
+		return
+	}
+
 	h.logDebug("Llamando a stockService.SalidaMultipleStock")
 
 	// Procesar salida múltiple
 	response, err := h.stockService.SalidaMultipleStock(c.Request.Context(), &req)
 	if err != nil {
 		h.logError("Error procesando salida múltiple", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error procesando salida múltiple de stock",
-			"error":   err.Error(),
-		})
+		c.JSON(errorStatus(c, err, salidaErrorStatus(err)), errorResponse(c, "❌ Error procesando salida múltiple de stock", err.Error()))
 		return
 	}
 
@@ -241,7 +275,38 @@ func (h *StockHandler) SalidaMultipleStock(c *gin.Context) {
 			zap.String("error", error.Error))
 	}
 
-	c.JSON(http.StatusOK, response)
+	// Si se agotó el deadline a mitad de la operación, los ítems restantes fallaron por timeout
+	status := multipleStatus(c, itemsAplicados(response.Resultados, response.DryRun))
+	if versionRespuestaMultiple(c) == models.RespuestaMultipleV2 {
+		c.JSON(status, response.V2())
+		return
+	}
+	c.JSON(status, response)
+}
+
+// itemsAplicados cantidad de ítems de una operación múltiple que quedaron registrados
+// (una simulación no registra ninguno)
+func itemsAplicados(resultados []models.ProductoResultado, dryRun bool) int {
+	if dryRun {
+		return 0
+	}
+	return len(resultados)
+}
+
+// responseVersionHeader header con que el cliente pide la versión de la respuesta (también ?version=)
+const responseVersionHeader = "X-Response-Version"
+
+// versionRespuestaMultiple versión pedida para la respuesta de entrada/salida múltiple
+// Sin indicar (o una versión desconocida) se responde la v1 para no romper a los clientes actuales
+func versionRespuestaMultiple(c *gin.Context) int {
+	version := c.GetHeader(responseVersionHeader)
+	if version == "" {
+		version = c.Query("version")
+	}
+	if strings.TrimPrefix(strings.ToLower(version), "v") == strconv.Itoa(models.RespuestaMultipleV2) {
+		return models.RespuestaMultipleV2
+	}
+	return models.RespuestaMultipleV1
 }
 
 // GetStockByLocal obtiene el stock de un local específico
@@ -252,11 +317,7 @@ func (h *StockHandler) GetStockByLocal(c *gin.Context) {
 	idLocal, err := strconv.Atoi(idLocalStr)
 	if err != nil {
 		logger.Error("Error parsing local ID", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ ID de local inválido",
-			"error":   "El ID debe ser un número válido",
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de local inválido", "El ID debe ser un número válido"))
 		return
 	}
 
@@ -265,11 +326,7 @@ func (h *StockHandler) GetStockByLocal(c *gin.Context) {
 	stock, err := h.stockService.GetStockByLocal(c.Request.Context(), idLocal)
 	if err != nil {
 		logger.Error("Error obteniendo stock por local", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error obteniendo stock del local",
-			"error":   err.Error(),
-		})
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo stock del local", err.Error()))
 		return
 	}
 
@@ -297,11 +354,7 @@ func (h *StockHandler) GetStockCompleteByLocal(c *gin.Context) {
 	idLocal, err := strconv.Atoi(idLocalStr)
 	if err != nil {
 		h.logError("ID de local inválido", zap.String("id", idLocalStr), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ ID de local inválido",
-			"error":   "El ID debe ser un número entero",
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de local inválido", "El ID debe ser un número entero"))
 		return
 	}
 
@@ -312,11 +365,7 @@ func (h *StockHandler) GetStockCompleteByLocal(c *gin.Context) {
 	stocks, err := h.stockService.GetStockCompleteByLocal(c.Request.Context(), idLocal)
 	if err != nil {
 		h.logError("Error obteniendo stock completo", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error obteniendo stock completo",
-			"error":   err.Error(),
-		})
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo stock completo", err.Error()))
 		return
 	}
 
@@ -330,6 +379,70 @@ func (h *StockHandler) GetStockCompleteByLocal(c *gin.Context) {
 	})
 }
 
+// GetStockDescontinuado reporta el stock remanente de productos desactivados (para liquidación)
+func (h *StockHandler) GetStockDescontinuado(c *gin.Context) {
+	idLocalStr := c.Param("id")
+	idLocal, err := strconv.Atoi(idLocalStr)
+	if err != nil {
+		h.logError("ID de local inválido", zap.String("id", idLocalStr), zap.Error(err))
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de local inválido", "El ID debe ser un número entero"))
+		return
+	}
+
+	stocks, err := h.stockService.GetStockDescontinuadoByLocal(c.Request.Context(), idLocal)
+	if err != nil {
+		h.logError("Error obteniendo stock descontinuado", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo stock descontinuado", err.Error()))
+		return
+	}
+
+	totalUnidades := 0
+	valorizacion := 0.0
+	for _, stock := range stocks {
+		totalUnidades += stock.CantidadActual
+		if stock.Precio != nil {
+			valorizacion += *stock.Precio * float64(stock.CantidadActual)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Stock descontinuado obtenido",
+		"data": gin.H{
+			"id_local":        idLocal,
+			"productos":       stocks,
+			"total_productos": len(stocks),
+			"total_unidades":  totalUnidades,
+			"valorizacion":    valorizacion,
+		},
+	})
+}
+
+// GetStockSummary resumen por local: productos, packs, bajo mínimo y valorización (?local=)
+func (h *StockHandler) GetStockSummary(c *gin.Context) {
+	idLocal, ok := queryIntOpcional(c, "local")
+	if !ok {
+		return
+	}
+
+	resumen, err := h.stockService.GetStockSummary(c.Request.Context(), idLocal)
+	if err != nil {
+		h.logError("Error obteniendo resumen de stock", zap.Error(err))
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrLocalNoEncontrado) {
+			status = http.StatusNotFound
+		}
+		c.JSON(errorStatus(c, err, status), errorResponse(c, "❌ Error obteniendo resumen de stock", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Resumen de stock obtenido",
+		"data":    resumen,
+	})
+}
+
 // GetStockBajo obtiene productos con stock bajo
 func (h *StockHandler) GetStockBajo(c *gin.Context) {
 	logger := h.logger.With(zap.String("handler", "get_stock_bajo"))
@@ -338,11 +451,7 @@ func (h *StockHandler) GetStockBajo(c *gin.Context) {
 	idLocal, err := strconv.Atoi(idLocalStr)
 	if err != nil {
 		logger.Error("Error parsing local ID", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ ID de local inválido",
-			"error":   "El ID debe ser un número válido",
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de local inválido", "El ID debe ser un número válido"))
 		return
 	}
 
@@ -351,11 +460,7 @@ func (h *StockHandler) GetStockBajo(c *gin.Context) {
 	stockBajo, err := h.stockService.GetStockBajo(c.Request.Context(), idLocal)
 	if err != nil {
 		logger.Error("Error obteniendo stock bajo", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error obteniendo stock bajo",
-			"error":   err.Error(),
-		})
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo stock bajo", err.Error()))
 		return
 	}
 
@@ -374,6 +479,125 @@ func (h *StockHandler) GetStockBajo(c *gin.Context) {
 	})
 }
 
+// ProyectarStock proyecta el stock día a día y estima la fecha de quiebre por producto
+func (h *StockHandler) ProyectarStock(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "proyectar_stock"))
+
+	var req models.ProyeccionStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	proyeccion, err := h.stockService.ProyectarStock(c.Request.Context(), &req)
+	if err != nil {
+		logger.Error("Error proyectando stock", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error proyectando stock", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Proyección de stock calculada",
+		"data":    proyeccion,
+	})
+}
+
+// AuditarStock compara el stock con el reconstruido desde los movimientos y lista los descuadres
+// Con "corregir": true registra un movimiento de ajuste por cada descuadre
+func (h *StockHandler) AuditarStock(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "auditar_stock"))
+
+	var req models.AuditoriaStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	auditoria, err := h.stockService.AuditarStock(c.Request.Context(), &req)
+	if err != nil {
+		logger.Error("Error auditando stock", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error auditando stock", err.Error()))
+		return
+	}
+
+	message := "✅ Stock cuadrado con los movimientos"
+	if auditoria.TotalDescuadres > 0 {
+		message = fmt.Sprintf("⚠️ %d descuadres entre el stock y los movimientos", auditoria.TotalDescuadres)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    auditoria,
+	})
+}
+
+// VerificarIntegridadMovimientos verifica la cadena de hashes de los movimientos del local
+// (?desde=YYYY-MM-DD&hasta=YYYY-MM-DD, ambos inclusive; sin fechas: toda la cadena)
+func (h *StockHandler) VerificarIntegridadMovimientos(c *gin.Context) {
+	idLocal, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de local inválido", "El ID debe ser un número válido"))
+		return
+	}
+
+	var desde, hasta *time.Time
+	if desdeStr := c.Query("desde"); desdeStr != "" {
+		fecha, err := time.Parse("2006-01-02", desdeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "desde debe tener formato YYYY-MM-DD"))
+			return
+		}
+		desde = &fecha
+	}
+	if hastaStr := c.Query("hasta"); hastaStr != "" {
+		fecha, err := time.Parse("2006-01-02", hastaStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "hasta debe tener formato YYYY-MM-DD"))
+			return
+		}
+		hasta = &fecha
+	}
+	if desde != nil && hasta != nil && hasta.Before(*desde) {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Filtros inválidos", "desde no puede ser posterior a hasta"))
+		return
+	}
+
+	integridad, err := h.stockService.VerificarIntegridadMovimientos(c.Request.Context(), idLocal, desde, hasta)
+	if err != nil {
+		h.logger.Error("Error verificando integridad de movimientos", zap.Error(err))
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrLocalNoEncontrado) {
+			status = http.StatusNotFound
+		}
+		c.JSON(errorStatus(c, err, status), errorResponse(c, "❌ Error verificando integridad de movimientos", err.Error()))
+		return
+	}
+
+	message := "✅ Cadena de movimientos íntegra"
+	if !integridad.Integra {
+		message = fmt.Sprintf("⚠️ Cadena de movimientos rota en el movimiento %d (%s)",
+			integridad.PrimeraRuptura.IDMovimiento, integridad.PrimeraRuptura.Motivo)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    integridad,
+	})
+}
+
 // GetStockByProducto obtiene el stock de un producto específico
 func (h *StockHandler) GetStockByProducto(c *gin.Context) {
 	logger := h.logger.With(zap.String("handler", "get_stock_by_producto"))
@@ -381,11 +605,7 @@ func (h *StockHandler) GetStockByProducto(c *gin.Context) {
 	codigoProducto := c.Param("codigo")
 	if codigoProducto == "" {
 		logger.Error("Código de producto vacío")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ Código de producto requerido",
-			"error":   "El código de producto no puede estar vacío",
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Código de producto requerido", "El código de producto no puede estar vacío"))
 		return
 	}
 
@@ -404,11 +624,15 @@ func (h *StockHandler) GetStockByProducto(c *gin.Context) {
 	stock, err := h.stockService.GetStockByProducto(c.Request.Context(), codigoProducto, idLocal)
 	if err != nil {
 		logger.Error("Error obteniendo stock por producto", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error obteniendo stock del producto",
-			"error":   err.Error(),
-		})
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo stock del producto", err.Error()))
+		return
+	}
+
+	// Desglose física / reservada / en tránsito / disponible (también sin stock: puede venir en tránsito)
+	disponibilidad, err := h.stockService.GetStockDisponibilidad(c.Request.Context(), codigoProducto, idLocal)
+	if err != nil {
+		logger.Error("Error calculando disponibilidad del producto", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo stock del producto", err.Error()))
 		return
 	}
 
@@ -421,6 +645,7 @@ func (h *StockHandler) GetStockByProducto(c *gin.Context) {
 				"codigo_producto": codigoProducto,
 				"id_local":        idLocal,
 				"stock":           nil,
+				"disponibilidad":  disponibilidad,
 			},
 		})
 		return
@@ -437,6 +662,46 @@ func (h *StockHandler) GetStockByProducto(c *gin.Context) {
 			"codigo_producto": codigoProducto,
 			"id_local":        idLocal,
 			"stock":           stock,
+			"disponibilidad":  disponibilidad,
+		},
+	})
+}
+
+// GetHistorialMinimos obtiene los cambios de cantidad mínima de un producto (?local=&limit=)
+// Explica por qué un producto empezó (o dejó) de aparecer con stock bajo
+func (h *StockHandler) GetHistorialMinimos(c *gin.Context) {
+	codigoProducto := c.Param("codigo")
+
+	idLocal, ok := queryIntOpcional(c, "local")
+	if !ok {
+		return
+	}
+	limit, ok := queryIntOpcional(c, "limit")
+	if !ok {
+		return
+	}
+	maxItems := 0 // el servicio aplica el límite por defecto
+	if limit != nil {
+		maxItems = *limit
+	}
+
+	historial, err := h.stockService.GetHistorialMinimos(c.Request.Context(), codigoProducto, idLocal, maxItems)
+	if err != nil {
+		h.logger.Error("Error obteniendo historial de cantidad mínima",
+			zap.String("codigo_producto", codigoProducto),
+			zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo historial de cantidad mínima", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Historial de cantidad mínima obtenido",
+		"data": gin.H{
+			"codigo_producto": codigoProducto,
+			"id_local":        idLocal,
+			"historial":       historial,
+			"total":           len(historial),
 		},
 	})
 }
@@ -445,6 +710,160 @@ func (h *StockHandler) GetStockByProducto(c *gin.Context) {
 func (h *StockHandler) GetMovimientos(c *gin.Context) {
 	logger := h.logger.With(zap.String("handler", "get_movimientos"))
 
+	filter, ok := parseMovimientosQuery(c)
+	if !ok {
+		return
+	}
+
+	// Con group_by se devuelven agregados por grupo en lugar de las filas (gráficos del dashboard)
+	if agrupacion := c.Query("group_by"); agrupacion != "" {
+		h.getMovimientosAgrupados(c, filter, agrupacion)
+		return
+	}
+
+	logger.Info("Obteniendo movimientos",
+		zap.Any("filtros", filter))
+
+	pagina, err := h.stockService.GetMovimientosPaginados(c.Request.Context(), filter)
+	if err != nil {
+		logger.Error("Error obteniendo movimientos", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo movimientos", err.Error()))
+		return
+	}
+
+	logger.Info("Movimientos obtenidos exitosamente",
+		zap.Int("total_movimientos", pagina.Total),
+		zap.Int("movimientos_pagina", len(pagina.Movimientos)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Movimientos obtenidos correctamente",
+		"data": gin.H{
+			"movimientos": pagina.Movimientos,
+			"total":       pagina.Total,
+			"limit":       pagina.Limit,
+			"offset":      pagina.Offset,
+			"filtros":     filter,
+		},
+	})
+}
+
+// ExportarStockLocal descarga el stock completo del local en CSV o XLSX
+// GET /stock/local/:id/export?formato=csv|xlsx
+func (h *StockHandler) ExportarStockLocal(c *gin.Context) {
+	idLocal, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de local inválido", "El ID debe ser un número válido"))
+		return
+	}
+	formato, ok := parseFormatoExportacion(c)
+	if !ok {
+		return
+	}
+
+	nombreArchivo := fmt.Sprintf("stock_local_%d_%s.%s", idLocal, time.Now().Format("20060102"), formato)
+	h.exportar(c, formato, nombreArchivo, "Stock", func(w export.Writer) (int, error) {
+		return h.stockService.ExportarStock(c.Request.Context(), idLocal, w)
+	})
+}
+
+// ExportarMovimientos descarga en CSV o XLSX todos los movimientos que cumplen los mismos filtros
+// de GET /movimientos (sin paginar)
+// GET /movimientos/export?formato=csv|xlsx&local=&tipo=&fecha_desde=&fecha_hasta=...
+func (h *StockHandler) ExportarMovimientos(c *gin.Context) {
+	filter, ok := parseMovimientosQuery(c)
+	if !ok {
+		return
+	}
+	formato, ok := parseFormatoExportacion(c)
+	if !ok {
+		return
+	}
+
+	nombreArchivo := "movimientos"
+	if filter.IDLocal != nil {
+		nombreArchivo += fmt.Sprintf("_local_%d", *filter.IDLocal)
+	}
+	if filter.FechaDesde != nil {
+		nombreArchivo += "_" + filter.FechaDesde.Format("20060102")
+	}
+	if filter.FechaHasta != nil {
+		nombreArchivo += "_" + filter.FechaHasta.Format("20060102")
+	}
+	nombreArchivo += "." + formato
+
+	h.exportar(c, formato, nombreArchivo, "Movimientos", func(w export.Writer) (int, error) {
+		return h.stockService.ExportarMovimientos(c.Request.Context(), filter, w)
+	})
+}
+
+// exportar inicia la descarga y escribe las filas con escribir
+// Una vez iniciada la descarga ya no se puede responder un error: el archivo queda truncado y solo se registra
+func (h *StockHandler) exportar(c *gin.Context, formato, nombreArchivo, hoja string, escribir func(export.Writer) (int, error)) {
+	c.Header("Content-Type", export.ContentType(formato))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", nombreArchivo))
+	c.Status(http.StatusOK)
+
+	w, err := export.NewWriter(formato, c.Writer, hoja)
+	if err != nil {
+		h.logger.Error("Error iniciando exportación", zap.String("archivo", nombreArchivo), zap.Error(err))
+		c.Error(err)
+		return
+	}
+
+	filas, err := escribir(w)
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		h.logger.Error("Error exportando archivo",
+			zap.String("archivo", nombreArchivo),
+			zap.Int("filas_escritas", filas),
+			zap.Error(err))
+		c.Error(err)
+	}
+}
+
+// parseFormatoExportacion lee ?formato= (csv por defecto); responde 400 y retorna false si no es válido
+func parseFormatoExportacion(c *gin.Context) (string, bool) {
+	formato := strings.ToLower(c.DefaultQuery("formato", export.FormatoCSV))
+	if err := export.ValidarFormato(formato); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Formato inválido", err.Error()))
+		return "", false
+	}
+	return formato, true
+}
+
+// getMovimientosAgrupados responde la suma de cantidades y el conteo de movimientos por grupo
+// GET /movimientos?group_by=dia|producto|usuario|motivo (con los mismos filtros)
+func (h *StockHandler) getMovimientosAgrupados(c *gin.Context, filter *models.MovimientoFilter, agrupacion string) {
+	pagina, err := h.stockService.GetMovimientosAgrupados(c.Request.Context(), filter, agrupacion)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrAgrupacionInvalida) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(errorStatus(c, err, status), errorResponse(c, "❌ Error agrupando movimientos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Movimientos agrupados correctamente",
+		"data": gin.H{
+			"agrupacion": pagina.Agrupacion,
+			"grupos":     pagina.Grupos,
+			"limit":      pagina.Limit,
+			"offset":     pagina.Offset,
+			"filtros":    filter,
+		},
+	})
+}
+
+// parseMovimientosQuery lee los filtros de GET /movimientos (?local=&tipo=&fecha_desde=&fecha_hasta=
+// &documento_tipo=&documento_numero=&id_operacion=&bodega= y los de parseFiltroMovimientos)
+// Responde 400 y retorna false si un parámetro es inválido
+func parseMovimientosQuery(c *gin.Context) (*models.MovimientoFilter, bool) {
 	// Parsear parámetros de query
 	idLocalStr := c.Query("local")
 	tipoMovimiento := c.Query("tipo")
@@ -463,6 +882,30 @@ func (h *StockHandler) GetMovimientos(c *gin.Context) {
 		filter.TipoMovimiento = &tipoMovimiento
 	}
 
+	// Filtro por documento de respaldo (factura o guía del proveedor)
+	if documentoTipo := c.Query("documento_tipo"); documentoTipo != "" {
+		filter.DocumentoTipo = &documentoTipo
+	}
+	if documentoNumero := c.Query("documento_numero"); documentoNumero != "" {
+		filter.DocumentoNumero = &documentoNumero
+	}
+
+	// Movimientos de una misma operación (entrada, salida, venta o transferencia)
+	if idOperacion := c.Query("id_operacion"); idOperacion != "" {
+		filter.IDOperacion = &idOperacion
+	}
+
+	// Movimientos de una bodega del local (incluye los traslados internos desde o hacia ella)
+	idBodega, ok := queryIntOpcional(c, "bodega")
+	if !ok {
+		return nil, false
+	}
+	filter.IDBodega = idBodega
+
+	if !parseFiltroMovimientos(c, filter) {
+		return nil, false
+	}
+
 	// Parsear fechas
 	if fechaDesdeStr != "" {
 		if fechaDesde, err := time.Parse("2006-01-02", fechaDesdeStr); err == nil {
@@ -476,32 +919,34 @@ func (h *StockHandler) GetMovimientos(c *gin.Context) {
 		}
 	}
 
-	logger.Info("Obteniendo movimientos",
-		zap.Any("filtros", filter))
+	return filter, true
+}
 
-	movimientos, err := h.stockService.GetMovimientosByLocal(c.Request.Context(), filter)
-	if err != nil {
-		logger.Error("Error obteniendo movimientos", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error obteniendo movimientos",
-			"error":   err.Error(),
-		})
-		return
+// parseFiltroMovimientos lee los filtros de producto y la paginación (?producto=&tipo_item=&limit=&offset=)
+// Responde 400 y retorna false si un parámetro es inválido
+func parseFiltroMovimientos(c *gin.Context, filter *models.MovimientoFilter) bool {
+	if codigoProducto := c.Query("producto"); codigoProducto != "" {
+		filter.CodigoProducto = &codigoProducto
+	}
+	if tipoItem := c.Query("tipo_item"); tipoItem != "" {
+		filter.TipoItem = &tipoItem
 	}
 
-	logger.Info("Movimientos obtenidos exitosamente",
-		zap.Int("total_movimientos", len(movimientos)))
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "✅ Movimientos obtenidos correctamente",
-		"data": gin.H{
-			"movimientos": movimientos,
-			"total":       len(movimientos),
-			"filtros":     filter,
-		},
-	})
+	limit, ok := queryIntOpcional(c, "limit")
+	if !ok {
+		return false
+	}
+	offset, ok := queryIntOpcional(c, "offset")
+	if !ok {
+		return false
+	}
+	if limit != nil {
+		filter.Limit = *limit
+	}
+	if offset != nil {
+		filter.Offset = *offset
+	}
+	return true
 }
 
 // GetMovimientosByLocal obtiene movimientos de un local específico (con parámetro en URL)
@@ -513,11 +958,7 @@ func (h *StockHandler) GetMovimientosByLocal(c *gin.Context) {
 	idLocal, err := strconv.Atoi(idLocalStr)
 	if err != nil {
 		logger.Error("Error parsing local ID", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "❌ ID de local inválido",
-			"error":   "El ID debe ser un número válido",
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de local inválido", "El ID debe ser un número válido"))
 		return
 	}
 
@@ -534,6 +975,23 @@ func (h *StockHandler) GetMovimientosByLocal(c *gin.Context) {
 		filter.TipoMovimiento = &tipoMovimiento
 	}
 
+	// Filtro por documento de respaldo (factura o guía del proveedor)
+	if documentoTipo := c.Query("documento_tipo"); documentoTipo != "" {
+		filter.DocumentoTipo = &documentoTipo
+	}
+	if documentoNumero := c.Query("documento_numero"); documentoNumero != "" {
+		filter.DocumentoNumero = &documentoNumero
+	}
+
+	// Movimientos de una misma operación (entrada, salida, venta o transferencia)
+	if idOperacion := c.Query("id_operacion"); idOperacion != "" {
+		filter.IDOperacion = &idOperacion
+	}
+
+	if !parseFiltroMovimientos(c, filter) {
+		return
+	}
+
 	// Parsear fechas
 	if fechaDesdeStr != "" {
 		if fechaDesde, err := time.Parse("2006-01-02", fechaDesdeStr); err == nil {
@@ -551,29 +1009,455 @@ func (h *StockHandler) GetMovimientosByLocal(c *gin.Context) {
 		zap.Int("id_local", idLocal),
 		zap.Any("filtros", filter))
 
-	movimientos, err := h.stockService.GetMovimientosByLocal(c.Request.Context(), filter)
+	pagina, err := h.stockService.GetMovimientosPaginados(c.Request.Context(), filter)
 	if err != nil {
 		logger.Error("Error obteniendo movimientos por local", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "❌ Error obteniendo movimientos",
-			"error":   err.Error(),
-		})
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo movimientos", err.Error()))
 		return
 	}
 
 	logger.Info("Movimientos por local obtenidos exitosamente",
 		zap.Int("id_local", idLocal),
-		zap.Int("total_movimientos", len(movimientos)))
+		zap.Int("total_movimientos", pagina.Total),
+		zap.Int("movimientos_pagina", len(pagina.Movimientos)))
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "✅ Movimientos obtenidos correctamente",
 		"data": gin.H{
 			"id_local":    idLocal,
-			"movimientos": movimientos,
-			"total":       len(movimientos),
+			"movimientos": pagina.Movimientos,
+			"total":       pagina.Total,
+			"limit":       pagina.Limit,
+			"offset":      pagina.Offset,
 			"filtros":     filter,
 		},
 	})
 }
+
+// GetBodegas lista las bodegas de un local (sala de venta, trastienda...)
+func (h *StockHandler) GetBodegas(c *gin.Context) {
+	idLocal, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de local inválido", "El ID debe ser un número válido"))
+		return
+	}
+
+	bodegas, err := h.stockService.GetBodegas(c.Request.Context(), idLocal)
+	if err != nil {
+		c.JSON(errorStatus(c, err, bodegaErrorStatus(err)), errorResponse(c, "❌ Error obteniendo bodegas", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Bodegas obtenidas",
+		"data": gin.H{
+			"id_local": idLocal,
+			"bodegas":  bodegas,
+			"total":    len(bodegas),
+		},
+	})
+}
+
+// CrearBodega crea una bodega en un local
+func (h *StockHandler) CrearBodega(c *gin.Context) {
+	var req models.CrearBodegaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	bodega, err := h.stockService.CrearBodega(c.Request.Context(), &req)
+	if err != nil {
+		h.logError("Error creando bodega", zap.Int("id_local", req.IDLocal), zap.Error(err))
+		c.JSON(errorStatus(c, err, bodegaErrorStatus(err)), errorResponse(c, "❌ Error creando bodega", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Bodega creada",
+		"data":    bodega,
+	})
+}
+
+// ActualizarBodega renombra o activa/desactiva una bodega
+func (h *StockHandler) ActualizarBodega(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de bodega inválido", "El ID debe ser un número válido"))
+		return
+	}
+
+	var req models.ActualizarBodegaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	bodega, err := h.stockService.ActualizarBodega(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logError("Error actualizando bodega", zap.Int("id_bodega", id), zap.Error(err))
+		c.JSON(errorStatus(c, err, bodegaErrorStatus(err)), errorResponse(c, "❌ Error actualizando bodega", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Bodega actualizada",
+		"data":    bodega,
+	})
+}
+
+// GetStockPorBodega stock de un local desglosado por bodega (?producto=)
+func (h *StockHandler) GetStockPorBodega(c *gin.Context) {
+	idLocal, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de local inválido", "El ID debe ser un número válido"))
+		return
+	}
+
+	var codigoProducto *string
+	if producto := c.Query("producto"); producto != "" {
+		codigoProducto = &producto
+	}
+
+	stock, err := h.stockService.GetStockPorBodega(c.Request.Context(), idLocal, codigoProducto)
+	if err != nil {
+		c.JSON(errorStatus(c, err, bodegaErrorStatus(err)), errorResponse(c, "❌ Error obteniendo stock por bodega", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Stock por bodega obtenido",
+		"data": gin.H{
+			"id_local": idLocal,
+			"stock":    stock,
+			"total":    len(stock),
+		},
+	})
+}
+
+// TrasladoInterno mueve stock entre dos bodegas del mismo local (ej. trastienda → sala)
+func (h *StockHandler) TrasladoInterno(c *gin.Context) {
+	var req models.TrasladoInternoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	movimiento, err := h.stockService.TrasladoInterno(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, bodegaErrorStatus(err)), errorResponse(c, "❌ Error en traslado interno", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Traslado interno registrado",
+		"data":    movimiento,
+	})
+}
+
+// AjustarStock fija la cantidad de un producto en el local para cuadrar un inventario físico
+// POST /stock/ajuste
+func (h *StockHandler) AjustarStock(c *gin.Context) {
+	var req models.AjusteStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	// Ajustes grandes quedan pendientes de aprobación del supervisor
+	solicitud, err := h.approvalService.EvaluarAjuste(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, ajusteErrorStatus(err)), errorResponse(c, "❌ Error ajustando stock", err.Error()))
+		return
+	}
+	if solicitud != nil {
+		h.logInfo("Ajuste de stock pendiente de aprobación", zap.Int("id_solicitud", solicitud.ID))
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"message": "⏳ Operación pendiente de aprobación de un supervisor",
+			"data":    solicitud,
+		})
+		return
+	}
+
+	movimiento, err := h.stockService.AjustarStock(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, ajusteErrorStatus(err)), errorResponse(c, "❌ Error ajustando stock", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Ajuste de stock registrado",
+		"data":    movimiento,
+	})
+}
+
+// TransferirStock traspasa stock de un local a otro en una sola transacción
+func (h *StockHandler) TransferirStock(c *gin.Context) {
+	var req models.TransferenciaStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	// Transferencias grandes quedan pendientes de aprobación del supervisor
+	solicitud, err := h.approvalService.EvaluarTransferencia(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, transferenciaErrorStatus(err)), errorResponse(c, "❌ Error en transferencia de stock", err.Error()))
+		return
+	}
+	if solicitud != nil {
+		h.logInfo("Transferencia pendiente de aprobación", zap.Int("id_solicitud", solicitud.ID))
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"message": "⏳ Operación pendiente de aprobación de un supervisor",
+			"data":    solicitud,
+		})
+		return
+	}
+
+	transferencia, err := h.stockService.TransferirStock(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, transferenciaErrorStatus(err)), errorResponse(c, "❌ Error en transferencia de stock", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("✅ Transferencia #%d aplicada", transferencia.ID),
+		"data":    transferencia,
+	})
+}
+
+// GetTransferencias lista las transferencias entre locales
+func (h *StockHandler) GetTransferencias(c *gin.Context) {
+	filter := &models.TransferenciaFilter{}
+	if localStr := c.Query("local"); localStr != "" {
+		idLocal, err := strconv.Atoi(localStr)
+		if err != nil || idLocal <= 0 {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Parámetro local inválido", "local debe ser un número mayor a 0"))
+			return
+		}
+		filter.IDLocal = &idLocal
+	}
+	if estado := c.Query("estado"); estado != "" {
+		if estado != models.TransferenciaEstadoAplicada && estado != models.TransferenciaEstadoAnulada {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Parámetro estado inválido", "estado debe ser aplicada o anulada"))
+			return
+		}
+		filter.Estado = &estado
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Parámetro limit inválido", "limit debe ser un número mayor a 0"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	transferencias, err := h.stockService.GetTransferencias(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo transferencias", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Transferencias obtenidas exitosamente",
+		"data": gin.H{
+			"transferencias": transferencias,
+			"total":          len(transferencias),
+		},
+	})
+}
+
+// GetTransferencia obtiene una transferencia con sus ítems
+func (h *StockHandler) GetTransferencia(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de transferencia inválido", "id debe ser un número mayor a 0"))
+		return
+	}
+
+	transferencia, err := h.stockService.GetTransferencia(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, transferenciaErrorStatus(err)), errorResponse(c, "❌ Error obteniendo transferencia", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Transferencia obtenida exitosamente",
+		"data":    transferencia,
+	})
+}
+
+// AnularTransferencia revierte una transferencia aplicada
+func (h *StockHandler) AnularTransferencia(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de transferencia inválido", "id debe ser un número mayor a 0"))
+		return
+	}
+
+	var req models.AnularTransferenciaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	req.IDUsuario = idUsuarioActual(c)
+
+	transferencia, err := h.stockService.AnularTransferencia(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, transferenciaErrorStatus(err)), errorResponse(c, "❌ Error anulando transferencia", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("✅ Transferencia #%d anulada", transferencia.ID),
+		"data":    transferencia,
+	})
+}
+
+// DecodificarGS1 lee una etiqueta GS1-128 / DataMatrix para precargar producto, lote y vencimiento
+func (h *StockHandler) DecodificarGS1(c *gin.Context) {
+	var req models.DecodificarGS1Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	precarga, err := h.stockService.DecodificarGS1(c.Request.Context(), req.Codigo)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gs1.ErrCodigoInvalido) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(errorStatus(c, err, status), errorResponse(c, "❌ Error decodificando etiqueta GS1", err.Error()))
+		return
+	}
+
+	message := "✅ Etiqueta GS1 decodificada"
+	if precarga.GTIN != "" && precarga.Producto == nil {
+		message = "⚠️ Etiqueta GS1 decodificada, pero el GTIN no corresponde a ningún producto"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    precarga,
+	})
+}
+
+// entradaErrorStatus determina el código HTTP para un error de una entrada de stock
+func entradaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrDocumentoDuplicado):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrDocumentoInvalido), errors.Is(err, services.ErrUnidadSinConversion):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrOperacionBloqueada):
+		return http.StatusForbidden
+	default:
+		return bodegaErrorStatus(err)
+	}
+}
+
+// salidaErrorStatus determina el código HTTP para un error de una salida de stock
+func salidaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUnidadSinConversion):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrOperacionBloqueada):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrStockInsuficiente):
+		return http.StatusConflict
+	default:
+		return bodegaErrorStatus(err)
+	}
+}
+
+// transferenciaErrorStatus determina el código HTTP para un error de una transferencia entre locales
+func transferenciaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrTransferenciaNoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrTransferenciaAnulada):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrTransferenciaInvalida):
+		return http.StatusBadRequest
+	default:
+		return salidaErrorStatus(err)
+	}
+}
+
+// ajusteErrorStatus determina el código HTTP para un error de un ajuste de stock
+func ajusteErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrAjusteInvalido):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrAjusteSinDiferencia):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrOperacionBloqueada):
+		return http.StatusForbidden
+	default:
+		return bodegaErrorStatus(err)
+	}
+}
+
+// bodegaErrorStatus determina el código HTTP para un error de bodegas o traslados internos
+func bodegaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrBodegaNoEncontrada), errors.Is(err, services.ErrProductoNoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrBodegaInvalida):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrBodegaDuplicada), errors.Is(err, services.ErrStockBodegaInsuficiente):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SyncHandler entrega los cambios del catálogo y del stock a las réplicas edge
+type SyncHandler struct {
+	syncService services.SyncService
+	logger      *zap.Logger
+}
+
+// NewSyncHandler crea una nueva instancia del handler
+func NewSyncHandler(syncService services.SyncService, logger *zap.Logger) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+		logger:      logger,
+	}
+}
+
+// GetDelta retorna lo modificado desde el cursor de la réplica
+// GET /sync/delta?local=N&desde=<cursor RFC3339> (sin desde: catálogo y stock completos)
+func (h *SyncHandler) GetDelta(c *gin.Context) {
+	idLocal, err := strconv.Atoi(c.Query("local"))
+	if err != nil || idLocal <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Local inválido", "local debe ser un número válido"))
+		return
+	}
+
+	var desde *time.Time
+	if desdeStr := c.Query("desde"); desdeStr != "" {
+		t, err := time.Parse(time.RFC3339Nano, desdeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Cursor inválido", "desde debe tener formato RFC3339"))
+			return
+		}
+		desde = &t
+	}
+
+	delta, err := h.syncService.Delta(c.Request.Context(), idLocal, desde)
+	if err != nil {
+		h.logger.Error("Error generando delta de sincronización", zap.Int("id_local", idLocal), zap.Error(err))
+		c.JSON(errorStatus(c, err, syncErrorStatus(err)), errorResponse(c, "❌ Error obteniendo cambios", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Cambios obtenidos",
+		"data":    delta,
+	})
+}
+
+// syncErrorStatus mapea los errores de dominio de la sincronización a códigos HTTP
+func syncErrorStatus(err error) int {
+	if errors.Is(err, services.ErrLocalNoEncontrado) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
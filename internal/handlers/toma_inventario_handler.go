@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// TomaInventarioHandler maneja las peticiones HTTP de las tomas de inventario
+type TomaInventarioHandler struct {
+	tomaService services.TomaInventarioService
+	validator   *validator.Validate
+	logger      *zap.Logger
+}
+
+// NewTomaInventarioHandler crea una nueva instancia del handler
+func NewTomaInventarioHandler(tomaService services.TomaInventarioService, logger *zap.Logger) *TomaInventarioHandler {
+	return &TomaInventarioHandler{
+		tomaService: tomaService,
+		validator:   validator.New(),
+		logger:      logger,
+	}
+}
+
+// AbrirToma inicia la toma de inventario de un local
+func (h *TomaInventarioHandler) AbrirToma(c *gin.Context) {
+	var req models.TomaInventarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+	req.IDUsuario = idUsuarioActual(c)
+
+	toma, err := h.tomaService.AbrirToma(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, tomaErrorStatus(err)), errorResponse(c, "❌ Error abriendo toma de inventario", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Toma de inventario abierta",
+		"data":    toma,
+	})
+}
+
+// GetTomas lista las tomas de inventario (filtros: local, estado)
+func (h *TomaInventarioHandler) GetTomas(c *gin.Context) {
+	filter := &models.TomaInventarioFilter{Estado: c.Query("estado")}
+
+	var ok bool
+	if filter.IDLocal, ok = queryIntOpcional(c, "local"); !ok {
+		return
+	}
+	switch filter.Estado {
+	case "", models.TomaInventarioAbierta, models.TomaInventarioCerrando, models.TomaInventarioCerrada, models.TomaInventarioCancelada:
+	default:
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Estado inválido", "estado debe ser abierta, cerrando, cerrada o cancelada"))
+		return
+	}
+
+	tomas, err := h.tomaService.GetTomas(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo tomas de inventario", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Tomas de inventario obtenidas",
+		"data":    tomas,
+	})
+}
+
+// GetToma obtiene una toma con lo contado
+func (h *TomaInventarioHandler) GetToma(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	toma, err := h.tomaService.GetToma(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, tomaErrorStatus(err)), errorResponse(c, "❌ Error obteniendo toma de inventario", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Toma de inventario obtenida",
+		"data":    toma,
+	})
+}
+
+// RegistrarConteo carga la lectura de un código de barras (sin cantidad suma una unidad)
+func (h *TomaInventarioHandler) RegistrarConteo(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ConteoTomaItem
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	item, err := h.tomaService.RegistrarConteo(c.Request.Context(), id, &req, idUsuarioActual(c))
+	if err != nil {
+		c.JSON(errorStatus(c, err, tomaErrorStatus(err)), errorResponse(c, "❌ Error registrando conteo", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Conteo registrado",
+		"data":    item,
+	})
+}
+
+// RegistrarConteoMasivo carga un lote de lecturas; los códigos desconocidos se informan sin rechazar el lote
+func (h *TomaInventarioHandler) RegistrarConteoMasivo(c *gin.Context) {
+	logger := h.logger.With(zap.String("handler", "registrar_conteo_masivo_toma"))
+
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ConteoTomaMasivoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+	req.IDUsuario = idUsuarioActual(c)
+
+	resultado, err := h.tomaService.RegistrarConteoMasivo(c.Request.Context(), id, &req)
+	if err != nil {
+		logger.Error("Error registrando conteo masivo", zap.Int64("id_toma", id), zap.Error(err))
+		c.JSON(errorStatus(c, err, tomaErrorStatus(err)), errorResponse(c, "❌ Error registrando conteo masivo", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Conteo masivo registrado",
+		"data":    resultado,
+	})
+}
+
+// GetDiferencias reporte de lo contado contra el stock del sistema (?solo_diferencias=true)
+func (h *TomaInventarioHandler) GetDiferencias(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	reporte, err := h.tomaService.GetDiferencias(c.Request.Context(), id, c.Query("solo_diferencias") == "true")
+	if err != nil {
+		c.JSON(errorStatus(c, err, tomaErrorStatus(err)), errorResponse(c, "❌ Error obteniendo diferencias de la toma", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Reporte de diferencias generado",
+		"data":    reporte,
+	})
+}
+
+// CerrarToma aplica los ajustes de la toma y la cierra
+func (h *TomaInventarioHandler) CerrarToma(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	// El body es opcional: sin body solo se ajusta lo contado
+	var req models.CerrarTomaRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+			return
+		}
+	}
+	req.IDUsuario = idUsuarioActual(c)
+
+	cierre, err := h.tomaService.CerrarToma(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, tomaErrorStatus(err)), errorResponse(c, "❌ Error cerrando toma de inventario", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Toma de inventario cerrada",
+		"data":    cierre,
+	})
+}
+
+// CancelarToma descarta una toma abierta sin ajustar el stock
+func (h *TomaInventarioHandler) CancelarToma(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	toma, err := h.tomaService.CancelarToma(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, tomaErrorStatus(err)), errorResponse(c, "❌ Error cancelando toma de inventario", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Toma de inventario cancelada",
+		"data":    toma,
+	})
+}
+
+// parseID obtiene el ID de la toma de la URL
+func (h *TomaInventarioHandler) parseID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de toma inválido", "El ID debe ser un número válido"))
+		return 0, false
+	}
+	return id, true
+}
+
+// tomaErrorStatus mapea los errores de dominio de las tomas de inventario a códigos HTTP
+// Los errores de los ajustes del cierre se mapean como en POST /stock/ajuste
+func tomaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrTomaInventarioNoEncontrada),
+		errors.Is(err, services.ErrProductoNoEncontrado),
+		errors.Is(err, services.ErrLocalNoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrTomaInventarioAbierta),
+		errors.Is(err, services.ErrTomaInventarioEstadoInvalido):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrLocalInactivo):
+		return http.StatusBadRequest
+	default:
+		return ajusteErrorStatus(err)
+	}
+}
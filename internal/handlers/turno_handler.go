@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// TurnoHandler maneja los turnos de trabajo y las excepciones autorizadas por un supervisor
+type TurnoHandler struct {
+	turnoService services.TurnoService
+	validator    *validator.Validate
+	logger       *zap.Logger
+}
+
+// NewTurnoHandler crea una nueva instancia del handler
+func NewTurnoHandler(turnoService services.TurnoService, logger *zap.Logger) *TurnoHandler {
+	return &TurnoHandler{
+		turnoService: turnoService,
+		validator:    validator.New(),
+		logger:       logger,
+	}
+}
+
+// GetTurnos lista los turnos (?usuario=&local=; por local incluye los válidos en cualquier local)
+func (h *TurnoHandler) GetTurnos(c *gin.Context) {
+	filter := &models.TurnoFilter{}
+	var ok bool
+	if filter.IDUsuario, ok = queryIntOpcional(c, "usuario"); !ok {
+		return
+	}
+	if filter.IDLocal, ok = queryIntOpcional(c, "local"); !ok {
+		return
+	}
+
+	turnos, err := h.turnoService.GetTurnos(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo turnos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Turnos obtenidos",
+		"data": gin.H{
+			"turnos": turnos,
+			"total":  len(turnos),
+		},
+	})
+}
+
+// CrearTurno define un turno de un usuario
+func (h *TurnoHandler) CrearTurno(c *gin.Context) {
+	req, ok := h.bindTurno(c)
+	if !ok {
+		return
+	}
+
+	turno, err := h.turnoService.CrearTurno(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, turnoErrorStatus(err)), errorResponse(c, "❌ Error creando turno", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Turno creado",
+		"data":    turno,
+	})
+}
+
+// ActualizarTurno reemplaza un turno
+func (h *TurnoHandler) ActualizarTurno(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+	req, ok := h.bindTurno(c)
+	if !ok {
+		return
+	}
+
+	turno, err := h.turnoService.ActualizarTurno(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, turnoErrorStatus(err)), errorResponse(c, "❌ Error actualizando turno", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Turno actualizado",
+		"data":    turno,
+	})
+}
+
+// EliminarTurno elimina un turno
+func (h *TurnoHandler) EliminarTurno(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.turnoService.EliminarTurno(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(c, err, turnoErrorStatus(err)), errorResponse(c, "❌ Error eliminando turno", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Turno eliminado",
+	})
+}
+
+// GetExcepciones lista las excepciones de turno (?usuario=&vigentes=true)
+func (h *TurnoHandler) GetExcepciones(c *gin.Context) {
+	filter := &models.ExcepcionTurnoFilter{SoloVigentes: c.Query("vigentes") == "true"}
+	var ok bool
+	if filter.IDUsuario, ok = queryIntOpcional(c, "usuario"); !ok {
+		return
+	}
+
+	excepciones, err := h.turnoService.GetExcepciones(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo excepciones de turno", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Excepciones de turno obtenidas",
+		"data": gin.H{
+			"excepciones": excepciones,
+			"total":       len(excepciones),
+		},
+	})
+}
+
+// CrearExcepcion autoriza a un usuario a operar fuera de su turno (el supervisor es el usuario autenticado)
+func (h *TurnoHandler) CrearExcepcion(c *gin.Context) {
+	var req models.ExcepcionTurnoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+	req.IDSupervisor = idUsuarioActual(c)
+
+	excepcion, err := h.turnoService.CrearExcepcion(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, turnoErrorStatus(err)), errorResponse(c, "❌ Error autorizando excepción de turno", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Excepción de turno autorizada",
+		"data":    excepcion,
+	})
+}
+
+// RevocarExcepcion termina una excepción antes de su vencimiento
+func (h *TurnoHandler) RevocarExcepcion(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.turnoService.RevocarExcepcion(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(c, err, turnoErrorStatus(err)), errorResponse(c, "❌ Error revocando excepción de turno", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Excepción de turno revocada",
+	})
+}
+
+// bindTurno lee y valida el body de un turno
+func (h *TurnoHandler) bindTurno(c *gin.Context) (*models.TurnoRequest, bool) {
+	var req models.TurnoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return nil, false
+	}
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return nil, false
+	}
+	return &req, true
+}
+
+// parseID obtiene el ID del turno o de la excepción de la URL
+func (h *TurnoHandler) parseID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID inválido", "El ID debe ser un número válido"))
+		return 0, false
+	}
+	return id, true
+}
+
+// turnoErrorStatus mapea los errores de dominio de turnos a códigos HTTP
+func turnoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrTurnoInvalido),
+		errors.Is(err, services.ErrExcepcionTurnoInvalida),
+		errors.Is(err, services.ErrLocalInactivo):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrTurnoNoEncontrado),
+		errors.Is(err, services.ErrExcepcionTurnoNoEncontrada),
+		errors.Is(err, services.ErrUsuarioNoEncontrado),
+		errors.Is(err, services.ErrLocalNoEncontrado):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// UbicacionHandler maneja las peticiones HTTP de las ubicaciones físicas de los productos
+type UbicacionHandler struct {
+	ubicacionService services.UbicacionService
+	validator        *validator.Validate
+	logger           *zap.Logger
+}
+
+// NewUbicacionHandler crea una nueva instancia del handler
+func NewUbicacionHandler(ubicacionService services.UbicacionService, logger *zap.Logger) *UbicacionHandler {
+	return &UbicacionHandler{
+		ubicacionService: ubicacionService,
+		validator:        validator.New(),
+		logger:           logger,
+	}
+}
+
+// SetUbicacion asigna (o cambia) la ubicación de un producto en un local
+func (h *UbicacionHandler) SetUbicacion(c *gin.Context) {
+	var req models.UbicacionProductoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	ubicacion, err := h.ubicacionService.SetUbicacion(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Error asignando ubicación de producto", zap.Error(err))
+		c.JSON(errorStatus(c, err, ubicacionErrorStatus(err)), errorResponse(c, "❌ Error asignando ubicación", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Ubicación asignada",
+		"data":    ubicacion,
+	})
+}
+
+// DeleteUbicacion quita la ubicación de un producto en un local (?local=)
+func (h *UbicacionHandler) DeleteUbicacion(c *gin.Context) {
+	idLocal, err := strconv.Atoi(c.Query("local"))
+	if err != nil || idLocal <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Local inválido", "local debe ser un número válido"))
+		return
+	}
+
+	if err := h.ubicacionService.DeleteUbicacion(c.Request.Context(), c.Param("codigo"), idLocal); err != nil {
+		c.JSON(errorStatus(c, err, ubicacionErrorStatus(err)), errorResponse(c, "❌ Error quitando ubicación", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Ubicación eliminada",
+	})
+}
+
+// GetUbicaciones lista las ubicaciones de un local en orden de recorrido (?pasillo=)
+func (h *UbicacionHandler) GetUbicaciones(c *gin.Context) {
+	idLocal, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de local inválido", "El ID debe ser un número válido"))
+		return
+	}
+
+	filter := &models.UbicacionFilter{IDLocal: idLocal}
+	if pasillo := c.Query("pasillo"); pasillo != "" {
+		filter.Pasillo = &pasillo
+	}
+
+	ubicaciones, err := h.ubicacionService.GetUbicaciones(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo ubicaciones", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Ubicaciones obtenidas",
+		"data": gin.H{
+			"id_local":    idLocal,
+			"ubicaciones": ubicaciones,
+			"total":       len(ubicaciones),
+		},
+	})
+}
+
+// GetUbicacionesProducto lista dónde está un producto en cada local
+func (h *UbicacionHandler) GetUbicacionesProducto(c *gin.Context) {
+	codigoProducto := c.Param("codigo")
+
+	ubicaciones, err := h.ubicacionService.GetUbicacionesProducto(c.Request.Context(), codigoProducto)
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo ubicaciones del producto", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Ubicaciones del producto obtenidas",
+		"data": gin.H{
+			"codigo_producto": codigoProducto,
+			"ubicaciones":     ubicaciones,
+		},
+	})
+}
+
+// ubicacionErrorStatus mapea los errores de dominio de las ubicaciones a códigos HTTP
+func ubicacionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUbicacionNoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrLocalNoEncontrado),
+		errors.Is(err, services.ErrProductoNoEncontrado),
+		errors.Is(err, services.ErrUbicacionInvalida):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
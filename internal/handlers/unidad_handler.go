@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// UnidadHandler maneja el maestro de unidades y las conversiones por producto
+type UnidadHandler struct {
+	unidadService services.UnidadService
+	validator     *validator.Validate
+	logger        *zap.Logger
+}
+
+// NewUnidadHandler crea una nueva instancia del handler
+func NewUnidadHandler(unidadService services.UnidadService, logger *zap.Logger) *UnidadHandler {
+	return &UnidadHandler{
+		unidadService: unidadService,
+		validator:     validator.New(),
+		logger:        logger,
+	}
+}
+
+// GetUnidades lista el maestro de unidades
+func (h *UnidadHandler) GetUnidades(c *gin.Context) {
+	unidades, err := h.unidadService.GetUnidades(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo unidades", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Unidades obtenidas",
+		"data":    unidades,
+	})
+}
+
+// CrearUnidad agrega una unidad al maestro
+func (h *UnidadHandler) CrearUnidad(c *gin.Context) {
+	var req models.CrearUnidadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	unidad, err := h.unidadService.CrearUnidad(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, unidadErrorStatus(err)), errorResponse(c, "❌ Error creando unidad", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Unidad creada",
+		"data":    unidad,
+	})
+}
+
+// GetConversiones lista las conversiones de unidad de un producto
+func (h *UnidadHandler) GetConversiones(c *gin.Context) {
+	codigo := c.Param("codigo")
+
+	conversiones, err := h.unidadService.GetConversiones(c.Request.Context(), codigo)
+	if err != nil {
+		c.JSON(errorStatus(c, err, unidadErrorStatus(err)), errorResponse(c, "❌ Error obteniendo conversiones", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Conversiones obtenidas",
+		"data": gin.H{
+			"codigo_producto": codigo,
+			"conversiones":    conversiones,
+		},
+	})
+}
+
+// SetConversion define el factor de conversión de una unidad del producto (1 caja = 24 un)
+func (h *UnidadHandler) SetConversion(c *gin.Context) {
+	var req models.ConversionUnidadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	conversion, err := h.unidadService.SetConversion(c.Request.Context(), c.Param("codigo"), c.Param("unidad"), req.Factor)
+	if err != nil {
+		c.JSON(errorStatus(c, err, unidadErrorStatus(err)), errorResponse(c, "❌ Error definiendo conversión", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Conversión definida",
+		"data":    conversion,
+	})
+}
+
+// EliminarConversion elimina la conversión de una unidad del producto
+func (h *UnidadHandler) EliminarConversion(c *gin.Context) {
+	if err := h.unidadService.EliminarConversion(c.Request.Context(), c.Param("codigo"), c.Param("unidad")); err != nil {
+		c.JSON(errorStatus(c, err, unidadErrorStatus(err)), errorResponse(c, "❌ Error eliminando conversión", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Conversión eliminada",
+	})
+}
+
+// unidadErrorStatus mapea los errores de dominio de unidades a códigos HTTP
+func unidadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrProductoNoEncontrado),
+		errors.Is(err, services.ErrUnidadNoEncontrada),
+		errors.Is(err, services.ErrUnidadSinConversion):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrUnidadDuplicada):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
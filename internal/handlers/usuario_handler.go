@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"stock-service/internal/models"
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// UsuarioHandler maneja el CRUD de usuarios
+type UsuarioHandler struct {
+	usuarioService services.UsuarioService
+	validator      *validator.Validate
+	logger         *zap.Logger
+}
+
+// NewUsuarioHandler crea una nueva instancia del handler
+func NewUsuarioHandler(usuarioService services.UsuarioService, logger *zap.Logger) *UsuarioHandler {
+	return &UsuarioHandler{
+		usuarioService: usuarioService,
+		validator:      validator.New(),
+		logger:         logger,
+	}
+}
+
+// GetUsuarios lista los usuarios
+func (h *UsuarioHandler) GetUsuarios(c *gin.Context) {
+	usuarios, err := h.usuarioService.GetUsuarios(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo usuarios", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Usuarios obtenidos",
+		"data":    usuarios,
+	})
+}
+
+// GetUsuario obtiene un usuario por id
+func (h *UsuarioHandler) GetUsuario(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	usuario, err := h.usuarioService.GetUsuario(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(c, err, usuarioErrorStatus(err)), errorResponse(c, "❌ Error obteniendo usuario", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Usuario obtenido",
+		"data":    usuario,
+	})
+}
+
+// CrearUsuario crea un usuario
+func (h *UsuarioHandler) CrearUsuario(c *gin.Context) {
+	var req models.CrearUsuarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	usuario, err := h.usuarioService.CrearUsuario(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, usuarioErrorStatus(err)), errorResponse(c, "❌ Error creando usuario", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "✅ Usuario creado",
+		"data":    usuario,
+	})
+}
+
+// ActualizarUsuario actualiza email, rol o estado de un usuario
+func (h *UsuarioHandler) ActualizarUsuario(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ActualizarUsuarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	usuario, err := h.usuarioService.ActualizarUsuario(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(errorStatus(c, err, usuarioErrorStatus(err)), errorResponse(c, "❌ Error actualizando usuario", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Usuario actualizado",
+		"data":    usuario,
+	})
+}
+
+// CambiarPassword fija una nueva contraseña del usuario y cierra sus sesiones
+func (h *UsuarioHandler) CambiarPassword(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CambiarPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Error en el formato de datos", err.Error()))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Datos de entrada inválidos", err.Error()))
+		return
+	}
+
+	if err := h.usuarioService.CambiarPassword(c.Request.Context(), id, &req); err != nil {
+		c.JSON(errorStatus(c, err, usuarioErrorStatus(err)), errorResponse(c, "❌ Error cambiando contraseña", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Contraseña cambiada",
+	})
+}
+
+// parseID obtiene el ID del usuario de la URL
+func (h *UsuarioHandler) parseID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "❌ ID de usuario inválido", "El ID debe ser un número válido"))
+		return 0, false
+	}
+	return id, true
+}
+
+// usuarioErrorStatus mapea los errores de dominio de usuarios a códigos HTTP
+func usuarioErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUsuarioNoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrUsuarioDuplicado):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
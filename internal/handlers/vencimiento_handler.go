@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stock-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// VencimientoHandler maneja los avisos de lotes próximos a vencer y la baja de los vencidos
+type VencimientoHandler struct {
+	avisoService services.AvisoVencimientoService
+	bajaService  services.BajaVencidosService
+	logger       *zap.Logger
+}
+
+// NewVencimientoHandler crea una nueva instancia del handler
+func NewVencimientoHandler(avisoService services.AvisoVencimientoService, bajaService services.BajaVencidosService, logger *zap.Logger) *VencimientoHandler {
+	return &VencimientoHandler{
+		avisoService: avisoService,
+		bajaService:  bajaService,
+		logger:       logger,
+	}
+}
+
+// parseFechaLocal lee los filtros fecha (YYYY-MM-DD) y local de los reportes de vencimientos
+// Si son inválidos responde 400 y retorna ok=false
+func parseFechaLocal(c *gin.Context) (fecha *time.Time, idLocal *int, ok bool) {
+	if fechaStr := c.Query("fecha"); fechaStr != "" {
+		f, err := time.ParseInLocation("2006-01-02", fechaStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Fecha inválida", "fecha debe tener formato YYYY-MM-DD"))
+			return nil, nil, false
+		}
+		fecha = &f
+	}
+
+	if idLocalStr := c.Query("local"); idLocalStr != "" {
+		id, err := strconv.Atoi(idLocalStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ Local inválido", "local debe ser un número válido"))
+			return nil, nil, false
+		}
+		idLocal = &id
+	}
+	return fecha, idLocal, true
+}
+
+// GetReporteVencimientos retorna los avisos de vencimiento de un día por local y umbral
+// GET /reportes/vencimientos?fecha=YYYY-MM-DD&local= (sin fecha: la última generada)
+func (h *VencimientoHandler) GetReporteVencimientos(c *gin.Context) {
+	fecha, idLocal, ok := parseFechaLocal(c)
+	if !ok {
+		return
+	}
+
+	reporte, err := h.avisoService.GetReporte(c.Request.Context(), fecha, idLocal)
+	if err != nil {
+		h.logger.Error("Error obteniendo avisos de vencimiento", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo avisos de vencimiento", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Avisos de vencimiento obtenidos",
+		"data":    reporte,
+	})
+}
+
+// GenerarAvisosVencimiento genera en el momento los avisos del día (aunque el job ya haya
+// corrido); los lotes ya avisados en su umbral no se repiten
+// POST /reportes/vencimientos/generar
+func (h *VencimientoHandler) GenerarAvisosVencimiento(c *gin.Context) {
+	resultado, err := h.avisoService.GenerarAvisos(c.Request.Context(), true)
+	if err != nil {
+		h.logger.Error("Error generando avisos de vencimiento", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error generando avisos de vencimiento", err.Error()))
+		return
+	}
+
+	h.logger.Info("Avisos de vencimiento generados manualmente",
+		zap.String("operation", "avisos_vencimiento"),
+		zap.Int("avisos", resultado.Avisos),
+		zap.Int("notificados", resultado.Notificados))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("✅ %d avisos de vencimiento nuevos", resultado.Avisos),
+		"data":    resultado,
+	})
+}
+
+// GetReporteBajasVencidos retorna los lotes vencidos dados de baja en un día, por local
+// GET /reportes/vencimientos/bajas?fecha=YYYY-MM-DD&local= (sin fecha: la última ejecución)
+func (h *VencimientoHandler) GetReporteBajasVencidos(c *gin.Context) {
+	fecha, idLocal, ok := parseFechaLocal(c)
+	if !ok {
+		return
+	}
+
+	reporte, err := h.bajaService.GetReporte(c.Request.Context(), fecha, idLocal)
+	if err != nil {
+		h.logger.Error("Error obteniendo bajas de lotes vencidos", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error obteniendo bajas de lotes vencidos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "✅ Bajas de lotes vencidos obtenidas",
+		"data":    reporte,
+	})
+}
+
+// DarDeBajaVencidos ejecuta en el momento la baja de lotes vencidos (aunque el job ya haya
+// corrido); dry_run=true solo calcula lo que se daría de baja (por defecto el modo configurado)
+// POST /reportes/vencimientos/bajas/ejecutar?dry_run=
+func (h *VencimientoHandler) DarDeBajaVencidos(c *gin.Context) {
+	dryRun := h.bajaService.DryRun()
+	if dryRunStr := c.Query("dry_run"); dryRunStr != "" {
+		valor, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "❌ dry_run inválido", "dry_run debe ser true o false"))
+			return
+		}
+		dryRun = valor
+	}
+
+	resumen, err := h.bajaService.DarDeBaja(c.Request.Context(), dryRun, true)
+	if err != nil {
+		h.logger.Error("Error dando de baja lotes vencidos", zap.Error(err))
+		c.JSON(errorStatus(c, err, http.StatusInternalServerError), errorResponse(c, "❌ Error dando de baja lotes vencidos", err.Error()))
+		return
+	}
+
+	h.logger.Info("Baja de lotes vencidos ejecutada manualmente",
+		zap.String("operation", "baja_lotes_vencidos"),
+		zap.Bool("dry_run", resumen.DryRun),
+		zap.Int("lotes", resumen.Lotes),
+		zap.Int("fallidos", resumen.Fallidos))
+
+	message := fmt.Sprintf("✅ %d lotes vencidos dados de baja (%d unidades)", resumen.Lotes, resumen.Unidades)
+	if resumen.DryRun {
+		message = fmt.Sprintf("✅ Dry-run: se darían de baja %d lotes vencidos (%d unidades)", resumen.Lotes, resumen.Unidades)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    resumen,
+	})
+}
@@ -0,0 +1,150 @@
+// Package maintenance maneja el modo mantenimiento del servicio
+// El estado vive en Redis para que todas las réplicas lo compartan
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"stock-service/internal/config"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// stateKey clave de Redis con el estado compartido
+const stateKey = "maintenance:state"
+
+// State estado del modo mantenimiento
+type State struct {
+	Activo     bool       `json:"activo"`
+	Mensaje    string     `json:"mensaje,omitempty"`
+	RetryAfter int        `json:"retry_after_seconds,omitempty"`
+	Desde      *time.Time `json:"desde,omitempty"`
+	IDUsuario  int        `json:"id_usuario,omitempty"`
+}
+
+// Mode estado del modo mantenimiento con caché local
+// Cada réplica relee Redis a lo más una vez por CheckInterval, así el middleware
+// no agrega un round-trip a Redis en cada request
+type Mode struct {
+	redisClient *redis.Client
+	config      config.MaintenanceConfig
+	logger      *zap.Logger
+
+	mu        sync.RWMutex
+	state     State
+	checkedAt time.Time
+}
+
+// New crea el modo mantenimiento (inactivo hasta leer Redis)
+func New(redisClient *redis.Client, cfg config.MaintenanceConfig, logger *zap.Logger) *Mode {
+	return &Mode{
+		redisClient: redisClient,
+		config:      cfg,
+		logger:      logger,
+	}
+}
+
+// Current retorna el estado vigente, releyendo Redis si la copia local expiró
+// Si Redis no responde se mantiene el último estado conocido
+func (m *Mode) Current(ctx context.Context) State {
+	m.mu.RLock()
+	state, fresh := m.state, time.Since(m.checkedAt) < m.config.CheckInterval
+	m.mu.RUnlock()
+	if fresh {
+		return state
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Otra goroutine pudo refrescar mientras se esperaba el lock
+	if time.Since(m.checkedAt) < m.config.CheckInterval {
+		return m.state
+	}
+	m.checkedAt = time.Now()
+
+	loaded, err := m.load(ctx)
+	if err != nil {
+		m.logger.Warn("Error leyendo estado de mantenimiento, se mantiene el último conocido", zap.Error(err))
+		return m.state
+	}
+	m.state = loaded
+	return m.state
+}
+
+// Enable activa el modo mantenimiento en todas las réplicas
+// mensaje y retryAfter vacíos usan los valores configurados
+func (m *Mode) Enable(ctx context.Context, mensaje string, retryAfter time.Duration, idUsuario int) (State, error) {
+	if mensaje == "" {
+		mensaje = m.config.Message
+	}
+	if retryAfter <= 0 {
+		retryAfter = m.config.RetryAfter
+	}
+
+	now := time.Now()
+	state := State{
+		Activo:     true,
+		Mensaje:    mensaje,
+		RetryAfter: int(retryAfter / time.Second),
+		Desde:      &now,
+		IDUsuario:  idUsuario,
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return State{}, fmt.Errorf("failed to encode maintenance state: %w", err)
+	}
+	if err := m.redisClient.Set(ctx, stateKey, data, 0).Err(); err != nil {
+		return State{}, fmt.Errorf("failed to enable maintenance mode: %w", err)
+	}
+
+	m.store(state)
+	m.logger.Warn("Modo mantenimiento activado",
+		zap.String("mensaje", mensaje),
+		zap.Int("id_usuario", idUsuario))
+
+	return state, nil
+}
+
+// Disable desactiva el modo mantenimiento en todas las réplicas
+func (m *Mode) Disable(ctx context.Context, idUsuario int) error {
+	if err := m.redisClient.Del(ctx, stateKey).Err(); err != nil {
+		return fmt.Errorf("failed to disable maintenance mode: %w", err)
+	}
+
+	m.store(State{})
+	m.logger.Info("Modo mantenimiento desactivado", zap.Int("id_usuario", idUsuario))
+
+	return nil
+}
+
+// load lee el estado desde Redis; sin clave significa inactivo
+func (m *Mode) load(ctx context.Context) (State, error) {
+	data, err := m.redisClient.Get(ctx, stateKey).Bytes()
+	if err == redis.Nil {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("failed to decode maintenance state: %w", err)
+	}
+	return state, nil
+}
+
+// store actualiza la copia local (la réplica que hizo el cambio lo ve de inmediato)
+func (m *Mode) store(state State) {
+	m.mu.Lock()
+	m.state = state
+	m.checkedAt = time.Now()
+	m.mu.Unlock()
+}
@@ -0,0 +1,145 @@
+// Package metrics escribe métricas en el formato de texto de Prometheus (exposition format 0.0.4)
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentType content type de la respuesta de /metrics
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Tipos de métrica
+const (
+	TipoCounter   = "counter"
+	TipoGauge     = "gauge"
+	TipoHistogram = "histogram"
+)
+
+// LatencyBuckets buckets por defecto de los histogramas de latencia (segundos)
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Label etiqueta de una muestra
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Histogram acumula observaciones en buckets; no es seguro para uso concurrente (lo protege quien lo usa)
+type Histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// NewHistogram crea un histograma con los límites superiores indicados (ordenados de menor a mayor)
+func NewHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+// Observe registra un valor
+func (h *Histogram) Observe(v float64) {
+	// Los buckets se guardan sin acumular; se acumulan al escribir
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+// Encoder escribe familias de métricas; el primer error se conserva y se retorna en Flush
+type Encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewEncoder crea un encoder sobre w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Familia escribe los comentarios HELP y TYPE que preceden a las muestras de una métrica
+func (e *Encoder) Familia(nombre, tipo, ayuda string) {
+	e.write("# HELP " + nombre + " " + escaparAyuda(ayuda) + "\n")
+	e.write("# TYPE " + nombre + " " + tipo + "\n")
+}
+
+// Muestra escribe una muestra de counter o gauge
+func (e *Encoder) Muestra(nombre string, labels []Label, valor float64) {
+	e.write(nombre + formatLabels(labels) + " " + formatValor(valor) + "\n")
+}
+
+// Histograma escribe los buckets acumulados, la suma y el conteo de un histograma
+func (e *Encoder) Histograma(nombre string, labels []Label, h *Histogram) {
+	var acumulado uint64
+	for i, limite := range h.buckets {
+		acumulado += h.counts[i]
+		e.Muestra(nombre+"_bucket", append(labels[:len(labels):len(labels)], Label{"le", formatValor(limite)}), float64(acumulado))
+	}
+	e.Muestra(nombre+"_bucket", append(labels[:len(labels):len(labels)], Label{"le", "+Inf"}), float64(h.count))
+	e.Muestra(nombre+"_sum", labels, h.sum)
+	e.Muestra(nombre+"_count", labels, float64(h.count))
+}
+
+// Flush vacía el buffer y retorna el primer error de escritura
+func (e *Encoder) Flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+func (e *Encoder) write(s string) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.WriteString(s)
+}
+
+// formatLabels {a="x",b="y"} con los valores escapados ("" sin etiquetas)
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l.Name)
+		b.WriteString(`="`)
+		b.WriteString(escaparValor(l.Value))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatValor(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	escapeValor = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	escapeAyuda = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escaparValor(s string) string { return escapeValor.Replace(s) }
+func escaparAyuda(s string) string { return escapeAyuda.Replace(s) }
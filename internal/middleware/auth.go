@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"stock-service/internal/auth"
+	"stock-service/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Claves del contexto con el usuario autenticado por el JWT
+const (
+	UsuarioKey   = "usuario"    // *auth.Claims
+	IDUsuarioKey = "id_usuario" // int
+)
+
+// grupoTodos en JWT_PROTECTED_GROUPS protege todos los grupos de rutas
+const grupoTodos = "*"
+
+// Authenticator valida el token del header Authorization (Bearer) por grupo de rutas
+// Los grupos de JWT_PROTECTED_GROUPS (y usuarios) exigen token; en el resto es opcional, pero si
+// viene tiene que ser válido (un token vencido no se ignora en silencio)
+type Authenticator struct {
+	tokens     *auth.Tokens
+	protegidos map[string]bool
+	logger     *zap.Logger
+}
+
+// NewAuthenticator crea el autenticador con los grupos protegidos configurados
+// más los que exigen token siempre (config.GruposSiempreProtegidos)
+func NewAuthenticator(tokens *auth.Tokens, cfg config.JWTConfig, logger *zap.Logger) *Authenticator {
+	protegidos := make(map[string]bool, len(cfg.ProtectedGroups)+len(config.GruposSiempreProtegidos))
+	for _, grupo := range cfg.ProtectedGroups {
+		protegidos[grupo] = true
+	}
+	for _, grupo := range config.GruposSiempreProtegidos {
+		protegidos[grupo] = true
+	}
+	return &Authenticator{
+		tokens:     tokens,
+		protegidos: protegidos,
+		logger:     logger,
+	}
+}
+
+// Protegido indica si el grupo exige token
+func (a *Authenticator) Protegido(grupo string) bool {
+	return a.protegidos[grupoTodos] || a.protegidos[grupo]
+}
+
+// Grupo middleware de autenticación del grupo de rutas (ej. "stock", "pos", "admin")
+func (a *Authenticator) Grupo(grupo string) gin.HandlerFunc {
+	requerido := a.Protegido(grupo)
+
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			if requerido {
+				abortNoAutenticado(c, "Debe enviar un token en el header Authorization (Bearer)")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		claims, err := a.tokens.Validar(token)
+		if err != nil {
+			a.logger.Warn("Token rechazado",
+				zap.String("grupo", grupo),
+				zap.String("path", c.Request.URL.Path),
+				zap.Error(err))
+			mensaje := "Token inválido"
+			if errors.Is(err, auth.ErrTokenExpirado) {
+				mensaje = "Token expirado, vuelva a iniciar sesión"
+			}
+			abortNoAutenticado(c, mensaje)
+			return
+		}
+
+		// Validar ya comprobó que sub es un id
+		idUsuario, _ := claims.IDUsuario()
+		c.Set(UsuarioKey, claims)
+		c.Set(IDUsuarioKey, idUsuario)
+		c.Next()
+	}
+}
+
+// Roles limita la ruta a los roles indicados (va después de Grupo, que deja el usuario en el contexto)
+// Sin token no hay rol que revisar: el request se rechaza con 401 aunque el grupo no esté protegido
+func (a *Authenticator) Roles(roles ...string) gin.HandlerFunc {
+	permitidos := make(map[string]bool, len(roles))
+	for _, rol := range roles {
+		permitidos[rol] = true
+	}
+	lista := strings.Join(roles, ", ")
+
+	return func(c *gin.Context) {
+		claims, ok := UsuarioActual(c)
+		if !ok {
+			abortNoAutenticado(c, fmt.Sprintf("Debe enviar un token en el header Authorization (Bearer); la operación requiere: %s", lista))
+			return
+		}
+		if permitidos[claims.Rol] {
+			c.Next()
+			return
+		}
+
+		a.logger.Warn("Operación rechazada por rol",
+			zap.String("path", c.Request.URL.Path),
+			zap.String("method", c.Request.Method),
+			zap.String("sub", claims.Subject),
+			zap.String("rol", claims.Rol))
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"success":    false,
+			"message":    "❌ Sin permiso para esta operación",
+			"error":      fmt.Sprintf("el rol %q no puede realizar esta operación (requiere: %s)", claims.Rol, lista),
+			"request_id": c.GetString(RequestIDKey),
+		})
+	}
+}
+
+// UsuarioActual claims del usuario autenticado en el request (false si no vino token)
+func UsuarioActual(c *gin.Context) (*auth.Claims, bool) {
+	value, ok := c.Get(UsuarioKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*auth.Claims)
+	return claims, ok
+}
+
+// bearerToken extrae el token de "Authorization: Bearer <token>"
+func bearerToken(header string) (string, bool) {
+	const prefijo = "Bearer "
+	if len(header) <= len(prefijo) || !strings.EqualFold(header[:len(prefijo)], prefijo) {
+		return "", false
+	}
+	token := strings.TrimSpace(header[len(prefijo):])
+	return token, token != ""
+}
+
+// abortNoAutenticado responde 401 con el envelope de error
+func abortNoAutenticado(c *gin.Context, detalle string) {
+	c.Header("WWW-Authenticate", `Bearer realm="stock-service"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"success":    false,
+		"message":    "❌ No autenticado",
+		"error":      detalle,
+		"request_id": c.GetString(RequestIDKey),
+	})
+}
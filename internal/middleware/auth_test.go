@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"stock-service/internal/auth"
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwt := config.JWTConfig{Secret: "secreto-de-prueba", ExpiryHours: 1}
+	tokens := auth.NewTokens(jwt)
+	authenticator := NewAuthenticator(tokens, jwt, zap.NewNop())
+
+	firmar := func(rol string) string {
+		token, _, err := tokens.Firmar(1, "prueba", rol)
+		if err != nil {
+			t.Fatalf("firmando token: %v", err)
+		}
+		return "Bearer " + token
+	}
+
+	tests := []struct {
+		name          string
+		grupo         string
+		authorization string
+		want          int
+	}{
+		{"sin token en grupo no protegido", "stock", "", http.StatusUnauthorized},
+		{"sin token en grupo protegido", "usuarios", "", http.StatusUnauthorized},
+		{"token inválido", "stock", "Bearer no.es.valido", http.StatusUnauthorized},
+		{"rol no permitido", "stock", firmar(models.RolVendedor), http.StatusForbidden},
+		{"rol permitido", "stock", firmar(models.RolAdmin), http.StatusOK},
+		{"otro rol permitido", "stock", firmar(models.RolBodeguero), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/ruta",
+				authenticator.Grupo(tt.grupo),
+				authenticator.Roles(models.RolAdmin, models.RolBodeguero),
+				func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodPost, "/ruta", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body: %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
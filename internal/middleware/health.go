@@ -6,21 +6,31 @@ import (
 	"time"
 
 	"stock-service/internal/database"
+	"stock-service/internal/degraded"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// pendingSales cuenta las ventas encoladas en modo degradado pendientes de aplicar
+type pendingSales interface {
+	Pendientes(ctx context.Context) (int64, error)
+}
+
 type HealthChecker struct {
 	postgresDB *database.PostgresDB
 	redisDB    *database.RedisDB
+	degraded   *degraded.Monitor
+	queued     pendingSales
 	logger     *zap.Logger
 }
 
-func NewHealthChecker(postgresDB *database.PostgresDB, redisDB *database.RedisDB, logger *zap.Logger) *HealthChecker {
+func NewHealthChecker(postgresDB *database.PostgresDB, redisDB *database.RedisDB, degradedMonitor *degraded.Monitor, queued pendingSales, logger *zap.Logger) *HealthChecker {
 	return &HealthChecker{
 		postgresDB: postgresDB,
 		redisDB:    redisDB,
+		degraded:   degradedMonitor,
+		queued:     queued,
 		logger:     logger,
 	}
 }
@@ -75,6 +85,24 @@ func (h *HealthChecker) HealthCheck(c *gin.Context) {
 		"stats":  redisStats,
 	}
 
+	// Modo degradado: el POS sigue operando con la cache y encolando ventas, así que la
+	// réplica responde 200 con estado "degraded" para que el balanceador no la saque
+	degradedState := h.degraded.Current()
+	modoDegradado := gin.H{"activo": degradedState.Activo}
+	if degradedState.Activo {
+		modoDegradado["desde"] = degradedState.Desde
+		modoDegradado["ultimo_error"] = degradedState.UltimoError
+		if redisStatus == "healthy" {
+			status["status"] = "degraded"
+		}
+	}
+	if h.queued != nil {
+		if pendientes, err := h.queued.Pendientes(ctx); err == nil {
+			modoDegradado["ventas_encoladas"] = pendientes
+		}
+	}
+	status["modo_degradado"] = modoDegradado
+
 	// Determinar código de respuesta HTTP
 	httpStatus := http.StatusOK
 	if status["status"] == "unhealthy" {
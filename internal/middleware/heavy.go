@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HeavyLimiter cupo de operaciones pesadas (reportes, exportaciones) ejecutándose a la vez
+// Acota cuántas conexiones del pool pueden ocupar, para que las ventas del POS nunca esperen
+// detrás de un reporte
+type HeavyLimiter struct {
+	slots   chan struct{}
+	maxWait time.Duration
+	logger  *zap.Logger
+}
+
+// NewHeavyLimiter crea el limitador con maxConcurrent cupos; un request espera a lo más maxWait
+func NewHeavyLimiter(maxConcurrent int, maxWait time.Duration, logger *zap.Logger) *HeavyLimiter {
+	return &HeavyLimiter{
+		slots:   make(chan struct{}, maxConcurrent),
+		maxWait: maxWait,
+		logger:  logger,
+	}
+}
+
+// Wrap ejecuta next (normalmente el deadline del reporte) solo con un cupo tomado
+// Si no se libera un cupo en maxWait (o el cliente se va) responde 503 con Retry-After
+func (l *HeavyLimiter) Wrap(next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timer := time.NewTimer(l.maxWait)
+		defer timer.Stop()
+
+		select {
+		case l.slots <- struct{}{}:
+		case <-timer.C:
+			l.reject(c)
+			return
+		case <-c.Request.Context().Done():
+			l.reject(c)
+			return
+		}
+		defer func() { <-l.slots }()
+
+		next(c)
+	}
+}
+
+func (l *HeavyLimiter) reject(c *gin.Context) {
+	l.logger.Warn("Operación pesada rechazada: sin cupo disponible",
+		zap.String("operation", "heavy_limiter"),
+		zap.String("path", c.FullPath()),
+		zap.Int("max_concurrent", cap(l.slots)),
+		zap.Duration("max_wait", l.maxWait))
+
+	c.Header("Retry-After", strconv.Itoa(int(l.maxWait/time.Second)+1))
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+		"success":    false,
+		"message":    "❌ Demasiadas operaciones pesadas en curso",
+		"error":      "Se alcanzó el máximo de " + strconv.Itoa(cap(l.slots)) + " reportes/exportaciones simultáneos, reintente en unos segundos",
+		"request_id": c.GetString(RequestIDKey),
+	})
+}
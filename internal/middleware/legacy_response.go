@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LegacyResponseKey clave del contexto que indica que el cliente pidió el formato legado
+const LegacyResponseKey = "legacy_response"
+
+// LegacyResponseHeader header con que las cajas antiguas piden el formato legado (X-Response-Format: legacy)
+// También se acepta el query param ?formato=legado para clientes que no pueden enviar headers
+const LegacyResponseHeader = "X-Response-Format"
+
+// LegacyResponseMiddleware modo de compatibilidad para las cajas que esperan el envelope actual
+// {"success","message","data","error"} con emojis mientras se migran al envelope estándar:
+//
+//	{"data": ..., "mensaje": "...", "error": {"codigo": "...", "mensaje": "...", "detalle": "..."}, "request_id": "..."}
+//
+// Con el modo pedido, las respuestas JSON con el envelope estándar se reescriben al legado;
+// las que ya vienen en el formato legado (o no son JSON) salen tal cual
+// Va después de ResponseSigningMiddleware para que la firma cubra el cuerpo que recibe la caja
+func LegacyResponseMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", LegacyResponseHeader)
+		if !pideFormatoLegado(c) {
+			c.Next()
+			return
+		}
+		c.Set(LegacyResponseKey, true)
+
+		// signingWriter retiene el cuerpo igual que para firmarlo
+		writer := &signingWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		body := writer.body.Bytes()
+		if strings.HasPrefix(writer.ResponseWriter.Header().Get("Content-Type"), "application/json") {
+			if legado, ok := aFormatoLegado(body); ok {
+				body = legado
+				writer.ResponseWriter.Header().Del("Content-Length")
+			}
+		}
+
+		writer.ResponseWriter.WriteHeader(writer.status)
+		writer.ResponseWriter.WriteHeaderNow()
+		if len(body) > 0 {
+			_, _ = writer.ResponseWriter.Write(body)
+		}
+	}
+}
+
+// pideFormatoLegado indica si el request pidió el modo de compatibilidad
+func pideFormatoLegado(c *gin.Context) bool {
+	if strings.EqualFold(c.GetHeader(LegacyResponseHeader), "legacy") {
+		return true
+	}
+	return strings.EqualFold(c.Query("formato"), "legado")
+}
+
+// envelopeEstandar envelope estándar de las respuestas
+type envelopeEstandar struct {
+	Data      json.RawMessage `json:"data,omitempty"`
+	Mensaje   string          `json:"mensaje"`
+	Error     *errorEstandar  `json:"error,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+// errorEstandar error del envelope estándar
+type errorEstandar struct {
+	Codigo  string `json:"codigo"`
+	Mensaje string `json:"mensaje"`
+	Detalle string `json:"detalle,omitempty"`
+}
+
+// aFormatoLegado reescribe un envelope estándar al formato legado
+// Retorna false si el cuerpo no es un envelope estándar (ej. ya viene en formato legado)
+func aFormatoLegado(body []byte) ([]byte, bool) {
+	var campos map[string]json.RawMessage
+	if err := json.Unmarshal(body, &campos); err != nil {
+		return nil, false
+	}
+	if _, legado := campos["success"]; legado {
+		return nil, false
+	}
+	if _, ok := campos["mensaje"]; !ok {
+		return nil, false
+	}
+
+	var estandar envelopeEstandar
+	if err := json.Unmarshal(body, &estandar); err != nil {
+		return nil, false
+	}
+
+	respuesta := gin.H{}
+	if estandar.Error == nil {
+		respuesta["success"] = true
+		respuesta["message"] = "✅ " + estandar.Mensaje
+		if estandar.Data != nil {
+			respuesta["data"] = estandar.Data
+		}
+	} else {
+		respuesta["success"] = false
+		respuesta["message"] = "❌ " + estandar.Mensaje
+		respuesta["error"] = estandar.Error.Detalle
+		if estandar.Error.Detalle == "" {
+			respuesta["error"] = estandar.Error.Mensaje
+		}
+		respuesta["codigo"] = estandar.Error.Codigo
+		if estandar.RequestID != "" {
+			respuesta["request_id"] = estandar.RequestID
+		}
+	}
+
+	legado, err := json.Marshal(respuesta)
+	if err != nil {
+		return nil, false
+	}
+	return legado, true
+}
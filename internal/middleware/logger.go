@@ -2,7 +2,9 @@ package middleware
 
 import (
 	"fmt"
-	"time"
+	"strings"
+
+	"stock-service/internal/uuid"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -32,7 +34,7 @@ func LoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 		)
 
 		// Log estructurado para debugging
-		logger.Info("HTTP Request",
+		fields := []zap.Field{
 			zap.String("method", param.Method),
 			zap.String("path", param.Path),
 			zap.String("client_ip", param.ClientIP),
@@ -40,21 +42,38 @@ func LoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 			zap.Int("status_code", param.StatusCode),
 			zap.Duration("latency", param.Latency),
 			zap.Time("timestamp", param.TimeStamp),
-		)
+		}
+		if id, ok := param.Keys[RequestIDKey].(string); ok {
+			fields = append(fields, zap.String("request_id", id))
+		}
+
+		// En producción la respuesta no lleva el detalle de los errores internos: queda acá,
+		// asociado al request_id que recibió el cliente
+		logFn := logger.Info
+		if param.ErrorMessage != "" {
+			fields = append(fields, zap.String("error", strings.TrimSpace(param.ErrorMessage)))
+			if param.StatusCode >= 500 {
+				logFn = logger.Error
+			}
+		}
+		logFn("HTTP Request", fields...)
 
 		return logLine
 	})
 }
 
+// RequestIDKey clave del contexto de Gin donde se guarda el ID del request
+const RequestIDKey = "request_id"
+
 // RequestIDMiddleware agrega un ID único a cada request para tracking
 func RequestIDMiddleware() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
 		requestID := c.GetHeader("X-Request-ID")
 		if requestID == "" {
-			requestID = generateRequestID()
+			requestID = uuid.New()
 		}
 		c.Header("X-Request-ID", requestID)
-		c.Set("request_id", requestID)
+		c.Set(RequestIDKey, requestID)
 		c.Next()
 	})
 }
@@ -90,16 +109,3 @@ func getMethodColor(method string) string {
 		return whiteColor
 	}
 }
-
-func generateRequestID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(8)
-}
-
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
-	}
-	return string(b)
-}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"stock-service/internal/maintenance"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maintenanceExemptPrefix rutas que siguen aceptando escrituras en mantenimiento
+// (entre ellas la que lo desactiva)
+const maintenanceExemptPrefix = "/api/v1/admin/"
+
+// MaintenanceMiddleware rechaza las escrituras con 503 mientras el modo mantenimiento está activo
+// Las lecturas (GET/HEAD/OPTIONS), /health y la administración siguen funcionando
+func MaintenanceMiddleware(mode *maintenance.Mode) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if strings.HasPrefix(c.Request.URL.Path, maintenanceExemptPrefix) {
+			c.Next()
+			return
+		}
+
+		state := mode.Current(c.Request.Context())
+		if !state.Activo {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", strconv.Itoa(state.RetryAfter))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"success":    false,
+			"message":    "❌ Servicio en mantenimiento",
+			"error":      state.Mensaje,
+			"request_id": c.GetString(RequestIDKey),
+			"data": gin.H{
+				"retry_after_seconds": state.RetryAfter,
+				"desde":               state.Desde,
+			},
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stock-service/internal/quota"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// APIKeyHeader header con la API key de las integraciones externas
+const APIKeyHeader = "X-API-Key"
+
+// QuotaMiddleware aplica la cuota de la API key del request y expone los headers X-RateLimit-*
+// Los requests sin API key no se limitan; una API key desconocida responde 401
+// Si Redis no responde el request se deja pasar (no se bloquea el POS por el contador)
+func QuotaMiddleware(limiter *quota.Limiter, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader(APIKeyHeader)
+		if apiKey == "" {
+			c.Next()
+			return
+		}
+
+		result, err := limiter.Take(c.Request.Context(), apiKey)
+		if errors.Is(err, quota.ErrUnknownKey) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success":    false,
+				"message":    "❌ API key inválida",
+				"error":      err.Error(),
+				"request_id": c.GetString(RequestIDKey),
+			})
+			return
+		}
+		if err != nil {
+			logger.Warn("Error contando cuota de API key, se permite el request", zap.Error(err))
+			c.Next()
+			return
+		}
+
+		binding := result.Binding()
+		if binding.Limit > 0 {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(binding.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.FormatInt(binding.Remaining, 10))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(binding.Reset.Unix(), 10))
+		}
+		setWindowHeaders(c, "Minute", result.Minute)
+		setWindowHeaders(c, "Day", result.Day)
+
+		if !result.Allowed() {
+			retryAfter := int(time.Until(binding.Reset).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+			logger.Warn("Cuota de API key excedida",
+				zap.String("api_key", result.Name),
+				zap.Int64("usados_minuto", result.Minute.Used),
+				zap.Int64("usados_dia", result.Day.Used))
+
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"success":    false,
+				"message":    "❌ Cuota de requests excedida",
+				"error":      "La API key " + result.Name + " superó su cuota, reintente en " + strconv.Itoa(retryAfter) + "s",
+				"request_id": c.GetString(RequestIDKey),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAPIKeyMiddleware exige una API key válida en endpoints expuestos a integraciones externas
+// (la cuota la sigue aplicando QuotaMiddleware). Si names no está vacío solo esas integraciones
+// tienen acceso
+func RequireAPIKeyMiddleware(limiter *quota.Limiter, names []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(names))
+	for _, name := range names {
+		allowed[name] = true
+	}
+
+	return func(c *gin.Context) {
+		name, ok := limiter.Name(c.GetHeader(APIKeyHeader))
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success":    false,
+				"message":    "❌ API key requerida",
+				"error":      "Debe enviar una API key válida en el header " + APIKeyHeader,
+				"request_id": c.GetString(RequestIDKey),
+			})
+			return
+		}
+		if len(allowed) > 0 && !allowed[name] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success":    false,
+				"message":    "❌ API key sin acceso",
+				"error":      "La API key " + name + " no tiene acceso a este recurso",
+				"request_id": c.GetString(RequestIDKey),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// setWindowHeaders agrega los headers de una ventana (X-RateLimit-Limit-Minute, etc.)
+func setWindowHeaders(c *gin.Context, suffix string, w quota.Window) {
+	if w.Limit <= 0 {
+		return
+	}
+	c.Header("X-RateLimit-Limit-"+suffix, strconv.Itoa(w.Limit))
+	c.Header("X-RateLimit-Remaining-"+suffix, strconv.FormatInt(w.Remaining, 10))
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"stock-service/internal/signing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseSigningMiddleware firma el cuerpo de las respuestas bajo los prefijos configurados
+// La respuesta se retiene en memoria hasta que el handler termina: la firma va en headers
+// (X-Signature, X-Signature-Key-Id, X-Signature-Alg, X-Signature-Timestamp) antes del cuerpo
+// Sin clave activa la respuesta sale sin firma
+func ResponseSigningMiddleware(signer *signing.Signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !signer.Enabled() || !firmaRuta(signer.PathPrefixes(), c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		writer := &signingWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		body := writer.body.Bytes()
+		if firma := signer.Firmar(c.Request.Context(), body); firma != nil {
+			header := writer.ResponseWriter.Header()
+			header.Set("X-Signature", firma.Valor)
+			header.Set("X-Signature-Key-Id", firma.KID)
+			header.Set("X-Signature-Alg", firma.Algoritmo)
+			header.Set("X-Signature-Timestamp", firma.Timestamp)
+		}
+
+		writer.ResponseWriter.WriteHeader(writer.status)
+		writer.ResponseWriter.WriteHeaderNow()
+		if len(body) > 0 {
+			_, _ = writer.ResponseWriter.Write(body)
+		}
+	}
+}
+
+// firmaRuta indica si la ruta cae bajo alguno de los prefijos firmados
+func firmaRuta(prefixes []string, path string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// signingWriter retiene el status y el cuerpo de la respuesta para firmarlos al final
+type signingWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	status  int
+	written bool
+}
+
+func (w *signingWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *signingWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *signingWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *signingWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *signingWriter) Status() int {
+	return w.status
+}
+
+func (w *signingWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *signingWriter) Written() bool {
+	return w.written
+}
+
+// Flush no envía nada: el cuerpo completo se necesita para firmarlo
+func (w *signingWriter) Flush() {}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutMiddleware aplica un deadline al contexto del request
+// El deadline se hace cumplir solo por el contexto: el handler no se interrumpe, son las consultas a
+// BD, Redis y HTTP con c.Request.Context() las que fallan al vencer. El middleware espera a que el
+// handler retorne y responde 504 solo si no escribió nada (nunca escribe una segunda respuesta);
+// un handler que ignora el contexto sigue corriendo y responde tarde con su propio resultado
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		// Si el handler ya respondió (incluso su propio error por el deadline) esa es la respuesta
+		if !c.Writer.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{
+				"success":    false,
+				"message":    "❌ Tiempo de espera agotado",
+				"error":      "La operación excedió el tiempo máximo de " + timeout.String(),
+				"request_id": c.GetString(RequestIDKey),
+			})
+		}
+	}
+}
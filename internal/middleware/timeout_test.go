@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		want    int
+	}{
+		{"responde antes del deadline", func(c *gin.Context) { c.Status(http.StatusOK) }, http.StatusOK},
+		{"retorna sin responder al vencer el contexto", func(c *gin.Context) {
+			<-c.Request.Context().Done()
+		}, http.StatusGatewayTimeout},
+		{"responde después del deadline sin revisar el contexto", func(c *gin.Context) {
+			time.Sleep(30 * time.Millisecond)
+			c.JSON(http.StatusCreated, gin.H{"success": true})
+		}, http.StatusCreated},
+		{"responde su propio error por el deadline", func(c *gin.Context) {
+			<-c.Request.Context().Done()
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		}, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/ruta", TimeoutMiddleware(10*time.Millisecond), tt.handler)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ruta", nil))
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			// Una sola respuesta: el 504 nunca se agrega a lo que ya escribió el handler
+			if tt.want != http.StatusGatewayTimeout && strings.Contains(w.Body.String(), "Tiempo de espera agotado") {
+				t.Errorf("body = %s, want solo la respuesta del handler", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"stock-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ExcepcionTurnoKey clave del contexto con el id de la excepción que autorizó una operación fuera de turno
+const ExcepcionTurnoKey = "id_excepcion_turno"
+
+// maxBodyTurno bytes del body que se leen para buscar el local de la operación
+const maxBodyTurno = 1 << 20
+
+// VerificadorTurnos decide si el usuario puede operar ahora en el local (services.TurnoService)
+type VerificadorTurnos interface {
+	VerificarTurno(ctx context.Context, idUsuario int, rol string, idLocal *int, instante time.Time) (*models.VerificacionTurno, error)
+}
+
+// TurnosMiddleware rechaza con 403 las escrituras de los usuarios fuera de su turno
+// El local se toma del body JSON (id_local o id_local_origen); sin local vale cualquier turno del usuario
+// Las lecturas y los requests sin usuario autenticado pasan; si la verificación falla el request
+// se deja pasar (no se bloquea la operación por no poder leer los turnos)
+func TurnosMiddleware(verificador VerificadorTurnos, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		claims, ok := UsuarioActual(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		idUsuario, err := claims.IDUsuario()
+		if err != nil {
+			c.Next()
+			return
+		}
+		idLocal := idLocalDelBody(c)
+
+		verificacion, err := verificador.VerificarTurno(c.Request.Context(), idUsuario, claims.Rol, idLocal, time.Now())
+		if err != nil {
+			logger.Warn("Error verificando turno, se permite el request",
+				zap.Int("id_usuario", idUsuario),
+				zap.String("path", c.Request.URL.Path),
+				zap.Error(err))
+			c.Next()
+			return
+		}
+
+		if verificacion.Permitido {
+			if verificacion.Excepcion != nil {
+				c.Set(ExcepcionTurnoKey, verificacion.Excepcion.ID)
+				logger.Info("Operación fuera de turno autorizada por excepción",
+					zap.Int("id_usuario", idUsuario),
+					zap.Int("id_excepcion", verificacion.Excepcion.ID),
+					zap.Int("id_supervisor", verificacion.Excepcion.IDSupervisor),
+					zap.String("method", c.Request.Method),
+					zap.String("path", c.Request.URL.Path))
+			}
+			c.Next()
+			return
+		}
+
+		logger.Warn("Operación rechazada fuera de turno",
+			zap.Int("id_usuario", idUsuario),
+			zap.String("rol", claims.Rol),
+			zap.Intp("id_local", idLocal),
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path))
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"success":    false,
+			"message":    "❌ Fuera de turno",
+			"error":      "el usuario no está en su turno; un supervisor puede autorizar una excepción",
+			"request_id": c.GetString(RequestIDKey),
+			"data": gin.H{
+				"turnos": verificacion.Turnos,
+			},
+		})
+	}
+}
+
+// idLocalDelBody lee id_local (o id_local_origen) del body JSON sin consumirlo; nil si no viene
+func idLocalDelBody(c *gin.Context) *int {
+	if c.Request.Body == nil || !strings.HasPrefix(c.ContentType(), gin.MIMEJSON) {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBodyTurno))
+	// El handler vuelve a leer el body completo: lo leído y lo que quedó sin leer
+	c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+	if err != nil {
+		return nil
+	}
+
+	var campos struct {
+		IDLocal       *int `json:"id_local"`
+		IDLocalOrigen *int `json:"id_local_origen"`
+	}
+	if err := json.Unmarshal(body, &campos); err != nil {
+		return nil
+	}
+	if campos.IDLocal != nil {
+		return campos.IDLocal
+	}
+	return campos.IDLocalOrigen
+}
+
+// readCloser lee de r y cierra el body original
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
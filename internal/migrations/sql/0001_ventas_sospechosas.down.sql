@@ -0,0 +1 @@
+DROP TABLE IF EXISTS ventas_sospechosas_cantera;
@@ -0,0 +1,20 @@
+-- Registro de ventas rápidas sospechosas de estar duplicadas
+-- Una venta es sospechosa si otra idéntica (mismos ítems, local y monto) ocurrió
+-- dentro de la ventana configurada en DUPLICATE_SALE_WINDOW_SECONDS
+
+CREATE TABLE IF NOT EXISTS ventas_sospechosas_cantera (
+    id SERIAL PRIMARY KEY,
+    fingerprint VARCHAR(64) NOT NULL,
+    id_local INTEGER NOT NULL,
+    id_usuario INTEGER NOT NULL,
+    items JSONB NOT NULL,
+    monto NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    bloqueada BOOLEAN NOT NULL DEFAULT false,
+    revisada BOOLEAN NOT NULL DEFAULT false,
+    revisada_por INTEGER,
+    revisada_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ventas_sospechosas_local_pendientes
+    ON ventas_sospechosas_cantera (id_local, revisada, created_at DESC);
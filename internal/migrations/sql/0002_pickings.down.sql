@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS picking_items_cantera;
+DROP TABLE IF EXISTS pickings_cantera;
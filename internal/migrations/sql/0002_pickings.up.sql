@@ -0,0 +1,32 @@
+-- Picking en dos pasos para salidas grandes
+-- La preparación reserva los ítems (estado 'preparado') hasta su confirmación,
+-- cancelación o expiración (PICKING_TTL_MINUTES)
+
+CREATE TABLE IF NOT EXISTS pickings_cantera (
+    id SERIAL PRIMARY KEY,
+    id_local INTEGER NOT NULL,
+    id_local_destino INTEGER,
+    estado VARCHAR(20) NOT NULL DEFAULT 'preparado',
+    motivo VARCHAR(255) NOT NULL,
+    observaciones TEXT NOT NULL DEFAULT '',
+    id_usuario INTEGER NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    confirmed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS picking_items_cantera (
+    id SERIAL PRIMARY KEY,
+    id_picking INTEGER NOT NULL REFERENCES pickings_cantera (id) ON DELETE CASCADE,
+    codigo_producto VARCHAR(50) NOT NULL,
+    tipo_item VARCHAR(20) NOT NULL,
+    cantidad_solicitada INTEGER NOT NULL,
+    cantidad_pickeada INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_pickings_estado_expires
+    ON pickings_cantera (estado, expires_at);
+
+CREATE INDEX IF NOT EXISTS idx_picking_items_producto
+    ON picking_items_cantera (codigo_producto, id_picking);
@@ -0,0 +1,25 @@
+-- Solicitudes de aprobación para operaciones de stock grandes
+-- Las operaciones múltiples que superan APPROVAL_CANTIDAD_THRESHOLD o APPROVAL_MONTO_THRESHOLD
+-- quedan 'pendiente' hasta que un supervisor las apruebe o rechace
+
+CREATE TABLE IF NOT EXISTS solicitudes_aprobacion_cantera (
+    id SERIAL PRIMARY KEY,
+    tipo_operacion VARCHAR(30) NOT NULL,
+    id_local INTEGER NOT NULL,
+    payload JSONB NOT NULL,
+    cantidad_total INTEGER NOT NULL,
+    monto_total NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    estado VARCHAR(20) NOT NULL DEFAULT 'pendiente',
+    id_solicitante INTEGER NOT NULL,
+    id_supervisor INTEGER,
+    motivo_rechazo TEXT,
+    resultado JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    resuelta_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_solicitudes_aprobacion_estado
+    ON solicitudes_aprobacion_cantera (estado, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_solicitudes_aprobacion_solicitante
+    ON solicitudes_aprobacion_cantera (id_solicitante, created_at DESC);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trigger_historial_precios ON lista_precios_cantera;
+DROP FUNCTION IF EXISTS registrar_historial_precios();
+DROP TABLE IF EXISTS historial_precios_cantera;
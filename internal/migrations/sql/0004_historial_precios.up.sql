@@ -0,0 +1,59 @@
+-- Historial de precios de lista_precios_cantera
+-- Cada cambio de precio cierra el registro vigente (vigente_hasta) y abre uno nuevo,
+-- lo que permite responder "¿a qué precio se vendía el día X?"
+
+CREATE TABLE IF NOT EXISTS historial_precios_cantera (
+    id SERIAL PRIMARY KEY,
+    codigo_tivendo VARCHAR(50) NOT NULL,
+    precio_detalle NUMERIC(12, 2),
+    precio_mayorista NUMERIC(12, 2),
+    vigente_desde TIMESTAMP NOT NULL DEFAULT NOW(),
+    vigente_hasta TIMESTAMP,
+    operacion VARCHAR(10) NOT NULL -- INSERT, UPDATE, DELETE
+);
+
+CREATE INDEX IF NOT EXISTS idx_historial_precios_codigo_vigencia
+    ON historial_precios_cantera (codigo_tivendo, vigente_desde DESC);
+
+-- Función que registra el cambio de precio
+CREATE OR REPLACE FUNCTION registrar_historial_precios()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+       AND NEW.precio_detalle IS NOT DISTINCT FROM OLD.precio_detalle
+       AND NEW.precio_mayorista IS NOT DISTINCT FROM OLD.precio_mayorista THEN
+        RETURN NEW;
+    END IF;
+
+    -- Cerrar el precio vigente
+    UPDATE historial_precios_cantera
+    SET vigente_hasta = NOW()
+    WHERE codigo_tivendo = COALESCE(NEW.codigo_tivendo, OLD.codigo_tivendo)
+      AND vigente_hasta IS NULL;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+
+    INSERT INTO historial_precios_cantera
+        (codigo_tivendo, precio_detalle, precio_mayorista, vigente_desde, operacion)
+    VALUES
+        (NEW.codigo_tivendo, NEW.precio_detalle, NEW.precio_mayorista, NOW(), TG_OP);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_historial_precios ON lista_precios_cantera;
+CREATE TRIGGER trigger_historial_precios
+    AFTER INSERT OR UPDATE OR DELETE ON lista_precios_cantera
+    FOR EACH ROW
+    EXECUTE FUNCTION registrar_historial_precios();
+
+-- Carga inicial: los precios actuales quedan vigentes desde su última actualización
+INSERT INTO historial_precios_cantera (codigo_tivendo, precio_detalle, precio_mayorista, vigente_desde, operacion)
+SELECT lp.codigo_tivendo, lp.precio_detalle, lp.precio_mayorista, COALESCE(lp.updated_at, NOW()), 'INSERT'
+FROM lista_precios_cantera lp
+WHERE NOT EXISTS (
+    SELECT 1 FROM historial_precios_cantera h WHERE h.codigo_tivendo = lp.codigo_tivendo
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS imagenes_productos_cantera;
@@ -0,0 +1,19 @@
+-- Imagen asociada a cada producto o pack (una por código)
+-- storage_key/thumbnail_key apuntan al almacenamiento configurado (IMAGES_STORAGE);
+-- si la imagen se asoció desde una URL externa ambas claves quedan en NULL
+
+CREATE TABLE IF NOT EXISTS imagenes_productos_cantera (
+    codigo_producto VARCHAR(50) PRIMARY KEY,
+    storage_key TEXT,
+    thumbnail_key TEXT,
+    content_type VARCHAR(50),
+    hash VARCHAR(64),
+    ancho INTEGER,
+    alto INTEGER,
+    tamano_bytes BIGINT,
+    url TEXT NOT NULL,
+    url_miniatura TEXT NOT NULL,
+    id_usuario INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
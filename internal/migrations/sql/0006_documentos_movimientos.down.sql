@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_movimientos_documento;
+
+ALTER TABLE stock_movimientos_cantera
+    DROP COLUMN IF EXISTS documento_fecha,
+    DROP COLUMN IF EXISTS documento_numero,
+    DROP COLUMN IF EXISTS documento_tipo;
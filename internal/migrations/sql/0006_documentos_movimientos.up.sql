@@ -0,0 +1,12 @@
+-- Documento de respaldo de las entradas de stock (factura o guía de despacho del proveedor)
+-- Todos los movimientos de una misma entrada comparten el documento; un documento
+-- (tipo + número) solo puede respaldar una entrada
+
+ALTER TABLE stock_movimientos_cantera
+    ADD COLUMN IF NOT EXISTS documento_tipo VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS documento_numero VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS documento_fecha DATE;
+
+CREATE INDEX IF NOT EXISTS idx_movimientos_documento
+    ON stock_movimientos_cantera (documento_tipo, documento_numero)
+    WHERE documento_numero IS NOT NULL;
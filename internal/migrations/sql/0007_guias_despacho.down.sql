@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS guia_despacho_items_cantera;
+DROP TABLE IF EXISTS guias_despacho_cantera;
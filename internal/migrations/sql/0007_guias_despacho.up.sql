@@ -0,0 +1,36 @@
+-- Guías de despacho de transferencias entre locales
+-- Emitir la guía descuenta el stock en el local origen; confirmar la recepción lo ingresa
+-- en el destino con las cantidades recibidas. Estados: emitida -> en_transito -> recibida
+-- ('anulada' si la emisión no pudo descontar el stock en origen)
+
+CREATE TABLE IF NOT EXISTS guias_despacho_cantera (
+    id SERIAL PRIMARY KEY,
+    correlativo SERIAL NOT NULL UNIQUE,
+    id_local_origen INTEGER NOT NULL,
+    id_local_destino INTEGER NOT NULL,
+    estado VARCHAR(20) NOT NULL DEFAULT 'emitida',
+    observaciones TEXT NOT NULL DEFAULT '',
+    id_usuario INTEGER NOT NULL,
+    id_usuario_recepcion INTEGER,
+    observaciones_recepcion TEXT NOT NULL DEFAULT '',
+    despachada_at TIMESTAMP,
+    recibida_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS guia_despacho_items_cantera (
+    id SERIAL PRIMARY KEY,
+    id_guia INTEGER NOT NULL REFERENCES guias_despacho_cantera (id) ON DELETE CASCADE,
+    codigo_producto VARCHAR(50) NOT NULL,
+    tipo_item VARCHAR(20) NOT NULL,
+    descripcion VARCHAR(255) NOT NULL DEFAULT '',
+    cantidad_enviada INTEGER NOT NULL,
+    cantidad_recibida INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_guias_despacho_estado
+    ON guias_despacho_cantera (estado, id_local_destino);
+
+CREATE INDEX IF NOT EXISTS idx_guia_despacho_items_guia
+    ON guia_despacho_items_cantera (id_guia);
@@ -0,0 +1,6 @@
+ALTER TABLE stock_movimientos_cantera
+    DROP COLUMN IF EXISTS cantidad_unidad,
+    DROP COLUMN IF EXISTS unidad;
+
+DROP TABLE IF EXISTS conversiones_unidad_cantera;
+DROP TABLE IF EXISTS unidades_medida_cantera;
@@ -0,0 +1,27 @@
+-- Unidades de medida y factores de conversión por producto (1 caja = 24 un)
+-- El stock se lleva siempre en la unidad base del producto; una entrada o salida en otra
+-- unidad se convierte antes de afectar el stock y el movimiento registra ambas cantidades
+
+CREATE TABLE IF NOT EXISTS unidades_medida_cantera (
+    codigo VARCHAR(20) PRIMARY KEY,
+    nombre VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO unidades_medida_cantera (codigo, nombre) VALUES
+    ('caja', 'Caja'),
+    ('display', 'Display'),
+    ('docena', 'Docena')
+ON CONFLICT (codigo) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS conversiones_unidad_cantera (
+    codigo_producto VARCHAR(50) NOT NULL,
+    unidad VARCHAR(20) NOT NULL REFERENCES unidades_medida_cantera (codigo),
+    factor INTEGER NOT NULL CHECK (factor > 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (codigo_producto, unidad)
+);
+
+ALTER TABLE stock_movimientos_cantera
+    ADD COLUMN IF NOT EXISTS unidad VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS cantidad_unidad INTEGER;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS plantilla_entrada_items_cantera;
+DROP TABLE IF EXISTS plantillas_entrada_cantera;
@@ -0,0 +1,30 @@
+-- Plantillas de entrada múltiple para recepciones recurrentes (mismo pedido de los mismos proveedores)
+-- Ejecutar una plantilla arma la entrada múltiple con sus ítems, ajustando cantidades si se indica
+
+CREATE TABLE IF NOT EXISTS plantillas_entrada_cantera (
+    id SERIAL PRIMARY KEY,
+    nombre VARCHAR(100) NOT NULL,
+    proveedor VARCHAR(100) NOT NULL DEFAULT '',
+    id_local INTEGER NOT NULL,
+    motivo VARCHAR(255) NOT NULL,
+    observaciones TEXT NOT NULL DEFAULT '',
+    id_usuario INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS plantilla_entrada_items_cantera (
+    id SERIAL PRIMARY KEY,
+    id_plantilla INTEGER NOT NULL REFERENCES plantillas_entrada_cantera (id) ON DELETE CASCADE,
+    codigo_producto VARCHAR(50) NOT NULL,
+    tipo_item VARCHAR(20) NOT NULL,
+    cantidad INTEGER NOT NULL,
+    cantidad_minima INTEGER NOT NULL DEFAULT 0,
+    unidad VARCHAR(20) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_plantillas_entrada_local
+    ON plantillas_entrada_cantera (id_local, nombre);
+
+CREATE INDEX IF NOT EXISTS idx_plantilla_entrada_items_plantilla
+    ON plantilla_entrada_items_cantera (id_plantilla);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS botones_rapidos_cantera;
@@ -0,0 +1,14 @@
+-- Grilla de botones rápidos del POS por local (productos sin código de barras: pan, verduras)
+-- Cada posición de la grilla de un local tiene a lo más un botón
+
+CREATE TABLE IF NOT EXISTS botones_rapidos_cantera (
+    id SERIAL PRIMARY KEY,
+    id_local INTEGER NOT NULL,
+    posicion INTEGER NOT NULL CHECK (posicion > 0),
+    codigo_producto VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '',
+    etiqueta VARCHAR(30) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (id_local, posicion)
+);
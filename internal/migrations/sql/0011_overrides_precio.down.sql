@@ -0,0 +1 @@
+DROP TABLE IF EXISTS overrides_precio_cantera;
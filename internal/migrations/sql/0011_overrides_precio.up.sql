@@ -0,0 +1,18 @@
+-- Precios modificados por el cajero en líneas de la venta rápida, con el supervisor que los autorizó
+
+CREATE TABLE IF NOT EXISTS overrides_precio_cantera (
+    id SERIAL PRIMARY KEY,
+    id_local INTEGER NOT NULL,
+    codigo_producto VARCHAR(50) NOT NULL,
+    tipo_item VARCHAR(20) NOT NULL,
+    cantidad INTEGER NOT NULL,
+    precio_original NUMERIC(12, 2) NOT NULL,
+    precio_aplicado NUMERIC(12, 2) NOT NULL,
+    motivo VARCHAR(255) NOT NULL,
+    id_usuario INTEGER NOT NULL,
+    id_autorizador INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_overrides_precio_fecha
+    ON overrides_precio_cantera (created_at, id_local);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS lineas_contables_venta_cantera;
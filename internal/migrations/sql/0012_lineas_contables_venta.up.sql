@@ -0,0 +1,16 @@
+-- Líneas contables de una venta rápida que no corresponden a productos (ajuste de redondeo, etc.)
+-- venta_ref es el identificador devuelto por la venta rápida
+
+CREATE TABLE IF NOT EXISTS lineas_contables_venta_cantera (
+    id SERIAL PRIMARY KEY,
+    venta_ref BIGINT NOT NULL,
+    id_local INTEGER NOT NULL,
+    id_usuario INTEGER NOT NULL,
+    medio_pago VARCHAR(30) NOT NULL DEFAULT '',
+    tipo VARCHAR(30) NOT NULL,
+    monto NUMERIC(12, 2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lineas_contables_venta_fecha
+    ON lineas_contables_venta_cantera (created_at, id_local, tipo);
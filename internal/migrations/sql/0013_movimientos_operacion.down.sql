@@ -0,0 +1,7 @@
+ALTER TABLE guias_despacho_cantera
+    DROP COLUMN IF EXISTS id_operacion;
+
+DROP INDEX IF EXISTS idx_movimientos_operacion;
+
+ALTER TABLE stock_movimientos_cantera
+    DROP COLUMN IF EXISTS id_operacion;
@@ -0,0 +1,14 @@
+-- Operación a la que pertenece cada movimiento de stock
+-- Todos los movimientos de una misma entrada, salida, venta o transferencia (incluida la
+-- expansión de packs) comparten el id_operacion
+
+ALTER TABLE stock_movimientos_cantera
+    ADD COLUMN IF NOT EXISTS id_operacion VARCHAR(36);
+
+CREATE INDEX IF NOT EXISTS idx_movimientos_operacion
+    ON stock_movimientos_cantera (id_operacion)
+    WHERE id_operacion IS NOT NULL;
+
+-- Una transferencia con guía de despacho agrupa la salida en origen y la recepción en destino
+ALTER TABLE guias_despacho_cantera
+    ADD COLUMN IF NOT EXISTS id_operacion VARCHAR(36);
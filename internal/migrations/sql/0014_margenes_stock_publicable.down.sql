@@ -0,0 +1 @@
+DROP TABLE IF EXISTS margenes_stock_publicable_cantera;
@@ -0,0 +1,20 @@
+-- Margen de seguridad del stock publicado a la tienda online: unidades que cada local
+-- retiene para la venta en sala. Se define por producto o por categoría (el del producto
+-- tiene precedencia); sin margen propio se usa ECOMMERCE_STOCK_BUFFER
+
+CREATE TABLE IF NOT EXISTS margenes_stock_publicable_cantera (
+    id SERIAL PRIMARY KEY,
+    codigo_producto VARCHAR(50),
+    id_categoria INTEGER,
+    margen INTEGER NOT NULL CHECK (margen >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK ((codigo_producto IS NULL) <> (id_categoria IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_margenes_stock_publicable_producto
+    ON margenes_stock_publicable_cantera (codigo_producto)
+    WHERE codigo_producto IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_margenes_stock_publicable_categoria
+    ON margenes_stock_publicable_cantera (id_categoria)
+    WHERE id_categoria IS NOT NULL;
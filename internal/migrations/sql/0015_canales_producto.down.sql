@@ -0,0 +1 @@
+DROP TABLE IF EXISTS canales_producto_cantera;
@@ -0,0 +1,10 @@
+-- Canales en que se vende cada producto o pack (pos, ecommerce, mayorista)
+-- Un producto sin fila se vende en todos los canales
+
+CREATE TABLE IF NOT EXISTS canales_producto_cantera (
+    codigo_producto VARCHAR(50) PRIMARY KEY,
+    canales TEXT[] NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (cardinality(canales) > 0),
+    CHECK (canales <@ ARRAY['pos', 'ecommerce', 'mayorista']::TEXT[])
+);
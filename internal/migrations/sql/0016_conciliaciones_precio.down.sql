@@ -0,0 +1 @@
+DROP TABLE IF EXISTS conciliaciones_precio_cantera;
@@ -0,0 +1,15 @@
+-- Bitácora de conciliaciones entre la cache de productos y lista_precios_cantera
+-- Cada ejecución registra cuántas entradas revisó y las divergencias encontradas,
+-- para detectar invalidaciones perdidas
+
+CREATE TABLE IF NOT EXISTS conciliaciones_precio_cantera (
+    id SERIAL PRIMARY KEY,
+    muestra INTEGER,
+    revisados INTEGER NOT NULL DEFAULT 0,
+    total_divergencias INTEGER NOT NULL DEFAULT 0,
+    divergencias JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_conciliaciones_precio_created
+    ON conciliaciones_precio_cantera (created_at DESC);
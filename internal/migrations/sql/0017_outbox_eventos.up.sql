@@ -0,0 +1,24 @@
+-- Outbox transaccional: los eventos se escriben en la misma transacción que los movimientos
+-- de stock y un dispatcher en background los entrega a los webhooks con reintentos.
+-- Pendiente: enviado_at y descartado_at en NULL; descartado tras agotar los intentos
+
+CREATE TABLE IF NOT EXISTS outbox_eventos_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    tipo VARCHAR(50) NOT NULL,
+    clave VARCHAR(100),
+    payload JSONB NOT NULL,
+    intentos INTEGER NOT NULL DEFAULT 0,
+    proximo_intento_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ultimo_error TEXT,
+    enviado_at TIMESTAMP,
+    descartado_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_eventos_pendientes
+    ON outbox_eventos_cantera (proximo_intento_at, id)
+    WHERE enviado_at IS NULL AND descartado_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_eventos_enviados
+    ON outbox_eventos_cantera (enviado_at)
+    WHERE enviado_at IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS movimientos_diarios_estado;
+DROP TABLE IF EXISTS movimientos_diarios_cantera;
@@ -0,0 +1,36 @@
+-- Agregados diarios de movimientos para los reportes por rango de fechas
+-- Los mantiene un job en background (no un trigger, para no sumar trabajo ni contención
+-- a cada movimiento de stock); cubren los días anteriores a movimientos_diarios_estado.hasta
+-- Ventas valorizadas: monto_historico suma cantidad * precio del historial vigente en el momento
+-- del movimiento; las unidades sin historial se valorizan al consultar con el precio actual,
+-- igual que la consulta sobre los movimientos
+
+CREATE TABLE IF NOT EXISTS movimientos_diarios_cantera (
+    fecha DATE NOT NULL,
+    id_local INTEGER NOT NULL,
+    id_usuario INTEGER NOT NULL,
+    codigo_producto VARCHAR(50) NOT NULL,
+    tipo_item VARCHAR(20) NOT NULL,
+    tipo_movimiento VARCHAR(20) NOT NULL,
+    -- Salida registrada por el POS (observaciones con prefijo [POS])
+    venta_pos BOOLEAN NOT NULL,
+    -- Movimiento de un componente al vender un pack (observaciones con prefijo "Pack: ")
+    componente_pack BOOLEAN NOT NULL,
+    movimientos INTEGER NOT NULL,
+    unidades BIGINT NOT NULL,
+    monto_historico NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    unidades_sin_historial BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (fecha, id_local, id_usuario, codigo_producto, tipo_item, tipo_movimiento, venta_pos, componente_pack)
+);
+
+CREATE INDEX IF NOT EXISTS idx_movimientos_diarios_tipo
+    ON movimientos_diarios_cantera (tipo_movimiento, fecha);
+
+-- Marca de agua: los días anteriores a "hasta" están agregados (fila única)
+CREATE TABLE IF NOT EXISTS movimientos_diarios_estado (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    hasta DATE,
+    actualizado_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO movimientos_diarios_estado (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS avisos_vencimiento_ejecuciones;
+DROP TABLE IF EXISTS avisos_vencimiento_cantera;
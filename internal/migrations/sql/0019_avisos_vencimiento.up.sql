@@ -0,0 +1,29 @@
+-- Avisos de lotes próximos a vencer (control_vencimientos_cantera), agrupados por local
+-- Un lote se asocia a cada local con stock del producto; se avisa una vez por umbral
+-- (la restricción única marca los lotes ya avisados) y el job registra una ejecución por día
+
+CREATE TABLE IF NOT EXISTS avisos_vencimiento_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    fecha_aviso DATE NOT NULL,
+    id_local INTEGER NOT NULL,
+    codigo_producto VARCHAR(50) NOT NULL,
+    codigo_barras VARCHAR(50) NOT NULL,
+    lote VARCHAR(100) NOT NULL DEFAULT '',
+    fecha_vencimiento DATE NOT NULL,
+    umbral_dias INTEGER NOT NULL,
+    dias_restantes INTEGER NOT NULL,
+    cantidad INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (id_local, codigo_barras, lote, fecha_vencimiento, umbral_dias)
+);
+
+CREATE INDEX IF NOT EXISTS idx_avisos_vencimiento_fecha
+    ON avisos_vencimiento_cantera (fecha_aviso, id_local);
+
+-- Una fila por día generado: evita que dos réplicas (o un reinicio) repitan el job del día
+CREATE TABLE IF NOT EXISTS avisos_vencimiento_ejecuciones (
+    fecha DATE PRIMARY KEY,
+    avisos INTEGER NOT NULL DEFAULT 0,
+    locales INTEGER NOT NULL DEFAULT 0,
+    ejecutado_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS bajas_lotes_vencidos_ejecuciones;
+DROP TABLE IF EXISTS bajas_lotes_vencidos_cantera;
@@ -0,0 +1,35 @@
+-- Bajas automáticas de lotes vencidos (control_vencimientos_cantera) con una salida por merma
+-- Una fila por lote y local: 'pendiente' mientras se aplica la salida y 'aplicada' con su
+-- operación; la restricción única evita dar de baja dos veces el mismo lote en un local
+
+CREATE TABLE IF NOT EXISTS bajas_lotes_vencidos_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    fecha_baja DATE NOT NULL,
+    id_local INTEGER NOT NULL,
+    codigo_producto VARCHAR(50) NOT NULL,
+    codigo_barras VARCHAR(50) NOT NULL,
+    lote VARCHAR(100) NOT NULL DEFAULT '',
+    fecha_vencimiento DATE NOT NULL,
+    cantidad INTEGER NOT NULL,
+    estado VARCHAR(20) NOT NULL DEFAULT 'pendiente',
+    id_operacion VARCHAR(36),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (id_local, codigo_barras, lote, fecha_vencimiento)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bajas_lotes_vencidos_fecha
+    ON bajas_lotes_vencidos_cantera (fecha_baja, id_local);
+
+CREATE INDEX IF NOT EXISTS idx_bajas_lotes_vencidos_lote
+    ON bajas_lotes_vencidos_cantera (codigo_barras, lote, fecha_vencimiento);
+
+-- Una fila por día ejecutado (también en dry-run): evita que dos réplicas repitan la baja del día
+CREATE TABLE IF NOT EXISTS bajas_lotes_vencidos_ejecuciones (
+    fecha DATE PRIMARY KEY,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    lotes INTEGER NOT NULL DEFAULT 0,
+    unidades INTEGER NOT NULL DEFAULT 0,
+    locales INTEGER NOT NULL DEFAULT 0,
+    fallidos INTEGER NOT NULL DEFAULT 0,
+    ejecutado_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
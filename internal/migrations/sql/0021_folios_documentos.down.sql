@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_guias_despacho_folio;
+ALTER TABLE guias_despacho_cantera DROP COLUMN IF EXISTS folio;
+DROP TABLE IF EXISTS folios_documentos_cantera;
@@ -0,0 +1,18 @@
+-- Folios correlativos de documentos por local y tipo (venta, guía de despacho, nota de crédito)
+-- El folio se toma con un UPDATE de la fila dentro de la transacción que crea el documento:
+-- el lock de la fila serializa a las réplicas y un rollback devuelve el número (sin huecos)
+
+CREATE TABLE IF NOT EXISTS folios_documentos_cantera (
+    id_local INTEGER NOT NULL,
+    tipo_documento VARCHAR(30) NOT NULL,
+    ultimo_folio BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id_local, tipo_documento)
+);
+
+-- Las guías nuevas se numeran por local origen; las anteriores conservan el correlativo global
+ALTER TABLE guias_despacho_cantera ADD COLUMN IF NOT EXISTS folio BIGINT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_guias_despacho_folio
+    ON guias_despacho_cantera (id_local_origen, folio)
+    WHERE folio IS NOT NULL;
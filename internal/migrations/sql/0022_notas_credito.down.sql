@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS nota_credito_items_cantera;
+DROP TABLE IF EXISTS notas_credito_cantera;
@@ -0,0 +1,40 @@
+-- Notas de crédito internas: anulan (total o parcialmente) una venta del POS ya cerrada
+-- La venta se identifica por el id_operacion de sus salidas; la nota reingresa el stock en una
+-- operación propia y registra el monto devuelto como línea contable negativa del medio de pago
+-- Estados: emitida -> aplicada ('anulada' si no se pudo reingresar el stock)
+
+CREATE TABLE IF NOT EXISTS notas_credito_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    id_local INTEGER NOT NULL,
+    folio BIGINT NOT NULL,
+    id_operacion_venta VARCHAR(36) NOT NULL,
+    venta_ref BIGINT,
+    estado VARCHAR(20) NOT NULL DEFAULT 'emitida',
+    motivo VARCHAR(255) NOT NULL,
+    medio_pago VARCHAR(30) NOT NULL,
+    monto NUMERIC(12, 2) NOT NULL,
+    id_usuario INTEGER NOT NULL,
+    id_autorizador INTEGER NOT NULL,
+    rol_autorizador VARCHAR(30) NOT NULL,
+    id_operacion VARCHAR(36),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (id_local, folio)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notas_credito_venta
+    ON notas_credito_cantera (id_operacion_venta);
+
+CREATE INDEX IF NOT EXISTS idx_notas_credito_fecha
+    ON notas_credito_cantera (created_at, id_local);
+
+CREATE TABLE IF NOT EXISTS nota_credito_items_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    id_nota BIGINT NOT NULL REFERENCES notas_credito_cantera (id) ON DELETE CASCADE,
+    codigo_producto VARCHAR(50) NOT NULL,
+    tipo_item VARCHAR(20) NOT NULL,
+    cantidad INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_nota_credito_items_nota
+    ON nota_credito_items_cantera (id_nota);
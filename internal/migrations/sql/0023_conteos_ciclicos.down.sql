@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS conteos_ciclicos_ejecuciones;
+DROP TABLE IF EXISTS conteo_ciclico_items_cantera;
+DROP TABLE IF EXISTS conteos_ciclicos_cantera;
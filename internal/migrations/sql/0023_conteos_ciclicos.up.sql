@@ -0,0 +1,51 @@
+-- Conteos cíclicos: cada semana se elige por local una muestra de productos (rotativa por
+-- categoría o por valor del stock) y se reparte en sesiones de conteo pequeñas asignadas a un
+-- usuario. Estados: pendiente -> en_curso -> completada; una sesión sin completar después de
+-- su fecha límite cuenta como vencida en el seguimiento de cumplimiento
+
+CREATE TABLE IF NOT EXISTS conteos_ciclicos_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    semana DATE NOT NULL,
+    id_local INTEGER NOT NULL,
+    criterio VARCHAR(20) NOT NULL,
+    id_categoria INTEGER,
+    id_usuario INTEGER NOT NULL,
+    estado VARCHAR(20) NOT NULL DEFAULT 'pendiente',
+    fecha_limite DATE NOT NULL,
+    productos INTEGER NOT NULL DEFAULT 0,
+    con_diferencia INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    iniciado_at TIMESTAMP,
+    completado_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_conteos_ciclicos_local
+    ON conteos_ciclicos_cantera (id_local, semana);
+
+CREATE INDEX IF NOT EXISTS idx_conteos_ciclicos_usuario
+    ON conteos_ciclicos_cantera (id_usuario, estado);
+
+-- cantidad_sistema es el stock al momento de registrar el conteo del producto
+CREATE TABLE IF NOT EXISTS conteo_ciclico_items_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    id_conteo BIGINT NOT NULL REFERENCES conteos_ciclicos_cantera (id) ON DELETE CASCADE,
+    codigo_producto VARCHAR(50) NOT NULL,
+    tipo_item VARCHAR(20) NOT NULL,
+    cantidad_sistema INTEGER,
+    cantidad_contada INTEGER,
+    contado_at TIMESTAMP,
+    UNIQUE (id_conteo, codigo_producto, tipo_item)
+);
+
+CREATE INDEX IF NOT EXISTS idx_conteo_ciclico_items_producto
+    ON conteo_ciclico_items_cantera (codigo_producto, contado_at);
+
+-- Una fila por semana generada: evita que dos réplicas generen dos veces la muestra semanal
+CREATE TABLE IF NOT EXISTS conteos_ciclicos_ejecuciones (
+    semana DATE PRIMARY KEY,
+    criterio VARCHAR(20) NOT NULL,
+    sesiones INTEGER NOT NULL DEFAULT 0,
+    productos INTEGER NOT NULL DEFAULT 0,
+    locales INTEGER NOT NULL DEFAULT 0,
+    ejecutado_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
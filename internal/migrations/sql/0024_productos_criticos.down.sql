@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_stock_movimientos_producto_local_fecha;
+DROP TABLE IF EXISTS productos_criticos_cantera;
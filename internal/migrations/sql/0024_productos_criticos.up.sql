@@ -0,0 +1,16 @@
+-- Productos críticos por local: su disponibilidad (porcentaje del tiempo con stock > 0) se
+-- calcula desde los movimientos y se compara contra el SLA (sla_objetivo NULL: el configurado)
+
+CREATE TABLE IF NOT EXISTS productos_criticos_cantera (
+    id SERIAL PRIMARY KEY,
+    codigo_producto VARCHAR(50) NOT NULL,
+    id_local INTEGER NOT NULL,
+    sla_objetivo NUMERIC(5, 2),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (codigo_producto, id_local)
+);
+
+-- La disponibilidad recorre los movimientos de cada producto y local por fecha
+CREATE INDEX IF NOT EXISTS idx_stock_movimientos_producto_local_fecha
+    ON stock_movimientos_cantera (codigo_producto, id_local, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE solicitudes_aprobacion_cantera DROP COLUMN IF EXISTS reglas;
+DROP TABLE IF EXISTS reglas_operacion_cantera;
@@ -0,0 +1,25 @@
+-- Reglas que bloquean una operación o la retienen para aprobación de un supervisor
+-- id_local NULL: la regla aplica a todos los locales. Parámetros según el tipo:
+--   salida_porcentaje_stock: porcentaje del stock del local que una salida no puede superar
+--   entrada_fuera_horario:   horario permitido [hora_desde, hora_hasta) (cruza medianoche si desde > hasta)
+--   ajuste_cantidad:         cantidad (unidad base) que un ajuste no puede superar por producto
+--   venta_bajo_costo:        sin parámetros (precio de venta menor al costo del maestro)
+CREATE TABLE IF NOT EXISTS reglas_operacion_cantera (
+    id SERIAL PRIMARY KEY,
+    nombre VARCHAR(100) NOT NULL,
+    tipo VARCHAR(30) NOT NULL CHECK (tipo IN ('salida_porcentaje_stock', 'entrada_fuera_horario', 'ajuste_cantidad', 'venta_bajo_costo')),
+    accion VARCHAR(20) NOT NULL CHECK (accion IN ('bloquear', 'aprobacion')),
+    id_local INTEGER,
+    porcentaje NUMERIC(6, 2),
+    cantidad INTEGER,
+    hora_desde SMALLINT CHECK (hora_desde BETWEEN 0 AND 23),
+    hora_hasta SMALLINT CHECK (hora_hasta BETWEEN 0 AND 23),
+    activa BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reglas_operacion_activas ON reglas_operacion_cantera (tipo) WHERE activa;
+
+-- Reglas que retuvieron la operación (NULL: retenida solo por los umbrales de approval)
+ALTER TABLE solicitudes_aprobacion_cantera ADD COLUMN IF NOT EXISTS reglas TEXT;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_stock_movimientos_created_at_id;
+DROP TABLE IF EXISTS perfiles_exportacion_erp_cantera;
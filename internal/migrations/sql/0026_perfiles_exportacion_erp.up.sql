@@ -0,0 +1,19 @@
+-- Perfiles de mapeo para exportar movimientos al layout de carga de un ERP externo (SAP u otro)
+-- columnas: [{"encabezado": "...", "campo": "...", "valor": "..."}] en el orden del archivo
+-- codigos_transaccion: {"tipo_movimiento[:motivo]": "código"}; gana la clave con motivo
+CREATE TABLE IF NOT EXISTS perfiles_exportacion_erp_cantera (
+    id SERIAL PRIMARY KEY,
+    nombre VARCHAR(50) NOT NULL UNIQUE,
+    descripcion VARCHAR(255),
+    columnas JSONB NOT NULL,
+    codigos_transaccion JSONB NOT NULL DEFAULT '{}',
+    delimitador VARCHAR(2) NOT NULL DEFAULT ';',
+    incluir_encabezado BOOLEAN NOT NULL DEFAULT TRUE,
+    formato_fecha VARCHAR(30) NOT NULL DEFAULT 'YYYYMMDD',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- La exportación recorre los movimientos del período en orden
+CREATE INDEX IF NOT EXISTS idx_stock_movimientos_created_at_id
+    ON stock_movimientos_cantera (created_at, id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS claves_firma_cantera;
@@ -0,0 +1,15 @@
+-- Claves con que se firman las respuestas para integradores (una sola activa a la vez)
+-- secreto: clave HMAC o semilla Ed25519; clave_publica solo en Ed25519
+CREATE TABLE IF NOT EXISTS claves_firma_cantera (
+    id SERIAL PRIMARY KEY,
+    kid VARCHAR(32) NOT NULL UNIQUE,
+    algoritmo VARCHAR(20) NOT NULL CHECK (algoritmo IN ('hmac-sha256', 'ed25519')),
+    secreto BYTEA NOT NULL,
+    clave_publica BYTEA,
+    estado VARCHAR(20) NOT NULL DEFAULT 'activa' CHECK (estado IN ('activa', 'retirada', 'revocada')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    retirada_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_claves_firma_una_activa
+    ON claves_firma_cantera (estado) WHERE estado = 'activa';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_escaneos_no_encontrados_ultima_vez;
+DROP TABLE IF EXISTS escaneos_no_encontrados_cantera;
@@ -0,0 +1,15 @@
+-- Códigos de barras escaneados en el POS que no existen en el catálogo (productos sin catalogar)
+-- Una fila por código y local con el acumulado de escaneos; id_local 0 si el POS no lo informó
+
+CREATE TABLE IF NOT EXISTS escaneos_no_encontrados_cantera (
+    id SERIAL PRIMARY KEY,
+    codigo_barras VARCHAR(100) NOT NULL,
+    id_local INTEGER NOT NULL DEFAULT 0,
+    veces INTEGER NOT NULL DEFAULT 0,
+    primera_vez TIMESTAMP NOT NULL DEFAULT NOW(),
+    ultima_vez TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (codigo_barras, id_local)
+);
+
+CREATE INDEX IF NOT EXISTS idx_escaneos_no_encontrados_ultima_vez
+    ON escaneos_no_encontrados_cantera (ultima_vez);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_historial_cantidad_minima_producto;
+DROP TABLE IF EXISTS historial_cantidad_minima_cantera;
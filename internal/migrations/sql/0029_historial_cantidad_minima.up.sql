@@ -0,0 +1,17 @@
+-- Cambios de cantidad_minima del stock: quién, cuándo y desde qué valor
+-- (cantidad_anterior NULL: el mínimo se fijó al crear el stock del producto en el local)
+
+CREATE TABLE IF NOT EXISTS historial_cantidad_minima_cantera (
+    id SERIAL PRIMARY KEY,
+    codigo_producto VARCHAR(50) NOT NULL,
+    id_local INTEGER NOT NULL,
+    cantidad_anterior INTEGER,
+    cantidad_nueva INTEGER NOT NULL,
+    id_usuario INTEGER NOT NULL,
+    motivo VARCHAR(255),
+    id_operacion VARCHAR(36),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_historial_cantidad_minima_producto
+    ON historial_cantidad_minima_cantera (codigo_producto, id_local, created_at DESC);
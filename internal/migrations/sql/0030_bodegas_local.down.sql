@@ -0,0 +1,6 @@
+ALTER TABLE stock_movimientos_cantera DROP COLUMN IF EXISTS id_bodega_destino;
+ALTER TABLE stock_movimientos_cantera DROP COLUMN IF EXISTS id_bodega;
+DROP INDEX IF EXISTS idx_stock_por_bodega_local;
+DROP TABLE IF EXISTS stock_por_bodega_cantera;
+DROP INDEX IF EXISTS idx_bodegas_sala_local;
+DROP TABLE IF EXISTS bodegas_cantera;
@@ -0,0 +1,40 @@
+-- Bodegas (ubicaciones) dentro de un local: sala de venta, trastienda, etc.
+-- stock_bodega_cantera sigue guardando el total del local; stock_por_bodega_cantera guarda lo que
+-- está en las bodegas que no son la sala, y la sala tiene el resto. Así las operaciones que no
+-- indican bodega (y las ventas del POS) descuentan de la sala sin tener que migrar el stock existente
+
+CREATE TABLE IF NOT EXISTS bodegas_cantera (
+    id SERIAL PRIMARY KEY,
+    id_local INTEGER NOT NULL,
+    nombre VARCHAR(100) NOT NULL,
+    tipo VARCHAR(20) NOT NULL DEFAULT 'otra' CHECK (tipo IN ('sala', 'trastienda', 'otra')),
+    activa BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (id_local, nombre)
+);
+
+-- Una sola sala de venta por local
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bodegas_sala_local
+    ON bodegas_cantera (id_local) WHERE tipo = 'sala';
+
+INSERT INTO bodegas_cantera (id_local, nombre, tipo)
+SELECT id, 'Sala de venta', 'sala' FROM locales
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS stock_por_bodega_cantera (
+    id SERIAL PRIMARY KEY,
+    codigo_producto VARCHAR(50) NOT NULL,
+    id_local INTEGER NOT NULL,
+    id_bodega INTEGER NOT NULL REFERENCES bodegas_cantera (id),
+    cantidad INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (codigo_producto, id_bodega)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_por_bodega_local
+    ON stock_por_bodega_cantera (id_local, codigo_producto);
+
+-- Bodega de la entrada/salida (origen en los traslados internos) y destino del traslado
+ALTER TABLE stock_movimientos_cantera ADD COLUMN IF NOT EXISTS id_bodega INTEGER;
+ALTER TABLE stock_movimientos_cantera ADD COLUMN IF NOT EXISTS id_bodega_destino INTEGER;
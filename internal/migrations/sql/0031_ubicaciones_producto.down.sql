@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_ubicaciones_producto_local;
+DROP TABLE IF EXISTS ubicaciones_producto_cantera;
@@ -0,0 +1,16 @@
+-- Ubicación física de cada producto en el local (pasillo, rack, nivel) para ordenar el picking por recorrido
+
+CREATE TABLE IF NOT EXISTS ubicaciones_producto_cantera (
+    id SERIAL PRIMARY KEY,
+    codigo_producto VARCHAR(50) NOT NULL,
+    id_local INTEGER NOT NULL,
+    pasillo VARCHAR(20) NOT NULL,
+    rack VARCHAR(20) NOT NULL DEFAULT '',
+    nivel VARCHAR(20) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (codigo_producto, id_local)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ubicaciones_producto_local
+    ON ubicaciones_producto_cantera (id_local, pasillo, rack, nivel);
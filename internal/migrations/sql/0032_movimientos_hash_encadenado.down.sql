@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS trigger_proteger_movimientos_sellados ON stock_movimientos_cantera;
+DROP FUNCTION IF EXISTS proteger_movimientos_sellados();
+
+DROP INDEX IF EXISTS idx_movimientos_cadena_local;
+
+ALTER TABLE stock_movimientos_cantera
+    DROP COLUMN IF EXISTS hash_anterior;
+ALTER TABLE stock_movimientos_cantera
+    DROP COLUMN IF EXISTS hash;
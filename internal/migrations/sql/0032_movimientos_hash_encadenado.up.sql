@@ -0,0 +1,40 @@
+-- Movimientos de stock sellados con hash encadenado por local
+-- Cada movimiento guarda el SHA-256 de sus datos y del hash del movimiento anterior del local,
+-- de modo que modificar o borrar uno rompe la cadena desde ese punto.
+-- Los movimientos anteriores a esta migración quedan sin hash (fuera de la cadena)
+
+ALTER TABLE stock_movimientos_cantera
+    ADD COLUMN IF NOT EXISTS hash CHAR(64);
+ALTER TABLE stock_movimientos_cantera
+    ADD COLUMN IF NOT EXISTS hash_anterior CHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_movimientos_cadena_local
+    ON stock_movimientos_cantera (id_local, id)
+    WHERE hash IS NOT NULL;
+
+-- Un movimiento sellado no se puede modificar ni borrar
+-- (el sellado es el UPDATE que asigna el hash a un movimiento recién creado)
+-- El borrado se permite solo si la sesión lo habilita explícitamente (limpieza de datos demo)
+CREATE OR REPLACE FUNCTION proteger_movimientos_sellados()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.hash IS NULL THEN
+        IF TG_OP = 'DELETE' THEN
+            RETURN OLD;
+        END IF;
+        RETURN NEW;
+    END IF;
+
+    IF TG_OP = 'DELETE' AND current_setting('cantera.permitir_borrar_movimientos', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+
+    RAISE EXCEPTION 'el movimiento % está sellado y no se puede modificar', OLD.id;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_proteger_movimientos_sellados ON stock_movimientos_cantera;
+CREATE TRIGGER trigger_proteger_movimientos_sellados
+    BEFORE UPDATE OR DELETE ON stock_movimientos_cantera
+    FOR EACH ROW
+    EXECUTE FUNCTION proteger_movimientos_sellados();
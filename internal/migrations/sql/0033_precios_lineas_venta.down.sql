@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_precios_lineas_venta_operacion;
+DROP TABLE IF EXISTS precios_lineas_venta_cantera;
@@ -0,0 +1,27 @@
+-- Precio aplicado en cada línea de las ventas del POS, para resolver reclamos
+-- Guarda el precio de lista con que se vendió (y su versión: lista_updated_at de la cache),
+-- el precio del maestro y el efectivamente cobrado (distinto si el cajero lo modificó)
+
+CREATE TABLE IF NOT EXISTS precios_lineas_venta_cantera (
+    id SERIAL PRIMARY KEY,
+    venta_ref BIGINT NOT NULL,
+    id_operacion VARCHAR(36) NOT NULL,
+    id_local INTEGER NOT NULL,
+    id_usuario INTEGER NOT NULL,
+    codigo_producto VARCHAR(50) NOT NULL,
+    tipo_item VARCHAR(20) NOT NULL,
+    cantidad INTEGER NOT NULL,
+    precio_lista NUMERIC(12, 2),
+    lista_updated_at TIMESTAMP,
+    precio_maestro NUMERIC(12, 2),
+    origen_precio VARCHAR(20) NOT NULL CHECK (origen_precio IN ('lista', 'maestro', 'sin_precio')),
+    precio_base NUMERIC(12, 2) NOT NULL,
+    precio_cobrado NUMERIC(12, 2) NOT NULL,
+    precio_modificado BOOLEAN NOT NULL DEFAULT false,
+    motivo_precio VARCHAR(255),
+    id_autorizador INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_precios_lineas_venta_operacion
+    ON precios_lineas_venta_cantera (id_operacion);
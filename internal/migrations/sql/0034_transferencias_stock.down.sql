@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transferencia_stock_items_transferencia;
+DROP TABLE IF EXISTS transferencia_stock_items_cantera;
+DROP INDEX IF EXISTS idx_transferencias_stock_destino;
+DROP INDEX IF EXISTS idx_transferencias_stock_origen;
+DROP TABLE IF EXISTS transferencias_stock_cantera;
@@ -0,0 +1,38 @@
+-- Transferencias directas de stock entre locales (sin guía ni tránsito): la salida en origen y la
+-- entrada en destino se aplican en una transacción como movimientos transferencia_salida /
+-- transferencia_entrada con el id_operacion de la transferencia. La anulación revierte ambos
+-- lados en una operación propia (id_operacion_anulacion)
+
+CREATE TABLE IF NOT EXISTS transferencias_stock_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    id_local_origen INTEGER NOT NULL,
+    id_local_destino INTEGER NOT NULL,
+    estado VARCHAR(20) NOT NULL DEFAULT 'aplicada' CHECK (estado IN ('aplicada', 'anulada')),
+    motivo VARCHAR(255) NOT NULL,
+    observaciones TEXT NOT NULL DEFAULT '',
+    id_usuario INTEGER NOT NULL,
+    id_operacion VARCHAR(36) NOT NULL,
+    id_operacion_anulacion VARCHAR(36),
+    motivo_anulacion VARCHAR(255),
+    id_usuario_anulacion INTEGER,
+    anulada_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (id_local_origen <> id_local_destino)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transferencias_stock_origen
+    ON transferencias_stock_cantera (id_local_origen, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_transferencias_stock_destino
+    ON transferencias_stock_cantera (id_local_destino, created_at);
+
+CREATE TABLE IF NOT EXISTS transferencia_stock_items_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    id_transferencia BIGINT NOT NULL REFERENCES transferencias_stock_cantera (id) ON DELETE CASCADE,
+    codigo_producto VARCHAR(50) NOT NULL,
+    tipo_item VARCHAR(20) NOT NULL,
+    cantidad INTEGER NOT NULL CHECK (cantidad > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transferencia_stock_items_transferencia
+    ON transferencia_stock_items_cantera (id_transferencia);
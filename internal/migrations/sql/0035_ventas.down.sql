@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_venta_detalles_venta;
+DROP TABLE IF EXISTS venta_detalles_cantera;
+DROP INDEX IF EXISTS idx_ventas_local_fecha;
+DROP TABLE IF EXISTS ventas_cantera;
@@ -0,0 +1,44 @@
+-- Ventas rápidas del POS: cabecera con totales y detalle por línea vendida
+-- id es el venta_ref de las líneas contables y de precios_lineas_venta_cantera;
+-- id_operacion (el de los movimientos de salida) es único: reconciliar una venta encolada
+-- dos veces no la duplica
+
+CREATE TABLE IF NOT EXISTS ventas_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    id_operacion VARCHAR(36) NOT NULL UNIQUE,
+    id_local INTEGER NOT NULL,
+    id_usuario INTEGER NOT NULL,
+    id_autorizador INTEGER,
+    motivo VARCHAR(255) NOT NULL,
+    observaciones TEXT NOT NULL DEFAULT '',
+    medio_pago VARCHAR(30) NOT NULL DEFAULT '',
+    subtotal NUMERIC(12, 2) NOT NULL,
+    propina NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    cargo_servicio NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    ajuste_redondeo NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    total NUMERIC(12, 2) NOT NULL,
+    neto NUMERIC(12, 2) NOT NULL,
+    iva NUMERIC(12, 2) NOT NULL,
+    exento NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    venta_sospechosa BOOLEAN NOT NULL DEFAULT false,
+    encolada BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ventas_local_fecha
+    ON ventas_cantera (id_local, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS venta_detalles_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    id_venta BIGINT NOT NULL REFERENCES ventas_cantera (id) ON DELETE CASCADE,
+    codigo_producto VARCHAR(50) NOT NULL,
+    tipo_item VARCHAR(20) NOT NULL,
+    cantidad INTEGER NOT NULL CHECK (cantidad > 0),
+    precio_unitario NUMERIC(12, 2) NOT NULL,
+    precio_modificado BOOLEAN NOT NULL DEFAULT false,
+    exento BOOLEAN NOT NULL DEFAULT false,
+    monto NUMERIC(12, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_venta_detalles_venta
+    ON venta_detalles_cantera (id_venta);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_bloqueos_producto_activos;
+DROP TABLE IF EXISTS bloqueos_producto_cantera;
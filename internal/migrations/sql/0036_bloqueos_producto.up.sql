@@ -0,0 +1,20 @@
+-- Bloqueos de venta de productos (ej. retiro de un lote por sanidad), en todos los locales
+-- Un bloqueo está vigente mientras no se levante y no haya pasado su fecha hasta (NULL: indefinido)
+-- Bloquear un producto también bloquea los packs que lo contienen
+
+CREATE TABLE IF NOT EXISTS bloqueos_producto_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    codigo_producto VARCHAR(50) NOT NULL,
+    motivo VARCHAR(255) NOT NULL,
+    hasta TIMESTAMP,
+    id_usuario INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    levantado_at TIMESTAMP,
+    id_usuario_levanta INTEGER,
+    motivo_levantamiento VARCHAR(255),
+    CHECK (hasta IS NULL OR hasta > created_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bloqueos_producto_activos
+    ON bloqueos_producto_cantera (codigo_producto)
+    WHERE levantado_at IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_refresh_tokens_usuario;
+DROP TABLE IF EXISTS refresh_tokens_cantera;
+DROP INDEX IF EXISTS idx_usuarios_username;
+DROP TABLE IF EXISTS usuarios_cantera;
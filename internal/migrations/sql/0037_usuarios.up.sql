@@ -0,0 +1,30 @@
+-- Usuarios del POS y del backoffice (login con usuario y contraseña, bcrypt)
+-- Los usuarios no se borran: se desactivan para conservar la trazabilidad de sus movimientos
+
+CREATE TABLE IF NOT EXISTS usuarios_cantera (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(50) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    password_hash VARCHAR(100) NOT NULL,
+    rol VARCHAR(20) NOT NULL,
+    activo BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_usuarios_username
+    ON usuarios_cantera (LOWER(username));
+
+-- Refresh tokens: se guarda solo el hash (SHA-256); cada uso lo revoca y emite uno nuevo
+CREATE TABLE IF NOT EXISTS refresh_tokens_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    id_usuario INTEGER NOT NULL REFERENCES usuarios_cantera (id),
+    expira_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revocado_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_usuario
+    ON refresh_tokens_cantera (id_usuario)
+    WHERE revocado_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_minimos_estacionales_local;
+DROP INDEX IF EXISTS idx_minimos_estacionales_producto;
+DROP TABLE IF EXISTS minimos_estacionales_cantera;
@@ -0,0 +1,23 @@
+-- Perfiles estacionales de cantidad mínima por producto y local (ej. bebidas en verano)
+-- Entre desde y hasta (ambos inclusive) reemplazan a la cantidad_minima del stock en
+-- GetStockBajo y en el resumen; fuera de los rangos rige la cantidad_minima de siempre
+-- Los rangos de un mismo producto y local no se solapan (lo valida el servicio)
+
+CREATE TABLE IF NOT EXISTS minimos_estacionales_cantera (
+    id SERIAL PRIMARY KEY,
+    codigo_producto VARCHAR(50) NOT NULL,
+    id_local INTEGER NOT NULL,
+    temporada VARCHAR(50) NOT NULL,
+    cantidad_minima INTEGER NOT NULL CHECK (cantidad_minima >= 0),
+    desde DATE NOT NULL,
+    hasta DATE NOT NULL,
+    id_usuario INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (hasta >= desde)
+);
+
+CREATE INDEX IF NOT EXISTS idx_minimos_estacionales_producto
+    ON minimos_estacionales_cantera (codigo_producto, id_local, desde);
+
+CREATE INDEX IF NOT EXISTS idx_minimos_estacionales_local
+    ON minimos_estacionales_cantera (id_local, hasta);
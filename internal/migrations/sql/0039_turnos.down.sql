@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_excepciones_turno_usuario;
+DROP TABLE IF EXISTS excepciones_turno_cantera;
+DROP INDEX IF EXISTS idx_turnos_usuario;
+DROP TABLE IF EXISTS turnos_cantera;
@@ -0,0 +1,38 @@
+-- Turnos de trabajo por usuario y local (SHIFTS_*): un turno por día de la semana y franja horaria
+-- Si hora_fin <= hora_inicio el turno cruza la medianoche y termina al día siguiente
+-- Sin id_local el turno vale en cualquier local
+
+CREATE TABLE IF NOT EXISTS turnos_cantera (
+    id SERIAL PRIMARY KEY,
+    id_usuario INTEGER NOT NULL,
+    id_local INTEGER,
+    -- 0 = domingo ... 6 = sábado
+    dia_semana SMALLINT NOT NULL CHECK (dia_semana BETWEEN 0 AND 6),
+    hora_inicio TIME NOT NULL,
+    hora_fin TIME NOT NULL,
+    activo BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_turnos_usuario
+    ON turnos_cantera (id_usuario)
+    WHERE activo;
+
+-- Excepciones: un supervisor autoriza a un usuario a operar fuera de su turno entre desde y hasta
+CREATE TABLE IF NOT EXISTS excepciones_turno_cantera (
+    id SERIAL PRIMARY KEY,
+    id_usuario INTEGER NOT NULL,
+    id_local INTEGER,
+    desde TIMESTAMP NOT NULL,
+    hasta TIMESTAMP NOT NULL,
+    motivo VARCHAR(255) NOT NULL,
+    id_supervisor INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revocada_at TIMESTAMP,
+    CHECK (hasta > desde)
+);
+
+CREATE INDEX IF NOT EXISTS idx_excepciones_turno_usuario
+    ON excepciones_turno_cantera (id_usuario, hasta)
+    WHERE revocada_at IS NULL;
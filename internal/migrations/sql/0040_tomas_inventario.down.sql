@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS toma_inventario_items_cantera;
+DROP TABLE IF EXISTS tomas_inventario_cantera;
@@ -0,0 +1,37 @@
+-- Tomas de inventario (conteo físico de un local completo): se cargan las cantidades contadas por
+-- código de barras y al cerrar la sesión los productos con diferencia se ajustan en una sola
+-- transacción con movimientos tipo ajuste bajo el id_operacion de la toma.
+-- Estados: abierta -> cerrando -> cerrada | abierta -> cancelada. Una toma abierta por local
+
+CREATE TABLE IF NOT EXISTS tomas_inventario_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    id_local INTEGER NOT NULL,
+    descripcion VARCHAR(255) NOT NULL DEFAULT '',
+    estado VARCHAR(20) NOT NULL DEFAULT 'abierta'
+        CHECK (estado IN ('abierta', 'cerrando', 'cerrada', 'cancelada')),
+    id_usuario INTEGER NOT NULL,
+    ajustados INTEGER NOT NULL DEFAULT 0,
+    id_operacion VARCHAR(36),
+    id_usuario_cierre INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    cerrada_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tomas_inventario_abierta
+    ON tomas_inventario_cantera (id_local) WHERE estado IN ('abierta', 'cerrando');
+
+CREATE INDEX IF NOT EXISTS idx_tomas_inventario_local
+    ON tomas_inventario_cantera (id_local, created_at);
+
+-- Cantidad contada acumulada por producto; lecturas cuenta las cargas que la modificaron
+CREATE TABLE IF NOT EXISTS toma_inventario_items_cantera (
+    id BIGSERIAL PRIMARY KEY,
+    id_toma BIGINT NOT NULL REFERENCES tomas_inventario_cantera (id) ON DELETE CASCADE,
+    codigo_producto VARCHAR(50) NOT NULL,
+    tipo_item VARCHAR(20) NOT NULL,
+    cantidad_contada INTEGER NOT NULL CHECK (cantidad_contada >= 0),
+    lecturas INTEGER NOT NULL DEFAULT 1,
+    id_usuario INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (id_toma, codigo_producto, tipo_item)
+);
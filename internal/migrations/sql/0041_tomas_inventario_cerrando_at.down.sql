@@ -0,0 +1 @@
+ALTER TABLE tomas_inventario_cantera DROP COLUMN IF EXISTS cerrando_at;
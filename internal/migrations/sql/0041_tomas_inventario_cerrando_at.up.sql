@@ -0,0 +1,5 @@
+-- Inicio del cierre de la toma: un cierre que quedó en 'cerrando' (réplica caída entre el cambio de
+-- estado y la transacción de los ajustes) se puede retomar pasado un plazo. Las tomas que ya están
+-- en 'cerrando' quedan sin fecha y se retoman en el próximo cierre
+
+ALTER TABLE tomas_inventario_cantera ADD COLUMN IF NOT EXISTS cerrando_at TIMESTAMP;
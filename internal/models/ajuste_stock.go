@@ -0,0 +1,20 @@
+package models
+
+// TipoMovimientoAjuste movimiento que fija la cantidad de un producto tras un inventario físico
+// Su cantidad es la diferencia con signo (cantidad_nueva - cantidad_anterior)
+const TipoMovimientoAjuste = "ajuste"
+
+// AjusteStockRequest fija la cantidad absoluta de un producto en el local
+// Con IDBodega la diferencia se aplica a esa bodega (sin indicar: la sala de venta)
+type AjusteStockRequest struct {
+	CodigoProducto string `json:"codigo_producto" validate:"required"`
+	TipoItem       string `json:"tipo_item" validate:"required,oneof=producto pack"`
+	IDLocal        int    `json:"id_local" validate:"required,gt=0"`
+	IDBodega       *int   `json:"id_bodega,omitempty" validate:"omitempty,gt=0"`
+	// Puntero para distinguir el 0 (producto agotado) de un campo omitido
+	CantidadNueva *int   `json:"cantidad_nueva" validate:"required,gte=0"`
+	Motivo        string `json:"motivo" validate:"required"`
+	Observaciones string `json:"observaciones"`
+	IDUsuario     int    `json:"-"` // Se obtiene del contexto de autenticación
+	IDOperacion   string `json:"-"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// Estados de una solicitud de aprobación
+const (
+	AprobacionEstadoPendiente = "pendiente"
+	AprobacionEstadoAprobada  = "aprobada"  // aprobada y aplicada al stock
+	AprobacionEstadoRechazada = "rechazada" // rechazada, no afectó el stock
+	AprobacionEstadoFallida   = "fallida"   // aprobada pero la operación no pudo aplicarse
+)
+
+// Tipos de operación sujetos a aprobación
+const (
+	OperacionEntradaMultiple = "entrada_multiple"
+	OperacionSalidaMultiple  = "salida_multiple"
+	OperacionTransferencia   = "transferencia"
+	OperacionAjuste          = "ajuste"
+)
+
+// SolicitudAprobacion representa la tabla solicitudes_aprobacion_cantera
+// Operación que supera los umbrales configurados y espera la decisión de un supervisor
+type SolicitudAprobacion struct {
+	ID            int        `json:"id" db:"id"`
+	TipoOperacion string     `json:"tipo_operacion" db:"tipo_operacion"`
+	IDLocal       int        `json:"id_local" db:"id_local"`
+	Payload       string     `json:"payload" db:"payload"`
+	CantidadTotal int        `json:"cantidad_total" db:"cantidad_total"`
+	MontoTotal    float64    `json:"monto_total" db:"monto_total"`
+	Estado        string     `json:"estado" db:"estado"`
+	IDSolicitante int        `json:"id_solicitante" db:"id_solicitante"`
+	IDSupervisor  *int       `json:"id_supervisor,omitempty" db:"id_supervisor"`
+	MotivoRechazo *string    `json:"motivo_rechazo,omitempty" db:"motivo_rechazo"`
+	Resultado     *string    `json:"resultado,omitempty" db:"resultado"`
+	Reglas        *string    `json:"reglas,omitempty" db:"reglas"` // reglas de operación que la retuvieron
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	ResueltaAt    *time.Time `json:"resuelta_at,omitempty" db:"resuelta_at"`
+}
+
+// SolicitudAprobacionFilter filtros para consultar solicitudes de aprobación
+type SolicitudAprobacionFilter struct {
+	Estado        string `json:"estado,omitempty"`
+	IDLocal       *int   `json:"id_local,omitempty"`
+	IDSolicitante *int   `json:"id_solicitante,omitempty"`
+	Limit         int    `json:"limit,omitempty"`
+}
+
+// RechazarAprobacionRequest DTO para rechazar una solicitud
+type RechazarAprobacionRequest struct {
+	Motivo string `json:"motivo" validate:"required"`
+}
+
+// NotificacionAprobacion evento enviado al solicitante cuando se resuelve su solicitud
+type NotificacionAprobacion struct {
+	IDSolicitud   int       `json:"id_solicitud"`
+	TipoOperacion string    `json:"tipo_operacion"`
+	Estado        string    `json:"estado"`
+	IDSolicitante int       `json:"id_solicitante"`
+	IDSupervisor  int       `json:"id_supervisor"`
+	MotivoRechazo *string   `json:"motivo_rechazo,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
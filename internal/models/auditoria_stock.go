@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// MotivoAjusteAuditoria motivo de los movimientos que corrigen un descuadre detectado por la auditoría
+const MotivoAjusteAuditoria = "ajuste_auditoria"
+
+// AuditoriaStockRequest DTO para auditar el stock contra los movimientos (sin filtros: todo el stock)
+// Con Corregir se registra un movimiento de ajuste por cada descuadre
+type AuditoriaStockRequest struct {
+	IDLocal        *int    `json:"id_local" validate:"omitempty,gt=0"`
+	CodigoProducto *string `json:"codigo_producto" validate:"omitempty,min=1"`
+	Corregir       bool    `json:"corregir"`
+	IDUsuario      int     `json:"id_usuario" validate:"required_if=Corregir true,gte=0"`
+}
+
+// MovimientoCuadre último movimiento hasta el cual la cadena de movimientos cuadraba
+type MovimientoCuadre struct {
+	ID            int       `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	CantidadNueva int       `json:"cantidad_nueva"`
+}
+
+// DescuadreStock producto/local cuyo stock no coincide con el reconstruido desde los movimientos
+// CantidadTeorica: cantidad anterior del primer movimiento más la suma de entradas menos salidas
+type DescuadreStock struct {
+	CodigoProducto         string            `json:"codigo_producto"`
+	TipoItem               string            `json:"tipo_item"`
+	IDLocal                int               `json:"id_local"`
+	CantidadActual         int               `json:"cantidad_actual"`
+	CantidadTeorica        int               `json:"cantidad_teorica"`
+	Diferencia             int               `json:"diferencia"`
+	Movimientos            int               `json:"movimientos"`
+	UltimoMovimientoCuadre *MovimientoCuadre `json:"ultimo_movimiento_cuadre,omitempty"`
+
+	// Resultado de la corrección (solo si se pidió)
+	IDMovimientoAjuste *int   `json:"id_movimiento_ajuste,omitempty"`
+	Omitido            string `json:"omitido,omitempty"`
+}
+
+// AuditoriaStockResponse resultado de la auditoría de stock
+type AuditoriaStockResponse struct {
+	IDLocal         *int              `json:"id_local,omitempty"`
+	CodigoProducto  *string           `json:"codigo_producto,omitempty"`
+	Descuadres      []*DescuadreStock `json:"descuadres"`
+	TotalDescuadres int               `json:"total_descuadres"`
+	Corregidos      int               `json:"corregidos"`
+	GeneradoEn      time.Time         `json:"generado_en"`
+}
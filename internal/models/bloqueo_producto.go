@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// CodigoProductoBloqueado código con que QuickSale rechaza una venta con productos bloqueados
+const CodigoProductoBloqueado = "producto_bloqueado"
+
+// BloqueoProducto representa la tabla bloqueos_producto_cantera
+// Bloquea la venta del producto en todos los locales (y de los packs que lo contienen)
+type BloqueoProducto struct {
+	ID             int64      `json:"id" db:"id"`
+	CodigoProducto string     `json:"codigo_producto" db:"codigo_producto"`
+	Motivo         string     `json:"motivo" db:"motivo"`
+	Hasta          *time.Time `json:"hasta,omitempty" db:"hasta"` // nil: hasta que se levante
+	IDUsuario      int        `json:"id_usuario" db:"id_usuario"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	Vigente        bool       `json:"vigente"`
+
+	// Levantamiento anticipado del bloqueo
+	LevantadoAt         *time.Time `json:"levantado_at,omitempty" db:"levantado_at"`
+	IDUsuarioLevanta    *int       `json:"id_usuario_levanta,omitempty" db:"id_usuario_levanta"`
+	MotivoLevantamiento *string    `json:"motivo_levantamiento,omitempty" db:"motivo_levantamiento"`
+}
+
+// BloqueoVenta bloqueo vigente que viaja en el producto cacheado
+type BloqueoVenta struct {
+	Motivo string     `json:"motivo"`
+	Hasta  *time.Time `json:"hasta,omitempty"`
+}
+
+// VigenteEn indica si el bloqueo sigue vigente en el instante dado
+// El producto cacheado puede sobrevivir al fin del bloqueo: se revisa al vender
+func (b *BloqueoVenta) VigenteEn(t time.Time) bool {
+	return b != nil && (b.Hasta == nil || t.Before(*b.Hasta))
+}
+
+// BloquearProductoRequest DTO para bloquear la venta de un producto
+type BloquearProductoRequest struct {
+	Motivo    string     `json:"motivo" validate:"required,max=255"`
+	Hasta     *time.Time `json:"hasta,omitempty"` // vacío: hasta que se levante
+	IDUsuario int        `json:"-"`               // Se obtiene del contexto de autenticación
+}
+
+// LevantarBloqueoRequest DTO para levantar los bloqueos vigentes de un producto
+type LevantarBloqueoRequest struct {
+	Motivo    string `json:"motivo" validate:"required,max=255"`
+	IDUsuario int    `json:"-"` // Se obtiene del contexto de autenticación
+}
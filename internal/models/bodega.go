@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Tipos de bodega dentro de un local
+const (
+	// BodegaSala sala de venta: el POS y las operaciones sin bodega descuentan de ella
+	BodegaSala       = "sala"
+	BodegaTrastienda = "trastienda"
+	BodegaOtra       = "otra"
+)
+
+// TipoMovimientoTrasladoInterno movimiento entre bodegas del mismo local
+// No cambia el stock total del local (cantidad_anterior = cantidad_nueva)
+const TipoMovimientoTrasladoInterno = "traslado_interno"
+
+// Bodega representa la tabla bodegas_cantera
+// Ubicación dentro de un local con stock propio (sala de venta, trastienda...)
+type Bodega struct {
+	ID        int       `json:"id" db:"id"`
+	IDLocal   int       `json:"id_local" db:"id_local"`
+	Nombre    string    `json:"nombre" db:"nombre"`
+	Tipo      string    `json:"tipo" db:"tipo"`
+	Activa    bool      `json:"activa" db:"activa"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CrearBodegaRequest crea una bodega en un local
+type CrearBodegaRequest struct {
+	IDLocal int    `json:"id_local" validate:"required,gt=0"`
+	Nombre  string `json:"nombre" validate:"required,max=100"`
+	Tipo    string `json:"tipo" validate:"required,oneof=sala trastienda otra"`
+}
+
+// ActualizarBodegaRequest renombra o activa/desactiva una bodega (los campos nil no cambian)
+type ActualizarBodegaRequest struct {
+	Nombre *string `json:"nombre,omitempty" validate:"omitempty,min=1,max=100"`
+	Activa *bool   `json:"activa,omitempty"`
+}
+
+// TrasladoInternoRequest mueve stock entre dos bodegas del mismo local
+type TrasladoInternoRequest struct {
+	CodigoProducto  string `json:"codigo_producto" validate:"required"`
+	IDLocal         int    `json:"id_local" validate:"required,gt=0"`
+	IDBodegaOrigen  int    `json:"id_bodega_origen" validate:"required,gt=0"`
+	IDBodegaDestino int    `json:"id_bodega_destino" validate:"required,gt=0,nefield=IDBodegaOrigen"`
+	Cantidad        int    `json:"cantidad" validate:"required,gt=0"`
+	Motivo          string `json:"motivo" validate:"required"`
+	Observaciones   string `json:"observaciones"`
+	IDUsuario       int    `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// StockEnBodega cantidad de un producto en una bodega
+type StockEnBodega struct {
+	IDBodega int    `json:"id_bodega"`
+	Nombre   string `json:"nombre"`
+	Tipo     string `json:"tipo"`
+	Cantidad int    `json:"cantidad"`
+}
+
+// StockPorBodega stock de un producto en el local desglosado por bodega
+// La sala tiene el total menos lo que está en las demás bodegas (negativo: faltó registrar un traslado)
+type StockPorBodega struct {
+	CodigoProducto string          `json:"codigo_producto"`
+	IDLocal        int             `json:"id_local"`
+	Total          int             `json:"total"`
+	Bodegas        []StockEnBodega `json:"bodegas"`
+}
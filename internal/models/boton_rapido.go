@@ -0,0 +1,32 @@
+package models
+
+// BotonRapido representa la tabla botones_rapidos_cantera
+// Acceso directo del POS a un producto sin código de barras; incluye los datos para renderizarlo
+type BotonRapido struct {
+	Posicion           int      `json:"posicion" db:"posicion"`
+	CodigoProducto     string   `json:"codigo_producto" db:"codigo_producto"`
+	Nombre             string   `json:"nombre" db:"nombre"`
+	Etiqueta           string   `json:"etiqueta,omitempty" db:"etiqueta"` // texto del botón (vacío: nombre del producto)
+	Color              string   `json:"color,omitempty" db:"color"`
+	Precio             *float64 `json:"precio,omitempty" db:"precio"` // precio detalle de lista_precios_cantera
+	ImagenMiniaturaURL *string  `json:"imagen_miniatura_url,omitempty" db:"imagen_miniatura_url"`
+}
+
+// GrillaBotonesRapidos botones rápidos de un local ordenados por posición
+type GrillaBotonesRapidos struct {
+	IDLocal int            `json:"id_local"`
+	Botones []*BotonRapido `json:"botones"`
+}
+
+// BotonRapidoRequest botón de la grilla a guardar
+type BotonRapidoRequest struct {
+	Posicion       int    `json:"posicion" validate:"required,gt=0,lte=100"`
+	CodigoProducto string `json:"codigo_producto" validate:"required"`
+	Color          string `json:"color" validate:"omitempty,hexcolor,len=7"`
+	Etiqueta       string `json:"etiqueta" validate:"max=30"`
+}
+
+// GrillaBotonesRequest DTO para reemplazar la grilla de botones rápidos de un local
+type GrillaBotonesRequest struct {
+	Botones []BotonRapidoRequest `json:"botones" validate:"dive"`
+}
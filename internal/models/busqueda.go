@@ -0,0 +1,28 @@
+package models
+
+// Tipos de resultado de la búsqueda global
+const (
+	ResultadoProducto   = "producto"
+	ResultadoPack       = "pack"
+	ResultadoMovimiento = "movimiento"
+)
+
+// ResultadoBusqueda resultado tipado de la búsqueda global
+type ResultadoBusqueda struct {
+	Tipo       string      `json:"tipo"`
+	Codigo     string      `json:"codigo"`
+	Titulo     string      `json:"titulo"`
+	Subtitulo  string      `json:"subtitulo,omitempty"`
+	Relevancia int         `json:"relevancia"`
+	Data       interface{} `json:"data"`
+}
+
+// ProductoBusqueda coincidencia en productos o packs
+type ProductoBusqueda struct {
+	Codigo             string   `json:"codigo" db:"codigo"`
+	Nombre             string   `json:"nombre" db:"nombre"`
+	CodigoBarraInterno *string  `json:"codigo_barra_interno,omitempty" db:"codigo_barra_interno"`
+	CodigoBarraExterno *string  `json:"codigo_barra_externo,omitempty" db:"codigo_barra_externo"`
+	Precio             *float64 `json:"precio,omitempty" db:"precio"`
+	Activo             bool     `json:"activo" db:"activo"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// Canales de venta de un producto
+const (
+	CanalPOS       = "pos"
+	CanalEcommerce = "ecommerce"
+	CanalMayorista = "mayorista"
+)
+
+// CanalesVenta canales de venta conocidos (un producto sin canales definidos se vende en todos)
+var CanalesVenta = []string{CanalPOS, CanalEcommerce, CanalMayorista}
+
+// EsCanalValido indica si el canal es uno de los canales de venta conocidos
+func EsCanalValido(canal string) bool {
+	for _, c := range CanalesVenta {
+		if c == canal {
+			return true
+		}
+	}
+	return false
+}
+
+// CanalHabilitado indica si canal está entre los habilitados (sin canales definidos: todos)
+func CanalHabilitado(habilitados []string, canal string) bool {
+	if len(habilitados) == 0 {
+		return true
+	}
+	for _, c := range habilitados {
+		if c == canal {
+			return true
+		}
+	}
+	return false
+}
+
+// CanalesProducto representa la tabla canales_producto_cantera
+type CanalesProducto struct {
+	CodigoProducto string   `json:"codigo_producto" db:"codigo_producto"`
+	Canales        []string `json:"canales" db:"canales"`
+	// false: el producto no tiene canales definidos y se vende en todos
+	Definidos bool       `json:"definidos"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// CanalesProductoRequest DTO para definir los canales en que se vende un producto
+type CanalesProductoRequest struct {
+	Canales []string `json:"canales" validate:"required,min=1,unique,dive,oneof=pos ecommerce mayorista"`
+}
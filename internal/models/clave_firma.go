@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Algoritmos de firma de respuestas
+const (
+	FirmaHMACSHA256 = "hmac-sha256"
+	FirmaEd25519    = "ed25519"
+)
+
+// Estados de una clave de firma
+const (
+	ClaveFirmaActiva   = "activa"   // firma las respuestas
+	ClaveFirmaRetirada = "retirada" // reemplazada; se publica un tiempo para verificar respuestas ya emitidas
+	ClaveFirmaRevocada = "revocada" // comprometida o dada de baja; no se publica
+)
+
+// ClaveFirma representa la tabla claves_firma_cantera
+type ClaveFirma struct {
+	ID        int    `json:"id" db:"id"`
+	KID       string `json:"kid" db:"kid"`
+	Algoritmo string `json:"algoritmo" db:"algoritmo"`
+	// Clave HMAC o semilla Ed25519 (nunca se expone salvo al crear una clave HMAC)
+	Secreto      []byte     `json:"-" db:"secreto"`
+	ClavePublica []byte     `json:"clave_publica,omitempty" db:"clave_publica"` // Ed25519, base64 en JSON
+	Estado       string     `json:"estado" db:"estado"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	RetiradaAt   *time.Time `json:"retirada_at,omitempty" db:"retirada_at"`
+}
+
+// RotarClaveFirmaRequest DTO para generar una nueva clave activa
+type RotarClaveFirmaRequest struct {
+	Algoritmo string `json:"algoritmo" validate:"required,oneof=hmac-sha256 ed25519"`
+}
+
+// ClaveFirmaRotada clave recién generada; el secreto HMAC solo se entrega en esta respuesta
+type ClaveFirmaRotada struct {
+	*ClaveFirma
+	SecretoHMAC string `json:"secreto,omitempty"` // base64
+}
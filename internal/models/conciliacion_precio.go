@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// Motivos de divergencia entre la cache de productos y lista_precios_cantera
+const (
+	DivergenciaPrecioDetalle   = "precio_detalle"
+	DivergenciaPrecioMayorista = "precio_mayorista"
+	DivergenciaVersion         = "version"
+	DivergenciaSinLista        = "sin_lista"
+)
+
+// PrecioLista fila vigente de lista_precios_cantera
+type PrecioLista struct {
+	CodigoTivendo   string     `json:"codigo_tivendo" db:"codigo_tivendo"`
+	PrecioDetalle   *float64   `json:"precio_detalle" db:"precio_detalle"`
+	PrecioMayorista *float64   `json:"precio_mayorista" db:"precio_mayorista"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// DivergenciaPrecio entrada de la cache cuyo precio no coincide con lista_precios_cantera
+// AntiguedadSegundos es el tiempo desde el cambio en la lista que la cache no recogió
+type DivergenciaPrecio struct {
+	CodigoBarras         string     `json:"codigo_barras"`
+	CodigoTivendo        string     `json:"codigo_tivendo"`
+	Motivos              []string   `json:"motivos"`
+	CachePrecioDetalle   *float64   `json:"cache_precio_detalle"`
+	ListaPrecioDetalle   *float64   `json:"lista_precio_detalle"`
+	CachePrecioMayorista *float64   `json:"cache_precio_mayorista"`
+	ListaPrecioMayorista *float64   `json:"lista_precio_mayorista"`
+	CacheUpdatedAt       *time.Time `json:"cache_updated_at,omitempty"`
+	ListaUpdatedAt       *time.Time `json:"lista_updated_at,omitempty"`
+	AntiguedadSegundos   int64      `json:"antiguedad_segundos"`
+}
+
+// ConciliacionPrecios representa la tabla conciliaciones_precio_cantera
+// Muestra nil: se revisaron todas las entradas de la cache
+type ConciliacionPrecios struct {
+	ID                int                 `json:"id" db:"id"`
+	Muestra           *int                `json:"muestra,omitempty" db:"muestra"`
+	Revisados         int                 `json:"revisados" db:"revisados"`
+	TotalDivergencias int                 `json:"total_divergencias" db:"total_divergencias"`
+	Divergencias      []DivergenciaPrecio `json:"divergencias" db:"divergencias"`
+	CreatedAt         time.Time           `json:"created_at" db:"created_at"`
+}
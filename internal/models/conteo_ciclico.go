@@ -0,0 +1,151 @@
+package models
+
+import "time"
+
+// Criterios de selección de la muestra semanal de conteo cíclico
+const (
+	CriterioConteoCategoria = "categoria" // una categoría por semana, rotando
+	CriterioConteoValor     = "valor"     // los de mayor valor en stock, rotando
+)
+
+// Estados de una sesión de conteo cíclico
+const (
+	ConteoEstadoPendiente  = "pendiente"
+	ConteoEstadoEnCurso    = "en_curso"
+	ConteoEstadoCompletada = "completada"
+)
+
+// ConteoCiclico representa la tabla conteos_ciclicos_cantera
+// Sesión de conteo de una muestra pequeña de productos de un local asignada a un usuario
+type ConteoCiclico struct {
+	ID            int64                `json:"id" db:"id"`
+	Semana        time.Time            `json:"semana" db:"semana"`
+	IDLocal       int                  `json:"id_local" db:"id_local"`
+	Criterio      string               `json:"criterio" db:"criterio"`
+	IDCategoria   *int                 `json:"id_categoria,omitempty" db:"id_categoria"`
+	IDUsuario     int                  `json:"id_usuario" db:"id_usuario"`
+	Estado        string               `json:"estado" db:"estado"`
+	FechaLimite   time.Time            `json:"fecha_limite" db:"fecha_limite"`
+	Productos     int                  `json:"productos" db:"productos"`
+	ConDiferencia int                  `json:"con_diferencia" db:"con_diferencia"`
+	Vencida       bool                 `json:"vencida"`
+	CreatedAt     time.Time            `json:"created_at" db:"created_at"`
+	IniciadoAt    *time.Time           `json:"iniciado_at,omitempty" db:"iniciado_at"`
+	CompletadoAt  *time.Time           `json:"completado_at,omitempty" db:"completado_at"`
+	Items         []*ConteoCiclicoItem `json:"items,omitempty"`
+}
+
+// ConteoCiclicoItem representa la tabla conteo_ciclico_items_cantera
+type ConteoCiclicoItem struct {
+	ID              int64      `json:"id" db:"id"`
+	IDConteo        int64      `json:"id_conteo" db:"id_conteo"`
+	CodigoProducto  string     `json:"codigo_producto" db:"codigo_producto"`
+	NombreProducto  *string    `json:"nombre_producto,omitempty"`
+	TipoItem        string     `json:"tipo_item" db:"tipo_item"`
+	CantidadSistema *int       `json:"cantidad_sistema,omitempty" db:"cantidad_sistema"`
+	CantidadContada *int       `json:"cantidad_contada,omitempty" db:"cantidad_contada"`
+	Diferencia      *int       `json:"diferencia,omitempty"`
+	ContadoAt       *time.Time `json:"contado_at,omitempty" db:"contado_at"`
+}
+
+// ProductoConteo producto elegido para la muestra de conteo de un local
+type ProductoConteo struct {
+	CodigoProducto string
+	TipoItem       string
+}
+
+// EjecucionConteosCiclicos representa la tabla conteos_ciclicos_ejecuciones
+type EjecucionConteosCiclicos struct {
+	Semana      time.Time `json:"semana" db:"semana"`
+	Criterio    string    `json:"criterio" db:"criterio"`
+	Sesiones    int       `json:"sesiones" db:"sesiones"`
+	Productos   int       `json:"productos" db:"productos"`
+	Locales     int       `json:"locales" db:"locales"`
+	EjecutadoAt time.Time `json:"ejecutado_at" db:"ejecutado_at"`
+}
+
+// ResultadoConteoItem cantidad contada de un producto de la sesión
+type ResultadoConteoItem struct {
+	CodigoProducto string `json:"codigo_producto" validate:"required"`
+	TipoItem       string `json:"tipo_item" validate:"required,oneof=producto pack"`
+	Cantidad       int    `json:"cantidad" validate:"gte=0"`
+}
+
+// RegistrarConteoRequest cantidades contadas de una sesión (puede registrarse en varias partes)
+type RegistrarConteoRequest struct {
+	Items     []*ResultadoConteoItem `json:"items" validate:"required,min=1,dive"`
+	IDUsuario int                    `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// ConteoCiclicoFilter filtros del listado de sesiones de conteo
+type ConteoCiclicoFilter struct {
+	IDLocal   *int
+	IDUsuario *int
+	Estado    string
+	Semana    *time.Time
+}
+
+// CumplimientoConteos cumplimiento de las sesiones de conteo de un usuario en un local
+// Cumplimiento: sesiones completadas a tiempo sobre las exigibles (plazo vencido o completadas)
+type CumplimientoConteos struct {
+	IDLocal       int     `json:"id_local"`
+	IDUsuario     int     `json:"id_usuario"`
+	Asignadas     int     `json:"asignadas"`
+	Completadas   int     `json:"completadas"`
+	ATiempo       int     `json:"a_tiempo"`
+	Vencidas      int     `json:"vencidas"`
+	Pendientes    int     `json:"pendientes"`
+	ConDiferencia int     `json:"productos_con_diferencia"`
+	Cumplimiento  float64 `json:"cumplimiento_pct"`
+}
+
+// ReporteConteosCiclicos seguimiento de cumplimiento de los conteos cíclicos de un período
+type ReporteConteosCiclicos struct {
+	Filtros      *ReporteFilter            `json:"filtros"`
+	Asignadas    int                       `json:"asignadas"`
+	Completadas  int                       `json:"completadas"`
+	Vencidas     int                       `json:"vencidas"`
+	Cumplimiento float64                   `json:"cumplimiento_pct"`
+	Ejecucion    *EjecucionConteosCiclicos `json:"ultima_generacion,omitempty"`
+	Detalle      []*CumplimientoConteos    `json:"detalle"`
+}
+
+// GeneracionConteos resultado de la generación semanal de sesiones de conteo
+type GeneracionConteos struct {
+	Semana   time.Time        `json:"semana"`
+	Criterio string           `json:"criterio"`
+	Generada bool             `json:"generada"` // false: la semana ya estaba generada
+	Sesiones []*ConteoCiclico `json:"sesiones"`
+}
+
+// Motivos por los que una línea del archivo de la PDT no se aplica a la sesión
+const (
+	LineaPDTFormatoInvalido   = "formato_invalido"
+	LineaPDTCodigoDesconocido = "codigo_desconocido"
+	LineaPDTFueraDeSesion     = "fuera_de_sesion"
+)
+
+// LineaPDTRechazada línea del archivo de la PDT que no se aplicó a la sesión
+type LineaPDTRechazada struct {
+	Linea  int    `json:"linea"`
+	Codigo string `json:"codigo,omitempty"`
+	Motivo string `json:"motivo"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportacionPDT resultado de cargar el archivo de una terminal de inventario en una sesión de conteo
+// Las lecturas del mismo producto se suman; el total reemplaza lo contado antes en la sesión
+type ImportacionPDT struct {
+	IDConteo        int64                  `json:"id_conteo"`
+	Archivo         string                 `json:"archivo"`
+	Lineas          int                    `json:"lineas"`
+	LineasAplicadas int                    `json:"lineas_aplicadas"`
+	Registrados     []*ResultadoConteoItem `json:"registrados"`
+	Rechazadas      []*LineaPDTRechazada   `json:"rechazadas"`
+	// Códigos leídos que no corresponden a ningún producto ni pack (sin repetir)
+	CodigosDesconocidos []string   `json:"codigos_desconocidos"`
+	PrimeraLectura      *time.Time `json:"primera_lectura,omitempty"`
+	UltimaLectura       *time.Time `json:"ultima_lectura,omitempty"`
+	// Sesión después de la carga (sin cambios si ninguna línea se aplicó)
+	Conteo *ConteoCiclico `json:"conteo"`
+}
@@ -12,6 +12,16 @@ type EntradaStockRequest struct {
 	Observaciones  string `json:"observaciones"`
 	CantidadMinima int    `json:"cantidad_minima" validate:"gte=0"`
 	IDUsuario      int    `json:"-"` // Se obtiene del contexto de autenticación
+	// Documento del proveedor que respalda la entrada (opcional)
+	Documento *DocumentoRespaldo `json:"documento,omitempty"`
+	// Unidad en que se expresa la cantidad (vacío: unidad base del producto)
+	Unidad string `json:"unidad,omitempty" validate:"omitempty,max=20"`
+	// Bodega del local (nil: la sala de venta)
+	IDBodega *int `json:"id_bodega,omitempty" validate:"omitempty,gt=0"`
+	// Operación a la que pertenece (la asigna el servicio; vacío: operación propia)
+	IDOperacion string `json:"-"`
+	// Tipo del movimiento registrado (lo asigna el servicio; vacío: entrada)
+	TipoMovimiento string `json:"-"`
 }
 
 // SalidaStockRequest DTO para salida de stock
@@ -23,6 +33,14 @@ type SalidaStockRequest struct {
 	IDLocal        int    `json:"id_local" validate:"required,gt=0"`
 	Observaciones  string `json:"observaciones"`
 	IDUsuario      int    `json:"-"` // Se obtiene del contexto de autenticación
+	// Unidad en que se expresa la cantidad (vacío: unidad base del producto)
+	Unidad string `json:"unidad,omitempty" validate:"omitempty,max=20"`
+	// Bodega del local (nil: la sala de venta)
+	IDBodega *int `json:"id_bodega,omitempty" validate:"omitempty,gt=0"`
+	// Operación a la que pertenece (la asigna el servicio; vacío: operación propia)
+	IDOperacion string `json:"-"`
+	// Tipo del movimiento registrado (lo asigna el servicio; vacío: salida)
+	TipoMovimiento string `json:"-"`
 }
 
 // ProductoEntrada representa un producto en entrada múltiple (con cantidad_minima)
@@ -31,6 +49,8 @@ type ProductoEntrada struct {
 	TipoItem       string `json:"tipo_item" validate:"required,oneof=producto pack"`
 	Cantidad       int    `json:"cantidad" validate:"required,gt=0"`
 	CantidadMinima int    `json:"cantidad_minima" validate:"gte=0"`
+	// Unidad en que se expresa la cantidad (vacío: unidad base del producto)
+	Unidad string `json:"unidad,omitempty" validate:"omitempty,max=20"`
 }
 
 // ProductoSalida representa un producto en salida múltiple (sin cantidad_minima)
@@ -38,6 +58,8 @@ type ProductoSalida struct {
 	CodigoProducto string `json:"codigo_producto" validate:"required"`
 	TipoItem       string `json:"tipo_item" validate:"required,oneof=producto pack"`
 	Cantidad       int    `json:"cantidad" validate:"required,gt=0"`
+	// Unidad en que se expresa la cantidad (vacío: unidad base del producto)
+	Unidad string `json:"unidad,omitempty" validate:"omitempty,max=20"`
 }
 
 // EntradaMultipleStockRequest DTO para entrada múltiple de stock
@@ -47,6 +69,16 @@ type EntradaMultipleStockRequest struct {
 	IDLocal       int               `json:"id_local" validate:"required,gt=0"`
 	Observaciones string            `json:"observaciones"`
 	IDUsuario     int               `json:"-"` // Se obtiene del contexto de autenticación
+	DryRun        bool              `json:"-"` // Se obtiene del query param dry_run
+	// Documento del proveedor que respalda toda la entrada (opcional)
+	Documento *DocumentoRespaldo `json:"documento,omitempty"`
+	// Bodega del local (nil: la sala de venta)
+	IDBodega *int `json:"id_bodega,omitempty" validate:"omitempty,gt=0"`
+	// Id de operación de los movimientos (vacío: se genera uno nuevo); lo fija quien reintenta
+	// una entrada ya iniciada
+	IDOperacion string `json:"-"`
+	// Atomico aplica todos los productos en una sola transacción: si alguno falla no se aplica ninguno
+	Atomico bool `json:"atomico,omitempty"`
 }
 
 // SalidaMultipleStockRequest DTO para salida múltiple de stock
@@ -56,6 +88,14 @@ type SalidaMultipleStockRequest struct {
 	IDLocal       int              `json:"id_local" validate:"required,gt=0"`
 	Observaciones string           `json:"observaciones"`
 	IDUsuario     int              `json:"-"` // Se obtiene del contexto de autenticación
+	DryRun        bool             `json:"-"` // Se obtiene del query param dry_run
+	// Bodega del local (nil: la sala de venta)
+	IDBodega *int `json:"id_bodega,omitempty" validate:"omitempty,gt=0"`
+	// Id de operación de los movimientos (vacío: se genera uno nuevo); lo fija quien reintenta
+	// una salida ya iniciada, como la reconciliación de ventas encoladas
+	IDOperacion string `json:"-"`
+	// Atomico aplica todos los productos en una sola transacción: si alguno falla no se aplica ninguno
+	Atomico bool `json:"atomico,omitempty"`
 }
 
 // ===== RESPONSE DTOs =====
@@ -71,6 +111,7 @@ type EntradaStockResponse struct {
 		CantidadNueva  int    `json:"cantidad_nueva"`
 		Motivo         string `json:"motivo"`
 		IDLocal        int    `json:"id_local"`
+		IDOperacion    string `json:"id_operacion"`
 		Timestamp      string `json:"timestamp"`
 	} `json:"data"`
 }
@@ -86,6 +127,7 @@ type SalidaStockResponse struct {
 		CantidadNueva  int    `json:"cantidad_nueva"`
 		Motivo         string `json:"motivo"`
 		IDLocal        int    `json:"id_local"`
+		IDOperacion    string `json:"id_operacion"`
 		Timestamp      string `json:"timestamp"`
 	} `json:"data"`
 }
@@ -97,7 +139,17 @@ type EntradaMultipleStockResponse struct {
 	TotalProductos int                 `json:"total_productos"`
 	Resultados     []ProductoResultado `json:"resultados"`
 	Errores        []ProductoError     `json:"errores,omitempty"`
+	DryRun         bool                `json:"dry_run,omitempty"`
+	Atomico        bool                `json:"atomico,omitempty"`
+	IDOperacion    string              `json:"id_operacion,omitempty"`
 	Timestamp      string              `json:"timestamp"`
+	// Resultado por ítem en el orden del request; se responde con la versión 2
+	Items []ResultadoItem `json:"-"`
+}
+
+// V2 respuesta por ítem (versión 2)
+func (r *EntradaMultipleStockResponse) V2() *OperacionMultipleV2Response {
+	return nuevaOperacionMultipleV2(r.Success, r.Message, r.Items, r.DryRun, r.Atomico, r.IDOperacion, r.Timestamp)
 }
 
 // ProductoResultado resultado de procesamiento de un producto
@@ -115,6 +167,76 @@ type ProductoError struct {
 	Error          string `json:"error"`
 }
 
+// Estados de un ítem de una operación múltiple
+const (
+	ItemEstadoAplicado    = "aplicado"
+	ItemEstadoSimulado    = "simulado"     // dry_run: se aplicaría sin errores
+	ItemEstadoFallido     = "fallido"      // ver codigo_error y error
+	ItemEstadoRevertido   = "revertido"    // atómica: se aplicó pero otro ítem falló
+	ItemEstadoNoProcesado = "no_procesado" // atómica: no se intentó tras el primer error
+)
+
+// ResultadoItem resultado de un ítem de una operación múltiple, en el orden del request
+type ResultadoItem struct {
+	Indice         int    `json:"indice"` // posición en productos del request
+	CodigoProducto string `json:"codigo_producto"`
+	TipoItem       string `json:"tipo_item"`
+	Cantidad       int    `json:"cantidad"`
+	Estado         string `json:"estado"`
+	CantidadNueva  *int   `json:"cantidad_nueva,omitempty"`
+	CodigoError    string `json:"codigo_error,omitempty"`
+	Error          string `json:"error,omitempty"`
+	// Movimiento registrado (solo en los ítems aplicados)
+	Movimiento *Movimiento `json:"movimiento,omitempty"`
+}
+
+// Versiones de la respuesta de las operaciones múltiples (header X-Response-Version o ?version=)
+const (
+	// RespuestaMultipleV1 listas paralelas resultados/errores (por defecto, compatibilidad)
+	RespuestaMultipleV1 = 1
+	// RespuestaMultipleV2 un ítem por producto del request con su estado
+	RespuestaMultipleV2 = 2
+)
+
+// OperacionMultipleV2Response respuesta v2 de entrada/salida múltiple
+type OperacionMultipleV2Response struct {
+	Success        bool            `json:"success"`
+	Message        string          `json:"message"`
+	Version        int             `json:"version"`
+	TotalProductos int             `json:"total_productos"`
+	Aplicados      int             `json:"aplicados"`
+	Fallidos       int             `json:"fallidos"`
+	Items          []ResultadoItem `json:"items"`
+	DryRun         bool            `json:"dry_run,omitempty"`
+	Atomico        bool            `json:"atomico,omitempty"`
+	IDOperacion    string          `json:"id_operacion,omitempty"`
+	Timestamp      string          `json:"timestamp"`
+}
+
+// nuevaOperacionMultipleV2 arma la respuesta v2 a partir de los ítems
+func nuevaOperacionMultipleV2(success bool, message string, items []ResultadoItem, dryRun, atomico bool, idOperacion, timestamp string) *OperacionMultipleV2Response {
+	response := &OperacionMultipleV2Response{
+		Success:        success,
+		Message:        message,
+		Version:        RespuestaMultipleV2,
+		TotalProductos: len(items),
+		Items:          items,
+		DryRun:         dryRun,
+		Atomico:        atomico,
+		IDOperacion:    idOperacion,
+		Timestamp:      timestamp,
+	}
+	for _, item := range items {
+		switch item.Estado {
+		case ItemEstadoAplicado, ItemEstadoSimulado:
+			response.Aplicados++
+		case ItemEstadoFallido:
+			response.Fallidos++
+		}
+	}
+	return response
+}
+
 // SalidaMultipleStockResponse respuesta para salida múltiple
 type SalidaMultipleStockResponse struct {
 	Success        bool                `json:"success"`
@@ -122,7 +244,17 @@ type SalidaMultipleStockResponse struct {
 	TotalProductos int                 `json:"total_productos"`
 	Resultados     []ProductoResultado `json:"resultados"`
 	Errores        []ProductoError     `json:"errores,omitempty"`
+	DryRun         bool                `json:"dry_run,omitempty"`
+	Atomico        bool                `json:"atomico,omitempty"`
+	IDOperacion    string              `json:"id_operacion,omitempty"`
 	Timestamp      string              `json:"timestamp"`
+	// Resultado por ítem en el orden del request; se responde con la versión 2
+	Items []ResultadoItem `json:"-"`
+}
+
+// V2 respuesta por ítem (versión 2)
+func (r *SalidaMultipleStockResponse) V2() *OperacionMultipleV2Response {
+	return nuevaOperacionMultipleV2(r.Success, r.Message, r.Items, r.DryRun, r.Atomico, r.IDOperacion, r.Timestamp)
 }
 
 // ===== POS DTOs =====
@@ -134,6 +266,15 @@ type QuickSaleRequest struct {
 	IDLocal       int             `json:"id_local" validate:"required,gt=0"`
 	Observaciones string          `json:"observaciones"`
 	IDUsuario     int             `json:"-"` // Se obtiene del contexto JWT
+	// Supervisor que autoriza los precios modificados (obligatorio si alguna línea lo modifica)
+	IDAutorizador *int `json:"id_autorizador,omitempty" validate:"omitempty,gt=0"`
+	// Contraseña del supervisor, que la ingresa en el POS (obligatoria con id_autorizador)
+	PasswordAutorizador string `json:"password_autorizador,omitempty" validate:"required_with=IDAutorizador,max=72"`
+	// Medio de pago; define el redondeo del total (SALES_ROUNDING_RULES)
+	MedioPago string `json:"medio_pago,omitempty" validate:"omitempty,max=30"`
+	// Agregar propina y/o cargo por servicio (SALES_TIP_PERCENT, SALES_SERVICE_CHARGE_PERCENT)
+	Propina       bool `json:"propina,omitempty"`
+	CargoServicio bool `json:"cargo_servicio,omitempty"`
 }
 
 // ProductoStock representa un producto en operaciones de stock
@@ -142,17 +283,73 @@ type ProductoStock struct {
 	TipoItem       string `json:"tipo_item" validate:"required,oneof=producto pack"`
 	Cantidad       int    `json:"cantidad" validate:"required,gt=0"`
 	CantidadMinima int    `json:"cantidad_minima" validate:"gte=0"`
+	// Precio modificado por el cajero para esta línea (vacío: precio de lista)
+	PrecioAplicado *float64 `json:"precio_aplicado,omitempty" validate:"omitempty,gte=0"`
+	MotivoPrecio   string   `json:"motivo_precio,omitempty" validate:"required_with=PrecioAplicado,max=255"`
 }
 
 // ===== POS Response DTOs =====
 
-// ProductoPOSResponse respuesta optimizada para POS
+// ProductoPOSResponse respuesta optimizada para POS (escaneo con ?detalle=minimo)
+// Se cachea aparte del ProductoCompleto y se serializa sin reflexión (AppendJSON en pos_json.go)
 type ProductoPOSResponse struct {
-	Codigo       string `json:"codigo"`
-	Nombre       string `json:"nombre"`
-	CodigoBarras string `json:"codigo_barras"`
-	EsPack       bool   `json:"es_pack"`
-	CantidadPack int    `json:"cantidad_pack,omitempty"`
+	Codigo       string  `json:"codigo"`
+	Nombre       string  `json:"nombre"`
+	CodigoBarras string  `json:"codigo_barras"`
+	Precio       float64 `json:"precio"`
+	EsPack       bool    `json:"es_pack"`
+	CantidadPack int     `json:"cantidad_pack,omitempty"`
+
+	ImagenURL          string `json:"imagen_url,omitempty"`
+	ImagenMiniaturaURL string `json:"imagen_miniatura_url,omitempty"`
+
+	// Canales en que se vende; vacío: todos los canales
+	Canales []string `json:"canales,omitempty"`
+}
+
+// HabilitadoEnCanal indica si el producto se vende en el canal (sin canales definidos: en todos)
+func (p *ProductoPOSResponse) HabilitadoEnCanal(canal string) bool {
+	return CanalHabilitado(p.Canales, canal)
+}
+
+// Niveles de detalle del escaneo por código de barras (?detalle=)
+const (
+	// DetalleMinimo solo lo necesario para cobrar: nombre, precio, pack e imagen
+	DetalleMinimo = "minimo"
+	// DetalleCompleto el ProductoCompleto con listas de precios y vencimientos
+	DetalleCompleto = "completo"
+)
+
+// BusquedaBarcodeData datos de la respuesta del escaneo por código de barras
+// Se serializa sin reflexión (AppendJSON en pos_json.go): es el endpoint más llamado del POS
+type BusquedaBarcodeData struct {
+	Producto      *ProductoCompleto `json:"producto"`
+	CacheHit      bool              `json:"cache_hit"`
+	ModoDegradado bool              `json:"modo_degradado"`
+	LatencyMs     int64             `json:"latency_ms"`
+}
+
+// BusquedaBarcodeMinimaData datos de la respuesta del escaneo con ?detalle=minimo
+// Se serializa sin reflexión (AppendJSON en pos_json.go)
+type BusquedaBarcodeMinimaData struct {
+	Producto      *ProductoPOSResponse `json:"producto"`
+	CacheHit      bool                 `json:"cache_hit"`
+	ModoDegradado bool                 `json:"modo_degradado"`
+	LatencyMs     int64                `json:"latency_ms"`
+}
+
+// VentaRapidaData datos de la respuesta de una venta rápida procesada
+// Se serializa sin reflexión (AppendJSON en pos_json.go)
+type VentaRapidaData struct {
+	VentaID             int64        `json:"venta_id"`
+	IDOperacion         string       `json:"id_operacion"`
+	Totales             TotalesVenta `json:"totales"`
+	ProductosProcesados int          `json:"productos_procesados"`
+	TotalItems          int          `json:"total_items"`
+	VentaSospechosa     bool         `json:"venta_sospechosa"`
+	PreciosModificados  int          `json:"precios_modificados"`
+	LatencyMs           int64        `json:"latency_ms"`
+	Timestamp           string       `json:"timestamp"`
 }
 
 // StockResponse respuesta para consultas de stock
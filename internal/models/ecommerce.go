@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// StockPublicable stock de un producto expuesto a la tienda online
+// Cantidad es la suma, en los locales publicados, del stock menos lo reservado y el margen de seguridad
+type StockPublicable struct {
+	CodigoProducto string `json:"codigo_producto"`
+	Cantidad       int    `json:"cantidad"`
+	Disponible     bool   `json:"disponible"`
+}
+
+// MargenStockPublicable representa la tabla margenes_stock_publicable_cantera
+// Se define para un producto o para una categoría, nunca ambos
+type MargenStockPublicable struct {
+	ID             int       `json:"id" db:"id"`
+	CodigoProducto *string   `json:"codigo_producto,omitempty" db:"codigo_producto"`
+	IDCategoria    *int      `json:"id_categoria,omitempty" db:"id_categoria"`
+	Margen         int       `json:"margen" db:"margen"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MargenStockRequest DTO para definir el margen de seguridad de un producto o categoría
+type MargenStockRequest struct {
+	Margen *int `json:"margen" validate:"required,gte=0"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// EscaneoNoEncontrado código de barras desconocido con sus escaneos acumulados
+// (todos los locales o uno, según el filtro)
+type EscaneoNoEncontrado struct {
+	CodigoBarras string    `json:"codigo_barras" db:"codigo_barras"`
+	Veces        int       `json:"veces" db:"veces"`
+	PrimeraVez   time.Time `json:"primera_vez" db:"primera_vez"`
+	UltimaVez    time.Time `json:"ultima_vez" db:"ultima_vez"`
+	// Locales donde se escaneó (0: el POS no informó el local)
+	Locales []int `json:"locales" db:"locales"`
+}
+
+// EscaneoNoEncontradoFilter filtros del top de códigos desconocidos
+type EscaneoNoEncontradoFilter struct {
+	IDLocal *int
+	// Solo códigos escaneados desde esta fecha
+	Desde *time.Time
+	Limit int
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Campos de un movimiento disponibles para las columnas de un perfil de exportación ERP
+const (
+	CampoERPIDMovimiento      = "id_movimiento"
+	CampoERPFecha             = "fecha"
+	CampoERPHora              = "hora"
+	CampoERPCodigoProducto    = "codigo_producto"
+	CampoERPNombreProducto    = "nombre_producto"
+	CampoERPTipoItem          = "tipo_item"
+	CampoERPTipoMovimiento    = "tipo_movimiento"
+	CampoERPCodigoTransaccion = "codigo_transaccion"
+	CampoERPCantidad          = "cantidad"
+	CampoERPCantidadConSigno  = "cantidad_con_signo" // negativa en las salidas
+	CampoERPMotivo            = "motivo"
+	CampoERPIDLocal           = "id_local"
+	CampoERPIDUsuario         = "id_usuario"
+	CampoERPDocumentoTipo     = "documento_tipo"
+	CampoERPDocumentoNumero   = "documento_numero"
+	CampoERPDocumentoFecha    = "documento_fecha"
+	CampoERPIDOperacion       = "id_operacion"
+	CampoERPObservaciones     = "observaciones"
+	CampoERPConstante         = "constante" // valor fijo de la columna (sociedad, centro, etc.)
+)
+
+// PerfilExportacionERP representa la tabla perfiles_exportacion_erp_cantera
+// Layout de carga de movimientos en el ERP de finanzas
+type PerfilExportacionERP struct {
+	ID          int          `json:"id" db:"id"`
+	Nombre      string       `json:"nombre" db:"nombre"`
+	Descripcion *string      `json:"descripcion,omitempty" db:"descripcion"`
+	Columnas    []ColumnaERP `json:"columnas" db:"columnas"`
+	// Código de transacción por "tipo_movimiento" o "tipo_movimiento:motivo" (gana la clave con motivo)
+	CodigosTransaccion map[string]string `json:"codigos_transaccion" db:"codigos_transaccion"`
+	Delimitador        string            `json:"delimitador" db:"delimitador"`
+	IncluirEncabezado  bool              `json:"incluir_encabezado" db:"incluir_encabezado"`
+	// Formato de las fechas con YYYY, MM, DD, HH, mm y ss (ej. YYYYMMDD, DD.MM.YYYY)
+	FormatoFecha string    `json:"formato_fecha" db:"formato_fecha"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ColumnaERP columna del archivo de exportación
+type ColumnaERP struct {
+	Encabezado string `json:"encabezado" validate:"required,max=50"`
+	Campo      string `json:"campo" validate:"required,oneof=id_movimiento fecha hora codigo_producto nombre_producto tipo_item tipo_movimiento codigo_transaccion cantidad cantidad_con_signo motivo id_local id_usuario documento_tipo documento_numero documento_fecha id_operacion observaciones constante"`
+	Valor      string `json:"valor,omitempty" validate:"max=100"` // solo campo constante
+}
+
+// PerfilExportacionERPRequest DTO para crear o reemplazar un perfil de exportación
+type PerfilExportacionERPRequest struct {
+	Nombre             string            `json:"nombre" validate:"required,max=50"`
+	Descripcion        *string           `json:"descripcion,omitempty" validate:"omitempty,max=255"`
+	Columnas           []ColumnaERP      `json:"columnas" validate:"required,min=1,dive"`
+	CodigosTransaccion map[string]string `json:"codigos_transaccion,omitempty"`
+	// ";", ",", "|" o "tab" (vacío: ;)
+	Delimitador       string `json:"delimitador,omitempty"`
+	IncluirEncabezado *bool  `json:"incluir_encabezado,omitempty"` // vacío: true
+	// Formato de las fechas (vacío: YYYYMMDD)
+	FormatoFecha string `json:"formato_fecha,omitempty" validate:"omitempty,max=30"`
+}
+
+// MovimientoERP movimiento con los datos que puede exportar un perfil
+type MovimientoERP struct {
+	Movimiento
+	NombreProducto *string
+}
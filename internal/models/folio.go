@@ -0,0 +1,48 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tipos de documento con folio correlativo por local
+const (
+	TipoDocumentoVenta        = "venta"
+	TipoDocumentoGuiaDespacho = "guia_despacho"
+	TipoDocumentoNotaCredito  = "nota_credito"
+)
+
+// prefijosDocumento prefijo del número imprimible de cada tipo de documento
+var prefijosDocumento = map[string]string{
+	TipoDocumentoVenta:        "V",
+	TipoDocumentoGuiaDespacho: "GD",
+	TipoDocumentoNotaCredito:  "NC",
+}
+
+// EsTipoDocumento indica si el tipo de documento lleva folio
+func EsTipoDocumento(tipo string) bool {
+	_, ok := prefijosDocumento[tipo]
+	return ok
+}
+
+// NumeroDocumento formatea el folio como número de documento imprimible (ej: NC-003-000042)
+func NumeroDocumento(tipo string, idLocal int, folio int64) string {
+	return fmt.Sprintf("%s-%03d-%06d", prefijosDocumento[tipo], idLocal, folio)
+}
+
+// Folio representa la tabla folios_documentos_cantera
+// Último folio emitido de un tipo de documento en un local
+type Folio struct {
+	IDLocal       int       `json:"id_local" db:"id_local"`
+	TipoDocumento string    `json:"tipo_documento" db:"tipo_documento"`
+	UltimoFolio   int64     `json:"ultimo_folio" db:"ultimo_folio"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AvanzarFolioRequest fija el último folio emitido (ej: al migrar desde otra numeración)
+// Solo puede avanzar: retroceder generaría números duplicados
+type AvanzarFolioRequest struct {
+	IDLocal       int    `json:"id_local" validate:"required,gt=0"`
+	TipoDocumento string `json:"tipo_documento" validate:"required,oneof=venta guia_despacho nota_credito"`
+	UltimoFolio   int64  `json:"ultimo_folio" validate:"gte=0"`
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Estados de una guía de despacho entre locales
+const (
+	GuiaEstadoEmitida    = "emitida"     // stock descontado en origen, pendiente de despacho
+	GuiaEstadoEnTransito = "en_transito" // despachada, pendiente de recepción en destino
+	GuiaEstadoRecibiendo = "recibiendo"  // recepción en curso (ingresando stock en destino)
+	GuiaEstadoRecibida   = "recibida"    // stock ingresado en destino con las cantidades recibidas
+	GuiaEstadoAnulada    = "anulada"     // la emisión no pudo descontar el stock en origen
+)
+
+// Motivos de los movimientos que concilian las diferencias de una recepción
+const (
+	MotivoMermaTransporte    = "Merma de transporte"
+	MotivoSobranteTransporte = "Sobrante de transporte"
+)
+
+// GuiaDespacho representa la tabla guias_despacho_cantera
+// Documento de una transferencia de mercadería entre dos locales
+type GuiaDespacho struct {
+	ID                     int                 `json:"id" db:"id"`
+	Correlativo            int                 `json:"correlativo" db:"correlativo"`
+	Folio                  *int64              `json:"folio,omitempty" db:"folio"` // por local origen (nil: guía anterior a los folios)
+	Numero                 string              `json:"numero"`
+	IDLocalOrigen          int                 `json:"id_local_origen" db:"id_local_origen"`
+	IDLocalDestino         int                 `json:"id_local_destino" db:"id_local_destino"`
+	Estado                 string              `json:"estado" db:"estado"`
+	Observaciones          string              `json:"observaciones" db:"observaciones"`
+	IDUsuario              int                 `json:"id_usuario" db:"id_usuario"`
+	IDUsuarioRecepcion     *int                `json:"id_usuario_recepcion,omitempty" db:"id_usuario_recepcion"`
+	ObservacionesRecepcion string              `json:"observaciones_recepcion" db:"observaciones_recepcion"`
+	DespachadaAt           *time.Time          `json:"despachada_at,omitempty" db:"despachada_at"`
+	RecibidaAt             *time.Time          `json:"recibida_at,omitempty" db:"recibida_at"`
+	CreatedAt              time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt              time.Time           `json:"updated_at" db:"updated_at"`
+	Items                  []*GuiaDespachoItem `json:"items"`
+
+	// Operación que agrupa los movimientos de la transferencia (salida en origen y recepción en destino)
+	IDOperacion *string `json:"id_operacion,omitempty" db:"id_operacion"`
+}
+
+// NumeroGuia formatea la guía como número de documento imprimible: con su folio del local
+// origen, o con el correlativo global si se emitió antes de los folios
+func NumeroGuia(correlativo, idLocalOrigen int, folio *int64) string {
+	if folio != nil {
+		return NumeroDocumento(TipoDocumentoGuiaDespacho, idLocalOrigen, *folio)
+	}
+	return fmt.Sprintf("GD-%06d", correlativo)
+}
+
+// GuiaDespachoItem representa la tabla guia_despacho_items_cantera
+type GuiaDespachoItem struct {
+	ID               int    `json:"id" db:"id"`
+	IDGuia           int    `json:"id_guia" db:"id_guia"`
+	CodigoProducto   string `json:"codigo_producto" db:"codigo_producto"`
+	TipoItem         string `json:"tipo_item" db:"tipo_item"`
+	Descripcion      string `json:"descripcion" db:"descripcion"`
+	CantidadEnviada  int    `json:"cantidad_enviada" db:"cantidad_enviada"`
+	CantidadRecibida *int   `json:"cantidad_recibida,omitempty" db:"cantidad_recibida"`
+}
+
+// GuiaDespachoImprimible guía con los datos de los locales para su impresión
+type GuiaDespachoImprimible struct {
+	*GuiaDespacho
+	LocalOrigen  *Local `json:"local_origen"`
+	LocalDestino *Local `json:"local_destino"`
+}
+
+// GuiaEnTransito fila del reporte de guías despachadas pendientes de recepción
+type GuiaEnTransito struct {
+	ID              int       `json:"id"`
+	Numero          string    `json:"numero"`
+	IDLocalOrigen   int       `json:"id_local_origen"`
+	IDLocalDestino  int       `json:"id_local_destino"`
+	TotalItems      int       `json:"total_items"`
+	TotalUnidades   int       `json:"total_unidades"`
+	DespachadaAt    time.Time `json:"despachada_at"`
+	HorasEnTransito float64   `json:"horas_en_transito"`
+}
+
+// GuiaEnTransitoFilter filtros del reporte de guías en tránsito
+type GuiaEnTransitoFilter struct {
+	IDLocalOrigen  *int
+	IDLocalDestino *int
+}
+
+// EmitirGuiaRequest DTO para emitir una guía de despacho
+type EmitirGuiaRequest struct {
+	IDLocalOrigen  int              `json:"id_local_origen" validate:"required,gt=0"`
+	IDLocalDestino int              `json:"id_local_destino" validate:"required,gt=0,nefield=IDLocalOrigen"`
+	Observaciones  string           `json:"observaciones"`
+	Productos      []ProductoSalida `json:"productos" validate:"required,min=1,dive"`
+	IDUsuario      int              `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// ProductoRecibido cantidad realmente recibida de un ítem
+type ProductoRecibido struct {
+	CodigoProducto   string `json:"codigo_producto" validate:"required"`
+	CantidadRecibida int    `json:"cantidad_recibida" validate:"gte=0"`
+}
+
+// RecibirGuiaRequest DTO para confirmar la recepción de una guía
+// Los ítems no informados se consideran recibidos por la cantidad enviada
+type RecibirGuiaRequest struct {
+	Productos     []ProductoRecibido `json:"productos" validate:"dive"`
+	Observaciones string             `json:"observaciones"`
+	// Acepta diferencias que superan la tolerancia de recepción (RECEPTION_TOLERANCE_PERCENT)
+	ConfirmarDiferencias bool `json:"confirmar_diferencias"`
+	IDUsuario            int  `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// MermaTransporte diferencias de recepción agregadas por producto y ruta (origen -> destino)
+type MermaTransporte struct {
+	CodigoProducto   string `json:"codigo_producto"`
+	Descripcion      string `json:"descripcion"`
+	IDLocalOrigen    int    `json:"id_local_origen"`
+	IDLocalDestino   int    `json:"id_local_destino"`
+	Guias            int    `json:"guias"`
+	CantidadEnviada  int    `json:"cantidad_enviada"`
+	CantidadRecibida int    `json:"cantidad_recibida"`
+	Merma            int    `json:"merma"`    // faltantes: enviado y no recibido
+	Sobrante         int    `json:"sobrante"` // recibido de más
+}
+
+// ReporteMermasTransporte diferencias de las guías recibidas en el período
+type ReporteMermasTransporte struct {
+	Desde         time.Time          `json:"desde"`
+	Hasta         time.Time          `json:"hasta"`
+	Items         []*MermaTransporte `json:"items"`
+	TotalEnviado  int                `json:"total_enviado"`
+	TotalRecibido int                `json:"total_recibido"`
+	TotalMerma    int                `json:"total_merma"`
+	TotalSobrante int                `json:"total_sobrante"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// HistorialPrecio representa la tabla historial_precios_cantera
+// Un registro por período de vigencia de un precio de lista_precios_cantera
+type HistorialPrecio struct {
+	ID              int        `json:"id" db:"id"`
+	CodigoTivendo   string     `json:"codigo_tivendo" db:"codigo_tivendo"`
+	PrecioDetalle   *float64   `json:"precio_detalle" db:"precio_detalle"`
+	PrecioMayorista *float64   `json:"precio_mayorista" db:"precio_mayorista"`
+	VigenteDesde    time.Time  `json:"vigente_desde" db:"vigente_desde"`
+	VigenteHasta    *time.Time `json:"vigente_hasta,omitempty" db:"vigente_hasta"`
+	Operacion       string     `json:"operacion" db:"operacion"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// ImagenProducto representa la tabla imagenes_productos_cantera
+type ImagenProducto struct {
+	CodigoProducto string    `json:"codigo_producto" db:"codigo_producto"`
+	StorageKey     *string   `json:"-" db:"storage_key"`
+	ThumbnailKey   *string   `json:"-" db:"thumbnail_key"`
+	ContentType    *string   `json:"content_type,omitempty" db:"content_type"`
+	Hash           *string   `json:"hash,omitempty" db:"hash"`
+	Ancho          *int      `json:"ancho,omitempty" db:"ancho"`
+	Alto           *int      `json:"alto,omitempty" db:"alto"`
+	TamanoBytes    *int64    `json:"tamano_bytes,omitempty" db:"tamano_bytes"`
+	URL            string    `json:"url" db:"url"`
+	URLMiniatura   string    `json:"url_miniatura" db:"url_miniatura"`
+	IDUsuario      int       `json:"id_usuario" db:"id_usuario"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// EsExterna indica si la imagen se asoció desde una URL externa (no está en nuestro almacenamiento)
+func (i *ImagenProducto) EsExterna() bool {
+	return i.StorageKey == nil
+}
+
+// AsociarImagenRequest asocia una imagen ya publicada en otra URL
+type AsociarImagenRequest struct {
+	URL          string `json:"url" validate:"required,url"`
+	URLMiniatura string `json:"url_miniatura" validate:"omitempty,url"`
+	IDUsuario    int    `json:"id_usuario"`
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Motivos de ruptura de la cadena de movimientos
+const (
+	// RupturaHashAlterado los datos del movimiento no corresponden a su hash
+	RupturaHashAlterado = "hash_alterado"
+	// RupturaCadenaRota el hash anterior no es el del movimiento previo del local (borrado o reordenado)
+	RupturaCadenaRota = "cadena_rota"
+)
+
+// CalcularHash SHA-256 (hex) del movimiento encadenado con hashAnterior ("" en el primero del local)
+// Cubre todos los datos del movimiento; created_at se toma en UTC con precisión de microsegundos
+func (m *Movimiento) CalcularHash(hashAnterior string) string {
+	campos := []string{
+		strconv.Itoa(m.ID),
+		strconv.Itoa(m.IDLocal),
+		strconv.Quote(m.CodigoProducto),
+		strconv.Quote(m.TipoItem),
+		strconv.Quote(m.TipoMovimiento),
+		strconv.Itoa(m.Cantidad),
+		strconv.Itoa(m.CantidadAnterior),
+		strconv.Itoa(m.CantidadNueva),
+		strconv.Quote(m.Motivo),
+		strconv.Itoa(m.IDUsuario),
+		strconv.Quote(m.Observaciones),
+		m.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000"),
+		campoHash(m.DocumentoTipo),
+		campoHash(m.DocumentoNumero),
+		campoFechaHash(m.DocumentoFecha),
+		campoHash(m.Unidad),
+		campoEnteroHash(m.CantidadUnidad),
+		campoHash(m.IDOperacion),
+		campoEnteroHash(m.IDBodega),
+		campoEnteroHash(m.IDBodegaDestino),
+		hashAnterior,
+	}
+
+	suma := sha256.Sum256([]byte(strings.Join(campos, "|")))
+	return hex.EncodeToString(suma[:])
+}
+
+func campoHash(v *string) string {
+	if v == nil {
+		return "-"
+	}
+	return strconv.Quote(*v)
+}
+
+func campoEnteroHash(v *int) string {
+	if v == nil {
+		return "-"
+	}
+	return strconv.Itoa(*v)
+}
+
+func campoFechaHash(v *time.Time) string {
+	if v == nil {
+		return "-"
+	}
+	return v.Format("2006-01-02")
+}
+
+// RupturaCadena primer movimiento donde la cadena deja de ser verificable
+type RupturaCadena struct {
+	IDMovimiento   int       `json:"id_movimiento"`
+	CreatedAt      time.Time `json:"created_at"`
+	Motivo         string    `json:"motivo"`
+	HashEsperado   string    `json:"hash_esperado"`
+	HashRegistrado string    `json:"hash_registrado"`
+}
+
+// IntegridadMovimientos resultado de verificar la cadena de movimientos de un local en un rango de fechas
+type IntegridadMovimientos struct {
+	IDLocal    int        `json:"id_local"`
+	FechaDesde *time.Time `json:"fecha_desde,omitempty"`
+	FechaHasta *time.Time `json:"fecha_hasta,omitempty"`
+	Integra    bool       `json:"integra"`
+	// Verificados movimientos sellados recorridos hasta la primera ruptura (o todos)
+	Verificados int `json:"verificados"`
+	// SinSellar movimientos del rango anteriores al sellado (no forman parte de la cadena)
+	SinSellar      int            `json:"sin_sellar"`
+	PrimeraRuptura *RupturaCadena `json:"primera_ruptura,omitempty"`
+	VerificadoEn   time.Time      `json:"verificado_en"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Tipos de job asíncrono
+const (
+	JobExportacionStock       = "exportacion_stock"       // stock de un local en CSV o XLSX
+	JobExportacionMovimientos = "exportacion_movimientos" // movimientos filtrados en CSV o XLSX
+	JobImportacionPDT         = "importacion_pdt"         // archivo de PDT cargado en un conteo cíclico
+)
+
+// Estados de un job
+const (
+	JobPendiente  = "pendiente"
+	JobProcesando = "procesando"
+	JobCompletado = "completado"
+	JobFallido    = "fallido"
+)
+
+// EventoJobFinalizado tipo del evento (X-Webhook-Event-Type) enviado al callback del job
+const EventoJobFinalizado = "job.finalizado"
+
+// Job exportación o importación encolada para el worker de jobs
+// El estado y los archivos se conservan JOBS_RETENTION_HOURS tras encolarse
+type Job struct {
+	ID           string                    `json:"id"`
+	Tipo         string                    `json:"tipo"`
+	Estado       string                    `json:"estado"`
+	IDUsuario    int                       `json:"id_usuario"`
+	Exportacion  *ParametrosExportacion    `json:"exportacion,omitempty"`
+	Importacion  *ParametrosImportacionPDT `json:"importacion,omitempty"`
+	Resultado    *ResultadoJob             `json:"resultado,omitempty"`
+	Error        string                    `json:"error,omitempty"`
+	EncoladoAt   time.Time                 `json:"encolado_at"`
+	IniciadoAt   *time.Time                `json:"iniciado_at,omitempty"`
+	FinalizadoAt *time.Time                `json:"finalizado_at,omitempty"`
+	CallbackURL  string                    `json:"callback_url,omitempty"`
+	Callback     *EntregaCallback          `json:"callback,omitempty"` // nil hasta que termina
+}
+
+// ParametrosExportacion exportación pedida (la misma de /stock/local/:id/export o /movimientos/export)
+type ParametrosExportacion struct {
+	Formato string            `json:"formato"`
+	IDLocal int               `json:"id_local,omitempty"`
+	Filtro  *MovimientoFilter `json:"filtro,omitempty"`
+}
+
+// ParametrosImportacionPDT archivo de PDT a cargar en la sesión de conteo
+type ParametrosImportacionPDT struct {
+	IDConteo int64  `json:"id_conteo"`
+	Archivo  string `json:"archivo"`
+}
+
+// ResultadoJob resultado de un job completado
+type ResultadoJob struct {
+	Mensaje string `json:"mensaje"`
+	// Filas de datos exportadas
+	Filas int `json:"filas,omitempty"`
+	// Resultado de la carga del archivo de PDT
+	Importacion *ImportacionPDT `json:"importacion,omitempty"`
+	// Archivos generados: la exportación o las líneas rechazadas de la importación
+	Archivos []*ArchivoJob `json:"archivos"`
+}
+
+// ArchivoJob archivo generado por un job
+type ArchivoJob struct {
+	Nombre      string `json:"nombre"`
+	ContentType string `json:"content_type"`
+	// Enlace de descarga (requiere el mismo token que la consulta del job)
+	Enlace string `json:"enlace"`
+}
+
+// JobRequest callback opcional de los endpoints que encolan jobs
+type JobRequest struct {
+	// URL que recibe el resultado al terminar (POST firmado como los webhooks); vacío: solo polling
+	CallbackURL string `json:"callback_url" form:"callback_url"`
+}
+
+// CallbackJob cuerpo enviado al callback al terminar el job
+type CallbackJob struct {
+	Tipo string `json:"tipo"`
+	Job  *Job   `json:"job"`
+	// Enlace para consultar el estado; los de descarga van en job.resultado.archivos
+	Enlace string `json:"enlace"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// ActivarMantenimientoRequest activa el modo mantenimiento
+// Los campos vacíos usan MAINTENANCE_MESSAGE y MAINTENANCE_RETRY_AFTER_SECONDS
+type ActivarMantenimientoRequest struct {
+	Mensaje           string `json:"mensaje" validate:"omitempty,max=500"`
+	RetryAfterSeconds int    `json:"retry_after_seconds" validate:"omitempty,min=1,max=86400"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// MinimoEstacional representa la tabla minimos_estacionales_cantera
+// Entre Desde y Hasta (inclusive) reemplaza a la cantidad_minima del stock del producto en el local
+type MinimoEstacional struct {
+	ID             int       `json:"id" db:"id"`
+	CodigoProducto string    `json:"codigo_producto" db:"codigo_producto"`
+	IDLocal        int       `json:"id_local" db:"id_local"`
+	Temporada      string    `json:"temporada" db:"temporada"`
+	CantidadMinima int       `json:"cantidad_minima" db:"cantidad_minima"`
+	Desde          time.Time `json:"desde" db:"desde"`
+	Hasta          time.Time `json:"hasta" db:"hasta"`
+	IDUsuario      int       `json:"id_usuario" db:"id_usuario"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	Vigente        bool      `json:"vigente"`
+}
+
+// MinimoEstacionalRequest DTO para definir un mínimo estacional (fechas YYYY-MM-DD)
+type MinimoEstacionalRequest struct {
+	CodigoProducto string `json:"codigo_producto" validate:"required"`
+	IDLocal        int    `json:"id_local" validate:"required,gt=0"`
+	Temporada      string `json:"temporada" validate:"required,max=50"`
+	CantidadMinima int    `json:"cantidad_minima" validate:"gte=0"`
+	Desde          string `json:"desde" validate:"required"`
+	Hasta          string `json:"hasta" validate:"required"`
+	IDUsuario      int    `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// MinimoEstacionalFilter filtros del listado de mínimos estacionales
+type MinimoEstacionalFilter struct {
+	CodigoProducto *string
+	IDLocal        *int
+	SoloVigentes   bool
+}
@@ -10,9 +10,11 @@ type MonitoringResponse struct {
 	Database    DatabaseMetrics    `json:"database"`
 	System      SystemMetrics      `json:"system"`
 	Redis       RedisMetrics       `json:"redis"`
-	Timestamp   string             `json:"timestamp"`
-	Version     string             `json:"version"`
-	GeneratedBy string             `json:"generated_by"`
+	// Latencias del escaneo por código de barras (L1, L2, BD y total)
+	Barcode     BarcodeLatencyMetrics `json:"barcode"`
+	Timestamp   string                `json:"timestamp"`
+	Version     string                `json:"version"`
+	GeneratedBy string                `json:"generated_by"`
 }
 
 // RequestMetrics métricas de requests
@@ -25,6 +27,8 @@ type RequestMetrics struct {
 	SlowRequestsCount int                        `json:"slow_requests_count"`
 	ErrorsCount       int                        `json:"errors_count"`
 	TopEndpoints      []TopEndpoint              `json:"top_endpoints"`
+	// Errores acumulados por código clasificado (db_unavailable, validation, ...)
+	ErrorsByCode map[string]int `json:"errors_by_code"`
 }
 
 // EndpointMetrics métricas por endpoint
@@ -45,6 +49,8 @@ type SlowRequest struct {
 type RequestError struct {
 	Endpoint   string    `json:"endpoint"`
 	StatusCode int       `json:"statusCode"`
+	Code       string    `json:"code,omitempty"`
+	Message    string    `json:"message,omitempty"`
 	Timestamp  time.Time `json:"timestamp"`
 }
 
@@ -65,6 +71,22 @@ type PerformanceMetrics struct {
 	MinResponseTimeMs string  `json:"min_response_time_ms"`
 }
 
+// CacheVersionStatus versiones globales que la cache de productos considera vigentes (diagnóstico)
+type CacheVersionStatus struct {
+	ListaPrecios CacheVersionGlobal `json:"lista_precios"`
+	Productos    CacheVersionGlobal `json:"productos"`
+	// Última invalidación total de la cache (nil si no hubo en los últimos 7 días)
+	UltimaInvalidacionTotal *time.Time `json:"ultima_invalidacion_total"`
+	// Cada cuánto se consulta la BD para comparar la versión
+	IntervaloCheckSegundos int64 `json:"intervalo_check_segundos"`
+}
+
+// CacheVersionGlobal versión guardada en Redis y último check contra la BD
+type CacheVersionGlobal struct {
+	Version       string     `json:"version"` // "" si aún no se registró
+	UltimoCheckBD *time.Time `json:"ultimo_check_bd"`
+}
+
 // CacheMetrics métricas de cache
 type CacheMetrics struct {
 	Connected         bool           `json:"connected"`
@@ -76,15 +98,57 @@ type CacheMetrics struct {
 	TotalHits         int64          `json:"total_hits"`
 	TotalMisses       int64          `json:"total_misses"`
 	TotalRequests     int64          `json:"total_requests"`
+	TotalNotFound     int64          `json:"total_not_found"`
+	TotalLookupErrors int64          `json:"total_lookup_errors"`
+	// Misses por causa (nunca_cacheado, expirado, invalidado, desalojado, desconocido)
+	MissCauses map[string]int64 `json:"miss_causes"`
+
+	// Hit rate de la última hora (el acumulado se diluye con el tiempo desde el arranque)
+	HitRateUltimaHora           float64 `json:"hit_rate_ultima_hora"`
+	HitRateUltimaHoraPercentage string  `json:"hit_rate_ultima_hora_percentage"`
+	HitsUltimaHora              int64   `json:"hits_ultima_hora"`
+	MissesUltimaHora            int64   `json:"misses_ultima_hora"`
+
+	// Invalidaciones de stock que fallaron y esperan reintento (en mora)
+	PendingInvalidations             int   `json:"pending_invalidations"`
+	OldestPendingInvalidationSeconds int64 `json:"oldest_pending_invalidation_seconds"`
+	RetriedInvalidations             int64 `json:"retried_invalidations"`
+	DeadLetterInvalidations          int64 `json:"dead_letter_invalidations"`
 }
 
 // DatabaseMetrics métricas de base de datos
 type DatabaseMetrics struct {
-	ActiveConnections      int      `json:"activeConnections"`
-	TotalQueries           int64    `json:"totalQueries"`
-	SlowQueries            []string `json:"slowQueries"`
-	Status                 string   `json:"status"`
-	ActiveConnectionsCount int      `json:"active_connections"`
+	ActiveConnections      int                 `json:"activeConnections"`
+	TotalQueries           int64               `json:"totalQueries"`
+	SlowQueries            []string            `json:"slowQueries"`
+	Status                 string              `json:"status"`
+	ActiveConnectionsCount int                 `json:"active_connections"`
+	Pool                   DatabasePoolMetrics `json:"pool"`
+}
+
+// DatabasePoolMetrics estado del pool de conexiones a PostgreSQL
+type DatabasePoolMetrics struct {
+	MaxOpenConns int `json:"max_open_conns"`
+	MaxIdleConns int `json:"max_idle_conns"`
+	Open         int `json:"open"`
+	InUse        int `json:"in_use"`
+	Idle         int `json:"idle"`
+	// Acumulados desde el arranque: esperas por una conexión libre y tiempo total esperado
+	WaitCount      int64   `json:"wait_count"`
+	WaitDurationMs float64 `json:"wait_duration_ms"`
+	// Última ventana de verificación: esperas y espera promedio
+	RecentWaits      int64      `json:"recent_waits"`
+	RecentAvgWaitMs  float64    `json:"recent_avg_wait_ms"`
+	WaitAlert        bool       `json:"wait_alert"`
+	WaitAlertSince   *time.Time `json:"wait_alert_since,omitempty"`
+	AlertThresholdMs float64    `json:"alert_threshold_ms"`
+}
+
+// AjustarPoolRequest ajusta en caliente los límites del pool de PostgreSQL
+// Sin max_idle_conns se conserva el actual (acotado a max_open_conns)
+type AjustarPoolRequest struct {
+	MaxOpenConns int  `json:"max_open_conns" validate:"required,min=1,max=1000"`
+	MaxIdleConns *int `json:"max_idle_conns" validate:"omitempty,min=0,max=1000"`
 }
 
 // SystemMetrics métricas del sistema
@@ -126,10 +190,46 @@ type RedisMetrics struct {
 
 // RequestData datos de un request individual
 type RequestData struct {
-	Endpoint   string
+	Endpoint string
+	// Ruta registrada en el router (/api/v1/pos/producto/:codigo); "" si ninguna coincidió
+	Route      string
 	Method     string
 	Duration   time.Duration
 	StatusCode int
 	Timestamp  time.Time
-	Error      error
+	// Error registrado por el handler (c.Error) y su código clasificado
+	Error     error
+	ErrorCode string
+}
+
+// BarcodeLatencyMetrics latencias del camino crítico de escaneo (búsqueda por código de barras)
+// Los buckets acumulan desde el arranque (o el último reset); los percentiles se calculan
+// sobre las últimas muestras, para que una regresión se note sin esperar a diluir el histórico
+type BarcodeLatencyMetrics struct {
+	SLAMs float64 `json:"sla_ms"`
+	// Porcentaje de escaneos completos dentro del SLA
+	WithinSLAPercentage float64          `json:"within_sla_percentage"`
+	L1                  LatencyHistogram `json:"l1"`
+	L2                  LatencyHistogram `json:"l2"`
+	Database            LatencyHistogram `json:"database"`
+	Total               LatencyHistogram `json:"total"`
+	Since               time.Time        `json:"since"`
+}
+
+// LatencyHistogram histograma de latencias de una etapa
+type LatencyHistogram struct {
+	Count   int64           `json:"count"`
+	AvgMs   float64         `json:"avg_ms"`
+	P50Ms   float64         `json:"p50_ms"`
+	P95Ms   float64         `json:"p95_ms"`
+	P99Ms   float64         `json:"p99_ms"`
+	MaxMs   float64         `json:"max_ms"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// LatencyBucket cantidad de muestras con latencia hasta Le (en ms, "+Inf" el último)
+// y mayor al límite del bucket anterior
+type LatencyBucket struct {
+	Le    string `json:"le"`
+	Count int64  `json:"count"`
 }
@@ -4,6 +4,24 @@ import (
 	"time"
 )
 
+// ObservacionVentaPOS prefijo de las observaciones de las salidas generadas por ventas del POS
+// Permite distinguir ventas de otras salidas en los reportes
+const ObservacionVentaPOS = "[POS]"
+
+// Tipos de documento de respaldo de una entrada
+const (
+	DocumentoFactura      = "factura"
+	DocumentoGuiaDespacho = "guia_despacho"
+	DocumentoNotaCredito  = "nota_credito" // reingreso de una venta anulada (solo interno)
+)
+
+// DocumentoRespaldo documento del proveedor (factura o guía de despacho) que respalda una entrada
+type DocumentoRespaldo struct {
+	Tipo   string `json:"tipo" validate:"required,oneof=factura guia_despacho"`
+	Numero string `json:"numero" validate:"required,max=50"`
+	Fecha  string `json:"fecha" validate:"required,datetime=2006-01-02"`
+}
+
 // Movimiento representa la tabla stock_movimientos_cantera
 type Movimiento struct {
 	ID               int       `json:"id" db:"id"`
@@ -18,6 +36,27 @@ type Movimiento struct {
 	IDLocal          int       `json:"id_local" db:"id_local"`
 	Observaciones    string    `json:"observaciones" db:"observaciones"`
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+
+	// Documento de respaldo (solo entradas que lo indicaron)
+	DocumentoTipo   *string    `json:"documento_tipo,omitempty" db:"documento_tipo"`
+	DocumentoNumero *string    `json:"documento_numero,omitempty" db:"documento_numero"`
+	DocumentoFecha  *time.Time `json:"documento_fecha,omitempty" db:"documento_fecha"`
+
+	// Unidad y cantidad informadas cuando la operación no vino en unidad base
+	// (Cantidad queda siempre en unidad base)
+	Unidad         *string `json:"unidad,omitempty" db:"unidad"`
+	CantidadUnidad *int    `json:"cantidad_unidad,omitempty" db:"cantidad_unidad"`
+
+	// Operación (entrada, salida, venta o transferencia) que agrupa al movimiento
+	IDOperacion *string `json:"id_operacion,omitempty" db:"id_operacion"`
+
+	// Bodega del local afectada (origen en un traslado interno) y destino del traslado
+	IDBodega        *int `json:"id_bodega,omitempty" db:"id_bodega"`
+	IDBodegaDestino *int `json:"id_bodega_destino,omitempty" db:"id_bodega_destino"`
+
+	// Sello de la cadena de movimientos del local (nil en movimientos anteriores al sellado)
+	Hash         *string `json:"hash,omitempty" db:"hash"`
+	HashAnterior *string `json:"hash_anterior,omitempty" db:"hash_anterior"`
 }
 
 // MovimientoWithDetails incluye información adicional
@@ -38,4 +77,47 @@ type MovimientoFilter struct {
 	FechaHasta     *time.Time `json:"fecha_hasta,omitempty"`
 	Limit          int        `json:"limit,omitempty"`
 	Offset         int        `json:"offset,omitempty"`
+
+	// Movimientos respaldados por un documento (tipo y/o número)
+	DocumentoTipo   *string `json:"documento_tipo,omitempty"`
+	DocumentoNumero *string `json:"documento_numero,omitempty"`
+
+	// Movimientos de una misma operación
+	IDOperacion *string `json:"id_operacion,omitempty"`
+
+	// Movimientos que afectan a una bodega del local (como origen o destino)
+	IDBodega *int `json:"id_bodega,omitempty"`
+}
+
+// PaginaMovimientos página del historial de movimientos con el total que cumple los filtros
+type PaginaMovimientos struct {
+	Movimientos []*Movimiento `json:"movimientos"`
+	Total       int           `json:"total"`
+	Limit       int           `json:"limit"`
+	Offset      int           `json:"offset"`
+}
+
+// Agrupaciones de movimientos (GET /movimientos?group_by=)
+const (
+	AgrupacionMovimientosDia      = "dia"
+	AgrupacionMovimientosProducto = "producto"
+	AgrupacionMovimientosUsuario  = "usuario"
+	AgrupacionMovimientosMotivo   = "motivo"
+)
+
+// MovimientoAgrupado agregado de los movimientos de un grupo
+type MovimientoAgrupado struct {
+	// Grupo fecha (YYYY-MM-DD), código de producto, id de usuario o motivo según la agrupación
+	Grupo       string `json:"grupo"`
+	Cantidad    int64  `json:"cantidad"` // suma de cantidades (unidad base)
+	Movimientos int    `json:"movimientos"`
+}
+
+// PaginaMovimientosAgrupados página de agregados de movimientos
+// Por día los grupos van en orden cronológico; el resto de mayor a menor cantidad
+type PaginaMovimientosAgrupados struct {
+	Agrupacion string                `json:"agrupacion"`
+	Grupos     []*MovimientoAgrupado `json:"grupos"`
+	Limit      int                   `json:"limit"`
+	Offset     int                   `json:"offset"`
 }
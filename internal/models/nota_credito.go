@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// Estados de una nota de crédito
+const (
+	NotaCreditoEstadoEmitida  = "emitida"  // folio asignado, reingresando el stock
+	NotaCreditoEstadoAplicada = "aplicada" // stock reingresado y monto devuelto registrado
+	NotaCreditoEstadoAnulada  = "anulada"  // no se pudo reingresar el stock
+)
+
+// MotivoNotaCredito motivo de las entradas que reingresan el stock de una venta anulada
+const MotivoNotaCredito = "Nota de crédito"
+
+// NotaCredito representa la tabla notas_credito_cantera
+// Anula total o parcialmente una venta del POS ya cerrada (identificada por su operación)
+type NotaCredito struct {
+	ID               int64              `json:"id" db:"id"`
+	IDLocal          int                `json:"id_local" db:"id_local"`
+	Folio            int64              `json:"folio" db:"folio"`
+	Numero           string             `json:"numero"`
+	IDOperacionVenta string             `json:"id_operacion_venta" db:"id_operacion_venta"`
+	VentaRef         *int64             `json:"venta_id,omitempty" db:"venta_ref"`
+	Estado           string             `json:"estado" db:"estado"`
+	Motivo           string             `json:"motivo" db:"motivo"`
+	MedioPago        string             `json:"medio_pago" db:"medio_pago"`
+	Monto            float64            `json:"monto" db:"monto"`
+	IDUsuario        int                `json:"id_usuario" db:"id_usuario"`
+	IDAutorizador    int                `json:"id_autorizador" db:"id_autorizador"`
+	RolAutorizador   string             `json:"rol_autorizador" db:"rol_autorizador"`
+	IDOperacion      *string            `json:"id_operacion,omitempty" db:"id_operacion"` // reingreso del stock
+	CreatedAt        time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at" db:"updated_at"`
+	Items            []*NotaCreditoItem `json:"items"`
+}
+
+// NotaCreditoItem representa la tabla nota_credito_items_cantera
+type NotaCreditoItem struct {
+	ID             int64  `json:"id" db:"id"`
+	IDNota         int64  `json:"id_nota" db:"id_nota"`
+	CodigoProducto string `json:"codigo_producto" db:"codigo_producto" validate:"required"`
+	TipoItem       string `json:"tipo_item" db:"tipo_item" validate:"required,oneof=producto pack"`
+	Cantidad       int    `json:"cantidad" db:"cantidad" validate:"required,gt=0"`
+}
+
+// EmitirNotaCreditoRequest solicitud de nota de crédito sobre una venta del POS
+type EmitirNotaCreditoRequest struct {
+	// Operación de la venta (id_operacion devuelto por la venta rápida)
+	IDOperacionVenta string `json:"id_operacion_venta" validate:"required,max=36"`
+	VentaRef         *int64 `json:"venta_id,omitempty"`
+	Motivo           string `json:"motivo" validate:"required,max=255"`
+	// Medio de pago por el que se devuelve el monto
+	MedioPago string  `json:"medio_pago" validate:"required,max=30"`
+	Monto     float64 `json:"monto" validate:"gte=0"`
+	// Ítems a anular (vacío: todo lo vendido que aún no tiene nota de crédito)
+	Items []*NotaCreditoItem `json:"items,omitempty" validate:"omitempty,dive"`
+	// Supervisor que autoriza la nota; su rol llega en el header X-User-Role
+	IDAutorizador  int    `json:"id_autorizador" validate:"required,gt=0"`
+	RolAutorizador string `json:"-"`
+	IDUsuario      int    `json:"-"`
+}
+
+// ItemVentaAcreditable producto de una venta con lo vendido y lo ya anulado por notas de crédito
+type ItemVentaAcreditable struct {
+	CodigoProducto string `json:"codigo_producto"`
+	TipoItem       string `json:"tipo_item"`
+	Vendido        int    `json:"vendido"`
+	Acreditado     int    `json:"acreditado"`
+}
+
+// VentaAcreditable venta del POS (salidas de una operación) sobre la que se emiten notas de crédito
+type VentaAcreditable struct {
+	IDOperacion string                  `json:"id_operacion"`
+	IDLocal     int                     `json:"id_local"`
+	Fecha       time.Time               `json:"fecha"`
+	Items       []*ItemVentaAcreditable `json:"items"`
+}
+
+// NotasCreditoMedioPago total devuelto por notas de crédito en un local y medio de pago
+type NotasCreditoMedioPago struct {
+	IDLocal   int     `json:"id_local"`
+	MedioPago string  `json:"medio_pago"`
+	Notas     int     `json:"notas"`
+	Unidades  int     `json:"unidades"`
+	Monto     float64 `json:"monto"`
+}
+
+// ReporteNotasCredito notas de crédito aplicadas en un período
+type ReporteNotasCredito struct {
+	Filtros    *ReporteFilter           `json:"filtros"`
+	TotalNotas int                      `json:"total_notas"`
+	MontoTotal float64                  `json:"monto_total"`
+	Resumen    []*NotasCreditoMedioPago `json:"resumen"`
+}
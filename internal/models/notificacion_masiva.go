@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Tipos de notificación de actualización masiva
+const (
+	NotificacionListaPrecios = "lista_precios" // lista_precios_cantera actualizada
+	NotificacionProductos    = "productos"     // productos/packs actualizados
+)
+
+// Estados de una notificación encolada
+const (
+	NotificacionPendiente  = "pendiente"
+	NotificacionProcesando = "procesando"
+	NotificacionCompletada = "completada"
+	NotificacionFallida    = "fallida"
+)
+
+// Estados de la entrega del resultado al callback del solicitante
+const (
+	CallbackPendiente = "pendiente"
+	CallbackEntregado = "entregado"
+	CallbackFallido   = "fallido" // agotó los reintentos; el resultado sigue disponible por polling
+)
+
+// EventoNotificacionMasivaFinalizada tipo del evento (X-Webhook-Event-Type) enviado al callback
+const EventoNotificacionMasivaFinalizada = "pos.notificacion_masiva.finalizada"
+
+// NotificacionMasivaRequest body opcional de las notificaciones de actualización masiva
+type NotificacionMasivaRequest struct {
+	// URL que recibe el resultado al terminar (POST firmado como los webhooks); vacío: solo polling
+	CallbackURL string `json:"callback_url"`
+}
+
+// NotificacionMasiva notificación de actualización masiva encolada para el worker
+// Se procesan de a una y en orden de llegada; el resultado se consulta por ID
+type NotificacionMasiva struct {
+	ID           string                       `json:"id"`
+	Tipo         string                       `json:"tipo"`
+	Estado       string                       `json:"estado"`
+	Resultado    *ResultadoNotificacionMasiva `json:"resultado,omitempty"`
+	Error        string                       `json:"error,omitempty"`
+	EncoladaAt   time.Time                    `json:"encolada_at"`
+	IniciadaAt   *time.Time                   `json:"iniciada_at,omitempty"`
+	FinalizadaAt *time.Time                   `json:"finalizada_at,omitempty"`
+	CallbackURL  string                       `json:"callback_url,omitempty"`
+	Callback     *EntregaCallback             `json:"callback,omitempty"` // nil hasta que termina
+}
+
+// EntregaCallback estado del aviso del resultado al callback del solicitante
+type EntregaCallback struct {
+	Estado      string     `json:"estado"`
+	Intentos    int        `json:"intentos"`
+	UltimoError string     `json:"ultimo_error,omitempty"`
+	EntregadoAt *time.Time `json:"entregado_at,omitempty"`
+}
+
+// CallbackNotificacionMasiva cuerpo enviado al callback al terminar la notificación
+type CallbackNotificacionMasiva struct {
+	Tipo         string              `json:"tipo"`
+	Notificacion *NotificacionMasiva `json:"notificacion"`
+	// Enlace para consultar el estado (el resultado se conserva 24 horas)
+	Enlace string `json:"enlace"`
+}
+
+// ResultadoNotificacionMasiva invalidación de cache aplicada por la notificación
+type ResultadoNotificacionMasiva struct {
+	Mensaje     string `json:"mensaje"`
+	Version     string `json:"version,omitempty"` // timestamp de lista_precios_cantera
+	Invalidated bool   `json:"invalidated"`
+}
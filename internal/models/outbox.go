@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Tipos de evento publicados a los webhooks
+const (
+	EventoStockMovimiento      = "stock.movimiento"
+	EventoVencimientosProximos = "stock.vencimientos_proximos"
+	EventoLotesVencidosBaja    = "stock.lotes_vencidos_baja"
+	EventoQuiebreCritico       = "stock.quiebre_critico"
+)
+
+// EventoOutbox representa la tabla outbox_eventos_cantera
+// Se escribe en la misma transacción que la operación que lo origina; el dispatcher lo entrega después
+type EventoOutbox struct {
+	ID               int64           `json:"id" db:"id"`
+	Tipo             string          `json:"tipo" db:"tipo"`
+	Clave            *string         `json:"clave,omitempty" db:"clave"`
+	Payload          json.RawMessage `json:"payload" db:"payload"`
+	Intentos         int             `json:"intentos" db:"intentos"`
+	ProximoIntentoAt time.Time       `json:"proximo_intento_at" db:"proximo_intento_at"`
+	UltimoError      *string         `json:"ultimo_error,omitempty" db:"ultimo_error"`
+	EnviadoAt        *time.Time      `json:"enviado_at,omitempty" db:"enviado_at"`
+	DescartadoAt     *time.Time      `json:"descartado_at,omitempty" db:"descartado_at"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+}
+
+// EventoWebhook cuerpo enviado a cada webhook
+type EventoWebhook struct {
+	ID        int64           `json:"id"`
+	Tipo      string          `json:"tipo"`
+	Clave     *string         `json:"clave,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ResumenOutbox estado de la outbox
+type ResumenOutbox struct {
+	Pendientes int `json:"pendientes"`
+	// Pendientes con al menos un intento fallido
+	EnReintento int `json:"en_reintento"`
+	Descartados int `json:"descartados"`
+	// Antigüedad del evento pendiente más viejo
+	MasAntiguoSegundos int64 `json:"mas_antiguo_segundos"`
+	// Últimos eventos descartados (más reciente primero)
+	DescartadosRecientes []*EventoOutbox `json:"descartados_recientes"`
+}
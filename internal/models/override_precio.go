@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// OverridePrecio representa la tabla overrides_precio_cantera
+// Línea de una venta rápida cobrada a un precio distinto al de lista, con autorización
+type OverridePrecio struct {
+	ID             int       `json:"id" db:"id"`
+	IDLocal        int       `json:"id_local" db:"id_local"`
+	CodigoProducto string    `json:"codigo_producto" db:"codigo_producto"`
+	TipoItem       string    `json:"tipo_item" db:"tipo_item"`
+	Cantidad       int       `json:"cantidad" db:"cantidad"`
+	PrecioOriginal float64   `json:"precio_original" db:"precio_original"`
+	PrecioAplicado float64   `json:"precio_aplicado" db:"precio_aplicado"`
+	Motivo         string    `json:"motivo" db:"motivo"`
+	IDUsuario      int       `json:"id_usuario" db:"id_usuario"`
+	IDAutorizador  int       `json:"id_autorizador" db:"id_autorizador"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// Diferencia monto dejado de cobrar en la línea (negativo si se cobró de más)
+func (o *OverridePrecio) Diferencia() float64 {
+	return (o.PrecioOriginal - o.PrecioAplicado) * float64(o.Cantidad)
+}
+
+// ReporteOverridesPrecio precios modificados en ventas del período
+type ReporteOverridesPrecio struct {
+	Desde          time.Time         `json:"desde"`
+	Hasta          time.Time         `json:"hasta"`
+	Overrides      []*OverridePrecio `json:"overrides"`
+	TotalOverrides int               `json:"total_overrides"`
+	TotalDescuento float64           `json:"total_descuento"` // rebajas sobre el precio de lista
+	TotalRecargo   float64           `json:"total_recargo"`   // cobros sobre el precio de lista
+}
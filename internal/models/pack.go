@@ -0,0 +1,42 @@
+package models
+
+// ComponentePack artículo incluido en un pack (una fila de pack_listados)
+type ComponentePack struct {
+	CodigoArticulo   string  `json:"codigo_articulo"`
+	NombreArticulo   string  `json:"nombre_articulo"`
+	CodBarraArticulo string  `json:"cod_barra_articulo"`
+	Cantidad         int     `json:"cantidad"`
+	PrecioUnitario   float64 `json:"precio_unitario"`
+	Subtotal         float64 `json:"subtotal"`
+}
+
+// PackCatalogo pack con sus componentes y precios calculados
+// Precio: lista de precios detalle del pack si existe, si no el precio base de pack_listados
+// PrecioComponentes: lo que costarían los artículos comprados por separado
+type PackCatalogo struct {
+	CodigoPack         string           `json:"codigo_pack"`
+	NombrePack         string           `json:"nombre_pack"`
+	CodBarraPack       string           `json:"cod_barra_pack"`
+	PrecioBase         float64          `json:"precio_base"`
+	ListaPrecioDetalle *float64         `json:"lista_precio_detalle,omitempty"`
+	Precio             float64          `json:"precio"`
+	PrecioComponentes  float64          `json:"precio_componentes"`
+	Ahorro             float64          `json:"ahorro"`
+	Componentes        []ComponentePack `json:"componentes"`
+}
+
+// AgregarComponente suma el componente al pack y recalcula los totales
+func (p *PackCatalogo) AgregarComponente(componente ComponentePack) {
+	componente.Subtotal = componente.PrecioUnitario * float64(componente.Cantidad)
+	p.Componentes = append(p.Componentes, componente)
+	p.PrecioComponentes += componente.Subtotal
+	p.Ahorro = p.PrecioComponentes - p.Precio
+}
+
+// PaginaPacks página del catálogo de packs
+type PaginaPacks struct {
+	Packs  []*PackCatalogo `json:"packs"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// Estados de una preparación de picking
+const (
+	PickingEstadoPreparado   = "preparado"   // ítems reservados, pendiente de confirmar
+	PickingEstadoConfirmando = "confirmando" // confirmación en curso (descontando stock)
+	PickingEstadoConfirmado  = "confirmado"  // stock descontado con las cantidades pickeadas
+	PickingEstadoCancelado   = "cancelado"
+	PickingEstadoExpirado    = "expirado" // abandonada, la reserva se liberó automáticamente
+)
+
+// Picking representa la tabla pickings_cantera
+// Preparación de una salida grande: reserva los ítems hasta su confirmación o expiración
+type Picking struct {
+	ID             int            `json:"id" db:"id"`
+	IDLocal        int            `json:"id_local" db:"id_local"`
+	IDLocalDestino *int           `json:"id_local_destino,omitempty" db:"id_local_destino"`
+	Estado         string         `json:"estado" db:"estado"`
+	Motivo         string         `json:"motivo" db:"motivo"`
+	Observaciones  string         `json:"observaciones" db:"observaciones"`
+	IDUsuario      int            `json:"id_usuario" db:"id_usuario"`
+	ExpiresAt      time.Time      `json:"expires_at" db:"expires_at"`
+	ConfirmedAt    *time.Time     `json:"confirmed_at,omitempty" db:"confirmed_at"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+	Items          []*PickingItem `json:"items"`
+}
+
+// PickingItem representa la tabla picking_items_cantera
+type PickingItem struct {
+	ID                 int    `json:"id" db:"id"`
+	IDPicking          int    `json:"id_picking" db:"id_picking"`
+	CodigoProducto     string `json:"codigo_producto" db:"codigo_producto"`
+	TipoItem           string `json:"tipo_item" db:"tipo_item"`
+	CantidadSolicitada int    `json:"cantidad_solicitada" db:"cantidad_solicitada"`
+	CantidadPickeada   *int   `json:"cantidad_pickeada,omitempty" db:"cantidad_pickeada"`
+	// Ubicación del producto en el local (nil si no tiene una registrada)
+	Ubicacion *UbicacionFisica `json:"ubicacion,omitempty"`
+}
+
+// PrepararPickingRequest DTO para preparar un picking (reserva de ítems)
+type PrepararPickingRequest struct {
+	IDLocal        int              `json:"id_local" validate:"required,gt=0"`
+	IDLocalDestino *int             `json:"id_local_destino" validate:"omitempty,gt=0"`
+	Motivo         string           `json:"motivo" validate:"required"`
+	Observaciones  string           `json:"observaciones"`
+	Productos      []ProductoSalida `json:"productos" validate:"required,min=1,dive"`
+	IDUsuario      int              `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// ProductoPickeado cantidad realmente pickeada de un ítem
+type ProductoPickeado struct {
+	CodigoProducto   string `json:"codigo_producto" validate:"required"`
+	CantidadPickeada int    `json:"cantidad_pickeada" validate:"gte=0"`
+}
+
+// ConfirmarPickingRequest DTO para confirmar un picking
+// Los ítems no informados se consideran pickeados por la cantidad solicitada
+type ConfirmarPickingRequest struct {
+	Productos []ProductoPickeado `json:"productos" validate:"dive"`
+	IDUsuario int                `json:"-"` // Se obtiene del contexto de autenticación
+}
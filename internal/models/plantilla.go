@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+)
+
+// PlantillaEntrada representa la tabla plantillas_entrada_cantera
+// Entrada múltiple recurrente guardada para ejecutarse sin rearmar la lista de productos
+type PlantillaEntrada struct {
+	ID            int                     `json:"id" db:"id"`
+	Nombre        string                  `json:"nombre" db:"nombre"`
+	Proveedor     string                  `json:"proveedor" db:"proveedor"`
+	IDLocal       int                     `json:"id_local" db:"id_local"`
+	Motivo        string                  `json:"motivo" db:"motivo"`
+	Observaciones string                  `json:"observaciones" db:"observaciones"`
+	IDUsuario     int                     `json:"id_usuario" db:"id_usuario"`
+	CreatedAt     time.Time               `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at" db:"updated_at"`
+	TotalItems    int                     `json:"total_items"`
+	Items         []*PlantillaEntradaItem `json:"items,omitempty"` // solo al obtener una plantilla
+}
+
+// PlantillaEntradaItem representa la tabla plantilla_entrada_items_cantera
+type PlantillaEntradaItem struct {
+	ID             int    `json:"id" db:"id"`
+	IDPlantilla    int    `json:"id_plantilla" db:"id_plantilla"`
+	CodigoProducto string `json:"codigo_producto" db:"codigo_producto"`
+	TipoItem       string `json:"tipo_item" db:"tipo_item"`
+	Cantidad       int    `json:"cantidad" db:"cantidad"`
+	CantidadMinima int    `json:"cantidad_minima" db:"cantidad_minima"`
+	Unidad         string `json:"unidad,omitempty" db:"unidad"`
+}
+
+// PlantillaEntradaRequest DTO para crear o reemplazar una plantilla
+type PlantillaEntradaRequest struct {
+	Nombre        string            `json:"nombre" validate:"required,max=100"`
+	Proveedor     string            `json:"proveedor" validate:"max=100"`
+	IDLocal       int               `json:"id_local" validate:"required,gt=0"`
+	Motivo        string            `json:"motivo" validate:"required"`
+	Observaciones string            `json:"observaciones"`
+	Productos     []ProductoEntrada `json:"productos" validate:"required,min=1,dive"`
+	IDUsuario     int               `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// AjusteCantidadPlantilla cantidad a usar para un ítem en esta ejecución (0 lo omite)
+type AjusteCantidadPlantilla struct {
+	CodigoProducto string `json:"codigo_producto" validate:"required"`
+	Cantidad       int    `json:"cantidad" validate:"gte=0"`
+}
+
+// EjecutarPlantillaRequest DTO para ejecutar una plantilla como entrada múltiple
+// Los ítems sin ajuste se ingresan con la cantidad típica de la plantilla
+type EjecutarPlantillaRequest struct {
+	IDLocal       *int                      `json:"id_local" validate:"omitempty,gt=0"` // otro local que el de la plantilla
+	Ajustes       []AjusteCantidadPlantilla `json:"ajustes" validate:"dive"`
+	Observaciones string                    `json:"observaciones"`
+	Documento     *DocumentoRespaldo        `json:"documento,omitempty"`
+	IDUsuario     int                       `json:"-"` // Se obtiene del contexto de autenticación
+	DryRun        bool                      `json:"-"` // Se obtiene del query param dry_run
+}
+
+// EjecucionPlantillaResponse resultado de ejecutar una plantilla
+// Si la entrada supera los umbrales de aprobación queda como solicitud pendiente
+type EjecucionPlantillaResponse struct {
+	Entrada   *EntradaMultipleStockResponse `json:"entrada,omitempty"`
+	Solicitud *SolicitudAprobacion          `json:"solicitud,omitempty"`
+}
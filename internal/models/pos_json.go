@@ -0,0 +1,248 @@
+package models
+
+import "stock-service/internal/jsonenc"
+
+// Serialización sin reflexión de las respuestas del hot path del POS
+// Producen lo mismo que encoding/json: al agregar un campo a estos structs hay que
+// agregarlo aquí también, en el mismo orden y con el mismo omitempty
+
+// AppendJSON agrega el producto como JSON
+func (p *ProductoCompleto) AppendJSON(b []byte) []byte {
+	if p == nil {
+		return append(b, "null"...)
+	}
+
+	b = append(b, '{')
+	if p.ID != nil {
+		b = jsonenc.AppendKey(b, "id")
+		b = jsonenc.AppendInt(b, int64(*p.ID))
+	}
+	b = jsonenc.AppendKey(b, "codigo")
+	b = jsonenc.AppendString(b, p.Codigo)
+	b = jsonenc.AppendKey(b, "nombre")
+	b = jsonenc.AppendString(b, p.Nombre)
+	b = appendOptString(b, "unidad", p.Unidad)
+	b = appendOptFloat(b, "precio", p.Precio)
+	b = appendOptString(b, "codigo_barra_interno", p.CodigoBarraInterno)
+	b = appendOptString(b, "codigo_barra_externo", p.CodigoBarraExterno)
+	b = appendOptString(b, "descripcion", p.Descripcion)
+	b = appendOptBool(b, "es_servicio", p.EsServicio)
+	b = appendOptBool(b, "es_exento", p.EsExento)
+	b = appendOptFloat(b, "impuesto_especifico", p.ImpuestoEspecifico)
+	b = appendOptInt(b, "id_categoria", p.IDCategoria)
+	b = appendOptBool(b, "disponible_para_venta", p.DisponibleParaVenta)
+	b = appendOptBool(b, "activo", p.Activo)
+	b = appendOptFloat(b, "utilidad", p.Utilidad)
+	b = appendOptString(b, "tipo_utilidad", p.TipoUtilidad)
+
+	b = jsonenc.AppendKey(b, "origen")
+	b = jsonenc.AppendString(b, p.Origen)
+	b = jsonenc.AppendKey(b, "codigo_final")
+	b = jsonenc.AppendString(b, p.CodigoFinal)
+
+	b = appendOptString(b, "codigo_pack", p.CodigoPack)
+	b = appendOptString(b, "nombre_pack", p.NombrePack)
+	b = appendOptFloat(b, "precio_base", p.PrecioBase)
+	b = appendOptInt(b, "cantidad_articulo", p.CantidadArticulo)
+	b = appendOptString(b, "codigo_articulo", p.CodigoArticulo)
+	b = appendOptString(b, "cod_barra_articulo", p.CodBarraArticulo)
+	b = appendOptString(b, "nombre_articulo", p.NombreArticulo)
+
+	b = appendOptFloat(b, "lista_precio_detalle", p.ListaPrecioDetalle)
+	b = appendOptFloat(b, "lista_precio_mayorista", p.ListaPrecioMayorista)
+	if p.ListaUpdatedAt != nil {
+		b = jsonenc.AppendKey(b, "lista_updated_at")
+		b = jsonenc.AppendTime(b, *p.ListaUpdatedAt)
+	}
+
+	b = appendOptString(b, "imagen_url", p.ImagenURL)
+	b = appendOptString(b, "imagen_miniatura_url", p.ImagenMiniaturaURL)
+
+	if len(p.Canales) > 0 {
+		b = jsonenc.AppendKey(b, "canales")
+		b = jsonenc.AppendStrings(b, p.Canales)
+	}
+
+	if p.Bloqueo != nil {
+		b = jsonenc.AppendKey(b, "bloqueo")
+		b = append(b, '{')
+		b = jsonenc.AppendKey(b, "motivo")
+		b = jsonenc.AppendString(b, p.Bloqueo.Motivo)
+		if p.Bloqueo.Hasta != nil {
+			b = jsonenc.AppendKey(b, "hasta")
+			b = jsonenc.AppendTime(b, *p.Bloqueo.Hasta)
+		}
+		b = append(b, '}')
+	}
+
+	if len(p.FechasVencimiento) > 0 {
+		b = jsonenc.AppendKey(b, "fechas_vencimiento")
+		b = append(b, '[')
+		for i := range p.FechasVencimiento {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = p.FechasVencimiento[i].AppendJSON(b)
+		}
+		b = append(b, ']')
+	}
+	return append(b, '}')
+}
+
+// AppendJSON agrega la fecha de vencimiento como JSON
+func (f *FechaVencimiento) AppendJSON(b []byte) []byte {
+	b = append(b, '{')
+	b = jsonenc.AppendKey(b, "fecha_vencimiento")
+	b = jsonenc.AppendTime(b, f.FechaVencimiento)
+	b = jsonenc.AppendKey(b, "cantidad")
+	b = jsonenc.AppendInt(b, int64(f.Cantidad))
+	b = jsonenc.AppendKey(b, "lote")
+	b = jsonenc.AppendString(b, f.Lote)
+	return append(b, '}')
+}
+
+// AppendJSON agrega los datos del escaneo como JSON
+func (d *BusquedaBarcodeData) AppendJSON(b []byte) []byte {
+	b = append(b, '{')
+	b = jsonenc.AppendKey(b, "producto")
+	b = d.Producto.AppendJSON(b)
+	b = jsonenc.AppendKey(b, "cache_hit")
+	b = jsonenc.AppendBool(b, d.CacheHit)
+	b = jsonenc.AppendKey(b, "modo_degradado")
+	b = jsonenc.AppendBool(b, d.ModoDegradado)
+	b = jsonenc.AppendKey(b, "latency_ms")
+	b = jsonenc.AppendInt(b, d.LatencyMs)
+	return append(b, '}')
+}
+
+// AppendJSON agrega el producto en detalle mínimo como JSON
+func (p *ProductoPOSResponse) AppendJSON(b []byte) []byte {
+	if p == nil {
+		return append(b, "null"...)
+	}
+
+	b = append(b, '{')
+	b = jsonenc.AppendKey(b, "codigo")
+	b = jsonenc.AppendString(b, p.Codigo)
+	b = jsonenc.AppendKey(b, "nombre")
+	b = jsonenc.AppendString(b, p.Nombre)
+	b = jsonenc.AppendKey(b, "codigo_barras")
+	b = jsonenc.AppendString(b, p.CodigoBarras)
+	b = jsonenc.AppendKey(b, "precio")
+	b = jsonenc.AppendFloat(b, p.Precio)
+	b = jsonenc.AppendKey(b, "es_pack")
+	b = jsonenc.AppendBool(b, p.EsPack)
+	if p.CantidadPack != 0 {
+		b = jsonenc.AppendKey(b, "cantidad_pack")
+		b = jsonenc.AppendInt(b, int64(p.CantidadPack))
+	}
+	if p.ImagenURL != "" {
+		b = jsonenc.AppendKey(b, "imagen_url")
+		b = jsonenc.AppendString(b, p.ImagenURL)
+	}
+	if p.ImagenMiniaturaURL != "" {
+		b = jsonenc.AppendKey(b, "imagen_miniatura_url")
+		b = jsonenc.AppendString(b, p.ImagenMiniaturaURL)
+	}
+	if len(p.Canales) > 0 {
+		b = jsonenc.AppendKey(b, "canales")
+		b = jsonenc.AppendStrings(b, p.Canales)
+	}
+	return append(b, '}')
+}
+
+// AppendJSON agrega los datos del escaneo en detalle mínimo como JSON
+func (d *BusquedaBarcodeMinimaData) AppendJSON(b []byte) []byte {
+	b = append(b, '{')
+	b = jsonenc.AppendKey(b, "producto")
+	b = d.Producto.AppendJSON(b)
+	b = jsonenc.AppendKey(b, "cache_hit")
+	b = jsonenc.AppendBool(b, d.CacheHit)
+	b = jsonenc.AppendKey(b, "modo_degradado")
+	b = jsonenc.AppendBool(b, d.ModoDegradado)
+	b = jsonenc.AppendKey(b, "latency_ms")
+	b = jsonenc.AppendInt(b, d.LatencyMs)
+	return append(b, '}')
+}
+
+// AppendJSON agrega los totales de la venta como JSON
+func (t *TotalesVenta) AppendJSON(b []byte) []byte {
+	b = append(b, '{')
+	if t.MedioPago != "" {
+		b = jsonenc.AppendKey(b, "medio_pago")
+		b = jsonenc.AppendString(b, t.MedioPago)
+	}
+	b = jsonenc.AppendKey(b, "subtotal")
+	b = jsonenc.AppendFloat(b, t.Subtotal)
+	b = jsonenc.AppendKey(b, "propina")
+	b = jsonenc.AppendFloat(b, t.Propina)
+	b = jsonenc.AppendKey(b, "cargo_servicio")
+	b = jsonenc.AppendFloat(b, t.CargoServicio)
+	b = jsonenc.AppendKey(b, "ajuste_redondeo")
+	b = jsonenc.AppendFloat(b, t.AjusteRedondeo)
+	b = jsonenc.AppendKey(b, "total")
+	b = jsonenc.AppendFloat(b, t.Total)
+	b = jsonenc.AppendKey(b, "neto")
+	b = jsonenc.AppendFloat(b, t.Neto)
+	b = jsonenc.AppendKey(b, "iva")
+	b = jsonenc.AppendFloat(b, t.IVA)
+	b = jsonenc.AppendKey(b, "exento")
+	b = jsonenc.AppendFloat(b, t.Exento)
+	return append(b, '}')
+}
+
+// AppendJSON agrega los datos de la venta rápida como JSON
+func (d *VentaRapidaData) AppendJSON(b []byte) []byte {
+	b = append(b, '{')
+	b = jsonenc.AppendKey(b, "venta_id")
+	b = jsonenc.AppendInt(b, d.VentaID)
+	b = jsonenc.AppendKey(b, "id_operacion")
+	b = jsonenc.AppendString(b, d.IDOperacion)
+	b = jsonenc.AppendKey(b, "totales")
+	b = d.Totales.AppendJSON(b)
+	b = jsonenc.AppendKey(b, "productos_procesados")
+	b = jsonenc.AppendInt(b, int64(d.ProductosProcesados))
+	b = jsonenc.AppendKey(b, "total_items")
+	b = jsonenc.AppendInt(b, int64(d.TotalItems))
+	b = jsonenc.AppendKey(b, "venta_sospechosa")
+	b = jsonenc.AppendBool(b, d.VentaSospechosa)
+	b = jsonenc.AppendKey(b, "precios_modificados")
+	b = jsonenc.AppendInt(b, int64(d.PreciosModificados))
+	b = jsonenc.AppendKey(b, "latency_ms")
+	b = jsonenc.AppendInt(b, d.LatencyMs)
+	b = jsonenc.AppendKey(b, "timestamp")
+	b = jsonenc.AppendString(b, d.Timestamp)
+	return append(b, '}')
+}
+
+func appendOptString(b []byte, key string, v *string) []byte {
+	if v == nil {
+		return b
+	}
+	b = jsonenc.AppendKey(b, key)
+	return jsonenc.AppendString(b, *v)
+}
+
+func appendOptFloat(b []byte, key string, v *float64) []byte {
+	if v == nil {
+		return b
+	}
+	b = jsonenc.AppendKey(b, key)
+	return jsonenc.AppendFloat(b, *v)
+}
+
+func appendOptInt(b []byte, key string, v *int) []byte {
+	if v == nil {
+		return b
+	}
+	b = jsonenc.AppendKey(b, key)
+	return jsonenc.AppendInt(b, int64(*v))
+}
+
+func appendOptBool(b []byte, key string, v *bool) []byte {
+	if v == nil {
+		return b
+	}
+	b = jsonenc.AppendKey(b, key)
+	return jsonenc.AppendBool(b, *v)
+}
@@ -60,6 +60,8 @@ type Usuario struct {
 	Activo    bool      `json:"activo" db:"activo"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	PasswordHash string `json:"-" db:"password_hash"` // bcrypt; nunca sale en las respuestas
 }
 
 // ProductoCompleto representa un producto con toda la información completa
@@ -101,15 +103,44 @@ type ProductoCompleto struct {
 	ListaPrecioMayorista *float64   `json:"lista_precio_mayorista,omitempty" db:"lista_precio_mayorista"`
 	ListaUpdatedAt       *time.Time `json:"lista_updated_at,omitempty" db:"lista_updated_at"`
 
+	// Imagen del producto (imagenes_productos_cantera)
+	ImagenURL          *string `json:"imagen_url,omitempty" db:"imagen_url"`
+	ImagenMiniaturaURL *string `json:"imagen_miniatura_url,omitempty" db:"imagen_miniatura_url"`
+
+	// Canales en que se vende (canales_producto_cantera); vacío: todos los canales
+	Canales []string `json:"canales,omitempty" db:"canales"`
+
+	// Bloqueo de venta vigente (bloqueos_producto_cantera); en packs, también el de sus componentes
+	Bloqueo *BloqueoVenta `json:"bloqueo,omitempty"`
+
 	// Fechas de vencimiento (se procesará como JSON)
 	FechasVencimiento []FechaVencimiento `json:"fechas_vencimiento,omitempty"`
 }
 
+// HabilitadoEnCanal indica si el producto se vende en el canal (sin canales definidos: en todos)
+func (p *ProductoCompleto) HabilitadoEnCanal(canal string) bool {
+	return CanalHabilitado(p.Canales, canal)
+}
+
+// BloqueadoParaVenta indica si el producto tiene un bloqueo de venta vigente en el instante dado
+func (p *ProductoCompleto) BloqueadoParaVenta(t time.Time) bool {
+	return p.Bloqueo.VigenteEn(t)
+}
+
 // ToProductoPOSResponse convierte ProductoCompleto a ProductoPOSResponse
 func (p *ProductoCompleto) ToProductoPOSResponse() ProductoPOSResponse {
 	response := ProductoPOSResponse{
-		Nombre: p.Nombre,
-		EsPack: p.Origen == "pack",
+		Nombre:  p.Nombre,
+		Precio:  p.PrecioVenta(),
+		EsPack:  p.Origen == "pack",
+		Canales: p.Canales,
+	}
+
+	if p.ImagenURL != nil {
+		response.ImagenURL = *p.ImagenURL
+	}
+	if p.ImagenMiniaturaURL != nil {
+		response.ImagenMiniaturaURL = *p.ImagenMiniaturaURL
 	}
 
 	// Determinar código y código de barras según el origen
@@ -138,6 +169,31 @@ func (p *ProductoCompleto) ToProductoPOSResponse() ProductoPOSResponse {
 	return response
 }
 
+// PrecioVenta retorna el precio de venta vigente: lista de precios detalle si existe,
+// si no el precio base del producto/pack
+func (p *ProductoCompleto) PrecioVenta() float64 {
+	if p.ListaPrecioDetalle != nil {
+		return *p.ListaPrecioDetalle
+	}
+	if p.Precio != nil {
+		return *p.Precio
+	}
+	return 0
+}
+
+// CostoUnitario deriva el costo del precio del maestro y su utilidad (nil si no es calculable)
+func (p *ProductoCompleto) CostoUnitario() *float64 {
+	return costoDesdeUtilidad(p.Precio, p.Utilidad, p.TipoUtilidad)
+}
+
+// CodigoLista retorna el código con que el producto o pack figura en lista_precios_cantera
+func (p *ProductoCompleto) CodigoLista() string {
+	if p.Origen == "pack" && p.CodigoPack != nil {
+		return *p.CodigoPack
+	}
+	return p.Codigo
+}
+
 // FechaVencimiento representa una fecha de vencimiento de un producto
 type FechaVencimiento struct {
 	FechaVencimiento time.Time `json:"fecha_vencimiento"`
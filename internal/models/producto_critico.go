@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// ProductoCritico representa la tabla productos_criticos_cantera
+// Producto que no debería quedarse sin stock en el local (con SLA de disponibilidad)
+type ProductoCritico struct {
+	ID             int       `json:"id" db:"id"`
+	CodigoProducto string    `json:"codigo_producto" db:"codigo_producto"`
+	NombreProducto *string   `json:"nombre_producto,omitempty"`
+	IDLocal        int       `json:"id_local" db:"id_local"`
+	SLAObjetivo    *float64  `json:"sla_objetivo,omitempty" db:"sla_objetivo"` // nil: el configurado
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MarcarCriticoRequest marca un producto como crítico en un local
+type MarcarCriticoRequest struct {
+	CodigoProducto string   `json:"codigo_producto" validate:"required"`
+	IDLocal        int      `json:"id_local" validate:"required,gt=0"`
+	SLAObjetivo    *float64 `json:"sla_objetivo,omitempty" validate:"omitempty,gt=0,lte=100"`
+}
+
+// DisponibilidadCritico disponibilidad de un producto crítico en el período
+type DisponibilidadCritico struct {
+	CodigoProducto string  `json:"codigo_producto"`
+	NombreProducto *string `json:"nombre_producto,omitempty"`
+	IDLocal        int     `json:"id_local"`
+	// Porcentaje del período con stock > 0
+	Disponibilidad   float64 `json:"disponibilidad_pct"`
+	SLAObjetivo      float64 `json:"sla_objetivo"`
+	CumpleSLA        bool    `json:"cumple_sla"`
+	SegundosSinStock int64   `json:"segundos_sin_stock"`
+	// Veces que el stock llegó a 0 en el período
+	Quiebres      int        `json:"quiebres"`
+	StockActual   int        `json:"stock_actual"`
+	SinStockDesde *time.Time `json:"sin_stock_desde,omitempty"`
+}
+
+// QuiebreCritico producto crítico actualmente sin stock
+type QuiebreCritico struct {
+	CodigoProducto string    `json:"codigo_producto"`
+	NombreProducto *string   `json:"nombre_producto,omitempty"`
+	IDLocal        int       `json:"id_local"`
+	StockActual    int       `json:"stock_actual"`
+	Desde          time.Time `json:"desde"`
+}
+
+// EventoQuiebreCriticoPayload cuerpo del evento stock.quiebre_critico
+type EventoQuiebreCriticoPayload struct {
+	CodigoProducto   string    `json:"codigo_producto"`
+	IDLocal          int       `json:"id_local"`
+	CantidadAnterior int       `json:"cantidad_anterior"`
+	CantidadNueva    int       `json:"cantidad_nueva"`
+	IDMovimiento     int       `json:"id_movimiento"`
+	Motivo           string    `json:"motivo"`
+	Fecha            time.Time `json:"fecha"`
+}
+
+// ReporteDisponibilidadCriticos disponibilidad de los productos críticos contra su SLA
+type ReporteDisponibilidadCriticos struct {
+	Desde     time.Time                `json:"desde"`
+	Hasta     time.Time                `json:"hasta"`
+	Productos int                      `json:"productos"`
+	Incumplen int                      `json:"incumplen_sla"`
+	SinStock  int                      `json:"sin_stock"`
+	Detalle   []*DisponibilidadCritico `json:"detalle"`
+}
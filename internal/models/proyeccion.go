@@ -0,0 +1,49 @@
+package models
+
+// ProyeccionStockRequest DTO para proyectar el stock de un local (what-if)
+type ProyeccionStockRequest struct {
+	IDLocal         int                `json:"id_local" validate:"required,gt=0"`
+	Semanas         int                `json:"semanas" validate:"omitempty,min=1,max=26"`
+	CodigosProducto []string           `json:"codigos_producto"` // vacío = todo el stock del local
+	Eventos         []EventoProyectado `json:"eventos" validate:"dive"`
+	// Demanda histórica: promedio diario de salidas de los últimos DiasHistorico días
+	UsarHistorico *bool `json:"usar_historico"`
+	DiasHistorico int   `json:"dias_historico" validate:"omitempty,min=1,max=365"`
+}
+
+// EventoProyectado venta o recepción proyectada para una fecha
+type EventoProyectado struct {
+	CodigoProducto string `json:"codigo_producto" validate:"required"`
+	Fecha          string `json:"fecha" validate:"required,datetime=2006-01-02"`
+	Tipo           string `json:"tipo" validate:"required,oneof=venta recepcion"`
+	Cantidad       int    `json:"cantidad" validate:"required,gt=0"`
+}
+
+// ProyeccionProducto proyección día a día del stock de un producto
+type ProyeccionProducto struct {
+	CodigoProducto   string          `json:"codigo_producto"`
+	StockActual      int             `json:"stock_actual"`
+	DemandaDiaria    float64         `json:"demanda_diaria"`
+	FechaQuiebre     *string         `json:"fecha_quiebre,omitempty"`
+	DiasHastaQuiebre *int            `json:"dias_hasta_quiebre,omitempty"`
+	Dias             []ProyeccionDia `json:"dias"`
+}
+
+// ProyeccionDia stock proyectado al cierre de un día
+type ProyeccionDia struct {
+	Fecha    string `json:"fecha"`
+	Stock    int    `json:"stock"`
+	Entradas int    `json:"entradas,omitempty"`
+	Salidas  int    `json:"salidas,omitempty"`
+	Quiebre  bool   `json:"quiebre,omitempty"`
+}
+
+// ProyeccionStockResponse resultado de la proyección
+type ProyeccionStockResponse struct {
+	IDLocal       int                   `json:"id_local"`
+	Desde         string                `json:"desde"`
+	Hasta         string                `json:"hasta"`
+	DiasHistorico int                   `json:"dias_historico,omitempty"`
+	Productos     []*ProyeccionProducto `json:"productos"`
+	ConQuiebre    int                   `json:"con_quiebre"`
+}
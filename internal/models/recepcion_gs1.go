@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// DecodificarGS1Request DTO con el texto escaneado de una etiqueta GS1-128 / DataMatrix
+type DecodificarGS1Request struct {
+	Codigo string `json:"codigo" validate:"required"`
+}
+
+// PrecargaRecepcionGS1 datos de la etiqueta GS1 para precargar una línea de recepción
+// Producto es nil si el GTIN no corresponde a ningún código de barras registrado
+type PrecargaRecepcionGS1 struct {
+	GTIN             string            `json:"gtin,omitempty"`
+	Lote             string            `json:"lote,omitempty"`
+	FechaVencimiento *time.Time        `json:"fecha_vencimiento,omitempty"`
+	Producto         *ProductoCompleto `json:"producto,omitempty"`
+	// Identificadores de aplicación leídos (AI -> valor), incluidos los que no se precargan
+	AIs map[string]string `json:"ais"`
+}
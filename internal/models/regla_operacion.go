@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+// Tipos de regla de operación
+const (
+	ReglaSalidaPorcentajeStock = "salida_porcentaje_stock"
+	ReglaEntradaFueraHorario   = "entrada_fuera_horario"
+	ReglaAjusteCantidad        = "ajuste_cantidad"
+	ReglaVentaBajoCosto        = "venta_bajo_costo"
+)
+
+// Acciones de una regla de operación
+const (
+	ReglaAccionBloquear   = "bloquear"   // la operación se rechaza
+	ReglaAccionAprobacion = "aprobacion" // la operación espera la decisión de un supervisor
+)
+
+// Operaciones evaluadas por las reglas
+const (
+	OperacionReglaEntrada = "entrada"
+	OperacionReglaSalida  = "salida"
+	OperacionReglaVenta   = "venta"
+)
+
+// ReglaOperacion representa la tabla reglas_operacion_cantera
+// Los parámetros usados dependen del tipo (ver migración 0025)
+type ReglaOperacion struct {
+	ID         int       `json:"id" db:"id"`
+	Nombre     string    `json:"nombre" db:"nombre"`
+	Tipo       string    `json:"tipo" db:"tipo"`
+	Accion     string    `json:"accion" db:"accion"`
+	IDLocal    *int      `json:"id_local,omitempty" db:"id_local"` // nil: todos los locales
+	Porcentaje *float64  `json:"porcentaje,omitempty" db:"porcentaje"`
+	Cantidad   *int      `json:"cantidad,omitempty" db:"cantidad"`
+	HoraDesde  *int      `json:"hora_desde,omitempty" db:"hora_desde"`
+	HoraHasta  *int      `json:"hora_hasta,omitempty" db:"hora_hasta"`
+	Activa     bool      `json:"activa" db:"activa"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ReglaOperacionRequest DTO para crear o reemplazar una regla
+type ReglaOperacionRequest struct {
+	Nombre     string   `json:"nombre" validate:"required,max=100"`
+	Tipo       string   `json:"tipo" validate:"required,oneof=salida_porcentaje_stock entrada_fuera_horario ajuste_cantidad venta_bajo_costo"`
+	Accion     string   `json:"accion" validate:"required,oneof=bloquear aprobacion"`
+	IDLocal    *int     `json:"id_local,omitempty" validate:"omitempty,gt=0"`
+	Porcentaje *float64 `json:"porcentaje,omitempty" validate:"omitempty,gt=0"`
+	Cantidad   *int     `json:"cantidad,omitempty" validate:"omitempty,gt=0"`
+	HoraDesde  *int     `json:"hora_desde,omitempty" validate:"omitempty,gte=0,lte=23"`
+	HoraHasta  *int     `json:"hora_hasta,omitempty" validate:"omitempty,gte=0,lte=23"`
+	Activa     *bool    `json:"activa,omitempty"` // vacío: activa
+}
+
+// OperacionEvaluada operación que se somete a las reglas antes de aplicarse
+type OperacionEvaluada struct {
+	Operacion string // entrada, salida o venta
+	IDLocal   int
+	Motivo    string
+	Fecha     time.Time
+	Items     []ItemEvaluado
+}
+
+// ItemEvaluado línea de una operación evaluada, con la cantidad en unidad base
+type ItemEvaluado struct {
+	CodigoProducto string
+	TipoItem       string
+	Cantidad       int
+	Precio         *float64 // precio de venta (solo ventas)
+	Costo          *float64 // costo unitario (solo ventas; nil si no es calculable)
+}
+
+// ReglaIncumplida regla que aplica a la operación y el motivo
+type ReglaIncumplida struct {
+	IDRegla int    `json:"id_regla"`
+	Nombre  string `json:"nombre"`
+	Tipo    string `json:"tipo"`
+	Accion  string `json:"accion"`
+	Detalle string `json:"detalle"`
+}
+
+// EvaluacionReglas resultado de evaluar una operación contra las reglas activas
+type EvaluacionReglas struct {
+	Incumplidas []ReglaIncumplida `json:"incumplidas"`
+}
+
+// Bloqueada indica si alguna regla incumplida bloquea la operación
+func (e *EvaluacionReglas) Bloqueada() bool {
+	return e.tieneAccion(ReglaAccionBloquear)
+}
+
+// RequiereAprobacion indica si alguna regla incumplida retiene la operación para aprobación
+func (e *EvaluacionReglas) RequiereAprobacion() bool {
+	return e.tieneAccion(ReglaAccionAprobacion)
+}
+
+func (e *EvaluacionReglas) tieneAccion(accion string) bool {
+	for _, regla := range e.Incumplidas {
+		if regla.Accion == accion {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// ReporteFilter filtros comunes de los reportes
+type ReporteFilter struct {
+	IDLocal   *int      `json:"id_local,omitempty"`
+	IDUsuario *int      `json:"id_usuario,omitempty"`
+	Desde     time.Time `json:"desde"`
+	Hasta     time.Time `json:"hasta"`
+}
+
+// VentaProducto ventas agregadas de un producto en un período (salidas valorizadas)
+type VentaProducto struct {
+	CodigoProducto  string   `json:"codigo_producto" db:"codigo_producto"`
+	NombreProducto  *string  `json:"nombre_producto,omitempty" db:"nombre_producto"`
+	IDCategoria     *int     `json:"id_categoria,omitempty" db:"id_categoria"`
+	NombreCategoria *string  `json:"nombre_categoria,omitempty" db:"nombre_categoria"`
+	PrecioMaestro   *float64 `json:"precio_maestro,omitempty" db:"precio"`
+	Utilidad        *float64 `json:"utilidad,omitempty" db:"utilidad"`
+	TipoUtilidad    *string  `json:"tipo_utilidad,omitempty" db:"tipo_utilidad"`
+	Unidades        int      `json:"unidades" db:"unidades"`
+	VentaTotal      float64  `json:"venta_total" db:"venta_total"`
+}
+
+// CostoUnitario deriva el costo del precio del maestro y su utilidad
+// tipo_utilidad "porcentaje" es un recargo sobre el costo; cualquier otro valor es un monto fijo
+func (v *VentaProducto) CostoUnitario() *float64 {
+	return costoDesdeUtilidad(v.PrecioMaestro, v.Utilidad, v.TipoUtilidad)
+}
+
+// costoDesdeUtilidad descuenta la utilidad del precio del maestro (nil si falta alguno)
+func costoDesdeUtilidad(precio, utilidad *float64, tipoUtilidad *string) *float64 {
+	if precio == nil || utilidad == nil {
+		return nil
+	}
+
+	var costo float64
+	if tipoUtilidad != nil && (strings.EqualFold(*tipoUtilidad, "porcentaje") || *tipoUtilidad == "%") {
+		costo = *precio / (1 + *utilidad/100)
+	} else {
+		costo = *precio - *utilidad
+	}
+	return &costo
+}
+
+// MargenProducto margen bruto de un producto
+type MargenProducto struct {
+	CodigoProducto   string   `json:"codigo_producto"`
+	NombreProducto   *string  `json:"nombre_producto,omitempty"`
+	IDCategoria      *int     `json:"id_categoria,omitempty"`
+	Unidades         int      `json:"unidades"`
+	PrecioPromedio   float64  `json:"precio_promedio"`
+	CostoUnitario    *float64 `json:"costo_unitario,omitempty"`
+	VentaTotal       float64  `json:"venta_total"`
+	CostoTotal       float64  `json:"costo_total"`
+	MargenBruto      float64  `json:"margen_bruto"`
+	MargenPorcentaje float64  `json:"margen_porcentaje"`
+	BajoCosto        bool     `json:"bajo_costo"`
+	SinCosto         bool     `json:"sin_costo,omitempty"` // sin utilidad en el maestro, margen no calculable
+}
+
+// MargenCategoria margen bruto agregado por categoría
+type MargenCategoria struct {
+	IDCategoria      *int    `json:"id_categoria,omitempty"`
+	NombreCategoria  string  `json:"nombre_categoria"`
+	Unidades         int     `json:"unidades"`
+	VentaTotal       float64 `json:"venta_total"`
+	CostoTotal       float64 `json:"costo_total"`
+	MargenBruto      float64 `json:"margen_bruto"`
+	MargenPorcentaje float64 `json:"margen_porcentaje"`
+}
+
+// ReporteMargenes reporte de márgenes y utilidad
+type ReporteMargenes struct {
+	Filtros            ReporteFilter      `json:"filtros"`
+	Productos          []*MargenProducto  `json:"productos"`
+	Categorias         []*MargenCategoria `json:"categorias"`
+	ProductosBajoCosto []string           `json:"productos_bajo_costo"`
+	VentaTotal         float64            `json:"venta_total"`
+	CostoTotal         float64            `json:"costo_total"`
+	MargenBruto        float64            `json:"margen_bruto"`
+	MargenPorcentaje   float64            `json:"margen_porcentaje"`
+	// Días del período leídos de los agregados diarios (nil: todo desde los movimientos)
+	Agregados *CoberturaAgregados `json:"agregados,omitempty"`
+}
+
+// Categorías de actividad de un usuario
+const (
+	ActividadEntrada = "entrada"
+	ActividadSalida  = "salida"
+	ActividadAjuste  = "ajuste"
+	ActividadVenta   = "venta"
+)
+
+// ActividadAgregada actividad de un usuario en una categoría (fila del reporte)
+type ActividadAgregada struct {
+	IDUsuario   int     `json:"id_usuario" db:"id_usuario"`
+	Categoria   string  `json:"categoria" db:"categoria"`
+	Movimientos int     `json:"movimientos" db:"movimientos"`
+	Unidades    int     `json:"unidades" db:"unidades"`
+	Monto       float64 `json:"monto" db:"monto"`
+}
+
+// ResumenActividad totales de una categoría de actividad
+type ResumenActividad struct {
+	Movimientos int     `json:"movimientos"`
+	Unidades    int     `json:"unidades"`
+	Monto       float64 `json:"monto"`
+}
+
+// ActividadUsuario resumen de actividad de un usuario
+type ActividadUsuario struct {
+	IDUsuario  int                          `json:"id_usuario"`
+	Categorias map[string]*ResumenActividad `json:"categorias"`
+	Total      ResumenActividad             `json:"total"`
+	Anomalias  []string                     `json:"anomalias,omitempty"`
+}
+
+// ReporteActividadUsuarios reporte de actividad por usuario
+type ReporteActividadUsuarios struct {
+	Filtros  ReporteFilter       `json:"filtros"`
+	Usuarios []*ActividadUsuario `json:"usuarios"`
+	Total    ResumenActividad    `json:"total"`
+	// Días del período leídos de los agregados diarios (nil: todo desde los movimientos)
+	Agregados *CoberturaAgregados `json:"agregados,omitempty"`
+}
+
+// CoberturaAgregados días completos de un período que se leen de los agregados diarios
+// El resto del período (días parciales o aún no agregados) se lee de los movimientos
+type CoberturaAgregados struct {
+	// Primer día agregado (inclusive) y día siguiente al último (exclusivo)
+	Desde time.Time `json:"desde"`
+	Hasta time.Time `json:"hasta"`
+	Dias  int       `json:"dias"`
+}
+
+// EstadoAgregados estado de los agregados diarios de movimientos
+type EstadoAgregados struct {
+	// Los días anteriores a Hasta están agregados (nil: todavía no se agregó ninguno)
+	Hasta         *time.Time `json:"hasta"`
+	ActualizadoAt time.Time  `json:"actualizado_at"`
+}
+
+// ResultadoAgregacion días agregados en una ronda del job
+type ResultadoAgregacion struct {
+	Desde time.Time `json:"desde"`
+	Hasta time.Time `json:"hasta"`
+	Filas int       `json:"filas"`
+}
+
+// CierreVentas ventas del POS del día: líneas cobradas más propinas, cargos y redondeos
+type CierreVentas struct {
+	Ventas         int     `json:"ventas"` // ventas distintas (venta_ref)
+	Unidades       int     `json:"unidades"`
+	MontoProductos float64 `json:"monto_productos"`
+	MontoOtros     float64 `json:"monto_otros"` // propina, cargo de servicio y ajuste de redondeo
+	Total          float64 `json:"total"`
+}
+
+// CierreDevoluciones notas de crédito aplicadas en el día
+type CierreDevoluciones struct {
+	NotasCredito int     `json:"notas_credito"`
+	Unidades     int     `json:"unidades"`
+	Monto        float64 `json:"monto"`
+}
+
+// CierreMovimientos movimientos de stock del día de un tipo (entradas, mermas)
+type CierreMovimientos struct {
+	Movimientos int `json:"movimientos"`
+	Unidades    int `json:"unidades"`
+}
+
+// CierreCaja cuadratura de la caja del día
+// Esperado = ventas - devoluciones; la diferencia solo se calcula si se declara lo contado
+type CierreCaja struct {
+	Esperado   float64  `json:"esperado"`
+	Declarado  *float64 `json:"declarado,omitempty"`
+	Diferencia *float64 `json:"diferencia,omitempty"` // declarado - esperado (negativo: falta)
+}
+
+// ProductoQuebrado producto que se quedó sin stock durante el día
+type ProductoQuebrado struct {
+	CodigoProducto string    `json:"codigo_producto"`
+	NombreProducto *string   `json:"nombre_producto,omitempty"`
+	QuebradoAt     time.Time `json:"quebrado_at"`  // primer movimiento del día que lo dejó en cero
+	StockActual    int       `json:"stock_actual"` // al generar el cierre (> 0 si ya se repuso)
+}
+
+// CierreDia cierre diario consolidado de un local
+type CierreDia struct {
+	IDLocal      int                 `json:"id_local"`
+	Fecha        string              `json:"fecha"` // YYYY-MM-DD
+	Ventas       CierreVentas        `json:"ventas"`
+	Devoluciones CierreDevoluciones  `json:"devoluciones"`
+	Mermas       CierreMovimientos   `json:"mermas"`
+	Entradas     CierreMovimientos   `json:"entradas"`
+	Caja         CierreCaja          `json:"caja"`
+	Quebrados    []*ProductoQuebrado `json:"quebrados"`
+	GeneradoAt   time.Time           `json:"generado_at"`
+}
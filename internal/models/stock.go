@@ -14,6 +14,27 @@ type Stock struct {
 	IDLocal        int       `json:"id_local" db:"id_local"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+
+	// Con un mínimo estacional vigente (solo en GetStockBajo): CantidadMinima es el de la
+	// temporada y CantidadMinimaBase el del stock
+	Temporada          *string `json:"temporada,omitempty"`
+	CantidadMinimaBase *int    `json:"cantidad_minima_base,omitempty"`
+}
+
+// StockDisponibilidad desglose del stock de un producto en un local
+// Disponible es lo que se puede vender: la cantidad física menos lo reservado por pickings
+// preparados; lo que viene en tránsito aún no está en el local y no cuenta como disponible
+type StockDisponibilidad struct {
+	CodigoProducto string `json:"codigo_producto"`
+	IDLocal        int    `json:"id_local"`
+	// Cantidad en el local (cantidad_actual del stock)
+	Fisica int `json:"fisica"`
+	// Comprometida por pickings preparados y vigentes
+	Reservada int `json:"reservada"`
+	// Enviada al local en guías de despacho emitidas y aún no recibidas
+	EnTransito int `json:"en_transito"`
+	// Física menos reservada (nunca negativa)
+	Disponible int `json:"disponible"`
 }
 
 // StockWithDetails incluye información adicional del producto/pack
@@ -34,7 +55,7 @@ type StockComplete struct {
 	IDLocal        int       `json:"id_local" db:"id_local"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
-	
+
 	// Campos del producto (JOIN con productos)
 	NombreProducto     *string  `json:"nombre_producto,omitempty" db:"nombre_producto"`
 	CodigoBarraInterno *string  `json:"codigo_barra_interno,omitempty" db:"codigo_barra_interno"`
@@ -50,12 +71,13 @@ type StockComplete struct {
 	Activo             *bool    `json:"activo,omitempty" db:"activo"`
 	Utilidad           *float64 `json:"utilidad,omitempty" db:"utilidad"`
 	TipoUtilidad       *string  `json:"tipo_utilidad,omitempty" db:"tipo_utilidad"`
-	
+	Descontinuado      bool     `json:"descontinuado"` // producto inactivo con stock remanente
+
 	// Campos de la categoría (JOIN con categorias)
-	NombreCategoria    *string `json:"nombre_categoria,omitempty" db:"nombre_categoria"`
-	
+	NombreCategoria *string `json:"nombre_categoria,omitempty" db:"nombre_categoria"`
+
 	// Campos del local (JOIN con locales)
-	NombreLocal        *string `json:"nombre_local,omitempty" db:"nombre_local"`
+	NombreLocal *string `json:"nombre_local,omitempty" db:"nombre_local"`
 }
 
 // StockSummary resumen de stock por local
@@ -65,4 +87,20 @@ type StockSummary struct {
 	TotalProductos int    `json:"total_productos"`
 	TotalPacks     int    `json:"total_packs"`
 	StockBajo      int    `json:"stock_bajo"`
+	// Valorizacion stock positivo al precio de lista (o del maestro / precio base del pack)
+	Valorizacion float64 `json:"valorizacion"`
+}
+
+// CambioCantidadMinima representa la tabla historial_cantidad_minima_cantera
+type CambioCantidadMinima struct {
+	ID             int    `json:"id" db:"id"`
+	CodigoProducto string `json:"codigo_producto" db:"codigo_producto"`
+	IDLocal        int    `json:"id_local" db:"id_local"`
+	// nil: el mínimo se fijó al crear el stock
+	CantidadAnterior *int      `json:"cantidad_anterior" db:"cantidad_anterior"`
+	CantidadNueva    int       `json:"cantidad_nueva" db:"cantidad_nueva"`
+	IDUsuario        int       `json:"id_usuario" db:"id_usuario"`
+	Motivo           string    `json:"motivo,omitempty" db:"motivo"`
+	IDOperacion      *string   `json:"id_operacion,omitempty" db:"id_operacion"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 }
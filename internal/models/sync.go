@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// DeltaSync cambios del catálogo y del stock de un local para una réplica edge
+// Sin desde se retorna el catálogo y el stock completos
+type DeltaSync struct {
+	IDLocal int        `json:"id_local"`
+	Desde   *time.Time `json:"desde,omitempty"`
+	// Cursor a enviar como desde en la próxima llamada
+	Cursor    time.Time           `json:"cursor"`
+	Completa  bool                `json:"completa"`
+	Productos []*ProductoCompleto `json:"productos"`
+	Stock     []*Stock            `json:"stock"`
+}
@@ -0,0 +1,132 @@
+package models
+
+import "time"
+
+// Estados de una toma de inventario
+const (
+	TomaInventarioAbierta   = "abierta"
+	TomaInventarioCerrando  = "cerrando" // aplicando los ajustes del cierre
+	TomaInventarioCerrada   = "cerrada"
+	TomaInventarioCancelada = "cancelada"
+)
+
+// Modos de cargar una cantidad contada en la toma
+const (
+	ConteoTomaSumar      = "sumar"      // se suma a lo contado (lecturas sucesivas de la pistola)
+	ConteoTomaReemplazar = "reemplazar" // corrige lo contado del producto
+)
+
+// MotivoTomaInventario motivo de los movimientos de ajuste del cierre de una toma de inventario
+const MotivoTomaInventario = "Toma de inventario"
+
+// TomaInventario representa la tabla tomas_inventario_cantera
+// Sesión de conteo físico de un local completo; al cerrarla se ajusta el stock a lo contado
+type TomaInventario struct {
+	ID              int64                 `json:"id" db:"id"`
+	IDLocal         int                   `json:"id_local" db:"id_local"`
+	Descripcion     string                `json:"descripcion" db:"descripcion"`
+	Estado          string                `json:"estado" db:"estado"`
+	IDUsuario       int                   `json:"id_usuario" db:"id_usuario"`
+	Productos       int                   `json:"productos"` // productos contados
+	Ajustados       int                   `json:"ajustados" db:"ajustados"`
+	IDOperacion     *string               `json:"id_operacion,omitempty" db:"id_operacion"`
+	IDUsuarioCierre *int                  `json:"id_usuario_cierre,omitempty" db:"id_usuario_cierre"`
+	CreatedAt       time.Time             `json:"created_at" db:"created_at"`
+	CerradaAt       *time.Time            `json:"cerrada_at,omitempty" db:"cerrada_at"`
+	Items           []*TomaInventarioItem `json:"items,omitempty"`
+}
+
+// TomaInventarioItem representa la tabla toma_inventario_items_cantera
+type TomaInventarioItem struct {
+	IDToma          int64     `json:"id_toma" db:"id_toma"`
+	CodigoProducto  string    `json:"codigo_producto" db:"codigo_producto"`
+	TipoItem        string    `json:"tipo_item" db:"tipo_item"`
+	CantidadContada int       `json:"cantidad_contada" db:"cantidad_contada"`
+	Lecturas        int       `json:"lecturas" db:"lecturas"`
+	IDUsuario       int       `json:"id_usuario" db:"id_usuario"` // último en cargar el producto
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TomaInventarioRequest DTO para abrir una toma de inventario
+type TomaInventarioRequest struct {
+	IDLocal     int    `json:"id_local" validate:"required,gt=0"`
+	Descripcion string `json:"descripcion" validate:"max=255"`
+	IDUsuario   int    `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// TomaInventarioFilter filtros del listado de tomas de inventario
+type TomaInventarioFilter struct {
+	IDLocal *int
+	Estado  string
+}
+
+// ConteoTomaItem cantidad contada de un código (código del producto, código de barras o pack)
+// Sin cantidad cuenta una unidad; sin modo se suma a lo contado
+type ConteoTomaItem struct {
+	Codigo   string `json:"codigo" validate:"required"`
+	Cantidad *int   `json:"cantidad,omitempty" validate:"omitempty,gte=0"`
+	Modo     string `json:"modo,omitempty" validate:"omitempty,oneof=sumar reemplazar"`
+}
+
+// ConteoTomaMasivoRequest carga de varias lecturas en una toma (archivo o lote de la pistola)
+type ConteoTomaMasivoRequest struct {
+	Items     []*ConteoTomaItem `json:"items" validate:"required,min=1,max=5000,dive"`
+	IDUsuario int               `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// LecturaTomaInventario cantidad de un producto ya resuelto a cargar en la toma
+// Reemplazar fija la cantidad contada; si no, se suma a lo contado
+type LecturaTomaInventario struct {
+	CodigoProducto string
+	TipoItem       string
+	Cantidad       int
+	Lecturas       int
+	Reemplazar     bool
+}
+
+// ResultadoConteoToma resultado de cargar lecturas en la toma
+// Las lecturas del mismo producto se acumulan en el orden recibido
+type ResultadoConteoToma struct {
+	IDToma      int64                 `json:"id_toma"`
+	Lecturas    int                   `json:"lecturas"`
+	Aplicadas   int                   `json:"aplicadas"`
+	Registrados []*TomaInventarioItem `json:"registrados"`
+	// Códigos que no corresponden a ningún producto ni pack (sin repetir)
+	CodigosDesconocidos []string `json:"codigos_desconocidos"`
+}
+
+// DiferenciaTomaInventario diferencia de un producto entre lo contado y el stock del sistema
+// Un producto con stock no contado aparece con cantidad_contada nula
+type DiferenciaTomaInventario struct {
+	CodigoProducto  string  `json:"codigo_producto"`
+	NombreProducto  *string `json:"nombre_producto,omitempty"`
+	TipoItem        string  `json:"tipo_item"`
+	CantidadSistema int     `json:"cantidad_sistema"`
+	CantidadContada *int    `json:"cantidad_contada"`
+	Diferencia      int     `json:"diferencia"` // contado - sistema (no contado: -sistema)
+}
+
+// ReporteDiferenciasToma diferencias de la toma contra el stock actual del local
+type ReporteDiferenciasToma struct {
+	Toma              *TomaInventario             `json:"toma"`
+	Contados          int                         `json:"contados"`
+	ConDiferencia     int                         `json:"con_diferencia"` // contados con diferencia
+	NoContados        int                         `json:"no_contados"`    // con stock y sin contar
+	UnidadesSobrantes int                         `json:"unidades_sobrantes"`
+	UnidadesFaltantes int                         `json:"unidades_faltantes"`
+	Items             []*DiferenciaTomaInventario `json:"items"`
+}
+
+// CerrarTomaRequest DTO para cerrar la toma y aplicar los ajustes
+// Con ajustar_no_contados los productos con stock que no se contaron quedan en 0
+type CerrarTomaRequest struct {
+	AjustarNoContados bool   `json:"ajustar_no_contados"`
+	Observaciones     string `json:"observaciones"`
+	IDUsuario         int    `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// CierreTomaInventario resultado del cierre de una toma
+type CierreTomaInventario struct {
+	Toma        *TomaInventario `json:"toma"`
+	Movimientos []*Movimiento   `json:"movimientos"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Tipos de movimiento de una transferencia directa entre locales
+const (
+	TipoMovimientoTransferenciaSalida  = "transferencia_salida"  // descuenta en el local origen
+	TipoMovimientoTransferenciaEntrada = "transferencia_entrada" // suma en el local destino
+)
+
+// Estados de una transferencia de stock
+const (
+	TransferenciaEstadoAplicada = "aplicada"
+	TransferenciaEstadoAnulada  = "anulada" // revertida en ambos locales
+)
+
+// TransferenciaStock representa la tabla transferencias_stock_cantera
+// Traspaso inmediato entre locales: ambos movimientos comparten IDOperacion
+type TransferenciaStock struct {
+	ID             int64                     `json:"id" db:"id"`
+	IDLocalOrigen  int                       `json:"id_local_origen" db:"id_local_origen"`
+	IDLocalDestino int                       `json:"id_local_destino" db:"id_local_destino"`
+	Estado         string                    `json:"estado" db:"estado"`
+	Motivo         string                    `json:"motivo" db:"motivo"`
+	Observaciones  string                    `json:"observaciones" db:"observaciones"`
+	IDUsuario      int                       `json:"id_usuario" db:"id_usuario"`
+	IDOperacion    string                    `json:"id_operacion" db:"id_operacion"`
+	CreatedAt      time.Time                 `json:"created_at" db:"created_at"`
+	Items          []*TransferenciaStockItem `json:"items"`
+
+	// Anulación: operación que revirtió los movimientos
+	IDOperacionAnulacion *string    `json:"id_operacion_anulacion,omitempty" db:"id_operacion_anulacion"`
+	MotivoAnulacion      *string    `json:"motivo_anulacion,omitempty" db:"motivo_anulacion"`
+	IDUsuarioAnulacion   *int       `json:"id_usuario_anulacion,omitempty" db:"id_usuario_anulacion"`
+	AnuladaAt            *time.Time `json:"anulada_at,omitempty" db:"anulada_at"`
+}
+
+// TransferenciaStockItem representa la tabla transferencia_stock_items_cantera
+// Cantidad en unidad base
+type TransferenciaStockItem struct {
+	ID              int64  `json:"id" db:"id"`
+	IDTransferencia int64  `json:"id_transferencia" db:"id_transferencia"`
+	CodigoProducto  string `json:"codigo_producto" db:"codigo_producto"`
+	TipoItem        string `json:"tipo_item" db:"tipo_item"`
+	Cantidad        int    `json:"cantidad" db:"cantidad"`
+}
+
+// TransferenciaStockRequest DTO para transferir stock entre locales
+type TransferenciaStockRequest struct {
+	IDLocalOrigen  int              `json:"id_local_origen" validate:"required,gt=0"`
+	IDLocalDestino int              `json:"id_local_destino" validate:"required,gt=0,nefield=IDLocalOrigen"`
+	Motivo         string           `json:"motivo" validate:"required,max=255"`
+	Observaciones  string           `json:"observaciones"`
+	Productos      []ProductoSalida `json:"productos" validate:"required,min=1,dive"`
+	IDUsuario      int              `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// AnularTransferenciaRequest DTO para anular una transferencia
+type AnularTransferenciaRequest struct {
+	Motivo    string `json:"motivo" validate:"required,max=255"`
+	IDUsuario int    `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// TransferenciaFilter filtros de la consulta de transferencias
+type TransferenciaFilter struct {
+	IDLocal *int    // como origen o destino
+	Estado  *string // aplicada / anulada
+	Limit   int
+}
@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// Resultado de verificar el turno de un usuario al registrar una operación
+const (
+	TurnoSinRestriccion = "sin_restriccion" // turnos desactivados o rol no restringido
+	TurnoSinDefinir     = "sin_turnos"      // el usuario no tiene turnos: no se restringe
+	TurnoEnHorario      = "en_turno"
+	TurnoExcepcion      = "excepcion" // fuera de turno con excepción vigente
+	TurnoFueraDeHorario = "fuera_de_turno"
+)
+
+// Turno representa la tabla turnos_cantera
+// Franja de un día de la semana; si HoraFin <= HoraInicio termina al día siguiente
+type Turno struct {
+	ID         int       `json:"id" db:"id"`
+	IDUsuario  int       `json:"id_usuario" db:"id_usuario"`
+	IDLocal    *int      `json:"id_local" db:"id_local"`     // nil: cualquier local
+	DiaSemana  int       `json:"dia_semana" db:"dia_semana"` // 0 = domingo ... 6 = sábado
+	HoraInicio string    `json:"hora_inicio" db:"hora_inicio"`
+	HoraFin    string    `json:"hora_fin" db:"hora_fin"`
+	Activo     bool      `json:"activo" db:"activo"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TurnoRequest DTO para definir o reemplazar un turno (horas HH:MM)
+type TurnoRequest struct {
+	IDUsuario int  `json:"id_usuario" validate:"required,gt=0"`
+	IDLocal   *int `json:"id_local,omitempty" validate:"omitempty,gt=0"`
+	// Puntero para distinguir el domingo (0) de un campo omitido
+	DiaSemana  *int   `json:"dia_semana" validate:"required,min=0,max=6"`
+	HoraInicio string `json:"hora_inicio" validate:"required"`
+	HoraFin    string `json:"hora_fin" validate:"required"`
+	Activo     *bool  `json:"activo,omitempty"`
+}
+
+// TurnoFilter filtros del listado de turnos
+type TurnoFilter struct {
+	IDUsuario *int
+	IDLocal   *int
+}
+
+// ExcepcionTurno representa la tabla excepciones_turno_cantera
+// Autorización de un supervisor para que el usuario opere fuera de su turno entre Desde y Hasta
+type ExcepcionTurno struct {
+	ID           int        `json:"id" db:"id"`
+	IDUsuario    int        `json:"id_usuario" db:"id_usuario"`
+	IDLocal      *int       `json:"id_local" db:"id_local"` // nil: cualquier local
+	Desde        time.Time  `json:"desde" db:"desde"`
+	Hasta        time.Time  `json:"hasta" db:"hasta"`
+	Motivo       string     `json:"motivo" db:"motivo"`
+	IDSupervisor int        `json:"id_supervisor" db:"id_supervisor"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	RevocadaAt   *time.Time `json:"revocada_at,omitempty" db:"revocada_at"`
+}
+
+// ExcepcionTurnoRequest DTO para autorizar una excepción (sin desde: a partir de ahora)
+type ExcepcionTurnoRequest struct {
+	IDUsuario    int        `json:"id_usuario" validate:"required,gt=0"`
+	IDLocal      *int       `json:"id_local,omitempty" validate:"omitempty,gt=0"`
+	Desde        *time.Time `json:"desde,omitempty"`
+	Hasta        time.Time  `json:"hasta" validate:"required"`
+	Motivo       string     `json:"motivo" validate:"required,max=255"`
+	IDSupervisor int        `json:"-"` // Se obtiene del contexto de autenticación
+}
+
+// ExcepcionTurnoFilter filtros del listado de excepciones
+type ExcepcionTurnoFilter struct {
+	IDUsuario    *int
+	SoloVigentes bool
+}
+
+// VerificacionTurno resultado de verificar si el usuario puede operar en el local en este momento
+type VerificacionTurno struct {
+	Permitido bool            `json:"permitido"`
+	Resultado string          `json:"resultado"`
+	Turno     *Turno          `json:"turno,omitempty"`     // turno en curso (en_turno)
+	Excepcion *ExcepcionTurno `json:"excepcion,omitempty"` // excepción que autoriza la operación
+	// Turnos del usuario aplicables al local (fuera_de_turno), para informar su horario
+	Turnos []*Turno `json:"turnos,omitempty"`
+}
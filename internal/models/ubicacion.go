@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// UbicacionProducto representa la tabla ubicaciones_producto_cantera
+// Dónde está físicamente el producto en el local (para recorrer el picking en orden)
+type UbicacionProducto struct {
+	ID             int       `json:"id" db:"id"`
+	CodigoProducto string    `json:"codigo_producto" db:"codigo_producto"`
+	NombreProducto *string   `json:"nombre_producto,omitempty"`
+	IDLocal        int       `json:"id_local" db:"id_local"`
+	Pasillo        string    `json:"pasillo" db:"pasillo"`
+	Rack           string    `json:"rack" db:"rack"`
+	Nivel          string    `json:"nivel" db:"nivel"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// UbicacionFisica pasillo, rack y nivel de un ítem de picking
+type UbicacionFisica struct {
+	Pasillo string `json:"pasillo"`
+	Rack    string `json:"rack,omitempty"`
+	Nivel   string `json:"nivel,omitempty"`
+}
+
+// UbicacionProductoRequest asigna (o cambia) la ubicación de un producto en un local
+type UbicacionProductoRequest struct {
+	CodigoProducto string `json:"codigo_producto" validate:"required"`
+	IDLocal        int    `json:"id_local" validate:"required,gt=0"`
+	Pasillo        string `json:"pasillo" validate:"required,max=20"`
+	Rack           string `json:"rack" validate:"max=20"`
+	Nivel          string `json:"nivel" validate:"max=20"`
+}
+
+// UbicacionFilter filtros para listar ubicaciones de un local
+type UbicacionFilter struct {
+	IDLocal int     `json:"id_local"`
+	Pasillo *string `json:"pasillo,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// UnidadMedida representa la tabla unidades_medida_cantera (maestro de unidades)
+type UnidadMedida struct {
+	Codigo    string    `json:"codigo" db:"codigo"`
+	Nombre    string    `json:"nombre" db:"nombre"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ConversionUnidad representa la tabla conversiones_unidad_cantera
+// Factor: cuántas unidades base del producto equivale una unidad (1 caja = 24 un)
+type ConversionUnidad struct {
+	CodigoProducto string    `json:"codigo_producto" db:"codigo_producto"`
+	Unidad         string    `json:"unidad" db:"unidad"`
+	Factor         int       `json:"factor" db:"factor"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CrearUnidadRequest DTO para agregar una unidad al maestro
+type CrearUnidadRequest struct {
+	Codigo string `json:"codigo" validate:"required,max=20"`
+	Nombre string `json:"nombre" validate:"required,max=100"`
+}
+
+// ConversionUnidadRequest DTO para definir el factor de conversión de un producto
+type ConversionUnidadRequest struct {
+	Factor int `json:"factor" validate:"required,gt=0"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Roles de los usuarios
+const (
+	RolAdmin     = "admin"
+	RolBodeguero = "bodeguero"
+	RolVendedor  = "vendedor"
+)
+
+// TokenTypeBearer tipo de token que se informa al cliente en el login
+const TokenTypeBearer = "Bearer"
+
+// LoginRequest DTO para iniciar sesión con usuario y contraseña
+type LoginRequest struct {
+	Username string `json:"username" validate:"required,max=50"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+// RefreshRequest DTO para renovar el token de acceso con el refresh token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// SesionResponse tokens emitidos por el login o el refresh
+// El refresh token es de un solo uso: cada refresh entrega uno nuevo
+type SesionResponse struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	Usuario          *Usuario  `json:"usuario"`
+}
+
+// CrearUsuarioRequest DTO para crear un usuario
+type CrearUsuarioRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=50"`
+	Email    string `json:"email" validate:"omitempty,email,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"` // bcrypt ignora lo que pasa de 72 bytes
+	Rol      string `json:"rol" validate:"required,oneof=admin bodeguero vendedor"`
+}
+
+// ActualizarUsuarioRequest DTO para actualizar un usuario (campos vacíos no se modifican)
+type ActualizarUsuarioRequest struct {
+	Email  *string `json:"email,omitempty" validate:"omitempty,email,max=255"`
+	Rol    *string `json:"rol,omitempty" validate:"omitempty,oneof=admin bodeguero vendedor"`
+	Activo *bool   `json:"activo,omitempty"`
+}
+
+// CambiarPasswordRequest DTO para fijar una nueva contraseña (revoca las sesiones abiertas)
+type CambiarPasswordRequest struct {
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
@@ -0,0 +1,138 @@
+package models
+
+import "time"
+
+// AvisoVencimiento representa la tabla avisos_vencimiento_cantera
+// Un lote próximo a vencer en un local con stock del producto, avisado en un umbral
+type AvisoVencimiento struct {
+	ID               int64     `json:"id" db:"id"`
+	FechaAviso       time.Time `json:"fecha_aviso" db:"fecha_aviso"`
+	IDLocal          int       `json:"id_local" db:"id_local"`
+	NombreLocal      *string   `json:"nombre_local,omitempty" db:"nombre_local"`
+	CodigoProducto   string    `json:"codigo_producto" db:"codigo_producto"`
+	NombreProducto   *string   `json:"nombre_producto,omitempty" db:"nombre_producto"`
+	CodigoBarras     string    `json:"codigo_barras" db:"codigo_barras"`
+	Lote             string    `json:"lote" db:"lote"`
+	FechaVencimiento time.Time `json:"fecha_vencimiento" db:"fecha_vencimiento"`
+	UmbralDias       int       `json:"umbral_dias" db:"umbral_dias"`
+	DiasRestantes    int       `json:"dias_restantes" db:"dias_restantes"`
+	Cantidad         int       `json:"cantidad" db:"cantidad"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// EjecucionAvisosVencimiento representa la tabla avisos_vencimiento_ejecuciones
+type EjecucionAvisosVencimiento struct {
+	Fecha       time.Time `json:"fecha" db:"fecha"`
+	Avisos      int       `json:"avisos" db:"avisos"`
+	Locales     int       `json:"locales" db:"locales"`
+	EjecutadoAt time.Time `json:"ejecutado_at" db:"ejecutado_at"`
+}
+
+// LotesPorUmbral lotes de un local avisados en un mismo umbral (7, 15, 30 días, ...)
+type LotesPorUmbral struct {
+	UmbralDias int                 `json:"umbral_dias"`
+	Cantidad   int                 `json:"cantidad"` // unidades en los lotes
+	Lotes      []*AvisoVencimiento `json:"lotes"`
+}
+
+// VencimientosLocal avisos de un local agrupados por umbral (del más urgente al menos)
+type VencimientosLocal struct {
+	IDLocal     int               `json:"id_local"`
+	NombreLocal *string           `json:"nombre_local,omitempty"`
+	TotalLotes  int               `json:"total_lotes"`
+	Umbrales    []*LotesPorUmbral `json:"umbrales"`
+}
+
+// ReporteVencimientos avisos de vencimiento de un día, por local
+// También es el payload del evento stock.vencimientos_proximos (uno por local)
+type ReporteVencimientos struct {
+	FechaAviso time.Time                   `json:"fecha_aviso"`
+	Ejecucion  *EjecucionAvisosVencimiento `json:"ejecucion,omitempty"`
+	TotalLotes int                         `json:"total_lotes"`
+	Locales    []*VencimientosLocal        `json:"locales"`
+}
+
+// ResultadoAvisosVencimiento resultado de una generación de avisos
+type ResultadoAvisosVencimiento struct {
+	FechaAviso time.Time `json:"fecha_aviso"`
+	// false si ya se habían generado los avisos del día (otra réplica o una ejecución previa)
+	Ejecutado   bool `json:"ejecutado"`
+	Avisos      int  `json:"avisos"`
+	Locales     int  `json:"locales"`
+	Notificados int  `json:"notificados"` // eventos encolados para los webhooks
+}
+
+// MotivoMermaVencimiento motivo de las salidas que dan de baja los lotes vencidos
+const MotivoMermaVencimiento = "Merma por vencimiento"
+
+// Estados de la baja de un lote vencido en un local
+const (
+	BajaEstadoPendiente = "pendiente" // reservada, aplicando la salida de stock
+	BajaEstadoAplicada  = "aplicada"  // salida por merma registrada
+)
+
+// LoteVencidoStock un lote vencido no dado de baja en un local con stock del producto
+type LoteVencidoStock struct {
+	IDLocal          int       `json:"id_local"`
+	NombreLocal      *string   `json:"nombre_local,omitempty"`
+	CodigoProducto   string    `json:"codigo_producto"`
+	NombreProducto   *string   `json:"nombre_producto,omitempty"`
+	CodigoBarras     string    `json:"codigo_barras"`
+	Lote             string    `json:"lote"`
+	FechaVencimiento time.Time `json:"fecha_vencimiento"`
+	Pendiente        int       `json:"pendiente"`  // unidades del lote aún no dadas de baja
+	Disponible       int       `json:"disponible"` // stock del local sin lo reservado
+}
+
+// BajaLoteVencido representa la tabla bajas_lotes_vencidos_cantera
+type BajaLoteVencido struct {
+	ID               int64     `json:"id,omitempty" db:"id"`
+	FechaBaja        time.Time `json:"fecha_baja" db:"fecha_baja"`
+	IDLocal          int       `json:"id_local" db:"id_local"`
+	NombreLocal      *string   `json:"nombre_local,omitempty" db:"nombre_local"`
+	CodigoProducto   string    `json:"codigo_producto" db:"codigo_producto"`
+	NombreProducto   *string   `json:"nombre_producto,omitempty" db:"nombre_producto"`
+	CodigoBarras     string    `json:"codigo_barras" db:"codigo_barras"`
+	Lote             string    `json:"lote" db:"lote"`
+	FechaVencimiento time.Time `json:"fecha_vencimiento" db:"fecha_vencimiento"`
+	Cantidad         int       `json:"cantidad" db:"cantidad"`
+	Estado           string    `json:"estado,omitempty" db:"estado"` // vacío en dry-run
+	IDOperacion      *string   `json:"id_operacion,omitempty" db:"id_operacion"`
+	CreatedAt        time.Time `json:"created_at,omitempty" db:"created_at"`
+}
+
+// EjecucionBajaVencidos representa la tabla bajas_lotes_vencidos_ejecuciones
+type EjecucionBajaVencidos struct {
+	Fecha       time.Time `json:"fecha" db:"fecha"`
+	DryRun      bool      `json:"dry_run" db:"dry_run"`
+	Lotes       int       `json:"lotes" db:"lotes"`
+	Unidades    int       `json:"unidades" db:"unidades"`
+	Locales     int       `json:"locales" db:"locales"`
+	Fallidos    int       `json:"fallidos" db:"fallidos"` // locales cuya salida no se pudo aplicar
+	EjecutadoAt time.Time `json:"ejecutado_at" db:"ejecutado_at"`
+}
+
+// BajasVencidosLocal bajas de lotes vencidos de un local
+type BajasVencidosLocal struct {
+	IDLocal     int                `json:"id_local"`
+	NombreLocal *string            `json:"nombre_local,omitempty"`
+	Lotes       int                `json:"lotes"`
+	Unidades    int                `json:"unidades"`
+	Error       string             `json:"error,omitempty"` // la salida del local no se aplicó
+	Bajas       []*BajaLoteVencido `json:"bajas"`
+}
+
+// ResumenBajaVencidos resumen de una baja de lotes vencidos, por local
+// También es el payload del evento stock.lotes_vencidos_baja (uno por local)
+type ResumenBajaVencidos struct {
+	Fecha     time.Time              `json:"fecha"`
+	DryRun    bool                   `json:"dry_run"`
+	Ejecucion *EjecucionBajaVencidos `json:"ejecucion,omitempty"`
+	// false si la baja del día ya se había ejecutado (otra réplica o una ejecución previa)
+	Ejecutado   bool                  `json:"ejecutado"`
+	Lotes       int                   `json:"lotes"`
+	Unidades    int                   `json:"unidades"`
+	Fallidos    int                   `json:"fallidos"`
+	Notificados int                   `json:"notificados,omitempty"` // eventos encolados para los webhooks
+	Locales     []*BajasVencidosLocal `json:"locales"`
+}
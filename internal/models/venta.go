@@ -0,0 +1,180 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Tipos de línea contable de una venta
+const (
+	LineaContableRedondeo      = "redondeo"
+	LineaContablePropina       = "propina"
+	LineaContableCargoServicio = "cargo_servicio"
+	LineaContableNotaCredito   = "nota_credito" // monto devuelto (negativo)
+)
+
+// SubtotalVenta montos de las líneas de productos de una venta, con IVA incluido
+type SubtotalVenta struct {
+	Afecto float64 // productos afectos a IVA
+	Exento float64 // productos exentos (es_exento)
+}
+
+// TotalesVenta desglose del total de una venta rápida
+// Neto + IVA + Exento + Propina + AjusteRedondeo = Total
+type TotalesVenta struct {
+	MedioPago      string  `json:"medio_pago,omitempty"`
+	Subtotal       float64 `json:"subtotal"` // suma de las líneas de productos
+	Propina        float64 `json:"propina"`
+	CargoServicio  float64 `json:"cargo_servicio"`
+	AjusteRedondeo float64 `json:"ajuste_redondeo"` // negativo si el redondeo rebaja el total
+	Total          float64 `json:"total"`
+
+	// Base imponible: la propina y el redondeo quedan fuera del cálculo de IVA
+	Neto   float64 `json:"neto"`
+	IVA    float64 `json:"iva"`
+	Exento float64 `json:"exento"`
+}
+
+// LineaContableVenta representa la tabla lineas_contables_venta_cantera
+// Monto de una venta que no corresponde a un producto (ajuste de redondeo, etc.)
+type LineaContableVenta struct {
+	ID        int       `json:"id" db:"id"`
+	VentaRef  int64     `json:"venta_ref" db:"venta_ref"`
+	IDLocal   int       `json:"id_local" db:"id_local"`
+	IDUsuario int       `json:"id_usuario" db:"id_usuario"`
+	MedioPago string    `json:"medio_pago" db:"medio_pago"`
+	Tipo      string    `json:"tipo" db:"tipo"`
+	Monto     float64   `json:"monto" db:"monto"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Origen del precio base de una línea de venta (antes de una modificación del cajero)
+const (
+	OrigenPrecioLista     = "lista"      // precio detalle de lista_precios_cantera
+	OrigenPrecioMaestro   = "maestro"    // precio del maestro de productos (sin precio de lista)
+	OrigenPrecioSinPrecio = "sin_precio" // ni lista ni maestro: se vendió a 0
+)
+
+// PrecioLineaVenta representa la tabla precios_lineas_venta_cantera
+// Precio con que se vendió cada línea y de dónde salió
+type PrecioLineaVenta struct {
+	ID             int    `json:"id" db:"id"`
+	VentaRef       int64  `json:"venta_ref" db:"venta_ref"`
+	IDOperacion    string `json:"id_operacion" db:"id_operacion"`
+	IDLocal        int    `json:"id_local" db:"id_local"`
+	IDUsuario      int    `json:"id_usuario" db:"id_usuario"`
+	CodigoProducto string `json:"codigo_producto" db:"codigo_producto"`
+	TipoItem       string `json:"tipo_item" db:"tipo_item"`
+	Cantidad       int    `json:"cantidad" db:"cantidad"`
+	// Precio de lista que tenía el POS (cache) y su versión
+	PrecioLista    *float64   `json:"precio_lista,omitempty" db:"precio_lista"`
+	ListaUpdatedAt *time.Time `json:"lista_updated_at,omitempty" db:"lista_updated_at"`
+	PrecioMaestro  *float64   `json:"precio_maestro,omitempty" db:"precio_maestro"`
+	OrigenPrecio   string     `json:"origen_precio" db:"origen_precio"`
+	// PrecioBase precio que correspondía (lista o maestro); PrecioCobrado el aplicado en la venta
+	PrecioBase       float64   `json:"precio_base" db:"precio_base"`
+	PrecioCobrado    float64   `json:"precio_cobrado" db:"precio_cobrado"`
+	PrecioModificado bool      `json:"precio_modificado" db:"precio_modificado"`
+	MotivoPrecio     *string   `json:"motivo_precio,omitempty" db:"motivo_precio"`
+	IDAutorizador    *int      `json:"id_autorizador,omitempty" db:"id_autorizador"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// NuevoPrecioLineaVenta arma el precio de la línea desde el producto de la cache del POS
+// precioCobrado es el precio final de la línea (el de lista o el modificado por el cajero)
+func NuevoPrecioLineaVenta(producto *ProductoCompleto, item *ProductoStock, precioCobrado float64) *PrecioLineaVenta {
+	linea := &PrecioLineaVenta{
+		CodigoProducto: item.CodigoProducto,
+		TipoItem:       item.TipoItem,
+		Cantidad:       item.Cantidad,
+		PrecioLista:    producto.ListaPrecioDetalle,
+		ListaUpdatedAt: producto.ListaUpdatedAt,
+		PrecioMaestro:  producto.Precio,
+		PrecioBase:     producto.PrecioVenta(),
+		PrecioCobrado:  precioCobrado,
+	}
+	switch {
+	case producto.ListaPrecioDetalle != nil:
+		linea.OrigenPrecio = OrigenPrecioLista
+	case producto.Precio != nil:
+		linea.OrigenPrecio = OrigenPrecioMaestro
+	default:
+		linea.OrigenPrecio = OrigenPrecioSinPrecio
+	}
+	if item.PrecioAplicado != nil {
+		linea.PrecioModificado = true
+		motivo := strings.TrimSpace(item.MotivoPrecio)
+		linea.MotivoPrecio = &motivo
+	}
+	return linea
+}
+
+// ExplicacionPrecioLinea reconstrucción del precio cobrado en una línea
+type ExplicacionPrecioLinea struct {
+	*PrecioLineaVenta
+	// Precio de lista vigente en el historial al momento de la venta (nil: sin historial)
+	PrecioHistorial *float64   `json:"precio_historial,omitempty"`
+	VigenteDesde    *time.Time `json:"historial_vigente_desde,omitempty"`
+	// El precio de lista usado (cache del POS) no coincide con el historial: la cache estaba desactualizada
+	DifiereDeHistorial bool   `json:"difiere_de_historial"`
+	Explicacion        string `json:"explicacion"`
+}
+
+// ExplicacionPreciosVenta reconstrucción de los precios cobrados en una venta del POS
+type ExplicacionPreciosVenta struct {
+	IDOperacion  string                    `json:"id_operacion"`
+	IDLocal      int                       `json:"id_local"`
+	Fecha        time.Time                 `json:"fecha"`
+	Lineas       []*ExplicacionPrecioLinea `json:"lineas"`
+	TotalCobrado float64                   `json:"total_cobrado"`
+}
+
+// Venta representa la tabla ventas_cantera
+// Venta rápida del POS con sus totales; ID es el venta_ref de sus líneas contables y precios
+type Venta struct {
+	ID            int64        `json:"id" db:"id"`
+	IDOperacion   string       `json:"id_operacion" db:"id_operacion"`
+	IDLocal       int          `json:"id_local" db:"id_local"`
+	IDUsuario     int          `json:"id_usuario" db:"id_usuario"`
+	IDAutorizador *int         `json:"id_autorizador,omitempty" db:"id_autorizador"`
+	Motivo        string       `json:"motivo" db:"motivo"`
+	Observaciones string       `json:"observaciones" db:"observaciones"`
+	Totales       TotalesVenta `json:"totales"`
+	// Marcada como posible duplicado por el control de ventas duplicadas
+	VentaSospechosa bool `json:"venta_sospechosa" db:"venta_sospechosa"`
+	// Recibida en modo degradado y aplicada al reconciliar
+	Encolada  bool            `json:"encolada" db:"encolada"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+	Detalles  []*VentaDetalle `json:"detalles,omitempty"`
+}
+
+// VentaDetalle representa la tabla venta_detalles_cantera
+// Línea vendida con el precio unitario cobrado
+type VentaDetalle struct {
+	ID               int64   `json:"id" db:"id"`
+	IDVenta          int64   `json:"id_venta" db:"id_venta"`
+	CodigoProducto   string  `json:"codigo_producto" db:"codigo_producto"`
+	TipoItem         string  `json:"tipo_item" db:"tipo_item"`
+	Cantidad         int     `json:"cantidad" db:"cantidad"`
+	PrecioUnitario   float64 `json:"precio_unitario" db:"precio_unitario"`
+	PrecioModificado bool    `json:"precio_modificado" db:"precio_modificado"`
+	Exento           bool    `json:"exento" db:"exento"`
+	Monto            float64 `json:"monto" db:"monto"`
+}
+
+// VentaFilter filtros de la consulta de ventas
+type VentaFilter struct {
+	IDLocal    *int
+	FechaDesde *time.Time
+	FechaHasta *time.Time // inclusiva (todo el día)
+	Limit      int
+	Offset     int
+}
+
+// PaginaVentas página de ventas con el total que cumple los filtros
+type PaginaVentas struct {
+	Ventas []*Venta `json:"ventas"`
+	Total  int      `json:"total"`
+	Limit  int      `json:"limit"`
+	Offset int      `json:"offset"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// VentaEncolada venta rápida recibida en modo degradado (PostgreSQL caído), pendiente de aplicar
+// Se validó solo contra la cache de productos; el stock se descuenta al reconciliar
+// ID se usa como id de operación de los movimientos, lo que hace idempotente la reconciliación
+type VentaEncolada struct {
+	ID        string           `json:"id"`
+	Venta     QuickSaleRequest `json:"venta"`
+	IDUsuario int              `json:"id_usuario"`
+	// Monto de cada producto al precio cobrado y productos exentos, para los totales
+	MontoPorProducto map[string]float64 `json:"monto_por_producto"`
+	Exentos          map[string]bool    `json:"exentos,omitempty"`
+	// Precios modificados por el cajero (se registran con la venta)
+	Overrides []*OverridePrecio `json:"overrides,omitempty"`
+	// Precio de cada línea según la cache del POS al encolar
+	PreciosLineas []*PrecioLineaVenta `json:"precios_lineas,omitempty"`
+	EncoladaAt    time.Time           `json:"encolada_at"`
+}
+
+// VentaEncoladaConErrores venta encolada que al reconciliar no pudo aplicarse completa
+// (por ejemplo stock insuficiente); queda para revisión del supervisor
+type VentaEncoladaConErrores struct {
+	Venta          *VentaEncolada  `json:"venta"`
+	Errores        []ProductoError `json:"errores"`
+	Aplicados      int             `json:"productos_aplicados"`
+	ReconciliadaAt time.Time       `json:"reconciliada_at"`
+}
+
+// ResultadoReconciliacionVentas resultado de una ronda de reconciliación de ventas encoladas
+type ResultadoReconciliacionVentas struct {
+	Aplicadas  int   `json:"aplicadas"`
+	ConErrores int   `json:"con_errores"`
+	Pendientes int64 `json:"pendientes"`
+	// Ventas que ya estaban aplicadas (reconciliación interrumpida y retomada)
+	YaAplicadas int `json:"ya_aplicadas"`
+}
+
+// EstadoVentasEncoladas cola de ventas encoladas
+type EstadoVentasEncoladas struct {
+	Pendientes int64 `json:"pendientes"`
+	// Antigüedad de la venta pendiente más vieja
+	MasAntiguaSegundos int64 `json:"mas_antigua_segundos"`
+	// Últimas ventas reconciliadas con errores (más reciente primero)
+	ConErrores []*VentaEncoladaConErrores `json:"con_errores"`
+}
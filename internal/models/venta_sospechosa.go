@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// VentaSospechosa representa la tabla ventas_sospechosas_cantera
+// Registra ventas rápidas idénticas detectadas dentro de la ventana de duplicados
+type VentaSospechosa struct {
+	ID          int        `json:"id" db:"id"`
+	Fingerprint string     `json:"fingerprint" db:"fingerprint"`
+	IDLocal     int        `json:"id_local" db:"id_local"`
+	IDUsuario   int        `json:"id_usuario" db:"id_usuario"`
+	Items       string     `json:"items" db:"items"`
+	Monto       float64    `json:"monto" db:"monto"`
+	Bloqueada   bool       `json:"bloqueada" db:"bloqueada"`
+	Revisada    bool       `json:"revisada" db:"revisada"`
+	RevisadaPor *int       `json:"revisada_por,omitempty" db:"revisada_por"`
+	RevisadaAt  *time.Time `json:"revisada_at,omitempty" db:"revisada_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// VentaSospechosaFilter filtros para consultar ventas sospechosas
+type VentaSospechosaFilter struct {
+	IDLocal        *int `json:"id_local,omitempty"`
+	SoloPendientes bool `json:"solo_pendientes"`
+	Limit          int  `json:"limit,omitempty"`
+}
+
+// DuplicateSaleCheck resultado de la verificación de venta duplicada
+type DuplicateSaleCheck struct {
+	Sospechosa  bool   `json:"sospechosa"`
+	Bloqueada   bool   `json:"bloqueada"`
+	Fingerprint string `json:"fingerprint"`
+}
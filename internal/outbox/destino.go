@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrDestinoNoPermitido la URL de un callback no está en los hosts permitidos o apunta a una
+// dirección interna (loopback, red privada, link-local)
+var ErrDestinoNoPermitido = errors.New("destino de callback no permitido")
+
+// redCGNAT espacio compartido de carrier-grade NAT (RFC 6598), tampoco es enrutable en Internet
+var redCGNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// ValidarDestino verifica que la URL de un callback sea http(s) absoluta, que su host esté en
+// hostsPermitidos y que todas las direcciones a las que resuelve sean públicas
+// Una entrada que empieza con "." permite cualquier subdominio (".ejemplo.cl")
+// Sin hosts permitidos no se acepta ningún callback
+func ValidarDestino(ctx context.Context, rawURL string, hostsPermitidos []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: debe ser una URL http(s) absoluta", ErrDestinoNoPermitido)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: no se aceptan credenciales en la URL", ErrDestinoNoPermitido)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if !hostPermitido(host, hostsPermitidos) {
+		return fmt.Errorf("%w: el host %s no está en WEBHOOK_CALLBACK_ALLOWED_HOSTS", ErrDestinoNoPermitido, host)
+	}
+
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: no se pudo resolver %s: %v", ErrDestinoNoPermitido, host, err)
+	}
+	for _, ip := range ips {
+		if !direccionPublica(ip.IP) {
+			return fmt.Errorf("%w: %s resuelve a la dirección interna %s", ErrDestinoNoPermitido, host, ip.IP)
+		}
+	}
+	return nil
+}
+
+// hostPermitido compara el host con la lista (sin distinguir mayúsculas)
+func hostPermitido(host string, hostsPermitidos []string) bool {
+	for _, permitido := range hostsPermitidos {
+		permitido = strings.ToLower(strings.TrimSpace(permitido))
+		if permitido == "" {
+			continue
+		}
+		if strings.HasPrefix(permitido, ".") {
+			if strings.HasSuffix(host, permitido) {
+				return true
+			}
+			continue
+		}
+		if host == permitido {
+			return true
+		}
+	}
+	return false
+}
+
+// direccionPublica indica si la IP es enrutable en Internet (no loopback, privada, link-local,
+// multicast, sin especificar ni CGNAT)
+func direccionPublica(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || redCGNAT.Contains(ip))
+}
+
+// NewClienteCallbacks cliente HTTP para las URLs que entregan los solicitantes
+// La conexión se rechaza si la dirección a la que se conecta no es pública (el DNS puede cambiar
+// entre la validación y el envío), no usa el proxy del entorno y no sigue redirecciones
+func NewClienteCallbacks(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !direccionPublica(ip) {
+				return fmt.Errorf("%w: conexión a la dirección interna %s", ErrDestinoNoPermitido, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,249 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// baseBackoff espera tras el primer intento fallido; se duplica por intento hasta maxBackoff
+	baseBackoff = 5 * time.Second
+	maxBackoff  = 10 * time.Minute
+	// purgeInterval cada cuánto se eliminan los eventos entregados fuera de la retención
+	purgeInterval = time.Hour
+)
+
+// Dispatcher entrega en background los eventos de la outbox a los webhooks configurados
+// La entrega es al menos una vez: un evento se marca enviado solo si todos los webhooks
+// respondieron 2xx, así que un webhook puede recibirlo repetido (usar X-Webhook-Event-ID)
+type Dispatcher struct {
+	repo   repository.OutboxRepository
+	client *http.Client
+	cfg    config.WebhooksConfig
+	logger *zap.Logger
+}
+
+// NewDispatcher crea el dispatcher de la outbox
+func NewDispatcher(repo repository.OutboxRepository, cfg config.WebhooksConfig, logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		repo:   repo,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Enabled indica si hay webhooks configurados (sin webhooks no se registran eventos)
+func (d *Dispatcher) Enabled() bool {
+	return len(d.cfg.URLs) > 0
+}
+
+// Start inicia la entrega hasta que se cancele ctx (no hace nada sin webhooks configurados)
+func (d *Dispatcher) Start(ctx context.Context) {
+	if !d.Enabled() {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(d.cfg.Interval)
+		defer ticker.Stop()
+		lastPurge := time.Time{}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// Mientras haya rondas completas quedan más pendientes: seguir sin esperar el tick
+				for {
+					if n := d.dispatchBatch(ctx); n < d.cfg.BatchSize || ctx.Err() != nil {
+						break
+					}
+				}
+				if time.Since(lastPurge) >= purgeInterval {
+					d.purge(ctx)
+					lastPurge = time.Now()
+				}
+			}
+		}
+	}()
+}
+
+// dispatchBatch toma y entrega una ronda de eventos; retorna cuántos tomó
+func (d *Dispatcher) dispatchBatch(ctx context.Context) int {
+	// Lease: lo que puede tardar la ronda completa si todos los webhooks agotan el timeout
+	lease := time.Duration(d.cfg.BatchSize*len(d.cfg.URLs))*d.cfg.Timeout + time.Minute
+	eventos, err := d.repo.TomarPendientes(ctx, d.cfg.BatchSize, lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.Warn("Error tomando eventos de la outbox", zap.Error(err))
+		}
+		return 0
+	}
+
+	for _, evento := range eventos {
+		if ctx.Err() != nil {
+			// Los no entregados vuelven a estar disponibles al vencer el lease
+			break
+		}
+		d.deliver(ctx, evento)
+	}
+
+	return len(eventos)
+}
+
+// deliver entrega el evento a todos los webhooks y registra el resultado
+func (d *Dispatcher) deliver(ctx context.Context, evento *models.EventoOutbox) {
+	body, err := json.Marshal(models.EventoWebhook{
+		ID:        evento.ID,
+		Tipo:      evento.Tipo,
+		Clave:     evento.Clave,
+		Payload:   evento.Payload,
+		CreatedAt: evento.CreatedAt,
+	})
+	if err != nil {
+		d.fail(ctx, evento, err, true)
+		return
+	}
+
+	for _, url := range d.cfg.URLs {
+		if err := d.post(ctx, url, evento, body); err != nil {
+			d.fail(ctx, evento, fmt.Errorf("%s: %w", url, err), false)
+			return
+		}
+	}
+
+	if err := d.repo.MarcarEnviado(ctx, evento.ID); err != nil {
+		// Se reenviará al vencer el lease (entrega al menos una vez)
+		d.logger.Warn("Error marcando evento de la outbox como enviado",
+			zap.Int64("id_evento", evento.ID),
+			zap.Error(err))
+	}
+}
+
+// post envía el cuerpo a un webhook; cualquier respuesta fuera de 2xx es un fallo
+func (d *Dispatcher) post(ctx context.Context, url string, evento *models.EventoOutbox, body []byte) error {
+	return Enviar(ctx, d.client, url, d.cfg.Secret, strconv.FormatInt(evento.ID, 10), evento.Tipo, body)
+}
+
+// Enviar hace el POST de un evento a una URL con los headers de los webhooks
+// (X-Webhook-Event-ID, X-Webhook-Event-Type y, con secreto, X-Webhook-Signature)
+// Cualquier respuesta fuera de 2xx es un fallo
+func Enviar(ctx context.Context, client *http.Client, url, secret, idEvento, tipo string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event-ID", idEvento)
+	req.Header.Set("X-Webhook-Event-Type", tipo)
+	if secret != "" {
+		req.Header.Set("X-Webhook-Signature", "sha256="+sign(secret, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("respuesta %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// fail registra el intento fallido: programa el reintento con backoff o descarta el evento
+// si agotó los intentos (o si nunca podrá entregarse)
+func (d *Dispatcher) fail(ctx context.Context, evento *models.EventoOutbox, cause error, permanent bool) {
+	attempts := evento.Intentos + 1
+	var next *time.Time
+	if !permanent && attempts < d.cfg.MaxAttempts {
+		t := time.Now().Add(Backoff(attempts))
+		next = &t
+	}
+
+	if err := d.repo.MarcarFallido(ctx, evento.ID, cause.Error(), next); err != nil {
+		d.logger.Warn("Error registrando fallo de entrega de la outbox",
+			zap.Int64("id_evento", evento.ID),
+			zap.Error(err))
+		return
+	}
+
+	if next == nil {
+		d.logger.Error("Evento de la outbox descartado tras agotar los reintentos",
+			zap.String("operation", "outbox_dispatch"),
+			zap.Int64("id_evento", evento.ID),
+			zap.String("tipo", evento.Tipo),
+			zap.Int("attempts", attempts),
+			zap.Error(cause))
+		return
+	}
+
+	d.logger.Warn("Entrega de evento de la outbox fallida, se reintentará",
+		zap.String("operation", "outbox_dispatch"),
+		zap.Int64("id_evento", evento.ID),
+		zap.Int("attempts", attempts),
+		zap.Time("next_attempt", *next),
+		zap.Error(cause))
+}
+
+// purge elimina los eventos entregados fuera de la retención
+func (d *Dispatcher) purge(ctx context.Context) {
+	antesDe := time.Now().AddDate(0, 0, -d.cfg.RetentionDays)
+	n, err := d.repo.PurgarEnviados(ctx, antesDe)
+	if err != nil {
+		d.logger.Warn("Error purgando eventos enviados de la outbox", zap.Error(err))
+		return
+	}
+	if n > 0 {
+		d.logger.Info("Eventos enviados de la outbox purgados",
+			zap.Int("eventos", n),
+			zap.Int("retention_days", d.cfg.RetentionDays))
+	}
+}
+
+// Resumen retorna el estado de la outbox
+func (d *Dispatcher) Resumen(ctx context.Context) (*models.ResumenOutbox, error) {
+	return d.repo.GetResumen(ctx)
+}
+
+// ReintentarDescartados vuelve a poner en cola los eventos descartados
+func (d *Dispatcher) ReintentarDescartados(ctx context.Context) (int, error) {
+	return d.repo.ReintentarDescartados(ctx)
+}
+
+// sign firma el cuerpo con HMAC-SHA256
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff espera antes del siguiente intento: se duplica por intento hasta maxBackoff
+func Backoff(attempts int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait
+}
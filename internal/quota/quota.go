@@ -0,0 +1,217 @@
+// Package quota limita los requests por API key con contadores en Redis
+// (ventanas fijas por minuto y por día, compartidas entre réplicas)
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"stock-service/internal/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// diasHistorial días de contadores diarios que se conservan para consultar el consumo
+const diasHistorial = 30
+
+// ErrUnknownKey la API key no está configurada
+var ErrUnknownKey = errors.New("API key desconocida")
+
+// Window estado de una ventana de la cuota tras contar el request
+type Window struct {
+	// Límite de la ventana (0 = sin límite)
+	Limit     int       `json:"limite"`
+	Used      int64     `json:"usados"`
+	Remaining int64     `json:"restantes"`
+	Reset     time.Time `json:"reinicio"`
+}
+
+// Exceeded indica si la ventana superó su límite
+func (w Window) Exceeded() bool {
+	return w.Limit > 0 && w.Used > int64(w.Limit)
+}
+
+// Result resultado de contar un request
+type Result struct {
+	Name   string
+	Minute Window
+	Day    Window
+}
+
+// Allowed indica si el request está dentro de la cuota
+func (r *Result) Allowed() bool {
+	return !r.Minute.Exceeded() && !r.Day.Exceeded()
+}
+
+// Binding retorna la ventana que más restringe (la excedida o la con menos restantes)
+// Es la que se informa en los headers X-RateLimit-*
+func (r *Result) Binding() Window {
+	switch {
+	case r.Day.Exceeded():
+		return r.Day
+	case r.Minute.Exceeded():
+		return r.Minute
+	case r.Day.Limit == 0:
+		return r.Minute
+	case r.Minute.Limit == 0:
+		return r.Day
+	case r.Day.Remaining < r.Minute.Remaining:
+		return r.Day
+	default:
+		return r.Minute
+	}
+}
+
+// Usage consumo de una API key
+type Usage struct {
+	Name      string `json:"nombre"`
+	PerMinute int    `json:"limite_por_minuto"`
+	PerDay    int    `json:"limite_por_dia"`
+	// Requests en el minuto y día actuales
+	Minute int64 `json:"minuto_actual"`
+	Today  int64 `json:"hoy"`
+	// Requests por día (YYYY-MM-DD, UTC), del más reciente al más antiguo
+	Days []DayUsage `json:"dias"`
+}
+
+// DayUsage requests de una API key en un día
+type DayUsage struct {
+	Fecha    string `json:"fecha"`
+	Requests int64  `json:"requests"`
+}
+
+// Limiter cuenta y limita los requests de cada API key
+type Limiter struct {
+	redisClient *redis.Client
+	byKey       map[string]config.APIKeyQuota
+	keys        []config.APIKeyQuota
+}
+
+// NewLimiter crea el limitador con las API keys configuradas
+func NewLimiter(redisClient *redis.Client, cfg config.QuotasConfig) *Limiter {
+	byKey := make(map[string]config.APIKeyQuota, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		byKey[k.Key] = k
+	}
+	return &Limiter{
+		redisClient: redisClient,
+		byKey:       byKey,
+		keys:        cfg.Keys,
+	}
+}
+
+// Name retorna el nombre de la integración dueña de la API key, sin contar el request
+func (l *Limiter) Name(apiKey string) (string, bool) {
+	k, ok := l.byKey[apiKey]
+	return k.Name, ok
+}
+
+// Take cuenta un request de la API key en ambas ventanas
+// Retorna ErrUnknownKey si la clave no está configurada
+func (l *Limiter) Take(ctx context.Context, apiKey string) (*Result, error) {
+	k, ok := l.byKey[apiKey]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	now := time.Now().UTC()
+	minuteStart := now.Truncate(time.Minute)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	pipe := l.redisClient.TxPipeline()
+	minuteCount := pipe.Incr(ctx, minuteKey(k.Name, minuteStart))
+	pipe.Expire(ctx, minuteKey(k.Name, minuteStart), 2*time.Minute)
+	dayCount := pipe.Incr(ctx, dayKey(k.Name, dayStart))
+	pipe.Expire(ctx, dayKey(k.Name, dayStart), (diasHistorial+1)*24*time.Hour)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count request for %s: %w", k.Name, err)
+	}
+
+	return &Result{
+		Name:   k.Name,
+		Minute: window(k.PerMinute, minuteCount.Val(), minuteStart.Add(time.Minute)),
+		Day:    window(k.PerDay, dayCount.Val(), dayStart.AddDate(0, 0, 1)),
+	}, nil
+}
+
+// Usage retorna el consumo de todas las API keys en los últimos dias días
+func (l *Limiter) Usage(ctx context.Context, dias int) ([]Usage, error) {
+	if dias < 1 {
+		dias = 1
+	}
+	if dias > diasHistorial {
+		dias = diasHistorial
+	}
+
+	now := time.Now().UTC()
+	minuteStart := now.Truncate(time.Minute)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	keys := append([]config.APIKeyQuota{}, l.keys...)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+
+	pipe := l.redisClient.Pipeline()
+	minuteCmds := make([]*redis.StringCmd, len(keys))
+	dayCmds := make([][]*redis.StringCmd, len(keys))
+	for i, k := range keys {
+		minuteCmds[i] = pipe.Get(ctx, minuteKey(k.Name, minuteStart))
+		for d := 0; d < dias; d++ {
+			dayCmds[i] = append(dayCmds[i], pipe.Get(ctx, dayKey(k.Name, dayStart.AddDate(0, 0, -d))))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read API key usage: %w", err)
+	}
+
+	usage := make([]Usage, 0, len(keys))
+	for i, k := range keys {
+		u := Usage{
+			Name:      k.Name,
+			PerMinute: k.PerMinute,
+			PerDay:    k.PerDay,
+			Minute:    count(minuteCmds[i]),
+			Days:      make([]DayUsage, 0, dias),
+		}
+		for d, cmd := range dayCmds[i] {
+			u.Days = append(u.Days, DayUsage{
+				Fecha:    dayStart.AddDate(0, 0, -d).Format("2006-01-02"),
+				Requests: count(cmd),
+			})
+		}
+		u.Today = u.Days[0].Requests
+		usage = append(usage, u)
+	}
+
+	return usage, nil
+}
+
+func window(limit int, used int64, reset time.Time) Window {
+	w := Window{Limit: limit, Used: used, Reset: reset}
+	if limit > 0 {
+		w.Remaining = int64(limit) - used
+		if w.Remaining < 0 {
+			w.Remaining = 0
+		}
+	}
+	return w
+}
+
+// count lee un contador; una clave inexistente es 0
+func count(cmd *redis.StringCmd) int64 {
+	n, err := cmd.Int64()
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func minuteKey(name string, start time.Time) string {
+	return fmt.Sprintf("quota:%s:min:%d", name, start.Unix())
+}
+
+func dayKey(name string, start time.Time) string {
+	return fmt.Sprintf("quota:%s:day:%s", name, start.Format("20060102"))
+}
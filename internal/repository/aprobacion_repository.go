@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// AprobacionRepository define la interfaz para las solicitudes de aprobación
+type AprobacionRepository interface {
+	CreateSolicitud(ctx context.Context, solicitud *models.SolicitudAprobacion) error
+	GetSolicitudByID(ctx context.Context, id int) (*models.SolicitudAprobacion, error)
+	GetSolicitudes(ctx context.Context, filter *models.SolicitudAprobacionFilter) ([]*models.SolicitudAprobacion, error)
+	Resolver(ctx context.Context, id int, estado string, idSupervisor int, motivoRechazo *string) (bool, error)
+	SetResultado(ctx context.Context, id int, estado string, resultado string) error
+}
+
+// aprobacionRepository implementa AprobacionRepository
+type aprobacionRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewAprobacionRepository crea una nueva instancia del repository
+func NewAprobacionRepository(db *sql.DB) (AprobacionRepository, error) {
+	repo := &aprobacionRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *aprobacionRepository) prepareStatements() error {
+	statements := map[string]string{
+		"create_solicitud": `
+			INSERT INTO solicitudes_aprobacion_cantera
+			(tipo_operacion, id_local, payload, cantidad_total, monto_total, estado, id_solicitante, reglas)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, created_at
+		`,
+		"get_solicitud": `
+			SELECT id, tipo_operacion, id_local, payload, cantidad_total, monto_total, estado,
+				   id_solicitante, id_supervisor, motivo_rechazo, resultado, reglas, created_at, resuelta_at
+			FROM solicitudes_aprobacion_cantera
+			WHERE id = $1
+		`,
+		"get_solicitudes": `
+			SELECT id, tipo_operacion, id_local, payload, cantidad_total, monto_total, estado,
+				   id_solicitante, id_supervisor, motivo_rechazo, resultado, reglas, created_at, resuelta_at
+			FROM solicitudes_aprobacion_cantera
+			WHERE ($1 = '' OR estado = $1)
+			  AND ($2::int IS NULL OR id_local = $2)
+			  AND ($3::int IS NULL OR id_solicitante = $3)
+			ORDER BY created_at DESC
+			LIMIT $4
+		`,
+		"resolver_solicitud": `
+			UPDATE solicitudes_aprobacion_cantera
+			SET estado = $2, id_supervisor = $3, motivo_rechazo = $4, resuelta_at = NOW()
+			WHERE id = $1 AND estado = 'pendiente'
+		`,
+		"set_resultado": `
+			UPDATE solicitudes_aprobacion_cantera
+			SET estado = $2, resultado = $3
+			WHERE id = $1
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// CreateSolicitud registra una solicitud pendiente de aprobación
+func (r *aprobacionRepository) CreateSolicitud(ctx context.Context, solicitud *models.SolicitudAprobacion) error {
+	err := r.stmts["create_solicitud"].QueryRowContext(ctx,
+		solicitud.TipoOperacion, solicitud.IDLocal, solicitud.Payload, solicitud.CantidadTotal,
+		solicitud.MontoTotal, solicitud.Estado, solicitud.IDSolicitante, solicitud.Reglas,
+	).Scan(&solicitud.ID, &solicitud.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create solicitud aprobacion: %w", err)
+	}
+
+	return nil
+}
+
+// GetSolicitudByID obtiene una solicitud por ID
+func (r *aprobacionRepository) GetSolicitudByID(ctx context.Context, id int) (*models.SolicitudAprobacion, error) {
+	solicitud, err := scanSolicitud(r.stmts["get_solicitud"].QueryRowContext(ctx, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get solicitud aprobacion: %w", err)
+	}
+
+	return solicitud, nil
+}
+
+// GetSolicitudes obtiene solicitudes con filtros
+func (r *aprobacionRepository) GetSolicitudes(ctx context.Context, filter *models.SolicitudAprobacionFilter) ([]*models.SolicitudAprobacion, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := r.stmts["get_solicitudes"].QueryContext(ctx, filter.Estado, filter.IDLocal, filter.IDSolicitante, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get solicitudes aprobacion: %w", err)
+	}
+	defer rows.Close()
+
+	var solicitudes []*models.SolicitudAprobacion
+	for rows.Next() {
+		solicitud, err := scanSolicitud(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan solicitud aprobacion: %w", err)
+		}
+		solicitudes = append(solicitudes, solicitud)
+	}
+
+	return solicitudes, nil
+}
+
+// Resolver aprueba o rechaza una solicitud solo si sigue pendiente
+func (r *aprobacionRepository) Resolver(ctx context.Context, id int, estado string, idSupervisor int, motivoRechazo *string) (bool, error) {
+	result, err := r.stmts["resolver_solicitud"].ExecContext(ctx, id, estado, idSupervisor, motivoRechazo)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolver solicitud aprobacion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// SetResultado guarda el resultado de aplicar una solicitud aprobada
+func (r *aprobacionRepository) SetResultado(ctx context.Context, id int, estado string, resultado string) error {
+	if _, err := r.stmts["set_resultado"].ExecContext(ctx, id, estado, resultado); err != nil {
+		return fmt.Errorf("failed to set resultado solicitud aprobacion: %w", err)
+	}
+
+	return nil
+}
+
+// scanSolicitud escanea una fila de solicitudes_aprobacion_cantera
+func scanSolicitud(row interface{ Scan(...interface{}) error }) (*models.SolicitudAprobacion, error) {
+	var solicitud models.SolicitudAprobacion
+	err := row.Scan(
+		&solicitud.ID, &solicitud.TipoOperacion, &solicitud.IDLocal, &solicitud.Payload,
+		&solicitud.CantidadTotal, &solicitud.MontoTotal, &solicitud.Estado, &solicitud.IDSolicitante,
+		&solicitud.IDSupervisor, &solicitud.MotivoRechazo, &solicitud.Resultado,
+		&solicitud.Reglas, &solicitud.CreatedAt, &solicitud.ResueltaAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &solicitud, nil
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// AvisoVencimientoRepository define la interfaz para los avisos de lotes próximos a vencer
+type AvisoVencimientoRepository interface {
+	// RegistrarAvisos registra en una transacción la ejecución del día y los avisos de los lotes
+	// que entraron en un umbral no avisado antes; eventos arma los eventos de la outbox que se
+	// insertan en la misma transacción. Sin forzar, si la ejecución del día ya existe no hace
+	// nada y retorna registrada=false
+	RegistrarAvisos(ctx context.Context, fecha time.Time, umbrales []int, forzar bool, eventos func([]*models.AvisoVencimiento) ([]*models.EventoOutbox, error)) (avisos []*models.AvisoVencimiento, registrada bool, err error)
+	// GetAvisos retorna los avisos generados en la fecha (opcionalmente de un local)
+	GetAvisos(ctx context.Context, fecha time.Time, idLocal *int) ([]*models.AvisoVencimiento, error)
+	// GetUltimaEjecucion retorna la ejecución más reciente hasta la fecha (nil si no hubo)
+	GetUltimaEjecucion(ctx context.Context, hasta time.Time) (*models.EjecucionAvisosVencimiento, error)
+}
+
+// avisoVencimientoRepository implementa AvisoVencimientoRepository
+type avisoVencimientoRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewAvisoVencimientoRepository crea una nueva instancia del repository
+func NewAvisoVencimientoRepository(db *sql.DB) (AvisoVencimientoRepository, error) {
+	repo := &avisoVencimientoRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *avisoVencimientoRepository) prepareStatements() error {
+	statements := map[string]string{
+		"insert_ejecucion": `
+			INSERT INTO avisos_vencimiento_ejecuciones (fecha)
+			VALUES ($1)
+			ON CONFLICT (fecha) DO NOTHING
+			RETURNING fecha
+		`,
+		// Forzada: toma el lock de la fila para no correr en paralelo con otra ejecución del día
+		"upsert_ejecucion": `
+			INSERT INTO avisos_vencimiento_ejecuciones (fecha)
+			VALUES ($1)
+			ON CONFLICT (fecha) DO UPDATE SET ejecutado_at = NOW()
+			RETURNING fecha
+		`,
+		// Lotes con stock que vencen dentro del mayor umbral, asociados a cada local con stock
+		// del producto; cada uno entra en el menor umbral que cubre los días que le quedan
+		// y la restricción única descarta los ya avisados en ese umbral
+		"insert_avisos": `
+			WITH lotes AS (
+				SELECT cvc.codigo_barras,
+					   COALESCE(cvc.lote, '') AS lote,
+					   cvc.fecha_vencimiento::date AS fecha_vencimiento,
+					   SUM(COALESCE(cvc.cantidad, 0))::int AS cantidad,
+					   cvc.fecha_vencimiento::date - $1::date AS dias_restantes
+				FROM control_vencimientos_cantera cvc
+				WHERE cvc.fecha_vencimiento::date >= $1::date
+				  AND cvc.fecha_vencimiento::date <= $1::date + (SELECT MAX(u) FROM unnest($2::int[]) u)
+				GROUP BY cvc.codigo_barras, COALESCE(cvc.lote, ''), cvc.fecha_vencimiento::date
+				HAVING SUM(COALESCE(cvc.cantidad, 0)) > 0
+			),
+			nuevos AS (
+				INSERT INTO avisos_vencimiento_cantera
+					(fecha_aviso, id_local, codigo_producto, codigo_barras, lote, fecha_vencimiento,
+					 umbral_dias, dias_restantes, cantidad)
+				SELECT $1::date, s.id_local, p.codigo, l.codigo_barras, l.lote, l.fecha_vencimiento,
+					   (SELECT MIN(u) FROM unnest($2::int[]) u WHERE u >= l.dias_restantes),
+					   l.dias_restantes, l.cantidad
+				FROM lotes l
+				JOIN productos p ON p.codigo_barra_interno = l.codigo_barras
+				JOIN stock_bodega_cantera s ON s.codigo_producto = p.codigo
+					AND s.tipo_item = 'producto'
+					AND s.cantidad_actual > 0
+				ON CONFLICT (id_local, codigo_barras, lote, fecha_vencimiento, umbral_dias) DO NOTHING
+				RETURNING id, fecha_aviso, id_local, codigo_producto, codigo_barras, lote,
+						  fecha_vencimiento, umbral_dias, dias_restantes, cantidad, created_at
+			)
+			SELECT n.id, n.fecha_aviso, n.id_local, loc.nombre_local, n.codigo_producto, p.nombre,
+				   n.codigo_barras, n.lote, n.fecha_vencimiento, n.umbral_dias, n.dias_restantes,
+				   n.cantidad, n.created_at
+			FROM nuevos n
+			LEFT JOIN locales loc ON loc.id = n.id_local
+			LEFT JOIN productos p ON p.codigo = n.codigo_producto
+			ORDER BY n.id_local, n.umbral_dias, n.fecha_vencimiento, n.codigo_producto
+		`,
+		"update_ejecucion": `
+			UPDATE avisos_vencimiento_ejecuciones e
+			SET avisos = t.avisos, locales = t.locales
+			FROM (
+				SELECT COUNT(*) AS avisos, COUNT(DISTINCT id_local) AS locales
+				FROM avisos_vencimiento_cantera
+				WHERE fecha_aviso = $1
+			) t
+			WHERE e.fecha = $1
+		`,
+		"create_evento_outbox": `
+			INSERT INTO outbox_eventos_cantera (tipo, clave, payload)
+			VALUES ($1, $2, $3)
+		`,
+		"get_avisos": `
+			SELECT a.id, a.fecha_aviso, a.id_local, loc.nombre_local, a.codigo_producto, p.nombre,
+				   a.codigo_barras, a.lote, a.fecha_vencimiento, a.umbral_dias, a.dias_restantes,
+				   a.cantidad, a.created_at
+			FROM avisos_vencimiento_cantera a
+			LEFT JOIN locales loc ON loc.id = a.id_local
+			LEFT JOIN productos p ON p.codigo = a.codigo_producto
+			WHERE a.fecha_aviso = $1
+			  AND ($2::int IS NULL OR a.id_local = $2)
+			ORDER BY a.id_local, a.umbral_dias, a.fecha_vencimiento, a.codigo_producto
+		`,
+		"get_ultima_ejecucion": `
+			SELECT fecha, avisos, locales, ejecutado_at
+			FROM avisos_vencimiento_ejecuciones
+			WHERE fecha <= $1
+			ORDER BY fecha DESC
+			LIMIT 1
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// RegistrarAvisos registra la ejecución del día, los avisos nuevos y sus eventos
+func (r *avisoVencimientoRepository) RegistrarAvisos(ctx context.Context, fecha time.Time, umbrales []int, forzar bool, eventos func([]*models.AvisoVencimiento) ([]*models.EventoOutbox, error)) ([]*models.AvisoVencimiento, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Otra réplica que registre el mismo día espera acá hasta el commit y luego no inserta nada
+	ejecucion := "insert_ejecucion"
+	if forzar {
+		ejecucion = "upsert_ejecucion"
+	}
+	var registrada time.Time
+	err = tx.StmtContext(ctx, r.stmts[ejecucion]).QueryRowContext(ctx, fecha).Scan(&registrada)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to register ejecucion avisos: %w", err)
+	}
+
+	rows, err := tx.StmtContext(ctx, r.stmts["insert_avisos"]).QueryContext(ctx, fecha, pq.Array(umbrales))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to insert avisos vencimiento: %w", err)
+	}
+	avisos, err := scanAvisosVencimiento(rows)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if _, err := tx.StmtContext(ctx, r.stmts["update_ejecucion"]).ExecContext(ctx, fecha); err != nil {
+		return nil, false, fmt.Errorf("failed to update ejecucion avisos: %w", err)
+	}
+
+	if len(avisos) > 0 && eventos != nil {
+		pendientes, err := eventos(avisos)
+		if err != nil {
+			return nil, false, err
+		}
+		insert := tx.StmtContext(ctx, r.stmts["create_evento_outbox"])
+		for _, evento := range pendientes {
+			if _, err := insert.ExecContext(ctx, evento.Tipo, evento.Clave, []byte(evento.Payload)); err != nil {
+				return nil, false, fmt.Errorf("failed to create evento outbox: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return avisos, true, nil
+}
+
+// GetAvisos retorna los avisos generados en la fecha
+func (r *avisoVencimientoRepository) GetAvisos(ctx context.Context, fecha time.Time, idLocal *int) ([]*models.AvisoVencimiento, error) {
+	rows, err := r.stmts["get_avisos"].QueryContext(ctx, fecha, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query avisos vencimiento: %w", err)
+	}
+	return scanAvisosVencimiento(rows)
+}
+
+// GetUltimaEjecucion retorna la ejecución más reciente hasta la fecha
+func (r *avisoVencimientoRepository) GetUltimaEjecucion(ctx context.Context, hasta time.Time) (*models.EjecucionAvisosVencimiento, error) {
+	var ejecucion models.EjecucionAvisosVencimiento
+	err := r.stmts["get_ultima_ejecucion"].QueryRowContext(ctx, hasta).Scan(
+		&ejecucion.Fecha, &ejecucion.Avisos, &ejecucion.Locales, &ejecucion.EjecutadoAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ultima ejecucion avisos: %w", err)
+	}
+
+	return &ejecucion, nil
+}
+
+// scanAvisosVencimiento escanea (y cierra) las filas de avisos
+func scanAvisosVencimiento(rows *sql.Rows) ([]*models.AvisoVencimiento, error) {
+	defer rows.Close()
+
+	avisos := []*models.AvisoVencimiento{}
+	for rows.Next() {
+		var aviso models.AvisoVencimiento
+		if err := rows.Scan(
+			&aviso.ID, &aviso.FechaAviso, &aviso.IDLocal, &aviso.NombreLocal, &aviso.CodigoProducto,
+			&aviso.NombreProducto, &aviso.CodigoBarras, &aviso.Lote, &aviso.FechaVencimiento,
+			&aviso.UmbralDias, &aviso.DiasRestantes, &aviso.Cantidad, &aviso.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan aviso vencimiento: %w", err)
+		}
+		avisos = append(avisos, &aviso)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate avisos vencimiento: %w", err)
+	}
+
+	return avisos, nil
+}
@@ -0,0 +1,337 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// BajaVencidosRepository define la interfaz para la baja de lotes vencidos
+type BajaVencidosRepository interface {
+	// RegistrarEjecucion registra la ejecución del día; sin forzar, si ya existe retorna false
+	RegistrarEjecucion(ctx context.Context, fecha time.Time, dryRun, forzar bool) (bool, error)
+	// GetLotesVencidos retorna los lotes vencidos antes de la fecha con unidades sin dar de baja,
+	// por cada local con stock disponible del producto que aún no les dio de baja
+	GetLotesVencidos(ctx context.Context, fecha time.Time) ([]*models.LoteVencidoStock, error)
+	// ReservarBajas registra las bajas como pendientes; retorna solo las registradas
+	// (una baja del mismo lote y local ya existente se descarta)
+	ReservarBajas(ctx context.Context, bajas []*models.BajaLoteVencido) ([]*models.BajaLoteVencido, error)
+	// ConfirmarBajas marca las bajas como aplicadas con la operación de su salida de stock
+	ConfirmarBajas(ctx context.Context, ids []int64, idOperacion string) error
+	// LiberarBajas elimina las bajas pendientes cuya salida no se aplicó
+	LiberarBajas(ctx context.Context, ids []int64) error
+	// FinalizarEjecucion guarda los totales de la ejecución e inserta sus eventos en la outbox
+	// en la misma transacción
+	FinalizarEjecucion(ctx context.Context, ejecucion *models.EjecucionBajaVencidos, eventos []*models.EventoOutbox) error
+	// GetBajas retorna las bajas registradas en la fecha (opcionalmente de un local)
+	GetBajas(ctx context.Context, fecha time.Time, idLocal *int) ([]*models.BajaLoteVencido, error)
+	// GetUltimaEjecucion retorna la ejecución más reciente hasta la fecha (nil si no hubo)
+	GetUltimaEjecucion(ctx context.Context, hasta time.Time) (*models.EjecucionBajaVencidos, error)
+}
+
+// bajaVencidosRepository implementa BajaVencidosRepository
+type bajaVencidosRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewBajaVencidosRepository crea una nueva instancia del repository
+func NewBajaVencidosRepository(db *sql.DB) (BajaVencidosRepository, error) {
+	repo := &bajaVencidosRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *bajaVencidosRepository) prepareStatements() error {
+	statements := map[string]string{
+		"insert_ejecucion": `
+			INSERT INTO bajas_lotes_vencidos_ejecuciones (fecha, dry_run)
+			VALUES ($1, $2)
+			ON CONFLICT (fecha) DO NOTHING
+			RETURNING fecha
+		`,
+		"upsert_ejecucion": `
+			INSERT INTO bajas_lotes_vencidos_ejecuciones (fecha, dry_run)
+			VALUES ($1, $2)
+			ON CONFLICT (fecha) DO UPDATE SET dry_run = EXCLUDED.dry_run, ejecutado_at = NOW()
+			RETURNING fecha
+		`,
+		// Lotes vencidos con unidades sin dar de baja, asociados a cada local con stock del
+		// producto (descontando lo reservado por pickings preparados) donde aún no se dieron de baja
+		"get_lotes_vencidos": `
+			WITH lotes AS (
+				SELECT cvc.codigo_barras,
+					   COALESCE(cvc.lote, '') AS lote,
+					   cvc.fecha_vencimiento::date AS fecha_vencimiento,
+					   SUM(COALESCE(cvc.cantidad, 0))::int AS cantidad
+				FROM control_vencimientos_cantera cvc
+				WHERE cvc.fecha_vencimiento::date < $1::date
+				GROUP BY cvc.codigo_barras, COALESCE(cvc.lote, ''), cvc.fecha_vencimiento::date
+				HAVING SUM(COALESCE(cvc.cantidad, 0)) > 0
+			),
+			pendientes AS (
+				SELECT l.codigo_barras, l.lote, l.fecha_vencimiento,
+					   l.cantidad - COALESCE((
+						   SELECT SUM(b.cantidad)
+						   FROM bajas_lotes_vencidos_cantera b
+						   WHERE b.codigo_barras = l.codigo_barras
+							 AND b.lote = l.lote
+							 AND b.fecha_vencimiento = l.fecha_vencimiento
+					   ), 0)::int AS pendiente
+				FROM lotes l
+			)
+			SELECT s.id_local, loc.nombre_local, p.codigo, p.nombre, l.codigo_barras, l.lote,
+				   l.fecha_vencimiento, l.pendiente,
+				   (s.cantidad_actual - COALESCE((
+					   SELECT SUM(pi.cantidad_solicitada)
+					   FROM picking_items_cantera pi
+					   JOIN pickings_cantera pk ON pk.id = pi.id_picking
+					   WHERE pi.codigo_producto = p.codigo AND pk.id_local = s.id_local
+						 AND pk.estado = 'preparado' AND pk.expires_at > NOW()
+				   ), 0))::int AS disponible
+			FROM pendientes l
+			JOIN productos p ON p.codigo_barra_interno = l.codigo_barras
+			JOIN stock_bodega_cantera s ON s.codigo_producto = p.codigo
+				AND s.tipo_item = 'producto'
+				AND s.cantidad_actual > 0
+			LEFT JOIN locales loc ON loc.id = s.id_local
+			WHERE l.pendiente > 0
+			  AND NOT EXISTS (
+				  SELECT 1 FROM bajas_lotes_vencidos_cantera b
+				  WHERE b.id_local = s.id_local
+					AND b.codigo_barras = l.codigo_barras
+					AND b.lote = l.lote
+					AND b.fecha_vencimiento = l.fecha_vencimiento
+			  )
+			ORDER BY l.fecha_vencimiento, l.codigo_barras, l.lote, s.id_local
+		`,
+		"insert_baja": `
+			INSERT INTO bajas_lotes_vencidos_cantera
+				(fecha_baja, id_local, codigo_producto, codigo_barras, lote, fecha_vencimiento, cantidad, estado)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, 'pendiente')
+			ON CONFLICT (id_local, codigo_barras, lote, fecha_vencimiento) DO NOTHING
+			RETURNING id, estado, created_at
+		`,
+		"confirmar_bajas": `
+			UPDATE bajas_lotes_vencidos_cantera
+			SET estado = 'aplicada', id_operacion = $2
+			WHERE id = ANY($1) AND estado = 'pendiente'
+		`,
+		"liberar_bajas": `
+			DELETE FROM bajas_lotes_vencidos_cantera
+			WHERE id = ANY($1) AND estado = 'pendiente'
+		`,
+		"update_ejecucion": `
+			UPDATE bajas_lotes_vencidos_ejecuciones
+			SET lotes = $2, unidades = $3, locales = $4, fallidos = $5
+			WHERE fecha = $1
+		`,
+		"create_evento_outbox": `
+			INSERT INTO outbox_eventos_cantera (tipo, clave, payload)
+			VALUES ($1, $2, $3)
+		`,
+		"get_bajas": `
+			SELECT b.id, b.fecha_baja, b.id_local, loc.nombre_local, b.codigo_producto, p.nombre,
+				   b.codigo_barras, b.lote, b.fecha_vencimiento, b.cantidad, b.estado,
+				   b.id_operacion, b.created_at
+			FROM bajas_lotes_vencidos_cantera b
+			LEFT JOIN locales loc ON loc.id = b.id_local
+			LEFT JOIN productos p ON p.codigo = b.codigo_producto
+			WHERE b.fecha_baja = $1
+			  AND ($2::int IS NULL OR b.id_local = $2)
+			ORDER BY b.id_local, b.fecha_vencimiento, b.codigo_producto
+		`,
+		"get_ultima_ejecucion": `
+			SELECT fecha, dry_run, lotes, unidades, locales, fallidos, ejecutado_at
+			FROM bajas_lotes_vencidos_ejecuciones
+			WHERE fecha <= $1
+			ORDER BY fecha DESC
+			LIMIT 1
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// RegistrarEjecucion registra la ejecución del día
+func (r *bajaVencidosRepository) RegistrarEjecucion(ctx context.Context, fecha time.Time, dryRun, forzar bool) (bool, error) {
+	ejecucion := "insert_ejecucion"
+	if forzar {
+		ejecucion = "upsert_ejecucion"
+	}
+	var registrada time.Time
+	err := r.stmts[ejecucion].QueryRowContext(ctx, fecha, dryRun).Scan(&registrada)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to register ejecucion bajas: %w", err)
+	}
+	return true, nil
+}
+
+// GetLotesVencidos retorna los lotes vencidos pendientes de baja por local
+func (r *bajaVencidosRepository) GetLotesVencidos(ctx context.Context, fecha time.Time) ([]*models.LoteVencidoStock, error) {
+	rows, err := r.stmts["get_lotes_vencidos"].QueryContext(ctx, fecha)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lotes vencidos: %w", err)
+	}
+	defer rows.Close()
+
+	lotes := []*models.LoteVencidoStock{}
+	for rows.Next() {
+		var lote models.LoteVencidoStock
+		if err := rows.Scan(
+			&lote.IDLocal, &lote.NombreLocal, &lote.CodigoProducto, &lote.NombreProducto,
+			&lote.CodigoBarras, &lote.Lote, &lote.FechaVencimiento, &lote.Pendiente, &lote.Disponible,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan lote vencido: %w", err)
+		}
+		lotes = append(lotes, &lote)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate lotes vencidos: %w", err)
+	}
+
+	return lotes, nil
+}
+
+// ReservarBajas registra las bajas como pendientes en una transacción
+func (r *bajaVencidosRepository) ReservarBajas(ctx context.Context, bajas []*models.BajaLoteVencido) ([]*models.BajaLoteVencido, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insert := tx.StmtContext(ctx, r.stmts["insert_baja"])
+	reservadas := make([]*models.BajaLoteVencido, 0, len(bajas))
+	for _, baja := range bajas {
+		err := insert.QueryRowContext(ctx,
+			baja.FechaBaja, baja.IDLocal, baja.CodigoProducto, baja.CodigoBarras, baja.Lote,
+			baja.FechaVencimiento, baja.Cantidad,
+		).Scan(&baja.ID, &baja.Estado, &baja.CreatedAt)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert baja lote vencido: %w", err)
+		}
+		reservadas = append(reservadas, baja)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return reservadas, nil
+}
+
+// ConfirmarBajas marca las bajas como aplicadas
+func (r *bajaVencidosRepository) ConfirmarBajas(ctx context.Context, ids []int64, idOperacion string) error {
+	if _, err := r.stmts["confirmar_bajas"].ExecContext(ctx, pq.Array(ids), idOperacion); err != nil {
+		return fmt.Errorf("failed to confirm bajas lotes vencidos: %w", err)
+	}
+	return nil
+}
+
+// LiberarBajas elimina las bajas pendientes
+func (r *bajaVencidosRepository) LiberarBajas(ctx context.Context, ids []int64) error {
+	if _, err := r.stmts["liberar_bajas"].ExecContext(ctx, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to release bajas lotes vencidos: %w", err)
+	}
+	return nil
+}
+
+// FinalizarEjecucion guarda los totales de la ejecución y sus eventos
+func (r *bajaVencidosRepository) FinalizarEjecucion(ctx context.Context, ejecucion *models.EjecucionBajaVencidos, eventos []*models.EventoOutbox) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.StmtContext(ctx, r.stmts["update_ejecucion"]).ExecContext(ctx,
+		ejecucion.Fecha, ejecucion.Lotes, ejecucion.Unidades, ejecucion.Locales, ejecucion.Fallidos,
+	); err != nil {
+		return fmt.Errorf("failed to update ejecucion bajas: %w", err)
+	}
+
+	insert := tx.StmtContext(ctx, r.stmts["create_evento_outbox"])
+	for _, evento := range eventos {
+		if _, err := insert.ExecContext(ctx, evento.Tipo, evento.Clave, []byte(evento.Payload)); err != nil {
+			return fmt.Errorf("failed to create evento outbox: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetBajas retorna las bajas registradas en la fecha
+func (r *bajaVencidosRepository) GetBajas(ctx context.Context, fecha time.Time, idLocal *int) ([]*models.BajaLoteVencido, error) {
+	rows, err := r.stmts["get_bajas"].QueryContext(ctx, fecha, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bajas lotes vencidos: %w", err)
+	}
+	defer rows.Close()
+
+	bajas := []*models.BajaLoteVencido{}
+	for rows.Next() {
+		var baja models.BajaLoteVencido
+		if err := rows.Scan(
+			&baja.ID, &baja.FechaBaja, &baja.IDLocal, &baja.NombreLocal, &baja.CodigoProducto,
+			&baja.NombreProducto, &baja.CodigoBarras, &baja.Lote, &baja.FechaVencimiento,
+			&baja.Cantidad, &baja.Estado, &baja.IDOperacion, &baja.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan baja lote vencido: %w", err)
+		}
+		bajas = append(bajas, &baja)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate bajas lotes vencidos: %w", err)
+	}
+
+	return bajas, nil
+}
+
+// GetUltimaEjecucion retorna la ejecución más reciente hasta la fecha
+func (r *bajaVencidosRepository) GetUltimaEjecucion(ctx context.Context, hasta time.Time) (*models.EjecucionBajaVencidos, error) {
+	var ejecucion models.EjecucionBajaVencidos
+	err := r.stmts["get_ultima_ejecucion"].QueryRowContext(ctx, hasta).Scan(
+		&ejecucion.Fecha, &ejecucion.DryRun, &ejecucion.Lotes, &ejecucion.Unidades,
+		&ejecucion.Locales, &ejecucion.Fallidos, &ejecucion.EjecutadoAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ultima ejecucion bajas: %w", err)
+	}
+
+	return &ejecucion, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// BloqueoProductoRepository define la interfaz para los bloqueos de venta de productos
+type BloqueoProductoRepository interface {
+	CreateBloqueo(ctx context.Context, bloqueo *models.BloqueoProducto) error
+	GetBloqueos(ctx context.Context, codigoProducto string) ([]*models.BloqueoProducto, error)
+	GetBloqueosVigentes(ctx context.Context) ([]*models.BloqueoProducto, error)
+	LevantarBloqueos(ctx context.Context, codigoProducto string, idUsuario int, motivo string) (int64, error)
+}
+
+// bloqueoProductoRepository implementa BloqueoProductoRepository
+type bloqueoProductoRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewBloqueoProductoRepository crea una nueva instancia del repository
+func NewBloqueoProductoRepository(db *sql.DB) (BloqueoProductoRepository, error) {
+	repo := &bloqueoProductoRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// bloqueoColumns columnas de bloqueos_producto_cantera en el orden de scanBloqueo
+const bloqueoColumns = `
+	id, codigo_producto, motivo, hasta, id_usuario, created_at,
+	levantado_at IS NULL AND (hasta IS NULL OR hasta > NOW()) AS vigente,
+	levantado_at, id_usuario_levanta, motivo_levantamiento
+`
+
+// prepareStatements prepara todas las consultas SQL
+func (r *bloqueoProductoRepository) prepareStatements() error {
+	statements := map[string]string{
+		"create_bloqueo": `
+			INSERT INTO bloqueos_producto_cantera (codigo_producto, motivo, hasta, id_usuario)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at
+		`,
+		"get_bloqueos": `
+			SELECT ` + bloqueoColumns + `
+			FROM bloqueos_producto_cantera
+			WHERE codigo_producto = $1
+			ORDER BY created_at DESC
+		`,
+		"get_bloqueos_vigentes": `
+			SELECT ` + bloqueoColumns + `
+			FROM bloqueos_producto_cantera
+			WHERE levantado_at IS NULL AND (hasta IS NULL OR hasta > NOW())
+			ORDER BY created_at DESC
+		`,
+		"levantar_bloqueos": `
+			UPDATE bloqueos_producto_cantera
+			SET levantado_at = NOW(), id_usuario_levanta = $2, motivo_levantamiento = $3
+			WHERE codigo_producto = $1
+			  AND levantado_at IS NULL AND (hasta IS NULL OR hasta > NOW())
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// CreateBloqueo registra un bloqueo de venta
+func (r *bloqueoProductoRepository) CreateBloqueo(ctx context.Context, bloqueo *models.BloqueoProducto) error {
+	err := r.stmts["create_bloqueo"].QueryRowContext(ctx,
+		bloqueo.CodigoProducto, bloqueo.Motivo, bloqueo.Hasta, bloqueo.IDUsuario,
+	).Scan(&bloqueo.ID, &bloqueo.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create bloqueo: %w", err)
+	}
+	bloqueo.Vigente = true
+
+	return nil
+}
+
+// GetBloqueos obtiene los bloqueos de un producto, vigentes o no, del más reciente al más antiguo
+func (r *bloqueoProductoRepository) GetBloqueos(ctx context.Context, codigoProducto string) ([]*models.BloqueoProducto, error) {
+	rows, err := r.stmts["get_bloqueos"].QueryContext(ctx, codigoProducto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bloqueos: %w", err)
+	}
+	defer rows.Close()
+
+	return scanBloqueos(rows)
+}
+
+// GetBloqueosVigentes obtiene los bloqueos vigentes de todos los productos
+func (r *bloqueoProductoRepository) GetBloqueosVigentes(ctx context.Context) ([]*models.BloqueoProducto, error) {
+	rows, err := r.stmts["get_bloqueos_vigentes"].QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bloqueos vigentes: %w", err)
+	}
+	defer rows.Close()
+
+	return scanBloqueos(rows)
+}
+
+// LevantarBloqueos levanta los bloqueos vigentes del producto; retorna cuántos levantó
+func (r *bloqueoProductoRepository) LevantarBloqueos(ctx context.Context, codigoProducto string, idUsuario int, motivo string) (int64, error) {
+	result, err := r.stmts["levantar_bloqueos"].ExecContext(ctx, codigoProducto, idUsuario, motivo)
+	if err != nil {
+		return 0, fmt.Errorf("failed to levantar bloqueos: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows, nil
+}
+
+// scanBloqueos lee las filas de bloqueoColumns
+func scanBloqueos(rows *sql.Rows) ([]*models.BloqueoProducto, error) {
+	bloqueos := []*models.BloqueoProducto{}
+	for rows.Next() {
+		var b models.BloqueoProducto
+		if err := rows.Scan(
+			&b.ID, &b.CodigoProducto, &b.Motivo, &b.Hasta, &b.IDUsuario, &b.CreatedAt,
+			&b.Vigente, &b.LevantadoAt, &b.IDUsuarioLevanta, &b.MotivoLevantamiento,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan bloqueo: %w", err)
+		}
+		bloqueos = append(bloqueos, &b)
+	}
+
+	return bloqueos, rows.Err()
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// BotonRapidoRepository define la interfaz para la grilla de botones rápidos del POS
+type BotonRapidoRepository interface {
+	GetBotones(ctx context.Context, idLocal int) ([]*models.BotonRapido, error)
+	ReemplazarBotones(ctx context.Context, idLocal int, botones []models.BotonRapidoRequest) error
+	DeleteBoton(ctx context.Context, idLocal, posicion int) (bool, error)
+}
+
+// botonRapidoRepository implementa BotonRapidoRepository
+type botonRapidoRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewBotonRapidoRepository crea una nueva instancia del repository
+func NewBotonRapidoRepository(db *sql.DB) (BotonRapidoRepository, error) {
+	repo := &botonRapidoRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *botonRapidoRepository) prepareStatements() error {
+	statements := map[string]string{
+		"get_botones": `
+			SELECT b.posicion, b.codigo_producto, p.nombre, b.etiqueta, b.color,
+				   lp.precio_detalle, img.url_miniatura
+			FROM botones_rapidos_cantera b
+			JOIN productos p ON p.codigo = b.codigo_producto
+			LEFT JOIN lista_precios_cantera lp ON lp.codigo_tivendo = b.codigo_producto
+			LEFT JOIN imagenes_productos_cantera img ON img.codigo_producto = b.codigo_producto
+			WHERE b.id_local = $1
+			ORDER BY b.posicion
+		`,
+		"delete_botones_local": `
+			DELETE FROM botones_rapidos_cantera
+			WHERE id_local = $1
+		`,
+		"create_boton": `
+			INSERT INTO botones_rapidos_cantera (id_local, posicion, codigo_producto, color, etiqueta)
+			VALUES ($1, $2, $3, $4, $5)
+		`,
+		"delete_boton": `
+			DELETE FROM botones_rapidos_cantera
+			WHERE id_local = $1 AND posicion = $2
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// GetBotones obtiene la grilla de un local con nombre, precio e imagen de cada producto
+func (r *botonRapidoRepository) GetBotones(ctx context.Context, idLocal int) ([]*models.BotonRapido, error) {
+	rows, err := r.stmts["get_botones"].QueryContext(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get botones rapidos: %w", err)
+	}
+	defer rows.Close()
+
+	botones := []*models.BotonRapido{}
+	for rows.Next() {
+		var boton models.BotonRapido
+		err := rows.Scan(
+			&boton.Posicion, &boton.CodigoProducto, &boton.Nombre, &boton.Etiqueta, &boton.Color,
+			&boton.Precio, &boton.ImagenMiniaturaURL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan boton rapido: %w", err)
+		}
+		botones = append(botones, &boton)
+	}
+
+	return botones, nil
+}
+
+// ReemplazarBotones reemplaza toda la grilla del local en una transacción
+func (r *botonRapidoRepository) ReemplazarBotones(ctx context.Context, idLocal int, botones []models.BotonRapidoRequest) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.StmtContext(ctx, r.stmts["delete_botones_local"]).ExecContext(ctx, idLocal); err != nil {
+		return fmt.Errorf("failed to delete botones rapidos: %w", err)
+	}
+
+	createStmt := tx.StmtContext(ctx, r.stmts["create_boton"])
+	for _, boton := range botones {
+		_, err := createStmt.ExecContext(ctx, idLocal, boton.Posicion, boton.CodigoProducto, boton.Color, boton.Etiqueta)
+		if err != nil {
+			return fmt.Errorf("failed to create boton rapido: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteBoton elimina el botón de una posición; retorna false si no existía
+func (r *botonRapidoRepository) DeleteBoton(ctx context.Context, idLocal, posicion int) (bool, error) {
+	result, err := r.stmts["delete_boton"].ExecContext(ctx, idLocal, posicion)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete boton rapido: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
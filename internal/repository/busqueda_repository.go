@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// BusquedaRepository define la interfaz para la búsqueda global
+type BusquedaRepository interface {
+	BuscarProductos(ctx context.Context, q string, limit int) ([]*models.ProductoBusqueda, error)
+	BuscarPacks(ctx context.Context, q string, limit int) ([]*models.ProductoBusqueda, error)
+	BuscarMovimientos(ctx context.Context, q string, dias int, limit int) ([]*models.Movimiento, error)
+}
+
+// busquedaRepository implementa BusquedaRepository
+type busquedaRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewBusquedaRepository crea una nueva instancia del repository
+func NewBusquedaRepository(db *sql.DB) (BusquedaRepository, error) {
+	repo := &busquedaRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+// $1 es el término tal cual (coincidencia exacta) y $2 el patrón ILIKE
+func (r *busquedaRepository) prepareStatements() error {
+	statements := map[string]string{
+		"buscar_productos": `
+			SELECT codigo, nombre, codigo_barra_interno, codigo_barra_externo, precio, activo
+			FROM productos
+			WHERE codigo = $1 OR codigo_barra_interno = $1 OR codigo_barra_externo = $1
+			   OR nombre ILIKE $2 OR codigo ILIKE $2
+			ORDER BY (codigo = $1 OR codigo_barra_interno = $1 OR codigo_barra_externo = $1) DESC,
+					 activo DESC, nombre
+			LIMIT $3
+		`,
+		"buscar_packs": `
+			SELECT DISTINCT ON (codigo_pack)
+				codigo_pack, nombre_pack, cod_barra_pack, cod_barra_pack, precio_base, true
+			FROM pack_listados
+			WHERE codigo_pack = $1 OR cod_barra_pack = $1
+			   OR nombre_pack ILIKE $2 OR codigo_pack ILIKE $2
+			ORDER BY codigo_pack
+			LIMIT $3
+		`,
+		"buscar_movimientos": `
+			SELECT id, codigo_producto, tipo_item, tipo_movimiento, cantidad, cantidad_anterior,
+				   cantidad_nueva, motivo, id_usuario, id_local, observaciones, created_at
+			FROM stock_movimientos_cantera
+			WHERE created_at >= NOW() - make_interval(days => $3)
+			  AND (codigo_producto = $1 OR motivo ILIKE $2 OR observaciones ILIKE $2)
+			ORDER BY created_at DESC
+			LIMIT $4
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// BuscarProductos busca por código, código de barras o nombre
+func (r *busquedaRepository) BuscarProductos(ctx context.Context, q string, limit int) ([]*models.ProductoBusqueda, error) {
+	rows, err := r.stmts["buscar_productos"].QueryContext(ctx, q, "%"+q+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to buscar productos: %w", err)
+	}
+	defer rows.Close()
+
+	return scanProductosBusqueda(rows)
+}
+
+// BuscarPacks busca packs por código, código de barras o nombre
+func (r *busquedaRepository) BuscarPacks(ctx context.Context, q string, limit int) ([]*models.ProductoBusqueda, error) {
+	rows, err := r.stmts["buscar_packs"].QueryContext(ctx, q, "%"+q+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to buscar packs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanProductosBusqueda(rows)
+}
+
+// BuscarMovimientos busca movimientos recientes por producto, motivo u observaciones
+func (r *busquedaRepository) BuscarMovimientos(ctx context.Context, q string, dias int, limit int) ([]*models.Movimiento, error) {
+	rows, err := r.stmts["buscar_movimientos"].QueryContext(ctx, q, "%"+q+"%", dias, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to buscar movimientos: %w", err)
+	}
+	defer rows.Close()
+
+	movimientos := []*models.Movimiento{}
+	for rows.Next() {
+		var m models.Movimiento
+		err := rows.Scan(
+			&m.ID, &m.CodigoProducto, &m.TipoItem, &m.TipoMovimiento, &m.Cantidad,
+			&m.CantidadAnterior, &m.CantidadNueva, &m.Motivo, &m.IDUsuario, &m.IDLocal,
+			&m.Observaciones, &m.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan movimiento: %w", err)
+		}
+		movimientos = append(movimientos, &m)
+	}
+
+	return movimientos, nil
+}
+
+// scanProductosBusqueda escanea coincidencias de productos o packs
+func scanProductosBusqueda(rows *sql.Rows) ([]*models.ProductoBusqueda, error) {
+	productos := []*models.ProductoBusqueda{}
+	for rows.Next() {
+		var p models.ProductoBusqueda
+		err := rows.Scan(&p.Codigo, &p.Nombre, &p.CodigoBarraInterno, &p.CodigoBarraExterno, &p.Precio, &p.Activo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan producto busqueda: %w", err)
+		}
+		productos = append(productos, &p)
+	}
+
+	return productos, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// CanalRepository define la interfaz para los canales de venta por producto
+type CanalRepository interface {
+	GetCanales(ctx context.Context, codigoProducto string) (*models.CanalesProducto, error)
+	UpsertCanales(ctx context.Context, canales *models.CanalesProducto) error
+	DeleteCanales(ctx context.Context, codigoProducto string) (bool, error)
+}
+
+// canalRepository implementa CanalRepository
+type canalRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewCanalRepository crea una nueva instancia del repository
+func NewCanalRepository(db *sql.DB) (CanalRepository, error) {
+	repo := &canalRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *canalRepository) prepareStatements() error {
+	statements := map[string]string{
+		"get_canales": `
+			SELECT codigo_producto, canales, updated_at
+			FROM canales_producto_cantera
+			WHERE codigo_producto = $1
+		`,
+		"upsert_canales": `
+			INSERT INTO canales_producto_cantera (codigo_producto, canales)
+			VALUES ($1, $2)
+			ON CONFLICT (codigo_producto)
+			DO UPDATE SET canales = EXCLUDED.canales, updated_at = NOW()
+			RETURNING updated_at
+		`,
+		"delete_canales": `
+			DELETE FROM canales_producto_cantera
+			WHERE codigo_producto = $1
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// GetCanales obtiene los canales definidos para un producto (nil si no tiene)
+func (r *canalRepository) GetCanales(ctx context.Context, codigoProducto string) (*models.CanalesProducto, error) {
+	canales := models.CanalesProducto{Definidos: true}
+	err := r.stmts["get_canales"].QueryRowContext(ctx, codigoProducto).Scan(
+		&canales.CodigoProducto, pq.Array(&canales.Canales), &canales.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get canales: %w", err)
+	}
+
+	return &canales, nil
+}
+
+// UpsertCanales define los canales de un producto
+func (r *canalRepository) UpsertCanales(ctx context.Context, canales *models.CanalesProducto) error {
+	err := r.stmts["upsert_canales"].QueryRowContext(ctx,
+		canales.CodigoProducto, pq.Array(canales.Canales),
+	).Scan(&canales.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert canales: %w", err)
+	}
+	canales.Definidos = true
+
+	return nil
+}
+
+// DeleteCanales elimina los canales definidos del producto (vuelve a venderse en todos)
+func (r *canalRepository) DeleteCanales(ctx context.Context, codigoProducto string) (bool, error) {
+	result, err := r.stmts["delete_canales"].ExecContext(ctx, codigoProducto)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete canales: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// ClaveFirmaRepository define la interfaz para las claves de firma de respuestas
+type ClaveFirmaRepository interface {
+	// Rotar retira la clave activa y registra la nueva como activa en una transacción
+	Rotar(ctx context.Context, clave *models.ClaveFirma) error
+	// Revocar revoca la clave; retorna false si no existe o ya estaba revocada
+	Revocar(ctx context.Context, kid string) (bool, error)
+	// GetClaves lista las claves no revocadas, la activa primero
+	GetClaves(ctx context.Context) ([]*models.ClaveFirma, error)
+}
+
+// claveFirmaRepository implementa ClaveFirmaRepository
+type claveFirmaRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewClaveFirmaRepository crea una nueva instancia del repository
+func NewClaveFirmaRepository(db *sql.DB) (ClaveFirmaRepository, error) {
+	repo := &claveFirmaRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *claveFirmaRepository) prepareStatements() error {
+	statements := map[string]string{
+		"retirar_activa": `
+			UPDATE claves_firma_cantera
+			SET estado = 'retirada', retirada_at = NOW()
+			WHERE estado = 'activa'
+		`,
+		"create_clave": `
+			INSERT INTO claves_firma_cantera (kid, algoritmo, secreto, clave_publica, estado)
+			VALUES ($1, $2, $3, $4, 'activa')
+			RETURNING id, estado, created_at
+		`,
+		"revocar_clave": `
+			UPDATE claves_firma_cantera
+			SET estado = 'revocada', retirada_at = COALESCE(retirada_at, NOW())
+			WHERE kid = $1 AND estado <> 'revocada'
+		`,
+		"get_claves": `
+			SELECT id, kid, algoritmo, secreto, clave_publica, estado, created_at, retirada_at
+			FROM claves_firma_cantera
+			WHERE estado <> 'revocada'
+			ORDER BY estado = 'activa' DESC, created_at DESC
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// Rotar reemplaza la clave activa por la nueva
+func (r *claveFirmaRepository) Rotar(ctx context.Context, clave *models.ClaveFirma) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.StmtContext(ctx, r.stmts["retirar_activa"]).ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to retirar clave activa: %w", err)
+	}
+
+	err = tx.StmtContext(ctx, r.stmts["create_clave"]).QueryRowContext(ctx,
+		clave.KID, clave.Algoritmo, clave.Secreto, clave.ClavePublica,
+	).Scan(&clave.ID, &clave.Estado, &clave.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create clave firma: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Revocar revoca la clave
+func (r *claveFirmaRepository) Revocar(ctx context.Context, kid string) (bool, error) {
+	result, err := r.stmts["revocar_clave"].ExecContext(ctx, kid)
+	if err != nil {
+		return false, fmt.Errorf("failed to revocar clave firma: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetClaves lista las claves no revocadas
+func (r *claveFirmaRepository) GetClaves(ctx context.Context) ([]*models.ClaveFirma, error) {
+	rows, err := r.stmts["get_claves"].QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query claves firma: %w", err)
+	}
+	defer rows.Close()
+
+	claves := []*models.ClaveFirma{}
+	for rows.Next() {
+		var clave models.ClaveFirma
+		if err := rows.Scan(
+			&clave.ID, &clave.KID, &clave.Algoritmo, &clave.Secreto, &clave.ClavePublica,
+			&clave.Estado, &clave.CreatedAt, &clave.RetiradaAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan clave firma: %w", err)
+		}
+		claves = append(claves, &clave)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate claves firma: %w", err)
+	}
+
+	return claves, nil
+}
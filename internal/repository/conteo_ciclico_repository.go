@@ -0,0 +1,555 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ConteoCiclicoRepository define la interfaz para las sesiones de conteo cíclico
+type ConteoCiclicoRepository interface {
+	// RegistrarEjecucion registra la generación de la semana; sin forzar, si ya existe retorna false
+	RegistrarEjecucion(ctx context.Context, semana time.Time, criterio string, forzar bool) (bool, error)
+	FinalizarEjecucion(ctx context.Context, ejecucion *models.EjecucionConteosCiclicos) error
+	GetUltimaEjecucion(ctx context.Context) (*models.EjecucionConteosCiclicos, error)
+	// GetLocales retorna los locales activos con stock
+	GetLocales(ctx context.Context) ([]int, error)
+	// GetSiguienteCategoria retorna la categoría con stock en el local que sigue a la última
+	// contada por categoría (nil si el local no tiene productos categorizados)
+	GetSiguienteCategoria(ctx context.Context, idLocal int) (*int, error)
+	// GetMuestra elige los productos a contar en el local: primero los contados hace más tiempo
+	// (o nunca) y entre ellos los de mayor valor en stock; excluye los que ya están en una
+	// sesión abierta y en plazo
+	GetMuestra(ctx context.Context, idLocal int, idCategoria *int, limite int) ([]*models.ProductoConteo, error)
+	// CreateConteos crea las sesiones con sus ítems en una transacción
+	CreateConteos(ctx context.Context, conteos []*models.ConteoCiclico) error
+	GetConteos(ctx context.Context, filter *models.ConteoCiclicoFilter) ([]*models.ConteoCiclico, error)
+	// GetConteo obtiene una sesión con sus ítems (nil si no existe)
+	GetConteo(ctx context.Context, id int64) (*models.ConteoCiclico, error)
+	// RegistrarConteo guarda las cantidades contadas con el stock del momento y actualiza el
+	// estado de la sesión; retorna false si la sesión ya estaba completada
+	RegistrarConteo(ctx context.Context, id int64, items []*models.ResultadoConteoItem) (bool, error)
+	GetCumplimiento(ctx context.Context, filter *models.ReporteFilter) ([]*models.CumplimientoConteos, error)
+	// ResolverCodigos busca cada código como código o código de barras de un producto y, si no,
+	// de un pack; los códigos sin coincidencia no aparecen en el mapa
+	ResolverCodigos(ctx context.Context, codigos []string) (map[string]*models.ProductoConteo, error)
+}
+
+// conteoCiclicoRepository implementa ConteoCiclicoRepository
+type conteoCiclicoRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewConteoCiclicoRepository crea una nueva instancia del repository
+func NewConteoCiclicoRepository(db *sql.DB) (ConteoCiclicoRepository, error) {
+	repo := &conteoCiclicoRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// conteoCiclicoColumns columnas de una sesión; vencida se calcula contra la fecha del servidor
+const conteoCiclicoColumns = `
+	c.id, c.semana, c.id_local, c.criterio, c.id_categoria, c.id_usuario, c.estado, c.fecha_limite,
+	c.productos, c.con_diferencia, (c.estado <> 'completada' AND c.fecha_limite < CURRENT_DATE),
+	c.created_at, c.iniciado_at, c.completado_at
+`
+
+// prepareStatements prepara todas las consultas SQL
+func (r *conteoCiclicoRepository) prepareStatements() error {
+	statements := map[string]string{
+		"insert_ejecucion": `
+			INSERT INTO conteos_ciclicos_ejecuciones (semana, criterio)
+			VALUES ($1, $2)
+			ON CONFLICT (semana) DO NOTHING
+			RETURNING semana
+		`,
+		"upsert_ejecucion": `
+			INSERT INTO conteos_ciclicos_ejecuciones (semana, criterio)
+			VALUES ($1, $2)
+			ON CONFLICT (semana) DO UPDATE SET criterio = EXCLUDED.criterio, ejecutado_at = NOW()
+			RETURNING semana
+		`,
+		"finalizar_ejecucion": `
+			UPDATE conteos_ciclicos_ejecuciones
+			SET sesiones = sesiones + $2, productos = productos + $3, locales = GREATEST(locales, $4)
+			WHERE semana = $1
+		`,
+		"get_ultima_ejecucion": `
+			SELECT semana, criterio, sesiones, productos, locales, ejecutado_at
+			FROM conteos_ciclicos_ejecuciones
+			ORDER BY semana DESC
+			LIMIT 1
+		`,
+		"get_locales": `
+			SELECT DISTINCT s.id_local
+			FROM stock_bodega_cantera s
+			JOIN locales l ON l.id = s.id_local
+			WHERE l.activo
+			ORDER BY s.id_local
+		`,
+		// La última categoría contada del local define la siguiente (en orden de id, circular)
+		"get_siguiente_categoria": `
+			SELECT p.id_categoria
+			FROM stock_bodega_cantera s
+			JOIN productos p ON p.codigo = s.codigo_producto
+			WHERE s.id_local = $1 AND s.tipo_item = 'producto' AND p.id_categoria IS NOT NULL
+			GROUP BY p.id_categoria
+			ORDER BY p.id_categoria <= COALESCE((
+				SELECT c.id_categoria
+				FROM conteos_ciclicos_cantera c
+				WHERE c.id_local = $1 AND c.criterio = 'categoria' AND c.id_categoria IS NOT NULL
+				ORDER BY c.id DESC
+				LIMIT 1
+			), -1), p.id_categoria
+			LIMIT 1
+		`,
+		"get_muestra": `
+			WITH ultimos AS (
+				SELECT i.codigo_producto, i.tipo_item, MAX(i.contado_at) AS ultimo
+				FROM conteo_ciclico_items_cantera i
+				JOIN conteos_ciclicos_cantera c ON c.id = i.id_conteo
+				WHERE c.id_local = $1 AND i.contado_at IS NOT NULL
+				GROUP BY i.codigo_producto, i.tipo_item
+			),
+			abiertos AS (
+				SELECT DISTINCT i.codigo_producto, i.tipo_item
+				FROM conteo_ciclico_items_cantera i
+				JOIN conteos_ciclicos_cantera c ON c.id = i.id_conteo
+				WHERE c.id_local = $1 AND c.estado <> 'completada'
+				  AND c.fecha_limite >= CURRENT_DATE AND i.contado_at IS NULL
+			)
+			SELECT s.codigo_producto, s.tipo_item
+			FROM stock_bodega_cantera s
+			JOIN productos p ON p.codigo = s.codigo_producto
+			LEFT JOIN ultimos u ON u.codigo_producto = s.codigo_producto AND u.tipo_item = s.tipo_item
+			WHERE s.id_local = $1
+			  AND s.tipo_item = 'producto'
+			  AND p.activo AND NOT p.es_servicio
+			  AND ($2::int IS NULL OR p.id_categoria = $2)
+			  AND NOT EXISTS (
+				SELECT 1 FROM abiertos a
+				WHERE a.codigo_producto = s.codigo_producto AND a.tipo_item = s.tipo_item
+			  )
+			ORDER BY u.ultimo ASC NULLS FIRST,
+					 GREATEST(s.cantidad_actual, 0) * COALESCE(p.precio, 0) DESC,
+					 s.codigo_producto
+			LIMIT $3
+		`,
+		"create_conteo": `
+			INSERT INTO conteos_ciclicos_cantera
+			(semana, id_local, criterio, id_categoria, id_usuario, estado, fecha_limite, productos)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, created_at
+		`,
+		"create_conteo_item": `
+			INSERT INTO conteo_ciclico_items_cantera (id_conteo, codigo_producto, tipo_item)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`,
+		"get_conteos": `
+			SELECT ` + conteoCiclicoColumns + `
+			FROM conteos_ciclicos_cantera c
+			WHERE ($1::int IS NULL OR c.id_local = $1)
+			  AND ($2::int IS NULL OR c.id_usuario = $2)
+			  AND ($3::date IS NULL OR c.semana = $3)
+			  AND ($4 = ''
+				OR ($4 = 'vencida' AND c.estado <> 'completada' AND c.fecha_limite < CURRENT_DATE)
+				OR c.estado = $4)
+			ORDER BY c.semana DESC, c.id_local, c.id
+			LIMIT 500
+		`,
+		"get_conteo": `
+			SELECT ` + conteoCiclicoColumns + `
+			FROM conteos_ciclicos_cantera c
+			WHERE c.id = $1
+		`,
+		"get_conteo_items": `
+			SELECT i.id, i.id_conteo, i.codigo_producto, p.nombre, i.tipo_item,
+				   i.cantidad_sistema, i.cantidad_contada, i.contado_at
+			FROM conteo_ciclico_items_cantera i
+			LEFT JOIN productos p ON p.codigo = i.codigo_producto
+			WHERE i.id_conteo = $1
+			ORDER BY i.id
+		`,
+		"lock_conteo": `
+			SELECT estado FROM conteos_ciclicos_cantera WHERE id = $1 FOR UPDATE
+		`,
+		"registrar_item": `
+			UPDATE conteo_ciclico_items_cantera i
+			SET cantidad_contada = $4,
+				cantidad_sistema = COALESCE((
+					SELECT s.cantidad_actual FROM stock_bodega_cantera s
+					WHERE s.codigo_producto = i.codigo_producto AND s.tipo_item = i.tipo_item
+					  AND s.id_local = c.id_local
+				), 0),
+				contado_at = NOW()
+			FROM conteos_ciclicos_cantera c
+			WHERE c.id = i.id_conteo AND i.id_conteo = $1
+			  AND i.codigo_producto = $2 AND i.tipo_item = $3
+		`,
+		"actualizar_estado": `
+			UPDATE conteos_ciclicos_cantera c
+			SET estado = CASE WHEN t.pendientes = 0 THEN 'completada' ELSE 'en_curso' END,
+				con_diferencia = t.con_diferencia,
+				iniciado_at = COALESCE(c.iniciado_at, NOW()),
+				completado_at = CASE WHEN t.pendientes = 0 THEN NOW() END
+			FROM (
+				SELECT COUNT(*) FILTER (WHERE contado_at IS NULL) AS pendientes,
+					   COUNT(*) FILTER (WHERE cantidad_contada <> cantidad_sistema) AS con_diferencia
+				FROM conteo_ciclico_items_cantera
+				WHERE id_conteo = $1
+			) t
+			WHERE c.id = $1
+		`,
+		"resolver_codigos": `
+			SELECT t.codigo, x.codigo_producto, x.tipo_item
+			FROM unnest($1::text[]) AS t(codigo)
+			JOIN LATERAL (
+				SELECT p.codigo AS codigo_producto, 'producto' AS tipo_item, 1 AS prioridad
+				FROM productos p
+				WHERE p.codigo = t.codigo OR p.codigo_barra_interno = t.codigo OR p.codigo_barra_externo = t.codigo
+				UNION ALL
+				SELECT pl.codigo_pack, 'pack', 2
+				FROM pack_listados pl
+				WHERE pl.codigo_pack = t.codigo OR pl.cod_barra_pack = t.codigo
+				ORDER BY prioridad
+				LIMIT 1
+			) x ON TRUE
+		`,
+		"get_cumplimiento": `
+			SELECT c.id_local, c.id_usuario, COUNT(*),
+				   COUNT(*) FILTER (WHERE c.estado = 'completada'),
+				   COUNT(*) FILTER (WHERE c.estado = 'completada' AND c.completado_at::date <= c.fecha_limite),
+				   COUNT(*) FILTER (WHERE c.estado <> 'completada' AND c.fecha_limite < CURRENT_DATE),
+				   COUNT(*) FILTER (WHERE c.estado <> 'completada' AND c.fecha_limite >= CURRENT_DATE),
+				   COALESCE(SUM(c.con_diferencia), 0)::int
+			FROM conteos_ciclicos_cantera c
+			WHERE ($1::int IS NULL OR c.id_local = $1)
+			  AND ($2::int IS NULL OR c.id_usuario = $2)
+			  AND c.created_at >= $3 AND c.created_at < $4
+			GROUP BY c.id_local, c.id_usuario
+			ORDER BY c.id_local, c.id_usuario
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// RegistrarEjecucion registra la generación de la semana
+func (r *conteoCiclicoRepository) RegistrarEjecucion(ctx context.Context, semana time.Time, criterio string, forzar bool) (bool, error) {
+	ejecucion := "insert_ejecucion"
+	if forzar {
+		ejecucion = "upsert_ejecucion"
+	}
+	var registrada time.Time
+	err := r.stmts[ejecucion].QueryRowContext(ctx, semana, criterio).Scan(&registrada)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to register ejecucion conteos: %w", err)
+	}
+	return true, nil
+}
+
+// FinalizarEjecucion suma los totales generados a la ejecución de la semana
+func (r *conteoCiclicoRepository) FinalizarEjecucion(ctx context.Context, ejecucion *models.EjecucionConteosCiclicos) error {
+	_, err := r.stmts["finalizar_ejecucion"].ExecContext(ctx,
+		ejecucion.Semana, ejecucion.Sesiones, ejecucion.Productos, ejecucion.Locales,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finalize ejecucion conteos: %w", err)
+	}
+	return nil
+}
+
+// GetUltimaEjecucion retorna la generación semanal más reciente (nil si no hubo)
+func (r *conteoCiclicoRepository) GetUltimaEjecucion(ctx context.Context) (*models.EjecucionConteosCiclicos, error) {
+	var ejecucion models.EjecucionConteosCiclicos
+	err := r.stmts["get_ultima_ejecucion"].QueryRowContext(ctx).Scan(
+		&ejecucion.Semana, &ejecucion.Criterio, &ejecucion.Sesiones, &ejecucion.Productos,
+		&ejecucion.Locales, &ejecucion.EjecutadoAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ultima ejecucion conteos: %w", err)
+	}
+	return &ejecucion, nil
+}
+
+// GetLocales retorna los locales activos con stock
+func (r *conteoCiclicoRepository) GetLocales(ctx context.Context) ([]int, error) {
+	rows, err := r.stmts["get_locales"].QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query locales: %w", err)
+	}
+	defer rows.Close()
+
+	var locales []int
+	for rows.Next() {
+		var idLocal int
+		if err := rows.Scan(&idLocal); err != nil {
+			return nil, fmt.Errorf("failed to scan local: %w", err)
+		}
+		locales = append(locales, idLocal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate locales: %w", err)
+	}
+
+	return locales, nil
+}
+
+// GetSiguienteCategoria retorna la próxima categoría a contar en el local
+func (r *conteoCiclicoRepository) GetSiguienteCategoria(ctx context.Context, idLocal int) (*int, error) {
+	var idCategoria int
+	err := r.stmts["get_siguiente_categoria"].QueryRowContext(ctx, idLocal).Scan(&idCategoria)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get siguiente categoria: %w", err)
+	}
+	return &idCategoria, nil
+}
+
+// GetMuestra elige los productos a contar en el local
+func (r *conteoCiclicoRepository) GetMuestra(ctx context.Context, idLocal int, idCategoria *int, limite int) ([]*models.ProductoConteo, error) {
+	rows, err := r.stmts["get_muestra"].QueryContext(ctx, idLocal, idCategoria, limite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query muestra conteo: %w", err)
+	}
+	defer rows.Close()
+
+	var productos []*models.ProductoConteo
+	for rows.Next() {
+		var producto models.ProductoConteo
+		if err := rows.Scan(&producto.CodigoProducto, &producto.TipoItem); err != nil {
+			return nil, fmt.Errorf("failed to scan muestra conteo: %w", err)
+		}
+		productos = append(productos, &producto)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate muestra conteo: %w", err)
+	}
+
+	return productos, nil
+}
+
+// CreateConteos crea las sesiones con sus ítems en una transacción
+func (r *conteoCiclicoRepository) CreateConteos(ctx context.Context, conteos []*models.ConteoCiclico) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	conteoStmt := tx.StmtContext(ctx, r.stmts["create_conteo"])
+	itemStmt := tx.StmtContext(ctx, r.stmts["create_conteo_item"])
+	for _, conteo := range conteos {
+		err := conteoStmt.QueryRowContext(ctx,
+			conteo.Semana, conteo.IDLocal, conteo.Criterio, conteo.IDCategoria, conteo.IDUsuario,
+			conteo.Estado, conteo.FechaLimite, len(conteo.Items),
+		).Scan(&conteo.ID, &conteo.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create conteo ciclico: %w", err)
+		}
+		conteo.Productos = len(conteo.Items)
+
+		for _, item := range conteo.Items {
+			item.IDConteo = conteo.ID
+			if err := itemStmt.QueryRowContext(ctx, item.IDConteo, item.CodigoProducto, item.TipoItem).Scan(&item.ID); err != nil {
+				return fmt.Errorf("failed to create conteo ciclico item: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// scanConteo lee una sesión con las columnas de conteoCiclicoColumns
+func scanConteo(row interface{ Scan(...interface{}) error }) (*models.ConteoCiclico, error) {
+	var conteo models.ConteoCiclico
+	err := row.Scan(
+		&conteo.ID, &conteo.Semana, &conteo.IDLocal, &conteo.Criterio, &conteo.IDCategoria,
+		&conteo.IDUsuario, &conteo.Estado, &conteo.FechaLimite, &conteo.Productos,
+		&conteo.ConDiferencia, &conteo.Vencida, &conteo.CreatedAt, &conteo.IniciadoAt,
+		&conteo.CompletadoAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &conteo, nil
+}
+
+// GetConteos lista las sesiones según los filtros (sin ítems)
+func (r *conteoCiclicoRepository) GetConteos(ctx context.Context, filter *models.ConteoCiclicoFilter) ([]*models.ConteoCiclico, error) {
+	rows, err := r.stmts["get_conteos"].QueryContext(ctx, filter.IDLocal, filter.IDUsuario, filter.Semana, filter.Estado)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conteos ciclicos: %w", err)
+	}
+	defer rows.Close()
+
+	conteos := []*models.ConteoCiclico{}
+	for rows.Next() {
+		conteo, err := scanConteo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conteo ciclico: %w", err)
+		}
+		conteos = append(conteos, conteo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate conteos ciclicos: %w", err)
+	}
+
+	return conteos, nil
+}
+
+// GetConteo obtiene una sesión con sus ítems
+func (r *conteoCiclicoRepository) GetConteo(ctx context.Context, id int64) (*models.ConteoCiclico, error) {
+	conteo, err := scanConteo(r.stmts["get_conteo"].QueryRowContext(ctx, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conteo ciclico: %w", err)
+	}
+
+	rows, err := r.stmts["get_conteo_items"].QueryContext(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conteo ciclico items: %w", err)
+	}
+	defer rows.Close()
+
+	conteo.Items = []*models.ConteoCiclicoItem{}
+	for rows.Next() {
+		var item models.ConteoCiclicoItem
+		if err := rows.Scan(
+			&item.ID, &item.IDConteo, &item.CodigoProducto, &item.NombreProducto, &item.TipoItem,
+			&item.CantidadSistema, &item.CantidadContada, &item.ContadoAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan conteo ciclico item: %w", err)
+		}
+		if item.CantidadSistema != nil && item.CantidadContada != nil {
+			diferencia := *item.CantidadContada - *item.CantidadSistema
+			item.Diferencia = &diferencia
+		}
+		conteo.Items = append(conteo.Items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate conteo ciclico items: %w", err)
+	}
+
+	return conteo, nil
+}
+
+// RegistrarConteo guarda las cantidades contadas y actualiza el estado de la sesión
+func (r *conteoCiclicoRepository) RegistrarConteo(ctx context.Context, id int64, items []*models.ResultadoConteoItem) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var estado string
+	if err := tx.StmtContext(ctx, r.stmts["lock_conteo"]).QueryRowContext(ctx, id).Scan(&estado); err != nil {
+		return false, fmt.Errorf("failed to lock conteo ciclico: %w", err)
+	}
+	if estado == models.ConteoEstadoCompletada {
+		return false, nil
+	}
+
+	itemStmt := tx.StmtContext(ctx, r.stmts["registrar_item"])
+	for _, item := range items {
+		if _, err := itemStmt.ExecContext(ctx, id, item.CodigoProducto, item.TipoItem, item.Cantidad); err != nil {
+			return false, fmt.Errorf("failed to registrar conteo item %s: %w", item.CodigoProducto, err)
+		}
+	}
+	if _, err := tx.StmtContext(ctx, r.stmts["actualizar_estado"]).ExecContext(ctx, id); err != nil {
+		return false, fmt.Errorf("failed to update estado conteo ciclico: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetCumplimiento agrega las sesiones creadas en el período por local y usuario
+func (r *conteoCiclicoRepository) GetCumplimiento(ctx context.Context, filter *models.ReporteFilter) ([]*models.CumplimientoConteos, error) {
+	rows, err := r.stmts["get_cumplimiento"].QueryContext(ctx, filter.IDLocal, filter.IDUsuario, filter.Desde, filter.Hasta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cumplimiento conteos: %w", err)
+	}
+	defer rows.Close()
+
+	detalle := []*models.CumplimientoConteos{}
+	for rows.Next() {
+		var fila models.CumplimientoConteos
+		if err := rows.Scan(
+			&fila.IDLocal, &fila.IDUsuario, &fila.Asignadas, &fila.Completadas, &fila.ATiempo,
+			&fila.Vencidas, &fila.Pendientes, &fila.ConDiferencia,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan cumplimiento conteos: %w", err)
+		}
+		detalle = append(detalle, &fila)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate cumplimiento conteos: %w", err)
+	}
+
+	return detalle, nil
+}
+
+// ResolverCodigos resuelve en una consulta los códigos leídos por una terminal de inventario
+func (r *conteoCiclicoRepository) ResolverCodigos(ctx context.Context, codigos []string) (map[string]*models.ProductoConteo, error) {
+	resueltos := make(map[string]*models.ProductoConteo, len(codigos))
+	if len(codigos) == 0 {
+		return resueltos, nil
+	}
+
+	rows, err := r.stmts["resolver_codigos"].QueryContext(ctx, pq.Array(codigos))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolver codigos: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var codigo string
+		var producto models.ProductoConteo
+		if err := rows.Scan(&codigo, &producto.CodigoProducto, &producto.TipoItem); err != nil {
+			return nil, fmt.Errorf("failed to scan codigo resuelto: %w", err)
+		}
+		resueltos[codigo] = &producto
+	}
+
+	return resueltos, rows.Err()
+}
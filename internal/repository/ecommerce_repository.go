@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// EcommerceRepository define la interfaz para el stock publicable y sus márgenes de seguridad
+type EcommerceRepository interface {
+	GetStockPublicable(ctx context.Context, locales []int, margenDefecto int, canal string, codigos []string) ([]*models.StockPublicable, error)
+	GetMargenes(ctx context.Context) ([]*models.MargenStockPublicable, error)
+	UpsertMargenProducto(ctx context.Context, codigoProducto string, margen int) (*models.MargenStockPublicable, error)
+	UpsertMargenCategoria(ctx context.Context, idCategoria int, margen int) (*models.MargenStockPublicable, error)
+	DeleteMargenProducto(ctx context.Context, codigoProducto string) (bool, error)
+	DeleteMargenCategoria(ctx context.Context, idCategoria int) (bool, error)
+	ExisteCategoria(ctx context.Context, idCategoria int) (bool, error)
+}
+
+// ecommerceRepository implementa EcommerceRepository
+type ecommerceRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewEcommerceRepository crea una nueva instancia del repository
+func NewEcommerceRepository(db *sql.DB) (EcommerceRepository, error) {
+	repo := &ecommerceRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *ecommerceRepository) prepareStatements() error {
+	statements := map[string]string{
+		// Por local: stock - reservado por pickings preparados - margen (producto > categoría > defecto),
+		// sin bajar de 0; luego se suma entre locales. Solo productos habilitados en el canal ($4)
+		"get_stock_publicable": `
+			SELECT s.codigo_producto,
+				   SUM(GREATEST(s.cantidad_actual - COALESCE(r.reservada, 0) - COALESCE(mp.margen, mc.margen, $2), 0))
+			FROM stock_bodega_cantera s
+			JOIN productos p ON p.codigo = s.codigo_producto
+			LEFT JOIN margenes_stock_publicable_cantera mp ON mp.codigo_producto = s.codigo_producto
+			LEFT JOIN margenes_stock_publicable_cantera mc ON mc.id_categoria = p.id_categoria
+			LEFT JOIN canales_producto_cantera cp ON cp.codigo_producto = s.codigo_producto
+			LEFT JOIN (
+				SELECT pi.codigo_producto, pk.id_local, SUM(pi.cantidad_solicitada) AS reservada
+				FROM picking_items_cantera pi
+				JOIN pickings_cantera pk ON pk.id = pi.id_picking
+				WHERE pk.estado = 'preparado' AND pk.expires_at > NOW()
+				GROUP BY pi.codigo_producto, pk.id_local
+			) r ON r.codigo_producto = s.codigo_producto AND r.id_local = s.id_local
+			WHERE s.id_local = ANY($1)
+			  AND s.tipo_item = 'producto'
+			  AND p.activo AND p.disponible_para_venta AND NOT p.es_servicio
+			  AND ($3::text[] IS NULL OR s.codigo_producto = ANY($3))
+			  AND (cp.canales IS NULL OR $4 = ANY(cp.canales))
+			GROUP BY s.codigo_producto
+			ORDER BY s.codigo_producto
+		`,
+		"get_margenes": `
+			SELECT id, codigo_producto, id_categoria, margen, updated_at
+			FROM margenes_stock_publicable_cantera
+			ORDER BY id_categoria NULLS LAST, codigo_producto
+		`,
+		"upsert_margen_producto": `
+			INSERT INTO margenes_stock_publicable_cantera (codigo_producto, margen)
+			VALUES ($1, $2)
+			ON CONFLICT (codigo_producto) WHERE codigo_producto IS NOT NULL
+			DO UPDATE SET margen = EXCLUDED.margen, updated_at = NOW()
+			RETURNING id, updated_at
+		`,
+		"upsert_margen_categoria": `
+			INSERT INTO margenes_stock_publicable_cantera (id_categoria, margen)
+			VALUES ($1, $2)
+			ON CONFLICT (id_categoria) WHERE id_categoria IS NOT NULL
+			DO UPDATE SET margen = EXCLUDED.margen, updated_at = NOW()
+			RETURNING id, updated_at
+		`,
+		"delete_margen_producto": `
+			DELETE FROM margenes_stock_publicable_cantera
+			WHERE codigo_producto = $1
+		`,
+		"delete_margen_categoria": `
+			DELETE FROM margenes_stock_publicable_cantera
+			WHERE id_categoria = $1
+		`,
+		"existe_categoria": `
+			SELECT EXISTS (SELECT 1 FROM categorias WHERE id = $1)
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// GetStockPublicable obtiene el stock publicable en el canal de los productos (codigos vacío: todos)
+func (r *ecommerceRepository) GetStockPublicable(ctx context.Context, locales []int, margenDefecto int, canal string, codigos []string) ([]*models.StockPublicable, error) {
+	var filtroCodigos interface{}
+	if len(codigos) > 0 {
+		filtroCodigos = pq.Array(codigos)
+	}
+
+	rows, err := r.stmts["get_stock_publicable"].QueryContext(ctx, pq.Array(locales), margenDefecto, filtroCodigos, canal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock publicable: %w", err)
+	}
+	defer rows.Close()
+
+	productos := []*models.StockPublicable{}
+	for rows.Next() {
+		var producto models.StockPublicable
+		if err := rows.Scan(&producto.CodigoProducto, &producto.Cantidad); err != nil {
+			return nil, fmt.Errorf("failed to scan stock publicable: %w", err)
+		}
+		producto.Disponible = producto.Cantidad > 0
+		productos = append(productos, &producto)
+	}
+
+	return productos, nil
+}
+
+// GetMargenes obtiene los márgenes de seguridad definidos (categorías primero)
+func (r *ecommerceRepository) GetMargenes(ctx context.Context) ([]*models.MargenStockPublicable, error) {
+	rows, err := r.stmts["get_margenes"].QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get margenes: %w", err)
+	}
+	defer rows.Close()
+
+	margenes := []*models.MargenStockPublicable{}
+	for rows.Next() {
+		var margen models.MargenStockPublicable
+		err := rows.Scan(&margen.ID, &margen.CodigoProducto, &margen.IDCategoria, &margen.Margen, &margen.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan margen: %w", err)
+		}
+		margenes = append(margenes, &margen)
+	}
+
+	return margenes, nil
+}
+
+// UpsertMargenProducto crea o actualiza el margen de seguridad de un producto
+func (r *ecommerceRepository) UpsertMargenProducto(ctx context.Context, codigoProducto string, margen int) (*models.MargenStockPublicable, error) {
+	m := &models.MargenStockPublicable{CodigoProducto: &codigoProducto, Margen: margen}
+	err := r.stmts["upsert_margen_producto"].QueryRowContext(ctx, codigoProducto, margen).Scan(&m.ID, &m.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert margen producto: %w", err)
+	}
+
+	return m, nil
+}
+
+// UpsertMargenCategoria crea o actualiza el margen de seguridad de una categoría
+func (r *ecommerceRepository) UpsertMargenCategoria(ctx context.Context, idCategoria int, margen int) (*models.MargenStockPublicable, error) {
+	m := &models.MargenStockPublicable{IDCategoria: &idCategoria, Margen: margen}
+	err := r.stmts["upsert_margen_categoria"].QueryRowContext(ctx, idCategoria, margen).Scan(&m.ID, &m.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert margen categoria: %w", err)
+	}
+
+	return m, nil
+}
+
+// DeleteMargenProducto elimina el margen propio de un producto
+func (r *ecommerceRepository) DeleteMargenProducto(ctx context.Context, codigoProducto string) (bool, error) {
+	result, err := r.stmts["delete_margen_producto"].ExecContext(ctx, codigoProducto)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete margen producto: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// DeleteMargenCategoria elimina el margen de una categoría
+func (r *ecommerceRepository) DeleteMargenCategoria(ctx context.Context, idCategoria int) (bool, error) {
+	result, err := r.stmts["delete_margen_categoria"].ExecContext(ctx, idCategoria)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete margen categoria: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// ExisteCategoria indica si la categoría existe en el maestro
+func (r *ecommerceRepository) ExisteCategoria(ctx context.Context, idCategoria int) (bool, error) {
+	var existe bool
+	if err := r.stmts["existe_categoria"].QueryRowContext(ctx, idCategoria).Scan(&existe); err != nil {
+		return false, fmt.Errorf("failed to check categoria: %w", err)
+	}
+
+	return existe, nil
+}
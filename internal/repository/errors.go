@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+)
+
+// ErrNotFound indica que el registro buscado no existe
+var ErrNotFound = errors.New("registro no encontrado")
+
+// ErrStockInsuficiente indica que un descuento atómico dejaría el stock bajo lo exigido
+var ErrStockInsuficiente = errors.New("stock insuficiente")
+
+// IsUnavailable indica si el error corresponde a una falla de conectividad con la BD
+// (conexión caída o rechazada), a diferencia de un error de consulta o de datos
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
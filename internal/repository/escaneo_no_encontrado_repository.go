@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// EscaneoNoEncontradoRepository define la interfaz para los escaneos de códigos desconocidos
+type EscaneoNoEncontradoRepository interface {
+	// Registrar suma los escaneos de un lote (código+local -> veces) en una sola sentencia
+	Registrar(ctx context.Context, codigos []string, locales []int, veces []int, ultimaVez time.Time) error
+	// GetTop lista los códigos que siguen sin existir en el catálogo, los más escaneados primero
+	GetTop(ctx context.Context, filter *models.EscaneoNoEncontradoFilter) ([]*models.EscaneoNoEncontrado, error)
+}
+
+// escaneoNoEncontradoRepository implementa EscaneoNoEncontradoRepository
+type escaneoNoEncontradoRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewEscaneoNoEncontradoRepository crea una nueva instancia del repository
+func NewEscaneoNoEncontradoRepository(db *sql.DB) (EscaneoNoEncontradoRepository, error) {
+	repo := &escaneoNoEncontradoRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *escaneoNoEncontradoRepository) prepareStatements() error {
+	statements := map[string]string{
+		"registrar": `
+			INSERT INTO escaneos_no_encontrados_cantera (codigo_barras, id_local, veces, primera_vez, ultima_vez)
+			SELECT codigo, id_local, veces, $4, $4
+			FROM unnest($1::varchar[], $2::int[], $3::int[]) AS l(codigo, id_local, veces)
+			ON CONFLICT (codigo_barras, id_local) DO UPDATE
+			SET veces = escaneos_no_encontrados_cantera.veces + EXCLUDED.veces,
+				ultima_vez = GREATEST(escaneos_no_encontrados_cantera.ultima_vez, EXCLUDED.ultima_vez)
+		`,
+		// Los códigos que catalogación ya creó (producto o pack) salen del listado
+		"get_top": `
+			SELECT e.codigo_barras, SUM(e.veces)::int AS veces,
+				   MIN(e.primera_vez) AS primera_vez, MAX(e.ultima_vez) AS ultima_vez,
+				   ARRAY_AGG(DISTINCT e.id_local ORDER BY e.id_local) AS locales
+			FROM escaneos_no_encontrados_cantera e
+			WHERE ($1::int IS NULL OR e.id_local = $1)
+			  AND ($2::timestamp IS NULL OR e.ultima_vez >= $2)
+			  AND NOT EXISTS (
+				SELECT 1 FROM productos p
+				WHERE p.codigo_barra_externo = e.codigo_barras OR p.codigo_barra_interno = e.codigo_barras
+			  )
+			  AND NOT EXISTS (
+				SELECT 1 FROM pack_listados pl
+				WHERE pl.cod_barra_pack = e.codigo_barras OR pl.codigo_pack = e.codigo_barras
+			  )
+			GROUP BY e.codigo_barras
+			ORDER BY veces DESC, ultima_vez DESC
+			LIMIT $3
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// Registrar acumula el lote de escaneos
+func (r *escaneoNoEncontradoRepository) Registrar(ctx context.Context, codigos []string, locales []int, veces []int, ultimaVez time.Time) error {
+	if len(codigos) == 0 {
+		return nil
+	}
+	_, err := r.stmts["registrar"].ExecContext(ctx, pq.Array(codigos), pq.Array(locales), pq.Array(veces), ultimaVez)
+	if err != nil {
+		return fmt.Errorf("failed to registrar escaneos no encontrados: %w", err)
+	}
+	return nil
+}
+
+// GetTop obtiene los códigos desconocidos más escaneados
+func (r *escaneoNoEncontradoRepository) GetTop(ctx context.Context, filter *models.EscaneoNoEncontradoFilter) ([]*models.EscaneoNoEncontrado, error) {
+	rows, err := r.stmts["get_top"].QueryContext(ctx, filter.IDLocal, filter.Desde, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query escaneos no encontrados: %w", err)
+	}
+	defer rows.Close()
+
+	escaneos := []*models.EscaneoNoEncontrado{}
+	for rows.Next() {
+		var escaneo models.EscaneoNoEncontrado
+		var locales pq.Int64Array
+		if err := rows.Scan(&escaneo.CodigoBarras, &escaneo.Veces, &escaneo.PrimeraVez, &escaneo.UltimaVez, &locales); err != nil {
+			return nil, fmt.Errorf("failed to scan escaneo no encontrado: %w", err)
+		}
+		escaneo.Locales = make([]int, len(locales))
+		for i, idLocal := range locales {
+			escaneo.Locales[i] = int(idLocal)
+		}
+		escaneos = append(escaneos, &escaneo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate escaneos no encontrados: %w", err)
+	}
+
+	return escaneos, nil
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+)
+
+// ExportacionERPRepository define la interfaz para los perfiles de exportación ERP
+type ExportacionERPRepository interface {
+	CreatePerfil(ctx context.Context, perfil *models.PerfilExportacionERP) error
+	// UpdatePerfil reemplaza el perfil; retorna false si no existe
+	UpdatePerfil(ctx context.Context, perfil *models.PerfilExportacionERP) (bool, error)
+	// DeletePerfil elimina el perfil; retorna false si no existe
+	DeletePerfil(ctx context.Context, id int) (bool, error)
+	GetPerfilByID(ctx context.Context, id int) (*models.PerfilExportacionERP, error)
+	GetPerfilByNombre(ctx context.Context, nombre string) (*models.PerfilExportacionERP, error)
+	GetPerfiles(ctx context.Context) ([]*models.PerfilExportacionERP, error)
+	// RecorrerMovimientos entrega en orden cronológico los movimientos de [desde, hasta)
+	// sin cargarlos todos en memoria; se detiene en el primer error de fn
+	RecorrerMovimientos(ctx context.Context, idLocal *int, desde, hasta time.Time, fn func(*models.MovimientoERP) error) error
+}
+
+// exportacionERPRepository implementa ExportacionERPRepository
+type exportacionERPRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewExportacionERPRepository crea una nueva instancia del repository
+func NewExportacionERPRepository(db *sql.DB) (ExportacionERPRepository, error) {
+	repo := &exportacionERPRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *exportacionERPRepository) prepareStatements() error {
+	const columnas = `id, nombre, descripcion, columnas, codigos_transaccion, delimitador, incluir_encabezado,
+			   formato_fecha, created_at, updated_at`
+
+	statements := map[string]string{
+		"create_perfil": `
+			INSERT INTO perfiles_exportacion_erp_cantera
+			(nombre, descripcion, columnas, codigos_transaccion, delimitador, incluir_encabezado, formato_fecha)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at, updated_at
+		`,
+		"update_perfil": `
+			UPDATE perfiles_exportacion_erp_cantera
+			SET nombre = $2, descripcion = $3, columnas = $4, codigos_transaccion = $5, delimitador = $6,
+				incluir_encabezado = $7, formato_fecha = $8, updated_at = NOW()
+			WHERE id = $1
+			RETURNING created_at, updated_at
+		`,
+		"delete_perfil": `
+			DELETE FROM perfiles_exportacion_erp_cantera WHERE id = $1
+		`,
+		"get_perfil": `
+			SELECT ` + columnas + `
+			FROM perfiles_exportacion_erp_cantera
+			WHERE id = $1
+		`,
+		"get_perfil_nombre": `
+			SELECT ` + columnas + `
+			FROM perfiles_exportacion_erp_cantera
+			WHERE nombre = $1
+		`,
+		"get_perfiles": `
+			SELECT ` + columnas + `
+			FROM perfiles_exportacion_erp_cantera
+			ORDER BY nombre
+		`,
+		// Los traslados internos entre bodegas no cambian el stock del local: el ERP no los ve
+		"get_movimientos_periodo": `
+			SELECT m.id, m.codigo_producto, m.tipo_item, m.tipo_movimiento, m.cantidad, m.cantidad_anterior,
+				   m.cantidad_nueva, m.motivo, m.id_usuario, m.id_local, COALESCE(m.observaciones, ''), m.created_at,
+				   m.documento_tipo, m.documento_numero, m.documento_fecha, m.unidad, m.cantidad_unidad, m.id_operacion,
+				   COALESCE(p.nombre, (SELECT pl.nombre_pack FROM pack_listados pl
+									   WHERE m.tipo_item = 'pack' AND pl.codigo_pack = m.codigo_producto LIMIT 1))
+			FROM stock_movimientos_cantera m
+			LEFT JOIN productos p ON m.tipo_item = 'producto' AND p.codigo = m.codigo_producto
+			WHERE m.created_at >= $1 AND m.created_at < $2
+			  AND ($3::int IS NULL OR m.id_local = $3)
+			  AND m.tipo_movimiento <> 'traslado_interno'
+			ORDER BY m.created_at, m.id
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// CreatePerfil registra un perfil nuevo
+func (r *exportacionERPRepository) CreatePerfil(ctx context.Context, perfil *models.PerfilExportacionERP) error {
+	columnas, codigos, err := marshalPerfilERP(perfil)
+	if err != nil {
+		return err
+	}
+
+	err = r.stmts["create_perfil"].QueryRowContext(ctx,
+		perfil.Nombre, perfil.Descripcion, columnas, codigos, perfil.Delimitador,
+		perfil.IncluirEncabezado, perfil.FormatoFecha,
+	).Scan(&perfil.ID, &perfil.CreatedAt, &perfil.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create perfil exportacion erp: %w", err)
+	}
+	return nil
+}
+
+// UpdatePerfil reemplaza los datos del perfil
+func (r *exportacionERPRepository) UpdatePerfil(ctx context.Context, perfil *models.PerfilExportacionERP) (bool, error) {
+	columnas, codigos, err := marshalPerfilERP(perfil)
+	if err != nil {
+		return false, err
+	}
+
+	err = r.stmts["update_perfil"].QueryRowContext(ctx,
+		perfil.ID, perfil.Nombre, perfil.Descripcion, columnas, codigos, perfil.Delimitador,
+		perfil.IncluirEncabezado, perfil.FormatoFecha,
+	).Scan(&perfil.CreatedAt, &perfil.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update perfil exportacion erp: %w", err)
+	}
+	return true, nil
+}
+
+// DeletePerfil elimina el perfil
+func (r *exportacionERPRepository) DeletePerfil(ctx context.Context, id int) (bool, error) {
+	result, err := r.stmts["delete_perfil"].ExecContext(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete perfil exportacion erp: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetPerfilByID obtiene un perfil por ID
+func (r *exportacionERPRepository) GetPerfilByID(ctx context.Context, id int) (*models.PerfilExportacionERP, error) {
+	perfil, err := scanPerfilERP(r.stmts["get_perfil"].QueryRowContext(ctx, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get perfil exportacion erp: %w", err)
+	}
+	return perfil, nil
+}
+
+// GetPerfilByNombre obtiene un perfil por nombre
+func (r *exportacionERPRepository) GetPerfilByNombre(ctx context.Context, nombre string) (*models.PerfilExportacionERP, error) {
+	perfil, err := scanPerfilERP(r.stmts["get_perfil_nombre"].QueryRowContext(ctx, nombre))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get perfil exportacion erp: %w", err)
+	}
+	return perfil, nil
+}
+
+// GetPerfiles lista los perfiles
+func (r *exportacionERPRepository) GetPerfiles(ctx context.Context) ([]*models.PerfilExportacionERP, error) {
+	rows, err := r.stmts["get_perfiles"].QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query perfiles exportacion erp: %w", err)
+	}
+	defer rows.Close()
+
+	perfiles := []*models.PerfilExportacionERP{}
+	for rows.Next() {
+		perfil, err := scanPerfilERP(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan perfil exportacion erp: %w", err)
+		}
+		perfiles = append(perfiles, perfil)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate perfiles exportacion erp: %w", err)
+	}
+
+	return perfiles, nil
+}
+
+// RecorrerMovimientos recorre los movimientos del período fila a fila
+func (r *exportacionERPRepository) RecorrerMovimientos(ctx context.Context, idLocal *int, desde, hasta time.Time, fn func(*models.MovimientoERP) error) error {
+	rows, err := r.stmts["get_movimientos_periodo"].QueryContext(ctx, desde, hasta, idLocal)
+	if err != nil {
+		return fmt.Errorf("failed to query movimientos exportacion erp: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movimiento models.MovimientoERP
+		if err := rows.Scan(
+			&movimiento.ID, &movimiento.CodigoProducto, &movimiento.TipoItem, &movimiento.TipoMovimiento,
+			&movimiento.Cantidad, &movimiento.CantidadAnterior, &movimiento.CantidadNueva, &movimiento.Motivo,
+			&movimiento.IDUsuario, &movimiento.IDLocal, &movimiento.Observaciones, &movimiento.CreatedAt,
+			&movimiento.DocumentoTipo, &movimiento.DocumentoNumero, &movimiento.DocumentoFecha,
+			&movimiento.Unidad, &movimiento.CantidadUnidad, &movimiento.IDOperacion, &movimiento.NombreProducto,
+		); err != nil {
+			return fmt.Errorf("failed to scan movimiento exportacion erp: %w", err)
+		}
+		if err := fn(&movimiento); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate movimientos exportacion erp: %w", err)
+	}
+
+	return nil
+}
+
+// marshalPerfilERP serializa las columnas y códigos de transacción del perfil
+func marshalPerfilERP(perfil *models.PerfilExportacionERP) ([]byte, []byte, error) {
+	columnas, err := json.Marshal(perfil.Columnas)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal columnas: %w", err)
+	}
+	codigos, err := json.Marshal(perfil.CodigosTransaccion)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal codigos transaccion: %w", err)
+	}
+	return columnas, codigos, nil
+}
+
+// scanPerfilERP escanea una fila de perfiles_exportacion_erp_cantera
+func scanPerfilERP(row interface{ Scan(...interface{}) error }) (*models.PerfilExportacionERP, error) {
+	var perfil models.PerfilExportacionERP
+	var columnas, codigos []byte
+	err := row.Scan(
+		&perfil.ID, &perfil.Nombre, &perfil.Descripcion, &columnas, &codigos, &perfil.Delimitador,
+		&perfil.IncluirEncabezado, &perfil.FormatoFecha, &perfil.CreatedAt, &perfil.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(columnas, &perfil.Columnas); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal columnas: %w", err)
+	}
+	if err := json.Unmarshal(codigos, &perfil.CodigosTransaccion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal codigos transaccion: %w", err)
+	}
+	return &perfil, nil
+}
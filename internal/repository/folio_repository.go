@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// siguienteFolioQuery incrementa el folio del local y tipo (creando la fila en el primero)
+// La fila queda bloqueada hasta el fin de la transacción: las emisiones concurrentes del mismo
+// local y tipo esperan, y si la transacción se revierte el folio no se consume
+const siguienteFolioQuery = `
+	INSERT INTO folios_documentos_cantera (id_local, tipo_documento, ultimo_folio)
+	VALUES ($1, $2, 1)
+	ON CONFLICT (id_local, tipo_documento)
+	DO UPDATE SET ultimo_folio = folios_documentos_cantera.ultimo_folio + 1, updated_at = NOW()
+	RETURNING ultimo_folio
+`
+
+// siguienteFolio toma el próximo folio del tipo de documento en el local dentro de tx
+// Debe llamarse en la misma transacción que inserta el documento, que conviene que sea corta
+// (el lock de la fila se mantiene hasta el commit)
+func siguienteFolio(ctx context.Context, tx *sql.Tx, idLocal int, tipoDocumento string) (int64, error) {
+	var folio int64
+	if err := tx.QueryRowContext(ctx, siguienteFolioQuery, idLocal, tipoDocumento).Scan(&folio); err != nil {
+		return 0, fmt.Errorf("failed to get siguiente folio %s local %d: %w", tipoDocumento, idLocal, err)
+	}
+	return folio, nil
+}
+
+// FolioRepository define la interfaz para consultar y ajustar los folios de documentos
+// Los folios se toman con siguienteFolio dentro de la transacción de cada documento
+type FolioRepository interface {
+	GetFolios(ctx context.Context, idLocal *int) ([]*models.Folio, error)
+	// AvanzarFolio fija el último folio emitido si es mayor al actual; retorna false si no avanzó
+	AvanzarFolio(ctx context.Context, idLocal int, tipoDocumento string, ultimoFolio int64) (bool, error)
+}
+
+// folioRepository implementa FolioRepository
+type folioRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewFolioRepository crea una nueva instancia del repository
+func NewFolioRepository(db *sql.DB) (FolioRepository, error) {
+	repo := &folioRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *folioRepository) prepareStatements() error {
+	statements := map[string]string{
+		"get_folios": `
+			SELECT id_local, tipo_documento, ultimo_folio, updated_at
+			FROM folios_documentos_cantera
+			WHERE ($1::int IS NULL OR id_local = $1)
+			ORDER BY id_local, tipo_documento
+		`,
+		"avanzar_folio": `
+			INSERT INTO folios_documentos_cantera (id_local, tipo_documento, ultimo_folio)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (id_local, tipo_documento)
+			DO UPDATE SET ultimo_folio = EXCLUDED.ultimo_folio, updated_at = NOW()
+			WHERE folios_documentos_cantera.ultimo_folio < EXCLUDED.ultimo_folio
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// GetFolios retorna el último folio de cada tipo de documento (opcionalmente de un local)
+func (r *folioRepository) GetFolios(ctx context.Context, idLocal *int) ([]*models.Folio, error) {
+	rows, err := r.stmts["get_folios"].QueryContext(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query folios: %w", err)
+	}
+	defer rows.Close()
+
+	folios := []*models.Folio{}
+	for rows.Next() {
+		var folio models.Folio
+		if err := rows.Scan(&folio.IDLocal, &folio.TipoDocumento, &folio.UltimoFolio, &folio.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan folio: %w", err)
+		}
+		folios = append(folios, &folio)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate folios: %w", err)
+	}
+
+	return folios, nil
+}
+
+// AvanzarFolio fija el último folio emitido solo hacia adelante
+func (r *folioRepository) AvanzarFolio(ctx context.Context, idLocal int, tipoDocumento string, ultimoFolio int64) (bool, error) {
+	result, err := r.stmts["avanzar_folio"].ExecContext(ctx, idLocal, tipoDocumento, ultimoFolio)
+	if err != nil {
+		return false, fmt.Errorf("failed to avanzar folio: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// GuiaDespachoRepository define la interfaz para las guías de despacho entre locales
+type GuiaDespachoRepository interface {
+	CreateGuia(ctx context.Context, guia *models.GuiaDespacho) error
+	GetGuiaByID(ctx context.Context, id int) (*models.GuiaDespacho, error)
+	CambiarEstado(ctx context.Context, id int, desde, hasta string) (bool, error)
+	ConfirmarRecepcion(ctx context.Context, id int, recibidos map[string]int, idUsuario int, observaciones string) error
+	GetGuiasEnTransito(ctx context.Context, filter *models.GuiaEnTransitoFilter) ([]*models.GuiaEnTransito, error)
+	GetMermasTransporte(ctx context.Context, filter *models.ReporteFilter) ([]*models.MermaTransporte, error)
+}
+
+// guiaDespachoRepository implementa GuiaDespachoRepository
+type guiaDespachoRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewGuiaDespachoRepository crea una nueva instancia del repository
+func NewGuiaDespachoRepository(db *sql.DB) (GuiaDespachoRepository, error) {
+	repo := &guiaDespachoRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *guiaDespachoRepository) prepareStatements() error {
+	statements := map[string]string{
+		"create_guia": `
+			INSERT INTO guias_despacho_cantera
+			(id_local_origen, id_local_destino, estado, observaciones, id_usuario, id_operacion, folio)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, correlativo, created_at, updated_at
+		`,
+		"create_guia_item": `
+			INSERT INTO guia_despacho_items_cantera
+			(id_guia, codigo_producto, tipo_item, descripcion, cantidad_enviada)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`,
+		"get_guia": `
+			SELECT id, correlativo, id_local_origen, id_local_destino, estado, observaciones,
+				   id_usuario, id_usuario_recepcion, observaciones_recepcion,
+				   despachada_at, recibida_at, created_at, updated_at, id_operacion, folio
+			FROM guias_despacho_cantera
+			WHERE id = $1
+		`,
+		"get_guia_items": `
+			SELECT id, id_guia, codigo_producto, tipo_item, descripcion, cantidad_enviada, cantidad_recibida
+			FROM guia_despacho_items_cantera
+			WHERE id_guia = $1
+			ORDER BY id
+		`,
+		"cambiar_estado": `
+			UPDATE guias_despacho_cantera
+			SET estado = $3, updated_at = NOW(),
+				despachada_at = CASE WHEN $3 = 'en_transito' AND despachada_at IS NULL THEN NOW() ELSE despachada_at END
+			WHERE id = $1 AND estado = $2
+		`,
+		"set_cantidad_recibida": `
+			UPDATE guia_despacho_items_cantera
+			SET cantidad_recibida = $3
+			WHERE id_guia = $1 AND codigo_producto = $2
+		`,
+		"confirmar_recepcion": `
+			UPDATE guias_despacho_cantera
+			SET estado = 'recibida', id_usuario_recepcion = $2, observaciones_recepcion = $3,
+				recibida_at = NOW(), updated_at = NOW()
+			WHERE id = $1
+		`,
+		"get_guias_en_transito": `
+			SELECT g.id, g.correlativo, g.folio, g.id_local_origen, g.id_local_destino,
+				   COUNT(i.id), COALESCE(SUM(i.cantidad_enviada), 0), g.despachada_at,
+				   EXTRACT(EPOCH FROM (NOW() - g.despachada_at)) / 3600
+			FROM guias_despacho_cantera g
+			LEFT JOIN guia_despacho_items_cantera i ON i.id_guia = g.id
+			WHERE g.estado = 'en_transito'
+			  AND ($1::int IS NULL OR g.id_local_origen = $1)
+			  AND ($2::int IS NULL OR g.id_local_destino = $2)
+			GROUP BY g.id
+			ORDER BY g.despachada_at
+		`,
+		"get_mermas_transporte": `
+			SELECT i.codigo_producto, MAX(i.descripcion), g.id_local_origen, g.id_local_destino,
+				   COUNT(DISTINCT g.id),
+				   SUM(i.cantidad_enviada), SUM(i.cantidad_recibida),
+				   SUM(GREATEST(i.cantidad_enviada - i.cantidad_recibida, 0)),
+				   SUM(GREATEST(i.cantidad_recibida - i.cantidad_enviada, 0))
+			FROM guias_despacho_cantera g
+			JOIN guia_despacho_items_cantera i ON i.id_guia = g.id
+			WHERE g.estado = 'recibida'
+			  AND i.cantidad_recibida <> i.cantidad_enviada
+			  AND ($1::int IS NULL OR g.id_local_origen = $1 OR g.id_local_destino = $1)
+			  AND g.recibida_at >= $2 AND g.recibida_at < $3
+			GROUP BY i.codigo_producto, g.id_local_origen, g.id_local_destino
+			ORDER BY SUM(GREATEST(i.cantidad_enviada - i.cantidad_recibida, 0)) DESC, i.codigo_producto
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// CreateGuia crea la guía con sus ítems en una sola transacción, asignándole el folio del local origen
+func (r *guiaDespachoRepository) CreateGuia(ctx context.Context, guia *models.GuiaDespacho) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	folio, err := siguienteFolio(ctx, tx, guia.IDLocalOrigen, models.TipoDocumentoGuiaDespacho)
+	if err != nil {
+		return err
+	}
+	guia.Folio = &folio
+
+	err = tx.StmtContext(ctx, r.stmts["create_guia"]).QueryRowContext(ctx,
+		guia.IDLocalOrigen, guia.IDLocalDestino, guia.Estado, guia.Observaciones, guia.IDUsuario, guia.IDOperacion, guia.Folio,
+	).Scan(&guia.ID, &guia.Correlativo, &guia.CreatedAt, &guia.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create guia despacho: %w", err)
+	}
+	guia.Numero = models.NumeroGuia(guia.Correlativo, guia.IDLocalOrigen, guia.Folio)
+
+	itemStmt := tx.StmtContext(ctx, r.stmts["create_guia_item"])
+	for _, item := range guia.Items {
+		item.IDGuia = guia.ID
+		err := itemStmt.QueryRowContext(ctx,
+			item.IDGuia, item.CodigoProducto, item.TipoItem, item.Descripcion, item.CantidadEnviada,
+		).Scan(&item.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create guia despacho item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetGuiaByID obtiene una guía con sus ítems
+func (r *guiaDespachoRepository) GetGuiaByID(ctx context.Context, id int) (*models.GuiaDespacho, error) {
+	var guia models.GuiaDespacho
+	err := r.stmts["get_guia"].QueryRowContext(ctx, id).Scan(
+		&guia.ID, &guia.Correlativo, &guia.IDLocalOrigen, &guia.IDLocalDestino, &guia.Estado,
+		&guia.Observaciones, &guia.IDUsuario, &guia.IDUsuarioRecepcion, &guia.ObservacionesRecepcion,
+		&guia.DespachadaAt, &guia.RecibidaAt, &guia.CreatedAt, &guia.UpdatedAt, &guia.IDOperacion, &guia.Folio,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guia despacho: %w", err)
+	}
+	guia.Numero = models.NumeroGuia(guia.Correlativo, guia.IDLocalOrigen, guia.Folio)
+
+	rows, err := r.stmts["get_guia_items"].QueryContext(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guia despacho items: %w", err)
+	}
+	defer rows.Close()
+
+	guia.Items = []*models.GuiaDespachoItem{}
+	for rows.Next() {
+		var item models.GuiaDespachoItem
+		err := rows.Scan(
+			&item.ID, &item.IDGuia, &item.CodigoProducto, &item.TipoItem, &item.Descripcion,
+			&item.CantidadEnviada, &item.CantidadRecibida,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan guia despacho item: %w", err)
+		}
+		guia.Items = append(guia.Items, &item)
+	}
+
+	return &guia, nil
+}
+
+// CambiarEstado transiciona la guía solo si está en el estado esperado
+// Al pasar a 'en_transito' registra el momento del despacho
+func (r *guiaDespachoRepository) CambiarEstado(ctx context.Context, id int, desde, hasta string) (bool, error) {
+	result, err := r.stmts["cambiar_estado"].ExecContext(ctx, id, desde, hasta)
+	if err != nil {
+		return false, fmt.Errorf("failed to cambiar estado guia despacho: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ConfirmarRecepcion registra las cantidades recibidas y marca la guía como recibida
+func (r *guiaDespachoRepository) ConfirmarRecepcion(ctx context.Context, id int, recibidos map[string]int, idUsuario int, observaciones string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	itemStmt := tx.StmtContext(ctx, r.stmts["set_cantidad_recibida"])
+	for codigo, cantidad := range recibidos {
+		if _, err := itemStmt.ExecContext(ctx, id, codigo, cantidad); err != nil {
+			return fmt.Errorf("failed to set cantidad recibida: %w", err)
+		}
+	}
+
+	if _, err := tx.StmtContext(ctx, r.stmts["confirmar_recepcion"]).ExecContext(ctx, id, idUsuario, observaciones); err != nil {
+		return fmt.Errorf("failed to confirmar recepcion guia despacho: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetGuiasEnTransito obtiene las guías despachadas pendientes de recepción, las más antiguas primero
+func (r *guiaDespachoRepository) GetGuiasEnTransito(ctx context.Context, filter *models.GuiaEnTransitoFilter) ([]*models.GuiaEnTransito, error) {
+	rows, err := r.stmts["get_guias_en_transito"].QueryContext(ctx, filter.IDLocalOrigen, filter.IDLocalDestino)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guias en transito: %w", err)
+	}
+	defer rows.Close()
+
+	guias := []*models.GuiaEnTransito{}
+	for rows.Next() {
+		var guia models.GuiaEnTransito
+		var correlativo int
+		var folio *int64
+		err := rows.Scan(
+			&guia.ID, &correlativo, &folio, &guia.IDLocalOrigen, &guia.IDLocalDestino,
+			&guia.TotalItems, &guia.TotalUnidades, &guia.DespachadaAt, &guia.HorasEnTransito,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan guia en transito: %w", err)
+		}
+		guia.Numero = models.NumeroGuia(correlativo, guia.IDLocalOrigen, folio)
+		guias = append(guias, &guia)
+	}
+
+	return guias, nil
+}
+
+// GetMermasTransporte agrega las diferencias de recepción de las guías recibidas en el período
+// El filtro de local considera las guías donde el local es origen o destino
+func (r *guiaDespachoRepository) GetMermasTransporte(ctx context.Context, filter *models.ReporteFilter) ([]*models.MermaTransporte, error) {
+	rows, err := r.stmts["get_mermas_transporte"].QueryContext(ctx, filter.IDLocal, filter.Desde, filter.Hasta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mermas transporte: %w", err)
+	}
+	defer rows.Close()
+
+	mermas := []*models.MermaTransporte{}
+	for rows.Next() {
+		var merma models.MermaTransporte
+		err := rows.Scan(
+			&merma.CodigoProducto, &merma.Descripcion, &merma.IDLocalOrigen, &merma.IDLocalDestino,
+			&merma.Guias, &merma.CantidadEnviada, &merma.CantidadRecibida, &merma.Merma, &merma.Sobrante,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan merma transporte: %w", err)
+		}
+		mermas = append(mermas, &merma)
+	}
+
+	return mermas, nil
+}
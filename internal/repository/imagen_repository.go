@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// ImagenRepository define la interfaz para las imágenes de productos
+type ImagenRepository interface {
+	ExisteProducto(ctx context.Context, codigo string) (bool, error)
+	GetImagen(ctx context.Context, codigo string) (*models.ImagenProducto, error)
+	UpsertImagen(ctx context.Context, imagen *models.ImagenProducto) error
+	DeleteImagen(ctx context.Context, codigo string) error
+}
+
+// imagenRepository implementa ImagenRepository
+type imagenRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewImagenRepository crea una nueva instancia del repository
+func NewImagenRepository(db *sql.DB) (ImagenRepository, error) {
+	repo := &imagenRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *imagenRepository) prepareStatements() error {
+	statements := map[string]string{
+		"existe_producto": `
+			SELECT EXISTS (SELECT 1 FROM productos WHERE codigo = $1)
+			    OR EXISTS (SELECT 1 FROM pack_listados WHERE codigo_pack = $1)
+		`,
+		"get_imagen": `
+			SELECT codigo_producto, storage_key, thumbnail_key, content_type, hash,
+				   ancho, alto, tamano_bytes, url, url_miniatura, id_usuario,
+				   created_at, updated_at
+			FROM imagenes_productos_cantera
+			WHERE codigo_producto = $1
+		`,
+		"upsert_imagen": `
+			INSERT INTO imagenes_productos_cantera
+				(codigo_producto, storage_key, thumbnail_key, content_type, hash,
+				 ancho, alto, tamano_bytes, url, url_miniatura, id_usuario)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (codigo_producto) DO UPDATE SET
+				storage_key = EXCLUDED.storage_key,
+				thumbnail_key = EXCLUDED.thumbnail_key,
+				content_type = EXCLUDED.content_type,
+				hash = EXCLUDED.hash,
+				ancho = EXCLUDED.ancho,
+				alto = EXCLUDED.alto,
+				tamano_bytes = EXCLUDED.tamano_bytes,
+				url = EXCLUDED.url,
+				url_miniatura = EXCLUDED.url_miniatura,
+				id_usuario = EXCLUDED.id_usuario,
+				updated_at = NOW()
+			RETURNING created_at, updated_at
+		`,
+		"delete_imagen": `
+			DELETE FROM imagenes_productos_cantera WHERE codigo_producto = $1
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// ExisteProducto indica si el código corresponde a un producto o a un pack
+func (r *imagenRepository) ExisteProducto(ctx context.Context, codigo string) (bool, error) {
+	var existe bool
+	if err := r.stmts["existe_producto"].QueryRowContext(ctx, codigo).Scan(&existe); err != nil {
+		return false, fmt.Errorf("failed to check producto: %w", err)
+	}
+	return existe, nil
+}
+
+// GetImagen obtiene la imagen de un producto (nil si no tiene)
+func (r *imagenRepository) GetImagen(ctx context.Context, codigo string) (*models.ImagenProducto, error) {
+	var imagen models.ImagenProducto
+	err := r.stmts["get_imagen"].QueryRowContext(ctx, codigo).Scan(
+		&imagen.CodigoProducto,
+		&imagen.StorageKey,
+		&imagen.ThumbnailKey,
+		&imagen.ContentType,
+		&imagen.Hash,
+		&imagen.Ancho,
+		&imagen.Alto,
+		&imagen.TamanoBytes,
+		&imagen.URL,
+		&imagen.URLMiniatura,
+		&imagen.IDUsuario,
+		&imagen.CreatedAt,
+		&imagen.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get imagen: %w", err)
+	}
+	return &imagen, nil
+}
+
+// UpsertImagen crea o reemplaza la imagen de un producto
+func (r *imagenRepository) UpsertImagen(ctx context.Context, imagen *models.ImagenProducto) error {
+	err := r.stmts["upsert_imagen"].QueryRowContext(ctx,
+		imagen.CodigoProducto,
+		imagen.StorageKey,
+		imagen.ThumbnailKey,
+		imagen.ContentType,
+		imagen.Hash,
+		imagen.Ancho,
+		imagen.Alto,
+		imagen.TamanoBytes,
+		imagen.URL,
+		imagen.URLMiniatura,
+		imagen.IDUsuario,
+	).Scan(&imagen.CreatedAt, &imagen.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert imagen: %w", err)
+	}
+	return nil
+}
+
+// DeleteImagen elimina la imagen de un producto
+func (r *imagenRepository) DeleteImagen(ctx context.Context, codigo string) error {
+	if _, err := r.stmts["delete_imagen"].ExecContext(ctx, codigo); err != nil {
+		return fmt.Errorf("failed to delete imagen: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+)
+
+// minimoEstacionalVigenteJoin mínimo estacional vigente hoy del stock s (alias me; NULL si no hay)
+// Lo usan GetStockBajo y el resumen por local en lugar de la cantidad_minima fija
+const minimoEstacionalVigenteJoin = `
+	LEFT JOIN LATERAL (
+		SELECT m.cantidad_minima, m.temporada
+		FROM minimos_estacionales_cantera m
+		WHERE m.codigo_producto = s.codigo_producto AND m.id_local = s.id_local
+		  AND CURRENT_DATE BETWEEN m.desde AND m.hasta
+		ORDER BY m.desde DESC
+		LIMIT 1
+	) me ON TRUE
+`
+
+// MinimoEstacionalRepository define la interfaz para los mínimos estacionales de stock
+type MinimoEstacionalRepository interface {
+	CreateMinimo(ctx context.Context, minimo *models.MinimoEstacional) error
+	GetMinimos(ctx context.Context, filter *models.MinimoEstacionalFilter) ([]*models.MinimoEstacional, error)
+	GetMinimosSolapados(ctx context.Context, codigoProducto string, idLocal int, desde, hasta time.Time) ([]*models.MinimoEstacional, error)
+	DeleteMinimo(ctx context.Context, id int) (bool, error)
+}
+
+// minimoEstacionalRepository implementa MinimoEstacionalRepository
+type minimoEstacionalRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewMinimoEstacionalRepository crea una nueva instancia del repository
+func NewMinimoEstacionalRepository(db *sql.DB) (MinimoEstacionalRepository, error) {
+	repo := &minimoEstacionalRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// minimoEstacionalColumns columnas de minimos_estacionales_cantera en el orden de scanMinimosEstacionales
+const minimoEstacionalColumns = `
+	id, codigo_producto, id_local, temporada, cantidad_minima, desde, hasta, id_usuario, created_at,
+	CURRENT_DATE BETWEEN desde AND hasta AS vigente
+`
+
+// prepareStatements prepara todas las consultas SQL
+func (r *minimoEstacionalRepository) prepareStatements() error {
+	statements := map[string]string{
+		"create_minimo": `
+			INSERT INTO minimos_estacionales_cantera
+			(codigo_producto, id_local, temporada, cantidad_minima, desde, hasta, id_usuario)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at, CURRENT_DATE BETWEEN desde AND hasta
+		`,
+		"get_minimos": `
+			SELECT ` + minimoEstacionalColumns + `
+			FROM minimos_estacionales_cantera
+			WHERE ($1::text IS NULL OR codigo_producto = $1)
+			  AND ($2::int IS NULL OR id_local = $2)
+			  AND (NOT $3 OR CURRENT_DATE BETWEEN desde AND hasta)
+			ORDER BY codigo_producto, id_local, desde
+		`,
+		"get_minimos_solapados": `
+			SELECT ` + minimoEstacionalColumns + `
+			FROM minimos_estacionales_cantera
+			WHERE codigo_producto = $1 AND id_local = $2
+			  AND desde <= $4 AND hasta >= $3
+			ORDER BY desde
+		`,
+		"delete_minimo": `
+			DELETE FROM minimos_estacionales_cantera WHERE id = $1
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// CreateMinimo registra un mínimo estacional
+func (r *minimoEstacionalRepository) CreateMinimo(ctx context.Context, minimo *models.MinimoEstacional) error {
+	err := r.stmts["create_minimo"].QueryRowContext(ctx,
+		minimo.CodigoProducto, minimo.IDLocal, minimo.Temporada, minimo.CantidadMinima,
+		minimo.Desde, minimo.Hasta, minimo.IDUsuario,
+	).Scan(&minimo.ID, &minimo.CreatedAt, &minimo.Vigente)
+	if err != nil {
+		return fmt.Errorf("failed to create minimo estacional: %w", err)
+	}
+
+	return nil
+}
+
+// GetMinimos lista los mínimos estacionales según el filtro
+func (r *minimoEstacionalRepository) GetMinimos(ctx context.Context, filter *models.MinimoEstacionalFilter) ([]*models.MinimoEstacional, error) {
+	rows, err := r.stmts["get_minimos"].QueryContext(ctx, filter.CodigoProducto, filter.IDLocal, filter.SoloVigentes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get minimos estacionales: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMinimosEstacionales(rows)
+}
+
+// GetMinimosSolapados mínimos del producto en el local cuyo rango se cruza con [desde, hasta]
+func (r *minimoEstacionalRepository) GetMinimosSolapados(ctx context.Context, codigoProducto string, idLocal int, desde, hasta time.Time) ([]*models.MinimoEstacional, error) {
+	rows, err := r.stmts["get_minimos_solapados"].QueryContext(ctx, codigoProducto, idLocal, desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get minimos solapados: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMinimosEstacionales(rows)
+}
+
+// DeleteMinimo elimina un mínimo estacional; retorna false si no existía
+func (r *minimoEstacionalRepository) DeleteMinimo(ctx context.Context, id int) (bool, error) {
+	result, err := r.stmts["delete_minimo"].ExecContext(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete minimo estacional: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// scanMinimosEstacionales lee las filas de minimoEstacionalColumns
+func scanMinimosEstacionales(rows *sql.Rows) ([]*models.MinimoEstacional, error) {
+	minimos := []*models.MinimoEstacional{}
+	for rows.Next() {
+		var m models.MinimoEstacional
+		if err := rows.Scan(
+			&m.ID, &m.CodigoProducto, &m.IDLocal, &m.Temporada, &m.CantidadMinima,
+			&m.Desde, &m.Hasta, &m.IDUsuario, &m.CreatedAt, &m.Vigente,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan minimo estacional: %w", err)
+		}
+		minimos = append(minimos, &m)
+	}
+
+	return minimos, rows.Err()
+}
@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// NotaCreditoRepository define la interfaz para las notas de crédito sobre ventas del POS
+type NotaCreditoRepository interface {
+	// GetVenta retorna lo vendido y lo ya anulado de la venta (nil si no hay salidas del POS
+	// con esa operación)
+	GetVenta(ctx context.Context, idOperacion string) (*models.VentaAcreditable, error)
+	// CreateNotaCredito crea la nota con sus ítems asignándole el folio del local; validar recibe
+	// la venta leída con el lock de la venta tomado, para que dos notas concurrentes no anulen
+	// más de lo vendido
+	CreateNotaCredito(ctx context.Context, nota *models.NotaCredito, validar func(*models.VentaAcreditable) error) error
+	// AplicarNotaCredito marca la nota como aplicada con la operación que reingresó el stock
+	// y registra el monto devuelto como línea contable de la venta, en una transacción
+	AplicarNotaCredito(ctx context.Context, nota *models.NotaCredito, idOperacion string) error
+	AnularNotaCredito(ctx context.Context, id int64) error
+	GetNotaCredito(ctx context.Context, id int64) (*models.NotaCredito, error)
+	GetNotasCreditoVenta(ctx context.Context, idOperacionVenta string) ([]*models.NotaCredito, error)
+	GetResumenNotasCredito(ctx context.Context, filter *models.ReporteFilter) ([]*models.NotasCreditoMedioPago, error)
+}
+
+// notaCreditoRepository implementa NotaCreditoRepository
+type notaCreditoRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewNotaCreditoRepository crea una nueva instancia del repository
+func NewNotaCreditoRepository(db *sql.DB) (NotaCreditoRepository, error) {
+	repo := &notaCreditoRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *notaCreditoRepository) prepareStatements() error {
+	statements := map[string]string{
+		// Salidas del POS de la operación (sin los componentes de packs, que se reingresan
+		// al reingresar el pack) y lo anulado por notas no anuladas
+		"get_venta": `
+			WITH vendidos AS (
+				SELECT m.codigo_producto, m.tipo_item, m.id_local,
+					   SUM(m.cantidad)::int AS vendido, MIN(m.created_at) AS fecha
+				FROM stock_movimientos_cantera m
+				WHERE m.id_operacion = $1
+				  AND m.tipo_movimiento = 'salida'
+				  AND m.observaciones LIKE '[POS]%'
+				GROUP BY m.codigo_producto, m.tipo_item, m.id_local
+			),
+			acreditados AS (
+				SELECT i.codigo_producto, i.tipo_item, SUM(i.cantidad)::int AS acreditado
+				FROM notas_credito_cantera n
+				JOIN nota_credito_items_cantera i ON i.id_nota = n.id
+				WHERE n.id_operacion_venta = $1 AND n.estado <> 'anulada'
+				GROUP BY i.codigo_producto, i.tipo_item
+			)
+			SELECT v.codigo_producto, v.tipo_item, v.id_local, v.vendido,
+				   COALESCE(a.acreditado, 0), v.fecha
+			FROM vendidos v
+			LEFT JOIN acreditados a ON a.codigo_producto = v.codigo_producto AND a.tipo_item = v.tipo_item
+			ORDER BY v.codigo_producto
+		`,
+		"lock_venta": `
+			SELECT pg_advisory_xact_lock(hashtext('nota_credito:' || $1))
+		`,
+		"create_nota": `
+			INSERT INTO notas_credito_cantera
+			(id_local, folio, id_operacion_venta, venta_ref, estado, motivo, medio_pago, monto,
+			 id_usuario, id_autorizador, rol_autorizador)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id, created_at, updated_at
+		`,
+		"create_nota_item": `
+			INSERT INTO nota_credito_items_cantera (id_nota, codigo_producto, tipo_item, cantidad)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id
+		`,
+		"aplicar_nota": `
+			UPDATE notas_credito_cantera
+			SET estado = 'aplicada', id_operacion = $2, updated_at = NOW()
+			WHERE id = $1 AND estado = 'emitida'
+		`,
+		"anular_nota": `
+			UPDATE notas_credito_cantera
+			SET estado = 'anulada', updated_at = NOW()
+			WHERE id = $1 AND estado = 'emitida'
+		`,
+		"create_linea_contable": `
+			INSERT INTO lineas_contables_venta_cantera
+			(venta_ref, id_local, id_usuario, medio_pago, tipo, monto)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`,
+		"get_nota": `
+			SELECT id, id_local, folio, id_operacion_venta, venta_ref, estado, motivo, medio_pago,
+				   monto, id_usuario, id_autorizador, rol_autorizador, id_operacion, created_at, updated_at
+			FROM notas_credito_cantera
+			WHERE id = $1
+		`,
+		"get_nota_items": `
+			SELECT id, id_nota, codigo_producto, tipo_item, cantidad
+			FROM nota_credito_items_cantera
+			WHERE id_nota = $1
+			ORDER BY id
+		`,
+		"get_notas_venta": `
+			SELECT id
+			FROM notas_credito_cantera
+			WHERE id_operacion_venta = $1
+			ORDER BY id
+		`,
+		"get_resumen": `
+			SELECT n.id_local, n.medio_pago, COUNT(*),
+				   COALESCE(SUM((SELECT SUM(i.cantidad) FROM nota_credito_items_cantera i WHERE i.id_nota = n.id)), 0)::int,
+				   COALESCE(SUM(n.monto), 0)
+			FROM notas_credito_cantera n
+			WHERE n.estado = 'aplicada'
+			  AND ($1::int IS NULL OR n.id_local = $1)
+			  AND n.created_at >= $2 AND n.created_at < $3
+			GROUP BY n.id_local, n.medio_pago
+			ORDER BY n.id_local, n.medio_pago
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// GetVenta retorna lo vendido y lo ya anulado de la venta
+func (r *notaCreditoRepository) GetVenta(ctx context.Context, idOperacion string) (*models.VentaAcreditable, error) {
+	return r.getVenta(ctx, r.stmts["get_venta"], idOperacion)
+}
+
+// getVenta lee la venta con el statement dado (ligado o no a una transacción)
+func (r *notaCreditoRepository) getVenta(ctx context.Context, stmt *sql.Stmt, idOperacion string) (*models.VentaAcreditable, error) {
+	rows, err := stmt.QueryContext(ctx, idOperacion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query venta: %w", err)
+	}
+	defer rows.Close()
+
+	var venta *models.VentaAcreditable
+	for rows.Next() {
+		var item models.ItemVentaAcreditable
+		var idLocal int
+		var fecha sql.NullTime
+		if err := rows.Scan(&item.CodigoProducto, &item.TipoItem, &idLocal, &item.Vendido, &item.Acreditado, &fecha); err != nil {
+			return nil, fmt.Errorf("failed to scan item venta: %w", err)
+		}
+		if venta == nil {
+			venta = &models.VentaAcreditable{IDOperacion: idOperacion, IDLocal: idLocal}
+		}
+		if fecha.Valid && (venta.Fecha.IsZero() || fecha.Time.Before(venta.Fecha)) {
+			venta.Fecha = fecha.Time
+		}
+		venta.Items = append(venta.Items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate items venta: %w", err)
+	}
+
+	return venta, nil
+}
+
+// CreateNotaCredito crea la nota con sus ítems y su folio en una sola transacción
+func (r *notaCreditoRepository) CreateNotaCredito(ctx context.Context, nota *models.NotaCredito, validar func(*models.VentaAcreditable) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serializa las notas de la misma venta hasta el commit
+	if _, err := tx.StmtContext(ctx, r.stmts["lock_venta"]).ExecContext(ctx, nota.IDOperacionVenta); err != nil {
+		return fmt.Errorf("failed to lock venta: %w", err)
+	}
+	venta, err := r.getVenta(ctx, tx.StmtContext(ctx, r.stmts["get_venta"]), nota.IDOperacionVenta)
+	if err != nil {
+		return err
+	}
+	if err := validar(venta); err != nil {
+		return err
+	}
+
+	folio, err := siguienteFolio(ctx, tx, nota.IDLocal, models.TipoDocumentoNotaCredito)
+	if err != nil {
+		return err
+	}
+	nota.Folio = folio
+	nota.Numero = models.NumeroDocumento(models.TipoDocumentoNotaCredito, nota.IDLocal, folio)
+
+	err = tx.StmtContext(ctx, r.stmts["create_nota"]).QueryRowContext(ctx,
+		nota.IDLocal, nota.Folio, nota.IDOperacionVenta, nota.VentaRef, nota.Estado, nota.Motivo,
+		nota.MedioPago, nota.Monto, nota.IDUsuario, nota.IDAutorizador, nota.RolAutorizador,
+	).Scan(&nota.ID, &nota.CreatedAt, &nota.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create nota credito: %w", err)
+	}
+
+	itemStmt := tx.StmtContext(ctx, r.stmts["create_nota_item"])
+	for _, item := range nota.Items {
+		item.IDNota = nota.ID
+		if err := itemStmt.QueryRowContext(ctx, item.IDNota, item.CodigoProducto, item.TipoItem, item.Cantidad).Scan(&item.ID); err != nil {
+			return fmt.Errorf("failed to create nota credito item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// AplicarNotaCredito marca la nota como aplicada y registra el monto devuelto
+// La línea contable usa la referencia de la venta si se informó (0 si no)
+func (r *notaCreditoRepository) AplicarNotaCredito(ctx context.Context, nota *models.NotaCredito, idOperacion string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.StmtContext(ctx, r.stmts["aplicar_nota"]).ExecContext(ctx, nota.ID, idOperacion); err != nil {
+		return fmt.Errorf("failed to aplicar nota credito: %w", err)
+	}
+
+	if nota.Monto != 0 {
+		var ventaRef int64
+		if nota.VentaRef != nil {
+			ventaRef = *nota.VentaRef
+		}
+		if _, err := tx.StmtContext(ctx, r.stmts["create_linea_contable"]).ExecContext(ctx,
+			ventaRef, nota.IDLocal, nota.IDUsuario, nota.MedioPago, models.LineaContableNotaCredito, -nota.Monto,
+		); err != nil {
+			return fmt.Errorf("failed to create linea contable nota credito: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// AnularNotaCredito anula una nota que no llegó a aplicarse (conserva su folio, sin huecos)
+func (r *notaCreditoRepository) AnularNotaCredito(ctx context.Context, id int64) error {
+	if _, err := r.stmts["anular_nota"].ExecContext(ctx, id); err != nil {
+		return fmt.Errorf("failed to anular nota credito: %w", err)
+	}
+	return nil
+}
+
+// GetNotaCredito obtiene una nota con sus ítems
+func (r *notaCreditoRepository) GetNotaCredito(ctx context.Context, id int64) (*models.NotaCredito, error) {
+	var nota models.NotaCredito
+	err := r.stmts["get_nota"].QueryRowContext(ctx, id).Scan(
+		&nota.ID, &nota.IDLocal, &nota.Folio, &nota.IDOperacionVenta, &nota.VentaRef, &nota.Estado,
+		&nota.Motivo, &nota.MedioPago, &nota.Monto, &nota.IDUsuario, &nota.IDAutorizador,
+		&nota.RolAutorizador, &nota.IDOperacion, &nota.CreatedAt, &nota.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nota credito: %w", err)
+	}
+	nota.Numero = models.NumeroDocumento(models.TipoDocumentoNotaCredito, nota.IDLocal, nota.Folio)
+
+	rows, err := r.stmts["get_nota_items"].QueryContext(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nota credito items: %w", err)
+	}
+	defer rows.Close()
+
+	nota.Items = []*models.NotaCreditoItem{}
+	for rows.Next() {
+		var item models.NotaCreditoItem
+		if err := rows.Scan(&item.ID, &item.IDNota, &item.CodigoProducto, &item.TipoItem, &item.Cantidad); err != nil {
+			return nil, fmt.Errorf("failed to scan nota credito item: %w", err)
+		}
+		nota.Items = append(nota.Items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate nota credito items: %w", err)
+	}
+
+	return &nota, nil
+}
+
+// GetNotasCreditoVenta obtiene las notas emitidas sobre una venta
+func (r *notaCreditoRepository) GetNotasCreditoVenta(ctx context.Context, idOperacionVenta string) ([]*models.NotaCredito, error) {
+	rows, err := r.stmts["get_notas_venta"].QueryContext(ctx, idOperacionVenta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notas credito venta: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan nota credito: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notas credito venta: %w", err)
+	}
+
+	notas := make([]*models.NotaCredito, 0, len(ids))
+	for _, id := range ids {
+		nota, err := r.GetNotaCredito(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if nota != nil {
+			notas = append(notas, nota)
+		}
+	}
+	return notas, nil
+}
+
+// GetResumenNotasCredito agrega las notas aplicadas del período por local y medio de pago
+func (r *notaCreditoRepository) GetResumenNotasCredito(ctx context.Context, filter *models.ReporteFilter) ([]*models.NotasCreditoMedioPago, error) {
+	rows, err := r.stmts["get_resumen"].QueryContext(ctx, filter.IDLocal, filter.Desde, filter.Hasta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query resumen notas credito: %w", err)
+	}
+	defer rows.Close()
+
+	resumen := []*models.NotasCreditoMedioPago{}
+	for rows.Next() {
+		var fila models.NotasCreditoMedioPago
+		if err := rows.Scan(&fila.IDLocal, &fila.MedioPago, &fila.Notas, &fila.Unidades, &fila.Monto); err != nil {
+			return nil, fmt.Errorf("failed to scan resumen notas credito: %w", err)
+		}
+		resumen = append(resumen, &fila)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate resumen notas credito: %w", err)
+	}
+
+	return resumen, nil
+}
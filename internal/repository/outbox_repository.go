@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"stock-service/internal/models"
+)
+
+// OutboxRepository define la interfaz para la entrega de los eventos de la outbox
+type OutboxRepository interface {
+	// TomarPendientes reserva hasta limite eventos vencidos para entregarlos; quedan reservados
+	// durante lease (otra réplica no los toma) y si la réplica cae vuelven a estar disponibles
+	TomarPendientes(ctx context.Context, limite int, lease time.Duration) ([]*models.EventoOutbox, error)
+	MarcarEnviado(ctx context.Context, id int64) error
+	// MarcarFallido registra el intento fallido; proximoIntento nil descarta el evento
+	MarcarFallido(ctx context.Context, id int64, ultimoError string, proximoIntento *time.Time) error
+	GetResumen(ctx context.Context) (*models.ResumenOutbox, error)
+	// ReintentarDescartados vuelve a dejar pendientes los eventos descartados (con los intentos en 0)
+	ReintentarDescartados(ctx context.Context) (int, error)
+	// PurgarEnviados elimina los eventos enviados antes de la fecha
+	PurgarEnviados(ctx context.Context, antesDe time.Time) (int, error)
+}
+
+// outboxRepository implementa OutboxRepository
+type outboxRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewOutboxRepository crea una nueva instancia del repository
+func NewOutboxRepository(db *sql.DB) (OutboxRepository, error) {
+	repo := &outboxRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *outboxRepository) prepareStatements() error {
+	statements := map[string]string{
+		// SKIP LOCKED: dos réplicas nunca toman el mismo evento; correr proximo_intento_at
+		// lo reserva hasta que se marque (o hasta que venza el lease si la réplica cae)
+		"tomar_pendientes": `
+			UPDATE outbox_eventos_cantera
+			SET proximo_intento_at = NOW() + $2 * INTERVAL '1 millisecond'
+			WHERE id IN (
+				SELECT id FROM outbox_eventos_cantera
+				WHERE enviado_at IS NULL AND descartado_at IS NULL AND proximo_intento_at <= NOW()
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, tipo, clave, payload, intentos, proximo_intento_at, ultimo_error, created_at
+		`,
+		"marcar_enviado": `
+			UPDATE outbox_eventos_cantera
+			SET enviado_at = NOW(), intentos = intentos + 1, ultimo_error = NULL
+			WHERE id = $1
+		`,
+		"marcar_fallido": `
+			UPDATE outbox_eventos_cantera
+			SET intentos = intentos + 1, ultimo_error = $2,
+				proximo_intento_at = COALESCE($3, proximo_intento_at),
+				descartado_at = CASE WHEN $3::timestamp IS NULL THEN NOW() END
+			WHERE id = $1
+		`,
+		"get_resumen": `
+			SELECT COUNT(*) FILTER (WHERE enviado_at IS NULL AND descartado_at IS NULL),
+				   COUNT(*) FILTER (WHERE enviado_at IS NULL AND descartado_at IS NULL AND intentos > 0),
+				   COUNT(*) FILTER (WHERE descartado_at IS NOT NULL),
+				   MIN(created_at) FILTER (WHERE enviado_at IS NULL AND descartado_at IS NULL)
+			FROM outbox_eventos_cantera
+			WHERE enviado_at IS NULL
+		`,
+		"get_descartados_recientes": `
+			SELECT id, tipo, clave, payload, intentos, proximo_intento_at, ultimo_error,
+				   enviado_at, descartado_at, created_at
+			FROM outbox_eventos_cantera
+			WHERE descartado_at IS NOT NULL
+			ORDER BY descartado_at DESC, id DESC
+			LIMIT 10
+		`,
+		"reintentar_descartados": `
+			UPDATE outbox_eventos_cantera
+			SET descartado_at = NULL, intentos = 0, proximo_intento_at = NOW()
+			WHERE descartado_at IS NOT NULL
+		`,
+		"purgar_enviados": `
+			DELETE FROM outbox_eventos_cantera
+			WHERE enviado_at IS NOT NULL AND enviado_at < $1
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// TomarPendientes reserva los eventos pendientes cuyo próximo intento venció (los más antiguos primero)
+func (r *outboxRepository) TomarPendientes(ctx context.Context, limite int, lease time.Duration) ([]*models.EventoOutbox, error) {
+	rows, err := r.stmts["tomar_pendientes"].QueryContext(ctx, limite, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to take eventos outbox: %w", err)
+	}
+	defer rows.Close()
+
+	eventos := []*models.EventoOutbox{}
+	for rows.Next() {
+		var evento models.EventoOutbox
+		var payload []byte
+		err := rows.Scan(&evento.ID, &evento.Tipo, &evento.Clave, &payload, &evento.Intentos,
+			&evento.ProximoIntentoAt, &evento.UltimoError, &evento.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan evento outbox: %w", err)
+		}
+		evento.Payload = payload
+		eventos = append(eventos, &evento)
+	}
+
+	// UPDATE ... RETURNING no garantiza el orden de la subconsulta
+	sort.Slice(eventos, func(i, j int) bool { return eventos[i].ID < eventos[j].ID })
+
+	return eventos, nil
+}
+
+// MarcarEnviado marca el evento como entregado
+func (r *outboxRepository) MarcarEnviado(ctx context.Context, id int64) error {
+	if _, err := r.stmts["marcar_enviado"].ExecContext(ctx, id); err != nil {
+		return fmt.Errorf("failed to mark evento outbox enviado: %w", err)
+	}
+	return nil
+}
+
+// MarcarFallido registra un intento fallido y programa el siguiente (nil: descarta el evento)
+func (r *outboxRepository) MarcarFallido(ctx context.Context, id int64, ultimoError string, proximoIntento *time.Time) error {
+	if _, err := r.stmts["marcar_fallido"].ExecContext(ctx, id, ultimoError, proximoIntento); err != nil {
+		return fmt.Errorf("failed to mark evento outbox fallido: %w", err)
+	}
+	return nil
+}
+
+// GetResumen obtiene los contadores de la outbox y los últimos eventos descartados
+func (r *outboxRepository) GetResumen(ctx context.Context) (*models.ResumenOutbox, error) {
+	resumen := &models.ResumenOutbox{DescartadosRecientes: []*models.EventoOutbox{}}
+	var masAntiguo sql.NullTime
+	err := r.stmts["get_resumen"].QueryRowContext(ctx).Scan(
+		&resumen.Pendientes, &resumen.EnReintento, &resumen.Descartados, &masAntiguo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resumen outbox: %w", err)
+	}
+	if masAntiguo.Valid {
+		resumen.MasAntiguoSegundos = int64(time.Since(masAntiguo.Time).Seconds())
+	}
+
+	rows, err := r.stmts["get_descartados_recientes"].QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get eventos descartados: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var evento models.EventoOutbox
+		var payload []byte
+		err := rows.Scan(&evento.ID, &evento.Tipo, &evento.Clave, &payload, &evento.Intentos,
+			&evento.ProximoIntentoAt, &evento.UltimoError, &evento.EnviadoAt, &evento.DescartadoAt, &evento.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan evento descartado: %w", err)
+		}
+		evento.Payload = payload
+		resumen.DescartadosRecientes = append(resumen.DescartadosRecientes, &evento)
+	}
+
+	return resumen, nil
+}
+
+// ReintentarDescartados reprograma todos los eventos descartados
+func (r *outboxRepository) ReintentarDescartados(ctx context.Context) (int, error) {
+	result, err := r.stmts["reintentar_descartados"].ExecContext(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retry eventos descartados: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rows), nil
+}
+
+// PurgarEnviados elimina los eventos ya entregados antes de la fecha
+func (r *outboxRepository) PurgarEnviados(ctx context.Context, antesDe time.Time) (int, error) {
+	result, err := r.stmts["purgar_enviados"].ExecContext(ctx, antesDe)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge eventos enviados: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rows), nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// PackRepository define la interfaz para consultar el catálogo de packs (pack_listados)
+type PackRepository interface {
+	// ListPacks página de packs ordenada por código, con sus componentes
+	ListPacks(ctx context.Context, limit, offset int) ([]*models.PackCatalogo, error)
+	CountPacks(ctx context.Context) (int, error)
+	// GetPacksPorArticulo packs que incluyen el artículo (por código o código de barras)
+	GetPacksPorArticulo(ctx context.Context, codigoArticulo string) ([]*models.PackCatalogo, error)
+}
+
+// packRepository implementa PackRepository
+type packRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewPackRepository crea una nueva instancia del repository
+func NewPackRepository(db *sql.DB) (PackRepository, error) {
+	repo := &packRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// packColumns columnas de un componente de pack con el precio vigente del pack y del artículo
+// Un pack tiene una fila por componente en pack_listados: las filas vienen ordenadas por pack
+const packColumns = `
+	pl.codigo_pack,
+	pl.nombre_pack,
+	COALESCE(pl.cod_barra_pack, ''),
+	COALESCE(pl.precio_base, 0),
+	lp.precio_detalle,
+	COALESCE(pl.codigo_articulo, ''),
+	COALESCE(pl.nombre_articulo, ''),
+	COALESCE(pl.cod_barra_articulo, ''),
+	COALESCE(pl.cantidad_articulo, 0),
+	COALESCE(lpa.precio_detalle, p.precio, 0)
+`
+
+// packJoins precios de lista del pack y del artículo, y precio del maestro como respaldo
+const packJoins = `
+	LEFT JOIN lista_precios_cantera lp ON lp.codigo_tivendo = pl.codigo_pack
+	LEFT JOIN lista_precios_cantera lpa ON lpa.codigo_tivendo = pl.codigo_articulo
+	LEFT JOIN productos p ON p.codigo = pl.codigo_articulo
+`
+
+// prepareStatements prepara todas las consultas SQL
+func (r *packRepository) prepareStatements() error {
+	statements := map[string]string{
+		"list_packs": `
+			WITH pagina AS (
+				SELECT codigo_pack
+				FROM pack_listados
+				GROUP BY codigo_pack
+				ORDER BY codigo_pack
+				LIMIT $1 OFFSET $2
+			)
+			SELECT ` + packColumns + `
+			FROM pack_listados pl
+			JOIN pagina pg ON pg.codigo_pack = pl.codigo_pack
+			` + packJoins + `
+			ORDER BY pl.codigo_pack, pl.codigo_articulo
+		`,
+		"count_packs": `
+			SELECT COUNT(DISTINCT codigo_pack) FROM pack_listados
+		`,
+		"get_packs_por_articulo": `
+			SELECT ` + packColumns + `
+			FROM pack_listados pl
+			` + packJoins + `
+			WHERE pl.codigo_pack IN (
+				SELECT codigo_pack FROM pack_listados
+				WHERE codigo_articulo = $1 OR cod_barra_articulo = $1
+			)
+			ORDER BY pl.codigo_pack, pl.codigo_articulo
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// ListPacks página de packs ordenada por código, con sus componentes
+func (r *packRepository) ListPacks(ctx context.Context, limit, offset int) ([]*models.PackCatalogo, error) {
+	rows, err := r.stmts["list_packs"].QueryContext(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPacks(rows)
+}
+
+// CountPacks cantidad de packs distintos del catálogo
+func (r *packRepository) CountPacks(ctx context.Context) (int, error) {
+	var total int
+	if err := r.stmts["count_packs"].QueryRowContext(ctx).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count packs: %w", err)
+	}
+	return total, nil
+}
+
+// GetPacksPorArticulo packs que incluyen el artículo (por código o código de barras)
+func (r *packRepository) GetPacksPorArticulo(ctx context.Context, codigoArticulo string) ([]*models.PackCatalogo, error) {
+	rows, err := r.stmts["get_packs_por_articulo"].QueryContext(ctx, codigoArticulo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get packs por articulo: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPacks(rows)
+}
+
+// scanPacks agrupa las filas (una por componente, ordenadas por pack) en packs
+func scanPacks(rows *sql.Rows) ([]*models.PackCatalogo, error) {
+	packs := []*models.PackCatalogo{}
+	var actual *models.PackCatalogo
+	for rows.Next() {
+		var (
+			pack       models.PackCatalogo
+			componente models.ComponentePack
+		)
+		err := rows.Scan(
+			&pack.CodigoPack, &pack.NombrePack, &pack.CodBarraPack, &pack.PrecioBase, &pack.ListaPrecioDetalle,
+			&componente.CodigoArticulo, &componente.NombreArticulo, &componente.CodBarraArticulo,
+			&componente.Cantidad, &componente.PrecioUnitario,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pack: %w", err)
+		}
+
+		if actual == nil || actual.CodigoPack != pack.CodigoPack {
+			pack.Precio = pack.PrecioBase
+			if pack.ListaPrecioDetalle != nil {
+				pack.Precio = *pack.ListaPrecioDetalle
+			}
+			pack.Componentes = []models.ComponentePack{}
+			actual = &pack
+			packs = append(packs, actual)
+		}
+		actual.AgregarComponente(componente)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate packs: %w", err)
+	}
+
+	return packs, nil
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// PickingRepository define la interfaz para las preparaciones de picking
+type PickingRepository interface {
+	CreatePicking(ctx context.Context, picking *models.Picking) error
+	GetPickingByID(ctx context.Context, id int) (*models.Picking, error)
+	CambiarEstado(ctx context.Context, id int, desde, hasta string) (bool, error)
+	ConfirmarPicking(ctx context.Context, id int, pickeados map[string]int) error
+	ExpirarPickings(ctx context.Context) (int64, error)
+}
+
+// pickingRepository implementa PickingRepository
+type pickingRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewPickingRepository crea una nueva instancia del repository
+func NewPickingRepository(db *sql.DB) (PickingRepository, error) {
+	repo := &pickingRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *pickingRepository) prepareStatements() error {
+	statements := map[string]string{
+		"create_picking": `
+			INSERT INTO pickings_cantera
+			(id_local, id_local_destino, estado, motivo, observaciones, id_usuario, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at, updated_at
+		`,
+		"create_picking_item": `
+			INSERT INTO picking_items_cantera
+			(id_picking, codigo_producto, tipo_item, cantidad_solicitada)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id
+		`,
+		"get_picking": `
+			SELECT id, id_local, id_local_destino, estado, motivo, observaciones, id_usuario,
+				   expires_at, confirmed_at, created_at, updated_at
+			FROM pickings_cantera
+			WHERE id = $1
+		`,
+		"get_picking_items": `
+			SELECT id, id_picking, codigo_producto, tipo_item, cantidad_solicitada, cantidad_pickeada
+			FROM picking_items_cantera
+			WHERE id_picking = $1
+			ORDER BY id
+		`,
+		"cambiar_estado": `
+			UPDATE pickings_cantera
+			SET estado = $3, updated_at = NOW()
+			WHERE id = $1 AND estado = $2
+			  AND ($2 <> 'preparado' OR expires_at > NOW())
+		`,
+		"set_cantidad_pickeada": `
+			UPDATE picking_items_cantera
+			SET cantidad_pickeada = $3
+			WHERE id_picking = $1 AND codigo_producto = $2
+		`,
+		"confirmar_picking": `
+			UPDATE pickings_cantera
+			SET estado = 'confirmado', confirmed_at = NOW(), updated_at = NOW()
+			WHERE id = $1
+		`,
+		"expirar_pickings": `
+			UPDATE pickings_cantera
+			SET estado = 'expirado', updated_at = NOW()
+			WHERE estado = 'preparado' AND expires_at <= NOW()
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// CreatePicking crea la preparación con sus ítems en una sola transacción
+func (r *pickingRepository) CreatePicking(ctx context.Context, picking *models.Picking) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.StmtContext(ctx, r.stmts["create_picking"]).QueryRowContext(ctx,
+		picking.IDLocal, picking.IDLocalDestino, picking.Estado, picking.Motivo,
+		picking.Observaciones, picking.IDUsuario, picking.ExpiresAt,
+	).Scan(&picking.ID, &picking.CreatedAt, &picking.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create picking: %w", err)
+	}
+
+	itemStmt := tx.StmtContext(ctx, r.stmts["create_picking_item"])
+	for _, item := range picking.Items {
+		item.IDPicking = picking.ID
+		err := itemStmt.QueryRowContext(ctx,
+			item.IDPicking, item.CodigoProducto, item.TipoItem, item.CantidadSolicitada,
+		).Scan(&item.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create picking item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetPickingByID obtiene una preparación con sus ítems
+func (r *pickingRepository) GetPickingByID(ctx context.Context, id int) (*models.Picking, error) {
+	var picking models.Picking
+	err := r.stmts["get_picking"].QueryRowContext(ctx, id).Scan(
+		&picking.ID, &picking.IDLocal, &picking.IDLocalDestino, &picking.Estado, &picking.Motivo,
+		&picking.Observaciones, &picking.IDUsuario, &picking.ExpiresAt, &picking.ConfirmedAt,
+		&picking.CreatedAt, &picking.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get picking: %w", err)
+	}
+
+	rows, err := r.stmts["get_picking_items"].QueryContext(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get picking items: %w", err)
+	}
+	defer rows.Close()
+
+	picking.Items = []*models.PickingItem{}
+	for rows.Next() {
+		var item models.PickingItem
+		err := rows.Scan(
+			&item.ID, &item.IDPicking, &item.CodigoProducto, &item.TipoItem,
+			&item.CantidadSolicitada, &item.CantidadPickeada,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan picking item: %w", err)
+		}
+		picking.Items = append(picking.Items, &item)
+	}
+
+	return &picking, nil
+}
+
+// CambiarEstado transiciona la preparación solo si está en el estado esperado
+// Una preparación vencida no puede salir de 'preparado' (solo expirar)
+func (r *pickingRepository) CambiarEstado(ctx context.Context, id int, desde, hasta string) (bool, error) {
+	result, err := r.stmts["cambiar_estado"].ExecContext(ctx, id, desde, hasta)
+	if err != nil {
+		return false, fmt.Errorf("failed to cambiar estado picking: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ConfirmarPicking registra las cantidades pickeadas y marca la preparación como confirmada
+func (r *pickingRepository) ConfirmarPicking(ctx context.Context, id int, pickeados map[string]int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	itemStmt := tx.StmtContext(ctx, r.stmts["set_cantidad_pickeada"])
+	for codigo, cantidad := range pickeados {
+		if _, err := itemStmt.ExecContext(ctx, id, codigo, cantidad); err != nil {
+			return fmt.Errorf("failed to set cantidad pickeada: %w", err)
+		}
+	}
+
+	if _, err := tx.StmtContext(ctx, r.stmts["confirmar_picking"]).ExecContext(ctx, id); err != nil {
+		return fmt.Errorf("failed to confirmar picking: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ExpirarPickings marca como expiradas las preparaciones vencidas, liberando su reserva
+func (r *pickingRepository) ExpirarPickings(ctx context.Context) (int64, error) {
+	result, err := r.stmts["expirar_pickings"].ExecContext(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expirar pickings: %w", err)
+	}
+
+	return result.RowsAffected()
+}
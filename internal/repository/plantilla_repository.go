@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// PlantillaRepository define la interfaz para las plantillas de entrada recurrente
+type PlantillaRepository interface {
+	CreatePlantilla(ctx context.Context, plantilla *models.PlantillaEntrada) error
+	UpdatePlantilla(ctx context.Context, plantilla *models.PlantillaEntrada) (bool, error)
+	DeletePlantilla(ctx context.Context, id int) (bool, error)
+	GetPlantillaByID(ctx context.Context, id int) (*models.PlantillaEntrada, error)
+	GetPlantillas(ctx context.Context, idLocal *int) ([]*models.PlantillaEntrada, error)
+}
+
+// plantillaRepository implementa PlantillaRepository
+type plantillaRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewPlantillaRepository crea una nueva instancia del repository
+func NewPlantillaRepository(db *sql.DB) (PlantillaRepository, error) {
+	repo := &plantillaRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *plantillaRepository) prepareStatements() error {
+	statements := map[string]string{
+		"create_plantilla": `
+			INSERT INTO plantillas_entrada_cantera
+			(nombre, proveedor, id_local, motivo, observaciones, id_usuario)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, created_at, updated_at
+		`,
+		"update_plantilla": `
+			UPDATE plantillas_entrada_cantera
+			SET nombre = $2, proveedor = $3, id_local = $4, motivo = $5, observaciones = $6,
+				id_usuario = $7, updated_at = NOW()
+			WHERE id = $1
+			RETURNING created_at, updated_at
+		`,
+		"delete_plantilla": `
+			DELETE FROM plantillas_entrada_cantera
+			WHERE id = $1
+		`,
+		"create_plantilla_item": `
+			INSERT INTO plantilla_entrada_items_cantera
+			(id_plantilla, codigo_producto, tipo_item, cantidad, cantidad_minima, unidad)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`,
+		"delete_plantilla_items": `
+			DELETE FROM plantilla_entrada_items_cantera
+			WHERE id_plantilla = $1
+		`,
+		"get_plantilla": `
+			SELECT id, nombre, proveedor, id_local, motivo, observaciones, id_usuario, created_at, updated_at
+			FROM plantillas_entrada_cantera
+			WHERE id = $1
+		`,
+		"get_plantilla_items": `
+			SELECT id, id_plantilla, codigo_producto, tipo_item, cantidad, cantidad_minima, unidad
+			FROM plantilla_entrada_items_cantera
+			WHERE id_plantilla = $1
+			ORDER BY id
+		`,
+		"get_plantillas": `
+			SELECT p.id, p.nombre, p.proveedor, p.id_local, p.motivo, p.observaciones, p.id_usuario,
+				   p.created_at, p.updated_at, COUNT(i.id)
+			FROM plantillas_entrada_cantera p
+			LEFT JOIN plantilla_entrada_items_cantera i ON i.id_plantilla = p.id
+			WHERE ($1::int IS NULL OR p.id_local = $1)
+			GROUP BY p.id
+			ORDER BY p.id_local, p.nombre
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// CreatePlantilla crea la plantilla con sus ítems en una transacción
+func (r *plantillaRepository) CreatePlantilla(ctx context.Context, plantilla *models.PlantillaEntrada) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.StmtContext(ctx, r.stmts["create_plantilla"]).QueryRowContext(ctx,
+		plantilla.Nombre, plantilla.Proveedor, plantilla.IDLocal, plantilla.Motivo,
+		plantilla.Observaciones, plantilla.IDUsuario,
+	).Scan(&plantilla.ID, &plantilla.CreatedAt, &plantilla.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create plantilla entrada: %w", err)
+	}
+
+	if err := r.insertItems(ctx, tx, plantilla); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePlantilla reemplaza los datos y los ítems de la plantilla; retorna false si no existe
+func (r *plantillaRepository) UpdatePlantilla(ctx context.Context, plantilla *models.PlantillaEntrada) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.StmtContext(ctx, r.stmts["update_plantilla"]).QueryRowContext(ctx,
+		plantilla.ID, plantilla.Nombre, plantilla.Proveedor, plantilla.IDLocal, plantilla.Motivo,
+		plantilla.Observaciones, plantilla.IDUsuario,
+	).Scan(&plantilla.CreatedAt, &plantilla.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update plantilla entrada: %w", err)
+	}
+
+	if _, err := tx.StmtContext(ctx, r.stmts["delete_plantilla_items"]).ExecContext(ctx, plantilla.ID); err != nil {
+		return false, fmt.Errorf("failed to delete plantilla entrada items: %w", err)
+	}
+
+	if err := r.insertItems(ctx, tx, plantilla); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// insertItems inserta los ítems de la plantilla dentro de la transacción
+func (r *plantillaRepository) insertItems(ctx context.Context, tx *sql.Tx, plantilla *models.PlantillaEntrada) error {
+	itemStmt := tx.StmtContext(ctx, r.stmts["create_plantilla_item"])
+	for _, item := range plantilla.Items {
+		item.IDPlantilla = plantilla.ID
+		err := itemStmt.QueryRowContext(ctx,
+			item.IDPlantilla, item.CodigoProducto, item.TipoItem, item.Cantidad, item.CantidadMinima, item.Unidad,
+		).Scan(&item.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create plantilla entrada item: %w", err)
+		}
+	}
+	plantilla.TotalItems = len(plantilla.Items)
+
+	return nil
+}
+
+// DeletePlantilla elimina la plantilla y sus ítems; retorna false si no existía
+func (r *plantillaRepository) DeletePlantilla(ctx context.Context, id int) (bool, error) {
+	result, err := r.stmts["delete_plantilla"].ExecContext(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete plantilla entrada: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetPlantillaByID obtiene una plantilla con sus ítems
+func (r *plantillaRepository) GetPlantillaByID(ctx context.Context, id int) (*models.PlantillaEntrada, error) {
+	var plantilla models.PlantillaEntrada
+	err := r.stmts["get_plantilla"].QueryRowContext(ctx, id).Scan(
+		&plantilla.ID, &plantilla.Nombre, &plantilla.Proveedor, &plantilla.IDLocal, &plantilla.Motivo,
+		&plantilla.Observaciones, &plantilla.IDUsuario, &plantilla.CreatedAt, &plantilla.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plantilla entrada: %w", err)
+	}
+
+	rows, err := r.stmts["get_plantilla_items"].QueryContext(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plantilla entrada items: %w", err)
+	}
+	defer rows.Close()
+
+	plantilla.Items = []*models.PlantillaEntradaItem{}
+	for rows.Next() {
+		var item models.PlantillaEntradaItem
+		err := rows.Scan(
+			&item.ID, &item.IDPlantilla, &item.CodigoProducto, &item.TipoItem,
+			&item.Cantidad, &item.CantidadMinima, &item.Unidad,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan plantilla entrada item: %w", err)
+		}
+		plantilla.Items = append(plantilla.Items, &item)
+	}
+	plantilla.TotalItems = len(plantilla.Items)
+
+	return &plantilla, nil
+}
+
+// GetPlantillas lista las plantillas (sin ítems), opcionalmente de un local
+func (r *plantillaRepository) GetPlantillas(ctx context.Context, idLocal *int) ([]*models.PlantillaEntrada, error) {
+	rows, err := r.stmts["get_plantillas"].QueryContext(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plantillas entrada: %w", err)
+	}
+	defer rows.Close()
+
+	plantillas := []*models.PlantillaEntrada{}
+	for rows.Next() {
+		var plantilla models.PlantillaEntrada
+		err := rows.Scan(
+			&plantilla.ID, &plantilla.Nombre, &plantilla.Proveedor, &plantilla.IDLocal, &plantilla.Motivo,
+			&plantilla.Observaciones, &plantilla.IDUsuario, &plantilla.CreatedAt, &plantilla.UpdatedAt,
+			&plantilla.TotalItems,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan plantilla entrada: %w", err)
+		}
+		plantillas = append(plantillas, &plantilla)
+	}
+
+	return plantillas, nil
+}
@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// PrecioRepository define la interfaz para el historial de precios, los precios modificados en venta
+// y la bitácora de conciliación de la cache contra lista_precios_cantera
+type PrecioRepository interface {
+	GetHistorial(ctx context.Context, codigo string, limit int) ([]*models.HistorialPrecio, error)
+	GetPreciosVigentes(ctx context.Context, codigo string, desde, hasta time.Time) ([]*models.HistorialPrecio, error)
+	CreateOverrides(ctx context.Context, overrides []*models.OverridePrecio) error
+	GetOverrides(ctx context.Context, filter *models.ReporteFilter) ([]*models.OverridePrecio, error)
+	GetListaPrecios(ctx context.Context, codigos []string) (map[string]*models.PrecioLista, error)
+	CreateConciliacion(ctx context.Context, conciliacion *models.ConciliacionPrecios) error
+	GetConciliaciones(ctx context.Context, limit int) ([]*models.ConciliacionPrecios, error)
+
+	// ConTransaccion repository que registra los precios modificados en la transacción de repo
+	// (ej. los de una venta con su salida de stock)
+	ConTransaccion(repo StockRepository) PrecioRepository
+}
+
+// precioRepository implementa PrecioRepository
+type precioRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+	tx    *sql.Tx // no nil si escribe en la transacción de una operación de stock
+}
+
+// NewPrecioRepository crea una nueva instancia del repository
+func NewPrecioRepository(db *sql.DB) (PrecioRepository, error) {
+	repo := &precioRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *precioRepository) prepareStatements() error {
+	statements := map[string]string{
+		"get_historial": `
+			SELECT id, codigo_tivendo, precio_detalle, precio_mayorista,
+				   vigente_desde, vigente_hasta, operacion
+			FROM historial_precios_cantera
+			WHERE codigo_tivendo = $1
+			ORDER BY vigente_desde DESC
+			LIMIT $2
+		`,
+		"get_precios_vigentes": `
+			SELECT id, codigo_tivendo, precio_detalle, precio_mayorista,
+				   vigente_desde, vigente_hasta, operacion
+			FROM historial_precios_cantera
+			WHERE codigo_tivendo = $1
+			  AND vigente_desde <= $3
+			  AND (vigente_hasta IS NULL OR vigente_hasta > $2)
+			ORDER BY vigente_desde
+		`,
+		"create_override": `
+			INSERT INTO overrides_precio_cantera
+			(id_local, codigo_producto, tipo_item, cantidad, precio_original, precio_aplicado,
+			 motivo, id_usuario, id_autorizador)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id, created_at
+		`,
+		"get_overrides": `
+			SELECT id, id_local, codigo_producto, tipo_item, cantidad, precio_original, precio_aplicado,
+				   motivo, id_usuario, id_autorizador, created_at
+			FROM overrides_precio_cantera
+			WHERE created_at >= $1 AND created_at < $2
+			  AND ($3::int IS NULL OR id_local = $3)
+			  AND ($4::int IS NULL OR id_usuario = $4)
+			ORDER BY created_at
+		`,
+		"get_lista_precios": `
+			SELECT codigo_tivendo, precio_detalle, precio_mayorista, updated_at
+			FROM lista_precios_cantera
+			WHERE codigo_tivendo = ANY($1)
+		`,
+		"create_conciliacion": `
+			INSERT INTO conciliaciones_precio_cantera (muestra, revisados, total_divergencias, divergencias)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at
+		`,
+		"get_conciliaciones": `
+			SELECT id, muestra, revisados, total_divergencias, divergencias, created_at
+			FROM conciliaciones_precio_cantera
+			ORDER BY created_at DESC
+			LIMIT $1
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// GetHistorial obtiene los cambios de precio de un producto, del más reciente al más antiguo
+func (r *precioRepository) GetHistorial(ctx context.Context, codigo string, limit int) ([]*models.HistorialPrecio, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := r.stmts["get_historial"].QueryContext(ctx, codigo, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get historial precios: %w", err)
+	}
+	defer rows.Close()
+
+	return scanHistorialPrecios(rows)
+}
+
+// GetPreciosVigentes obtiene los precios que estuvieron vigentes en algún momento del rango
+func (r *precioRepository) GetPreciosVigentes(ctx context.Context, codigo string, desde, hasta time.Time) ([]*models.HistorialPrecio, error) {
+	rows, err := r.stmts["get_precios_vigentes"].QueryContext(ctx, codigo, desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get precios vigentes: %w", err)
+	}
+	defer rows.Close()
+
+	return scanHistorialPrecios(rows)
+}
+
+// ConTransaccion liga las escrituras a la transacción del repository de stock
+func (r *precioRepository) ConTransaccion(repo StockRepository) PrecioRepository {
+	stock, ok := repo.(*stockRepository)
+	if !ok || stock.tx == nil {
+		return r
+	}
+	return &precioRepository{db: r.db, stmts: r.stmts, tx: stock.tx}
+}
+
+// CreateOverrides registra los precios modificados de una venta en una transacción
+// (la de la operación de stock si el repository está ligado a una)
+func (r *precioRepository) CreateOverrides(ctx context.Context, overrides []*models.OverridePrecio) error {
+	tx := r.tx
+	if tx == nil {
+		var err error
+		if tx, err = r.db.BeginTx(ctx, nil); err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+	}
+
+	stmt := tx.StmtContext(ctx, r.stmts["create_override"])
+	for _, override := range overrides {
+		err := stmt.QueryRowContext(ctx,
+			override.IDLocal, override.CodigoProducto, override.TipoItem, override.Cantidad,
+			override.PrecioOriginal, override.PrecioAplicado, override.Motivo,
+			override.IDUsuario, override.IDAutorizador,
+		).Scan(&override.ID, &override.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create override precio: %w", err)
+		}
+	}
+
+	if r.tx != nil {
+		return nil
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetOverrides obtiene los precios modificados del período
+func (r *precioRepository) GetOverrides(ctx context.Context, filter *models.ReporteFilter) ([]*models.OverridePrecio, error) {
+	rows, err := r.stmts["get_overrides"].QueryContext(ctx, filter.Desde, filter.Hasta, filter.IDLocal, filter.IDUsuario)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overrides precio: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := []*models.OverridePrecio{}
+	for rows.Next() {
+		var override models.OverridePrecio
+		err := rows.Scan(
+			&override.ID, &override.IDLocal, &override.CodigoProducto, &override.TipoItem, &override.Cantidad,
+			&override.PrecioOriginal, &override.PrecioAplicado, &override.Motivo,
+			&override.IDUsuario, &override.IDAutorizador, &override.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan override precio: %w", err)
+		}
+		overrides = append(overrides, &override)
+	}
+
+	return overrides, nil
+}
+
+// GetListaPrecios obtiene las filas vigentes de lista_precios_cantera de los códigos, por código
+func (r *precioRepository) GetListaPrecios(ctx context.Context, codigos []string) (map[string]*models.PrecioLista, error) {
+	precios := make(map[string]*models.PrecioLista, len(codigos))
+	if len(codigos) == 0 {
+		return precios, nil
+	}
+
+	rows, err := r.stmts["get_lista_precios"].QueryContext(ctx, pq.Array(codigos))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lista precios: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var precio models.PrecioLista
+		if err := rows.Scan(&precio.CodigoTivendo, &precio.PrecioDetalle, &precio.PrecioMayorista, &precio.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan lista precio: %w", err)
+		}
+		precios[precio.CodigoTivendo] = &precio
+	}
+
+	return precios, nil
+}
+
+// CreateConciliacion registra una ejecución de la conciliación en la bitácora
+func (r *precioRepository) CreateConciliacion(ctx context.Context, conciliacion *models.ConciliacionPrecios) error {
+	divergencias, err := json.Marshal(conciliacion.Divergencias)
+	if err != nil {
+		return fmt.Errorf("failed to marshal divergencias: %w", err)
+	}
+
+	err = r.stmts["create_conciliacion"].QueryRowContext(ctx,
+		conciliacion.Muestra, conciliacion.Revisados, conciliacion.TotalDivergencias, string(divergencias),
+	).Scan(&conciliacion.ID, &conciliacion.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create conciliacion precios: %w", err)
+	}
+
+	return nil
+}
+
+// GetConciliaciones obtiene las últimas ejecuciones de la conciliación, de la más reciente a la más antigua
+func (r *precioRepository) GetConciliaciones(ctx context.Context, limit int) ([]*models.ConciliacionPrecios, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	rows, err := r.stmts["get_conciliaciones"].QueryContext(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conciliaciones precios: %w", err)
+	}
+	defer rows.Close()
+
+	conciliaciones := []*models.ConciliacionPrecios{}
+	for rows.Next() {
+		var conciliacion models.ConciliacionPrecios
+		var divergencias []byte
+		err := rows.Scan(
+			&conciliacion.ID, &conciliacion.Muestra, &conciliacion.Revisados,
+			&conciliacion.TotalDivergencias, &divergencias, &conciliacion.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conciliacion precios: %w", err)
+		}
+		if err := json.Unmarshal(divergencias, &conciliacion.Divergencias); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal divergencias: %w", err)
+		}
+		conciliaciones = append(conciliaciones, &conciliacion)
+	}
+
+	return conciliaciones, nil
+}
+
+// scanHistorialPrecios escanea filas de historial_precios_cantera
+func scanHistorialPrecios(rows *sql.Rows) ([]*models.HistorialPrecio, error) {
+	historial := []*models.HistorialPrecio{}
+	for rows.Next() {
+		var precio models.HistorialPrecio
+		err := rows.Scan(
+			&precio.ID, &precio.CodigoTivendo, &precio.PrecioDetalle, &precio.PrecioMayorista,
+			&precio.VigenteDesde, &precio.VigenteHasta, &precio.Operacion,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan historial precio: %w", err)
+		}
+		historial = append(historial, &precio)
+	}
+
+	return historial, nil
+}
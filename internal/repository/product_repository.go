@@ -8,6 +8,7 @@ import (
 
 	"stock-service/internal/models"
 
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -17,6 +18,10 @@ type ProductRepository interface {
 	GetProductosFrecuentes(ctx context.Context, limit int) ([]*models.ProductoCompleto, error)
 	UpdateProducto(ctx context.Context, producto *models.ProductoCompleto) error
 	GetLastListaPreciosTimestamp(ctx context.Context) (*time.Time, error)
+	ListCodigosBarras(ctx context.Context) ([]string, error)
+	// GetCatalogoModificadoDesde productos y packs cuya lista de precios cambió después de desde
+	// (el catálogo completo si desde es nil)
+	GetCatalogoModificadoDesde(ctx context.Context, desde *time.Time) ([]*models.ProductoCompleto, error)
 }
 
 // productRepository implementación del repository
@@ -41,6 +46,13 @@ func NewProductRepository(db *sql.DB, logger *zap.Logger) (ProductRepository, er
 	return repo, nil
 }
 
+// bloqueoVigenteQuery bloqueo de venta vigente del producto (se completa con el filtro por código)
+// Con varios vigentes se toma el que dura más
+const bloqueoVigenteQuery = `
+			SELECT b.motivo, b.hasta
+			FROM bloqueos_producto_cantera b
+			WHERE b.levantado_at IS NULL AND (b.hasta IS NULL OR b.hasta > NOW())`
+
 // prepareStatements prepara todas las queries SQL
 func (r *productRepository) prepareStatements() error {
 	// Query para buscar producto por código de barras
@@ -74,6 +86,11 @@ func (r *productRepository) prepareStatements() error {
 			lp.precio_detalle AS lista_precio_detalle,
 			lp.precio_mayorista AS lista_precio_mayorista,
 			lp.updated_at AS lista_updated_at,
+			img.url AS imagen_url,
+			img.url_miniatura AS imagen_miniatura_url,
+			cp.canales AS canales,
+			bq.motivo AS bloqueo_motivo,
+			bq.hasta AS bloqueo_hasta,
 			ARRAY_AGG(
 				CASE 
 					WHEN cvc.fecha_vencimiento IS NOT NULL 
@@ -86,6 +103,13 @@ func (r *productRepository) prepareStatements() error {
 			) FILTER (WHERE cvc.fecha_vencimiento IS NOT NULL) AS fechas_vencimiento
 		FROM productos p
 		LEFT JOIN lista_precios_cantera lp ON p.codigo = lp.codigo_tivendo
+		LEFT JOIN imagenes_productos_cantera img ON img.codigo_producto = p.codigo
+		LEFT JOIN canales_producto_cantera cp ON cp.codigo_producto = p.codigo
+		LEFT JOIN LATERAL (` + bloqueoVigenteQuery + `
+			AND b.codigo_producto = p.codigo
+			ORDER BY b.hasta DESC NULLS FIRST
+			LIMIT 1
+		) bq ON TRUE
 		LEFT JOIN control_vencimientos_cantera cvc ON p.codigo_barra_interno = cvc.codigo_barras
 		WHERE p.codigo_barra_externo = $1 OR p.codigo_barra_interno = $1
 		GROUP BY 
@@ -93,7 +117,8 @@ func (r *productRepository) prepareStatements() error {
 			p.codigo_barra_externo, p.descripcion, p.es_servicio, p.es_exento,
 			p.impuesto_especifico, p.id_categoria, p.disponible_para_venta,
 			p.activo, p.utilidad, p.tipo_utilidad,
-			lp.precio_detalle, lp.precio_mayorista, lp.updated_at
+			lp.precio_detalle, lp.precio_mayorista, lp.updated_at,
+			img.url, img.url_miniatura, cp.canales, bq.motivo, bq.hasta
 		LIMIT 1;
 	`
 
@@ -128,6 +153,11 @@ func (r *productRepository) prepareStatements() error {
 			lp.precio_detalle AS lista_precio_detalle,
 			lp.precio_mayorista AS lista_precio_mayorista,
 			lp.updated_at AS lista_updated_at,
+			img.url AS imagen_url,
+			img.url_miniatura AS imagen_miniatura_url,
+			cp.canales AS canales,
+			bq.motivo AS bloqueo_motivo,
+			bq.hasta AS bloqueo_hasta,
 			ARRAY_AGG(
 				CASE 
 					WHEN cvc.fecha_vencimiento IS NOT NULL 
@@ -140,13 +170,23 @@ func (r *productRepository) prepareStatements() error {
 			) FILTER (WHERE cvc.fecha_vencimiento IS NOT NULL) AS fechas_vencimiento
 		FROM pack_listados pl
 		LEFT JOIN lista_precios_cantera lp ON pl.codigo_pack = lp.codigo_tivendo
+		LEFT JOIN imagenes_productos_cantera img ON img.codigo_producto = pl.codigo_pack
+		LEFT JOIN canales_producto_cantera cp ON cp.codigo_producto = pl.codigo_pack
+		LEFT JOIN LATERAL (` + bloqueoVigenteQuery + `
+			AND (b.codigo_producto = pl.codigo_pack OR b.codigo_producto IN (
+				SELECT c.codigo_articulo FROM pack_listados c WHERE c.codigo_pack = pl.codigo_pack
+			))
+			ORDER BY b.hasta DESC NULLS FIRST
+			LIMIT 1
+		) bq ON TRUE
 		LEFT JOIN control_vencimientos_cantera cvc ON pl.cod_barra_pack = cvc.codigo_barras
 		WHERE pl.cod_barra_pack = $1 OR pl.codigo_pack = $1
 		GROUP BY 
 			pl.codigo_pack, pl.nombre_pack, pl.precio_base, pl.cantidad_articulo,
 			pl.codigo_articulo, pl.cod_barra_articulo, pl.nombre_articulo,
 			pl.cod_barra_pack,
-			lp.precio_detalle, lp.precio_mayorista, lp.updated_at
+			lp.precio_detalle, lp.precio_mayorista, lp.updated_at,
+			img.url, img.url_miniatura, cp.canales, bq.motivo, bq.hasta
 		LIMIT 1;
 	`
 
@@ -181,6 +221,11 @@ func (r *productRepository) prepareStatements() error {
 			lp.precio_detalle AS lista_precio_detalle,
 			lp.precio_mayorista AS lista_precio_mayorista,
 			lp.updated_at AS lista_updated_at,
+			img.url AS imagen_url,
+			img.url_miniatura AS imagen_miniatura_url,
+			cp.canales AS canales,
+			bq.motivo AS bloqueo_motivo,
+			bq.hasta AS bloqueo_hasta,
 			ARRAY_AGG(
 				CASE 
 					WHEN cvc.fecha_vencimiento IS NOT NULL 
@@ -193,6 +238,13 @@ func (r *productRepository) prepareStatements() error {
 			) FILTER (WHERE cvc.fecha_vencimiento IS NOT NULL) AS fechas_vencimiento
 		FROM productos p
 		LEFT JOIN lista_precios_cantera lp ON p.codigo = lp.codigo_tivendo
+		LEFT JOIN imagenes_productos_cantera img ON img.codigo_producto = p.codigo
+		LEFT JOIN canales_producto_cantera cp ON cp.codigo_producto = p.codigo
+		LEFT JOIN LATERAL (` + bloqueoVigenteQuery + `
+			AND b.codigo_producto = p.codigo
+			ORDER BY b.hasta DESC NULLS FIRST
+			LIMIT 1
+		) bq ON TRUE
 		LEFT JOIN control_vencimientos_cantera cvc ON p.codigo_barra_interno = cvc.codigo_barras
 		WHERE p.activo = true AND p.disponible_para_venta = true
 		GROUP BY 
@@ -200,7 +252,8 @@ func (r *productRepository) prepareStatements() error {
 			p.codigo_barra_externo, p.descripcion, p.es_servicio, p.es_exento,
 			p.impuesto_especifico, p.id_categoria, p.disponible_para_venta,
 			p.activo, p.utilidad, p.tipo_utilidad,
-			lp.precio_detalle, lp.precio_mayorista, lp.updated_at
+			lp.precio_detalle, lp.precio_mayorista, lp.updated_at,
+			img.url, img.url_miniatura, cp.canales, bq.motivo, bq.hasta
 		ORDER BY p.nombre
 		LIMIT $1;
 	`
@@ -212,12 +265,151 @@ func (r *productRepository) prepareStatements() error {
 		WHERE updated_at IS NOT NULL;
 	`
 
+	// Query con todos los códigos por los que GetProductoByBarcode encuentra algo
+	// (carga del filtro de existencia rápida)
+	queryCodigosBarras := `
+		SELECT codigo FROM (
+			SELECT codigo_barra_externo AS codigo FROM productos
+			UNION
+			SELECT codigo_barra_interno FROM productos
+			UNION
+			SELECT cod_barra_pack FROM pack_listados
+			UNION
+			SELECT codigo_pack FROM pack_listados
+		) c
+		WHERE codigo IS NOT NULL AND codigo <> '';
+	`
+
+	// Catálogo modificado desde una fecha (sincronización de réplicas edge)
+	// productos y lista_precios no guardan otra marca de cambio que lista_precios_cantera.updated_at
+	queryProductosModificados := `
+		SELECT
+			p.id,
+			p.codigo,
+			p.nombre,
+			p.unidad,
+			p.precio,
+			p.codigo_barra_interno,
+			p.codigo_barra_externo,
+			p.descripcion,
+			p.es_servicio,
+			p.es_exento,
+			p.impuesto_especifico,
+			p.id_categoria,
+			p.disponible_para_venta,
+			p.activo,
+			p.utilidad,
+			p.tipo_utilidad,
+			'producto' AS origen,
+			p.codigo AS codigo_final,
+			NULL AS codigo_pack,
+			NULL AS nombre_pack,
+			NULL AS precio_base,
+			NULL AS cantidad_articulo,
+			NULL AS codigo_articulo,
+			NULL AS cod_barra_articulo,
+			NULL AS nombre_articulo,
+			lp.precio_detalle AS lista_precio_detalle,
+			lp.precio_mayorista AS lista_precio_mayorista,
+			lp.updated_at AS lista_updated_at,
+			img.url AS imagen_url,
+			img.url_miniatura AS imagen_miniatura_url,
+			cp.canales AS canales,
+			bq.motivo AS bloqueo_motivo,
+			bq.hasta AS bloqueo_hasta,
+			ARRAY_AGG(
+				CASE 
+					WHEN cvc.fecha_vencimiento IS NOT NULL 
+					THEN json_build_object(
+						'fecha_vencimiento', cvc.fecha_vencimiento,
+						'cantidad', cvc.cantidad,
+						'lote', cvc.lote
+					)
+				END
+			) FILTER (WHERE cvc.fecha_vencimiento IS NOT NULL) AS fechas_vencimiento
+		FROM productos p
+		LEFT JOIN lista_precios_cantera lp ON p.codigo = lp.codigo_tivendo
+		LEFT JOIN imagenes_productos_cantera img ON img.codigo_producto = p.codigo
+		LEFT JOIN canales_producto_cantera cp ON cp.codigo_producto = p.codigo
+		LEFT JOIN LATERAL (` + bloqueoVigenteQuery + `
+			AND b.codigo_producto = p.codigo
+			ORDER BY b.hasta DESC NULLS FIRST
+			LIMIT 1
+		) bq ON TRUE
+		LEFT JOIN control_vencimientos_cantera cvc ON p.codigo_barra_interno = cvc.codigo_barras
+		WHERE $1::timestamp IS NULL OR lp.updated_at > $1
+		GROUP BY 
+			p.id, p.codigo, p.nombre, p.unidad, p.precio, p.codigo_barra_interno,
+			p.codigo_barra_externo, p.descripcion, p.es_servicio, p.es_exento,
+			p.impuesto_especifico, p.id_categoria, p.disponible_para_venta,
+			p.activo, p.utilidad, p.tipo_utilidad,
+			lp.precio_detalle, lp.precio_mayorista, lp.updated_at,
+			img.url, img.url_miniatura, cp.canales, bq.motivo, bq.hasta
+		ORDER BY p.codigo;
+	`
+
+	// Un pack tiene una fila por componente en pack_listados: se toma una por pack,
+	// como en la búsqueda por código de barras
+	queryPacksModificados := `
+		SELECT DISTINCT ON (pl.codigo_pack)
+			NULL AS id,
+			pl.codigo_pack AS codigo,
+			pl.nombre_pack AS nombre,
+			NULL AS unidad,
+			pl.precio_base AS precio,
+			pl.cod_barra_pack AS codigo_barra_interno,
+			pl.cod_barra_pack AS codigo_barra_externo,
+			NULL AS descripcion,
+			false AS es_servicio,
+			false AS es_exento,
+			NULL AS impuesto_especifico,
+			NULL AS id_categoria,
+			true AS disponible_para_venta,
+			true AS activo,
+			NULL AS utilidad,
+			NULL AS tipo_utilidad,
+			'pack' AS origen,
+			pl.codigo_pack AS codigo_final,
+			pl.codigo_pack,
+			pl.nombre_pack,
+			pl.precio_base,
+			pl.cantidad_articulo,
+			pl.codigo_articulo,
+			pl.cod_barra_articulo,
+			pl.nombre_articulo,
+			lp.precio_detalle AS lista_precio_detalle,
+			lp.precio_mayorista AS lista_precio_mayorista,
+			lp.updated_at AS lista_updated_at,
+			img.url AS imagen_url,
+			img.url_miniatura AS imagen_miniatura_url,
+			cp.canales AS canales,
+			bq.motivo AS bloqueo_motivo,
+			bq.hasta AS bloqueo_hasta,
+			NULL::json[] AS fechas_vencimiento
+		FROM pack_listados pl
+		LEFT JOIN lista_precios_cantera lp ON pl.codigo_pack = lp.codigo_tivendo
+		LEFT JOIN imagenes_productos_cantera img ON img.codigo_producto = pl.codigo_pack
+		LEFT JOIN canales_producto_cantera cp ON cp.codigo_producto = pl.codigo_pack
+		LEFT JOIN LATERAL (` + bloqueoVigenteQuery + `
+			AND (b.codigo_producto = pl.codigo_pack OR b.codigo_producto IN (
+				SELECT c.codigo_articulo FROM pack_listados c WHERE c.codigo_pack = pl.codigo_pack
+			))
+			ORDER BY b.hasta DESC NULLS FIRST
+			LIMIT 1
+		) bq ON TRUE
+		WHERE $1::timestamp IS NULL OR lp.updated_at > $1
+		ORDER BY pl.codigo_pack;
+	`
+
 	// Preparar statements
 	statements := map[string]string{
 		"get_producto_by_barcode":          queryProducto,
 		"get_pack_by_barcode":              queryPack,
 		"get_productos_frecuentes":         queryFrecuentes,
 		"get_last_lista_precios_timestamp": queryLastTimestamp,
+		"list_codigos_barras":              queryCodigosBarras,
+		"get_productos_modificados":        queryProductosModificados,
+		"get_packs_modificados":            queryPacksModificados,
 	}
 
 	for name, query := range statements {
@@ -232,13 +424,17 @@ func (r *productRepository) prepareStatements() error {
 }
 
 // GetProductoByBarcode busca un producto o pack por código de barras
+// Retorna ErrNotFound si no existe en ninguna de las dos tablas
 func (r *productRepository) GetProductoByBarcode(ctx context.Context, barcode string) (*models.ProductoCompleto, error) {
 	start := time.Now()
 
 	// 1. Buscar en productos
 	row := r.stmts["get_producto_by_barcode"].QueryRowContext(ctx, barcode)
 	producto, err := r.scanProductoCompleto(row)
-	if err == nil && producto != nil {
+	if err != nil {
+		return nil, fmt.Errorf("failed to get producto by barcode: %w", err)
+	}
+	if producto != nil {
 		r.logger.Debug("Producto encontrado en tabla productos",
 			zap.String("codigo_barras", barcode),
 			zap.String("nombre", producto.Nombre),
@@ -249,7 +445,10 @@ func (r *productRepository) GetProductoByBarcode(ctx context.Context, barcode st
 	// 2. Buscar en packs
 	row = r.stmts["get_pack_by_barcode"].QueryRowContext(ctx, barcode)
 	pack, err := r.scanProductoCompleto(row)
-	if err == nil && pack != nil {
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pack by barcode: %w", err)
+	}
+	if pack != nil {
 		r.logger.Debug("Pack encontrado en tabla pack_listados",
 			zap.String("codigo_barras", barcode),
 			zap.String("nombre", pack.Nombre),
@@ -261,7 +460,7 @@ func (r *productRepository) GetProductoByBarcode(ctx context.Context, barcode st
 		zap.String("codigo_barras", barcode),
 		zap.Duration("latency", time.Since(start)))
 
-	return nil, fmt.Errorf("producto no encontrado: %s", barcode)
+	return nil, fmt.Errorf("%w: producto %s", ErrNotFound, barcode)
 }
 
 // GetProductosFrecuentes obtiene productos frecuentes para pre-carga
@@ -310,11 +509,63 @@ func (r *productRepository) GetLastListaPreciosTimestamp(ctx context.Context) (*
 	return &timestamp.Time, nil
 }
 
+// ListCodigosBarras retorna todos los códigos de barras de productos y packs
+func (r *productRepository) ListCodigosBarras(ctx context.Context) ([]string, error) {
+	rows, err := r.stmts["list_codigos_barras"].QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query codigos de barras: %w", err)
+	}
+	defer rows.Close()
+
+	var codigos []string
+	for rows.Next() {
+		var codigo string
+		if err := rows.Scan(&codigo); err != nil {
+			return nil, fmt.Errorf("failed to scan codigo de barras: %w", err)
+		}
+		codigos = append(codigos, codigo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate codigos de barras: %w", err)
+	}
+
+	return codigos, nil
+}
+
+// GetCatalogoModificadoDesde obtiene los productos y packs con precios modificados después de desde
+func (r *productRepository) GetCatalogoModificadoDesde(ctx context.Context, desde *time.Time) ([]*models.ProductoCompleto, error) {
+	catalogo := []*models.ProductoCompleto{}
+	for _, name := range []string{"get_productos_modificados", "get_packs_modificados"} {
+		rows, err := r.stmts[name].QueryContext(ctx, desde)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query catalogo modificado: %w", err)
+		}
+
+		for rows.Next() {
+			producto, err := r.scanProductoCompleto(rows)
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan catalogo modificado: %w", err)
+			}
+			catalogo = append(catalogo, producto)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate catalogo modificado: %w", err)
+		}
+	}
+
+	return catalogo, nil
+}
+
 // scanProductoCompleto escanea una fila de la base de datos
 func (r *productRepository) scanProductoCompleto(row interface{}) (*models.ProductoCompleto, error) {
 	var producto models.ProductoCompleto
 	var fechasVencimientoJSON []byte
 	var listaUpdatedAt sql.NullTime
+	var bloqueoMotivo sql.NullString
+	var bloqueoHasta sql.NullTime
 
 	// Determinar el tipo de row (Row o Rows)
 	switch r := row.(type) {
@@ -348,6 +599,11 @@ func (r *productRepository) scanProductoCompleto(row interface{}) (*models.Produ
 			&producto.ListaPrecioDetalle,
 			&producto.ListaPrecioMayorista,
 			&listaUpdatedAt,
+			&producto.ImagenURL,
+			&producto.ImagenMiniaturaURL,
+			pq.Array(&producto.Canales),
+			&bloqueoMotivo,
+			&bloqueoHasta,
 			&fechasVencimientoJSON,
 		)
 		if err != nil {
@@ -386,6 +642,11 @@ func (r *productRepository) scanProductoCompleto(row interface{}) (*models.Produ
 			&producto.ListaPrecioDetalle,
 			&producto.ListaPrecioMayorista,
 			&listaUpdatedAt,
+			&producto.ImagenURL,
+			&producto.ImagenMiniaturaURL,
+			pq.Array(&producto.Canales),
+			&bloqueoMotivo,
+			&bloqueoHasta,
 			&fechasVencimientoJSON,
 		)
 		if err != nil {
@@ -406,5 +667,12 @@ func (r *productRepository) scanProductoCompleto(row interface{}) (*models.Produ
 		producto.ListaUpdatedAt = &listaUpdatedAt.Time
 	}
 
+	if bloqueoMotivo.Valid {
+		producto.Bloqueo = &models.BloqueoVenta{Motivo: bloqueoMotivo.String}
+		if bloqueoHasta.Valid {
+			producto.Bloqueo.Hasta = &bloqueoHasta.Time
+		}
+	}
+
 	return &producto, nil
 }
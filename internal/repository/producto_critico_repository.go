@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+)
+
+// ProductoCriticoRepository define la interfaz para los productos críticos y su disponibilidad
+type ProductoCriticoRepository interface {
+	// MarcarCritico marca el producto como crítico en el local (o actualiza su SLA)
+	MarcarCritico(ctx context.Context, critico *models.ProductoCritico) error
+	// DesmarcarCritico quita la marca; retorna false si el producto no era crítico en el local
+	DesmarcarCritico(ctx context.Context, codigoProducto string, idLocal int) (bool, error)
+	GetCriticos(ctx context.Context, idLocal *int) ([]*models.ProductoCritico, error)
+	// GetDisponibilidad reconstruye desde los movimientos el tiempo con y sin stock de cada
+	// producto crítico en [desde, hasta); SLAObjetivo queda en 0 si el producto no define uno
+	GetDisponibilidad(ctx context.Context, idLocal *int, desde, hasta time.Time) ([]*models.DisponibilidadCritico, error)
+	// GetQuiebresActivos retorna los productos críticos hoy sin stock, desde cuándo
+	GetQuiebresActivos(ctx context.Context, idLocal *int) ([]*models.QuiebreCritico, error)
+}
+
+// productoCriticoRepository implementa ProductoCriticoRepository
+type productoCriticoRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewProductoCriticoRepository crea una nueva instancia del repository
+func NewProductoCriticoRepository(db *sql.DB) (ProductoCriticoRepository, error) {
+	repo := &productoCriticoRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *productoCriticoRepository) prepareStatements() error {
+	statements := map[string]string{
+		"marcar_critico": `
+			INSERT INTO productos_criticos_cantera (codigo_producto, id_local, sla_objetivo)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (codigo_producto, id_local)
+			DO UPDATE SET sla_objetivo = EXCLUDED.sla_objetivo, updated_at = NOW()
+			RETURNING id, created_at, updated_at
+		`,
+		"desmarcar_critico": `
+			DELETE FROM productos_criticos_cantera
+			WHERE codigo_producto = $1 AND id_local = $2
+		`,
+		"get_criticos": `
+			SELECT c.id, c.codigo_producto, p.nombre, c.id_local, c.sla_objetivo, c.created_at, c.updated_at
+			FROM productos_criticos_cantera c
+			LEFT JOIN productos p ON p.codigo = c.codigo_producto
+			WHERE ($1::int IS NULL OR c.id_local = $1)
+			ORDER BY c.id_local, c.codigo_producto
+		`,
+		// Cada movimiento abre un tramo con su cantidad nueva hasta el siguiente movimiento (o el
+		// fin del período); el primer tramo parte del stock al inicio del período: el último
+		// movimiento anterior, si no el stock previo al primero del período, si no el stock actual
+		"get_disponibilidad": `
+			WITH criticos AS (
+				SELECT codigo_producto, id_local, sla_objetivo
+				FROM productos_criticos_cantera
+				WHERE ($1::int IS NULL OR id_local = $1)
+			),
+			iniciales AS (
+				SELECT c.codigo_producto, c.id_local,
+					   COALESCE(
+						   (SELECT m.cantidad_nueva FROM stock_movimientos_cantera m
+							WHERE m.codigo_producto = c.codigo_producto AND m.id_local = c.id_local
+							  AND m.tipo_item = 'producto' AND m.created_at < $2
+							ORDER BY m.created_at DESC, m.id DESC LIMIT 1),
+						   (SELECT m.cantidad_anterior FROM stock_movimientos_cantera m
+							WHERE m.codigo_producto = c.codigo_producto AND m.id_local = c.id_local
+							  AND m.tipo_item = 'producto' AND m.created_at >= $2 AND m.created_at < $3
+							ORDER BY m.created_at, m.id LIMIT 1),
+						   (SELECT s.cantidad_actual FROM stock_bodega_cantera s
+							WHERE s.codigo_producto = c.codigo_producto AND s.id_local = c.id_local
+							  AND s.tipo_item = 'producto'),
+						   0
+					   ) AS cantidad
+				FROM criticos c
+			),
+			tramos AS (
+				SELECT codigo_producto, id_local, $2::timestamp AS inicio, 0 AS orden, cantidad
+				FROM iniciales
+				UNION ALL
+				SELECT m.codigo_producto, m.id_local, m.created_at, m.id, m.cantidad_nueva
+				FROM stock_movimientos_cantera m
+				JOIN criticos c ON c.codigo_producto = m.codigo_producto AND c.id_local = m.id_local
+				WHERE m.tipo_item = 'producto' AND m.created_at >= $2 AND m.created_at < $3
+			),
+			intervalos AS (
+				SELECT codigo_producto, id_local, cantidad, inicio,
+					   LEAD(inicio, 1, $3::timestamp) OVER w AS fin,
+					   LAG(cantidad) OVER w AS cantidad_previa
+				FROM tramos
+				WINDOW w AS (PARTITION BY codigo_producto, id_local ORDER BY inicio, orden)
+			)
+			SELECT c.codigo_producto, p.nombre, c.id_local, COALESCE(c.sla_objetivo, 0),
+				   COALESCE(EXTRACT(EPOCH FROM SUM(i.fin - i.inicio)), 0)::bigint,
+				   COALESCE(EXTRACT(EPOCH FROM SUM(i.fin - i.inicio) FILTER (WHERE i.cantidad <= 0)), 0)::bigint,
+				   COUNT(*) FILTER (WHERE i.cantidad <= 0 AND i.cantidad_previa > 0),
+				   COALESCE(s.cantidad_actual, 0)
+			FROM criticos c
+			LEFT JOIN intervalos i ON i.codigo_producto = c.codigo_producto AND i.id_local = c.id_local
+			LEFT JOIN productos p ON p.codigo = c.codigo_producto
+			LEFT JOIN stock_bodega_cantera s ON s.codigo_producto = c.codigo_producto
+				AND s.id_local = c.id_local AND s.tipo_item = 'producto'
+			GROUP BY c.codigo_producto, p.nombre, c.id_local, c.sla_objetivo, s.cantidad_actual
+			ORDER BY c.id_local, c.codigo_producto
+		`,
+		"get_quiebres_activos": `
+			SELECT c.codigo_producto, p.nombre, c.id_local, COALESCE(s.cantidad_actual, 0),
+				   COALESCE(
+					   (SELECT MAX(m.created_at) FROM stock_movimientos_cantera m
+						WHERE m.codigo_producto = c.codigo_producto AND m.id_local = c.id_local
+						  AND m.tipo_item = 'producto' AND m.cantidad_anterior > 0 AND m.cantidad_nueva <= 0),
+					   s.updated_at, c.created_at
+				   ) AS desde
+			FROM productos_criticos_cantera c
+			LEFT JOIN productos p ON p.codigo = c.codigo_producto
+			LEFT JOIN stock_bodega_cantera s ON s.codigo_producto = c.codigo_producto
+				AND s.id_local = c.id_local AND s.tipo_item = 'producto'
+			WHERE ($1::int IS NULL OR c.id_local = $1)
+			  AND COALESCE(s.cantidad_actual, 0) <= 0
+			ORDER BY desde
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// MarcarCritico marca el producto como crítico en el local
+func (r *productoCriticoRepository) MarcarCritico(ctx context.Context, critico *models.ProductoCritico) error {
+	err := r.stmts["marcar_critico"].QueryRowContext(ctx,
+		critico.CodigoProducto, critico.IDLocal, critico.SLAObjetivo,
+	).Scan(&critico.ID, &critico.CreatedAt, &critico.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to marcar producto critico: %w", err)
+	}
+	return nil
+}
+
+// DesmarcarCritico quita la marca de crítico del producto en el local
+func (r *productoCriticoRepository) DesmarcarCritico(ctx context.Context, codigoProducto string, idLocal int) (bool, error) {
+	result, err := r.stmts["desmarcar_critico"].ExecContext(ctx, codigoProducto, idLocal)
+	if err != nil {
+		return false, fmt.Errorf("failed to desmarcar producto critico: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetCriticos lista los productos críticos (opcionalmente de un local)
+func (r *productoCriticoRepository) GetCriticos(ctx context.Context, idLocal *int) ([]*models.ProductoCritico, error) {
+	rows, err := r.stmts["get_criticos"].QueryContext(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query productos criticos: %w", err)
+	}
+	defer rows.Close()
+
+	criticos := []*models.ProductoCritico{}
+	for rows.Next() {
+		var critico models.ProductoCritico
+		if err := rows.Scan(
+			&critico.ID, &critico.CodigoProducto, &critico.NombreProducto, &critico.IDLocal,
+			&critico.SLAObjetivo, &critico.CreatedAt, &critico.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan producto critico: %w", err)
+		}
+		criticos = append(criticos, &critico)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate productos criticos: %w", err)
+	}
+
+	return criticos, nil
+}
+
+// GetDisponibilidad calcula el tiempo sin stock de cada producto crítico en el período
+func (r *productoCriticoRepository) GetDisponibilidad(ctx context.Context, idLocal *int, desde, hasta time.Time) ([]*models.DisponibilidadCritico, error) {
+	rows, err := r.stmts["get_disponibilidad"].QueryContext(ctx, idLocal, desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query disponibilidad criticos: %w", err)
+	}
+	defer rows.Close()
+
+	detalle := []*models.DisponibilidadCritico{}
+	for rows.Next() {
+		var fila models.DisponibilidadCritico
+		var totalSegundos int64
+		if err := rows.Scan(
+			&fila.CodigoProducto, &fila.NombreProducto, &fila.IDLocal, &fila.SLAObjetivo,
+			&totalSegundos, &fila.SegundosSinStock, &fila.Quiebres, &fila.StockActual,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan disponibilidad critico: %w", err)
+		}
+		fila.Disponibilidad = 100
+		if totalSegundos > 0 {
+			fila.Disponibilidad = float64(totalSegundos-fila.SegundosSinStock) * 100 / float64(totalSegundos)
+		}
+		detalle = append(detalle, &fila)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate disponibilidad criticos: %w", err)
+	}
+
+	return detalle, nil
+}
+
+// GetQuiebresActivos retorna los productos críticos sin stock
+func (r *productoCriticoRepository) GetQuiebresActivos(ctx context.Context, idLocal *int) ([]*models.QuiebreCritico, error) {
+	rows, err := r.stmts["get_quiebres_activos"].QueryContext(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quiebres criticos: %w", err)
+	}
+	defer rows.Close()
+
+	quiebres := []*models.QuiebreCritico{}
+	for rows.Next() {
+		var quiebre models.QuiebreCritico
+		if err := rows.Scan(
+			&quiebre.CodigoProducto, &quiebre.NombreProducto, &quiebre.IDLocal,
+			&quiebre.StockActual, &quiebre.Desde,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan quiebre critico: %w", err)
+		}
+		quiebres = append(quiebres, &quiebre)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate quiebres criticos: %w", err)
+	}
+
+	return quiebres, nil
+}
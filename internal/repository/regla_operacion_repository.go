@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// ReglaOperacionRepository define la interfaz para las reglas de bloqueo de operaciones
+type ReglaOperacionRepository interface {
+	CreateRegla(ctx context.Context, regla *models.ReglaOperacion) error
+	// UpdateRegla reemplaza la regla; retorna false si no existe
+	UpdateRegla(ctx context.Context, regla *models.ReglaOperacion) (bool, error)
+	// DeleteRegla elimina la regla; retorna false si no existe
+	DeleteRegla(ctx context.Context, id int) (bool, error)
+	GetReglaByID(ctx context.Context, id int) (*models.ReglaOperacion, error)
+	GetReglas(ctx context.Context, idLocal *int) ([]*models.ReglaOperacion, error)
+	// GetReglasActivas retorna las reglas activas que aplican al local (las propias y las globales)
+	GetReglasActivas(ctx context.Context, idLocal int) ([]*models.ReglaOperacion, error)
+}
+
+// reglaOperacionRepository implementa ReglaOperacionRepository
+type reglaOperacionRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewReglaOperacionRepository crea una nueva instancia del repository
+func NewReglaOperacionRepository(db *sql.DB) (ReglaOperacionRepository, error) {
+	repo := &reglaOperacionRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *reglaOperacionRepository) prepareStatements() error {
+	const columnas = `id, nombre, tipo, accion, id_local, porcentaje, cantidad, hora_desde, hora_hasta,
+			   activa, created_at, updated_at`
+
+	statements := map[string]string{
+		"create_regla": `
+			INSERT INTO reglas_operacion_cantera
+			(nombre, tipo, accion, id_local, porcentaje, cantidad, hora_desde, hora_hasta, activa)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id, created_at, updated_at
+		`,
+		"update_regla": `
+			UPDATE reglas_operacion_cantera
+			SET nombre = $2, tipo = $3, accion = $4, id_local = $5, porcentaje = $6, cantidad = $7,
+				hora_desde = $8, hora_hasta = $9, activa = $10, updated_at = NOW()
+			WHERE id = $1
+			RETURNING created_at, updated_at
+		`,
+		"delete_regla": `
+			DELETE FROM reglas_operacion_cantera WHERE id = $1
+		`,
+		"get_regla": `
+			SELECT ` + columnas + `
+			FROM reglas_operacion_cantera
+			WHERE id = $1
+		`,
+		"get_reglas": `
+			SELECT ` + columnas + `
+			FROM reglas_operacion_cantera
+			WHERE ($1::int IS NULL OR id_local IS NULL OR id_local = $1)
+			ORDER BY id
+		`,
+		"get_reglas_activas": `
+			SELECT ` + columnas + `
+			FROM reglas_operacion_cantera
+			WHERE activa AND (id_local IS NULL OR id_local = $1)
+			ORDER BY id
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// CreateRegla registra una regla nueva
+func (r *reglaOperacionRepository) CreateRegla(ctx context.Context, regla *models.ReglaOperacion) error {
+	err := r.stmts["create_regla"].QueryRowContext(ctx,
+		regla.Nombre, regla.Tipo, regla.Accion, regla.IDLocal, regla.Porcentaje, regla.Cantidad,
+		regla.HoraDesde, regla.HoraHasta, regla.Activa,
+	).Scan(&regla.ID, &regla.CreatedAt, &regla.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create regla operacion: %w", err)
+	}
+	return nil
+}
+
+// UpdateRegla reemplaza los datos de la regla
+func (r *reglaOperacionRepository) UpdateRegla(ctx context.Context, regla *models.ReglaOperacion) (bool, error) {
+	err := r.stmts["update_regla"].QueryRowContext(ctx,
+		regla.ID, regla.Nombre, regla.Tipo, regla.Accion, regla.IDLocal, regla.Porcentaje, regla.Cantidad,
+		regla.HoraDesde, regla.HoraHasta, regla.Activa,
+	).Scan(&regla.CreatedAt, &regla.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update regla operacion: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteRegla elimina la regla
+func (r *reglaOperacionRepository) DeleteRegla(ctx context.Context, id int) (bool, error) {
+	result, err := r.stmts["delete_regla"].ExecContext(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete regla operacion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetReglaByID obtiene una regla por ID
+func (r *reglaOperacionRepository) GetReglaByID(ctx context.Context, id int) (*models.ReglaOperacion, error) {
+	regla, err := scanReglaOperacion(r.stmts["get_regla"].QueryRowContext(ctx, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get regla operacion: %w", err)
+	}
+	return regla, nil
+}
+
+// GetReglas lista las reglas (opcionalmente las que aplican a un local)
+func (r *reglaOperacionRepository) GetReglas(ctx context.Context, idLocal *int) ([]*models.ReglaOperacion, error) {
+	return r.queryReglas(ctx, "get_reglas", idLocal)
+}
+
+// GetReglasActivas retorna las reglas activas que aplican al local
+func (r *reglaOperacionRepository) GetReglasActivas(ctx context.Context, idLocal int) ([]*models.ReglaOperacion, error) {
+	return r.queryReglas(ctx, "get_reglas_activas", idLocal)
+}
+
+// queryReglas ejecuta una consulta de reglas filtrada por local
+func (r *reglaOperacionRepository) queryReglas(ctx context.Context, stmt string, idLocal interface{}) ([]*models.ReglaOperacion, error) {
+	rows, err := r.stmts[stmt].QueryContext(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reglas operacion: %w", err)
+	}
+	defer rows.Close()
+
+	reglas := []*models.ReglaOperacion{}
+	for rows.Next() {
+		regla, err := scanReglaOperacion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan regla operacion: %w", err)
+		}
+		reglas = append(reglas, regla)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate reglas operacion: %w", err)
+	}
+
+	return reglas, nil
+}
+
+// scanReglaOperacion escanea una fila de reglas_operacion_cantera
+func scanReglaOperacion(row interface{ Scan(...interface{}) error }) (*models.ReglaOperacion, error) {
+	var regla models.ReglaOperacion
+	err := row.Scan(
+		&regla.ID, &regla.Nombre, &regla.Tipo, &regla.Accion, &regla.IDLocal, &regla.Porcentaje,
+		&regla.Cantidad, &regla.HoraDesde, &regla.HoraHasta, &regla.Activa,
+		&regla.CreatedAt, &regla.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &regla, nil
+}
@@ -0,0 +1,480 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+)
+
+// ReporteRepository define la interfaz para las consultas de reportes
+type ReporteRepository interface {
+	// agregados indica los días que se leen de los agregados diarios (nil: solo movimientos)
+	GetVentasPorProducto(ctx context.Context, filter *models.ReporteFilter, agregados *models.CoberturaAgregados) ([]*models.VentaProducto, error)
+	GetActividadPorUsuario(ctx context.Context, filter *models.ReporteFilter, agregados *models.CoberturaAgregados) ([]*models.ActividadAgregada, error)
+
+	// Agregados diarios de movimientos
+	GetEstadoAgregados(ctx context.Context) (*models.EstadoAgregados, error)
+	// AgregarDias agrega hasta maxDias días cerrados pendientes; nil si no había nada que agregar
+	// o si otra réplica está agregando
+	AgregarDias(ctx context.Context, maxDias int) (*models.ResultadoAgregacion, error)
+	// DescartarAgregadosDesde elimina los agregados desde la fecha y retrocede la marca
+	DescartarAgregadosDesde(ctx context.Context, desde time.Time) error
+
+	// GetCierreDia consolida ventas, devoluciones, mermas, entradas y quiebres del local en [desde, hasta)
+	GetCierreDia(ctx context.Context, idLocal int, desde, hasta time.Time) (*models.CierreDia, error)
+}
+
+// reporteRepository implementa ReporteRepository
+type reporteRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewReporteRepository crea una nueva instancia del repository
+func NewReporteRepository(db *sql.DB) (ReporteRepository, error) {
+	repo := &reporteRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+// Los reportes combinan los agregados diarios (días completos en [$desdeAgregado, $hastaAgregado))
+// con los movimientos del resto del período; con un rango de agregados vacío leen solo movimientos
+func (r *reporteRepository) prepareStatements() error {
+	statements := map[string]string{
+		// Ventas = salidas de productos, valorizadas al precio vigente en el momento del movimiento
+		// (historial de precios), con fallback al precio actual de lista y del maestro
+		// Las unidades sin historial se valorizan al final, así agregados y movimientos suman igual
+		"get_ventas_por_producto": `
+			WITH parciales AS (
+				SELECT
+					m.codigo_producto,
+					SUM(m.cantidad) AS unidades,
+					COALESCE(SUM(m.cantidad * h.precio_detalle), 0) AS monto_historico,
+					COALESCE(SUM(m.cantidad) FILTER (WHERE h.precio_detalle IS NULL), 0) AS unidades_sin_historial
+				FROM stock_movimientos_cantera m
+				LEFT JOIN LATERAL (
+					SELECT hp.precio_detalle
+					FROM historial_precios_cantera hp
+					WHERE hp.codigo_tivendo = m.codigo_producto
+					  AND hp.vigente_desde <= m.created_at
+					  AND (hp.vigente_hasta IS NULL OR hp.vigente_hasta > m.created_at)
+					ORDER BY hp.vigente_desde DESC
+					LIMIT 1
+				) h ON true
+				WHERE m.tipo_movimiento = 'salida'
+				  AND m.tipo_item = 'producto'
+				  AND ($1::int IS NULL OR m.id_local = $1)
+				  AND m.created_at >= $2 AND m.created_at < $3
+				  AND NOT (m.created_at >= $4::timestamp AND m.created_at < $5::timestamp)
+				GROUP BY m.codigo_producto
+				UNION ALL
+				SELECT
+					a.codigo_producto,
+					SUM(a.unidades),
+					SUM(a.monto_historico),
+					SUM(a.unidades_sin_historial)
+				FROM movimientos_diarios_cantera a
+				WHERE a.tipo_movimiento = 'salida'
+				  AND a.tipo_item = 'producto'
+				  AND ($1::int IS NULL OR a.id_local = $1)
+				  AND a.fecha >= ($4::timestamp)::date AND a.fecha < ($5::timestamp)::date
+				GROUP BY a.codigo_producto
+			), ventas AS (
+				SELECT
+					codigo_producto,
+					SUM(unidades) AS unidades,
+					SUM(monto_historico) AS monto_historico,
+					SUM(unidades_sin_historial) AS unidades_sin_historial
+				FROM parciales
+				GROUP BY codigo_producto
+			)
+			SELECT
+				v.codigo_producto, p.nombre, p.id_categoria, c.nombre,
+				p.precio, p.utilidad, p.tipo_utilidad,
+				v.unidades,
+				v.monto_historico + v.unidades_sin_historial * COALESCE(lp.precio_detalle, p.precio, 0) AS venta_total
+			FROM ventas v
+			LEFT JOIN productos p ON p.codigo = v.codigo_producto
+			LEFT JOIN categorias c ON c.id = p.id_categoria
+			LEFT JOIN lista_precios_cantera lp ON lp.codigo_tivendo = v.codigo_producto
+			ORDER BY venta_total DESC
+		`,
+		// Las ventas del POS son salidas con observaciones prefijadas con models.ObservacionVentaPOS
+		// El monto usa el precio actual: se valoriza al final sobre las unidades por producto
+		"get_actividad_por_usuario": `
+			WITH parciales AS (
+				SELECT
+					m.id_usuario,
+					CASE
+						WHEN m.tipo_movimiento = 'salida' AND m.observaciones LIKE '[POS]%' THEN 'venta'
+						ELSE m.tipo_movimiento
+					END AS categoria,
+					m.tipo_item,
+					m.codigo_producto,
+					COUNT(*) AS movimientos,
+					COALESCE(SUM(m.cantidad), 0) AS unidades
+				FROM stock_movimientos_cantera m
+				WHERE ($1::int IS NULL OR m.id_local = $1)
+				  AND ($2::int IS NULL OR m.id_usuario = $2)
+				  AND m.created_at >= $3 AND m.created_at < $4
+				  AND NOT (m.created_at >= $5::timestamp AND m.created_at < $6::timestamp)
+				  AND COALESCE(m.observaciones, '') NOT LIKE 'Pack: %'
+				GROUP BY m.id_usuario, categoria, m.tipo_item, m.codigo_producto
+				UNION ALL
+				SELECT
+					a.id_usuario,
+					CASE
+						WHEN a.tipo_movimiento = 'salida' AND a.venta_pos THEN 'venta'
+						ELSE a.tipo_movimiento
+					END AS categoria,
+					a.tipo_item,
+					a.codigo_producto,
+					SUM(a.movimientos),
+					SUM(a.unidades)
+				FROM movimientos_diarios_cantera a
+				WHERE ($1::int IS NULL OR a.id_local = $1)
+				  AND ($2::int IS NULL OR a.id_usuario = $2)
+				  AND a.fecha >= ($5::timestamp)::date AND a.fecha < ($6::timestamp)::date
+				  AND NOT a.componente_pack
+				GROUP BY a.id_usuario, categoria, a.tipo_item, a.codigo_producto
+			)
+			SELECT
+				x.id_usuario,
+				x.categoria,
+				SUM(x.movimientos) AS movimientos,
+				COALESCE(SUM(x.unidades), 0) AS unidades,
+				COALESCE(SUM(x.unidades * COALESCE(lp.precio_detalle, p.precio, pk.precio_base, 0)), 0) AS monto
+			FROM parciales x
+			LEFT JOIN productos p ON x.tipo_item = 'producto' AND p.codigo = x.codigo_producto
+			LEFT JOIN (
+				SELECT DISTINCT ON (codigo_pack) codigo_pack, precio_base
+				FROM pack_listados
+			) pk ON x.tipo_item = 'pack' AND pk.codigo_pack = x.codigo_producto
+			LEFT JOIN lista_precios_cantera lp ON lp.codigo_tivendo = x.codigo_producto
+			GROUP BY x.id_usuario, x.categoria
+			ORDER BY x.id_usuario, x.categoria
+		`,
+		"get_estado_agregados": `
+			SELECT hasta, actualizado_at FROM movimientos_diarios_estado WHERE id = 1
+		`,
+		// Rango a agregar: desde la marca (o el primer movimiento) hasta hoy, sin incluirlo
+		// SKIP LOCKED: si otra réplica está agregando no hay filas y la ronda se omite
+		"lock_rango_agregados": `
+			SELECT
+				COALESCE(e.hasta, (SELECT MIN(created_at)::date FROM stock_movimientos_cantera)),
+				CURRENT_DATE
+			FROM movimientos_diarios_estado e
+			WHERE e.id = 1
+			FOR UPDATE SKIP LOCKED
+		`,
+		"lock_estado_agregados": `
+			SELECT hasta FROM movimientos_diarios_estado WHERE id = 1 FOR UPDATE
+		`,
+		"delete_agregados": `
+			DELETE FROM movimientos_diarios_cantera
+			WHERE fecha >= $1::date AND ($2::date IS NULL OR fecha < $2::date)
+		`,
+		"insert_agregados": `
+			INSERT INTO movimientos_diarios_cantera (
+				fecha, id_local, id_usuario, codigo_producto, tipo_item, tipo_movimiento,
+				venta_pos, componente_pack, movimientos, unidades, monto_historico, unidades_sin_historial
+			)
+			SELECT
+				m.created_at::date,
+				m.id_local,
+				m.id_usuario,
+				m.codigo_producto,
+				m.tipo_item,
+				m.tipo_movimiento,
+				COALESCE(m.observaciones LIKE '[POS]%', false),
+				COALESCE(m.observaciones LIKE 'Pack: %', false),
+				COUNT(*),
+				COALESCE(SUM(m.cantidad), 0),
+				COALESCE(SUM(m.cantidad * h.precio_detalle), 0),
+				COALESCE(SUM(m.cantidad) FILTER (WHERE h.precio_detalle IS NULL), 0)
+			FROM stock_movimientos_cantera m
+			LEFT JOIN LATERAL (
+				SELECT hp.precio_detalle
+				FROM historial_precios_cantera hp
+				WHERE m.tipo_movimiento = 'salida'
+				  AND m.tipo_item = 'producto'
+				  AND hp.codigo_tivendo = m.codigo_producto
+				  AND hp.vigente_desde <= m.created_at
+				  AND (hp.vigente_hasta IS NULL OR hp.vigente_hasta > m.created_at)
+				ORDER BY hp.vigente_desde DESC
+				LIMIT 1
+			) h ON true
+			WHERE m.created_at >= $1::date AND m.created_at < $2::date
+			GROUP BY 1, 2, 3, 4, 5, 6, 7, 8
+		`,
+		"update_estado_agregados": `
+			UPDATE movimientos_diarios_estado SET hasta = $1::date, actualizado_at = NOW() WHERE id = 1
+		`,
+
+		// Cierre diario: cada sección lee las filas del local en [$2, $3)
+		"get_cierre_ventas": `
+			SELECT
+				COUNT(DISTINCT pl.venta_ref),
+				COALESCE(SUM(pl.cantidad), 0),
+				COALESCE(SUM(pl.cantidad * pl.precio_cobrado), 0),
+				COALESCE((
+					SELECT SUM(lc.monto)
+					FROM lineas_contables_venta_cantera lc
+					WHERE lc.id_local = $1 AND lc.created_at >= $2 AND lc.created_at < $3
+					  AND lc.tipo <> 'nota_credito'
+				), 0)
+			FROM precios_lineas_venta_cantera pl
+			WHERE pl.id_local = $1 AND pl.created_at >= $2 AND pl.created_at < $3
+		`,
+		"get_cierre_devoluciones": `
+			SELECT
+				COUNT(*),
+				COALESCE(SUM((SELECT SUM(i.cantidad) FROM nota_credito_items_cantera i WHERE i.id_nota = n.id)), 0),
+				COALESCE(SUM(n.monto), 0)
+			FROM notas_credito_cantera n
+			WHERE n.id_local = $1 AND n.created_at >= $2 AND n.created_at < $3
+			  AND n.estado = 'aplicada'
+		`,
+		// Entradas y mermas sin los componentes de packs (se cuentan en el pack)
+		"get_cierre_movimientos": `
+			SELECT
+				COUNT(*) FILTER (WHERE m.tipo_movimiento = 'entrada'),
+				COALESCE(SUM(m.cantidad) FILTER (WHERE m.tipo_movimiento = 'entrada'), 0),
+				COUNT(*) FILTER (WHERE m.tipo_movimiento = 'salida' AND m.motivo ILIKE 'merma%'),
+				COALESCE(SUM(m.cantidad) FILTER (WHERE m.tipo_movimiento = 'salida' AND m.motivo ILIKE 'merma%'), 0)
+			FROM stock_movimientos_cantera m
+			WHERE m.id_local = $1 AND m.created_at >= $2 AND m.created_at < $3
+			  AND COALESCE(m.observaciones, '') NOT LIKE 'Pack: %'
+		`,
+		// Productos que pasaron de tener stock a cero o menos durante el día
+		"get_cierre_quebrados": `
+			SELECT m.codigo_producto, p.nombre, MIN(m.created_at), COALESCE(s.cantidad_actual, 0)
+			FROM stock_movimientos_cantera m
+			LEFT JOIN productos p ON p.codigo = m.codigo_producto
+			LEFT JOIN stock_bodega_cantera s ON s.codigo_producto = m.codigo_producto AND s.id_local = m.id_local
+			WHERE m.id_local = $1 AND m.created_at >= $2 AND m.created_at < $3
+			  AND m.tipo_item = 'producto'
+			  AND m.cantidad_anterior > 0 AND m.cantidad_nueva <= 0
+			GROUP BY m.codigo_producto, p.nombre, s.cantidad_actual
+			ORDER BY MIN(m.created_at)
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// GetVentasPorProducto obtiene las ventas valorizadas por producto en el período
+func (r *reporteRepository) GetVentasPorProducto(ctx context.Context, filter *models.ReporteFilter, agregados *models.CoberturaAgregados) ([]*models.VentaProducto, error) {
+	desdeAgregado, hastaAgregado := rangoAgregado(filter, agregados)
+	rows, err := r.stmts["get_ventas_por_producto"].QueryContext(ctx, filter.IDLocal, filter.Desde, filter.Hasta, desdeAgregado, hastaAgregado)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ventas por producto: %w", err)
+	}
+	defer rows.Close()
+
+	ventas := []*models.VentaProducto{}
+	for rows.Next() {
+		var venta models.VentaProducto
+		err := rows.Scan(
+			&venta.CodigoProducto, &venta.NombreProducto, &venta.IDCategoria, &venta.NombreCategoria,
+			&venta.PrecioMaestro, &venta.Utilidad, &venta.TipoUtilidad,
+			&venta.Unidades, &venta.VentaTotal,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan venta producto: %w", err)
+		}
+		ventas = append(ventas, &venta)
+	}
+
+	return ventas, nil
+}
+
+// GetActividadPorUsuario obtiene movimientos, unidades y montos por usuario y categoría
+func (r *reporteRepository) GetActividadPorUsuario(ctx context.Context, filter *models.ReporteFilter, agregados *models.CoberturaAgregados) ([]*models.ActividadAgregada, error) {
+	desdeAgregado, hastaAgregado := rangoAgregado(filter, agregados)
+	rows, err := r.stmts["get_actividad_por_usuario"].QueryContext(ctx, filter.IDLocal, filter.IDUsuario, filter.Desde, filter.Hasta, desdeAgregado, hastaAgregado)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get actividad por usuario: %w", err)
+	}
+	defer rows.Close()
+
+	actividad := []*models.ActividadAgregada{}
+	for rows.Next() {
+		var fila models.ActividadAgregada
+		err := rows.Scan(&fila.IDUsuario, &fila.Categoria, &fila.Movimientos, &fila.Unidades, &fila.Monto)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan actividad: %w", err)
+		}
+		actividad = append(actividad, &fila)
+	}
+
+	return actividad, nil
+}
+
+// GetEstadoAgregados obtiene la marca de los agregados diarios
+func (r *reporteRepository) GetEstadoAgregados(ctx context.Context) (*models.EstadoAgregados, error) {
+	var estado models.EstadoAgregados
+	var hasta sql.NullTime
+	err := r.stmts["get_estado_agregados"].QueryRowContext(ctx).Scan(&hasta, &estado.ActualizadoAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get estado agregados: %w", err)
+	}
+	if hasta.Valid {
+		estado.Hasta = &hasta.Time
+	}
+	return &estado, nil
+}
+
+// AgregarDias agrega los días cerrados pendientes (a lo sumo maxDias) en una transacción
+func (r *reporteRepository) AgregarDias(ctx context.Context, maxDias int) (*models.ResultadoAgregacion, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var desde, hoy sql.NullTime
+	err = tx.StmtContext(ctx, r.stmts["lock_rango_agregados"]).QueryRowContext(ctx).Scan(&desde, &hoy)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock estado agregados: %w", err)
+	}
+	// Sin movimientos todavía, o ya agregado hasta ayer
+	if !desde.Valid || !desde.Time.Before(hoy.Time) {
+		return nil, nil
+	}
+
+	hasta := desde.Time.AddDate(0, 0, maxDias)
+	if hasta.After(hoy.Time) {
+		hasta = hoy.Time
+	}
+
+	// Por si quedaron filas de un recálculo (la marca garantiza que no cuentan en los reportes)
+	if _, err := tx.StmtContext(ctx, r.stmts["delete_agregados"]).ExecContext(ctx, desde.Time, hasta); err != nil {
+		return nil, fmt.Errorf("failed to delete agregados: %w", err)
+	}
+	result, err := tx.StmtContext(ctx, r.stmts["insert_agregados"]).ExecContext(ctx, desde.Time, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert agregados: %w", err)
+	}
+	filas, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if _, err := tx.StmtContext(ctx, r.stmts["update_estado_agregados"]).ExecContext(ctx, hasta); err != nil {
+		return nil, fmt.Errorf("failed to update estado agregados: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &models.ResultadoAgregacion{Desde: desde.Time, Hasta: hasta, Filas: int(filas)}, nil
+}
+
+// DescartarAgregadosDesde elimina los agregados desde la fecha; si la marca era posterior
+// retrocede a esa fecha para que el job vuelva a agregar esos días
+func (r *reporteRepository) DescartarAgregadosDesde(ctx context.Context, desde time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var hasta sql.NullTime
+	if err := tx.StmtContext(ctx, r.stmts["lock_estado_agregados"]).QueryRowContext(ctx).Scan(&hasta); err != nil {
+		return fmt.Errorf("failed to lock estado agregados: %w", err)
+	}
+
+	if _, err := tx.StmtContext(ctx, r.stmts["delete_agregados"]).ExecContext(ctx, desde, nil); err != nil {
+		return fmt.Errorf("failed to delete agregados: %w", err)
+	}
+	if hasta.Valid && hasta.Time.After(desde) {
+		if _, err := tx.StmtContext(ctx, r.stmts["update_estado_agregados"]).ExecContext(ctx, desde); err != nil {
+			return fmt.Errorf("failed to update estado agregados: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetCierreDia obtiene cada sección del cierre diario del local
+func (r *reporteRepository) GetCierreDia(ctx context.Context, idLocal int, desde, hasta time.Time) (*models.CierreDia, error) {
+	cierre := &models.CierreDia{
+		IDLocal:   idLocal,
+		Quebrados: []*models.ProductoQuebrado{},
+	}
+
+	err := r.stmts["get_cierre_ventas"].QueryRowContext(ctx, idLocal, desde, hasta).Scan(
+		&cierre.Ventas.Ventas, &cierre.Ventas.Unidades, &cierre.Ventas.MontoProductos, &cierre.Ventas.MontoOtros,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ventas del cierre: %w", err)
+	}
+
+	err = r.stmts["get_cierre_devoluciones"].QueryRowContext(ctx, idLocal, desde, hasta).Scan(
+		&cierre.Devoluciones.NotasCredito, &cierre.Devoluciones.Unidades, &cierre.Devoluciones.Monto,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get devoluciones del cierre: %w", err)
+	}
+
+	err = r.stmts["get_cierre_movimientos"].QueryRowContext(ctx, idLocal, desde, hasta).Scan(
+		&cierre.Entradas.Movimientos, &cierre.Entradas.Unidades, &cierre.Mermas.Movimientos, &cierre.Mermas.Unidades,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get movimientos del cierre: %w", err)
+	}
+
+	rows, err := r.stmts["get_cierre_quebrados"].QueryContext(ctx, idLocal, desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quebrados del cierre: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var quebrado models.ProductoQuebrado
+		if err := rows.Scan(&quebrado.CodigoProducto, &quebrado.NombreProducto, &quebrado.QuebradoAt, &quebrado.StockActual); err != nil {
+			return nil, fmt.Errorf("failed to scan quebrado: %w", err)
+		}
+		cierre.Quebrados = append(cierre.Quebrados, &quebrado)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate quebrados: %w", err)
+	}
+
+	return cierre, nil
+}
+
+// rangoAgregado rango de los agregados para las consultas; vacío (desde = hasta) si no se usan
+func rangoAgregado(filter *models.ReporteFilter, agregados *models.CoberturaAgregados) (time.Time, time.Time) {
+	if agregados == nil {
+		return filter.Desde, filter.Desde
+	}
+	return agregados.Desde, agregados.Hasta
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"stock-service/internal/models"
 )
@@ -14,15 +17,47 @@ type StockRepository interface {
 	GetStockByProducto(ctx context.Context, codigoProducto string, idLocal int) (*models.Stock, error)
 	UpdateStock(ctx context.Context, stock *models.Stock) error
 	CreateStock(ctx context.Context, stock *models.Stock) error
+	// IncrementStock suma cantidad al stock en una sola sentencia y retorna el nuevo saldo
+	// (ErrNotFound si el producto no tiene stock en el local)
+	IncrementStock(ctx context.Context, codigoProducto string, idLocal, cantidad int) (int, error)
+	// DecrementStock descuenta cantidad en una sola sentencia solo si quedan al menos minimoRestante
+	// unidades (lo reservado); retorna el nuevo saldo, ErrStockInsuficiente o ErrNotFound
+	DecrementStock(ctx context.Context, codigoProducto string, idLocal, cantidad, minimoRestante int) (int, error)
 	GetStockByLocal(ctx context.Context, idLocal int) ([]*models.Stock, error)
 	GetStockBajo(ctx context.Context, idLocal int) ([]*models.Stock, error)
+	// GetStockModificadoDesde stock del local actualizado después de desde (todo si desde es nil)
+	GetStockModificadoDesde(ctx context.Context, idLocal int, desde *time.Time) ([]*models.Stock, error)
 
 	// Nueva operación con JOINs completos
 	GetStockCompleteByLocal(ctx context.Context, idLocal int) ([]*models.StockComplete, error)
+	GetStockDescontinuadoByLocal(ctx context.Context, idLocal int) ([]*models.StockComplete, error)
+	// GetStockSummary resumen por local (todos los locales si idLocal es nil)
+	GetStockSummary(ctx context.Context, idLocal *int) ([]*models.StockSummary, error)
 
 	// Operaciones de movimientos
 	CreateMovimiento(ctx context.Context, movimiento *models.Movimiento) error
 	GetMovimientosByLocal(ctx context.Context, filter *models.MovimientoFilter) ([]*models.Movimiento, error)
+	// CountMovimientos total de movimientos que cumplen el filtro (sin limit ni offset)
+	CountMovimientos(ctx context.Context, filter *models.MovimientoFilter) (int, error)
+	// RecorrerMovimientos recorre fila a fila, del más antiguo al más reciente, todos los movimientos
+	// que cumplen el filtro (ignora limit y offset)
+	RecorrerMovimientos(ctx context.Context, filter *models.MovimientoFilter, fn func(*models.Movimiento) error) error
+	// AgruparMovimientos suma cantidades y cuenta los movimientos que cumplen el filtro por grupo
+	// (agrupacion es una de models.AgrupacionMovimientos*; aplica limit y offset a los grupos)
+	AgruparMovimientos(ctx context.Context, filter *models.MovimientoFilter, agrupacion string) ([]*models.MovimientoAgrupado, error)
+	// RecorrerCadenaMovimientos recorre en orden de cadena (id) los movimientos del local entre el
+	// primero y el último del rango de fechas (los extremos nil no acotan)
+	RecorrerCadenaMovimientos(ctx context.Context, idLocal int, desde, hasta *time.Time, fn func(*models.Movimiento) error) error
+	// GetHashPrevio hash del último movimiento sellado del local anterior a antesDe ("" si no hay)
+	GetHashPrevio(ctx context.Context, idLocal, antesDe int) (string, error)
+	// ExisteDocumento indica si el documento ya respalda alguna entrada
+	// Dentro de una transacción toma además un lock sobre el documento hasta el commit
+	ExisteDocumento(ctx context.Context, tipo, numero string) (bool, error)
+
+	// Historial de cambios de cantidad mínima (dentro de la transacción si existe)
+	CreateCambioMinimo(ctx context.Context, cambio *models.CambioCantidadMinima) error
+	// GetHistorialMinimos cambios del mínimo de un producto, los más recientes primero (todos los locales si idLocal es nil)
+	GetHistorialMinimos(ctx context.Context, codigoProducto string, idLocal *int, limit int) ([]*models.CambioCantidadMinima, error)
 
 	// Operaciones batch
 	BatchUpdateStock(ctx context.Context, stocks []*models.Stock) error
@@ -31,12 +66,79 @@ type StockRepository interface {
 	GetProductoByCodigo(ctx context.Context, codigo string) (*models.Producto, error)
 	GetPackByCodigo(ctx context.Context, codigo string) (*models.Pack, error)
 	GetPacksByProducto(ctx context.Context, codigoProducto string) ([]*models.Pack, error)
+	GetComponentesPack(ctx context.Context, codigoPack string) ([]*models.Pack, error)
+
+	// Conversión de unidades (0 si la unidad no está definida para el producto)
+	GetFactorConversion(ctx context.Context, codigoProducto, unidad string) (int, error)
+
+	// Operaciones de locales
+	GetLocalByID(ctx context.Context, idLocal int) (*models.Local, error)
+
+	// Bodegas dentro del local (dentro de la transacción si existe)
+	GetBodegas(ctx context.Context, idLocal int) ([]*models.Bodega, error)
+	GetBodegaByID(ctx context.Context, id int) (*models.Bodega, error)
+	// AsegurarBodegaSala retorna la sala de venta del local, creándola si el local aún no tiene
+	AsegurarBodegaSala(ctx context.Context, idLocal int) (*models.Bodega, error)
+	CreateBodega(ctx context.Context, bodega *models.Bodega) error
+	UpdateBodega(ctx context.Context, bodega *models.Bodega) error
+	// GetStockEnBodega cantidad del producto en una bodega que no es la sala (0 si no tiene)
+	GetStockEnBodega(ctx context.Context, codigoProducto string, idBodega int) (int, error)
+	// GetStockFueraDeSala cantidad del producto en las bodegas del local que no son la sala
+	GetStockFueraDeSala(ctx context.Context, codigoProducto string, idLocal int) (int, error)
+	// AjustarStockBodega suma delta a la cantidad del producto en la bodega y retorna la resultante
+	AjustarStockBodega(ctx context.Context, codigoProducto string, idLocal, idBodega, delta int) (int, error)
+	// GetStockPorBodega stock del local con lo que está fuera de la sala por bodega
+	// (Bodegas solo trae las que no son la sala; codigoProducto nil: todos los productos)
+	GetStockPorBodega(ctx context.Context, idLocal int, codigoProducto *string) ([]*models.StockPorBodega, error)
+
+	// Transferencias directas entre locales (dentro de la transacción si existe)
+	CreateTransferencia(ctx context.Context, transferencia *models.TransferenciaStock) error
+	// GetTransferencia transferencia con sus ítems (nil si no existe); dentro de una transacción
+	// toma un lock sobre ella hasta el commit
+	GetTransferencia(ctx context.Context, id int64) (*models.TransferenciaStock, error)
+	GetTransferencias(ctx context.Context, filter *models.TransferenciaFilter) ([]*models.TransferenciaStock, error)
+	// AnularTransferencia marca la transferencia como anulada con la operación que la revirtió
+	AnularTransferencia(ctx context.Context, transferencia *models.TransferenciaStock) error
+
+	// Demanda histórica: unidades de salida por producto desde una fecha
+	GetSalidasDesde(ctx context.Context, idLocal int, desde time.Time) (map[string]int, error)
+
+	// Reservas (picking preparado y vigente)
+	GetCantidadReservada(ctx context.Context, codigoProducto string, idLocal int) (int, error)
+	// GetCantidadEnTransito cantidad enviada al local en guías de despacho aún no recibidas
+	GetCantidadEnTransito(ctx context.Context, codigoProducto string, idLocal int) (int, error)
+
+	// Auditoría: stock que no coincide con el reconstruido desde los movimientos
+	GetDescuadresStock(ctx context.Context, idLocal *int, codigoProducto *string) ([]*models.DescuadreStock, error)
+
+	// EsProductoCritico indica si el producto está marcado como crítico en el local
+	EsProductoCritico(ctx context.Context, codigoProducto string, idLocal int) (bool, error)
+
+	// Outbox: evento a publicar, escrito en la transacción de la operación que lo origina
+	CreateEventoOutbox(ctx context.Context, evento *models.EventoOutbox) error
+
+	// ConfirmarCierreToma marca la toma 'cerrando' como cerrada con la operación de sus ajustes
+	// (dentro de la transacción si existe); ErrNotFound si la toma ya no se está cerrando
+	ConfirmarCierreToma(ctx context.Context, id int64, idOperacion string, ajustados, idUsuario int) error
+
+	// BloquearCadenas toma el lock de la cadena de movimientos de los locales (en orden de id) para la
+	// transacción; va al inicio de la operación, antes de bloquear filas de stock, para que dos
+	// operaciones sobre los mismos locales no se bloqueen entre sí. Fuera de una transacción no hace nada
+	BloquearCadenas(ctx context.Context, idLocales ...int) error
+
+	// Transacciones
+	// RunInTransaction ejecuta fn con un repository ligado a una transacción;
+	// si fn retorna error se hace rollback, si no commit. Las llamadas anidadas reutilizan la transacción
+	RunInTransaction(ctx context.Context, fn func(repo StockRepository) error) error
 }
 
 // stockRepository implementa StockRepository
 type stockRepository struct {
 	db    *sql.DB
 	stmts map[string]*sql.Stmt
+	tx    *sql.Tx // no nil si el repository está ligado a una transacción
+	// Locales cuya cadena de movimientos ya bloqueó la transacción
+	cadenas map[int]bool
 }
 
 // NewStockRepository crea una nueva instancia del repository
@@ -67,6 +169,65 @@ func (r *stockRepository) prepareStatements() error {
 			SET cantidad_actual = $1, cantidad_minima = $2, updated_at = NOW()
 			WHERE codigo_producto = $3 AND id_local = $4
 		`,
+		"create_transferencia": `
+			INSERT INTO transferencias_stock_cantera
+			(id_local_origen, id_local_destino, estado, motivo, observaciones, id_usuario, id_operacion)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at
+		`,
+		"create_transferencia_item": `
+			INSERT INTO transferencia_stock_items_cantera (id_transferencia, codigo_producto, tipo_item, cantidad)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id
+		`,
+		"get_transferencia": `
+			SELECT id, id_local_origen, id_local_destino, estado, motivo, observaciones, id_usuario,
+				   id_operacion, id_operacion_anulacion, motivo_anulacion, id_usuario_anulacion, anulada_at, created_at
+			FROM transferencias_stock_cantera
+			WHERE id = $1
+		`,
+		"lock_transferencia": `
+			SELECT id, id_local_origen, id_local_destino, estado, motivo, observaciones, id_usuario,
+				   id_operacion, id_operacion_anulacion, motivo_anulacion, id_usuario_anulacion, anulada_at, created_at
+			FROM transferencias_stock_cantera
+			WHERE id = $1
+			FOR UPDATE
+		`,
+		"get_transferencia_items": `
+			SELECT id, id_transferencia, codigo_producto, tipo_item, cantidad
+			FROM transferencia_stock_items_cantera
+			WHERE id_transferencia = $1
+			ORDER BY id
+		`,
+		"get_transferencias": `
+			SELECT id, id_local_origen, id_local_destino, estado, motivo, observaciones, id_usuario,
+				   id_operacion, id_operacion_anulacion, motivo_anulacion, id_usuario_anulacion, anulada_at, created_at
+			FROM transferencias_stock_cantera
+			WHERE ($1::int IS NULL OR id_local_origen = $1 OR id_local_destino = $1)
+			  AND ($2::text IS NULL OR estado = $2)
+			ORDER BY created_at DESC, id DESC
+			LIMIT $3
+		`,
+		"anular_transferencia": `
+			UPDATE transferencias_stock_cantera
+			SET estado = 'anulada', id_operacion_anulacion = $2, motivo_anulacion = $3,
+				id_usuario_anulacion = $4, anulada_at = NOW()
+			WHERE id = $1 AND estado = 'aplicada'
+			RETURNING anulada_at
+		`,
+		// Incremento y descuento en la misma sentencia: no hay ventana entre leer y escribir
+		"increment_stock": `
+			UPDATE stock_bodega_cantera
+			SET cantidad_actual = cantidad_actual + $1, updated_at = NOW()
+			WHERE codigo_producto = $2 AND id_local = $3
+			RETURNING cantidad_actual
+		`,
+		"decrement_stock": `
+			UPDATE stock_bodega_cantera
+			SET cantidad_actual = cantidad_actual - $1, updated_at = NOW()
+			WHERE codigo_producto = $2 AND id_local = $3 AND cantidad_actual >= $1 + $4
+			RETURNING cantidad_actual
+		`,
 		"create_stock": `
 			INSERT INTO stock_bodega_cantera 
 			(codigo_producto, tipo_item, cantidad_actual, cantidad_minima, id_local)
@@ -80,12 +241,22 @@ func (r *stockRepository) prepareStatements() error {
 			WHERE id_local = $1
 			ORDER BY codigo_producto
 		`,
-		"get_stock_bajo": `
+		"get_stock_modificado_desde": `
 			SELECT id, codigo_producto, tipo_item, cantidad_actual, cantidad_minima, 
 				   id_local, created_at, updated_at
 			FROM stock_bodega_cantera 
-			WHERE id_local = $1 AND cantidad_actual <= cantidad_minima
-			ORDER BY cantidad_actual ASC
+			WHERE id_local = $1 AND ($2::timestamp IS NULL OR updated_at > $2)
+			ORDER BY updated_at, id
+		`,
+		// El mínimo vigente es el estacional de hoy si hay uno; si no, el del stock
+		"get_stock_bajo": `
+			SELECT s.id, s.codigo_producto, s.tipo_item, s.cantidad_actual,
+				   COALESCE(me.cantidad_minima, s.cantidad_minima), s.cantidad_minima, me.temporada,
+				   s.id_local, s.created_at, s.updated_at
+			FROM stock_bodega_cantera s
+			` + minimoEstacionalVigenteJoin + `
+			WHERE s.id_local = $1 AND s.cantidad_actual <= COALESCE(me.cantidad_minima, s.cantidad_minima)
+			ORDER BY s.cantidad_actual ASC
 		`,
 		"get_stock_complete_by_local": `
 			SELECT 
@@ -103,20 +274,110 @@ func (r *stockRepository) prepareStatements() error {
 			WHERE s.id_local = $1
 			ORDER BY s.codigo_producto
 		`,
+		"get_stock_descontinuado_by_local": `
+			SELECT 
+				s.id, s.codigo_producto, s.tipo_item, s.cantidad_actual, s.cantidad_minima, 
+				s.id_local, s.created_at, s.updated_at,
+				p.nombre as nombre_producto, p.codigo_barra_interno, p.codigo_barra_externo,
+				p.descripcion, p.precio, p.unidad, p.id_categoria, p.es_servicio, p.es_exento,
+				p.impuesto_especifico, p.disponible_para_venta, p.activo, p.utilidad, p.tipo_utilidad,
+				c.nombre as nombre_categoria,
+				l.nombre_local as nombre_local
+			FROM stock_bodega_cantera s
+			LEFT JOIN productos p ON s.codigo_producto = p.codigo
+			LEFT JOIN categorias c ON p.id_categoria = c.id
+			LEFT JOIN locales l ON s.id_local = l.id
+			WHERE s.id_local = $1 AND p.activo = false AND s.cantidad_actual > 0
+			ORDER BY s.codigo_producto
+		`,
+		// Una sola pasada agregada por local; los locales sin stock aparecen en cero
+		"get_stock_summary": `
+			SELECT
+				l.id, l.nombre_local,
+				COUNT(s.id) FILTER (WHERE s.tipo_item = 'producto'),
+				COUNT(s.id) FILTER (WHERE s.tipo_item = 'pack'),
+				COUNT(s.id) FILTER (WHERE s.cantidad_actual <= COALESCE(me.cantidad_minima, s.cantidad_minima)),
+				COALESCE(SUM(GREATEST(s.cantidad_actual, 0) * COALESCE(lp.precio_detalle, p.precio, pk.precio_base, 0)), 0)
+			FROM locales l
+			LEFT JOIN stock_bodega_cantera s ON s.id_local = l.id
+			` + minimoEstacionalVigenteJoin + `
+			LEFT JOIN productos p ON s.tipo_item = 'producto' AND p.codigo = s.codigo_producto
+			LEFT JOIN (
+				SELECT DISTINCT ON (codigo_pack) codigo_pack, precio_base
+				FROM pack_listados
+			) pk ON s.tipo_item = 'pack' AND pk.codigo_pack = s.codigo_producto
+			LEFT JOIN lista_precios_cantera lp ON lp.codigo_tivendo = s.codigo_producto
+			WHERE $1::int IS NULL OR l.id = $1
+			GROUP BY l.id, l.nombre_local
+			ORDER BY l.id
+		`,
 		"create_movimiento": `
 			INSERT INTO stock_movimientos_cantera 
 			(codigo_producto, tipo_item, tipo_movimiento, cantidad, cantidad_anterior, 
-			 cantidad_nueva, motivo, id_usuario, id_local, observaciones)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			 cantidad_nueva, motivo, id_usuario, id_local, observaciones,
+			 documento_tipo, documento_numero, documento_fecha, unidad, cantidad_unidad, id_operacion,
+			 id_bodega, id_bodega_destino)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 			RETURNING id, created_at
 		`,
+		"lock_cadena_movimientos": `
+			SELECT pg_advisory_xact_lock(hashtext('cadena_movimientos:' || $1::text))
+		`,
+		"get_ultimo_hash": `
+			SELECT hash FROM stock_movimientos_cantera
+			WHERE id_local = $1 AND hash IS NOT NULL
+			ORDER BY id DESC
+			LIMIT 1
+		`,
+		"get_hash_previo": `
+			SELECT hash FROM stock_movimientos_cantera
+			WHERE id_local = $1 AND hash IS NOT NULL AND id < $2
+			ORDER BY id DESC
+			LIMIT 1
+		`,
+		"sellar_movimiento": `
+			UPDATE stock_movimientos_cantera
+			SET hash = $2, hash_anterior = $3
+			WHERE id = $1
+		`,
+		"get_cadena_movimientos": `
+			WITH rango AS (
+				SELECT MIN(id) AS desde, MAX(id) AS hasta
+				FROM stock_movimientos_cantera
+				WHERE id_local = $1
+				  AND ($2::timestamp IS NULL OR created_at >= $2)
+				  AND ($3::timestamp IS NULL OR created_at < $3::timestamp + INTERVAL '1 day')
+			)
+			SELECT m.id, m.codigo_producto, m.tipo_item, m.tipo_movimiento, m.cantidad, m.cantidad_anterior,
+				   m.cantidad_nueva, m.motivo, m.id_usuario, m.id_local, COALESCE(m.observaciones, ''), m.created_at,
+				   m.documento_tipo, m.documento_numero, m.documento_fecha, m.unidad, m.cantidad_unidad, m.id_operacion,
+				   m.id_bodega, m.id_bodega_destino, m.hash, m.hash_anterior
+			FROM stock_movimientos_cantera m, rango
+			WHERE m.id_local = $1 AND m.id BETWEEN rango.desde AND rango.hasta
+			ORDER BY m.id
+		`,
+		"lock_documento": `
+			SELECT pg_advisory_xact_lock(hashtext('documento:' || $1 || ':' || $2))
+		`,
+		"existe_documento": `
+			SELECT EXISTS (
+				SELECT 1 FROM stock_movimientos_cantera
+				WHERE documento_tipo = $1 AND documento_numero = $2
+			)
+		`,
+		"es_producto_critico": `
+			SELECT EXISTS (
+				SELECT 1 FROM productos_criticos_cantera
+				WHERE codigo_producto = $1 AND id_local = $2
+			)
+		`,
 		"get_producto": `
 			SELECT id, codigo, nombre, unidad, precio, codigo_barra_interno, 
 				   codigo_barra_externo, descripcion, es_servicio, es_exento,
 				   impuesto_especifico, id_categoria, disponible_para_venta, 
 				   activo, utilidad, tipo_utilidad
 			FROM productos 
-			WHERE codigo = $1 AND activo = true
+			WHERE codigo = $1
 		`,
 		"get_pack": `
 			SELECT id, codigo_pack, cod_barra_pack, nombre_pack, precio_base,
@@ -130,6 +391,164 @@ func (r *stockRepository) prepareStatements() error {
 			FROM pack_listados 
 			WHERE codigo_articulo = $1
 		`,
+		"get_componentes_pack": `
+			SELECT id, codigo_pack, cod_barra_pack, nombre_pack, precio_base,
+				   cantidad_articulo, codigo_articulo, cod_barra_articulo, nombre_articulo
+			FROM pack_listados 
+			WHERE codigo_pack = $1
+		`,
+		"get_salidas_desde": `
+			SELECT codigo_producto, COALESCE(SUM(cantidad), 0)
+			FROM stock_movimientos_cantera
+			WHERE id_local = $1 AND tipo_movimiento = 'salida' AND created_at >= $2
+			GROUP BY codigo_producto
+		`,
+		"get_cantidad_reservada": `
+			SELECT COALESCE(SUM(pi.cantidad_solicitada), 0)
+			FROM picking_items_cantera pi
+			JOIN pickings_cantera p ON p.id = pi.id_picking
+			WHERE pi.codigo_producto = $1 AND p.id_local = $2
+			  AND p.estado = 'preparado' AND p.expires_at > NOW()
+		`,
+		"get_cantidad_en_transito": `
+			SELECT COALESCE(SUM(i.cantidad_enviada), 0)
+			FROM guia_despacho_items_cantera i
+			JOIN guias_despacho_cantera g ON g.id = i.id_guia
+			WHERE i.codigo_producto = $1 AND g.id_local_destino = $2
+			  AND g.estado IN ('emitida', 'en_transito', 'recibiendo')
+		`,
+		"get_factor_conversion": `
+			SELECT factor
+			FROM conversiones_unidad_cantera
+			WHERE codigo_producto = $1 AND unidad = $2
+		`,
+		// Stock teórico por producto/local: cantidad anterior del primer movimiento + entradas - salidas
+		// (los ajustes ya llevan la diferencia con signo).
+		// Un movimiento quiebra la cadena si no parte de la cantidad en que quedó el anterior;
+		// las salidas de servicios (sin stock: anterior y nueva en 0) y los traslados internos entre
+		// bodegas (no cambian el total del local) no se consideran
+		"get_descuadres_stock": `
+			WITH movs AS (
+				SELECT m.id, m.codigo_producto, m.id_local, m.created_at, m.cantidad_anterior, m.cantidad_nueva,
+					   CASE WHEN m.tipo_movimiento IN ('entrada', 'transferencia_entrada', 'ajuste') THEN m.cantidad ELSE -m.cantidad END AS delta,
+					   ROW_NUMBER() OVER w AS orden,
+					   COALESCE(m.cantidad_anterior <> LAG(m.cantidad_nueva) OVER w, false) AS quiebre
+				FROM stock_movimientos_cantera m
+				WHERE ($1::int IS NULL OR m.id_local = $1)
+				  AND ($2::text IS NULL OR m.codigo_producto = $2)
+				  AND NOT (m.tipo_movimiento = 'salida' AND m.cantidad_anterior = 0 AND m.cantidad_nueva = 0)
+				  AND m.tipo_movimiento <> 'traslado_interno'
+				WINDOW w AS (PARTITION BY m.codigo_producto, m.id_local ORDER BY m.created_at, m.id)
+			),
+			marcados AS (
+				SELECT movs.*,
+					   BOOL_OR(quiebre) OVER (PARTITION BY codigo_producto, id_local ORDER BY orden) AS roto
+				FROM movs
+			),
+			resumen AS (
+				SELECT codigo_producto, id_local,
+					   (ARRAY_AGG(cantidad_anterior ORDER BY orden))[1] + SUM(delta) AS teorica,
+					   COUNT(*) AS movimientos,
+					   MAX(orden) FILTER (WHERE NOT roto) AS orden_cuadre
+				FROM marcados
+				GROUP BY codigo_producto, id_local
+			)
+			SELECT s.codigo_producto, s.tipo_item, s.id_local, s.cantidad_actual, r.teorica, r.movimientos,
+				   u.id, u.created_at, u.cantidad_nueva
+			FROM stock_bodega_cantera s
+			JOIN resumen r ON r.codigo_producto = s.codigo_producto AND r.id_local = s.id_local
+			LEFT JOIN marcados u ON u.codigo_producto = r.codigo_producto AND u.id_local = r.id_local
+				AND u.orden = r.orden_cuadre
+			WHERE s.cantidad_actual <> r.teorica
+			ORDER BY s.id_local, s.codigo_producto
+		`,
+		"create_evento_outbox": `
+			INSERT INTO outbox_eventos_cantera (tipo, clave, payload)
+			VALUES ($1, $2, $3)
+			RETURNING id, proximo_intento_at, created_at
+		`,
+		"confirmar_cierre_toma": `
+			UPDATE tomas_inventario_cantera
+			SET estado = 'cerrada', id_operacion = NULLIF($2, ''), ajustados = $3,
+				id_usuario_cierre = $4, cerrada_at = NOW()
+			WHERE id = $1 AND estado = 'cerrando'
+		`,
+		"create_cambio_minimo": `
+			INSERT INTO historial_cantidad_minima_cantera
+			(codigo_producto, id_local, cantidad_anterior, cantidad_nueva, id_usuario, motivo, id_operacion)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at
+		`,
+		"get_historial_minimos": `
+			SELECT id, codigo_producto, id_local, cantidad_anterior, cantidad_nueva,
+				   id_usuario, COALESCE(motivo, ''), id_operacion, created_at
+			FROM historial_cantidad_minima_cantera
+			WHERE codigo_producto = $1 AND ($2::int IS NULL OR id_local = $2)
+			ORDER BY created_at DESC, id DESC
+			LIMIT $3
+		`,
+		"get_local": `
+			SELECT id, nombre_local, activo
+			FROM locales
+			WHERE id = $1
+		`,
+		"get_bodegas": `
+			SELECT id, id_local, nombre, tipo, activa, created_at, updated_at
+			FROM bodegas_cantera
+			WHERE id_local = $1
+			ORDER BY tipo <> 'sala', nombre
+		`,
+		"get_bodega": `
+			SELECT id, id_local, nombre, tipo, activa, created_at, updated_at
+			FROM bodegas_cantera
+			WHERE id = $1
+		`,
+		"get_bodega_sala": `
+			SELECT id, id_local, nombre, tipo, activa, created_at, updated_at
+			FROM bodegas_cantera
+			WHERE id_local = $1 AND tipo = 'sala'
+		`,
+		"create_bodega_sala": `
+			INSERT INTO bodegas_cantera (id_local, nombre, tipo)
+			VALUES ($1, 'Sala de venta', 'sala')
+			ON CONFLICT DO NOTHING
+		`,
+		"create_bodega": `
+			INSERT INTO bodegas_cantera (id_local, nombre, tipo, activa)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at, updated_at
+		`,
+		"update_bodega": `
+			UPDATE bodegas_cantera
+			SET nombre = $1, activa = $2, updated_at = NOW()
+			WHERE id = $3
+			RETURNING updated_at
+		`,
+		"get_stock_en_bodega": `
+			SELECT cantidad
+			FROM stock_por_bodega_cantera
+			WHERE codigo_producto = $1 AND id_bodega = $2
+		`,
+		"get_stock_fuera_de_sala": `
+			SELECT COALESCE(SUM(cantidad), 0)
+			FROM stock_por_bodega_cantera
+			WHERE codigo_producto = $1 AND id_local = $2
+		`,
+		"ajustar_stock_bodega": `
+			INSERT INTO stock_por_bodega_cantera (codigo_producto, id_local, id_bodega, cantidad)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (codigo_producto, id_bodega)
+			DO UPDATE SET cantidad = stock_por_bodega_cantera.cantidad + EXCLUDED.cantidad, updated_at = NOW()
+			RETURNING cantidad
+		`,
+		"get_stock_por_bodega": `
+			SELECT s.codigo_producto, s.cantidad_actual, b.id_bodega, b.cantidad
+			FROM stock_bodega_cantera s
+			LEFT JOIN stock_por_bodega_cantera b ON b.codigo_producto = s.codigo_producto
+				AND b.id_local = s.id_local AND b.cantidad <> 0
+			WHERE s.id_local = $1 AND ($2::text IS NULL OR s.codigo_producto = $2)
+			ORDER BY s.codigo_producto, b.id_bodega
+		`,
 	}
 
 	for name, query := range statements {
@@ -143,10 +562,87 @@ func (r *stockRepository) prepareStatements() error {
 	return nil
 }
 
+// stmt retorna el statement preparado, ligado a la transacción si existe
+func (r *stockRepository) stmt(ctx context.Context, name string) *sql.Stmt {
+	if r.tx != nil {
+		return r.tx.StmtContext(ctx, r.stmts[name])
+	}
+	return r.stmts[name]
+}
+
+// query ejecuta una consulta armada en tiempo de ejecución (dentro de la transacción si existe)
+func (r *stockRepository) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if r.tx != nil {
+		return r.tx.QueryContext(ctx, query, args...)
+	}
+	return r.db.QueryContext(ctx, query, args...)
+}
+
+// queryRow como query para consultas de una fila
+func (r *stockRepository) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if r.tx != nil {
+		return r.tx.QueryRowContext(ctx, query, args...)
+	}
+	return r.db.QueryRowContext(ctx, query, args...)
+}
+
+// RunInTransaction ejecuta fn dentro de una transacción
+func (r *stockRepository) RunInTransaction(ctx context.Context, fn func(repo StockRepository) error) error {
+	// Transacción anidada: reutilizar la actual
+	if r.tx != nil {
+		return fn(r)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txRepo := &stockRepository{
+		db:      r.db,
+		stmts:   r.stmts,
+		tx:      tx,
+		cadenas: make(map[int]bool),
+	}
+
+	if err := fn(txRepo); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// BloquearCadenas toma los locks de cadena que la transacción aún no tiene, en orden de id de local
+// Los movimientos de un local quedan serializados hasta el commit: el orden de los id es el de la cadena
+func (r *stockRepository) BloquearCadenas(ctx context.Context, idLocales ...int) error {
+	if r.tx == nil {
+		return nil
+	}
+
+	locales := append([]int(nil), idLocales...)
+	sort.Ints(locales)
+	for _, idLocal := range locales {
+		if r.cadenas[idLocal] {
+			continue
+		}
+		if _, err := r.stmt(ctx, "lock_cadena_movimientos").ExecContext(ctx, idLocal); err != nil {
+			return fmt.Errorf("failed to lock cadena movimientos: %w", err)
+		}
+		r.cadenas[idLocal] = true
+	}
+
+	return nil
+}
+
 // GetStockByProducto obtiene el stock de un producto específico
 func (r *stockRepository) GetStockByProducto(ctx context.Context, codigoProducto string, idLocal int) (*models.Stock, error) {
 	var stock models.Stock
-	err := r.stmts["get_stock"].QueryRowContext(ctx, codigoProducto, idLocal).Scan(
+	err := r.stmt(ctx, "get_stock").QueryRowContext(ctx, codigoProducto, idLocal).Scan(
 		&stock.ID, &stock.CodigoProducto, &stock.TipoItem, &stock.CantidadActual,
 		&stock.CantidadMinima, &stock.IDLocal, &stock.CreatedAt, &stock.UpdatedAt,
 	)
@@ -163,7 +659,11 @@ func (r *stockRepository) GetStockByProducto(ctx context.Context, codigoProducto
 
 // UpdateStock actualiza el stock de un producto
 func (r *stockRepository) UpdateStock(ctx context.Context, stock *models.Stock) error {
-	result, err := r.stmts["update_stock"].ExecContext(ctx,
+	// La cadena del local se bloquea antes que la fila (si la operación no lo hizo al iniciar)
+	if err := r.BloquearCadenas(ctx, stock.IDLocal); err != nil {
+		return err
+	}
+	result, err := r.stmt(ctx, "update_stock").ExecContext(ctx,
 		stock.CantidadActual, stock.CantidadMinima, stock.CodigoProducto, stock.IDLocal,
 	)
 	if err != nil {
@@ -182,9 +682,50 @@ func (r *stockRepository) UpdateStock(ctx context.Context, stock *models.Stock)
 	return nil
 }
 
+// IncrementStock suma cantidad al stock del producto en el local de forma atómica
+func (r *stockRepository) IncrementStock(ctx context.Context, codigoProducto string, idLocal, cantidad int) (int, error) {
+	if err := r.BloquearCadenas(ctx, idLocal); err != nil {
+		return 0, err
+	}
+	var cantidadNueva int
+	err := r.stmt(ctx, "increment_stock").QueryRowContext(ctx, cantidad, codigoProducto, idLocal).Scan(&cantidadNueva)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment stock: %w", err)
+	}
+	return cantidadNueva, nil
+}
+
+// DecrementStock descuenta cantidad del stock del producto en el local de forma atómica
+// El chequeo de saldo va en el WHERE: si no alcanza no se modifica nada
+func (r *stockRepository) DecrementStock(ctx context.Context, codigoProducto string, idLocal, cantidad, minimoRestante int) (int, error) {
+	if err := r.BloquearCadenas(ctx, idLocal); err != nil {
+		return 0, err
+	}
+	var cantidadNueva int
+	err := r.stmt(ctx, "decrement_stock").QueryRowContext(ctx, cantidad, codigoProducto, idLocal, minimoRestante).Scan(&cantidadNueva)
+	if err == sql.ErrNoRows {
+		// Sin fila actualizada: distinguir producto sin stock en el local de saldo insuficiente
+		stock, err := r.GetStockByProducto(ctx, codigoProducto, idLocal)
+		if err != nil {
+			return 0, err
+		}
+		if stock == nil {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("%w: disponible %d, solicitado %d", ErrStockInsuficiente, stock.CantidadActual-minimoRestante, cantidad)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrement stock: %w", err)
+	}
+	return cantidadNueva, nil
+}
+
 // CreateStock crea un nuevo registro de stock
 func (r *stockRepository) CreateStock(ctx context.Context, stock *models.Stock) error {
-	err := r.stmts["create_stock"].QueryRowContext(ctx,
+	err := r.stmt(ctx, "create_stock").QueryRowContext(ctx,
 		stock.CodigoProducto, stock.TipoItem, stock.CantidadActual, stock.CantidadMinima, stock.IDLocal,
 	).Scan(&stock.ID, &stock.CreatedAt, &stock.UpdatedAt)
 
@@ -197,7 +738,7 @@ func (r *stockRepository) CreateStock(ctx context.Context, stock *models.Stock)
 
 // GetStockByLocal obtiene todo el stock de un local
 func (r *stockRepository) GetStockByLocal(ctx context.Context, idLocal int) ([]*models.Stock, error) {
-	rows, err := r.stmts["get_stock_by_local"].QueryContext(ctx, idLocal)
+	rows, err := r.stmt(ctx, "get_stock_by_local").QueryContext(ctx, idLocal)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get stock by local: %w", err)
 	}
@@ -219,9 +760,36 @@ func (r *stockRepository) GetStockByLocal(ctx context.Context, idLocal int) ([]*
 	return stocks, nil
 }
 
-// GetStockBajo obtiene productos con stock bajo
+// GetStockModificadoDesde obtiene el stock del local modificado después de desde
+func (r *stockRepository) GetStockModificadoDesde(ctx context.Context, idLocal int, desde *time.Time) ([]*models.Stock, error) {
+	rows, err := r.stmt(ctx, "get_stock_modificado_desde").QueryContext(ctx, idLocal, desde)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock modificado: %w", err)
+	}
+	defer rows.Close()
+
+	stocks := []*models.Stock{}
+	for rows.Next() {
+		var stock models.Stock
+		err := rows.Scan(
+			&stock.ID, &stock.CodigoProducto, &stock.TipoItem, &stock.CantidadActual,
+			&stock.CantidadMinima, &stock.IDLocal, &stock.CreatedAt, &stock.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock: %w", err)
+		}
+		stocks = append(stocks, &stock)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate stock modificado: %w", err)
+	}
+
+	return stocks, nil
+}
+
+// GetStockBajo obtiene productos bajo su mínimo vigente (el estacional de hoy o el del stock)
 func (r *stockRepository) GetStockBajo(ctx context.Context, idLocal int) ([]*models.Stock, error) {
-	rows, err := r.stmts["get_stock_bajo"].QueryContext(ctx, idLocal)
+	rows, err := r.stmt(ctx, "get_stock_bajo").QueryContext(ctx, idLocal)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get stock bajo: %w", err)
 	}
@@ -230,13 +798,18 @@ func (r *stockRepository) GetStockBajo(ctx context.Context, idLocal int) ([]*mod
 	var stocks []*models.Stock
 	for rows.Next() {
 		var stock models.Stock
+		var minimoBase int
 		err := rows.Scan(
 			&stock.ID, &stock.CodigoProducto, &stock.TipoItem, &stock.CantidadActual,
-			&stock.CantidadMinima, &stock.IDLocal, &stock.CreatedAt, &stock.UpdatedAt,
+			&stock.CantidadMinima, &minimoBase, &stock.Temporada,
+			&stock.IDLocal, &stock.CreatedAt, &stock.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan stock: %w", err)
 		}
+		if stock.Temporada != nil {
+			stock.CantidadMinimaBase = &minimoBase
+		}
 		stocks = append(stocks, &stock)
 	}
 
@@ -245,12 +818,28 @@ func (r *stockRepository) GetStockBajo(ctx context.Context, idLocal int) ([]*mod
 
 // GetStockCompleteByLocal obtiene stock con información completa del producto, categoría y local
 func (r *stockRepository) GetStockCompleteByLocal(ctx context.Context, idLocal int) ([]*models.StockComplete, error) {
-	rows, err := r.stmts["get_stock_complete_by_local"].QueryContext(ctx, idLocal)
+	rows, err := r.stmt(ctx, "get_stock_complete_by_local").QueryContext(ctx, idLocal)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get stock complete by local: %w", err)
 	}
 	defer rows.Close()
 
+	return scanStockComplete(rows)
+}
+
+// GetStockDescontinuadoByLocal obtiene el stock remanente de productos desactivados en el maestro
+func (r *stockRepository) GetStockDescontinuadoByLocal(ctx context.Context, idLocal int) ([]*models.StockComplete, error) {
+	rows, err := r.stmt(ctx, "get_stock_descontinuado_by_local").QueryContext(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock descontinuado by local: %w", err)
+	}
+	defer rows.Close()
+
+	return scanStockComplete(rows)
+}
+
+// scanStockComplete escanea filas de stock con información completa
+func scanStockComplete(rows *sql.Rows) ([]*models.StockComplete, error) {
 	var stocks []*models.StockComplete
 	for rows.Next() {
 		var stock models.StockComplete
@@ -267,32 +856,436 @@ func (r *stockRepository) GetStockCompleteByLocal(ctx context.Context, idLocal i
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan stock complete: %w", err)
 		}
+		// Producto desactivado en el maestro con stock que sigue en bodega
+		stock.Descontinuado = stock.Activo != nil && !*stock.Activo
 		stocks = append(stocks, &stock)
 	}
 
 	return stocks, nil
 }
 
-// CreateMovimiento crea un nuevo movimiento de stock
+// GetStockSummary obtiene el resumen de stock por local
+func (r *stockRepository) GetStockSummary(ctx context.Context, idLocal *int) ([]*models.StockSummary, error) {
+	rows, err := r.stmt(ctx, "get_stock_summary").QueryContext(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock summary: %w", err)
+	}
+	defer rows.Close()
+
+	resumen := []*models.StockSummary{}
+	for rows.Next() {
+		var summary models.StockSummary
+		if err := rows.Scan(
+			&summary.IDLocal, &summary.NombreLocal, &summary.TotalProductos, &summary.TotalPacks,
+			&summary.StockBajo, &summary.Valorizacion,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan stock summary: %w", err)
+		}
+		resumen = append(resumen, &summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate stock summary: %w", err)
+	}
+
+	return resumen, nil
+}
+
+// CreateMovimiento crea un nuevo movimiento de stock y lo sella en la cadena del local
+// El sellado necesita transacción: fuera de una se abre una propia
 func (r *stockRepository) CreateMovimiento(ctx context.Context, movimiento *models.Movimiento) error {
-	err := r.stmts["create_movimiento"].QueryRowContext(ctx,
+	if r.tx == nil {
+		return r.RunInTransaction(ctx, func(repo StockRepository) error {
+			return repo.CreateMovimiento(ctx, movimiento)
+		})
+	}
+
+	// Normalmente la operación ya tomó el lock al iniciar la transacción (BloquearCadenas)
+	if err := r.BloquearCadenas(ctx, movimiento.IDLocal); err != nil {
+		return err
+	}
+	var hashAnterior sql.NullString
+	err := r.stmt(ctx, "get_ultimo_hash").QueryRowContext(ctx, movimiento.IDLocal).Scan(&hashAnterior)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get ultimo hash: %w", err)
+	}
+
+	err = r.stmt(ctx, "create_movimiento").QueryRowContext(ctx,
 		movimiento.CodigoProducto, movimiento.TipoItem, movimiento.TipoMovimiento,
 		movimiento.Cantidad, movimiento.CantidadAnterior, movimiento.CantidadNueva,
 		movimiento.Motivo, movimiento.IDUsuario, movimiento.IDLocal, movimiento.Observaciones,
+		movimiento.DocumentoTipo, movimiento.DocumentoNumero, movimiento.DocumentoFecha,
+		movimiento.Unidad, movimiento.CantidadUnidad, movimiento.IDOperacion,
+		movimiento.IDBodega, movimiento.IDBodegaDestino,
 	).Scan(&movimiento.ID, &movimiento.CreatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to create movimiento: %w", err)
 	}
 
+	hash := movimiento.CalcularHash(hashAnterior.String)
+	var anterior *string
+	if hashAnterior.Valid {
+		anterior = &hashAnterior.String
+	}
+	if _, err := r.stmt(ctx, "sellar_movimiento").ExecContext(ctx, movimiento.ID, hash, anterior); err != nil {
+		return fmt.Errorf("failed to seal movimiento: %w", err)
+	}
+	movimiento.Hash = &hash
+	movimiento.HashAnterior = anterior
+
+	return nil
+}
+
+// CreateCambioMinimo registra un cambio de cantidad mínima (dentro de la transacción si existe)
+func (r *stockRepository) CreateCambioMinimo(ctx context.Context, cambio *models.CambioCantidadMinima) error {
+	err := r.stmt(ctx, "create_cambio_minimo").QueryRowContext(ctx,
+		cambio.CodigoProducto, cambio.IDLocal, cambio.CantidadAnterior, cambio.CantidadNueva,
+		cambio.IDUsuario, cambio.Motivo, cambio.IDOperacion,
+	).Scan(&cambio.ID, &cambio.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create cambio cantidad minima: %w", err)
+	}
+
+	return nil
+}
+
+// GetHistorialMinimos obtiene los cambios de cantidad mínima de un producto
+func (r *stockRepository) GetHistorialMinimos(ctx context.Context, codigoProducto string, idLocal *int, limit int) ([]*models.CambioCantidadMinima, error) {
+	rows, err := r.stmt(ctx, "get_historial_minimos").QueryContext(ctx, codigoProducto, idLocal, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get historial cantidad minima: %w", err)
+	}
+	defer rows.Close()
+
+	historial := []*models.CambioCantidadMinima{}
+	for rows.Next() {
+		var cambio models.CambioCantidadMinima
+		if err := rows.Scan(
+			&cambio.ID, &cambio.CodigoProducto, &cambio.IDLocal, &cambio.CantidadAnterior, &cambio.CantidadNueva,
+			&cambio.IDUsuario, &cambio.Motivo, &cambio.IDOperacion, &cambio.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan cambio cantidad minima: %w", err)
+		}
+		historial = append(historial, &cambio)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate historial cantidad minima: %w", err)
+	}
+
+	return historial, nil
+}
+
+// CreateEventoOutbox registra un evento en la outbox (dentro de la transacción si existe)
+func (r *stockRepository) CreateEventoOutbox(ctx context.Context, evento *models.EventoOutbox) error {
+	err := r.stmt(ctx, "create_evento_outbox").QueryRowContext(ctx,
+		evento.Tipo, evento.Clave, []byte(evento.Payload),
+	).Scan(&evento.ID, &evento.ProximoIntentoAt, &evento.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create evento outbox: %w", err)
+	}
+
+	return nil
+}
+
+// ConfirmarCierreToma cierra la toma en la transacción de sus ajustes
+func (r *stockRepository) ConfirmarCierreToma(ctx context.Context, id int64, idOperacion string, ajustados, idUsuario int) error {
+	result, err := r.stmt(ctx, "confirmar_cierre_toma").ExecContext(ctx, id, idOperacion, ajustados, idUsuario)
+	if err != nil {
+		return fmt.Errorf("failed to confirmar cierre toma inventario: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
 	return nil
 }
 
-// GetMovimientosByLocal obtiene movimientos de un local con filtros
+// movimientosWhere arma el WHERE de las consultas de movimientos con solo los filtros informados
+// Sin condiciones OR-NULL el planner puede usar el índice que corresponde a cada combinación
+func movimientosWhere(filter *models.MovimientoFilter) (string, []interface{}) {
+	var condiciones []string
+	var args []interface{}
+	agregar := func(condicion string, valor interface{}) {
+		args = append(args, valor)
+		condiciones = append(condiciones, strings.ReplaceAll(condicion, "?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if filter.IDLocal != nil {
+		agregar("id_local = ?", *filter.IDLocal)
+	}
+	if filter.TipoMovimiento != nil {
+		agregar("tipo_movimiento = ?", *filter.TipoMovimiento)
+	}
+	if filter.TipoItem != nil {
+		agregar("tipo_item = ?", *filter.TipoItem)
+	}
+	if filter.CodigoProducto != nil {
+		agregar("codigo_producto = ?", *filter.CodigoProducto)
+	}
+	if filter.FechaDesde != nil {
+		agregar("created_at >= ?", *filter.FechaDesde)
+	}
+	if filter.FechaHasta != nil {
+		// Fecha hasta inclusiva: todo el día indicado
+		agregar("created_at < ?::timestamp + INTERVAL '1 day'", *filter.FechaHasta)
+	}
+	if filter.DocumentoTipo != nil {
+		agregar("documento_tipo = ?", *filter.DocumentoTipo)
+	}
+	if filter.DocumentoNumero != nil {
+		agregar("documento_numero = ?", *filter.DocumentoNumero)
+	}
+	if filter.IDOperacion != nil {
+		agregar("id_operacion = ?", *filter.IDOperacion)
+	}
+	if filter.IDBodega != nil {
+		agregar("(id_bodega = ? OR id_bodega_destino = ?)", *filter.IDBodega)
+	}
+
+	if len(condiciones) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(condiciones, " AND "), args
+}
+
+// GetMovimientosByLocal obtiene una página de movimientos con filtros (los filtros nil no se aplican)
 func (r *stockRepository) GetMovimientosByLocal(ctx context.Context, filter *models.MovimientoFilter) ([]*models.Movimiento, error) {
-	// TODO: Implementar consulta dinámica con filtros
-	// Por ahora retornamos una implementación básica
-	return []*models.Movimiento{}, nil
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	offset := filter.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	where, args := movimientosWhere(filter)
+	args = append(args, limit, offset)
+	query := fmt.Sprintf(`
+		SELECT id, codigo_producto, tipo_item, tipo_movimiento, cantidad, cantidad_anterior,
+			   cantidad_nueva, motivo, id_usuario, id_local, COALESCE(observaciones, ''), created_at,
+			   documento_tipo, documento_numero, documento_fecha, unidad, cantidad_unidad, id_operacion,
+			   id_bodega, id_bodega_destino, hash, hash_anterior
+		FROM stock_movimientos_cantera
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := r.query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get movimientos: %w", err)
+	}
+	defer rows.Close()
+
+	movimientos := []*models.Movimiento{}
+	for rows.Next() {
+		var movimiento models.Movimiento
+		err := rows.Scan(
+			&movimiento.ID, &movimiento.CodigoProducto, &movimiento.TipoItem, &movimiento.TipoMovimiento,
+			&movimiento.Cantidad, &movimiento.CantidadAnterior, &movimiento.CantidadNueva,
+			&movimiento.Motivo, &movimiento.IDUsuario, &movimiento.IDLocal, &movimiento.Observaciones,
+			&movimiento.CreatedAt,
+			&movimiento.DocumentoTipo, &movimiento.DocumentoNumero, &movimiento.DocumentoFecha,
+			&movimiento.Unidad, &movimiento.CantidadUnidad, &movimiento.IDOperacion,
+			&movimiento.IDBodega, &movimiento.IDBodegaDestino, &movimiento.Hash, &movimiento.HashAnterior,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan movimiento: %w", err)
+		}
+		movimientos = append(movimientos, &movimiento)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate movimientos: %w", err)
+	}
+
+	return movimientos, nil
+}
+
+// CountMovimientos cuenta los movimientos que cumplen el filtro
+func (r *stockRepository) CountMovimientos(ctx context.Context, filter *models.MovimientoFilter) (int, error) {
+	where, args := movimientosWhere(filter)
+
+	var total int
+	err := r.queryRow(ctx, "SELECT COUNT(*) FROM stock_movimientos_cantera "+where, args...).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count movimientos: %w", err)
+	}
+	return total, nil
+}
+
+// RecorrerMovimientos recorre fila a fila los movimientos que cumplen el filtro, sin paginar
+func (r *stockRepository) RecorrerMovimientos(ctx context.Context, filter *models.MovimientoFilter, fn func(*models.Movimiento) error) error {
+	where, args := movimientosWhere(filter)
+	query := fmt.Sprintf(`
+		SELECT id, codigo_producto, tipo_item, tipo_movimiento, cantidad, cantidad_anterior,
+			   cantidad_nueva, motivo, id_usuario, id_local, COALESCE(observaciones, ''), created_at,
+			   documento_tipo, documento_numero, documento_fecha, unidad, cantidad_unidad, id_operacion,
+			   id_bodega, id_bodega_destino, hash, hash_anterior
+		FROM stock_movimientos_cantera
+		%s
+		ORDER BY created_at, id
+	`, where)
+
+	rows, err := r.query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query movimientos: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movimiento models.Movimiento
+		if err := rows.Scan(
+			&movimiento.ID, &movimiento.CodigoProducto, &movimiento.TipoItem, &movimiento.TipoMovimiento,
+			&movimiento.Cantidad, &movimiento.CantidadAnterior, &movimiento.CantidadNueva,
+			&movimiento.Motivo, &movimiento.IDUsuario, &movimiento.IDLocal, &movimiento.Observaciones,
+			&movimiento.CreatedAt,
+			&movimiento.DocumentoTipo, &movimiento.DocumentoNumero, &movimiento.DocumentoFecha,
+			&movimiento.Unidad, &movimiento.CantidadUnidad, &movimiento.IDOperacion,
+			&movimiento.IDBodega, &movimiento.IDBodegaDestino, &movimiento.Hash, &movimiento.HashAnterior,
+		); err != nil {
+			return fmt.Errorf("failed to scan movimiento: %w", err)
+		}
+		if err := fn(&movimiento); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate movimientos: %w", err)
+	}
+
+	return nil
+}
+
+// agrupacionesMovimientos expresión del grupo y orden de cada agrupación de movimientos
+var agrupacionesMovimientos = map[string]struct{ grupo, orden string }{
+	models.AgrupacionMovimientosDia:      {"to_char(created_at::date, 'YYYY-MM-DD')", "grupo"},
+	models.AgrupacionMovimientosProducto: {"codigo_producto", "cantidad DESC, grupo"},
+	models.AgrupacionMovimientosUsuario:  {"id_usuario::text", "cantidad DESC, grupo"},
+	models.AgrupacionMovimientosMotivo:   {"motivo", "cantidad DESC, grupo"},
+}
+
+// AgruparMovimientos agrega los movimientos que cumplen el filtro por día, producto, usuario o motivo
+func (r *stockRepository) AgruparMovimientos(ctx context.Context, filter *models.MovimientoFilter, agrupacion string) ([]*models.MovimientoAgrupado, error) {
+	agrupar, ok := agrupacionesMovimientos[agrupacion]
+	if !ok {
+		return nil, fmt.Errorf("agrupación de movimientos desconocida: %q", agrupacion)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	offset := filter.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	where, args := movimientosWhere(filter)
+	args = append(args, limit, offset)
+	query := fmt.Sprintf(`
+		SELECT %s AS grupo, COALESCE(SUM(cantidad), 0) AS cantidad, COUNT(*)
+		FROM stock_movimientos_cantera
+		%s
+		GROUP BY 1
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, agrupar.grupo, where, agrupar.orden, len(args)-1, len(args))
+
+	rows, err := r.query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to group movimientos: %w", err)
+	}
+	defer rows.Close()
+
+	grupos := []*models.MovimientoAgrupado{}
+	for rows.Next() {
+		var grupo models.MovimientoAgrupado
+		if err := rows.Scan(&grupo.Grupo, &grupo.Cantidad, &grupo.Movimientos); err != nil {
+			return nil, fmt.Errorf("failed to scan movimiento agrupado: %w", err)
+		}
+		grupos = append(grupos, &grupo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate movimientos agrupados: %w", err)
+	}
+
+	return grupos, nil
+}
+
+// RecorrerCadenaMovimientos recorre fila a fila los movimientos del local en orden de cadena
+func (r *stockRepository) RecorrerCadenaMovimientos(ctx context.Context, idLocal int, desde, hasta *time.Time, fn func(*models.Movimiento) error) error {
+	rows, err := r.stmt(ctx, "get_cadena_movimientos").QueryContext(ctx, idLocal, desde, hasta)
+	if err != nil {
+		return fmt.Errorf("failed to query cadena movimientos: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movimiento models.Movimiento
+		if err := rows.Scan(
+			&movimiento.ID, &movimiento.CodigoProducto, &movimiento.TipoItem, &movimiento.TipoMovimiento,
+			&movimiento.Cantidad, &movimiento.CantidadAnterior, &movimiento.CantidadNueva,
+			&movimiento.Motivo, &movimiento.IDUsuario, &movimiento.IDLocal, &movimiento.Observaciones,
+			&movimiento.CreatedAt,
+			&movimiento.DocumentoTipo, &movimiento.DocumentoNumero, &movimiento.DocumentoFecha,
+			&movimiento.Unidad, &movimiento.CantidadUnidad, &movimiento.IDOperacion,
+			&movimiento.IDBodega, &movimiento.IDBodegaDestino, &movimiento.Hash, &movimiento.HashAnterior,
+		); err != nil {
+			return fmt.Errorf("failed to scan movimiento cadena: %w", err)
+		}
+		if err := fn(&movimiento); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate cadena movimientos: %w", err)
+	}
+
+	return nil
+}
+
+// GetHashPrevio obtiene el hash del movimiento sellado del local anterior a antesDe
+func (r *stockRepository) GetHashPrevio(ctx context.Context, idLocal, antesDe int) (string, error) {
+	var hash string
+	err := r.stmt(ctx, "get_hash_previo").QueryRowContext(ctx, idLocal, antesDe).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get hash previo: %w", err)
+	}
+	return hash, nil
+}
+
+// ExisteDocumento indica si el documento ya respalda alguna entrada
+func (r *stockRepository) ExisteDocumento(ctx context.Context, tipo, numero string) (bool, error) {
+	// El lock serializa entradas concurrentes con el mismo documento (se libera al commit)
+	if r.tx != nil {
+		if _, err := r.stmt(ctx, "lock_documento").ExecContext(ctx, tipo, numero); err != nil {
+			return false, fmt.Errorf("failed to lock documento: %w", err)
+		}
+	}
+
+	var existe bool
+	if err := r.stmt(ctx, "existe_documento").QueryRowContext(ctx, tipo, numero).Scan(&existe); err != nil {
+		return false, fmt.Errorf("failed to check documento: %w", err)
+	}
+
+	return existe, nil
+}
+
+// EsProductoCritico indica si el producto está marcado como crítico en el local
+func (r *stockRepository) EsProductoCritico(ctx context.Context, codigoProducto string, idLocal int) (bool, error) {
+	var critico bool
+	if err := r.stmt(ctx, "es_producto_critico").QueryRowContext(ctx, codigoProducto, idLocal).Scan(&critico); err != nil {
+		return false, fmt.Errorf("failed to check producto critico: %w", err)
+	}
+	return critico, nil
 }
 
 // BatchUpdateStock actualiza múltiples stocks en una transacción
@@ -328,7 +1321,7 @@ func (r *stockRepository) BatchUpdateStock(ctx context.Context, stocks []*models
 // GetProductoByCodigo obtiene un producto por código
 func (r *stockRepository) GetProductoByCodigo(ctx context.Context, codigo string) (*models.Producto, error) {
 	var producto models.Producto
-	err := r.stmts["get_producto"].QueryRowContext(ctx, codigo).Scan(
+	err := r.stmt(ctx, "get_producto").QueryRowContext(ctx, codigo).Scan(
 		&producto.ID, &producto.Codigo, &producto.Nombre, &producto.Unidad, &producto.Precio,
 		&producto.CodigoBarraInterno, &producto.CodigoBarraExterno, &producto.Descripcion,
 		&producto.EsServicio, &producto.EsExento, &producto.ImpuestoEspecifico,
@@ -349,7 +1342,7 @@ func (r *stockRepository) GetProductoByCodigo(ctx context.Context, codigo string
 // GetPackByCodigo obtiene un pack por código
 func (r *stockRepository) GetPackByCodigo(ctx context.Context, codigo string) (*models.Pack, error) {
 	var pack models.Pack
-	err := r.stmts["get_pack"].QueryRowContext(ctx, codigo).Scan(
+	err := r.stmt(ctx, "get_pack").QueryRowContext(ctx, codigo).Scan(
 		&pack.ID, &pack.CodigoPack, &pack.CodBarraPack, &pack.NombrePack, &pack.PrecioBase,
 		&pack.CantidadArticulo, &pack.CodigoArticulo, &pack.CodBarraArticulo, &pack.NombreArticulo,
 	)
@@ -366,7 +1359,7 @@ func (r *stockRepository) GetPackByCodigo(ctx context.Context, codigo string) (*
 
 // GetPacksByProducto obtiene todos los packs que contienen un producto
 func (r *stockRepository) GetPacksByProducto(ctx context.Context, codigoProducto string) ([]*models.Pack, error) {
-	rows, err := r.stmts["get_packs_by_producto"].QueryContext(ctx, codigoProducto)
+	rows, err := r.stmt(ctx, "get_packs_by_producto").QueryContext(ctx, codigoProducto)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get packs by producto: %w", err)
 	}
@@ -387,3 +1380,428 @@ func (r *stockRepository) GetPacksByProducto(ctx context.Context, codigoProducto
 
 	return packs, nil
 }
+
+// GetLocalByID obtiene un local por ID (incluye locales inactivos)
+func (r *stockRepository) GetLocalByID(ctx context.Context, idLocal int) (*models.Local, error) {
+	var local models.Local
+	err := r.stmt(ctx, "get_local").QueryRowContext(ctx, idLocal).Scan(
+		&local.ID, &local.Nombre, &local.Activo,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get local: %w", err)
+	}
+
+	return &local, nil
+}
+
+// GetBodegas lista las bodegas del local (la sala primero)
+func (r *stockRepository) GetBodegas(ctx context.Context, idLocal int) ([]*models.Bodega, error) {
+	rows, err := r.stmt(ctx, "get_bodegas").QueryContext(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bodegas: %w", err)
+	}
+	defer rows.Close()
+
+	bodegas := []*models.Bodega{}
+	for rows.Next() {
+		var bodega models.Bodega
+		if err := rows.Scan(
+			&bodega.ID, &bodega.IDLocal, &bodega.Nombre, &bodega.Tipo, &bodega.Activa,
+			&bodega.CreatedAt, &bodega.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan bodega: %w", err)
+		}
+		bodegas = append(bodegas, &bodega)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate bodegas: %w", err)
+	}
+
+	return bodegas, nil
+}
+
+// GetBodegaByID obtiene una bodega por su ID
+func (r *stockRepository) GetBodegaByID(ctx context.Context, id int) (*models.Bodega, error) {
+	var bodega models.Bodega
+	err := r.stmt(ctx, "get_bodega").QueryRowContext(ctx, id).Scan(
+		&bodega.ID, &bodega.IDLocal, &bodega.Nombre, &bodega.Tipo, &bodega.Activa,
+		&bodega.CreatedAt, &bodega.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bodega: %w", err)
+	}
+
+	return &bodega, nil
+}
+
+// AsegurarBodegaSala retorna la sala de venta del local, creándola si no existe
+// Retorna nil si no se pudo crear (otra bodega del local ya usa el nombre)
+func (r *stockRepository) AsegurarBodegaSala(ctx context.Context, idLocal int) (*models.Bodega, error) {
+	sala, err := r.getBodegaSala(ctx, idLocal)
+	if err != nil || sala != nil {
+		return sala, err
+	}
+
+	// Locales creados después de la migración: la sala se crea la primera vez que se necesita
+	if _, err := r.stmt(ctx, "create_bodega_sala").ExecContext(ctx, idLocal); err != nil {
+		return nil, fmt.Errorf("failed to create bodega sala: %w", err)
+	}
+	return r.getBodegaSala(ctx, idLocal)
+}
+
+// getBodegaSala obtiene la sala de venta del local (nil si no tiene)
+func (r *stockRepository) getBodegaSala(ctx context.Context, idLocal int) (*models.Bodega, error) {
+	var bodega models.Bodega
+	err := r.stmt(ctx, "get_bodega_sala").QueryRowContext(ctx, idLocal).Scan(
+		&bodega.ID, &bodega.IDLocal, &bodega.Nombre, &bodega.Tipo, &bodega.Activa,
+		&bodega.CreatedAt, &bodega.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bodega sala: %w", err)
+	}
+
+	return &bodega, nil
+}
+
+// CreateBodega crea una bodega en el local
+func (r *stockRepository) CreateBodega(ctx context.Context, bodega *models.Bodega) error {
+	err := r.stmt(ctx, "create_bodega").QueryRowContext(ctx,
+		bodega.IDLocal, bodega.Nombre, bodega.Tipo, bodega.Activa,
+	).Scan(&bodega.ID, &bodega.CreatedAt, &bodega.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create bodega: %w", err)
+	}
+	return nil
+}
+
+// UpdateBodega actualiza el nombre y el estado de la bodega
+func (r *stockRepository) UpdateBodega(ctx context.Context, bodega *models.Bodega) error {
+	err := r.stmt(ctx, "update_bodega").QueryRowContext(ctx,
+		bodega.Nombre, bodega.Activa, bodega.ID,
+	).Scan(&bodega.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update bodega: %w", err)
+	}
+	return nil
+}
+
+// GetStockEnBodega obtiene la cantidad del producto en la bodega (0 si no tiene registro)
+func (r *stockRepository) GetStockEnBodega(ctx context.Context, codigoProducto string, idBodega int) (int, error) {
+	var cantidad int
+	err := r.stmt(ctx, "get_stock_en_bodega").QueryRowContext(ctx, codigoProducto, idBodega).Scan(&cantidad)
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stock en bodega: %w", err)
+	}
+
+	return cantidad, nil
+}
+
+// GetStockFueraDeSala suma la cantidad del producto en las bodegas del local que no son la sala
+func (r *stockRepository) GetStockFueraDeSala(ctx context.Context, codigoProducto string, idLocal int) (int, error) {
+	var cantidad int
+	if err := r.stmt(ctx, "get_stock_fuera_de_sala").QueryRowContext(ctx, codigoProducto, idLocal).Scan(&cantidad); err != nil {
+		return 0, fmt.Errorf("failed to get stock fuera de sala: %w", err)
+	}
+	return cantidad, nil
+}
+
+// AjustarStockBodega suma delta (negativo para descontar) a la cantidad del producto en la bodega
+func (r *stockRepository) AjustarStockBodega(ctx context.Context, codigoProducto string, idLocal, idBodega, delta int) (int, error) {
+	if err := r.BloquearCadenas(ctx, idLocal); err != nil {
+		return 0, err
+	}
+	var cantidad int
+	err := r.stmt(ctx, "ajustar_stock_bodega").QueryRowContext(ctx,
+		codigoProducto, idLocal, idBodega, delta,
+	).Scan(&cantidad)
+	if err != nil {
+		return 0, fmt.Errorf("failed to ajustar stock bodega: %w", err)
+	}
+	return cantidad, nil
+}
+
+// GetStockPorBodega obtiene el stock del local con lo que está en cada bodega fuera de la sala
+func (r *stockRepository) GetStockPorBodega(ctx context.Context, idLocal int, codigoProducto *string) ([]*models.StockPorBodega, error) {
+	rows, err := r.stmt(ctx, "get_stock_por_bodega").QueryContext(ctx, idLocal, codigoProducto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stock por bodega: %w", err)
+	}
+	defer rows.Close()
+
+	stock := []*models.StockPorBodega{}
+	var actual *models.StockPorBodega
+	for rows.Next() {
+		var codigo string
+		var total int
+		var idBodega, cantidad sql.NullInt64
+		if err := rows.Scan(&codigo, &total, &idBodega, &cantidad); err != nil {
+			return nil, fmt.Errorf("failed to scan stock por bodega: %w", err)
+		}
+		if actual == nil || actual.CodigoProducto != codigo {
+			actual = &models.StockPorBodega{
+				CodigoProducto: codigo,
+				IDLocal:        idLocal,
+				Total:          total,
+				Bodegas:        []models.StockEnBodega{},
+			}
+			stock = append(stock, actual)
+		}
+		if idBodega.Valid {
+			actual.Bodegas = append(actual.Bodegas, models.StockEnBodega{
+				IDBodega: int(idBodega.Int64),
+				Cantidad: int(cantidad.Int64),
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate stock por bodega: %w", err)
+	}
+
+	return stock, nil
+}
+
+// GetFactorConversion obtiene cuántas unidades base equivale una unidad del producto
+// Retorna 0 si el producto no tiene conversión definida para esa unidad
+func (r *stockRepository) GetFactorConversion(ctx context.Context, codigoProducto, unidad string) (int, error) {
+	var factor int
+	err := r.stmt(ctx, "get_factor_conversion").QueryRowContext(ctx, codigoProducto, unidad).Scan(&factor)
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get factor conversion: %w", err)
+	}
+
+	return factor, nil
+}
+
+// GetComponentesPack obtiene los artículos que componen un pack
+func (r *stockRepository) GetComponentesPack(ctx context.Context, codigoPack string) ([]*models.Pack, error) {
+	rows, err := r.stmt(ctx, "get_componentes_pack").QueryContext(ctx, codigoPack)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get componentes pack: %w", err)
+	}
+	defer rows.Close()
+
+	var componentes []*models.Pack
+	for rows.Next() {
+		var pack models.Pack
+		err := rows.Scan(
+			&pack.ID, &pack.CodigoPack, &pack.CodBarraPack, &pack.NombrePack, &pack.PrecioBase,
+			&pack.CantidadArticulo, &pack.CodigoArticulo, &pack.CodBarraArticulo, &pack.NombreArticulo,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan componente pack: %w", err)
+		}
+		componentes = append(componentes, &pack)
+	}
+
+	return componentes, nil
+}
+
+// GetCantidadReservada obtiene la cantidad reservada por pickings preparados y no vencidos
+func (r *stockRepository) GetCantidadReservada(ctx context.Context, codigoProducto string, idLocal int) (int, error) {
+	var reservada int
+	err := r.stmt(ctx, "get_cantidad_reservada").QueryRowContext(ctx, codigoProducto, idLocal).Scan(&reservada)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cantidad reservada: %w", err)
+	}
+
+	return reservada, nil
+}
+
+// GetCantidadEnTransito obtiene la cantidad enviada al local en guías emitidas y no recibidas
+func (r *stockRepository) GetCantidadEnTransito(ctx context.Context, codigoProducto string, idLocal int) (int, error) {
+	var enTransito int
+	err := r.stmt(ctx, "get_cantidad_en_transito").QueryRowContext(ctx, codigoProducto, idLocal).Scan(&enTransito)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cantidad en transito: %w", err)
+	}
+
+	return enTransito, nil
+}
+
+// CreateTransferencia registra la transferencia y sus ítems
+func (r *stockRepository) CreateTransferencia(ctx context.Context, transferencia *models.TransferenciaStock) error {
+	err := r.stmt(ctx, "create_transferencia").QueryRowContext(ctx,
+		transferencia.IDLocalOrigen, transferencia.IDLocalDestino, transferencia.Estado, transferencia.Motivo,
+		transferencia.Observaciones, transferencia.IDUsuario, transferencia.IDOperacion,
+	).Scan(&transferencia.ID, &transferencia.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create transferencia: %w", err)
+	}
+
+	for _, item := range transferencia.Items {
+		item.IDTransferencia = transferencia.ID
+		err := r.stmt(ctx, "create_transferencia_item").QueryRowContext(ctx,
+			item.IDTransferencia, item.CodigoProducto, item.TipoItem, item.Cantidad,
+		).Scan(&item.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create transferencia item: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetTransferencia obtiene una transferencia con sus ítems
+func (r *stockRepository) GetTransferencia(ctx context.Context, id int64) (*models.TransferenciaStock, error) {
+	name := "get_transferencia"
+	if r.tx != nil {
+		name = "lock_transferencia"
+	}
+	transferencia, err := scanTransferencia(r.stmt(ctx, name).QueryRowContext(ctx, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transferencia: %w", err)
+	}
+
+	rows, err := r.stmt(ctx, "get_transferencia_items").QueryContext(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transferencia items: %w", err)
+	}
+	defer rows.Close()
+
+	transferencia.Items = []*models.TransferenciaStockItem{}
+	for rows.Next() {
+		var item models.TransferenciaStockItem
+		if err := rows.Scan(&item.ID, &item.IDTransferencia, &item.CodigoProducto, &item.TipoItem, &item.Cantidad); err != nil {
+			return nil, fmt.Errorf("failed to scan transferencia item: %w", err)
+		}
+		transferencia.Items = append(transferencia.Items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transferencia items: %w", err)
+	}
+
+	return transferencia, nil
+}
+
+// GetTransferencias lista las transferencias (sin ítems), las más recientes primero
+func (r *stockRepository) GetTransferencias(ctx context.Context, filter *models.TransferenciaFilter) ([]*models.TransferenciaStock, error) {
+	rows, err := r.stmt(ctx, "get_transferencias").QueryContext(ctx, filter.IDLocal, filter.Estado, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transferencias: %w", err)
+	}
+	defer rows.Close()
+
+	transferencias := []*models.TransferenciaStock{}
+	for rows.Next() {
+		transferencia, err := scanTransferencia(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transferencia: %w", err)
+		}
+		transferencias = append(transferencias, transferencia)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transferencias: %w", err)
+	}
+
+	return transferencias, nil
+}
+
+// AnularTransferencia marca la transferencia como anulada (ErrNotFound si ya no estaba aplicada)
+func (r *stockRepository) AnularTransferencia(ctx context.Context, transferencia *models.TransferenciaStock) error {
+	var anuladaAt time.Time
+	err := r.stmt(ctx, "anular_transferencia").QueryRowContext(ctx,
+		transferencia.ID, transferencia.IDOperacionAnulacion, transferencia.MotivoAnulacion, transferencia.IDUsuarioAnulacion,
+	).Scan(&anuladaAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to anular transferencia: %w", err)
+	}
+	transferencia.Estado = models.TransferenciaEstadoAnulada
+	transferencia.AnuladaAt = &anuladaAt
+	return nil
+}
+
+// scanTransferencia lee la cabecera de una transferencia
+func scanTransferencia(row interface{ Scan(...interface{}) error }) (*models.TransferenciaStock, error) {
+	var t models.TransferenciaStock
+	err := row.Scan(
+		&t.ID, &t.IDLocalOrigen, &t.IDLocalDestino, &t.Estado, &t.Motivo, &t.Observaciones, &t.IDUsuario,
+		&t.IDOperacion, &t.IDOperacionAnulacion, &t.MotivoAnulacion, &t.IDUsuarioAnulacion, &t.AnuladaAt, &t.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetSalidasDesde obtiene las unidades de salida por producto de un local desde una fecha
+func (r *stockRepository) GetSalidasDesde(ctx context.Context, idLocal int, desde time.Time) (map[string]int, error) {
+	rows, err := r.stmt(ctx, "get_salidas_desde").QueryContext(ctx, idLocal, desde)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get salidas desde: %w", err)
+	}
+	defer rows.Close()
+
+	salidas := make(map[string]int)
+	for rows.Next() {
+		var codigo string
+		var cantidad int
+		if err := rows.Scan(&codigo, &cantidad); err != nil {
+			return nil, fmt.Errorf("failed to scan salidas: %w", err)
+		}
+		salidas[codigo] = cantidad
+	}
+
+	return salidas, nil
+}
+
+// GetDescuadresStock obtiene el stock que no coincide con el reconstruido desde los movimientos
+// con el último movimiento hasta el cual la cadena cuadraba (filtros nil: todo el stock)
+func (r *stockRepository) GetDescuadresStock(ctx context.Context, idLocal *int, codigoProducto *string) ([]*models.DescuadreStock, error) {
+	rows, err := r.stmt(ctx, "get_descuadres_stock").QueryContext(ctx, idLocal, codigoProducto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get descuadres stock: %w", err)
+	}
+	defer rows.Close()
+
+	descuadres := []*models.DescuadreStock{}
+	for rows.Next() {
+		var d models.DescuadreStock
+		var cuadreID sql.NullInt64
+		var cuadreCreatedAt sql.NullTime
+		var cuadreCantidad sql.NullInt64
+		err := rows.Scan(
+			&d.CodigoProducto, &d.TipoItem, &d.IDLocal, &d.CantidadActual, &d.CantidadTeorica, &d.Movimientos,
+			&cuadreID, &cuadreCreatedAt, &cuadreCantidad,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan descuadre stock: %w", err)
+		}
+		d.Diferencia = d.CantidadActual - d.CantidadTeorica
+		if cuadreID.Valid {
+			d.UltimoMovimientoCuadre = &models.MovimientoCuadre{
+				ID:            int(cuadreID.Int64),
+				CreatedAt:     cuadreCreatedAt.Time,
+				CantidadNueva: int(cuadreCantidad.Int64),
+			}
+		}
+		descuadres = append(descuadres, &d)
+	}
+
+	return descuadres, nil
+}
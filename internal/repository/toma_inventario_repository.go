@@ -0,0 +1,400 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// TomaInventarioRepository define la interfaz para las tomas de inventario
+type TomaInventarioRepository interface {
+	// CreateToma retorna false si el local ya tiene una toma abierta (o cerrándose)
+	CreateToma(ctx context.Context, toma *models.TomaInventario) (bool, error)
+	GetToma(ctx context.Context, id int64) (*models.TomaInventario, error)
+	GetTomaAbierta(ctx context.Context, idLocal int) (*models.TomaInventario, error)
+	GetTomas(ctx context.Context, filter *models.TomaInventarioFilter) ([]*models.TomaInventario, error)
+	GetItems(ctx context.Context, id int64) ([]*models.TomaInventarioItem, error)
+	// RegistrarLecturas carga las cantidades en una transacción; retorna false si la toma no está abierta
+	RegistrarLecturas(ctx context.Context, id int64, lecturas []*models.LecturaTomaInventario, idUsuario int) ([]*models.TomaInventarioItem, bool, error)
+	// GetDiferencias cruza lo contado con el stock actual del local (incluye lo no contado con stock)
+	GetDiferencias(ctx context.Context, id int64, idLocal int) ([]*models.DiferenciaTomaInventario, error)
+	CambiarEstado(ctx context.Context, id int64, desde, hasta string) (bool, error)
+	// TomarParaCierre pasa la toma abierta a 'cerrando', o retoma un cierre iniciado hace más de
+	// abandonado que no se confirmó; retorna false si no está en ninguno de esos casos
+	TomarParaCierre(ctx context.Context, id int64, abandonado time.Duration) (bool, error)
+	// ResolverCodigos busca cada código como código o código de barras de un producto (no servicio)
+	// y, si no, de un pack; los códigos sin coincidencia no aparecen en el mapa
+	ResolverCodigos(ctx context.Context, codigos []string) (map[string]*models.ProductoConteo, error)
+}
+
+// tomaInventarioRepository implementa TomaInventarioRepository
+type tomaInventarioRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewTomaInventarioRepository crea una nueva instancia del repository
+func NewTomaInventarioRepository(db *sql.DB) (TomaInventarioRepository, error) {
+	repo := &tomaInventarioRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// tomaInventarioColumns columnas de una toma en el orden de scanTomas
+const tomaInventarioColumns = `
+	t.id, t.id_local, t.descripcion, t.estado, t.id_usuario,
+	(SELECT COUNT(*) FROM toma_inventario_items_cantera i WHERE i.id_toma = t.id)::int,
+	t.ajustados, t.id_operacion, t.id_usuario_cierre, t.created_at, t.cerrada_at
+`
+
+// tomaInventarioItemColumns columnas de un ítem en el orden de scanItemsToma
+const tomaInventarioItemColumns = `
+	id_toma, codigo_producto, tipo_item, cantidad_contada, lecturas, id_usuario, updated_at
+`
+
+// prepareStatements prepara todas las consultas SQL
+func (r *tomaInventarioRepository) prepareStatements() error {
+	statements := map[string]string{
+		"create_toma": `
+			INSERT INTO tomas_inventario_cantera (id_local, descripcion, id_usuario)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (id_local) WHERE estado IN ('abierta', 'cerrando') DO NOTHING
+			RETURNING id, estado, created_at
+		`,
+		"get_toma": `
+			SELECT ` + tomaInventarioColumns + `
+			FROM tomas_inventario_cantera t
+			WHERE t.id = $1
+		`,
+		"get_toma_abierta": `
+			SELECT ` + tomaInventarioColumns + `
+			FROM tomas_inventario_cantera t
+			WHERE t.id_local = $1 AND t.estado IN ('abierta', 'cerrando')
+		`,
+		"get_tomas": `
+			SELECT ` + tomaInventarioColumns + `
+			FROM tomas_inventario_cantera t
+			WHERE ($1::int IS NULL OR t.id_local = $1)
+			  AND ($2 = '' OR t.estado = $2)
+			ORDER BY t.created_at DESC
+			LIMIT 200
+		`,
+		"get_items": `
+			SELECT ` + tomaInventarioItemColumns + `
+			FROM toma_inventario_items_cantera
+			WHERE id_toma = $1
+			ORDER BY codigo_producto, tipo_item
+		`,
+		// FOR SHARE: varias pistolas cargan a la vez, pero el cierre espera a que terminen
+		"lock_toma": `
+			SELECT estado FROM tomas_inventario_cantera WHERE id = $1 FOR SHARE
+		`,
+		"registrar_lectura": `
+			INSERT INTO toma_inventario_items_cantera
+				(id_toma, codigo_producto, tipo_item, cantidad_contada, lecturas, id_usuario)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (id_toma, codigo_producto, tipo_item) DO UPDATE
+			SET cantidad_contada = CASE WHEN $7::boolean THEN EXCLUDED.cantidad_contada
+									   ELSE toma_inventario_items_cantera.cantidad_contada + EXCLUDED.cantidad_contada END,
+				lecturas = toma_inventario_items_cantera.lecturas + EXCLUDED.lecturas,
+				id_usuario = EXCLUDED.id_usuario,
+				updated_at = NOW()
+			RETURNING ` + tomaInventarioItemColumns + `
+		`,
+		// Productos contados y productos del local con stock que no se contaron
+		"get_diferencias": `
+			WITH contados AS (
+				SELECT codigo_producto, tipo_item, cantidad_contada
+				FROM toma_inventario_items_cantera
+				WHERE id_toma = $1
+			),
+			sistema AS (
+				SELECT codigo_producto, tipo_item, cantidad_actual
+				FROM stock_bodega_cantera
+				WHERE id_local = $2
+			)
+			SELECT COALESCE(c.codigo_producto, s.codigo_producto), p.nombre, COALESCE(c.tipo_item, s.tipo_item),
+				   COALESCE(s.cantidad_actual, 0), c.cantidad_contada
+			FROM contados c
+			FULL JOIN sistema s ON s.codigo_producto = c.codigo_producto AND s.tipo_item = c.tipo_item
+			LEFT JOIN productos p ON p.codigo = COALESCE(c.codigo_producto, s.codigo_producto)
+			WHERE (c.codigo_producto IS NOT NULL OR s.cantidad_actual <> 0)
+			  AND NOT COALESCE(p.es_servicio, false)
+			ORDER BY 1, 3
+		`,
+		"cambiar_estado": `
+			UPDATE tomas_inventario_cantera
+			SET estado = $3
+			WHERE id = $1 AND estado = $2
+		`,
+		"tomar_para_cierre": `
+			UPDATE tomas_inventario_cantera
+			SET estado = 'cerrando', cerrando_at = NOW()
+			WHERE id = $1
+			  AND (estado = 'abierta'
+			       OR (estado = 'cerrando' AND (cerrando_at IS NULL OR cerrando_at < NOW() - make_interval(secs => $2))))
+		`,
+		"resolver_codigos": `
+			SELECT t.codigo, x.codigo_producto, x.tipo_item
+			FROM unnest($1::text[]) AS t(codigo)
+			JOIN LATERAL (
+				SELECT p.codigo AS codigo_producto, 'producto' AS tipo_item, 1 AS prioridad
+				FROM productos p
+				WHERE (p.codigo = t.codigo OR p.codigo_barra_interno = t.codigo OR p.codigo_barra_externo = t.codigo)
+				  AND NOT p.es_servicio
+				UNION ALL
+				SELECT pl.codigo_pack, 'pack', 2
+				FROM pack_listados pl
+				WHERE pl.codigo_pack = t.codigo OR pl.cod_barra_pack = t.codigo
+				ORDER BY prioridad
+				LIMIT 1
+			) x ON TRUE
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// CreateToma abre una toma de inventario en el local
+func (r *tomaInventarioRepository) CreateToma(ctx context.Context, toma *models.TomaInventario) (bool, error) {
+	err := r.stmts["create_toma"].QueryRowContext(ctx, toma.IDLocal, toma.Descripcion, toma.IDUsuario).
+		Scan(&toma.ID, &toma.Estado, &toma.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create toma inventario: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetToma obtiene una toma por ID sin sus ítems (nil si no existe)
+func (r *tomaInventarioRepository) GetToma(ctx context.Context, id int64) (*models.TomaInventario, error) {
+	rows, err := r.stmts["get_toma"].QueryContext(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get toma inventario: %w", err)
+	}
+	defer rows.Close()
+
+	tomas, err := scanTomas(rows)
+	if err != nil || len(tomas) == 0 {
+		return nil, err
+	}
+	return tomas[0], nil
+}
+
+// GetTomaAbierta obtiene la toma en curso del local (nil si no hay)
+func (r *tomaInventarioRepository) GetTomaAbierta(ctx context.Context, idLocal int) (*models.TomaInventario, error) {
+	rows, err := r.stmts["get_toma_abierta"].QueryContext(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get toma inventario abierta: %w", err)
+	}
+	defer rows.Close()
+
+	tomas, err := scanTomas(rows)
+	if err != nil || len(tomas) == 0 {
+		return nil, err
+	}
+	return tomas[0], nil
+}
+
+// GetTomas lista las tomas según el filtro (las más recientes primero)
+func (r *tomaInventarioRepository) GetTomas(ctx context.Context, filter *models.TomaInventarioFilter) ([]*models.TomaInventario, error) {
+	rows, err := r.stmts["get_tomas"].QueryContext(ctx, filter.IDLocal, filter.Estado)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tomas inventario: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTomas(rows)
+}
+
+// GetItems obtiene lo contado en la toma
+func (r *tomaInventarioRepository) GetItems(ctx context.Context, id int64) ([]*models.TomaInventarioItem, error) {
+	rows, err := r.stmts["get_items"].QueryContext(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items toma inventario: %w", err)
+	}
+	defer rows.Close()
+
+	return scanItemsToma(rows)
+}
+
+// RegistrarLecturas suma o reemplaza las cantidades contadas de cada producto
+func (r *tomaInventarioRepository) RegistrarLecturas(ctx context.Context, id int64, lecturas []*models.LecturaTomaInventario, idUsuario int) ([]*models.TomaInventarioItem, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var estado string
+	if err := tx.StmtContext(ctx, r.stmts["lock_toma"]).QueryRowContext(ctx, id).Scan(&estado); err != nil {
+		return nil, false, fmt.Errorf("failed to lock toma inventario: %w", err)
+	}
+	if estado != models.TomaInventarioAbierta {
+		return nil, false, nil
+	}
+
+	stmt := tx.StmtContext(ctx, r.stmts["registrar_lectura"])
+	items := make([]*models.TomaInventarioItem, 0, len(lecturas))
+	for _, lectura := range lecturas {
+		var item models.TomaInventarioItem
+		err := stmt.QueryRowContext(ctx, id, lectura.CodigoProducto, lectura.TipoItem, lectura.Cantidad,
+			lectura.Lecturas, idUsuario, lectura.Reemplazar,
+		).Scan(
+			&item.IDToma, &item.CodigoProducto, &item.TipoItem, &item.CantidadContada,
+			&item.Lecturas, &item.IDUsuario, &item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to registrar lectura %s: %w", lectura.CodigoProducto, err)
+		}
+		items = append(items, &item)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return items, true, nil
+}
+
+// GetDiferencias obtiene lo contado frente al stock actual del local
+func (r *tomaInventarioRepository) GetDiferencias(ctx context.Context, id int64, idLocal int) ([]*models.DiferenciaTomaInventario, error) {
+	rows, err := r.stmts["get_diferencias"].QueryContext(ctx, id, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diferencias toma inventario: %w", err)
+	}
+	defer rows.Close()
+
+	diferencias := []*models.DiferenciaTomaInventario{}
+	for rows.Next() {
+		var d models.DiferenciaTomaInventario
+		var contada sql.NullInt64
+		if err := rows.Scan(&d.CodigoProducto, &d.NombreProducto, &d.TipoItem, &d.CantidadSistema, &contada); err != nil {
+			return nil, fmt.Errorf("failed to scan diferencia toma inventario: %w", err)
+		}
+		if contada.Valid {
+			cantidad := int(contada.Int64)
+			d.CantidadContada = &cantidad
+			d.Diferencia = cantidad - d.CantidadSistema
+		} else {
+			d.Diferencia = -d.CantidadSistema
+		}
+		diferencias = append(diferencias, &d)
+	}
+
+	return diferencias, rows.Err()
+}
+
+// CambiarEstado transiciona la toma solo si está en el estado esperado
+func (r *tomaInventarioRepository) CambiarEstado(ctx context.Context, id int64, desde, hasta string) (bool, error) {
+	result, err := r.stmts["cambiar_estado"].ExecContext(ctx, id, desde, hasta)
+	if err != nil {
+		return false, fmt.Errorf("failed to cambiar estado toma inventario: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// TomarParaCierre inicia el cierre de la toma o retoma uno abandonado
+// Retomarlo es seguro: el cierre se confirma en la transacción de sus ajustes solo si la toma sigue
+// en 'cerrando', así que de dos cierres concurrentes uno solo aplica
+func (r *tomaInventarioRepository) TomarParaCierre(ctx context.Context, id int64, abandonado time.Duration) (bool, error) {
+	result, err := r.stmts["tomar_para_cierre"].ExecContext(ctx, id, abandonado.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to tomar toma inventario para cierre: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ResolverCodigos resuelve en una consulta los códigos leídos en la toma
+func (r *tomaInventarioRepository) ResolverCodigos(ctx context.Context, codigos []string) (map[string]*models.ProductoConteo, error) {
+	resueltos := make(map[string]*models.ProductoConteo, len(codigos))
+	if len(codigos) == 0 {
+		return resueltos, nil
+	}
+
+	rows, err := r.stmts["resolver_codigos"].QueryContext(ctx, pq.Array(codigos))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolver codigos: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var codigo string
+		var producto models.ProductoConteo
+		if err := rows.Scan(&codigo, &producto.CodigoProducto, &producto.TipoItem); err != nil {
+			return nil, fmt.Errorf("failed to scan codigo resuelto: %w", err)
+		}
+		resueltos[codigo] = &producto
+	}
+
+	return resueltos, rows.Err()
+}
+
+// scanTomas lee las filas de tomaInventarioColumns
+func scanTomas(rows *sql.Rows) ([]*models.TomaInventario, error) {
+	tomas := []*models.TomaInventario{}
+	for rows.Next() {
+		var t models.TomaInventario
+		if err := rows.Scan(
+			&t.ID, &t.IDLocal, &t.Descripcion, &t.Estado, &t.IDUsuario, &t.Productos,
+			&t.Ajustados, &t.IDOperacion, &t.IDUsuarioCierre, &t.CreatedAt, &t.CerradaAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan toma inventario: %w", err)
+		}
+		tomas = append(tomas, &t)
+	}
+
+	return tomas, rows.Err()
+}
+
+// scanItemsToma lee las filas de tomaInventarioItemColumns
+func scanItemsToma(rows *sql.Rows) ([]*models.TomaInventarioItem, error) {
+	items := []*models.TomaInventarioItem{}
+	for rows.Next() {
+		var i models.TomaInventarioItem
+		if err := rows.Scan(
+			&i.IDToma, &i.CodigoProducto, &i.TipoItem, &i.CantidadContada,
+			&i.Lecturas, &i.IDUsuario, &i.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan item toma inventario: %w", err)
+		}
+		items = append(items, &i)
+	}
+
+	return items, rows.Err()
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+)
+
+// TurnoRepository define la interfaz para los turnos de trabajo y sus excepciones
+type TurnoRepository interface {
+	CreateTurno(ctx context.Context, turno *models.Turno) error
+	// UpdateTurno reemplaza el turno; retorna false si no existe
+	UpdateTurno(ctx context.Context, turno *models.Turno) (bool, error)
+	DeleteTurno(ctx context.Context, id int) (bool, error)
+	GetTurno(ctx context.Context, id int) (*models.Turno, error)
+	GetTurnos(ctx context.Context, filter *models.TurnoFilter) ([]*models.Turno, error)
+	// GetTurnosActivos turnos activos del usuario en todos los locales
+	GetTurnosActivos(ctx context.Context, idUsuario int) ([]*models.Turno, error)
+
+	CreateExcepcion(ctx context.Context, excepcion *models.ExcepcionTurno) error
+	GetExcepciones(ctx context.Context, filter *models.ExcepcionTurnoFilter) ([]*models.ExcepcionTurno, error)
+	// RevocarExcepcion retorna false si no existe o ya estaba revocada
+	RevocarExcepcion(ctx context.Context, id int) (bool, error)
+	// GetExcepcionVigente excepción no revocada del usuario que cubre el instante y el local
+	// (idLocal nil: solo las excepciones válidas en cualquier local); nil si no hay
+	GetExcepcionVigente(ctx context.Context, idUsuario int, idLocal *int, instante time.Time) (*models.ExcepcionTurno, error)
+}
+
+// turnoRepository implementa TurnoRepository
+type turnoRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewTurnoRepository crea una nueva instancia del repository
+func NewTurnoRepository(db *sql.DB) (TurnoRepository, error) {
+	repo := &turnoRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// turnoColumns columnas de turnos_cantera en el orden de scanTurno (horas como HH:MM)
+const turnoColumns = `
+	id, id_usuario, id_local, dia_semana, to_char(hora_inicio, 'HH24:MI'), to_char(hora_fin, 'HH24:MI'),
+	activo, created_at, updated_at
+`
+
+// excepcionTurnoColumns columnas de excepciones_turno_cantera en el orden de scanExcepcionTurno
+const excepcionTurnoColumns = `
+	id, id_usuario, id_local, desde, hasta, motivo, id_supervisor, created_at, revocada_at
+`
+
+// prepareStatements prepara todas las consultas SQL
+func (r *turnoRepository) prepareStatements() error {
+	statements := map[string]string{
+		"create_turno": `
+			INSERT INTO turnos_cantera (id_usuario, id_local, dia_semana, hora_inicio, hora_fin, activo)
+			VALUES ($1, $2, $3, $4::time, $5::time, $6)
+			RETURNING id, created_at, updated_at
+		`,
+		"update_turno": `
+			UPDATE turnos_cantera
+			SET id_usuario = $2, id_local = $3, dia_semana = $4, hora_inicio = $5::time, hora_fin = $6::time,
+				activo = $7, updated_at = NOW()
+			WHERE id = $1
+			RETURNING created_at, updated_at
+		`,
+		"delete_turno": `
+			DELETE FROM turnos_cantera WHERE id = $1
+		`,
+		"get_turno": `
+			SELECT ` + turnoColumns + `
+			FROM turnos_cantera
+			WHERE id = $1
+		`,
+		"get_turnos": `
+			SELECT ` + turnoColumns + `
+			FROM turnos_cantera
+			WHERE ($1::int IS NULL OR id_usuario = $1)
+			  AND ($2::int IS NULL OR id_local = $2 OR id_local IS NULL)
+			ORDER BY id_usuario, dia_semana, hora_inicio
+		`,
+		"get_turnos_activos": `
+			SELECT ` + turnoColumns + `
+			FROM turnos_cantera
+			WHERE id_usuario = $1 AND activo
+			ORDER BY dia_semana, hora_inicio
+		`,
+		"create_excepcion": `
+			INSERT INTO excepciones_turno_cantera (id_usuario, id_local, desde, hasta, motivo, id_supervisor)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, created_at
+		`,
+		"get_excepciones": `
+			SELECT ` + excepcionTurnoColumns + `
+			FROM excepciones_turno_cantera
+			WHERE ($1::int IS NULL OR id_usuario = $1)
+			  AND (NOT $2 OR (revocada_at IS NULL AND hasta > NOW()))
+			ORDER BY desde DESC
+			LIMIT 500
+		`,
+		"revocar_excepcion": `
+			UPDATE excepciones_turno_cantera
+			SET revocada_at = NOW()
+			WHERE id = $1 AND revocada_at IS NULL
+		`,
+		"get_excepcion_vigente": `
+			SELECT ` + excepcionTurnoColumns + `
+			FROM excepciones_turno_cantera
+			WHERE id_usuario = $1 AND revocada_at IS NULL
+			  AND desde <= $3 AND hasta > $3
+			  AND (id_local IS NULL OR id_local = $2)
+			ORDER BY hasta DESC
+			LIMIT 1
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// CreateTurno registra un turno
+func (r *turnoRepository) CreateTurno(ctx context.Context, turno *models.Turno) error {
+	err := r.stmts["create_turno"].QueryRowContext(ctx,
+		turno.IDUsuario, turno.IDLocal, turno.DiaSemana, turno.HoraInicio, turno.HoraFin, turno.Activo,
+	).Scan(&turno.ID, &turno.CreatedAt, &turno.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create turno: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTurno reemplaza los datos del turno
+func (r *turnoRepository) UpdateTurno(ctx context.Context, turno *models.Turno) (bool, error) {
+	err := r.stmts["update_turno"].QueryRowContext(ctx,
+		turno.ID, turno.IDUsuario, turno.IDLocal, turno.DiaSemana, turno.HoraInicio, turno.HoraFin, turno.Activo,
+	).Scan(&turno.CreatedAt, &turno.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update turno: %w", err)
+	}
+
+	return true, nil
+}
+
+// DeleteTurno elimina un turno; retorna false si no existía
+func (r *turnoRepository) DeleteTurno(ctx context.Context, id int) (bool, error) {
+	result, err := r.stmts["delete_turno"].ExecContext(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete turno: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetTurno obtiene un turno por ID (nil si no existe)
+func (r *turnoRepository) GetTurno(ctx context.Context, id int) (*models.Turno, error) {
+	rows, err := r.stmts["get_turno"].QueryContext(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get turno: %w", err)
+	}
+	defer rows.Close()
+
+	turnos, err := scanTurnos(rows)
+	if err != nil || len(turnos) == 0 {
+		return nil, err
+	}
+	return turnos[0], nil
+}
+
+// GetTurnos lista los turnos según el filtro (por local incluye los válidos en cualquier local)
+func (r *turnoRepository) GetTurnos(ctx context.Context, filter *models.TurnoFilter) ([]*models.Turno, error) {
+	rows, err := r.stmts["get_turnos"].QueryContext(ctx, filter.IDUsuario, filter.IDLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get turnos: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTurnos(rows)
+}
+
+// GetTurnosActivos turnos activos del usuario
+func (r *turnoRepository) GetTurnosActivos(ctx context.Context, idUsuario int) ([]*models.Turno, error) {
+	rows, err := r.stmts["get_turnos_activos"].QueryContext(ctx, idUsuario)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get turnos activos: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTurnos(rows)
+}
+
+// CreateExcepcion registra una excepción de turno
+func (r *turnoRepository) CreateExcepcion(ctx context.Context, excepcion *models.ExcepcionTurno) error {
+	err := r.stmts["create_excepcion"].QueryRowContext(ctx,
+		excepcion.IDUsuario, excepcion.IDLocal, excepcion.Desde, excepcion.Hasta, excepcion.Motivo, excepcion.IDSupervisor,
+	).Scan(&excepcion.ID, &excepcion.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create excepcion de turno: %w", err)
+	}
+
+	return nil
+}
+
+// GetExcepciones lista las excepciones según el filtro (las más recientes primero)
+func (r *turnoRepository) GetExcepciones(ctx context.Context, filter *models.ExcepcionTurnoFilter) ([]*models.ExcepcionTurno, error) {
+	rows, err := r.stmts["get_excepciones"].QueryContext(ctx, filter.IDUsuario, filter.SoloVigentes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get excepciones de turno: %w", err)
+	}
+	defer rows.Close()
+
+	return scanExcepcionesTurno(rows)
+}
+
+// RevocarExcepcion marca la excepción como revocada
+func (r *turnoRepository) RevocarExcepcion(ctx context.Context, id int) (bool, error) {
+	result, err := r.stmts["revocar_excepcion"].ExecContext(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to revocar excepcion de turno: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetExcepcionVigente excepción que autoriza al usuario en el local en el instante indicado
+func (r *turnoRepository) GetExcepcionVigente(ctx context.Context, idUsuario int, idLocal *int, instante time.Time) (*models.ExcepcionTurno, error) {
+	rows, err := r.stmts["get_excepcion_vigente"].QueryContext(ctx, idUsuario, idLocal, instante)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get excepcion vigente: %w", err)
+	}
+	defer rows.Close()
+
+	excepciones, err := scanExcepcionesTurno(rows)
+	if err != nil || len(excepciones) == 0 {
+		return nil, err
+	}
+	return excepciones[0], nil
+}
+
+// scanTurnos lee las filas de turnoColumns
+func scanTurnos(rows *sql.Rows) ([]*models.Turno, error) {
+	turnos := []*models.Turno{}
+	for rows.Next() {
+		var t models.Turno
+		if err := rows.Scan(
+			&t.ID, &t.IDUsuario, &t.IDLocal, &t.DiaSemana, &t.HoraInicio, &t.HoraFin,
+			&t.Activo, &t.CreatedAt, &t.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan turno: %w", err)
+		}
+		turnos = append(turnos, &t)
+	}
+
+	return turnos, rows.Err()
+}
+
+// scanExcepcionesTurno lee las filas de excepcionTurnoColumns
+func scanExcepcionesTurno(rows *sql.Rows) ([]*models.ExcepcionTurno, error) {
+	excepciones := []*models.ExcepcionTurno{}
+	for rows.Next() {
+		var e models.ExcepcionTurno
+		if err := rows.Scan(
+			&e.ID, &e.IDUsuario, &e.IDLocal, &e.Desde, &e.Hasta, &e.Motivo,
+			&e.IDSupervisor, &e.CreatedAt, &e.RevocadaAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan excepcion de turno: %w", err)
+		}
+		excepciones = append(excepciones, &e)
+	}
+
+	return excepciones, rows.Err()
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// UbicacionRepository define la interfaz para las ubicaciones físicas de los productos por local
+type UbicacionRepository interface {
+	// SetUbicacion asigna la ubicación del producto en el local (o la reemplaza)
+	SetUbicacion(ctx context.Context, ubicacion *models.UbicacionProducto) error
+	// DeleteUbicacion quita la ubicación; retorna false si el producto no tenía una en el local
+	DeleteUbicacion(ctx context.Context, codigoProducto string, idLocal int) (bool, error)
+	GetUbicaciones(ctx context.Context, filter *models.UbicacionFilter) ([]*models.UbicacionProducto, error)
+	// GetUbicacionesProducto ubicaciones del producto en todos los locales
+	GetUbicacionesProducto(ctx context.Context, codigoProducto string) ([]*models.UbicacionProducto, error)
+	// GetUbicacionesPorCodigo ubicaciones en el local de los productos dados, por código
+	GetUbicacionesPorCodigo(ctx context.Context, idLocal int, codigos []string) (map[string]*models.UbicacionProducto, error)
+}
+
+// ubicacionRepository implementa UbicacionRepository
+type ubicacionRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewUbicacionRepository crea una nueva instancia del repository
+func NewUbicacionRepository(db *sql.DB) (UbicacionRepository, error) {
+	repo := &ubicacionRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *ubicacionRepository) prepareStatements() error {
+	const columnas = `u.id, u.codigo_producto, p.nombre, u.id_local, u.pasillo, u.rack, u.nivel, u.created_at, u.updated_at`
+
+	statements := map[string]string{
+		"set_ubicacion": `
+			INSERT INTO ubicaciones_producto_cantera (codigo_producto, id_local, pasillo, rack, nivel)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (codigo_producto, id_local)
+			DO UPDATE SET pasillo = EXCLUDED.pasillo, rack = EXCLUDED.rack, nivel = EXCLUDED.nivel, updated_at = NOW()
+			RETURNING id, created_at, updated_at
+		`,
+		"delete_ubicacion": `
+			DELETE FROM ubicaciones_producto_cantera
+			WHERE codigo_producto = $1 AND id_local = $2
+		`,
+		"get_ubicaciones": `
+			SELECT ` + columnas + `
+			FROM ubicaciones_producto_cantera u
+			LEFT JOIN productos p ON p.codigo = u.codigo_producto
+			WHERE u.id_local = $1 AND ($2::text IS NULL OR u.pasillo = $2)
+			ORDER BY u.pasillo, u.rack, u.nivel, u.codigo_producto
+		`,
+		"get_ubicaciones_producto": `
+			SELECT ` + columnas + `
+			FROM ubicaciones_producto_cantera u
+			LEFT JOIN productos p ON p.codigo = u.codigo_producto
+			WHERE u.codigo_producto = $1
+			ORDER BY u.id_local
+		`,
+		"get_ubicaciones_por_codigo": `
+			SELECT ` + columnas + `
+			FROM ubicaciones_producto_cantera u
+			LEFT JOIN productos p ON p.codigo = u.codigo_producto
+			WHERE u.id_local = $1 AND u.codigo_producto = ANY($2)
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// SetUbicacion asigna la ubicación del producto en el local
+func (r *ubicacionRepository) SetUbicacion(ctx context.Context, ubicacion *models.UbicacionProducto) error {
+	err := r.stmts["set_ubicacion"].QueryRowContext(ctx,
+		ubicacion.CodigoProducto, ubicacion.IDLocal, ubicacion.Pasillo, ubicacion.Rack, ubicacion.Nivel,
+	).Scan(&ubicacion.ID, &ubicacion.CreatedAt, &ubicacion.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set ubicacion: %w", err)
+	}
+	return nil
+}
+
+// DeleteUbicacion quita la ubicación del producto en el local
+func (r *ubicacionRepository) DeleteUbicacion(ctx context.Context, codigoProducto string, idLocal int) (bool, error) {
+	result, err := r.stmts["delete_ubicacion"].ExecContext(ctx, codigoProducto, idLocal)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete ubicacion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetUbicaciones lista las ubicaciones de un local (opcionalmente de un pasillo)
+func (r *ubicacionRepository) GetUbicaciones(ctx context.Context, filter *models.UbicacionFilter) ([]*models.UbicacionProducto, error) {
+	rows, err := r.stmts["get_ubicaciones"].QueryContext(ctx, filter.IDLocal, filter.Pasillo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ubicaciones: %w", err)
+	}
+	return scanUbicaciones(rows)
+}
+
+// GetUbicacionesProducto lista las ubicaciones del producto en cada local
+func (r *ubicacionRepository) GetUbicacionesProducto(ctx context.Context, codigoProducto string) ([]*models.UbicacionProducto, error) {
+	rows, err := r.stmts["get_ubicaciones_producto"].QueryContext(ctx, codigoProducto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ubicaciones producto: %w", err)
+	}
+	return scanUbicaciones(rows)
+}
+
+// GetUbicacionesPorCodigo obtiene las ubicaciones en el local de los productos dados
+func (r *ubicacionRepository) GetUbicacionesPorCodigo(ctx context.Context, idLocal int, codigos []string) (map[string]*models.UbicacionProducto, error) {
+	rows, err := r.stmts["get_ubicaciones_por_codigo"].QueryContext(ctx, idLocal, pq.Array(codigos))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ubicaciones por codigo: %w", err)
+	}
+	ubicaciones, err := scanUbicaciones(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	porCodigo := make(map[string]*models.UbicacionProducto, len(ubicaciones))
+	for _, ubicacion := range ubicaciones {
+		porCodigo[ubicacion.CodigoProducto] = ubicacion
+	}
+	return porCodigo, nil
+}
+
+// scanUbicaciones lee las filas de ubicaciones y cierra rows
+func scanUbicaciones(rows *sql.Rows) ([]*models.UbicacionProducto, error) {
+	defer rows.Close()
+
+	ubicaciones := []*models.UbicacionProducto{}
+	for rows.Next() {
+		var ubicacion models.UbicacionProducto
+		if err := rows.Scan(
+			&ubicacion.ID, &ubicacion.CodigoProducto, &ubicacion.NombreProducto, &ubicacion.IDLocal,
+			&ubicacion.Pasillo, &ubicacion.Rack, &ubicacion.Nivel, &ubicacion.CreatedAt, &ubicacion.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan ubicacion: %w", err)
+		}
+		ubicaciones = append(ubicaciones, &ubicacion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate ubicaciones: %w", err)
+	}
+
+	return ubicaciones, nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// UnidadRepository define la interfaz para el maestro de unidades y las conversiones por producto
+type UnidadRepository interface {
+	GetUnidades(ctx context.Context) ([]*models.UnidadMedida, error)
+	GetUnidad(ctx context.Context, codigo string) (*models.UnidadMedida, error)
+	CreateUnidad(ctx context.Context, unidad *models.UnidadMedida) error
+	GetConversiones(ctx context.Context, codigoProducto string) ([]*models.ConversionUnidad, error)
+	UpsertConversion(ctx context.Context, conversion *models.ConversionUnidad) error
+	DeleteConversion(ctx context.Context, codigoProducto, unidad string) (bool, error)
+}
+
+// unidadRepository implementa UnidadRepository
+type unidadRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewUnidadRepository crea una nueva instancia del repository
+func NewUnidadRepository(db *sql.DB) (UnidadRepository, error) {
+	repo := &unidadRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *unidadRepository) prepareStatements() error {
+	statements := map[string]string{
+		"get_unidades": `
+			SELECT codigo, nombre, created_at
+			FROM unidades_medida_cantera
+			ORDER BY codigo
+		`,
+		"get_unidad": `
+			SELECT codigo, nombre, created_at
+			FROM unidades_medida_cantera
+			WHERE codigo = $1
+		`,
+		"create_unidad": `
+			INSERT INTO unidades_medida_cantera (codigo, nombre)
+			VALUES ($1, $2)
+			RETURNING created_at
+		`,
+		"get_conversiones": `
+			SELECT codigo_producto, unidad, factor, updated_at
+			FROM conversiones_unidad_cantera
+			WHERE codigo_producto = $1
+			ORDER BY factor
+		`,
+		"upsert_conversion": `
+			INSERT INTO conversiones_unidad_cantera (codigo_producto, unidad, factor)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (codigo_producto, unidad)
+			DO UPDATE SET factor = EXCLUDED.factor, updated_at = NOW()
+			RETURNING updated_at
+		`,
+		"delete_conversion": `
+			DELETE FROM conversiones_unidad_cantera
+			WHERE codigo_producto = $1 AND unidad = $2
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// GetUnidades obtiene el maestro de unidades
+func (r *unidadRepository) GetUnidades(ctx context.Context) ([]*models.UnidadMedida, error) {
+	rows, err := r.stmts["get_unidades"].QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unidades: %w", err)
+	}
+	defer rows.Close()
+
+	unidades := []*models.UnidadMedida{}
+	for rows.Next() {
+		var unidad models.UnidadMedida
+		if err := rows.Scan(&unidad.Codigo, &unidad.Nombre, &unidad.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan unidad: %w", err)
+		}
+		unidades = append(unidades, &unidad)
+	}
+
+	return unidades, nil
+}
+
+// GetUnidad obtiene una unidad del maestro
+func (r *unidadRepository) GetUnidad(ctx context.Context, codigo string) (*models.UnidadMedida, error) {
+	var unidad models.UnidadMedida
+	err := r.stmts["get_unidad"].QueryRowContext(ctx, codigo).Scan(&unidad.Codigo, &unidad.Nombre, &unidad.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unidad: %w", err)
+	}
+
+	return &unidad, nil
+}
+
+// CreateUnidad agrega una unidad al maestro
+func (r *unidadRepository) CreateUnidad(ctx context.Context, unidad *models.UnidadMedida) error {
+	err := r.stmts["create_unidad"].QueryRowContext(ctx, unidad.Codigo, unidad.Nombre).Scan(&unidad.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create unidad: %w", err)
+	}
+
+	return nil
+}
+
+// GetConversiones obtiene las conversiones definidas para un producto
+func (r *unidadRepository) GetConversiones(ctx context.Context, codigoProducto string) ([]*models.ConversionUnidad, error) {
+	rows, err := r.stmts["get_conversiones"].QueryContext(ctx, codigoProducto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversiones: %w", err)
+	}
+	defer rows.Close()
+
+	conversiones := []*models.ConversionUnidad{}
+	for rows.Next() {
+		var conversion models.ConversionUnidad
+		err := rows.Scan(&conversion.CodigoProducto, &conversion.Unidad, &conversion.Factor, &conversion.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conversion: %w", err)
+		}
+		conversiones = append(conversiones, &conversion)
+	}
+
+	return conversiones, nil
+}
+
+// UpsertConversion crea o reemplaza el factor de conversión de una unidad del producto
+func (r *unidadRepository) UpsertConversion(ctx context.Context, conversion *models.ConversionUnidad) error {
+	err := r.stmts["upsert_conversion"].QueryRowContext(ctx,
+		conversion.CodigoProducto, conversion.Unidad, conversion.Factor,
+	).Scan(&conversion.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert conversion: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteConversion elimina la conversión; retorna false si no existía
+func (r *unidadRepository) DeleteConversion(ctx context.Context, codigoProducto, unidad string) (bool, error) {
+	result, err := r.stmts["delete_conversion"].ExecContext(ctx, codigoProducto, unidad)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete conversion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
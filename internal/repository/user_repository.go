@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+)
+
+// UserRepository define la interfaz para los usuarios y sus refresh tokens
+type UserRepository interface {
+	CreateUsuario(ctx context.Context, usuario *models.Usuario) error
+	GetUsuarioByID(ctx context.Context, id int) (*models.Usuario, error)
+	GetUsuarioByUsername(ctx context.Context, username string) (*models.Usuario, error)
+	GetUsuarios(ctx context.Context) ([]*models.Usuario, error)
+	UpdateUsuario(ctx context.Context, usuario *models.Usuario) error
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+
+	CreateRefreshToken(ctx context.Context, tokenHash string, idUsuario int, expiraAt time.Time) error
+	ConsumirRefreshToken(ctx context.Context, tokenHash string) (int, error)
+	RevocarRefreshTokens(ctx context.Context, idUsuario int) error
+}
+
+// userRepository implementa UserRepository
+type userRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewUserRepository crea una nueva instancia del repository
+func NewUserRepository(db *sql.DB) (UserRepository, error) {
+	repo := &userRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// usuarioColumns columnas de usuarios_cantera en el orden de scanUsuario
+const usuarioColumns = `id, username, email, rol, activo, created_at, updated_at, password_hash`
+
+// prepareStatements prepara todas las consultas SQL
+func (r *userRepository) prepareStatements() error {
+	statements := map[string]string{
+		"create_usuario": `
+			INSERT INTO usuarios_cantera (username, email, password_hash, rol, activo)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at, updated_at
+		`,
+		"get_usuario_by_id": `
+			SELECT ` + usuarioColumns + `
+			FROM usuarios_cantera
+			WHERE id = $1
+		`,
+		"get_usuario_by_username": `
+			SELECT ` + usuarioColumns + `
+			FROM usuarios_cantera
+			WHERE LOWER(username) = LOWER($1)
+		`,
+		"get_usuarios": `
+			SELECT ` + usuarioColumns + `
+			FROM usuarios_cantera
+			ORDER BY username
+		`,
+		"update_usuario": `
+			UPDATE usuarios_cantera
+			SET email = $2, rol = $3, activo = $4, updated_at = NOW()
+			WHERE id = $1
+			RETURNING updated_at
+		`,
+		"create_refresh_token": `
+			INSERT INTO refresh_tokens_cantera (token_hash, id_usuario, expira_at)
+			VALUES ($1, $2, $3)
+		`,
+		"consumir_refresh_token": `
+			UPDATE refresh_tokens_cantera
+			SET revocado_at = NOW()
+			WHERE token_hash = $1 AND revocado_at IS NULL AND expira_at > NOW()
+			RETURNING id_usuario
+		`,
+		"revocar_refresh_tokens": `
+			UPDATE refresh_tokens_cantera
+			SET revocado_at = NOW()
+			WHERE id_usuario = $1 AND revocado_at IS NULL
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// CreateUsuario registra un usuario
+func (r *userRepository) CreateUsuario(ctx context.Context, usuario *models.Usuario) error {
+	err := r.stmts["create_usuario"].QueryRowContext(ctx,
+		usuario.Username, usuario.Email, usuario.PasswordHash, usuario.Rol, usuario.Activo,
+	).Scan(&usuario.ID, &usuario.CreatedAt, &usuario.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create usuario: %w", err)
+	}
+
+	return nil
+}
+
+// GetUsuarioByID obtiene un usuario por id (nil si no existe)
+func (r *userRepository) GetUsuarioByID(ctx context.Context, id int) (*models.Usuario, error) {
+	usuario, err := scanUsuario(r.stmts["get_usuario_by_id"].QueryRowContext(ctx, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usuario: %w", err)
+	}
+
+	return usuario, nil
+}
+
+// GetUsuarioByUsername obtiene un usuario por username, sin distinguir mayúsculas (nil si no existe)
+func (r *userRepository) GetUsuarioByUsername(ctx context.Context, username string) (*models.Usuario, error) {
+	usuario, err := scanUsuario(r.stmts["get_usuario_by_username"].QueryRowContext(ctx, username))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usuario: %w", err)
+	}
+
+	return usuario, nil
+}
+
+// GetUsuarios lista todos los usuarios, activos o no
+func (r *userRepository) GetUsuarios(ctx context.Context) ([]*models.Usuario, error) {
+	rows, err := r.stmts["get_usuarios"].QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usuarios: %w", err)
+	}
+	defer rows.Close()
+
+	usuarios := []*models.Usuario{}
+	for rows.Next() {
+		usuario, err := scanUsuario(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan usuario: %w", err)
+		}
+		usuarios = append(usuarios, usuario)
+	}
+
+	return usuarios, rows.Err()
+}
+
+// UpdateUsuario actualiza email, rol y estado del usuario
+func (r *userRepository) UpdateUsuario(ctx context.Context, usuario *models.Usuario) error {
+	err := r.stmts["update_usuario"].QueryRowContext(ctx,
+		usuario.ID, usuario.Email, usuario.Rol, usuario.Activo,
+	).Scan(&usuario.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update usuario: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePassword fija la contraseña del usuario y revoca sus refresh tokens en una transacción
+func (r *userRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE usuarios_cantera
+		SET password_hash = $2, updated_at = NOW()
+		WHERE id = $1
+	`, id, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.StmtContext(ctx, r.stmts["revocar_refresh_tokens"]).ExecContext(ctx, id); err != nil {
+		return fmt.Errorf("failed to revocar refresh tokens: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// CreateRefreshToken registra el hash de un refresh token emitido
+func (r *userRepository) CreateRefreshToken(ctx context.Context, tokenHash string, idUsuario int, expiraAt time.Time) error {
+	if _, err := r.stmts["create_refresh_token"].ExecContext(ctx, tokenHash, idUsuario, expiraAt); err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumirRefreshToken revoca el refresh token si sigue vigente y retorna su usuario
+// Es atómico: dos refresh simultáneos con el mismo token no pueden ganar ambos
+func (r *userRepository) ConsumirRefreshToken(ctx context.Context, tokenHash string) (int, error) {
+	var idUsuario int
+	err := r.stmts["consumir_refresh_token"].QueryRowContext(ctx, tokenHash).Scan(&idUsuario)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to consumir refresh token: %w", err)
+	}
+
+	return idUsuario, nil
+}
+
+// RevocarRefreshTokens revoca todos los refresh tokens vigentes del usuario
+func (r *userRepository) RevocarRefreshTokens(ctx context.Context, idUsuario int) error {
+	if _, err := r.stmts["revocar_refresh_tokens"].ExecContext(ctx, idUsuario); err != nil {
+		return fmt.Errorf("failed to revocar refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// scanUsuario lee una fila de usuarioColumns (nil si no hay fila)
+func scanUsuario(row interface{ Scan(...interface{}) error }) (*models.Usuario, error) {
+	var u models.Usuario
+	err := row.Scan(&u.ID, &u.Username, &u.Email, &u.Rol, &u.Activo, &u.CreatedAt, &u.UpdatedAt, &u.PasswordHash)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
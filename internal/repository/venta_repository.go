@@ -0,0 +1,369 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// VentaRepository define la interfaz para las líneas contables y los precios de las ventas rápidas
+type VentaRepository interface {
+	CreateLineasContables(ctx context.Context, lineas []*models.LineaContableVenta) error
+	// CreatePreciosLineas registra el precio de cada línea vendida en una transacción
+	CreatePreciosLineas(ctx context.Context, lineas []*models.PrecioLineaVenta) error
+	// GetPreciosLineas precios de las líneas de la venta con el historial de lista vigente al venderse
+	GetPreciosLineas(ctx context.Context, idOperacion string) ([]*models.ExplicacionPrecioLinea, error)
+
+	// CreateVenta registra la venta con su detalle en una transacción; si ya existe una venta con
+	// el mismo id_operacion no la duplica y retorna false (carga el ID de la existente)
+	CreateVenta(ctx context.Context, venta *models.Venta) (bool, error)
+	// GetVenta venta con su detalle (nil si no existe)
+	GetVenta(ctx context.Context, id int64) (*models.Venta, error)
+	// GetVentas página de ventas (sin detalle), las más recientes primero
+	GetVentas(ctx context.Context, filter *models.VentaFilter) ([]*models.Venta, error)
+	CountVentas(ctx context.Context, filter *models.VentaFilter) (int, error)
+
+	// ConTransaccion repository que escribe en la transacción de repo (ej. la venta con su salida de
+	// stock); si repo no tiene transacción cada escritura usa la suya
+	ConTransaccion(repo StockRepository) VentaRepository
+}
+
+// ventaColumns columnas de la cabecera de una venta (en el orden de scanVenta)
+const ventaColumns = `id, id_operacion, id_local, id_usuario, id_autorizador, motivo, observaciones,
+	medio_pago, subtotal, propina, cargo_servicio, ajuste_redondeo, total, neto, iva, exento,
+	venta_sospechosa, encolada, created_at`
+
+// ventaRepository implementa VentaRepository
+type ventaRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+	tx    *sql.Tx // no nil si escribe en la transacción de una operación de stock
+}
+
+// NewVentaRepository crea una nueva instancia del repository
+func NewVentaRepository(db *sql.DB) (VentaRepository, error) {
+	repo := &ventaRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *ventaRepository) prepareStatements() error {
+	statements := map[string]string{
+		"create_linea_contable": `
+			INSERT INTO lineas_contables_venta_cantera
+			(venta_ref, id_local, id_usuario, medio_pago, tipo, monto)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, created_at
+		`,
+		"create_precio_linea": `
+			INSERT INTO precios_lineas_venta_cantera
+			(venta_ref, id_operacion, id_local, id_usuario, codigo_producto, tipo_item, cantidad,
+			 precio_lista, lista_updated_at, precio_maestro, origen_precio, precio_base, precio_cobrado,
+			 precio_modificado, motivo_precio, id_autorizador)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+			RETURNING id, created_at
+		`,
+		"get_precios_lineas": `
+			SELECT pl.id, pl.venta_ref, pl.id_operacion, pl.id_local, pl.id_usuario, pl.codigo_producto,
+				   pl.tipo_item, pl.cantidad, pl.precio_lista, pl.lista_updated_at, pl.precio_maestro,
+				   pl.origen_precio, pl.precio_base, pl.precio_cobrado, pl.precio_modificado,
+				   pl.motivo_precio, pl.id_autorizador, pl.created_at,
+				   h.precio_detalle, h.vigente_desde
+			FROM precios_lineas_venta_cantera pl
+			LEFT JOIN LATERAL (
+				SELECT hp.precio_detalle, hp.vigente_desde
+				FROM historial_precios_cantera hp
+				WHERE hp.codigo_tivendo = pl.codigo_producto
+				  AND hp.vigente_desde <= pl.created_at
+				  AND (hp.vigente_hasta IS NULL OR hp.vigente_hasta > pl.created_at)
+				ORDER BY hp.vigente_desde DESC
+				LIMIT 1
+			) h ON true
+			WHERE pl.id_operacion = $1
+			ORDER BY pl.id
+		`,
+		// xmax = 0 solo en una fila recién insertada (en conflicto se "actualiza" sin cambios)
+		"create_venta": `
+			INSERT INTO ventas_cantera
+			(id_operacion, id_local, id_usuario, id_autorizador, motivo, observaciones, medio_pago,
+			 subtotal, propina, cargo_servicio, ajuste_redondeo, total, neto, iva, exento,
+			 venta_sospechosa, encolada)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+			ON CONFLICT (id_operacion) DO UPDATE SET id_operacion = EXCLUDED.id_operacion
+			RETURNING id, created_at, (xmax = 0)
+		`,
+		"create_venta_detalle": `
+			INSERT INTO venta_detalles_cantera
+			(id_venta, codigo_producto, tipo_item, cantidad, precio_unitario, precio_modificado, exento, monto)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id
+		`,
+		"get_venta": `
+			SELECT ` + ventaColumns + `
+			FROM ventas_cantera
+			WHERE id = $1
+		`,
+		"get_venta_detalles": `
+			SELECT id, id_venta, codigo_producto, tipo_item, cantidad, precio_unitario,
+				   precio_modificado, exento, monto
+			FROM venta_detalles_cantera
+			WHERE id_venta = $1
+			ORDER BY id
+		`,
+		"get_ventas": `
+			SELECT ` + ventaColumns + `
+			FROM ventas_cantera
+			WHERE ($1::int IS NULL OR id_local = $1)
+			  AND ($2::timestamp IS NULL OR created_at >= $2)
+			  AND ($3::timestamp IS NULL OR created_at < $3::timestamp + INTERVAL '1 day')
+			ORDER BY created_at DESC, id DESC
+			LIMIT $4 OFFSET $5
+		`,
+		"count_ventas": `
+			SELECT COUNT(*)
+			FROM ventas_cantera
+			WHERE ($1::int IS NULL OR id_local = $1)
+			  AND ($2::timestamp IS NULL OR created_at >= $2)
+			  AND ($3::timestamp IS NULL OR created_at < $3::timestamp + INTERVAL '1 day')
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// ConTransaccion liga las escrituras a la transacción del repository de stock
+func (r *ventaRepository) ConTransaccion(repo StockRepository) VentaRepository {
+	stock, ok := repo.(*stockRepository)
+	if !ok || stock.tx == nil {
+		return r
+	}
+	return &ventaRepository{db: r.db, stmts: r.stmts, tx: stock.tx}
+}
+
+// runInTx ejecuta fn en la transacción ligada o, si no hay, en una propia
+func (r *ventaRepository) runInTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CreateLineasContables registra las líneas contables de una venta en una transacción
+func (r *ventaRepository) CreateLineasContables(ctx context.Context, lineas []*models.LineaContableVenta) error {
+	return r.runInTx(ctx, func(tx *sql.Tx) error {
+		stmt := tx.StmtContext(ctx, r.stmts["create_linea_contable"])
+		for _, linea := range lineas {
+			err := stmt.QueryRowContext(ctx,
+				linea.VentaRef, linea.IDLocal, linea.IDUsuario, linea.MedioPago, linea.Tipo, linea.Monto,
+			).Scan(&linea.ID, &linea.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to create linea contable venta: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// CreatePreciosLineas registra los precios de las líneas de una venta en una transacción
+func (r *ventaRepository) CreatePreciosLineas(ctx context.Context, lineas []*models.PrecioLineaVenta) error {
+	return r.runInTx(ctx, func(tx *sql.Tx) error {
+		stmt := tx.StmtContext(ctx, r.stmts["create_precio_linea"])
+		for _, linea := range lineas {
+			err := stmt.QueryRowContext(ctx,
+				linea.VentaRef, linea.IDOperacion, linea.IDLocal, linea.IDUsuario, linea.CodigoProducto,
+				linea.TipoItem, linea.Cantidad, linea.PrecioLista, linea.ListaUpdatedAt, linea.PrecioMaestro,
+				linea.OrigenPrecio, linea.PrecioBase, linea.PrecioCobrado, linea.PrecioModificado,
+				linea.MotivoPrecio, linea.IDAutorizador,
+			).Scan(&linea.ID, &linea.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to create precio linea venta: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetPreciosLineas obtiene los precios de las líneas de la venta con el historial vigente
+func (r *ventaRepository) GetPreciosLineas(ctx context.Context, idOperacion string) ([]*models.ExplicacionPrecioLinea, error) {
+	rows, err := r.stmts["get_precios_lineas"].QueryContext(ctx, idOperacion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query precios lineas venta: %w", err)
+	}
+	defer rows.Close()
+
+	lineas := []*models.ExplicacionPrecioLinea{}
+	for rows.Next() {
+		linea := &models.ExplicacionPrecioLinea{PrecioLineaVenta: &models.PrecioLineaVenta{}}
+		if err := rows.Scan(
+			&linea.ID, &linea.VentaRef, &linea.IDOperacion, &linea.IDLocal, &linea.IDUsuario,
+			&linea.CodigoProducto, &linea.TipoItem, &linea.Cantidad, &linea.PrecioLista,
+			&linea.ListaUpdatedAt, &linea.PrecioMaestro, &linea.OrigenPrecio, &linea.PrecioBase,
+			&linea.PrecioCobrado, &linea.PrecioModificado, &linea.MotivoPrecio, &linea.IDAutorizador,
+			&linea.CreatedAt, &linea.PrecioHistorial, &linea.VigenteDesde,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan precio linea venta: %w", err)
+		}
+		lineas = append(lineas, linea)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate precios lineas venta: %w", err)
+	}
+
+	return lineas, nil
+}
+
+// CreateVenta registra la venta y su detalle en una transacción
+func (r *ventaRepository) CreateVenta(ctx context.Context, venta *models.Venta) (bool, error) {
+	var creada bool
+	err := r.runInTx(ctx, func(tx *sql.Tx) error {
+		t := venta.Totales
+		err := tx.StmtContext(ctx, r.stmts["create_venta"]).QueryRowContext(ctx,
+			venta.IDOperacion, venta.IDLocal, venta.IDUsuario, venta.IDAutorizador, venta.Motivo, venta.Observaciones,
+			t.MedioPago, t.Subtotal, t.Propina, t.CargoServicio, t.AjusteRedondeo, t.Total, t.Neto, t.IVA, t.Exento,
+			venta.VentaSospechosa, venta.Encolada,
+		).Scan(&venta.ID, &venta.CreatedAt, &creada)
+		if err != nil {
+			return fmt.Errorf("failed to create venta: %w", err)
+		}
+		if !creada {
+			return nil
+		}
+
+		stmt := tx.StmtContext(ctx, r.stmts["create_venta_detalle"])
+		for _, detalle := range venta.Detalles {
+			detalle.IDVenta = venta.ID
+			err := stmt.QueryRowContext(ctx,
+				detalle.IDVenta, detalle.CodigoProducto, detalle.TipoItem, detalle.Cantidad,
+				detalle.PrecioUnitario, detalle.PrecioModificado, detalle.Exento, detalle.Monto,
+			).Scan(&detalle.ID)
+			if err != nil {
+				return fmt.Errorf("failed to create venta detalle: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return creada, nil
+}
+
+// GetVenta obtiene la venta con su detalle
+func (r *ventaRepository) GetVenta(ctx context.Context, id int64) (*models.Venta, error) {
+	venta, err := scanVenta(r.stmts["get_venta"].QueryRowContext(ctx, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get venta: %w", err)
+	}
+
+	rows, err := r.stmts["get_venta_detalles"].QueryContext(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query venta detalles: %w", err)
+	}
+	defer rows.Close()
+
+	venta.Detalles = []*models.VentaDetalle{}
+	for rows.Next() {
+		var d models.VentaDetalle
+		if err := rows.Scan(
+			&d.ID, &d.IDVenta, &d.CodigoProducto, &d.TipoItem, &d.Cantidad, &d.PrecioUnitario,
+			&d.PrecioModificado, &d.Exento, &d.Monto,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan venta detalle: %w", err)
+		}
+		venta.Detalles = append(venta.Detalles, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate venta detalles: %w", err)
+	}
+
+	return venta, nil
+}
+
+// GetVentas obtiene una página de ventas sin su detalle
+func (r *ventaRepository) GetVentas(ctx context.Context, filter *models.VentaFilter) ([]*models.Venta, error) {
+	rows, err := r.stmts["get_ventas"].QueryContext(ctx,
+		filter.IDLocal, filter.FechaDesde, filter.FechaHasta, filter.Limit, filter.Offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ventas: %w", err)
+	}
+	defer rows.Close()
+
+	ventas := []*models.Venta{}
+	for rows.Next() {
+		venta, err := scanVenta(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan venta: %w", err)
+		}
+		ventas = append(ventas, venta)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate ventas: %w", err)
+	}
+
+	return ventas, nil
+}
+
+// CountVentas cuenta las ventas que cumplen el filtro
+func (r *ventaRepository) CountVentas(ctx context.Context, filter *models.VentaFilter) (int, error) {
+	var total int
+	err := r.stmts["count_ventas"].QueryRowContext(ctx,
+		filter.IDLocal, filter.FechaDesde, filter.FechaHasta,
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count ventas: %w", err)
+	}
+	return total, nil
+}
+
+// scanVenta lee la cabecera de una venta (columnas de ventaColumns)
+func scanVenta(row interface{ Scan(...interface{}) error }) (*models.Venta, error) {
+	var v models.Venta
+	err := row.Scan(
+		&v.ID, &v.IDOperacion, &v.IDLocal, &v.IDUsuario, &v.IDAutorizador, &v.Motivo, &v.Observaciones,
+		&v.Totales.MedioPago, &v.Totales.Subtotal, &v.Totales.Propina, &v.Totales.CargoServicio,
+		&v.Totales.AjusteRedondeo, &v.Totales.Total, &v.Totales.Neto, &v.Totales.IVA, &v.Totales.Exento,
+		&v.VentaSospechosa, &v.Encolada, &v.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
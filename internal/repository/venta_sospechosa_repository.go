@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"stock-service/internal/models"
+)
+
+// VentaSospechosaRepository define la interfaz para el registro de ventas sospechosas
+type VentaSospechosaRepository interface {
+	CreateVentaSospechosa(ctx context.Context, venta *models.VentaSospechosa) error
+	GetVentasSospechosas(ctx context.Context, filter *models.VentaSospechosaFilter) ([]*models.VentaSospechosa, error)
+	MarcarRevisada(ctx context.Context, id int, idUsuario int) error
+}
+
+// ventaSospechosaRepository implementa VentaSospechosaRepository
+type ventaSospechosaRepository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewVentaSospechosaRepository crea una nueva instancia del repository
+func NewVentaSospechosaRepository(db *sql.DB) (VentaSospechosaRepository, error) {
+	repo := &ventaSospechosaRepository{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := repo.prepareStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return repo, nil
+}
+
+// prepareStatements prepara todas las consultas SQL
+func (r *ventaSospechosaRepository) prepareStatements() error {
+	statements := map[string]string{
+		"create_venta_sospechosa": `
+			INSERT INTO ventas_sospechosas_cantera
+			(fingerprint, id_local, id_usuario, items, monto, bloqueada)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, created_at
+		`,
+		"get_ventas_sospechosas": `
+			SELECT id, fingerprint, id_local, id_usuario, items, monto, bloqueada,
+				   revisada, revisada_por, revisada_at, created_at
+			FROM ventas_sospechosas_cantera
+			WHERE ($1::int IS NULL OR id_local = $1)
+			  AND ($2 = false OR revisada = false)
+			ORDER BY created_at DESC
+			LIMIT $3
+		`,
+		"marcar_revisada": `
+			UPDATE ventas_sospechosas_cantera
+			SET revisada = true, revisada_por = $2, revisada_at = NOW()
+			WHERE id = $1
+		`,
+	}
+
+	for name, query := range statements {
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		r.stmts[name] = stmt
+	}
+
+	return nil
+}
+
+// CreateVentaSospechosa registra una venta sospechosa para revisión
+func (r *ventaSospechosaRepository) CreateVentaSospechosa(ctx context.Context, venta *models.VentaSospechosa) error {
+	err := r.stmts["create_venta_sospechosa"].QueryRowContext(ctx,
+		venta.Fingerprint, venta.IDLocal, venta.IDUsuario, venta.Items, venta.Monto, venta.Bloqueada,
+	).Scan(&venta.ID, &venta.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create venta sospechosa: %w", err)
+	}
+
+	return nil
+}
+
+// GetVentasSospechosas obtiene ventas sospechosas con filtros
+func (r *ventaSospechosaRepository) GetVentasSospechosas(ctx context.Context, filter *models.VentaSospechosaFilter) ([]*models.VentaSospechosa, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := r.stmts["get_ventas_sospechosas"].QueryContext(ctx, filter.IDLocal, filter.SoloPendientes, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ventas sospechosas: %w", err)
+	}
+	defer rows.Close()
+
+	var ventas []*models.VentaSospechosa
+	for rows.Next() {
+		var venta models.VentaSospechosa
+		err := rows.Scan(
+			&venta.ID, &venta.Fingerprint, &venta.IDLocal, &venta.IDUsuario, &venta.Items,
+			&venta.Monto, &venta.Bloqueada, &venta.Revisada, &venta.RevisadaPor,
+			&venta.RevisadaAt, &venta.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan venta sospechosa: %w", err)
+		}
+		ventas = append(ventas, &venta)
+	}
+
+	return ventas, nil
+}
+
+// MarcarRevisada marca una venta sospechosa como revisada por un supervisor
+func (r *ventaSospechosaRepository) MarcarRevisada(ctx context.Context, id int, idUsuario int) error {
+	result, err := r.stmts["marcar_revisada"].ExecContext(ctx, id, idUsuario)
+	if err != nil {
+		return fmt.Errorf("failed to marcar revisada: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: venta sospechosa %d", ErrNotFound, id)
+	}
+
+	return nil
+}
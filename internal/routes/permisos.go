@@ -0,0 +1,186 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"stock-service/internal/middleware"
+	"stock-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Roles: admin todo; bodeguero recepción, traslados e inventario; vendedor salidas y POS
+var (
+	rolesAdmin  = []string{models.RolAdmin}
+	rolesBodega = []string{models.RolAdmin, models.RolBodeguero}
+	rolesVenta  = []string{models.RolAdmin, models.RolBodeguero, models.RolVendedor}
+)
+
+// Tabla única de permisos de /api/v1: SetupRoutes aplica estos roles (Authenticator.Roles) al
+// registrar cada ruta y no arranca si una ruta que modifica datos queda sin roles
+// Una ruta con roles exige token aunque su grupo no esté en JWT_PROTECTED_GROUPS
+
+// rolesPorGrupo roles que exige el grupo a todas sus rutas
+var rolesPorGrupo = map[string][]string{
+	"usuarios":           rolesAdmin,
+	"turnos":             rolesAdmin,
+	"picking":            rolesBodega,
+	"ubicaciones":        rolesBodega,
+	"guias":              rolesBodega,
+	"notas-credito":      rolesVenta, // además NOTA_CREDITO_ALLOWED_ROLES en el servicio
+	"conteos-ciclicos":   rolesBodega,
+	"tomas-inventario":   rolesBodega,
+	"plantillas-entrada": rolesBodega,
+	"aprobaciones":       rolesAdmin,
+	"reglas-operacion":   rolesAdmin,
+	"reportes":           rolesBodega,
+	"jobs":               rolesBodega,
+	"movimientos":        rolesBodega,
+	"admin":              rolesAdmin,
+	"monitoring":         rolesAdmin,
+}
+
+// rolesPorRuta roles de una ruta ("MÉTODO /ruta" sin /api/v1): las de grupos sin roles propios y las
+// que acotan los de su grupo
+var rolesPorRuta = map[string][]string{
+	// Stock
+	"POST /stock/entrada-multiple":           rolesBodega,
+	"POST /stock/salida-multiple":            rolesVenta,
+	"POST /stock/ajuste":                     rolesBodega,
+	"POST /stock/recepcion/gs1":              rolesBodega,
+	"POST /stock/minimos-estacionales":       rolesBodega,
+	"DELETE /stock/minimos-estacionales/:id": rolesBodega,
+	"POST /stock/proyeccion":                 rolesBodega,
+	"POST /stock/auditoria":                  rolesAdmin,
+	"GET /stock/movimientos/:id/integridad":  rolesAdmin,
+	"POST /stock/bodegas":                    rolesAdmin,
+	"PUT /stock/bodegas/:id":                 rolesAdmin,
+	"POST /stock/traslado-interno":           rolesBodega,
+	"POST /stock/transferencia":              rolesBodega,
+	"POST /stock/transferencia/:id/anular":   rolesAdmin,
+
+	// Maestros, e-commerce y perfiles del ERP
+	"POST /productos/:codigo/imagen":              rolesAdmin,
+	"PUT /productos/:codigo/imagen":               rolesAdmin,
+	"DELETE /productos/:codigo/imagen":            rolesAdmin,
+	"PUT /productos/:codigo/unidades/:unidad":     rolesAdmin,
+	"DELETE /productos/:codigo/unidades/:unidad":  rolesAdmin,
+	"PUT /productos/:codigo/canales":              rolesAdmin,
+	"DELETE /productos/:codigo/canales":           rolesAdmin,
+	"POST /productos/:codigo/bloqueos":            rolesBodega,
+	"POST /productos/:codigo/bloqueos/levantar":   rolesBodega,
+	"POST /unidades":                              rolesAdmin,
+	"PUT /ecommerce/margenes/producto/:codigo":    rolesAdmin,
+	"DELETE /ecommerce/margenes/producto/:codigo": rolesAdmin,
+	"PUT /ecommerce/margenes/categoria/:id":       rolesAdmin,
+	"DELETE /ecommerce/margenes/categoria/:id":    rolesAdmin,
+	"POST /reportes/erp/perfiles":                 rolesAdmin,
+	"PUT /reportes/erp/perfiles/:id":              rolesAdmin,
+	"DELETE /reportes/erp/perfiles/:id":           rolesAdmin,
+
+	// POS: la venta la hace cualquier rol; las consultas de ventas y del cache no son públicas
+	"POST /pos/venta-rapida":                       rolesVenta,
+	"GET /pos/ventas/:id":                          rolesVenta,
+	"GET /pos/ventas/:id/precios":                  rolesVenta,
+	"GET /pos/ventas":                              rolesBodega,
+	"GET /pos/ventas-sospechosas":                  rolesBodega,
+	"GET /pos/overrides-precio":                    rolesBodega,
+	"GET /pos/ventas-encoladas":                    rolesBodega,
+	"GET /pos/cache-stats":                         rolesBodega,
+	"GET /pos/cache/conciliaciones-precios":        rolesBodega,
+	"PUT /pos/botones-rapidos/:local":              rolesBodega,
+	"DELETE /pos/botones-rapidos/:local/:posicion": rolesBodega,
+	"POST /pos/preload":                            rolesBodega,
+	"POST /pos/ventas-sospechosas/:id/revisar":     rolesAdmin,
+	"POST /pos/ventas-encoladas/reconciliar":       rolesBodega,
+	"DELETE /pos/cache/producto/:codigo":           rolesBodega,
+	"DELETE /pos/cache/codigo-tivendo/:codigo":     rolesBodega,
+	"DELETE /pos/cache/all":                        rolesBodega,
+	"POST /pos/cache/invalidate":                   rolesBodega,
+	"POST /pos/cache/conciliacion-precios":         rolesBodega,
+	"POST /pos/cache/notify-lista-precios-update":  rolesBodega,
+	"POST /pos/cache/notify-productos-update":      rolesBodega,
+}
+
+// permisos registra los grupos de /api/v1 aplicando la tabla de roles
+type permisos struct {
+	v1     *gin.RouterGroup
+	authn  *middleware.Authenticator
+	usadas map[string]bool
+}
+
+func nuevosPermisos(v1 *gin.RouterGroup, authn *middleware.Authenticator) *permisos {
+	return &permisos{v1: v1, authn: authn, usadas: make(map[string]bool, len(rolesPorRuta))}
+}
+
+// grupo crea el grupo autenticado por Authenticator.Grupo(nombre) con los roles de rolesPorGrupo
+func (p *permisos) grupo(relativePath, nombre string, handlers ...gin.HandlerFunc) *grupoRutas {
+	cadena := []gin.HandlerFunc{p.authn.Grupo(nombre)}
+	roles := rolesPorGrupo[nombre]
+	if roles != nil {
+		cadena = append(cadena, p.authn.Roles(roles...))
+	}
+	return &grupoRutas{
+		permisos: p,
+		group:    p.v1.Group(relativePath, append(cadena, handlers...)...),
+		conRoles: roles != nil,
+	}
+}
+
+// verificar falla si la tabla tiene rutas que no se registraron (quedarían sin los roles previstos)
+func (p *permisos) verificar() {
+	sobrantes := []string{}
+	for ruta := range rolesPorRuta {
+		if !p.usadas[ruta] {
+			sobrantes = append(sobrantes, ruta)
+		}
+	}
+	if len(sobrantes) > 0 {
+		sort.Strings(sobrantes)
+		panic(fmt.Sprintf("rolesPorRuta tiene rutas no registradas: %s", strings.Join(sobrantes, ", ")))
+	}
+}
+
+// grupoRutas grupo de /api/v1 que agrega a cada ruta sus roles de rolesPorRuta
+type grupoRutas struct {
+	*permisos
+	group    *gin.RouterGroup
+	conRoles bool
+}
+
+func (g *grupoRutas) GET(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodGet, relativePath, handlers)
+}
+
+func (g *grupoRutas) HEAD(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodHead, relativePath, handlers)
+}
+
+func (g *grupoRutas) POST(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodPost, relativePath, handlers)
+}
+
+func (g *grupoRutas) PUT(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodPut, relativePath, handlers)
+}
+
+func (g *grupoRutas) DELETE(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodDelete, relativePath, handlers)
+}
+
+// handle registra la ruta con sus roles; una ruta que modifica datos sin roles (propios ni del grupo)
+// es un error de programación y el servidor no arranca
+func (g *grupoRutas) handle(method, relativePath string, handlers []gin.HandlerFunc) {
+	ruta := method + " " + strings.TrimPrefix(path.Join(g.group.BasePath(), relativePath), g.v1.BasePath())
+	if roles, ok := rolesPorRuta[ruta]; ok {
+		g.usadas[ruta] = true
+		handlers = append([]gin.HandlerFunc{g.authn.Roles(roles...)}, handlers...)
+	} else if !g.conRoles && method != http.MethodGet && method != http.MethodHead {
+		panic(fmt.Sprintf("la ruta %s modifica datos y no tiene roles en rolesPorRuta ni en rolesPorGrupo", ruta))
+	}
+	g.group.Handle(method, relativePath, handlers...)
+}
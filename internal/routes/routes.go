@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"stock-service/internal/admin"
+	"stock-service/internal/config"
 	"stock-service/internal/handlers"
 	"stock-service/internal/middleware"
 
@@ -8,66 +10,410 @@ import (
 )
 
 // SetupRoutes configura todas las rutas de la aplicación
-func SetupRoutes(router *gin.Engine, stockHandler *handlers.StockHandler, posHandler *handlers.POSHandler, monitoringHandler *handlers.MonitoringHandler, healthChecker *middleware.HealthChecker) {
+func SetupRoutes(router *gin.Engine, stockHandler *handlers.StockHandler, posHandler *handlers.POSHandler, botonHandler *handlers.BotonRapidoHandler, pickingHandler *handlers.PickingHandler, ubicacionHandler *handlers.UbicacionHandler, guiaHandler *handlers.GuiaDespachoHandler, notaCreditoHandler *handlers.NotaCreditoHandler, conteoCiclicoHandler *handlers.ConteoCiclicoHandler, tomaInventarioHandler *handlers.TomaInventarioHandler, approvalHandler *handlers.ApprovalHandler, reglaHandler *handlers.ReglaOperacionHandler, productoHandler *handlers.ProductoHandler, unidadHandler *handlers.UnidadHandler, packHandler *handlers.PackHandler, plantillaHandler *handlers.PlantillaHandler, ecommerceHandler *handlers.EcommerceHandler, reporteHandler *handlers.ReporteHandler, exportacionERPHandler *handlers.ExportacionERPHandler, vencimientoHandler *handlers.VencimientoHandler, busquedaHandler *handlers.BusquedaHandler, syncHandler *handlers.SyncHandler, adminHandler *handlers.AdminHandler, monitoringHandler *handlers.MonitoringHandler, criticoHandler *handlers.ProductoCriticoHandler, authHandler *handlers.AuthHandler, usuarioHandler *handlers.UsuarioHandler, minimoHandler *handlers.MinimoEstacionalHandler, turnoHandler *handlers.TurnoHandler, jobHandler *handlers.JobHandler, healthChecker *middleware.HealthChecker, authn *middleware.Authenticator, apiKeyAuth gin.HandlerFunc, enTurno gin.HandlerFunc, heavyLimiter *middleware.HeavyLimiter, timeouts config.TimeoutsConfig) {
+	// Deadlines por tipo de operación
+	posTimeout := middleware.TimeoutMiddleware(timeouts.POSSearch)
+	stockTimeout := middleware.TimeoutMiddleware(timeouts.StockOperation)
+	// Los reportes además compiten por un cupo acotado del pool: nunca bloquean al POS
+	reportTimeout := heavyLimiter.Wrap(middleware.TimeoutMiddleware(timeouts.Report))
+
 	// API v1 group
 	v1 := router.Group("/api/v1")
+	// Los grupos se registran con los roles de la tabla de permisos (permisos.go)
+	p := nuevosPermisos(v1, authn)
 	{
+		// Login y renovación de tokens (públicas: son las que entregan el token)
+		authAPI := v1.Group("/auth")
+		{
+			authAPI.POST("/login", stockTimeout, authHandler.Login)
+			authAPI.POST("/refresh", stockTimeout, authHandler.Refresh)
+		}
+
+		// Usuarios (no se borran: se desactivan con activo=false); exige token siempre
+		usuarios := p.grupo("/usuarios", "usuarios")
+		{
+			usuarios.GET("", stockTimeout, usuarioHandler.GetUsuarios)
+			usuarios.POST("", stockTimeout, usuarioHandler.CrearUsuario)
+			usuarios.GET("/:id", stockTimeout, usuarioHandler.GetUsuario)
+			usuarios.PUT("/:id", stockTimeout, usuarioHandler.ActualizarUsuario)
+			usuarios.PUT("/:id/password", stockTimeout, usuarioHandler.CambiarPassword)
+		}
+
+		// Turnos de trabajo y excepciones autorizadas por un supervisor
+		turnos := p.grupo("/turnos", "turnos")
+		{
+			turnos.GET("", stockTimeout, turnoHandler.GetTurnos)
+			turnos.POST("", stockTimeout, turnoHandler.CrearTurno)
+			turnos.PUT("/:id", stockTimeout, turnoHandler.ActualizarTurno)
+			turnos.DELETE("/:id", stockTimeout, turnoHandler.EliminarTurno)
+			turnos.GET("/excepciones", stockTimeout, turnoHandler.GetExcepciones)
+			turnos.POST("/excepciones", stockTimeout, turnoHandler.CrearExcepcion)
+			turnos.POST("/excepciones/:id/revocar", stockTimeout, turnoHandler.RevocarExcepcion)
+		}
+
 		// Stock routes
-		stock := v1.Group("/stock")
+		stock := p.grupo("/stock", "stock", enTurno)
 		{
 			// Operaciones múltiples (las más importantes)
-			stock.POST("/entrada-multiple", stockHandler.EntradaMultipleStock)
-			stock.POST("/salida-multiple", stockHandler.SalidaMultipleStock)
+			stock.POST("/entrada-multiple", stockTimeout, stockHandler.EntradaMultipleStock)
+			stock.POST("/salida-multiple", stockTimeout, stockHandler.SalidaMultipleStock)
+			// Ajuste a la cantidad contada en un inventario físico (movimiento "ajuste" con la diferencia)
+			stock.POST("/ajuste", stockTimeout, stockHandler.AjustarStock)
+			// Precarga de una línea de recepción desde la etiqueta GS1-128 / DataMatrix de la caja
+			stock.POST("/recepcion/gs1", stockTimeout, stockHandler.DecodificarGS1)
 
 			// Consultas
-			stock.GET("/local/:id", stockHandler.GetStockByLocal)
-			stock.GET("/local-completo/:id", stockHandler.GetStockCompleteByLocal)
-			stock.GET("/bajo/:id", stockHandler.GetStockBajo)
-			stock.GET("/bajo-stock/:id", stockHandler.GetStockBajo) // Alias para compatibilidad
-			stock.GET("/producto/:codigo", stockHandler.GetStockByProducto)
-			stock.GET("/movimientos/:id", stockHandler.GetMovimientosByLocal) // Movimientos por local
-			stock.GET("/reporte/:id", stockHandler.GetStockByLocal)           // Alias para reporte
+			stock.GET("/local/:id", reportTimeout, stockHandler.GetStockByLocal)
+			// Descarga CSV/XLSX del stock completo del local (?formato=csv|xlsx)
+			stock.GET("/local/:id/export", reportTimeout, stockHandler.ExportarStockLocal)
+			stock.GET("/local-completo/:id", reportTimeout, stockHandler.GetStockCompleteByLocal)
+			stock.GET("/bajo/:id", reportTimeout, stockHandler.GetStockBajo)
+			stock.GET("/bajo-stock/:id", reportTimeout, stockHandler.GetStockBajo) // Alias para compatibilidad
+			stock.GET("/descontinuado/:id", reportTimeout, stockHandler.GetStockDescontinuado)
+			// Resumen por local: productos, packs, bajo mínimo y valorización (?local=)
+			stock.GET("/resumen", reportTimeout, stockHandler.GetStockSummary)
+			stock.GET("/producto/:codigo", stockTimeout, stockHandler.GetStockByProducto)
+			// Cambios de cantidad mínima del producto (por qué saltan las alertas de stock bajo)
+			stock.GET("/producto/:codigo/minimos", reportTimeout, stockHandler.GetHistorialMinimos)
+			// Mínimos por temporada (rangos de fechas): reemplazan al fijo en stock bajo y en el resumen
+			stock.GET("/minimos-estacionales", stockTimeout, minimoHandler.GetMinimos)
+			stock.POST("/minimos-estacionales", stockTimeout, minimoHandler.CrearMinimo)
+			stock.DELETE("/minimos-estacionales/:id", stockTimeout, minimoHandler.EliminarMinimo)
+			stock.GET("/movimientos/:id", reportTimeout, stockHandler.GetMovimientosByLocal) // Movimientos por local
+			stock.GET("/reporte/:id", reportTimeout, stockHandler.GetStockByLocal)           // Alias para reporte
+
+			// Proyección what-if (demanda histórica + eventos proyectados)
+			stock.POST("/proyeccion", reportTimeout, stockHandler.ProyectarStock)
+
+			// Auditoría del stock contra los movimientos (opcionalmente con ajustes correctivos)
+			stock.POST("/auditoria", reportTimeout, stockHandler.AuditarStock)
+			// Verificación de la cadena de hashes de los movimientos del local (?desde=&hasta=)
+			stock.GET("/movimientos/:id/integridad", reportTimeout, stockHandler.VerificarIntegridadMovimientos)
+
+			// Bodegas dentro del local (sala de venta, trastienda) y traslados internos entre ellas
+			stock.GET("/bodegas/local/:id", reportTimeout, stockHandler.GetBodegas)
+			stock.POST("/bodegas", stockTimeout, stockHandler.CrearBodega)
+			stock.PUT("/bodegas/:id", stockTimeout, stockHandler.ActualizarBodega)
+			stock.GET("/bodegas/stock/:id", reportTimeout, stockHandler.GetStockPorBodega)
+			stock.POST("/traslado-interno", stockTimeout, stockHandler.TrasladoInterno)
+			// Transferencias directas entre locales (salida del origen y entrada al destino en una transacción)
+			stock.POST("/transferencia", stockTimeout, stockHandler.TransferirStock)
+			stock.GET("/transferencias", reportTimeout, stockHandler.GetTransferencias)
+			stock.GET("/transferencia/:id", stockTimeout, stockHandler.GetTransferencia)
+			stock.POST("/transferencia/:id/anular", stockTimeout, stockHandler.AnularTransferencia)
+		}
+
+		// Picking en dos pasos (preparación y confirmación de salidas grandes)
+		picking := p.grupo("/picking", "picking", enTurno)
+		{
+			picking.POST("", stockTimeout, pickingHandler.PrepararPicking)
+			picking.GET("/:id", stockTimeout, pickingHandler.GetPicking)
+			picking.POST("/:id/confirmar", stockTimeout, pickingHandler.ConfirmarPicking)
+			picking.POST("/:id/cancelar", stockTimeout, pickingHandler.CancelarPicking)
+		}
+
+		// Ubicación física de los productos por local (pasillo, rack, nivel): orden del picking
+		ubicaciones := p.grupo("/ubicaciones", "ubicaciones")
+		{
+			ubicaciones.PUT("", stockTimeout, ubicacionHandler.SetUbicacion)
+			ubicaciones.GET("/local/:id", reportTimeout, ubicacionHandler.GetUbicaciones)
+			ubicaciones.GET("/producto/:codigo", stockTimeout, ubicacionHandler.GetUbicacionesProducto)
+			ubicaciones.DELETE("/producto/:codigo", stockTimeout, ubicacionHandler.DeleteUbicacion)
+		}
+
+		// Guías de despacho (transferencias entre locales)
+		guias := p.grupo("/guias", "guias", enTurno)
+		{
+			guias.POST("", stockTimeout, guiaHandler.EmitirGuia)
+			guias.GET("/en-transito", reportTimeout, guiaHandler.GetGuiasEnTransito)
+			guias.GET("/mermas", reportTimeout, guiaHandler.GetReporteMermas)
+			guias.GET("/:id", stockTimeout, guiaHandler.GetGuia)
+			guias.GET("/:id/imprimir", stockTimeout, guiaHandler.ImprimirGuia)
+			guias.POST("/:id/despachar", stockTimeout, guiaHandler.DespacharGuia)
+			guias.POST("/:id/recibir", stockTimeout, guiaHandler.RecibirGuia)
+		}
+
+		// Notas de crédito (anulación de ventas del POS ya cerradas)
+		notasCredito := p.grupo("/notas-credito", "notas-credito", enTurno)
+		{
+			notasCredito.POST("", stockTimeout, notaCreditoHandler.EmitirNotaCredito)
+			notasCredito.GET("/venta/:id_operacion", stockTimeout, notaCreditoHandler.GetNotasCreditoVenta)
+			notasCredito.GET("/:id", stockTimeout, notaCreditoHandler.GetNotaCredito)
+		}
+
+		// Conteos cíclicos semanales (sesiones pequeñas asignadas a un usuario)
+		conteos := p.grupo("/conteos-ciclicos", "conteos-ciclicos", enTurno)
+		{
+			conteos.GET("", reportTimeout, conteoCiclicoHandler.GetConteos)
+			conteos.POST("/generar", reportTimeout, conteoCiclicoHandler.GenerarSemana)
+			conteos.GET("/:id", stockTimeout, conteoCiclicoHandler.GetConteo)
+			conteos.POST("/:id/conteo", stockTimeout, conteoCiclicoHandler.RegistrarConteo)
+			conteos.POST("/:id/archivo-pdt", stockTimeout, conteoCiclicoHandler.ImportarArchivoPDT)
+		}
+
+		// Tomas de inventario (conteo físico del local completo; el cierre ajusta el stock a lo contado)
+		tomas := p.grupo("/tomas-inventario", "tomas-inventario", enTurno)
+		{
+			tomas.GET("", stockTimeout, tomaInventarioHandler.GetTomas)
+			tomas.POST("", stockTimeout, tomaInventarioHandler.AbrirToma)
+			tomas.GET("/:id", stockTimeout, tomaInventarioHandler.GetToma)
+			tomas.POST("/:id/conteo", stockTimeout, tomaInventarioHandler.RegistrarConteo)
+			tomas.POST("/:id/conteo-masivo", stockTimeout, tomaInventarioHandler.RegistrarConteoMasivo)
+			tomas.GET("/:id/diferencias", reportTimeout, tomaInventarioHandler.GetDiferencias)
+			tomas.POST("/:id/cerrar", reportTimeout, tomaInventarioHandler.CerrarToma)
+			tomas.POST("/:id/cancelar", stockTimeout, tomaInventarioHandler.CancelarToma)
+		}
+
+		// Plantillas de recepción recurrente (entrada múltiple guardada)
+		plantillas := p.grupo("/plantillas-entrada", "plantillas-entrada")
+		{
+			plantillas.POST("", stockTimeout, plantillaHandler.CrearPlantilla)
+			plantillas.GET("", stockTimeout, plantillaHandler.GetPlantillas)
+			plantillas.GET("/:id", stockTimeout, plantillaHandler.GetPlantilla)
+			plantillas.PUT("/:id", stockTimeout, plantillaHandler.ActualizarPlantilla)
+			plantillas.DELETE("/:id", stockTimeout, plantillaHandler.EliminarPlantilla)
+			plantillas.POST("/:id/ejecutar", stockTimeout, plantillaHandler.EjecutarPlantilla)
+		}
+
+		// Aprobación de operaciones grandes (supervisor)
+		aprobaciones := p.grupo("/aprobaciones", "aprobaciones")
+		{
+			aprobaciones.GET("", reportTimeout, approvalHandler.GetSolicitudes)
+			aprobaciones.GET("/:id", stockTimeout, approvalHandler.GetSolicitud)
+			aprobaciones.POST("/:id/aprobar", stockTimeout, approvalHandler.Aprobar)
+			aprobaciones.POST("/:id/rechazar", stockTimeout, approvalHandler.Rechazar)
+		}
+
+		// Reglas que bloquean operaciones o las retienen para aprobación
+		reglas := p.grupo("/reglas-operacion", "reglas-operacion")
+		{
+			reglas.GET("", stockTimeout, reglaHandler.GetReglas)
+			reglas.POST("", stockTimeout, reglaHandler.CrearRegla)
+			reglas.GET("/:id", stockTimeout, reglaHandler.GetRegla)
+			reglas.PUT("/:id", stockTimeout, reglaHandler.ActualizarRegla)
+			reglas.DELETE("/:id", stockTimeout, reglaHandler.EliminarRegla)
+		}
+
+		// Productos (maestro)
+		productos := p.grupo("/productos", "productos")
+		{
+			productos.GET("/:codigo/precios/historial", reportTimeout, productoHandler.GetHistorialPrecios)
+
+			// Imágenes (subida multipart o asociación de URL externa)
+			productos.POST("/:codigo/imagen", reportTimeout, productoHandler.SubirImagen)
+			productos.PUT("/:codigo/imagen", stockTimeout, productoHandler.AsociarImagen)
+			productos.DELETE("/:codigo/imagen", stockTimeout, productoHandler.EliminarImagen)
+			productos.GET("/:codigo/imagen", productoHandler.GetImagen)
+			productos.GET("/:codigo/imagen/miniatura", productoHandler.GetMiniatura)
+
+			// Conversiones de unidad (1 caja = 24 un)
+			productos.GET("/:codigo/unidades", stockTimeout, unidadHandler.GetConversiones)
+			productos.PUT("/:codigo/unidades/:unidad", stockTimeout, unidadHandler.SetConversion)
+			productos.DELETE("/:codigo/unidades/:unidad", stockTimeout, unidadHandler.EliminarConversion)
+
+			// Canales de venta habilitados (pos, ecommerce, mayorista)
+			productos.GET("/:codigo/canales", stockTimeout, productoHandler.GetCanales)
+			productos.PUT("/:codigo/canales", stockTimeout, productoHandler.SetCanales)
+			productos.DELETE("/:codigo/canales", stockTimeout, productoHandler.EliminarCanales)
+
+			// Bloqueo de venta en todos los locales (ej. retiro de un lote): QuickSale lo rechaza
+			productos.GET("/bloqueos", stockTimeout, productoHandler.GetBloqueosVigentes)
+			productos.GET("/:codigo/bloqueos", stockTimeout, productoHandler.GetBloqueos)
+			productos.POST("/:codigo/bloqueos", stockTimeout, productoHandler.BloquearProducto)
+			productos.POST("/:codigo/bloqueos/levantar", stockTimeout, productoHandler.LevantarBloqueo)
+		}
+
+		// Maestro de unidades de medida
+		unidades := p.grupo("/unidades", "unidades")
+		{
+			unidades.GET("", unidadHandler.GetUnidades)
+			unidades.POST("", unidadHandler.CrearUnidad)
+		}
+
+		// Catálogo de packs con componentes y precios calculados
+		packs := p.grupo("/packs", "packs")
+		{
+			packs.GET("", reportTimeout, packHandler.ListPacks)
+			packs.GET("/por-articulo/:codigo", stockTimeout, packHandler.GetPacksPorArticulo)
+		}
+
+		// Tienda online: stock publicable (API key) y márgenes de seguridad (dashboard)
+		ecommerce := p.grupo("/ecommerce", "ecommerce")
+		{
+			ecommerce.GET("/stock", apiKeyAuth, reportTimeout, ecommerceHandler.GetStockPublicable)
+			ecommerce.GET("/margenes", ecommerceHandler.GetMargenes)
+			ecommerce.PUT("/margenes/producto/:codigo", ecommerceHandler.SetMargenProducto)
+			ecommerce.DELETE("/margenes/producto/:codigo", ecommerceHandler.EliminarMargenProducto)
+			ecommerce.PUT("/margenes/categoria/:id", ecommerceHandler.SetMargenCategoria)
+			ecommerce.DELETE("/margenes/categoria/:id", ecommerceHandler.EliminarMargenCategoria)
+		}
+
+		// Búsqueda global (barra de búsqueda del dashboard)
+		v1.GET("/buscar", authn.Grupo("buscar"), posTimeout, busquedaHandler.Buscar)
+
+		// Sincronización de réplicas edge: catálogo y stock del local modificados desde un cursor
+		v1.GET("/sync/delta", authn.Grupo("sync"), reportTimeout, syncHandler.GetDelta)
+
+		// Reportes de gestión
+		reportes := p.grupo("/reportes", "reportes", reportTimeout)
+		{
+			reportes.GET("/margenes", reporteHandler.GetReporteMargenes)
+			reportes.GET("/actividad-usuarios", reporteHandler.GetReporteActividad)
+			// Cierre diario consolidado por local (ventas, devoluciones, mermas, entradas, caja, quiebres)
+			reportes.GET("/cierre-dia", reporteHandler.GetCierreDia)
+			// Agregados diarios de movimientos que usan los reportes
+			reportes.GET("/agregados", reporteHandler.GetEstadoAgregados)
+			reportes.POST("/agregados/recalcular", reporteHandler.RecalcularAgregados)
+			// Lotes próximos a vencer por local (job diario; generar fuerza una revisión)
+			reportes.GET("/vencimientos", vencimientoHandler.GetReporteVencimientos)
+			reportes.POST("/vencimientos/generar", vencimientoHandler.GenerarAvisosVencimiento)
+			reportes.GET("/vencimientos/bajas", vencimientoHandler.GetReporteBajasVencidos)
+			reportes.POST("/vencimientos/bajas/ejecutar", vencimientoHandler.DarDeBajaVencidos)
+			reportes.GET("/notas-credito", notaCreditoHandler.GetReporte)
+			reportes.GET("/conteos-ciclicos", conteoCiclicoHandler.GetReporteCumplimiento)
+
+			// Movimientos en el layout de carga del ERP de finanzas, según perfiles de mapeo
+			reportes.GET("/erp", exportacionERPHandler.Exportar)
+			reportes.GET("/erp/perfiles", exportacionERPHandler.GetPerfiles)
+			reportes.POST("/erp/perfiles", exportacionERPHandler.CrearPerfil)
+			reportes.PUT("/erp/perfiles/:id", exportacionERPHandler.ActualizarPerfil)
+			reportes.DELETE("/erp/perfiles/:id", exportacionERPHandler.EliminarPerfil)
+		}
+
+		// Jobs asíncronos: exportaciones e importaciones en background; el resultado (con los enlaces
+		// de descarga) se consulta en /jobs/:id o se avisa al callback_url del solicitante
+		jobs := p.grupo("/jobs", "jobs")
+		{
+			jobs.POST("/exportaciones/stock/:id", stockTimeout, jobHandler.EncolarExportacionStock)
+			jobs.POST("/exportaciones/movimientos", stockTimeout, jobHandler.EncolarExportacionMovimientos)
+			jobs.POST("/importaciones/conteos-ciclicos/:id/archivo-pdt", stockTimeout, jobHandler.EncolarImportacionPDT)
+			jobs.GET("/:id", stockTimeout, jobHandler.GetJob)
+			jobs.GET("/:id/archivos/:nombre", reportTimeout, jobHandler.DescargarArchivo)
 		}
 
 		// Movimientos routes (mantener para compatibilidad)
-		movimientos := v1.Group("/movimientos")
+		movimientos := p.grupo("/movimientos", "movimientos")
 		{
-			movimientos.GET("", stockHandler.GetMovimientos)
+			movimientos.GET("", reportTimeout, stockHandler.GetMovimientos)
+			// Descarga CSV/XLSX con los mismos filtros, sin paginar (?formato=csv|xlsx)
+			movimientos.GET("/export", reportTimeout, stockHandler.ExportarMovimientos)
 		}
 
 		// POS routes (ultra-rápido)
-		pos := v1.Group("/pos")
+		pos := p.grupo("/pos", "pos")
 		{
-			pos.GET("/producto/:codigo", posHandler.SearchProductByBarcode)
-			pos.POST("/venta-rapida", posHandler.QuickSale)
+			pos.GET("/producto/:codigo", posTimeout, posHandler.SearchProductByBarcode)
+			// Existencia rápida para pistolas de inventario (filtro + cache, sin el producto)
+			pos.HEAD("/producto/:codigo/existe", posTimeout, posHandler.ExisteProducto)
+			pos.GET("/producto/:codigo/existe", posTimeout, posHandler.ExisteProducto)
+			pos.POST("/venta-rapida", enTurno, stockTimeout, posHandler.QuickSale)
+
+			// Grilla de botones rápidos por local (productos sin código de barras)
+			pos.GET("/botones-rapidos/:local", posTimeout, botonHandler.GetGrilla)
+			pos.PUT("/botones-rapidos/:local", stockTimeout, botonHandler.ReemplazarGrilla)
+			pos.DELETE("/botones-rapidos/:local/:posicion", stockTimeout, botonHandler.EliminarBoton)
 			pos.POST("/preload", posHandler.PreloadFrequentProducts)
 			pos.GET("/cache-stats", posHandler.GetCacheStats)
-			
+			// Versión global de lista_precios/productos vigente, último check a BD y última invalidación total
+			pos.GET("/cache/version-status", posHandler.GetCacheVersionStatus)
+
+			// Ventas sospechosas de duplicado (revisión del supervisor)
+			pos.GET("/ventas-sospechosas", reportTimeout, posHandler.GetVentasSospechosas)
+			pos.GET("/overrides-precio", reportTimeout, posHandler.GetReporteOverridesPrecio)
+			// Ventas rápidas registradas (detalle por id y listado por local/fecha)
+			pos.GET("/ventas", reportTimeout, posHandler.GetVentas)
+			pos.GET("/ventas/:id", stockTimeout, posHandler.GetVenta)
+			// Reconstrucción del precio cobrado en cada línea de una venta (reclamos; :id es el id de operación)
+			pos.GET("/ventas/:id/precios", stockTimeout, posHandler.GetPreciosVenta)
+			pos.POST("/ventas-sospechosas/:id/revisar", posHandler.MarcarVentaSospechosaRevisada)
+			// Códigos escaneados que no existen en el catálogo (pendientes de catalogar)
+			pos.GET("/escaneos-no-encontrados", reportTimeout, posHandler.GetEscaneosNoEncontrados)
+
+			// Ventas encoladas en modo degradado (PostgreSQL caído)
+			pos.GET("/ventas-encoladas", posHandler.GetVentasEncoladas)
+			pos.POST("/ventas-encoladas/reconciliar", posHandler.ReconciliarVentasEncoladas)
+
 			// Endpoints para invalidar cache
 			pos.DELETE("/cache/producto/:codigo", posHandler.InvalidateProductCache)
 			pos.DELETE("/cache/codigo-tivendo/:codigo", posHandler.InvalidateByCodigoTivendo)
 			pos.DELETE("/cache/all", posHandler.InvalidateAllCache)
 			pos.POST("/cache/invalidate", posHandler.InvalidateProductsCache)
-			
+
+			// Diagnóstico: precios en cache contra lista_precios_cantera (invalidaciones perdidas)
+			pos.POST("/cache/conciliacion-precios", reportTimeout, posHandler.ConciliarPreciosCache)
+			pos.GET("/cache/conciliaciones-precios", posHandler.GetConciliacionesPrecios)
+
 			// Endpoints para notificar actualización masiva
 			// Llamar desde el otro servidor después de actualizar masivamente
 			pos.POST("/cache/notify-lista-precios-update", posHandler.NotifyListaPreciosUpdate)
 			pos.POST("/cache/notify-productos-update", posHandler.NotifyProductosUpdate)
+			// Estado de una notificación encolada (se procesan en orden por un único worker)
+			pos.GET("/cache/notificaciones/:id", posHandler.GetNotificacion)
 		}
 
+		// Administración en caliente
+		adminAPI := p.grupo("/admin", "admin")
+		{
+			adminAPI.POST("/config/reload", adminHandler.ReloadConfig)
+			adminAPI.GET("/features", adminHandler.GetFeatures)
+
+			// Modo mantenimiento (compartido entre réplicas vía Redis)
+			adminAPI.GET("/mantenimiento", adminHandler.GetMantenimiento)
+			adminAPI.POST("/mantenimiento", adminHandler.ActivarMantenimiento)
+			adminAPI.DELETE("/mantenimiento", adminHandler.DesactivarMantenimiento)
+
+			// Consumo de cuotas por API key
+			adminAPI.GET("/api-keys/uso", adminHandler.GetUsoAPIKeys)
+
+			// Outbox de eventos para webhooks (pendientes, descartados y reintento)
+			adminAPI.GET("/outbox", adminHandler.GetOutbox)
+			adminAPI.POST("/outbox/reintentar", adminHandler.ReintentarOutbox)
+
+			// Pool de conexiones a PostgreSQL (estado y ajuste en caliente de esta réplica)
+			adminAPI.GET("/db-pool", adminHandler.GetDBPool)
+			adminAPI.PUT("/db-pool", adminHandler.AjustarDBPool)
+
+			// Folios correlativos de documentos por local
+			adminAPI.GET("/folios", adminHandler.GetFolios)
+			adminAPI.PUT("/folios", adminHandler.AvanzarFolio)
+			// Claves de firma de las respuestas para integradores
+			adminAPI.GET("/firmas", adminHandler.GetClavesFirma)
+			adminAPI.POST("/firmas/rotar", adminHandler.RotarClaveFirma)
+			adminAPI.DELETE("/firmas/:kid", adminHandler.RevocarClaveFirma)
+		}
+
+		// Claves con que los integradores verifican las respuestas firmadas
+		v1.GET("/firmas/claves", adminHandler.GetClavesPublicas)
+
 		// Monitoring routes
-		monitoring := v1.Group("/monitoring")
+		monitoring := p.grupo("/monitoring", "monitoring")
 		{
 			monitoring.GET("/metrics", monitoringHandler.GetMetrics)
 			monitoring.GET("/metrics/summary", monitoringHandler.GetMetricsSummary)
 			monitoring.GET("/ws", monitoringHandler.WebSocketMetrics)
+			// Latencias del camino de escaneo (L1, L2, BD y total) contra el SLA del POS
+			monitoring.GET("/barcode", monitoringHandler.GetBarcodeLatency)
+			monitoring.POST("/barcode/reset", monitoringHandler.ResetBarcodeLatency)
+			// Productos críticos por local: disponibilidad contra su SLA y quiebres vigentes
+			monitoring.GET("/criticos", reportTimeout, criticoHandler.GetCriticos)
+			monitoring.POST("/criticos", stockTimeout, criticoHandler.MarcarCritico)
+			monitoring.DELETE("/criticos/:codigo", stockTimeout, criticoHandler.DesmarcarCritico)
+			monitoring.GET("/criticos/disponibilidad", reportTimeout, criticoHandler.GetDisponibilidad)
+			monitoring.GET("/criticos/quiebres", reportTimeout, criticoHandler.GetQuiebresActivos)
 		}
 	}
+	p.verificar()
 
 	// Health check (mantener en raíz para compatibilidad)
 	router.GET("/health", healthChecker.HealthCheck)
 	router.GET("/health/monitoring", monitoringHandler.HealthCheck)
 
+	// Métricas para Prometheus (fuera de /api/v1: lo consulta el scraper, no los clientes)
+	router.GET("/metrics", monitoringHandler.PrometheusMetrics)
+
+	// Dashboard administrativo embebido (SPA estática)
+	router.StaticFS("/admin", admin.FileSystem())
+
 	// API info en raíz
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -85,7 +431,34 @@ func SetupRoutes(router *gin.Engine, stockHandler *handlers.StockHandler, posHan
 					"stock_producto":   "GET /api/v1/stock/producto/:codigo",
 				},
 				"movimientos": "GET /api/v1/movimientos",
+				"admin":       "GET /admin/",
 			},
 		})
 	})
 }
+
+// SetupEdgeRoutes configura las rutas del modo edge: las consultas frecuentes del POS se
+// responden desde la réplica local y todo lo demás se reenvía al central
+func SetupEdgeRoutes(router *gin.Engine, edgeHandler *handlers.EdgeHandler) {
+	v1 := router.Group("/api/v1")
+	{
+		pos := v1.Group("/pos")
+		{
+			pos.GET("/producto/:codigo", edgeHandler.SearchProductByBarcode)
+			pos.HEAD("/producto/:codigo/existe", edgeHandler.ExisteProducto)
+			pos.GET("/producto/:codigo/existe", edgeHandler.ExisteProducto)
+			pos.POST("/venta-rapida", edgeHandler.QuickSale)
+		}
+
+		v1.GET("/stock/producto/:codigo", edgeHandler.GetStockByProducto)
+
+		edgeAPI := v1.Group("/edge")
+		{
+			edgeAPI.GET("/estado", edgeHandler.GetEstado)
+			edgeAPI.GET("/pendientes", edgeHandler.GetPendientes)
+		}
+	}
+
+	router.GET("/health", edgeHandler.Health)
+	router.NoRoute(edgeHandler.Proxy)
+}
@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"stock-service/internal/auth"
+	"stock-service/internal/config"
+	"stock-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TestRutasQueModificanExigenToken arma el router real (handlers nil: ningún request llega a
+// ellos) y comprueba que toda ruta de /api/v1 que modifica datos, salvo el login, y las consultas de ventas
+// responden 401 sin token
+func TestRutasQueModificanExigenToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwt := config.JWTConfig{Secret: "secreto-de-prueba", ExpiryHours: 1}
+	authn := middleware.NewAuthenticator(auth.NewTokens(jwt), jwt, zap.NewNop())
+	sigue := func(c *gin.Context) { c.Next() }
+
+	router := gin.New()
+	SetupRoutes(router, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, authn, sigue, sigue, middleware.NewHeavyLimiter(1, time.Second, zap.NewNop()),
+		config.TimeoutsConfig{POSSearch: time.Second, StockOperation: time.Second, Report: time.Second})
+
+	revisadas := 0
+	for _, ruta := range router.Routes() {
+		if ruta.Method == http.MethodGet || ruta.Method == http.MethodHead ||
+			!strings.HasPrefix(ruta.Path, "/api/v1/") || strings.HasPrefix(ruta.Path, "/api/v1/auth/") {
+			continue
+		}
+		revisadas++
+
+		req := httptest.NewRequest(ruta.Method, ruta.Path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s sin token = %d, want %d", ruta.Method, ruta.Path, w.Code, http.StatusUnauthorized)
+		}
+	}
+	if revisadas == 0 {
+		t.Fatal("no se registraron rutas que modifiquen datos")
+	}
+
+	// Consultas con datos de ventas o del cache
+	for _, ruta := range []string{"/api/v1/pos/ventas", "/api/v1/pos/ventas/1", "/api/v1/pos/ventas-encoladas", "/api/v1/pos/cache-stats"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ruta, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("GET %s sin token = %d, want %d", ruta, w.Code, http.StatusUnauthorized)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+// Package seed genera datos de demostración para capacitación y pruebas del frontend
+// Todo lo generado queda marcado (códigos DEMO-*, locales "[DEMO] ...") para poder borrarlo
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"stock-service/internal/models"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PrefijoCodigo prefijo de los códigos de productos y packs de demostración
+	PrefijoCodigo = "DEMO-"
+	// PrefijoLocal prefijo del nombre de los locales de demostración
+	PrefijoLocal = "[DEMO] "
+
+	// semilla fija: dos ejecuciones generan exactamente los mismos datos
+	semilla = 20240601
+	// idUsuarioDemo usuario al que se atribuyen los movimientos (el mismo ID por defecto de la API)
+	idUsuarioDemo = 1
+)
+
+// Options parámetros de la generación
+type Options struct {
+	// Días de historial de movimientos hacia atrás desde hoy
+	Dias int
+	// Si es true borra los datos demo existentes antes de generar
+	Reset bool
+}
+
+// Resumen cantidades generadas o borradas
+type Resumen struct {
+	Locales     int `json:"locales"`
+	Productos   int `json:"productos"`
+	Packs       int `json:"packs"`
+	Stock       int `json:"stock"`
+	Movimientos int `json:"movimientos"`
+	Ventas      int `json:"ventas"`
+	// Omitido indica que ya existían datos demo y no se generó nada
+	Omitido bool `json:"omitido,omitempty"`
+}
+
+// productoDemo entrada del catálogo de demostración
+type productoDemo struct {
+	nombre string
+	unidad string
+	precio float64
+	// ventas diarias promedio por local
+	rotacion float64
+}
+
+var catalogoDemo = []productoDemo{
+	{"Bebida Cola 1.5L", "UN", 1890, 6},
+	{"Bebida Naranja 1.5L", "UN", 1790, 4},
+	{"Agua Mineral 600ml", "UN", 790, 8},
+	{"Jugo Durazno 1L", "UN", 1290, 3},
+	{"Cerveza Lager 350ml", "UN", 990, 7},
+	{"Energética 250ml", "UN", 1490, 2},
+	{"Papas Fritas 150g", "UN", 1690, 5},
+	{"Maní Salado 200g", "UN", 1390, 2},
+	{"Galletas Chocolate 120g", "UN", 890, 4},
+	{"Chocolate Leche 100g", "UN", 1190, 3},
+	{"Pan Molde Blanco", "UN", 2290, 3},
+	{"Leche Entera 1L", "UN", 1090, 6},
+	{"Yogurt Frutilla 125g", "UN", 390, 5},
+	{"Mantequilla 250g", "UN", 2590, 1},
+	{"Queso Gauda 250g", "UN", 3290, 1},
+	{"Arroz Grado 1 1kg", "UN", 1490, 2},
+	{"Fideos Spaghetti 400g", "UN", 890, 3},
+	{"Aceite Maravilla 1L", "UN", 2990, 1},
+	{"Azúcar 1kg", "UN", 1190, 2},
+	{"Café Instantáneo 170g", "UN", 4490, 1},
+	{"Té 100 bolsitas", "UN", 2190, 1},
+	{"Detergente Líquido 3L", "UN", 6990, 0.5},
+	{"Papel Higiénico 4 rollos", "UN", 2490, 2},
+	{"Lavalozas 750ml", "UN", 1590, 1},
+	{"Shampoo 400ml", "UN", 3490, 0.5},
+	{"Pasta Dental 90g", "UN", 1890, 1},
+	{"Pilas AA x4", "UN", 3990, 0.3},
+	{"Hielo 2kg", "UN", 1490, 2},
+	{"Cigarrillos Cajetilla 20", "UN", 5200, 4},
+	{"Helado Vainilla 1L", "UN", 3990, 1},
+}
+
+var localesDemo = []string{"Local Centro", "Local Norte", "Bodega Sur"}
+
+// Seeder genera y borra los datos de demostración
+type Seeder struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+// NewSeeder crea una nueva instancia del generador
+func NewSeeder(db *sql.DB, logger *zap.Logger) *Seeder {
+	return &Seeder{db: db, logger: logger}
+}
+
+// Seed genera locales, productos, precios, packs, stock y el historial de movimientos y ventas
+// Es idempotente: si ya hay datos demo no hace nada (salvo con Reset, que los regenera)
+func (s *Seeder) Seed(ctx context.Context, opts Options) (*Resumen, error) {
+	if opts.Dias <= 0 {
+		opts.Dias = 90
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var existe bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM productos WHERE codigo LIKE $1)`, PrefijoCodigo+"%").Scan(&existe); err != nil {
+		return nil, fmt.Errorf("failed to check demo data: %w", err)
+	}
+	if existe {
+		if !opts.Reset {
+			return &Resumen{Omitido: true}, nil
+		}
+		if _, err := clean(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	g := &generador{tx: tx, rnd: rand.New(rand.NewSource(semilla)), resumen: &Resumen{}}
+	if err := g.generar(ctx, opts.Dias); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit demo data: %w", err)
+	}
+
+	s.logger.Info("Demo data generated",
+		zap.Int("locales", g.resumen.Locales),
+		zap.Int("productos", g.resumen.Productos),
+		zap.Int("movimientos", g.resumen.Movimientos))
+
+	return g.resumen, nil
+}
+
+// Clean borra todos los datos de demostración
+func (s *Seeder) Clean(ctx context.Context) (*Resumen, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	resumen, err := clean(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit demo cleanup: %w", err)
+	}
+
+	s.logger.Info("Demo data removed",
+		zap.Int("locales", resumen.Locales),
+		zap.Int("productos", resumen.Productos),
+		zap.Int("movimientos", resumen.Movimientos))
+
+	return resumen, nil
+}
+
+// clean borra los datos demo respetando el orden de las dependencias
+func clean(ctx context.Context, tx *sql.Tx) (*Resumen, error) {
+	codigo := PrefijoCodigo + "%"
+	local := PrefijoLocal + "%"
+	resumen := &Resumen{}
+
+	deletes := []struct {
+		query  string
+		arg    string
+		contar *int
+	}{
+		{`DELETE FROM picking_items_cantera WHERE codigo_producto LIKE $1`, codigo, nil},
+		{`DELETE FROM pickings_cantera WHERE id_local IN (SELECT id FROM locales WHERE nombre_local LIKE $1)`, local, nil},
+		{`DELETE FROM stock_movimientos_cantera WHERE codigo_producto LIKE $1`, codigo, &resumen.Movimientos},
+		{`DELETE FROM stock_bodega_cantera WHERE codigo_producto LIKE $1`, codigo, &resumen.Stock},
+		{`DELETE FROM imagenes_productos_cantera WHERE codigo_producto LIKE $1`, codigo, nil},
+		{`DELETE FROM lista_precios_cantera WHERE codigo_tivendo LIKE $1`, codigo, nil},
+		{`DELETE FROM historial_precios_cantera WHERE codigo_tivendo LIKE $1`, codigo, nil},
+		{`DELETE FROM pack_listados WHERE codigo_pack LIKE $1`, codigo, &resumen.Packs},
+		{`DELETE FROM productos WHERE codigo LIKE $1`, codigo, &resumen.Productos},
+		{`DELETE FROM locales WHERE nombre_local LIKE $1`, local, &resumen.Locales},
+	}
+
+	// Los movimientos de los productos demo pueden estar sellados en la cadena de su local
+	if _, err := tx.ExecContext(ctx, `SET LOCAL cantera.permitir_borrar_movimientos = 'on'`); err != nil {
+		return nil, fmt.Errorf("failed to allow demo movimientos cleanup: %w", err)
+	}
+
+	for _, d := range deletes {
+		result, err := tx.ExecContext(ctx, d.query, d.arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete demo data (%s): %w", d.query, err)
+		}
+		if d.contar != nil {
+			n, _ := result.RowsAffected()
+			*d.contar = int(n)
+		}
+	}
+
+	return resumen, nil
+}
+
+// generador estado de una generación (todas las inserciones van en la misma transacción)
+type generador struct {
+	tx      *sql.Tx
+	rnd     *rand.Rand
+	resumen *Resumen
+
+	locales   []int
+	productos []productoGenerado
+}
+
+type productoGenerado struct {
+	productoDemo
+	codigo  string
+	barcode string
+}
+
+func (g *generador) generar(ctx context.Context, dias int) error {
+	if err := g.crearLocales(ctx); err != nil {
+		return err
+	}
+	if err := g.crearProductos(ctx); err != nil {
+		return err
+	}
+	if err := g.crearPacks(ctx); err != nil {
+		return err
+	}
+	return g.simularHistorial(ctx, dias)
+}
+
+func (g *generador) crearLocales(ctx context.Context) error {
+	for _, nombre := range localesDemo {
+		var id int
+		err := g.tx.QueryRowContext(ctx,
+			`INSERT INTO locales (nombre_local, activo) VALUES ($1, true) RETURNING id`,
+			PrefijoLocal+nombre,
+		).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to create demo local: %w", err)
+		}
+		g.locales = append(g.locales, id)
+	}
+	g.resumen.Locales = len(g.locales)
+	return nil
+}
+
+func (g *generador) crearProductos(ctx context.Context) error {
+	for i, p := range catalogoDemo {
+		producto := productoGenerado{
+			productoDemo: p,
+			codigo:       fmt.Sprintf("%s%04d", PrefijoCodigo, i+1),
+			barcode:      ean13(fmt.Sprintf("780999%06d", i+1)),
+		}
+
+		_, err := g.tx.ExecContext(ctx, `
+			INSERT INTO productos
+				(codigo, nombre, unidad, precio, codigo_barra_interno, codigo_barra_externo,
+				 descripcion, es_servicio, es_exento, disponible_para_venta, activo)
+			VALUES ($1, $2, $3, $4, $1, $5, 'Producto de demostración', false, false, true, true)
+		`, producto.codigo, producto.nombre, producto.unidad, producto.precio, producto.barcode)
+		if err != nil {
+			return fmt.Errorf("failed to create demo producto %s: %w", producto.codigo, err)
+		}
+
+		_, err = g.tx.ExecContext(ctx, `
+			INSERT INTO lista_precios_cantera (codigo_tivendo, precio_detalle, precio_mayorista, updated_at)
+			VALUES ($1, $2, $3, NOW())
+		`, producto.codigo, producto.precio, redondear(producto.precio*0.85))
+		if err != nil {
+			return fmt.Errorf("failed to create demo precio %s: %w", producto.codigo, err)
+		}
+
+		g.productos = append(g.productos, producto)
+	}
+	g.resumen.Productos = len(g.productos)
+	return nil
+}
+
+// crearPacks arma packs de 6 y 12 unidades con los productos de más rotación
+func (g *generador) crearPacks(ctx context.Context) error {
+	for i, p := range g.productos {
+		if p.rotacion < 4 {
+			continue
+		}
+		for _, cantidad := range []int{6, 12} {
+			codigo := fmt.Sprintf("%sP%02d%02d", PrefijoCodigo, i+1, cantidad)
+			_, err := g.tx.ExecContext(ctx, `
+				INSERT INTO pack_listados
+					(codigo_pack, cod_barra_pack, nombre_pack, precio_base,
+					 cantidad_articulo, codigo_articulo, cod_barra_articulo, nombre_articulo)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			`, codigo, ean13(fmt.Sprintf("780998%04d%02d", i+1, cantidad)),
+				fmt.Sprintf("Pack %d x %s", cantidad, p.nombre),
+				redondear(p.precio*float64(cantidad)*0.9),
+				cantidad, p.codigo, p.barcode, p.nombre)
+			if err != nil {
+				return fmt.Errorf("failed to create demo pack %s: %w", codigo, err)
+			}
+			g.resumen.Packs++
+		}
+	}
+	return nil
+}
+
+// simularHistorial recorre los días vendiendo y reponiendo, y deja como stock actual el resultado
+// Los fines de semana se vende más; cuando el stock cae bajo el mínimo se repone al día siguiente
+func (g *generador) simularHistorial(ctx context.Context, dias int) error {
+	insertMovimiento, err := g.tx.PrepareContext(ctx, `
+		INSERT INTO stock_movimientos_cantera
+			(codigo_producto, tipo_item, tipo_movimiento, cantidad, cantidad_anterior,
+			 cantidad_nueva, motivo, id_usuario, id_local, observaciones, created_at)
+		VALUES ($1, 'producto', $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare demo movimiento: %w", err)
+	}
+	defer insertMovimiento.Close()
+
+	hoy := time.Now().Truncate(24 * time.Hour)
+	inicio := hoy.AddDate(0, 0, -dias)
+
+	for _, idLocal := range g.locales {
+		for _, p := range g.productos {
+			minimo := 5 + int(p.rotacion*3)
+			stock := minimo*4 + g.rnd.Intn(minimo*2)
+
+			registrar := func(tipo string, cantidad int, motivo, observaciones string, fecha time.Time) error {
+				anterior := stock
+				if tipo == "entrada" {
+					stock += cantidad
+				} else {
+					stock -= cantidad
+				}
+				_, err := insertMovimiento.ExecContext(ctx, p.codigo, tipo, cantidad, anterior, stock,
+					motivo, idUsuarioDemo, idLocal, observaciones, fecha)
+				if err != nil {
+					return fmt.Errorf("failed to create demo movimiento: %w", err)
+				}
+				g.resumen.Movimientos++
+				return nil
+			}
+
+			if err := registrar("entrada", stock, "Inventario inicial", "[DEMO] Carga inicial", inicio); err != nil {
+				return err
+			}
+
+			for d := 0; d < dias; d++ {
+				dia := inicio.AddDate(0, 0, d+1)
+
+				// Reposición a primera hora si quedó bajo el mínimo
+				if stock <= minimo {
+					lote := minimo*3 + g.rnd.Intn(minimo+1)
+					if err := registrar("entrada", lote, "Reposición", "[DEMO] Reposición de proveedor", dia.Add(8*time.Hour)); err != nil {
+						return err
+					}
+				}
+
+				demanda := p.rotacion
+				if dia.Weekday() == time.Friday || dia.Weekday() == time.Saturday {
+					demanda *= 1.6
+				}
+
+				// Ventas del día repartidas entre las 10 y las 21 hrs
+				ventas := poisson(g.rnd, demanda)
+				for v := 0; v < ventas && stock > 0; v++ {
+					cantidad := 1
+					if g.rnd.Float64() < 0.2 {
+						cantidad = 2 + g.rnd.Intn(3)
+					}
+					if cantidad > stock {
+						cantidad = stock
+					}
+					hora := dia.Add(10*time.Hour + time.Duration(g.rnd.Intn(11*60))*time.Minute)
+					if err := registrar("salida", cantidad, "Venta rápida POS", models.ObservacionVentaPOS+" Venta demo", hora); err != nil {
+						return err
+					}
+					g.resumen.Ventas++
+				}
+			}
+
+			_, err := g.tx.ExecContext(ctx, `
+				INSERT INTO stock_bodega_cantera (codigo_producto, tipo_item, cantidad_actual, cantidad_minima, id_local)
+				VALUES ($1, 'producto', $2, $3, $4)
+			`, p.codigo, stock, minimo, idLocal)
+			if err != nil {
+				return fmt.Errorf("failed to create demo stock: %w", err)
+			}
+			g.resumen.Stock++
+		}
+	}
+
+	return nil
+}
+
+// poisson genera una cantidad de eventos con media lambda (algoritmo de Knuth)
+func poisson(rnd *rand.Rand, lambda float64) int {
+	if lambda <= 0 {
+		return 0
+	}
+	l, k, p := math.Exp(-lambda), 0, 1.0
+	for {
+		p *= rnd.Float64()
+		if p < l {
+			return k
+		}
+		k++
+	}
+}
+
+// ean13 completa un código de 12 dígitos con su dígito verificador EAN-13
+func ean13(code string) string {
+	sum := 0
+	for i, c := range code {
+		digit := int(c - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return code + fmt.Sprintf("%d", (10-sum%10)%10)
+}
+
+// redondear redondea a la decena (precios en pesos)
+func redondear(v float64) float64 {
+	return float64(int(v/10+0.5) * 10)
+}
@@ -0,0 +1,126 @@
+// Package server levanta el servidor HTTP/HTTPS del servicio
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+
+	"stock-service/internal/config"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Server servidor principal más el listener HTTP opcional de redirección a HTTPS
+type Server struct {
+	cfg      config.ServerConfig
+	main     *http.Server
+	redirect *http.Server
+	logger   *zap.Logger
+}
+
+// New crea el servidor según la configuración
+// Con TLS se sirve HTTP/2 automáticamente (net/http lo negocia vía ALPN)
+func New(cfg config.ServerConfig, handler http.Handler, logger *zap.Logger) *Server {
+	s := &Server{
+		cfg: cfg,
+		main: &http.Server{
+			Addr:         ":" + cfg.Port,
+			Handler:      handler,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+		},
+		logger: logger,
+	}
+
+	var redirectHandler http.Handler = http.HandlerFunc(s.redirectToHTTPS)
+
+	if len(cfg.TLS.AutocertDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLS.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.TLS.AutocertCacheDir),
+			Email:      cfg.TLS.AutocertEmail,
+		}
+		// TLS-ALPN-01 funciona sobre el propio puerto HTTPS; con redirección
+		// también se responden los desafíos HTTP-01
+		s.main.TLSConfig = manager.TLSConfig()
+		redirectHandler = manager.HTTPHandler(redirectHandler)
+	} else if cfg.TLS.Enabled() {
+		s.main.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if cfg.TLS.Enabled() && cfg.TLS.RedirectHTTP {
+		s.redirect = &http.Server{
+			Addr:         ":" + cfg.TLS.HTTPPort,
+			Handler:      redirectHandler,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+		}
+	}
+
+	return s
+}
+
+// TLSEnabled indica si el servidor sirve HTTPS
+func (s *Server) TLSEnabled() bool {
+	return s.cfg.TLS.Enabled()
+}
+
+// ListenAndServe bloquea sirviendo peticiones hasta Shutdown
+// Retorna nil cuando el servidor se cerró normalmente
+func (s *Server) ListenAndServe() error {
+	if s.redirect != nil {
+		go func() {
+			s.logger.Info("Starting HTTP→HTTPS redirect listener", zap.String("port", s.cfg.TLS.HTTPPort))
+			if err := s.redirect.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.logger.Error("HTTP redirect listener failed", zap.Error(err))
+			}
+		}()
+	}
+
+	var err error
+	switch {
+	case len(s.cfg.TLS.AutocertDomains) > 0:
+		// Los certificados los provee autocert vía TLSConfig.GetCertificate
+		err = s.main.ListenAndServeTLS("", "")
+	case s.cfg.TLS.Enabled():
+		err = s.main.ListenAndServeTLS(s.cfg.TLS.CertFile, s.cfg.TLS.KeyFile)
+	default:
+		err = s.main.ListenAndServe()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown cierra ambos listeners esperando las peticiones en curso
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.redirect != nil {
+		if err := s.redirect.Shutdown(ctx); err != nil {
+			s.logger.Warn("Error closing HTTP redirect listener", zap.Error(err))
+		}
+	}
+	return s.main.Shutdown(ctx)
+}
+
+// redirectToHTTPS redirige la petición al mismo host y ruta sobre HTTPS
+func (s *Server) redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if s.cfg.Port != "443" {
+		host = net.JoinHostPort(host, s.cfg.Port)
+	}
+
+	target := "https://" + host + r.URL.RequestURI()
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+}
@@ -0,0 +1,412 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// ApprovalService retiene las operaciones grandes hasta que un supervisor las apruebe o rechace
+// Antes de los umbrales evalúa las reglas de operación: las que bloquean la rechazan
+// (ErrOperacionBloqueada) y las que piden aprobación la retienen aunque no supere ningún umbral
+type ApprovalService interface {
+	// Evaluar* retornan una solicitud pendiente si la operación requiere aprobación, o nil si puede aplicarse
+	EvaluarEntradaMultiple(ctx context.Context, req *models.EntradaMultipleStockRequest) (*models.SolicitudAprobacion, error)
+	EvaluarSalidaMultiple(ctx context.Context, req *models.SalidaMultipleStockRequest) (*models.SolicitudAprobacion, error)
+	EvaluarTransferencia(ctx context.Context, req *models.TransferenciaStockRequest) (*models.SolicitudAprobacion, error)
+	EvaluarAjuste(ctx context.Context, req *models.AjusteStockRequest) (*models.SolicitudAprobacion, error)
+
+	GetSolicitud(ctx context.Context, id int) (*models.SolicitudAprobacion, error)
+	GetSolicitudes(ctx context.Context, filter *models.SolicitudAprobacionFilter) ([]*models.SolicitudAprobacion, error)
+	Aprobar(ctx context.Context, id int, idSupervisor int) (*models.SolicitudAprobacion, error)
+	Rechazar(ctx context.Context, id int, idSupervisor int, motivo string) (*models.SolicitudAprobacion, error)
+}
+
+// approvalService implementa ApprovalService
+type approvalService struct {
+	repo         repository.AprobacionRepository
+	stockRepo    repository.StockRepository
+	stockService StockService
+	reglaService ReglaOperacionService
+	redisClient  *redis.Client
+	config       config.ApprovalConfig
+	logger       *zap.Logger
+}
+
+// NewApprovalService crea una nueva instancia del servicio
+func NewApprovalService(repo repository.AprobacionRepository, stockRepo repository.StockRepository, stockService StockService, reglaService ReglaOperacionService, redisClient *redis.Client, cfg config.ApprovalConfig, logger *zap.Logger) ApprovalService {
+	return &approvalService{
+		repo:         repo,
+		stockRepo:    stockRepo,
+		stockService: stockService,
+		reglaService: reglaService,
+		redisClient:  redisClient,
+		config:       cfg,
+		logger:       logger,
+	}
+}
+
+// EvaluarEntradaMultiple retiene la entrada si supera los umbrales de aprobación
+func (s *approvalService) EvaluarEntradaMultiple(ctx context.Context, req *models.EntradaMultipleStockRequest) (*models.SolicitudAprobacion, error) {
+	items := make([]models.ProductoSalida, 0, len(req.Productos))
+	for _, producto := range req.Productos {
+		items = append(items, models.ProductoSalida{
+			CodigoProducto: producto.CodigoProducto,
+			TipoItem:       producto.TipoItem,
+			Cantidad:       producto.Cantidad,
+			Unidad:         producto.Unidad,
+		})
+	}
+	return s.evaluar(ctx, models.OperacionEntradaMultiple, models.OperacionReglaEntrada, req.IDLocal, req.IDUsuario, req.Motivo, items, req)
+}
+
+// EvaluarSalidaMultiple retiene la salida si supera los umbrales de aprobación
+func (s *approvalService) EvaluarSalidaMultiple(ctx context.Context, req *models.SalidaMultipleStockRequest) (*models.SolicitudAprobacion, error) {
+	return s.evaluar(ctx, models.OperacionSalidaMultiple, models.OperacionReglaSalida, req.IDLocal, req.IDUsuario, req.Motivo, req.Productos, req)
+}
+
+// EvaluarTransferencia retiene la transferencia si supera los umbrales de aprobación
+// Para las reglas es una salida del local de origen
+func (s *approvalService) EvaluarTransferencia(ctx context.Context, req *models.TransferenciaStockRequest) (*models.SolicitudAprobacion, error) {
+	return s.evaluar(ctx, models.OperacionTransferencia, models.OperacionReglaSalida, req.IDLocalOrigen, req.IDUsuario, req.Motivo, req.Productos, req)
+}
+
+// EvaluarAjuste retiene el ajuste si la diferencia con el stock actual supera los umbrales
+// Para las reglas es una entrada o una salida según el signo de la diferencia
+func (s *approvalService) EvaluarAjuste(ctx context.Context, req *models.AjusteStockRequest) (*models.SolicitudAprobacion, error) {
+	if req.CantidadNueva == nil {
+		return nil, nil
+	}
+	stockActual, err := s.stockRepo.GetStockByProducto(ctx, req.CodigoProducto, req.IDLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo stock actual: %w", err)
+	}
+	cantidadAnterior := 0
+	if stockActual != nil {
+		cantidadAnterior = stockActual.CantidadActual
+	}
+
+	diferencia := *req.CantidadNueva - cantidadAnterior
+	operacion := models.OperacionReglaEntrada
+	if diferencia < 0 {
+		operacion = models.OperacionReglaSalida
+		diferencia = -diferencia
+	}
+	if diferencia == 0 {
+		// Sin diferencia el ajuste se rechaza al aplicarlo (ErrAjusteSinDiferencia)
+		return nil, nil
+	}
+
+	items := []models.ProductoSalida{{
+		CodigoProducto: req.CodigoProducto,
+		TipoItem:       req.TipoItem,
+		Cantidad:       diferencia,
+	}}
+	return s.evaluar(ctx, models.OperacionAjuste, operacion, req.IDLocal, req.IDUsuario, req.Motivo, items, req)
+}
+
+// evaluar aplica las reglas de operación (como operacionRegla), calcula cantidad y monto y crea la
+// solicitud si alguna regla pide aprobación o se supera algún umbral
+func (s *approvalService) evaluar(ctx context.Context, tipo, operacionRegla string, idLocal, idUsuario int, motivo string, items []models.ProductoSalida, req interface{}) (*models.SolicitudAprobacion, error) {
+	// Las reglas y los umbrales se evalúan en unidad base
+	items = append([]models.ProductoSalida(nil), items...)
+	evaluados := make([]models.ItemEvaluado, 0, len(items))
+	cantidadTotal := 0
+	for i, item := range items {
+		cantidad, err := cantidadEnUnidadBase(ctx, s.stockRepo, item.CodigoProducto, item.Unidad, item.Cantidad)
+		if err != nil {
+			return nil, err
+		}
+		items[i].Cantidad = cantidad
+		items[i].Unidad = ""
+		cantidadTotal += cantidad
+		evaluados = append(evaluados, models.ItemEvaluado{
+			CodigoProducto: item.CodigoProducto,
+			TipoItem:       item.TipoItem,
+			Cantidad:       cantidad,
+		})
+	}
+
+	evaluacion, err := s.reglaService.Evaluar(ctx, nuevaOperacionEvaluada(operacionRegla, idLocal, motivo, evaluados))
+	if err != nil {
+		return nil, err
+	}
+	if evaluacion.Bloqueada() {
+		return nil, errorReglasBloqueo(evaluacion)
+	}
+	porReglas := evaluacion.RequiereAprobacion()
+
+	if !porReglas && s.config.CantidadThreshold <= 0 && s.config.MontoThreshold <= 0 {
+		return nil, nil
+	}
+
+	montoTotal := 0.0
+	if s.config.MontoThreshold > 0 || porReglas {
+		montoTotal, err = s.calcularMonto(ctx, items)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	superaCantidad := s.config.CantidadThreshold > 0 && cantidadTotal >= s.config.CantidadThreshold
+	superaMonto := s.config.MontoThreshold > 0 && montoTotal >= s.config.MontoThreshold
+	if !superaCantidad && !superaMonto && !porReglas {
+		return nil, nil
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error serializando operación: %w", err)
+	}
+
+	solicitud := &models.SolicitudAprobacion{
+		TipoOperacion: tipo,
+		IDLocal:       idLocal,
+		Payload:       string(payload),
+		CantidadTotal: cantidadTotal,
+		MontoTotal:    montoTotal,
+		Estado:        models.AprobacionEstadoPendiente,
+		IDSolicitante: idUsuario,
+	}
+	if porReglas {
+		reglas := describirReglas(evaluacion, models.ReglaAccionAprobacion)
+		solicitud.Reglas = &reglas
+	}
+
+	if err := s.repo.CreateSolicitud(ctx, solicitud); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Operación retenida para aprobación",
+		zap.String("operation", "solicitar_aprobacion"),
+		zap.Int("id_solicitud", solicitud.ID),
+		zap.String("tipo_operacion", tipo),
+		zap.Int("cantidad_total", cantidadTotal),
+		zap.Float64("monto_total", montoTotal),
+		zap.Bool("por_reglas", porReglas))
+
+	return solicitud, nil
+}
+
+// calcularMonto valoriza la operación con el precio de cada producto o pack
+func (s *approvalService) calcularMonto(ctx context.Context, items []models.ProductoSalida) (float64, error) {
+	monto := 0.0
+	for _, item := range items {
+		precio := 0.0
+		if item.TipoItem == "pack" {
+			pack, err := s.stockRepo.GetPackByCodigo(ctx, item.CodigoProducto)
+			if err != nil {
+				return 0, fmt.Errorf("error obteniendo precio de %s: %w", item.CodigoProducto, err)
+			}
+			if pack != nil {
+				precio = pack.PrecioBase
+			}
+		} else {
+			producto, err := s.stockRepo.GetProductoByCodigo(ctx, item.CodigoProducto)
+			if err != nil {
+				return 0, fmt.Errorf("error obteniendo precio de %s: %w", item.CodigoProducto, err)
+			}
+			if producto != nil && producto.Precio != nil {
+				precio = *producto.Precio
+			}
+		}
+		monto += precio * float64(item.Cantidad)
+	}
+	return monto, nil
+}
+
+// GetSolicitud obtiene una solicitud por ID
+func (s *approvalService) GetSolicitud(ctx context.Context, id int) (*models.SolicitudAprobacion, error) {
+	solicitud, err := s.repo.GetSolicitudByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if solicitud == nil {
+		return nil, fmt.Errorf("%w: %d", ErrSolicitudNoEncontrada, id)
+	}
+	return solicitud, nil
+}
+
+// GetSolicitudes obtiene solicitudes con filtros
+func (s *approvalService) GetSolicitudes(ctx context.Context, filter *models.SolicitudAprobacionFilter) ([]*models.SolicitudAprobacion, error) {
+	return s.repo.GetSolicitudes(ctx, filter)
+}
+
+// Aprobar aprueba la solicitud y recién entonces aplica la operación al stock
+// Quien pidió la operación no puede aprobarla (ErrAutoaprobacion)
+func (s *approvalService) Aprobar(ctx context.Context, id int, idSupervisor int) (*models.SolicitudAprobacion, error) {
+	logger := s.logger.With(
+		zap.String("operation", "aprobar_solicitud"),
+		zap.Int("id_solicitud", id),
+		zap.Int("id_supervisor", idSupervisor),
+	)
+
+	solicitud, err := s.GetSolicitud(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if solicitud.IDSolicitante == idSupervisor {
+		logger.Warn("Intento de aprobar una solicitud propia")
+		return nil, fmt.Errorf("%w: solicitud %d", ErrAutoaprobacion, id)
+	}
+
+	// Resolver primero para que una solicitud no se aplique dos veces
+	resuelta, err := s.repo.Resolver(ctx, id, models.AprobacionEstadoAprobada, idSupervisor, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !resuelta {
+		return nil, fmt.Errorf("%w: solicitud %d en estado %s", ErrSolicitudYaResuelta, id, solicitud.Estado)
+	}
+
+	resultado, errAplicar := s.aplicar(ctx, solicitud)
+
+	estado := models.AprobacionEstadoAprobada
+	if errAplicar != nil {
+		estado = models.AprobacionEstadoFallida
+		logger.Error("Error aplicando operación aprobada", zap.Error(errAplicar))
+		resultado, _ = json.Marshal(map[string]string{"error": errAplicar.Error()})
+	}
+	if err := s.repo.SetResultado(ctx, id, estado, string(resultado)); err != nil {
+		logger.Error("Error guardando resultado de la solicitud", zap.Error(err))
+	}
+
+	solicitud, err = s.GetSolicitud(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.notificar(ctx, solicitud)
+
+	logger.Info("Solicitud aprobada", zap.String("estado", solicitud.Estado))
+
+	return solicitud, errAplicar
+}
+
+// Rechazar rechaza la solicitud sin afectar el stock
+func (s *approvalService) Rechazar(ctx context.Context, id int, idSupervisor int, motivo string) (*models.SolicitudAprobacion, error) {
+	solicitud, err := s.GetSolicitud(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resuelta, err := s.repo.Resolver(ctx, id, models.AprobacionEstadoRechazada, idSupervisor, &motivo)
+	if err != nil {
+		return nil, err
+	}
+	if !resuelta {
+		return nil, fmt.Errorf("%w: solicitud %d en estado %s", ErrSolicitudYaResuelta, id, solicitud.Estado)
+	}
+
+	solicitud, err = s.GetSolicitud(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.notificar(ctx, solicitud)
+
+	s.logger.Info("Solicitud rechazada",
+		zap.String("operation", "rechazar_solicitud"),
+		zap.Int("id_solicitud", id),
+		zap.Int("id_supervisor", idSupervisor))
+
+	return solicitud, nil
+}
+
+// aplicar ejecuta la operación original de la solicitud
+func (s *approvalService) aplicar(ctx context.Context, solicitud *models.SolicitudAprobacion) ([]byte, error) {
+	var response interface{}
+
+	switch solicitud.TipoOperacion {
+	case models.OperacionEntradaMultiple:
+		var req models.EntradaMultipleStockRequest
+		if err := json.Unmarshal([]byte(solicitud.Payload), &req); err != nil {
+			return nil, fmt.Errorf("payload inválido: %w", err)
+		}
+		req.IDUsuario = solicitud.IDSolicitante
+		resp, err := s.stockService.EntradaMultipleStock(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		if !resp.Success {
+			return nil, fmt.Errorf("%s", resp.Message)
+		}
+		response = resp
+	case models.OperacionSalidaMultiple:
+		var req models.SalidaMultipleStockRequest
+		if err := json.Unmarshal([]byte(solicitud.Payload), &req); err != nil {
+			return nil, fmt.Errorf("payload inválido: %w", err)
+		}
+		req.IDUsuario = solicitud.IDSolicitante
+		resp, err := s.stockService.SalidaMultipleStock(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		if !resp.Success {
+			return nil, fmt.Errorf("%s", resp.Message)
+		}
+		response = resp
+	case models.OperacionTransferencia:
+		var req models.TransferenciaStockRequest
+		if err := json.Unmarshal([]byte(solicitud.Payload), &req); err != nil {
+			return nil, fmt.Errorf("payload inválido: %w", err)
+		}
+		req.IDUsuario = solicitud.IDSolicitante
+		transferencia, err := s.stockService.TransferirStock(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		response = transferencia
+	case models.OperacionAjuste:
+		var req models.AjusteStockRequest
+		if err := json.Unmarshal([]byte(solicitud.Payload), &req); err != nil {
+			return nil, fmt.Errorf("payload inválido: %w", err)
+		}
+		req.IDUsuario = solicitud.IDSolicitante
+		movimiento, err := s.stockService.AjustarStock(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		response = movimiento
+	default:
+		return nil, fmt.Errorf("tipo de operación no soportado: %s", solicitud.TipoOperacion)
+	}
+
+	return json.Marshal(response)
+}
+
+// notificar publica la resolución en el canal del solicitante (no bloquea si falla)
+func (s *approvalService) notificar(ctx context.Context, solicitud *models.SolicitudAprobacion) {
+	idSupervisor := 0
+	if solicitud.IDSupervisor != nil {
+		idSupervisor = *solicitud.IDSupervisor
+	}
+
+	notificacion := models.NotificacionAprobacion{
+		IDSolicitud:   solicitud.ID,
+		TipoOperacion: solicitud.TipoOperacion,
+		Estado:        solicitud.Estado,
+		IDSolicitante: solicitud.IDSolicitante,
+		IDSupervisor:  idSupervisor,
+		MotivoRechazo: solicitud.MotivoRechazo,
+		Timestamp:     time.Now(),
+	}
+
+	data, err := json.Marshal(notificacion)
+	if err != nil {
+		return
+	}
+
+	channel := fmt.Sprintf("notificaciones:usuario:%d", solicitud.IDSolicitante)
+	if err := s.redisClient.Publish(ctx, channel, data).Err(); err != nil {
+		s.logger.Warn("No se pudo notificar al solicitante",
+			zap.Int("id_solicitud", solicitud.ID),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// fakeAprobacionRepo solicitud en memoria; los métodos que la aprobación no usa quedan sin implementar
+type fakeAprobacionRepo struct {
+	repository.AprobacionRepository
+	solicitud *models.SolicitudAprobacion
+}
+
+func (f *fakeAprobacionRepo) GetSolicitudByID(ctx context.Context, id int) (*models.SolicitudAprobacion, error) {
+	if f.solicitud.ID != id {
+		return nil, nil
+	}
+	solicitud := *f.solicitud
+	return &solicitud, nil
+}
+
+func (f *fakeAprobacionRepo) Resolver(ctx context.Context, id int, estado string, idSupervisor int, motivoRechazo *string) (bool, error) {
+	if f.solicitud.Estado != models.AprobacionEstadoPendiente {
+		return false, nil
+	}
+	f.solicitud.Estado = estado
+	f.solicitud.IDSupervisor = &idSupervisor
+	return true, nil
+}
+
+func (f *fakeAprobacionRepo) SetResultado(ctx context.Context, id int, estado string, resultado string) error {
+	f.solicitud.Estado = estado
+	f.solicitud.Resultado = &resultado
+	return nil
+}
+
+// fakeAjusteAprobadoService aplica el ajuste aprobado registrando quién lo pidió
+type fakeAjusteAprobadoService struct {
+	StockService
+	ajustes []*models.AjusteStockRequest
+}
+
+func (f *fakeAjusteAprobadoService) AjustarStock(ctx context.Context, req *models.AjusteStockRequest) (*models.Movimiento, error) {
+	f.ajustes = append(f.ajustes, req)
+	return &models.Movimiento{IDUsuario: req.IDUsuario}, nil
+}
+
+func TestAprobar(t *testing.T) {
+	const idSolicitante = 7
+
+	tests := []struct {
+		name         string
+		idSupervisor int
+		wantErr      error
+		wantEstado   string
+		wantAjustes  int
+	}{
+		{"supervisor distinto del solicitante", 3, nil, models.AprobacionEstadoAprobada, 1},
+		{"el solicitante aprueba su propia solicitud", idSolicitante, ErrAutoaprobacion, models.AprobacionEstadoPendiente, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAprobacionRepo{solicitud: &models.SolicitudAprobacion{
+				ID:            1,
+				TipoOperacion: models.OperacionAjuste,
+				IDLocal:       1,
+				Payload:       `{"codigo_producto":"P1","tipo_item":"producto","id_local":1,"cantidad_nueva":0,"motivo":"merma"}`,
+				Estado:        models.AprobacionEstadoPendiente,
+				IDSolicitante: idSolicitante,
+			}}
+			stockService := &fakeAjusteAprobadoService{}
+			// Redis inalcanzable: la notificación al solicitante es best effort
+			redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+			defer redisClient.Close()
+			service := NewApprovalService(repo, nil, stockService, nil, redisClient, config.ApprovalConfig{}, zap.NewNop())
+
+			_, err := service.Aprobar(context.Background(), 1, tt.idSupervisor)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if repo.solicitud.Estado != tt.wantEstado {
+				t.Errorf("estado = %q, want %q", repo.solicitud.Estado, tt.wantEstado)
+			}
+			if len(stockService.ajustes) != tt.wantAjustes {
+				t.Fatalf("ajustes aplicados = %d, want %d", len(stockService.ajustes), tt.wantAjustes)
+			}
+			if tt.wantAjustes > 0 && stockService.ajustes[0].IDUsuario != idSolicitante {
+				t.Errorf("ajuste aplicado como usuario %d, want %d (el solicitante)", stockService.ajustes[0].IDUsuario, idSolicitante)
+			}
+		})
+	}
+}
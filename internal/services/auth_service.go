@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"stock-service/internal/auth"
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// AuthService inicia sesión con usuario y contraseña y renueva los tokens
+// El access token es un JWT de JWT_EXPIRY_HOURS; el refresh token es opaco, de un solo uso
+type AuthService interface {
+	Login(ctx context.Context, req *models.LoginRequest) (*models.SesionResponse, error)
+	Refresh(ctx context.Context, req *models.RefreshRequest) (*models.SesionResponse, error)
+	// VerificarAutorizador comprueba la contraseña del supervisor que autoriza una operación del
+	// cajero (precio modificado, regla de venta): usuario activo con rol admin
+	VerificarAutorizador(ctx context.Context, idAutorizador int, password string) (*models.Usuario, error)
+}
+
+// authService implementa AuthService
+type authService struct {
+	repo          repository.UserRepository
+	tokens        *auth.Tokens
+	refreshExpiry time.Duration
+	logger        *zap.Logger
+}
+
+// NewAuthService crea una nueva instancia del servicio
+func NewAuthService(repo repository.UserRepository, tokens *auth.Tokens, cfg config.JWTConfig, logger *zap.Logger) AuthService {
+	return &authService{
+		repo:          repo,
+		tokens:        tokens,
+		refreshExpiry: time.Duration(cfg.RefreshExpiryHours) * time.Hour,
+		logger:        logger,
+	}
+}
+
+// Login valida usuario y contraseña y emite el access token y el refresh token
+// Usuario inexistente y contraseña incorrecta responden igual (no se revela qué usernames existen)
+func (s *authService) Login(ctx context.Context, req *models.LoginRequest) (*models.SesionResponse, error) {
+	usuario, err := s.repo.GetUsuarioByUsername(ctx, strings.TrimSpace(req.Username))
+	if err != nil {
+		return nil, err
+	}
+
+	hash := ""
+	if usuario != nil {
+		hash = usuario.PasswordHash
+	}
+	if !auth.VerificarPassword(hash, req.Password) {
+		s.logger.Warn("Login rechazado",
+			zap.String("operation", "login"),
+			zap.String("username", req.Username))
+		return nil, ErrCredencialesInvalidas
+	}
+	if !usuario.Activo {
+		return nil, fmt.Errorf("%w: %s", ErrUsuarioInactivo, usuario.Username)
+	}
+
+	sesion, err := s.emitirSesion(ctx, usuario)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Login exitoso",
+		zap.String("operation", "login"),
+		zap.Int("id_usuario", usuario.ID),
+		zap.String("rol", usuario.Rol))
+
+	return sesion, nil
+}
+
+// Refresh consume el refresh token y emite un par de tokens nuevo
+// Rol y estado se leen de la BD: un cambio de rol o una desactivación aplica en el próximo refresh
+func (s *authService) Refresh(ctx context.Context, req *models.RefreshRequest) (*models.SesionResponse, error) {
+	idUsuario, err := s.repo.ConsumirRefreshToken(ctx, auth.HashRefreshToken(req.RefreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrRefreshTokenInvalido
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	usuario, err := s.repo.GetUsuarioByID(ctx, idUsuario)
+	if err != nil {
+		return nil, err
+	}
+	if usuario == nil {
+		return nil, ErrRefreshTokenInvalido
+	}
+	if !usuario.Activo {
+		return nil, fmt.Errorf("%w: %s", ErrUsuarioInactivo, usuario.Username)
+	}
+
+	return s.emitirSesion(ctx, usuario)
+}
+
+// VerificarAutorizador valida la contraseña y el rol del supervisor que autoriza la operación
+// El id que manda el cliente no basta: sin la contraseña cualquier cajero podría citar a un supervisor
+func (s *authService) VerificarAutorizador(ctx context.Context, idAutorizador int, password string) (*models.Usuario, error) {
+	usuario, err := s.repo.GetUsuarioByID(ctx, idAutorizador)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := ""
+	if usuario != nil {
+		hash = usuario.PasswordHash
+	}
+	if !auth.VerificarPassword(hash, password) {
+		s.logger.Warn("Autorización de supervisor rechazada",
+			zap.String("operation", "verificar_autorizador"),
+			zap.Int("id_autorizador", idAutorizador))
+		return nil, fmt.Errorf("%w: contraseña incorrecta o usuario inexistente", ErrAutorizadorInvalido)
+	}
+	if !usuario.Activo {
+		return nil, fmt.Errorf("%w: %s", ErrUsuarioInactivo, usuario.Username)
+	}
+	if usuario.Rol != models.RolAdmin {
+		return nil, fmt.Errorf("%w: el rol %q no autoriza operaciones", ErrAutorizadorInvalido, usuario.Rol)
+	}
+	return usuario, nil
+}
+
+// emitirSesion firma el access token y registra un refresh token nuevo para el usuario
+func (s *authService) emitirSesion(ctx context.Context, usuario *models.Usuario) (*models.SesionResponse, error) {
+	accessToken, claims, err := s.tokens.Firmar(usuario.ID, usuario.Username, usuario.Rol)
+	if err != nil {
+		return nil, fmt.Errorf("error firmando token: %w", err)
+	}
+
+	refreshToken, refreshHash, err := auth.NuevoRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("error generando refresh token: %w", err)
+	}
+	refreshExpiresAt := time.Now().Add(s.refreshExpiry)
+	if err := s.repo.CreateRefreshToken(ctx, refreshHash, usuario.ID, refreshExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return &models.SesionResponse{
+		AccessToken:      accessToken,
+		TokenType:        models.TokenTypeBearer,
+		ExpiresAt:        time.Unix(claims.ExpiresAt, 0),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+		Usuario:          usuario,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"stock-service/internal/auth"
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// fakeUserRepo usuarios en memoria por id
+type fakeUserRepo struct {
+	repository.UserRepository
+	usuarios map[int]*models.Usuario
+}
+
+func (f *fakeUserRepo) GetUsuarioByID(ctx context.Context, id int) (*models.Usuario, error) {
+	return f.usuarios[id], nil
+}
+
+func TestVerificarAutorizador(t *testing.T) {
+	hash, err := auth.HashPassword("clave-supervisor")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	repo := &fakeUserRepo{usuarios: map[int]*models.Usuario{
+		1: {ID: 1, Username: "supervisor", Rol: models.RolAdmin, Activo: true, PasswordHash: hash},
+		2: {ID: 2, Username: "cajero", Rol: models.RolVendedor, Activo: true, PasswordHash: hash},
+		3: {ID: 3, Username: "ex-supervisor", Rol: models.RolAdmin, Activo: false, PasswordHash: hash},
+	}}
+	jwt := config.JWTConfig{Secret: "secreto-de-prueba", ExpiryHours: 1}
+	service := NewAuthService(repo, auth.NewTokens(jwt), jwt, zap.NewNop())
+
+	tests := []struct {
+		name     string
+		id       int
+		password string
+		wantErr  error
+	}{
+		{"supervisor con su contraseña", 1, "clave-supervisor", nil},
+		{"solo el id, sin contraseña", 1, "", ErrAutorizadorInvalido},
+		{"contraseña incorrecta", 1, "otra", ErrAutorizadorInvalido},
+		{"usuario inexistente", 99, "clave-supervisor", ErrAutorizadorInvalido},
+		{"rol sin permiso para autorizar", 2, "clave-supervisor", ErrAutorizadorInvalido},
+		{"supervisor desactivado", 3, "clave-supervisor", ErrUsuarioInactivo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usuario, err := service.VerificarAutorizador(context.Background(), tt.id, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && usuario.ID != tt.id {
+				t.Errorf("usuario = %d, want %d", usuario.ID, tt.id)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// avisosCheckInterval cada cuánto el job revisa si ya corresponde generar los avisos del día
+const avisosCheckInterval = 10 * time.Minute
+
+// AvisoVencimientoService genera y consulta los avisos de lotes próximos a vencer
+type AvisoVencimientoService interface {
+	// GenerarAvisos genera los avisos del día; forzar vuelve a revisar los lotes aunque
+	// el job ya haya corrido hoy (los lotes ya avisados en su umbral no se repiten)
+	GenerarAvisos(ctx context.Context, forzar bool) (*models.ResultadoAvisosVencimiento, error)
+	// GetReporte retorna los avisos de la fecha (nil: la última generada) agrupados por local
+	GetReporte(ctx context.Context, fecha *time.Time, idLocal *int) (*models.ReporteVencimientos, error)
+	StartDailyWorker(ctx context.Context)
+}
+
+// avisoVencimientoService implementa AvisoVencimientoService
+type avisoVencimientoService struct {
+	repo     repository.AvisoVencimientoRepository
+	config   config.ExpiryAlertsConfig
+	umbrales []int
+	// Sin webhooks configurados los avisos solo quedan para el reporte
+	publicarEventos bool
+	logger          *zap.Logger
+
+	// Último día generado por el worker (solo lo usa su goroutine)
+	ultimoDia time.Time
+}
+
+// NewAvisoVencimientoService crea una nueva instancia del servicio
+func NewAvisoVencimientoService(repo repository.AvisoVencimientoRepository, cfg config.ExpiryAlertsConfig, webhooks config.WebhooksConfig, logger *zap.Logger) AvisoVencimientoService {
+	umbrales := append([]int(nil), cfg.Thresholds...)
+	sort.Ints(umbrales)
+
+	return &avisoVencimientoService{
+		repo:            repo,
+		config:          cfg,
+		umbrales:        umbrales,
+		publicarEventos: len(webhooks.URLs) > 0,
+		logger:          logger,
+	}
+}
+
+// GenerarAvisos registra los avisos del día y encola un evento por local en la misma transacción
+func (s *avisoVencimientoService) GenerarAvisos(ctx context.Context, forzar bool) (*models.ResultadoAvisosVencimiento, error) {
+	fecha := inicioDelDia(time.Now())
+	resultado := &models.ResultadoAvisosVencimiento{FechaAviso: fecha}
+
+	var eventos func([]*models.AvisoVencimiento) ([]*models.EventoOutbox, error)
+	if s.publicarEventos {
+		eventos = func(avisos []*models.AvisoVencimiento) ([]*models.EventoOutbox, error) {
+			pendientes, err := eventosVencimiento(fecha, avisos)
+			resultado.Notificados = len(pendientes)
+			return pendientes, err
+		}
+	}
+
+	avisos, registrada, err := s.repo.RegistrarAvisos(ctx, fecha, s.umbrales, forzar, eventos)
+	if err != nil {
+		return nil, fmt.Errorf("error generando avisos de vencimiento: %w", err)
+	}
+	if !registrada {
+		return resultado, nil
+	}
+
+	resultado.Ejecutado = true
+	resultado.Avisos = len(avisos)
+	resultado.Locales = len(agruparAvisos(avisos))
+	return resultado, nil
+}
+
+// GetReporte retorna los avisos de la fecha agrupados por local y umbral
+func (s *avisoVencimientoService) GetReporte(ctx context.Context, fecha *time.Time, idLocal *int) (*models.ReporteVencimientos, error) {
+	hasta := inicioDelDia(time.Now())
+	if fecha != nil {
+		hasta = *fecha
+	}
+
+	ejecucion, err := s.repo.GetUltimaEjecucion(ctx, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo ejecución de avisos: %w", err)
+	}
+
+	reporte := &models.ReporteVencimientos{
+		FechaAviso: hasta,
+		Locales:    []*models.VencimientosLocal{},
+	}
+	switch {
+	case fecha == nil && ejecucion != nil:
+		// Sin fecha: la última generada
+		reporte.FechaAviso = ejecucion.Fecha
+		reporte.Ejecucion = ejecucion
+	case ejecucion != nil && ejecucion.Fecha.Format("2006-01-02") == hasta.Format("2006-01-02"):
+		reporte.Ejecucion = ejecucion
+	}
+	if reporte.Ejecucion == nil {
+		return reporte, nil
+	}
+
+	avisos, err := s.repo.GetAvisos(ctx, reporte.FechaAviso, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo avisos de vencimiento: %w", err)
+	}
+	reporte.TotalLotes = len(avisos)
+	reporte.Locales = agruparAvisos(avisos)
+	return reporte, nil
+}
+
+// StartDailyWorker genera los avisos una vez por día, desde la hora configurada
+// Con varias réplicas solo una los genera (la ejecución del día se registra en la BD)
+func (s *avisoVencimientoService) StartDailyWorker(ctx context.Context) {
+	if !s.config.Enabled {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(avisosCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			s.avisarSiCorresponde(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// avisarSiCorresponde genera los avisos del día si ya pasó la hora y no se generaron
+func (s *avisoVencimientoService) avisarSiCorresponde(ctx context.Context) {
+	now := time.Now()
+	hoy := inicioDelDia(now)
+	if now.Hour() < s.config.Hour || s.ultimoDia.Equal(hoy) {
+		return
+	}
+
+	start := time.Now()
+	resultado, err := s.GenerarAvisos(ctx, false)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error("Error generando avisos de vencimiento",
+				zap.String("operation", "avisos_vencimiento"),
+				zap.Error(err))
+		}
+		return
+	}
+	s.ultimoDia = hoy
+	if !resultado.Ejecutado {
+		return
+	}
+
+	s.logger.Info("Avisos de vencimiento generados",
+		zap.String("operation", "avisos_vencimiento"),
+		zap.Int("avisos", resultado.Avisos),
+		zap.Int("locales", resultado.Locales),
+		zap.Int("notificados", resultado.Notificados),
+		zap.Duration("duration", time.Since(start)))
+}
+
+// eventosVencimiento arma un evento stock.vencimientos_proximos por local
+func eventosVencimiento(fecha time.Time, avisos []*models.AvisoVencimiento) ([]*models.EventoOutbox, error) {
+	locales := agruparAvisos(avisos)
+	eventos := make([]*models.EventoOutbox, 0, len(locales))
+	for _, local := range locales {
+		payload, err := json.Marshal(&models.ReporteVencimientos{
+			FechaAviso: fecha,
+			TotalLotes: local.TotalLotes,
+			Locales:    []*models.VencimientosLocal{local},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error serializando evento: %w", err)
+		}
+		clave := fmt.Sprintf("vencimientos:%d:%s", local.IDLocal, fecha.Format("2006-01-02"))
+		eventos = append(eventos, &models.EventoOutbox{
+			Tipo:    models.EventoVencimientosProximos,
+			Clave:   &clave,
+			Payload: payload,
+		})
+	}
+	return eventos, nil
+}
+
+// agruparAvisos agrupa por local y umbral los avisos (vienen ordenados por local y umbral)
+func agruparAvisos(avisos []*models.AvisoVencimiento) []*models.VencimientosLocal {
+	locales := []*models.VencimientosLocal{}
+	var local *models.VencimientosLocal
+	var grupo *models.LotesPorUmbral
+	for _, aviso := range avisos {
+		if local == nil || local.IDLocal != aviso.IDLocal {
+			local = &models.VencimientosLocal{IDLocal: aviso.IDLocal, NombreLocal: aviso.NombreLocal}
+			locales = append(locales, local)
+			grupo = nil
+		}
+		if grupo == nil || grupo.UmbralDias != aviso.UmbralDias {
+			grupo = &models.LotesPorUmbral{UmbralDias: aviso.UmbralDias}
+			local.Umbrales = append(local.Umbrales, grupo)
+		}
+		grupo.Lotes = append(grupo.Lotes, aviso)
+		grupo.Cantidad += aviso.Cantidad
+		local.TotalLotes++
+	}
+	return locales
+}
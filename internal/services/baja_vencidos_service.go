@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+	"stock-service/internal/uuid"
+
+	"go.uber.org/zap"
+)
+
+// bajasCheckInterval cada cuánto el job revisa si ya corresponde dar de baja los lotes del día
+const bajasCheckInterval = 10 * time.Minute
+
+// BajaVencidosService da de baja los lotes vencidos con salidas por merma
+type BajaVencidosService interface {
+	// DarDeBaja da de baja los lotes vencidos (en dry-run solo calcula lo que daría de baja);
+	// forzar la ejecuta aunque el job ya haya corrido hoy (los lotes ya dados de baja no se repiten)
+	DarDeBaja(ctx context.Context, dryRun, forzar bool) (*models.ResumenBajaVencidos, error)
+	// GetReporte retorna las bajas de la fecha (nil: la última ejecución) agrupadas por local
+	GetReporte(ctx context.Context, fecha *time.Time, idLocal *int) (*models.ResumenBajaVencidos, error)
+	DryRun() bool
+	StartDailyWorker(ctx context.Context)
+}
+
+// bajaVencidosService implementa BajaVencidosService
+type bajaVencidosService struct {
+	repo         repository.BajaVencidosRepository
+	stockService StockService
+	config       config.ExpiredLotsConfig
+	// Sin webhooks configurados el resumen solo queda para el reporte
+	publicarEventos bool
+	logger          *zap.Logger
+
+	// Último día ejecutado por el worker (solo lo usa su goroutine)
+	ultimoDia time.Time
+}
+
+// NewBajaVencidosService crea una nueva instancia del servicio
+func NewBajaVencidosService(repo repository.BajaVencidosRepository, stockService StockService, cfg config.ExpiredLotsConfig, webhooks config.WebhooksConfig, logger *zap.Logger) BajaVencidosService {
+	return &bajaVencidosService{
+		repo:            repo,
+		stockService:    stockService,
+		config:          cfg,
+		publicarEventos: len(webhooks.URLs) > 0,
+		logger:          logger,
+	}
+}
+
+// DryRun indica si el job está configurado para solo calcular las bajas
+func (s *bajaVencidosService) DryRun() bool {
+	return s.config.DryRun
+}
+
+// DarDeBaja registra la ejecución del día, aplica una salida por merma por local y notifica
+// el resumen de cada local; un local cuya salida falla queda para la próxima ejecución
+func (s *bajaVencidosService) DarDeBaja(ctx context.Context, dryRun, forzar bool) (*models.ResumenBajaVencidos, error) {
+	logger := s.logger.With(
+		zap.String("operation", "baja_lotes_vencidos"),
+		zap.Bool("dry_run", dryRun),
+	)
+
+	fecha := inicioDelDia(time.Now())
+	resumen := &models.ResumenBajaVencidos{
+		Fecha:   fecha,
+		DryRun:  dryRun,
+		Locales: []*models.BajasVencidosLocal{},
+	}
+
+	registrada, err := s.repo.RegistrarEjecucion(ctx, fecha, dryRun, forzar)
+	if err != nil {
+		return nil, fmt.Errorf("error registrando baja de lotes vencidos: %w", err)
+	}
+	if !registrada {
+		return resumen, nil
+	}
+	resumen.Ejecutado = true
+
+	lotes, err := s.repo.GetLotesVencidos(ctx, fecha)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo lotes vencidos: %w", err)
+	}
+
+	for _, local := range asignarBajas(fecha, lotes) {
+		if !dryRun {
+			s.aplicarBajasLocal(ctx, logger, local)
+			if local.Lotes == 0 && local.Error == "" {
+				// Otra ejecución ya las había dado de baja
+				continue
+			}
+		}
+		resumen.Locales = append(resumen.Locales, local)
+		if local.Error != "" {
+			resumen.Fallidos++
+			continue
+		}
+		resumen.Lotes += local.Lotes
+		resumen.Unidades += local.Unidades
+	}
+
+	var eventos []*models.EventoOutbox
+	if s.publicarEventos {
+		if eventos, err = eventosBajaVencidos(resumen); err != nil {
+			return nil, err
+		}
+		resumen.Notificados = len(eventos)
+	}
+
+	ejecucion := &models.EjecucionBajaVencidos{
+		Fecha:    fecha,
+		DryRun:   dryRun,
+		Lotes:    resumen.Lotes,
+		Unidades: resumen.Unidades,
+		Locales:  len(resumen.Locales) - resumen.Fallidos,
+		Fallidos: resumen.Fallidos,
+	}
+	if err := s.repo.FinalizarEjecucion(ctx, ejecucion, eventos); err != nil {
+		return nil, fmt.Errorf("error finalizando baja de lotes vencidos: %w", err)
+	}
+
+	return resumen, nil
+}
+
+// aplicarBajasLocal reserva las bajas del local, aplica sus salidas en una sola operación y
+// las confirma; si la salida falla las libera y deja el error en el resumen del local
+// Una baja que queda pendiente (caída entre la salida y la confirmación) no se vuelve a aplicar
+func (s *bajaVencidosService) aplicarBajasLocal(ctx context.Context, logger *zap.Logger, local *models.BajasVencidosLocal) {
+	logger = logger.With(zap.Int("id_local", local.IDLocal))
+
+	bajas, err := s.repo.ReservarBajas(ctx, local.Bajas)
+	if err != nil {
+		logger.Error("Error reservando bajas de lotes vencidos", zap.Error(err))
+		local.Error = err.Error()
+		return
+	}
+	local.Bajas = bajas
+	local.Lotes, local.Unidades = 0, 0
+	if len(bajas) == 0 {
+		return
+	}
+
+	idOperacion := uuid.New()
+	ids := make([]int64, 0, len(bajas))
+	salidas := make([]*models.SalidaStockRequest, 0, len(bajas))
+	for _, baja := range bajas {
+		ids = append(ids, baja.ID)
+		salidas = append(salidas, &models.SalidaStockRequest{
+			CodigoProducto: baja.CodigoProducto,
+			TipoItem:       "producto",
+			Cantidad:       baja.Cantidad,
+			Motivo:         models.MotivoMermaVencimiento,
+			IDLocal:        baja.IDLocal,
+			Observaciones:  fmt.Sprintf("Lote %s vencido el %s", baja.Lote, baja.FechaVencimiento.Format("2006-01-02")),
+			IDUsuario:      s.config.IDUsuario,
+			IDOperacion:    idOperacion,
+		})
+		local.Lotes++
+		local.Unidades += baja.Cantidad
+	}
+
+	if _, err := s.stockService.SalidaStockLote(ctx, salidas); err != nil {
+		logger.Error("Error aplicando bajas de lotes vencidos", zap.Error(err))
+		local.Error = err.Error()
+		for _, baja := range bajas {
+			baja.ID, baja.Estado = 0, ""
+		}
+		// Liberar las bajas para reintentarlas (con un contexto propio por si el original expiró)
+		if errLiberar := s.repo.LiberarBajas(context.Background(), ids); errLiberar != nil {
+			logger.Error("Error liberando bajas de lotes vencidos", zap.Error(errLiberar))
+		}
+		return
+	}
+
+	if err := s.repo.ConfirmarBajas(ctx, ids, idOperacion); err != nil {
+		// La salida ya se aplicó: las bajas quedan pendientes y no se repiten
+		logger.Error("Error confirmando bajas de lotes vencidos", zap.Error(err))
+	}
+	for _, baja := range bajas {
+		baja.Estado = models.BajaEstadoAplicada
+		baja.IDOperacion = &idOperacion
+	}
+
+	logger.Info("Lotes vencidos dados de baja",
+		zap.String("id_operacion", idOperacion),
+		zap.Int("lotes", local.Lotes),
+		zap.Int("unidades", local.Unidades))
+}
+
+// GetReporte retorna las bajas de la fecha agrupadas por local
+func (s *bajaVencidosService) GetReporte(ctx context.Context, fecha *time.Time, idLocal *int) (*models.ResumenBajaVencidos, error) {
+	hasta := inicioDelDia(time.Now())
+	if fecha != nil {
+		hasta = *fecha
+	}
+
+	ejecucion, err := s.repo.GetUltimaEjecucion(ctx, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo ejecución de bajas: %w", err)
+	}
+
+	resumen := &models.ResumenBajaVencidos{
+		Fecha:   hasta,
+		Locales: []*models.BajasVencidosLocal{},
+	}
+	switch {
+	case fecha == nil && ejecucion != nil:
+		// Sin fecha: la última ejecutada
+		resumen.Fecha = ejecucion.Fecha
+		resumen.Ejecucion = ejecucion
+	case ejecucion != nil && ejecucion.Fecha.Format("2006-01-02") == hasta.Format("2006-01-02"):
+		resumen.Ejecucion = ejecucion
+	}
+	if resumen.Ejecucion == nil {
+		return resumen, nil
+	}
+	resumen.Ejecutado = true
+	resumen.DryRun = resumen.Ejecucion.DryRun
+
+	bajas, err := s.repo.GetBajas(ctx, resumen.Fecha, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo bajas de lotes vencidos: %w", err)
+	}
+	resumen.Locales = agruparBajas(bajas)
+	for _, local := range resumen.Locales {
+		resumen.Lotes += local.Lotes
+		resumen.Unidades += local.Unidades
+	}
+	return resumen, nil
+}
+
+// StartDailyWorker da de baja los lotes vencidos una vez por día, desde la hora configurada
+// Con varias réplicas solo una la ejecuta (la ejecución del día se registra en la BD)
+func (s *bajaVencidosService) StartDailyWorker(ctx context.Context) {
+	if !s.config.Enabled {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(bajasCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			s.darDeBajaSiCorresponde(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// darDeBajaSiCorresponde ejecuta la baja del día si ya pasó la hora y no se ejecutó
+func (s *bajaVencidosService) darDeBajaSiCorresponde(ctx context.Context) {
+	now := time.Now()
+	hoy := inicioDelDia(now)
+	if now.Hour() < s.config.Hour || s.ultimoDia.Equal(hoy) {
+		return
+	}
+
+	start := time.Now()
+	resumen, err := s.DarDeBaja(ctx, s.config.DryRun, false)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error("Error dando de baja lotes vencidos",
+				zap.String("operation", "baja_lotes_vencidos"),
+				zap.Error(err))
+		}
+		return
+	}
+	s.ultimoDia = hoy
+	if !resumen.Ejecutado {
+		return
+	}
+
+	s.logger.Info("Baja de lotes vencidos ejecutada",
+		zap.String("operation", "baja_lotes_vencidos"),
+		zap.Bool("dry_run", resumen.DryRun),
+		zap.Int("lotes", resumen.Lotes),
+		zap.Int("unidades", resumen.Unidades),
+		zap.Int("locales", len(resumen.Locales)),
+		zap.Int("fallidos", resumen.Fallidos),
+		zap.Duration("duration", time.Since(start)))
+}
+
+// asignarBajas reparte las unidades pendientes de cada lote entre los locales con stock
+// (en orden de local), sin superar lo disponible de cada producto en el local; los lotes
+// vienen ordenados por vencimiento, así que los más antiguos toman el stock primero
+func asignarBajas(fecha time.Time, lotes []*models.LoteVencidoStock) []*models.BajasVencidosLocal {
+	type loteKey struct {
+		codigoBarras string
+		lote         string
+		vencimiento  string
+	}
+	pendientes := make(map[loteKey]int)
+	disponibles := make(map[stockKey]int)
+
+	locales := []*models.BajasVencidosLocal{}
+	porLocal := make(map[int]*models.BajasVencidosLocal)
+	for _, lote := range lotes {
+		kl := loteKey{lote.CodigoBarras, lote.Lote, lote.FechaVencimiento.Format("2006-01-02")}
+		pendiente, ok := pendientes[kl]
+		if !ok {
+			pendiente = lote.Pendiente
+		}
+		ks := stockKey{codigoProducto: lote.CodigoProducto, idLocal: lote.IDLocal}
+		disponible, ok := disponibles[ks]
+		if !ok {
+			disponible = lote.Disponible
+		}
+
+		cantidad := min(pendiente, disponible)
+		if cantidad <= 0 {
+			continue
+		}
+		pendientes[kl] = pendiente - cantidad
+		disponibles[ks] = disponible - cantidad
+
+		local := porLocal[lote.IDLocal]
+		if local == nil {
+			local = &models.BajasVencidosLocal{IDLocal: lote.IDLocal, NombreLocal: lote.NombreLocal}
+			porLocal[lote.IDLocal] = local
+			locales = append(locales, local)
+		}
+		local.Bajas = append(local.Bajas, &models.BajaLoteVencido{
+			FechaBaja:        fecha,
+			IDLocal:          lote.IDLocal,
+			NombreLocal:      lote.NombreLocal,
+			CodigoProducto:   lote.CodigoProducto,
+			NombreProducto:   lote.NombreProducto,
+			CodigoBarras:     lote.CodigoBarras,
+			Lote:             lote.Lote,
+			FechaVencimiento: lote.FechaVencimiento,
+			Cantidad:         cantidad,
+		})
+		local.Lotes++
+		local.Unidades += cantidad
+	}
+	return locales
+}
+
+// agruparBajas agrupa por local las bajas (vienen ordenadas por local)
+func agruparBajas(bajas []*models.BajaLoteVencido) []*models.BajasVencidosLocal {
+	locales := []*models.BajasVencidosLocal{}
+	var local *models.BajasVencidosLocal
+	for _, baja := range bajas {
+		if local == nil || local.IDLocal != baja.IDLocal {
+			local = &models.BajasVencidosLocal{IDLocal: baja.IDLocal, NombreLocal: baja.NombreLocal}
+			locales = append(locales, local)
+		}
+		local.Bajas = append(local.Bajas, baja)
+		local.Lotes++
+		local.Unidades += baja.Cantidad
+	}
+	return locales
+}
+
+// eventosBajaVencidos arma un evento stock.lotes_vencidos_baja por local
+func eventosBajaVencidos(resumen *models.ResumenBajaVencidos) ([]*models.EventoOutbox, error) {
+	eventos := make([]*models.EventoOutbox, 0, len(resumen.Locales))
+	for _, local := range resumen.Locales {
+		payload, err := json.Marshal(&models.ResumenBajaVencidos{
+			Fecha:     resumen.Fecha,
+			DryRun:    resumen.DryRun,
+			Ejecutado: resumen.Ejecutado,
+			Lotes:     local.Lotes,
+			Unidades:  local.Unidades,
+			Locales:   []*models.BajasVencidosLocal{local},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error serializando evento: %w", err)
+		}
+		clave := fmt.Sprintf("lotes_vencidos:%d:%s", local.IDLocal, resumen.Fecha.Format("2006-01-02"))
+		eventos = append(eventos, &models.EventoOutbox{
+			Tipo:    models.EventoLotesVencidosBaja,
+			Clave:   &clave,
+			Payload: payload,
+		})
+	}
+	return eventos, nil
+}
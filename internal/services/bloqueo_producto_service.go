@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"stock-service/internal/cache"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// BloqueoProductoService maneja el bloqueo temporal de venta de productos (ej. retiro de un lote por sanidad)
+// El bloqueo aplica en todos los locales y viaja en el producto cacheado: QuickSale lo rechaza
+type BloqueoProductoService interface {
+	Bloquear(ctx context.Context, codigoProducto string, req *models.BloquearProductoRequest) (*models.BloqueoProducto, error)
+	Levantar(ctx context.Context, codigoProducto string, req *models.LevantarBloqueoRequest) error
+	GetBloqueos(ctx context.Context, codigoProducto string) ([]*models.BloqueoProducto, error)
+	GetBloqueosVigentes(ctx context.Context) ([]*models.BloqueoProducto, error)
+}
+
+// bloqueoProductoService implementa BloqueoProductoService
+type bloqueoProductoService struct {
+	repo         repository.BloqueoProductoRepository
+	stockRepo    repository.StockRepository
+	productCache *cache.ProductCache
+	logger       *zap.Logger
+}
+
+// NewBloqueoProductoService crea una nueva instancia del servicio
+func NewBloqueoProductoService(repo repository.BloqueoProductoRepository, stockRepo repository.StockRepository, productCache *cache.ProductCache, logger *zap.Logger) BloqueoProductoService {
+	return &bloqueoProductoService{
+		repo:         repo,
+		stockRepo:    stockRepo,
+		productCache: productCache,
+		logger:       logger,
+	}
+}
+
+// Bloquear bloquea la venta del producto de inmediato, hasta req.Hasta o hasta que se levante
+func (s *bloqueoProductoService) Bloquear(ctx context.Context, codigoProducto string, req *models.BloquearProductoRequest) (*models.BloqueoProducto, error) {
+	if req.Hasta != nil && !req.Hasta.After(time.Now()) {
+		return nil, fmt.Errorf("%w: la vigencia debe terminar en el futuro", ErrBloqueoInvalido)
+	}
+	if err := s.verificarProducto(ctx, codigoProducto); err != nil {
+		return nil, err
+	}
+
+	bloqueo := &models.BloqueoProducto{
+		CodigoProducto: codigoProducto,
+		Motivo:         strings.TrimSpace(req.Motivo),
+		Hasta:          req.Hasta,
+		IDUsuario:      req.IDUsuario,
+	}
+	if err := s.repo.CreateBloqueo(ctx, bloqueo); err != nil {
+		return nil, err
+	}
+
+	s.invalidarProducto(ctx, codigoProducto)
+
+	s.logger.Warn("Venta de producto bloqueada",
+		zap.String("operation", "bloquear_producto"),
+		zap.String("codigo_producto", codigoProducto),
+		zap.String("motivo", bloqueo.Motivo),
+		zap.Int64("id_bloqueo", bloqueo.ID))
+
+	return bloqueo, nil
+}
+
+// Levantar levanta los bloqueos vigentes del producto antes de su vigencia
+func (s *bloqueoProductoService) Levantar(ctx context.Context, codigoProducto string, req *models.LevantarBloqueoRequest) error {
+	levantados, err := s.repo.LevantarBloqueos(ctx, codigoProducto, req.IDUsuario, strings.TrimSpace(req.Motivo))
+	if err != nil {
+		return err
+	}
+	if levantados == 0 {
+		return fmt.Errorf("%w: %s", ErrBloqueoNoEncontrado, codigoProducto)
+	}
+
+	s.invalidarProducto(ctx, codigoProducto)
+
+	s.logger.Info("Bloqueo de venta levantado",
+		zap.String("operation", "levantar_bloqueo"),
+		zap.String("codigo_producto", codigoProducto),
+		zap.Int64("bloqueos_levantados", levantados))
+
+	return nil
+}
+
+// GetBloqueos obtiene el historial de bloqueos del producto
+func (s *bloqueoProductoService) GetBloqueos(ctx context.Context, codigoProducto string) ([]*models.BloqueoProducto, error) {
+	return s.repo.GetBloqueos(ctx, codigoProducto)
+}
+
+// GetBloqueosVigentes obtiene los bloqueos vigentes de todos los productos
+func (s *bloqueoProductoService) GetBloqueosVigentes(ctx context.Context) ([]*models.BloqueoProducto, error) {
+	return s.repo.GetBloqueosVigentes(ctx)
+}
+
+// verificarProducto verifica que el código corresponda a un producto o a un pack
+func (s *bloqueoProductoService) verificarProducto(ctx context.Context, codigo string) error {
+	producto, err := s.stockRepo.GetProductoByCodigo(ctx, codigo)
+	if err != nil {
+		return fmt.Errorf("error verificando producto: %w", err)
+	}
+	if producto != nil {
+		return nil
+	}
+
+	pack, err := s.stockRepo.GetPackByCodigo(ctx, codigo)
+	if err != nil {
+		return fmt.Errorf("error verificando pack: %w", err)
+	}
+	if pack == nil {
+		return fmt.Errorf("%w: %s", ErrProductoNoEncontrado, codigo)
+	}
+	return nil
+}
+
+// invalidarProducto invalida en la caché del POS el producto y los packs que lo contienen
+// (el bloqueo de un componente también bloquea el pack)
+func (s *bloqueoProductoService) invalidarProducto(ctx context.Context, codigo string) {
+	if s.productCache == nil {
+		return
+	}
+
+	codigos := []string{codigo}
+	packs, err := s.stockRepo.GetPacksByProducto(ctx, codigo)
+	if err != nil {
+		s.logger.Warn("Error obteniendo packs del producto bloqueado",
+			zap.String("operation", "invalidar_producto"),
+			zap.String("codigo_producto", codigo),
+			zap.Error(err))
+	}
+	for _, pack := range packs {
+		codigos = append(codigos, pack.CodigoPack)
+	}
+
+	for _, c := range codigos {
+		if err := s.productCache.InvalidateByCodigoTivendo(ctx, c); err != nil {
+			s.logger.Warn("Error invalidando cache del producto",
+				zap.String("operation", "invalidar_producto"),
+				zap.String("codigo_producto", c),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"stock-service/internal/cache"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// botonesCachePattern claves de las grillas cacheadas (pos:botones:{id_local})
+const botonesCachePattern = "pos:botones:*"
+
+// BotonRapidoService define la interfaz de la grilla de botones rápidos del POS
+type BotonRapidoService interface {
+	GetGrilla(ctx context.Context, idLocal int) (*models.GrillaBotonesRapidos, bool, error)
+	ReemplazarGrilla(ctx context.Context, idLocal int, req *models.GrillaBotonesRequest) (*models.GrillaBotonesRapidos, error)
+	EliminarBoton(ctx context.Context, idLocal, posicion int) error
+	InvalidarGrillas(ctx context.Context) error
+}
+
+// botonRapidoService implementa BotonRapidoService
+type botonRapidoService struct {
+	repo          repository.BotonRapidoRepository
+	stockRepo     repository.StockRepository
+	cache         *redis.Client
+	invalidations *cache.InvalidationQueue
+	ttl           time.Duration
+	logger        *zap.Logger
+}
+
+// NewBotonRapidoService crea una nueva instancia del servicio
+func NewBotonRapidoService(repo repository.BotonRapidoRepository, stockRepo repository.StockRepository, redisClient *redis.Client, invalidations *cache.InvalidationQueue, ttl time.Duration, logger *zap.Logger) BotonRapidoService {
+	return &botonRapidoService{
+		repo:          repo,
+		stockRepo:     stockRepo,
+		cache:         redisClient,
+		invalidations: invalidations,
+		ttl:           ttl,
+		logger:        logger,
+	}
+}
+
+// GetGrilla obtiene la grilla del local desde la cache o, si no está, desde la BD
+// Retorna true si la grilla vino de la cache
+func (s *botonRapidoService) GetGrilla(ctx context.Context, idLocal int) (*models.GrillaBotonesRapidos, bool, error) {
+	cacheKey := botonesCacheKey(idLocal)
+
+	if data, err := s.cache.Get(ctx, cacheKey).Result(); err == nil {
+		var grilla models.GrillaBotonesRapidos
+		if err := json.Unmarshal([]byte(data), &grilla); err == nil {
+			return &grilla, true, nil
+		}
+		s.logger.Warn("Grilla de botones cacheada corrupta, se recarga", zap.String("key", cacheKey))
+	} else if err != redis.Nil {
+		// Redis caído: se sirve desde la BD sin bloquear al POS
+		s.logger.Warn("Error leyendo grilla de botones de cache", zap.String("key", cacheKey), zap.Error(err))
+	}
+
+	botones, err := s.repo.GetBotones(ctx, idLocal)
+	if err != nil {
+		return nil, false, fmt.Errorf("error obteniendo botones rápidos: %w", err)
+	}
+	grilla := &models.GrillaBotonesRapidos{IDLocal: idLocal, Botones: botones}
+
+	if data, err := json.Marshal(grilla); err == nil {
+		if err := s.cache.Set(ctx, cacheKey, data, s.ttl).Err(); err != nil {
+			s.logger.Warn("Error guardando grilla de botones en cache", zap.String("key", cacheKey), zap.Error(err))
+		}
+	}
+
+	return grilla, false, nil
+}
+
+// ReemplazarGrilla guarda la grilla completa del local e invalida su cache
+func (s *botonRapidoService) ReemplazarGrilla(ctx context.Context, idLocal int, req *models.GrillaBotonesRequest) (*models.GrillaBotonesRapidos, error) {
+	logger := s.logger.With(
+		zap.String("operation", "reemplazar_grilla_botones"),
+		zap.Int("id_local", idLocal),
+		zap.Int("cantidad_botones", len(req.Botones)),
+	)
+
+	local, err := s.stockRepo.GetLocalByID(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error verificando local: %w", err)
+	}
+	if local == nil {
+		return nil, fmt.Errorf("%w: %d", ErrLocalNoEncontrado, idLocal)
+	}
+
+	posiciones := make(map[int]bool, len(req.Botones))
+	for _, boton := range req.Botones {
+		if posiciones[boton.Posicion] {
+			return nil, fmt.Errorf("%w: posición %d repetida", ErrGrillaBotonesInvalida, boton.Posicion)
+		}
+		posiciones[boton.Posicion] = true
+
+		producto, err := s.stockRepo.GetProductoByCodigo(ctx, boton.CodigoProducto)
+		if err != nil {
+			return nil, fmt.Errorf("error verificando producto: %w", err)
+		}
+		if producto == nil {
+			return nil, fmt.Errorf("%w: %s", ErrProductoNoEncontrado, boton.CodigoProducto)
+		}
+		if !producto.Activo {
+			return nil, fmt.Errorf("%w: %s", ErrProductoDescontinuado, boton.CodigoProducto)
+		}
+	}
+
+	if err := s.repo.ReemplazarBotones(ctx, idLocal, req.Botones); err != nil {
+		return nil, fmt.Errorf("error guardando botones rápidos: %w", err)
+	}
+	s.invalidations.Invalidate(context.Background(), botonesCacheKey(idLocal))
+
+	logger.Info("Grilla de botones rápidos actualizada")
+
+	botones, err := s.repo.GetBotones(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo botones rápidos: %w", err)
+	}
+
+	return &models.GrillaBotonesRapidos{IDLocal: idLocal, Botones: botones}, nil
+}
+
+// EliminarBoton quita el botón de una posición e invalida la cache del local
+func (s *botonRapidoService) EliminarBoton(ctx context.Context, idLocal, posicion int) error {
+	found, err := s.repo.DeleteBoton(ctx, idLocal, posicion)
+	if err != nil {
+		return fmt.Errorf("error eliminando botón rápido: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("%w: local %d posición %d", ErrBotonRapidoNoEncontrado, idLocal, posicion)
+	}
+
+	s.invalidations.Invalidate(context.Background(), botonesCacheKey(idLocal))
+	return nil
+}
+
+// InvalidarGrillas invalida las grillas cacheadas de todos los locales
+// (los botones incluyen nombre y precio: se llama tras actualizaciones masivas de productos o precios)
+func (s *botonRapidoService) InvalidarGrillas(ctx context.Context) error {
+	iter := s.cache.Scan(ctx, 0, botonesCachePattern, 0).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("error escaneando grillas de botones: %w", err)
+	}
+
+	s.invalidations.Invalidate(ctx, keys...)
+	return nil
+}
+
+// botonesCacheKey clave de cache de la grilla de un local
+func botonesCacheKey(idLocal int) string {
+	return fmt.Sprintf("pos:botones:%d", idLocal)
+}
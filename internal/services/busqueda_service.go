@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// Parámetros de la búsqueda global
+const (
+	busquedaLimitePorTipo  = 10
+	busquedaDiasMovimiento = 30
+)
+
+// BusquedaService búsqueda global unificada para el dashboard
+type BusquedaService interface {
+	Buscar(ctx context.Context, q string, limit int) ([]*models.ResultadoBusqueda, error)
+}
+
+// busquedaService implementa BusquedaService
+type busquedaService struct {
+	repo   repository.BusquedaRepository
+	logger *zap.Logger
+}
+
+// NewBusquedaService crea una nueva instancia del servicio
+func NewBusquedaService(repo repository.BusquedaRepository, logger *zap.Logger) BusquedaService {
+	return &busquedaService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Buscar consulta en paralelo productos, packs y movimientos recientes
+// y devuelve los resultados ordenados por relevancia
+func (s *busquedaService) Buscar(ctx context.Context, q string, limit int) ([]*models.ResultadoBusqueda, error) {
+	q = strings.TrimSpace(q)
+	if limit <= 0 {
+		limit = busquedaLimitePorTipo
+	}
+
+	var (
+		wg          sync.WaitGroup
+		productos   []*models.ProductoBusqueda
+		packs       []*models.ProductoBusqueda
+		movimientos []*models.Movimiento
+		errs        [3]error
+	)
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		productos, errs[0] = s.repo.BuscarProductos(ctx, q, limit)
+	}()
+	go func() {
+		defer wg.Done()
+		packs, errs[1] = s.repo.BuscarPacks(ctx, q, limit)
+	}()
+	go func() {
+		defer wg.Done()
+		movimientos, errs[2] = s.repo.BuscarMovimientos(ctx, q, busquedaDiasMovimiento, limit)
+	}()
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resultados := make([]*models.ResultadoBusqueda, 0, len(productos)+len(packs)+len(movimientos))
+	for _, p := range productos {
+		resultados = append(resultados, &models.ResultadoBusqueda{
+			Tipo:       models.ResultadoProducto,
+			Codigo:     p.Codigo,
+			Titulo:     p.Nombre,
+			Subtitulo:  p.Codigo,
+			Relevancia: relevanciaProducto(q, p),
+			Data:       p,
+		})
+	}
+	for _, p := range packs {
+		resultados = append(resultados, &models.ResultadoBusqueda{
+			Tipo:       models.ResultadoPack,
+			Codigo:     p.Codigo,
+			Titulo:     p.Nombre,
+			Subtitulo:  "Pack " + p.Codigo,
+			Relevancia: relevanciaProducto(q, p),
+			Data:       p,
+		})
+	}
+	for _, m := range movimientos {
+		relevancia := 20
+		if m.CodigoProducto == q {
+			relevancia = 40
+		}
+		resultados = append(resultados, &models.ResultadoBusqueda{
+			Tipo:       models.ResultadoMovimiento,
+			Codigo:     m.CodigoProducto,
+			Titulo:     fmt.Sprintf("%s de %d × %s", m.TipoMovimiento, m.Cantidad, m.CodigoProducto),
+			Subtitulo:  m.Motivo,
+			Relevancia: relevancia,
+			Data:       m,
+		})
+	}
+
+	// Orden estable: a igual relevancia se mantiene productos > packs > movimientos (recientes primero)
+	sort.SliceStable(resultados, func(i, j int) bool {
+		return resultados[i].Relevancia > resultados[j].Relevancia
+	})
+
+	return resultados, nil
+}
+
+// relevanciaProducto puntúa una coincidencia: exacta en código/barcode > prefijo > contenida en el nombre
+func relevanciaProducto(q string, p *models.ProductoBusqueda) int {
+	ql := strings.ToLower(q)
+	nombre := strings.ToLower(p.Nombre)
+
+	relevancia := 30
+	switch {
+	case p.Codigo == q ||
+		(p.CodigoBarraInterno != nil && *p.CodigoBarraInterno == q) ||
+		(p.CodigoBarraExterno != nil && *p.CodigoBarraExterno == q):
+		relevancia = 100
+	case nombre == ql:
+		relevancia = 90
+	case strings.HasPrefix(strings.ToLower(p.Codigo), ql) || strings.HasPrefix(nombre, ql):
+		relevancia = 70
+	case strings.Contains(nombre, " "+ql):
+		relevancia = 50
+	}
+
+	if !p.Activo {
+		relevancia -= 10
+	}
+	return relevancia
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"stock-service/internal/cache"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// CanalService maneja los canales de venta habilitados por producto (pos, ecommerce, mayorista)
+type CanalService interface {
+	GetCanales(ctx context.Context, codigoProducto string) (*models.CanalesProducto, error)
+	SetCanales(ctx context.Context, codigoProducto string, canales []string) (*models.CanalesProducto, error)
+	EliminarCanales(ctx context.Context, codigoProducto string) error
+}
+
+// canalService implementa CanalService
+type canalService struct {
+	repo         repository.CanalRepository
+	stockRepo    repository.StockRepository
+	productCache *cache.ProductCache
+	logger       *zap.Logger
+}
+
+// NewCanalService crea una nueva instancia del servicio
+func NewCanalService(repo repository.CanalRepository, stockRepo repository.StockRepository, productCache *cache.ProductCache, logger *zap.Logger) CanalService {
+	return &canalService{
+		repo:         repo,
+		stockRepo:    stockRepo,
+		productCache: productCache,
+		logger:       logger,
+	}
+}
+
+// GetCanales obtiene los canales del producto; sin canales definidos se informan todos
+func (s *canalService) GetCanales(ctx context.Context, codigoProducto string) (*models.CanalesProducto, error) {
+	if err := s.verificarProducto(ctx, codigoProducto); err != nil {
+		return nil, err
+	}
+
+	canales, err := s.repo.GetCanales(ctx, codigoProducto)
+	if err != nil {
+		return nil, err
+	}
+	if canales == nil {
+		canales = &models.CanalesProducto{
+			CodigoProducto: codigoProducto,
+			Canales:        models.CanalesVenta,
+		}
+	}
+
+	return canales, nil
+}
+
+// SetCanales define los canales en que se vende el producto
+func (s *canalService) SetCanales(ctx context.Context, codigoProducto string, canales []string) (*models.CanalesProducto, error) {
+	if err := s.verificarProducto(ctx, codigoProducto); err != nil {
+		return nil, err
+	}
+
+	definidos := &models.CanalesProducto{
+		CodigoProducto: codigoProducto,
+		Canales:        canales,
+	}
+	if err := s.repo.UpsertCanales(ctx, definidos); err != nil {
+		return nil, err
+	}
+
+	s.invalidarProducto(ctx, codigoProducto)
+
+	s.logger.Info("Canales de venta definidos",
+		zap.String("operation", "set_canales"),
+		zap.String("codigo_producto", codigoProducto),
+		zap.Strings("canales", canales))
+
+	return definidos, nil
+}
+
+// EliminarCanales elimina los canales definidos: el producto vuelve a venderse en todos
+func (s *canalService) EliminarCanales(ctx context.Context, codigoProducto string) error {
+	eliminados, err := s.repo.DeleteCanales(ctx, codigoProducto)
+	if err != nil {
+		return err
+	}
+	if !eliminados {
+		return fmt.Errorf("%w: %s", ErrCanalesNoDefinidos, codigoProducto)
+	}
+
+	s.invalidarProducto(ctx, codigoProducto)
+
+	return nil
+}
+
+// verificarProducto verifica que el código corresponda a un producto o a un pack
+func (s *canalService) verificarProducto(ctx context.Context, codigo string) error {
+	producto, err := s.stockRepo.GetProductoByCodigo(ctx, codigo)
+	if err != nil {
+		return fmt.Errorf("error verificando producto: %w", err)
+	}
+	if producto != nil {
+		return nil
+	}
+
+	pack, err := s.stockRepo.GetPackByCodigo(ctx, codigo)
+	if err != nil {
+		return fmt.Errorf("error verificando pack: %w", err)
+	}
+	if pack == nil {
+		return fmt.Errorf("%w: %s", ErrProductoNoEncontrado, codigo)
+	}
+	return nil
+}
+
+// invalidarProducto invalida el producto en la caché del POS (los canales viajan en el producto cacheado)
+func (s *canalService) invalidarProducto(ctx context.Context, codigo string) {
+	if s.productCache == nil {
+		return
+	}
+	if err := s.productCache.InvalidateByCodigoTivendo(ctx, codigo); err != nil {
+		s.logger.Warn("Error invalidando cache del producto",
+			zap.String("operation", "invalidar_producto"),
+			zap.String("codigo_producto", codigo),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// conteosCheckInterval cada cuánto el job revisa si ya corresponde generar los conteos de la semana
+const conteosCheckInterval = 15 * time.Minute
+
+// ConteoCiclicoService genera y da seguimiento a los conteos cíclicos semanales
+// Las diferencias contadas quedan registradas en la sesión; no ajustan el stock
+type ConteoCiclicoService interface {
+	// GenerarSemana crea las sesiones de la semana en curso; forzar genera una muestra adicional
+	// aunque la semana ya esté generada (excluye lo que sigue abierto)
+	GenerarSemana(ctx context.Context, forzar bool) (*models.GeneracionConteos, error)
+	GetConteos(ctx context.Context, filter *models.ConteoCiclicoFilter) ([]*models.ConteoCiclico, error)
+	GetConteo(ctx context.Context, id int64) (*models.ConteoCiclico, error)
+	RegistrarConteo(ctx context.Context, id int64, req *models.RegistrarConteoRequest) (*models.ConteoCiclico, error)
+	// ImportarArchivoPDT registra en la sesión las lecturas del archivo de una terminal de inventario
+	// y reporta las líneas con formato inválido, códigos desconocidos o productos fuera de la sesión
+	ImportarArchivoPDT(ctx context.Context, id int64, archivo string, data []byte, idUsuario int) (*models.ImportacionPDT, error)
+	GetCumplimiento(ctx context.Context, filter *models.ReporteFilter) (*models.ReporteConteosCiclicos, error)
+	StartWeeklyWorker(ctx context.Context)
+}
+
+// conteoCiclicoService implementa ConteoCiclicoService
+type conteoCiclicoService struct {
+	repo   repository.ConteoCiclicoRepository
+	config config.CycleCountsConfig
+	pdt    config.PDTConfig
+	logger *zap.Logger
+
+	// Última semana generada por el worker (solo lo usa su goroutine)
+	ultimaSemana time.Time
+}
+
+// NewConteoCiclicoService crea una nueva instancia del servicio
+func NewConteoCiclicoService(repo repository.ConteoCiclicoRepository, cfg config.CycleCountsConfig, pdt config.PDTConfig, logger *zap.Logger) ConteoCiclicoService {
+	return &conteoCiclicoService{
+		repo:   repo,
+		config: cfg,
+		pdt:    pdt,
+		logger: logger,
+	}
+}
+
+// inicioDeSemana retorna el lunes a medianoche de la semana de t
+func inicioDeSemana(t time.Time) time.Time {
+	dia := inicioDelDia(t)
+	desdeLunes := (int(dia.Weekday()) + 6) % 7
+	return dia.AddDate(0, 0, -desdeLunes)
+}
+
+// GenerarSemana elige la muestra de cada local y la reparte en sesiones asignadas en rotación
+// Un local que falla se registra en el log y no impide generar el resto
+func (s *conteoCiclicoService) GenerarSemana(ctx context.Context, forzar bool) (*models.GeneracionConteos, error) {
+	logger := s.logger.With(zap.String("operation", "generar_conteos_ciclicos"))
+
+	now := time.Now()
+	semana := inicioDeSemana(now)
+	generacion := &models.GeneracionConteos{
+		Semana:   semana,
+		Criterio: s.config.Criteria,
+		Sesiones: []*models.ConteoCiclico{},
+	}
+
+	registrada, err := s.repo.RegistrarEjecucion(ctx, semana, s.config.Criteria, forzar)
+	if err != nil {
+		return nil, fmt.Errorf("error registrando generación de conteos cíclicos: %w", err)
+	}
+	if !registrada {
+		return generacion, nil
+	}
+	generacion.Generada = true
+
+	locales, err := s.repo.GetLocales(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo locales para conteos cíclicos: %w", err)
+	}
+
+	// La rotación de usuarios parte de la semana del año para no asignar siempre lo mismo
+	_, semanaISO := semana.ISOWeek()
+	turno := semanaISO
+	fechaLimite := inicioDelDia(now).AddDate(0, 0, s.config.DueDays)
+
+	ejecucion := &models.EjecucionConteosCiclicos{Semana: semana, Criterio: s.config.Criteria}
+	for _, idLocal := range locales {
+		conteos, err := s.muestraLocal(ctx, idLocal, semana, fechaLimite, &turno)
+		if err == nil && len(conteos) > 0 {
+			err = s.repo.CreateConteos(ctx, conteos)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			logger.Error("Error generando conteos cíclicos del local", zap.Int("id_local", idLocal), zap.Error(err))
+			continue
+		}
+		if len(conteos) == 0 {
+			continue
+		}
+
+		ejecucion.Locales++
+		for _, conteo := range conteos {
+			ejecucion.Sesiones++
+			ejecucion.Productos += len(conteo.Items)
+			generacion.Sesiones = append(generacion.Sesiones, conteo)
+		}
+	}
+
+	if err := s.repo.FinalizarEjecucion(ctx, ejecucion); err != nil {
+		logger.Error("Error guardando totales de conteos cíclicos", zap.Error(err))
+	}
+
+	return generacion, nil
+}
+
+// muestraLocal arma las sesiones del local con la muestra de la semana
+func (s *conteoCiclicoService) muestraLocal(ctx context.Context, idLocal int, semana, fechaLimite time.Time, turno *int) ([]*models.ConteoCiclico, error) {
+	var idCategoria *int
+	if s.config.Criteria == models.CriterioConteoCategoria {
+		var err error
+		idCategoria, err = s.repo.GetSiguienteCategoria(ctx, idLocal)
+		if err != nil {
+			return nil, err
+		}
+		if idCategoria == nil {
+			return nil, nil
+		}
+	}
+
+	productos, err := s.repo.GetMuestra(ctx, idLocal, idCategoria, s.config.ItemsPerSession*s.config.SessionsPerLocal)
+	if err != nil {
+		return nil, err
+	}
+
+	var conteos []*models.ConteoCiclico
+	for inicio := 0; inicio < len(productos); inicio += s.config.ItemsPerSession {
+		fin := min(inicio+s.config.ItemsPerSession, len(productos))
+		conteo := &models.ConteoCiclico{
+			Semana:      semana,
+			IDLocal:     idLocal,
+			Criterio:    s.config.Criteria,
+			IDCategoria: idCategoria,
+			IDUsuario:   s.config.UserIDs[*turno%len(s.config.UserIDs)],
+			Estado:      models.ConteoEstadoPendiente,
+			FechaLimite: fechaLimite,
+		}
+		*turno++
+		for _, producto := range productos[inicio:fin] {
+			conteo.Items = append(conteo.Items, &models.ConteoCiclicoItem{
+				CodigoProducto: producto.CodigoProducto,
+				TipoItem:       producto.TipoItem,
+			})
+		}
+		conteos = append(conteos, conteo)
+	}
+
+	return conteos, nil
+}
+
+// GetConteos lista las sesiones según los filtros
+func (s *conteoCiclicoService) GetConteos(ctx context.Context, filter *models.ConteoCiclicoFilter) ([]*models.ConteoCiclico, error) {
+	return s.repo.GetConteos(ctx, filter)
+}
+
+// GetConteo obtiene una sesión con sus ítems
+func (s *conteoCiclicoService) GetConteo(ctx context.Context, id int64) (*models.ConteoCiclico, error) {
+	conteo, err := s.repo.GetConteo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if conteo == nil {
+		return nil, ErrConteoNoEncontrado
+	}
+	return conteo, nil
+}
+
+// RegistrarConteo guarda las cantidades contadas (un producto ya contado se puede corregir
+// mientras la sesión no esté completada); la sesión se completa al contar todos sus productos
+func (s *conteoCiclicoService) RegistrarConteo(ctx context.Context, id int64, req *models.RegistrarConteoRequest) (*models.ConteoCiclico, error) {
+	conteo, err := s.GetConteo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if conteo.Estado == models.ConteoEstadoCompletada {
+		return nil, fmt.Errorf("%w: sesión %d", ErrConteoCompletado, id)
+	}
+
+	enSesion := make(map[string]bool, len(conteo.Items))
+	for _, item := range conteo.Items {
+		enSesion[item.TipoItem+":"+item.CodigoProducto] = true
+	}
+	for _, item := range req.Items {
+		if !enSesion[item.TipoItem+":"+item.CodigoProducto] {
+			return nil, fmt.Errorf("%w: %s", ErrProductoFueraDeConteo, item.CodigoProducto)
+		}
+	}
+
+	registrado, err := s.repo.RegistrarConteo(ctx, id, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	if !registrado {
+		return nil, fmt.Errorf("%w: sesión %d", ErrConteoCompletado, id)
+	}
+
+	conteo, err = s.GetConteo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Conteo cíclico registrado",
+		zap.String("operation", "registrar_conteo_ciclico"),
+		zap.Int64("id_conteo", id),
+		zap.Int("id_usuario", req.IDUsuario),
+		zap.Int("items", len(req.Items)),
+		zap.String("estado", conteo.Estado))
+
+	return conteo, nil
+}
+
+// GetCumplimiento resume el cumplimiento de las sesiones creadas en el período
+func (s *conteoCiclicoService) GetCumplimiento(ctx context.Context, filter *models.ReporteFilter) (*models.ReporteConteosCiclicos, error) {
+	detalle, err := s.repo.GetCumplimiento(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	ejecucion, err := s.repo.GetUltimaEjecucion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reporte := &models.ReporteConteosCiclicos{
+		Filtros:   filter,
+		Ejecucion: ejecucion,
+		Detalle:   detalle,
+	}
+	aTiempo := 0
+	for _, fila := range detalle {
+		fila.Cumplimiento = porcentajeCumplimiento(fila.ATiempo, fila.Completadas+fila.Vencidas)
+		reporte.Asignadas += fila.Asignadas
+		reporte.Completadas += fila.Completadas
+		reporte.Vencidas += fila.Vencidas
+		aTiempo += fila.ATiempo
+	}
+	reporte.Cumplimiento = porcentajeCumplimiento(aTiempo, reporte.Completadas+reporte.Vencidas)
+
+	return reporte, nil
+}
+
+// porcentajeCumplimiento sesiones a tiempo sobre las exigibles (sin exigibles: 0)
+func porcentajeCumplimiento(aTiempo, exigibles int) float64 {
+	if exigibles == 0 {
+		return 0
+	}
+	return float64(aTiempo) * 100 / float64(exigibles)
+}
+
+// StartWeeklyWorker genera los conteos una vez por semana, desde el día y hora configurados
+// Con varias réplicas solo una los genera (la semana generada se registra en la BD)
+func (s *conteoCiclicoService) StartWeeklyWorker(ctx context.Context) {
+	if !s.config.Enabled {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(conteosCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			s.generarSiCorresponde(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// generarSiCorresponde genera la semana si ya pasó el día y hora programados y no se generó
+func (s *conteoCiclicoService) generarSiCorresponde(ctx context.Context) {
+	now := time.Now()
+	semana := inicioDeSemana(now)
+	programada := semana.AddDate(0, 0, s.config.Weekday-1).Add(time.Duration(s.config.Hour) * time.Hour)
+	if now.Before(programada) || s.ultimaSemana.Equal(semana) {
+		return
+	}
+
+	start := time.Now()
+	generacion, err := s.GenerarSemana(ctx, false)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error("Error generando conteos cíclicos",
+				zap.String("operation", "generar_conteos_ciclicos"),
+				zap.Error(err))
+		}
+		return
+	}
+	s.ultimaSemana = semana
+	if !generacion.Generada {
+		return
+	}
+
+	s.logger.Info("Conteos cíclicos generados",
+		zap.String("operation", "generar_conteos_ciclicos"),
+		zap.String("criterio", generacion.Criterio),
+		zap.Int("sesiones", len(generacion.Sesiones)),
+		zap.Duration("duration", time.Since(start)))
+}
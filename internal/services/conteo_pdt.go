@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"stock-service/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// bomUTF8 marca de orden de bytes que agregan algunos exportadores al inicio del archivo
+var bomUTF8 = []byte{0xEF, 0xBB, 0xBF}
+
+// lecturaPDT línea válida del archivo de la PDT
+type lecturaPDT struct {
+	linea    int
+	codigo   string
+	cantidad int
+	fecha    *time.Time
+}
+
+// ImportarArchivoPDT lee el archivo con el layout configurado, resuelve los códigos a productos o packs
+// y registra en la sesión la suma de las lecturas de cada producto
+// Las líneas que no se pueden aplicar se reportan sin impedir cargar el resto
+func (s *conteoCiclicoService) ImportarArchivoPDT(ctx context.Context, id int64, archivo string, data []byte, idUsuario int) (*models.ImportacionPDT, error) {
+	if s.pdt.MaxBytes > 0 && int64(len(data)) > s.pdt.MaxBytes {
+		return nil, fmt.Errorf("%w: más de %d bytes", ErrArchivoPDTDemasiadoGrande, s.pdt.MaxBytes)
+	}
+
+	conteo, err := s.GetConteo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if conteo.Estado == models.ConteoEstadoCompletada {
+		return nil, fmt.Errorf("%w: sesión %d", ErrConteoCompletado, id)
+	}
+
+	importacion := &models.ImportacionPDT{
+		IDConteo:            id,
+		Archivo:             archivo,
+		Registrados:         []*models.ResultadoConteoItem{},
+		Rechazadas:          []*models.LineaPDTRechazada{},
+		CodigosDesconocidos: []string{},
+	}
+
+	lecturas, err := s.leerArchivoPDT(data, importacion)
+	if err != nil {
+		return nil, err
+	}
+	if importacion.Lineas == 0 {
+		return nil, fmt.Errorf("%w: el archivo no tiene lecturas", ErrArchivoPDTInvalido)
+	}
+
+	codigos := make([]string, 0, len(lecturas))
+	vistos := make(map[string]bool, len(lecturas))
+	for _, lectura := range lecturas {
+		if !vistos[lectura.codigo] {
+			vistos[lectura.codigo] = true
+			codigos = append(codigos, lectura.codigo)
+		}
+	}
+	resueltos, err := s.repo.ResolverCodigos(ctx, codigos)
+	if err != nil {
+		return nil, err
+	}
+
+	enSesion := make(map[string]bool, len(conteo.Items))
+	for _, item := range conteo.Items {
+		enSesion[item.TipoItem+":"+item.CodigoProducto] = true
+	}
+
+	// Suma por producto en el orden de la primera lectura
+	totales := make(map[string]*models.ResultadoConteoItem)
+	desconocidos := make(map[string]bool)
+	for _, lectura := range lecturas {
+		producto, ok := resueltos[lectura.codigo]
+		if !ok {
+			importacion.Rechazadas = append(importacion.Rechazadas, &models.LineaPDTRechazada{
+				Linea:  lectura.linea,
+				Codigo: lectura.codigo,
+				Motivo: models.LineaPDTCodigoDesconocido,
+			})
+			if !desconocidos[lectura.codigo] {
+				desconocidos[lectura.codigo] = true
+				importacion.CodigosDesconocidos = append(importacion.CodigosDesconocidos, lectura.codigo)
+			}
+			continue
+		}
+
+		clave := producto.TipoItem + ":" + producto.CodigoProducto
+		if !enSesion[clave] {
+			importacion.Rechazadas = append(importacion.Rechazadas, &models.LineaPDTRechazada{
+				Linea:  lectura.linea,
+				Codigo: lectura.codigo,
+				Motivo: models.LineaPDTFueraDeSesion,
+				Error:  producto.CodigoProducto,
+			})
+			continue
+		}
+
+		total, ok := totales[clave]
+		if !ok {
+			total = &models.ResultadoConteoItem{CodigoProducto: producto.CodigoProducto, TipoItem: producto.TipoItem}
+			totales[clave] = total
+			importacion.Registrados = append(importacion.Registrados, total)
+		}
+		total.Cantidad += lectura.cantidad
+		importacion.LineasAplicadas++
+
+		if lectura.fecha != nil {
+			if importacion.PrimeraLectura == nil || lectura.fecha.Before(*importacion.PrimeraLectura) {
+				importacion.PrimeraLectura = lectura.fecha
+			}
+			if importacion.UltimaLectura == nil || lectura.fecha.After(*importacion.UltimaLectura) {
+				importacion.UltimaLectura = lectura.fecha
+			}
+		}
+	}
+
+	if len(importacion.Registrados) > 0 {
+		registrado, err := s.repo.RegistrarConteo(ctx, id, importacion.Registrados)
+		if err != nil {
+			return nil, err
+		}
+		if !registrado {
+			return nil, fmt.Errorf("%w: sesión %d", ErrConteoCompletado, id)
+		}
+
+		conteo, err = s.GetConteo(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	importacion.Conteo = conteo
+
+	s.logger.Info("Archivo de PDT importado en conteo cíclico",
+		zap.String("operation", "importar_archivo_pdt"),
+		zap.Int64("id_conteo", id),
+		zap.Int("id_usuario", idUsuario),
+		zap.String("archivo", archivo),
+		zap.Int("lineas", importacion.Lineas),
+		zap.Int("lineas_aplicadas", importacion.LineasAplicadas),
+		zap.Int("codigos_desconocidos", len(importacion.CodigosDesconocidos)),
+		zap.String("estado", conteo.Estado))
+
+	return importacion, nil
+}
+
+// leerArchivoPDT separa las líneas válidas de las que no respetan el layout (que quedan en
+// Rechazadas); solo retorna error si el archivo no se puede leer
+func (s *conteoCiclicoService) leerArchivoPDT(data []byte, importacion *models.ImportacionPDT) ([]*lecturaPDT, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, bomUTF8)))
+	reader.Comma = s.pdt.Separador()
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var lecturas []*lecturaPDT
+	encabezado := s.pdt.SkipHeader
+	for {
+		campos, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("%w: %v", ErrArchivoPDTInvalido, err)
+			}
+			importacion.Lineas++
+			importacion.Rechazadas = append(importacion.Rechazadas, &models.LineaPDTRechazada{
+				Linea:  parseErr.StartLine,
+				Motivo: models.LineaPDTFormatoInvalido,
+				Error:  parseErr.Err.Error(),
+			})
+			continue
+		}
+		linea, _ := reader.FieldPos(0)
+
+		if encabezado {
+			encabezado = false
+			continue
+		}
+		importacion.Lineas++
+
+		lectura, err := s.parseLineaPDT(campos)
+		if err != nil {
+			importacion.Rechazadas = append(importacion.Rechazadas, &models.LineaPDTRechazada{
+				Linea:  linea,
+				Codigo: lectura.codigo,
+				Motivo: models.LineaPDTFormatoInvalido,
+				Error:  err.Error(),
+			})
+			continue
+		}
+		lectura.linea = linea
+		lecturas = append(lecturas, lectura)
+	}
+
+	return lecturas, nil
+}
+
+// parseLineaPDT lee código, cantidad y fecha de las columnas configuradas
+// Sin columna de cantidad la línea es una unidad (una lectura por unidad escaneada)
+func (s *conteoCiclicoService) parseLineaPDT(campos []string) (*lecturaPDT, error) {
+	columna := func(n int) (string, bool) {
+		if n < 1 || n > len(campos) {
+			return "", false
+		}
+		return strings.TrimSpace(campos[n-1]), true
+	}
+
+	lectura := &lecturaPDT{cantidad: 1}
+
+	codigo, ok := columna(s.pdt.CodeColumn)
+	if !ok || codigo == "" {
+		return lectura, fmt.Errorf("falta el código (columna %d)", s.pdt.CodeColumn)
+	}
+	lectura.codigo = codigo
+
+	if s.pdt.QuantityColumn > 0 {
+		valor, ok := columna(s.pdt.QuantityColumn)
+		if !ok || valor == "" {
+			return lectura, fmt.Errorf("falta la cantidad (columna %d)", s.pdt.QuantityColumn)
+		}
+		cantidad, err := strconv.Atoi(valor)
+		if err != nil || cantidad < 0 {
+			return lectura, fmt.Errorf("cantidad inválida %q: debe ser un entero mayor o igual a 0", valor)
+		}
+		lectura.cantidad = cantidad
+	}
+
+	if s.pdt.TimestampColumn > 0 {
+		valor, ok := columna(s.pdt.TimestampColumn)
+		if !ok || valor == "" {
+			return lectura, fmt.Errorf("falta la fecha (columna %d)", s.pdt.TimestampColumn)
+		}
+		fecha, err := time.ParseInLocation(s.pdt.TimestampFormat, valor, time.Local)
+		if err != nil {
+			return lectura, fmt.Errorf("fecha inválida %q: formato esperado %s", valor, s.pdt.TimestampFormat)
+		}
+		lectura.fecha = &fecha
+	}
+
+	return lectura, nil
+}
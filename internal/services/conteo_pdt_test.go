@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// rechazo motivo y línea de una línea rechazada (lo que se compara en los tests)
+type rechazo struct {
+	linea  int
+	motivo string
+}
+
+func rechazos(importacion *models.ImportacionPDT) []rechazo {
+	r := []rechazo{}
+	for _, rechazada := range importacion.Rechazadas {
+		r = append(r, rechazo{rechazada.Linea, rechazada.Motivo})
+	}
+	return r
+}
+
+func TestLeerArchivoPDT(t *testing.T) {
+	csvConCantidad := config.PDTConfig{Delimiter: ",", CodeColumn: 1, QuantityColumn: 2}
+
+	tests := []struct {
+		name        string
+		pdt         config.PDTConfig
+		data        string
+		wantCodigos []string
+		wantCant    []int
+		wantLineas  int
+		wantRechazo []rechazo
+	}{
+		{
+			name:        "coma con cantidad",
+			pdt:         csvConCantidad,
+			data:        "7801234567894,3\n12345670,1\n",
+			wantCodigos: []string{"7801234567894", "12345670"},
+			wantCant:    []int{3, 1},
+			wantLineas:  2,
+		},
+		{
+			name:        "punto y coma, encabezado, BOM y CRLF",
+			pdt:         config.PDTConfig{Delimiter: ";", CodeColumn: 2, QuantityColumn: 3, SkipHeader: true},
+			data:        "\xEF\xBB\xBFlocal;codigo;cantidad\r\n1;7801234567894;2\r\n1; 12345670 ; 5\r\n",
+			wantCodigos: []string{"7801234567894", "12345670"},
+			wantCant:    []int{2, 5},
+			wantLineas:  2,
+		},
+		{
+			name:        "tabulador sin columna de cantidad: una unidad por línea",
+			pdt:         config.PDTConfig{Delimiter: "tab", CodeColumn: 1},
+			data:        "7801234567894\tx\n7801234567894\n",
+			wantCodigos: []string{"7801234567894", "7801234567894"},
+			wantCant:    []int{1, 1},
+			wantLineas:  2,
+		},
+		{
+			name:        "líneas en blanco se ignoran",
+			pdt:         csvConCantidad,
+			data:        "\n7801234567894,1\n\n",
+			wantCodigos: []string{"7801234567894"},
+			wantCant:    []int{1},
+			wantLineas:  1,
+		},
+		{
+			name:        "líneas malas se rechazan sin impedir el resto",
+			pdt:         csvConCantidad,
+			data:        "7801234567894,2\n,4\n12345670\n12345670,-1\n12345670,dos\n12345670,1\n",
+			wantCodigos: []string{"7801234567894", "12345670"},
+			wantCant:    []int{2, 1},
+			wantLineas:  6,
+			wantRechazo: []rechazo{
+				{2, models.LineaPDTFormatoInvalido}, // sin código
+				{3, models.LineaPDTFormatoInvalido}, // sin cantidad
+				{4, models.LineaPDTFormatoInvalido}, // cantidad negativa
+				{5, models.LineaPDTFormatoInvalido}, // cantidad no numérica
+			},
+		},
+		{
+			name: "fecha con el formato configurado",
+			pdt: config.PDTConfig{Delimiter: ",", CodeColumn: 1, QuantityColumn: 2, TimestampColumn: 3,
+				TimestampFormat: "02/01/2006 15:04"},
+			data:        "7801234567894,1,15/06/2026 10:30\n12345670,1,2026-06-15 10:30\n12345670,1\n",
+			wantCodigos: []string{"7801234567894"},
+			wantCant:    []int{1},
+			wantLineas:  3,
+			wantRechazo: []rechazo{{2, models.LineaPDTFormatoInvalido}, {3, models.LineaPDTFormatoInvalido}},
+		},
+		{
+			name:        "comillas sin cerrar se rechazan como formato inválido",
+			pdt:         csvConCantidad,
+			data:        "7801234567894,1\n\"12345670,1\n",
+			wantCodigos: []string{"7801234567894"},
+			wantCant:    []int{1},
+			wantLineas:  2,
+			wantRechazo: []rechazo{{2, models.LineaPDTFormatoInvalido}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &conteoCiclicoService{pdt: tt.pdt, logger: zap.NewNop()}
+			importacion := &models.ImportacionPDT{}
+
+			lecturas, err := service.leerArchivoPDT([]byte(tt.data), importacion)
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			codigos, cantidades := []string{}, []int{}
+			for _, lectura := range lecturas {
+				codigos = append(codigos, lectura.codigo)
+				cantidades = append(cantidades, lectura.cantidad)
+			}
+			if tt.wantCodigos == nil {
+				tt.wantCodigos = []string{}
+			}
+			if !reflect.DeepEqual(codigos, tt.wantCodigos) || !reflect.DeepEqual(cantidades, tt.wantCant) {
+				t.Errorf("lecturas = %q %v, want %q %v", codigos, cantidades, tt.wantCodigos, tt.wantCant)
+			}
+			if importacion.Lineas != tt.wantLineas {
+				t.Errorf("lineas = %d, want %d", importacion.Lineas, tt.wantLineas)
+			}
+			if tt.wantRechazo == nil {
+				tt.wantRechazo = []rechazo{}
+			}
+			if got := rechazos(importacion); !reflect.DeepEqual(got, tt.wantRechazo) {
+				t.Errorf("rechazadas = %v, want %v", got, tt.wantRechazo)
+			}
+		})
+	}
+}
+
+// fakeConteoRepo sesión en memoria con los códigos del catálogo
+type fakeConteoRepo struct {
+	repository.ConteoCiclicoRepository
+	conteo      *models.ConteoCiclico
+	catalogo    map[string]*models.ProductoConteo
+	registrados []*models.ResultadoConteoItem
+}
+
+func (f *fakeConteoRepo) GetConteo(ctx context.Context, id int64) (*models.ConteoCiclico, error) {
+	if f.conteo.ID != id {
+		return nil, nil
+	}
+	return f.conteo, nil
+}
+
+func (f *fakeConteoRepo) ResolverCodigos(ctx context.Context, codigos []string) (map[string]*models.ProductoConteo, error) {
+	resueltos := map[string]*models.ProductoConteo{}
+	for _, codigo := range codigos {
+		if producto, ok := f.catalogo[codigo]; ok {
+			resueltos[codigo] = producto
+		}
+	}
+	return resueltos, nil
+}
+
+func (f *fakeConteoRepo) RegistrarConteo(ctx context.Context, id int64, items []*models.ResultadoConteoItem) (bool, error) {
+	f.registrados = append(f.registrados, items...)
+	return true, nil
+}
+
+func TestImportarArchivoPDT(t *testing.T) {
+	nuevoRepo := func(estado string) *fakeConteoRepo {
+		return &fakeConteoRepo{
+			conteo: &models.ConteoCiclico{ID: 1, Estado: estado, Items: []*models.ConteoCiclicoItem{
+				{CodigoProducto: "P1", TipoItem: "producto"},
+				{CodigoProducto: "K1", TipoItem: "pack"},
+			}},
+			catalogo: map[string]*models.ProductoConteo{
+				"7801234567894": {CodigoProducto: "P1", TipoItem: "producto"},
+				"P1":            {CodigoProducto: "P1", TipoItem: "producto"},
+				"12345670":      {CodigoProducto: "K1", TipoItem: "pack"},
+				"0000000000017": {CodigoProducto: "P9", TipoItem: "producto"},
+			},
+		}
+	}
+	pdt := config.PDTConfig{Delimiter: ",", CodeColumn: 1, QuantityColumn: 2, TimestampColumn: 3,
+		TimestampFormat: "2006-01-02 15:04", MaxBytes: 1024}
+
+	t.Run("suma códigos repetidos y rechaza los desconocidos", func(t *testing.T) {
+		repo := nuevoRepo(models.ConteoEstadoEnCurso)
+		service := NewConteoCiclicoService(repo, config.CycleCountsConfig{}, pdt, zap.NewNop())
+
+		data := "7801234567894,2,2026-06-15 10:00\n" +
+			"12345670,1,2026-06-15 09:00\n" +
+			"P1,3,2026-06-15 11:00\n" + // otro código del mismo producto
+			"999,1,2026-06-15 10:05\n" +
+			"0000000000017,4,2026-06-15 10:06\n" + // existe pero no está en la sesión
+			"999,2,2026-06-15 10:07\n" +
+			"7801234567894,x,2026-06-15 10:08\n"
+		importacion, err := service.ImportarArchivoPDT(context.Background(), 1, "pdt.csv", []byte(data), 5)
+		if err != nil {
+			t.Fatalf("err = %v", err)
+		}
+
+		want := []*models.ResultadoConteoItem{
+			{CodigoProducto: "P1", TipoItem: "producto", Cantidad: 5},
+			{CodigoProducto: "K1", TipoItem: "pack", Cantidad: 1},
+		}
+		if !reflect.DeepEqual(repo.registrados, want) {
+			t.Errorf("registrados = %+v, want %+v", repo.registrados, want)
+		}
+		if importacion.Lineas != 7 || importacion.LineasAplicadas != 3 {
+			t.Errorf("lineas = %d, aplicadas = %d, want 7, 3", importacion.Lineas, importacion.LineasAplicadas)
+		}
+		if !reflect.DeepEqual(importacion.CodigosDesconocidos, []string{"999"}) {
+			t.Errorf("codigos desconocidos = %v, want [999]", importacion.CodigosDesconocidos)
+		}
+		wantRechazo := []rechazo{
+			{7, models.LineaPDTFormatoInvalido},
+			{4, models.LineaPDTCodigoDesconocido},
+			{5, models.LineaPDTFueraDeSesion},
+			{6, models.LineaPDTCodigoDesconocido},
+		}
+		if got := rechazos(importacion); !reflect.DeepEqual(got, wantRechazo) {
+			t.Errorf("rechazadas = %v, want %v", got, wantRechazo)
+		}
+		primera := time.Date(2026, 6, 15, 9, 0, 0, 0, time.Local)
+		ultima := time.Date(2026, 6, 15, 11, 0, 0, 0, time.Local)
+		if importacion.PrimeraLectura == nil || !importacion.PrimeraLectura.Equal(primera) ||
+			importacion.UltimaLectura == nil || !importacion.UltimaLectura.Equal(ultima) {
+			t.Errorf("lecturas entre %v y %v, want %v y %v", importacion.PrimeraLectura, importacion.UltimaLectura, primera, ultima)
+		}
+	})
+
+	errores := []struct {
+		name    string
+		estado  string
+		data    string
+		wantErr error
+	}{
+		{"sesión completada", models.ConteoEstadoCompletada, "P1,1,2026-06-15 10:00\n", ErrConteoCompletado},
+		{"archivo sin lecturas", models.ConteoEstadoPendiente, "\n\n", ErrArchivoPDTInvalido},
+		{"archivo demasiado grande", models.ConteoEstadoPendiente, string(make([]byte, 2048)), ErrArchivoPDTDemasiadoGrande},
+	}
+	for _, tt := range errores {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := nuevoRepo(tt.estado)
+			service := NewConteoCiclicoService(repo, config.CycleCountsConfig{}, pdt, zap.NewNop())
+
+			_, err := service.ImportarArchivoPDT(context.Background(), 1, "pdt.csv", []byte(tt.data), 5)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(repo.registrados) > 0 {
+				t.Errorf("registrados = %+v, want ninguno", repo.registrados)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// DuplicateSaleService detecta ventas rápidas idénticas dentro de una ventana corta
+type DuplicateSaleService interface {
+	Check(ctx context.Context, req *models.QuickSaleRequest, monto float64) (*models.DuplicateSaleCheck, error)
+	Registrar(ctx context.Context, fingerprint string) error
+	GetVentasSospechosas(ctx context.Context, filter *models.VentaSospechosaFilter) ([]*models.VentaSospechosa, error)
+	MarcarRevisada(ctx context.Context, id int, idUsuario int) error
+}
+
+// duplicateSaleService implementa DuplicateSaleService
+type duplicateSaleService struct {
+	repo        repository.VentaSospechosaRepository
+	redisClient *redis.Client
+	config      config.SalesConfig
+	logger      *zap.Logger
+}
+
+// NewDuplicateSaleService crea una nueva instancia del servicio
+func NewDuplicateSaleService(repo repository.VentaSospechosaRepository, redisClient *redis.Client, cfg config.SalesConfig, logger *zap.Logger) DuplicateSaleService {
+	return &duplicateSaleService{
+		repo:        repo,
+		redisClient: redisClient,
+		config:      cfg,
+		logger:      logger,
+	}
+}
+
+// Check verifica si la venta es idéntica a otra registrada dentro de la ventana
+// Solo consulta la huella: se guarda con Registrar cuando la venta se aplica, para que un
+// intento rechazado (sin stock, reglas, BD caída) no bloquee el reintento del cajero
+func (s *duplicateSaleService) Check(ctx context.Context, req *models.QuickSaleRequest, monto float64) (*models.DuplicateSaleCheck, error) {
+	fingerprint := saleFingerprint(req, monto)
+	result := &models.DuplicateSaleCheck{Fingerprint: fingerprint}
+
+	if s.config.DuplicateWindow <= 0 {
+		return result, nil
+	}
+
+	existe, err := s.redisClient.Exists(ctx, fingerprintKey(fingerprint)).Result()
+	if err != nil {
+		return result, fmt.Errorf("error verificando venta duplicada: %w", err)
+	}
+	if existe == 0 {
+		return result, nil
+	}
+
+	result.Sospechosa = true
+	result.Bloqueada = s.config.BlockDuplicates
+
+	logger := s.logger.With(
+		zap.String("operation", "check_duplicate_sale"),
+		zap.String("fingerprint", fingerprint),
+		zap.Int("id_local", req.IDLocal),
+		zap.Float64("monto", monto),
+		zap.Bool("bloqueada", result.Bloqueada),
+	)
+	logger.Warn("Venta idéntica detectada dentro de la ventana de duplicados")
+
+	// Registrar para revisión del supervisor (no debe impedir la venta si falla)
+	items, _ := json.Marshal(req.Items)
+	venta := &models.VentaSospechosa{
+		Fingerprint: fingerprint,
+		IDLocal:     req.IDLocal,
+		IDUsuario:   req.IDUsuario,
+		Items:       string(items),
+		Monto:       monto,
+		Bloqueada:   result.Bloqueada,
+	}
+	if err := s.repo.CreateVentaSospechosa(ctx, venta); err != nil {
+		logger.Error("Error registrando venta sospechosa", zap.Error(err))
+	}
+
+	return result, nil
+}
+
+// Registrar guarda la huella de una venta aplicada (o encolada) en Redis, compartida entre
+// réplicas, con TTL igual a la ventana
+func (s *duplicateSaleService) Registrar(ctx context.Context, fingerprint string) error {
+	if s.config.DuplicateWindow <= 0 || fingerprint == "" {
+		return nil
+	}
+	if err := s.redisClient.Set(ctx, fingerprintKey(fingerprint), 1, s.config.DuplicateWindow).Err(); err != nil {
+		return fmt.Errorf("error registrando huella de venta: %w", err)
+	}
+	return nil
+}
+
+// GetVentasSospechosas obtiene las ventas sospechosas registradas
+func (s *duplicateSaleService) GetVentasSospechosas(ctx context.Context, filter *models.VentaSospechosaFilter) ([]*models.VentaSospechosa, error) {
+	return s.repo.GetVentasSospechosas(ctx, filter)
+}
+
+// MarcarRevisada marca una venta sospechosa como revisada
+func (s *duplicateSaleService) MarcarRevisada(ctx context.Context, id int, idUsuario int) error {
+	return s.repo.MarcarRevisada(ctx, id, idUsuario)
+}
+
+// fingerprintKey clave en Redis de la huella de una venta
+func fingerprintKey(fingerprint string) string {
+	return fmt.Sprintf("venta:fingerprint:%s", fingerprint)
+}
+
+// saleFingerprint calcula una huella estable de la venta: local, ítems (sin importar el orden) y monto
+func saleFingerprint(req *models.QuickSaleRequest, monto float64) string {
+	items := make([]string, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, fmt.Sprintf("%s:%s:%d", item.CodigoProducto, item.TipoItem, item.Cantidad))
+	}
+	sort.Strings(items)
+
+	raw := fmt.Sprintf("%d|%s|%.2f", req.IDLocal, strings.Join(items, ","), monto)
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// EcommerceService maneja el stock publicable para la tienda online y sus márgenes de seguridad
+type EcommerceService interface {
+	GetStockPublicable(ctx context.Context, canal string, codigos []string) ([]*models.StockPublicable, error)
+	GetMargenes(ctx context.Context) ([]*models.MargenStockPublicable, error)
+	SetMargenProducto(ctx context.Context, codigoProducto string, margen int) (*models.MargenStockPublicable, error)
+	SetMargenCategoria(ctx context.Context, idCategoria int, margen int) (*models.MargenStockPublicable, error)
+	EliminarMargenProducto(ctx context.Context, codigoProducto string) error
+	EliminarMargenCategoria(ctx context.Context, idCategoria int) error
+}
+
+// ecommerceService implementa EcommerceService
+type ecommerceService struct {
+	repo      repository.EcommerceRepository
+	stockRepo repository.StockRepository
+	cfg       config.EcommerceConfig
+	logger    *zap.Logger
+}
+
+// NewEcommerceService crea una nueva instancia del servicio
+func NewEcommerceService(repo repository.EcommerceRepository, stockRepo repository.StockRepository, cfg config.EcommerceConfig, logger *zap.Logger) EcommerceService {
+	return &ecommerceService{
+		repo:      repo,
+		stockRepo: stockRepo,
+		cfg:       cfg,
+		logger:    logger,
+	}
+}
+
+// GetStockPublicable obtiene el stock publicable de los locales configurados (codigos vacío: todos)
+// Solo se publican productos activos, disponibles para venta, que llevan stock y están habilitados en el canal
+func (s *ecommerceService) GetStockPublicable(ctx context.Context, canal string, codigos []string) ([]*models.StockPublicable, error) {
+	return s.repo.GetStockPublicable(ctx, s.cfg.Locales, s.cfg.DefaultBuffer, canal, codigos)
+}
+
+// GetMargenes obtiene los márgenes de seguridad definidos por producto y categoría
+func (s *ecommerceService) GetMargenes(ctx context.Context) ([]*models.MargenStockPublicable, error) {
+	return s.repo.GetMargenes(ctx)
+}
+
+// SetMargenProducto define el margen de seguridad propio de un producto
+func (s *ecommerceService) SetMargenProducto(ctx context.Context, codigoProducto string, margen int) (*models.MargenStockPublicable, error) {
+	producto, err := s.stockRepo.GetProductoByCodigo(ctx, codigoProducto)
+	if err != nil {
+		return nil, fmt.Errorf("error verificando producto: %w", err)
+	}
+	if producto == nil {
+		return nil, fmt.Errorf("%w: %s", ErrProductoNoEncontrado, codigoProducto)
+	}
+
+	m, err := s.repo.UpsertMargenProducto(ctx, codigoProducto, margen)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Margen de stock publicable definido",
+		zap.String("operation", "set_margen_producto"),
+		zap.String("codigo_producto", codigoProducto),
+		zap.Int("margen", margen))
+
+	return m, nil
+}
+
+// SetMargenCategoria define el margen de seguridad de los productos de una categoría
+func (s *ecommerceService) SetMargenCategoria(ctx context.Context, idCategoria int, margen int) (*models.MargenStockPublicable, error) {
+	existe, err := s.repo.ExisteCategoria(ctx, idCategoria)
+	if err != nil {
+		return nil, err
+	}
+	if !existe {
+		return nil, fmt.Errorf("%w: %d", ErrCategoriaNoEncontrada, idCategoria)
+	}
+
+	m, err := s.repo.UpsertMargenCategoria(ctx, idCategoria, margen)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Margen de stock publicable definido",
+		zap.String("operation", "set_margen_categoria"),
+		zap.Int("id_categoria", idCategoria),
+		zap.Int("margen", margen))
+
+	return m, nil
+}
+
+// EliminarMargenProducto elimina el margen propio del producto (vuelve al de su categoría o al por defecto)
+func (s *ecommerceService) EliminarMargenProducto(ctx context.Context, codigoProducto string) error {
+	eliminado, err := s.repo.DeleteMargenProducto(ctx, codigoProducto)
+	if err != nil {
+		return err
+	}
+	if !eliminado {
+		return fmt.Errorf("%w: producto %s", ErrMargenNoEncontrado, codigoProducto)
+	}
+	return nil
+}
+
+// EliminarMargenCategoria elimina el margen de la categoría (sus productos vuelven al por defecto)
+func (s *ecommerceService) EliminarMargenCategoria(ctx context.Context, idCategoria int) error {
+	eliminado, err := s.repo.DeleteMargenCategoria(ctx, idCategoria)
+	if err != nil {
+		return err
+	}
+	if !eliminado {
+		return fmt.Errorf("%w: categoría %d", ErrMargenNoEncontrado, idCategoria)
+	}
+	return nil
+}
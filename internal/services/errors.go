@@ -0,0 +1,130 @@
+package services
+
+import "errors"
+
+// Errores de dominio de las operaciones de stock
+var (
+	ErrLocalNoEncontrado = errors.New("local no encontrado")
+	ErrLocalInactivo     = errors.New("local inactivo")
+
+	ErrProductoNoEncontrado  = errors.New("producto no encontrado")
+	ErrProductoDescontinuado = errors.New("producto descontinuado")
+
+	ErrDocumentoDuplicado = errors.New("el documento de respaldo ya fue registrado en otra entrada")
+	ErrDocumentoInvalido  = errors.New("documento de respaldo inválido")
+
+	ErrUnidadNoEncontrada  = errors.New("unidad de medida no encontrada")
+	ErrUnidadDuplicada     = errors.New("la unidad de medida ya existe")
+	ErrUnidadSinConversion = errors.New("el producto no tiene conversión definida para la unidad")
+
+	ErrStockOcupado = errors.New("otra operación está modificando el stock del producto, reintente")
+
+	ErrCicloPack       = errors.New("ciclo detectado en la composición de packs")
+	ErrProfundidadPack = errors.New("profundidad máxima de packs anidados excedida")
+
+	ErrPickingNoEncontrado      = errors.New("picking no encontrado")
+	ErrPickingEstadoInvalido    = errors.New("estado de picking no permite la operación")
+	ErrPickingExpirado          = errors.New("picking expirado")
+	ErrCantidadPickeadaInvalida = errors.New("cantidad pickeada inválida")
+
+	ErrGuiaNoEncontrada          = errors.New("guía de despacho no encontrada")
+	ErrGuiaEstadoInvalido        = errors.New("estado de la guía de despacho no permite la operación")
+	ErrCantidadRecibidaInvalida  = errors.New("cantidad recibida inválida")
+	ErrDiferenciaFueraTolerancia = errors.New("diferencia de recepción fuera de tolerancia")
+
+	ErrPlantillaNoEncontrada   = errors.New("plantilla de entrada no encontrada")
+	ErrAjustePlantillaInvalido = errors.New("ajuste de plantilla inválido")
+
+	ErrBotonRapidoNoEncontrado = errors.New("botón rápido no encontrado")
+	ErrGrillaBotonesInvalida   = errors.New("grilla de botones rápidos inválida")
+
+	ErrCategoriaNoEncontrada = errors.New("categoría no encontrada")
+	ErrMargenNoEncontrado    = errors.New("margen de stock publicable no encontrado")
+
+	ErrCanalesNoDefinidos = errors.New("el producto no tiene canales de venta definidos")
+
+	ErrBloqueoInvalido     = errors.New("bloqueo de producto inválido")
+	ErrBloqueoNoEncontrado = errors.New("el producto no tiene bloqueos vigentes")
+
+	ErrSolicitudNoEncontrada = errors.New("solicitud de aprobación no encontrada")
+	ErrSolicitudYaResuelta   = errors.New("solicitud de aprobación ya resuelta")
+	ErrAutoaprobacion        = errors.New("el solicitante no puede aprobar su propia solicitud")
+
+	ErrImagenNoEncontrada    = errors.New("el producto no tiene imagen")
+	ErrImagenInvalida        = errors.New("imagen inválida")
+	ErrImagenDemasiadoGrande = errors.New("imagen demasiado grande")
+
+	ErrTipoDocumentoInvalido = errors.New("tipo de documento sin folio")
+	ErrFolioRetroceso        = errors.New("el folio solo puede avanzar")
+
+	ErrRolNoAutorizado         = errors.New("rol no autorizado para la operación")
+	ErrVentaNoEncontrada       = errors.New("venta no encontrada")
+	ErrPlazoNotaCreditoVencido = errors.New("venta fuera del plazo para emitir nota de crédito")
+	ErrNotaCreditoExcedeVenta  = errors.New("la nota de crédito excede lo vendido")
+	ErrVentaYaAcreditada       = errors.New("la venta ya fue anulada por completo")
+	ErrNotaCreditoNoEncontrada = errors.New("nota de crédito no encontrada")
+
+	ErrConteoNoEncontrado        = errors.New("sesión de conteo no encontrada")
+	ErrConteoCompletado          = errors.New("la sesión de conteo ya está completada")
+	ErrProductoFueraDeConteo     = errors.New("el producto no está en la sesión de conteo")
+	ErrArchivoPDTInvalido        = errors.New("archivo de la terminal de inventario inválido")
+	ErrArchivoPDTDemasiadoGrande = errors.New("archivo de la terminal de inventario demasiado grande")
+
+	ErrProductoCriticoNoEncontrado = errors.New("el producto no está marcado como crítico en el local")
+
+	ErrReglaNoEncontrada  = errors.New("regla de operación no encontrada")
+	ErrReglaInvalida      = errors.New("regla de operación inválida")
+	ErrOperacionBloqueada = errors.New("operación bloqueada por una regla")
+
+	ErrPerfilERPNoEncontrado = errors.New("perfil de exportación ERP no encontrado")
+	ErrPerfilERPDuplicado    = errors.New("ya existe un perfil de exportación ERP con ese nombre")
+	ErrPerfilERPInvalido     = errors.New("perfil de exportación ERP inválido")
+
+	ErrBodegaNoEncontrada      = errors.New("bodega no encontrada")
+	ErrBodegaInvalida          = errors.New("bodega inválida")
+	ErrBodegaDuplicada         = errors.New("ya existe una bodega con ese nombre o una sala de venta en el local")
+	ErrStockBodegaInsuficiente = errors.New("stock insuficiente en la bodega")
+
+	ErrAjusteInvalido      = errors.New("ajuste de stock inválido")
+	ErrAjusteSinDiferencia = errors.New("la cantidad indicada es igual al stock actual")
+
+	ErrUbicacionNoEncontrada = errors.New("el producto no tiene ubicación en el local")
+	ErrUbicacionInvalida     = errors.New("ubicación inválida")
+
+	ErrColaVentasLlena       = errors.New("cola de ventas encoladas llena")
+	ErrReconciliacionEnCurso = errors.New("otra réplica está reconciliando las ventas encoladas")
+	ErrBaseDatosNoDisponible = errors.New("base de datos no disponible (modo degradado)")
+
+	ErrTransferenciaNoEncontrada = errors.New("transferencia no encontrada")
+	ErrTransferenciaAnulada      = errors.New("la transferencia ya fue anulada")
+	ErrTransferenciaInvalida     = errors.New("transferencia inválida")
+
+	ErrNotificacionNoEncontrada = errors.New("notificación no encontrada o expirada")
+	ErrNotificacionInvalida     = errors.New("tipo de notificación inválido")
+	ErrCallbackInvalido         = errors.New("callback_url inválida")
+
+	ErrJobNoEncontrado = errors.New("job no encontrado o expirado")
+	ErrJobInvalido     = errors.New("job inválido")
+
+	ErrCredencialesInvalidas = errors.New("usuario o contraseña incorrectos")
+	ErrUsuarioInactivo       = errors.New("el usuario está desactivado")
+	ErrRefreshTokenInvalido  = errors.New("refresh token inválido, expirado o ya usado")
+	ErrUsuarioNoEncontrado   = errors.New("usuario no encontrado")
+	ErrUsuarioDuplicado      = errors.New("ya existe un usuario con ese username")
+	ErrAutorizadorInvalido   = errors.New("autorización de supervisor inválida")
+
+	ErrMinimoEstacionalInvalido     = errors.New("mínimo estacional inválido")
+	ErrMinimoEstacionalSolapado     = errors.New("el rango se solapa con otro mínimo estacional del producto en el local")
+	ErrMinimoEstacionalNoEncontrado = errors.New("mínimo estacional no encontrado")
+
+	ErrAgrupacionInvalida = errors.New("agrupación inválida (use dia, producto, usuario o motivo)")
+
+	ErrTurnoNoEncontrado          = errors.New("turno no encontrado")
+	ErrTurnoInvalido              = errors.New("turno inválido")
+	ErrExcepcionTurnoNoEncontrada = errors.New("excepción de turno no encontrada o ya revocada")
+	ErrExcepcionTurnoInvalida     = errors.New("excepción de turno inválida")
+
+	ErrTomaInventarioNoEncontrada   = errors.New("toma de inventario no encontrada")
+	ErrTomaInventarioAbierta        = errors.New("el local ya tiene una toma de inventario abierta")
+	ErrTomaInventarioEstadoInvalido = errors.New("estado de la toma de inventario no permite la operación")
+)
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// escaneosFlushInterval cada cuánto se escriben en la BD los escaneos acumulados en memoria
+	escaneosFlushInterval = 10 * time.Second
+	// escaneosMaxPendientes códigos distintos retenidos entre escrituras; más allá los nuevos se
+	// descartan (una pistola que lee basura no debe hacer crecer la memoria sin límite)
+	escaneosMaxPendientes = 10000
+	// escaneosMaxLargoCodigo largo de codigo_barras en la tabla
+	escaneosMaxLargoCodigo = 100
+
+	escaneosTopDefault = 50
+	escaneosTopMax     = 500
+)
+
+// EscaneoNoEncontradoService registra los códigos de barras escaneados que no existen en el
+// catálogo y lista los más frecuentes para que catalogación los cree
+type EscaneoNoEncontradoService interface {
+	// Registrar cuenta un escaneo sin resultado; no bloquea al POS (se escribe por lotes)
+	Registrar(codigoBarras string, idLocal int)
+	GetTop(ctx context.Context, filter *models.EscaneoNoEncontradoFilter) ([]*models.EscaneoNoEncontrado, error)
+	// StartFlushWorker escribe los escaneos acumulados periódicamente y al apagar
+	StartFlushWorker(ctx context.Context)
+}
+
+// escaneoKey código y local de un escaneo acumulado
+type escaneoKey struct {
+	codigo  string
+	idLocal int
+}
+
+// escaneoNoEncontradoService implementa EscaneoNoEncontradoService
+type escaneoNoEncontradoService struct {
+	repo   repository.EscaneoNoEncontradoRepository
+	logger *zap.Logger
+
+	mu         sync.Mutex
+	pendientes map[escaneoKey]int
+	ultimaVez  time.Time
+}
+
+// NewEscaneoNoEncontradoService crea una nueva instancia del servicio
+func NewEscaneoNoEncontradoService(repo repository.EscaneoNoEncontradoRepository, logger *zap.Logger) EscaneoNoEncontradoService {
+	return &escaneoNoEncontradoService{
+		repo:       repo,
+		logger:     logger,
+		pendientes: make(map[escaneoKey]int),
+	}
+}
+
+// Registrar acumula el escaneo en memoria hasta la próxima escritura
+func (s *escaneoNoEncontradoService) Registrar(codigoBarras string, idLocal int) {
+	if codigoBarras == "" || len(codigoBarras) > escaneosMaxLargoCodigo {
+		return
+	}
+	key := escaneoKey{codigo: codigoBarras, idLocal: idLocal}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pendientes[key]; !ok && len(s.pendientes) >= escaneosMaxPendientes {
+		return
+	}
+	s.pendientes[key]++
+	s.ultimaVez = time.Now()
+}
+
+// GetTop lista los códigos desconocidos más escaneados
+func (s *escaneoNoEncontradoService) GetTop(ctx context.Context, filter *models.EscaneoNoEncontradoFilter) ([]*models.EscaneoNoEncontrado, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = escaneosTopDefault
+	}
+	if filter.Limit > escaneosTopMax {
+		filter.Limit = escaneosTopMax
+	}
+	return s.repo.GetTop(ctx, filter)
+}
+
+// StartFlushWorker inicia la escritura periódica hasta que se cancele ctx
+func (s *escaneoNoEncontradoService) StartFlushWorker(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(escaneosFlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				// Lo acumulado desde la última escritura no se pierde al apagar
+				flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				s.flush(flushCtx)
+				cancel()
+				return
+			case <-ticker.C:
+				s.flush(ctx)
+			}
+		}
+	}()
+}
+
+// flush escribe lo acumulado; si la BD falla se devuelve al acumulado para el próximo intento
+func (s *escaneoNoEncontradoService) flush(ctx context.Context) {
+	s.mu.Lock()
+	lote, ultimaVez := s.pendientes, s.ultimaVez
+	if len(lote) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	s.pendientes = make(map[escaneoKey]int, len(lote))
+	s.mu.Unlock()
+
+	codigos := make([]string, 0, len(lote))
+	locales := make([]int, 0, len(lote))
+	veces := make([]int, 0, len(lote))
+	for key, n := range lote {
+		codigos = append(codigos, key.codigo)
+		locales = append(locales, key.idLocal)
+		veces = append(veces, n)
+	}
+
+	if err := s.repo.Registrar(ctx, codigos, locales, veces, ultimaVez); err != nil {
+		s.logger.Warn("Error registrando escaneos no encontrados, se reintentará",
+			zap.Int("codigos", len(lote)),
+			zap.Error(err))
+
+		s.mu.Lock()
+		for key, n := range lote {
+			if _, ok := s.pendientes[key]; ok || len(s.pendientes) < escaneosMaxPendientes {
+				s.pendientes[key] += n
+			}
+		}
+		s.mu.Unlock()
+	}
+}
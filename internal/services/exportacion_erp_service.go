@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// formatoFechaERP traduce los formatos de fecha de los perfiles (YYYYMMDD) al layout de Go
+var formatoFechaERP = strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02", "HH", "15", "mm", "04", "ss", "05")
+
+// ExportacionERPService administra los perfiles de mapeo y exporta los movimientos al layout del ERP
+type ExportacionERPService interface {
+	CreatePerfil(ctx context.Context, req *models.PerfilExportacionERPRequest) (*models.PerfilExportacionERP, error)
+	UpdatePerfil(ctx context.Context, id int, req *models.PerfilExportacionERPRequest) (*models.PerfilExportacionERP, error)
+	DeletePerfil(ctx context.Context, id int) error
+	GetPerfil(ctx context.Context, nombre string) (*models.PerfilExportacionERP, error)
+	GetPerfiles(ctx context.Context) ([]*models.PerfilExportacionERP, error)
+	// Exportar escribe en w los movimientos del período con el layout del perfil; retorna las filas escritas
+	Exportar(ctx context.Context, perfil *models.PerfilExportacionERP, filter *models.ReporteFilter, w io.Writer) (int, error)
+}
+
+// exportacionERPService implementa ExportacionERPService
+type exportacionERPService struct {
+	repo   repository.ExportacionERPRepository
+	logger *zap.Logger
+}
+
+// NewExportacionERPService crea una nueva instancia del servicio
+func NewExportacionERPService(repo repository.ExportacionERPRepository, logger *zap.Logger) ExportacionERPService {
+	return &exportacionERPService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// CreatePerfil valida y registra un perfil nuevo
+func (s *exportacionERPService) CreatePerfil(ctx context.Context, req *models.PerfilExportacionERPRequest) (*models.PerfilExportacionERP, error) {
+	perfil, err := nuevoPerfilERP(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verificarNombreLibre(ctx, perfil.Nombre, 0); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreatePerfil(ctx, perfil); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Perfil de exportación ERP creado",
+		zap.String("operation", "crear_perfil_erp"),
+		zap.Int("id_perfil", perfil.ID),
+		zap.String("nombre", perfil.Nombre))
+
+	return perfil, nil
+}
+
+// UpdatePerfil reemplaza el perfil
+func (s *exportacionERPService) UpdatePerfil(ctx context.Context, id int, req *models.PerfilExportacionERPRequest) (*models.PerfilExportacionERP, error) {
+	perfil, err := nuevoPerfilERP(req)
+	if err != nil {
+		return nil, err
+	}
+	perfil.ID = id
+	if err := s.verificarNombreLibre(ctx, perfil.Nombre, id); err != nil {
+		return nil, err
+	}
+
+	actualizado, err := s.repo.UpdatePerfil(ctx, perfil)
+	if err != nil {
+		return nil, err
+	}
+	if !actualizado {
+		return nil, fmt.Errorf("%w: %d", ErrPerfilERPNoEncontrado, id)
+	}
+	return perfil, nil
+}
+
+// DeletePerfil elimina el perfil
+func (s *exportacionERPService) DeletePerfil(ctx context.Context, id int) error {
+	eliminado, err := s.repo.DeletePerfil(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !eliminado {
+		return fmt.Errorf("%w: %d", ErrPerfilERPNoEncontrado, id)
+	}
+	return nil
+}
+
+// GetPerfil obtiene un perfil por nombre
+func (s *exportacionERPService) GetPerfil(ctx context.Context, nombre string) (*models.PerfilExportacionERP, error) {
+	perfil, err := s.repo.GetPerfilByNombre(ctx, nombre)
+	if err != nil {
+		return nil, err
+	}
+	if perfil == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPerfilERPNoEncontrado, nombre)
+	}
+	return perfil, nil
+}
+
+// GetPerfiles lista los perfiles
+func (s *exportacionERPService) GetPerfiles(ctx context.Context) ([]*models.PerfilExportacionERP, error) {
+	return s.repo.GetPerfiles(ctx)
+}
+
+// Exportar recorre los movimientos del período y escribe una fila por movimiento
+func (s *exportacionERPService) Exportar(ctx context.Context, perfil *models.PerfilExportacionERP, filter *models.ReporteFilter, w io.Writer) (int, error) {
+	writer := csv.NewWriter(w)
+	writer.Comma, _ = utf8.DecodeRuneInString(perfil.Delimitador)
+	layout := formatoFechaERP.Replace(perfil.FormatoFecha)
+
+	if perfil.IncluirEncabezado {
+		encabezados := make([]string, len(perfil.Columnas))
+		for i, columna := range perfil.Columnas {
+			encabezados[i] = columna.Encabezado
+		}
+		if err := writer.Write(encabezados); err != nil {
+			return 0, fmt.Errorf("error escribiendo encabezado: %w", err)
+		}
+	}
+
+	filas := 0
+	fila := make([]string, len(perfil.Columnas))
+	err := s.repo.RecorrerMovimientos(ctx, filter.IDLocal, filter.Desde, filter.Hasta, func(movimiento *models.MovimientoERP) error {
+		for i, columna := range perfil.Columnas {
+			fila[i] = valorColumnaERP(perfil, columna, movimiento, layout)
+		}
+		if err := writer.Write(fila); err != nil {
+			return fmt.Errorf("error escribiendo movimiento %d: %w", movimiento.ID, err)
+		}
+		filas++
+		return nil
+	})
+	if err != nil {
+		return filas, err
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return filas, fmt.Errorf("error escribiendo exportación: %w", err)
+	}
+
+	s.logger.Info("Movimientos exportados al layout ERP",
+		zap.String("operation", "exportar_movimientos_erp"),
+		zap.String("perfil", perfil.Nombre),
+		zap.Time("desde", filter.Desde),
+		zap.Time("hasta", filter.Hasta),
+		zap.Int("filas", filas))
+
+	return filas, nil
+}
+
+// verificarNombreLibre rechaza un nombre ya usado por otro perfil
+func (s *exportacionERPService) verificarNombreLibre(ctx context.Context, nombre string, id int) error {
+	existente, err := s.repo.GetPerfilByNombre(ctx, nombre)
+	if err != nil {
+		return err
+	}
+	if existente != nil && existente.ID != id {
+		return fmt.Errorf("%w: %s", ErrPerfilERPDuplicado, nombre)
+	}
+	return nil
+}
+
+// nuevoPerfilERP arma el perfil desde el request con los valores por defecto
+func nuevoPerfilERP(req *models.PerfilExportacionERPRequest) (*models.PerfilExportacionERP, error) {
+	perfil := &models.PerfilExportacionERP{
+		Nombre:             strings.TrimSpace(req.Nombre),
+		Descripcion:        req.Descripcion,
+		Columnas:           req.Columnas,
+		CodigosTransaccion: req.CodigosTransaccion,
+		Delimitador:        ";",
+		IncluirEncabezado:  req.IncluirEncabezado == nil || *req.IncluirEncabezado,
+		FormatoFecha:       "YYYYMMDD",
+	}
+	if perfil.CodigosTransaccion == nil {
+		perfil.CodigosTransaccion = map[string]string{}
+	}
+
+	switch req.Delimitador {
+	case "":
+	case ";", ",", "|":
+		perfil.Delimitador = req.Delimitador
+	case "tab":
+		perfil.Delimitador = "\t"
+	default:
+		return nil, fmt.Errorf("%w: delimitador debe ser ;, ,, | o tab", ErrPerfilERPInvalido)
+	}
+
+	if req.FormatoFecha != "" {
+		if !strings.Contains(req.FormatoFecha, "DD") {
+			return nil, fmt.Errorf("%w: formato_fecha debe incluir al menos DD", ErrPerfilERPInvalido)
+		}
+		perfil.FormatoFecha = req.FormatoFecha
+	}
+
+	return perfil, nil
+}
+
+// valorColumnaERP obtiene el valor de una columna del perfil para el movimiento
+func valorColumnaERP(perfil *models.PerfilExportacionERP, columna models.ColumnaERP, movimiento *models.MovimientoERP, layout string) string {
+	switch columna.Campo {
+	case models.CampoERPIDMovimiento:
+		return strconv.Itoa(movimiento.ID)
+	case models.CampoERPFecha:
+		return movimiento.CreatedAt.Format(layout)
+	case models.CampoERPHora:
+		return movimiento.CreatedAt.Format("15:04:05")
+	case models.CampoERPCodigoProducto:
+		return movimiento.CodigoProducto
+	case models.CampoERPNombreProducto:
+		return valorOVacio(movimiento.NombreProducto)
+	case models.CampoERPTipoItem:
+		return movimiento.TipoItem
+	case models.CampoERPTipoMovimiento:
+		return movimiento.TipoMovimiento
+	case models.CampoERPCodigoTransaccion:
+		if codigo, ok := perfil.CodigosTransaccion[movimiento.TipoMovimiento+":"+movimiento.Motivo]; ok {
+			return codigo
+		}
+		return perfil.CodigosTransaccion[movimiento.TipoMovimiento]
+	case models.CampoERPCantidad:
+		return strconv.Itoa(movimiento.Cantidad)
+	case models.CampoERPCantidadConSigno:
+		if movimiento.TipoMovimiento == "salida" || movimiento.TipoMovimiento == models.TipoMovimientoTransferenciaSalida {
+			return strconv.Itoa(-movimiento.Cantidad)
+		}
+		return strconv.Itoa(movimiento.Cantidad)
+	case models.CampoERPMotivo:
+		return movimiento.Motivo
+	case models.CampoERPIDLocal:
+		return strconv.Itoa(movimiento.IDLocal)
+	case models.CampoERPIDUsuario:
+		return strconv.Itoa(movimiento.IDUsuario)
+	case models.CampoERPDocumentoTipo:
+		return valorOVacio(movimiento.DocumentoTipo)
+	case models.CampoERPDocumentoNumero:
+		return valorOVacio(movimiento.DocumentoNumero)
+	case models.CampoERPDocumentoFecha:
+		if movimiento.DocumentoFecha == nil {
+			return ""
+		}
+		return movimiento.DocumentoFecha.Format(layout)
+	case models.CampoERPIDOperacion:
+		return valorOVacio(movimiento.IDOperacion)
+	case models.CampoERPObservaciones:
+		return movimiento.Observaciones
+	case models.CampoERPConstante:
+		return columna.Valor
+	default:
+		return ""
+	}
+}
+
+// valorOVacio retorna el texto o vacío si es nil
+func valorOVacio(valor *string) string {
+	if valor == nil {
+		return ""
+	}
+	return *valor
+}
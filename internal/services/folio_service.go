@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// FolioService consulta y ajusta los folios correlativos de documentos por local
+// Los repositories de cada documento toman el folio en la transacción que lo crea
+type FolioService interface {
+	GetFolios(ctx context.Context, idLocal *int) ([]*models.Folio, error)
+	AvanzarFolio(ctx context.Context, req *models.AvanzarFolioRequest) error
+}
+
+// folioService implementa FolioService
+type folioService struct {
+	repo   repository.FolioRepository
+	logger *zap.Logger
+}
+
+// NewFolioService crea una nueva instancia del servicio
+func NewFolioService(repo repository.FolioRepository, logger *zap.Logger) FolioService {
+	return &folioService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// GetFolios obtiene el último folio emitido de cada tipo de documento
+func (s *folioService) GetFolios(ctx context.Context, idLocal *int) ([]*models.Folio, error) {
+	return s.repo.GetFolios(ctx, idLocal)
+}
+
+// AvanzarFolio fija el último folio emitido; rechaza retroceder o repetir el actual
+func (s *folioService) AvanzarFolio(ctx context.Context, req *models.AvanzarFolioRequest) error {
+	if !models.EsTipoDocumento(req.TipoDocumento) {
+		return fmt.Errorf("%w: %s", ErrTipoDocumentoInvalido, req.TipoDocumento)
+	}
+
+	avanzado, err := s.repo.AvanzarFolio(ctx, req.IDLocal, req.TipoDocumento, req.UltimoFolio)
+	if err != nil {
+		return err
+	}
+	if !avanzado {
+		return fmt.Errorf("%w: %s local %d ya emitió el folio %d", ErrFolioRetroceso, req.TipoDocumento, req.IDLocal, req.UltimoFolio)
+	}
+
+	s.logger.Warn("Folio de documentos avanzado manualmente",
+		zap.String("operation", "avanzar_folio"),
+		zap.Int("id_local", req.IDLocal),
+		zap.String("tipo_documento", req.TipoDocumento),
+		zap.Int64("ultimo_folio", req.UltimoFolio))
+
+	return nil
+}
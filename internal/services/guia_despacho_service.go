@@ -0,0 +1,409 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+	"stock-service/internal/uuid"
+
+	"go.uber.org/zap"
+)
+
+// guiaEstadoEmitiendo estado transitorio mientras se descuenta el stock en origen
+const guiaEstadoEmitiendo = "emitiendo"
+
+// GuiaDespachoService maneja las transferencias entre locales documentadas con guía de despacho
+type GuiaDespachoService interface {
+	EmitirGuia(ctx context.Context, req *models.EmitirGuiaRequest) (*models.GuiaDespacho, error)
+	GetGuia(ctx context.Context, id int) (*models.GuiaDespacho, error)
+	GetGuiaImprimible(ctx context.Context, id int) (*models.GuiaDespachoImprimible, error)
+	DespacharGuia(ctx context.Context, id int) (*models.GuiaDespacho, error)
+	RecibirGuia(ctx context.Context, id int, req *models.RecibirGuiaRequest) (*models.GuiaDespacho, error)
+	GetGuiasEnTransito(ctx context.Context, filter *models.GuiaEnTransitoFilter) ([]*models.GuiaEnTransito, error)
+	GetReporteMermas(ctx context.Context, filter *models.ReporteFilter) (*models.ReporteMermasTransporte, error)
+}
+
+// guiaDespachoService implementa GuiaDespachoService
+type guiaDespachoService struct {
+	repo         repository.GuiaDespachoRepository
+	stockRepo    repository.StockRepository
+	stockService StockService
+	config       config.ReceptionConfig
+	logger       *zap.Logger
+}
+
+// NewGuiaDespachoService crea una nueva instancia del servicio
+func NewGuiaDespachoService(repo repository.GuiaDespachoRepository, stockRepo repository.StockRepository, stockService StockService, cfg config.ReceptionConfig, logger *zap.Logger) GuiaDespachoService {
+	return &guiaDespachoService{
+		repo:         repo,
+		stockRepo:    stockRepo,
+		stockService: stockService,
+		config:       cfg,
+		logger:       logger,
+	}
+}
+
+// EmitirGuia crea la guía con su correlativo y descuenta el stock en el local origen
+// Las salidas se aplican todas o ninguna; si fallan la guía queda anulada
+func (s *guiaDespachoService) EmitirGuia(ctx context.Context, req *models.EmitirGuiaRequest) (*models.GuiaDespacho, error) {
+	logger := s.logger.With(
+		zap.String("operation", "emitir_guia"),
+		zap.Int("id_local_origen", req.IDLocalOrigen),
+		zap.Int("id_local_destino", req.IDLocalDestino),
+		zap.Int("cantidad_productos", len(req.Productos)),
+	)
+
+	if err := s.verificarLocal(ctx, req.IDLocalOrigen); err != nil {
+		return nil, fmt.Errorf("local origen: %w", err)
+	}
+	if err := s.verificarLocal(ctx, req.IDLocalDestino); err != nil {
+		return nil, fmt.Errorf("local destino: %w", err)
+	}
+
+	// Agrupar por producto (un mismo código puede venir en varias líneas)
+	items := []*models.GuiaDespachoItem{}
+	porCodigo := make(map[string]*models.GuiaDespachoItem)
+	for _, producto := range req.Productos {
+		// La guía se emite en unidad base
+		cantidad, err := cantidadEnUnidadBase(ctx, s.stockRepo, producto.CodigoProducto, producto.Unidad, producto.Cantidad)
+		if err != nil {
+			return nil, err
+		}
+		if item, ok := porCodigo[producto.CodigoProducto]; ok {
+			item.CantidadEnviada += cantidad
+			continue
+		}
+		descripcion, err := s.descripcionItem(ctx, producto.CodigoProducto, producto.TipoItem)
+		if err != nil {
+			return nil, err
+		}
+		item := &models.GuiaDespachoItem{
+			CodigoProducto:  producto.CodigoProducto,
+			TipoItem:        producto.TipoItem,
+			Descripcion:     descripcion,
+			CantidadEnviada: cantidad,
+		}
+		porCodigo[producto.CodigoProducto] = item
+		items = append(items, item)
+	}
+
+	// La salida en origen y la recepción en destino forman una misma operación
+	idOperacion := uuid.New()
+	guia := &models.GuiaDespacho{
+		IDOperacion:    &idOperacion,
+		IDLocalOrigen:  req.IDLocalOrigen,
+		IDLocalDestino: req.IDLocalDestino,
+		Estado:         guiaEstadoEmitiendo,
+		Observaciones:  req.Observaciones,
+		IDUsuario:      req.IDUsuario,
+		Items:          items,
+	}
+
+	if err := s.repo.CreateGuia(ctx, guia); err != nil {
+		logger.Error("Error creando guía de despacho", zap.Error(err))
+		return nil, err
+	}
+
+	salidas := make([]*models.SalidaStockRequest, 0, len(items))
+	for _, item := range items {
+		salidas = append(salidas, &models.SalidaStockRequest{
+			CodigoProducto: item.CodigoProducto,
+			TipoItem:       item.TipoItem,
+			Cantidad:       item.CantidadEnviada,
+			Motivo:         fmt.Sprintf("Transferencia a local %d", guia.IDLocalDestino),
+			IDLocal:        guia.IDLocalOrigen,
+			Observaciones:  fmt.Sprintf("Guía de despacho: %s", guia.Numero),
+			IDUsuario:      req.IDUsuario,
+			IDOperacion:    idOperacion,
+		})
+	}
+
+	if _, err := s.stockService.SalidaStockLote(ctx, salidas); err != nil {
+		logger.Error("Error descontando stock de la guía", zap.String("numero", guia.Numero), zap.Error(err))
+		// El correlativo ya se asignó: la guía queda anulada para no dejar huecos en la numeración
+		if _, errEstado := s.repo.CambiarEstado(context.Background(), guia.ID, guiaEstadoEmitiendo, models.GuiaEstadoAnulada); errEstado != nil {
+			logger.Error("Error anulando guía de despacho", zap.Error(errEstado))
+		}
+		return nil, err
+	}
+
+	if _, err := s.repo.CambiarEstado(ctx, guia.ID, guiaEstadoEmitiendo, models.GuiaEstadoEmitida); err != nil {
+		// El stock ya se descontó: no anular para no perder el registro de la transferencia
+		logger.Error("Error marcando guía como emitida", zap.String("numero", guia.Numero), zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("Guía de despacho emitida",
+		zap.Int("id_guia", guia.ID),
+		zap.String("numero", guia.Numero))
+
+	return s.GetGuia(ctx, guia.ID)
+}
+
+// GetGuia obtiene una guía con sus ítems
+func (s *guiaDespachoService) GetGuia(ctx context.Context, id int) (*models.GuiaDespacho, error) {
+	guia, err := s.repo.GetGuiaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if guia == nil {
+		return nil, fmt.Errorf("%w: %d", ErrGuiaNoEncontrada, id)
+	}
+
+	return guia, nil
+}
+
+// GetGuiaImprimible obtiene la guía junto con los datos de los locales para imprimirla
+func (s *guiaDespachoService) GetGuiaImprimible(ctx context.Context, id int) (*models.GuiaDespachoImprimible, error) {
+	guia, err := s.GetGuia(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	origen, err := s.stockRepo.GetLocalByID(ctx, guia.IDLocalOrigen)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo local origen: %w", err)
+	}
+	destino, err := s.stockRepo.GetLocalByID(ctx, guia.IDLocalDestino)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo local destino: %w", err)
+	}
+
+	return &models.GuiaDespachoImprimible{
+		GuiaDespacho: guia,
+		LocalOrigen:  origen,
+		LocalDestino: destino,
+	}, nil
+}
+
+// DespacharGuia marca la guía como en tránsito hacia el local destino
+func (s *guiaDespachoService) DespacharGuia(ctx context.Context, id int) (*models.GuiaDespacho, error) {
+	despachada, err := s.repo.CambiarEstado(ctx, id, models.GuiaEstadoEmitida, models.GuiaEstadoEnTransito)
+	if err != nil {
+		return nil, err
+	}
+	if !despachada {
+		return nil, s.errorEstado(ctx, id)
+	}
+
+	s.logger.Info("Guía de despacho en tránsito",
+		zap.String("operation", "despachar_guia"),
+		zap.Int("id_guia", id))
+
+	return s.GetGuia(ctx, id)
+}
+
+// RecibirGuia ingresa el stock en el local destino con las cantidades realmente recibidas
+// Se ingresa lo enviado y cada diferencia queda como un movimiento propio (merma o sobrante de
+// transporte), todo en una sola transacción; si falla la guía vuelve a 'en_transito'
+func (s *guiaDespachoService) RecibirGuia(ctx context.Context, id int, req *models.RecibirGuiaRequest) (*models.GuiaDespacho, error) {
+	logger := s.logger.With(
+		zap.String("operation", "recibir_guia"),
+		zap.Int("id_guia", id),
+	)
+
+	guia, err := s.GetGuia(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cantidades recibidas: por defecto lo enviado
+	recibidos := make(map[string]int, len(guia.Items))
+	for _, item := range guia.Items {
+		recibidos[item.CodigoProducto] = item.CantidadEnviada
+	}
+	for _, producto := range req.Productos {
+		if _, ok := recibidos[producto.CodigoProducto]; !ok {
+			return nil, fmt.Errorf("%w: %s no pertenece a la guía", ErrCantidadRecibidaInvalida, producto.CodigoProducto)
+		}
+		recibidos[producto.CodigoProducto] = producto.CantidadRecibida
+	}
+
+	// Las diferencias sobre la tolerancia deben confirmarse explícitamente
+	if !req.ConfirmarDiferencias {
+		for _, item := range guia.Items {
+			if !s.dentroDeTolerancia(item.CantidadEnviada, recibidos[item.CodigoProducto]) {
+				return nil, fmt.Errorf("%w: %s enviado %d, recibido %d (tolerancia %d%%, use confirmar_diferencias para aceptarla)",
+					ErrDiferenciaFueraTolerancia, item.CodigoProducto, item.CantidadEnviada, recibidos[item.CodigoProducto], s.config.TolerancePercent)
+			}
+		}
+	}
+
+	// Tomar la guía para que la recepción no se confirme dos veces
+	tomada, err := s.repo.CambiarEstado(ctx, id, models.GuiaEstadoEnTransito, models.GuiaEstadoRecibiendo)
+	if err != nil {
+		return nil, err
+	}
+	if !tomada {
+		return nil, s.errorEstado(ctx, id)
+	}
+
+	// La guía respalda las entradas en destino (y evita ingresarla dos veces)
+	documento := &models.DocumentoRespaldo{
+		Tipo:   models.DocumentoGuiaDespacho,
+		Numero: guia.Numero,
+		Fecha:  guia.CreatedAt.Format("2006-01-02"),
+	}
+
+	// Guías emitidas antes de registrar la operación: la recepción forma una operación propia
+	idOperacion := ""
+	if guia.IDOperacion != nil {
+		idOperacion = *guia.IDOperacion
+	}
+
+	entradas := []*models.EntradaStockRequest{}
+	salidas := []*models.SalidaStockRequest{}
+	diferencias := 0
+	for _, item := range guia.Items {
+		recibida := recibidos[item.CodigoProducto]
+		entradas = append(entradas, &models.EntradaStockRequest{
+			CodigoProducto: item.CodigoProducto,
+			TipoItem:       item.TipoItem,
+			Cantidad:       item.CantidadEnviada,
+			Motivo:         fmt.Sprintf("Transferencia desde local %d", guia.IDLocalOrigen),
+			IDLocal:        guia.IDLocalDestino,
+			Observaciones:  req.Observaciones,
+			IDUsuario:      req.IDUsuario,
+			IDOperacion:    idOperacion,
+			Documento:      documento,
+		})
+
+		if recibida == item.CantidadEnviada {
+			continue
+		}
+		diferencias++
+		observaciones := fmt.Sprintf("Guía de despacho: %s (enviado %d, recibido %d)", guia.Numero, item.CantidadEnviada, recibida)
+		if recibida < item.CantidadEnviada {
+			salidas = append(salidas, &models.SalidaStockRequest{
+				CodigoProducto: item.CodigoProducto,
+				TipoItem:       item.TipoItem,
+				Cantidad:       item.CantidadEnviada - recibida,
+				Motivo:         models.MotivoMermaTransporte,
+				IDLocal:        guia.IDLocalDestino,
+				Observaciones:  observaciones,
+				IDUsuario:      req.IDUsuario,
+				IDOperacion:    idOperacion,
+			})
+			continue
+		}
+		entradas = append(entradas, &models.EntradaStockRequest{
+			CodigoProducto: item.CodigoProducto,
+			TipoItem:       item.TipoItem,
+			Cantidad:       recibida - item.CantidadEnviada,
+			Motivo:         models.MotivoSobranteTransporte,
+			IDLocal:        guia.IDLocalDestino,
+			Observaciones:  observaciones,
+			IDUsuario:      req.IDUsuario,
+			IDOperacion:    idOperacion,
+			Documento:      documento,
+		})
+	}
+
+	if err := s.stockService.MovimientoStockLote(ctx, entradas, salidas); err != nil {
+		logger.Error("Error ingresando stock de la guía", zap.Error(err))
+		// Liberar la guía para poder reintentar (con un contexto propio por si el original expiró)
+		if _, errEstado := s.repo.CambiarEstado(context.Background(), id, models.GuiaEstadoRecibiendo, models.GuiaEstadoEnTransito); errEstado != nil {
+			logger.Error("Error liberando guía de despacho", zap.Error(errEstado))
+		}
+		return nil, err
+	}
+
+	if err := s.repo.ConfirmarRecepcion(ctx, id, recibidos, req.IDUsuario, req.Observaciones); err != nil {
+		// El stock ya se ingresó: no volver a 'en_transito' para no duplicar la entrada
+		logger.Error("Error marcando guía como recibida", zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("Guía de despacho recibida",
+		zap.String("numero", guia.Numero),
+		zap.Int("items", len(guia.Items)),
+		zap.Int("items_con_diferencia", diferencias))
+
+	return s.GetGuia(ctx, id)
+}
+
+// GetGuiasEnTransito obtiene el reporte de guías despachadas pendientes de recepción
+func (s *guiaDespachoService) GetGuiasEnTransito(ctx context.Context, filter *models.GuiaEnTransitoFilter) ([]*models.GuiaEnTransito, error) {
+	return s.repo.GetGuiasEnTransito(ctx, filter)
+}
+
+// GetReporteMermas agrega las diferencias de las guías recibidas en el período
+func (s *guiaDespachoService) GetReporteMermas(ctx context.Context, filter *models.ReporteFilter) (*models.ReporteMermasTransporte, error) {
+	items, err := s.repo.GetMermasTransporte(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	reporte := &models.ReporteMermasTransporte{
+		Desde: filter.Desde,
+		Hasta: filter.Hasta,
+		Items: items,
+	}
+	for _, item := range items {
+		reporte.TotalEnviado += item.CantidadEnviada
+		reporte.TotalRecibido += item.CantidadRecibida
+		reporte.TotalMerma += item.Merma
+		reporte.TotalSobrante += item.Sobrante
+	}
+
+	return reporte, nil
+}
+
+// dentroDeTolerancia indica si la diferencia entre lo enviado y lo recibido es aceptable sin confirmación
+func (s *guiaDespachoService) dentroDeTolerancia(enviada, recibida int) bool {
+	diferencia := enviada - recibida
+	if diferencia < 0 {
+		diferencia = -diferencia
+	}
+	return diferencia*100 <= enviada*s.config.TolerancePercent
+}
+
+// errorEstado construye el error para una transición de estado rechazada
+func (s *guiaDespachoService) errorEstado(ctx context.Context, id int) error {
+	guia, err := s.GetGuia(ctx, id)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: guía %s en estado %s", ErrGuiaEstadoInvalido, guia.Numero, guia.Estado)
+}
+
+// descripcionItem obtiene el nombre del producto o pack para imprimirlo en la guía
+func (s *guiaDespachoService) descripcionItem(ctx context.Context, codigo, tipoItem string) (string, error) {
+	if tipoItem == "pack" {
+		pack, err := s.stockRepo.GetPackByCodigo(ctx, codigo)
+		if err != nil {
+			return "", fmt.Errorf("error obteniendo pack %s: %w", codigo, err)
+		}
+		if pack == nil {
+			return "", fmt.Errorf("%w: %s", ErrProductoNoEncontrado, codigo)
+		}
+		return pack.NombrePack, nil
+	}
+
+	producto, err := s.stockRepo.GetProductoByCodigo(ctx, codigo)
+	if err != nil {
+		return "", fmt.Errorf("error obteniendo producto %s: %w", codigo, err)
+	}
+	if producto == nil {
+		return "", fmt.Errorf("%w: %s", ErrProductoNoEncontrado, codigo)
+	}
+	return producto.Nombre, nil
+}
+
+// verificarLocal verifica que el local exista y esté activo
+func (s *guiaDespachoService) verificarLocal(ctx context.Context, idLocal int) error {
+	local, err := s.stockRepo.GetLocalByID(ctx, idLocal)
+	if err != nil {
+		return fmt.Errorf("error verificando local: %w", err)
+	}
+	if local == nil {
+		return fmt.Errorf("%w: %d", ErrLocalNoEncontrado, idLocal)
+	}
+	if !local.Activo {
+		return fmt.Errorf("%w: %d", ErrLocalInactivo, idLocal)
+	}
+	return nil
+}
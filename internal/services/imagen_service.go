@@ -0,0 +1,380 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	// Decoders soportados para las imágenes subidas
+	_ "image/gif"
+	_ "image/png"
+
+	"stock-service/internal/cache"
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+	"stock-service/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+// formatosImagen tipos aceptados y su extensión en el almacenamiento
+var formatosImagen = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// maxPixelesImagen evita decodificar imágenes enormes (un JPEG chico puede tener
+// dimensiones que ocupen gigas en memoria al decodificarse)
+const maxPixelesImagen = 40_000_000
+
+// ImagenService gestiona las imágenes de productos y sus miniaturas
+type ImagenService interface {
+	Subir(ctx context.Context, codigo string, data []byte, idUsuario int) (*models.ImagenProducto, error)
+	Asociar(ctx context.Context, codigo string, req *models.AsociarImagenRequest) (*models.ImagenProducto, error)
+	Eliminar(ctx context.Context, codigo string) error
+	GetImagen(ctx context.Context, codigo string) (*models.ImagenProducto, error)
+	// Abrir retorna el contenido de la imagen (o de su miniatura) y su content type
+	// Para imágenes externas el reader es nil y se debe redirigir a la URL
+	Abrir(ctx context.Context, codigo string, miniatura bool) (*models.ImagenProducto, io.ReadCloser, string, error)
+}
+
+// imagenService implementa ImagenService
+type imagenService struct {
+	repo         repository.ImagenRepository
+	storage      storage.ImageStorage
+	productCache *cache.ProductCache
+	config       config.ImagesConfig
+	logger       *zap.Logger
+}
+
+// NewImagenService crea una nueva instancia del servicio
+func NewImagenService(repo repository.ImagenRepository, imageStorage storage.ImageStorage, productCache *cache.ProductCache, cfg config.ImagesConfig, logger *zap.Logger) ImagenService {
+	return &imagenService{
+		repo:         repo,
+		storage:      imageStorage,
+		productCache: productCache,
+		config:       cfg,
+		logger:       logger,
+	}
+}
+
+// Subir guarda la imagen original y su miniatura, reemplazando la anterior del producto
+// Las claves llevan el hash del contenido, así las URLs cambian con cada imagen y se
+// pueden cachear sin expiración en el cliente
+func (s *imagenService) Subir(ctx context.Context, codigo string, data []byte, idUsuario int) (*models.ImagenProducto, error) {
+	logger := s.logger.With(
+		zap.String("operation", "subir_imagen"),
+		zap.String("codigo", codigo),
+	)
+
+	if s.config.MaxBytes > 0 && int64(len(data)) > s.config.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes (máximo %d)", ErrImagenDemasiadoGrande, len(data), s.config.MaxBytes)
+	}
+
+	if err := s.verificarProducto(ctx, codigo); err != nil {
+		return nil, err
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := formatosImagen[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: formato %s no soportado (use JPEG, PNG o GIF)", ErrImagenInvalida, contentType)
+	}
+
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImagenInvalida, err)
+	}
+	if imgConfig.Width*imgConfig.Height > maxPixelesImagen {
+		return nil, fmt.Errorf("%w: %dx%d píxeles", ErrImagenDemasiadoGrande, imgConfig.Width, imgConfig.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImagenInvalida, err)
+	}
+
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, generarMiniatura(img, s.config.ThumbnailSize), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, fmt.Errorf("error generando miniatura: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	version := hash[:16]
+
+	prefijo := "productos/" + url.PathEscape(codigo) + "/" + version
+	storageKey := prefijo + "." + ext
+	thumbnailKey := prefijo + "_thumb.jpg"
+
+	if err := s.storage.Save(ctx, storageKey, bytes.NewReader(data), contentType); err != nil {
+		return nil, fmt.Errorf("error guardando imagen: %w", err)
+	}
+	if err := s.storage.Save(ctx, thumbnailKey, &thumb, "image/jpeg"); err != nil {
+		return nil, fmt.Errorf("error guardando miniatura: %w", err)
+	}
+
+	bounds := img.Bounds()
+	ancho, alto := bounds.Dx(), bounds.Dy()
+	tamano := int64(len(data))
+
+	imagen := &models.ImagenProducto{
+		CodigoProducto: codigo,
+		StorageKey:     &storageKey,
+		ThumbnailKey:   &thumbnailKey,
+		ContentType:    &contentType,
+		Hash:           &hash,
+		Ancho:          &ancho,
+		Alto:           &alto,
+		TamanoBytes:    &tamano,
+		URL:            s.urlPublica(codigo, storageKey, false, version),
+		URLMiniatura:   s.urlPublica(codigo, thumbnailKey, true, version),
+		IDUsuario:      idUsuario,
+	}
+
+	if err := s.reemplazar(ctx, logger, imagen); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Imagen de producto subida",
+		zap.String("content_type", contentType),
+		zap.Int64("tamano_bytes", tamano),
+		zap.Int("ancho", ancho),
+		zap.Int("alto", alto))
+
+	return imagen, nil
+}
+
+// Asociar asocia al producto una imagen publicada en otra URL (sin copiarla)
+func (s *imagenService) Asociar(ctx context.Context, codigo string, req *models.AsociarImagenRequest) (*models.ImagenProducto, error) {
+	logger := s.logger.With(
+		zap.String("operation", "asociar_imagen"),
+		zap.String("codigo", codigo),
+	)
+
+	if err := s.verificarProducto(ctx, codigo); err != nil {
+		return nil, err
+	}
+
+	miniatura := req.URLMiniatura
+	if miniatura == "" {
+		miniatura = req.URL
+	}
+
+	imagen := &models.ImagenProducto{
+		CodigoProducto: codigo,
+		URL:            req.URL,
+		URLMiniatura:   miniatura,
+		IDUsuario:      req.IDUsuario,
+	}
+
+	if err := s.reemplazar(ctx, logger, imagen); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Imagen externa asociada al producto", zap.String("url", req.URL))
+
+	return imagen, nil
+}
+
+// Eliminar quita la imagen del producto y borra sus archivos
+func (s *imagenService) Eliminar(ctx context.Context, codigo string) error {
+	logger := s.logger.With(
+		zap.String("operation", "eliminar_imagen"),
+		zap.String("codigo", codigo),
+	)
+
+	imagen, err := s.repo.GetImagen(ctx, codigo)
+	if err != nil {
+		return err
+	}
+	if imagen == nil {
+		return fmt.Errorf("%w: %s", ErrImagenNoEncontrada, codigo)
+	}
+
+	if err := s.repo.DeleteImagen(ctx, codigo); err != nil {
+		return err
+	}
+
+	s.borrarArchivos(ctx, logger, imagen)
+	s.invalidarProducto(ctx, logger, codigo)
+
+	logger.Info("Imagen de producto eliminada")
+	return nil
+}
+
+// GetImagen obtiene los datos de la imagen del producto
+func (s *imagenService) GetImagen(ctx context.Context, codigo string) (*models.ImagenProducto, error) {
+	imagen, err := s.repo.GetImagen(ctx, codigo)
+	if err != nil {
+		return nil, err
+	}
+	if imagen == nil {
+		return nil, fmt.Errorf("%w: %s", ErrImagenNoEncontrada, codigo)
+	}
+	return imagen, nil
+}
+
+// Abrir abre el contenido de la imagen o de su miniatura
+func (s *imagenService) Abrir(ctx context.Context, codigo string, miniatura bool) (*models.ImagenProducto, io.ReadCloser, string, error) {
+	imagen, err := s.GetImagen(ctx, codigo)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if imagen.EsExterna() {
+		return imagen, nil, "", nil
+	}
+
+	key, contentType := *imagen.StorageKey, "application/octet-stream"
+	if imagen.ContentType != nil {
+		contentType = *imagen.ContentType
+	}
+	if miniatura && imagen.ThumbnailKey != nil {
+		key, contentType = *imagen.ThumbnailKey, "image/jpeg"
+	}
+
+	reader, err := s.storage.Open(ctx, key)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("error abriendo imagen: %w", err)
+	}
+	return imagen, reader, contentType, nil
+}
+
+// verificarProducto valida que el código corresponda a un producto o pack
+func (s *imagenService) verificarProducto(ctx context.Context, codigo string) error {
+	existe, err := s.repo.ExisteProducto(ctx, codigo)
+	if err != nil {
+		return err
+	}
+	if !existe {
+		return fmt.Errorf("%w: %s", ErrProductoNoEncontrado, codigo)
+	}
+	return nil
+}
+
+// reemplazar guarda la nueva imagen, borra los archivos de la anterior e invalida
+// el producto en la caché del POS para que exponga la nueva URL
+func (s *imagenService) reemplazar(ctx context.Context, logger *zap.Logger, imagen *models.ImagenProducto) error {
+	anterior, err := s.repo.GetImagen(ctx, imagen.CodigoProducto)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpsertImagen(ctx, imagen); err != nil {
+		return err
+	}
+
+	if anterior != nil && !anterior.EsExterna() && (imagen.StorageKey == nil || *anterior.StorageKey != *imagen.StorageKey) {
+		s.borrarArchivos(ctx, logger, anterior)
+	}
+	s.invalidarProducto(ctx, logger, imagen.CodigoProducto)
+	return nil
+}
+
+// borrarArchivos elimina del almacenamiento la imagen y su miniatura
+// Un archivo huérfano no es crítico: solo se registra el error
+func (s *imagenService) borrarArchivos(ctx context.Context, logger *zap.Logger, imagen *models.ImagenProducto) {
+	for _, key := range []*string{imagen.StorageKey, imagen.ThumbnailKey} {
+		if key == nil {
+			continue
+		}
+		if err := s.storage.Delete(ctx, *key); err != nil {
+			logger.Warn("No se pudo borrar archivo de imagen", zap.String("key", *key), zap.Error(err))
+		}
+	}
+}
+
+// invalidarProducto invalida el producto en la caché del POS
+func (s *imagenService) invalidarProducto(ctx context.Context, logger *zap.Logger, codigo string) {
+	if s.productCache == nil {
+		return
+	}
+	if err := s.productCache.InvalidateByCodigoTivendo(ctx, codigo); err != nil {
+		logger.Warn("Error invalidando cache del producto", zap.Error(err))
+	}
+}
+
+// urlPublica arma la URL expuesta: directa al bucket/CDN si hay base pública,
+// si no a través de los endpoints del servicio (versionada con el hash)
+func (s *imagenService) urlPublica(codigo, key string, miniatura bool, version string) string {
+	if s.config.PublicBaseURL != "" {
+		return strings.TrimRight(s.config.PublicBaseURL, "/") + "/" + key
+	}
+
+	path := "/api/v1/productos/" + url.PathEscape(codigo) + "/imagen"
+	if miniatura {
+		path += "/miniatura"
+	}
+	return path + "?v=" + version
+}
+
+// generarMiniatura reduce la imagen para que su lado mayor mida como máximo lado píxeles,
+// promediando los píxeles de origen que caen en cada píxel de destino
+// El fondo transparente (PNG/GIF) se rellena de blanco porque la miniatura es JPEG
+func generarMiniatura(src image.Image, lado int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	dw, dh := w, h
+	if lado > 0 && (w > lado || h > lado) {
+		if w >= h {
+			dw, dh = lado, h*lado/w
+		} else {
+			dw, dh = w*lado/h, lado
+		}
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	// Aplanar sobre blanco en RGBA para leer los píxeles de forma uniforme
+	plano := image.NewRGBA(bounds)
+	draw.Draw(plano, bounds, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(plano, bounds, src, bounds.Min, draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := y * h / dh
+		y1 := (y + 1) * h / dh
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < dw; x++ {
+			x0 := x * w / dw
+			x1 := (x + 1) * w / dw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, n uint32
+			for sy := y0; sy < y1; sy++ {
+				off := plano.PixOffset(bounds.Min.X+x0, bounds.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(plano.Pix[off])
+					g += uint32(plano.Pix[off+1])
+					b += uint32(plano.Pix[off+2])
+					off += 4
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: 255})
+		}
+	}
+
+	return dst
+}
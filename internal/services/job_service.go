@@ -0,0 +1,662 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"stock-service/internal/cache"
+	"stock-service/internal/config"
+	"stock-service/internal/export"
+	"stock-service/internal/models"
+	"stock-service/internal/outbox"
+	"stock-service/internal/storage"
+	"stock-service/internal/uuid"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// jobsKey lista de Redis con los IDs de jobs pendientes (el más antiguo se toma primero)
+	jobsKey = "pos:jobs"
+	// jobsProcesandoKey job tomado por el worker; si la réplica cae vuelve a la cola
+	jobsProcesandoKey = "pos:jobs:procesando"
+	// jobKeyPrefix estado de cada job (se consulta por ID)
+	jobKeyPrefix = "pos:jobs:job:"
+	// jobsCallbacksKey sorted set con los IDs cuyo resultado falta avisar al callback; el score es
+	// el próximo intento (unix ms)
+	jobsCallbacksKey = "pos:jobs:callbacks"
+	// jobsArchivosKey sorted set con las claves de archivos a eliminar; el score es su vencimiento (unix ms)
+	jobsArchivosKey = "pos:jobs:archivos"
+	// lockJobs una sola réplica ejecuta jobs a la vez
+	lockJobs = "pos:jobs:worker"
+	// lockJobsCallbacks una sola réplica entrega callbacks de jobs a la vez
+	lockJobsCallbacks = "pos:jobs:callbacks:worker"
+	// jobsIntervalo cada cuánto el worker revisa la cola
+	jobsIntervalo = time.Second
+	// jobEnlace ruta donde el solicitante consulta el job
+	jobEnlace = "/api/v1/jobs/"
+	// jobEntrada nombre con que se guarda el archivo subido de una importación
+	jobEntrada = "entrada"
+)
+
+// JobService encola exportaciones e importaciones para ejecutarlas en background, de a una
+// El resultado se consulta por ID (GET /jobs/:id) y, con callbackURL, se avisa además por POST al
+// terminar con los enlaces de descarga de los archivos generados (al menos una vez, con los
+// reintentos y la firma de los webhooks)
+type JobService interface {
+	EncolarExportacionStock(ctx context.Context, idLocal int, formato, callbackURL string, idUsuario int) (*models.Job, error)
+	EncolarExportacionMovimientos(ctx context.Context, filter *models.MovimientoFilter, formato, callbackURL string, idUsuario int) (*models.Job, error)
+	EncolarImportacionPDT(ctx context.Context, idConteo int64, archivo string, data []byte, callbackURL string, idUsuario int) (*models.Job, error)
+	GetJob(ctx context.Context, id string) (*models.Job, error)
+	// AbrirArchivo abre para descarga un archivo generado por el job
+	AbrirArchivo(ctx context.Context, id, nombre string) (*models.ArchivoJob, io.ReadCloser, error)
+	StartWorker(ctx context.Context)
+}
+
+// jobService implementa JobService
+type jobService struct {
+	redisClient   *redis.Client
+	stockService  StockService
+	conteoService ConteoCiclicoService
+	archivos      storage.ImageStorage
+	locker        *cache.KeyLocker
+	cfg           config.JobsConfig
+	webhooks      config.WebhooksConfig
+	client        *http.Client
+	logger        *zap.Logger
+}
+
+// NewJobService crea una nueva instancia del servicio
+func NewJobService(redisClient *redis.Client, stockService StockService, conteoService ConteoCiclicoService, archivos storage.ImageStorage, cfg config.JobsConfig, webhooks config.WebhooksConfig, logger *zap.Logger) JobService {
+	return &jobService{
+		redisClient:   redisClient,
+		stockService:  stockService,
+		conteoService: conteoService,
+		archivos:      archivos,
+		// El lock dura más que el job más largo: si la réplica cae, otra lo retoma al vencer
+		locker:   cache.NewKeyLocker(redisClient, cfg.Timeout+time.Minute, 0, logger),
+		cfg:      cfg,
+		webhooks: webhooks,
+		client:   outbox.NewClienteCallbacks(webhooks.Timeout),
+		logger:   logger,
+	}
+}
+
+// EncolarExportacionStock encola la exportación del stock de un local
+func (s *jobService) EncolarExportacionStock(ctx context.Context, idLocal int, formato, callbackURL string, idUsuario int) (*models.Job, error) {
+	if err := export.ValidarFormato(formato); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJobInvalido, err)
+	}
+	return s.encolar(ctx, &models.Job{
+		Tipo:        models.JobExportacionStock,
+		IDUsuario:   idUsuario,
+		Exportacion: &models.ParametrosExportacion{Formato: formato, IDLocal: idLocal},
+		CallbackURL: callbackURL,
+	}, nil)
+}
+
+// EncolarExportacionMovimientos encola la exportación de los movimientos que cumplen el filtro
+func (s *jobService) EncolarExportacionMovimientos(ctx context.Context, filter *models.MovimientoFilter, formato, callbackURL string, idUsuario int) (*models.Job, error) {
+	if err := export.ValidarFormato(formato); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJobInvalido, err)
+	}
+	return s.encolar(ctx, &models.Job{
+		Tipo:        models.JobExportacionMovimientos,
+		IDUsuario:   idUsuario,
+		Exportacion: &models.ParametrosExportacion{Formato: formato, Filtro: filter},
+		CallbackURL: callbackURL,
+	}, nil)
+}
+
+// EncolarImportacionPDT guarda el archivo de la PDT y encola su carga en la sesión de conteo
+// La sesión se verifica al encolar para rechazar de inmediato una inexistente o completada
+func (s *jobService) EncolarImportacionPDT(ctx context.Context, idConteo int64, archivo string, data []byte, callbackURL string, idUsuario int) (*models.Job, error) {
+	conteo, err := s.conteoService.GetConteo(ctx, idConteo)
+	if err != nil {
+		return nil, err
+	}
+	if conteo.Estado == models.ConteoEstadoCompletada {
+		return nil, fmt.Errorf("%w: sesión %d", ErrConteoCompletado, idConteo)
+	}
+
+	return s.encolar(ctx, &models.Job{
+		Tipo:        models.JobImportacionPDT,
+		IDUsuario:   idUsuario,
+		Importacion: &models.ParametrosImportacionPDT{IDConteo: idConteo, Archivo: archivo},
+		CallbackURL: callbackURL,
+	}, data)
+}
+
+// encolar registra el job como pendiente y lo agrega a la cola; entrada es el archivo subido (si hay)
+func (s *jobService) encolar(ctx context.Context, job *models.Job, entrada []byte) (*models.Job, error) {
+	if job.CallbackURL != "" {
+		if err := outbox.ValidarDestino(ctx, job.CallbackURL, s.webhooks.CallbackAllowedHosts); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCallbackInvalido, err)
+		}
+	}
+
+	job.ID = uuid.New()
+	job.Estado = models.JobPendiente
+	job.EncoladoAt = time.Now()
+
+	if entrada != nil {
+		key := s.claveArchivo(job.ID, jobEntrada)
+		if err := s.archivos.Save(ctx, key, bytes.NewReader(entrada), "application/octet-stream"); err != nil {
+			return nil, fmt.Errorf("error guardando archivo del job: %w", err)
+		}
+		s.programarBorrado(ctx, key, job.EncoladoAt)
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("error serializando job: %w", err)
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, jobKeyPrefix+job.ID, data, s.cfg.Retention)
+	pipe.LPush(ctx, jobsKey, job.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("error encolando job: %w", err)
+	}
+
+	s.logger.Info("Job encolado",
+		zap.String("operation", "encolar_job"),
+		zap.String("id_job", job.ID),
+		zap.String("tipo", job.Tipo),
+		zap.Int("id_usuario", job.IDUsuario))
+
+	return job, nil
+}
+
+// GetJob obtiene el estado de un job (se conserva JOBS_RETENTION_HOURS)
+func (s *jobService) GetJob(ctx context.Context, id string) (*models.Job, error) {
+	data, err := s.redisClient.Get(ctx, jobKeyPrefix+id).Bytes()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("%w: %s", ErrJobNoEncontrado, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error consultando job: %w", err)
+	}
+
+	var job models.Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("error leyendo job: %w", err)
+	}
+	return &job, nil
+}
+
+// AbrirArchivo abre un archivo del resultado del job; solo se sirven los archivos que el job
+// declara en su resultado
+func (s *jobService) AbrirArchivo(ctx context.Context, id, nombre string) (*models.ArchivoJob, io.ReadCloser, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Resultado == nil {
+		return nil, nil, fmt.Errorf("%w: el job %s no generó archivos", ErrJobNoEncontrado, id)
+	}
+
+	for _, archivo := range job.Resultado.Archivos {
+		if archivo.Nombre != nombre {
+			continue
+		}
+		r, err := s.archivos.Open(ctx, s.claveArchivo(id, nombre))
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, nil, fmt.Errorf("%w: archivo %s expirado", ErrJobNoEncontrado, nombre)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error abriendo archivo del job: %w", err)
+		}
+		return archivo, r, nil
+	}
+	return nil, nil, fmt.Errorf("%w: el job %s no tiene el archivo %s", ErrJobNoEncontrado, id, nombre)
+}
+
+// procesarSiguiente ejecuta el job más antiguo de la cola; retorna false si no había ninguno
+// Solo una réplica a la vez: si otra tiene el lock, esta no hace nada
+func (s *jobService) procesarSiguiente(ctx context.Context) (bool, error) {
+	release, err := s.locker.Lock(ctx, lockJobs)
+	if err != nil {
+		if errors.Is(err, cache.ErrLockTimeout) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer release()
+
+	// Job que tomó un worker interrumpido: vuelve a la cola como el más antiguo
+	if err := s.recuperarProcesando(ctx); err != nil {
+		return false, err
+	}
+
+	id, err := s.redisClient.RPopLPush(ctx, jobsKey, jobsProcesandoKey).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error tomando job: %w", err)
+	}
+
+	s.procesar(ctx, id)
+	s.redisClient.LRem(ctx, jobsProcesandoKey, 1, id)
+	return true, nil
+}
+
+// recuperarProcesando devuelve al final de la cola (se toma primero) el job que quedó tomado por
+// un worker interrumpido; se vuelve a ejecutar completo
+func (s *jobService) recuperarProcesando(ctx context.Context) error {
+	ids, err := s.redisClient.LRange(ctx, jobsProcesandoKey, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("error recuperando jobs en proceso: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	for _, id := range ids {
+		pipe.RPush(ctx, jobsKey, id)
+	}
+	pipe.Del(ctx, jobsProcesandoKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("error recuperando jobs en proceso: %w", err)
+	}
+
+	s.logger.Warn("Jobs de un worker interrumpido devueltos a la cola",
+		zap.String("operation", "procesar_job"),
+		zap.Strings("ids", ids))
+	return nil
+}
+
+// procesar ejecuta un job (con JOBS_TIMEOUT_MINUTES como máximo) y guarda su resultado
+func (s *jobService) procesar(ctx context.Context, id string) {
+	logger := s.logger.With(
+		zap.String("operation", "procesar_job"),
+		zap.String("id_job", id),
+	)
+
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		// Expirado o ilegible: no hay a quién informar el resultado
+		logger.Error("Job encolado sin estado, se descarta", zap.Error(err))
+		return
+	}
+
+	iniciado := time.Now()
+	job.Estado = models.JobProcesando
+	job.IniciadoAt = &iniciado
+	s.guardar(ctx, job)
+
+	jobCtx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	var resultado *models.ResultadoJob
+	switch job.Tipo {
+	case models.JobExportacionStock:
+		resultado, err = s.exportarStock(jobCtx, job)
+	case models.JobExportacionMovimientos:
+		resultado, err = s.exportarMovimientos(jobCtx, job)
+	case models.JobImportacionPDT:
+		resultado, err = s.importarPDT(jobCtx, job)
+	default:
+		err = fmt.Errorf("%w: tipo %s", ErrJobInvalido, job.Tipo)
+	}
+	cancel()
+
+	finalizado := time.Now()
+	job.FinalizadoAt = &finalizado
+	if err != nil {
+		logger.Error("Error ejecutando job", zap.String("tipo", job.Tipo), zap.Error(err))
+		job.Estado = models.JobFallido
+		job.Error = err.Error()
+	} else {
+		job.Estado = models.JobCompletado
+		job.Resultado = resultado
+	}
+	if job.CallbackURL != "" {
+		job.Callback = &models.EntregaCallback{Estado: models.CallbackPendiente}
+	}
+	s.guardar(ctx, job)
+
+	// El aviso al callback no demora la cola: lo entrega el worker en la siguiente vuelta
+	if job.Callback != nil {
+		s.programarCallback(ctx, id, finalizado, logger)
+	}
+
+	logger.Info("Job procesado",
+		zap.String("tipo", job.Tipo),
+		zap.String("estado", job.Estado),
+		zap.Duration("duracion", finalizado.Sub(iniciado)))
+}
+
+// exportarStock genera el archivo del stock del local
+func (s *jobService) exportarStock(ctx context.Context, job *models.Job) (*models.ResultadoJob, error) {
+	params := job.Exportacion
+	nombre := fmt.Sprintf("stock_local_%d_%s.%s", params.IDLocal, job.EncoladoAt.Format("20060102"), params.Formato)
+	return s.exportar(ctx, job, nombre, "Stock", func(w export.Writer) (int, error) {
+		return s.stockService.ExportarStock(ctx, params.IDLocal, w)
+	})
+}
+
+// exportarMovimientos genera el archivo de los movimientos filtrados
+func (s *jobService) exportarMovimientos(ctx context.Context, job *models.Job) (*models.ResultadoJob, error) {
+	params := job.Exportacion
+	filtro := params.Filtro
+	if filtro == nil {
+		filtro = &models.MovimientoFilter{}
+	}
+	nombre := fmt.Sprintf("movimientos_%s.%s", job.EncoladoAt.Format("20060102"), params.Formato)
+	return s.exportar(ctx, job, nombre, "Movimientos", func(w export.Writer) (int, error) {
+		return s.stockService.ExportarMovimientos(ctx, filtro, w)
+	})
+}
+
+// exportar escribe las filas en un archivo temporal y lo sube al almacenamiento de los jobs
+func (s *jobService) exportar(ctx context.Context, job *models.Job, nombre, hoja string, escribir func(export.Writer) (int, error)) (*models.ResultadoJob, error) {
+	formato := job.Exportacion.Formato
+	tmp, err := os.CreateTemp("", "job-*."+formato)
+	if err != nil {
+		return nil, fmt.Errorf("error creando archivo temporal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w, err := export.NewWriter(formato, tmp, hoja)
+	if err != nil {
+		return nil, err
+	}
+	filas, err := escribir(w)
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error exportando (%d filas escritas): %w", filas, err)
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("error leyendo archivo temporal: %w", err)
+	}
+	archivo, err := s.guardarArchivo(ctx, job, nombre, export.ContentType(formato), tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ResultadoJob{
+		Mensaje:  fmt.Sprintf("%d filas exportadas", filas),
+		Filas:    filas,
+		Archivos: []*models.ArchivoJob{archivo},
+	}, nil
+}
+
+// importarPDT carga el archivo subido en la sesión de conteo; las líneas rechazadas quedan además
+// en un CSV descargable
+func (s *jobService) importarPDT(ctx context.Context, job *models.Job) (*models.ResultadoJob, error) {
+	params := job.Importacion
+	key := s.claveArchivo(job.ID, jobEntrada)
+	r, err := s.archivos.Open(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo archivo de la PDT: %w", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivo de la PDT: %w", err)
+	}
+
+	importacion, err := s.conteoService.ImportarArchivoPDT(ctx, params.IDConteo, params.Archivo, data, job.IDUsuario)
+	if err != nil {
+		return nil, err
+	}
+	// El archivo subido ya no se necesita (si la réplica cae antes, se elimina al vencer)
+	if err := s.archivos.Delete(ctx, key); err != nil {
+		s.logger.Warn("Error eliminando archivo de la PDT importado", zap.String("id_job", job.ID), zap.Error(err))
+	}
+
+	resultado := &models.ResultadoJob{
+		Mensaje:     fmt.Sprintf("%d de %d líneas aplicadas", importacion.LineasAplicadas, importacion.Lineas),
+		Importacion: importacion,
+		Archivos:    []*models.ArchivoJob{},
+	}
+	if len(importacion.Rechazadas) == 0 {
+		return resultado, nil
+	}
+
+	var buf bytes.Buffer
+	w, err := export.NewWriter(export.FormatoCSV, &buf, "Rechazadas")
+	if err != nil {
+		return nil, err
+	}
+	if err := w.WriteRow("linea", "codigo", "motivo", "error"); err != nil {
+		return nil, err
+	}
+	for _, linea := range importacion.Rechazadas {
+		if err := w.WriteRow(linea.Linea, linea.Codigo, linea.Motivo, linea.Error); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	nombre := fmt.Sprintf("rechazadas_conteo_%d.%s", params.IDConteo, export.FormatoCSV)
+	archivo, err := s.guardarArchivo(ctx, job, nombre, export.ContentType(export.FormatoCSV), &buf)
+	if err != nil {
+		return nil, err
+	}
+	resultado.Archivos = append(resultado.Archivos, archivo)
+	return resultado, nil
+}
+
+// guardarArchivo sube un archivo del resultado y agenda su eliminación al vencer el job
+func (s *jobService) guardarArchivo(ctx context.Context, job *models.Job, nombre, contentType string, r io.Reader) (*models.ArchivoJob, error) {
+	key := s.claveArchivo(job.ID, nombre)
+	if err := s.archivos.Save(ctx, key, r, contentType); err != nil {
+		return nil, fmt.Errorf("error guardando archivo del job: %w", err)
+	}
+	s.programarBorrado(ctx, key, job.EncoladoAt)
+
+	return &models.ArchivoJob{
+		Nombre:      nombre,
+		ContentType: contentType,
+		Enlace:      jobEnlace + job.ID + "/archivos/" + nombre,
+	}, nil
+}
+
+// claveArchivo clave de un archivo del job en el almacenamiento
+func (s *jobService) claveArchivo(id, nombre string) string {
+	return "jobs/" + id + "/" + nombre
+}
+
+// programarBorrado agenda la eliminación del archivo cuando vence el estado del job
+func (s *jobService) programarBorrado(ctx context.Context, key string, encolado time.Time) {
+	err := s.redisClient.ZAdd(ctx, jobsArchivosKey, &redis.Z{
+		Score:  float64(encolado.Add(s.cfg.Retention).UnixMilli()),
+		Member: key,
+	}).Err()
+	if err != nil {
+		s.logger.Warn("Error agendando eliminación de archivo del job", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// purgarArchivos elimina los archivos de jobs vencidos
+func (s *jobService) purgarArchivos(ctx context.Context) error {
+	keys, err := s.redisClient.ZRangeByScore(ctx, jobsArchivosKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: int64(s.webhooks.BatchSize),
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("error tomando archivos vencidos: %w", err)
+	}
+
+	for _, key := range keys {
+		if err := s.archivos.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+			s.logger.Warn("Error eliminando archivo vencido de un job", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		s.redisClient.ZRem(ctx, jobsArchivosKey, key)
+	}
+	return nil
+}
+
+// guardar actualiza el estado del job conservando su vencimiento original
+func (s *jobService) guardar(ctx context.Context, job *models.Job) {
+	ttl := time.Until(job.EncoladoAt.Add(s.cfg.Retention))
+	data, err := json.Marshal(job)
+	if err != nil || ttl <= 0 {
+		return
+	}
+	if err := s.redisClient.Set(ctx, jobKeyPrefix+job.ID, data, ttl).Err(); err != nil {
+		s.logger.Warn("Error guardando estado de job",
+			zap.String("id_job", job.ID),
+			zap.Error(err))
+	}
+}
+
+// programarCallback agenda el aviso del resultado al callback en el instante indicado
+func (s *jobService) programarCallback(ctx context.Context, id string, cuando time.Time, logger *zap.Logger) {
+	err := s.redisClient.ZAdd(ctx, jobsCallbacksKey, &redis.Z{
+		Score:  float64(cuando.UnixMilli()),
+		Member: id,
+	}).Err()
+	if err != nil {
+		// El resultado sigue disponible por polling
+		logger.Error("Error agendando callback de job", zap.Error(err))
+	}
+}
+
+// entregarCallbacks avisa los resultados cuyo intento ya venció
+// Solo una réplica a la vez; un ID sale del set recién tras entregarse o agotar los reintentos
+func (s *jobService) entregarCallbacks(ctx context.Context) error {
+	release, err := s.locker.Lock(ctx, lockJobsCallbacks)
+	if err != nil {
+		if errors.Is(err, cache.ErrLockTimeout) {
+			return nil
+		}
+		return err
+	}
+	defer release()
+
+	ids, err := s.redisClient.ZRangeByScore(ctx, jobsCallbacksKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: int64(s.webhooks.BatchSize),
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("error tomando callbacks de jobs pendientes: %w", err)
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return nil
+		}
+		s.entregarCallback(ctx, id)
+	}
+	return nil
+}
+
+// entregarCallback envía el resultado al callback y registra el intento en el job
+// Un fallo se reintenta con el backoff de los webhooks hasta agotar WEBHOOK_MAX_ATTEMPTS; un
+// destino que dejó de estar permitido se descarta sin reintentos
+func (s *jobService) entregarCallback(ctx context.Context, id string) {
+	logger := s.logger.With(
+		zap.String("operation", "callback_job"),
+		zap.String("id_job", id),
+	)
+
+	job, err := s.GetJob(ctx, id)
+	if err != nil || job.Callback == nil {
+		// Expirado: ya no hay resultado que avisar
+		logger.Warn("Callback de un job sin estado, se descarta", zap.Error(err))
+		s.redisClient.ZRem(ctx, jobsCallbacksKey, id)
+		return
+	}
+
+	// El destino se vuelve a validar al enviar: la lista de hosts pudo cambiar y el DNS también
+	err = outbox.ValidarDestino(ctx, job.CallbackURL, s.webhooks.CallbackAllowedHosts)
+	permanente := err != nil
+	if err == nil {
+		var body []byte
+		body, err = json.Marshal(models.CallbackJob{
+			Tipo:   models.EventoJobFinalizado,
+			Job:    job,
+			Enlace: jobEnlace + id,
+		})
+		if err == nil {
+			err = outbox.Enviar(ctx, s.client, job.CallbackURL, s.webhooks.Secret, id, models.EventoJobFinalizado, body)
+		}
+	}
+
+	callback := job.Callback
+	callback.Intentos++
+	switch {
+	case err == nil:
+		entregado := time.Now()
+		callback.Estado = models.CallbackEntregado
+		callback.UltimoError = ""
+		callback.EntregadoAt = &entregado
+	case !permanente && callback.Intentos < s.webhooks.MaxAttempts:
+		callback.UltimoError = err.Error()
+		s.guardar(ctx, job)
+		proximo := time.Now().Add(outbox.Backoff(callback.Intentos))
+		logger.Warn("Entrega de callback de job fallida, se reintentará",
+			zap.Int("attempts", callback.Intentos),
+			zap.Time("next_attempt", proximo),
+			zap.Error(err))
+		s.programarCallback(ctx, id, proximo, logger)
+		return
+	default:
+		callback.Estado = models.CallbackFallido
+		callback.UltimoError = err.Error()
+		logger.Error("Callback de job descartado tras agotar los reintentos",
+			zap.Int("attempts", callback.Intentos),
+			zap.Error(err))
+	}
+
+	s.guardar(ctx, job)
+	s.redisClient.ZRem(ctx, jobsCallbacksKey, id)
+}
+
+// StartWorker ejecuta los jobs encolados, entrega sus callbacks y elimina los archivos vencidos
+// hasta que ctx se cancele
+func (s *jobService) StartWorker(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(jobsIntervalo)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for ctx.Err() == nil {
+					procesado, err := s.procesarSiguiente(ctx)
+					if err != nil && ctx.Err() == nil {
+						s.logger.Error("Error procesando jobs", zap.Error(err))
+					}
+					if !procesado {
+						break
+					}
+				}
+
+				callbacks, err := s.redisClient.ZCard(ctx, jobsCallbacksKey).Result()
+				if err == nil && callbacks > 0 {
+					if err := s.entregarCallbacks(ctx); err != nil && ctx.Err() == nil {
+						s.logger.Error("Error entregando callbacks de jobs", zap.Error(err))
+					}
+				}
+
+				if err := s.purgarArchivos(ctx); err != nil && ctx.Err() == nil {
+					s.logger.Error("Error eliminando archivos vencidos de jobs", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// MinimoEstacionalService maneja los perfiles estacionales de cantidad mínima por producto y local
+// GetStockBajo y el resumen por local usan el mínimo de la temporada vigente en lugar del fijo
+type MinimoEstacionalService interface {
+	CrearMinimo(ctx context.Context, req *models.MinimoEstacionalRequest) (*models.MinimoEstacional, error)
+	GetMinimos(ctx context.Context, filter *models.MinimoEstacionalFilter) ([]*models.MinimoEstacional, error)
+	EliminarMinimo(ctx context.Context, id int) error
+}
+
+// minimoEstacionalService implementa MinimoEstacionalService
+type minimoEstacionalService struct {
+	repo      repository.MinimoEstacionalRepository
+	stockRepo repository.StockRepository
+	logger    *zap.Logger
+}
+
+// NewMinimoEstacionalService crea una nueva instancia del servicio
+func NewMinimoEstacionalService(repo repository.MinimoEstacionalRepository, stockRepo repository.StockRepository, logger *zap.Logger) MinimoEstacionalService {
+	return &minimoEstacionalService{
+		repo:      repo,
+		stockRepo: stockRepo,
+		logger:    logger,
+	}
+}
+
+// CrearMinimo define el mínimo del producto en el local para un rango de fechas
+// Un producto no puede tener dos temporadas que se crucen en el mismo local
+func (s *minimoEstacionalService) CrearMinimo(ctx context.Context, req *models.MinimoEstacionalRequest) (*models.MinimoEstacional, error) {
+	desde, err := time.Parse("2006-01-02", req.Desde)
+	if err != nil {
+		return nil, fmt.Errorf("%w: desde %q (use YYYY-MM-DD)", ErrMinimoEstacionalInvalido, req.Desde)
+	}
+	hasta, err := time.Parse("2006-01-02", req.Hasta)
+	if err != nil {
+		return nil, fmt.Errorf("%w: hasta %q (use YYYY-MM-DD)", ErrMinimoEstacionalInvalido, req.Hasta)
+	}
+	if hasta.Before(desde) {
+		return nil, fmt.Errorf("%w: hasta (%s) es anterior a desde (%s)", ErrMinimoEstacionalInvalido, req.Hasta, req.Desde)
+	}
+
+	if err := s.verificarLocal(ctx, req.IDLocal); err != nil {
+		return nil, err
+	}
+	if err := s.verificarProducto(ctx, req.CodigoProducto); err != nil {
+		return nil, err
+	}
+
+	solapados, err := s.repo.GetMinimosSolapados(ctx, req.CodigoProducto, req.IDLocal, desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+	if len(solapados) > 0 {
+		otro := solapados[0]
+		return nil, fmt.Errorf("%w: %s (%s a %s)", ErrMinimoEstacionalSolapado,
+			otro.Temporada, otro.Desde.Format("2006-01-02"), otro.Hasta.Format("2006-01-02"))
+	}
+
+	minimo := &models.MinimoEstacional{
+		CodigoProducto: req.CodigoProducto,
+		IDLocal:        req.IDLocal,
+		Temporada:      strings.TrimSpace(req.Temporada),
+		CantidadMinima: req.CantidadMinima,
+		Desde:          desde,
+		Hasta:          hasta,
+		IDUsuario:      req.IDUsuario,
+	}
+	if err := s.repo.CreateMinimo(ctx, minimo); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Mínimo estacional definido",
+		zap.String("operation", "crear_minimo_estacional"),
+		zap.String("codigo_producto", minimo.CodigoProducto),
+		zap.Int("id_local", minimo.IDLocal),
+		zap.String("temporada", minimo.Temporada),
+		zap.Int("cantidad_minima", minimo.CantidadMinima))
+
+	return minimo, nil
+}
+
+// GetMinimos lista los mínimos estacionales
+func (s *minimoEstacionalService) GetMinimos(ctx context.Context, filter *models.MinimoEstacionalFilter) ([]*models.MinimoEstacional, error) {
+	return s.repo.GetMinimos(ctx, filter)
+}
+
+// EliminarMinimo elimina un mínimo estacional (vuelve a regir el mínimo del stock en ese rango)
+func (s *minimoEstacionalService) EliminarMinimo(ctx context.Context, id int) error {
+	eliminado, err := s.repo.DeleteMinimo(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !eliminado {
+		return fmt.Errorf("%w: %d", ErrMinimoEstacionalNoEncontrado, id)
+	}
+
+	s.logger.Info("Mínimo estacional eliminado",
+		zap.String("operation", "eliminar_minimo_estacional"),
+		zap.Int("id", id))
+
+	return nil
+}
+
+// verificarLocal verifica que el local exista y esté activo
+func (s *minimoEstacionalService) verificarLocal(ctx context.Context, idLocal int) error {
+	local, err := s.stockRepo.GetLocalByID(ctx, idLocal)
+	if err != nil {
+		return fmt.Errorf("error verificando local: %w", err)
+	}
+	if local == nil {
+		return fmt.Errorf("%w: %d", ErrLocalNoEncontrado, idLocal)
+	}
+	if !local.Activo {
+		return fmt.Errorf("%w: %d", ErrLocalInactivo, idLocal)
+	}
+	return nil
+}
+
+// verificarProducto verifica que el código corresponda a un producto o a un pack
+func (s *minimoEstacionalService) verificarProducto(ctx context.Context, codigo string) error {
+	producto, err := s.stockRepo.GetProductoByCodigo(ctx, codigo)
+	if err != nil {
+		return fmt.Errorf("error verificando producto: %w", err)
+	}
+	if producto != nil {
+		return nil
+	}
+
+	pack, err := s.stockRepo.GetPackByCodigo(ctx, codigo)
+	if err != nil {
+		return fmt.Errorf("error verificando pack: %w", err)
+	}
+	if pack == nil {
+		return fmt.Errorf("%w: %s", ErrProductoNoEncontrado, codigo)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"stock-service/internal/metrics"
+	"stock-service/internal/models"
+)
+
+// rutaSinCoincidencia etiqueta de los requests que no coincidieron con ninguna ruta (404)
+const rutaSinCoincidencia = "sin_ruta"
+
+// promRouteKey serie de latencia (método y ruta del router)
+type promRouteKey struct {
+	method string
+	route  string
+}
+
+// promRequestKey serie del contador de requests
+type promRequestKey struct {
+	promRouteKey
+	status int
+}
+
+// recordPrometheus suma el request a las series de Prometheus (con requestsMutex tomado)
+func (s *monitoringService) recordPrometheus(data models.RequestData) {
+	route := data.Route
+	if route == "" {
+		route = rutaSinCoincidencia
+	}
+	key := promRouteKey{method: data.Method, route: route}
+
+	s.promRequests[promRequestKey{promRouteKey: key, status: data.StatusCode}]++
+
+	histograma, ok := s.promLatency[key]
+	if !ok {
+		histograma = metrics.NewHistogram(metrics.LatencyBuckets)
+		s.promLatency[key] = histograma
+	}
+	histograma.Observe(data.Duration.Seconds())
+}
+
+// WritePrometheus escribe requests, latencias, cache de productos, invalidaciones, pool de PostgreSQL
+// y runtime en el formato de texto de Prometheus
+func (s *monitoringService) WritePrometheus(w io.Writer) error {
+	enc := metrics.NewEncoder(w)
+
+	s.writePrometheusRequests(enc)
+	s.writePrometheusCache(enc)
+	s.writePrometheusPool(enc)
+	s.writePrometheusRuntime(enc)
+
+	return enc.Flush()
+}
+
+// writePrometheusRequests series de requests y latencia, ordenadas para una salida estable
+func (s *monitoringService) writePrometheusRequests(enc *metrics.Encoder) {
+	s.requestsMutex.RLock()
+	defer s.requestsMutex.RUnlock()
+
+	requests := make([]promRequestKey, 0, len(s.promRequests))
+	for key := range s.promRequests {
+		requests = append(requests, key)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	enc.Familia("stock_http_requests_total", metrics.TipoCounter, "Requests HTTP por método, ruta y status")
+	for _, key := range requests {
+		enc.Muestra("stock_http_requests_total", []metrics.Label{
+			{Name: "method", Value: key.method},
+			{Name: "route", Value: key.route},
+			{Name: "status", Value: strconv.Itoa(key.status)},
+		}, float64(s.promRequests[key]))
+	}
+
+	rutas := make([]promRouteKey, 0, len(s.promLatency))
+	for key := range s.promLatency {
+		rutas = append(rutas, key)
+	}
+	sort.Slice(rutas, func(i, j int) bool {
+		if rutas[i].route != rutas[j].route {
+			return rutas[i].route < rutas[j].route
+		}
+		return rutas[i].method < rutas[j].method
+	})
+
+	enc.Familia("stock_http_request_duration_seconds", metrics.TipoHistogram, "Latencia de los requests HTTP por método y ruta")
+	for _, key := range rutas {
+		enc.Histograma("stock_http_request_duration_seconds", []metrics.Label{
+			{Name: "method", Value: key.method},
+			{Name: "route", Value: key.route},
+		}, s.promLatency[key])
+	}
+}
+
+// writePrometheusCache hits, misses y claves del cache de productos, e invalidaciones en mora
+func (s *monitoringService) writePrometheusCache(enc *metrics.Encoder) {
+	stats := s.productCache.GetStats()
+	invalidaciones := s.invalidations.Stats()
+
+	enc.Familia("stock_cache_hits_total", metrics.TipoCounter, "Búsquedas resueltas por el cache de productos")
+	enc.Muestra("stock_cache_hits_total", nil, float64(stats.Hits))
+
+	enc.Familia("stock_cache_misses_total", metrics.TipoCounter, "Búsquedas que no encontraron el producto en el cache")
+	enc.Muestra("stock_cache_misses_total", nil, float64(stats.Misses))
+
+	causas := make([]string, 0, len(stats.MissCauses))
+	for causa := range stats.MissCauses {
+		causas = append(causas, causa)
+	}
+	sort.Strings(causas)
+
+	enc.Familia("stock_cache_misses_by_cause_total", metrics.TipoCounter, "Misses del cache de productos por causa")
+	for _, causa := range causas {
+		enc.Muestra("stock_cache_misses_by_cause_total", []metrics.Label{{Name: "cause", Value: causa}}, float64(stats.MissCauses[causa]))
+	}
+
+	enc.Familia("stock_cache_not_found_total", metrics.TipoCounter, "Misses cuyo producto tampoco existe en la base de datos")
+	enc.Muestra("stock_cache_not_found_total", nil, float64(stats.NotFound))
+	enc.Familia("stock_cache_lookup_errors_total", metrics.TipoCounter, "Errores buscando en la base de datos tras un miss")
+	enc.Muestra("stock_cache_lookup_errors_total", nil, float64(stats.LookupErrors))
+	enc.Familia("stock_cache_keys", metrics.TipoGauge, "Productos en el cache L1")
+	enc.Muestra("stock_cache_keys", nil, float64(stats.TotalKeys))
+
+	enc.Familia("stock_cache_invalidations_pending", metrics.TipoGauge, "Invalidaciones de cache en mora esperando reintento")
+	enc.Muestra("stock_cache_invalidations_pending", nil, float64(invalidaciones.Pending))
+	enc.Familia("stock_cache_invalidations_oldest_pending_seconds", metrics.TipoGauge, "Antigüedad de la invalidación en mora más vieja")
+	enc.Muestra("stock_cache_invalidations_oldest_pending_seconds", nil, float64(invalidaciones.OldestPendingSeconds))
+	enc.Familia("stock_cache_invalidations_dead_letter_total", metrics.TipoCounter, "Invalidaciones descartadas tras agotar los reintentos")
+	enc.Muestra("stock_cache_invalidations_dead_letter_total", nil, float64(invalidaciones.DeadLetter))
+}
+
+// writePrometheusPool estado del pool de conexiones a PostgreSQL
+func (s *monitoringService) writePrometheusPool(enc *metrics.Encoder) {
+	pool := s.dbPool.Stats()
+
+	enc.Familia("stock_db_pool_max_open_connections", metrics.TipoGauge, "Máximo de conexiones abiertas del pool")
+	enc.Muestra("stock_db_pool_max_open_connections", nil, float64(pool.MaxOpenConns))
+	enc.Familia("stock_db_pool_connections", metrics.TipoGauge, "Conexiones del pool por estado")
+	enc.Muestra("stock_db_pool_connections", []metrics.Label{{Name: "state", Value: "in_use"}}, float64(pool.InUse))
+	enc.Muestra("stock_db_pool_connections", []metrics.Label{{Name: "state", Value: "idle"}}, float64(pool.Idle))
+	enc.Familia("stock_db_pool_wait_total", metrics.TipoCounter, "Esperas por una conexión libre")
+	enc.Muestra("stock_db_pool_wait_total", nil, float64(pool.WaitCount))
+	enc.Familia("stock_db_pool_wait_seconds_total", metrics.TipoCounter, "Tiempo total esperado por una conexión libre")
+	enc.Muestra("stock_db_pool_wait_seconds_total", nil, pool.WaitDurationMs/1000)
+
+	alerta := 0.0
+	if pool.WaitAlert {
+		alerta = 1
+	}
+	enc.Familia("stock_db_pool_wait_alert", metrics.TipoGauge, "1 si la espera promedio por conexión supera el umbral de alerta")
+	enc.Muestra("stock_db_pool_wait_alert", nil, alerta)
+}
+
+// writePrometheusRuntime uptime, goroutines y memoria del proceso
+func (s *monitoringService) writePrometheusRuntime(enc *metrics.Encoder) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	enc.Familia("stock_uptime_seconds", metrics.TipoGauge, "Segundos desde el arranque del servicio")
+	enc.Muestra("stock_uptime_seconds", nil, time.Since(s.startTime).Seconds())
+	enc.Familia("go_goroutines", metrics.TipoGauge, "Goroutines en ejecución")
+	enc.Muestra("go_goroutines", nil, float64(runtime.NumGoroutine()))
+	enc.Familia("go_memstats_heap_alloc_bytes", metrics.TipoGauge, "Bytes asignados en el heap")
+	enc.Muestra("go_memstats_heap_alloc_bytes", nil, float64(m.HeapAlloc))
+	enc.Familia("go_memstats_sys_bytes", metrics.TipoGauge, "Bytes obtenidos del sistema operativo")
+	enc.Muestra("go_memstats_sys_bytes", nil, float64(m.Sys))
+}
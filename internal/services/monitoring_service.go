@@ -2,8 +2,8 @@ package services
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"io"
 	"math"
 	"runtime"
 	"sort"
@@ -14,6 +14,8 @@ import (
 
 	"stock-service/internal/cache"
 	"stock-service/internal/config"
+	"stock-service/internal/database"
+	"stock-service/internal/metrics"
 	"stock-service/internal/models"
 
 	"github.com/go-redis/redis/v8"
@@ -27,20 +29,32 @@ type MonitoringService interface {
 	GetDatabaseStats(ctx context.Context) models.DatabaseMetrics
 	GetSystemStats() models.SystemMetrics
 	GetRedisStats(ctx context.Context) models.RedisMetrics
+	GetBarcodeLatency() models.BarcodeLatencyMetrics
+	ResetBarcodeLatency()
+	// WritePrometheus escribe las métricas en el formato de texto de Prometheus
+	WritePrometheus(w io.Writer) error
 }
 
 type monitoringService struct {
 	logger       *zap.Logger
 	config       *config.Config
 	redisClient  *redis.Client
-	dbPool       *sql.DB
+	dbPool       *database.PoolMonitor
 	productCache *cache.ProductCache
+	// Cola de invalidaciones de stock (métricas de invalidaciones en mora)
+	invalidations *cache.InvalidationQueue
 
 	// Métricas de requests
 	requestsMutex sync.RWMutex
 	requests      map[string]*models.EndpointMetrics
 	slowRequests  []models.SlowRequest
 	errors        []models.RequestError
+	errorsByCode  map[string]int
+
+	// Métricas Prometheus: requests por método, ruta y status, y latencia por método y ruta
+	// (por ruta del router y no por path, para no crear una serie por código de producto)
+	promRequests map[promRequestKey]int64
+	promLatency  map[promRouteKey]*metrics.Histogram
 
 	// Contadores
 	totalRequests int64
@@ -56,17 +70,22 @@ func NewMonitoringService(
 	logger *zap.Logger,
 	config *config.Config,
 	redisClient *redis.Client,
-	dbPool *sql.DB,
+	dbPool *database.PoolMonitor,
 	productCache *cache.ProductCache,
+	invalidations *cache.InvalidationQueue,
 ) MonitoringService {
 	return &monitoringService{
-		logger:       logger,
-		config:       config,
-		redisClient:  redisClient,
-		dbPool:       dbPool,
-		productCache: productCache,
-		requests:     make(map[string]*models.EndpointMetrics),
-		startTime:    time.Now(),
+		logger:        logger,
+		config:        config,
+		redisClient:   redisClient,
+		dbPool:        dbPool,
+		productCache:  productCache,
+		invalidations: invalidations,
+		requests:      make(map[string]*models.EndpointMetrics),
+		errorsByCode:  make(map[string]int),
+		promRequests:  make(map[promRequestKey]int64),
+		promLatency:   make(map[promRouteKey]*metrics.Histogram),
+		startTime:     time.Now(),
 	}
 }
 
@@ -93,6 +112,8 @@ func (s *monitoringService) RecordRequest(data models.RequestData) {
 	// Incrementar contador total
 	s.totalRequests++
 
+	s.recordPrometheus(data)
+
 	// Registrar request lento (> 1000ms)
 	if durationMs > 1000 {
 		slowReq := models.SlowRequest{
@@ -113,9 +134,16 @@ func (s *monitoringService) RecordRequest(data models.RequestData) {
 		errorReq := models.RequestError{
 			Endpoint:   endpointKey,
 			StatusCode: data.StatusCode,
+			Code:       data.ErrorCode,
 			Timestamp:  data.Timestamp,
 		}
+		if data.Error != nil {
+			errorReq.Message = data.Error.Error()
+		}
 		s.errors = append(s.errors, errorReq)
+		if data.ErrorCode != "" {
+			s.errorsByCode[data.ErrorCode]++
+		}
 
 		// Mantener solo los últimos 100 errores
 		if len(s.errors) > 100 {
@@ -147,6 +175,7 @@ func (s *monitoringService) GetMetrics(ctx context.Context) *models.MonitoringRe
 		Database:    databaseMetrics,
 		System:      systemMetrics,
 		Redis:       redisMetrics,
+		Barcode:     s.GetBarcodeLatency(),
 		Timestamp:   time.Now().UTC().Format(time.RFC3339),
 		Version:     "2.0",
 		GeneratedBy: "Go Monitoring Service",
@@ -191,6 +220,11 @@ func (s *monitoringService) calculateRequestMetrics() models.RequestMetrics {
 		byEndpoint[key] = *metrics
 	}
 
+	errorsByCode := make(map[string]int, len(s.errorsByCode))
+	for code, count := range s.errorsByCode {
+		errorsByCode[code] = count
+	}
+
 	return models.RequestMetrics{
 		Total:             len(s.requests),
 		ByEndpoint:        byEndpoint,
@@ -199,6 +233,7 @@ func (s *monitoringService) calculateRequestMetrics() models.RequestMetrics {
 		TotalRequests:     int(s.totalRequests),
 		SlowRequestsCount: len(s.slowRequests),
 		ErrorsCount:       len(s.errors),
+		ErrorsByCode:      errorsByCode,
 		TopEndpoints:      topEndpoints,
 	}
 }
@@ -242,12 +277,11 @@ func (s *monitoringService) calculatePerformanceMetrics() models.PerformanceMetr
 func (s *monitoringService) GetCacheStats() models.CacheMetrics {
 	// Obtener stats del cache de productos
 	cacheStats := s.productCache.GetStats()
+	invalidationStats := s.invalidations.Stats()
 
-	// Calcular hit rate
-	var hitRate float64
-	if cacheStats.TotalRequests > 0 {
-		hitRate = float64(cacheStats.Hits) / float64(cacheStats.TotalRequests)
-	}
+	// Calcular hit rate (acumulado y de la última hora)
+	hitRate := cache.HitRate(cacheStats.Hits, cacheStats.Misses)
+	hitRateHora := cache.HitRate(cacheStats.HitsUltimaHora, cacheStats.MissesUltimaHora)
 
 	return models.CacheMetrics{
 		Connected:         true,
@@ -259,19 +293,45 @@ func (s *monitoringService) GetCacheStats() models.CacheMetrics {
 		TotalHits:         cacheStats.Hits,
 		TotalMisses:       cacheStats.Misses,
 		TotalRequests:     cacheStats.TotalRequests,
+		TotalNotFound:     cacheStats.NotFound,
+		TotalLookupErrors: cacheStats.LookupErrors,
+		MissCauses:        cacheStats.MissCauses,
+
+		HitRateUltimaHora:           hitRateHora,
+		HitRateUltimaHoraPercentage: fmt.Sprintf("%.2f%%", hitRateHora*100),
+		HitsUltimaHora:              cacheStats.HitsUltimaHora,
+		MissesUltimaHora:            cacheStats.MissesUltimaHora,
+
+		PendingInvalidations:             invalidationStats.Pending,
+		OldestPendingInvalidationSeconds: invalidationStats.OldestPendingSeconds,
+		RetriedInvalidations:             invalidationStats.Retried,
+		DeadLetterInvalidations:          invalidationStats.DeadLetter,
 	}
 }
 
+// GetBarcodeLatency retorna las latencias del camino de escaneo por código de barras
+func (s *monitoringService) GetBarcodeLatency() models.BarcodeLatencyMetrics {
+	return s.productCache.Latency().Snapshot()
+}
+
+// ResetBarcodeLatency descarta las muestras de latencia del escaneo
+func (s *monitoringService) ResetBarcodeLatency() {
+	s.productCache.Latency().Reset()
+	s.logger.Info("Latencias del escaneo por código de barras reiniciadas",
+		zap.String("operation", "reset_barcode_latency"))
+}
+
 func (s *monitoringService) GetDatabaseStats(ctx context.Context) models.DatabaseMetrics {
-	// Obtener stats de la conexión de la base de datos
-	stats := s.dbPool.Stats()
+	// Obtener stats del pool de conexiones de la base de datos
+	pool := s.dbPool.Stats()
 
 	return models.DatabaseMetrics{
-		ActiveConnections:      int(stats.OpenConnections),
+		ActiveConnections:      pool.Open,
 		TotalQueries:           s.totalQueries,
 		SlowQueries:            []string{}, // Por ahora vacío
 		Status:                 "online",
-		ActiveConnectionsCount: int(stats.OpenConnections),
+		ActiveConnectionsCount: pool.Open,
+		Pool:                   pool,
 	}
 }
 
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+	"stock-service/internal/uuid"
+
+	"go.uber.org/zap"
+)
+
+// NotaCreditoService emite notas de crédito sobre ventas del POS ya cerradas
+type NotaCreditoService interface {
+	// EmitirNotaCredito anula la venta (total o parcialmente): reingresa el stock y registra el
+	// monto devuelto en el medio de pago, con folio propio del local
+	EmitirNotaCredito(ctx context.Context, req *models.EmitirNotaCreditoRequest) (*models.NotaCredito, error)
+	GetNotaCredito(ctx context.Context, id int64) (*models.NotaCredito, error)
+	GetNotasCreditoVenta(ctx context.Context, idOperacionVenta string) ([]*models.NotaCredito, error)
+	GetReporte(ctx context.Context, filter *models.ReporteFilter) (*models.ReporteNotasCredito, error)
+}
+
+// notaCreditoService implementa NotaCreditoService
+type notaCreditoService struct {
+	repo         repository.NotaCreditoRepository
+	stockService StockService
+	config       config.CreditNotesConfig
+	logger       *zap.Logger
+}
+
+// NewNotaCreditoService crea una nueva instancia del servicio
+func NewNotaCreditoService(repo repository.NotaCreditoRepository, stockService StockService, cfg config.CreditNotesConfig, logger *zap.Logger) NotaCreditoService {
+	return &notaCreditoService{
+		repo:         repo,
+		stockService: stockService,
+		config:       cfg,
+		logger:       logger,
+	}
+}
+
+// EmitirNotaCredito valida rol y plazo, crea la nota (con su folio) y reingresa el stock
+// Si el reingreso falla la nota queda anulada: conserva su folio y no cuenta contra la venta
+func (s *notaCreditoService) EmitirNotaCredito(ctx context.Context, req *models.EmitirNotaCreditoRequest) (*models.NotaCredito, error) {
+	logger := s.logger.With(
+		zap.String("operation", "emitir_nota_credito"),
+		zap.String("id_operacion_venta", req.IDOperacionVenta),
+		zap.Int("id_autorizador", req.IDAutorizador),
+	)
+
+	if !s.rolAutorizado(req.RolAutorizador) {
+		return nil, fmt.Errorf("%w: %q", ErrRolNoAutorizado, req.RolAutorizador)
+	}
+
+	nota := &models.NotaCredito{
+		IDOperacionVenta: req.IDOperacionVenta,
+		VentaRef:         req.VentaRef,
+		Estado:           models.NotaCreditoEstadoEmitida,
+		Motivo:           req.Motivo,
+		MedioPago:        req.MedioPago,
+		Monto:            req.Monto,
+		IDUsuario:        req.IDUsuario,
+		IDAutorizador:    req.IDAutorizador,
+		RolAutorizador:   req.RolAutorizador,
+	}
+
+	// La validación contra la venta corre dentro de la transacción que crea la nota
+	err := s.repo.CreateNotaCredito(ctx, nota, func(venta *models.VentaAcreditable) error {
+		if venta == nil {
+			return fmt.Errorf("%w: operación %s", ErrVentaNoEncontrada, req.IDOperacionVenta)
+		}
+		limite := inicioDelDia(venta.Fecha).AddDate(0, 0, s.config.MaxDays+1)
+		if !time.Now().Before(limite) {
+			return fmt.Errorf("%w: venta del %s (máximo %d días)",
+				ErrPlazoNotaCreditoVencido, venta.Fecha.Format("2006-01-02"), s.config.MaxDays)
+		}
+
+		items, err := itemsNotaCredito(venta, req.Items)
+		if err != nil {
+			return err
+		}
+		nota.IDLocal = venta.IDLocal
+		nota.Items = items
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger = logger.With(zap.String("numero", nota.Numero), zap.Int64("id_nota", nota.ID))
+
+	idOperacion := uuid.New()
+	documento := &models.DocumentoRespaldo{
+		Tipo:   models.DocumentoNotaCredito,
+		Numero: nota.Numero,
+		Fecha:  nota.CreatedAt.Format("2006-01-02"),
+	}
+	entradas := make([]*models.EntradaStockRequest, 0, len(nota.Items))
+	for _, item := range nota.Items {
+		entradas = append(entradas, &models.EntradaStockRequest{
+			CodigoProducto: item.CodigoProducto,
+			TipoItem:       item.TipoItem,
+			Cantidad:       item.Cantidad,
+			Motivo:         models.MotivoNotaCredito,
+			IDLocal:        nota.IDLocal,
+			Observaciones:  fmt.Sprintf("%s venta %s: %s", nota.Numero, nota.IDOperacionVenta, nota.Motivo),
+			IDUsuario:      nota.IDUsuario,
+			Documento:      documento,
+			IDOperacion:    idOperacion,
+		})
+	}
+
+	if err := s.stockService.MovimientoStockLote(ctx, entradas, nil); err != nil {
+		// La anulación no depende del request: si el cliente cortó la nota no debe quedar emitida
+		if errAnular := s.repo.AnularNotaCredito(context.Background(), nota.ID); errAnular != nil {
+			logger.Error("Error anulando nota de crédito sin reingreso de stock", zap.Error(errAnular))
+		}
+		return nil, fmt.Errorf("error reingresando stock de la nota de crédito %s: %w", nota.Numero, err)
+	}
+
+	if err := s.repo.AplicarNotaCredito(ctx, nota, idOperacion); err != nil {
+		// El stock ya se reingresó: la nota queda emitida para revisión manual
+		logger.Error("Error aplicando nota de crédito con stock reingresado",
+			zap.String("id_operacion", idOperacion), zap.Error(err))
+		return nil, fmt.Errorf("error aplicando nota de crédito %s: %w", nota.Numero, err)
+	}
+	nota.Estado = models.NotaCreditoEstadoAplicada
+	nota.IDOperacion = &idOperacion
+
+	logger.Info("Nota de crédito emitida",
+		zap.Int("id_local", nota.IDLocal),
+		zap.Int("items", len(nota.Items)),
+		zap.Float64("monto", nota.Monto),
+	)
+
+	return nota, nil
+}
+
+// rolAutorizado indica si el rol puede emitir notas de crédito
+func (s *notaCreditoService) rolAutorizado(rol string) bool {
+	for _, permitido := range s.config.AllowedRoles {
+		if strings.EqualFold(strings.TrimSpace(permitido), rol) {
+			return true
+		}
+	}
+	return false
+}
+
+// itemsNotaCredito arma los ítems de la nota contra lo pendiente de la venta
+// Sin ítems solicitados anula todo lo que aún no tiene nota de crédito
+func itemsNotaCredito(venta *models.VentaAcreditable, solicitados []*models.NotaCreditoItem) ([]*models.NotaCreditoItem, error) {
+	pendientes := make(map[string]int, len(venta.Items))
+	for _, item := range venta.Items {
+		pendientes[item.TipoItem+":"+item.CodigoProducto] = item.Vendido - item.Acreditado
+	}
+
+	if len(solicitados) == 0 {
+		items := []*models.NotaCreditoItem{}
+		for _, item := range venta.Items {
+			if pendiente := item.Vendido - item.Acreditado; pendiente > 0 {
+				items = append(items, &models.NotaCreditoItem{
+					CodigoProducto: item.CodigoProducto,
+					TipoItem:       item.TipoItem,
+					Cantidad:       pendiente,
+				})
+			}
+		}
+		if len(items) == 0 {
+			return nil, fmt.Errorf("%w: operación %s", ErrVentaYaAcreditada, venta.IDOperacion)
+		}
+		return items, nil
+	}
+
+	// Un producto repetido en la solicitud se suma antes de comparar con lo pendiente
+	for _, item := range solicitados {
+		clave := item.TipoItem + ":" + item.CodigoProducto
+		pendiente, ok := pendientes[clave]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s no está en la venta", ErrNotaCreditoExcedeVenta, item.CodigoProducto)
+		}
+		if item.Cantidad > pendiente {
+			return nil, fmt.Errorf("%w: %s (solicitado %d, pendiente %d)",
+				ErrNotaCreditoExcedeVenta, item.CodigoProducto, item.Cantidad, pendiente)
+		}
+		pendientes[clave] = pendiente - item.Cantidad
+	}
+
+	return solicitados, nil
+}
+
+// GetNotaCredito obtiene una nota con sus ítems
+func (s *notaCreditoService) GetNotaCredito(ctx context.Context, id int64) (*models.NotaCredito, error) {
+	nota, err := s.repo.GetNotaCredito(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if nota == nil {
+		return nil, ErrNotaCreditoNoEncontrada
+	}
+	return nota, nil
+}
+
+// GetNotasCreditoVenta obtiene las notas emitidas sobre una venta
+func (s *notaCreditoService) GetNotasCreditoVenta(ctx context.Context, idOperacionVenta string) ([]*models.NotaCredito, error) {
+	return s.repo.GetNotasCreditoVenta(ctx, idOperacionVenta)
+}
+
+// GetReporte resume las notas aplicadas del período por local y medio de pago
+func (s *notaCreditoService) GetReporte(ctx context.Context, filter *models.ReporteFilter) (*models.ReporteNotasCredito, error) {
+	resumen, err := s.repo.GetResumenNotasCredito(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	reporte := &models.ReporteNotasCredito{
+		Filtros: filter,
+		Resumen: resumen,
+	}
+	for _, fila := range resumen {
+		reporte.TotalNotas += fila.Notas
+		reporte.MontoTotal += fila.Monto
+	}
+
+	return reporte, nil
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stock-service/internal/cache"
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/outbox"
+	"stock-service/internal/repository"
+	"stock-service/internal/uuid"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// notificacionesKey lista de Redis con los IDs de notificaciones pendientes (se encola por la
+	// izquierda y se toma por la derecha: la más antigua primero)
+	notificacionesKey = "pos:notificaciones_masivas"
+	// notificacionesProcesandoKey notificación tomada por el worker; si la réplica cae vuelve a la cola
+	notificacionesProcesandoKey = "pos:notificaciones_masivas:procesando"
+	// notificacionKeyPrefix estado de cada notificación (se consulta por ID)
+	notificacionKeyPrefix = "pos:notificaciones_masivas:notificacion:"
+	// notificacionTTL tiempo que se conserva el estado de una notificación
+	notificacionTTL = 24 * time.Hour
+	// lockNotificaciones una sola réplica procesa notificaciones a la vez
+	lockNotificaciones = "pos:notificaciones_masivas:worker"
+	// maxDuracionNotificaciones vida máxima del lock del worker
+	maxDuracionNotificaciones = 5 * time.Minute
+	// notificacionesIntervalo cada cuánto el worker revisa la cola
+	notificacionesIntervalo = time.Second
+	// notificacionesCallbacksKey sorted set de Redis con los IDs cuyo resultado falta avisar al
+	// callback; el score es el próximo intento (unix ms)
+	notificacionesCallbacksKey = "pos:notificaciones_masivas:callbacks"
+	// lockCallbacks una sola réplica entrega callbacks a la vez
+	lockCallbacks = "pos:notificaciones_masivas:callbacks:worker"
+	// notificacionEnlace ruta donde el solicitante consulta la notificación
+	notificacionEnlace = "/api/v1/pos/cache/notificaciones/"
+)
+
+// NotificacionMasivaService encola las notificaciones de actualización masiva (lista de precios,
+// productos) para que un único worker las procese en orden, sin que dos invalidaciones se pisen
+// Con callbackURL el resultado se avisa además por POST al terminar (al menos una vez, con los
+// reintentos y la firma de los webhooks)
+type NotificacionMasivaService interface {
+	Encolar(ctx context.Context, tipo, callbackURL string) (*models.NotificacionMasiva, error)
+	GetNotificacion(ctx context.Context, id string) (*models.NotificacionMasiva, error)
+	StartWorker(ctx context.Context)
+}
+
+// notificacionMasivaService implementa NotificacionMasivaService
+type notificacionMasivaService struct {
+	redisClient   *redis.Client
+	productCache  *cache.ProductCache
+	productRepo   repository.ProductRepository
+	botonService  BotonRapidoService
+	barcodeFilter *cache.BarcodeFilter
+	locker        *cache.KeyLocker
+	webhooks      config.WebhooksConfig
+	client        *http.Client
+	logger        *zap.Logger
+}
+
+// NewNotificacionMasivaService crea una nueva instancia del servicio
+func NewNotificacionMasivaService(redisClient *redis.Client, productCache *cache.ProductCache, productRepo repository.ProductRepository, botonService BotonRapidoService, barcodeFilter *cache.BarcodeFilter, webhooks config.WebhooksConfig, logger *zap.Logger) NotificacionMasivaService {
+	return &notificacionMasivaService{
+		redisClient:   redisClient,
+		productCache:  productCache,
+		productRepo:   productRepo,
+		botonService:  botonService,
+		barcodeFilter: barcodeFilter,
+		locker:        cache.NewKeyLocker(redisClient, maxDuracionNotificaciones, 0, logger),
+		webhooks:      webhooks,
+		client:        outbox.NewClienteCallbacks(webhooks.Timeout),
+		logger:        logger,
+	}
+}
+
+// Encolar registra la notificación como pendiente y la agrega a la cola
+func (s *notificacionMasivaService) Encolar(ctx context.Context, tipo, callbackURL string) (*models.NotificacionMasiva, error) {
+	if tipo != models.NotificacionListaPrecios && tipo != models.NotificacionProductos {
+		return nil, fmt.Errorf("%w: %s", ErrNotificacionInvalida, tipo)
+	}
+	if callbackURL != "" {
+		if err := outbox.ValidarDestino(ctx, callbackURL, s.webhooks.CallbackAllowedHosts); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCallbackInvalido, err)
+		}
+	}
+
+	notificacion := &models.NotificacionMasiva{
+		ID:          uuid.New(),
+		Tipo:        tipo,
+		Estado:      models.NotificacionPendiente,
+		EncoladaAt:  time.Now(),
+		CallbackURL: callbackURL,
+	}
+	data, err := json.Marshal(notificacion)
+	if err != nil {
+		return nil, fmt.Errorf("error serializando notificación: %w", err)
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, notificacionKeyPrefix+notificacion.ID, data, notificacionTTL)
+	pipe.LPush(ctx, notificacionesKey, notificacion.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("error encolando notificación: %w", err)
+	}
+
+	s.logger.Info("Notificación de actualización masiva encolada",
+		zap.String("operation", "encolar_notificacion_masiva"),
+		zap.String("id_notificacion", notificacion.ID),
+		zap.String("tipo", tipo))
+
+	return notificacion, nil
+}
+
+// GetNotificacion obtiene el estado de una notificación (se conserva 24 horas)
+func (s *notificacionMasivaService) GetNotificacion(ctx context.Context, id string) (*models.NotificacionMasiva, error) {
+	data, err := s.redisClient.Get(ctx, notificacionKeyPrefix+id).Bytes()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotificacionNoEncontrada, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error consultando notificación: %w", err)
+	}
+
+	var notificacion models.NotificacionMasiva
+	if err := json.Unmarshal(data, &notificacion); err != nil {
+		return nil, fmt.Errorf("error leyendo notificación: %w", err)
+	}
+	return &notificacion, nil
+}
+
+// procesarPendientes procesa la cola de la más antigua a la más reciente
+// Solo una réplica a la vez: si otra tiene el lock, esta no hace nada
+func (s *notificacionMasivaService) procesarPendientes(ctx context.Context) error {
+	release, err := s.locker.Lock(ctx, lockNotificaciones)
+	if err != nil {
+		if errors.Is(err, cache.ErrLockTimeout) {
+			return nil
+		}
+		return err
+	}
+	defer release()
+
+	// Notificación que tomó un worker interrumpido: vuelve a la cola como la más antigua
+	if err := s.recuperarProcesando(ctx); err != nil {
+		return err
+	}
+
+	for ctx.Err() == nil {
+		id, err := s.redisClient.RPopLPush(ctx, notificacionesKey, notificacionesProcesandoKey).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error tomando notificación: %w", err)
+		}
+
+		s.procesar(ctx, id)
+		s.redisClient.LRem(ctx, notificacionesProcesandoKey, 1, id)
+	}
+	return nil
+}
+
+// recuperarProcesando devuelve al final de la cola (se toma primero) la notificación que quedó
+// tomada por un worker interrumpido
+func (s *notificacionMasivaService) recuperarProcesando(ctx context.Context) error {
+	ids, err := s.redisClient.LRange(ctx, notificacionesProcesandoKey, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("error recuperando notificaciones en proceso: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	for _, id := range ids {
+		pipe.RPush(ctx, notificacionesKey, id)
+	}
+	pipe.Del(ctx, notificacionesProcesandoKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("error recuperando notificaciones en proceso: %w", err)
+	}
+
+	s.logger.Warn("Notificaciones de un worker interrumpido devueltas a la cola",
+		zap.String("operation", "procesar_notificacion_masiva"),
+		zap.Strings("ids", ids))
+	return nil
+}
+
+// procesar aplica la invalidación de una notificación y guarda su resultado
+func (s *notificacionMasivaService) procesar(ctx context.Context, id string) {
+	logger := s.logger.With(
+		zap.String("operation", "procesar_notificacion_masiva"),
+		zap.String("id_notificacion", id),
+	)
+
+	notificacion, err := s.GetNotificacion(ctx, id)
+	if err != nil {
+		// Expirada o ilegible: no hay a quién informar el resultado
+		logger.Error("Notificación encolada sin estado, se descarta", zap.Error(err))
+		return
+	}
+
+	iniciada := time.Now()
+	notificacion.Estado = models.NotificacionProcesando
+	notificacion.IniciadaAt = &iniciada
+	s.guardar(ctx, notificacion)
+
+	var resultado *models.ResultadoNotificacionMasiva
+	switch notificacion.Tipo {
+	case models.NotificacionListaPrecios:
+		resultado, err = s.procesarListaPrecios(ctx, logger)
+	case models.NotificacionProductos:
+		resultado, err = s.procesarProductos(ctx, logger)
+	default:
+		err = fmt.Errorf("%w: %s", ErrNotificacionInvalida, notificacion.Tipo)
+	}
+
+	finalizada := time.Now()
+	notificacion.FinalizadaAt = &finalizada
+	if err != nil {
+		logger.Error("Error procesando notificación de actualización masiva", zap.Error(err))
+		notificacion.Estado = models.NotificacionFallida
+		notificacion.Error = err.Error()
+	} else {
+		notificacion.Estado = models.NotificacionCompletada
+		notificacion.Resultado = resultado
+	}
+	if notificacion.CallbackURL != "" {
+		notificacion.Callback = &models.EntregaCallback{Estado: models.CallbackPendiente}
+	}
+	s.guardar(ctx, notificacion)
+
+	// El aviso al callback no demora la cola: lo entrega el worker en la siguiente vuelta
+	if notificacion.Callback != nil {
+		s.programarCallback(ctx, id, finalizada, logger)
+	}
+
+	logger.Info("Notificación de actualización masiva procesada",
+		zap.String("tipo", notificacion.Tipo),
+		zap.String("estado", notificacion.Estado),
+		zap.Duration("duracion", finalizada.Sub(iniciada)))
+}
+
+// procesarListaPrecios invalida la cache si cambió la versión de lista_precios_cantera
+func (s *notificacionMasivaService) procesarListaPrecios(ctx context.Context, logger *zap.Logger) (*models.ResultadoNotificacionMasiva, error) {
+	timestamp, err := s.productRepo.GetLastListaPreciosTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo timestamp de lista_precios: %w", err)
+	}
+	if timestamp == nil {
+		logger.Warn("No se encontró timestamp de lista_precios")
+		return &models.ResultadoNotificacionMasiva{Mensaje: "No hay timestamp disponible"}, nil
+	}
+
+	// El timestamp es la versión global de la cache
+	version := timestamp.Format(time.RFC3339Nano)
+	invalidated, err := s.productCache.InvalidateAllByVersion(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("error invalidando cache por versión: %w", err)
+	}
+	if !invalidated {
+		return &models.ResultadoNotificacionMasiva{Mensaje: "Cache ya está actualizada", Version: version}, nil
+	}
+
+	s.invalidarBotonesRapidos(ctx, logger)
+	return &models.ResultadoNotificacionMasiva{Mensaje: "Cache invalidada correctamente", Version: version, Invalidated: true}, nil
+}
+
+// procesarProductos invalida toda la cache de productos, sin verificar timestamps
+func (s *notificacionMasivaService) procesarProductos(ctx context.Context, logger *zap.Logger) (*models.ResultadoNotificacionMasiva, error) {
+	if err := s.productCache.InvalidateAll(ctx); err != nil {
+		return nil, fmt.Errorf("error invalidando cache: %w", err)
+	}
+	s.invalidarBotonesRapidos(ctx, logger)
+
+	// Altas y bajas masivas: recargar el filtro de existencia sin esperar al intervalo
+	s.barcodeFilter.RequestRebuild()
+
+	return &models.ResultadoNotificacionMasiva{Mensaje: "Cache invalidada correctamente", Invalidated: true}, nil
+}
+
+// invalidarBotonesRapidos invalida las grillas de botones rápidos (incluyen nombre y precio)
+// best effort, la cache expira igual por TTL
+func (s *notificacionMasivaService) invalidarBotonesRapidos(ctx context.Context, logger *zap.Logger) {
+	if err := s.botonService.InvalidarGrillas(ctx); err != nil {
+		logger.Warn("Error invalidando grillas de botones rápidos", zap.Error(err))
+	}
+}
+
+// guardar actualiza el estado de la notificación conservando su TTL
+func (s *notificacionMasivaService) guardar(ctx context.Context, notificacion *models.NotificacionMasiva) {
+	data, err := json.Marshal(notificacion)
+	if err != nil {
+		return
+	}
+	if err := s.redisClient.Set(ctx, notificacionKeyPrefix+notificacion.ID, data, notificacionTTL).Err(); err != nil {
+		s.logger.Warn("Error guardando estado de notificación",
+			zap.String("id_notificacion", notificacion.ID),
+			zap.Error(err))
+	}
+}
+
+// programarCallback agenda el aviso del resultado al callback en el instante indicado
+func (s *notificacionMasivaService) programarCallback(ctx context.Context, id string, cuando time.Time, logger *zap.Logger) {
+	err := s.redisClient.ZAdd(ctx, notificacionesCallbacksKey, &redis.Z{
+		Score:  float64(cuando.UnixMilli()),
+		Member: id,
+	}).Err()
+	if err != nil {
+		// El resultado sigue disponible por polling
+		logger.Error("Error agendando callback de notificación", zap.Error(err))
+	}
+}
+
+// entregarCallbacks avisa los resultados cuyo intento ya venció
+// Solo una réplica a la vez; un ID sale del set recién tras entregarse o agotar los reintentos,
+// así que si la réplica cae a mitad de una entrega se reintenta (al menos una vez)
+func (s *notificacionMasivaService) entregarCallbacks(ctx context.Context) error {
+	release, err := s.locker.Lock(ctx, lockCallbacks)
+	if err != nil {
+		if errors.Is(err, cache.ErrLockTimeout) {
+			return nil
+		}
+		return err
+	}
+	defer release()
+
+	ids, err := s.redisClient.ZRangeByScore(ctx, notificacionesCallbacksKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: int64(s.webhooks.BatchSize),
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("error tomando callbacks pendientes: %w", err)
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return nil
+		}
+		s.entregarCallback(ctx, id)
+	}
+	return nil
+}
+
+// entregarCallback envía el resultado al callback y registra el intento en la notificación
+// Un fallo se reintenta con el backoff de los webhooks hasta agotar WEBHOOK_MAX_ATTEMPTS; un
+// destino que dejó de estar permitido se descarta sin reintentos
+func (s *notificacionMasivaService) entregarCallback(ctx context.Context, id string) {
+	logger := s.logger.With(
+		zap.String("operation", "callback_notificacion_masiva"),
+		zap.String("id_notificacion", id),
+	)
+
+	notificacion, err := s.GetNotificacion(ctx, id)
+	if err != nil || notificacion.Callback == nil {
+		// Expirada: ya no hay resultado que avisar
+		logger.Warn("Callback de una notificación sin estado, se descarta", zap.Error(err))
+		s.redisClient.ZRem(ctx, notificacionesCallbacksKey, id)
+		return
+	}
+
+	// El destino se vuelve a validar al enviar: la lista de hosts pudo cambiar y el DNS también
+	err = outbox.ValidarDestino(ctx, notificacion.CallbackURL, s.webhooks.CallbackAllowedHosts)
+	permanente := err != nil
+	if err == nil {
+		var body []byte
+		body, err = json.Marshal(models.CallbackNotificacionMasiva{
+			Tipo:         models.EventoNotificacionMasivaFinalizada,
+			Notificacion: notificacion,
+			Enlace:       notificacionEnlace + id,
+		})
+		if err == nil {
+			err = outbox.Enviar(ctx, s.client, notificacion.CallbackURL, s.webhooks.Secret, id, models.EventoNotificacionMasivaFinalizada, body)
+		}
+	}
+
+	callback := notificacion.Callback
+	callback.Intentos++
+	switch {
+	case err == nil:
+		entregado := time.Now()
+		callback.Estado = models.CallbackEntregado
+		callback.UltimoError = ""
+		callback.EntregadoAt = &entregado
+	case !permanente && callback.Intentos < s.webhooks.MaxAttempts:
+		callback.UltimoError = err.Error()
+		s.guardar(ctx, notificacion)
+		proximo := time.Now().Add(outbox.Backoff(callback.Intentos))
+		logger.Warn("Entrega de callback fallida, se reintentará",
+			zap.Int("attempts", callback.Intentos),
+			zap.Time("next_attempt", proximo),
+			zap.Error(err))
+		s.programarCallback(ctx, id, proximo, logger)
+		return
+	default:
+		callback.Estado = models.CallbackFallido
+		callback.UltimoError = err.Error()
+		logger.Error("Callback de notificación descartado tras agotar los reintentos",
+			zap.Int("attempts", callback.Intentos),
+			zap.Error(err))
+	}
+
+	s.guardar(ctx, notificacion)
+	s.redisClient.ZRem(ctx, notificacionesCallbacksKey, id)
+}
+
+// StartWorker procesa las notificaciones encoladas y entrega sus callbacks hasta que ctx se cancele
+func (s *notificacionMasivaService) StartWorker(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(notificacionesIntervalo)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				pendientes, err := s.redisClient.LLen(ctx, notificacionesKey).Result()
+				if err == nil && pendientes > 0 {
+					if err := s.procesarPendientes(ctx); err != nil && ctx.Err() == nil {
+						s.logger.Error("Error procesando notificaciones de actualización masiva", zap.Error(err))
+					}
+				}
+
+				callbacks, err := s.redisClient.ZCard(ctx, notificacionesCallbacksKey).Result()
+				if err == nil && callbacks > 0 {
+					if err := s.entregarCallbacks(ctx); err != nil && ctx.Err() == nil {
+						s.logger.Error("Error entregando callbacks de notificaciones", zap.Error(err))
+					}
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// packsLimitePorDefecto tamaño de página del catálogo de packs si no se indica
+	packsLimitePorDefecto = 50
+	// packsLimiteMaximo tamaño máximo de página del catálogo de packs
+	packsLimiteMaximo = 200
+)
+
+// PackService expone el catálogo de packs con sus componentes y precios
+type PackService interface {
+	ListPacks(ctx context.Context, limit, offset int) (*models.PaginaPacks, error)
+	GetPacksPorArticulo(ctx context.Context, codigoArticulo string) ([]*models.PackCatalogo, error)
+}
+
+// packService implementa PackService
+type packService struct {
+	repo   repository.PackRepository
+	logger *zap.Logger
+}
+
+// NewPackService crea una nueva instancia del servicio
+func NewPackService(repo repository.PackRepository, logger *zap.Logger) PackService {
+	return &packService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// ListPacks página del catálogo de packs (limit fuera de rango: el por defecto o el máximo)
+func (s *packService) ListPacks(ctx context.Context, limit, offset int) (*models.PaginaPacks, error) {
+	if limit <= 0 {
+		limit = packsLimitePorDefecto
+	}
+	if limit > packsLimiteMaximo {
+		limit = packsLimiteMaximo
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	total, err := s.repo.CountPacks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	packs, err := s.repo.ListPacks(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginaPacks{
+		Packs:  packs,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// GetPacksPorArticulo packs que incluyen el artículo (por código o código de barras)
+func (s *packService) GetPacksPorArticulo(ctx context.Context, codigoArticulo string) ([]*models.PackCatalogo, error) {
+	return s.repo.GetPacksPorArticulo(ctx, codigoArticulo)
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// PickingService maneja el picking en dos pasos: preparación (reserva) y confirmación (descuento)
+type PickingService interface {
+	PrepararPicking(ctx context.Context, req *models.PrepararPickingRequest) (*models.Picking, error)
+	GetPicking(ctx context.Context, id int) (*models.Picking, error)
+	ConfirmarPicking(ctx context.Context, id int, req *models.ConfirmarPickingRequest) (*models.Picking, error)
+	CancelarPicking(ctx context.Context, id int) (*models.Picking, error)
+	ExpirarPickings(ctx context.Context) (int64, error)
+	StartExpirationWorker(ctx context.Context)
+}
+
+// pickingService implementa PickingService
+type pickingService struct {
+	repo          repository.PickingRepository
+	stockRepo     repository.StockRepository
+	ubicacionRepo repository.UbicacionRepository
+	stockService  StockService
+	config        config.PickingConfig
+	logger        *zap.Logger
+}
+
+// NewPickingService crea una nueva instancia del servicio
+func NewPickingService(repo repository.PickingRepository, stockRepo repository.StockRepository, ubicacionRepo repository.UbicacionRepository, stockService StockService, cfg config.PickingConfig, logger *zap.Logger) PickingService {
+	return &pickingService{
+		repo:          repo,
+		stockRepo:     stockRepo,
+		ubicacionRepo: ubicacionRepo,
+		stockService:  stockService,
+		config:        cfg,
+		logger:        logger,
+	}
+}
+
+// PrepararPicking valida la disponibilidad y reserva los ítems hasta la confirmación o expiración
+func (s *pickingService) PrepararPicking(ctx context.Context, req *models.PrepararPickingRequest) (*models.Picking, error) {
+	logger := s.logger.With(
+		zap.String("operation", "preparar_picking"),
+		zap.Int("id_local", req.IDLocal),
+		zap.Int("cantidad_productos", len(req.Productos)),
+	)
+
+	if err := s.verificarLocal(ctx, req.IDLocal); err != nil {
+		return nil, err
+	}
+	if req.IDLocalDestino != nil {
+		if err := s.verificarLocal(ctx, *req.IDLocalDestino); err != nil {
+			return nil, fmt.Errorf("local destino: %w", err)
+		}
+	}
+
+	// Agrupar por producto (un mismo código puede venir en varias líneas)
+	items := []*models.PickingItem{}
+	porCodigo := make(map[string]*models.PickingItem)
+	for _, producto := range req.Productos {
+		// La reserva se lleva en unidad base
+		cantidad, err := cantidadEnUnidadBase(ctx, s.stockRepo, producto.CodigoProducto, producto.Unidad, producto.Cantidad)
+		if err != nil {
+			return nil, err
+		}
+		if item, ok := porCodigo[producto.CodigoProducto]; ok {
+			item.CantidadSolicitada += cantidad
+			continue
+		}
+		item := &models.PickingItem{
+			CodigoProducto:     producto.CodigoProducto,
+			TipoItem:           producto.TipoItem,
+			CantidadSolicitada: cantidad,
+		}
+		porCodigo[producto.CodigoProducto] = item
+		items = append(items, item)
+	}
+
+	// Verificar disponibilidad descontando lo ya reservado por otras preparaciones
+	for _, item := range items {
+		stock, err := s.stockRepo.GetStockByProducto(ctx, item.CodigoProducto, req.IDLocal)
+		if err != nil {
+			return nil, fmt.Errorf("error obteniendo stock de %s: %w", item.CodigoProducto, err)
+		}
+		reservada, err := s.stockRepo.GetCantidadReservada(ctx, item.CodigoProducto, req.IDLocal)
+		if err != nil {
+			return nil, fmt.Errorf("error obteniendo reserva de %s: %w", item.CodigoProducto, err)
+		}
+
+		disponible := -reservada
+		if stock != nil {
+			disponible += stock.CantidadActual
+		}
+		if disponible < item.CantidadSolicitada {
+			return nil, fmt.Errorf("stock insuficiente para %s: disponible %d, solicitado %d", item.CodigoProducto, disponible, item.CantidadSolicitada)
+		}
+	}
+
+	picking := &models.Picking{
+		IDLocal:        req.IDLocal,
+		IDLocalDestino: req.IDLocalDestino,
+		Estado:         models.PickingEstadoPreparado,
+		Motivo:         req.Motivo,
+		Observaciones:  req.Observaciones,
+		IDUsuario:      req.IDUsuario,
+		ExpiresAt:      time.Now().Add(s.config.TTL),
+		Items:          items,
+	}
+
+	if err := s.repo.CreatePicking(ctx, picking); err != nil {
+		logger.Error("Error creando picking", zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("Picking preparado",
+		zap.Int("id_picking", picking.ID),
+		zap.Time("expires_at", picking.ExpiresAt))
+
+	if err := s.ordenarPorRecorrido(ctx, picking); err != nil {
+		return nil, err
+	}
+	return picking, nil
+}
+
+// GetPicking obtiene una preparación con sus ítems
+// Una preparación vencida que el worker aún no procesó se informa como expirada
+func (s *pickingService) GetPicking(ctx context.Context, id int) (*models.Picking, error) {
+	picking, err := s.repo.GetPickingByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if picking == nil {
+		return nil, fmt.Errorf("%w: %d", ErrPickingNoEncontrado, id)
+	}
+
+	if picking.Estado == models.PickingEstadoPreparado && !picking.ExpiresAt.After(time.Now()) {
+		picking.Estado = models.PickingEstadoExpirado
+	}
+
+	if err := s.ordenarPorRecorrido(ctx, picking); err != nil {
+		return nil, err
+	}
+	return picking, nil
+}
+
+// ConfirmarPicking descuenta el stock con las cantidades realmente pickeadas
+// Las salidas se aplican todas o ninguna; si fallan la preparación vuelve a 'preparado'
+func (s *pickingService) ConfirmarPicking(ctx context.Context, id int, req *models.ConfirmarPickingRequest) (*models.Picking, error) {
+	logger := s.logger.With(
+		zap.String("operation", "confirmar_picking"),
+		zap.Int("id_picking", id),
+	)
+
+	picking, err := s.GetPicking(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cantidades pickeadas: por defecto lo solicitado
+	pickeados := make(map[string]int, len(picking.Items))
+	for _, item := range picking.Items {
+		pickeados[item.CodigoProducto] = item.CantidadSolicitada
+	}
+	for _, producto := range req.Productos {
+		solicitada, ok := pickeados[producto.CodigoProducto]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s no pertenece al picking", ErrCantidadPickeadaInvalida, producto.CodigoProducto)
+		}
+		if producto.CantidadPickeada > solicitada {
+			return nil, fmt.Errorf("%w: %s pickeado %d, reservado %d", ErrCantidadPickeadaInvalida, producto.CodigoProducto, producto.CantidadPickeada, solicitada)
+		}
+	}
+	for _, producto := range req.Productos {
+		pickeados[producto.CodigoProducto] = producto.CantidadPickeada
+	}
+
+	// Tomar la preparación para que no se confirme dos veces ni expire durante el descuento
+	tomado, err := s.repo.CambiarEstado(ctx, id, models.PickingEstadoPreparado, models.PickingEstadoConfirmando)
+	if err != nil {
+		return nil, err
+	}
+	if !tomado {
+		return nil, s.errorEstado(ctx, id)
+	}
+
+	motivo := picking.Motivo
+	if picking.IDLocalDestino != nil {
+		motivo = fmt.Sprintf("%s (despacho a local %d)", picking.Motivo, *picking.IDLocalDestino)
+	}
+
+	salidas := []*models.SalidaStockRequest{}
+	for _, item := range picking.Items {
+		if pickeados[item.CodigoProducto] == 0 {
+			continue
+		}
+		salidas = append(salidas, &models.SalidaStockRequest{
+			CodigoProducto: item.CodigoProducto,
+			TipoItem:       item.TipoItem,
+			Cantidad:       pickeados[item.CodigoProducto],
+			Motivo:         motivo,
+			IDLocal:        picking.IDLocal,
+			Observaciones:  fmt.Sprintf("Picking: %d", picking.ID),
+			IDUsuario:      req.IDUsuario,
+		})
+	}
+
+	if _, err := s.stockService.SalidaStockLote(ctx, salidas); err != nil {
+		logger.Error("Error descontando stock del picking", zap.Error(err))
+		// Liberar la preparación para poder reintentar (con un contexto propio por si el original expiró)
+		if _, errEstado := s.repo.CambiarEstado(context.Background(), id, models.PickingEstadoConfirmando, models.PickingEstadoPreparado); errEstado != nil {
+			logger.Error("Error liberando picking", zap.Error(errEstado))
+		}
+		return nil, err
+	}
+
+	if err := s.repo.ConfirmarPicking(ctx, id, pickeados); err != nil {
+		// El stock ya se descontó: no volver a 'preparado' para no duplicar la salida
+		logger.Error("Error marcando picking como confirmado", zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("Picking confirmado", zap.Int("items_descontados", len(salidas)))
+
+	return s.GetPicking(ctx, id)
+}
+
+// CancelarPicking libera la reserva de una preparación
+func (s *pickingService) CancelarPicking(ctx context.Context, id int) (*models.Picking, error) {
+	cancelado, err := s.repo.CambiarEstado(ctx, id, models.PickingEstadoPreparado, models.PickingEstadoCancelado)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelado {
+		return nil, s.errorEstado(ctx, id)
+	}
+
+	s.logger.Info("Picking cancelado",
+		zap.String("operation", "cancelar_picking"),
+		zap.Int("id_picking", id))
+
+	return s.GetPicking(ctx, id)
+}
+
+// ExpirarPickings marca como expiradas las preparaciones vencidas
+func (s *pickingService) ExpirarPickings(ctx context.Context) (int64, error) {
+	return s.repo.ExpirarPickings(ctx)
+}
+
+// StartExpirationWorker expira periódicamente las preparaciones abandonadas hasta que ctx se cancele
+func (s *pickingService) StartExpirationWorker(ctx context.Context) {
+	logger := s.logger.With(zap.String("operation", "expirar_pickings"))
+
+	go func() {
+		ticker := time.NewTicker(s.config.ExpirationInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				expirados, err := s.ExpirarPickings(ctx)
+				if err != nil {
+					logger.Error("Error expirando pickings", zap.Error(err))
+					continue
+				}
+				if expirados > 0 {
+					logger.Info("Pickings expirados", zap.Int64("cantidad", expirados))
+				}
+			}
+		}
+	}()
+}
+
+// errorEstado construye el error para una transición de estado rechazada
+func (s *pickingService) errorEstado(ctx context.Context, id int) error {
+	picking, err := s.GetPicking(ctx, id)
+	if err != nil {
+		return err
+	}
+	if picking.Estado == models.PickingEstadoExpirado {
+		return fmt.Errorf("%w: %d", ErrPickingExpirado, id)
+	}
+	return fmt.Errorf("%w: picking %d en estado %s", ErrPickingEstadoInvalido, id, picking.Estado)
+}
+
+// verificarLocal verifica que el local exista y esté activo
+func (s *pickingService) verificarLocal(ctx context.Context, idLocal int) error {
+	local, err := s.stockRepo.GetLocalByID(ctx, idLocal)
+	if err != nil {
+		return fmt.Errorf("error verificando local: %w", err)
+	}
+	if local == nil {
+		return fmt.Errorf("%w: %d", ErrLocalNoEncontrado, idLocal)
+	}
+	if !local.Activo {
+		return fmt.Errorf("%w: %d", ErrLocalInactivo, idLocal)
+	}
+	return nil
+}
+
+// ordenarPorRecorrido asigna a cada ítem su ubicación en el local y los ordena por pasillo, rack
+// y nivel para recorrer la bodega una sola vez; los ítems sin ubicación quedan al final
+func (s *pickingService) ordenarPorRecorrido(ctx context.Context, picking *models.Picking) error {
+	codigos := make([]string, len(picking.Items))
+	for i, item := range picking.Items {
+		codigos[i] = item.CodigoProducto
+	}
+
+	ubicaciones, err := s.ubicacionRepo.GetUbicacionesPorCodigo(ctx, picking.IDLocal, codigos)
+	if err != nil {
+		return fmt.Errorf("error obteniendo ubicaciones del picking: %w", err)
+	}
+	for _, item := range picking.Items {
+		if ubicacion, ok := ubicaciones[item.CodigoProducto]; ok {
+			item.Ubicacion = &models.UbicacionFisica{
+				Pasillo: ubicacion.Pasillo,
+				Rack:    ubicacion.Rack,
+				Nivel:   ubicacion.Nivel,
+			}
+		}
+	}
+
+	sort.SliceStable(picking.Items, func(i, j int) bool {
+		return compararRecorrido(picking.Items[i].Ubicacion, picking.Items[j].Ubicacion) < 0
+	})
+	return nil
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// PlantillaService define la interfaz de las plantillas de recepción recurrente
+type PlantillaService interface {
+	CrearPlantilla(ctx context.Context, req *models.PlantillaEntradaRequest) (*models.PlantillaEntrada, error)
+	ActualizarPlantilla(ctx context.Context, id int, req *models.PlantillaEntradaRequest) (*models.PlantillaEntrada, error)
+	EliminarPlantilla(ctx context.Context, id int) error
+	GetPlantilla(ctx context.Context, id int) (*models.PlantillaEntrada, error)
+	GetPlantillas(ctx context.Context, idLocal *int) ([]*models.PlantillaEntrada, error)
+	EjecutarPlantilla(ctx context.Context, id int, req *models.EjecutarPlantillaRequest) (*models.EjecucionPlantillaResponse, error)
+}
+
+// plantillaService implementa PlantillaService
+type plantillaService struct {
+	repo            repository.PlantillaRepository
+	stockRepo       repository.StockRepository
+	stockService    StockService
+	approvalService ApprovalService
+	logger          *zap.Logger
+}
+
+// NewPlantillaService crea una nueva instancia del servicio
+func NewPlantillaService(repo repository.PlantillaRepository, stockRepo repository.StockRepository, stockService StockService, approvalService ApprovalService, logger *zap.Logger) PlantillaService {
+	return &plantillaService{
+		repo:            repo,
+		stockRepo:       stockRepo,
+		stockService:    stockService,
+		approvalService: approvalService,
+		logger:          logger,
+	}
+}
+
+// CrearPlantilla guarda una entrada múltiple recurrente
+func (s *plantillaService) CrearPlantilla(ctx context.Context, req *models.PlantillaEntradaRequest) (*models.PlantillaEntrada, error) {
+	plantilla, err := s.armarPlantilla(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreatePlantilla(ctx, plantilla); err != nil {
+		return nil, fmt.Errorf("error creando plantilla: %w", err)
+	}
+
+	s.logger.Info("Plantilla de entrada creada",
+		zap.String("operation", "crear_plantilla"),
+		zap.Int("id_plantilla", plantilla.ID),
+		zap.Int("id_local", plantilla.IDLocal),
+		zap.Int("cantidad_items", len(plantilla.Items)))
+
+	return plantilla, nil
+}
+
+// ActualizarPlantilla reemplaza los datos y los productos de la plantilla
+func (s *plantillaService) ActualizarPlantilla(ctx context.Context, id int, req *models.PlantillaEntradaRequest) (*models.PlantillaEntrada, error) {
+	plantilla, err := s.armarPlantilla(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	plantilla.ID = id
+
+	found, err := s.repo.UpdatePlantilla(ctx, plantilla)
+	if err != nil {
+		return nil, fmt.Errorf("error actualizando plantilla: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %d", ErrPlantillaNoEncontrada, id)
+	}
+
+	return plantilla, nil
+}
+
+// EliminarPlantilla elimina la plantilla con sus productos
+func (s *plantillaService) EliminarPlantilla(ctx context.Context, id int) error {
+	found, err := s.repo.DeletePlantilla(ctx, id)
+	if err != nil {
+		return fmt.Errorf("error eliminando plantilla: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("%w: %d", ErrPlantillaNoEncontrada, id)
+	}
+	return nil
+}
+
+// GetPlantilla obtiene una plantilla con sus productos
+func (s *plantillaService) GetPlantilla(ctx context.Context, id int) (*models.PlantillaEntrada, error) {
+	plantilla, err := s.repo.GetPlantillaByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo plantilla: %w", err)
+	}
+	if plantilla == nil {
+		return nil, fmt.Errorf("%w: %d", ErrPlantillaNoEncontrada, id)
+	}
+	return plantilla, nil
+}
+
+// GetPlantillas lista las plantillas, opcionalmente de un local
+func (s *plantillaService) GetPlantillas(ctx context.Context, idLocal *int) ([]*models.PlantillaEntrada, error) {
+	plantillas, err := s.repo.GetPlantillas(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo plantillas: %w", err)
+	}
+	return plantillas, nil
+}
+
+// EjecutarPlantilla arma la entrada múltiple de la plantilla con los ajustes de cantidad y la procesa
+// como una entrada múltiple normal: simulación, umbrales de aprobación y documento de respaldo incluidos
+func (s *plantillaService) EjecutarPlantilla(ctx context.Context, id int, req *models.EjecutarPlantillaRequest) (*models.EjecucionPlantillaResponse, error) {
+	logger := s.logger.With(
+		zap.String("operation", "ejecutar_plantilla"),
+		zap.Int("id_plantilla", id),
+		zap.Bool("dry_run", req.DryRun),
+	)
+
+	plantilla, err := s.GetPlantilla(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	entradaReq, err := armarEntradaPlantilla(plantilla, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Operaciones grandes quedan pendientes de aprobación del supervisor (salvo simulaciones)
+	if !req.DryRun {
+		solicitud, err := s.approvalService.EvaluarEntradaMultiple(ctx, entradaReq)
+		if err != nil {
+			return nil, err
+		}
+		if solicitud != nil {
+			logger.Info("Ejecución de plantilla pendiente de aprobación", zap.Int("id_solicitud", solicitud.ID))
+			return &models.EjecucionPlantillaResponse{Solicitud: solicitud}, nil
+		}
+	}
+
+	entrada, err := s.stockService.EntradaMultipleStock(ctx, entradaReq)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Plantilla de entrada ejecutada",
+		zap.Int("productos_procesados", entrada.TotalProductos),
+		zap.Int("productos_fallidos", len(entrada.Errores)))
+
+	return &models.EjecucionPlantillaResponse{Entrada: entrada}, nil
+}
+
+// armarPlantilla valida el local y los productos del request y arma la plantilla
+func (s *plantillaService) armarPlantilla(ctx context.Context, req *models.PlantillaEntradaRequest) (*models.PlantillaEntrada, error) {
+	local, err := s.stockRepo.GetLocalByID(ctx, req.IDLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error verificando local: %w", err)
+	}
+	if local == nil {
+		return nil, fmt.Errorf("%w: %d", ErrLocalNoEncontrado, req.IDLocal)
+	}
+
+	plantilla := &models.PlantillaEntrada{
+		Nombre:        req.Nombre,
+		Proveedor:     req.Proveedor,
+		IDLocal:       req.IDLocal,
+		Motivo:        req.Motivo,
+		Observaciones: req.Observaciones,
+		IDUsuario:     req.IDUsuario,
+		Items:         make([]*models.PlantillaEntradaItem, 0, len(req.Productos)),
+	}
+
+	vistos := make(map[string]bool, len(req.Productos))
+	for _, producto := range req.Productos {
+		if vistos[producto.CodigoProducto] {
+			return nil, fmt.Errorf("%w: producto %s repetido en la plantilla", ErrAjustePlantillaInvalido, producto.CodigoProducto)
+		}
+		vistos[producto.CodigoProducto] = true
+
+		if producto.TipoItem == "producto" {
+			p, err := s.stockRepo.GetProductoByCodigo(ctx, producto.CodigoProducto)
+			if err != nil {
+				return nil, fmt.Errorf("error verificando producto: %w", err)
+			}
+			if p == nil {
+				return nil, fmt.Errorf("%w: %s", ErrProductoNoEncontrado, producto.CodigoProducto)
+			}
+		}
+
+		plantilla.Items = append(plantilla.Items, &models.PlantillaEntradaItem{
+			CodigoProducto: producto.CodigoProducto,
+			TipoItem:       producto.TipoItem,
+			Cantidad:       producto.Cantidad,
+			CantidadMinima: producto.CantidadMinima,
+			Unidad:         normalizarUnidad(producto.Unidad),
+		})
+	}
+
+	return plantilla, nil
+}
+
+// armarEntradaPlantilla construye la entrada múltiple aplicando los ajustes de cantidad
+// Un ajuste en 0 omite el producto en esta ejecución
+func armarEntradaPlantilla(plantilla *models.PlantillaEntrada, req *models.EjecutarPlantillaRequest) (*models.EntradaMultipleStockRequest, error) {
+	enPlantilla := make(map[string]bool, len(plantilla.Items))
+	for _, item := range plantilla.Items {
+		enPlantilla[item.CodigoProducto] = true
+	}
+
+	ajustes := make(map[string]int, len(req.Ajustes))
+	for _, ajuste := range req.Ajustes {
+		if !enPlantilla[ajuste.CodigoProducto] {
+			return nil, fmt.Errorf("%w: el producto %s no pertenece a la plantilla", ErrAjustePlantillaInvalido, ajuste.CodigoProducto)
+		}
+		ajustes[ajuste.CodigoProducto] = ajuste.Cantidad
+	}
+
+	entradaReq := &models.EntradaMultipleStockRequest{
+		Productos:     make([]models.ProductoEntrada, 0, len(plantilla.Items)),
+		Motivo:        plantilla.Motivo,
+		IDLocal:       plantilla.IDLocal,
+		Observaciones: plantilla.Observaciones,
+		IDUsuario:     req.IDUsuario,
+		DryRun:        req.DryRun,
+		Documento:     req.Documento,
+	}
+	if req.IDLocal != nil {
+		entradaReq.IDLocal = *req.IDLocal
+	}
+	if req.Observaciones != "" {
+		entradaReq.Observaciones = req.Observaciones
+	}
+
+	for _, item := range plantilla.Items {
+		cantidad := item.Cantidad
+		if ajustada, ok := ajustes[item.CodigoProducto]; ok {
+			cantidad = ajustada
+		}
+		if cantidad == 0 {
+			continue
+		}
+
+		entradaReq.Productos = append(entradaReq.Productos, models.ProductoEntrada{
+			CodigoProducto: item.CodigoProducto,
+			TipoItem:       item.TipoItem,
+			Cantidad:       cantidad,
+			CantidadMinima: item.CantidadMinima,
+			Unidad:         item.Unidad,
+		})
+	}
+
+	if len(entradaReq.Productos) == 0 {
+		return nil, fmt.Errorf("%w: todos los productos quedaron en cantidad 0", ErrAjustePlantillaInvalido)
+	}
+
+	return entradaReq, nil
+}
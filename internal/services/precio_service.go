@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"stock-service/internal/cache"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// PrecioService consulta el historial de precios de lista_precios_cantera
+// y registra los precios modificados por el cajero en la venta rápida
+// También concilia la cache de productos contra la lista para detectar invalidaciones perdidas
+type PrecioService interface {
+	GetHistorial(ctx context.Context, codigo string, limit int) ([]*models.HistorialPrecio, error)
+	GetPreciosVigentes(ctx context.Context, codigo string, desde, hasta time.Time) ([]*models.HistorialPrecio, error)
+	RegistrarOverrides(ctx context.Context, overrides []*models.OverridePrecio) error
+	GetReporteOverrides(ctx context.Context, filter *models.ReporteFilter) (*models.ReporteOverridesPrecio, error)
+	ConciliarCache(ctx context.Context, muestra int) (*models.ConciliacionPrecios, error)
+	GetConciliaciones(ctx context.Context, limit int) ([]*models.ConciliacionPrecios, error)
+
+	// ConTransaccion servicio que registra los precios modificados en la transacción de repo
+	ConTransaccion(repo repository.StockRepository) PrecioService
+}
+
+// precioService implementa PrecioService
+type precioService struct {
+	repo         repository.PrecioRepository
+	productCache *cache.ProductCache
+	logger       *zap.Logger
+}
+
+// NewPrecioService crea una nueva instancia del servicio
+func NewPrecioService(repo repository.PrecioRepository, productCache *cache.ProductCache, logger *zap.Logger) PrecioService {
+	return &precioService{
+		repo:         repo,
+		productCache: productCache,
+		logger:       logger,
+	}
+}
+
+// ConTransaccion copia del servicio con el repository ligado a la transacción de repo
+func (s *precioService) ConTransaccion(repo repository.StockRepository) PrecioService {
+	tx := *s
+	tx.repo = s.repo.ConTransaccion(repo)
+	return &tx
+}
+
+// GetHistorial obtiene los cambios de precio de un producto
+func (s *precioService) GetHistorial(ctx context.Context, codigo string, limit int) ([]*models.HistorialPrecio, error) {
+	return s.repo.GetHistorial(ctx, codigo, limit)
+}
+
+// GetPreciosVigentes obtiene los precios vigentes en el rango (para auditorías)
+func (s *precioService) GetPreciosVigentes(ctx context.Context, codigo string, desde, hasta time.Time) ([]*models.HistorialPrecio, error) {
+	return s.repo.GetPreciosVigentes(ctx, codigo, desde, hasta)
+}
+
+// RegistrarOverrides registra los precios modificados de una venta con su autorizador
+func (s *precioService) RegistrarOverrides(ctx context.Context, overrides []*models.OverridePrecio) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	if err := s.repo.CreateOverrides(ctx, overrides); err != nil {
+		return fmt.Errorf("error registrando precios modificados: %w", err)
+	}
+
+	s.logger.Info("Precios modificados registrados",
+		zap.String("operation", "registrar_overrides_precio"),
+		zap.Int("id_local", overrides[0].IDLocal),
+		zap.Int("id_autorizador", overrides[0].IDAutorizador),
+		zap.Int("cantidad_lineas", len(overrides)))
+
+	return nil
+}
+
+// GetReporteOverrides reporta los precios modificados del período con el total rebajado y recargado
+func (s *precioService) GetReporteOverrides(ctx context.Context, filter *models.ReporteFilter) (*models.ReporteOverridesPrecio, error) {
+	overrides, err := s.repo.GetOverrides(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo precios modificados: %w", err)
+	}
+
+	reporte := &models.ReporteOverridesPrecio{
+		Desde:          filter.Desde,
+		Hasta:          filter.Hasta,
+		Overrides:      overrides,
+		TotalOverrides: len(overrides),
+	}
+	for _, override := range overrides {
+		if diferencia := override.Diferencia(); diferencia > 0 {
+			reporte.TotalDescuento += diferencia
+		} else {
+			reporte.TotalRecargo -= diferencia
+		}
+	}
+
+	return reporte, nil
+}
+
+// ConciliarCache compara los precios de las entradas en cache (muestra <= 0: todas) contra
+// lista_precios_cantera y registra el resultado en la bitácora. Solo diagnostica: no invalida nada
+func (s *precioService) ConciliarCache(ctx context.Context, muestra int) (*models.ConciliacionPrecios, error) {
+	entradas, err := s.productCache.Entradas(ctx, muestra)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo la cache de productos: %w", err)
+	}
+
+	codigosBarras := make([]string, 0, len(entradas))
+	codigos := make([]string, 0, len(entradas))
+	vistos := make(map[string]bool, len(entradas))
+	for codigoBarras, producto := range entradas {
+		codigosBarras = append(codigosBarras, codigoBarras)
+		if codigo := producto.CodigoLista(); !vistos[codigo] {
+			vistos[codigo] = true
+			codigos = append(codigos, codigo)
+		}
+	}
+	sort.Strings(codigosBarras)
+
+	lista, err := s.repo.GetListaPrecios(ctx, codigos)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo lista de precios: %w", err)
+	}
+
+	conciliacion := &models.ConciliacionPrecios{
+		Revisados:    len(entradas),
+		Divergencias: []models.DivergenciaPrecio{},
+	}
+	if muestra > 0 {
+		conciliacion.Muestra = &muestra
+	}
+
+	ahora := time.Now()
+	for _, codigoBarras := range codigosBarras {
+		if divergencia := compararConLista(codigoBarras, entradas[codigoBarras], lista, ahora); divergencia != nil {
+			conciliacion.Divergencias = append(conciliacion.Divergencias, *divergencia)
+		}
+	}
+	conciliacion.TotalDivergencias = len(conciliacion.Divergencias)
+
+	// La bitácora es best effort: el diagnóstico se responde aunque no se pueda registrar
+	if err := s.repo.CreateConciliacion(ctx, conciliacion); err != nil {
+		s.logger.Warn("Error registrando conciliación de precios en la bitácora", zap.Error(err))
+	}
+
+	logFn := s.logger.Info
+	if conciliacion.TotalDivergencias > 0 {
+		logFn = s.logger.Warn
+	}
+	logFn("Conciliación de cache contra lista de precios",
+		zap.String("operation", "conciliar_cache_precios"),
+		zap.Int("revisados", conciliacion.Revisados),
+		zap.Int("divergencias", conciliacion.TotalDivergencias))
+
+	return conciliacion, nil
+}
+
+// GetConciliaciones obtiene las últimas ejecuciones registradas en la bitácora
+func (s *precioService) GetConciliaciones(ctx context.Context, limit int) ([]*models.ConciliacionPrecios, error) {
+	return s.repo.GetConciliaciones(ctx, limit)
+}
+
+// compararConLista compara una entrada de la cache con su fila de lista_precios_cantera
+// Retorna nil si coinciden (o si el producto no tiene lista ni en cache ni en BD)
+func compararConLista(codigoBarras string, producto *models.ProductoCompleto, lista map[string]*models.PrecioLista, ahora time.Time) *models.DivergenciaPrecio {
+	codigo := producto.CodigoLista()
+	divergencia := &models.DivergenciaPrecio{
+		CodigoBarras:         codigoBarras,
+		CodigoTivendo:        codigo,
+		Motivos:              []string{},
+		CachePrecioDetalle:   producto.ListaPrecioDetalle,
+		CachePrecioMayorista: producto.ListaPrecioMayorista,
+		CacheUpdatedAt:       producto.ListaUpdatedAt,
+	}
+
+	precio, ok := lista[codigo]
+	if !ok {
+		if producto.ListaPrecioDetalle == nil && producto.ListaPrecioMayorista == nil {
+			return nil
+		}
+		divergencia.Motivos = append(divergencia.Motivos, models.DivergenciaSinLista)
+		return divergencia
+	}
+
+	divergencia.ListaPrecioDetalle = precio.PrecioDetalle
+	divergencia.ListaPrecioMayorista = precio.PrecioMayorista
+	divergencia.ListaUpdatedAt = precio.UpdatedAt
+
+	if !mismoPrecio(producto.ListaPrecioDetalle, precio.PrecioDetalle) {
+		divergencia.Motivos = append(divergencia.Motivos, models.DivergenciaPrecioDetalle)
+	}
+	if !mismoPrecio(producto.ListaPrecioMayorista, precio.PrecioMayorista) {
+		divergencia.Motivos = append(divergencia.Motivos, models.DivergenciaPrecioMayorista)
+	}
+	if !mismaFecha(producto.ListaUpdatedAt, precio.UpdatedAt) {
+		divergencia.Motivos = append(divergencia.Motivos, models.DivergenciaVersion)
+	}
+	if len(divergencia.Motivos) == 0 {
+		return nil
+	}
+
+	// Antigüedad: desde que cambió la lista sin que la cache lo recogiera
+	if precio.UpdatedAt != nil && ahora.After(*precio.UpdatedAt) {
+		divergencia.AntiguedadSegundos = int64(ahora.Sub(*precio.UpdatedAt) / time.Second)
+	}
+
+	return divergencia
+}
+
+// mismoPrecio compara dos precios opcionales (al centavo)
+func mismoPrecio(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return math.Abs(*a-*b) < 0.005
+}
+
+// mismaFecha compara dos timestamps opcionales
+func mismaFecha(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
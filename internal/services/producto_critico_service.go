@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// ProductoCriticoService administra los productos críticos y calcula su disponibilidad
+// La alerta de quiebre la genera el movimiento que deja al producto sin stock (ver crearMovimiento)
+type ProductoCriticoService interface {
+	MarcarCritico(ctx context.Context, req *models.MarcarCriticoRequest) (*models.ProductoCritico, error)
+	DesmarcarCritico(ctx context.Context, codigoProducto string, idLocal int) error
+	GetCriticos(ctx context.Context, idLocal *int) ([]*models.ProductoCritico, error)
+	// GetDisponibilidad calcula la disponibilidad en [desde, hasta) contra el SLA
+	// (desde/hasta nil: la ventana configurada hasta ahora)
+	GetDisponibilidad(ctx context.Context, idLocal *int, desde, hasta *time.Time) (*models.ReporteDisponibilidadCriticos, error)
+	GetQuiebresActivos(ctx context.Context, idLocal *int) ([]*models.QuiebreCritico, error)
+}
+
+// productoCriticoService implementa ProductoCriticoService
+type productoCriticoService struct {
+	repo      repository.ProductoCriticoRepository
+	stockRepo repository.StockRepository
+	config    config.CriticalProductsConfig
+	logger    *zap.Logger
+}
+
+// NewProductoCriticoService crea una nueva instancia del servicio
+func NewProductoCriticoService(repo repository.ProductoCriticoRepository, stockRepo repository.StockRepository, cfg config.CriticalProductsConfig, logger *zap.Logger) ProductoCriticoService {
+	return &productoCriticoService{
+		repo:      repo,
+		stockRepo: stockRepo,
+		config:    cfg,
+		logger:    logger,
+	}
+}
+
+// MarcarCritico marca el producto como crítico en el local (o actualiza su SLA)
+func (s *productoCriticoService) MarcarCritico(ctx context.Context, req *models.MarcarCriticoRequest) (*models.ProductoCritico, error) {
+	local, err := s.stockRepo.GetLocalByID(ctx, req.IDLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error verificando local: %w", err)
+	}
+	if local == nil {
+		return nil, fmt.Errorf("%w: %d", ErrLocalNoEncontrado, req.IDLocal)
+	}
+
+	producto, err := s.stockRepo.GetProductoByCodigo(ctx, req.CodigoProducto)
+	if err != nil {
+		return nil, fmt.Errorf("error verificando producto: %w", err)
+	}
+	if producto == nil {
+		return nil, fmt.Errorf("%w: %s", ErrProductoNoEncontrado, req.CodigoProducto)
+	}
+
+	critico := &models.ProductoCritico{
+		CodigoProducto: req.CodigoProducto,
+		NombreProducto: &producto.Nombre,
+		IDLocal:        req.IDLocal,
+		SLAObjetivo:    req.SLAObjetivo,
+	}
+	if err := s.repo.MarcarCritico(ctx, critico); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Producto marcado como crítico",
+		zap.String("operation", "marcar_producto_critico"),
+		zap.String("codigo_producto", req.CodigoProducto),
+		zap.Int("id_local", req.IDLocal))
+
+	return critico, nil
+}
+
+// DesmarcarCritico quita la marca de crítico del producto en el local
+func (s *productoCriticoService) DesmarcarCritico(ctx context.Context, codigoProducto string, idLocal int) error {
+	eliminado, err := s.repo.DesmarcarCritico(ctx, codigoProducto, idLocal)
+	if err != nil {
+		return err
+	}
+	if !eliminado {
+		return fmt.Errorf("%w: %s local %d", ErrProductoCriticoNoEncontrado, codigoProducto, idLocal)
+	}
+	return nil
+}
+
+// GetCriticos lista los productos críticos
+func (s *productoCriticoService) GetCriticos(ctx context.Context, idLocal *int) ([]*models.ProductoCritico, error) {
+	return s.repo.GetCriticos(ctx, idLocal)
+}
+
+// GetDisponibilidad calcula la disponibilidad de los productos críticos contra su SLA
+func (s *productoCriticoService) GetDisponibilidad(ctx context.Context, idLocal *int, desde, hasta *time.Time) (*models.ReporteDisponibilidadCriticos, error) {
+	// El período no se extiende al futuro: el tiempo sin transcurrir no cuenta como disponible
+	now := time.Now()
+	fin := now
+	if hasta != nil && hasta.Before(now) {
+		fin = *hasta
+	}
+	inicio := fin.AddDate(0, 0, -s.config.WindowDays)
+	if desde != nil {
+		inicio = *desde
+	}
+
+	detalle, err := s.repo.GetDisponibilidad(ctx, idLocal, inicio, fin)
+	if err != nil {
+		return nil, err
+	}
+	quiebres, err := s.repo.GetQuiebresActivos(ctx, idLocal)
+	if err != nil {
+		return nil, err
+	}
+	sinStockDesde := make(map[stockKey]time.Time, len(quiebres))
+	for _, quiebre := range quiebres {
+		sinStockDesde[stockKey{codigoProducto: quiebre.CodigoProducto, idLocal: quiebre.IDLocal}] = quiebre.Desde
+	}
+
+	reporte := &models.ReporteDisponibilidadCriticos{
+		Desde:     inicio,
+		Hasta:     fin,
+		Productos: len(detalle),
+		Detalle:   detalle,
+	}
+	for _, fila := range detalle {
+		if fila.SLAObjetivo == 0 {
+			fila.SLAObjetivo = s.config.SLATargetPercent
+		}
+		fila.CumpleSLA = fila.Disponibilidad >= fila.SLAObjetivo
+		if !fila.CumpleSLA {
+			reporte.Incumplen++
+		}
+		if desdeQuiebre, ok := sinStockDesde[stockKey{codigoProducto: fila.CodigoProducto, idLocal: fila.IDLocal}]; ok {
+			fila.SinStockDesde = &desdeQuiebre
+			reporte.SinStock++
+		}
+	}
+
+	return reporte, nil
+}
+
+// GetQuiebresActivos retorna los productos críticos hoy sin stock
+func (s *productoCriticoService) GetQuiebresActivos(ctx context.Context, idLocal *int) ([]*models.QuiebreCritico, error) {
+	return s.repo.GetQuiebresActivos(ctx, idLocal)
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// ReglaOperacionService administra las reglas que bloquean o retienen operaciones y las evalúa
+// Las entradas y salidas se evalúan en ApprovalService; las ventas del POS, al validar la venta
+type ReglaOperacionService interface {
+	CreateRegla(ctx context.Context, req *models.ReglaOperacionRequest) (*models.ReglaOperacion, error)
+	UpdateRegla(ctx context.Context, id int, req *models.ReglaOperacionRequest) (*models.ReglaOperacion, error)
+	DeleteRegla(ctx context.Context, id int) error
+	GetRegla(ctx context.Context, id int) (*models.ReglaOperacion, error)
+	GetReglas(ctx context.Context, idLocal *int) ([]*models.ReglaOperacion, error)
+	// Evaluar retorna las reglas activas del local que la operación incumple
+	Evaluar(ctx context.Context, op *models.OperacionEvaluada) (*models.EvaluacionReglas, error)
+}
+
+// reglaOperacionService implementa ReglaOperacionService
+type reglaOperacionService struct {
+	repo      repository.ReglaOperacionRepository
+	stockRepo repository.StockRepository
+	logger    *zap.Logger
+}
+
+// NewReglaOperacionService crea una nueva instancia del servicio
+func NewReglaOperacionService(repo repository.ReglaOperacionRepository, stockRepo repository.StockRepository, logger *zap.Logger) ReglaOperacionService {
+	return &reglaOperacionService{
+		repo:      repo,
+		stockRepo: stockRepo,
+		logger:    logger,
+	}
+}
+
+// CreateRegla valida los parámetros del tipo y registra la regla
+func (s *reglaOperacionService) CreateRegla(ctx context.Context, req *models.ReglaOperacionRequest) (*models.ReglaOperacion, error) {
+	regla, err := nuevaReglaOperacion(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRegla(ctx, regla); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Regla de operación creada",
+		zap.String("operation", "crear_regla_operacion"),
+		zap.Int("id_regla", regla.ID),
+		zap.String("tipo", regla.Tipo),
+		zap.String("accion", regla.Accion))
+
+	return regla, nil
+}
+
+// UpdateRegla reemplaza la regla
+func (s *reglaOperacionService) UpdateRegla(ctx context.Context, id int, req *models.ReglaOperacionRequest) (*models.ReglaOperacion, error) {
+	regla, err := nuevaReglaOperacion(req)
+	if err != nil {
+		return nil, err
+	}
+	regla.ID = id
+
+	actualizada, err := s.repo.UpdateRegla(ctx, regla)
+	if err != nil {
+		return nil, err
+	}
+	if !actualizada {
+		return nil, fmt.Errorf("%w: %d", ErrReglaNoEncontrada, id)
+	}
+
+	s.logger.Info("Regla de operación actualizada",
+		zap.String("operation", "actualizar_regla_operacion"),
+		zap.Int("id_regla", id),
+		zap.Bool("activa", regla.Activa))
+
+	return regla, nil
+}
+
+// DeleteRegla elimina la regla
+func (s *reglaOperacionService) DeleteRegla(ctx context.Context, id int) error {
+	eliminada, err := s.repo.DeleteRegla(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !eliminada {
+		return fmt.Errorf("%w: %d", ErrReglaNoEncontrada, id)
+	}
+	return nil
+}
+
+// GetRegla obtiene una regla por ID
+func (s *reglaOperacionService) GetRegla(ctx context.Context, id int) (*models.ReglaOperacion, error) {
+	regla, err := s.repo.GetReglaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if regla == nil {
+		return nil, fmt.Errorf("%w: %d", ErrReglaNoEncontrada, id)
+	}
+	return regla, nil
+}
+
+// GetReglas lista las reglas
+func (s *reglaOperacionService) GetReglas(ctx context.Context, idLocal *int) ([]*models.ReglaOperacion, error) {
+	return s.repo.GetReglas(ctx, idLocal)
+}
+
+// Evaluar aplica cada regla activa del local a la operación
+func (s *reglaOperacionService) Evaluar(ctx context.Context, op *models.OperacionEvaluada) (*models.EvaluacionReglas, error) {
+	evaluacion := &models.EvaluacionReglas{Incumplidas: []models.ReglaIncumplida{}}
+
+	reglas, err := s.repo.GetReglasActivas(ctx, op.IDLocal)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, regla := range reglas {
+		detalle, err := s.evaluarRegla(ctx, regla, op)
+		if err != nil {
+			return nil, err
+		}
+		if detalle == "" {
+			continue
+		}
+		evaluacion.Incumplidas = append(evaluacion.Incumplidas, models.ReglaIncumplida{
+			IDRegla: regla.ID,
+			Nombre:  regla.Nombre,
+			Tipo:    regla.Tipo,
+			Accion:  regla.Accion,
+			Detalle: detalle,
+		})
+	}
+
+	if len(evaluacion.Incumplidas) > 0 {
+		s.logger.Info("Operación incumple reglas",
+			zap.String("operation", "evaluar_reglas_operacion"),
+			zap.String("tipo_operacion", op.Operacion),
+			zap.Int("id_local", op.IDLocal),
+			zap.Int("reglas_incumplidas", len(evaluacion.Incumplidas)),
+			zap.Bool("bloqueada", evaluacion.Bloqueada()))
+	}
+
+	return evaluacion, nil
+}
+
+// evaluarRegla retorna por qué la operación incumple la regla, o vacío si la cumple
+func (s *reglaOperacionService) evaluarRegla(ctx context.Context, regla *models.ReglaOperacion, op *models.OperacionEvaluada) (string, error) {
+	switch regla.Tipo {
+	case models.ReglaSalidaPorcentajeStock:
+		if op.Operacion != models.OperacionReglaSalida || regla.Porcentaje == nil {
+			return "", nil
+		}
+		for _, item := range op.Items {
+			if item.TipoItem == "pack" {
+				continue
+			}
+			stock, err := s.stockRepo.GetStockByProducto(ctx, item.CodigoProducto, op.IDLocal)
+			if err != nil {
+				return "", fmt.Errorf("error obteniendo stock de %s: %w", item.CodigoProducto, err)
+			}
+			// Sin stock la salida ya falla por stock insuficiente
+			if stock == nil || stock.CantidadActual <= 0 {
+				continue
+			}
+			porcentaje := float64(item.Cantidad) * 100 / float64(stock.CantidadActual)
+			if porcentaje > *regla.Porcentaje {
+				return fmt.Sprintf("salida de %d de %s es el %.1f%% del stock del local (máximo %.1f%%)",
+					item.Cantidad, item.CodigoProducto, porcentaje, *regla.Porcentaje), nil
+			}
+		}
+
+	case models.ReglaEntradaFueraHorario:
+		if op.Operacion != models.OperacionReglaEntrada || regla.HoraDesde == nil || regla.HoraHasta == nil {
+			return "", nil
+		}
+		if !dentroDeHorario(op.Fecha.Hour(), *regla.HoraDesde, *regla.HoraHasta) {
+			return fmt.Sprintf("entrada a las %s fuera del horario permitido (%02d:00 a %02d:00)",
+				op.Fecha.Format("15:04"), *regla.HoraDesde, *regla.HoraHasta), nil
+		}
+
+	case models.ReglaAjusteCantidad:
+		if op.Operacion == models.OperacionReglaVenta || !esAjuste(op.Motivo) || regla.Cantidad == nil {
+			return "", nil
+		}
+		for _, item := range op.Items {
+			if item.Cantidad > *regla.Cantidad {
+				return fmt.Sprintf("ajuste de %d unidades de %s supera el máximo de %d",
+					item.Cantidad, item.CodigoProducto, *regla.Cantidad), nil
+			}
+		}
+
+	case models.ReglaVentaBajoCosto:
+		if op.Operacion != models.OperacionReglaVenta {
+			return "", nil
+		}
+		for _, item := range op.Items {
+			// Sin utilidad en el maestro el costo no es calculable
+			if item.Precio == nil || item.Costo == nil {
+				continue
+			}
+			if *item.Precio < *item.Costo {
+				return fmt.Sprintf("venta de %s a %.2f bajo su costo de %.2f",
+					item.CodigoProducto, *item.Precio, *item.Costo), nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// nuevaReglaOperacion arma la regla desde el request, exigiendo los parámetros de su tipo
+// y descartando los que el tipo no usa
+func nuevaReglaOperacion(req *models.ReglaOperacionRequest) (*models.ReglaOperacion, error) {
+	regla := &models.ReglaOperacion{
+		Nombre:  strings.TrimSpace(req.Nombre),
+		Tipo:    req.Tipo,
+		Accion:  req.Accion,
+		IDLocal: req.IDLocal,
+		Activa:  req.Activa == nil || *req.Activa,
+	}
+
+	switch req.Tipo {
+	case models.ReglaSalidaPorcentajeStock:
+		if req.Porcentaje == nil || *req.Porcentaje > 100 {
+			return nil, fmt.Errorf("%w: %s requiere porcentaje entre 0 y 100", ErrReglaInvalida, req.Tipo)
+		}
+		regla.Porcentaje = req.Porcentaje
+	case models.ReglaEntradaFueraHorario:
+		if req.HoraDesde == nil || req.HoraHasta == nil || *req.HoraDesde == *req.HoraHasta {
+			return nil, fmt.Errorf("%w: %s requiere hora_desde y hora_hasta distintas", ErrReglaInvalida, req.Tipo)
+		}
+		regla.HoraDesde = req.HoraDesde
+		regla.HoraHasta = req.HoraHasta
+	case models.ReglaAjusteCantidad:
+		if req.Cantidad == nil {
+			return nil, fmt.Errorf("%w: %s requiere cantidad", ErrReglaInvalida, req.Tipo)
+		}
+		regla.Cantidad = req.Cantidad
+	}
+
+	return regla, nil
+}
+
+// dentroDeHorario indica si la hora cae en [desde, hasta); si desde > hasta el horario cruza medianoche
+func dentroDeHorario(hora, desde, hasta int) bool {
+	if desde < hasta {
+		return hora >= desde && hora < hasta
+	}
+	return hora >= desde || hora < hasta
+}
+
+// esAjuste indica si el motivo de la operación corresponde a un ajuste de inventario
+func esAjuste(motivo string) bool {
+	return strings.Contains(strings.ToLower(motivo), "ajuste")
+}
+
+// errorReglasBloqueo arma el error de una operación bloqueada con el detalle de las reglas
+func errorReglasBloqueo(evaluacion *models.EvaluacionReglas) error {
+	return fmt.Errorf("%w: %s", ErrOperacionBloqueada, describirReglas(evaluacion, models.ReglaAccionBloquear))
+}
+
+// describirReglas resume las reglas incumplidas con la acción indicada
+func describirReglas(evaluacion *models.EvaluacionReglas, accion string) string {
+	var detalles []string
+	for _, regla := range evaluacion.Incumplidas {
+		if regla.Accion == accion {
+			detalles = append(detalles, fmt.Sprintf("%s (%s)", regla.Nombre, regla.Detalle))
+		}
+	}
+	return strings.Join(detalles, "; ")
+}
+
+// nuevaOperacionEvaluada arma la operación a evaluar con la fecha actual
+func nuevaOperacionEvaluada(operacion string, idLocal int, motivo string, items []models.ItemEvaluado) *models.OperacionEvaluada {
+	return &models.OperacionEvaluada{
+		Operacion: operacion,
+		IDLocal:   idLocal,
+		Motivo:    motivo,
+		Fecha:     time.Now(),
+		Items:     items,
+	}
+}
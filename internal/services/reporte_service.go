@@ -0,0 +1,405 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// ReporteService genera los reportes de gestión
+type ReporteService interface {
+	GetReporteMargenes(ctx context.Context, filter *models.ReporteFilter) (*models.ReporteMargenes, error)
+	GetReporteActividad(ctx context.Context, filter *models.ReporteFilter) (*models.ReporteActividadUsuarios, error)
+	// GetCierreDia cierre consolidado del local en el día de fecha; montoDeclarado (opcional) es lo
+	// contado en caja, para calcular la diferencia contra lo esperado
+	GetCierreDia(ctx context.Context, idLocal int, fecha time.Time, montoDeclarado *float64) (*models.CierreDia, error)
+
+	// Agregados diarios de movimientos
+	GetEstadoAgregados(ctx context.Context) (*models.EstadoAgregados, error)
+	RecalcularAgregados(ctx context.Context, desde time.Time) error
+	StartAggregationWorker(ctx context.Context)
+}
+
+// Criterios para marcar actividad anómala de un usuario
+const (
+	// Desviaciones estándar sobre el promedio de los usuarios para considerar un volumen atípico
+	anomaliaDesviaciones = 2.0
+	// Mínimo de usuarios para que la comparación entre usuarios tenga sentido
+	anomaliaMinUsuarios = 3
+	// Proporción de ajustes sobre el total de movimientos de un usuario
+	anomaliaProporcionAjustes = 0.2
+)
+
+// reporteService implementa ReporteService
+type reporteService struct {
+	repo   repository.ReporteRepository
+	config config.ReportAggregatesConfig
+	logger *zap.Logger
+}
+
+// NewReporteService crea una nueva instancia del servicio
+func NewReporteService(repo repository.ReporteRepository, cfg config.ReportAggregatesConfig, logger *zap.Logger) ReporteService {
+	return &reporteService{
+		repo:   repo,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// GetReporteMargenes calcula el margen bruto por producto y por categoría
+// identificando los productos vendidos bajo costo
+func (s *reporteService) GetReporteMargenes(ctx context.Context, filter *models.ReporteFilter) (*models.ReporteMargenes, error) {
+	agregados, err := s.coberturaAgregados(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	ventas, err := s.repo.GetVentasPorProducto(ctx, filter, agregados)
+	if err != nil {
+		return nil, err
+	}
+
+	reporte := &models.ReporteMargenes{
+		Filtros:            *filter,
+		Agregados:          agregados,
+		Productos:          make([]*models.MargenProducto, 0, len(ventas)),
+		Categorias:         []*models.MargenCategoria{},
+		ProductosBajoCosto: []string{},
+	}
+
+	categorias := make(map[string]*models.MargenCategoria)
+	for _, venta := range ventas {
+		margen := &models.MargenProducto{
+			CodigoProducto: venta.CodigoProducto,
+			NombreProducto: venta.NombreProducto,
+			IDCategoria:    venta.IDCategoria,
+			Unidades:       venta.Unidades,
+			VentaTotal:     redondear(venta.VentaTotal),
+		}
+		if venta.Unidades > 0 {
+			margen.PrecioPromedio = redondear(venta.VentaTotal / float64(venta.Unidades))
+		}
+
+		costo := venta.CostoUnitario()
+		if costo == nil {
+			margen.SinCosto = true
+		} else {
+			margen.CostoUnitario = costo
+			margen.CostoTotal = redondear(*costo * float64(venta.Unidades))
+			margen.MargenBruto = redondear(margen.VentaTotal - margen.CostoTotal)
+			margen.MargenPorcentaje = porcentaje(margen.MargenBruto, margen.VentaTotal)
+			if margen.MargenBruto < 0 {
+				margen.BajoCosto = true
+				reporte.ProductosBajoCosto = append(reporte.ProductosBajoCosto, venta.CodigoProducto)
+			}
+		}
+		reporte.Productos = append(reporte.Productos, margen)
+
+		// Agregar por categoría (solo productos con costo conocido suman al margen)
+		nombreCategoria := "Sin categoría"
+		if venta.NombreCategoria != nil {
+			nombreCategoria = *venta.NombreCategoria
+		}
+		categoria, ok := categorias[nombreCategoria]
+		if !ok {
+			categoria = &models.MargenCategoria{
+				IDCategoria:     venta.IDCategoria,
+				NombreCategoria: nombreCategoria,
+			}
+			categorias[nombreCategoria] = categoria
+		}
+		categoria.Unidades += margen.Unidades
+		if !margen.SinCosto {
+			categoria.VentaTotal += margen.VentaTotal
+			categoria.CostoTotal += margen.CostoTotal
+			reporte.VentaTotal += margen.VentaTotal
+			reporte.CostoTotal += margen.CostoTotal
+		}
+	}
+
+	for _, categoria := range categorias {
+		categoria.VentaTotal = redondear(categoria.VentaTotal)
+		categoria.CostoTotal = redondear(categoria.CostoTotal)
+		categoria.MargenBruto = redondear(categoria.VentaTotal - categoria.CostoTotal)
+		categoria.MargenPorcentaje = porcentaje(categoria.MargenBruto, categoria.VentaTotal)
+		reporte.Categorias = append(reporte.Categorias, categoria)
+	}
+	sort.Slice(reporte.Categorias, func(i, j int) bool {
+		return reporte.Categorias[i].MargenBruto > reporte.Categorias[j].MargenBruto
+	})
+
+	reporte.VentaTotal = redondear(reporte.VentaTotal)
+	reporte.CostoTotal = redondear(reporte.CostoTotal)
+	reporte.MargenBruto = redondear(reporte.VentaTotal - reporte.CostoTotal)
+	reporte.MargenPorcentaje = porcentaje(reporte.MargenBruto, reporte.VentaTotal)
+
+	s.logger.Info("Reporte de márgenes generado",
+		zap.String("operation", "reporte_margenes"),
+		zap.Int("productos", len(reporte.Productos)),
+		zap.Int("bajo_costo", len(reporte.ProductosBajoCosto)))
+
+	return reporte, nil
+}
+
+// GetReporteActividad resume por usuario las entradas, salidas, ajustes y ventas del período
+// y marca patrones anómalos respecto del resto de los usuarios
+func (s *reporteService) GetReporteActividad(ctx context.Context, filter *models.ReporteFilter) (*models.ReporteActividadUsuarios, error) {
+	agregados, err := s.coberturaAgregados(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	filas, err := s.repo.GetActividadPorUsuario(ctx, filter, agregados)
+	if err != nil {
+		return nil, err
+	}
+
+	reporte := &models.ReporteActividadUsuarios{
+		Filtros:   *filter,
+		Usuarios:  []*models.ActividadUsuario{},
+		Agregados: agregados,
+	}
+
+	porUsuario := make(map[int]*models.ActividadUsuario)
+	for _, fila := range filas {
+		usuario, ok := porUsuario[fila.IDUsuario]
+		if !ok {
+			usuario = &models.ActividadUsuario{
+				IDUsuario:  fila.IDUsuario,
+				Categorias: make(map[string]*models.ResumenActividad),
+			}
+			porUsuario[fila.IDUsuario] = usuario
+			reporte.Usuarios = append(reporte.Usuarios, usuario)
+		}
+
+		usuario.Categorias[fila.Categoria] = &models.ResumenActividad{
+			Movimientos: fila.Movimientos,
+			Unidades:    fila.Unidades,
+			Monto:       redondear(fila.Monto),
+		}
+		usuario.Total.Movimientos += fila.Movimientos
+		usuario.Total.Unidades += fila.Unidades
+		usuario.Total.Monto = redondear(usuario.Total.Monto + fila.Monto)
+
+		reporte.Total.Movimientos += fila.Movimientos
+		reporte.Total.Unidades += fila.Unidades
+		reporte.Total.Monto = redondear(reporte.Total.Monto + fila.Monto)
+	}
+
+	marcarAnomalias(reporte.Usuarios)
+
+	return reporte, nil
+}
+
+// GetCierreDia consolida el día del local desde las ventas, notas de crédito y movimientos
+func (s *reporteService) GetCierreDia(ctx context.Context, idLocal int, fecha time.Time, montoDeclarado *float64) (*models.CierreDia, error) {
+	desde := inicioDelDia(fecha)
+	cierre, err := s.repo.GetCierreDia(ctx, idLocal, desde, desde.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	cierre.Fecha = desde.Format("2006-01-02")
+	cierre.GeneradoAt = time.Now()
+	cierre.Ventas.MontoProductos = redondear(cierre.Ventas.MontoProductos)
+	cierre.Ventas.MontoOtros = redondear(cierre.Ventas.MontoOtros)
+	cierre.Ventas.Total = redondear(cierre.Ventas.MontoProductos + cierre.Ventas.MontoOtros)
+	cierre.Devoluciones.Monto = redondear(cierre.Devoluciones.Monto)
+
+	cierre.Caja.Esperado = redondear(cierre.Ventas.Total - cierre.Devoluciones.Monto)
+	if montoDeclarado != nil {
+		declarado := redondear(*montoDeclarado)
+		diferencia := redondear(declarado - cierre.Caja.Esperado)
+		cierre.Caja.Declarado = &declarado
+		cierre.Caja.Diferencia = &diferencia
+	}
+
+	s.logger.Info("Cierre diario generado",
+		zap.String("operation", "cierre_dia"),
+		zap.Int("id_local", idLocal),
+		zap.String("fecha", cierre.Fecha),
+		zap.Int("ventas", cierre.Ventas.Ventas),
+		zap.Int("quebrados", len(cierre.Quebrados)))
+
+	return cierre, nil
+}
+
+// coberturaAgregados determina los días completos del período que ya están agregados
+// (nil si ninguno: el reporte se calcula solo desde los movimientos)
+func (s *reporteService) coberturaAgregados(ctx context.Context, filter *models.ReporteFilter) (*models.CoberturaAgregados, error) {
+	estado, err := s.repo.GetEstadoAgregados(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if estado.Hasta == nil {
+		return nil, nil
+	}
+
+	// Los días del filtro se interpretan en su zona, igual que al comparar con created_at
+	loc := filter.Desde.Location()
+	desde := inicioDelDia(filter.Desde)
+	if desde.Before(filter.Desde) {
+		desde = desde.AddDate(0, 0, 1)
+	}
+	hasta := inicioDelDia(filter.Hasta)
+	marca := time.Date(estado.Hasta.Year(), estado.Hasta.Month(), estado.Hasta.Day(), 0, 0, 0, 0, loc)
+	if marca.Before(hasta) {
+		hasta = marca
+	}
+	if !hasta.After(desde) {
+		return nil, nil
+	}
+
+	return &models.CoberturaAgregados{
+		Desde: desde,
+		Hasta: hasta,
+		Dias:  int(math.Round(hasta.Sub(desde).Hours() / 24)),
+	}, nil
+}
+
+// GetEstadoAgregados retorna hasta qué día están agregados los movimientos
+func (s *reporteService) GetEstadoAgregados(ctx context.Context) (*models.EstadoAgregados, error) {
+	return s.repo.GetEstadoAgregados(ctx)
+}
+
+// RecalcularAgregados descarta los agregados desde la fecha; el job los vuelve a generar
+// Mientras tanto los reportes leen esos días desde los movimientos
+func (s *reporteService) RecalcularAgregados(ctx context.Context, desde time.Time) error {
+	if err := s.repo.DescartarAgregadosDesde(ctx, inicioDelDia(desde)); err != nil {
+		return err
+	}
+
+	s.logger.Warn("Agregados diarios descartados para recalcular",
+		zap.String("operation", "recalcular_agregados"),
+		zap.Time("desde", desde))
+	return nil
+}
+
+// StartAggregationWorker agrega periódicamente los días cerrados pendientes hasta que ctx
+// se cancele (no hace nada si está deshabilitado)
+func (s *reporteService) StartAggregationWorker(ctx context.Context) {
+	if !s.config.Enabled {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			s.agregarPendientes(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// agregarPendientes ejecuta una ronda de agregación (a lo sumo ChunkDays días)
+func (s *reporteService) agregarPendientes(ctx context.Context) {
+	start := time.Now()
+	resultado, err := s.repo.AgregarDias(ctx, s.config.ChunkDays)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error("Error agregando movimientos diarios",
+				zap.String("operation", "agregar_movimientos"),
+				zap.Error(err))
+		}
+		return
+	}
+	if resultado == nil {
+		return
+	}
+
+	s.logger.Info("Movimientos diarios agregados",
+		zap.String("operation", "agregar_movimientos"),
+		zap.Time("desde", resultado.Desde),
+		zap.Time("hasta", resultado.Hasta),
+		zap.Int("filas", resultado.Filas),
+		zap.Duration("duration", time.Since(start)))
+}
+
+// inicioDelDia retorna la medianoche del día de t en su zona
+func inicioDelDia(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// marcarAnomalias marca usuarios con volúmenes atípicos o con demasiados ajustes
+func marcarAnomalias(usuarios []*models.ActividadUsuario) {
+	categorias := []string{models.ActividadEntrada, models.ActividadSalida, models.ActividadAjuste, models.ActividadVenta}
+
+	if len(usuarios) >= anomaliaMinUsuarios {
+		for _, categoria := range categorias {
+			unidades := make([]float64, len(usuarios))
+			for i, usuario := range usuarios {
+				if resumen, ok := usuario.Categorias[categoria]; ok {
+					unidades[i] = float64(resumen.Unidades)
+				}
+			}
+
+			promedio, desviacion := estadisticas(unidades)
+			if desviacion == 0 {
+				continue
+			}
+			for i, usuario := range usuarios {
+				if unidades[i] > promedio+anomaliaDesviaciones*desviacion {
+					usuario.Anomalias = append(usuario.Anomalias,
+						fmt.Sprintf("volumen de %s atípico: %.0f unidades (promedio %.1f)", categoria, unidades[i], promedio))
+				}
+			}
+		}
+	}
+
+	for _, usuario := range usuarios {
+		ajustes, ok := usuario.Categorias[models.ActividadAjuste]
+		if !ok || usuario.Total.Movimientos == 0 {
+			continue
+		}
+		proporcion := float64(ajustes.Movimientos) / float64(usuario.Total.Movimientos)
+		if proporcion > anomaliaProporcionAjustes {
+			usuario.Anomalias = append(usuario.Anomalias,
+				fmt.Sprintf("%.0f%% de sus movimientos son ajustes", proporcion*100))
+		}
+	}
+}
+
+// estadisticas calcula promedio y desviación estándar poblacional
+func estadisticas(valores []float64) (float64, float64) {
+	if len(valores) == 0 {
+		return 0, 0
+	}
+
+	suma := 0.0
+	for _, v := range valores {
+		suma += v
+	}
+	promedio := suma / float64(len(valores))
+
+	varianza := 0.0
+	for _, v := range valores {
+		varianza += (v - promedio) * (v - promedio)
+	}
+	return promedio, math.Sqrt(varianza / float64(len(valores)))
+}
+
+// redondear redondea montos a 2 decimales
+func redondear(valor float64) float64 {
+	return math.Round(valor*100) / 100
+}
+
+// porcentaje calcula parte/total en porcentaje (0 si el total es 0)
+func porcentaje(parte, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return redondear(parte / total * 100)
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// AjustarStock fija la cantidad del producto en el local y registra un movimiento de ajuste
+// con la diferencia respecto al stock actual
+func (s *stockService) AjustarStock(ctx context.Context, req *models.AjusteStockRequest) (*models.Movimiento, error) {
+	if err := s.verificarLocal(ctx, req.IDLocal); err != nil {
+		return nil, err
+	}
+
+	op := s.nuevaOperacionSerializada("")
+	defer op.liberar()
+	var movimiento *models.Movimiento
+
+	err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+		op.repo = repo
+		if err := repo.BloquearCadenas(ctx, req.IDLocal); err != nil {
+			return err
+		}
+		var err error
+		movimiento, err = s.aplicarAjuste(ctx, op, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidarAfectados(op)
+
+	return movimiento, nil
+}
+
+// AjusteStockLote aplica varios ajustes en una sola transacción: o se aplican todos o ninguno
+// Los ítems que ya tienen la cantidad indicada no generan movimiento
+// Todos los ajustes forman una operación (la del primer ítem que la indique)
+// alConfirmar registra en la misma transacción lo que depende de los ajustes (p. ej. el cierre de
+// una toma de inventario); si retorna error no se aplica ningún ajuste
+func (s *stockService) AjusteStockLote(ctx context.Context, reqs []*models.AjusteStockRequest, alConfirmar func(repo repository.StockRepository, movimientos []*models.Movimiento) error) ([]*models.Movimiento, error) {
+	idOperacion := ""
+	locales := make([]int, 0, len(reqs))
+	for _, req := range reqs {
+		if idOperacion == "" {
+			idOperacion = req.IDOperacion
+		}
+		locales = append(locales, req.IDLocal)
+	}
+	op := s.nuevaOperacionSerializada(idOperacion)
+	defer op.liberar()
+	movimientos := []*models.Movimiento{}
+
+	err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+		op.repo = repo
+		if err := repo.BloquearCadenas(ctx, locales...); err != nil {
+			return err
+		}
+		verificados := make(map[int]bool)
+		for _, req := range reqs {
+			if !verificados[req.IDLocal] {
+				if err := s.verificarLocal(ctx, req.IDLocal); err != nil {
+					return err
+				}
+				verificados[req.IDLocal] = true
+			}
+			movimiento, err := s.aplicarAjuste(ctx, op, req)
+			if errors.Is(err, ErrAjusteSinDiferencia) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", req.CodigoProducto, err)
+			}
+			movimientos = append(movimientos, movimiento)
+		}
+		if alConfirmar != nil {
+			return alConfirmar(repo, movimientos)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidarAfectados(op)
+
+	return movimientos, nil
+}
+
+// aplicarAjuste fija la cantidad de un ítem dentro de la transacción de la operación
+// El motivo es obligatorio y una cantidad igual a la actual no genera movimiento (ErrAjusteSinDiferencia)
+func (s *stockService) aplicarAjuste(ctx context.Context, op *operacionStock, req *models.AjusteStockRequest) (*models.Movimiento, error) {
+	logger := s.logger.With(
+		zap.String("operation", "ajuste_stock"),
+		zap.String("codigo_producto", req.CodigoProducto),
+		zap.Int("id_local", req.IDLocal),
+		zap.Int("id_usuario", req.IDUsuario),
+	)
+
+	motivo := strings.TrimSpace(req.Motivo)
+	if motivo == "" {
+		return nil, fmt.Errorf("%w: el motivo es obligatorio", ErrAjusteInvalido)
+	}
+	if req.CantidadNueva == nil || *req.CantidadNueva < 0 {
+		return nil, fmt.Errorf("%w: cantidad_nueva debe ser mayor o igual a 0", ErrAjusteInvalido)
+	}
+	cantidadNueva := *req.CantidadNueva
+
+	if err := s.verificarProductoExiste(ctx, op.repo, req.CodigoProducto, req.TipoItem, models.TipoMovimientoAjuste); err != nil {
+		return nil, err
+	}
+	servicio, err := esServicio(ctx, op.repo, req.CodigoProducto, req.TipoItem)
+	if err != nil {
+		return nil, fmt.Errorf("error verificando producto: %w", err)
+	}
+	if servicio {
+		return nil, fmt.Errorf("%w: %s es un servicio y no lleva stock", ErrAjusteInvalido, req.CodigoProducto)
+	}
+
+	bodega, err := s.resolverBodega(ctx, op.repo, req.IDLocal, req.IDBodega)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := op.bloquear(ctx, req.CodigoProducto, req.IDLocal); err != nil {
+		return nil, err
+	}
+	stockActual, err := op.repo.GetStockByProducto(ctx, req.CodigoProducto, req.IDLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo stock actual: %w", err)
+	}
+
+	cantidadAnterior := 0
+	if stockActual != nil {
+		cantidadAnterior = stockActual.CantidadActual
+	}
+	diferencia := cantidadNueva - cantidadAnterior
+	if diferencia == 0 {
+		return nil, fmt.Errorf("%w: %s ya tiene %d en el local %d", ErrAjusteSinDiferencia, req.CodigoProducto, cantidadAnterior, req.IDLocal)
+	}
+
+	if stockActual != nil {
+		stockActual.CantidadActual = cantidadNueva
+		err = op.repo.UpdateStock(ctx, stockActual)
+	} else {
+		err = op.repo.CreateStock(ctx, &models.Stock{
+			CodigoProducto: req.CodigoProducto,
+			TipoItem:       req.TipoItem,
+			CantidadActual: cantidadNueva,
+			IDLocal:        req.IDLocal,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error actualizando stock: %w", err)
+	}
+
+	if err := s.ajustarBodega(ctx, op.repo, bodega, req.CodigoProducto, req.IDLocal, diferencia); err != nil {
+		return nil, err
+	}
+
+	// El inventario físico manda: lo reservado por pickings se informa pero no impide el ajuste
+	reservada, err := op.repo.GetCantidadReservada(ctx, req.CodigoProducto, req.IDLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo cantidad reservada: %w", err)
+	}
+	if cantidadNueva < reservada {
+		logger.Warn("Ajuste deja el stock bajo lo reservado por pickings",
+			zap.Int("cantidad_nueva", cantidadNueva),
+			zap.Int("stock_reservado", reservada))
+	}
+
+	movimiento := &models.Movimiento{
+		CodigoProducto:   req.CodigoProducto,
+		TipoItem:         req.TipoItem,
+		TipoMovimiento:   models.TipoMovimientoAjuste,
+		Cantidad:         diferencia,
+		CantidadAnterior: cantidadAnterior,
+		CantidadNueva:    cantidadNueva,
+		Motivo:           motivo,
+		IDUsuario:        req.IDUsuario,
+		IDLocal:          req.IDLocal,
+		Observaciones:    req.Observaciones,
+	}
+	op.asignarOperacion(movimiento)
+	if bodega != nil {
+		movimiento.IDBodega = &bodega.ID
+	}
+
+	if err := s.crearMovimiento(ctx, op.repo, movimiento); err != nil {
+		return nil, fmt.Errorf("error creando movimiento: %w", err)
+	}
+	op.movimientos = append(op.movimientos, movimiento)
+	op.registrarAfectado(req.CodigoProducto, req.IDLocal)
+
+	logger.Info("Ajuste de stock registrado",
+		zap.Int("cantidad_anterior", cantidadAnterior),
+		zap.Int("cantidad_nueva", cantidadNueva),
+		zap.Int("diferencia", diferencia),
+		zap.String("motivo", motivo))
+
+	return movimiento, nil
+}
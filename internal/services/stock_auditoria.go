@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// AuditarStock reconstruye el stock teórico desde los movimientos y lo compara con cantidad_actual
+// por producto/local. Con Corregir registra por cada descuadre un movimiento de ajuste que lleva
+// la cadena de movimientos de la cantidad teórica a la actual (el stock no cambia)
+func (s *stockService) AuditarStock(ctx context.Context, req *models.AuditoriaStockRequest) (*models.AuditoriaStockResponse, error) {
+	logger := s.logger.With(zap.String("operation", "auditar_stock"))
+
+	if req.IDLocal != nil {
+		if err := s.verificarLocal(ctx, *req.IDLocal); err != nil {
+			return nil, err
+		}
+	}
+
+	descuadres, err := s.repo.GetDescuadresStock(ctx, req.IDLocal, req.CodigoProducto)
+	if err != nil {
+		return nil, fmt.Errorf("error auditando stock: %w", err)
+	}
+
+	response := &models.AuditoriaStockResponse{
+		IDLocal:         req.IDLocal,
+		CodigoProducto:  req.CodigoProducto,
+		Descuadres:      descuadres,
+		TotalDescuadres: len(descuadres),
+		GeneradoEn:      time.Now(),
+	}
+
+	if req.Corregir {
+		for _, descuadre := range descuadres {
+			if err := s.corregirDescuadre(ctx, descuadre, req.IDUsuario); err != nil {
+				return nil, err
+			}
+			if descuadre.IDMovimientoAjuste != nil {
+				response.Corregidos++
+			}
+		}
+	}
+
+	logFn := logger.Info
+	if len(descuadres) > 0 {
+		logFn = logger.Warn
+	}
+	logFn("Auditoría de stock contra movimientos",
+		zap.Int("descuadres", response.TotalDescuadres),
+		zap.Int("corregidos", response.Corregidos),
+		zap.Bool("corregir", req.Corregir))
+
+	return response, nil
+}
+
+// corregirDescuadre registra el movimiento de ajuste de un descuadre en su propia transacción
+// Si el stock cambió desde la auditoría el descuadre se omite (hay que volver a auditar)
+func (s *stockService) corregirDescuadre(ctx context.Context, descuadre *models.DescuadreStock, idUsuario int) error {
+	return s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+		if err := repo.BloquearCadenas(ctx, descuadre.IDLocal); err != nil {
+			return err
+		}
+		stock, err := repo.GetStockByProducto(ctx, descuadre.CodigoProducto, descuadre.IDLocal)
+		if err != nil {
+			return fmt.Errorf("error obteniendo stock de %s: %w", descuadre.CodigoProducto, err)
+		}
+		if stock == nil || stock.CantidadActual != descuadre.CantidadActual {
+			descuadre.Omitido = "el stock cambió durante la auditoría"
+			return nil
+		}
+
+		tipoMovimiento, cantidad := "entrada", descuadre.Diferencia
+		if cantidad < 0 {
+			tipoMovimiento, cantidad = "salida", -cantidad
+		}
+
+		movimiento := &models.Movimiento{
+			CodigoProducto:   descuadre.CodigoProducto,
+			TipoItem:         descuadre.TipoItem,
+			TipoMovimiento:   tipoMovimiento,
+			Cantidad:         cantidad,
+			CantidadAnterior: descuadre.CantidadTeorica,
+			CantidadNueva:    descuadre.CantidadActual,
+			Motivo:           models.MotivoAjusteAuditoria,
+			IDUsuario:        idUsuario,
+			IDLocal:          descuadre.IDLocal,
+			Observaciones: fmt.Sprintf("Ajuste de auditoría: stock según movimientos %d, stock actual %d",
+				descuadre.CantidadTeorica, descuadre.CantidadActual),
+		}
+		nuevaOperacion("").asignarOperacion(movimiento)
+
+		if err := s.crearMovimiento(ctx, repo, movimiento); err != nil {
+			return fmt.Errorf("error registrando ajuste de %s: %w", descuadre.CodigoProducto, err)
+		}
+		descuadre.IDMovimientoAjuste = &movimiento.ID
+		return nil
+	})
+}
+
+// VerificarIntegridadMovimientos recorre la cadena de movimientos sellados del local y recalcula cada
+// hash; se detiene en el primer movimiento alterado o cuyo hash anterior no sea el del previo
+// (un movimiento borrado). Los movimientos sin sellar (anteriores a la cadena) solo se cuentan
+func (s *stockService) VerificarIntegridadMovimientos(ctx context.Context, idLocal int, desde, hasta *time.Time) (*models.IntegridadMovimientos, error) {
+	if err := s.verificarLocal(ctx, idLocal); err != nil {
+		return nil, err
+	}
+
+	resultado := &models.IntegridadMovimientos{
+		IDLocal:    idLocal,
+		FechaDesde: desde,
+		FechaHasta: hasta,
+		Integra:    true,
+	}
+
+	errRuptura := errors.New("ruptura de cadena")
+	var previo *string
+	err := s.repo.RecorrerCadenaMovimientos(ctx, idLocal, desde, hasta, func(movimiento *models.Movimiento) error {
+		if movimiento.Hash == nil {
+			resultado.SinSellar++
+			return nil
+		}
+
+		if previo == nil {
+			// Primer sellado del rango: se encadena con el último sellado anterior al rango
+			hash, err := s.repo.GetHashPrevio(ctx, idLocal, movimiento.ID)
+			if err != nil {
+				return err
+			}
+			previo = &hash
+		}
+
+		var registrado string
+		if movimiento.HashAnterior != nil {
+			registrado = *movimiento.HashAnterior
+		}
+		if registrado != *previo {
+			resultado.PrimeraRuptura = &models.RupturaCadena{
+				IDMovimiento:   movimiento.ID,
+				CreatedAt:      movimiento.CreatedAt,
+				Motivo:         models.RupturaCadenaRota,
+				HashEsperado:   *previo,
+				HashRegistrado: registrado,
+			}
+			return errRuptura
+		}
+
+		if esperado := movimiento.CalcularHash(registrado); esperado != *movimiento.Hash {
+			resultado.PrimeraRuptura = &models.RupturaCadena{
+				IDMovimiento:   movimiento.ID,
+				CreatedAt:      movimiento.CreatedAt,
+				Motivo:         models.RupturaHashAlterado,
+				HashEsperado:   esperado,
+				HashRegistrado: *movimiento.Hash,
+			}
+			return errRuptura
+		}
+
+		resultado.Verificados++
+		previo = movimiento.Hash
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRuptura) {
+		return nil, fmt.Errorf("error verificando cadena de movimientos: %w", err)
+	}
+	resultado.Integra = resultado.PrimeraRuptura == nil
+	resultado.VerificadoEn = time.Now()
+
+	logFn := s.logger.Info
+	if !resultado.Integra {
+		logFn = s.logger.Error
+	}
+	logFn("Verificación de integridad de movimientos",
+		zap.String("operation", "verificar_integridad_movimientos"),
+		zap.Int("id_local", idLocal),
+		zap.Int("verificados", resultado.Verificados),
+		zap.Int("sin_sellar", resultado.SinSellar),
+		zap.Bool("integra", resultado.Integra))
+
+	return resultado, nil
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// GetBodegas lista las bodegas del local (la sala de venta primero)
+func (s *stockService) GetBodegas(ctx context.Context, idLocal int) ([]*models.Bodega, error) {
+	if err := s.verificarLocal(ctx, idLocal); err != nil {
+		return nil, err
+	}
+	// El local siempre tiene sala: es de donde descuentan las operaciones sin bodega
+	if _, err := s.repo.AsegurarBodegaSala(ctx, idLocal); err != nil {
+		return nil, err
+	}
+	return s.repo.GetBodegas(ctx, idLocal)
+}
+
+// CrearBodega crea una bodega en el local; el local admite una sola sala de venta
+func (s *stockService) CrearBodega(ctx context.Context, req *models.CrearBodegaRequest) (*models.Bodega, error) {
+	if err := s.verificarLocal(ctx, req.IDLocal); err != nil {
+		return nil, err
+	}
+
+	nombre := strings.TrimSpace(req.Nombre)
+	if nombre == "" {
+		return nil, fmt.Errorf("%w: el nombre no puede estar vacío", ErrBodegaInvalida)
+	}
+	existentes, err := s.repo.GetBodegas(ctx, req.IDLocal)
+	if err != nil {
+		return nil, err
+	}
+	for _, existente := range existentes {
+		if strings.EqualFold(existente.Nombre, nombre) {
+			return nil, fmt.Errorf("%w: %s", ErrBodegaDuplicada, nombre)
+		}
+		if req.Tipo == models.BodegaSala && existente.Tipo == models.BodegaSala {
+			return nil, fmt.Errorf("%w: el local ya tiene la sala %s", ErrBodegaDuplicada, existente.Nombre)
+		}
+	}
+
+	bodega := &models.Bodega{
+		IDLocal: req.IDLocal,
+		Nombre:  nombre,
+		Tipo:    req.Tipo,
+		Activa:  true,
+	}
+	if err := s.repo.CreateBodega(ctx, bodega); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Bodega creada",
+		zap.String("operation", "crear_bodega"),
+		zap.Int("id_bodega", bodega.ID),
+		zap.Int("id_local", bodega.IDLocal),
+		zap.String("tipo", bodega.Tipo))
+
+	return bodega, nil
+}
+
+// ActualizarBodega renombra o activa/desactiva una bodega; la sala no se puede desactivar
+// Una bodega inactiva no recibe stock, pero se puede vaciar con traslados
+func (s *stockService) ActualizarBodega(ctx context.Context, id int, req *models.ActualizarBodegaRequest) (*models.Bodega, error) {
+	bodega, err := s.repo.GetBodegaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if bodega == nil {
+		return nil, fmt.Errorf("%w: %d", ErrBodegaNoEncontrada, id)
+	}
+
+	if req.Nombre != nil {
+		nombre := strings.TrimSpace(*req.Nombre)
+		if nombre == "" {
+			return nil, fmt.Errorf("%w: el nombre no puede estar vacío", ErrBodegaInvalida)
+		}
+		existentes, err := s.repo.GetBodegas(ctx, bodega.IDLocal)
+		if err != nil {
+			return nil, err
+		}
+		for _, existente := range existentes {
+			if existente.ID != id && strings.EqualFold(existente.Nombre, nombre) {
+				return nil, fmt.Errorf("%w: %s", ErrBodegaDuplicada, nombre)
+			}
+		}
+		bodega.Nombre = nombre
+	}
+	if req.Activa != nil {
+		if !*req.Activa && bodega.Tipo == models.BodegaSala {
+			return nil, fmt.Errorf("%w: la sala de venta no se puede desactivar", ErrBodegaInvalida)
+		}
+		bodega.Activa = *req.Activa
+	}
+
+	if err := s.repo.UpdateBodega(ctx, bodega); err != nil {
+		return nil, err
+	}
+	return bodega, nil
+}
+
+// GetStockPorBodega stock del local desglosado por bodega; la sala tiene lo que no está en otra bodega
+func (s *stockService) GetStockPorBodega(ctx context.Context, idLocal int, codigoProducto *string) ([]*models.StockPorBodega, error) {
+	bodegas, err := s.GetBodegas(ctx, idLocal)
+	if err != nil {
+		return nil, err
+	}
+	porID := make(map[int]*models.Bodega, len(bodegas))
+	var sala *models.Bodega
+	for _, bodega := range bodegas {
+		porID[bodega.ID] = bodega
+		if bodega.Tipo == models.BodegaSala {
+			sala = bodega
+		}
+	}
+
+	stock, err := s.repo.GetStockPorBodega(ctx, idLocal, codigoProducto)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range stock {
+		enSala := item.Total
+		for i := range item.Bodegas {
+			enSala -= item.Bodegas[i].Cantidad
+			if bodega, ok := porID[item.Bodegas[i].IDBodega]; ok {
+				item.Bodegas[i].Nombre = bodega.Nombre
+				item.Bodegas[i].Tipo = bodega.Tipo
+			}
+		}
+		if sala != nil {
+			item.Bodegas = append([]models.StockEnBodega{{
+				IDBodega: sala.ID,
+				Nombre:   sala.Nombre,
+				Tipo:     sala.Tipo,
+				Cantidad: enSala,
+			}}, item.Bodegas...)
+		}
+	}
+
+	return stock, nil
+}
+
+// TrasladoInterno mueve stock entre dos bodegas del mismo local
+// El total del local no cambia: se registra un único movimiento traslado_interno con origen y destino
+func (s *stockService) TrasladoInterno(ctx context.Context, req *models.TrasladoInternoRequest) (*models.Movimiento, error) {
+	logger := s.logger.With(
+		zap.String("operation", "traslado_interno"),
+		zap.String("codigo_producto", req.CodigoProducto),
+		zap.Int("id_local", req.IDLocal),
+		zap.Int("id_bodega_origen", req.IDBodegaOrigen),
+		zap.Int("id_bodega_destino", req.IDBodegaDestino),
+		zap.Int("cantidad", req.Cantidad),
+	)
+
+	if err := s.verificarLocal(ctx, req.IDLocal); err != nil {
+		return nil, err
+	}
+
+	op := s.nuevaOperacionSerializada("")
+	defer op.liberar()
+	var movimiento *models.Movimiento
+
+	err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+		op.repo = repo
+		if err := repo.BloquearCadenas(ctx, req.IDLocal); err != nil {
+			return err
+		}
+
+		origen, err := s.bodegaDelLocal(ctx, repo, req.IDBodegaOrigen, req.IDLocal)
+		if err != nil {
+			return err
+		}
+		destino, err := s.bodegaDelLocal(ctx, repo, req.IDBodegaDestino, req.IDLocal)
+		if err != nil {
+			return err
+		}
+		if !destino.Activa {
+			return fmt.Errorf("%w: la bodega %s está inactiva", ErrBodegaInvalida, destino.Nombre)
+		}
+
+		if err := op.bloquear(ctx, req.CodigoProducto, req.IDLocal); err != nil {
+			return err
+		}
+		stockActual, err := repo.GetStockByProducto(ctx, req.CodigoProducto, req.IDLocal)
+		if err != nil {
+			return fmt.Errorf("error obteniendo stock actual: %w", err)
+		}
+		if stockActual == nil {
+			return fmt.Errorf("%w: %s no tiene stock en el local %d", ErrStockBodegaInsuficiente, req.CodigoProducto, req.IDLocal)
+		}
+
+		disponible, err := s.stockEnBodega(ctx, repo, origen, stockActual)
+		if err != nil {
+			return err
+		}
+		if disponible < req.Cantidad {
+			return fmt.Errorf("%w: %s tiene %d, solicitado %d", ErrStockBodegaInsuficiente, origen.Nombre, disponible, req.Cantidad)
+		}
+
+		if err := s.ajustarBodega(ctx, repo, origen, req.CodigoProducto, req.IDLocal, -req.Cantidad); err != nil {
+			return err
+		}
+		if err := s.ajustarBodega(ctx, repo, destino, req.CodigoProducto, req.IDLocal, req.Cantidad); err != nil {
+			return err
+		}
+
+		movimiento = &models.Movimiento{
+			CodigoProducto:   req.CodigoProducto,
+			TipoItem:         stockActual.TipoItem,
+			TipoMovimiento:   models.TipoMovimientoTrasladoInterno,
+			Cantidad:         req.Cantidad,
+			CantidadAnterior: stockActual.CantidadActual,
+			CantidadNueva:    stockActual.CantidadActual,
+			Motivo:           req.Motivo,
+			IDUsuario:        req.IDUsuario,
+			IDLocal:          req.IDLocal,
+			Observaciones:    req.Observaciones,
+			IDBodega:         &origen.ID,
+			IDBodegaDestino:  &destino.ID,
+		}
+		op.asignarOperacion(movimiento)
+		if err := s.crearMovimiento(ctx, repo, movimiento); err != nil {
+			return fmt.Errorf("error creando movimiento: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Error en traslado interno", zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("Traslado interno registrado", zap.Int("id_movimiento", movimiento.ID))
+	return movimiento, nil
+}
+
+// resolverBodega bodega de una entrada o salida: la indicada (del local y activa) o la sala de venta
+// Retorna nil solo si el local no tiene sala y no se pudo crear
+func (s *stockService) resolverBodega(ctx context.Context, repo repository.StockRepository, idLocal int, idBodega *int) (*models.Bodega, error) {
+	if idBodega == nil {
+		return repo.AsegurarBodegaSala(ctx, idLocal)
+	}
+
+	bodega, err := s.bodegaDelLocal(ctx, repo, *idBodega, idLocal)
+	if err != nil {
+		return nil, err
+	}
+	if !bodega.Activa {
+		return nil, fmt.Errorf("%w: la bodega %s está inactiva", ErrBodegaInvalida, bodega.Nombre)
+	}
+	return bodega, nil
+}
+
+// bodegaDelLocal obtiene la bodega verificando que pertenezca al local
+func (s *stockService) bodegaDelLocal(ctx context.Context, repo repository.StockRepository, idBodega, idLocal int) (*models.Bodega, error) {
+	bodega, err := repo.GetBodegaByID(ctx, idBodega)
+	if err != nil {
+		return nil, err
+	}
+	if bodega == nil {
+		return nil, fmt.Errorf("%w: %d", ErrBodegaNoEncontrada, idBodega)
+	}
+	if bodega.IDLocal != idLocal {
+		return nil, fmt.Errorf("%w: la bodega %d no pertenece al local %d", ErrBodegaInvalida, idBodega, idLocal)
+	}
+	return bodega, nil
+}
+
+// stockEnBodega cantidad del producto en la bodega; la de la sala es el total menos lo que está en otras
+func (s *stockService) stockEnBodega(ctx context.Context, repo repository.StockRepository, bodega *models.Bodega, stock *models.Stock) (int, error) {
+	if bodega.Tipo != models.BodegaSala {
+		return repo.GetStockEnBodega(ctx, stock.CodigoProducto, bodega.ID)
+	}
+	fuera, err := repo.GetStockFueraDeSala(ctx, stock.CodigoProducto, stock.IDLocal)
+	if err != nil {
+		return 0, err
+	}
+	return stock.CantidadActual - fuera, nil
+}
+
+// ajustarBodega registra delta en una bodega que no es la sala (la sala se deriva del total)
+// Una bodega no puede quedar con stock negativo
+func (s *stockService) ajustarBodega(ctx context.Context, repo repository.StockRepository, bodega *models.Bodega, codigoProducto string, idLocal, delta int) error {
+	if bodega == nil || bodega.Tipo == models.BodegaSala {
+		return nil
+	}
+	cantidad, err := repo.AjustarStockBodega(ctx, codigoProducto, idLocal, bodega.ID, delta)
+	if err != nil {
+		return err
+	}
+	if cantidad < 0 {
+		return fmt.Errorf("%w: %s tiene %d, solicitado %d", ErrStockBodegaInsuficiente, bodega.Nombre, cantidad-delta, -delta)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"stock-service/internal/export"
+	"stock-service/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// ExportarStock escribe el stock completo del local (encabezado y una fila por producto o pack)
+// Retorna las filas de datos escritas
+func (s *stockService) ExportarStock(ctx context.Context, idLocal int, w export.Writer) (int, error) {
+	stock, err := s.repo.GetStockCompleteByLocal(ctx, idLocal)
+	if err != nil {
+		return 0, fmt.Errorf("error obteniendo stock del local: %w", err)
+	}
+
+	if err := w.WriteRow("codigo_producto", "tipo_item", "nombre", "categoria", "unidad",
+		"cantidad_actual", "cantidad_minima", "precio", "descontinuado", "id_local", "local", "actualizado"); err != nil {
+		return 0, err
+	}
+
+	filas := 0
+	for _, item := range stock {
+		if err := w.WriteRow(item.CodigoProducto, item.TipoItem, item.NombreProducto, item.NombreCategoria, item.Unidad,
+			item.CantidadActual, item.CantidadMinima, item.Precio, item.Descontinuado, item.IDLocal, item.NombreLocal, item.UpdatedAt); err != nil {
+			return filas, err
+		}
+		filas++
+	}
+
+	s.logger.Info("Stock exportado",
+		zap.String("operation", "exportar_stock"),
+		zap.Int("id_local", idLocal),
+		zap.Int("filas", filas))
+
+	return filas, nil
+}
+
+// ExportarMovimientos escribe todos los movimientos que cumplen el filtro, del más antiguo al más reciente,
+// leyéndolos fila a fila (limit y offset no se aplican). Retorna las filas de datos escritas
+func (s *stockService) ExportarMovimientos(ctx context.Context, filter *models.MovimientoFilter, w export.Writer) (int, error) {
+	if err := w.WriteRow("id", "fecha", "id_local", "codigo_producto", "tipo_item", "tipo_movimiento",
+		"cantidad", "cantidad_anterior", "cantidad_nueva", "unidad", "cantidad_unidad", "motivo",
+		"documento_tipo", "documento_numero", "documento_fecha", "id_operacion", "id_bodega", "id_bodega_destino",
+		"id_usuario", "observaciones"); err != nil {
+		return 0, err
+	}
+
+	filas := 0
+	err := s.repo.RecorrerMovimientos(ctx, filter, func(m *models.Movimiento) error {
+		if err := w.WriteRow(m.ID, m.CreatedAt, m.IDLocal, m.CodigoProducto, m.TipoItem, m.TipoMovimiento,
+			m.Cantidad, m.CantidadAnterior, m.CantidadNueva, m.Unidad, m.CantidadUnidad, m.Motivo,
+			m.DocumentoTipo, m.DocumentoNumero, m.DocumentoFecha, m.IDOperacion, m.IDBodega, m.IDBodegaDestino,
+			m.IDUsuario, m.Observaciones); err != nil {
+			return err
+		}
+		filas++
+		return nil
+	})
+	if err != nil {
+		return filas, err
+	}
+
+	s.logger.Info("Movimientos exportados",
+		zap.String("operation", "exportar_movimientos"),
+		zap.Any("filtros", filter),
+		zap.Int("filas", filas))
+
+	return filas, nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"stock-service/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// Valores por defecto de la proyección de stock
+const (
+	proyeccionSemanasDefault       = 4
+	proyeccionDiasHistoricoDefault = 28
+)
+
+// ProyectarStock proyecta el stock día a día combinando la demanda histórica
+// con las ventas y recepciones proyectadas, e indica la fecha estimada de quiebre
+func (s *stockService) ProyectarStock(ctx context.Context, req *models.ProyeccionStockRequest) (*models.ProyeccionStockResponse, error) {
+	logger := s.logger.With(
+		zap.String("operation", "proyectar_stock"),
+		zap.Int("id_local", req.IDLocal),
+	)
+
+	if err := s.verificarLocal(ctx, req.IDLocal); err != nil {
+		return nil, err
+	}
+
+	semanas := req.Semanas
+	if semanas <= 0 {
+		semanas = proyeccionSemanasDefault
+	}
+	usarHistorico := req.UsarHistorico == nil || *req.UsarHistorico
+	diasHistorico := req.DiasHistorico
+	if diasHistorico <= 0 {
+		diasHistorico = proyeccionDiasHistoricoDefault
+	}
+
+	ahora := time.Now()
+	hoy := time.Date(ahora.Year(), ahora.Month(), ahora.Day(), 0, 0, 0, 0, ahora.Location())
+	dias := semanas * 7
+
+	// Stock actual de los productos a proyectar
+	stockActual := make(map[string]int)
+	codigos := []string{}
+	if len(req.CodigosProducto) > 0 {
+		for _, codigo := range req.CodigosProducto {
+			stock, err := s.repo.GetStockByProducto(ctx, codigo, req.IDLocal)
+			if err != nil {
+				return nil, fmt.Errorf("error obteniendo stock de %s: %w", codigo, err)
+			}
+			if stock != nil {
+				stockActual[codigo] = stock.CantidadActual
+			}
+			codigos = append(codigos, codigo)
+		}
+	} else {
+		stocks, err := s.repo.GetStockByLocal(ctx, req.IDLocal)
+		if err != nil {
+			return nil, fmt.Errorf("error obteniendo stock del local: %w", err)
+		}
+		for _, stock := range stocks {
+			stockActual[stock.CodigoProducto] = stock.CantidadActual
+			codigos = append(codigos, stock.CodigoProducto)
+		}
+	}
+
+	// Demanda diaria promedio según el histórico de salidas
+	demanda := make(map[string]float64)
+	if usarHistorico {
+		salidas, err := s.repo.GetSalidasDesde(ctx, req.IDLocal, hoy.AddDate(0, 0, -diasHistorico))
+		if err != nil {
+			return nil, fmt.Errorf("error obteniendo demanda histórica: %w", err)
+		}
+		for codigo, cantidad := range salidas {
+			demanda[codigo] = float64(cantidad) / float64(diasHistorico)
+		}
+	}
+
+	// Eventos proyectados por producto y día (offset desde hoy)
+	type eventoDia struct{ entradas, salidas int }
+	eventos := make(map[string]map[int]*eventoDia)
+	for _, evento := range req.Eventos {
+		fecha, err := time.ParseInLocation("2006-01-02", evento.Fecha, hoy.Location())
+		if err != nil {
+			return nil, fmt.Errorf("fecha inválida %s: %w", evento.Fecha, err)
+		}
+		offset := int(math.Round(fecha.Sub(hoy).Hours() / 24))
+		if offset < 0 || offset >= dias {
+			continue
+		}
+		if _, ok := eventos[evento.CodigoProducto]; !ok {
+			eventos[evento.CodigoProducto] = make(map[int]*eventoDia)
+			if _, ok := stockActual[evento.CodigoProducto]; !ok && len(req.CodigosProducto) == 0 {
+				codigos = append(codigos, evento.CodigoProducto)
+			}
+		}
+		dia, ok := eventos[evento.CodigoProducto][offset]
+		if !ok {
+			dia = &eventoDia{}
+			eventos[evento.CodigoProducto][offset] = dia
+		}
+		if evento.Tipo == "recepcion" {
+			dia.entradas += evento.Cantidad
+		} else {
+			dia.salidas += evento.Cantidad
+		}
+	}
+
+	response := &models.ProyeccionStockResponse{
+		IDLocal:   req.IDLocal,
+		Desde:     hoy.Format("2006-01-02"),
+		Hasta:     hoy.AddDate(0, 0, dias-1).Format("2006-01-02"),
+		Productos: make([]*models.ProyeccionProducto, 0, len(codigos)),
+	}
+	if usarHistorico {
+		response.DiasHistorico = diasHistorico
+	}
+
+	for _, codigo := range codigos {
+		proyeccion := &models.ProyeccionProducto{
+			CodigoProducto: codigo,
+			StockActual:    stockActual[codigo],
+			DemandaDiaria:  math.Round(demanda[codigo]*100) / 100,
+			Dias:           make([]models.ProyeccionDia, 0, dias),
+		}
+
+		// El stock se acumula en float para no perder la demanda fraccionaria
+		stock := float64(stockActual[codigo])
+		for d := 0; d < dias; d++ {
+			dia := models.ProyeccionDia{Fecha: hoy.AddDate(0, 0, d).Format("2006-01-02")}
+			stock -= demanda[codigo]
+			if evento, ok := eventos[codigo][d]; ok {
+				stock += float64(evento.entradas - evento.salidas)
+				dia.Entradas = evento.entradas
+				dia.Salidas = evento.salidas
+			}
+			dia.Stock = int(math.Floor(stock))
+
+			if dia.Stock <= 0 && proyeccion.FechaQuiebre == nil {
+				dia.Quiebre = true
+				fecha, diasHasta := dia.Fecha, d
+				proyeccion.FechaQuiebre = &fecha
+				proyeccion.DiasHastaQuiebre = &diasHasta
+			}
+			proyeccion.Dias = append(proyeccion.Dias, dia)
+		}
+
+		if proyeccion.FechaQuiebre != nil {
+			response.ConQuiebre++
+		}
+		response.Productos = append(response.Productos, proyeccion)
+	}
+
+	logger.Info("Proyección de stock calculada",
+		zap.Int("productos", len(response.Productos)),
+		zap.Int("con_quiebre", response.ConQuiebre),
+		zap.Int("dias", dias))
+
+	return response, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+)
+
+// Códigos de error de los ítems de una operación múltiple (codigo_error en la respuesta v2)
+const (
+	CodigoErrorStockInsuficiente    = "stock_insuficiente"
+	CodigoErrorProductoNoEncontrado = "producto_no_encontrado"
+	CodigoErrorProductoInactivo     = "producto_descontinuado"
+	CodigoErrorLocalInvalido        = "local_invalido"
+	CodigoErrorBodegaInvalida       = "bodega_invalida"
+	CodigoErrorUnidadInvalida       = "unidad_invalida"
+	CodigoErrorDocumentoInvalido    = "documento_invalido"
+	CodigoErrorPackInvalido         = "pack_invalido"
+	CodigoErrorOperacionBloqueada   = "operacion_bloqueada"
+	CodigoErrorStockOcupado         = "stock_ocupado"
+	CodigoErrorTimeout              = "timeout"
+	CodigoErrorBDNoDisponible       = "db_no_disponible"
+	CodigoErrorInterno              = "error_interno"
+)
+
+// codigoErrorItem código estable del error de un ítem, para que el cliente reaccione sin parsear el mensaje
+func codigoErrorItem(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return CodigoErrorTimeout
+	case repository.IsUnavailable(err):
+		return CodigoErrorBDNoDisponible
+	case errors.Is(err, repository.ErrStockInsuficiente), errors.Is(err, ErrStockBodegaInsuficiente):
+		return CodigoErrorStockInsuficiente
+	case errors.Is(err, ErrProductoNoEncontrado):
+		return CodigoErrorProductoNoEncontrado
+	case errors.Is(err, ErrProductoDescontinuado):
+		return CodigoErrorProductoInactivo
+	case errors.Is(err, ErrLocalNoEncontrado), errors.Is(err, ErrLocalInactivo):
+		return CodigoErrorLocalInvalido
+	case errors.Is(err, ErrBodegaNoEncontrada), errors.Is(err, ErrBodegaInvalida):
+		return CodigoErrorBodegaInvalida
+	case errors.Is(err, ErrUnidadNoEncontrada), errors.Is(err, ErrUnidadSinConversion):
+		return CodigoErrorUnidadInvalida
+	case errors.Is(err, ErrDocumentoDuplicado), errors.Is(err, ErrDocumentoInvalido):
+		return CodigoErrorDocumentoInvalido
+	case errors.Is(err, ErrCicloPack), errors.Is(err, ErrProfundidadPack):
+		return CodigoErrorPackInvalido
+	case errors.Is(err, ErrOperacionBloqueada):
+		return CodigoErrorOperacionBloqueada
+	case errors.Is(err, ErrStockOcupado):
+		return CodigoErrorStockOcupado
+	default:
+		return CodigoErrorInterno
+	}
+}
+
+// nuevosResultadosItems un resultado por producto del request, sin procesar todavía
+func nuevosResultadosItems(n int) []models.ResultadoItem {
+	items := make([]models.ResultadoItem, n)
+	for i := range items {
+		items[i].Indice = i
+		items[i].Estado = models.ItemEstadoNoProcesado
+	}
+	return items
+}
+
+// marcarAplicado registra el ítem como aplicado (o simulado: sin movimiento, no quedó registrado)
+func marcarAplicado(item *models.ResultadoItem, cantidadNueva int, movimiento *models.Movimiento, dryRun bool) {
+	item.CantidadNueva = &cantidadNueva
+	if dryRun {
+		item.Estado = models.ItemEstadoSimulado
+		return
+	}
+	item.Estado = models.ItemEstadoAplicado
+	item.Movimiento = movimiento
+}
+
+// marcarFallido registra el error del ítem con su código
+func marcarFallido(item *models.ResultadoItem, err error) {
+	item.Estado = models.ItemEstadoFallido
+	item.CodigoError = codigoErrorItem(err)
+	item.Error = err.Error()
+}
+
+// marcarRevertidos marca como revertidos los ítems aplicados de una operación atómica que falló
+func marcarRevertidos(items []models.ResultadoItem) {
+	for i := range items {
+		if items[i].Estado == models.ItemEstadoAplicado {
+			items[i].Estado = models.ItemEstadoRevertido
+			items[i].CantidadNueva = nil
+			items[i].Movimiento = nil
+		}
+	}
+}
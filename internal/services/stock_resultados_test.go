@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"stock-service/internal/repository"
+)
+
+func TestCodigoErrorItem(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"timeout", context.DeadlineExceeded, CodigoErrorTimeout},
+		{"cancelado", fmt.Errorf("aplicando salida: %w", context.Canceled), CodigoErrorTimeout},
+		{"bd no disponible", fmt.Errorf("failed to update stock: %w", driver.ErrBadConn), CodigoErrorBDNoDisponible},
+		{"stock insuficiente envuelto", fmt.Errorf("%w: disponible 1, solicitado 2", repository.ErrStockInsuficiente), CodigoErrorStockInsuficiente},
+		{"stock de bodega insuficiente", ErrStockBodegaInsuficiente, CodigoErrorStockInsuficiente},
+		{"producto no encontrado envuelto", fmt.Errorf("%w: pack P1", ErrProductoNoEncontrado), CodigoErrorProductoNoEncontrado},
+		{"producto descontinuado", ErrProductoDescontinuado, CodigoErrorProductoInactivo},
+		{"local inactivo", ErrLocalInactivo, CodigoErrorLocalInvalido},
+		{"bodega inválida", ErrBodegaInvalida, CodigoErrorBodegaInvalida},
+		{"unidad sin conversión", ErrUnidadSinConversion, CodigoErrorUnidadInvalida},
+		{"documento duplicado", ErrDocumentoDuplicado, CodigoErrorDocumentoInvalido},
+		{"ciclo de pack", ErrCicloPack, CodigoErrorPackInvalido},
+		{"operación bloqueada", ErrOperacionBloqueada, CodigoErrorOperacionBloqueada},
+		{"stock ocupado", ErrStockOcupado, CodigoErrorStockOcupado},
+		{"error desconocido", errors.New("boom"), CodigoErrorInterno},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codigoErrorItem(tt.err); got != tt.want {
+				t.Errorf("codigoErrorItem(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
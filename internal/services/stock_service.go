@@ -2,11 +2,20 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	"stock-service/internal/cache"
+	"stock-service/internal/config"
+	"stock-service/internal/export"
+	"stock-service/internal/gs1"
 	"stock-service/internal/models"
 	"stock-service/internal/repository"
+	"stock-service/internal/uuid"
 
 	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
@@ -21,16 +30,183 @@ type StockService interface {
 	// Operaciones múltiples
 	EntradaMultipleStock(ctx context.Context, req *models.EntradaMultipleStockRequest) (*models.EntradaMultipleStockResponse, error)
 	SalidaMultipleStock(ctx context.Context, req *models.SalidaMultipleStockRequest) (*models.SalidaMultipleStockResponse, error)
+	// SalidaMultipleStockAtomica salida todo o nada; alConfirmar registra en la misma transacción lo
+	// que depende de la salida (p. ej. la venta rápida) y si retorna error no se aplica ningún producto
+	SalidaMultipleStockAtomica(ctx context.Context, req *models.SalidaMultipleStockRequest, alConfirmar func(repo repository.StockRepository, idOperacion string) error) (*models.SalidaMultipleStockResponse, error)
+	MovimientoStockLote(ctx context.Context, entradas []*models.EntradaStockRequest, salidas []*models.SalidaStockRequest) error
+	SalidaStockLote(ctx context.Context, reqs []*models.SalidaStockRequest) ([]int, error)
 
 	// Consultas
 	GetStockByLocal(ctx context.Context, idLocal int) ([]*models.Stock, error)
 	GetStockBajo(ctx context.Context, idLocal int) ([]*models.Stock, error)
 	GetStockByProducto(ctx context.Context, codigoProducto string, idLocal int) (*models.Stock, error)
+	// GetStockDisponibilidad desglose física / reservada / en tránsito / disponible para venta
+	GetStockDisponibilidad(ctx context.Context, codigoProducto string, idLocal int) (*models.StockDisponibilidad, error)
 	GetStockCompleteByLocal(ctx context.Context, idLocal int) ([]*models.StockComplete, error)
+	GetStockDescontinuadoByLocal(ctx context.Context, idLocal int) ([]*models.StockComplete, error)
+	// GetStockSummary resumen por local (todos si idLocal es nil)
+	GetStockSummary(ctx context.Context, idLocal *int) ([]*models.StockSummary, error)
 	GetMovimientosByLocal(ctx context.Context, filter *models.MovimientoFilter) ([]*models.Movimiento, error)
+	// GetMovimientosPaginados página de movimientos con el total que cumple los filtros
+	GetMovimientosPaginados(ctx context.Context, filter *models.MovimientoFilter) (*models.PaginaMovimientos, error)
+	// GetMovimientosAgrupados suma de cantidades y conteo de los movimientos por día, producto, usuario o motivo
+	GetMovimientosAgrupados(ctx context.Context, filter *models.MovimientoFilter, agrupacion string) (*models.PaginaMovimientosAgrupados, error)
+	// GetHistorialMinimos cambios de cantidad mínima del producto (idLocal nil: todos los locales)
+	GetHistorialMinimos(ctx context.Context, codigoProducto string, idLocal *int, limit int) ([]*models.CambioCantidadMinima, error)
+
+	// Exportaciones descargables (CSV o XLSX) escritas fila a fila; retornan las filas de datos escritas
+	ExportarStock(ctx context.Context, idLocal int, w export.Writer) (int, error)
+	ExportarMovimientos(ctx context.Context, filter *models.MovimientoFilter, w export.Writer) (int, error)
+
+	// Proyecciones
+	ProyectarStock(ctx context.Context, req *models.ProyeccionStockRequest) (*models.ProyeccionStockResponse, error)
+
+	// Auditoría del stock contra los movimientos
+	AuditarStock(ctx context.Context, req *models.AuditoriaStockRequest) (*models.AuditoriaStockResponse, error)
+	// VerificarIntegridadMovimientos recalcula la cadena de hashes de los movimientos del local en el rango
+	VerificarIntegridadMovimientos(ctx context.Context, idLocal int, desde, hasta *time.Time) (*models.IntegridadMovimientos, error)
+
+	// Bodegas dentro del local (sala de venta, trastienda...) y traslados internos entre ellas
+	GetBodegas(ctx context.Context, idLocal int) ([]*models.Bodega, error)
+	CrearBodega(ctx context.Context, req *models.CrearBodegaRequest) (*models.Bodega, error)
+	ActualizarBodega(ctx context.Context, id int, req *models.ActualizarBodegaRequest) (*models.Bodega, error)
+	// GetStockPorBodega stock del local desglosado por bodega (codigoProducto nil: todos)
+	GetStockPorBodega(ctx context.Context, idLocal int, codigoProducto *string) ([]*models.StockPorBodega, error)
+	TrasladoInterno(ctx context.Context, req *models.TrasladoInternoRequest) (*models.Movimiento, error)
+
+	// AjustarStock fija la cantidad absoluta de un producto tras un inventario físico (movimiento "ajuste")
+	AjustarStock(ctx context.Context, req *models.AjusteStockRequest) (*models.Movimiento, error)
+	// AjusteStockLote aplica varios ajustes en una transacción; los ítems sin diferencia se omiten
+	// alConfirmar (opcional) corre en la misma transacción con los movimientos generados
+	AjusteStockLote(ctx context.Context, reqs []*models.AjusteStockRequest, alConfirmar func(repo repository.StockRepository, movimientos []*models.Movimiento) error) ([]*models.Movimiento, error)
+
+	// Transferencias directas entre locales (salida del origen y entrada al destino en una transacción)
+	TransferirStock(ctx context.Context, req *models.TransferenciaStockRequest) (*models.TransferenciaStock, error)
+	GetTransferencia(ctx context.Context, id int64) (*models.TransferenciaStock, error)
+	GetTransferencias(ctx context.Context, filter *models.TransferenciaFilter) ([]*models.TransferenciaStock, error)
+	AnularTransferencia(ctx context.Context, id int64, req *models.AnularTransferenciaRequest) (*models.TransferenciaStock, error)
 
 	// POS - Búsqueda de productos
 	GetProductoByBarcode(ctx context.Context, barcode string) (*models.ProductoCompleto, error)
+
+	// Recepción: producto, lote y vencimiento desde una etiqueta GS1-128 / DataMatrix
+	DecodificarGS1(ctx context.Context, codigo string) (*models.PrecargaRecepcionGS1, error)
+}
+
+// localesCacheTTL tiempo que se mantiene un local validado en memoria
+const localesCacheTTL = 5 * time.Minute
+
+// localCacheEntry local cacheado con su expiración
+type localCacheEntry struct {
+	local     *models.Local
+	expiresAt time.Time
+}
+
+const (
+	// movimientosLimitePorDefecto tamaño de página del historial de movimientos si no se indica
+	movimientosLimitePorDefecto = 100
+	// movimientosLimiteMaximo tamaño máximo de página del historial de movimientos
+	movimientosLimiteMaximo = 1000
+)
+
+// maxProfundidadPack niveles máximos de packs anidados al expandir una operación
+const maxProfundidadPack = 3
+
+// stockKey identifica un registro de stock (producto + local)
+type stockKey struct {
+	codigoProducto string
+	idLocal        int
+}
+
+// operacionStock estado de una operación de stock en curso
+// repo está ligado a la transacción de la operación; idOperacion agrupa todos sus movimientos
+// bloqueos son los locks por producto+local tomados por la operación (se liberan al terminarla)
+type operacionStock struct {
+	repo        repository.StockRepository
+	idOperacion string
+	afectados   []stockKey
+	locker      *cache.KeyLocker
+	bloqueos    map[stockKey]func()
+	// Movimientos registrados por la operación, en orden (el de un pack antes que los de sus componentes)
+	movimientos []*models.Movimiento
+}
+
+// nuevaOperacion inicia una operación con el id dado (vacío: genera uno nuevo)
+func nuevaOperacion(idOperacion string) *operacionStock {
+	if idOperacion == "" {
+		idOperacion = uuid.New()
+	}
+	return &operacionStock{idOperacion: idOperacion}
+}
+
+// movimientoDesde primer movimiento registrado desde que la operación llevaba n movimientos:
+// el del ítem aplicado (los de los componentes de un pack se registran después)
+func (op *operacionStock) movimientoDesde(n int) *models.Movimiento {
+	if n < len(op.movimientos) {
+		return op.movimientos[n]
+	}
+	return nil
+}
+
+// nuevaOperacionSerializada inicia una operación que toma el lock de cada producto+local que modifica
+// Hay que liberar los locks con op.liberar() cuando la transacción termina
+func (s *stockService) nuevaOperacionSerializada(idOperacion string) *operacionStock {
+	op := nuevaOperacion(idOperacion)
+	op.locker = s.locker
+	return op
+}
+
+// bloquear toma el lock del producto+local antes de leer su stock, para que dos operaciones
+// sobre el mismo ítem no se intercalen entre réplicas; un ítem ya bloqueado por la operación no se retoma
+func (op *operacionStock) bloquear(ctx context.Context, codigoProducto string, idLocal int) error {
+	if op.locker == nil {
+		return nil
+	}
+	key := stockKey{codigoProducto: codigoProducto, idLocal: idLocal}
+	if _, ok := op.bloqueos[key]; ok {
+		return nil
+	}
+
+	release, err := op.locker.Lock(ctx, fmt.Sprintf("stock:%s:%d", codigoProducto, idLocal))
+	if err != nil {
+		if errors.Is(err, cache.ErrLockTimeout) {
+			return fmt.Errorf("%w: %s en local %d", ErrStockOcupado, codigoProducto, idLocal)
+		}
+		return err
+	}
+
+	if op.bloqueos == nil {
+		op.bloqueos = make(map[stockKey]func())
+	}
+	op.bloqueos[key] = release
+	return nil
+}
+
+// liberar libera todos los locks tomados por la operación (tras el commit o rollback)
+func (op *operacionStock) liberar() {
+	for key, release := range op.bloqueos {
+		release()
+		delete(op.bloqueos, key)
+	}
+}
+
+// asignarOperacion marca el movimiento como parte de la operación en curso
+func (op *operacionStock) asignarOperacion(movimiento *models.Movimiento) {
+	if op.idOperacion != "" {
+		idOperacion := op.idOperacion
+		movimiento.IDOperacion = &idOperacion
+	}
+}
+
+// registrarAfectado registra un ítem modificado para invalidar su cache tras el commit
+func (op *operacionStock) registrarAfectado(codigoProducto string, idLocal int) {
+	op.afectados = append(op.afectados, stockKey{codigoProducto: codigoProducto, idLocal: idLocal})
+}
+
+// expansionPack estado de la expansión recursiva de packs en la rama actual
+type expansionPack struct {
+	profundidad int
+	ruta        []string // packs expandidos en la rama (para detectar ciclos)
 }
 
 // stockService implementa StockService
@@ -38,21 +214,260 @@ type stockService struct {
 	repo        repository.StockRepository
 	productRepo repository.ProductRepository
 	cache       *redis.Client
-	logger      *zap.Logger
+	// Invalidaciones de stock con reintentos si Redis falla
+	invalidations *cache.InvalidationQueue
+	// Serialización por producto+local entre réplicas (nil: deshabilitada)
+	locker *cache.KeyLocker
+	// Registrar un evento en la outbox por movimiento (solo con webhooks configurados)
+	publicarEventos bool
+	logger          *zap.Logger
+
+	// Cache en memoria de locales (se consulta en cada operación)
+	localesMutex sync.RWMutex
+	locales      map[int]*localCacheEntry
 }
 
 // NewStockService crea una nueva instancia del servicio
-func NewStockService(repo repository.StockRepository, productRepo repository.ProductRepository, cache *redis.Client, logger *zap.Logger) StockService {
+func NewStockService(repo repository.StockRepository, productRepo repository.ProductRepository, redisClient *redis.Client, invalidations *cache.InvalidationQueue, locker *cache.KeyLocker, webhooks config.WebhooksConfig, logger *zap.Logger) StockService {
 	return &stockService{
-		repo:        repo,
-		productRepo: productRepo,
-		cache:       cache,
-		logger:      logger,
+		repo:            repo,
+		productRepo:     productRepo,
+		cache:           redisClient,
+		invalidations:   invalidations,
+		locker:          locker,
+		publicarEventos: len(webhooks.URLs) > 0,
+		logger:          logger,
+		locales:         make(map[int]*localCacheEntry),
 	}
 }
 
 // EntradaStock procesa la entrada de stock de un producto
+// Toda la operación, incluida la expansión de packs, se ejecuta en una sola transacción
 func (s *stockService) EntradaStock(ctx context.Context, req *models.EntradaStockRequest) (*models.EntradaStockResponse, error) {
+	return s.entradaStock(ctx, req, true)
+}
+
+// entradaStock aplica una entrada; verificarDocumento en false cuando el documento ya se
+// verificó para toda la operación (entrada múltiple: todos los ítems comparten documento)
+func (s *stockService) entradaStock(ctx context.Context, req *models.EntradaStockRequest, verificarDocumento bool) (*models.EntradaStockResponse, error) {
+	op := s.nuevaOperacionSerializada(req.IDOperacion)
+	defer op.liberar()
+	var cantidadNueva int
+
+	err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+		op.repo = repo
+		if err := repo.BloquearCadenas(ctx, req.IDLocal); err != nil {
+			return err
+		}
+		if verificarDocumento {
+			if err := s.verificarDocumento(ctx, repo, req.Documento); err != nil {
+				return err
+			}
+		}
+		var err error
+		cantidadNueva, err = s.aplicarEntrada(ctx, op, req, expansionPack{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Invalidar cache de todos los ítems afectados (solo tras el commit)
+	s.invalidarAfectados(op)
+
+	return &models.EntradaStockResponse{
+		Success: true,
+		Message: "✅ Entrada de stock registrada correctamente",
+		Data: struct {
+			CodigoProducto string `json:"codigo_producto"`
+			TipoItem       string `json:"tipo_item"`
+			Cantidad       int    `json:"cantidad"`
+			CantidadNueva  int    `json:"cantidad_nueva"`
+			Motivo         string `json:"motivo"`
+			IDLocal        int    `json:"id_local"`
+			IDOperacion    string `json:"id_operacion"`
+			Timestamp      string `json:"timestamp"`
+		}{
+			CodigoProducto: req.CodigoProducto,
+			TipoItem:       req.TipoItem,
+			Cantidad:       req.Cantidad,
+			CantidadNueva:  cantidadNueva,
+			Motivo:         req.Motivo,
+			IDLocal:        req.IDLocal,
+			IDOperacion:    op.idOperacion,
+			Timestamp:      time.Now().Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// SalidaStock procesa la salida de stock de un producto
+// Toda la operación, incluida la expansión de packs, se ejecuta en una sola transacción
+func (s *stockService) SalidaStock(ctx context.Context, req *models.SalidaStockRequest) (*models.SalidaStockResponse, error) {
+	op := s.nuevaOperacionSerializada(req.IDOperacion)
+	defer op.liberar()
+	var cantidadNueva int
+
+	err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+		op.repo = repo
+		if err := repo.BloquearCadenas(ctx, req.IDLocal); err != nil {
+			return err
+		}
+		var err error
+		cantidadNueva, err = s.aplicarSalida(ctx, op, req, expansionPack{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Invalidar cache de todos los ítems afectados (solo tras el commit)
+	s.invalidarAfectados(op)
+
+	return &models.SalidaStockResponse{
+		Success: true,
+		Message: "✅ Salida de stock registrada correctamente",
+		Data: struct {
+			CodigoProducto string `json:"codigo_producto"`
+			TipoItem       string `json:"tipo_item"`
+			Cantidad       int    `json:"cantidad"`
+			CantidadNueva  int    `json:"cantidad_nueva"`
+			Motivo         string `json:"motivo"`
+			IDLocal        int    `json:"id_local"`
+			IDOperacion    string `json:"id_operacion"`
+			Timestamp      string `json:"timestamp"`
+		}{
+			CodigoProducto: req.CodigoProducto,
+			TipoItem:       req.TipoItem,
+			Cantidad:       req.Cantidad,
+			CantidadNueva:  cantidadNueva,
+			Motivo:         req.Motivo,
+			IDLocal:        req.IDLocal,
+			IDOperacion:    op.idOperacion,
+			Timestamp:      time.Now().Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// errSimulacion fuerza el rollback de la transacción de una simulación
+var errSimulacion = errors.New("simulación: rollback")
+
+// errOperacionRevertida fuerza el rollback de una operación múltiple atómica con algún ítem fallido
+var errOperacionRevertida = errors.New("operación atómica: rollback")
+
+// simular ejecuta fn dentro de una transacción que siempre se revierte
+// Valida con la misma lógica de la operación real sin persistir nada ni invalidar cache
+func (s *stockService) simular(ctx context.Context, idLocal int, fn func(op *operacionStock) error) error {
+	err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+		if err := repo.BloquearCadenas(ctx, idLocal); err != nil {
+			return err
+		}
+		if err := fn(&operacionStock{repo: repo}); err != nil {
+			return err
+		}
+		return errSimulacion
+	})
+	if errors.Is(err, errSimulacion) {
+		return nil
+	}
+	return err
+}
+
+// MovimientoStockLote aplica entradas y luego salidas en una sola transacción: o se aplican todas o ninguna
+// Los ítems pueden compartir documento de respaldo: cada documento se verifica una sola vez
+// Todos los movimientos del lote forman una operación (la del primer ítem que la indique)
+func (s *stockService) MovimientoStockLote(ctx context.Context, entradas []*models.EntradaStockRequest, salidas []*models.SalidaStockRequest) error {
+	idOperacion := ""
+	var locales []int
+	for _, req := range entradas {
+		if idOperacion == "" {
+			idOperacion = req.IDOperacion
+		}
+		locales = append(locales, req.IDLocal)
+	}
+	for _, req := range salidas {
+		if idOperacion == "" {
+			idOperacion = req.IDOperacion
+		}
+		locales = append(locales, req.IDLocal)
+	}
+	op := s.nuevaOperacionSerializada(idOperacion)
+	defer op.liberar()
+
+	err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+		op.repo = repo
+		if err := repo.BloquearCadenas(ctx, locales...); err != nil {
+			return err
+		}
+		verificados := make(map[models.DocumentoRespaldo]bool)
+		for _, req := range entradas {
+			if req.Documento != nil && !verificados[*req.Documento] {
+				if err := s.verificarDocumento(ctx, repo, req.Documento); err != nil {
+					return err
+				}
+				verificados[*req.Documento] = true
+			}
+			if _, err := s.aplicarEntrada(ctx, op, req, expansionPack{}); err != nil {
+				return fmt.Errorf("%s: %w", req.CodigoProducto, err)
+			}
+		}
+		for _, req := range salidas {
+			if _, err := s.aplicarSalida(ctx, op, req, expansionPack{}); err != nil {
+				return fmt.Errorf("%s: %w", req.CodigoProducto, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidarAfectados(op)
+
+	return nil
+}
+
+// SalidaStockLote aplica varias salidas en una sola transacción: o se aplican todas o ninguna
+// Retorna la cantidad resultante de cada ítem, en el mismo orden de reqs
+// Todas las salidas forman una operación (la del primer ítem que la indique)
+func (s *stockService) SalidaStockLote(ctx context.Context, reqs []*models.SalidaStockRequest) ([]int, error) {
+	idOperacion := ""
+	locales := make([]int, 0, len(reqs))
+	for _, req := range reqs {
+		if idOperacion == "" {
+			idOperacion = req.IDOperacion
+		}
+		locales = append(locales, req.IDLocal)
+	}
+	op := s.nuevaOperacionSerializada(idOperacion)
+	defer op.liberar()
+	cantidades := make([]int, len(reqs))
+
+	err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+		op.repo = repo
+		if err := repo.BloquearCadenas(ctx, locales...); err != nil {
+			return err
+		}
+		for i, req := range reqs {
+			cantidadNueva, err := s.aplicarSalida(ctx, op, req, expansionPack{})
+			if err != nil {
+				return fmt.Errorf("%s: %w", req.CodigoProducto, err)
+			}
+			cantidades[i] = cantidadNueva
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidarAfectados(op)
+
+	return cantidades, nil
+}
+
+// aplicarEntrada aplica la entrada de un ítem dentro de la transacción de la operación
+// Retorna la cantidad resultante del ítem
+func (s *stockService) aplicarEntrada(ctx context.Context, op *operacionStock, req *models.EntradaStockRequest, exp expansionPack) (int, error) {
 	logger := s.logger.With(
 		zap.String("operation", "entrada_stock"),
 		zap.String("codigo_producto", req.CodigoProducto),
@@ -63,23 +478,46 @@ func (s *stockService) EntradaStock(ctx context.Context, req *models.EntradaStoc
 
 	logger.Info("🔍 [DEBUG] Iniciando entrada de stock individual")
 
+	// Verificar que el local existe y está activo
+	if err := s.verificarLocal(ctx, req.IDLocal); err != nil {
+		logger.Error("❌ [DEBUG] Local inválido", zap.Error(err))
+		return 0, err
+	}
+
 	// Verificar que el producto existe
 	logger.Info("🔍 [DEBUG] Verificando que el producto existe",
 		zap.String("codigo_producto", req.CodigoProducto),
 		zap.String("tipo_item", req.TipoItem))
 
-	if err := s.verificarProductoExiste(ctx, req.CodigoProducto, req.TipoItem); err != nil {
+	if err := s.verificarProductoExiste(ctx, op.repo, req.CodigoProducto, req.TipoItem, "entrada"); err != nil {
 		logger.Error("❌ [DEBUG] Producto no encontrado", zap.Error(err))
-		return nil, fmt.Errorf("producto no encontrado: %w", err)
+		return 0, err
 	}
 	logger.Info("✅ [DEBUG] Producto verificado exitosamente")
 
+	// Bodega del local que recibe la entrada (sin indicar: la sala de venta)
+	bodega, err := s.resolverBodega(ctx, op.repo, req.IDLocal, req.IDBodega)
+	if err != nil {
+		return 0, err
+	}
+
+	// Convertir a la unidad base del producto antes de afectar el stock
+	cantidad, err := cantidadEnUnidadBase(ctx, op.repo, req.CodigoProducto, req.Unidad, req.Cantidad)
+	if err != nil {
+		return 0, err
+	}
+
+	// Serializar con otras operaciones sobre el mismo producto+local
+	if err := op.bloquear(ctx, req.CodigoProducto, req.IDLocal); err != nil {
+		return 0, err
+	}
+
 	// Obtener stock actual
 	logger.Info("🔍 [DEBUG] Obteniendo stock actual")
-	stockActual, err := s.repo.GetStockByProducto(ctx, req.CodigoProducto, req.IDLocal)
+	stockActual, err := op.repo.GetStockByProducto(ctx, req.CodigoProducto, req.IDLocal)
 	if err != nil {
 		logger.Error("❌ [DEBUG] Error obteniendo stock actual", zap.Error(err))
-		return nil, fmt.Errorf("error obteniendo stock actual: %w", err)
+		return 0, fmt.Errorf("error obteniendo stock actual: %w", err)
 	}
 
 	cantidadAnterior := 0
@@ -91,21 +529,47 @@ func (s *stockService) EntradaStock(ctx context.Context, req *models.EntradaStoc
 		logger.Info("🔍 [DEBUG] No hay stock actual, creando nuevo registro")
 	}
 
-	cantidadNueva := cantidadAnterior + req.Cantidad
+	cantidadNueva := cantidadAnterior + cantidad
 	logger.Info("🔍 [DEBUG] Calculando cantidad nueva",
 		zap.Int("cantidad_anterior", cantidadAnterior),
-		zap.Int("cantidad_entrada", req.Cantidad),
+		zap.Int("cantidad_entrada", cantidad),
 		zap.Int("cantidad_nueva", cantidadNueva))
 
+	// Cambio de cantidad mínima a auditar (nil si la entrada no la modifica)
+	var cambioMinimo *models.CambioCantidadMinima
+	if req.CantidadMinima > 0 && (stockActual == nil || stockActual.CantidadMinima != req.CantidadMinima) {
+		cambioMinimo = &models.CambioCantidadMinima{
+			CodigoProducto: req.CodigoProducto,
+			IDLocal:        req.IDLocal,
+			CantidadNueva:  req.CantidadMinima,
+			IDUsuario:      req.IDUsuario,
+			Motivo:         req.Motivo,
+		}
+		if stockActual != nil {
+			anterior := stockActual.CantidadMinima
+			cambioMinimo.CantidadAnterior = &anterior
+		}
+		if op.idOperacion != "" {
+			idOperacion := op.idOperacion
+			cambioMinimo.IDOperacion = &idOperacion
+		}
+	}
+
 	// Actualizar o crear stock
 	if stockActual != nil {
 		logger.Info("🔍 [DEBUG] Actualizando stock existente")
-		stockActual.CantidadActual = cantidadNueva
-		if req.CantidadMinima > 0 {
-			stockActual.CantidadMinima = req.CantidadMinima
-			logger.Info("🔍 [DEBUG] Actualizando cantidad mínima", zap.Int("cantidad_minima", req.CantidadMinima))
+		// El incremento se hace en la BD: el saldo resultante no depende de lo leído antes
+		cantidadNueva, err = op.repo.IncrementStock(ctx, req.CodigoProducto, req.IDLocal, cantidad)
+		if err == nil {
+			cantidadAnterior = cantidadNueva - cantidad
+			stockActual.CantidadActual = cantidadNueva
+			if cambioMinimo != nil {
+				// La fila ya quedó bloqueada por el incremento hasta el commit
+				stockActual.CantidadMinima = req.CantidadMinima
+				logger.Info("🔍 [DEBUG] Actualizando cantidad mínima", zap.Int("cantidad_minima", req.CantidadMinima))
+				err = op.repo.UpdateStock(ctx, stockActual)
+			}
 		}
-		err = s.repo.UpdateStock(ctx, stockActual)
 	} else {
 		logger.Info("🔍 [DEBUG] Creando nuevo stock")
 		stockActual = &models.Stock{
@@ -115,22 +579,39 @@ func (s *stockService) EntradaStock(ctx context.Context, req *models.EntradaStoc
 			CantidadMinima: req.CantidadMinima,
 			IDLocal:        req.IDLocal,
 		}
-		err = s.repo.CreateStock(ctx, stockActual)
+		err = op.repo.CreateStock(ctx, stockActual)
 	}
 
 	if err != nil {
 		logger.Error("❌ [DEBUG] Error actualizando/creando stock", zap.Error(err))
-		return nil, fmt.Errorf("error actualizando stock: %w", err)
+		return 0, fmt.Errorf("error actualizando stock: %w", err)
 	}
 	logger.Info("✅ [DEBUG] Stock actualizado/creado exitosamente")
 
+	if err := s.ajustarBodega(ctx, op.repo, bodega, req.CodigoProducto, req.IDLocal, cantidad); err != nil {
+		return 0, err
+	}
+
+	if cambioMinimo != nil {
+		if err := op.repo.CreateCambioMinimo(ctx, cambioMinimo); err != nil {
+			return 0, fmt.Errorf("error registrando cambio de cantidad mínima: %w", err)
+		}
+		logger.Info("Cantidad mínima modificada",
+			zap.Intp("cantidad_minima_anterior", cambioMinimo.CantidadAnterior),
+			zap.Int("cantidad_minima_nueva", cambioMinimo.CantidadNueva))
+	}
+
 	// Registrar movimiento
 	logger.Info("🔍 [DEBUG] Creando movimiento")
+	tipoMovimiento := req.TipoMovimiento
+	if tipoMovimiento == "" {
+		tipoMovimiento = "entrada"
+	}
 	movimiento := &models.Movimiento{
 		CodigoProducto:   req.CodigoProducto,
 		TipoItem:         req.TipoItem,
-		TipoMovimiento:   "entrada",
-		Cantidad:         req.Cantidad,
+		TipoMovimiento:   tipoMovimiento,
+		Cantidad:         cantidad,
 		CantidadAnterior: cantidadAnterior,
 		CantidadNueva:    cantidadNueva,
 		Motivo:           req.Motivo,
@@ -138,55 +619,45 @@ func (s *stockService) EntradaStock(ctx context.Context, req *models.EntradaStoc
 		IDLocal:          req.IDLocal,
 		Observaciones:    req.Observaciones,
 	}
+	if err := asignarDocumento(movimiento, req.Documento); err != nil {
+		return 0, err
+	}
+	asignarUnidad(movimiento, req.Unidad, req.Cantidad)
+	op.asignarOperacion(movimiento)
+	if bodega != nil {
+		movimiento.IDBodega = &bodega.ID
+	}
 
-	if err := s.repo.CreateMovimiento(ctx, movimiento); err != nil {
+	if err := s.crearMovimiento(ctx, op.repo, movimiento); err != nil {
 		logger.Error("❌ [DEBUG] Error creando movimiento", zap.Error(err))
-		return nil, fmt.Errorf("error creando movimiento: %w", err)
+		return 0, fmt.Errorf("error creando movimiento: %w", err)
 	}
+	op.movimientos = append(op.movimientos, movimiento)
 	logger.Info("✅ [DEBUG] Movimiento creado exitosamente")
 
 	// Si es un pack, procesar productos individuales
 	if req.TipoItem == "pack" {
 		logger.Info("🔍 [DEBUG] Procesando pack")
-		if err := s.procesarPack(ctx, req.CodigoProducto, req.Cantidad, "entrada", req.IDUsuario, req.IDLocal); err != nil {
+		if err := s.procesarPack(ctx, op, req.CodigoProducto, cantidad, "entrada", req.IDUsuario, req.IDLocal, req.IDBodega, exp); err != nil {
 			logger.Error("❌ [DEBUG] Error procesando pack", zap.Error(err))
-			return nil, fmt.Errorf("error procesando pack: %w", err)
+			return 0, fmt.Errorf("error procesando pack: %w", err)
 		}
 		logger.Info("✅ [DEBUG] Pack procesado exitosamente")
 	}
 
-	// Invalidar cache
+	// Registrar para invalidar cache tras el commit
 	logger.Info("🔍 [DEBUG] Invalidando cache")
-	s.invalidarCacheStock(req.CodigoProducto, req.IDLocal)
+	op.registrarAfectado(req.CodigoProducto, req.IDLocal)
 
 	logger.Info("✅ [DEBUG] Entrada de stock completada exitosamente",
 		zap.Int("cantidad_nueva", cantidadNueva))
 
-	return &models.EntradaStockResponse{
-		Success: true,
-		Message: "✅ Entrada de stock registrada correctamente",
-		Data: struct {
-			CodigoProducto string `json:"codigo_producto"`
-			TipoItem       string `json:"tipo_item"`
-			Cantidad       int    `json:"cantidad"`
-			CantidadNueva  int    `json:"cantidad_nueva"`
-			Motivo         string `json:"motivo"`
-			IDLocal        int    `json:"id_local"`
-			Timestamp      string `json:"timestamp"`
-		}{
-			CodigoProducto: req.CodigoProducto,
-			TipoItem:       req.TipoItem,
-			Cantidad:       req.Cantidad,
-			CantidadNueva:  cantidadNueva,
-			Motivo:         req.Motivo,
-			IDLocal:        req.IDLocal,
-			Timestamp:      time.Now().Format(time.RFC3339),
-		},
-	}, nil
+	return cantidadNueva, nil
 }
 
-// SalidaStock procesa la salida de stock de un producto
-func (s *stockService) SalidaStock(ctx context.Context, req *models.SalidaStockRequest) (*models.SalidaStockResponse, error) {
+// aplicarSalida aplica la salida de un ítem dentro de la transacción de la operación
+// Retorna la cantidad resultante del ítem
+func (s *stockService) aplicarSalida(ctx context.Context, op *operacionStock, req *models.SalidaStockRequest, exp expansionPack) (int, error) {
 	logger := s.logger.With(
 		zap.String("operation", "salida_stock"),
 		zap.String("codigo_producto", req.CodigoProducto),
@@ -196,48 +667,118 @@ func (s *stockService) SalidaStock(ctx context.Context, req *models.SalidaStockR
 
 	logger.Info("Iniciando salida de stock")
 
+	// Verificar que el local existe y está activo
+	if err := s.verificarLocal(ctx, req.IDLocal); err != nil {
+		logger.Error("Local inválido", zap.Error(err))
+		return 0, err
+	}
+
 	// Verificar que el producto existe
-	if err := s.verificarProductoExiste(ctx, req.CodigoProducto, req.TipoItem); err != nil {
+	if err := s.verificarProductoExiste(ctx, op.repo, req.CodigoProducto, req.TipoItem, "salida"); err != nil {
 		logger.Error("Producto no encontrado", zap.Error(err))
-		return nil, fmt.Errorf("producto no encontrado: %w", err)
+		return 0, err
+	}
+
+	// Convertir a la unidad base del producto antes de afectar el stock
+	cantidad, err := cantidadEnUnidadBase(ctx, op.repo, req.CodigoProducto, req.Unidad, req.Cantidad)
+	if err != nil {
+		return 0, err
+	}
+
+	// Los servicios (flete, garantía) no llevan stock: solo se registra el movimiento
+	servicio, err := esServicio(ctx, op.repo, req.CodigoProducto, req.TipoItem)
+	if err != nil {
+		logger.Error("Error verificando tipo de producto", zap.Error(err))
+		return 0, fmt.Errorf("error verificando producto: %w", err)
+	}
+	if servicio {
+		return s.registrarSalidaServicio(ctx, op, req, cantidad)
+	}
+
+	// Bodega de la que sale el stock (sin indicar, como las ventas del POS: la sala de venta)
+	bodega, err := s.resolverBodega(ctx, op.repo, req.IDLocal, req.IDBodega)
+	if err != nil {
+		return 0, err
+	}
+
+	// Serializar con otras operaciones sobre el mismo producto+local
+	if err := op.bloquear(ctx, req.CodigoProducto, req.IDLocal); err != nil {
+		return 0, err
 	}
 
 	// Obtener stock actual
-	stockActual, err := s.repo.GetStockByProducto(ctx, req.CodigoProducto, req.IDLocal)
+	stockActual, err := op.repo.GetStockByProducto(ctx, req.CodigoProducto, req.IDLocal)
 	if err != nil {
 		logger.Error("Error obteniendo stock actual", zap.Error(err))
-		return nil, fmt.Errorf("error obteniendo stock actual: %w", err)
+		return 0, fmt.Errorf("error obteniendo stock actual: %w", err)
 	}
 
 	if stockActual == nil {
 		logger.Error("No hay stock disponible")
-		return nil, fmt.Errorf("no hay stock disponible para el producto %s", req.CodigoProducto)
+		return 0, fmt.Errorf("%w: no hay stock disponible para el producto %s", repository.ErrStockInsuficiente, req.CodigoProducto)
 	}
 
 	cantidadAnterior := stockActual.CantidadActual
-	cantidadNueva := cantidadAnterior - req.Cantidad
+	cantidadNueva := cantidadAnterior - cantidad
+
+	// Lo reservado por pickings preparados no está disponible para otras salidas
+	reservada, err := op.repo.GetCantidadReservada(ctx, req.CodigoProducto, req.IDLocal)
+	if err != nil {
+		logger.Error("Error obteniendo cantidad reservada", zap.Error(err))
+		return 0, fmt.Errorf("error obteniendo cantidad reservada: %w", err)
+	}
 
 	// Verificar stock suficiente
-	if cantidadNueva < 0 {
+	if cantidadNueva < reservada {
 		logger.Error("Stock insuficiente",
-			zap.Int("stock_disponible", cantidadAnterior),
-			zap.Int("cantidad_solicitada", req.Cantidad))
-		return nil, fmt.Errorf("stock insuficiente: disponible %d, solicitado %d", cantidadAnterior, req.Cantidad)
+			zap.Int("stock_disponible", cantidadAnterior-reservada),
+			zap.Int("stock_reservado", reservada),
+			zap.Int("cantidad_solicitada", cantidad))
+		if reservada > 0 {
+			return 0, fmt.Errorf("%w: disponible %d (reservado %d), solicitado %d", repository.ErrStockInsuficiente, cantidadAnterior-reservada, reservada, cantidad)
+		}
+		return 0, fmt.Errorf("%w: disponible %d, solicitado %d", repository.ErrStockInsuficiente, cantidadAnterior, cantidad)
 	}
 
-	// Actualizar stock
-	stockActual.CantidadActual = cantidadNueva
-	if err := s.repo.UpdateStock(ctx, stockActual); err != nil {
+	// Descontar en una sola sentencia con el chequeo de saldo: dos POS vendiendo el mismo
+	// producto a la vez no pueden dejarlo bajo lo reservado aunque ambos hayan leído el mismo saldo
+	cantidadNueva, err = op.repo.DecrementStock(ctx, req.CodigoProducto, req.IDLocal, cantidad, reservada)
+	if err != nil {
 		logger.Error("Error actualizando stock", zap.Error(err))
-		return nil, fmt.Errorf("error actualizando stock: %w", err)
+		if errors.Is(err, repository.ErrStockInsuficiente) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("error actualizando stock: %w", err)
+	}
+	cantidadAnterior = cantidadNueva + cantidad
+	stockActual.CantidadActual = cantidadNueva
+
+	if bodega != nil && bodega.Tipo == models.BodegaSala {
+		// La venta ya ocurrió en la sala: no se rechaza, pero una sala en negativo indica
+		// un traslado desde la trastienda que no se registró
+		enSala, err := s.stockEnBodega(ctx, op.repo, bodega, stockActual)
+		if err != nil {
+			return 0, err
+		}
+		if enSala < 0 {
+			logger.Warn("Sala de venta con stock negativo: falta registrar un traslado interno",
+				zap.Int("id_bodega", bodega.ID),
+				zap.Int("stock_sala", enSala))
+		}
+	} else if err := s.ajustarBodega(ctx, op.repo, bodega, req.CodigoProducto, req.IDLocal, -cantidad); err != nil {
+		return 0, err
 	}
 
 	// Registrar movimiento
+	tipoMovimiento := req.TipoMovimiento
+	if tipoMovimiento == "" {
+		tipoMovimiento = "salida"
+	}
 	movimiento := &models.Movimiento{
 		CodigoProducto:   req.CodigoProducto,
 		TipoItem:         req.TipoItem,
-		TipoMovimiento:   "salida",
-		Cantidad:         req.Cantidad,
+		TipoMovimiento:   tipoMovimiento,
+		Cantidad:         cantidad,
 		CantidadAnterior: cantidadAnterior,
 		CantidadNueva:    cantidadNueva,
 		Motivo:           req.Motivo,
@@ -245,46 +786,120 @@ func (s *stockService) SalidaStock(ctx context.Context, req *models.SalidaStockR
 		IDLocal:          req.IDLocal,
 		Observaciones:    req.Observaciones,
 	}
+	asignarUnidad(movimiento, req.Unidad, req.Cantidad)
+	op.asignarOperacion(movimiento)
+	if bodega != nil {
+		movimiento.IDBodega = &bodega.ID
+	}
 
-	if err := s.repo.CreateMovimiento(ctx, movimiento); err != nil {
+	if err := s.crearMovimiento(ctx, op.repo, movimiento); err != nil {
 		logger.Error("Error creando movimiento", zap.Error(err))
-		return nil, fmt.Errorf("error creando movimiento: %w", err)
+		return 0, fmt.Errorf("error creando movimiento: %w", err)
 	}
+	op.movimientos = append(op.movimientos, movimiento)
 
 	// Si es un pack, procesar productos individuales
 	if req.TipoItem == "pack" {
-		if err := s.procesarPack(ctx, req.CodigoProducto, req.Cantidad, "salida", req.IDUsuario, req.IDLocal); err != nil {
+		if err := s.procesarPack(ctx, op, req.CodigoProducto, cantidad, "salida", req.IDUsuario, req.IDLocal, req.IDBodega, exp); err != nil {
 			logger.Error("Error procesando pack", zap.Error(err))
-			return nil, fmt.Errorf("error procesando pack: %w", err)
+			return 0, fmt.Errorf("error procesando pack: %w", err)
 		}
 	}
 
-	// Invalidar cache
-	s.invalidarCacheStock(req.CodigoProducto, req.IDLocal)
+	// Registrar para invalidar cache tras el commit
+	op.registrarAfectado(req.CodigoProducto, req.IDLocal)
 
 	logger.Info("Salida de stock completada", zap.Int("cantidad_nueva", cantidadNueva))
 
-	return &models.SalidaStockResponse{
-		Success: true,
-		Message: "✅ Salida de stock registrada correctamente",
-		Data: struct {
-			CodigoProducto string `json:"codigo_producto"`
-			TipoItem       string `json:"tipo_item"`
-			Cantidad       int    `json:"cantidad"`
-			CantidadNueva  int    `json:"cantidad_nueva"`
-			Motivo         string `json:"motivo"`
-			IDLocal        int    `json:"id_local"`
-			Timestamp      string `json:"timestamp"`
-		}{
-			CodigoProducto: req.CodigoProducto,
-			TipoItem:       req.TipoItem,
-			Cantidad:       req.Cantidad,
-			CantidadNueva:  cantidadNueva,
-			Motivo:         req.Motivo,
-			IDLocal:        req.IDLocal,
-			Timestamp:      time.Now().Format(time.RFC3339),
-		},
-	}, nil
+	return cantidadNueva, nil
+}
+
+// crearMovimiento registra el movimiento y, con webhooks configurados, su evento en la outbox
+// dentro de la misma transacción: si el servicio cae tras el commit el evento igual se entrega
+func (s *stockService) crearMovimiento(ctx context.Context, repo repository.StockRepository, movimiento *models.Movimiento) error {
+	if err := repo.CreateMovimiento(ctx, movimiento); err != nil {
+		return err
+	}
+	if !s.publicarEventos {
+		return nil
+	}
+
+	payload, err := json.Marshal(movimiento)
+	if err != nil {
+		return fmt.Errorf("error serializando evento: %w", err)
+	}
+	clave := fmt.Sprintf("%s:%d", movimiento.CodigoProducto, movimiento.IDLocal)
+	evento := &models.EventoOutbox{
+		Tipo:    models.EventoStockMovimiento,
+		Clave:   &clave,
+		Payload: payload,
+	}
+	if err := repo.CreateEventoOutbox(ctx, evento); err != nil {
+		return fmt.Errorf("error registrando evento: %w", err)
+	}
+
+	// Un producto crítico que se queda sin stock genera además su alerta
+	if movimiento.TipoItem != "producto" || movimiento.CantidadAnterior <= 0 || movimiento.CantidadNueva > 0 {
+		return nil
+	}
+	critico, err := repo.EsProductoCritico(ctx, movimiento.CodigoProducto, movimiento.IDLocal)
+	if err != nil {
+		return err
+	}
+	if !critico {
+		return nil
+	}
+	payload, err = json.Marshal(&models.EventoQuiebreCriticoPayload{
+		CodigoProducto:   movimiento.CodigoProducto,
+		IDLocal:          movimiento.IDLocal,
+		CantidadAnterior: movimiento.CantidadAnterior,
+		CantidadNueva:    movimiento.CantidadNueva,
+		IDMovimiento:     movimiento.ID,
+		Motivo:           movimiento.Motivo,
+		Fecha:            movimiento.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("error serializando evento: %w", err)
+	}
+	alerta := &models.EventoOutbox{
+		Tipo:    models.EventoQuiebreCritico,
+		Clave:   &clave,
+		Payload: payload,
+	}
+	if err := repo.CreateEventoOutbox(ctx, alerta); err != nil {
+		return fmt.Errorf("error registrando alerta de quiebre: %w", err)
+	}
+	return nil
+}
+
+// registrarSalidaServicio registra la salida de un producto tipo servicio sin exigir ni descontar stock
+// El movimiento deja el ítem en la venta con cantidades de stock en 0
+func (s *stockService) registrarSalidaServicio(ctx context.Context, op *operacionStock, req *models.SalidaStockRequest, cantidad int) (int, error) {
+	movimiento := &models.Movimiento{
+		CodigoProducto: req.CodigoProducto,
+		TipoItem:       req.TipoItem,
+		TipoMovimiento: "salida",
+		Cantidad:       cantidad,
+		Motivo:         req.Motivo,
+		IDUsuario:      req.IDUsuario,
+		IDLocal:        req.IDLocal,
+		Observaciones:  req.Observaciones,
+	}
+	asignarUnidad(movimiento, req.Unidad, req.Cantidad)
+	op.asignarOperacion(movimiento)
+
+	if err := s.crearMovimiento(ctx, op.repo, movimiento); err != nil {
+		return 0, fmt.Errorf("error creando movimiento: %w", err)
+	}
+	op.movimientos = append(op.movimientos, movimiento)
+
+	s.logger.Info("Salida de servicio registrada sin control de stock",
+		zap.String("operation", "salida_stock"),
+		zap.String("codigo_producto", req.CodigoProducto),
+		zap.Int("cantidad", cantidad),
+		zap.Int("id_local", req.IDLocal))
+
+	return 0, nil
 }
 
 // GetStockByProducto obtiene el stock de un producto con cache
@@ -311,6 +926,36 @@ func (s *stockService) GetStockByProducto(ctx context.Context, codigoProducto st
 	return stock, nil
 }
 
+// GetStockDisponibilidad calcula cuánto del stock físico está comprometido y cuánto se puede vender
+func (s *stockService) GetStockDisponibilidad(ctx context.Context, codigoProducto string, idLocal int) (*models.StockDisponibilidad, error) {
+	disponibilidad := &models.StockDisponibilidad{
+		CodigoProducto: codigoProducto,
+		IDLocal:        idLocal,
+	}
+
+	stock, err := s.repo.GetStockByProducto(ctx, codigoProducto, idLocal)
+	if err != nil {
+		return nil, err
+	}
+	if stock != nil {
+		disponibilidad.Fisica = stock.CantidadActual
+	}
+
+	if disponibilidad.Reservada, err = s.repo.GetCantidadReservada(ctx, codigoProducto, idLocal); err != nil {
+		return nil, err
+	}
+	if disponibilidad.EnTransito, err = s.repo.GetCantidadEnTransito(ctx, codigoProducto, idLocal); err != nil {
+		return nil, err
+	}
+
+	disponibilidad.Disponible = disponibilidad.Fisica - disponibilidad.Reservada
+	if disponibilidad.Disponible < 0 {
+		disponibilidad.Disponible = 0
+	}
+
+	return disponibilidad, nil
+}
+
 // GetStockByLocal obtiene todo el stock de un local
 func (s *stockService) GetStockByLocal(ctx context.Context, idLocal int) ([]*models.Stock, error) {
 	return s.repo.GetStockByLocal(ctx, idLocal)
@@ -326,11 +971,96 @@ func (s *stockService) GetStockCompleteByLocal(ctx context.Context, idLocal int)
 	return s.repo.GetStockCompleteByLocal(ctx, idLocal)
 }
 
+// GetStockDescontinuadoByLocal obtiene el stock remanente de productos desactivados
+func (s *stockService) GetStockDescontinuadoByLocal(ctx context.Context, idLocal int) ([]*models.StockComplete, error) {
+	return s.repo.GetStockDescontinuadoByLocal(ctx, idLocal)
+}
+
+// GetStockSummary obtiene el resumen de stock por local
+func (s *stockService) GetStockSummary(ctx context.Context, idLocal *int) ([]*models.StockSummary, error) {
+	if idLocal != nil {
+		if err := s.verificarLocal(ctx, *idLocal); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetStockSummary(ctx, idLocal)
+}
+
 // GetMovimientosByLocal obtiene movimientos de un local
 func (s *stockService) GetMovimientosByLocal(ctx context.Context, filter *models.MovimientoFilter) ([]*models.Movimiento, error) {
 	return s.repo.GetMovimientosByLocal(ctx, filter)
 }
 
+// GetMovimientosPaginados página del historial de movimientos (limit fuera de rango: el por defecto o el máximo)
+func (s *stockService) GetMovimientosPaginados(ctx context.Context, filter *models.MovimientoFilter) (*models.PaginaMovimientos, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = movimientosLimitePorDefecto
+	}
+	if filter.Limit > movimientosLimiteMaximo {
+		filter.Limit = movimientosLimiteMaximo
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	total, err := s.repo.CountMovimientos(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	movimientos, err := s.repo.GetMovimientosByLocal(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginaMovimientos{
+		Movimientos: movimientos,
+		Total:       total,
+		Limit:       filter.Limit,
+		Offset:      filter.Offset,
+	}, nil
+}
+
+// GetMovimientosAgrupados agregados de movimientos con la misma paginación que el historial (sobre los grupos)
+func (s *stockService) GetMovimientosAgrupados(ctx context.Context, filter *models.MovimientoFilter, agrupacion string) (*models.PaginaMovimientosAgrupados, error) {
+	switch agrupacion {
+	case models.AgrupacionMovimientosDia, models.AgrupacionMovimientosProducto,
+		models.AgrupacionMovimientosUsuario, models.AgrupacionMovimientosMotivo:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrAgrupacionInvalida, agrupacion)
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = movimientosLimitePorDefecto
+	}
+	if filter.Limit > movimientosLimiteMaximo {
+		filter.Limit = movimientosLimiteMaximo
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	grupos, err := s.repo.AgruparMovimientos(ctx, filter, agrupacion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginaMovimientosAgrupados{
+		Agrupacion: agrupacion,
+		Grupos:     grupos,
+		Limit:      filter.Limit,
+		Offset:     filter.Offset,
+	}, nil
+}
+
+// GetHistorialMinimos obtiene los cambios de cantidad mínima de un producto (hasta 500)
+func (s *stockService) GetHistorialMinimos(ctx context.Context, codigoProducto string, idLocal *int, limit int) ([]*models.CambioCantidadMinima, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.repo.GetHistorialMinimos(ctx, codigoProducto, idLocal, limit)
+}
+
 // EntradaMultipleStock procesa entrada múltiple de stock
 func (s *stockService) EntradaMultipleStock(ctx context.Context, req *models.EntradaMultipleStockRequest) (*models.EntradaMultipleStockResponse, error) {
 	logger := s.logger.With(
@@ -342,55 +1072,140 @@ func (s *stockService) EntradaMultipleStock(ctx context.Context, req *models.Ent
 
 	logger.Info("🔍 [DEBUG] Iniciando entrada múltiple de stock en service")
 
+	// El documento respalda toda la entrada: se verifica una vez y lo comparten todos los ítems
+	// (cada ítem se aplica en su propia transacción, así que no se re-verifica por ítem)
+	if err := s.verificarDocumento(ctx, s.repo, req.Documento); err != nil {
+		return nil, err
+	}
+
 	resultados := []models.ProductoResultado{}
 	errores := []models.ProductoError{}
-
-	// Procesar cada producto
-	for i, producto := range req.Productos {
-		logger.Info("🔍 [DEBUG] Procesando producto en entrada múltiple",
-			zap.Int("index", i),
-			zap.String("codigo_producto", producto.CodigoProducto),
-			zap.String("tipo_item", producto.TipoItem),
-			zap.Int("cantidad", producto.Cantidad),
-			zap.Int("cantidad_minima", producto.CantidadMinima))
-
-		entradaReq := &models.EntradaStockRequest{
-			CodigoProducto: producto.CodigoProducto,
-			TipoItem:       producto.TipoItem,
-			Cantidad:       producto.Cantidad,
-			CantidadMinima: producto.CantidadMinima,
-			Motivo:         req.Motivo,
-			IDUsuario:      req.IDUsuario,
-			IDLocal:        req.IDLocal,
-			Observaciones:  req.Observaciones,
+	items := nuevosResultadosItems(len(req.Productos))
+
+	// Cada producto se aplica en su propia transacción (o todos en una si es atómica), pero todos
+	// sus movimientos comparten el id de la operación (las simulaciones no registran movimientos)
+	idOperacion := ""
+	if !req.DryRun {
+		idOperacion = req.IDOperacion
+		if idOperacion == "" {
+			idOperacion = uuid.New()
 		}
+	}
 
-		logger.Info("🔍 [DEBUG] Llamando a EntradaStock individual",
-			zap.String("codigo_producto", entradaReq.CodigoProducto),
-			zap.Int("cantidad", entradaReq.Cantidad),
-			zap.Int("id_local", entradaReq.IDLocal))
+	// procesarProductos aplica cada producto con la función dada, acumulando resultados y errores
+	procesarProductos := func(aplicar func(entradaReq *models.EntradaStockRequest) (int, *models.Movimiento, error)) {
+		for i, producto := range req.Productos {
+			items[i].CodigoProducto = producto.CodigoProducto
+			items[i].TipoItem = producto.TipoItem
+			items[i].Cantidad = producto.Cantidad
 
-		response, err := s.EntradaStock(ctx, entradaReq)
-		if err != nil {
-			logger.Error("❌ [DEBUG] Error procesando producto en entrada múltiple",
-				zap.String("codigo_producto", producto.CodigoProducto),
-				zap.Error(err))
-			errores = append(errores, models.ProductoError{
-				CodigoProducto: producto.CodigoProducto,
-				Error:          err.Error(),
-			})
-		} else {
-			logger.Info("✅ [DEBUG] Producto procesado exitosamente en entrada múltiple",
+			logger.Info("🔍 [DEBUG] Procesando producto en entrada múltiple",
+				zap.Int("index", i),
 				zap.String("codigo_producto", producto.CodigoProducto),
-				zap.Int("cantidad_nueva", response.Data.CantidadNueva))
-			resultados = append(resultados, models.ProductoResultado{
+				zap.String("tipo_item", producto.TipoItem),
+				zap.Int("cantidad", producto.Cantidad),
+				zap.Int("cantidad_minima", producto.CantidadMinima))
+
+			entradaReq := &models.EntradaStockRequest{
 				CodigoProducto: producto.CodigoProducto,
 				TipoItem:       producto.TipoItem,
 				Cantidad:       producto.Cantidad,
-				CantidadNueva:  response.Data.CantidadNueva,
-				Success:        true,
+				CantidadMinima: producto.CantidadMinima,
+				Motivo:         req.Motivo,
+				IDUsuario:      req.IDUsuario,
+				IDLocal:        req.IDLocal,
+				Observaciones:  req.Observaciones,
+				Documento:      req.Documento,
+				Unidad:         producto.Unidad,
+				IDBodega:       req.IDBodega,
+				IDOperacion:    idOperacion,
+			}
+
+			logger.Info("🔍 [DEBUG] Llamando a EntradaStock individual",
+				zap.String("codigo_producto", entradaReq.CodigoProducto),
+				zap.Int("cantidad", entradaReq.Cantidad),
+				zap.Int("id_local", entradaReq.IDLocal))
+
+			cantidadNueva, movimiento, err := aplicar(entradaReq)
+			if err != nil {
+				logger.Error("❌ [DEBUG] Error procesando producto en entrada múltiple",
+					zap.String("codigo_producto", producto.CodigoProducto),
+					zap.Error(err))
+				errores = append(errores, models.ProductoError{
+					CodigoProducto: producto.CodigoProducto,
+					Error:          err.Error(),
+				})
+				marcarFallido(&items[i], err)
+				// En modo atómico el primer error revierte la operación: no tiene sentido seguir
+				if req.Atomico {
+					return
+				}
+			} else {
+				logger.Info("✅ [DEBUG] Producto procesado exitosamente en entrada múltiple",
+					zap.String("codigo_producto", producto.CodigoProducto),
+					zap.Int("cantidad_nueva", cantidadNueva))
+				resultados = append(resultados, models.ProductoResultado{
+					CodigoProducto: producto.CodigoProducto,
+					TipoItem:       producto.TipoItem,
+					Cantidad:       producto.Cantidad,
+					CantidadNueva:  cantidadNueva,
+					Success:        true,
+				})
+				marcarAplicado(&items[i], cantidadNueva, movimiento, req.DryRun)
+			}
+		}
+	}
+
+	if req.DryRun {
+		// Simulación: se aplica todo en una transacción que se revierte al final
+		err := s.simular(ctx, req.IDLocal, func(op *operacionStock) error {
+			procesarProductos(func(entradaReq *models.EntradaStockRequest) (int, *models.Movimiento, error) {
+				n := len(op.movimientos)
+				cantidadNueva, err := s.aplicarEntrada(ctx, op, entradaReq, expansionPack{})
+				return cantidadNueva, op.movimientoDesde(n), err
 			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if req.Atomico {
+		// Todo o nada: todos los productos en una transacción; si uno falla se revierten todos
+		op := s.nuevaOperacionSerializada(idOperacion)
+		defer op.liberar()
+		err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+			op.repo = repo
+			if err := repo.BloquearCadenas(ctx, req.IDLocal); err != nil {
+				return err
+			}
+			if err := s.verificarDocumento(ctx, repo, req.Documento); err != nil {
+				return err
+			}
+			procesarProductos(func(entradaReq *models.EntradaStockRequest) (int, *models.Movimiento, error) {
+				n := len(op.movimientos)
+				cantidadNueva, err := s.aplicarEntrada(ctx, op, entradaReq, expansionPack{})
+				return cantidadNueva, op.movimientoDesde(n), err
+			})
+			if len(errores) > 0 {
+				return errOperacionRevertida
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errOperacionRevertida) {
+			return nil, err
+		}
+		if err != nil {
+			resultados = []models.ProductoResultado{}
+			marcarRevertidos(items)
+		} else {
+			s.invalidarAfectados(op)
 		}
+	} else {
+		procesarProductos(func(entradaReq *models.EntradaStockRequest) (int, *models.Movimiento, error) {
+			return s.aplicarItemIndividual(ctx, idOperacion, req.IDLocal, func(op *operacionStock) (int, error) {
+				return s.aplicarEntrada(ctx, op, entradaReq, expansionPack{})
+			})
+		})
 	}
 
 	// Determinar si fue exitoso
@@ -398,6 +1213,15 @@ func (s *stockService) EntradaMultipleStock(ctx context.Context, req *models.Ent
 	message := "✅ Entrada múltiple de stock registrada correctamente"
 	if len(errores) > 0 {
 		message = "Algunos productos no pudieron ser procesados"
+		if req.Atomico {
+			message = "❌ Entrada múltiple revertida: ningún producto fue aplicado"
+		}
+	}
+	if req.DryRun {
+		message = "🧪 Simulación: la entrada múltiple puede aplicarse sin errores"
+		if len(errores) > 0 {
+			message = "🧪 Simulación: algunos productos no podrían ser procesados"
+		}
 	}
 
 	logger.Info("✅ [DEBUG] Entrada múltiple completada en service",
@@ -412,12 +1236,29 @@ func (s *stockService) EntradaMultipleStock(ctx context.Context, req *models.Ent
 		TotalProductos: len(resultados),
 		Resultados:     resultados,
 		Errores:        errores,
+		DryRun:         req.DryRun,
+		Atomico:        req.Atomico,
+		IDOperacion:    idOperacion,
 		Timestamp:      time.Now().Format(time.RFC3339),
+		Items:          items,
 	}, nil
 }
 
 // SalidaMultipleStock procesa salida múltiple de stock
 func (s *stockService) SalidaMultipleStock(ctx context.Context, req *models.SalidaMultipleStockRequest) (*models.SalidaMultipleStockResponse, error) {
+	return s.salidaMultipleStock(ctx, req, nil)
+}
+
+// SalidaMultipleStockAtomica aplica la salida múltiple en modo atómico y ejecuta alConfirmar en la
+// misma transacción cuando todos los productos se aplicaron; si alConfirmar falla no se aplica ninguno
+func (s *stockService) SalidaMultipleStockAtomica(ctx context.Context, req *models.SalidaMultipleStockRequest, alConfirmar func(repo repository.StockRepository, idOperacion string) error) (*models.SalidaMultipleStockResponse, error) {
+	req.Atomico = true
+	req.DryRun = false
+	return s.salidaMultipleStock(ctx, req, alConfirmar)
+}
+
+// salidaMultipleStock procesa la salida múltiple; alConfirmar solo se usa en modo atómico
+func (s *stockService) salidaMultipleStock(ctx context.Context, req *models.SalidaMultipleStockRequest, alConfirmar func(repo repository.StockRepository, idOperacion string) error) (*models.SalidaMultipleStockResponse, error) {
 	logger := s.logger.With(
 		zap.String("operation", "salida_multiple_stock"),
 		zap.Int("cantidad_productos", len(req.Productos)),
@@ -429,51 +1270,129 @@ func (s *stockService) SalidaMultipleStock(ctx context.Context, req *models.Sali
 
 	resultados := []models.ProductoResultado{}
 	errores := []models.ProductoError{}
-
-	// Procesar cada producto
-	for i, producto := range req.Productos {
-		logger.Info("🔍 [DEBUG] Procesando producto en salida múltiple",
-			zap.Int("index", i),
-			zap.String("codigo_producto", producto.CodigoProducto),
-			zap.String("tipo_item", producto.TipoItem),
-			zap.Int("cantidad", producto.Cantidad))
-
-		salidaReq := &models.SalidaStockRequest{
-			CodigoProducto: producto.CodigoProducto,
-			TipoItem:       producto.TipoItem,
-			Cantidad:       producto.Cantidad,
-			Motivo:         req.Motivo,
-			IDUsuario:      req.IDUsuario,
-			IDLocal:        req.IDLocal,
-			Observaciones:  req.Observaciones,
+	items := nuevosResultadosItems(len(req.Productos))
+
+	// Cada producto se aplica en su propia transacción (o todos en una si es atómica), pero todos
+	// sus movimientos comparten el id de la operación (las simulaciones no registran movimientos)
+	idOperacion := ""
+	if !req.DryRun {
+		idOperacion = req.IDOperacion
+		if idOperacion == "" {
+			idOperacion = uuid.New()
 		}
+	}
 
-		logger.Info("🔍 [DEBUG] Llamando a SalidaStock individual",
-			zap.String("codigo_producto", salidaReq.CodigoProducto),
-			zap.Int("cantidad", salidaReq.Cantidad),
-			zap.Int("id_local", salidaReq.IDLocal))
+	// procesarProductos aplica cada producto con la función dada, acumulando resultados y errores
+	procesarProductos := func(aplicar func(salidaReq *models.SalidaStockRequest) (int, *models.Movimiento, error)) {
+		for i, producto := range req.Productos {
+			items[i].CodigoProducto = producto.CodigoProducto
+			items[i].TipoItem = producto.TipoItem
+			items[i].Cantidad = producto.Cantidad
 
-		response, err := s.SalidaStock(ctx, salidaReq)
-		if err != nil {
-			logger.Error("❌ [DEBUG] Error procesando producto en salida múltiple",
+			logger.Info("🔍 [DEBUG] Procesando producto en salida múltiple",
+				zap.Int("index", i),
 				zap.String("codigo_producto", producto.CodigoProducto),
-				zap.Error(err))
-			errores = append(errores, models.ProductoError{
-				CodigoProducto: producto.CodigoProducto,
-				Error:          err.Error(),
-			})
-		} else {
-			logger.Info("✅ [DEBUG] Producto procesado exitosamente en salida múltiple",
-				zap.String("codigo_producto", producto.CodigoProducto),
-				zap.Int("cantidad_nueva", response.Data.CantidadNueva))
-			resultados = append(resultados, models.ProductoResultado{
+				zap.String("tipo_item", producto.TipoItem),
+				zap.Int("cantidad", producto.Cantidad))
+
+			salidaReq := &models.SalidaStockRequest{
 				CodigoProducto: producto.CodigoProducto,
 				TipoItem:       producto.TipoItem,
 				Cantidad:       producto.Cantidad,
-				CantidadNueva:  response.Data.CantidadNueva,
-				Success:        true,
+				Motivo:         req.Motivo,
+				IDUsuario:      req.IDUsuario,
+				IDLocal:        req.IDLocal,
+				Observaciones:  req.Observaciones,
+				Unidad:         producto.Unidad,
+				IDBodega:       req.IDBodega,
+				IDOperacion:    idOperacion,
+			}
+
+			logger.Info("🔍 [DEBUG] Llamando a SalidaStock individual",
+				zap.String("codigo_producto", salidaReq.CodigoProducto),
+				zap.Int("cantidad", salidaReq.Cantidad),
+				zap.Int("id_local", salidaReq.IDLocal))
+
+			cantidadNueva, movimiento, err := aplicar(salidaReq)
+			if err != nil {
+				logger.Error("❌ [DEBUG] Error procesando producto en salida múltiple",
+					zap.String("codigo_producto", producto.CodigoProducto),
+					zap.Error(err))
+				errores = append(errores, models.ProductoError{
+					CodigoProducto: producto.CodigoProducto,
+					Error:          err.Error(),
+				})
+				marcarFallido(&items[i], err)
+				// En modo atómico el primer error revierte la operación: no tiene sentido seguir
+				if req.Atomico {
+					return
+				}
+			} else {
+				logger.Info("✅ [DEBUG] Producto procesado exitosamente en salida múltiple",
+					zap.String("codigo_producto", producto.CodigoProducto),
+					zap.Int("cantidad_nueva", cantidadNueva))
+				resultados = append(resultados, models.ProductoResultado{
+					CodigoProducto: producto.CodigoProducto,
+					TipoItem:       producto.TipoItem,
+					Cantidad:       producto.Cantidad,
+					CantidadNueva:  cantidadNueva,
+					Success:        true,
+				})
+				marcarAplicado(&items[i], cantidadNueva, movimiento, req.DryRun)
+			}
+		}
+	}
+
+	if req.DryRun {
+		// Simulación: se aplica todo en una transacción que se revierte al final
+		err := s.simular(ctx, req.IDLocal, func(op *operacionStock) error {
+			procesarProductos(func(salidaReq *models.SalidaStockRequest) (int, *models.Movimiento, error) {
+				n := len(op.movimientos)
+				cantidadNueva, err := s.aplicarSalida(ctx, op, salidaReq, expansionPack{})
+				return cantidadNueva, op.movimientoDesde(n), err
 			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if req.Atomico {
+		// Todo o nada: todos los productos en una transacción; si uno falla se revierten todos
+		op := s.nuevaOperacionSerializada(idOperacion)
+		defer op.liberar()
+		err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+			op.repo = repo
+			if err := repo.BloquearCadenas(ctx, req.IDLocal); err != nil {
+				return err
+			}
+			procesarProductos(func(salidaReq *models.SalidaStockRequest) (int, *models.Movimiento, error) {
+				n := len(op.movimientos)
+				cantidadNueva, err := s.aplicarSalida(ctx, op, salidaReq, expansionPack{})
+				return cantidadNueva, op.movimientoDesde(n), err
+			})
+			if len(errores) > 0 {
+				return errOperacionRevertida
+			}
+			if alConfirmar != nil {
+				return alConfirmar(repo, idOperacion)
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errOperacionRevertida) {
+			return nil, err
+		}
+		if err != nil {
+			resultados = []models.ProductoResultado{}
+			marcarRevertidos(items)
+		} else {
+			s.invalidarAfectados(op)
 		}
+	} else {
+		procesarProductos(func(salidaReq *models.SalidaStockRequest) (int, *models.Movimiento, error) {
+			return s.aplicarItemIndividual(ctx, idOperacion, req.IDLocal, func(op *operacionStock) (int, error) {
+				return s.aplicarSalida(ctx, op, salidaReq, expansionPack{})
+			})
+		})
 	}
 
 	// Determinar si fue exitoso
@@ -481,6 +1400,15 @@ func (s *stockService) SalidaMultipleStock(ctx context.Context, req *models.Sali
 	message := "✅ Salida múltiple de stock registrada correctamente"
 	if len(errores) > 0 {
 		message = "Algunos productos no pudieron ser procesados"
+		if req.Atomico {
+			message = "❌ Salida múltiple revertida: ningún producto fue aplicado"
+		}
+	}
+	if req.DryRun {
+		message = "🧪 Simulación: la salida múltiple puede aplicarse sin errores"
+		if len(errores) > 0 {
+			message = "🧪 Simulación: algunos productos no podrían ser procesados"
+		}
 	}
 
 	logger.Info("✅ [DEBUG] Salida múltiple completada en service",
@@ -495,65 +1423,171 @@ func (s *stockService) SalidaMultipleStock(ctx context.Context, req *models.Sali
 		TotalProductos: len(resultados),
 		Resultados:     resultados,
 		Errores:        errores,
+		DryRun:         req.DryRun,
+		Atomico:        req.Atomico,
+		IDOperacion:    idOperacion,
 		Timestamp:      time.Now().Format(time.RFC3339),
+		Items:          items,
 	}, nil
 }
 
+// aplicarItemIndividual aplica un ítem de una operación múltiple no atómica en su propia transacción
+// Retorna la cantidad resultante y el movimiento registrado
+func (s *stockService) aplicarItemIndividual(ctx context.Context, idOperacion string, idLocal int, aplicar func(op *operacionStock) (int, error)) (int, *models.Movimiento, error) {
+	op := s.nuevaOperacionSerializada(idOperacion)
+	defer op.liberar()
+	var cantidadNueva int
+
+	err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+		op.repo = repo
+		if err := repo.BloquearCadenas(ctx, idLocal); err != nil {
+			return err
+		}
+		var err error
+		cantidadNueva, err = aplicar(op)
+		return err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Invalidar cache de todos los ítems afectados (solo tras el commit)
+	s.invalidarAfectados(op)
+
+	return cantidadNueva, op.movimientoDesde(0), nil
+}
+
 // Métodos auxiliares
 
-func (s *stockService) verificarProductoExiste(ctx context.Context, codigoProducto, tipoItem string) error {
+func (s *stockService) verificarProductoExiste(ctx context.Context, repo repository.StockRepository, codigoProducto, tipoItem, tipoMovimiento string) error {
 	if tipoItem == "producto" {
-		producto, err := s.repo.GetProductoByCodigo(ctx, codigoProducto)
+		producto, err := repo.GetProductoByCodigo(ctx, codigoProducto)
 		if err != nil {
 			return err
 		}
 		if producto == nil {
-			return fmt.Errorf("producto %s no encontrado", codigoProducto)
+			return fmt.Errorf("%w: %s", ErrProductoNoEncontrado, codigoProducto)
+		}
+		// Un producto desactivado solo admite salidas (liquidación del remanente)
+		if !producto.Activo && tipoMovimiento == "entrada" {
+			return fmt.Errorf("%w: %s no admite nuevas entradas", ErrProductoDescontinuado, codigoProducto)
 		}
 	} else if tipoItem == "pack" {
-		pack, err := s.repo.GetPackByCodigo(ctx, codigoProducto)
+		pack, err := repo.GetPackByCodigo(ctx, codigoProducto)
 		if err != nil {
 			return err
 		}
 		if pack == nil {
-			return fmt.Errorf("pack %s no encontrado", codigoProducto)
+			return fmt.Errorf("%w: pack %s", ErrProductoNoEncontrado, codigoProducto)
 		}
 	}
 	return nil
 }
 
-func (s *stockService) procesarPack(ctx context.Context, codigoPack string, cantidad int, operacion string, idUsuario, idLocal int) error {
-	// Obtener productos del pack
-	productosPack, err := s.repo.GetPacksByProducto(ctx, codigoPack)
+// esServicio indica si el ítem es un producto marcado es_servicio (sin control de stock)
+func esServicio(ctx context.Context, repo repository.StockRepository, codigoProducto, tipoItem string) (bool, error) {
+	if tipoItem != "producto" {
+		return false, nil
+	}
+	producto, err := repo.GetProductoByCodigo(ctx, codigoProducto)
+	if err != nil {
+		return false, err
+	}
+	return producto != nil && producto.EsServicio, nil
+}
+
+// verificarLocal valida que el local exista y esté activo, usando cache en memoria
+func (s *stockService) verificarLocal(ctx context.Context, idLocal int) error {
+	s.localesMutex.RLock()
+	entry, ok := s.locales[idLocal]
+	s.localesMutex.RUnlock()
+
+	var local *models.Local
+	if ok && time.Now().Before(entry.expiresAt) {
+		local = entry.local
+	} else {
+		var err error
+		local, err = s.repo.GetLocalByID(ctx, idLocal)
+		if err != nil {
+			return fmt.Errorf("error verificando local: %w", err)
+		}
+		if local == nil {
+			return fmt.Errorf("%w: %d", ErrLocalNoEncontrado, idLocal)
+		}
+
+		s.localesMutex.Lock()
+		s.locales[idLocal] = &localCacheEntry{local: local, expiresAt: time.Now().Add(localesCacheTTL)}
+		s.localesMutex.Unlock()
+	}
+
+	if !local.Activo {
+		return fmt.Errorf("%w: %d", ErrLocalInactivo, idLocal)
+	}
+
+	return nil
+}
+
+// procesarPack expande un pack en sus componentes y les aplica la misma operación
+// Si un componente es a su vez un pack se expande recursivamente, detectando ciclos
+// (un pack que se contiene a sí mismo directa o indirectamente) y limitando la profundidad
+func (s *stockService) procesarPack(ctx context.Context, op *operacionStock, codigoPack string, cantidad int, operacion string, idUsuario, idLocal int, idBodega *int, exp expansionPack) error {
+	for _, codigo := range exp.ruta {
+		if codigo == codigoPack {
+			return fmt.Errorf("%w: %s", ErrCicloPack, strings.Join(append(exp.ruta, codigoPack), " → "))
+		}
+	}
+	if exp.profundidad >= maxProfundidadPack {
+		return fmt.Errorf("%w: %s (máximo %d niveles)", ErrProfundidadPack, strings.Join(append(exp.ruta, codigoPack), " → "), maxProfundidadPack)
+	}
+
+	sub := expansionPack{
+		profundidad: exp.profundidad + 1,
+		ruta:        append(append([]string{}, exp.ruta...), codigoPack),
+	}
+
+	// Obtener artículos que componen el pack
+	componentes, err := op.repo.GetComponentesPack(ctx, codigoPack)
 	if err != nil {
 		return err
 	}
 
-	for _, productoPack := range productosPack {
-		cantidadProducto := cantidad * productoPack.CantidadArticulo
+	for _, componente := range componentes {
+		cantidadProducto := cantidad * componente.CantidadArticulo
+
+		// Un componente que es a su vez un pack se procesa como pack
+		tipoItem := "producto"
+		packAnidado, err := op.repo.GetPackByCodigo(ctx, componente.CodigoArticulo)
+		if err != nil {
+			return err
+		}
+		if packAnidado != nil {
+			tipoItem = "pack"
+		}
 
 		if operacion == "entrada" {
 			req := &models.EntradaStockRequest{
-				CodigoProducto: productoPack.CodigoArticulo,
-				TipoItem:       "producto",
+				CodigoProducto: componente.CodigoArticulo,
+				TipoItem:       tipoItem,
 				Cantidad:       cantidadProducto,
 				Motivo:         fmt.Sprintf("Entrada automática desde pack %s", codigoPack),
 				IDUsuario:      idUsuario,
 				IDLocal:        idLocal,
 				Observaciones:  fmt.Sprintf("Pack: %s", codigoPack),
+				IDBodega:       idBodega,
 			}
-			_, err = s.EntradaStock(ctx, req)
+			_, err = s.aplicarEntrada(ctx, op, req, sub)
 		} else {
 			req := &models.SalidaStockRequest{
-				CodigoProducto: productoPack.CodigoArticulo,
-				TipoItem:       "producto",
+				CodigoProducto: componente.CodigoArticulo,
+				TipoItem:       tipoItem,
 				Cantidad:       cantidadProducto,
 				Motivo:         fmt.Sprintf("Salida automática desde pack %s", codigoPack),
 				IDUsuario:      idUsuario,
 				IDLocal:        idLocal,
 				Observaciones:  fmt.Sprintf("Pack: %s", codigoPack),
+				IDBodega:       idBodega,
 			}
-			_, err = s.SalidaStock(ctx, req)
+			_, err = s.aplicarSalida(ctx, op, req, sub)
 		}
 
 		if err != nil {
@@ -564,9 +1598,59 @@ func (s *stockService) procesarPack(ctx context.Context, codigoPack string, cant
 	return nil
 }
 
-func (s *stockService) invalidarCacheStock(codigoProducto string, idLocal int) {
-	cacheKey := fmt.Sprintf("stock:%s:%d", codigoProducto, idLocal)
-	s.cache.Del(context.Background(), cacheKey)
+// verificarDocumento valida que el documento de respaldo no esté ya registrado en otra entrada
+// Sin documento no hay nada que verificar
+func (s *stockService) verificarDocumento(ctx context.Context, repo repository.StockRepository, documento *models.DocumentoRespaldo) error {
+	if documento == nil {
+		return nil
+	}
+
+	existe, err := repo.ExisteDocumento(ctx, documento.Tipo, documento.Numero)
+	if err != nil {
+		return err
+	}
+	if existe {
+		return fmt.Errorf("%w: %s %s", ErrDocumentoDuplicado, documento.Tipo, documento.Numero)
+	}
+
+	return nil
+}
+
+// asignarDocumento copia el documento de respaldo al movimiento
+func asignarDocumento(movimiento *models.Movimiento, documento *models.DocumentoRespaldo) error {
+	if documento == nil {
+		return nil
+	}
+
+	fecha, err := time.Parse("2006-01-02", documento.Fecha)
+	if err != nil {
+		return fmt.Errorf("%w: fecha %q (use YYYY-MM-DD)", ErrDocumentoInvalido, documento.Fecha)
+	}
+
+	movimiento.DocumentoTipo = &documento.Tipo
+	movimiento.DocumentoNumero = &documento.Numero
+	movimiento.DocumentoFecha = &fecha
+	return nil
+}
+
+// asignarUnidad registra en el movimiento la unidad y cantidad informadas, si no era la unidad base
+func asignarUnidad(movimiento *models.Movimiento, unidad string, cantidad int) {
+	unidad = normalizarUnidad(unidad)
+	if unidad == "" {
+		return
+	}
+	movimiento.Unidad = &unidad
+	movimiento.CantidadUnidad = &cantidad
+}
+
+// invalidarAfectados invalida la cache de todos los ítems modificados por una operación
+// Si Redis falla las claves quedan en la cola de invalidaciones para reintentarse
+func (s *stockService) invalidarAfectados(op *operacionStock) {
+	keys := make([]string, 0, len(op.afectados))
+	for _, afectado := range op.afectados {
+		keys = append(keys, fmt.Sprintf("stock:%s:%d", afectado.codigoProducto, afectado.idLocal))
+	}
+	s.invalidations.Invalidate(context.Background(), keys...)
 }
 
 // GetProductoByBarcode busca un producto por código de barras (POS)
@@ -580,19 +1664,56 @@ func (s *stockService) GetProductoByBarcode(ctx context.Context, barcode string)
 
 	// Buscar en el repository
 	producto, err := s.productRepo.GetProductoByBarcode(ctx, barcode)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && producto == nil) {
+		logger.Warn("Producto no encontrado")
+		return nil, fmt.Errorf("%w: %s", ErrProductoNoEncontrado, barcode)
+	}
 	if err != nil {
 		logger.Error("Error buscando producto", zap.Error(err))
 		return nil, fmt.Errorf("error buscando producto: %w", err)
 	}
 
-	if producto == nil {
-		logger.Warn("Producto no encontrado")
-		return nil, fmt.Errorf("producto no encontrado: %s", barcode)
-	}
-
 	logger.Info("Producto encontrado",
 		zap.String("nombre", producto.Nombre),
 		zap.String("origen", producto.Origen))
 
 	return producto, nil
 }
+
+// DecodificarGS1 lee GTIN (AI 01), lote (AI 10) y vencimiento (AI 17) de la etiqueta y busca
+// el producto por el GTIN en sus formas EAN-13/UPC-A/EAN-8; sin producto se precarga igual el resto
+func (s *stockService) DecodificarGS1(ctx context.Context, codigo string) (*models.PrecargaRecepcionGS1, error) {
+	datos, err := gs1.Parse(codigo)
+	if err != nil {
+		return nil, err
+	}
+
+	precarga := &models.PrecargaRecepcionGS1{
+		GTIN:             datos.GTIN,
+		Lote:             datos.Lote,
+		FechaVencimiento: datos.Vencimiento,
+		AIs:              datos.AIs,
+	}
+	if datos.GTIN == "" {
+		return precarga, nil
+	}
+
+	for _, barcode := range gs1.CodigosBarra(datos.GTIN) {
+		producto, err := s.productRepo.GetProductoByBarcode(ctx, barcode)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && producto == nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error buscando producto: %w", err)
+		}
+		precarga.Producto = producto
+		break
+	}
+
+	if precarga.Producto == nil {
+		s.logger.Warn("GTIN de etiqueta GS1 sin producto registrado",
+			zap.String("operation", "decodificar_gs1"),
+			zap.String("gtin", datos.GTIN))
+	}
+	return precarga, nil
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// TransferirStock traspasa productos de un local a otro en una sola transacción
+// Por ítem se registra una transferencia_salida en el origen y una transferencia_entrada en el destino,
+// todos con el mismo id_operacion; si un ítem falla no se aplica ninguno
+func (s *stockService) TransferirStock(ctx context.Context, req *models.TransferenciaStockRequest) (*models.TransferenciaStock, error) {
+	logger := s.logger.With(
+		zap.String("operation", "transferencia_stock"),
+		zap.Int("id_local_origen", req.IDLocalOrigen),
+		zap.Int("id_local_destino", req.IDLocalDestino),
+		zap.Int("productos", len(req.Productos)),
+	)
+
+	if req.IDLocalOrigen == req.IDLocalDestino {
+		return nil, fmt.Errorf("%w: el local de origen y destino son el mismo", ErrTransferenciaInvalida)
+	}
+	if err := s.verificarLocal(ctx, req.IDLocalOrigen); err != nil {
+		return nil, err
+	}
+	if err := s.verificarLocal(ctx, req.IDLocalDestino); err != nil {
+		return nil, err
+	}
+
+	op := s.nuevaOperacionSerializada("")
+	defer op.liberar()
+
+	transferencia := &models.TransferenciaStock{
+		IDLocalOrigen:  req.IDLocalOrigen,
+		IDLocalDestino: req.IDLocalDestino,
+		Estado:         models.TransferenciaEstadoAplicada,
+		Motivo:         req.Motivo,
+		Observaciones:  req.Observaciones,
+		IDUsuario:      req.IDUsuario,
+		IDOperacion:    op.idOperacion,
+	}
+
+	err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+		op.repo = repo
+		if err := repo.BloquearCadenas(ctx, req.IDLocalOrigen, req.IDLocalDestino); err != nil {
+			return err
+		}
+
+		// Los ítems quedan en unidad base: la anulación revierte exactamente lo transferido
+		transferencia.Items = make([]*models.TransferenciaStockItem, 0, len(req.Productos))
+		for _, producto := range req.Productos {
+			servicio, err := esServicio(ctx, repo, producto.CodigoProducto, producto.TipoItem)
+			if err != nil {
+				return fmt.Errorf("error verificando producto: %w", err)
+			}
+			if servicio {
+				return fmt.Errorf("%w: %s es un servicio y no lleva stock", ErrTransferenciaInvalida, producto.CodigoProducto)
+			}
+			cantidad, err := cantidadEnUnidadBase(ctx, repo, producto.CodigoProducto, producto.Unidad, producto.Cantidad)
+			if err != nil {
+				return err
+			}
+			transferencia.Items = append(transferencia.Items, &models.TransferenciaStockItem{
+				CodigoProducto: producto.CodigoProducto,
+				TipoItem:       producto.TipoItem,
+				Cantidad:       cantidad,
+			})
+		}
+
+		if err := repo.CreateTransferencia(ctx, transferencia); err != nil {
+			return err
+		}
+
+		observaciones := fmt.Sprintf("Transferencia #%d: local %d → local %d", transferencia.ID, req.IDLocalOrigen, req.IDLocalDestino)
+		if obs := strings.TrimSpace(req.Observaciones); obs != "" {
+			observaciones += " - " + obs
+		}
+		for i, producto := range req.Productos {
+			if err := s.aplicarTraspaso(ctx, op, transferencia.Items[i].CodigoProducto, transferencia.Items[i].TipoItem,
+				producto.Cantidad, producto.Unidad, req.IDLocalOrigen, req.IDLocalDestino, req.Motivo, observaciones, req.IDUsuario); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Error en transferencia de stock", zap.Error(err))
+		return nil, err
+	}
+
+	s.invalidarAfectados(op)
+
+	logger.Info("Transferencia de stock aplicada",
+		zap.Int64("id_transferencia", transferencia.ID),
+		zap.String("id_operacion", transferencia.IDOperacion))
+	return transferencia, nil
+}
+
+// GetTransferencia obtiene una transferencia con sus ítems
+func (s *stockService) GetTransferencia(ctx context.Context, id int64) (*models.TransferenciaStock, error) {
+	transferencia, err := s.repo.GetTransferencia(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transferencia == nil {
+		return nil, fmt.Errorf("%w: %d", ErrTransferenciaNoEncontrada, id)
+	}
+	return transferencia, nil
+}
+
+// GetTransferencias lista las transferencias, opcionalmente de un local (como origen o destino) y estado
+func (s *stockService) GetTransferencias(ctx context.Context, filter *models.TransferenciaFilter) ([]*models.TransferenciaStock, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	return s.repo.GetTransferencias(ctx, filter)
+}
+
+// AnularTransferencia revierte una transferencia aplicada: devuelve al origen lo que llegó al destino
+// Falla con stock insuficiente si el destino ya consumió lo recibido
+func (s *stockService) AnularTransferencia(ctx context.Context, id int64, req *models.AnularTransferenciaRequest) (*models.TransferenciaStock, error) {
+	logger := s.logger.With(
+		zap.String("operation", "anular_transferencia"),
+		zap.Int64("id_transferencia", id),
+	)
+
+	op := s.nuevaOperacionSerializada("")
+	defer op.liberar()
+	var transferencia *models.TransferenciaStock
+
+	err := s.repo.RunInTransaction(ctx, func(repo repository.StockRepository) error {
+		op.repo = repo
+
+		// Dentro de la transacción la transferencia queda bloqueada: dos anulaciones no se intercalan
+		var err error
+		transferencia, err = repo.GetTransferencia(ctx, id)
+		if err != nil {
+			return err
+		}
+		if transferencia == nil {
+			return fmt.Errorf("%w: %d", ErrTransferenciaNoEncontrada, id)
+		}
+		if transferencia.Estado == models.TransferenciaEstadoAnulada {
+			return fmt.Errorf("%w: %d", ErrTransferenciaAnulada, id)
+		}
+		if err := repo.BloquearCadenas(ctx, transferencia.IDLocalOrigen, transferencia.IDLocalDestino); err != nil {
+			return err
+		}
+
+		observaciones := fmt.Sprintf("Anulación transferencia #%d: %s", transferencia.ID, req.Motivo)
+		for _, item := range transferencia.Items {
+			if err := s.aplicarTraspaso(ctx, op, item.CodigoProducto, item.TipoItem, item.Cantidad, "",
+				transferencia.IDLocalDestino, transferencia.IDLocalOrigen, transferencia.Motivo, observaciones, req.IDUsuario); err != nil {
+				return err
+			}
+		}
+
+		idOperacion := op.idOperacion
+		motivo := req.Motivo
+		idUsuario := req.IDUsuario
+		transferencia.IDOperacionAnulacion = &idOperacion
+		transferencia.MotivoAnulacion = &motivo
+		transferencia.IDUsuarioAnulacion = &idUsuario
+		if err := repo.AnularTransferencia(ctx, transferencia); err != nil {
+			if err == repository.ErrNotFound {
+				return fmt.Errorf("%w: %d", ErrTransferenciaAnulada, id)
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Error anulando transferencia", zap.Error(err))
+		return nil, err
+	}
+
+	s.invalidarAfectados(op)
+
+	logger.Info("Transferencia anulada", zap.String("id_operacion_anulacion", op.idOperacion))
+	return transferencia, nil
+}
+
+// aplicarTraspaso descuenta el ítem en un local y lo suma en el otro dentro de la operación
+func (s *stockService) aplicarTraspaso(ctx context.Context, op *operacionStock, codigoProducto, tipoItem string, cantidad int, unidad string,
+	idLocalDesde, idLocalHacia int, motivo, observaciones string, idUsuario int) error {
+	salida := &models.SalidaStockRequest{
+		CodigoProducto: codigoProducto,
+		TipoItem:       tipoItem,
+		Cantidad:       cantidad,
+		Unidad:         unidad,
+		Motivo:         motivo,
+		IDLocal:        idLocalDesde,
+		Observaciones:  observaciones,
+		IDUsuario:      idUsuario,
+		TipoMovimiento: models.TipoMovimientoTransferenciaSalida,
+	}
+	if _, err := s.aplicarSalida(ctx, op, salida, expansionPack{}); err != nil {
+		return fmt.Errorf("producto %s en local %d: %w", codigoProducto, idLocalDesde, err)
+	}
+
+	entrada := &models.EntradaStockRequest{
+		CodigoProducto: codigoProducto,
+		TipoItem:       tipoItem,
+		Cantidad:       cantidad,
+		Unidad:         unidad,
+		Motivo:         motivo,
+		IDLocal:        idLocalHacia,
+		Observaciones:  observaciones,
+		IDUsuario:      idUsuario,
+		TipoMovimiento: models.TipoMovimientoTransferenciaEntrada,
+	}
+	if _, err := s.aplicarEntrada(ctx, op, entrada, expansionPack{}); err != nil {
+		return fmt.Errorf("producto %s en local %d: %w", codigoProducto, idLocalHacia, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// deltaOverlap margen hacia atrás con que se consulta desde el cursor: una transacción que
+// confirma después de la consulta puede traer un updated_at anterior al cursor entregado
+// Las filas repetidas no afectan a la réplica (se reemplazan por código)
+const deltaOverlap = 2 * time.Minute
+
+// SyncService entrega a las réplicas edge los cambios del catálogo y del stock de su local
+type SyncService interface {
+	// Delta retorna lo modificado después de desde (todo si desde es nil)
+	Delta(ctx context.Context, idLocal int, desde *time.Time) (*models.DeltaSync, error)
+}
+
+// syncService implementa SyncService
+type syncService struct {
+	productRepo repository.ProductRepository
+	stockRepo   repository.StockRepository
+	logger      *zap.Logger
+}
+
+// NewSyncService crea una nueva instancia del servicio
+func NewSyncService(productRepo repository.ProductRepository, stockRepo repository.StockRepository, logger *zap.Logger) SyncService {
+	return &syncService{
+		productRepo: productRepo,
+		stockRepo:   stockRepo,
+		logger:      logger,
+	}
+}
+
+// Delta arma los cambios del local; el cursor es la marca más reciente entregada
+func (s *syncService) Delta(ctx context.Context, idLocal int, desde *time.Time) (*models.DeltaSync, error) {
+	local, err := s.stockRepo.GetLocalByID(ctx, idLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error verificando local: %w", err)
+	}
+	if local == nil {
+		return nil, fmt.Errorf("%w: %d", ErrLocalNoEncontrado, idLocal)
+	}
+
+	var consultaDesde *time.Time
+	if desde != nil {
+		t := desde.Add(-deltaOverlap)
+		consultaDesde = &t
+	}
+
+	productos, err := s.productRepo.GetCatalogoModificadoDesde(ctx, consultaDesde)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo catálogo modificado: %w", err)
+	}
+	stock, err := s.stockRepo.GetStockModificadoDesde(ctx, idLocal, consultaDesde)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo stock modificado: %w", err)
+	}
+
+	delta := &models.DeltaSync{
+		IDLocal:   idLocal,
+		Desde:     desde,
+		Completa:  desde == nil,
+		Productos: productos,
+		Stock:     stock,
+	}
+	if desde != nil {
+		delta.Cursor = *desde
+	}
+	for _, producto := range productos {
+		if producto.ListaUpdatedAt != nil && producto.ListaUpdatedAt.After(delta.Cursor) {
+			delta.Cursor = *producto.ListaUpdatedAt
+		}
+	}
+	for _, item := range stock {
+		if item.UpdatedAt.After(delta.Cursor) {
+			delta.Cursor = item.UpdatedAt
+		}
+	}
+
+	s.logger.Debug("Delta de sincronización generado",
+		zap.String("operation", "sync_delta"),
+		zap.Int("id_local", idLocal),
+		zap.Bool("completa", delta.Completa),
+		zap.Int("productos", len(productos)),
+		zap.Int("stock", len(stock)))
+
+	return delta, nil
+}
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+	"stock-service/internal/uuid"
+
+	"go.uber.org/zap"
+)
+
+// TomaInventarioService gestiona las tomas de inventario (conteo físico de un local completo)
+// A diferencia de los conteos cíclicos, el cierre ajusta el stock del local a lo contado
+type TomaInventarioService interface {
+	// AbrirToma inicia una toma en el local; solo puede haber una abierta por local
+	AbrirToma(ctx context.Context, req *models.TomaInventarioRequest) (*models.TomaInventario, error)
+	GetTomas(ctx context.Context, filter *models.TomaInventarioFilter) ([]*models.TomaInventario, error)
+	GetToma(ctx context.Context, id int64) (*models.TomaInventario, error)
+	// RegistrarConteo carga la lectura de un código (ErrProductoNoEncontrado si no se reconoce)
+	RegistrarConteo(ctx context.Context, id int64, item *models.ConteoTomaItem, idUsuario int) (*models.TomaInventarioItem, error)
+	// RegistrarConteoMasivo carga varias lecturas y reporta los códigos que no se reconocen
+	RegistrarConteoMasivo(ctx context.Context, id int64, req *models.ConteoTomaMasivoRequest) (*models.ResultadoConteoToma, error)
+	// GetDiferencias compara lo contado con el stock actual del local
+	GetDiferencias(ctx context.Context, id int64, soloDiferencias bool) (*models.ReporteDiferenciasToma, error)
+	// CerrarToma ajusta el stock a lo contado en una sola transacción y cierra la toma; un cierre
+	// abandonado en 'cerrando' (réplica caída) se retoma pasado cierreTomaAbandonado
+	CerrarToma(ctx context.Context, id int64, req *models.CerrarTomaRequest) (*models.CierreTomaInventario, error)
+	CancelarToma(ctx context.Context, id int64) (*models.TomaInventario, error)
+}
+
+// tomaInventarioService implementa TomaInventarioService
+type tomaInventarioService struct {
+	repo         repository.TomaInventarioRepository
+	stockRepo    repository.StockRepository
+	stockService StockService
+	logger       *zap.Logger
+}
+
+// cierreTomaAbandonado plazo tras el cual un cierre sin confirmar se puede retomar; muy por sobre lo
+// que dura un cierre (TIMEOUT_REPORT_MS)
+const cierreTomaAbandonado = 15 * time.Minute
+
+// NewTomaInventarioService crea una nueva instancia del servicio
+func NewTomaInventarioService(repo repository.TomaInventarioRepository, stockRepo repository.StockRepository, stockService StockService, logger *zap.Logger) TomaInventarioService {
+	return &tomaInventarioService{
+		repo:         repo,
+		stockRepo:    stockRepo,
+		stockService: stockService,
+		logger:       logger,
+	}
+}
+
+// AbrirToma crea la toma de inventario del local
+func (s *tomaInventarioService) AbrirToma(ctx context.Context, req *models.TomaInventarioRequest) (*models.TomaInventario, error) {
+	local, err := s.stockRepo.GetLocalByID(ctx, req.IDLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error verificando local: %w", err)
+	}
+	if local == nil {
+		return nil, fmt.Errorf("%w: %d", ErrLocalNoEncontrado, req.IDLocal)
+	}
+	if !local.Activo {
+		return nil, fmt.Errorf("%w: %d", ErrLocalInactivo, req.IDLocal)
+	}
+
+	toma := &models.TomaInventario{
+		IDLocal:     req.IDLocal,
+		Descripcion: strings.TrimSpace(req.Descripcion),
+		IDUsuario:   req.IDUsuario,
+	}
+	creada, err := s.repo.CreateToma(ctx, toma)
+	if err != nil {
+		return nil, err
+	}
+	if !creada {
+		abierta, err := s.repo.GetTomaAbierta(ctx, req.IDLocal)
+		if err != nil {
+			return nil, err
+		}
+		if abierta != nil {
+			return nil, fmt.Errorf("%w: toma %d del local %d", ErrTomaInventarioAbierta, abierta.ID, req.IDLocal)
+		}
+		return nil, fmt.Errorf("%w: local %d", ErrTomaInventarioAbierta, req.IDLocal)
+	}
+
+	s.logger.Info("Toma de inventario abierta",
+		zap.Int64("id_toma", toma.ID),
+		zap.Int("id_local", toma.IDLocal),
+		zap.Int("id_usuario", toma.IDUsuario))
+
+	return toma, nil
+}
+
+// GetTomas lista las tomas de inventario
+func (s *tomaInventarioService) GetTomas(ctx context.Context, filter *models.TomaInventarioFilter) ([]*models.TomaInventario, error) {
+	return s.repo.GetTomas(ctx, filter)
+}
+
+// GetToma obtiene la toma con lo contado
+func (s *tomaInventarioService) GetToma(ctx context.Context, id int64) (*models.TomaInventario, error) {
+	toma, err := s.buscarToma(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	toma.Items, err = s.repo.GetItems(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toma, nil
+}
+
+// RegistrarConteo carga una lectura individual
+func (s *tomaInventarioService) RegistrarConteo(ctx context.Context, id int64, item *models.ConteoTomaItem, idUsuario int) (*models.TomaInventarioItem, error) {
+	resultado, err := s.RegistrarConteoMasivo(ctx, id, &models.ConteoTomaMasivoRequest{
+		Items:     []*models.ConteoTomaItem{item},
+		IDUsuario: idUsuario,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resultado.Registrados) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrProductoNoEncontrado, strings.TrimSpace(item.Codigo))
+	}
+
+	return resultado.Registrados[0], nil
+}
+
+// RegistrarConteoMasivo resuelve los códigos, agrupa las lecturas por producto y las carga en una transacción
+// Los códigos desconocidos no impiden cargar el resto
+func (s *tomaInventarioService) RegistrarConteoMasivo(ctx context.Context, id int64, req *models.ConteoTomaMasivoRequest) (*models.ResultadoConteoToma, error) {
+	toma, err := s.buscarToma(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if toma.Estado != models.TomaInventarioAbierta {
+		return nil, fmt.Errorf("%w: toma %d en estado %s", ErrTomaInventarioEstadoInvalido, id, toma.Estado)
+	}
+
+	codigos := make([]string, 0, len(req.Items))
+	vistos := make(map[string]bool, len(req.Items))
+	for _, item := range req.Items {
+		codigo := strings.TrimSpace(item.Codigo)
+		if !vistos[codigo] {
+			vistos[codigo] = true
+			codigos = append(codigos, codigo)
+		}
+	}
+	resueltos, err := s.repo.ResolverCodigos(ctx, codigos)
+	if err != nil {
+		return nil, err
+	}
+
+	resultado := &models.ResultadoConteoToma{
+		IDToma:              id,
+		Lecturas:            len(req.Items),
+		Registrados:         []*models.TomaInventarioItem{},
+		CodigosDesconocidos: []string{},
+	}
+
+	// Un reemplazo descarta lo leído antes del mismo producto en el lote
+	lecturas := []*models.LecturaTomaInventario{}
+	porProducto := make(map[string]*models.LecturaTomaInventario)
+	desconocidos := make(map[string]bool)
+	for _, item := range req.Items {
+		codigo := strings.TrimSpace(item.Codigo)
+		producto, ok := resueltos[codigo]
+		if !ok {
+			if !desconocidos[codigo] {
+				desconocidos[codigo] = true
+				resultado.CodigosDesconocidos = append(resultado.CodigosDesconocidos, codigo)
+			}
+			continue
+		}
+		resultado.Aplicadas++
+
+		cantidad := 1
+		if item.Cantidad != nil {
+			cantidad = *item.Cantidad
+		}
+
+		key := producto.TipoItem + ":" + producto.CodigoProducto
+		lectura, ok := porProducto[key]
+		if !ok {
+			lectura = &models.LecturaTomaInventario{
+				CodigoProducto: producto.CodigoProducto,
+				TipoItem:       producto.TipoItem,
+			}
+			porProducto[key] = lectura
+			lecturas = append(lecturas, lectura)
+		}
+		lectura.Lecturas++
+		if item.Modo == models.ConteoTomaReemplazar {
+			lectura.Cantidad = cantidad
+			lectura.Reemplazar = true
+		} else {
+			lectura.Cantidad += cantidad
+		}
+	}
+
+	if len(lecturas) == 0 {
+		return resultado, nil
+	}
+
+	registrados, abierta, err := s.repo.RegistrarLecturas(ctx, id, lecturas, req.IDUsuario)
+	if err != nil {
+		return nil, err
+	}
+	if !abierta {
+		return nil, fmt.Errorf("%w: la toma %d ya no está abierta", ErrTomaInventarioEstadoInvalido, id)
+	}
+	resultado.Registrados = registrados
+
+	if len(resultado.CodigosDesconocidos) > 0 {
+		s.logger.Info("Lecturas de toma de inventario con códigos desconocidos",
+			zap.Int64("id_toma", id),
+			zap.Int("desconocidos", len(resultado.CodigosDesconocidos)))
+	}
+
+	return resultado, nil
+}
+
+// GetDiferencias arma el reporte de diferencias de la toma
+// Para una toma ya cerrada se compara contra el stock actual, no contra el del cierre
+func (s *tomaInventarioService) GetDiferencias(ctx context.Context, id int64, soloDiferencias bool) (*models.ReporteDiferenciasToma, error) {
+	toma, err := s.buscarToma(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	diferencias, err := s.repo.GetDiferencias(ctx, id, toma.IDLocal)
+	if err != nil {
+		return nil, err
+	}
+
+	reporte := &models.ReporteDiferenciasToma{
+		Toma:  toma,
+		Items: []*models.DiferenciaTomaInventario{},
+	}
+	for _, d := range diferencias {
+		if d.CantidadContada == nil {
+			reporte.NoContados++
+		} else {
+			reporte.Contados++
+			if d.Diferencia != 0 {
+				reporte.ConDiferencia++
+			}
+			if d.Diferencia > 0 {
+				reporte.UnidadesSobrantes += d.Diferencia
+			} else {
+				reporte.UnidadesFaltantes -= d.Diferencia
+			}
+		}
+		if soloDiferencias && d.Diferencia == 0 {
+			continue
+		}
+		reporte.Items = append(reporte.Items, d)
+	}
+
+	return reporte, nil
+}
+
+// CerrarToma fija el stock de cada producto contado (y, si se pide, deja en 0 lo no contado)
+// Los ajustes y el cierre van en una transacción; si algo falla no se aplica nada y la toma sigue abierta
+func (s *tomaInventarioService) CerrarToma(ctx context.Context, id int64, req *models.CerrarTomaRequest) (*models.CierreTomaInventario, error) {
+	logger := s.logger.With(
+		zap.String("operation", "cerrar_toma_inventario"),
+		zap.Int64("id_toma", id),
+		zap.Int("id_usuario", req.IDUsuario),
+	)
+
+	toma, err := s.buscarToma(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tomar la toma: las cargas en curso terminan antes y las nuevas se rechazan
+	// Un cierre que quedó en 'cerrando' (réplica caída antes de la transacción) se retoma pasado
+	// cierreTomaAbandonado; hasta entonces se informa el estado
+	tomada, err := s.repo.TomarParaCierre(ctx, id, cierreTomaAbandonado)
+	if err != nil {
+		return nil, err
+	}
+	if !tomada {
+		return nil, s.errorEstado(ctx, id)
+	}
+
+	diferencias, err := s.repo.GetDiferencias(ctx, id, toma.IDLocal)
+	if err != nil {
+		s.reabrir(id, logger)
+		return nil, err
+	}
+
+	observaciones := fmt.Sprintf("Toma de inventario %d", id)
+	if extra := strings.TrimSpace(req.Observaciones); extra != "" {
+		observaciones += ": " + extra
+	}
+	idOperacion := uuid.New()
+
+	// Los contados van todos: la diferencia se recalcula contra el stock bloqueado en la transacción
+	ajustes := []*models.AjusteStockRequest{}
+	for _, d := range diferencias {
+		cantidad := 0
+		if d.CantidadContada != nil {
+			cantidad = *d.CantidadContada
+		} else if !req.AjustarNoContados {
+			continue
+		}
+		ajustes = append(ajustes, &models.AjusteStockRequest{
+			CodigoProducto: d.CodigoProducto,
+			TipoItem:       d.TipoItem,
+			IDLocal:        toma.IDLocal,
+			CantidadNueva:  &cantidad,
+			Motivo:         models.MotivoTomaInventario,
+			Observaciones:  observaciones,
+			IDUsuario:      req.IDUsuario,
+			IDOperacion:    idOperacion,
+		})
+	}
+
+	// La toma queda cerrada en la transacción de los ajustes: o se aplica todo o sigue abierta
+	movimientos, err := s.stockService.AjusteStockLote(ctx, ajustes, func(repo repository.StockRepository, movimientos []*models.Movimiento) error {
+		if len(movimientos) == 0 {
+			idOperacion = ""
+		}
+		if err := repo.ConfirmarCierreToma(ctx, id, idOperacion, len(movimientos), req.IDUsuario); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%w: toma %d ya no se está cerrando", ErrTomaInventarioEstadoInvalido, id)
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Error cerrando toma de inventario", zap.String("id_operacion", idOperacion), zap.Error(err))
+		s.reabrir(id, logger)
+		return nil, err
+	}
+
+	logger.Info("Toma de inventario cerrada",
+		zap.Int("id_local", toma.IDLocal),
+		zap.Int("productos", len(ajustes)),
+		zap.Int("ajustados", len(movimientos)),
+		zap.Bool("ajustar_no_contados", req.AjustarNoContados))
+
+	toma, err = s.buscarToma(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CierreTomaInventario{
+		Toma:        toma,
+		Movimientos: movimientos,
+	}, nil
+}
+
+// CancelarToma descarta una toma abierta sin tocar el stock
+func (s *tomaInventarioService) CancelarToma(ctx context.Context, id int64) (*models.TomaInventario, error) {
+	if _, err := s.buscarToma(ctx, id); err != nil {
+		return nil, err
+	}
+
+	cancelada, err := s.repo.CambiarEstado(ctx, id, models.TomaInventarioAbierta, models.TomaInventarioCancelada)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelada {
+		return nil, s.errorEstado(ctx, id)
+	}
+
+	s.logger.Info("Toma de inventario cancelada", zap.Int64("id_toma", id))
+
+	return s.buscarToma(ctx, id)
+}
+
+// buscarToma obtiene la toma sin ítems o ErrTomaInventarioNoEncontrada
+func (s *tomaInventarioService) buscarToma(ctx context.Context, id int64) (*models.TomaInventario, error) {
+	toma, err := s.repo.GetToma(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if toma == nil {
+		return nil, fmt.Errorf("%w: %d", ErrTomaInventarioNoEncontrada, id)
+	}
+	return toma, nil
+}
+
+// errorEstado arma el error de una toma que no está en el estado requerido
+func (s *tomaInventarioService) errorEstado(ctx context.Context, id int64) error {
+	toma, err := s.buscarToma(ctx, id)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: toma %d en estado %s", ErrTomaInventarioEstadoInvalido, id, toma.Estado)
+}
+
+// reabrir libera la toma tras un cierre fallido para poder corregir y reintentar
+// Usa un contexto propio por si el del request expiró
+func (s *tomaInventarioService) reabrir(id int64, logger *zap.Logger) {
+	if _, err := s.repo.CambiarEstado(context.Background(), id, models.TomaInventarioCerrando, models.TomaInventarioAbierta); err != nil {
+		logger.Error("Error reabriendo toma de inventario", zap.Error(err))
+	}
+}
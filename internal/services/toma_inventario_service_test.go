@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// fakeTomaRepo toma en memoria; los métodos que el cierre no usa quedan sin implementar
+type fakeTomaRepo struct {
+	repository.TomaInventarioRepository
+	toma *models.TomaInventario
+	// cierre en 'cerrando' iniciado hace más del plazo (réplica caída)
+	cierreAbandonado bool
+}
+
+func (f *fakeTomaRepo) GetToma(ctx context.Context, id int64) (*models.TomaInventario, error) {
+	if f.toma.ID != id {
+		return nil, nil
+	}
+	toma := *f.toma
+	return &toma, nil
+}
+
+func (f *fakeTomaRepo) GetDiferencias(ctx context.Context, id int64, idLocal int) ([]*models.DiferenciaTomaInventario, error) {
+	contada := 3
+	return []*models.DiferenciaTomaInventario{
+		{CodigoProducto: "P1", TipoItem: "producto", CantidadSistema: 5, CantidadContada: &contada, Diferencia: -2},
+	}, nil
+}
+
+func (f *fakeTomaRepo) CambiarEstado(ctx context.Context, id int64, desde, hasta string) (bool, error) {
+	if f.toma.ID != id || f.toma.Estado != desde {
+		return false, nil
+	}
+	f.toma.Estado = hasta
+	return true, nil
+}
+
+func (f *fakeTomaRepo) TomarParaCierre(ctx context.Context, id int64, abandonado time.Duration) (bool, error) {
+	if f.toma.ID != id {
+		return false, nil
+	}
+	switch {
+	case f.toma.Estado == models.TomaInventarioAbierta,
+		f.toma.Estado == models.TomaInventarioCerrando && f.cierreAbandonado:
+		f.toma.Estado = models.TomaInventarioCerrando
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// fakeCierreStockRepo confirma el cierre de la toma dentro de la transacción de los ajustes
+type fakeCierreStockRepo struct {
+	repository.StockRepository
+	toma *models.TomaInventario
+	err  error
+}
+
+func (f *fakeCierreStockRepo) ConfirmarCierreToma(ctx context.Context, id int64, idOperacion string, ajustados, idUsuario int) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.toma.Estado = models.TomaInventarioCerrada
+	f.toma.Ajustados = ajustados
+	return nil
+}
+
+// fakeAjusteStockService aplica el lote llamando a alConfirmar como lo haría la transacción
+type fakeAjusteStockService struct {
+	StockService
+	repo *fakeCierreStockRepo
+}
+
+func (f *fakeAjusteStockService) AjusteStockLote(ctx context.Context, reqs []*models.AjusteStockRequest, alConfirmar func(repo repository.StockRepository, movimientos []*models.Movimiento) error) ([]*models.Movimiento, error) {
+	movimientos := make([]*models.Movimiento, len(reqs))
+	for i := range reqs {
+		movimientos[i] = &models.Movimiento{}
+	}
+	if err := alConfirmar(f.repo, movimientos); err != nil {
+		return nil, err
+	}
+	return movimientos, nil
+}
+
+func TestCerrarToma(t *testing.T) {
+	errBD := errors.New("conexión perdida")
+
+	tests := []struct {
+		name             string
+		estado           string
+		cierreAbandonado bool
+		errCierre        error
+		wantErr          error
+		wantEstado       string
+		wantAjustes      int
+	}{
+		{"cierre confirmado", models.TomaInventarioAbierta, false, nil, nil, models.TomaInventarioCerrada, 1},
+		{"falla al confirmar el cierre", models.TomaInventarioAbierta, false, errBD, errBD, models.TomaInventarioAbierta, 0},
+		{"la toma ya no se está cerrando", models.TomaInventarioAbierta, false, repository.ErrNotFound, ErrTomaInventarioEstadoInvalido, models.TomaInventarioAbierta, 0},
+		{"cierre en curso de otra réplica", models.TomaInventarioCerrando, false, nil, ErrTomaInventarioEstadoInvalido, models.TomaInventarioCerrando, 0},
+		{"retoma un cierre abandonado", models.TomaInventarioCerrando, true, nil, nil, models.TomaInventarioCerrada, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toma := &models.TomaInventario{ID: 7, IDLocal: 1, Estado: tt.estado}
+			stockRepo := &fakeCierreStockRepo{toma: toma, err: tt.errCierre}
+			service := NewTomaInventarioService(
+				&fakeTomaRepo{toma: toma, cierreAbandonado: tt.cierreAbandonado},
+				stockRepo,
+				&fakeAjusteStockService{repo: stockRepo},
+				zap.NewNop(),
+			)
+
+			cierre, err := service.CerrarToma(context.Background(), toma.ID, &models.CerrarTomaRequest{IDUsuario: 1})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if cierre != nil {
+					t.Errorf("cierre = %+v, want nil", cierre)
+				}
+			} else if err != nil {
+				t.Fatalf("err = %v", err)
+			} else if len(cierre.Movimientos) != tt.wantAjustes {
+				t.Errorf("movimientos = %d, want %d", len(cierre.Movimientos), tt.wantAjustes)
+			}
+
+			if toma.Estado != tt.wantEstado {
+				t.Errorf("estado = %q, want %q", toma.Estado, tt.wantEstado)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// minutosSemana minutos de una semana: los turnos se comparan como franjas dentro de la semana
+const minutosSemana = 7 * 24 * 60
+
+// TurnoService administra los turnos de trabajo por usuario y local y las excepciones
+// que autoriza un supervisor; VerificarTurno lo usa el middleware de las operaciones
+type TurnoService interface {
+	CrearTurno(ctx context.Context, req *models.TurnoRequest) (*models.Turno, error)
+	ActualizarTurno(ctx context.Context, id int, req *models.TurnoRequest) (*models.Turno, error)
+	EliminarTurno(ctx context.Context, id int) error
+	GetTurnos(ctx context.Context, filter *models.TurnoFilter) ([]*models.Turno, error)
+
+	CrearExcepcion(ctx context.Context, req *models.ExcepcionTurnoRequest) (*models.ExcepcionTurno, error)
+	GetExcepciones(ctx context.Context, filter *models.ExcepcionTurnoFilter) ([]*models.ExcepcionTurno, error)
+	RevocarExcepcion(ctx context.Context, id int) error
+
+	// VerificarTurno indica si el usuario puede operar en el local (nil: local no informado) en el instante
+	VerificarTurno(ctx context.Context, idUsuario int, rol string, idLocal *int, instante time.Time) (*models.VerificacionTurno, error)
+}
+
+// turnoService implementa TurnoService
+type turnoService struct {
+	repo      repository.TurnoRepository
+	userRepo  repository.UserRepository
+	stockRepo repository.StockRepository
+	config    config.ShiftsConfig
+	roles     map[string]bool
+	logger    *zap.Logger
+}
+
+// NewTurnoService crea una nueva instancia del servicio
+func NewTurnoService(repo repository.TurnoRepository, userRepo repository.UserRepository, stockRepo repository.StockRepository, cfg config.ShiftsConfig, logger *zap.Logger) TurnoService {
+	roles := make(map[string]bool, len(cfg.Roles))
+	for _, rol := range cfg.Roles {
+		roles[rol] = true
+	}
+	return &turnoService{
+		repo:      repo,
+		userRepo:  userRepo,
+		stockRepo: stockRepo,
+		config:    cfg,
+		roles:     roles,
+		logger:    logger,
+	}
+}
+
+// CrearTurno define un turno del usuario
+func (s *turnoService) CrearTurno(ctx context.Context, req *models.TurnoRequest) (*models.Turno, error) {
+	turno, err := s.nuevoTurno(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateTurno(ctx, turno); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Turno creado",
+		zap.String("operation", "crear_turno"),
+		zap.Int("id_turno", turno.ID),
+		zap.Int("id_usuario", turno.IDUsuario),
+		zap.Int("dia_semana", turno.DiaSemana),
+		zap.String("hora_inicio", turno.HoraInicio),
+		zap.String("hora_fin", turno.HoraFin))
+
+	return turno, nil
+}
+
+// ActualizarTurno reemplaza el turno
+func (s *turnoService) ActualizarTurno(ctx context.Context, id int, req *models.TurnoRequest) (*models.Turno, error) {
+	turno, err := s.nuevoTurno(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	turno.ID = id
+
+	actualizado, err := s.repo.UpdateTurno(ctx, turno)
+	if err != nil {
+		return nil, err
+	}
+	if !actualizado {
+		return nil, fmt.Errorf("%w: %d", ErrTurnoNoEncontrado, id)
+	}
+	return turno, nil
+}
+
+// EliminarTurno elimina el turno
+func (s *turnoService) EliminarTurno(ctx context.Context, id int) error {
+	eliminado, err := s.repo.DeleteTurno(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !eliminado {
+		return fmt.Errorf("%w: %d", ErrTurnoNoEncontrado, id)
+	}
+	return nil
+}
+
+// GetTurnos lista los turnos según el filtro
+func (s *turnoService) GetTurnos(ctx context.Context, filter *models.TurnoFilter) ([]*models.Turno, error) {
+	return s.repo.GetTurnos(ctx, filter)
+}
+
+// nuevoTurno arma el turno desde el request validando usuario, local y horas
+func (s *turnoService) nuevoTurno(ctx context.Context, req *models.TurnoRequest) (*models.Turno, error) {
+	inicio, err := parseHoraTurno(req.HoraInicio)
+	if err != nil {
+		return nil, err
+	}
+	fin, err := parseHoraTurno(req.HoraFin)
+	if err != nil {
+		return nil, err
+	}
+	if inicio == fin {
+		return nil, fmt.Errorf("%w: hora_inicio y hora_fin no pueden ser iguales", ErrTurnoInvalido)
+	}
+	if req.DiaSemana == nil || *req.DiaSemana < 0 || *req.DiaSemana > 6 {
+		return nil, fmt.Errorf("%w: dia_semana debe estar entre 0 (domingo) y 6 (sábado)", ErrTurnoInvalido)
+	}
+
+	if err := s.verificarUsuario(ctx, req.IDUsuario); err != nil {
+		return nil, err
+	}
+	if req.IDLocal != nil {
+		if err := s.verificarLocal(ctx, *req.IDLocal); err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.Turno{
+		IDUsuario:  req.IDUsuario,
+		IDLocal:    req.IDLocal,
+		DiaSemana:  *req.DiaSemana,
+		HoraInicio: formatHoraTurno(inicio),
+		HoraFin:    formatHoraTurno(fin),
+		Activo:     req.Activo == nil || *req.Activo,
+	}, nil
+}
+
+// CrearExcepcion autoriza al usuario a operar fuera de su turno en el rango indicado
+func (s *turnoService) CrearExcepcion(ctx context.Context, req *models.ExcepcionTurnoRequest) (*models.ExcepcionTurno, error) {
+	now := time.Now()
+	desde := now
+	if req.Desde != nil {
+		desde = *req.Desde
+	}
+	if !req.Hasta.After(desde) {
+		return nil, fmt.Errorf("%w: hasta debe ser posterior a desde", ErrExcepcionTurnoInvalida)
+	}
+	if !req.Hasta.After(now) {
+		return nil, fmt.Errorf("%w: hasta ya pasó", ErrExcepcionTurnoInvalida)
+	}
+	maximo := time.Duration(s.config.MaxExceptionHours) * time.Hour
+	if req.Hasta.Sub(desde) > maximo {
+		return nil, fmt.Errorf("%w: la excepción no puede durar más de %d horas", ErrExcepcionTurnoInvalida, s.config.MaxExceptionHours)
+	}
+	motivo := strings.TrimSpace(req.Motivo)
+	if motivo == "" {
+		return nil, fmt.Errorf("%w: el motivo es obligatorio", ErrExcepcionTurnoInvalida)
+	}
+
+	if err := s.verificarUsuario(ctx, req.IDUsuario); err != nil {
+		return nil, err
+	}
+	if req.IDLocal != nil {
+		if err := s.verificarLocal(ctx, *req.IDLocal); err != nil {
+			return nil, err
+		}
+	}
+
+	excepcion := &models.ExcepcionTurno{
+		IDUsuario:    req.IDUsuario,
+		IDLocal:      req.IDLocal,
+		Desde:        desde,
+		Hasta:        req.Hasta,
+		Motivo:       motivo,
+		IDSupervisor: req.IDSupervisor,
+	}
+	if err := s.repo.CreateExcepcion(ctx, excepcion); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Excepción de turno autorizada",
+		zap.String("operation", "crear_excepcion_turno"),
+		zap.Int("id_excepcion", excepcion.ID),
+		zap.Int("id_usuario", excepcion.IDUsuario),
+		zap.Int("id_supervisor", excepcion.IDSupervisor),
+		zap.Time("desde", excepcion.Desde),
+		zap.Time("hasta", excepcion.Hasta),
+		zap.String("motivo", excepcion.Motivo))
+
+	return excepcion, nil
+}
+
+// GetExcepciones lista las excepciones según el filtro
+func (s *turnoService) GetExcepciones(ctx context.Context, filter *models.ExcepcionTurnoFilter) ([]*models.ExcepcionTurno, error) {
+	return s.repo.GetExcepciones(ctx, filter)
+}
+
+// RevocarExcepcion termina una excepción antes de su vencimiento
+func (s *turnoService) RevocarExcepcion(ctx context.Context, id int) error {
+	revocada, err := s.repo.RevocarExcepcion(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !revocada {
+		return fmt.Errorf("%w: %d", ErrExcepcionTurnoNoEncontrada, id)
+	}
+
+	s.logger.Info("Excepción de turno revocada",
+		zap.String("operation", "revocar_excepcion_turno"),
+		zap.Int("id_excepcion", id))
+	return nil
+}
+
+// VerificarTurno permite la operación si el rol no está restringido, si el usuario no tiene turnos,
+// si el instante cae en alguno de sus turnos del local (con la tolerancia configurada) o si tiene
+// una excepción vigente; sin local informado vale cualquiera de sus turnos
+func (s *turnoService) VerificarTurno(ctx context.Context, idUsuario int, rol string, idLocal *int, instante time.Time) (*models.VerificacionTurno, error) {
+	if !s.config.Enabled || !s.roles[rol] {
+		return &models.VerificacionTurno{Permitido: true, Resultado: models.TurnoSinRestriccion}, nil
+	}
+
+	turnos, err := s.repo.GetTurnosActivos(ctx, idUsuario)
+	if err != nil {
+		return nil, err
+	}
+	if len(turnos) == 0 {
+		return &models.VerificacionTurno{Permitido: true, Resultado: models.TurnoSinDefinir}, nil
+	}
+
+	aplicables := make([]*models.Turno, 0, len(turnos))
+	for _, turno := range turnos {
+		if idLocal == nil || turno.IDLocal == nil || *turno.IDLocal == *idLocal {
+			aplicables = append(aplicables, turno)
+		}
+	}
+
+	tolerancia := s.config.ToleranceMinutes
+	for _, turno := range aplicables {
+		if turnoCubre(turno, instante, tolerancia) {
+			return &models.VerificacionTurno{Permitido: true, Resultado: models.TurnoEnHorario, Turno: turno}, nil
+		}
+	}
+
+	excepcion, err := s.repo.GetExcepcionVigente(ctx, idUsuario, idLocal, instante)
+	if err != nil {
+		return nil, err
+	}
+	if excepcion != nil {
+		return &models.VerificacionTurno{Permitido: true, Resultado: models.TurnoExcepcion, Excepcion: excepcion}, nil
+	}
+
+	return &models.VerificacionTurno{Permitido: false, Resultado: models.TurnoFueraDeHorario, Turnos: aplicables}, nil
+}
+
+// turnoCubre indica si el instante cae en el turno, ampliado en tolerancia minutos por cada extremo
+// El turno es una franja de la semana: uno que cruza la medianoche del sábado sigue el domingo
+func turnoCubre(turno *models.Turno, instante time.Time, tolerancia int) bool {
+	inicio, err := parseHoraTurno(turno.HoraInicio)
+	if err != nil {
+		return false
+	}
+	fin, err := parseHoraTurno(turno.HoraFin)
+	if err != nil {
+		return false
+	}
+	duracion := fin - inicio
+	if duracion <= 0 {
+		duracion += 24 * 60
+	}
+
+	desde := turno.DiaSemana*24*60 + inicio - tolerancia
+	hasta := turno.DiaSemana*24*60 + inicio + duracion + tolerancia
+	ahora := int(instante.Weekday())*24*60 + instante.Hour()*60 + instante.Minute()
+
+	for _, desplazamiento := range []int{-minutosSemana, 0, minutosSemana} {
+		if ahora >= desde+desplazamiento && ahora < hasta+desplazamiento {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHoraTurno minutos desde la medianoche de una hora HH:MM
+func parseHoraTurno(hora string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(hora))
+	if err != nil {
+		return 0, fmt.Errorf("%w: hora %q (use HH:MM)", ErrTurnoInvalido, hora)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// formatHoraTurno HH:MM de los minutos desde la medianoche
+func formatHoraTurno(minutos int) string {
+	return fmt.Sprintf("%02d:%02d", minutos/60, minutos%60)
+}
+
+// verificarUsuario verifica que el usuario exista
+func (s *turnoService) verificarUsuario(ctx context.Context, idUsuario int) error {
+	usuario, err := s.userRepo.GetUsuarioByID(ctx, idUsuario)
+	if err != nil {
+		return fmt.Errorf("error verificando usuario: %w", err)
+	}
+	if usuario == nil {
+		return fmt.Errorf("%w: %d", ErrUsuarioNoEncontrado, idUsuario)
+	}
+	return nil
+}
+
+// verificarLocal verifica que el local exista y esté activo
+func (s *turnoService) verificarLocal(ctx context.Context, idLocal int) error {
+	local, err := s.stockRepo.GetLocalByID(ctx, idLocal)
+	if err != nil {
+		return fmt.Errorf("error verificando local: %w", err)
+	}
+	if local == nil {
+		return fmt.Errorf("%w: %d", ErrLocalNoEncontrado, idLocal)
+	}
+	if !local.Activo {
+		return fmt.Errorf("%w: %d", ErrLocalInactivo, idLocal)
+	}
+	return nil
+}
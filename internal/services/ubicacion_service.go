@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// UbicacionService mantiene la ubicación física (pasillo, rack, nivel) de los productos por local
+type UbicacionService interface {
+	SetUbicacion(ctx context.Context, req *models.UbicacionProductoRequest) (*models.UbicacionProducto, error)
+	DeleteUbicacion(ctx context.Context, codigoProducto string, idLocal int) error
+	// GetUbicaciones ubicaciones de un local en orden de recorrido
+	GetUbicaciones(ctx context.Context, filter *models.UbicacionFilter) ([]*models.UbicacionProducto, error)
+	GetUbicacionesProducto(ctx context.Context, codigoProducto string) ([]*models.UbicacionProducto, error)
+}
+
+// ubicacionService implementa UbicacionService
+type ubicacionService struct {
+	repo      repository.UbicacionRepository
+	stockRepo repository.StockRepository
+	logger    *zap.Logger
+}
+
+// NewUbicacionService crea una nueva instancia del servicio
+func NewUbicacionService(repo repository.UbicacionRepository, stockRepo repository.StockRepository, logger *zap.Logger) UbicacionService {
+	return &ubicacionService{
+		repo:      repo,
+		stockRepo: stockRepo,
+		logger:    logger,
+	}
+}
+
+// SetUbicacion asigna la ubicación del producto en el local (o la reemplaza)
+func (s *ubicacionService) SetUbicacion(ctx context.Context, req *models.UbicacionProductoRequest) (*models.UbicacionProducto, error) {
+	local, err := s.stockRepo.GetLocalByID(ctx, req.IDLocal)
+	if err != nil {
+		return nil, fmt.Errorf("error verificando local: %w", err)
+	}
+	if local == nil {
+		return nil, fmt.Errorf("%w: %d", ErrLocalNoEncontrado, req.IDLocal)
+	}
+
+	producto, err := s.stockRepo.GetProductoByCodigo(ctx, req.CodigoProducto)
+	if err != nil {
+		return nil, fmt.Errorf("error verificando producto: %w", err)
+	}
+	if producto == nil {
+		return nil, fmt.Errorf("%w: %s", ErrProductoNoEncontrado, req.CodigoProducto)
+	}
+
+	pasillo := strings.ToUpper(strings.TrimSpace(req.Pasillo))
+	if pasillo == "" {
+		return nil, fmt.Errorf("%w: el pasillo no puede estar vacío", ErrUbicacionInvalida)
+	}
+
+	ubicacion := &models.UbicacionProducto{
+		CodigoProducto: req.CodigoProducto,
+		NombreProducto: &producto.Nombre,
+		IDLocal:        req.IDLocal,
+		Pasillo:        pasillo,
+		Rack:           strings.ToUpper(strings.TrimSpace(req.Rack)),
+		Nivel:          strings.ToUpper(strings.TrimSpace(req.Nivel)),
+	}
+	if err := s.repo.SetUbicacion(ctx, ubicacion); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Ubicación de producto asignada",
+		zap.String("operation", "set_ubicacion"),
+		zap.String("codigo_producto", ubicacion.CodigoProducto),
+		zap.Int("id_local", ubicacion.IDLocal),
+		zap.String("pasillo", ubicacion.Pasillo),
+		zap.String("rack", ubicacion.Rack),
+		zap.String("nivel", ubicacion.Nivel))
+
+	return ubicacion, nil
+}
+
+// DeleteUbicacion quita la ubicación del producto en el local
+func (s *ubicacionService) DeleteUbicacion(ctx context.Context, codigoProducto string, idLocal int) error {
+	eliminada, err := s.repo.DeleteUbicacion(ctx, codigoProducto, idLocal)
+	if err != nil {
+		return err
+	}
+	if !eliminada {
+		return fmt.Errorf("%w: %s local %d", ErrUbicacionNoEncontrada, codigoProducto, idLocal)
+	}
+	return nil
+}
+
+// GetUbicaciones lista las ubicaciones de un local en orden de recorrido
+func (s *ubicacionService) GetUbicaciones(ctx context.Context, filter *models.UbicacionFilter) ([]*models.UbicacionProducto, error) {
+	if filter.Pasillo != nil {
+		pasillo := strings.ToUpper(strings.TrimSpace(*filter.Pasillo))
+		filter.Pasillo = &pasillo
+	}
+
+	ubicaciones, err := s.repo.GetUbicaciones(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(ubicaciones, func(i, j int) bool {
+		a := &models.UbicacionFisica{Pasillo: ubicaciones[i].Pasillo, Rack: ubicaciones[i].Rack, Nivel: ubicaciones[i].Nivel}
+		b := &models.UbicacionFisica{Pasillo: ubicaciones[j].Pasillo, Rack: ubicaciones[j].Rack, Nivel: ubicaciones[j].Nivel}
+		return compararRecorrido(a, b) < 0
+	})
+	return ubicaciones, nil
+}
+
+// GetUbicacionesProducto lista dónde está el producto en cada local
+func (s *ubicacionService) GetUbicacionesProducto(ctx context.Context, codigoProducto string) ([]*models.UbicacionProducto, error) {
+	return s.repo.GetUbicacionesProducto(ctx, codigoProducto)
+}
+
+// compararRecorrido orden de recorrido entre dos ubicaciones: pasillo, rack y nivel con
+// orden natural ("P2" antes que "P10"); nil (sin ubicación) va al final
+func compararRecorrido(a, b *models.UbicacionFisica) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	if c := compararNatural(a.Pasillo, b.Pasillo); c != 0 {
+		return c
+	}
+	if c := compararNatural(a.Rack, b.Rack); c != 0 {
+		return c
+	}
+	return compararNatural(a.Nivel, b.Nivel)
+}
+
+// compararNatural compara dos códigos tratando los tramos de dígitos como números
+func compararNatural(a, b string) int {
+	for a != "" && b != "" {
+		ta, restoA := tramoNatural(a)
+		tb, restoB := tramoNatural(b)
+
+		esNumA, esNumB := esDigito(ta[0]), esDigito(tb[0])
+		switch {
+		case esNumA && esNumB:
+			na, nb := strings.TrimLeft(ta, "0"), strings.TrimLeft(tb, "0")
+			if len(na) != len(nb) {
+				if len(na) < len(nb) {
+					return -1
+				}
+				return 1
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+		case esNumA != esNumB:
+			// Los números van antes que las letras
+			if esNumA {
+				return -1
+			}
+			return 1
+		default:
+			if c := strings.Compare(ta, tb); c != 0 {
+				return c
+			}
+		}
+		a, b = restoA, restoB
+	}
+	return strings.Compare(a, b)
+}
+
+// tramoNatural separa el primer tramo de dígitos o de no dígitos de s
+func tramoNatural(s string) (tramo, resto string) {
+	numerico := esDigito(s[0])
+	i := 1
+	for i < len(s) && esDigito(s[i]) == numerico {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// esDigito indica si el byte es un dígito ASCII
+func esDigito(c byte) bool {
+	return c >= '0' && c <= '9'
+}
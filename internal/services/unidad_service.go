@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// UnidadService maneja el maestro de unidades y los factores de conversión por producto
+type UnidadService interface {
+	GetUnidades(ctx context.Context) ([]*models.UnidadMedida, error)
+	CrearUnidad(ctx context.Context, req *models.CrearUnidadRequest) (*models.UnidadMedida, error)
+	GetConversiones(ctx context.Context, codigoProducto string) ([]*models.ConversionUnidad, error)
+	SetConversion(ctx context.Context, codigoProducto, unidad string, factor int) (*models.ConversionUnidad, error)
+	EliminarConversion(ctx context.Context, codigoProducto, unidad string) error
+}
+
+// unidadService implementa UnidadService
+type unidadService struct {
+	repo      repository.UnidadRepository
+	stockRepo repository.StockRepository
+	logger    *zap.Logger
+}
+
+// NewUnidadService crea una nueva instancia del servicio
+func NewUnidadService(repo repository.UnidadRepository, stockRepo repository.StockRepository, logger *zap.Logger) UnidadService {
+	return &unidadService{
+		repo:      repo,
+		stockRepo: stockRepo,
+		logger:    logger,
+	}
+}
+
+// GetUnidades obtiene el maestro de unidades
+func (s *unidadService) GetUnidades(ctx context.Context) ([]*models.UnidadMedida, error) {
+	return s.repo.GetUnidades(ctx)
+}
+
+// CrearUnidad agrega una unidad al maestro (el código se normaliza a minúsculas)
+func (s *unidadService) CrearUnidad(ctx context.Context, req *models.CrearUnidadRequest) (*models.UnidadMedida, error) {
+	unidad := &models.UnidadMedida{
+		Codigo: normalizarUnidad(req.Codigo),
+		Nombre: strings.TrimSpace(req.Nombre),
+	}
+
+	existente, err := s.repo.GetUnidad(ctx, unidad.Codigo)
+	if err != nil {
+		return nil, err
+	}
+	if existente != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnidadDuplicada, unidad.Codigo)
+	}
+
+	if err := s.repo.CreateUnidad(ctx, unidad); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Unidad de medida creada",
+		zap.String("operation", "crear_unidad"),
+		zap.String("unidad", unidad.Codigo))
+
+	return unidad, nil
+}
+
+// GetConversiones obtiene las conversiones definidas para un producto o pack
+func (s *unidadService) GetConversiones(ctx context.Context, codigoProducto string) ([]*models.ConversionUnidad, error) {
+	if err := s.verificarProducto(ctx, codigoProducto); err != nil {
+		return nil, err
+	}
+	return s.repo.GetConversiones(ctx, codigoProducto)
+}
+
+// SetConversion define cuántas unidades base equivale una unidad del producto
+func (s *unidadService) SetConversion(ctx context.Context, codigoProducto, unidad string, factor int) (*models.ConversionUnidad, error) {
+	unidad = normalizarUnidad(unidad)
+	if err := s.verificarProducto(ctx, codigoProducto); err != nil {
+		return nil, err
+	}
+
+	existente, err := s.repo.GetUnidad(ctx, unidad)
+	if err != nil {
+		return nil, err
+	}
+	if existente == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnidadNoEncontrada, unidad)
+	}
+
+	conversion := &models.ConversionUnidad{
+		CodigoProducto: codigoProducto,
+		Unidad:         unidad,
+		Factor:         factor,
+	}
+	if err := s.repo.UpsertConversion(ctx, conversion); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Conversión de unidad definida",
+		zap.String("operation", "set_conversion"),
+		zap.String("codigo_producto", codigoProducto),
+		zap.String("unidad", unidad),
+		zap.Int("factor", factor))
+
+	return conversion, nil
+}
+
+// EliminarConversion elimina la conversión de una unidad del producto
+func (s *unidadService) EliminarConversion(ctx context.Context, codigoProducto, unidad string) error {
+	unidad = normalizarUnidad(unidad)
+	eliminada, err := s.repo.DeleteConversion(ctx, codigoProducto, unidad)
+	if err != nil {
+		return err
+	}
+	if !eliminada {
+		return fmt.Errorf("%w: %s en %s", ErrUnidadSinConversion, codigoProducto, unidad)
+	}
+	return nil
+}
+
+// verificarProducto verifica que el código corresponda a un producto o a un pack
+func (s *unidadService) verificarProducto(ctx context.Context, codigo string) error {
+	producto, err := s.stockRepo.GetProductoByCodigo(ctx, codigo)
+	if err != nil {
+		return fmt.Errorf("error verificando producto: %w", err)
+	}
+	if producto != nil {
+		return nil
+	}
+
+	pack, err := s.stockRepo.GetPackByCodigo(ctx, codigo)
+	if err != nil {
+		return fmt.Errorf("error verificando pack: %w", err)
+	}
+	if pack == nil {
+		return fmt.Errorf("%w: %s", ErrProductoNoEncontrado, codigo)
+	}
+	return nil
+}
+
+// normalizarUnidad unifica el código de unidad (el maestro los guarda en minúsculas)
+func normalizarUnidad(unidad string) string {
+	return strings.ToLower(strings.TrimSpace(unidad))
+}
+
+// cantidadEnUnidadBase convierte una cantidad expresada en unidad a la unidad base del producto
+// Sin unidad la cantidad ya está en unidad base
+func cantidadEnUnidadBase(ctx context.Context, repo repository.StockRepository, codigoProducto, unidad string, cantidad int) (int, error) {
+	unidad = normalizarUnidad(unidad)
+	if unidad == "" {
+		return cantidad, nil
+	}
+
+	factor, err := repo.GetFactorConversion(ctx, codigoProducto, unidad)
+	if err != nil {
+		return 0, err
+	}
+	if factor == 0 {
+		return 0, fmt.Errorf("%w: %s en %s", ErrUnidadSinConversion, codigoProducto, unidad)
+	}
+
+	return cantidad * factor, nil
+}
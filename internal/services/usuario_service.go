@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"stock-service/internal/auth"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// UsuarioService maneja el CRUD de usuarios (los usuarios no se borran: se desactivan)
+type UsuarioService interface {
+	GetUsuarios(ctx context.Context) ([]*models.Usuario, error)
+	GetUsuario(ctx context.Context, id int) (*models.Usuario, error)
+	CrearUsuario(ctx context.Context, req *models.CrearUsuarioRequest) (*models.Usuario, error)
+	ActualizarUsuario(ctx context.Context, id int, req *models.ActualizarUsuarioRequest) (*models.Usuario, error)
+	CambiarPassword(ctx context.Context, id int, req *models.CambiarPasswordRequest) error
+}
+
+// usuarioService implementa UsuarioService
+type usuarioService struct {
+	repo   repository.UserRepository
+	logger *zap.Logger
+}
+
+// NewUsuarioService crea una nueva instancia del servicio
+func NewUsuarioService(repo repository.UserRepository, logger *zap.Logger) UsuarioService {
+	return &usuarioService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// GetUsuarios lista todos los usuarios
+func (s *usuarioService) GetUsuarios(ctx context.Context) ([]*models.Usuario, error) {
+	return s.repo.GetUsuarios(ctx)
+}
+
+// GetUsuario obtiene un usuario por id
+func (s *usuarioService) GetUsuario(ctx context.Context, id int) (*models.Usuario, error) {
+	usuario, err := s.repo.GetUsuarioByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if usuario == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUsuarioNoEncontrado, id)
+	}
+	return usuario, nil
+}
+
+// CrearUsuario crea un usuario activo con la contraseña hasheada con bcrypt
+func (s *usuarioService) CrearUsuario(ctx context.Context, req *models.CrearUsuarioRequest) (*models.Usuario, error) {
+	username := strings.TrimSpace(req.Username)
+	existente, err := s.repo.GetUsuarioByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if existente != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUsuarioDuplicado, username)
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("error hasheando contraseña: %w", err)
+	}
+
+	usuario := &models.Usuario{
+		Username:     username,
+		Email:        strings.TrimSpace(req.Email),
+		Rol:          req.Rol,
+		Activo:       true,
+		PasswordHash: hash,
+	}
+	if err := s.repo.CreateUsuario(ctx, usuario); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Usuario creado",
+		zap.String("operation", "crear_usuario"),
+		zap.Int("id_usuario", usuario.ID),
+		zap.String("username", usuario.Username),
+		zap.String("rol", usuario.Rol))
+
+	return usuario, nil
+}
+
+// ActualizarUsuario actualiza email, rol o estado; desactivar revoca sus refresh tokens
+// (el access token vigente expira solo, a más tardar en JWT_EXPIRY_HOURS)
+func (s *usuarioService) ActualizarUsuario(ctx context.Context, id int, req *models.ActualizarUsuarioRequest) (*models.Usuario, error) {
+	usuario, err := s.GetUsuario(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Email != nil {
+		usuario.Email = strings.TrimSpace(*req.Email)
+	}
+	if req.Rol != nil {
+		usuario.Rol = *req.Rol
+	}
+	if req.Activo != nil {
+		usuario.Activo = *req.Activo
+	}
+
+	if err := s.repo.UpdateUsuario(ctx, usuario); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %d", ErrUsuarioNoEncontrado, id)
+		}
+		return nil, err
+	}
+
+	if !usuario.Activo {
+		if err := s.repo.RevocarRefreshTokens(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.Info("Usuario actualizado",
+		zap.String("operation", "actualizar_usuario"),
+		zap.Int("id_usuario", usuario.ID),
+		zap.String("rol", usuario.Rol),
+		zap.Bool("activo", usuario.Activo))
+
+	return usuario, nil
+}
+
+// CambiarPassword fija una nueva contraseña y cierra las sesiones abiertas del usuario
+func (s *usuarioService) CambiarPassword(ctx context.Context, id int, req *models.CambiarPasswordRequest) error {
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return fmt.Errorf("error hasheando contraseña: %w", err)
+	}
+
+	if err := s.repo.UpdatePassword(ctx, id, hash); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: %d", ErrUsuarioNoEncontrado, id)
+		}
+		return err
+	}
+
+	s.logger.Info("Contraseña cambiada",
+		zap.String("operation", "cambiar_password"),
+		zap.Int("id_usuario", id))
+
+	return nil
+}
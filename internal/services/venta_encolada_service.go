@@ -0,0 +1,426 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"stock-service/internal/cache"
+	"stock-service/internal/config"
+	"stock-service/internal/degraded"
+	"stock-service/internal/models"
+	"stock-service/internal/uuid"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// ventasEncoladasKey lista de Redis con las ventas pendientes (se encola por la izquierda
+	// y se toma por la derecha: la más antigua primero)
+	ventasEncoladasKey = "pos:ventas_encoladas"
+	// ventasProcesandoKey venta tomada por la reconciliación en curso; si la réplica cae
+	// la próxima reconciliación la devuelve a la cola
+	ventasProcesandoKey = "pos:ventas_encoladas:procesando"
+	// ventasConErroresKey ventas reconciliadas que no pudieron aplicarse completas
+	ventasConErroresKey = "pos:ventas_encoladas:con_errores"
+	// maxVentasConErrores ventas con errores que se conservan para revisión
+	maxVentasConErrores = 200
+	// lockReconciliacion una sola réplica reconcilia a la vez
+	lockReconciliacion = "pos:ventas_encoladas:reconciliacion"
+	// maxDuracionReconciliacion vida máxima del lock de reconciliación
+	maxDuracionReconciliacion = 10 * time.Minute
+)
+
+// VentaEncoladaService encola las ventas rápidas recibidas en modo degradado y las aplica
+// cuando PostgreSQL vuelve a estar disponible
+type VentaEncoladaService interface {
+	Encolar(ctx context.Context, venta *models.VentaEncolada) error
+	Reconciliar(ctx context.Context) (*models.ResultadoReconciliacionVentas, error)
+	GetEstado(ctx context.Context) (*models.EstadoVentasEncoladas, error)
+	Pendientes(ctx context.Context) (int64, error)
+	StartReconciliationWorker(ctx context.Context)
+}
+
+// ventaEncoladaService implementa VentaEncoladaService
+type ventaEncoladaService struct {
+	redisClient   *redis.Client
+	stockService  StockService
+	precioService PrecioService
+	ventaService  VentaService
+	monitor       *degraded.Monitor
+	locker        *cache.KeyLocker
+	config        config.DegradedConfig
+	logger        *zap.Logger
+}
+
+// NewVentaEncoladaService crea una nueva instancia del servicio
+func NewVentaEncoladaService(redisClient *redis.Client, stockService StockService, precioService PrecioService, ventaService VentaService, monitor *degraded.Monitor, cfg config.DegradedConfig, logger *zap.Logger) VentaEncoladaService {
+	return &ventaEncoladaService{
+		redisClient:   redisClient,
+		stockService:  stockService,
+		precioService: precioService,
+		ventaService:  ventaService,
+		monitor:       monitor,
+		locker:        cache.NewKeyLocker(redisClient, maxDuracionReconciliacion, 0, logger),
+		config:        cfg,
+		logger:        logger,
+	}
+}
+
+// Encolar registra la venta en la cola de Redis; su ID será el id de operación de los movimientos
+func (s *ventaEncoladaService) Encolar(ctx context.Context, venta *models.VentaEncolada) error {
+	pendientes, err := s.Pendientes(ctx)
+	if err != nil {
+		return err
+	}
+	if pendientes >= int64(s.config.MaxQueuedSales) {
+		return fmt.Errorf("%w: %d ventas pendientes", ErrColaVentasLlena, pendientes)
+	}
+
+	venta.ID = uuid.New()
+	venta.EncoladaAt = time.Now()
+	data, err := json.Marshal(venta)
+	if err != nil {
+		return fmt.Errorf("error serializando venta: %w", err)
+	}
+	if err := s.redisClient.LPush(ctx, ventasEncoladasKey, data).Err(); err != nil {
+		return fmt.Errorf("error encolando venta: %w", err)
+	}
+
+	s.logger.Warn("Venta rápida encolada en modo degradado",
+		zap.String("operation", "encolar_venta"),
+		zap.String("id_venta", venta.ID),
+		zap.Int("id_local", venta.Venta.IDLocal),
+		zap.Int("cantidad_items", len(venta.Venta.Items)),
+		zap.Int64("pendientes", pendientes+1))
+
+	return nil
+}
+
+// Pendientes cantidad de ventas por aplicar (incluida la que se esté procesando)
+func (s *ventaEncoladaService) Pendientes(ctx context.Context) (int64, error) {
+	pipe := s.redisClient.Pipeline()
+	encoladas := pipe.LLen(ctx, ventasEncoladasKey)
+	procesando := pipe.LLen(ctx, ventasProcesandoKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("error consultando ventas encoladas: %w", err)
+	}
+	return encoladas.Val() + procesando.Val(), nil
+}
+
+// Reconciliar aplica las ventas encoladas, de la más antigua a la más reciente
+// Se detiene si la BD vuelve a caer; las ventas que no se aplicaron completas quedan para revisión
+func (s *ventaEncoladaService) Reconciliar(ctx context.Context) (*models.ResultadoReconciliacionVentas, error) {
+	if s.monitor.Active() {
+		return nil, ErrBaseDatosNoDisponible
+	}
+
+	release, err := s.locker.Lock(ctx, lockReconciliacion)
+	if err != nil {
+		if errors.Is(err, cache.ErrLockTimeout) {
+			return nil, ErrReconciliacionEnCurso
+		}
+		return nil, err
+	}
+	defer release()
+
+	logger := s.logger.With(zap.String("operation", "reconciliar_ventas_encoladas"))
+
+	// Ventas que tomó una reconciliación interrumpida: vuelven a la cola como las más antiguas
+	if err := s.recuperarProcesando(ctx); err != nil {
+		return nil, err
+	}
+
+	// Una venta ya iniciada se termina de aplicar aunque se cancele ctx (el corte es entre ventas)
+	aplicarCtx := context.WithoutCancel(ctx)
+
+	resultado := &models.ResultadoReconciliacionVentas{}
+	for ctx.Err() == nil && !s.monitor.Active() {
+		data, err := s.redisClient.RPopLPush(ctx, ventasEncoladasKey, ventasProcesandoKey).Result()
+		if err == redis.Nil {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error tomando venta encolada: %w", err)
+		}
+
+		var venta models.VentaEncolada
+		if err := json.Unmarshal([]byte(data), &venta); err != nil {
+			logger.Error("Venta encolada ilegible, se descarta", zap.String("data", data), zap.Error(err))
+			s.redisClient.LRem(ctx, ventasProcesandoKey, 1, data)
+			continue
+		}
+
+		conErrores, yaAplicada, err := s.aplicar(aplicarCtx, &venta)
+		if err != nil {
+			// Falla de infraestructura: la venta vuelve a la cola para la próxima ronda
+			logger.Error("Error aplicando venta encolada, se reintentará",
+				zap.String("id_venta", venta.ID),
+				zap.Error(err))
+			s.redisClient.RPush(ctx, ventasEncoladasKey, data)
+			s.redisClient.LRem(ctx, ventasProcesandoKey, 1, data)
+			break
+		}
+
+		switch {
+		case yaAplicada:
+			resultado.YaAplicadas++
+		case conErrores != nil:
+			resultado.ConErrores++
+			if errData, err := json.Marshal(conErrores); err == nil {
+				pipe := s.redisClient.TxPipeline()
+				pipe.LPush(ctx, ventasConErroresKey, errData)
+				pipe.LTrim(ctx, ventasConErroresKey, 0, maxVentasConErrores-1)
+				pipe.Exec(ctx)
+			}
+		default:
+			resultado.Aplicadas++
+		}
+		s.redisClient.LRem(ctx, ventasProcesandoKey, 1, data)
+	}
+
+	resultado.Pendientes, _ = s.Pendientes(ctx)
+
+	logFn := logger.Info
+	if resultado.ConErrores > 0 {
+		logFn = logger.Warn
+	}
+	logFn("Reconciliación de ventas encoladas completada",
+		zap.Int("aplicadas", resultado.Aplicadas),
+		zap.Int("con_errores", resultado.ConErrores),
+		zap.Int("ya_aplicadas", resultado.YaAplicadas),
+		zap.Int64("pendientes", resultado.Pendientes))
+
+	return resultado, nil
+}
+
+// recuperarProcesando devuelve a la cola las ventas que quedaron tomadas por una reconciliación
+// interrumpida; pasan al final de la cola (se toman primero) conservando su orden
+func (s *ventaEncoladaService) recuperarProcesando(ctx context.Context) error {
+	items, err := s.redisClient.LRange(ctx, ventasProcesandoKey, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("error recuperando ventas en proceso: %w", err)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	for _, data := range items {
+		pipe.RPush(ctx, ventasEncoladasKey, data)
+	}
+	pipe.Del(ctx, ventasProcesandoKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("error recuperando ventas en proceso: %w", err)
+	}
+
+	s.logger.Warn("Ventas de una reconciliación interrumpida devueltas a la cola",
+		zap.String("operation", "reconciliar_ventas_encoladas"),
+		zap.Int("ventas", len(items)))
+	return nil
+}
+
+// aplicar descuenta el stock de la venta y registra sus precios modificados y líneas contables
+// Los productos que ya tienen movimiento con el id de la venta no se vuelven a descontar
+// Retorna el detalle si algún producto no pudo aplicarse, y yaAplicada si no quedaba nada por aplicar
+func (s *ventaEncoladaService) aplicar(ctx context.Context, venta *models.VentaEncolada) (*models.VentaEncoladaConErrores, bool, error) {
+	req := venta.Venta
+	idOperacion := venta.ID
+	movimientos, err := s.stockService.GetMovimientosByLocal(ctx, &models.MovimientoFilter{
+		IDLocal:     &req.IDLocal,
+		IDOperacion: &idOperacion,
+		Limit:       1000,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	previos := map[string]int{}
+	for _, m := range movimientos {
+		if m.TipoMovimiento == "salida" {
+			previos[m.CodigoProducto]++
+		}
+	}
+
+	// Productos con salida: los ya aplicados en una ronda anterior y los que se aplican ahora
+	vendidos := map[string]bool{}
+	var productos []models.ProductoSalida
+	for _, item := range req.Items {
+		if previos[item.CodigoProducto] > 0 {
+			previos[item.CodigoProducto]--
+			vendidos[item.CodigoProducto] = true
+			continue
+		}
+		productos = append(productos, models.ProductoSalida{
+			CodigoProducto: item.CodigoProducto,
+			TipoItem:       item.TipoItem,
+			Cantidad:       item.Cantidad,
+		})
+	}
+	if len(productos) == 0 {
+		// Se interrumpió tras descontar todo: sus líneas contables pudieron quedar registradas o no
+		s.logger.Warn("Venta encolada ya aplicada, se omite",
+			zap.String("operation", "reconciliar_ventas_encoladas"),
+			zap.String("id_venta", venta.ID))
+		return nil, true, nil
+	}
+
+	response, err := s.stockService.SalidaMultipleStock(ctx, &models.SalidaMultipleStockRequest{
+		Productos:     productos,
+		Motivo:        req.Motivo,
+		IDLocal:       req.IDLocal,
+		Observaciones: strings.TrimSpace(models.ObservacionVentaPOS + " " + req.Observaciones + " (venta encolada " + venta.ID + ")"),
+		IDUsuario:     venta.IDUsuario,
+		IDOperacion:   venta.ID,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	for _, resultado := range response.Resultados {
+		vendidos[resultado.CodigoProducto] = true
+	}
+
+	// Totales y auditoría solo sobre lo efectivamente vendido
+	var subtotal models.SubtotalVenta
+	var overrides []*models.OverridePrecio
+	for codigo := range vendidos {
+		if venta.Exentos[codigo] {
+			subtotal.Exento += venta.MontoPorProducto[codigo]
+		} else {
+			subtotal.Afecto += venta.MontoPorProducto[codigo]
+		}
+	}
+	for _, override := range venta.Overrides {
+		if vendidos[override.CodigoProducto] {
+			overrides = append(overrides, override)
+		}
+	}
+
+	if err := s.precioService.RegistrarOverrides(ctx, overrides); err != nil {
+		s.logger.Error("Error registrando precios modificados de venta encolada",
+			zap.String("id_venta", venta.ID),
+			zap.Error(err))
+	}
+	var preciosLineas []*models.PrecioLineaVenta
+	for _, linea := range venta.PreciosLineas {
+		if vendidos[linea.CodigoProducto] {
+			preciosLineas = append(preciosLineas, linea)
+		}
+	}
+	totales := s.ventaService.CalcularTotales(subtotal, &req)
+
+	// Registrada con el id de operación de la venta encolada: una reconciliación retomada no la duplica
+	registrada := &models.Venta{
+		IDOperacion:   venta.ID,
+		IDLocal:       req.IDLocal,
+		IDUsuario:     venta.IDUsuario,
+		IDAutorizador: req.IDAutorizador,
+		Motivo:        req.Motivo,
+		Observaciones: req.Observaciones,
+		Totales:       *totales,
+		Encolada:      true,
+	}
+	if err := s.ventaService.RegistrarVenta(ctx, registrada, preciosLineas, venta.Exentos); err != nil {
+		s.logger.Error("Error registrando venta encolada",
+			zap.String("id_venta", venta.ID),
+			zap.Error(err))
+	}
+
+	reqPrecios := req
+	reqPrecios.IDUsuario = venta.IDUsuario
+	if err := s.ventaService.RegistrarPreciosLineas(ctx, registrada.ID, venta.ID, &reqPrecios, preciosLineas); err != nil {
+		s.logger.Error("Error registrando precios de líneas de venta encolada",
+			zap.String("id_venta", venta.ID),
+			zap.Error(err))
+	}
+	if err := s.ventaService.RegistrarLineasContables(ctx, registrada.ID, req.IDLocal, venta.IDUsuario, totales); err != nil {
+		s.logger.Error("Error registrando líneas contables de venta encolada",
+			zap.String("id_venta", venta.ID),
+			zap.Error(err))
+	}
+
+	if len(response.Errores) == 0 {
+		return nil, false, nil
+	}
+
+	s.logger.Warn("Venta encolada aplicada con errores, queda para revisión",
+		zap.String("operation", "reconciliar_ventas_encoladas"),
+		zap.String("id_venta", venta.ID),
+		zap.Int("productos_con_error", len(response.Errores)))
+
+	return &models.VentaEncoladaConErrores{
+		Venta:          venta,
+		Errores:        response.Errores,
+		Aplicados:      len(vendidos),
+		ReconciliadaAt: time.Now(),
+	}, false, nil
+}
+
+// GetEstado obtiene las ventas pendientes y las últimas reconciliadas con errores
+func (s *ventaEncoladaService) GetEstado(ctx context.Context) (*models.EstadoVentasEncoladas, error) {
+	pendientes, err := s.Pendientes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	estado := &models.EstadoVentasEncoladas{
+		Pendientes: pendientes,
+		ConErrores: []*models.VentaEncoladaConErrores{},
+	}
+
+	if data, err := s.redisClient.LIndex(ctx, ventasEncoladasKey, -1).Result(); err == nil {
+		var venta models.VentaEncolada
+		if json.Unmarshal([]byte(data), &venta) == nil {
+			estado.MasAntiguaSegundos = int64(time.Since(venta.EncoladaAt).Seconds())
+		}
+	}
+
+	items, err := s.redisClient.LRange(ctx, ventasConErroresKey, 0, 19).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error consultando ventas con errores: %w", err)
+	}
+	for _, item := range items {
+		var conErrores models.VentaEncoladaConErrores
+		if json.Unmarshal([]byte(item), &conErrores) == nil {
+			estado.ConErrores = append(estado.ConErrores, &conErrores)
+		}
+	}
+
+	return estado, nil
+}
+
+// StartReconciliationWorker aplica periódicamente las ventas encoladas mientras la BD esté disponible,
+// y de inmediato al salir del modo degradado, hasta que ctx se cancele
+func (s *ventaEncoladaService) StartReconciliationWorker(ctx context.Context) {
+	if !s.config.Enabled {
+		return
+	}
+
+	reconciliar := func() {
+		pendientes, err := s.Pendientes(ctx)
+		if err != nil || pendientes == 0 {
+			return
+		}
+		if _, err := s.Reconciliar(ctx); err != nil &&
+			!errors.Is(err, ErrReconciliacionEnCurso) && !errors.Is(err, ErrBaseDatosNoDisponible) && ctx.Err() == nil {
+			s.logger.Error("Error reconciliando ventas encoladas", zap.Error(err))
+		}
+	}
+	s.monitor.OnRecover(reconciliar)
+
+	go func() {
+		ticker := time.NewTicker(s.config.ReconcileInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reconciliar()
+			}
+		}
+	}()
+}
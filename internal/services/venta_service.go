@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// VentaService calcula los totales de la venta rápida y registra sus líneas contables y precios
+type VentaService interface {
+	CalcularTotales(subtotal models.SubtotalVenta, req *models.QuickSaleRequest) *models.TotalesVenta
+	RegistrarLineasContables(ctx context.Context, ventaRef int64, idLocal, idUsuario int, totales *models.TotalesVenta) error
+	// RegistrarPreciosLineas guarda el precio con que se vendió cada línea de la venta
+	RegistrarPreciosLineas(ctx context.Context, ventaRef int64, idOperacion string, req *models.QuickSaleRequest, lineas []*models.PrecioLineaVenta) error
+	// ExplicarPrecios reconstruye por qué se cobró cada precio de la venta
+	ExplicarPrecios(ctx context.Context, idOperacion string) (*models.ExplicacionPreciosVenta, error)
+
+	// RegistrarVenta persiste la venta con el detalle de sus líneas vendidas; venta.ID queda como
+	// venta_ref de sus líneas contables y precios
+	RegistrarVenta(ctx context.Context, venta *models.Venta, lineas []*models.PrecioLineaVenta, exentos map[string]bool) error
+	GetVenta(ctx context.Context, id int64) (*models.Venta, error)
+	GetVentas(ctx context.Context, filter *models.VentaFilter) (*models.PaginaVentas, error)
+
+	// ConTransaccion servicio que registra la venta en la transacción de su salida de stock
+	ConTransaccion(repo repository.StockRepository) VentaService
+}
+
+const (
+	// ventasLimitePorDefecto tamaño de página de la consulta de ventas si no se indica
+	ventasLimitePorDefecto = 50
+	// ventasLimiteMaximo tamaño máximo de página de la consulta de ventas
+	ventasLimiteMaximo = 500
+)
+
+// ventaService implementa VentaService
+type ventaService struct {
+	repo     repository.VentaRepository
+	config   config.SalesConfig
+	redondeo map[string]config.RoundingRule
+	logger   *zap.Logger
+}
+
+// NewVentaService crea una nueva instancia del servicio
+func NewVentaService(repo repository.VentaRepository, cfg config.SalesConfig, logger *zap.Logger) VentaService {
+	redondeo := make(map[string]config.RoundingRule, len(cfg.RoundingRules))
+	for _, rule := range cfg.RoundingRules {
+		redondeo[rule.MedioPago] = rule
+	}
+
+	return &ventaService{
+		repo:     repo,
+		config:   cfg,
+		redondeo: redondeo,
+		logger:   logger,
+	}
+}
+
+// ConTransaccion copia del servicio con el repository ligado a la transacción de repo
+func (s *ventaService) ConTransaccion(repo repository.StockRepository) VentaService {
+	tx := *s
+	tx.repo = s.repo.ConTransaccion(repo)
+	return &tx
+}
+
+// CalcularTotales arma el desglose del total: propina y cargo por servicio sobre el subtotal,
+// IVA incluido en los montos afectos y redondeo del medio de pago sobre el total final
+func (s *ventaService) CalcularTotales(subtotal models.SubtotalVenta, req *models.QuickSaleRequest) *models.TotalesVenta {
+	medioPago := strings.ToLower(strings.TrimSpace(req.MedioPago))
+	afecto := redondear(subtotal.Afecto)
+	exento := redondear(subtotal.Exento)
+
+	totales := &models.TotalesVenta{
+		MedioPago: medioPago,
+		Subtotal:  afecto + exento,
+		Exento:    exento,
+	}
+
+	if req.Propina {
+		totales.Propina = aplicarPorcentaje(totales.Subtotal, s.config.TipPercent)
+	}
+	if req.CargoServicio {
+		totales.CargoServicio = aplicarPorcentaje(totales.Subtotal, s.config.ServiceChargePercent)
+		if s.config.ServiceChargeTaxable {
+			afecto += totales.CargoServicio
+		} else {
+			totales.Exento += totales.CargoServicio
+		}
+	}
+
+	// Los precios incluyen IVA: se separa el neto de la base afecta
+	totales.Neto = redondear(afecto / (1 + float64(s.config.IVAPercent)/100))
+	totales.IVA = redondear(afecto - totales.Neto)
+
+	totales.Total = redondear(totales.Subtotal + totales.Propina + totales.CargoServicio)
+	if rule, ok := s.redondeo[medioPago]; ok {
+		redondeado := redondearTotal(totales.Total, rule)
+		totales.AjusteRedondeo = redondear(redondeado - totales.Total)
+		totales.Total = redondeado
+	}
+
+	return totales
+}
+
+// RegistrarLineasContables registra los montos de la venta que no son productos
+// (propina, cargo por servicio y ajuste de redondeo; solo los distintos de cero)
+func (s *ventaService) RegistrarLineasContables(ctx context.Context, ventaRef int64, idLocal, idUsuario int, totales *models.TotalesVenta) error {
+	montos := []struct {
+		tipo  string
+		monto float64
+	}{
+		{tipo: models.LineaContablePropina, monto: totales.Propina},
+		{tipo: models.LineaContableCargoServicio, monto: totales.CargoServicio},
+		{tipo: models.LineaContableRedondeo, monto: totales.AjusteRedondeo},
+	}
+
+	var lineas []*models.LineaContableVenta
+	for _, m := range montos {
+		if m.monto == 0 {
+			continue
+		}
+		lineas = append(lineas, &models.LineaContableVenta{
+			VentaRef:  ventaRef,
+			IDLocal:   idLocal,
+			IDUsuario: idUsuario,
+			MedioPago: totales.MedioPago,
+			Tipo:      m.tipo,
+			Monto:     m.monto,
+		})
+	}
+
+	if len(lineas) == 0 {
+		return nil
+	}
+
+	if err := s.repo.CreateLineasContables(ctx, lineas); err != nil {
+		return fmt.Errorf("error registrando líneas contables de la venta: %w", err)
+	}
+
+	s.logger.Debug("Líneas contables de venta registradas",
+		zap.String("operation", "registrar_lineas_contables"),
+		zap.Int64("venta_ref", ventaRef),
+		zap.Int("cantidad_lineas", len(lineas)))
+
+	return nil
+}
+
+// RegistrarPreciosLineas registra los precios de las líneas vendidas de la venta
+func (s *ventaService) RegistrarPreciosLineas(ctx context.Context, ventaRef int64, idOperacion string, req *models.QuickSaleRequest, lineas []*models.PrecioLineaVenta) error {
+	if len(lineas) == 0 {
+		return nil
+	}
+
+	for _, linea := range lineas {
+		linea.VentaRef = ventaRef
+		linea.IDOperacion = idOperacion
+		linea.IDLocal = req.IDLocal
+		linea.IDUsuario = req.IDUsuario
+		if linea.PrecioModificado {
+			linea.IDAutorizador = req.IDAutorizador
+		}
+	}
+
+	if err := s.repo.CreatePreciosLineas(ctx, lineas); err != nil {
+		return fmt.Errorf("error registrando precios de la venta: %w", err)
+	}
+	return nil
+}
+
+// RegistrarVenta registra la venta con una línea de detalle por cada línea vendida
+// Si la venta ya estaba registrada (misma operación, ej. una venta encolada reconciliada de nuevo)
+// no se duplica: venta.ID queda con el de la existente
+func (s *ventaService) RegistrarVenta(ctx context.Context, venta *models.Venta, lineas []*models.PrecioLineaVenta, exentos map[string]bool) error {
+	venta.Detalles = make([]*models.VentaDetalle, 0, len(lineas))
+	for _, linea := range lineas {
+		venta.Detalles = append(venta.Detalles, &models.VentaDetalle{
+			CodigoProducto:   linea.CodigoProducto,
+			TipoItem:         linea.TipoItem,
+			Cantidad:         linea.Cantidad,
+			PrecioUnitario:   linea.PrecioCobrado,
+			PrecioModificado: linea.PrecioModificado,
+			Exento:           exentos[linea.CodigoProducto],
+			Monto:            redondear(linea.PrecioCobrado * float64(linea.Cantidad)),
+		})
+	}
+
+	creada, err := s.repo.CreateVenta(ctx, venta)
+	if err != nil {
+		return fmt.Errorf("error registrando venta: %w", err)
+	}
+	if !creada {
+		s.logger.Warn("Venta ya registrada, no se duplica",
+			zap.String("operation", "registrar_venta"),
+			zap.Int64("id_venta", venta.ID),
+			zap.String("id_operacion", venta.IDOperacion))
+		return nil
+	}
+
+	s.logger.Debug("Venta registrada",
+		zap.String("operation", "registrar_venta"),
+		zap.Int64("id_venta", venta.ID),
+		zap.String("id_operacion", venta.IDOperacion),
+		zap.Int("cantidad_lineas", len(venta.Detalles)))
+
+	return nil
+}
+
+// GetVenta obtiene una venta con su detalle
+func (s *ventaService) GetVenta(ctx context.Context, id int64) (*models.Venta, error) {
+	venta, err := s.repo.GetVenta(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if venta == nil {
+		return nil, fmt.Errorf("%w: %d", ErrVentaNoEncontrada, id)
+	}
+	return venta, nil
+}
+
+// GetVentas página de ventas, las más recientes primero (limit fuera de rango: el por defecto o el máximo)
+func (s *ventaService) GetVentas(ctx context.Context, filter *models.VentaFilter) (*models.PaginaVentas, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = ventasLimitePorDefecto
+	}
+	if filter.Limit > ventasLimiteMaximo {
+		filter.Limit = ventasLimiteMaximo
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	total, err := s.repo.CountVentas(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ventas, err := s.repo.GetVentas(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginaVentas{
+		Ventas: ventas,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+// ExplicarPrecios arma, por línea, el origen del precio cobrado y lo contrasta con el historial
+// de la lista de precios vigente al momento de la venta
+func (s *ventaService) ExplicarPrecios(ctx context.Context, idOperacion string) (*models.ExplicacionPreciosVenta, error) {
+	lineas, err := s.repo.GetPreciosLineas(ctx, idOperacion)
+	if err != nil {
+		return nil, err
+	}
+	if len(lineas) == 0 {
+		return nil, fmt.Errorf("%w: operación %s", ErrVentaNoEncontrada, idOperacion)
+	}
+
+	explicacion := &models.ExplicacionPreciosVenta{
+		IDOperacion: idOperacion,
+		IDLocal:     lineas[0].IDLocal,
+		Fecha:       lineas[0].CreatedAt,
+		Lineas:      lineas,
+	}
+	for _, linea := range lineas {
+		linea.DifiereDeHistorial = linea.OrigenPrecio == models.OrigenPrecioLista &&
+			linea.PrecioHistorial != nil && math.Abs(*linea.PrecioHistorial-*linea.PrecioLista) >= 0.01
+		linea.Explicacion = explicarPrecioLinea(linea)
+		explicacion.TotalCobrado += linea.PrecioCobrado * float64(linea.Cantidad)
+	}
+
+	return explicacion, nil
+}
+
+// explicarPrecioLinea describe de dónde salió el precio cobrado en la línea
+func explicarPrecioLinea(linea *models.ExplicacionPrecioLinea) string {
+	var partes []string
+	switch linea.OrigenPrecio {
+	case models.OrigenPrecioLista:
+		version := "sin versión"
+		if linea.ListaUpdatedAt != nil {
+			version = "versión " + linea.ListaUpdatedAt.Format("2006-01-02 15:04:05")
+		}
+		partes = append(partes, fmt.Sprintf("Precio de lista %.2f (%s)", *linea.PrecioLista, version))
+	case models.OrigenPrecioMaestro:
+		partes = append(partes, fmt.Sprintf("Sin precio de lista, se usó el precio del maestro %.2f", *linea.PrecioMaestro))
+	default:
+		partes = append(partes, "Sin precio de lista ni del maestro")
+	}
+
+	if linea.PrecioModificado {
+		modificado := fmt.Sprintf("modificado por el cajero a %.2f", linea.PrecioCobrado)
+		if linea.MotivoPrecio != nil && *linea.MotivoPrecio != "" {
+			modificado += " (motivo: " + *linea.MotivoPrecio + ")"
+		}
+		if linea.IDAutorizador != nil {
+			modificado += fmt.Sprintf(", autorizado por el usuario %d", *linea.IDAutorizador)
+		}
+		partes = append(partes, modificado)
+	} else {
+		partes = append(partes, fmt.Sprintf("cobrado %.2f", linea.PrecioCobrado))
+	}
+
+	if linea.DifiereDeHistorial {
+		partes = append(partes, fmt.Sprintf("el historial tenía %.2f vigente desde %s: el POS vendió con un precio desactualizado",
+			*linea.PrecioHistorial, linea.VigenteDesde.Format("2006-01-02 15:04:05")))
+	}
+
+	return strings.Join(partes, "; ")
+}
+
+// redondearTotal redondea el monto al múltiplo de la regla según su modo
+func redondearTotal(monto float64, rule config.RoundingRule) float64 {
+	multiplo := float64(rule.Multiple)
+	cociente := monto / multiplo
+
+	switch rule.Mode {
+	case config.RoundingDown:
+		cociente = math.Floor(cociente)
+	case config.RoundingUp:
+		cociente = math.Ceil(cociente)
+	default:
+		// La mitad se redondea hacia abajo (a favor del cliente)
+		cociente = math.Ceil(cociente - 0.5)
+	}
+	if cociente == 0 {
+		// math.Ceil(-0.3) es -0: evita exponer un total "-0"
+		return 0
+	}
+
+	return cociente * multiplo
+}
+
+// aplicarPorcentaje calcula pct% de un monto, redondeado a centavos
+func aplicarPorcentaje(monto float64, pct int) float64 {
+	return redondear(monto * float64(pct) / 100)
+}
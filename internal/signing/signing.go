@@ -0,0 +1,225 @@
+// Package signing firma las respuestas para los integradores que lo exigen
+// Las claves viven en la BD para que todas las réplicas firmen con la misma clave activa
+package signing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"stock-service/internal/config"
+	"stock-service/internal/models"
+	"stock-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// ErrClaveNoEncontrada la clave no existe o ya estaba revocada
+var ErrClaveNoEncontrada = errors.New("clave de firma no encontrada")
+
+// Firma firma de un cuerpo de respuesta
+type Firma struct {
+	KID       string
+	Algoritmo string
+	Timestamp string
+	Valor     string // base64
+}
+
+// Signer firma con la clave activa, con caché local de las claves
+// Cada réplica relee la BD a lo más una vez por CheckInterval, así el middleware
+// no agrega una consulta en cada request
+type Signer struct {
+	repo   repository.ClaveFirmaRepository
+	config config.ResponseSigningConfig
+	logger *zap.Logger
+
+	mu        sync.RWMutex
+	claves    []*models.ClaveFirma
+	checkedAt time.Time
+}
+
+// New crea el firmador (sin claves hasta leer la BD)
+func New(repo repository.ClaveFirmaRepository, cfg config.ResponseSigningConfig, logger *zap.Logger) *Signer {
+	return &Signer{
+		repo:   repo,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Enabled indica si las respuestas se firman
+func (s *Signer) Enabled() bool {
+	return s.config.Enabled
+}
+
+// PathPrefixes prefijos de ruta cuyas respuestas se firman
+func (s *Signer) PathPrefixes() []string {
+	return s.config.PathPrefixes
+}
+
+// Firmar firma "timestamp.cuerpo" con la clave activa; retorna nil si no hay clave activa
+func (s *Signer) Firmar(ctx context.Context, body []byte) *Firma {
+	activa := s.activa(ctx)
+	if activa == nil {
+		return nil
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mensaje := make([]byte, 0, len(timestamp)+1+len(body))
+	mensaje = append(mensaje, timestamp...)
+	mensaje = append(mensaje, '.')
+	mensaje = append(mensaje, body...)
+
+	var firma []byte
+	switch activa.Algoritmo {
+	case models.FirmaEd25519:
+		firma = ed25519.Sign(ed25519.NewKeyFromSeed(activa.Secreto), mensaje)
+	default:
+		mac := hmac.New(sha256.New, activa.Secreto)
+		mac.Write(mensaje)
+		firma = mac.Sum(nil)
+	}
+
+	return &Firma{
+		KID:       activa.KID,
+		Algoritmo: activa.Algoritmo,
+		Timestamp: timestamp,
+		Valor:     base64.StdEncoding.EncodeToString(firma),
+	}
+}
+
+// Rotar genera una nueva clave activa; la anterior queda retirada
+// El secreto HMAC se retorna solo aquí (los integradores lo necesitan para verificar)
+func (s *Signer) Rotar(ctx context.Context, algoritmo string) (*models.ClaveFirmaRotada, error) {
+	kid, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	clave := &models.ClaveFirma{KID: kid, Algoritmo: algoritmo}
+	rotada := &models.ClaveFirmaRotada{ClaveFirma: clave}
+
+	switch algoritmo {
+	case models.FirmaEd25519:
+		publica, privada, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("error generando clave ed25519: %w", err)
+		}
+		clave.Secreto = privada.Seed()
+		clave.ClavePublica = publica
+	default:
+		clave.Secreto = make([]byte, 32)
+		if _, err := rand.Read(clave.Secreto); err != nil {
+			return nil, fmt.Errorf("error generando clave hmac: %w", err)
+		}
+		rotada.SecretoHMAC = base64.StdEncoding.EncodeToString(clave.Secreto)
+	}
+
+	if err := s.repo.Rotar(ctx, clave); err != nil {
+		return nil, err
+	}
+	s.invalidar()
+
+	s.logger.Info("Clave de firma de respuestas rotada",
+		zap.String("operation", "rotar_clave_firma"),
+		zap.String("kid", clave.KID),
+		zap.String("algoritmo", clave.Algoritmo))
+
+	return rotada, nil
+}
+
+// Revocar revoca una clave; si era la activa, las respuestas dejan de firmarse hasta rotar
+func (s *Signer) Revocar(ctx context.Context, kid string) error {
+	revocada, err := s.repo.Revocar(ctx, kid)
+	if err != nil {
+		return err
+	}
+	if !revocada {
+		return fmt.Errorf("%w: %s", ErrClaveNoEncontrada, kid)
+	}
+	s.invalidar()
+
+	s.logger.Warn("Clave de firma de respuestas revocada",
+		zap.String("operation", "revocar_clave_firma"),
+		zap.String("kid", kid))
+	return nil
+}
+
+// Claves lista las claves no revocadas (sin secretos)
+func (s *Signer) Claves(ctx context.Context) ([]*models.ClaveFirma, error) {
+	return s.repo.GetClaves(ctx)
+}
+
+// ClavesPublicas lista las claves con que un integrador puede verificar: la activa y las
+// retiradas dentro de RetiredKeyHours (de las HMAC solo el kid; el secreto se entregó al rotar)
+func (s *Signer) ClavesPublicas(ctx context.Context) ([]*models.ClaveFirma, error) {
+	claves, err := s.repo.GetClaves(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limite := time.Now().Add(-time.Duration(s.config.RetiredKeyHours) * time.Hour)
+	publicas := []*models.ClaveFirma{}
+	for _, clave := range claves {
+		if clave.Estado == models.ClaveFirmaRetirada && clave.RetiradaAt != nil && clave.RetiradaAt.Before(limite) {
+			continue
+		}
+		publicas = append(publicas, clave)
+	}
+	return publicas, nil
+}
+
+// activa retorna la clave activa, releyendo la BD si la copia local expiró
+// Si la BD no responde se mantienen las últimas claves conocidas
+func (s *Signer) activa(ctx context.Context) *models.ClaveFirma {
+	s.mu.RLock()
+	claves, fresh := s.claves, !s.checkedAt.IsZero() && time.Since(s.checkedAt) < s.config.CheckInterval
+	s.mu.RUnlock()
+
+	if !fresh {
+		s.mu.Lock()
+		// Otra goroutine pudo refrescar mientras se esperaba el lock
+		if s.checkedAt.IsZero() || time.Since(s.checkedAt) >= s.config.CheckInterval {
+			s.checkedAt = time.Now()
+			loaded, err := s.repo.GetClaves(ctx)
+			if err != nil {
+				s.logger.Warn("Error leyendo claves de firma, se mantienen las últimas conocidas", zap.Error(err))
+			} else {
+				s.claves = loaded
+			}
+		}
+		claves = s.claves
+		s.mu.Unlock()
+	}
+
+	for _, clave := range claves {
+		if clave.Estado == models.ClaveFirmaActiva {
+			return clave
+		}
+	}
+	return nil
+}
+
+// invalidar fuerza a releer las claves en la próxima firma de esta réplica
+func (s *Signer) invalidar() {
+	s.mu.Lock()
+	s.checkedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// randomHex genera n bytes aleatorios en hexadecimal
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generando identificador de clave: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// bucketStorage guarda los objetos en un bucket accesible por HTTP (PUT/GET/DELETE sobre
+// <baseURL>/<key>), como MinIO o GCS con token bearer
+type bucketStorage struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewBucketStorage crea el almacenamiento en bucket
+func NewBucketStorage(baseURL, token string) ImageStorage {
+	return &bucketStorage{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Save sube el objeto
+func (s *bucketStorage) Save(ctx context.Context, key string, r io.Reader, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to upload %s: bucket respondió %d", key, resp.StatusCode)
+	}
+	return nil
+}
+
+// Open descarga el objeto
+func (s *bucketStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: bucket respondió %d", key, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// Delete elimina el objeto (no falla si ya no existe)
+func (s *bucketStorage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete %s: bucket respondió %d", key, resp.StatusCode)
+	}
+	return nil
+}
+
+// newRequest arma la petición al objeto con la autenticación del bucket
+func (s *bucketStorage) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+"/"+strings.Join(segments, "/"), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build bucket request: %w", err)
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return req, nil
+}
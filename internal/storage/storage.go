@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"stock-service/internal/config"
+)
+
+// ErrObjectNotFound indica que el objeto no existe en el almacenamiento
+var ErrObjectNotFound = errors.New("objeto no encontrado")
+
+// ImageStorage almacenamiento de objetos binarios (imágenes de productos, archivos de los jobs)
+// Las claves son rutas relativas separadas por "/" (ej: productos/ABC123/<hash>.jpg)
+type ImageStorage interface {
+	Save(ctx context.Context, key string, r io.Reader, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New crea el almacenamiento configurado
+func New(cfg config.ImagesConfig) (ImageStorage, error) {
+	return nuevo(cfg.Storage, cfg.Dir, cfg.BucketURL, cfg.BucketToken, "IMAGES")
+}
+
+// NewJobs crea el almacenamiento de los archivos que generan los jobs asíncronos
+func NewJobs(cfg config.JobsConfig) (ImageStorage, error) {
+	return nuevo(cfg.Storage, cfg.Dir, cfg.BucketURL, cfg.BucketToken, "JOBS")
+}
+
+// nuevo crea el almacenamiento indicado; prefijo es el de sus variables de entorno (para los errores)
+func nuevo(tipo, dir, bucketURL, bucketToken, prefijo string) (ImageStorage, error) {
+	switch tipo {
+	case "disk", "":
+		return NewDiskStorage(dir)
+	case "bucket":
+		if bucketURL == "" {
+			return nil, fmt.Errorf("%s_BUCKET_URL es requerido para %s_STORAGE=bucket", prefijo, prefijo)
+		}
+		return NewBucketStorage(bucketURL, bucketToken), nil
+	default:
+		return nil, fmt.Errorf("almacenamiento no soportado en %s_STORAGE: %s", prefijo, tipo)
+	}
+}
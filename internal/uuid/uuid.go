@@ -0,0 +1,34 @@
+// Package uuid genera los identificadores del servicio (request_id, id_operacion, ids de cola)
+// Todos son UUID v7: ordenables por tiempo, así los índices y los logs quedan en orden de creación
+package uuid
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// New genera un UUID v7 (48 bits de timestamp en milisegundos y el resto aleatorio) con crypto/rand
+// Si crypto/rand falla entra en pánico: nunca se entrega un id que no sea un UUID ni uno predecible
+// (los ids se guardan en columnas UUID y algunos sirven como clave de idempotencia)
+func New() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("uuid: crypto/rand falló: %v", err))
+	}
+
+	// 48 bits de timestamp Unix en milisegundos
+	ms := uint64(time.Now().UnixMilli())
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+
+	// Versión 7 y variante RFC 4122
+	b[6] = (b[6] & 0x0f) | 0x70
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
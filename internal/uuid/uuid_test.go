@@ -0,0 +1,28 @@
+package uuid
+
+import (
+	"regexp"
+	"testing"
+)
+
+var formatoV7 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNew(t *testing.T) {
+	vistos := map[string]bool{}
+	anterior := ""
+	for i := 0; i < 1000; i++ {
+		id := New()
+		if !formatoV7.MatchString(id) {
+			t.Fatalf("New() = %q, no es un UUID v7", id)
+		}
+		if vistos[id] {
+			t.Fatalf("New() repitió %q", id)
+		}
+		vistos[id] = true
+		// Ordenables por tiempo: el prefijo de milisegundos nunca retrocede
+		if anterior != "" && id[:13] < anterior[:13] {
+			t.Fatalf("New() = %q retrocedió respecto de %q", id, anterior)
+		}
+		anterior = id
+	}
+}